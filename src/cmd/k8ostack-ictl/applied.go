@@ -0,0 +1,41 @@
+package main
+
+import (
+	"k8ostack-ictl/internal/config"
+)
+
+// appliedChanges collects what a run changed on the cluster: the kinds that ran for real, on the nodes they succeeded on
+// History, ownership claims and the applied state are built from it, so kinds that ran dry and failed nodes are left out.
+type appliedChanges struct {
+	bundle *config.ConfigBundle
+}
+
+// newAppliedChanges starts an empty collection for a run of the bundle, keeping its defaults and source
+func newAppliedChanges(bundle *config.ConfigBundle) *appliedChanges {
+	return &appliedChanges{bundle: &config.ConfigBundle{Defaults: bundle.Defaults, Source: bundle.Source, Digest: bundle.Digest}}
+}
+
+// add records that a configuration ran for real, leaving out the nodes it failed on
+// Nodes reached by a role pattern alone stay in, so label roles should be resolved to node names first.
+func (a *appliedChanges) add(cfg config.Config, failedNodes []string) {
+	succeeded, err := config.NewSingleConfigBundle(cfg).ExcludeNodes(failedNodes)
+	if err != nil {
+		// It failed on every node
+		return
+	}
+	if succeeded.Cleanup != nil {
+		a.bundle.Cleanup = succeeded.Cleanup
+	}
+	if succeeded.NodeLabels != nil {
+		a.bundle.NodeLabels = succeeded.NodeLabels
+	}
+	if succeeded.VLANs != nil {
+		a.bundle.VLANs = succeeded.VLANs
+	}
+	if succeeded.Sysctls != nil {
+		a.bundle.Sysctls = succeeded.Sysctls
+	}
+	if succeeded.Storage != nil {
+		a.bundle.Storage = succeeded.Storage
+	}
+}
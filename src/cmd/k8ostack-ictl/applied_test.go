@@ -0,0 +1,58 @@
+// Package main provides unit tests for collecting what a run applied
+// WHY: History, ownership and drift compare against the recorded changes, so changes never made must not be recorded
+package main
+
+import (
+	"testing"
+
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/state"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestAppliedChanges tests that only kinds that ran for real, on the nodes they succeeded on, are collected
+// WHY: A kind that ran dry or a node that failed would otherwise show up as applied in history and drift
+func TestAppliedChanges(t *testing.T) {
+	bundle := &config.ConfigBundle{
+		NodeLabels: &config.NodeLabelConf{Spec: config.NodeLabelSpec{NodeRoles: map[string]config.NodeRole{
+			"compute": {Nodes: []string{"rsb2", "rsb3"}, Labels: map[string]string{"zone": "a"}},
+		}}},
+		VLANs: &config.NodeVLANConf{Spec: config.NodeVLANSpec{VLANs: map[string]config.VLANConfig{
+			"storage": {ID: 100, NodeMapping: config.NodeMapping{"rsb2": "10.0.100.2/24", "rsb3": "10.0.100.3/24"}},
+		}}},
+	}
+
+	t.Run("failed_nodes_left_out", func(t *testing.T) {
+		// Given: Labels failed on rsb3 and VLANs failed on rsb2
+		applied := newAppliedChanges(bundle)
+
+		// When: Collect both kinds
+		applied.add(bundle.NodeLabels, []string{"rsb3"})
+		applied.add(bundle.VLANs, []string{"rsb2"})
+
+		// Then: Each kind keeps the nodes it succeeded on
+		assert.Equal(t, []string{"rsb2"}, applied.bundle.NodeLabels.Spec.NodeRoles["compute"].Nodes)
+		assert.Equal(t, config.NodeMapping{"rsb3": "10.0.100.3/24"}, applied.bundle.VLANs.Spec.VLANs["storage"].NodeMapping)
+	})
+
+	t.Run("kinds_not_added_are_not_applied", func(t *testing.T) {
+		// Given: VLANs ran dry, so only labels are added
+		applied := newAppliedChanges(bundle)
+		applied.add(bundle.NodeLabels, nil)
+
+		// When: Record the apply in the state
+		recorded := state.New()
+		recorded.Record(applied.bundle, operationApply)
+
+		// Then: Only the labels are in the state
+		assert.Equal(t, map[string]map[string]string{"rsb2": {"zone": "a"}, "rsb3": {"zone": "a"}}, recorded.Labels)
+		assert.Empty(t, recorded.VLANs)
+	})
+
+	t.Run("failed_everywhere", func(t *testing.T) {
+		applied := newAppliedChanges(bundle)
+		applied.add(bundle.VLANs, []string{"rsb2", "rsb3"})
+		assert.False(t, applied.bundle.HasVLANs())
+	})
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"k8ostack-ictl/internal/approval"
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/logging"
+	"k8ostack-ictl/internal/plan"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh"
+)
+
+// createApproveCommand creates the command that records a second person's approval of a plan
+func createApproveCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "approve <plan>",
+		Short: "Approve a plan from 'kictl plan --out' so it can be applied to a protected cluster",
+		Long: `Review a plan saved with 'kictl plan --out' and record your approval in its
+approvals file. Contexts listed in spec.approval.protectedContexts of the Defaults
+document only accept 'kictl apply --plan' with an approval from someone other than
+the person who made the plan.
+
+Your identity is $KICTL_IDENTITY, or your login name. When the approvers of the
+policy have public keys, sign with the matching private key using --key.
+
+Examples:
+  kictl approve prod.plan.json
+  kictl approve prod.plan.json --key ~/.ssh/kictl_approval
+  kictl approve prod.plan.json --as alice --approvals /shared/prod.approvals.json`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			approver, _ := cmd.Flags().GetString("as")
+			keyPath, _ := cmd.Flags().GetString("key")
+			approvalsPath, _ := cmd.Flags().GetString("approvals")
+			if approvalsPath == "" {
+				approvalsPath = approval.ApprovalsPath(args[0])
+			}
+
+			artifact, err := approval.Read(args[0])
+			if err != nil {
+				return err
+			}
+			if approver == artifact.Planner {
+				return fmt.Errorf("%s made this plan and cannot approve it: a second person must approve", approver)
+			}
+
+			out := cmd.OutOrStdout()
+			digest := artifact.Digest()
+			fmt.Fprintf(out, "📋 Plan %s\n", digest)
+			fmt.Fprintf(out, "  Made by %s at %s for context '%s'\n", artifact.Planner, artifact.CreatedAt.Format(time.RFC3339), artifact.Context)
+			fmt.Fprintf(out, "  Configuration %s (%s)\n", artifact.ConfigSource, artifact.ConfigDigest)
+			renderPlan(out, &plan.Plan{Changes: artifact.Changes})
+
+			granted := approval.Approval{Approver: approver, PlanDigest: digest, ApprovedAt: time.Now().UTC().Truncate(time.Second)}
+			var signer ssh.Signer
+			if keyPath != "" {
+				if signer, err = approval.LoadSigner(keyPath); err != nil {
+					return err
+				}
+				if granted.Signature, err = approval.Sign(signer, approver, digest); err != nil {
+					return err
+				}
+			}
+			if err := approval.AddApproval(approvalsPath, granted); err != nil {
+				return err
+			}
+
+			if signer != nil {
+				fmt.Fprintf(out, "\n✅ Approved by %s, signed with %s, in %s\n", approver, ssh.FingerprintSHA256(signer.PublicKey()), approvalsPath)
+			} else {
+				fmt.Fprintf(out, "\n✅ Approved by %s in %s\n", approver, approvalsPath)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().String("as", approval.Identity(), "Identity to approve as (default $"+approval.EnvIdentity+" or the login name)")
+	cmd.Flags().String("key", "", "Unencrypted SSH private key signing the approval")
+	cmd.Flags().String("approvals", "", "Approvals file to add the approval to (default <plan>.approvals.json)")
+	return cmd
+}
+
+// planScope returns the part of the bundle the current run addresses, as recorded in plan artifacts
+func planScope() approval.Scope {
+	return approval.Scope{Targets: targets, Only: onlyKinds, Skip: skipKinds}
+}
+
+// checkPlanApproval enforces the approval policy before an apply
+// An apply to a protected context needs a --plan matching the run that someone other than its planner approved;
+// dry runs change nothing and pass without one. A --plan on an unprotected context is still checked for a match.
+func checkPlanApproval(logger logging.Logger, bundle *config.ConfigBundle) error {
+	policy := bundle.GetDefaults().Spec.Approval
+	if policy == nil && planFile == "" {
+		return nil
+	}
+
+	currentContext, err := clusterTarget().CurrentContext()
+	if err != nil {
+		return fmt.Errorf("failed to determine the cluster context: %w", err)
+	}
+	protected := policy.Protects(currentContext)
+
+	if planFile == "" {
+		if !protected || isBundleDryRun(bundle) {
+			return nil
+		}
+		return fmt.Errorf("context '%s' is protected: apply a plan approved by a second person with --plan (see 'kictl plan --out' and 'kictl approve')", currentContext)
+	}
+
+	if len(nodeFilter) > 0 || nodesFile != "" {
+		return fmt.Errorf("--nodes and --nodes-file cannot be combined with --plan; plan with --target instead")
+	}
+
+	artifact, err := approval.Read(planFile)
+	if err != nil {
+		return err
+	}
+	if err := artifact.Check(bundle.Digest, currentContext, planScope()); err != nil {
+		return fmt.Errorf("plan %s does not match this apply: %w", planFile, err)
+	}
+	if !protected {
+		logger.Info(fmt.Sprintf("📋 Applying plan %s by %s", planFile, artifact.Planner))
+		return nil
+	}
+
+	path := approvalsFile
+	if path == "" {
+		path = approval.ApprovalsPath(planFile)
+	}
+	approvals, err := approval.ReadApprovals(path)
+	if err != nil {
+		return err
+	}
+	granted, err := approval.Verify(policy, artifact, approvals)
+	if err != nil {
+		return fmt.Errorf("context '%s' is protected: %w", currentContext, err)
+	}
+	logger.Info(fmt.Sprintf("🔏 Applying plan %s by %s, approved by %s", planFile, artifact.Planner, granted.Approver))
+	return nil
+}
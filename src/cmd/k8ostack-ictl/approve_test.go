@@ -0,0 +1,136 @@
+// Package main provides unit tests for plan approval
+// WHY: Protected clusters must refuse applies that a second person did not approve
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"k8ostack-ictl/internal/approval"
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/logging"
+	"k8ostack-ictl/internal/plan"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTestPlan saves a plan made by alice for the prod context and returns its path
+func writeTestPlan(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "prod.plan.json")
+	require.NoError(t, approval.Write(path, &approval.Artifact{
+		Version:      approval.ArtifactVersion,
+		ConfigSource: "prod.yaml",
+		ConfigDigest: "sha256:abc",
+		Context:      "prod",
+		Planner:      "alice",
+		CreatedAt:    time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC),
+		Changes:      []plan.Change{{Node: "rsb2", Kind: "label", Key: "zone", Op: plan.OpAdd, NewValue: "a"}},
+	}))
+	return path
+}
+
+// TestApproveCommand tests recording approvals of a plan
+// WHY: The planner must not be able to approve their own plan
+func TestApproveCommand(t *testing.T) {
+	tests := []struct {
+		name          string
+		approver      string
+		expectedError string
+	}{
+		{name: "second_person", approver: "bob"},
+		{name: "planner", approver: "alice", expectedError: "alice made this plan and cannot approve it"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A plan made by alice
+			planPath := writeTestPlan(t)
+
+			// When: Approve it
+			root := createRootCommand()
+			var out bytes.Buffer
+			root.SetOut(&out)
+			root.SetErr(new(bytes.Buffer))
+			root.SetArgs([]string{"approve", planPath, "--as", tt.approver})
+			err := root.Execute()
+
+			// Then: Only a second person's approval is recorded
+			approvals, readErr := approval.ReadApprovals(approval.ApprovalsPath(planPath))
+			require.NoError(t, readErr)
+			if tt.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+				assert.Empty(t, approvals)
+				return
+			}
+			require.NoError(t, err)
+			assert.Contains(t, out.String(), "+ label zone=a")
+			assert.Contains(t, out.String(), "✅ Approved by bob")
+			require.Len(t, approvals, 1)
+			assert.Equal(t, "bob", approvals[0].Approver)
+		})
+	}
+}
+
+// TestCheckPlanApproval tests the approval gate of apply
+// WHY: Applies to protected contexts need a matching, approved plan; other contexts are not held up
+func TestCheckPlanApproval(t *testing.T) {
+	tests := []struct {
+		name          string
+		protected     string
+		withPlan      bool
+		approver      string
+		dryRun        bool
+		expectedError string
+	}{
+		{name: "unprotected_context", protected: "staging"},
+		{name: "protected_without_plan", protected: "prod", expectedError: "context 'prod' is protected"},
+		{name: "protected_dry_run_without_plan", protected: "prod", dryRun: true},
+		{name: "protected_unapproved_plan", protected: "prod", withPlan: true, expectedError: "has no approval"},
+		{name: "protected_self_approved_plan", protected: "prod", withPlan: true, approver: "alice", expectedError: "alice made the plan"},
+		{name: "protected_approved_plan", protected: "prod", withPlan: true, approver: "bob"},
+		{name: "plan_checked_on_unprotected_context", protected: "staging", withPlan: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A bundle protecting one context, applied to prod
+			bundle := &config.ConfigBundle{
+				Defaults: &config.Defaults{Spec: config.DefaultsSpec{
+					Approval: &config.ApprovalPolicy{ProtectedContexts: []string{tt.protected}},
+				}},
+				NodeLabels: &config.NodeLabelConf{},
+				Digest:     "sha256:abc",
+			}
+			bundle.NodeLabels.Tools.Nlabel.DryRun = tt.dryRun
+
+			savedPlan, savedContext := planFile, kubeContext
+			t.Cleanup(func() { planFile, kubeContext = savedPlan, savedContext })
+			planFile, kubeContext = "", "prod"
+			if tt.withPlan {
+				planFile = writeTestPlan(t)
+				if tt.approver != "" {
+					artifact, err := approval.Read(planFile)
+					require.NoError(t, err)
+					require.NoError(t, approval.AddApproval(approval.ApprovalsPath(planFile),
+						approval.Approval{Approver: tt.approver, PlanDigest: artifact.Digest()}))
+				}
+			}
+
+			// When: Check the approval
+			err := checkPlanApproval(logging.NewRecordingLogger(), bundle)
+
+			// Then: Only approved or unprotected applies pass
+			if tt.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
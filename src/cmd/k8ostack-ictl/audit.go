@@ -0,0 +1,71 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+
+	"k8ostack-ictl/internal/approval"
+	"k8ostack-ictl/internal/audit"
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/kubectl"
+	"k8ostack-ictl/internal/logging"
+	"k8ostack-ictl/internal/workspace"
+)
+
+// auditRunner replaces the kubectl of the executor for --audit-log events in tests
+var auditRunner kubectl.Runner
+
+// runAudit is the audit log of the apply or delete in progress, nil outside of one
+// newBundleExecutor records the node changes of every service through it.
+var runAudit *audit.Log
+
+// startAudit opens the --audit-log destination for a run that changes the cluster and returns the function ending it
+// The default destination is the audit file of the default workspace, which --workspace does not move, so one file covers every run.
+func startAudit(logger logging.Logger, bundle *config.ConfigBundle, operation string) (func(), error) {
+	var sink audit.Sink
+	closeSink := func() {}
+	if auditLog == audit.DestinationEvents {
+		// The audit log is not open yet, so the executor writing the events is not audited itself
+		sink = audit.NewEventSink(kubectlRunner(newBundleExecutor(logger, bundle.GetDefaults()), auditRunner))
+	} else {
+		path := auditLog
+		if path == "" {
+			root, err := workspace.DefaultRoot()
+			if err != nil {
+				return nil, err
+			}
+			path = filepath.Join(root, audit.FileName)
+		}
+		file, err := audit.OpenFile(path)
+		if err != nil {
+			return nil, err
+		}
+		sink, closeSink = file, func() { file.Close() }
+	}
+
+	host, _ := os.Hostname()
+	currentContext, err := clusterTarget().CurrentContext()
+	if err != nil {
+		currentContext = kubeContext
+	}
+	runAudit = audit.NewLog(sink, audit.Record{
+		User:         approval.Identity(),
+		Host:         host,
+		Context:      currentContext,
+		Operation:    operation,
+		Config:       configFile,
+		ConfigDigest: bundle.Digest,
+	})
+	return func() {
+		runAudit = nil
+		closeSink()
+	}, nil
+}
+
+// newAuditedExecutor wraps an executor to record its node changes while a run is audited
+func newAuditedExecutor(logger logging.Logger, executor kubectl.DryRunExecutor) kubectl.DryRunExecutor {
+	if runAudit == nil {
+		return executor
+	}
+	return audit.NewExecutor(executor, runAudit, logger)
+}
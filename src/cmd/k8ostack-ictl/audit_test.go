@@ -0,0 +1,143 @@
+// Package main provides unit tests for the audit trail of runs
+// WHY: Every apply or delete that changes nodes must leave a record in the destination --audit-log names
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"k8ostack-ictl/internal/approval"
+	"k8ostack-ictl/internal/audit"
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/labeler"
+	"k8ostack-ictl/internal/logging"
+	"k8ostack-ictl/internal/workspace"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestStartAudit tests recording the node changes of a run
+// WHY: Records name the person and configuration behind a change, and executors outside a run record nothing
+func TestStartAudit(t *testing.T) {
+	tests := []struct {
+		name        string
+		destination string
+	}{
+		{name: "default_file"},
+		{name: "events", destination: audit.DestinationEvents},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A run of bob applying a configuration
+			home := t.TempDir()
+			t.Setenv(workspace.EnvHome, home)
+			t.Setenv(approval.EnvIdentity, "bob")
+			createRootCommand()
+
+			var events []string
+			savedAudit, savedConfig, savedRunner := auditLog, configFile, auditRunner
+			t.Cleanup(func() { auditLog, configFile, auditRunner = savedAudit, savedConfig, savedRunner })
+			auditLog, configFile = tt.destination, "prod.yaml"
+			auditRunner = func(ctx context.Context, stdin []byte, args ...string) (string, error) {
+				events = append(events, string(stdin))
+				return "", nil
+			}
+			stopAudit, err := startAudit(logging.NewRecordingLogger(), &config.ConfigBundle{Digest: "sha256:abc"}, operationApply)
+			require.NoError(t, err)
+
+			// When: A service labels a node
+			inner := labeler.NewMockDryRunExecutor()
+			inner.On("IsDryRun").Return(false)
+			inner.On("LabelNode", context.Background(), "rsb2", "zone=a", true).Return(true, "node/rsb2 labeled", nil)
+			newAuditedExecutor(logging.NewRecordingLogger(), inner).LabelNode(context.Background(), "rsb2", "zone=a", true)
+			stopAudit()
+
+			// Then: The change is recorded with the run's user and configuration
+			var record audit.Record
+			if tt.destination == audit.DestinationEvents {
+				require.Len(t, events, 1)
+				var event struct {
+					Metadata struct {
+						Annotations map[string]string `json:"annotations"`
+					} `json:"metadata"`
+				}
+				require.NoError(t, json.Unmarshal([]byte(events[0]), &event))
+				require.NoError(t, json.Unmarshal([]byte(event.Metadata.Annotations["kictl.icycloud.io/audit"]), &record))
+			} else {
+				data, err := os.ReadFile(filepath.Join(home, audit.FileName))
+				require.NoError(t, err)
+				require.NoError(t, json.Unmarshal([]byte(strings.TrimSpace(string(data))), &record))
+			}
+			assert.Equal(t, "bob", record.User)
+			assert.Equal(t, operationApply, record.Operation)
+			assert.Equal(t, "prod.yaml", record.Config)
+			assert.Equal(t, "sha256:abc", record.ConfigDigest)
+			assert.Equal(t, "label node rsb2 zone=a --overwrite", record.Command)
+
+			// And: Executors created after the run are not audited
+			assert.Same(t, inner, newAuditedExecutor(logging.NewRecordingLogger(), inner))
+		})
+	}
+}
+
+// TestAuditedCommands tests that commands changing the cluster outside a bundle run are audited too
+// WHY: The audit trail must answer who changed a node or the cluster, whichever command made the change
+func TestAuditedCommands(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		operation string
+		expected  string
+	}{
+		{name: "unlabel_prefix", args: []string{"--unlabel-prefix", "legacy.icycloud.io/", "--nodes", "rsb2"}, operation: "cleanup",
+			expected: "unlabel rsb2"},
+		{name: "quarantine_release", args: []string{"quarantine", "release", "rsb2"}, operation: "quarantine release",
+			expected: "kubectl rsb2: label node rsb2 --overwrite kictl.icycloud.io/quarantined-"},
+		{name: "multus_apply", args: []string{"export", "multus", "--apply"}, operation: "export multus",
+			expected: "kubectl : apply -f -"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A kubectl that reports a legacy label on rsb2 and accepts every change
+			home := t.TempDir()
+			t.Setenv(workspace.EnvHome, home)
+			t.Setenv(approval.EnvIdentity, "bob")
+			bin := t.TempDir()
+			script := "#!/bin/sh\ncase \"$*\" in *--show-labels*) printf 'NAME STATUS ROLES AGE VERSION LABELS\\nrsb2 Ready <none> 1d v1.30.0 legacy.icycloud.io/role=compute\\n';; esac\n"
+			require.NoError(t, os.WriteFile(filepath.Join(bin, "kubectl"), []byte(script), 0755))
+			t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+			configPath := filepath.Join(t.TempDir(), "cluster-config.yaml")
+			require.NoError(t, os.WriteFile(configPath, []byte(exportTestConfig), 0644))
+
+			cmd := createRootCommand()
+			cmd.SetOut(new(bytes.Buffer))
+			cmd.SetErr(new(bytes.Buffer))
+			cmd.SetArgs(append(tt.args, "--config", configPath))
+			t.Cleanup(func() { configFile = "" })
+
+			// When: Run the command
+			require.NoError(t, cmd.Execute())
+
+			// Then: Its change is in the audit file, with the user and the command as operation
+			data, err := os.ReadFile(filepath.Join(home, audit.FileName))
+			require.NoError(t, err)
+			var changes []string
+			for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+				var record audit.Record
+				require.NoError(t, json.Unmarshal([]byte(line), &record))
+				assert.Equal(t, "bob", record.User)
+				assert.Equal(t, tt.operation, record.Operation)
+				changes = append(changes, record.Verb+" "+record.Node+": "+record.Command)
+			}
+			assert.True(t, strings.HasPrefix(changes[0], tt.expected), "first change %q", changes[0])
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"k8ostack-ictl/internal/history"
+	"k8ostack-ictl/internal/logging"
+	"k8ostack-ictl/internal/nethealthcheck"
+)
+
+// connectivityMatrix records which endpoints each test execution reached
+func connectivityMatrix(results *nethealthcheck.TestResults) history.Matrix {
+	matrix := history.Matrix{TakenAt: time.Now().UTC(), ConfigFile: configFile}
+	for _, execution := range results.TestExecutions {
+		matrix.Pairs = append(matrix.Pairs, history.Pair{
+			Test:          execution.TestName,
+			SourceNode:    execution.SourceNode,
+			SourceNetwork: execution.SourceNetwork,
+			TargetNode:    execution.TargetNode,
+			TargetNetwork: execution.TargetNetwork,
+			Protocol:      execution.Protocol,
+			Port:          execution.Port,
+			Reachable:     execution.ActualSuccess,
+		})
+	}
+	return matrix
+}
+
+// compareConnectivityBaseline records the connectivity matrix of a test run and returns the pairs broken since the baseline
+// Pairs are compared whatever their tests expect, so a reachable pair that breaks is flagged even in a test expecting failure.
+func compareConnectivityBaseline(logger logging.Logger, store history.Store, results *nethealthcheck.TestResults, recordBaseline bool) ([]history.Pair, error) {
+	matrix := connectivityMatrix(results)
+
+	var baseline *history.Matrix
+	if recordBaseline {
+		matrix.Baseline = true
+	} else {
+		var err error
+		if baseline, err = store.GetBaseline(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := store.SaveMatrix(matrix); err != nil {
+		return nil, err
+	}
+	if recordBaseline {
+		logger.Info(fmt.Sprintf("📌 Recorded the connectivity of %d pairs as the baseline", len(matrix.Pairs)))
+		return nil, nil
+	}
+	if baseline == nil {
+		logger.Debug("No connectivity baseline recorded, use --record-baseline to record one")
+		return nil, nil
+	}
+
+	broken := history.CompareMatrix(*baseline, matrix)
+	for _, pair := range broken {
+		logger.Error(fmt.Sprintf("💔 %s was reachable in the baseline of %s and no longer is",
+			pair, baseline.TakenAt.Local().Format("2006-01-02 15:04:05")))
+	}
+	if len(broken) == 0 {
+		logger.Info(fmt.Sprintf("✅ No connectivity regressions against the baseline of %s", baseline.TakenAt.Local().Format("2006-01-02 15:04:05")))
+	}
+	return broken, nil
+}
@@ -0,0 +1,65 @@
+// Package main provides unit tests for connectivity baseline comparison
+// WHY: Regressions must be flagged from the recorded baseline, not only from what each test expects
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"k8ostack-ictl/internal/history"
+	"k8ostack-ictl/internal/logging"
+	"k8ostack-ictl/internal/nethealthcheck"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCompareConnectivityBaseline tests recording a baseline and comparing later runs against it
+// WHY: A pair that was reachable and breaks is a regression even when its test tolerates failure
+func TestCompareConnectivityBaseline(t *testing.T) {
+	execution := func(test string, expect, actual bool) nethealthcheck.TestExecution {
+		return nethealthcheck.TestExecution{TestName: test, SourceNode: "rsb7", SourceNetwork: "storage",
+			TargetNetwork: "storage", Protocol: "icmp", ExpectSuccess: expect, ActualSuccess: actual}
+	}
+
+	// Given: An empty history store
+	store, err := history.NewFileStore(filepath.Join(t.TempDir(), "history"))
+	require.NoError(t, err)
+	logger := logging.NewRecordingLogger()
+
+	// When: A run is compared before any baseline exists
+	broken, err := compareConnectivityBaseline(logger, store, &nethealthcheck.TestResults{
+		TestExecutions: []nethealthcheck.TestExecution{execution("storage", true, false)},
+	}, false)
+
+	// Then: Nothing is flagged
+	require.NoError(t, err)
+	assert.Empty(t, broken)
+
+	// When: A healthy run is recorded as the baseline
+	broken, err = compareConnectivityBaseline(logger, store, &nethealthcheck.TestResults{
+		TestExecutions: []nethealthcheck.TestExecution{execution("storage", true, true), execution("isolation", false, true)},
+	}, true)
+
+	// Then: It becomes the baseline
+	require.NoError(t, err)
+	assert.Empty(t, broken)
+	baseline, err := store.GetBaseline()
+	require.NoError(t, err)
+	require.NotNil(t, baseline)
+	assert.Len(t, baseline.Pairs, 2)
+
+	// When: A later run loses a pair whose test expects failure
+	broken, err = compareConnectivityBaseline(logger, store, &nethealthcheck.TestResults{
+		TestExecutions: []nethealthcheck.TestExecution{execution("storage", true, true), execution("isolation", false, false)},
+	}, false)
+
+	// Then: The pair is flagged, and the later run does not replace the baseline
+	require.NoError(t, err)
+	require.Len(t, broken, 1)
+	assert.Equal(t, "isolation", broken[0].Test)
+	assert.Len(t, logger.Messages(logging.LevelError), 1)
+	latest, err := store.GetBaseline()
+	require.NoError(t, err)
+	assert.Equal(t, baseline.TakenAt, latest.TakenAt)
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"k8ostack-ictl/internal/bmc"
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/kubectl"
+
+	"github.com/spf13/cobra"
+)
+
+// createBMCCommand creates the command group reaching nodes through their BMC
+func createBMCCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "bmc",
+		Short: "Recover a node through its BMC when the network no longer reaches it",
+		Long: `Reach a node through the BMC configured for it in tools.nvlan.bmc of the
+VLAN configuration, using ipmitool with credentials read from a Secret.
+Apply and delete runs do the same on their own, with the configured action,
+when a node is lost during a change.
+
+Examples:
+  # Read the serial console of a node for a minute
+  kictl bmc console-log node-ctrl-01 --config cluster-config.yaml --duration 1m
+
+  # Power-cycle a node, dropping VLAN interfaces that were not persisted
+  kictl bmc power-cycle node-ctrl-01 --config cluster-config.yaml`,
+	}
+
+	consoleLog := &cobra.Command{
+		Use:   "console-log <node>",
+		Short: "Print the serial console of a node",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			recovery, err := loadBMCRecovery()
+			if err != nil {
+				return err
+			}
+			if duration, _ := cmd.Flags().GetDuration("duration"); duration > 0 {
+				recovery.ConsoleDuration = duration
+			}
+			return runBMCAction(context.Background(), cmd.OutOrStdout(), newBMCController(recovery), config.BMCActionConsoleLog, args[0])
+		},
+	}
+	consoleLog.Flags().Duration("duration", 0, "How long to read the console (default consoleDuration of the config, or 30s)")
+
+	powerCycle := &cobra.Command{
+		Use:   "power-cycle <node>",
+		Short: "Power-cycle a node",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			recovery, err := loadBMCRecovery()
+			if err != nil {
+				return err
+			}
+			return runBMCAction(context.Background(), cmd.OutOrStdout(), newBMCController(recovery), config.BMCActionPowerCycle, args[0])
+		},
+	}
+
+	cmd.AddCommand(consoleLog, powerCycle)
+	return cmd
+}
+
+// loadBMCRecovery reads the BMC recovery section of the VLAN configuration in --config
+func loadBMCRecovery() (*config.BMCRecovery, error) {
+	if configFile == "" {
+		return nil, fmt.Errorf("configuration file is required. Use --config to specify a YAML file")
+	}
+	bundle, err := loadBundle()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+	if !bundle.HasVLANs() || bundle.VLANs.GetTools().Nvlan.BMC == nil {
+		return nil, fmt.Errorf("%s configures no BMCs: add tools.nvlan.bmc to its NodeVLANConf", configFile)
+	}
+	return bundle.VLANs.GetTools().Nvlan.BMC, nil
+}
+
+// newBMCController returns the BMC recovery of a VLAN configuration, or nil when it has none
+func newBMCController(recovery *config.BMCRecovery) *bmc.Controller {
+	if recovery == nil {
+		return nil
+	}
+	return bmc.NewController(*recovery, clusterSecretCredentials, nil)
+}
+
+// clusterSecretCredentials reads BMC credentials from Secrets of the --kubeconfig cluster when they are first needed
+func clusterSecretCredentials(ctx context.Context, ref config.SecretRef) (bmc.Credentials, error) {
+	client, _, err := kubectl.NewClientset(clusterTarget())
+	if err != nil {
+		return bmc.Credentials{}, err
+	}
+	return bmc.SecretCredentials(client)(ctx, ref)
+}
+
+// runBMCAction runs a BMC action on a node and prints its outcome
+func runBMCAction(ctx context.Context, out io.Writer, controller *bmc.Controller, action, nodeName string) error {
+	if !controller.Has(nodeName) {
+		return fmt.Errorf("node %s has no BMC configured", nodeName)
+	}
+
+	if dryRun {
+		fmt.Fprintf(out, "🧪 DRY RUN: Would run %s on node %s through its BMC\n", action, nodeName)
+		return nil
+	}
+
+	if action == config.BMCActionPowerCycle {
+		if err := controller.PowerCycle(ctx, nodeName); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "🔌 Power-cycled node %s through its BMC\n", nodeName)
+		return nil
+	}
+
+	output, err := controller.ConsoleLog(ctx, nodeName)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "📟 Serial console of node %s:\n%s\n", nodeName, output)
+	return nil
+}
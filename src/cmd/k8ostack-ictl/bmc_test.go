@@ -0,0 +1,94 @@
+// Package main provides unit tests for the bmc command
+// WHY: The bmc commands are used on nodes that are already lost, so they must act on the right node or refuse
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"k8ostack-ictl/internal/bmc"
+	"k8ostack-ictl/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRunBMCAction tests running BMC actions from the command line
+// WHY: Dry runs and nodes without a BMC must never reach ipmitool
+func TestRunBMCAction(t *testing.T) {
+	tests := []struct {
+		name          string
+		action        string
+		node          string
+		dryRun        bool
+		expectedCalls []string
+		expectedOut   string
+		expectedError string
+	}{
+		{
+			name:          "power_cycle",
+			action:        config.BMCActionPowerCycle,
+			node:          "node1",
+			expectedCalls: []string{"power cycle"},
+			expectedOut:   "🔌 Power-cycled node node1 through its BMC\n",
+		},
+		{
+			name:          "console_log",
+			action:        config.BMCActionConsoleLog,
+			node:          "node1",
+			expectedCalls: []string{"sol deactivate", "sol activate", "sol deactivate"},
+			expectedOut:   "📟 Serial console of node node1:\nnode1 login:\n",
+		},
+		{
+			name:        "dry_run",
+			action:      config.BMCActionPowerCycle,
+			node:        "node1",
+			dryRun:      true,
+			expectedOut: "🧪 DRY RUN: Would run power-cycle on node node1 through its BMC\n",
+		},
+		{
+			name:          "node_without_bmc",
+			action:        config.BMCActionPowerCycle,
+			node:          "node2",
+			expectedError: "node node2 has no BMC configured",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A BMC for node1
+			originalDryRun := dryRun
+			defer func() { dryRun = originalDryRun }()
+			dryRun = tt.dryRun
+
+			var calls []string
+			recovery := config.BMCRecovery{
+				ConsoleDuration: time.Millisecond,
+				Nodes:           map[string]config.NodeBMC{"node1": {Address: "10.0.9.11", Credentials: config.SecretRef{Name: "node1-bmc"}}},
+			}
+			credentials := func(ctx context.Context, ref config.SecretRef) (bmc.Credentials, error) {
+				return bmc.Credentials{Username: "admin", Password: "s3cret"}, nil
+			}
+			controller := bmc.NewController(recovery, credentials, func(ctx context.Context, env []string, args ...string) (string, error) {
+				calls = append(calls, strings.Join(args[len(args)-2:], " "))
+				return "node1 login:", nil
+			})
+			var out bytes.Buffer
+
+			// When: Run the action
+			err := runBMCAction(context.Background(), &out, controller, tt.action, tt.node)
+
+			// Then: Only nodes with a BMC are acted on, and dry runs only report
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.expectedCalls, calls)
+			assert.Equal(t, tt.expectedOut, out.String())
+		})
+	}
+}
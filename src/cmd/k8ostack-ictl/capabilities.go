@@ -0,0 +1,176 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"runtime/debug"
+	"strings"
+
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/kubectl"
+	"k8ostack-ictl/internal/logging"
+	"k8ostack-ictl/internal/operator"
+	"k8ostack-ictl/internal/segmentation"
+	"k8ostack-ictl/internal/settings"
+
+	"github.com/spf13/cobra"
+)
+
+// Output formats of kictl capabilities
+const (
+	capabilitiesOutputText = "text"
+	capabilitiesOutputJSON = "json"
+)
+
+// capabilities describes what this kictl build supports, for wrapper tooling to adapt to
+type capabilities struct {
+	Version             string              `json:"version"`
+	APIVersions         []string            `json:"apiVersions"` // apiVersions of configuration documents, oldest first
+	Kinds               []capabilityKind    `json:"kinds"`
+	KubeClients         []string            `json:"kubeClients"`
+	NodeExecBackends    []string            `json:"nodeExecBackends"`
+	PersistenceBackends []string            `json:"persistenceBackends"`
+	TestTypes           []string            `json:"testTypes"`
+	OutputFormats       map[string][]string `json:"outputFormats"`
+	Protections         []string            `json:"protections"`
+	Policies            []capabilityPolicy  `json:"policies"`
+}
+
+// capabilityKind is a configuration kind, with its custom resource when the operator reconciles it
+type capabilityKind struct {
+	Kind       string `json:"kind"`
+	APIVersion string `json:"apiVersion"`
+	Plural     string `json:"plural,omitempty"`
+	ShortName  string `json:"shortName,omitempty"`
+}
+
+// capabilityPolicy is a safety feature and the flag or setting enabling it
+type capabilityPolicy struct {
+	Name        string `json:"name"`
+	EnabledBy   string `json:"enabledBy"`
+	Description string `json:"description"`
+}
+
+// capabilityPolicies lists the safety features of kictl
+var capabilityPolicies = []capabilityPolicy{
+	{"restricted-commands", "--restricted", "Refuse node commands whose executable is not in --allowed-commands"},
+	{"cluster-protection", "settings clusters", "Classify kubeconfig contexts as sandbox, staging or production"},
+	{"plan-approvals", "--plan", "Apply only a plan that matches the cluster and carries its approvals"},
+	{"reachability-guard", "tools.nvlan.reachabilityGuard", "Refuse to change the interface kictl reaches a node through without another way in"},
+	{"rollback-on-failure", "--rollback-on-failure", "Tear down the VLAN interfaces of a run when any node fails"},
+	{"zone-aware", "--zone-aware", "Change at most one node per failure domain at once"},
+	{"batch-soak", "--soak", "Watch every batch of nodes for Ready and smoke tests, aborting the rollout on a regression"},
+	{"zap-confirmation", "--confirm-zap", "Wipe storage devices only after typing or passing their count"},
+	{"strict-schema", "--strict-schema", "Reject configuration with unknown fields"},
+	{"offline", "--offline", "Reach no host but the cluster API server"},
+}
+
+// createCapabilitiesCommand creates the command that reports the kinds, backends, formats and policies of this build
+func createCapabilitiesCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "capabilities",
+		Short: "Report the kinds, backends, formats and policies this kictl supports",
+		Long: `Report what this kictl build supports: configuration apiVersions and kinds,
+the custom resources of the operator, kube clients, node command and persistence
+backends, connectivity test types, output formats and safety policies.
+
+Wrapper tooling can read the JSON output to adapt to the installed version
+instead of parsing help texts.
+
+Examples:
+  kictl capabilities
+  kictl capabilities --output json | jq -r '.testTypes[]'`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			output, _ := cmd.Flags().GetString("output")
+			return writeCapabilities(cmd.OutOrStdout(), currentCapabilities(), output)
+		},
+	}
+
+	cmd.Flags().StringP("output", "o", capabilitiesOutputText, "Output format: text or json")
+	return cmd
+}
+
+// currentCapabilities collects the capabilities of this build from the lists the rest of kictl validates against
+func currentCapabilities() capabilities {
+	version := "(devel)"
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" {
+		version = info.Main.Version
+	}
+
+	customResources := make(map[string]operator.Kind, len(operator.Kinds))
+	for _, kind := range operator.Kinds {
+		customResources[kind.Name] = kind
+	}
+	var kinds []capabilityKind
+	for _, name := range config.SchemaKinds() {
+		kind := capabilityKind{Kind: name, APIVersion: operator.Group + "/" + operator.Version}
+		if resource, ok := customResources[name]; ok {
+			kind.Plural, kind.ShortName = resource.Plural, resource.ShortName
+		}
+		kinds = append(kinds, kind)
+	}
+
+	var apiVersions []string
+	for _, apiVersion := range config.APIVersions() {
+		apiVersions = append(apiVersions, config.APIGroup+"/"+apiVersion)
+	}
+
+	return capabilities{
+		Version:             version,
+		APIVersions:         apiVersions,
+		Kinds:               kinds,
+		KubeClients:         []string{kubectl.ClientKubectl, kubectl.ClientNative},
+		NodeExecBackends:    []string{kubectl.NodeExecBackendDebugPod, kubectl.NodeExecBackendEphemeral, kubectl.NodeExecBackendAgent, "ssh"},
+		PersistenceBackends: append(append([]string{}, config.PersistenceBackends...), config.PersistenceBackendAuto),
+		TestTypes:           config.TestTypes,
+		OutputFormats: map[string][]string{
+			"capabilities":    {capabilitiesOutputText, capabilitiesOutputJSON},
+			"export firewall": segmentation.Formats,
+			"log":             {logging.FormatText, logging.FormatJSON},
+			"report":          supportedReportFormats,
+		},
+		Protections: settings.Protections,
+		Policies:    capabilityPolicies,
+	}
+}
+
+// writeCapabilities prints the capabilities as indented JSON or as a text summary
+func writeCapabilities(out io.Writer, caps capabilities, output string) error {
+	switch output {
+	case capabilitiesOutputJSON:
+		data, err := json.MarshalIndent(caps, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode capabilities: %w", err)
+		}
+		fmt.Fprintln(out, string(data))
+		return nil
+	case capabilitiesOutputText:
+	default:
+		return fmt.Errorf("unknown output '%s'. Expected: %s or %s", output, capabilitiesOutputText, capabilitiesOutputJSON)
+	}
+
+	fmt.Fprintf(out, "kictl %s\n\nConfig API versions:   %s\n\nKinds:\n", caps.Version, strings.Join(caps.APIVersions, ", "))
+	for _, kind := range caps.Kinds {
+		resource := ""
+		if kind.Plural != "" {
+			resource = fmt.Sprintf("  custom resource %s (%s)", kind.Plural, kind.ShortName)
+		}
+		fmt.Fprintf(out, "  %-16s %s%s\n", kind.Kind, kind.APIVersion, resource)
+	}
+	fmt.Fprintf(out, "\nKube clients:          %s\n", strings.Join(caps.KubeClients, ", "))
+	fmt.Fprintf(out, "Node command backends: %s\n", strings.Join(caps.NodeExecBackends, ", "))
+	fmt.Fprintf(out, "Persistence backends:  %s\n", strings.Join(caps.PersistenceBackends, ", "))
+	fmt.Fprintf(out, "Test types:            %s\n", strings.Join(caps.TestTypes, ", "))
+	fmt.Fprintf(out, "Protection levels:     %s\n", strings.Join(caps.Protections, ", "))
+	fmt.Fprintln(out, "\nOutput formats:")
+	for _, name := range []string{"capabilities", "export firewall", "log", "report"} {
+		fmt.Fprintf(out, "  %-16s %s\n", name, strings.Join(caps.OutputFormats[name], ", "))
+	}
+	fmt.Fprintln(out, "\nPolicies:")
+	for _, policy := range caps.Policies {
+		fmt.Fprintf(out, "  %-20s %-30s %s\n", policy.Name, policy.EnabledBy, policy.Description)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+// Package main provides unit tests for the capabilities report
+// WHY: Wrapper tooling parses the JSON output, so its fields must hold what this build actually supports
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCapabilitiesCommand tests reporting the capabilities as text and JSON
+// WHY: Both outputs must list the same kinds, backends and formats, and an unknown output must fail
+func TestCapabilitiesCommand(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		expected    []string
+		expectError string
+	}{
+		{
+			name:     "text",
+			expected: []string{"Config API versions:   openstack.kictl.icycloud.io/v1, openstack.kictl.icycloud.io/v2", "NodeVLANConf     openstack.kictl.icycloud.io/v1  custom resource nodevlanconfs (nvc)", "Test types:            ping, tcp, http, bandwidth", "zone-aware"},
+		},
+		{
+			name:        "unknown_output",
+			args:        []string{"--output", "yaml"},
+			expectError: "unknown output 'yaml'. Expected: text or json",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: The capabilities command
+			rootCmd := createRootCommand()
+			out := new(bytes.Buffer)
+			rootCmd.SetOut(out)
+			rootCmd.SetErr(new(bytes.Buffer))
+			rootCmd.SetArgs(append([]string{"capabilities"}, tt.args...))
+
+			// When: Report the capabilities
+			err := rootCmd.Execute()
+
+			// Then: The report lists what this build supports
+			if tt.expectError != "" {
+				assert.EqualError(t, err, tt.expectError)
+				return
+			}
+			require.NoError(t, err)
+			for _, expected := range tt.expected {
+				assert.Contains(t, out.String(), expected)
+			}
+		})
+	}
+}
+
+// TestCapabilitiesCommand_JSON tests the fields of the JSON capabilities
+// WHY: Tooling keys on kinds, backends and formats by name, and only operator kinds have a custom resource
+func TestCapabilitiesCommand_JSON(t *testing.T) {
+	// Given: The capabilities command with JSON output
+	rootCmd := createRootCommand()
+	out := new(bytes.Buffer)
+	rootCmd.SetOut(out)
+	rootCmd.SetArgs([]string{"capabilities", "-o", "json"})
+
+	// When: Report the capabilities
+	require.NoError(t, rootCmd.Execute())
+
+	// Then: The JSON decodes into the capabilities of this build
+	var caps capabilities
+	require.NoError(t, json.Unmarshal(out.Bytes(), &caps))
+	assert.Equal(t, currentCapabilities(), caps)
+	assert.Contains(t, caps.Kinds, capabilityKind{Kind: "NodeStorageConf", APIVersion: "openstack.kictl.icycloud.io/v1", Plural: "nodestorageconfs", ShortName: "nstc"})
+	assert.Contains(t, caps.Kinds, capabilityKind{Kind: "CleanupConf", APIVersion: "openstack.kictl.icycloud.io/v1"})
+	assert.Equal(t, []string{"openstack.kictl.icycloud.io/v1", "openstack.kictl.icycloud.io/v2"}, caps.APIVersions)
+	assert.Equal(t, []string{"debug-pod", "ephemeral", "agent", "ssh"}, caps.NodeExecBackends)
+	assert.Equal(t, []string{"netplan", "networkmanager", "networkd", "ifcfg", "auto"}, caps.PersistenceBackends)
+	assert.Equal(t, []string{"report", "iptables", "nftables"}, caps.OutputFormats["export firewall"])
+}
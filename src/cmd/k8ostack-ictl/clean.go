@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"k8ostack-ictl/internal/workspace"
+
+	"github.com/spf13/cobra"
+)
+
+// createCleanCommand creates the command that prunes old run folders from the workspace
+func createCleanCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "clean",
+		Short: "Remove old run logs and plans from the workspace",
+		Long: `Remove run folders that exceed the --retention-* limits, oldest first.
+The same pruning runs automatically whenever a command starts a new run.
+History is never pruned, so timelines stay complete.
+
+Examples:
+  # Apply the default retention limits now
+  kictl clean
+
+  # Keep only the last 10 runs, listing what would be removed
+  kictl clean --retention-max-runs 10 --dry-run
+
+  # Remove every run folder
+  kictl clean --all`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			all, _ := cmd.Flags().GetBool("all")
+
+			ws, err := openWorkspace()
+			if err != nil {
+				return err
+			}
+			runs, err := ws.Runs()
+			if err != nil {
+				return err
+			}
+
+			expired := runs
+			if !all {
+				retention, err := retentionPolicy()
+				if err != nil {
+					return err
+				}
+				expired = workspace.Expired(runs, retention, time.Now())
+			}
+
+			out := cmd.OutOrStdout()
+			if len(expired) == 0 {
+				fmt.Fprintf(out, "✅ Nothing to clean in %s (%d runs kept)\n", ws.Root, len(runs))
+				return nil
+			}
+
+			if dryRun {
+				var size int64
+				for _, run := range expired {
+					fmt.Fprintf(out, "🧪 Would remove %s (%s)\n", run.ID, formatSize(run.Size))
+					size += run.Size
+				}
+				fmt.Fprintf(out, "🧪 DRY RUN: %d runs (%s) would be removed from %s\n", len(expired), formatSize(size), ws.Root)
+				return nil
+			}
+
+			result, err := ws.Remove(expired)
+			for _, run := range result.Removed {
+				fmt.Fprintf(out, "🗑️  Removed %s (%s)\n", run.ID, formatSize(run.Size))
+			}
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "🧹 Removed %d runs from %s, %s freed\n", len(result.Removed), ws.Root, formatSize(result.Freed))
+			return nil
+		},
+	}
+
+	cmd.Flags().Bool("all", false, "Remove every run folder regardless of the retention limits")
+	return cmd
+}
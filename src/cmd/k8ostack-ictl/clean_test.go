@@ -0,0 +1,140 @@
+// Package main provides unit tests for the clean command
+// WHY: Cleaning deletes files, so what it removes must follow the flags exactly
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"k8ostack-ictl/internal/workspace"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCleanCommand_Unit tests the clean command against a workspace with three runs
+// WHY: Dry-run must only list, retention must keep the newest runs and --all must empty the workspace
+func TestCleanCommand_Unit(t *testing.T) {
+	originalWorkspace, originalDryRun := workspaceDir, dryRun
+	defer func() { workspaceDir, dryRun = originalWorkspace, originalDryRun }()
+
+	tests := []struct {
+		name           string
+		args           []string
+		expectedKept   int
+		expectedOutput string
+	}{
+		{
+			name:           "retention keeps the newest runs",
+			args:           []string{"clean", "--retention-max-runs", "1"},
+			expectedKept:   1,
+			expectedOutput: "Removed 2 runs",
+		},
+		{
+			name:           "dry run only lists",
+			args:           []string{"clean", "--retention-max-runs", "1", "--dry-run"},
+			expectedKept:   3,
+			expectedOutput: "2 runs (6Ki) would be removed",
+		},
+		{
+			name:           "within limits removes nothing",
+			args:           []string{"clean"},
+			expectedKept:   3,
+			expectedOutput: "Nothing to clean",
+		},
+		{
+			name:           "all removes every run",
+			args:           []string{"clean", "--all"},
+			expectedKept:   0,
+			expectedOutput: "Removed 3 runs",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: Workspace with three runs of 3Ki each
+			root := t.TempDir()
+			ws, err := workspace.Open(root)
+			require.NoError(t, err)
+			for i := 0; i < 3; i++ {
+				run, err := ws.NewRun("apply", time.Now())
+				require.NoError(t, err)
+				require.NoError(t, os.WriteFile(run.Path("run.log"), make([]byte, 3*1024), 0644))
+				modTime := time.Now().Add(time.Duration(i-3) * time.Minute)
+				require.NoError(t, os.Chtimes(run.Dir, modTime, modTime))
+			}
+
+			var out bytes.Buffer
+			cmd := createRootCommand()
+			cmd.SetOut(&out)
+			cmd.SetErr(new(bytes.Buffer))
+			cmd.SetArgs(append(tt.args, "--workspace", root))
+
+			// When: Execute
+			err = cmd.Execute()
+
+			// Then: Expected runs remain and the outcome is reported
+			require.NoError(t, err)
+			remaining, err := os.ReadDir(filepath.Join(root, workspace.RunsDir))
+			require.NoError(t, err)
+			assert.Len(t, remaining, tt.expectedKept)
+			assert.Contains(t, out.String(), tt.expectedOutput)
+		})
+	}
+}
+
+// TestRetentionPolicy_Unit tests parsing of the --retention-* flags
+// WHY: A typo in a size limit must fail the command instead of silently disabling pruning
+func TestRetentionPolicy_Unit(t *testing.T) {
+	originalAge, originalRuns, originalSize := retentionMaxAge, retentionMaxRuns, retentionMaxSize
+	defer func() { retentionMaxAge, retentionMaxRuns, retentionMaxSize = originalAge, originalRuns, originalSize }()
+
+	tests := []struct {
+		name        string
+		maxRuns     int
+		maxSize     string
+		expected    workspace.Retention
+		expectError bool
+	}{
+		{
+			name:     "binary size suffix",
+			maxRuns:  5,
+			maxSize:  "500Mi",
+			expected: workspace.Retention{MaxAge: time.Hour, MaxRuns: 5, MaxSize: 500 * 1024 * 1024},
+		},
+		{
+			name:     "zero disables the size limit",
+			maxSize:  "0",
+			expected: workspace.Retention{MaxAge: time.Hour},
+		},
+		{
+			name:        "unparsable size",
+			maxSize:     "1 gigabyte",
+			expectError: true,
+		},
+		{
+			name:        "negative run count",
+			maxRuns:     -1,
+			maxSize:     "1Gi",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			retentionMaxAge, retentionMaxRuns, retentionMaxSize = time.Hour, tt.maxRuns, tt.maxSize
+
+			retention, err := retentionPolicy()
+
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, retention)
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"k8ostack-ictl/internal/cmdb"
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/history"
+	"k8ostack-ictl/internal/logging"
+	"k8ostack-ictl/internal/workspace"
+
+	"github.com/spf13/cobra"
+)
+
+// newCMDBClient returns the client for --cmdb-url, queueing undelivered changes in the workspace, or nil when it is unset
+func newCMDBClient() (*cmdb.Client, error) {
+	if cmdbURL == "" {
+		return nil, nil
+	}
+	ws, err := openWorkspace()
+	if err != nil {
+		return nil, err
+	}
+	return cmdb.NewClient(cmdb.Options{
+		Endpoint: cmdbURL,
+		Token:    os.Getenv(cmdb.TokenEnv),
+		Retries:  cmdbRetries,
+		QueueDir: ws.Path(workspace.CMDBQueueDir),
+	})
+}
+
+// syncCMDB posts the per-node changes of a successful apply to the CMDB
+// Failures are only logged: the change stays queued and goes out with the next apply or 'kictl cmdb flush'.
+func syncCMDB(ctx context.Context, logger logging.Logger, client *cmdb.Client, bundle *config.ConfigBundle) {
+	now := time.Now()
+	result, err := client.Send(ctx, cmdb.Event{
+		RunID:      history.NewRunID(now),
+		Operation:  operationApply,
+		ConfigFile: configFile,
+		Timestamp:  now.UTC(),
+		Nodes:      cmdb.Changes(bundle),
+	})
+	logCMDBResult(logger, client, result, err)
+}
+
+// logCMDBResult reports what a delivery attempt did with the queue
+func logCMDBResult(logger logging.Logger, client *cmdb.Client, result cmdb.Result, err error) {
+	if result.Delivered > 0 {
+		logger.Info(fmt.Sprintf("🗄️  Sent %d change events to the CMDB at %s", result.Delivered, client.Endpoint()))
+	}
+	if result.Rejected > 0 {
+		logger.Error(fmt.Sprintf("❌ The CMDB rejected %d change events, kept as *.rejected in the queue for inspection", result.Rejected))
+	}
+	if err != nil {
+		logger.Warn(fmt.Sprintf("⚠️  Failed to sync the CMDB: %v (%d change events queued for the next attempt)", err, result.Queued))
+	}
+}
+
+// createCMDBCommand creates the command group for the CMDB change hook
+func createCMDBCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cmdb",
+		Short: "Manage the changes posted to the CMDB with --cmdb-url",
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "flush",
+		Short: "Deliver the change events queued while the CMDB was unreachable",
+		Long: `Deliver the change events queued while the CMDB was unreachable, oldest first.
+Every successful apply does this too before posting its own changes.
+
+Examples:
+  kictl cmdb flush --cmdb-url https://cmdb.lab/api/kictl/changes`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := newCMDBClient()
+			if err != nil {
+				return err
+			}
+			if client == nil {
+				return fmt.Errorf("--cmdb-url is required")
+			}
+
+			logger, run, err := newRunLogger(cmd, "cmdb-flush")
+			if err != nil {
+				return fmt.Errorf("failed to initialize logger: %w", err)
+			}
+			defer closeRun(cmd, logger, run)
+
+			result, err := client.Flush(context.Background())
+			logCMDBResult(logger, client, result, err)
+			if err != nil {
+				return fmt.Errorf("failed to flush the CMDB queue: %w", err)
+			}
+			if result.Delivered == 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), "✅ No change events queued")
+			}
+			return nil
+		},
+	})
+
+	return cmd
+}
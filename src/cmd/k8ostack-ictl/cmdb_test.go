@@ -0,0 +1,97 @@
+// Package main provides unit tests for the CMDB change hook
+// WHY: A CMDB outage must never fail an apply, and its changes must reach the CMDB once it is back
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"k8ostack-ictl/internal/cmdb"
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/logging"
+	"k8ostack-ictl/internal/workspace"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSyncCMDB tests posting the changes of an apply while the CMDB is down and after it recovers
+// WHY: Changes queued during an outage must be delivered, in order, by the next apply or flush
+func TestSyncCMDB(t *testing.T) {
+	// Given: A CMDB that is down, and a bundle labelling one node
+	originalURL, originalRetries, originalWorkspace := cmdbURL, cmdbRetries, workspaceDir
+	defer func() { cmdbURL, cmdbRetries, workspaceDir = originalURL, originalRetries, originalWorkspace }()
+	home := t.TempDir()
+	workspaceDir = home
+
+	down := true
+	var received []cmdb.Event
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var event cmdb.Event
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&event))
+		received = append(received, event)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer ts.Close()
+	cmdbURL, cmdbRetries = ts.URL, 0
+
+	bundle := &config.ConfigBundle{NodeLabels: &config.NodeLabelConf{Spec: config.NodeLabelSpec{NodeRoles: map[string]config.NodeRole{
+		"compute": {Nodes: []string{"rsb2"}, Labels: map[string]string{"role": "compute"}},
+	}}}}
+	client, err := newCMDBClient()
+	require.NoError(t, err)
+
+	// When: Sync while the CMDB is down
+	logger := logging.NewRecordingLogger()
+	syncCMDB(context.Background(), logger, client, bundle)
+
+	// Then: The apply only warns and the change is queued
+	assert.Len(t, logger.Messages(logging.LevelWarn), 1)
+	queued, err := os.ReadDir(filepath.Join(home, workspace.CMDBQueueDir))
+	require.NoError(t, err)
+	assert.Len(t, queued, 1)
+	assert.Empty(t, received)
+
+	// When: The CMDB is back and the queue is flushed
+	down = false
+	rootCmd := createRootCommand()
+	out := new(bytes.Buffer)
+	rootCmd.SetOut(out)
+	rootCmd.SetErr(new(bytes.Buffer))
+	rootCmd.SetArgs([]string{"cmdb", "flush", "--cmdb-url", ts.URL, "--workspace", home})
+	require.NoError(t, rootCmd.Execute())
+
+	// Then: The queued change is delivered with its node labels
+	require.Len(t, received, 1)
+	assert.Equal(t, []cmdb.NodeChange{{Node: "rsb2", Labels: map[string]string{"role": "compute"}}}, received[0].Nodes)
+	assert.Contains(t, out.String(), "Sent 1 change events to the CMDB")
+	queued, err = os.ReadDir(filepath.Join(home, workspace.CMDBQueueDir))
+	require.NoError(t, err)
+	assert.Empty(t, queued)
+}
+
+// TestCMDBFlush_RequiresURL tests flushing without a CMDB
+// WHY: Flushing nowhere must fail loudly instead of pretending the queue is empty
+func TestCMDBFlush_RequiresURL(t *testing.T) {
+	// Given: No --cmdb-url
+	rootCmd := createRootCommand()
+	rootCmd.SetOut(new(bytes.Buffer))
+	rootCmd.SetErr(new(bytes.Buffer))
+	rootCmd.SetArgs([]string{"cmdb", "flush"})
+
+	// When: Flush the queue
+	err := rootCmd.Execute()
+
+	// Then: It fails
+	assert.EqualError(t, err, "--cmdb-url is required")
+}
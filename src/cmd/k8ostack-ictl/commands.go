@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/readiness"
+
+	"github.com/spf13/cobra"
+)
+
+// Bundle operations run by the operation subcommands
+const (
+	operationApply  = "apply"
+	operationDelete = "delete"
+	operationVerify = "verify"
+)
+
+// templatesDirFlagUsage is the help text of --templates-dir on apply and the legacy root command
+const templatesDirFlagUsage = "Directory of templates replacing the built-in ones for persistent VLAN configuration files, e.g. netplan.yaml.tmpl"
+
+// createApplyCommand creates the command that applies every configuration in the bundle
+func createApplyCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "apply",
+		Short: "Apply the configuration bundle to the cluster",
+		Long: `Apply every configuration in the bundle: label cleanup, node labels,
+VLANs and connectivity tests, in that order.
+
+Examples:
+  # Apply a multi-CRD configuration
+  kictl apply --config cluster-config.yaml
+
+  # Preview the changes first
+  kictl apply --config cluster-config.yaml --dry-run --verbose
+
+  # Undo the VLANs of this run if any node fails
+  kictl apply --config cluster-config.yaml --rollback-on-failure
+
+  # Record the connectivity of a healthy cluster, then flag pairs that break later
+  kictl apply --config cluster-config.yaml --record-baseline
+
+  # Feed connectivity test results to a CI dashboard
+  kictl apply --config cluster-config.yaml --report junit=test-report.xml
+
+  # Apply one VLAN on one node only (see 'kictl addresses')
+  kictl apply --config cluster-config.yaml --target vlan.management.rsb2
+
+  # Bring freshly added hosts in line without touching the rest
+  kictl apply --config cluster-config.yaml --nodes rsb17,rsb18
+
+  # Apply the VLANs of a bundle only
+  kictl apply --config cluster-config.yaml --only vlans
+
+  # Apply a plan a second person approved (see 'kictl approve')
+  kictl apply --config cluster-config.yaml --plan prod.plan.json`,
+		Args: cobra.NoArgs,
+		RunE: runOperationCommand(operationApply),
+	}
+
+	cmd.Flags().BoolVar(&fixTypos, "fix-typos", false, "Check node names against the cluster and offer to correct typos in the config file")
+	cmd.Flags().BoolVar(&rollbackOnFailure, "rollback-on-failure", false, "Tear down the VLAN interfaces created by the run when any node fails")
+	cmd.Flags().BoolVar(&force, "force", false, "Change the interface kictl reaches a node through even when the reachability guard finds no other way in")
+	cmd.Flags().StringVar(&templatesDir, "templates-dir", "", templatesDirFlagUsage)
+	_ = cmd.MarkFlagDirname("templates-dir")
+	cmd.Flags().IntVar(&confirmZapCount, "confirm-zap", 0, confirmZapFlagUsage)
+	cmd.Flags().BoolVar(&recordBaseline, "record-baseline", false, "Record the connectivity matrix of the tests as the baseline later runs are compared against")
+	cmd.Flags().Var(newReportValue(&testReports), "report", "Also write connectivity test results as <format>=<path>, e.g. junit=report.xml (repeatable)")
+	cmd.Flags().StringSliceVar(&targets, "target", nil, targetFlagUsage)
+	cmd.Flags().StringSliceVar(&nodeFilter, "nodes", nil, nodesFlagUsage)
+	cmd.Flags().StringVar(&nodesFile, "nodes-file", "", nodesFileFlagUsage)
+	cmd.Flags().StringSliceVar(&onlyKinds, "only", nil, onlyFlagUsage)
+	cmd.Flags().StringSliceVar(&skipKinds, "skip", nil, skipFlagUsage)
+	cmd.Flags().StringVar(&planFile, "plan", "", "Apply only if the configuration, context and scope match this plan from 'kictl plan --out'; required on protected contexts")
+	cmd.Flags().StringVar(&approvalsFile, "approvals", "", "Approvals of the --plan (default <plan>.approvals.json)")
+	registerFilterCompletions(cmd)
+	return cmd
+}
+
+// createDeleteCommand creates the command that removes every configuration in the bundle
+func createDeleteCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "delete",
+		Short: "Remove the configuration bundle from the cluster",
+		Long: `Remove the node labels and VLANs defined in the bundle and stop running tests.
+CleanupConf documents are skipped since removed labels cannot be restored.
+
+Examples:
+  kictl delete --config cluster-config.yaml --dry-run
+
+  # Remove one label from one node only
+  kictl delete --config cluster-config.yaml --target nodelabel.control.rsb2/openstack-role`,
+		Args: cobra.NoArgs,
+		RunE: runOperationCommand(operationDelete),
+	}
+
+	cmd.Flags().BoolVar(&force, "force", false, "Remove VLANs on the interface kictl reaches a node through even when the reachability guard finds no other way in")
+	cmd.Flags().StringSliceVar(&targets, "target", nil, targetFlagUsage)
+	cmd.Flags().StringSliceVar(&nodeFilter, "nodes", nil, nodesFlagUsage)
+	cmd.Flags().StringVar(&nodesFile, "nodes-file", "", nodesFileFlagUsage)
+	cmd.Flags().StringSliceVar(&onlyKinds, "only", nil, onlyFlagUsage)
+	cmd.Flags().StringSliceVar(&skipKinds, "skip", nil, skipFlagUsage)
+	registerFilterCompletions(cmd)
+	return cmd
+}
+
+// createVerifyCommand creates the command that checks the cluster against the bundle without changing it
+func createVerifyCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "verify",
+		Short: "Check that the cluster matches the configuration bundle",
+		Long: `Verify node labels, VLAN interfaces and test prerequisites against the bundle.
+Nothing is changed but the condition --publish-condition sets, and no history is recorded.
+A report lists every node checked.
+
+Exit codes:
+  0  every node matches the bundle
+  1  the bundle could not be verified, e.g. an invalid config or an unreachable cluster
+  2  some nodes deviate from the bundle
+
+Examples:
+  kictl verify --config cluster-config.yaml
+
+  # Report each node as a JUnit test case for CI dashboards
+  kictl verify --config cluster-config.yaml --junit verify-report.xml
+
+  # Check the hosts listed in a file only
+  kictl verify --config cluster-config.yaml --nodes-file new-hosts.txt
+
+  # Set the kictl.icycloud.io/NetworkVerified condition of each node for readiness controllers
+  kictl verify --config cluster-config.yaml --publish-condition`,
+		Args: cobra.NoArgs,
+		RunE: runOperationCommand(operationVerify),
+	}
+
+	cmd.Flags().StringVar(&junitOutput, "junit", "", "Also write the results as JUnit XML to this file, one test case per node")
+	cmd.Flags().BoolVar(&publishCondition, "publish-condition", false, "Set the "+readiness.ConditionType+" condition of each verified node to its result")
+	cmd.Flags().StringSliceVar(&nodeFilter, "nodes", nil, nodesFlagUsage)
+	cmd.Flags().StringVar(&nodesFile, "nodes-file", "", nodesFileFlagUsage)
+	cmd.Flags().StringSliceVar(&onlyKinds, "only", nil, onlyFlagUsage)
+	cmd.Flags().StringSliceVar(&skipKinds, "skip", nil, skipFlagUsage)
+	registerFilterCompletions(cmd)
+	return cmd
+}
+
+// createGenerateCommand creates the command that writes sample configuration files
+func createGenerateCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "generate",
+		Short: "Generate a sample configuration file",
+		Long: `Generate a sample NodeLabelConf, or with --multi a multi-CRD bundle.
+
+Examples:
+  kictl generate
+  kictl generate --multi --output cluster-config.yaml
+  kictl generate fixtures --config cluster-config.yaml`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			multi, _ := cmd.Flags().GetBool("multi")
+			output, _ := cmd.Flags().GetString("output")
+
+			if multi {
+				if output == "" {
+					output = "sample-multi-config.yaml"
+				}
+				return config.GenerateMultiCRDSampleConfig(output)
+			}
+
+			if output == "" {
+				output = "sample-config.yaml"
+			}
+			return config.GenerateSampleConfig(output)
+		},
+	}
+
+	cmd.Flags().Bool("multi", false, "Generate a multi-CRD sample (NodeLabelConf, NodeVLANConf and NodeTestConf)")
+	cmd.Flags().StringP("output", "o", "", "Output file (default sample-config.yaml, or sample-multi-config.yaml with --multi)")
+	cmd.AddCommand(createFixturesCommand())
+	return cmd
+}
+
+// runOperationCommand returns the run function of an operation subcommand
+func runOperationCommand(operation string) func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		if configFile == "" {
+			return fmt.Errorf("configuration file is required. Use --config to specify a YAML file, or 'kictl generate' to create a sample")
+		}
+
+		logger, run, err := newRunLogger(cmd, operation)
+		if err != nil {
+			return fmt.Errorf("failed to initialize logger: %w", err)
+		}
+		defer closeRun(cmd, logger, run)
+
+		return runRecordedOperation(context.Background(), cmd, logger, run, operation)
+	}
+}
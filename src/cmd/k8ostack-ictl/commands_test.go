@@ -0,0 +1,138 @@
+// Package main provides unit tests for the operation subcommands
+// WHY: Each operation must stay reachable as a subcommand while sharing the root's flags
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestOperationSubcommands_Unit tests that every operation subcommand is registered
+// WHY: Scripts move from --apply/--delete to subcommands and rely on these names
+func TestOperationSubcommands_Unit(t *testing.T) {
+	root := createRootCommand()
+
+	for _, name := range []string{"apply", "delete", "verify", "validate", "generate"} {
+		t.Run(name, func(t *testing.T) {
+			sub, _, err := root.Find([]string{name})
+			require.NoError(t, err)
+			assert.Equal(t, name, sub.Name())
+			assert.NotNil(t, sub.RunE, "%s should have a run function", name)
+		})
+	}
+}
+
+// TestSubcommandSharedFlags_Unit tests that shared flags are inherited by subcommands
+// WHY: --config, --dry-run and the precedence flags must mean the same thing on every operation
+func TestSubcommandSharedFlags_Unit(t *testing.T) {
+	root := createRootCommand()
+	apply, _, err := root.Find([]string{"apply"})
+	require.NoError(t, err)
+
+	for _, name := range []string{"config", "dry-run", "verbose", "log-level", "log-format", "node-name-pattern", "history-dir", "node-exec-backend", "kubeconfig", "context", "namespace"} {
+		assert.NotNil(t, apply.InheritedFlags().Lookup(name), "apply should inherit --%s", name)
+	}
+
+	// Operation-specific flags stay local to their subcommand
+	assert.NotNil(t, apply.LocalFlags().Lookup("fix-typos"))
+	assert.NotNil(t, apply.LocalFlags().Lookup("rollback-on-failure"))
+	assert.NotNil(t, apply.LocalFlags().Lookup("report"))
+	verify, _, err := root.Find([]string{"verify"})
+	require.NoError(t, err)
+	assert.Nil(t, verify.Flags().Lookup("fix-typos"))
+	assert.NotNil(t, verify.LocalFlags().Lookup("junit"))
+	assert.Nil(t, verify.Flags().Lookup("apply"), "legacy operation flags should not leak into subcommands")
+
+	// The operator keeps its own --namespace, the namespace it watches
+	operatorCmd, _, err := root.Find([]string{"operator"})
+	require.NoError(t, err)
+	assert.Equal(t, "Only watch resources in this namespace (default all namespaces)", operatorCmd.Flags().Lookup("namespace").Usage)
+}
+
+// TestOperationSubcommands_RequireConfig tests the missing configuration error
+// WHY: Operations must fail before touching the cluster when no configuration is given
+func TestOperationSubcommands_RequireConfig(t *testing.T) {
+	for _, name := range []string{"apply", "delete", "verify"} {
+		t.Run(name, func(t *testing.T) {
+			// Given: Subcommand without --config
+			root := createRootCommand()
+			root.SetOut(new(bytes.Buffer))
+			root.SetErr(new(bytes.Buffer))
+			root.SetArgs([]string{name})
+
+			// When: Execute
+			err := root.Execute()
+
+			// Then: Configuration is required
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), "configuration file is required")
+		})
+	}
+}
+
+// TestOperationSubcommands_InvalidConfig tests that subcommands load the shared --config file
+// WHY: The persistent flag must reach the subcommand rather than a stale global
+func TestOperationSubcommands_InvalidConfig(t *testing.T) {
+	skipOnNetworkFS(t)
+	tempDir := t.TempDir()
+	originalDir, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalDir)
+	require.NoError(t, os.Chdir(tempDir))
+
+	// Given: Verify subcommand pointing at a missing file
+	root := createRootCommand()
+	root.SetOut(new(bytes.Buffer))
+	root.SetErr(new(bytes.Buffer))
+	root.SetArgs([]string{"verify", "--config", filepath.Join(tempDir, "missing.yaml")})
+
+	// When: Execute
+	err = root.Execute()
+
+	// Then: Loading fails for that file
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to load configuration")
+}
+
+// TestGenerateCommand tests sample generation through the generate subcommand
+// WHY: Replaces --generate-config and --generate-multi-config and adds an output path
+func TestGenerateCommand(t *testing.T) {
+	skipOnNetworkFS(t)
+	tests := []struct {
+		name         string
+		args         []string
+		expectedFile string
+		expectedKind string
+	}{
+		{"default_single", []string{"generate"}, "sample-config.yaml", "NodeLabelConf"},
+		{"default_multi", []string{"generate", "--multi"}, "sample-multi-config.yaml", "NodeVLANConf"},
+		{"custom_output", []string{"generate", "--multi", "-o", "cluster.yaml"}, "cluster.yaml", "NodeTestConf"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: Clean working directory
+			tempDir := t.TempDir()
+			originalDir, err := os.Getwd()
+			require.NoError(t, err)
+			defer os.Chdir(originalDir)
+			require.NoError(t, os.Chdir(tempDir))
+
+			// When: Execute generate
+			root := createRootCommand()
+			root.SetArgs(tt.args)
+			err = root.Execute()
+
+			// Then: Sample file is written at the expected path
+			require.NoError(t, err)
+			data, err := os.ReadFile(filepath.Join(tempDir, tt.expectedFile))
+			require.NoError(t, err)
+			assert.Contains(t, string(data), tt.expectedKind)
+		})
+	}
+}
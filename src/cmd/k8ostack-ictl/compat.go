@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8ostack-ictl/internal/compat"
+	"k8ostack-ictl/internal/kubectl"
+	"k8ostack-ictl/internal/logging"
+)
+
+// versionRunner replaces the kubectl of the executor for the compatibility check in tests
+var versionRunner kubectl.Runner
+
+// legacyDebug makes executors create debug node pods from a manifest, set when kubectl predates --profile=sysadmin
+var legacyDebug bool
+
+// versionCheckTimeout bounds reading the versions, so an unreachable cluster does not hold up the run here
+const versionCheckTimeout = 30 * time.Second
+
+// checkCompatibility checks the local kubectl and the cluster against what the node command backend needs
+// It fails on versions that cannot run the generated commands and adjusts the commands where kictl can.
+// The native client runs no kubectl, and versions that cannot be read are only warned about.
+func checkCompatibility(ctx context.Context, logger logging.Logger) error {
+	legacyDebug = false
+	if kubeClient == kubectl.ClientNative {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, versionCheckTimeout)
+	defer cancel()
+	versions, err := compat.Read(ctx, kubectlRunner(newKubectlExecutor(logger), versionRunner))
+	if err != nil {
+		logger.Warn(fmt.Sprintf("⚠️  Could not check kubectl and cluster versions: %v", err))
+		return nil
+	}
+	logger.Debug(fmt.Sprintf("kubectl %s, cluster %s", versions.Client, versions.Server))
+
+	result, err := compat.Check(versions, nodeExecBackend)
+	if err != nil {
+		return fmt.Errorf("incompatible versions: %w", err)
+	}
+	for _, adjustment := range result.Adjustments {
+		logger.Info(fmt.Sprintf("🔧 %s", adjustment))
+	}
+	for _, warning := range result.Warnings {
+		logger.Warn(fmt.Sprintf("⚠️  %s", warning))
+	}
+	legacyDebug = result.LegacyDebug
+	return nil
+}
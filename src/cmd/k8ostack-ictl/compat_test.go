@@ -0,0 +1,100 @@
+// Package main provides unit tests for the kubectl and cluster version check before runs
+// WHY: A run must stop before its first node command when kubectl cannot generate it, not halfway through the nodes
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"k8ostack-ictl/internal/kubectl"
+	"k8ostack-ictl/internal/logging"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCheckCompatibility tests adapting a run to the versions of kubectl and the cluster
+// WHY: Old kubectl switches debug pods to a manifest, impossible combinations fail, and unknown versions only warn
+func TestCheckCompatibility(t *testing.T) {
+	tests := []struct {
+		name              string
+		client            string
+		backend           string
+		output            string
+		runErr            error
+		expectLegacyDebug bool
+		expectInfo        string
+		expectWarning     string
+		expectError       string
+		expectNoKubectl   bool
+	}{
+		{
+			name:    "supported",
+			backend: kubectl.NodeExecBackendDebugPod,
+			output:  `{"clientVersion":{"major":"1","minor":"28","gitVersion":"v1.28.2"},"serverVersion":{"major":"1","minor":"28","gitVersion":"v1.28.2"}}`,
+		},
+		{
+			name:              "legacy_kubectl",
+			backend:           kubectl.NodeExecBackendDebugPod,
+			output:            `{"clientVersion":{"major":"1","minor":"26","gitVersion":"v1.26.3"},"serverVersion":{"major":"1","minor":"26","gitVersion":"v1.26.9"}}`,
+			expectLegacyDebug: true,
+			expectInfo:        "🔧 kubectl v1.26.3 has no kubectl debug --profile=sysadmin (added in v1.27): creating privileged debug pods from a manifest instead",
+		},
+		{
+			name:        "ephemeral_with_legacy_kubectl",
+			backend:     kubectl.NodeExecBackendEphemeral,
+			output:      `{"clientVersion":{"major":"1","minor":"26","gitVersion":"v1.26.3"},"serverVersion":{"major":"1","minor":"26","gitVersion":"v1.26.9"}}`,
+			expectError: "incompatible versions: kubectl v1.26.3 is not compatible with the ephemeral exec backend",
+		},
+		{
+			name:          "unreachable",
+			backend:       kubectl.NodeExecBackendDebugPod,
+			output:        "Unable to connect to the server: dial tcp 10.0.0.1:6443: i/o timeout",
+			runErr:        errors.New("exit status 1"),
+			expectWarning: "⚠️  Could not check kubectl and cluster versions: kubectl version failed: Unable to connect to the server: dial tcp 10.0.0.1:6443: i/o timeout",
+		},
+		{
+			name:            "native_client",
+			client:          kubectl.ClientNative,
+			backend:         kubectl.NodeExecBackendEphemeral,
+			expectNoKubectl: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A kubectl reporting the versions of the case
+			savedRunner, savedClient, savedBackend, savedLegacy := versionRunner, kubeClient, nodeExecBackend, legacyDebug
+			t.Cleanup(func() {
+				versionRunner, kubeClient, nodeExecBackend, legacyDebug = savedRunner, savedClient, savedBackend, savedLegacy
+			})
+			kubeClient, nodeExecBackend = tt.client, tt.backend
+			called := false
+			versionRunner = func(ctx context.Context, stdin []byte, args ...string) (string, error) {
+				called = true
+				return tt.output, tt.runErr
+			}
+			logger := logging.NewRecordingLogger()
+
+			// When: Check compatibility before a run
+			err := checkCompatibility(context.Background(), logger)
+
+			// Then: The run adapts, warns, or stops with the reason
+			assert.Equal(t, !tt.expectNoKubectl, called)
+			if tt.expectError != "" {
+				assert.ErrorContains(t, err, tt.expectError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectLegacyDebug, legacyDebug)
+			if tt.expectInfo != "" {
+				assert.Contains(t, logger.Messages(logging.LevelInfo), tt.expectInfo)
+			}
+			if tt.expectWarning != "" {
+				assert.Contains(t, logger.Messages(logging.LevelWarn), tt.expectWarning)
+			} else {
+				assert.Empty(t, logger.Messages(logging.LevelWarn))
+			}
+		})
+	}
+}
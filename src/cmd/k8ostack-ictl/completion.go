@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"k8ostack-ictl/internal/config"
+
+	"github.com/spf13/cobra"
+)
+
+// completionShells are the shells `kictl completion` generates a script for
+var completionShells = []string{"bash", "zsh", "fish", "powershell"}
+
+// createCompletionCommand creates the completion command printing the completion script of a shell
+func createCompletionCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "completion bash|zsh|fish|powershell",
+		Short: "Generate the shell completion script",
+		Long: `Generate the completion script of a shell, completing commands, flags, the kinds of --only and --skip,
+YAML files for --config and, from the bundle of --config, the values of --target and --nodes.`,
+		Example: `  # Load completions in the current bash session
+  source <(kictl completion bash)
+
+  # Load completions for every zsh session
+  kictl completion zsh > "${fpath[1]}/_kictl"
+
+  # Load completions for every fish session
+  kictl completion fish > ~/.config/fish/completions/kictl.fish
+
+  # Load completions in the current PowerShell session
+  kictl completion powershell | Out-String | Invoke-Expression`,
+		ValidArgs:             completionShells,
+		Args:                  cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+		DisableFlagsInUseLine: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return writeCompletionScript(cmd.Root(), args[0], cmd.OutOrStdout())
+		},
+	}
+}
+
+// writeCompletionScript writes the completion script of a shell for the root command, with descriptions
+func writeCompletionScript(root *cobra.Command, shell string, w io.Writer) error {
+	switch shell {
+	case "bash":
+		return root.GenBashCompletionV2(w, true)
+	case "zsh":
+		return root.GenZshCompletion(w)
+	case "fish":
+		return root.GenFishCompletion(w, true)
+	case "powershell":
+		return root.GenPowerShellCompletionWithDesc(w)
+	}
+	return fmt.Errorf("unsupported shell '%s'. Expected: %s", shell, strings.Join(completionShells, ", "))
+}
+
+// registerConfigCompletion completes --config and --values with YAML files
+func registerConfigCompletion(root *cobra.Command) {
+	for _, name := range []string{"config", "values"} {
+		_ = root.RegisterFlagCompletionFunc(name, func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return []string{"yaml", "yml"}, cobra.ShellCompDirectiveFilterFileExt
+		})
+	}
+}
+
+// registerFilterCompletions completes --only and --skip with the kinds, and --target and --nodes of a command
+// from the bundle of --config
+func registerFilterCompletions(cmd *cobra.Command) {
+	for _, name := range []string{"only", "skip"} {
+		if cmd.Flags().Lookup(name) != nil {
+			_ = cmd.RegisterFlagCompletionFunc(name, completeKinds)
+		}
+	}
+	if cmd.Flags().Lookup("target") != nil {
+		_ = cmd.RegisterFlagCompletionFunc("target", completeFromBundle(targetCompletions))
+	}
+	if cmd.Flags().Lookup("nodes") != nil {
+		_ = cmd.RegisterFlagCompletionFunc("nodes", completeFromBundle(nodeCompletions))
+	}
+}
+
+// completeKinds completes the last value of --only or --skip with the kinds not listed yet
+func completeKinds(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return completeList(toComplete, kindFilterNames), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeFromBundle completes the last value of a comma separated flag with the candidates of the --config bundle
+// Without --config, or with a bundle that does not load, nothing is offered rather than file names.
+func completeFromBundle(candidates func(bundle *config.ConfigBundle) []string) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if configFile == "" {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		bundle, err := loadBundle()
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return completeList(toComplete, candidates(bundle)), cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// completeList completes the last value of a comma separated list with the candidates it is a prefix of
+// Values already typed before the last comma are kept and not offered again.
+func completeList(toComplete string, candidates []string) []string {
+	typed, current := "", toComplete
+	if i := strings.LastIndex(toComplete, ","); i >= 0 {
+		typed, current = toComplete[:i+1], toComplete[i+1:]
+	}
+	listed := make(map[string]bool)
+	for _, value := range strings.Split(typed, ",") {
+		listed[value] = true
+	}
+
+	var completions []string
+	for _, candidate := range candidates {
+		if !listed[candidate] && strings.HasPrefix(candidate, current) {
+			completions = append(completions, typed+candidate)
+		}
+	}
+	return completions
+}
+
+// targetCompletions returns every resource address of the bundle with the roles, VLANs and nodes above them
+// e.g. vlan.management and vlan.management.rsb2, so a whole role or VLAN can be targeted too.
+func targetCompletions(bundle *config.ConfigBundle) []string {
+	seen := make(map[string]bool)
+	var completions []string
+	add := func(address config.ResourceAddress) {
+		if s := address.String(); !seen[s] {
+			seen[s] = true
+			completions = append(completions, s)
+		}
+	}
+
+	for _, address := range bundle.ResourceAddresses() {
+		add(config.ResourceAddress{Kind: address.Kind, Group: address.Group})
+		if address.Node != "" {
+			add(config.ResourceAddress{Kind: address.Kind, Group: address.Group, Node: address.Node})
+		}
+		add(address)
+	}
+	return completions
+}
+
+// nodeCompletions returns the node names of the bundle, leaving out node patterns
+func nodeCompletions(bundle *config.ConfigBundle) []string {
+	var completions []string
+	for _, node := range bundle.GetAllNodeNames() {
+		if !config.IsNodePattern(node) {
+			completions = append(completions, node)
+		}
+	}
+	return completions
+}
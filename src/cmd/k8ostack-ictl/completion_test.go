@@ -0,0 +1,186 @@
+// Package main provides unit tests for completing filter flags from the bundle
+// WHY: Completions must offer exactly what --target and --nodes accept, or they send users back to the YAML
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// completionTestConfig is a bundle with two roles over three nodes, one matched by pattern, and one VLAN
+const completionTestConfig = `apiVersion: openstack.kictl.icycloud.io/v1
+kind: NodeLabelConf
+metadata:
+  name: test-labels
+spec:
+  nodeRoles:
+    control:
+      nodes: [rsb2]
+      labels:
+        role: control
+    compute:
+      nodes: ["rsb1*", rsb3]
+      labels:
+        role: compute
+---
+apiVersion: openstack.kictl.icycloud.io/v1
+kind: NodeVLANConf
+metadata:
+  name: test-vlans
+spec:
+  vlans:
+    management:
+      id: 100
+      subnet: "192.168.100.0/24"
+      interface: eth0
+      nodeMapping:
+        rsb2: "192.168.100.12/24"
+        rsb3: "192.168.100.13/24"
+`
+
+// TestFilterCompletions tests completing --only, --skip, --target, --nodes and addresses from the --config bundle
+// WHY: Whole roles and VLANs must be offered as well as single items, and node patterns are no node names
+func TestFilterCompletions(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		expected []string
+	}{
+		{
+			name:     "target_vlan",
+			args:     []string{"apply", "--target", "vlan."},
+			expected: []string{"vlan.management", "vlan.management.rsb2", "vlan.management.rsb3"},
+		},
+		{
+			name: "target_role",
+			args: []string{"plan", "--target", "nodelabel.comp"},
+			expected: []string{"nodelabel.compute", "nodelabel.compute.rsb1*", "nodelabel.compute.rsb1*/role",
+				"nodelabel.compute.rsb3", "nodelabel.compute.rsb3/role"},
+		},
+		{
+			name:     "nodes",
+			args:     []string{"verify", "--nodes", ""},
+			expected: []string{"rsb2", "rsb3"},
+		},
+		{
+			name:     "nodes_after_comma",
+			args:     []string{"delete", "--nodes", "rsb2,"},
+			expected: []string{"rsb2,rsb3"},
+		},
+		{
+			name:     "addresses_argument",
+			args:     []string{"addresses", "test"},
+			expected: nil,
+		},
+		{
+			name:     "only_kinds",
+			args:     []string{"apply", "--only", "vlans,"},
+			expected: []string{"vlans,labels", "vlans,tests", "vlans,cleanup", "vlans,sysctls", "vlans,storage"},
+		},
+		{
+			name:     "skip_kind_prefix",
+			args:     []string{"plan", "--skip", "st"},
+			expected: []string{"storage"},
+		},
+		{
+			name:     "legacy_root_flag",
+			args:     []string{"--nodes", "rsb3"},
+			expected: []string{"rsb3"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A bundle file and a partially typed command line
+			configPath := filepath.Join(t.TempDir(), "cluster-config.yaml")
+			require.NoError(t, os.WriteFile(configPath, []byte(completionTestConfig), 0644))
+			rootCmd := createRootCommand()
+			out := new(bytes.Buffer)
+			rootCmd.SetOut(out)
+			rootCmd.SetErr(new(bytes.Buffer))
+			args := append([]string{"__complete", "--config", configPath}, tt.args...)
+			rootCmd.SetArgs(args)
+
+			// When: Ask for completions as the shell does
+			require.NoError(t, rootCmd.Execute())
+
+			// Then: The candidates come from the bundle, followed by the no-file directive
+			lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+			require.NotEmpty(t, lines)
+			assert.Equal(t, ":4", lines[len(lines)-1])
+			assert.Equal(t, tt.expected, nonEmpty(lines[:len(lines)-1]))
+		})
+	}
+}
+
+// TestConfigCompletion tests completing --config
+// WHY: Bundles are YAML files, so the shell must offer those and directories rather than every file
+func TestConfigCompletion(t *testing.T) {
+	// Given: A partially typed --config
+	rootCmd := createRootCommand()
+	out := new(bytes.Buffer)
+	rootCmd.SetOut(out)
+	rootCmd.SetErr(new(bytes.Buffer))
+	rootCmd.SetArgs([]string{"__complete", "apply", "--config", "clus"})
+
+	// When: Ask for completions as the shell does
+	require.NoError(t, rootCmd.Execute())
+
+	// Then: The shell is told to filter files by the YAML extensions
+	assert.Equal(t, []string{"yaml", "yml", ":8"}, nonEmpty(strings.Split(strings.TrimSpace(out.String()), "\n")))
+}
+
+// TestCompletionCommand tests generating the completion script of each shell
+// WHY: The script is sourced by shell startup files, so an unknown shell must fail instead of printing something else
+func TestCompletionCommand(t *testing.T) {
+	tests := []struct {
+		shell       string
+		expected    string
+		expectError string
+	}{
+		{shell: "bash", expected: "__start_kictl"},
+		{shell: "zsh", expected: "#compdef kictl"},
+		{shell: "fish", expected: "complete -c kictl"},
+		{shell: "powershell", expected: "Register-ArgumentCompleter"},
+		{shell: "tcsh", expectError: `invalid argument "tcsh" for "kictl completion"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.shell, func(t *testing.T) {
+			// Given: The root command
+			rootCmd := createRootCommand()
+			out := new(bytes.Buffer)
+			rootCmd.SetOut(out)
+			rootCmd.SetErr(new(bytes.Buffer))
+			rootCmd.SetArgs([]string{"completion", tt.shell})
+
+			// When: Generate the script
+			err := rootCmd.Execute()
+
+			// Then: The script of the shell is printed, or the shell is rejected
+			if tt.expectError != "" {
+				assert.ErrorContains(t, err, tt.expectError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Contains(t, out.String(), tt.expected)
+		})
+	}
+}
+
+// nonEmpty returns the lines that are not empty, or nil when there are none
+func nonEmpty(lines []string) []string {
+	var result []string
+	for _, line := range lines {
+		if line != "" {
+			result = append(result, line)
+		}
+	}
+	return result
+}
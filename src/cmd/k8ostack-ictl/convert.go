@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"k8ostack-ictl/internal/config"
+
+	"github.com/spf13/cobra"
+)
+
+// createConvertCommand creates the command that rewrites a configuration bundle to another API version
+func createConvertCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "convert",
+		Short: "Rewrite a configuration bundle to the latest API version",
+		Long: `Rewrite every document of the --config bundle to an API version, by default the
+latest. In v2, the tools of a configuration move from the top level of the
+document into spec.tools, where Defaults already keep them, so every document is
+a valid custom resource.
+
+v1 bundles keep loading as before, so converting is never required to run them.
+Comments are kept, and the converted bundle is validated before it is written.
+
+Examples:
+  # Print the v2 bundle
+  kictl convert --config cluster-config.yaml
+
+  # Rewrite the bundle in place
+  kictl convert --config cluster-config.yaml --in-place
+
+  # Go back to v1, e.g. for an older kictl
+  kictl convert --config cluster-config.yaml --to v1 --output cluster-config.v1.yaml`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			to, _ := cmd.Flags().GetString("to")
+			output, _ := cmd.Flags().GetString("output")
+			inPlace, _ := cmd.Flags().GetBool("in-place")
+			return runConvert(cmd, to, output, inPlace)
+		},
+	}
+
+	cmd.Flags().String("to", strings.TrimPrefix(config.LatestAPIVersion, config.APIGroup+"/"),
+		"API version to convert to ("+strings.Join(config.APIVersions(), ", ")+")")
+	cmd.Flags().StringP("output", "o", "", "Output file (default stdout)")
+	cmd.Flags().Bool("in-place", false, "Rewrite the --config file instead of printing the converted bundle")
+	_ = cmd.RegisterFlagCompletionFunc("to", cobra.FixedCompletions(config.APIVersions(), cobra.ShellCompDirectiveNoFileComp))
+	return cmd
+}
+
+// runConvert converts the --config bundle and writes it to stdout, an output file or back to --config
+func runConvert(cmd *cobra.Command, to, output string, inPlace bool) error {
+	if configFile == "" {
+		return configError(fmt.Errorf("configuration file is required. Use --config to specify the bundle to convert"))
+	}
+	if inPlace && output != "" {
+		return configError(fmt.Errorf("--in-place and --output cannot be used together"))
+	}
+	if inPlace {
+		output = configFile
+	}
+
+	data, err := os.ReadFile(configFile)
+	if err != nil {
+		return configError(fmt.Errorf("failed to read configuration: %w", err))
+	}
+	converted, count, err := config.ConvertDocuments(data, to)
+	if err != nil {
+		return configError(fmt.Errorf("failed to convert %s: %w", configFile, err))
+	}
+	if _, err := config.LoadConfigData(converted, configFile); err != nil {
+		return configError(fmt.Errorf("converted config is not valid: %w", err))
+	}
+
+	// Status goes to stderr when the bundle is printed to stdout
+	status := cmd.OutOrStdout()
+	if output == "" {
+		status = cmd.ErrOrStderr()
+		if _, err := cmd.OutOrStdout().Write(converted); err != nil {
+			return err
+		}
+	} else if count > 0 || !inPlace {
+		if err := os.WriteFile(output, converted, 0644); err != nil {
+			return fmt.Errorf("failed to write converted config: %w", err)
+		}
+	}
+
+	if count == 0 {
+		fmt.Fprintf(consoleOptions().Chatter(status), "✅ %s is already at %s\n", configFile, to)
+		return nil
+	}
+	fmt.Fprintf(consoleOptions().Chatter(status), "🔄 Converted %d documents of %s to %s\n", count, configFile, to)
+	return nil
+}
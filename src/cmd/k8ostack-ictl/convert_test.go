@@ -0,0 +1,123 @@
+// Package main provides unit tests for the convert command
+// WHY: convert rewrites the bundles people maintain, so a file must only change when it converts to a valid bundle
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// convertTestV1 is a v1 bundle with tools next to spec
+const convertTestV1 = `apiVersion: openstack.kictl.icycloud.io/v1
+kind: NodeLabelConf
+metadata:
+  name: labels
+spec:
+  nodeRoles:
+    compute:
+      nodes: [rsb2]
+      labels:
+        openstack-role: compute
+tools:
+  nlabel:
+    validateNodes: false
+`
+
+// TestConvertCommand tests converting a bundle to stdout, to an output file and in place
+// WHY: Printing must leave --config alone, and a bundle already at the version must not be rewritten
+func TestConvertCommand(t *testing.T) {
+	tests := []struct {
+		name           string
+		args           []string
+		data           string
+		expectStdout   string
+		expectStatus   string
+		expectFile     string
+		expectError    string
+		expectExitCode int
+	}{
+		{
+			name:         "stdout",
+			data:         convertTestV1,
+			expectStdout: "apiVersion: openstack.kictl.icycloud.io/v2",
+			expectStatus: "🔄 Converted 1 documents of",
+			expectFile:   "apiVersion: openstack.kictl.icycloud.io/v1",
+		},
+		{
+			name:         "in_place",
+			args:         []string{"--in-place"},
+			data:         convertTestV1,
+			expectStatus: "🔄 Converted 1 documents of",
+			expectFile:   "      labels:\n        openstack-role: compute\n  tools:\n    nlabel:\n",
+		},
+		{
+			name:         "already_converted",
+			args:         []string{"--to", "v1", "--in-place"},
+			data:         convertTestV1,
+			expectStatus: "is already at v1",
+			expectFile:   convertTestV1,
+		},
+		{
+			name:           "invalid_result",
+			data:           strings.Replace(convertTestV1, "kind: NodeLabelConf", "kind: NodeRouteConf", 1),
+			expectError:    "converted config is not valid",
+			expectExitCode: 2,
+		},
+		{
+			name:           "unknown_version",
+			args:           []string{"--to", "v3"},
+			data:           convertTestV1,
+			expectError:    "unsupported API version 'v3'. Expected: v1, v2",
+			expectExitCode: 2,
+		},
+		{
+			name:           "in_place_and_output",
+			args:           []string{"--in-place", "--output", "out.yaml"},
+			data:           convertTestV1,
+			expectError:    "--in-place and --output cannot be used together",
+			expectExitCode: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A bundle on disk
+			savedConfig := configFile
+			t.Cleanup(func() { configFile = savedConfig })
+			configPath := filepath.Join(t.TempDir(), "cluster-config.yaml")
+			require.NoError(t, os.WriteFile(configPath, []byte(tt.data), 0644))
+
+			// When: Convert it
+			var stdout, stderr bytes.Buffer
+			root := createRootCommand()
+			root.SetOut(&stdout)
+			root.SetErr(&stderr)
+			root.SetArgs(append([]string{"convert", "--config", configPath}, tt.args...))
+			err := root.Execute()
+
+			// Then: The bundle is printed or written, with the status apart from it
+			if tt.expectError != "" {
+				assert.ErrorContains(t, err, tt.expectError)
+				assert.Equal(t, tt.expectExitCode, exitCode(err))
+				return
+			}
+			require.NoError(t, err)
+			if tt.expectStdout != "" {
+				assert.Contains(t, stdout.String(), tt.expectStdout)
+				assert.NotContains(t, stdout.String(), "Converted")
+				assert.Contains(t, stderr.String(), tt.expectStatus)
+			} else {
+				assert.Contains(t, stdout.String(), tt.expectStatus)
+			}
+			written, err := os.ReadFile(configPath)
+			require.NoError(t, err)
+			assert.Contains(t, string(written), tt.expectFile)
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/kubectl"
+	"k8ostack-ictl/internal/labeler"
+	"k8ostack-ictl/internal/logging"
+	"k8ostack-ictl/internal/plan"
+	"k8ostack-ictl/internal/state"
+	"k8ostack-ictl/internal/vlan"
+
+	"github.com/spf13/cobra"
+)
+
+// stateFileName is the state file kept in the workspace when --state is unset
+const stateFileName = "state.json"
+
+// createDriftCommand creates the command comparing the cluster with the last applied state
+func createDriftCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "drift",
+		Short: "Report per node where the cluster differs from what kictl last applied",
+		Long: `Compare the live labels and VLAN interfaces of every node against the state
+recorded by the last apply and delete runs, and report per node what changed
+since. Exits with an error when drift is found, so it can gate automation.
+
+Examples:
+  # Check against the state in the workspace
+  kictl drift
+
+  # Check against a state shared in the cluster
+  kictl drift --state configmap:kube-system/kictl-state`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			logger, run, err := newRunLogger(cmd, "drift")
+			if err != nil {
+				return fmt.Errorf("failed to initialize logger: %w", err)
+			}
+			defer closeRun(cmd, logger, run)
+
+			store, err := openStateStore()
+			if err != nil {
+				return err
+			}
+			return runDrift(context.Background(), cmd.OutOrStdout(), logger, store, newKubectlExecutor(logger))
+		},
+	}
+}
+
+// openStateStore opens the --state location, falling back to the state file of the workspace
+func openStateStore() (state.Store, error) {
+	namespace, name, isConfigMap, err := state.ParseConfigMapLocation(stateLocation)
+	if err != nil {
+		return nil, err
+	}
+	if isConfigMap {
+		client, _, err := kubectl.NewClientset(clusterTarget())
+		if err != nil {
+			return nil, err
+		}
+		return state.NewConfigMapStore(client, namespace, name), nil
+	}
+
+	path := stateLocation
+	if path == "" {
+		ws, err := openWorkspace()
+		if err != nil {
+			return nil, err
+		}
+		path = ws.Path(stateFileName)
+	}
+	return state.NewFileStore(path), nil
+}
+
+// recordAppliedState adds the effect of an operation to the recorded state
+// bundle is what the operation applied, see appliedChanges, so drift is never checked against changes not made
+func recordAppliedState(ctx context.Context, logger logging.Logger, store state.Store, bundle *config.ConfigBundle, operation string) error {
+	applied, err := store.Load(ctx)
+	if errors.Is(err, state.ErrNotFound) {
+		applied, err = state.New(), nil
+	}
+	if err != nil {
+		return err
+	}
+
+	applied.Record(bundle, operation)
+	applied.UpdatedAt = time.Now().UTC()
+	applied.ConfigFile = configFile
+	if err := store.Save(ctx, applied); err != nil {
+		return err
+	}
+
+	logger.Info(fmt.Sprintf("💾 Recorded applied state of %d nodes in %s", len(applied.Nodes()), store))
+	return nil
+}
+
+// runDrift diffs the cluster against the recorded state and renders the drift per node
+func runDrift(ctx context.Context, out io.Writer, logger logging.Logger, store state.Store, executor kubectl.DryRunExecutor) error {
+	applied, err := store.Load(ctx)
+	if errors.Is(err, state.ErrNotFound) {
+		return fmt.Errorf("no applied state in %s: run kictl apply first", store)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "🔎 Comparing %d nodes with the state applied %s from %s\n",
+		len(applied.Nodes()), applied.UpdatedAt.Local().Format("2006-01-02 15:04:05"), store)
+
+	bundle := applied.Bundle()
+	result := &plan.Plan{}
+
+	if bundle.HasNodeLabels() {
+		labelingService := labeler.NewService(executor, labeler.Options{Verbose: verbose, Logger: logger})
+		labelPlan, err := labelingService.PlanLabels(ctx, bundle.NodeLabels, nil)
+		if err != nil {
+			return fmt.Errorf("failed to compare labels: %w", err)
+		}
+		result.Merge(labelPlan)
+	}
+
+	if bundle.HasVLANs() {
+		vlanService := vlan.NewService(executor, vlan.Options{
+			Verbose:            verbose,
+			DefaultInterface:   config.DefaultInterface,
+			InterfaceDetection: applied.InterfaceDetection,
+			InterfaceAliases:   applied.InterfaceAliases,
+			Logger:             logger,
+		})
+		vlanPlan, err := vlanService.PlanVLANs(ctx, bundle.VLANs)
+		if err != nil {
+			return fmt.Errorf("failed to compare VLANs: %w", err)
+		}
+		result.Merge(vlanPlan)
+	}
+
+	renderDrift(out, result)
+
+	if nodes := result.Nodes(); len(nodes) > 0 {
+		return fmt.Errorf("drift detected on %d nodes", len(nodes))
+	}
+	if len(result.Errors) > 0 {
+		return fmt.Errorf("drift check is incomplete: %d nodes could not be read", len(result.Errors))
+	}
+	return nil
+}
+
+// renderDrift prints the differences from the applied state grouped by node
+// The plan holds what an apply of the state would change, so additions are values missing from the cluster.
+func renderDrift(out io.Writer, result *plan.Plan) {
+	for _, node := range result.Nodes() {
+		fmt.Fprintf(out, "\nNode %s:\n", node)
+		for _, change := range result.NodeChanges(node) {
+			switch change.Op {
+			case plan.OpAdd:
+				fmt.Fprintf(out, "  - %s %s=%s is missing\n", change.Kind, change.Key, change.NewValue)
+			case plan.OpChange:
+				fmt.Fprintf(out, "  ~ %s %s: applied %s, now %s\n", change.Kind, change.Key, change.NewValue, change.OldValue)
+			}
+		}
+	}
+
+	if len(result.Errors) > 0 {
+		fmt.Fprintln(out)
+		for _, err := range result.Errors {
+			fmt.Fprintf(out, "⚠️  %v\n", err)
+		}
+	}
+
+	if len(result.Changes) == 0 && len(result.Errors) == 0 {
+		fmt.Fprintln(out, "\n✅ No drift. The cluster matches the applied state.")
+		return
+	}
+	fmt.Fprintf(out, "\n📋 Drift: %d differences on %d nodes.\n", len(result.Changes), len(result.Nodes()))
+}
@@ -0,0 +1,125 @@
+// Package main provides unit tests for the drift command
+// WHY: Drift reports are used to audit manual changes, so they must name exactly what changed
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/kubectl"
+	"k8ostack-ictl/internal/logging"
+	"k8ostack-ictl/internal/plan"
+	"k8ostack-ictl/internal/state"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// TestRenderDrift tests rendering of differences from the applied state
+// WHY: Missing and changed values must read as drift, not as pending changes
+func TestRenderDrift(t *testing.T) {
+	tests := []struct {
+		name     string
+		plan     *plan.Plan
+		expected string
+	}{
+		{
+			name: "drift_grouped_by_node",
+			plan: &plan.Plan{Changes: []plan.Change{
+				{Node: "rsb3", Kind: "vlan", Key: "storage", Op: plan.OpAdd, NewValue: "eth0.100 10.100.0.13/24"},
+				{Node: "rsb2", Kind: "label", Key: "zone", Op: plan.OpChange, OldValue: "b", NewValue: "a"},
+			}},
+			expected: "\nNode rsb2:\n" +
+				"  ~ label zone: applied a, now b\n" +
+				"\nNode rsb3:\n" +
+				"  - vlan storage=eth0.100 10.100.0.13/24 is missing\n" +
+				"\n📋 Drift: 2 differences on 2 nodes.\n",
+		},
+		{
+			name:     "no_drift",
+			plan:     &plan.Plan{},
+			expected: "\n✅ No drift. The cluster matches the applied state.\n",
+		},
+		{
+			name:     "unreadable_nodes_are_not_reported_as_clean",
+			plan:     &plan.Plan{Errors: []error{errors.New("node rsb9 not found")}},
+			expected: "\n⚠️  node rsb9 not found\n\n📋 Drift: 0 differences on 0 nodes.\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: Output buffer
+			var out bytes.Buffer
+
+			// When: Render
+			renderDrift(&out, tt.plan)
+
+			// Then: Output matches exactly
+			assert.Equal(t, tt.expected, out.String())
+		})
+	}
+}
+
+// TestRunDrift tests recording an apply and detecting manual label changes afterwards
+// WHY: The state written by apply must be what drift compares against
+func TestRunDrift(t *testing.T) {
+	ctx := context.Background()
+	store := state.NewFileStore(filepath.Join(t.TempDir(), "state.json"))
+	logger := logging.NewRecordingLogger()
+	client := fake.NewSimpleClientset(
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "rsb2", Labels: map[string]string{"openstack-role": "compute", "ceph-node": "enabled"}}},
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "rsb3", Labels: map[string]string{"openstack-role": "compute"}}},
+	)
+	executor := kubectl.NewNativeExecutorWithClient(logger, client, "default", kubectl.ExecutorOptions{})
+
+	// Given: No state recorded yet
+	var out bytes.Buffer
+	err := runDrift(ctx, &out, logger, store, executor)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no applied state")
+
+	// And: An apply recorded
+	bundle := config.NewSingleConfigBundle(&config.NodeLabelConf{
+		APIVersion: "openstack.kictl.icycloud.io/v1",
+		Kind:       "NodeLabelConf",
+		Metadata:   config.Metadata{Name: "labels"},
+		Spec: config.NodeLabelSpec{NodeRoles: map[string]config.NodeRole{
+			"compute": {Nodes: []string{"rsb2", "rsb3"}, Labels: map[string]string{"openstack-role": "compute"}},
+			"storage": {Nodes: []string{"rsb2"}, Labels: map[string]string{"ceph-node": "enabled"}},
+		}},
+	})
+	require.NoError(t, recordAppliedState(ctx, logger, store, bundle, "apply"))
+
+	// When: Cluster still matches
+	out.Reset()
+	err = runDrift(ctx, &out, logger, store, executor)
+
+	// Then: No drift
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), "No drift")
+
+	// When: Labels are changed by hand
+	node, err := client.CoreV1().Nodes().Get(ctx, "rsb2", metav1.GetOptions{})
+	require.NoError(t, err)
+	node.Labels = map[string]string{"openstack-role": "storage"}
+	_, err = client.CoreV1().Nodes().Update(ctx, node, metav1.UpdateOptions{})
+	require.NoError(t, err)
+
+	out.Reset()
+	err = runDrift(ctx, &out, logger, store, executor)
+
+	// Then: Both differences are reported for rsb2 only
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "drift detected on 1 nodes")
+	assert.Contains(t, out.String(), "  - label ceph-node=enabled is missing\n")
+	assert.Contains(t, out.String(), "  ~ label openstack-role: applied compute, now storage\n")
+	assert.NotContains(t, out.String(), "Node rsb3")
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"k8ostack-ictl/internal/config"
+)
+
+// dryRunStrictValue is the --dry-run argument that fails the run on any attempted mutation
+const dryRunStrictValue = "strict"
+
+// dryRunValue backs --dry-run, a boolean flag that also accepts --dry-run=strict and --dry-run=<kinds>
+// It reports itself as a bool so the precedence resolver can keep reading it with GetBool.
+type dryRunValue struct {
+	enabled *bool
+	strict  *bool
+	kinds   *[]string
+}
+
+// newDryRunValue binds --dry-run to the enabled, strict and kinds variables, resetting them like BoolVar does
+func newDryRunValue(enabled, strict *bool, kinds *[]string) *dryRunValue {
+	*enabled, *strict, *kinds = false, false, nil
+	return &dryRunValue{enabled: enabled, strict: strict, kinds: kinds}
+}
+
+// Set parses true, false, strict or a comma-separated list of kinds such as vlans,cleanup
+func (v *dryRunValue) Set(value string) error {
+	*v.kinds = nil
+	if value == dryRunStrictValue {
+		*v.enabled, *v.strict = true, true
+		return nil
+	}
+	enabled, err := strconv.ParseBool(value)
+	if err == nil {
+		*v.enabled, *v.strict = enabled, false
+		return nil
+	}
+
+	var kinds []string
+	for _, name := range strings.Split(value, ",") {
+		kind, ok := config.ConfigKindOf(strings.TrimSpace(name))
+		if !ok {
+			return fmt.Errorf("must be true, false, %s or kinds among nodelabels, vlans, tests, cleanup, sysctls and storage", dryRunStrictValue)
+		}
+		kinds = append(kinds, kind)
+	}
+	*v.enabled, *v.strict, *v.kinds = false, false, kinds
+	return nil
+}
+
+// String renders the flag as a bool so GetBool keeps working; a dry run of some kinds is true
+func (v *dryRunValue) String() string {
+	return strconv.FormatBool(*v.enabled || len(*v.kinds) > 0)
+}
+
+// Scope returns the configuration kinds of --dry-run=<kinds>, so the resolver only overrides their tools
+func (v *dryRunValue) Scope() []string {
+	return *v.kinds
+}
+
+// Type reports bool so the flag parses like the boolean it extends
+func (v *dryRunValue) Type() string {
+	return "bool"
+}
+
+// IsBoolFlag lets --dry-run be given without a value
+func (v *dryRunValue) IsBoolFlag() bool {
+	return true
+}
+
+// dryRunCovers reports whether --dry-run simulates the changes of a configuration kind
+func dryRunCovers(kind string) bool {
+	if dryRun {
+		return true
+	}
+	for _, dryRunKind := range dryRunKinds {
+		if dryRunKind == kind {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,143 @@
+// Package main provides unit tests for dry-run handling
+// WHY: A dry run is a promise that nothing changes, so every service is checked end to end
+package main
+
+import (
+	"context"
+	"testing"
+
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/kubectl"
+	"k8ostack-ictl/internal/labeler"
+	"k8ostack-ictl/internal/logging"
+	"k8ostack-ictl/internal/vlan"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// TestDryRunFlag_Unit tests parsing of --dry-run, --dry-run=strict and --dry-run=<kinds>
+// WHY: The flag must stay a bool for the precedence resolver while accepting strict and kinds
+func TestDryRunFlag_Unit(t *testing.T) {
+	originalDryRun, originalStrict, originalKinds := dryRun, dryRunStrict, dryRunKinds
+	defer func() { dryRun, dryRunStrict, dryRunKinds = originalDryRun, originalStrict, originalKinds }()
+
+	tests := []struct {
+		name           string
+		args           []string
+		expectedDryRun bool
+		expectedStrict bool
+		expectedKinds  []string
+		expectError    bool
+	}{
+		{name: "unset", args: nil},
+		{name: "bare flag", args: []string{"--dry-run"}, expectedDryRun: true},
+		{name: "explicit true", args: []string{"--dry-run=true"}, expectedDryRun: true},
+		{name: "explicit false", args: []string{"--dry-run=false"}},
+		{name: "strict", args: []string{"--dry-run=strict"}, expectedDryRun: true, expectedStrict: true},
+		{name: "kinds", args: []string{"--dry-run=vlans,cleanup"}, expectedKinds: []string{"NodeVLANConf", "CleanupConf"}},
+		{name: "configuration kind", args: []string{"--dry-run=NodeLabelConf"}, expectedKinds: []string{"NodeLabelConf"}},
+		{name: "sysctls", args: []string{"--dry-run=sysctls"}, expectedKinds: []string{"NodeSysctlConf"}},
+		{name: "storage", args: []string{"--dry-run=storage"}, expectedKinds: []string{"NodeStorageConf"}},
+		{name: "invalid value", args: []string{"--dry-run=maybe"}, expectError: true},
+		{name: "invalid kind", args: []string{"--dry-run=vlans,switches"}, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: Fresh root command
+			dryRun, dryRunStrict, dryRunKinds = false, false, nil
+			cmd := createRootCommand()
+
+			// When: Parse the flags
+			err := cmd.PersistentFlags().Parse(tt.args)
+
+			// Then: The variables agree, and GetBool is true for a dry run of some kinds too
+			if tt.expectError {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "must be true, false, strict or kinds among nodelabels, vlans, tests, cleanup, sysctls and storage")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedDryRun, dryRun)
+			assert.Equal(t, tt.expectedStrict, dryRunStrict)
+			assert.Equal(t, tt.expectedKinds, dryRunKinds)
+			value, err := cmd.PersistentFlags().GetBool("dry-run")
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedDryRun || len(tt.expectedKinds) > 0, value)
+		})
+	}
+}
+
+// TestDryRunNoMutations_Unit runs the mutating services in a strict dry run against a fake API server
+// WHY: Any write reaching the cluster during a dry run is a bug, strict mode would surface it as an error
+func TestDryRunNoMutations_Unit(t *testing.T) {
+	ctx := context.Background()
+	nodes := []string{"rsb2", "rsb3"}
+
+	labelConfig := &config.NodeLabelConf{
+		APIVersion: "openstack.kictl.icycloud.io/v1",
+		Kind:       "NodeLabelConf",
+		Metadata:   config.Metadata{Name: "dry-run-labels"},
+		Spec: config.NodeLabelSpec{
+			NodeRoles: map[string]config.NodeRole{
+				"compute": {Nodes: nodes, Labels: map[string]string{"openstack-role": "compute"}},
+			},
+		},
+	}
+	vlanConfig := &config.NodeVLANConf{
+		APIVersion: "openstack.kictl.icycloud.io/v1",
+		Kind:       "NodeVLANConf",
+		Metadata:   config.Metadata{Name: "dry-run-vlans"},
+		Spec: config.NodeVLANSpec{
+			VLANs: map[string]config.VLANConfig{
+				"management": {
+					ID:          100,
+					Subnet:      "192.168.100.0/24",
+					Interface:   "eth0",
+					NodeMapping: map[string]string{"rsb2": "192.168.100.10/24", "rsb3": "192.168.100.11/24"},
+				},
+			},
+		},
+	}
+
+	for _, backend := range []string{kubectl.NodeExecBackendDebugPod, kubectl.NodeExecBackendEphemeral, kubectl.NodeExecBackendAgent} {
+		t.Run(backend, func(t *testing.T) {
+			// Given: Strict dry-run executor against a fake cluster
+			client := fake.NewSimpleClientset(
+				&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "rsb2"}},
+				&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "rsb3"}},
+			)
+			logger := logging.NewRecordingLogger()
+			executor := kubectl.NewNativeExecutorWithClient(logger, client, "default",
+				kubectl.ExecutorOptions{NodeExecBackend: backend, StrictDryRun: true})
+			executor.SetPollingInterval(0)
+
+			labels := labeler.NewService(executor, labeler.Options{DryRun: true, ValidateNodes: true, Logger: logger})
+			vlans := vlan.NewService(executor, vlan.Options{DryRun: true, ValidateConnectivity: true, DefaultInterface: "eth0", Logger: logger})
+
+			// When: Apply and remove every configuration
+			labelResults, err := labels.ApplyLabels(ctx, labelConfig)
+			require.NoError(t, err)
+			assert.Empty(t, labelResults.Errors)
+			labelResults, err = labels.RemoveLabels(ctx, labelConfig)
+			require.NoError(t, err)
+			assert.Empty(t, labelResults.Errors)
+			vlanResults, err := vlans.ConfigureVLANs(ctx, vlanConfig)
+			require.NoError(t, err)
+			assert.Empty(t, vlanResults.Errors)
+			vlanResults, err = vlans.RemoveVLANs(ctx, vlanConfig)
+			require.NoError(t, err)
+			assert.Empty(t, vlanResults.Errors)
+
+			// Then: The API server only saw reads
+			for _, action := range client.Actions() {
+				assert.Contains(t, []string{"get", "list"}, action.GetVerb(), "dry-run should only read, got %s %s", action.GetVerb(), action.GetResource().Resource)
+			}
+			assert.Empty(t, logger.Messages(logging.LevelWarn))
+		})
+	}
+}
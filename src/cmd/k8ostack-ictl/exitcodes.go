@@ -0,0 +1,75 @@
+package main
+
+import (
+	"errors"
+
+	"k8ostack-ictl/internal/kubectl"
+	"k8ostack-ictl/internal/summary"
+)
+
+// Exit codes of kictl, so scripts can branch on why a command failed
+const (
+	exitFailure      = 1 // Any other failure
+	exitConfig       = 2 // Invalid flags or configuration; the cluster was not touched
+	exitPartial      = 3 // Some nodes or services failed
+	exitDeviation    = 4 // verify found nodes deviating from the bundle
+	exitConnectivity = 5 // The cluster could not be reached or refused access
+)
+
+// exitCodeError is an error ending kictl with a specific exit code
+type exitCodeError struct {
+	code int
+	err  error
+}
+
+// Error returns the message of the wrapped error
+func (e *exitCodeError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the wrapped error
+func (e *exitCodeError) Unwrap() error {
+	return e.err
+}
+
+// configError marks an error in flags or configuration, returning nil for nil
+func configError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &exitCodeError{code: exitConfig, err: err}
+}
+
+// runFailedError ends a run that completed with errors, with exitConnectivity when every failure the run
+// recorded was the cluster being unreachable or refusing access, and exitPartial otherwise
+func runFailedError(err error, failures *summary.Summary) error {
+	code := exitPartial
+	if errs := failures.Errors(); len(errs) > 0 {
+		code = exitConnectivity
+		for _, failure := range errs {
+			if !isConnectivityError(failure) {
+				code = exitPartial
+				break
+			}
+		}
+	}
+	return &exitCodeError{code: code, err: err}
+}
+
+// isConnectivityError reports whether an error reads like the API server being unreachable or refusing access
+func isConnectivityError(err error) bool {
+	return kubectl.ClassifyHostCommandFailure("", err) == kubectl.HostErrorTransport
+}
+
+// exitCode returns the exit code kictl ends with after a command failed with the error
+// Errors without a code of their own exit with exitConnectivity when they read like API or RBAC failures.
+func exitCode(err error) int {
+	var codeErr *exitCodeError
+	if errors.As(err, &codeErr) {
+		return codeErr.code
+	}
+	if isConnectivityError(err) {
+		return exitConnectivity
+	}
+	return exitFailure
+}
@@ -0,0 +1,67 @@
+// Package main provides unit tests for the exit codes of kictl
+// WHY: Scripts branch on the exit code, so each failure class must keep its own code
+package main
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"k8ostack-ictl/internal/kubectl"
+	"k8ostack-ictl/internal/summary"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestExitCode tests the exit code of command errors
+// WHY: Errors without a code of their own must keep exiting with 1 unless they read like the cluster refusing access
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name         string
+		err          error
+		expectedCode int
+	}{
+		{name: "other", err: errors.New("failed to write the JUnit report"), expectedCode: exitFailure},
+		{name: "config", err: fmt.Errorf("apply: %w", configError(errors.New("failed to load configuration"))), expectedCode: exitConfig},
+		{name: "deviation", err: &exitCodeError{code: exitDeviation, err: errors.New("deviations")}, expectedCode: exitDeviation},
+		{name: "unreachable", err: errors.New("failed to list quarantined nodes: Unable to connect to the server: dial tcp 10.0.0.1:6443: i/o timeout"), expectedCode: exitConnectivity},
+		{name: "forbidden", err: errors.New(`nodes "rsb2" is forbidden: User "ci" cannot patch resource "nodes"`), expectedCode: exitConnectivity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expectedCode, exitCode(tt.err))
+		})
+	}
+	assert.NoError(t, configError(nil))
+}
+
+// TestRunFailedError tests the exit code of runs that completed with errors
+// WHY: A cluster that was down for the whole run needs a different response than a few broken nodes
+func TestRunFailedError(t *testing.T) {
+	transport := &kubectl.HostCommandError{Node: "rsb2", Class: kubectl.HostErrorTransport, Cause: errors.New("debug pod not scheduled")}
+	tests := []struct {
+		name         string
+		errs         []error
+		expectedCode int
+	}{
+		{name: "nothing_attributed", expectedCode: exitPartial},
+		{name: "node_failures", errs: []error{transport, &kubectl.HostCommandError{Node: "rsb3", Class: kubectl.HostErrorCommand, Cause: errors.New("exit status 2")}}, expectedCode: exitPartial},
+		{name: "cluster_unreachable", errs: []error{transport, errors.New("failed to label node rsb3: Unable to connect to the server")}, expectedCode: exitConnectivity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: The failures a run recorded
+			failures := summary.New()
+			failures.Add("NodeVLANConf", tt.errs...)
+
+			// When: End the run
+			err := runFailedError(errors.New("operation completed with 2 errors"), failures)
+
+			// Then: The message stays, with the code of the failures
+			assert.EqualError(t, err, "operation completed with 2 errors")
+			assert.Equal(t, tt.expectedCode, exitCode(err))
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+
+	"k8ostack-ictl/internal/config/precedence"
+	"k8ostack-ictl/internal/logging"
+
+	"github.com/spf13/cobra"
+)
+
+// newPrecedenceResolver creates the precedence resolver of a command, tracing its decisions with --explain-config
+func newPrecedenceResolver(cmd *cobra.Command) *precedence.GlobalResolver {
+	resolver := precedence.NewGlobalResolver(cmd)
+	resolver.SetTrace(explainConfig)
+	return resolver
+}
+
+// printConfigDecisions prints where every tool setting got its value from when --explain-config is set
+func printConfigDecisions(cmd *cobra.Command, resolver *precedence.GlobalResolver) {
+	if !explainConfig {
+		return
+	}
+	out := cmd.OutOrStdout()
+	printSettingsFlags(out)
+	fmt.Fprintf(out, "🔍 Configuration decisions (%s → %s → %s → %s):\n",
+		precedence.SourceDefault, precedence.SourceConfig, precedence.SourceEnv, precedence.SourceFlag)
+	for _, decision := range resolver.Trace() {
+		fmt.Fprintf(out, "  %s\n", decision)
+	}
+}
+
+// warnUnsafeOverrides logs every override turning off a safety setting of the config file, as --allow-unsafe-override allowed
+func warnUnsafeOverrides(logger logging.Logger, resolver *precedence.GlobalResolver) {
+	for _, message := range resolver.UnsafeOverrides() {
+		logger.Warn(fmt.Sprintf("🚨 UNSAFE OVERRIDE: %s", message))
+	}
+}
@@ -0,0 +1,34 @@
+// Package main provides unit tests for reporting how tool settings were resolved
+// WHY: An override silently undoing a safety of the config file would change the cluster when the file promised a dry run
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestApplyUnsafeOverride tests that apply refuses to turn off dryRun: true of the config file without acknowledgment
+// WHY: The config file is the deliberate safety, so the flag turning it off must be confirmed before touching the cluster
+func TestApplyUnsafeOverride(t *testing.T) {
+	// Given: A bundle file whose VLANs are a dry run, and a workspace
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "cluster-config.yaml")
+	content := strings.Replace(exportTestConfig, "  name: test-vlans\n", "  name: test-vlans\ntools:\n  nvlan:\n    dryRun: true\n", 1)
+	require.NoError(t, os.WriteFile(configPath, []byte(content), 0644))
+	rootCmd := createRootCommand()
+	rootCmd.SetOut(new(bytes.Buffer))
+	rootCmd.SetErr(new(bytes.Buffer))
+	rootCmd.SetArgs([]string{"apply", "--config", configPath, "--dry-run=false", "--workspace", dir})
+
+	// When: Apply with the dry run turned off on the command line
+	err := rootCmd.Execute()
+
+	// Then: The run is refused until the override is acknowledged
+	assert.ErrorContains(t, err, "NodeVLANConf/test-vlans tools.nvlan.dryRun is true in the config file, flag --dry-run turns it off; pass --allow-unsafe-override to confirm")
+}
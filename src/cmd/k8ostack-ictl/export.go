@@ -0,0 +1,236 @@
+package main
+
+import (
+	"fmt"
+
+	"k8ostack-ictl/internal/ansible"
+	"k8ostack-ictl/internal/multus"
+	"k8ostack-ictl/internal/segmentation"
+
+	"github.com/spf13/cobra"
+)
+
+// createExportCommand creates the command group exporting the bundle for other tools
+func createExportCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export the configuration bundle for other tools",
+	}
+
+	exportAnsible := &cobra.Command{
+		Use:   "ansible",
+		Short: "Export roles, labels and VLAN addresses as an Ansible inventory with host_vars",
+		Long: `Export the bundle as an Ansible inventory, so playbooks configuring the nodes
+after kictl read the same roles and addressing instead of a copy of them.
+
+Every role and every VLAN becomes a group (kictl_<role>, kictl_vlan_<name>).
+Each host gets kictl_roles, kictl_labels and kictl_vlans variables; a VLAN has
+its id, subnet, address, ip, parent interface and device. Nothing is read from the
+cluster, so roles targeting nodes by pattern or nodeSelector only export their
+listed nodes.
+
+Without --output-dir the inventory is printed with the variables inline.
+
+Examples:
+  kictl export ansible --config cluster-config.yaml --output-dir inventory/
+  ansible-playbook -i inventory/ openstack.yml
+
+  kictl export ansible --config cluster-config.yaml > inventory.yml`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if configFile == "" {
+				return fmt.Errorf("configuration file is required. Use --config to specify a YAML file")
+			}
+			outputDir, _ := cmd.Flags().GetString("output-dir")
+
+			bundle, err := loadBundle()
+			if err != nil {
+				return fmt.Errorf("failed to load configuration: %w", err)
+			}
+			inv, err := ansible.FromBundle(bundle)
+			if err != nil {
+				return err
+			}
+			for _, warning := range inv.Warnings {
+				fmt.Fprintf(cmd.ErrOrStderr(), "⚠️  %s\n", warning)
+			}
+
+			if outputDir == "" {
+				data, err := inv.MarshalHosts(true)
+				if err != nil {
+					return err
+				}
+				_, err = cmd.OutOrStdout().Write(data)
+				return err
+			}
+
+			written, err := inv.WriteDir(outputDir)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "📝 Wrote inventory of %d hosts and %d groups to %s (%d files)\n",
+				len(inv.Hosts), len(inv.Groups), outputDir, len(written))
+			return nil
+		},
+	}
+	exportAnsible.Flags().String("output-dir", "", "Write hosts.yml and host_vars/<node>.yml into this directory")
+
+	cmd.AddCommand(exportAnsible, createExportMultusCommand(), createExportFirewallCommand())
+	return cmd
+}
+
+// createExportMultusCommand creates the command generating NetworkAttachmentDefinitions from the VLANs of the bundle
+func createExportMultusCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "multus",
+		Short: "Generate Multus NetworkAttachmentDefinitions for the VLANs, optionally applying them",
+		Long: `Generate one Multus NetworkAttachmentDefinition per VLAN, so pods attach to the
+VLAN interfaces kictl creates without a second copy of the VLAN layout.
+
+Each definition is named after its VLAN and runs macvlan or ipvlan on the VLAN
+interface (<parent>.<id>). Addresses come from whereabouts over the VLAN subnet
+with the node addresses excluded, or from the pods themselves with --ipam static.
+VLANs whose parent interface is detected or differs per node are skipped with a
+warning, since a definition names a single master interface.
+
+Without --output-dir the definitions are printed as a YAML stream. --apply applies
+them with kubectl; with --dry-run they are only validated by the API server.
+
+Examples:
+  kictl export multus --config cluster-config.yaml > attachments.yaml
+  kictl export multus --config cluster-config.yaml --plugin ipvlan --output-dir nads/
+  kictl export multus --config cluster-config.yaml --attachment-namespace openstack --apply`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if configFile == "" {
+				return fmt.Errorf("configuration file is required. Use --config to specify a YAML file")
+			}
+			outputDir, _ := cmd.Flags().GetString("output-dir")
+			apply, _ := cmd.Flags().GetBool("apply")
+			var opts multus.Options
+			opts.Namespace, _ = cmd.Flags().GetString("attachment-namespace")
+			opts.Plugin, _ = cmd.Flags().GetString("plugin")
+			opts.Mode, _ = cmd.Flags().GetString("mode")
+			opts.IPAM, _ = cmd.Flags().GetString("ipam")
+
+			bundle, err := loadBundle()
+			if err != nil {
+				return fmt.Errorf("failed to load configuration: %w", err)
+			}
+			if !bundle.HasVLANs() {
+				return fmt.Errorf("the configuration holds no NodeVLANConf to generate NetworkAttachmentDefinitions from")
+			}
+			attachments, err := multus.FromBundle(bundle, opts)
+			if err != nil {
+				return err
+			}
+			for _, warning := range attachments.Warnings {
+				fmt.Fprintf(cmd.ErrOrStderr(), "⚠️  %s\n", warning)
+			}
+
+			switch {
+			case outputDir != "":
+				written, err := attachments.WriteDir(outputDir)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "📝 Wrote %d NetworkAttachmentDefinitions to %s\n", len(written), outputDir)
+			case !apply:
+				data, err := attachments.Marshal()
+				if err != nil {
+					return err
+				}
+				if _, err := cmd.OutOrStdout().Write(data); err != nil {
+					return err
+				}
+			}
+
+			if !apply {
+				return nil
+			}
+			// Manifests may be on stdout, so the log goes to stderr
+			logger, run, err := newRunLoggerTo(cmd.ErrOrStderr(), "export")
+			if err != nil {
+				return fmt.Errorf("failed to initialize logger: %w", err)
+			}
+			defer closeRun(cmd, logger, run)
+			validateOnly := dryRunCovers("NodeVLANConf")
+			if !validateOnly {
+				protected, err := enforceProtection(cmd.Context(), cmd, logger, newPrecedenceResolver(cmd), "apply NetworkAttachmentDefinitions")
+				if err != nil {
+					return err
+				}
+				defer protected.release(cmd.Context(), logger)
+
+				stopAudit, err := startAudit(logger, bundle, "export multus")
+				if err != nil {
+					return fmt.Errorf("failed to open the audit log: %w", err)
+				}
+				defer stopAudit()
+			}
+			output, err := attachments.Apply(cmd.Context(), validateOnly, newKubectlExecutor(logger).RunKubectl)
+			if err != nil {
+				return err
+			}
+			if output != "" {
+				fmt.Fprintln(cmd.OutOrStdout(), output)
+			}
+			if validateOnly {
+				fmt.Fprintf(cmd.OutOrStdout(), "🧪 Validated %d NetworkAttachmentDefinitions without applying them\n", len(attachments.Definitions))
+			} else {
+				fmt.Fprintf(cmd.OutOrStdout(), "✅ Applied %d NetworkAttachmentDefinitions\n", len(attachments.Definitions))
+			}
+			return nil
+		},
+	}
+	cmd.Flags().String("output-dir", "", "Write one <vlan>.yaml file per definition into this directory")
+	cmd.Flags().String("attachment-namespace", "", "Namespace of the definitions (default the namespace of the context)")
+	cmd.Flags().String("plugin", multus.PluginMacvlan, "CNI plugin attaching pods to the VLAN interface: macvlan or ipvlan")
+	cmd.Flags().String("mode", "", "Mode of the plugin (default bridge for macvlan, l2 for ipvlan)")
+	cmd.Flags().String("ipam", multus.IPAMWhereabouts, "Pod address assignment: whereabouts (pool of the VLAN subnet) or static")
+	cmd.Flags().Bool("apply", false, "Apply the definitions to the cluster with kubectl")
+	return cmd
+}
+
+// createExportFirewallCommand creates the command suggesting firewall rules from the isolation tests
+func createExportFirewallCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "firewall",
+		Short: "Report the traffic the isolation tests expect to be blocked, or suggest firewall rules for it",
+		Long: `Derive the expected-deny pairs of the bundle: for every connectivity test with
+expectSuccess false, traffic from the subnet of its source VLAN to the subnet of
+each target VLAN. They describe the segmentation the physical or virtual network
+must enforce between the VLANs.
+
+The report names the nodes on both VLANs of a pair, since their traffic never
+crosses a firewall. iptables and nftables print FORWARD rules dropping each pair,
+to review and apply on the routers or firewalls between the VLANs. Tests naming
+a network that is no VLAN of the bundle are skipped with a warning.
+
+Examples:
+  kictl export firewall --config cluster-config.yaml
+  kictl export firewall --config cluster-config.yaml --format nftables > segmentation.nft`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if configFile == "" {
+				return fmt.Errorf("configuration file is required. Use --config to specify a YAML file")
+			}
+			format, _ := cmd.Flags().GetString("format")
+
+			bundle, err := loadBundle()
+			if err != nil {
+				return fmt.Errorf("failed to load configuration: %w", err)
+			}
+			if !bundle.HasTests() {
+				return fmt.Errorf("the configuration holds no NodeTestConf to derive isolation from")
+			}
+			plan := segmentation.FromBundle(bundle)
+			for _, warning := range plan.Warnings {
+				fmt.Fprintf(cmd.ErrOrStderr(), "⚠️  %s\n", warning)
+			}
+			return plan.Write(cmd.OutOrStdout(), format)
+		},
+	}
+	cmd.Flags().String("format", segmentation.FormatReport, "Output format: report, iptables or nftables")
+	return cmd
+}
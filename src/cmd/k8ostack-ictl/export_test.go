@@ -0,0 +1,214 @@
+// Package main provides unit tests for the export command
+// WHY: Playbooks consume the exported inventory directly, so the command must write it where and how they expect
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// exportTestConfig labels one node and gives it a VLAN
+const exportTestConfig = `apiVersion: openstack.kictl.icycloud.io/v1
+kind: NodeLabelConf
+metadata:
+  name: test-labels
+spec:
+  nodeRoles:
+    compute:
+      nodes: [rsb3]
+      labels:
+        role: compute
+---
+apiVersion: openstack.kictl.icycloud.io/v1
+kind: NodeVLANConf
+metadata:
+  name: test-vlans
+spec:
+  vlans:
+    management:
+      id: 100
+      subnet: "192.168.100.0/24"
+      interface: eth0
+      nodeMapping:
+        rsb3: "192.168.100.13/24"
+`
+
+// TestExportAnsible tests printing and writing the Ansible inventory
+// WHY: The same bundle must give the same inventory inline and as a directory
+func TestExportAnsible(t *testing.T) {
+	tests := []struct {
+		name          string
+		outputDir     bool
+		expected      []string
+		expectedFiles []string
+	}{
+		{
+			name:     "inline",
+			expected: []string{"kictl_compute:", "kictl_vlan_management:", "device: eth0.100", "ip: 192.168.100.13"},
+		},
+		{
+			name:          "output_dir",
+			outputDir:     true,
+			expected:      []string{"Wrote inventory of 1 hosts and 2 groups"},
+			expectedFiles: []string{"host_vars/rsb3.yml", "hosts.yml"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A bundle file
+			dir := t.TempDir()
+			configPath := filepath.Join(dir, "cluster-config.yaml")
+			require.NoError(t, os.WriteFile(configPath, []byte(exportTestConfig), 0644))
+			inventoryDir := filepath.Join(dir, "inventory")
+			args := []string{"export", "ansible", "--config", configPath}
+			if tt.outputDir {
+				args = append(args, "--output-dir", inventoryDir)
+			}
+			rootCmd := createRootCommand()
+			out := new(bytes.Buffer)
+			rootCmd.SetOut(out)
+			rootCmd.SetErr(new(bytes.Buffer))
+			rootCmd.SetArgs(args)
+
+			// When: Export the inventory
+			err := rootCmd.Execute()
+
+			// Then: It is printed or written
+			require.NoError(t, err)
+			for _, expected := range tt.expected {
+				assert.Contains(t, out.String(), expected)
+			}
+			for _, file := range tt.expectedFiles {
+				assert.FileExists(t, filepath.Join(inventoryDir, file))
+			}
+		})
+	}
+}
+
+// TestExportMultus tests printing and writing the NetworkAttachmentDefinitions of the VLANs
+// WHY: Pods attach to the VLAN interfaces through these definitions, so they must name the interface kictl creates
+func TestExportMultus(t *testing.T) {
+	tests := []struct {
+		name          string
+		args          []string
+		expectedError string
+		expected      []string
+		expectedFiles []string
+	}{
+		{
+			name:     "printed",
+			expected: []string{"kind: NetworkAttachmentDefinition", "name: management", `"master": "eth0.100"`, `"192.168.100.13/32"`},
+		},
+		{
+			name:     "ipvlan",
+			args:     []string{"--plugin", "ipvlan"},
+			expected: []string{`"type": "ipvlan"`, `"mode": "l2"`},
+		},
+		{
+			name:          "output_dir",
+			args:          []string{"--output-dir"},
+			expected:      []string{"Wrote 1 NetworkAttachmentDefinitions"},
+			expectedFiles: []string{"management.yaml"},
+		},
+		{
+			name:          "unknown_mode",
+			args:          []string{"--mode", "l3"},
+			expectedError: "unknown macvlan mode 'l3'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A bundle file with a VLAN
+			dir := t.TempDir()
+			configPath := filepath.Join(dir, "cluster-config.yaml")
+			require.NoError(t, os.WriteFile(configPath, []byte(exportTestConfig), 0644))
+			outputDir := filepath.Join(dir, "nads")
+			args := []string{"export", "multus", "--config", configPath}
+			for _, arg := range tt.args {
+				args = append(args, arg)
+				if arg == "--output-dir" {
+					args = append(args, outputDir)
+				}
+			}
+			rootCmd := createRootCommand()
+			out := new(bytes.Buffer)
+			rootCmd.SetOut(out)
+			rootCmd.SetErr(new(bytes.Buffer))
+			rootCmd.SetArgs(args)
+
+			// When: Export the definitions
+			err := rootCmd.Execute()
+
+			// Then: They are printed or written, or the options are rejected
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			for _, expected := range tt.expected {
+				assert.Contains(t, out.String(), expected)
+			}
+			for _, file := range tt.expectedFiles {
+				assert.FileExists(t, filepath.Join(outputDir, file))
+			}
+		})
+	}
+}
+
+// TestExportFirewall tests printing the expected-deny pairs of the isolation tests
+// WHY: Without a NodeTestConf there is nothing to derive, which must be said rather than printing no rules
+func TestExportFirewall(t *testing.T) {
+	isolationTests := `---
+apiVersion: openstack.kictl.icycloud.io/v1
+kind: NodeTestConf
+metadata:
+  name: test-isolation
+spec:
+  tests:
+    - name: management-isolation
+      source: management
+      targets: [storage]
+`
+	tests := []struct {
+		name          string
+		config        string
+		format        string
+		expected      string
+		expectedError string
+	}{
+		{name: "report", config: exportTestConfig + isolationTests, format: "report", expected: "test management-isolation: target storage is no VLAN of the bundle; skipped"},
+		{name: "nftables", config: exportTestConfig + isolationTests, format: "nftables", expected: "table inet kictl_segmentation {"},
+		{name: "no_tests", config: exportTestConfig, format: "report", expectedError: "the configuration holds no NodeTestConf"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A bundle file
+			configPath := filepath.Join(t.TempDir(), "cluster-config.yaml")
+			require.NoError(t, os.WriteFile(configPath, []byte(tt.config), 0644))
+			rootCmd := createRootCommand()
+			out := new(bytes.Buffer)
+			rootCmd.SetOut(out)
+			rootCmd.SetErr(out)
+			rootCmd.SetArgs([]string{"export", "firewall", "--config", configPath, "--format", tt.format})
+
+			// When: Export the expected-deny pairs
+			err := rootCmd.Execute()
+
+			// Then: They are printed in the format, with a warning for networks that are no VLAN
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Contains(t, out.String(), tt.expected)
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+
+	"k8ostack-ictl/internal/fixtures"
+
+	"github.com/spf13/cobra"
+)
+
+// createFixturesCommand creates the command that writes a synthetic cluster snapshot of a bundle
+// Downstream CI uses the snapshot to check configs against kictl without cluster access
+func createFixturesCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "fixtures",
+		Short: "Generate a synthetic cluster snapshot from a configuration bundle",
+		Long: `Generate a ClusterSnapshot with every node the bundle references: a synthetic
+InternalIP, the hostname label and the physical interfaces the VLANs need.
+With --applied the role labels and VLAN interfaces are present as well.
+
+Examples:
+  kictl generate fixtures --config cluster-config.yaml
+  kictl generate fixtures --config cluster-config.yaml --applied -o applied-fixtures.yaml`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if configFile == "" {
+				return fmt.Errorf("configuration file is required. Use --config to specify a YAML file")
+			}
+
+			bundle, err := loadBundle()
+			if err != nil {
+				return fmt.Errorf("failed to load configuration: %w", err)
+			}
+
+			applied, _ := cmd.Flags().GetBool("applied")
+			output, _ := cmd.Flags().GetString("output")
+
+			snapshot, err := fixtures.Generate(bundle, fixtures.Options{Applied: applied})
+			if err != nil {
+				return fmt.Errorf("failed to generate fixtures: %w", err)
+			}
+			if err := fixtures.Save(snapshot, output); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "✅ Wrote fixtures for %d nodes to %s\n", len(snapshot.Nodes), output)
+			return nil
+		},
+	}
+
+	cmd.Flags().Bool("applied", false, "Include the role labels and VLAN interfaces as if the bundle was applied")
+	cmd.Flags().StringP("output", "o", "fixtures.yaml", "Output file")
+	return cmd
+}
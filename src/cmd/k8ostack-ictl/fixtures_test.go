@@ -0,0 +1,87 @@
+// Package main provides unit tests for the fixtures command
+// WHY: Downstream CI consumes the written snapshot, so the command must write a loadable file
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"k8ostack-ictl/internal/fixtures"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFixturesCommand tests snapshot generation through generate fixtures
+// WHY: The snapshot must only contain the VLAN interfaces when --applied is given
+func TestFixturesCommand(t *testing.T) {
+	bundleYAML := `apiVersion: openstack.kictl.icycloud.io/v1
+kind: NodeVLANConf
+metadata:
+  name: vlans
+spec:
+  vlans:
+    storage:
+      id: 100
+      subnet: 10.100.0.0/24
+      nodeMapping:
+        rsb2: 10.100.0.12/24
+`
+
+	tests := []struct {
+		name               string
+		extraArgs          []string
+		expectedInterfaces []string
+	}{
+		{"unapplied", nil, []string{"eth0"}},
+		{"applied", []string{"--applied"}, []string{"eth0", "eth0.100"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A VLAN bundle on disk
+			tempDir := t.TempDir()
+			bundlePath := filepath.Join(tempDir, "bundle.yaml")
+			outputPath := filepath.Join(tempDir, "fixtures.yaml")
+			require.NoError(t, os.WriteFile(bundlePath, []byte(bundleYAML), 0644))
+			defer func() { configFile = "" }()
+
+			// When: Execute generate fixtures
+			var out bytes.Buffer
+			root := createRootCommand()
+			root.SetOut(&out)
+			root.SetErr(new(bytes.Buffer))
+			root.SetArgs(append([]string{"generate", "fixtures", "--config", bundlePath, "-o", outputPath}, tt.extraArgs...))
+			err := root.Execute()
+
+			// Then: The snapshot loads back with the expected interfaces
+			require.NoError(t, err)
+			assert.Contains(t, out.String(), "Wrote fixtures for 1 nodes")
+			snapshot, err := fixtures.Load(outputPath)
+			require.NoError(t, err)
+			node, ok := snapshot.Node("rsb2")
+			require.True(t, ok)
+			var names []string
+			for _, iface := range node.Interfaces {
+				names = append(names, iface.Name)
+			}
+			assert.Equal(t, tt.expectedInterfaces, names)
+		})
+	}
+}
+
+// TestFixturesCommand_RequiresConfig tests the missing configuration error
+// WHY: Fixtures are generated from a bundle, so there is nothing to write without one
+func TestFixturesCommand_RequiresConfig(t *testing.T) {
+	root := createRootCommand()
+	root.SetOut(new(bytes.Buffer))
+	root.SetErr(new(bytes.Buffer))
+	root.SetArgs([]string{"generate", "fixtures"})
+
+	err := root.Execute()
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "configuration file is required")
+}
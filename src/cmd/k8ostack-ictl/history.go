@@ -0,0 +1,213 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/history"
+	"k8ostack-ictl/internal/logging"
+
+	"github.com/spf13/cobra"
+)
+
+// createSnapshotCommand creates the command that records node metadata snapshots on demand
+// Intended to be run periodically (e.g. from cron) to catch changes made outside kictl
+func createSnapshotCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "snapshot",
+		Short: "Record a snapshot of node labels, annotations and VLAN state",
+		Long: `Record a snapshot of the kictl-relevant metadata of every node referenced
+by the configuration into the history directory.
+
+Examples:
+  # Snapshot all nodes referenced by a configuration
+  kictl snapshot --config cluster-config.yaml`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if configFile == "" {
+				return fmt.Errorf("configuration file is required. Use --config to specify a YAML file")
+			}
+
+			bundle, err := loadBundle()
+			if err != nil {
+				return fmt.Errorf("failed to load configuration: %w", err)
+			}
+
+			logger, run, err := newRunLogger(cmd, "snapshot")
+			if err != nil {
+				return fmt.Errorf("failed to initialize logger: %w", err)
+			}
+			defer closeRun(cmd, logger, run)
+
+			store, err := openHistoryStore()
+			if err != nil {
+				return err
+			}
+
+			return recordRunHistory(context.Background(), logger, store, newKubectlExecutor(logger), bundle, config.NewEmptyBundle(), "snapshot")
+		},
+	}
+
+	return cmd
+}
+
+// createTimelineCommand creates the command group for viewing recorded history
+func createTimelineCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "timeline",
+		Short: "Show recorded metadata changes over time",
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "node <name>",
+		Short: "Show when each label, annotation and VLAN change happened on a node and which run caused it",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := openHistoryStore()
+			if err != nil {
+				return err
+			}
+			return printNodeTimeline(cmd.OutOrStdout(), store, args[0])
+		},
+	})
+
+	return cmd
+}
+
+// printNodeTimeline renders the change timeline of a node
+func printNodeTimeline(out io.Writer, store history.Store, node string) error {
+	snapshots, err := store.GetNodeSnapshots(node)
+	if err != nil {
+		return fmt.Errorf("failed to read snapshots: %w", err)
+	}
+	if len(snapshots) == 0 {
+		return fmt.Errorf("no snapshots recorded for node %s", node)
+	}
+
+	runs, err := store.GetRuns()
+	if err != nil {
+		return fmt.Errorf("failed to read runs: %w", err)
+	}
+
+	fmt.Fprintf(out, "🕒 Timeline for node %s (%d snapshots)\n", node, len(snapshots))
+	for _, entry := range history.BuildTimeline(snapshots) {
+		cause := "unknown run"
+		if run, ok := runs[entry.RunID]; ok {
+			cause = run.Operation
+			if run.ConfigFile != "" {
+				cause += " " + run.ConfigFile
+			}
+		}
+		fmt.Fprintf(out, "\n%s  run %s (%s)\n", entry.Time.Local().Format("2006-01-02 15:04:05"), entry.RunID, cause)
+
+		for _, change := range entry.Changes {
+			switch change.Op {
+			case history.ChangeAdded:
+				fmt.Fprintf(out, "  + %s %s=%s\n", change.Kind, change.Key, change.NewValue)
+			case history.ChangeRemoved:
+				fmt.Fprintf(out, "  - %s %s=%s\n", change.Kind, change.Key, change.OldValue)
+			case history.ChangeModified:
+				fmt.Fprintf(out, "  ~ %s %s: %s -> %s\n", change.Kind, change.Key, change.OldValue, change.NewValue)
+			}
+		}
+	}
+
+	return nil
+}
+
+// recordRunHistory records a run and a snapshot of every node referenced by the bundle
+// applied is what the run changed, see appliedChanges. Snapshot failures are logged rather than returned
+// so history never fails an operation
+func recordRunHistory(ctx context.Context, logger logging.Logger, store history.Store, reader history.MetadataReader, bundle, applied *config.ConfigBundle, operation string) error {
+	run := history.Run{
+		ID:         history.NewRunID(time.Now()),
+		StartedAt:  time.Now().UTC(),
+		Operation:  operation,
+		ConfigFile: configFile,
+	}
+	if err := store.RecordRun(run); err != nil {
+		return fmt.Errorf("failed to record run: %w", err)
+	}
+
+	recorded := 0
+	for _, node := range bundle.GetAllNodeNames() {
+		vlans, err := nodeVLANState(store, applied, node, operation)
+		if err != nil {
+			logger.Warn(fmt.Sprintf("⚠️  Failed to determine VLAN state of node %s: %v", node, err))
+		}
+
+		snapshot, err := history.CaptureNode(ctx, reader, run.ID, node, vlans)
+		if err != nil {
+			logger.Warn(fmt.Sprintf("⚠️  Skipping snapshot of node %s: %v", node, err))
+			continue
+		}
+		if err := store.SaveSnapshot(snapshot); err != nil {
+			logger.Warn(fmt.Sprintf("⚠️  Failed to save snapshot of node %s: %v", node, err))
+			continue
+		}
+		recorded++
+	}
+
+	logger.Info(fmt.Sprintf("🗂️  Recorded run %s with %d node snapshots", run.ID, recorded))
+	return nil
+}
+
+// nodeVLANState returns the VLAN assignments of a node after an operation
+// VLAN state is not read from the node: the last recorded state is updated with what the run applied,
+// so VLANs that failed on the node or ran dry leave it unchanged
+func nodeVLANState(store history.Store, applied *config.ConfigBundle, node, operation string) (map[string]string, error) {
+	vlans := make(map[string]string)
+
+	snapshots, err := store.GetNodeSnapshots(node)
+	if err != nil {
+		return nil, err
+	}
+	if len(snapshots) > 0 {
+		for vlanName, address := range snapshots[len(snapshots)-1].VLANs {
+			vlans[vlanName] = address
+		}
+	}
+
+	if applied.HasVLANs() {
+		for vlanName, vlanConfig := range applied.VLANs.Spec.VLANs {
+			address, ok := vlanConfig.NodeMapping[node]
+			if !ok {
+				continue
+			}
+			switch operation {
+			case "apply":
+				vlans[vlanName] = address
+			case "delete":
+				delete(vlans, vlanName)
+			}
+		}
+	}
+
+	return vlans, nil
+}
+
+// isBundleDryRun reports whether every configuration in the bundle runs in dry-run mode
+func isBundleDryRun(bundle *config.ConfigBundle) bool {
+	if bundle.HasNodeLabels() && !bundle.NodeLabels.Tools.Nlabel.DryRun {
+		return false
+	}
+	if bundle.HasVLANs() && !bundle.VLANs.Tools.Nvlan.DryRun {
+		return false
+	}
+	if bundle.HasTests() && !bundle.Tests.Tools.Ntest.DryRun {
+		return false
+	}
+	if bundle.HasCleanup() && !bundle.Cleanup.Tools.Nlabel.DryRun {
+		return false
+	}
+	if bundle.HasSysctls() && !bundle.Sysctls.Tools.Nsysctl.DryRun {
+		return false
+	}
+	if bundle.HasStorage() && !bundle.Storage.Tools.Nstorage.DryRun {
+		return false
+	}
+	return true
+}
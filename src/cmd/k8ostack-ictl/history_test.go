@@ -0,0 +1,104 @@
+// Package main provides unit tests for the history commands
+// WHY: The timeline view must attribute changes to runs without needing a cluster
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/history"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPrintNodeTimeline tests rendering of a node timeline from a history store
+// WHY: Operators read this output to find which run caused a change
+func TestPrintNodeTimeline(t *testing.T) {
+	// Given: Store with two runs that changed rsb2
+	store, err := history.NewFileStore(filepath.Join(t.TempDir(), "history"))
+	require.NoError(t, err)
+
+	base := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, store.RecordRun(history.Run{ID: "run-1", Operation: "apply", ConfigFile: "cluster.yaml"}))
+	require.NoError(t, store.RecordRun(history.Run{ID: "run-2", Operation: "snapshot"}))
+	require.NoError(t, store.SaveSnapshot(history.NodeSnapshot{
+		Node: "rsb2", RunID: "run-1", TakenAt: base,
+		Labels: map[string]string{"openstack-role": "compute"},
+	}))
+	require.NoError(t, store.SaveSnapshot(history.NodeSnapshot{
+		Node: "rsb2", RunID: "run-2", TakenAt: base.Add(time.Hour),
+		Labels: map[string]string{"openstack-role": "storage"},
+	}))
+
+	// When: Print timeline
+	var out bytes.Buffer
+	err = printNodeTimeline(&out, store, "rsb2")
+
+	// Then: Each change is listed under the run that introduced it
+	require.NoError(t, err)
+	output := out.String()
+	assert.Contains(t, output, "Timeline for node rsb2 (2 snapshots)")
+	assert.Contains(t, output, "run run-1 (apply cluster.yaml)")
+	assert.Contains(t, output, "+ label openstack-role=compute")
+	assert.Contains(t, output, "run run-2 (snapshot)")
+	assert.Contains(t, output, "~ label openstack-role: compute -> storage")
+
+	// When: Node without history
+	err = printNodeTimeline(&out, store, "rsb9")
+
+	// Then: Should explain there is nothing recorded
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "no snapshots recorded for node rsb9")
+}
+
+// TestNodeVLANState tests derivation of VLAN state for snapshots
+// WHY: VLANs are not read from nodes, so state must follow applies and deletes correctly
+func TestNodeVLANState(t *testing.T) {
+	store, err := history.NewFileStore(t.TempDir())
+	require.NoError(t, err)
+	require.NoError(t, store.SaveSnapshot(history.NodeSnapshot{
+		Node: "rsb2", RunID: "run-1",
+		VLANs: map[string]string{"storage": "10.2.0.2/24"},
+	}))
+
+	bundle := &config.ConfigBundle{
+		VLANs: &config.NodeVLANConf{Spec: config.NodeVLANSpec{VLANs: map[string]config.VLANConfig{
+			"management": {NodeMapping: map[string]string{"rsb2": "10.1.0.2/24"}},
+		}}},
+	}
+
+	tests := []struct {
+		name      string
+		operation string
+		expected  map[string]string
+	}{
+		{"apply_merges_with_previous", "apply", map[string]string{"storage": "10.2.0.2/24", "management": "10.1.0.2/24"}},
+		{"delete_removes_bundle_vlans", "delete", map[string]string{"storage": "10.2.0.2/24"}},
+		{"snapshot_carries_forward", "snapshot", map[string]string{"storage": "10.2.0.2/24"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vlans, err := nodeVLANState(store, bundle, "rsb2", tt.operation)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, vlans)
+		})
+	}
+}
+
+// TestIsBundleDryRun tests detection of fully simulated runs
+// WHY: Dry runs change nothing and must not add history records
+func TestIsBundleDryRun(t *testing.T) {
+	bundle := &config.ConfigBundle{
+		NodeLabels: &config.NodeLabelConf{Tools: config.Tools{Nlabel: config.ToolConfig{DryRun: true}}},
+		VLANs:      &config.NodeVLANConf{Tools: config.Tools{Nvlan: config.ToolConfig{DryRun: true}}},
+	}
+	assert.True(t, isBundleDryRun(bundle))
+
+	bundle.VLANs.Tools.Nvlan.DryRun = false
+	assert.False(t, isBundleDryRun(bundle), "a single real operation makes the run real")
+}
@@ -0,0 +1,345 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strings"
+
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/kubectl"
+	"k8ostack-ictl/internal/labeler"
+	"k8ostack-ictl/internal/logging"
+	"k8ostack-ictl/internal/vlan"
+
+	"github.com/spf13/cobra"
+)
+
+// importOptions selects what kictl import reads from the cluster
+type importOptions struct {
+	Name          string   // metadata.name of the generated configs
+	LabelPrefixes []string // only label keys starting with one of these are imported
+	Nodes         []string // nodes to import; all nodes when neither Nodes nor Selector is set
+	Selector      string   // label selector choosing nodes to import
+}
+
+// createImportCommand creates the command that writes a config bundle from the live labels and VLAN interfaces
+func createImportCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "import",
+		Short: "Generate a configuration bundle from the live labels and VLAN interfaces of the cluster",
+		Long: `Read the labels and VLAN interfaces of existing nodes and write them as a
+NodeLabelConf and NodeVLANConf bundle, so a running cluster can be brought under
+kictl management.
+
+Only labels whose key starts with a --label-prefix are imported, which keeps
+labels owned by Kubernetes out of the bundle. Nodes with the same imported labels
+share a role, and VLAN interfaces with the same ID and parent share a VLAN.
+The bundle is validated before it is written; review the generated role and VLAN
+names before applying it.
+
+Examples:
+  # Import the openstack labels and the VLANs of every node
+  kictl import --label-prefix openstack --output cluster-config.yaml
+
+  # Import only the VLANs of the worker nodes
+  kictl import --selector node-role.kubernetes.io/worker`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			output, _ := cmd.Flags().GetString("output")
+			opts := importOptions{}
+			opts.Name, _ = cmd.Flags().GetString("name")
+			opts.LabelPrefixes, _ = cmd.Flags().GetStringSlice("label-prefix")
+			opts.Nodes, _ = cmd.Flags().GetStringSlice("nodes")
+			opts.Selector, _ = cmd.Flags().GetString("selector")
+
+			// Logs go to stderr when the bundle is written to stdout
+			console := cmd.OutOrStdout()
+			if output == "" {
+				console = cmd.ErrOrStderr()
+			}
+			logger, _, err := newRunLoggerTo(console, "import")
+			if err != nil {
+				return fmt.Errorf("failed to initialize logger: %w", err)
+			}
+			defer logger.Close()
+
+			data, err := importBundle(context.Background(), logger, newKubectlExecutor(logger), opts)
+			if err != nil {
+				return err
+			}
+
+			if output == "" {
+				_, err = cmd.OutOrStdout().Write(data)
+				return err
+			}
+			if err := os.WriteFile(output, data, 0644); err != nil {
+				return fmt.Errorf("failed to write imported config: %w", err)
+			}
+			logger.Info(fmt.Sprintf("📝 Wrote imported config to %s", output))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringSlice("label-prefix", nil, "Import labels whose key starts with this prefix (repeatable); without it no labels are imported")
+	cmd.Flags().StringSlice("nodes", nil, "Nodes to import (comma separated; default all nodes)")
+	cmd.Flags().String("selector", "", "Label selector choosing nodes to import")
+	cmd.Flags().StringP("output", "o", "", "Output file (default stdout)")
+	cmd.Flags().String("name", "imported", "metadata.name of the generated configs")
+	return cmd
+}
+
+// importBundle reads the nodes' labels and VLAN interfaces and renders them as a validated multi-document bundle
+func importBundle(ctx context.Context, logger logging.Logger, executor kubectl.DryRunExecutor, opts importOptions) ([]byte, error) {
+	nodes, err := importNodes(ctx, executor, opts.Nodes, opts.Selector)
+	if err != nil {
+		return nil, err
+	}
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("no nodes to import")
+	}
+	logger.Info(fmt.Sprintf("🔎 Importing labels and VLAN interfaces of %d nodes", len(nodes)))
+
+	var documents []interface{}
+
+	if len(opts.LabelPrefixes) == 0 {
+		logger.Info("ℹ️  No --label-prefix given, skipping labels")
+	} else {
+		labelingService := labeler.NewService(executor, labeler.Options{Verbose: verbose, Logger: logger})
+		labels, err := labelingService.GetCurrentState(ctx, nodes)
+		if err != nil {
+			return nil, err
+		}
+		for _, node := range nodes {
+			if _, ok := labels[node]; !ok {
+				logger.Warn(fmt.Sprintf("⚠️  Could not read the labels of node %s, it is left out", node))
+			}
+		}
+		if labelConf := importedLabels(opts.Name, labels, opts.LabelPrefixes); labelConf != nil {
+			logger.Info(fmt.Sprintf("🏷️  Imported %d roles", len(labelConf.Spec.NodeRoles)))
+			documents = append(documents, labelConf)
+		} else {
+			logger.Info(fmt.Sprintf("ℹ️  No labels start with %s", strings.Join(opts.LabelPrefixes, ", ")))
+		}
+	}
+
+	vlanService := vlan.NewService(executor, vlan.Options{Verbose: verbose, Logger: logger})
+	interfaces, err := vlanService.GetCurrentState(ctx, nodes)
+	vlanService.Cleanup(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if vlanConf := importedVLANs(opts.Name, interfaces, logger); vlanConf != nil {
+		logger.Info(fmt.Sprintf("🌐 Imported %d VLANs", len(vlanConf.Spec.VLANs)))
+		documents = append(documents, vlanConf)
+	} else {
+		logger.Info("ℹ️  No VLAN interfaces with addresses found")
+	}
+
+	if len(documents) == 0 {
+		return nil, fmt.Errorf("nothing to import from %d nodes", len(nodes))
+	}
+
+	data := []byte(fmt.Sprintf("# Generated by kictl import from %d nodes\n", len(nodes)))
+	for i, document := range documents {
+		if i > 0 {
+			data = append(data, []byte("---\n")...)
+		}
+		documentData, err := config.MarshalDocument(document)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal imported config: %w", err)
+		}
+		data = append(data, documentData...)
+	}
+
+	if _, err := config.LoadConfigData(data, "import"); err != nil {
+		return nil, fmt.Errorf("imported config is not valid: %w", err)
+	}
+	return data, nil
+}
+
+// importNodes resolves the nodes to import, defaulting to every node of the cluster
+func importNodes(ctx context.Context, executor kubectl.DryRunExecutor, nodes []string, selector string) ([]string, error) {
+	unique := make(map[string]bool)
+	for _, node := range nodes {
+		unique[node] = true
+	}
+
+	if selector != "" || len(nodes) == 0 {
+		var success bool
+		var output string
+		var err error
+		if selector != "" {
+			success, output, err = executor.GetNodesByLabel(ctx, selector)
+		} else {
+			success, output, err = executor.GetAllNodes(ctx)
+		}
+		if err != nil || !success {
+			return nil, fmt.Errorf("failed to list nodes to import: %v", err)
+		}
+		for _, node := range kubectl.ParseNodeNames(output) {
+			unique[node] = true
+		}
+	}
+
+	resolved := make([]string, 0, len(unique))
+	for node := range unique {
+		resolved = append(resolved, node)
+	}
+	sort.Strings(resolved)
+	return resolved, nil
+}
+
+// importedLabels groups nodes with the same prefixed labels into the roles of a NodeLabelConf, or returns nil when no node has any
+// Roles are named role-1, role-2, ... in the order of their first node
+func importedLabels(name string, state map[string]map[string]string, prefixes []string) *config.NodeLabelConf {
+	nodes := make([]string, 0, len(state))
+	for node := range state {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+
+	groups := make(map[string]*config.NodeRole)
+	var order []string
+	for _, node := range nodes {
+		labels := make(map[string]string)
+		var pairs []string
+		for key, value := range state[node] {
+			for _, prefix := range prefixes {
+				if strings.HasPrefix(key, prefix) {
+					labels[key] = value
+					pairs = append(pairs, key+"="+value)
+					break
+				}
+			}
+		}
+		if len(labels) == 0 {
+			continue
+		}
+
+		sort.Strings(pairs)
+		key := strings.Join(pairs, ",")
+		role, ok := groups[key]
+		if !ok {
+			role = &config.NodeRole{Labels: labels}
+			groups[key] = role
+			order = append(order, key)
+		}
+		role.Nodes = append(role.Nodes, node)
+	}
+	if len(order) == 0 {
+		return nil
+	}
+
+	roles := make(map[string]config.NodeRole, len(order))
+	for i, key := range order {
+		role := groups[key]
+		role.Description = fmt.Sprintf("Imported from %d nodes", len(role.Nodes))
+		roles[fmt.Sprintf("role-%d", i+1)] = *role
+	}
+
+	return &config.NodeLabelConf{
+		APIVersion: "openstack.kictl.icycloud.io/v1",
+		Kind:       "NodeLabelConf",
+		Metadata:   config.Metadata{Name: name, Namespace: "default"},
+		Spec:       config.NodeLabelSpec{NodeRoles: roles},
+	}
+}
+
+// importedVLANs groups VLAN interfaces with the same ID and parent into the VLANs of a NodeVLANConf, or returns nil when there are none
+// VLANs are named vlan<ID>, with the parent appended when one ID is found on several parents
+func importedVLANs(name string, state map[string][]vlan.VLANInterfaceInfo, logger logging.Logger) *config.NodeVLANConf {
+	type vlanKey struct {
+		id     int
+		parent string
+	}
+
+	nodes := make([]string, 0, len(state))
+	for node := range state {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+
+	groups := make(map[vlanKey]*config.VLANConfig)
+	parents := make(map[int]int)
+	for _, node := range nodes {
+		for _, info := range state[node] {
+			if info.VLANId == 0 || info.PhysInterface == "" {
+				logger.Warn(fmt.Sprintf("⚠️  Skipping %s on node %s: its VLAN ID or parent is unknown", info.Interface, node))
+				continue
+			}
+			address, others := importedAddress(info.IPAddress)
+			if address == "" {
+				logger.Warn(fmt.Sprintf("⚠️  Skipping %s on node %s: it has no address to import", info.Interface, node))
+				continue
+			}
+			if others > 0 {
+				logger.Warn(fmt.Sprintf("⚠️  %s on node %s has %d more addresses, only %s is imported", info.Interface, node, others, address))
+			}
+			_, network, _ := net.ParseCIDR(config.PrimaryAddress(address))
+
+			key := vlanKey{id: info.VLANId, parent: info.PhysInterface}
+			group, ok := groups[key]
+			if !ok {
+				group = &config.VLANConfig{
+					ID:          info.VLANId,
+					Subnet:      network.String(),
+					Interface:   info.PhysInterface,
+					NodeMapping: make(map[string]string),
+				}
+				groups[key] = group
+				parents[info.VLANId]++
+			} else if group.Subnet != network.String() {
+				logger.Warn(fmt.Sprintf("⚠️  %s on node %s is in %s, not in subnet %s of the other nodes", info.Interface, node, network, group.Subnet))
+			}
+			group.NodeMapping[node] = address
+		}
+	}
+	if len(groups) == 0 {
+		return nil
+	}
+
+	vlans := make(map[string]config.VLANConfig, len(groups))
+	for key, group := range groups {
+		vlanName := fmt.Sprintf("vlan%d", key.id)
+		if parents[key.id] > 1 {
+			vlanName = fmt.Sprintf("vlan%d-%s", key.id, key.parent)
+		}
+		vlans[vlanName] = *group
+	}
+
+	return &config.NodeVLANConf{
+		APIVersion: "openstack.kictl.icycloud.io/v1",
+		Kind:       "NodeVLANConf",
+		Metadata:   config.Metadata{Name: name, Namespace: "default"},
+		Spec:       config.NodeVLANSpec{VLANs: vlans},
+	}
+}
+
+// importedAddress picks the addresses of an interface to import from a comma separated list, skipping link-local addresses
+// A dual-stack interface keeps its first IPv4 and first IPv6 address. It also returns how many other importable addresses were left out.
+func importedAddress(addresses string) (string, int) {
+	var ipv4, ipv6 []string
+	for _, address := range strings.Split(addresses, ",") {
+		ip, _, err := net.ParseCIDR(address)
+		if err != nil || ip.IsLinkLocalUnicast() {
+			continue
+		}
+		if ip.To4() == nil {
+			ipv6 = append(ipv6, address)
+		} else {
+			ipv4 = append(ipv4, address)
+		}
+	}
+
+	var imported []string
+	if len(ipv4) > 0 {
+		imported = append(imported, ipv4[0])
+	}
+	if len(ipv6) > 0 {
+		imported = append(imported, ipv6[0])
+	}
+	return config.JoinAddresses(imported), len(ipv4) + len(ipv6) - len(imported)
+}
@@ -0,0 +1,198 @@
+// Package main provides unit tests for the import command
+// WHY: Imported bundles become the source of truth for existing clusters, so they must describe exactly what is live
+package main
+
+import (
+	"context"
+	"testing"
+
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/kubectl"
+	"k8ostack-ictl/internal/logging"
+	"k8ostack-ictl/internal/vlan"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// TestImportedLabels tests grouping of live labels into roles
+// WHY: Nodes sharing labels must share a role, and labels outside the prefixes must stay out of the bundle
+func TestImportedLabels(t *testing.T) {
+	tests := []struct {
+		name     string
+		state    map[string]map[string]string
+		prefixes []string
+		expected map[string]config.NodeRole
+	}{
+		{
+			name: "nodes_with_same_labels_share_a_role",
+			state: map[string]map[string]string{
+				"rsb3": {"openstack-role": "compute", "kubernetes.io/hostname": "rsb3"},
+				"rsb2": {"openstack-role": "compute", "kubernetes.io/hostname": "rsb2"},
+				"rsb4": {"openstack-role": "storage", "ceph-node": "enabled"},
+			},
+			prefixes: []string{"openstack", "ceph"},
+			expected: map[string]config.NodeRole{
+				"role-1": {Nodes: []string{"rsb2", "rsb3"}, Labels: map[string]string{"openstack-role": "compute"}, Description: "Imported from 2 nodes"},
+				"role-2": {Nodes: []string{"rsb4"}, Labels: map[string]string{"openstack-role": "storage", "ceph-node": "enabled"}, Description: "Imported from 1 nodes"},
+			},
+		},
+		{
+			name: "nodes_without_prefixed_labels_are_left_out",
+			state: map[string]map[string]string{
+				"rsb2": {"openstack-role": "compute"},
+				"rsb5": {"kubernetes.io/hostname": "rsb5"},
+			},
+			prefixes: []string{"openstack"},
+			expected: map[string]config.NodeRole{
+				"role-1": {Nodes: []string{"rsb2"}, Labels: map[string]string{"openstack-role": "compute"}, Description: "Imported from 1 nodes"},
+			},
+		},
+		{
+			name:     "no_prefixed_labels",
+			state:    map[string]map[string]string{"rsb2": {"kubernetes.io/hostname": "rsb2"}},
+			prefixes: []string{"openstack"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: Live labels per node
+
+			// When: Import them
+			labelConf := importedLabels("imported", tt.state, tt.prefixes)
+
+			// Then: Roles group nodes by their prefixed labels
+			if tt.expected == nil {
+				assert.Nil(t, labelConf)
+				return
+			}
+			require.NotNil(t, labelConf)
+			assert.Equal(t, "NodeLabelConf", labelConf.Kind)
+			assert.Equal(t, tt.expected, labelConf.Spec.NodeRoles)
+		})
+	}
+}
+
+// TestImportedVLANs tests grouping of live VLAN interfaces into VLANs
+// WHY: One VLAN per ID and parent keeps the bundle applicable, and interfaces without addresses cannot be described
+func TestImportedVLANs(t *testing.T) {
+	tests := []struct {
+		name     string
+		state    map[string][]vlan.VLANInterfaceInfo
+		expected map[string]config.VLANConfig
+		warnings int
+	}{
+		{
+			name: "same_id_and_parent_share_a_vlan",
+			state: map[string][]vlan.VLANInterfaceInfo{
+				"rsb2": {{Interface: "eth0.100", PhysInterface: "eth0", VLANId: 100, IPAddress: "10.100.0.12/24,fe80::5054:ff:fe12:3456/64"}},
+				"rsb3": {{Interface: "eth0.100", PhysInterface: "eth0", VLANId: 100, IPAddress: "10.100.0.13/24"}},
+			},
+			expected: map[string]config.VLANConfig{
+				"vlan100": {ID: 100, Subnet: "10.100.0.0/24", Interface: "eth0",
+					NodeMapping: map[string]string{"rsb2": "10.100.0.12/24", "rsb3": "10.100.0.13/24"}},
+			},
+		},
+		{
+			name: "same_id_on_different_parents",
+			state: map[string][]vlan.VLANInterfaceInfo{
+				"rsb2": {{Interface: "eth0.100", PhysInterface: "eth0", VLANId: 100, IPAddress: "10.100.0.12/24"}},
+				"rsb3": {{Interface: "ens3.100", PhysInterface: "ens3", VLANId: 100, IPAddress: "10.100.0.13/24"}},
+			},
+			expected: map[string]config.VLANConfig{
+				"vlan100-eth0": {ID: 100, Subnet: "10.100.0.0/24", Interface: "eth0", NodeMapping: map[string]string{"rsb2": "10.100.0.12/24"}},
+				"vlan100-ens3": {ID: 100, Subnet: "10.100.0.0/24", Interface: "ens3", NodeMapping: map[string]string{"rsb3": "10.100.0.13/24"}},
+			},
+		},
+		{
+			name: "interfaces_without_addresses_are_skipped",
+			state: map[string][]vlan.VLANInterfaceInfo{
+				"rsb2": {
+					{Interface: "eth0.200", PhysInterface: "eth0", VLANId: 200},
+					{Interface: "eth0.300", PhysInterface: "eth0", VLANId: 300, IPAddress: "fe80::5054:ff:fe12:3456/64"},
+				},
+			},
+			warnings: 2,
+		},
+		{
+			name: "dual_stack_interfaces_keep_both_families",
+			state: map[string][]vlan.VLANInterfaceInfo{
+				"rsb2": {{Interface: "eth0.100", PhysInterface: "eth0", VLANId: 100, IPAddress: "fd00:100::12/64,10.100.0.12/24,fe80::5054:ff:fe12:3456/64"}},
+			},
+			expected: map[string]config.VLANConfig{
+				"vlan100": {ID: 100, Subnet: "10.100.0.0/24", Interface: "eth0",
+					NodeMapping: map[string]string{"rsb2": "10.100.0.12/24,fd00:100::12/64"}},
+			},
+		},
+		{
+			name: "extra_addresses_and_subnets_are_reported",
+			state: map[string][]vlan.VLANInterfaceInfo{
+				"rsb2": {{Interface: "eth0.100", PhysInterface: "eth0", VLANId: 100, IPAddress: "10.100.0.12/24,10.100.0.50/24"}},
+				"rsb3": {{Interface: "eth0.100", PhysInterface: "eth0", VLANId: 100, IPAddress: "10.101.0.13/24"}},
+			},
+			expected: map[string]config.VLANConfig{
+				"vlan100": {ID: 100, Subnet: "10.100.0.0/24", Interface: "eth0",
+					NodeMapping: map[string]string{"rsb2": "10.100.0.12/24", "rsb3": "10.101.0.13/24"}},
+			},
+			warnings: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: Live VLAN interfaces per node
+			logger := logging.NewRecordingLogger()
+
+			// When: Import them
+			vlanConf := importedVLANs("imported", tt.state, logger)
+
+			// Then: VLANs group interfaces by ID and parent, and anything left out is reported
+			assert.Len(t, logger.Messages(logging.LevelWarn), tt.warnings)
+			if tt.expected == nil {
+				assert.Nil(t, vlanConf)
+				return
+			}
+			require.NotNil(t, vlanConf)
+			assert.Equal(t, "NodeVLANConf", vlanConf.Kind)
+			assert.Equal(t, tt.expected, vlanConf.Spec.VLANs)
+		})
+	}
+}
+
+// TestImportBundle tests importing the labels of a cluster into a bundle kictl loads
+// WHY: The generated YAML must load back as the same roles, or onboarding a cluster would change it
+func TestImportBundle(t *testing.T) {
+	// Given: Three nodes, two of them labeled alike
+	logger := logging.NewRecordingLogger()
+	client := fake.NewSimpleClientset(
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "rsb2", Labels: map[string]string{"openstack-role": "compute", "kubernetes.io/os": "linux"}}},
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "rsb3", Labels: map[string]string{"openstack-role": "compute", "kubernetes.io/os": "linux"}}},
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "rsb4", Labels: map[string]string{"kubernetes.io/os": "linux"}}},
+	)
+	executor := kubectl.NewNativeExecutorWithClient(logger, client, "default", kubectl.ExecutorOptions{})
+	// Node commands cannot run against the fake clientset, so VLAN discovery sees no interfaces
+	executor.SetDryRun(true)
+
+	// When: Import the openstack labels
+	data, err := importBundle(context.Background(), logger, executor, importOptions{Name: "imported", LabelPrefixes: []string{"openstack"}})
+
+	// Then: The bundle loads back with one role for both compute nodes
+	require.NoError(t, err)
+	bundle, err := config.LoadConfigData(data, "import")
+	require.NoError(t, err)
+	require.True(t, bundle.HasNodeLabels())
+	assert.False(t, bundle.HasVLANs())
+	assert.Equal(t, map[string]config.NodeRole{
+		"role-1": {Nodes: []string{"rsb2", "rsb3"}, Labels: map[string]string{"openstack-role": "compute"}, Description: "Imported from 2 nodes"},
+	}, bundle.NodeLabels.Spec.NodeRoles)
+
+	// When: Import without a label prefix
+	_, err = importBundle(context.Background(), logger, executor, importOptions{Name: "imported"})
+
+	// Then: There is nothing to import
+	assert.EqualError(t, err, "nothing to import from 3 nodes")
+}
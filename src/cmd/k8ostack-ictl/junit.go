@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8ostack-ictl/internal/junit"
+	"k8ostack-ictl/internal/kubectl"
+	"k8ostack-ictl/internal/labeler"
+	"k8ostack-ictl/internal/nethealthcheck"
+	"k8ostack-ictl/internal/storage"
+	"k8ostack-ictl/internal/sysctl"
+	"k8ostack-ictl/internal/vlan"
+)
+
+// serviceCase names the case holding service errors that no node is attributed to
+const serviceCase = "service"
+
+// addLabelVerifyCases records one case per verified node of a NodeLabelConf
+func addLabelVerifyCases(suite *junit.Suite, results *labeler.OperationResults, err error) {
+	if err != nil {
+		suite.Fail(serviceCase, err.Error())
+		return
+	}
+	nodes := make([]string, 0, len(results.AppliedLabels))
+	for nodeName := range results.AppliedLabels {
+		nodes = append(nodes, nodeName)
+	}
+	addNodeCases(suite, nodes, results.FailedNodes, results.Errors, "expected labels are missing")
+}
+
+// addVLANVerifyCases records one case per verified node of a NodeVLANConf
+func addVLANVerifyCases(suite *junit.Suite, results *vlan.OperationResults, err error) {
+	if err != nil {
+		suite.Fail(serviceCase, err.Error())
+		return
+	}
+	nodes := make([]string, 0, len(results.ConfiguredVLANs))
+	for nodeName := range results.ConfiguredVLANs {
+		nodes = append(nodes, nodeName)
+	}
+	addNodeCases(suite, nodes, results.FailedNodes, results.Errors, "VLAN interfaces do not match the configuration")
+}
+
+// addSysctlVerifyCases records one case per verified node of a NodeSysctlConf
+func addSysctlVerifyCases(suite *junit.Suite, results *sysctl.OperationResults, err error) {
+	if err != nil {
+		suite.Fail(serviceCase, err.Error())
+		return
+	}
+	addNodeCases(suite, results.MatchingNodes, results.FailedNodes, results.Errors, "kernel settings do not match the configuration")
+}
+
+// addStorageVerifyCases records one case per verified node of a NodeStorageConf
+func addStorageVerifyCases(suite *junit.Suite, results *storage.OperationResults, err error) {
+	if err != nil {
+		suite.Fail(serviceCase, err.Error())
+		return
+	}
+	addNodeCases(suite, results.PreparedNodes, results.FailedNodes, results.Errors, "storage devices or labels are not ready for Ceph")
+}
+
+// addTestCases records one case per connectivity test of a NodeTestConf, with its duration and output
+func addTestCases(suite *junit.Suite, results *nethealthcheck.TestResults, err error) {
+	if err != nil {
+		suite.Fail(serviceCase, err.Error())
+		return
+	}
+	for _, execution := range results.TestExecutions {
+		testCase := junit.Case{
+			Name:     fmt.Sprintf("%s %s -> %s", execution.TestName, execution.SourceNode, execution.TargetNode),
+			Duration: execution.Duration,
+			Output:   execution.Output,
+		}
+		if execution.Flaky {
+			testCase.Output = strings.TrimSpace(fmt.Sprintf("flaky: passed on attempt %d\n%s", execution.Attempts, testCase.Output))
+		}
+		if execution.ActualSuccess != execution.ExpectSuccess {
+			testCase.Failure = execution.ErrorMessage
+			if testCase.Failure == "" {
+				testCase.Failure = fmt.Sprintf("expected success=%t, got %t", execution.ExpectSuccess, execution.ActualSuccess)
+			}
+			if execution.Attempts > 1 {
+				testCase.Failure = fmt.Sprintf("%s (failed all %d attempts)", testCase.Failure, execution.Attempts)
+			}
+		}
+		suite.Add(testCase)
+	}
+	if len(results.Errors) > 0 {
+		suite.Fail(serviceCase, joinErrors(results.Errors))
+	}
+}
+
+// addNodeCases records a case per node, failing the failed nodes with the errors attributed to them
+// Errors attributed to no node fail a separate service case so they are not lost.
+func addNodeCases(suite *junit.Suite, nodes, failedNodes []string, errs []error, fallback string) {
+	failed := make(map[string]bool, len(failedNodes))
+	for _, nodeName := range failedNodes {
+		failed[nodeName] = true
+	}
+
+	nodeErrors := make(map[string][]error)
+	var unattributed []error
+	for _, err := range errs {
+		if nodeName := kubectl.NodeOf(err); nodeName != "" {
+			nodeErrors[nodeName] = append(nodeErrors[nodeName], err)
+			failed[nodeName] = true
+		} else {
+			unattributed = append(unattributed, err)
+		}
+	}
+
+	unique := make(map[string]bool, len(nodes)+len(failed))
+	for _, nodeName := range nodes {
+		unique[nodeName] = true
+	}
+	for nodeName := range failed {
+		unique[nodeName] = true
+	}
+	sorted := make([]string, 0, len(unique))
+	for nodeName := range unique {
+		sorted = append(sorted, nodeName)
+	}
+	sort.Strings(sorted)
+
+	for _, nodeName := range sorted {
+		switch {
+		case len(nodeErrors[nodeName]) > 0:
+			suite.Fail(nodeName, joinErrors(nodeErrors[nodeName]))
+		case failed[nodeName]:
+			suite.Fail(nodeName, fallback)
+		default:
+			suite.Pass(nodeName)
+		}
+	}
+
+	if len(unattributed) > 0 {
+		suite.Fail(serviceCase, joinErrors(unattributed))
+	}
+}
+
+// joinErrors returns the messages of errs, one per line
+func joinErrors(errs []error) string {
+	messages := make([]string, len(errs))
+	for i, err := range errs {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "\n")
+}
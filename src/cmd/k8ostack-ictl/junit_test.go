@@ -0,0 +1,128 @@
+// Package main provides unit tests for the JUnit cases of verify and test reports
+// WHY: Each node and test must appear as its own test case with the errors that belong to it
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"k8ostack-ictl/internal/junit"
+	"k8ostack-ictl/internal/kubectl"
+	"k8ostack-ictl/internal/labeler"
+	"k8ostack-ictl/internal/nethealthcheck"
+	"k8ostack-ictl/internal/storage"
+	"k8ostack-ictl/internal/sysctl"
+	"k8ostack-ictl/internal/vlan"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestAddVerifyCases tests the cases recorded for each service's verify results
+// WHY: Failed nodes keep their own messages, and errors without a node are still reported
+func TestAddVerifyCases(t *testing.T) {
+	tests := []struct {
+		name     string
+		add      func(suite *junit.Suite)
+		expected []junit.Case
+	}{
+		{
+			name: "labels_missing_on_one_node",
+			add: func(suite *junit.Suite) {
+				addLabelVerifyCases(suite, &labeler.OperationResults{
+					AppliedLabels: map[string][]string{"rsb3": {"role=control"}, "rsb2": {}},
+					FailedNodes:   []string{"rsb2"},
+				}, nil)
+			},
+			expected: []junit.Case{
+				{Name: "rsb2", Failure: "expected labels are missing"},
+				{Name: "rsb3"},
+			},
+		},
+		{
+			name: "vlan_node_errors_and_unattributed_errors",
+			add: func(suite *junit.Suite) {
+				addVLANVerifyCases(suite, &vlan.OperationResults{
+					ConfiguredVLANs: map[string][]vlan.VLANInterfaceInfo{"rsb2": nil},
+					FailedNodes:     []string{"rsb4"},
+					Errors: []error{
+						kubectl.WrapNodeError("rsb4", errors.New("eth0.100 missing")),
+						errors.New("debug pod cleanup failed"),
+					},
+				}, nil)
+			},
+			expected: []junit.Case{
+				{Name: "rsb2"},
+				{Name: "rsb4", Failure: "eth0.100 missing"},
+				{Name: "service", Failure: "debug pod cleanup failed"},
+			},
+		},
+		{
+			name: "service_failure",
+			add: func(suite *junit.Suite) {
+				addVLANVerifyCases(suite, nil, errors.New("cluster unreachable"))
+			},
+			expected: []junit.Case{{Name: "service", Failure: "cluster unreachable"}},
+		},
+		{
+			name: "sysctl_deviation",
+			add: func(suite *junit.Suite) {
+				addSysctlVerifyCases(suite, &sysctl.OperationResults{
+					MatchingNodes: []string{"rsb2"},
+					FailedNodes:   []string{"rsb3"},
+					Errors:        []error{kubectl.WrapNodeError("rsb3", errors.New("net.ipv4.ip_forward is 0, not 1"))},
+				}, nil)
+			},
+			expected: []junit.Case{
+				{Name: "rsb2"},
+				{Name: "rsb3", Failure: "net.ipv4.ip_forward is 0, not 1"},
+			},
+		},
+		{
+			name: "storage_device_holds_data",
+			add: func(suite *junit.Suite) {
+				addStorageVerifyCases(suite, &storage.OperationResults{
+					PreparedNodes: []string{"rsb2"},
+					FailedNodes:   []string{"rsb3"},
+					Errors:        []error{kubectl.WrapNodeError("rsb3", errors.New("device /dev/sdb holds data (part ext4)"))},
+				}, nil)
+			},
+			expected: []junit.Case{
+				{Name: "rsb2"},
+				{Name: "rsb3", Failure: "device /dev/sdb holds data (part ext4)"},
+			},
+		},
+		{
+			name: "connectivity_tests",
+			add: func(suite *junit.Suite) {
+				addTestCases(suite, &nethealthcheck.TestResults{TestExecutions: []nethealthcheck.TestExecution{
+					{TestName: "ping", SourceNode: "rsb2", TargetNode: "rsb3", ExpectSuccess: true, ActualSuccess: true, Duration: time.Second, Output: "1 packets received"},
+					{TestName: "isolation", SourceNode: "rsb2", TargetNode: "rsb4", ExpectSuccess: false, ActualSuccess: true},
+					{TestName: "api", SourceNode: "rsb3", TargetNode: "rsb2", ExpectSuccess: true, ErrorMessage: "connection refused"},
+					{TestName: "rabbitmq", SourceNode: "rsb3", TargetNode: "rsb4", ExpectSuccess: true, ActualSuccess: true, Attempts: 2, Flaky: true, Output: "port 5672 open"},
+					{TestName: "galera", SourceNode: "rsb3", TargetNode: "rsb4", ExpectSuccess: true, Attempts: 3, ErrorMessage: "timed out after 30s"},
+				}}, nil)
+			},
+			expected: []junit.Case{
+				{Name: "ping rsb2 -> rsb3", Duration: time.Second, Output: "1 packets received"},
+				{Name: "isolation rsb2 -> rsb4", Failure: "expected success=false, got true"},
+				{Name: "api rsb3 -> rsb2", Failure: "connection refused"},
+				{Name: "rabbitmq rsb3 -> rsb4", Output: "flaky: passed on attempt 2\nport 5672 open"},
+				{Name: "galera rsb3 -> rsb4", Failure: "timed out after 30s (failed all 3 attempts)"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: An empty suite
+			suite := junit.NewReport("kictl verify").Suite("kind")
+
+			// When: Add the verify results
+			tt.add(suite)
+
+			// Then: Cases match exactly
+			assert.Equal(t, tt.expected, suite.Cases)
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/logging"
+)
+
+// Help texts of the kind filter flags on every command accepting them
+const (
+	onlyFlagUsage = "Only process the configurations of these kinds: labels, vlans, tests, cleanup, sysctls or storage (comma separated)"
+	skipFlagUsage = "Skip the configurations of these kinds: labels, vlans, tests, cleanup, sysctls or storage (comma separated)"
+)
+
+// kindFilterNames are the kinds --only and --skip are completed with
+var kindFilterNames = []string{"labels", "vlans", "tests", "cleanup", "sysctls", "storage"}
+
+// selectKinds restricts the bundle to the kinds of --only, or without the kinds of --skip, returning it unchanged without them
+func selectKinds(logger logging.Logger, bundle *config.ConfigBundle) (*config.ConfigBundle, error) {
+	if len(onlyKinds) > 0 && len(skipKinds) > 0 {
+		return nil, fmt.Errorf("--only and --skip cannot be used together")
+	}
+	names, skip := onlyKinds, false
+	if len(skipKinds) > 0 {
+		names, skip = skipKinds, true
+	}
+	if len(names) == 0 {
+		return bundle, nil
+	}
+
+	var kinds []string
+	for _, name := range names {
+		kind, ok := config.ConfigKindOf(strings.TrimSpace(name))
+		if !ok {
+			return nil, fmt.Errorf("unknown kind '%s'. Expected: labels, vlans, tests, cleanup, sysctls or storage", name)
+		}
+		kinds = append(kinds, kind)
+	}
+	selected, err := bundle.SelectKinds(kinds, skip)
+	if err != nil {
+		return nil, err
+	}
+
+	if skip {
+		logger.Info(fmt.Sprintf("🧩 Skipping %s: %s", strings.Join(kinds, ", "), selected.GetSummary()))
+	} else {
+		logger.Info(fmt.Sprintf("🧩 Only processing %s: %s", strings.Join(kinds, ", "), selected.GetSummary()))
+	}
+	return selected, nil
+}
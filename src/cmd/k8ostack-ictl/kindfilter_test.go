@@ -0,0 +1,66 @@
+// Package main provides unit tests for the kind filter flags
+// WHY: --only and --skip must name real kinds, and combining them has no clear meaning
+package main
+
+import (
+	"testing"
+
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/logging"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSelectKinds tests resolving --only and --skip against a bundle
+// WHY: Names like labels and vlans must select the matching configurations of the bundle
+func TestSelectKinds(t *testing.T) {
+	tests := []struct {
+		name         string
+		only         []string
+		skip         []string
+		expectLabels bool
+		expectVLANs  bool
+		expectTests  bool
+		expectSysctl bool
+		expectError  string
+	}{
+		{name: "no_filter", expectLabels: true, expectVLANs: true, expectTests: true, expectSysctl: true},
+		{name: "only_vlans", only: []string{"vlans"}, expectVLANs: true},
+		{name: "only_labels_and_tests", only: []string{"labels", "tests"}, expectLabels: true, expectTests: true},
+		{name: "skip_tests", skip: []string{"tests"}, expectLabels: true, expectVLANs: true, expectSysctl: true},
+		{name: "only_sysctls", only: []string{"sysctls"}, expectSysctl: true},
+		{name: "skip_sysctl", skip: []string{"sysctl"}, expectLabels: true, expectVLANs: true, expectTests: true},
+		{name: "only_and_skip", only: []string{"vlans"}, skip: []string{"tests"}, expectError: "--only and --skip cannot be used together"},
+		{name: "unknown_kind", only: []string{"switches"}, expectError: "unknown kind 'switches'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A bundle of labels, VLANs, tests and sysctls, and the kind filter flags
+			originalOnly, originalSkip := onlyKinds, skipKinds
+			defer func() { onlyKinds, skipKinds = originalOnly, originalSkip }()
+			onlyKinds, skipKinds = tt.only, tt.skip
+			bundle := &config.ConfigBundle{
+				NodeLabels: &config.NodeLabelConf{Kind: "NodeLabelConf"},
+				VLANs:      &config.NodeVLANConf{Kind: "NodeVLANConf"},
+				Tests:      &config.NodeTestConf{Kind: "NodeTestConf"},
+				Sysctls:    &config.NodeSysctlConf{Kind: "NodeSysctlConf"},
+			}
+
+			// When: Select the kinds
+			selected, err := selectKinds(logging.NewRecordingLogger(), bundle)
+
+			// Then: Only the selected configurations are left
+			if tt.expectError != "" {
+				assert.ErrorContains(t, err, tt.expectError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectLabels, selected.NodeLabels != nil)
+			assert.Equal(t, tt.expectVLANs, selected.VLANs != nil)
+			assert.Equal(t, tt.expectTests, selected.Tests != nil)
+			assert.Equal(t, tt.expectSysctl, selected.Sysctls != nil)
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/kubectl"
+	"k8ostack-ictl/internal/labeler"
+	"k8ostack-ictl/internal/logging"
+
+	"github.com/spf13/cobra"
+)
+
+// createLabelsCommand creates the command group for reports on the node labels of a bundle
+func createLabelsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "labels",
+		Short: "Reports on the node labels of a configuration bundle",
+	}
+
+	report := &cobra.Command{
+		Use:   "report [node...]",
+		Short: "Show per node the roles it belongs to, its merged labels and how they compare to the cluster",
+		Long: `Invert the bundle: for every node, list the roles that target it and the labels
+they merge to, with the role setting each label. Keys two roles set to different
+values are reported as conflicts, since apply sets them in no defined order. With
+membershipLabels, the VLAN membership labels are included.
+
+Each label is compared with the live node: ✅ set, ❌ missing or ~ set to another
+value. Nothing on the cluster is changed. Roles matching nodes by pattern or
+nodeSelector are resolved against the cluster even with --no-live.
+
+Examples:
+  # What is rsb5 supposed to look like?
+  kictl labels report rsb5 --config cluster-config.yaml
+
+  # Every node of the bundle, without reading the cluster
+  kictl labels report --config cluster-config.yaml --no-live`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if configFile == "" {
+				return fmt.Errorf("configuration file is required. Use --config to specify a YAML file")
+			}
+			noLive, _ := cmd.Flags().GetBool("no-live")
+
+			logger, run, err := newRunLogger(cmd, "labels-report")
+			if err != nil {
+				return fmt.Errorf("failed to initialize logger: %w", err)
+			}
+			defer closeRun(cmd, logger, run)
+
+			bundle, err := loadBundle()
+			if err != nil {
+				return fmt.Errorf("failed to load configuration: %w", err)
+			}
+			return runLabelsReport(context.Background(), cmd.OutOrStdout(), logger, bundle, newKubectlExecutor(logger), args, !noLive)
+		},
+	}
+	report.Flags().Bool("no-live", false, "Only show the configured labels, without comparing them to the nodes")
+	cmd.AddCommand(report)
+	return cmd
+}
+
+// runLabelsReport renders the effective labels of the given nodes, or of every node of the bundle
+func runLabelsReport(ctx context.Context, out io.Writer, logger logging.Logger, bundle *config.ConfigBundle, executor kubectl.DryRunExecutor, nodes []string, live bool) error {
+	var reports []*labeler.NodeLabelReport
+	if bundle.HasNodeLabels() {
+		labelingService := labeler.NewService(executor, labeler.Options{Verbose: verbose, Logger: logger})
+		var err error
+		if reports, err = labelingService.ReportLabels(ctx, bundle.NodeLabels); err != nil {
+			return fmt.Errorf("failed to resolve the roles of the bundle: %w", err)
+		}
+	}
+	reports = addMembershipLabels(reports, bundle)
+
+	byNode := make(map[string]*labeler.NodeLabelReport, len(reports))
+	for _, report := range reports {
+		byNode[report.Node] = report
+	}
+	if len(nodes) == 0 {
+		for _, report := range reports {
+			nodes = append(nodes, report.Node)
+		}
+	}
+
+	unread := 0
+	for _, nodeName := range nodes {
+		report, ok := byNode[nodeName]
+		if !ok {
+			fmt.Fprintf(out, "\nNode %s:\n  ❔ Not targeted by any role of the bundle\n", nodeName)
+			continue
+		}
+
+		var current map[string]string
+		if live {
+			success, output, err := executor.GetNodeLabels(ctx, nodeName)
+			if err != nil || !success {
+				logger.Warn(fmt.Sprintf("⚠️  Failed to read the labels of node %s: %v", nodeName, err))
+				unread++
+			} else {
+				current = kubectl.ParseNodeLabels(output)
+			}
+		}
+		renderLabelReport(out, report, current)
+	}
+
+	if unread > 0 {
+		return fmt.Errorf("labels report is incomplete: %d nodes could not be read", unread)
+	}
+	return nil
+}
+
+// addMembershipLabels adds the VLAN membership labels of bundles with membershipLabels to the reports
+func addMembershipLabels(reports []*labeler.NodeLabelReport, bundle *config.ConfigBundle) []*labeler.NodeLabelReport {
+	if !bundle.HasVLANs() || !bundle.VLANs.Tools.Nvlan.MembershipLabels {
+		return reports
+	}
+
+	byNode := make(map[string]*labeler.NodeLabelReport, len(reports))
+	for _, report := range reports {
+		byNode[report.Node] = report
+	}
+	vlanNames := make([]string, 0, len(bundle.VLANs.Spec.VLANs))
+	for vlanName := range bundle.VLANs.Spec.VLANs {
+		vlanNames = append(vlanNames, vlanName)
+	}
+	sort.Strings(vlanNames)
+
+	for _, vlanName := range vlanNames {
+		for nodeName, addresses := range bundle.VLANs.Spec.VLANs[vlanName].NodeMapping {
+			report, ok := byNode[nodeName]
+			if !ok {
+				report = labeler.NewNodeLabelReport(nodeName)
+				byNode[nodeName] = report
+				reports = append(reports, report)
+			}
+			key, value := config.VLANMembershipLabel(vlanName, addresses)
+			report.Add("vlan "+vlanName, key, value)
+		}
+	}
+	sort.Slice(reports, func(i, j int) bool { return reports[i].Node < reports[j].Node })
+	return reports
+}
+
+// renderLabelReport prints the roles and labels of a node, comparing each label with current unless it is nil
+func renderLabelReport(out io.Writer, report *labeler.NodeLabelReport, current map[string]string) {
+	fmt.Fprintf(out, "\nNode %s:\n", report.Node)
+	if len(report.Roles) > 0 {
+		fmt.Fprintf(out, "  Roles: %s\n", strings.Join(report.Roles, ", "))
+	}
+
+	keys := make([]string, 0, len(report.Sources))
+	for key := range report.Sources {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		sources := strings.Join(report.Sources[key], ", ")
+		liveValue, set := current[key]
+
+		if values, conflicting := report.Conflicts[key]; conflicting {
+			parts := make([]string, 0, len(values))
+			for _, source := range report.Sources[key] {
+				parts = append(parts, fmt.Sprintf("%s=%s", source, values[source]))
+			}
+			line := fmt.Sprintf("  ⚠️  %s: conflict (%s)", key, strings.Join(parts, ", "))
+			if current != nil {
+				if set {
+					line += ", live " + liveValue
+				} else {
+					line += ", live unset"
+				}
+			}
+			fmt.Fprintln(out, line)
+			continue
+		}
+
+		value := report.Labels[key]
+		switch {
+		case current == nil:
+			fmt.Fprintf(out, "  • %s=%s (%s)\n", key, value, sources)
+		case !set:
+			fmt.Fprintf(out, "  ❌ %s=%s (%s): missing\n", key, value, sources)
+		case liveValue != value:
+			fmt.Fprintf(out, "  ~ %s=%s (%s): live %s\n", key, value, sources, liveValue)
+		default:
+			fmt.Fprintf(out, "  ✅ %s=%s (%s)\n", key, value, sources)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+// Package main provides unit tests for the per-node labels report
+// WHY: "What is rsb5 supposed to look like?" must be answerable from the bundle alone, and against the live node
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/labeler"
+	"k8ostack-ictl/internal/logging"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// labelsReportBundle returns a bundle with rsb2 in two roles that conflict on tier, and VLAN membership labels
+func labelsReportBundle() *config.ConfigBundle {
+	bundle := revisionTestBundle()
+	bundle.NodeLabels.Spec.NodeRoles["storage"] = config.NodeRole{Nodes: []string{"rsb2"}, Labels: map[string]string{"ceph-osd": "enabled", "zone": "a", "tier": "silver"}}
+	compute := bundle.NodeLabels.Spec.NodeRoles["compute"]
+	compute.Labels["tier"] = "gold"
+	bundle.VLANs.Tools.Nvlan.MembershipLabels = true
+	return bundle
+}
+
+// TestRunLabelsReport tests the report of the roles and labels of a node
+// WHY: Each label must name the role setting it and show whether the live node has it
+func TestRunLabelsReport(t *testing.T) {
+	tests := []struct {
+		name           string
+		nodes          []string
+		live           bool
+		expectedOutput []string
+		expectError    string
+	}{
+		{
+			name:  "live_comparison",
+			nodes: []string{"rsb2"},
+			live:  true,
+			expectedOutput: []string{
+				"Node rsb2:\n  Roles: compute, storage\n",
+				"  ❌ ceph-osd=enabled (storage): missing\n",
+				"  ⚠️  tier: conflict (compute=gold, storage=silver), live gold\n",
+				"  ✅ zone=a (compute, storage)\n",
+			},
+		},
+		{
+			name: "every_node_without_cluster",
+			expectedOutput: []string{
+				"  • ceph-osd=enabled (storage)\n",
+				"Node rsb3:\n  Roles: compute\n",
+				"Node rsb4:\n  • network.kictl.io/vlan-storage=10.0.100.4 (vlan storage)\n",
+			},
+		},
+		{
+			name:           "node_outside_bundle",
+			nodes:          []string{"rsb9"},
+			expectedOutput: []string{"Node rsb9:\n  ❔ Not targeted by any role of the bundle\n"},
+		},
+		{
+			name:           "unreadable_node",
+			nodes:          []string{"rsb3"},
+			live:           true,
+			expectedOutput: []string{"Node rsb3:\n  Roles: compute\n"},
+			expectError:    "labels report is incomplete: 1 nodes could not be read",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: rsb2 labeled with zone a and tier gold, and rsb3 unreadable
+			executor := labeler.NewMockDryRunExecutor()
+			executor.On("GetNodeLabels", mock.Anything, "rsb2").Return(true, "NAME   STATUS   LABELS\nrsb2   Ready    tier=gold,zone=a", nil).Maybe()
+			executor.On("GetNodeLabels", mock.Anything, "rsb3").Return(false, "", assert.AnError).Maybe()
+
+			// When: Report the labels
+			var out bytes.Buffer
+			err := runLabelsReport(context.Background(), &out, logging.NewRecordingLogger(), labelsReportBundle(), executor, tt.nodes, tt.live)
+
+			// Then: Each label shows its roles and, when live, its state on the node
+			if tt.expectError != "" {
+				assert.EqualError(t, err, tt.expectError)
+			} else {
+				require.NoError(t, err)
+			}
+			for _, expected := range tt.expectedOutput {
+				assert.Contains(t, out.String(), expected)
+			}
+		})
+	}
+}
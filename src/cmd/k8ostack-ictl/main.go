@@ -2,17 +2,31 @@
 package main
 
 import (
+	"bufio"
 	"context"
 	"fmt"
+	"net"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
+	"k8ostack-ictl/internal/audit"
 	"k8ostack-ictl/internal/config"
 	"k8ostack-ictl/internal/config/precedence"
+	"k8ostack-ictl/internal/history"
+	"k8ostack-ictl/internal/junit"
 	"k8ostack-ictl/internal/kubectl"
 	"k8ostack-ictl/internal/labeler"
 	"k8ostack-ictl/internal/logging"
 	"k8ostack-ictl/internal/nethealthcheck"
+	"k8ostack-ictl/internal/settings"
+	"k8ostack-ictl/internal/storage"
+	"k8ostack-ictl/internal/summary"
+	"k8ostack-ictl/internal/sysctl"
+	"k8ostack-ictl/internal/throttle"
 	"k8ostack-ictl/internal/vlan"
+	"k8ostack-ictl/internal/workspace"
 
 	"github.com/spf13/cobra"
 )
@@ -20,19 +34,91 @@ import (
 // CLI flags
 var (
 	configFile          string
+	strictSchema        bool
+	valuesFiles         []string
 	dryRun              bool
+	dryRunStrict        bool
+	dryRunKinds         []string
 	verbose             bool
 	generateConfig      bool
 	generateMultiConfig bool
+	fixTypos            bool
+	rollbackOnFailure   bool
+	force               bool
+	templatesDir        string
+	confirmZapCount     int
+	recordBaseline      bool
+	targets             []string
+	nodeFilter          []string
+	nodesFile           string
+	onlyKinds           []string
+	skipKinds           []string
+	junitOutput         string
+	publishCondition    bool
+	testReports         map[string]string
+	historyDir          string
+	runDBPath           string
+	stateLocation       string
+	artifactsURL        string
+	artifactsEndpoint   string
+	cmdbURL             string
+	cmdbRetries         int
+	workspaceDir        string
+	retentionMaxAge     time.Duration
+	retentionMaxRuns    int
+	retentionMaxSize    string
+	nodeExecBackend     string
+	hostPodNamespace    string
+	hostPodSelector     string
+	hostEntry           string
+	agentNamespace      string
+	sshNodes            []string
+	restricted          bool
+	allowedCommands     []string
+	offline             bool
+	kubeClient          string
+	kubeconfigPath      string
+	kubeContext         string
+	kubeNamespace       string
+	maxParallelism      int
+	zoneAware           bool
+	zoneKey             string
+	rolloutOrder        string
+	rolloutSeed         int64
+	batchSize           int
+	soakDuration        time.Duration
+	soakTests           []string
+	soakRollback        bool
+	logFormat           string
+	explainConfig       bool
+	toolOverrides       []string
+	noProgress          bool
+	noSummaryTable      bool
+	planFile            string
+	approvalsFile       string
+	colorMode           string
+	noColor             bool
+	quiet               bool
+	auditLog            string
 )
 
 func main() {
 	rootCmd := createRootCommand()
+	if pluginMode = isPluginInvocation(os.Args[0]); pluginMode {
+		adaptToPlugin(rootCmd)
+	}
 
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+	args, err := expandAlias(rootCmd, os.Args[1:])
+	if err != nil {
+		fmt.Fprintf(rootCmd.ErrOrStderr(), "❌ Error: %v\n", err)
 		os.Exit(1)
 	}
+	rootCmd.SetArgs(args)
+
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Fprintf(rootCmd.ErrOrStderr(), "❌ Error: %v\n", err)
+		os.Exit(exitCode(err))
+	}
 }
 
 func createRootCommand() *cobra.Command {
@@ -45,47 +131,288 @@ Multi-CRD unified infrastructure automation platform supporting:
 - NodeLabelConf: Kubernetes node labeling
 - NodeVLANConf: VLAN configuration and management  
 - NodeTestConf: Network connectivity testing
+- CleanupConf: Bulk removal of labels by key prefix
+- NodeSysctlConf: Kernel tuning with sysctls and hugepage reservations
+- NodeStorageConf: Ceph OSD device preparation and Rook node labels
 
 The tool processes single or multi-document YAML configurations with
 global CLI precedence and comprehensive validation.
 
 Examples:
   # Generate sample configuration
-  kictl --generate-config
+  kictl generate
 
   # Apply node labels from configuration
-  kictl --config cluster-config.yaml --apply
+  kictl apply --config cluster-config.yaml
 
   # Dry-run with verbose output
-  kictl --config cluster-config.yaml --apply --dry-run --verbose
+  kictl apply --config cluster-config.yaml --dry-run --verbose
+
+  # Show what an apply would change, per node
+  kictl plan --config cluster-config.yaml
+
+  # Check that the cluster matches the configuration without changing it
+  kictl verify --config cluster-config.yaml
 
   # Remove applied labels
-  kictl --config cluster-config.yaml --delete
+  kictl delete --config cluster-config.yaml
 
-  # Apply multi-CRD infrastructure
-  kictl --config multi-infrastructure.yaml --apply`,
+  # Preview removal of legacy labels by key prefix
+  kictl --unlabel-prefix openstack-role --nodes rsb2,rsb3 --dry-run
+
+  # Show how a node's labels, annotations and VLANs changed across runs
+  kictl timeline node rsb2
+
+  # Remove old run logs and plans from the workspace
+  kictl clean
+
+  # Reconcile the configuration kinds as custom resources
+  kictl operator
+
+Legacy flag form (still supported):
+  kictl --generate-config
+  kictl --config cluster-config.yaml --apply
+  kictl --config cluster-config.yaml --delete`,
 		RunE: runCommand,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return configError(validateGlobalFlags(cmd))
+		},
 	}
 
-	// Operation flags
-	rootCmd.Flags().Bool("apply", false, "Apply labels defined in the configuration file")
-	rootCmd.Flags().Bool("delete", false, "Remove labels defined in the configuration file")
+	// Flags that do not parse exit like invalid configuration
+	rootCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return configError(err)
+	})
+
+	// Shared flags, inherited by every subcommand
+	rootCmd.PersistentFlags().StringVarP(&configFile, "config", "c", "", "Path or URL (https://, s3://, git::<repo>//<path>?ref=<ref>) of the YAML configuration")
+	rootCmd.PersistentFlags().BoolVar(&strictSchema, "strict-schema", false, "Validate the configuration against the JSON Schema of each kind first, rejecting unknown fields")
+	rootCmd.PersistentFlags().StringArrayVar(&valuesFiles, "values", nil,
+		"Values file (path or URL like --config) to render the configuration with as a Go template; later files override earlier ones (repeatable)")
+	rootCmd.PersistentFlags().Var(newDryRunValue(&dryRun, &dryRunStrict, &dryRunKinds), "dry-run",
+		"Simulate the operation without making actual changes (--dry-run=strict fails instead of warning if a change would still reach the cluster; --dry-run=vlans,cleanup simulates those kinds only)")
+	rootCmd.PersistentFlags().Lookup("dry-run").NoOptDefVal = "true"
+	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Enable verbose debug output")
+	rootCmd.PersistentFlags().String("node-name-pattern", "", "Regex that every node name in the configuration must match (overrides tools.*.nodeNamePattern)")
+	rootCmd.PersistentFlags().String("log-level", "info", "Set log level (debug, info, warn, error)")
+	rootCmd.PersistentFlags().StringVar(&logFormat, "log-format", logging.FormatText,
+		"Log entry format: text or json (one object per line with level, time, component, node and operation for Loki or ELK)")
+	rootCmd.PersistentFlags().StringVar(&colorMode, "color", settings.ColorAuto, "When to color output: auto (terminals, unless NO_COLOR is set), always or never")
+	rootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "Do not color output (same as --color=never)")
+	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false,
+		"Print only errors and the final summaries, without status lines, warnings or the progress line (the run log keeps everything)")
+	rootCmd.PersistentFlags().BoolVar(&noProgress, "no-progress", false,
+		"Do not draw the progress line of operations across many nodes (it is only drawn when the output is a terminal)")
+	rootCmd.PersistentFlags().BoolVar(&noSummaryTable, "no-summary-table", false,
+		"Do not print the table of what apply, delete and verify did per node at the end of the run")
+	rootCmd.PersistentFlags().StringArrayVar(&toolOverrides, precedence.SetFlag, nil,
+		"Override one tool setting for the documents of that tool only, as <tool>.<setting>=<value>, e.g. nlabel.validateNodes=false (repeatable)")
+	rootCmd.PersistentFlags().Bool(precedence.AllowUnsafeFlag, false,
+		"Allow a flag, KICTL_* variable or --set to turn off a safety setting the config file turns on, e.g. dryRun: true")
+	rootCmd.PersistentFlags().BoolVar(&explainConfig, "explain-config", false,
+		"Print where each tool setting of each configuration got its value: default, config file, KICTL_* environment variable or flag")
+
+	// Legacy operation flags (prefer the apply, delete and generate subcommands)
+	rootCmd.Flags().Bool("apply", false, "Apply the configuration (same as 'kictl apply')")
+	rootCmd.Flags().Bool("delete", false, "Remove the configuration (same as 'kictl delete')")
+	rootCmd.Flags().BoolVar(&generateConfig, "generate-config", false, "Generate a sample configuration file and exit (same as 'kictl generate')")
+	rootCmd.Flags().BoolVar(&generateMultiConfig, "generate-multi-config", false, "Generate a sample multi-CRD configuration file and exit (same as 'kictl generate --multi')")
+
+	// Interactive flags
+	rootCmd.Flags().BoolVar(&fixTypos, "fix-typos", false, "Check node names against the cluster and offer to correct typos in the config file")
+
+	// VLAN flags
+	rootCmd.Flags().BoolVar(&rollbackOnFailure, "rollback-on-failure", false, "Tear down the VLAN interfaces created by an apply when any node fails")
+	rootCmd.Flags().BoolVar(&force, "force", false, "Change the interface kictl reaches a node through even when the reachability guard finds no other way in")
+	rootCmd.Flags().StringVar(&templatesDir, "templates-dir", "", templatesDirFlagUsage)
+	_ = rootCmd.MarkFlagDirname("templates-dir")
 
-	// Configuration flags
-	rootCmd.Flags().StringVarP(&configFile, "config", "c", "", "Path to YAML configuration file")
-	rootCmd.Flags().BoolVar(&generateConfig, "generate-config", false, "Generate a sample configuration file and exit")
-	rootCmd.Flags().BoolVar(&generateMultiConfig, "generate-multi-config", false, "Generate a sample multi-CRD configuration file and exit")
+	// Storage flags
+	rootCmd.Flags().IntVar(&confirmZapCount, "confirm-zap", 0, confirmZapFlagUsage)
+	rootCmd.Flags().BoolVar(&recordBaseline, "record-baseline", false, "Record the connectivity matrix of the tests as the baseline later runs are compared against")
 
-	// Behavior flags
-	rootCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Simulate the operation without making actual changes")
-	rootCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Enable verbose debug output")
+	// Targeting flags
+	rootCmd.Flags().StringSliceVar(&targets, "target", nil, targetFlagUsage)
 
-	// Future extensibility flags (placeholders for other tools)
-	rootCmd.Flags().String("log-level", "info", "Set log level (debug, info, warn, error)")
+	// Test report flags
+	rootCmd.Flags().Var(newReportValue(&testReports), "report", "Also write connectivity test results of an apply as <format>=<path>, e.g. junit=report.xml (repeatable)")
+
+	// Cleanup flags
+	rootCmd.Flags().StringSlice("unlabel-prefix", nil, "Remove all labels whose key starts with this prefix (repeatable)")
+	rootCmd.Flags().StringSliceVar(&nodeFilter, "nodes", nil, nodesFlagUsage+"; with --unlabel-prefix, the nodes to clean up")
+	rootCmd.Flags().StringVar(&nodesFile, "nodes-file", "", nodesFileFlagUsage)
+	rootCmd.Flags().StringSliceVar(&onlyKinds, "only", nil, onlyFlagUsage)
+	rootCmd.Flags().StringSliceVar(&skipKinds, "skip", nil, skipFlagUsage)
+	rootCmd.Flags().String("selector", "", "Label selector choosing nodes to clean up with --unlabel-prefix")
+
+	// Kubernetes client flags
+	rootCmd.PersistentFlags().StringVar(&kubeconfigPath, "kubeconfig", "", "Path to the kubeconfig file (default $KUBECONFIG or ~/.kube/config)")
+	rootCmd.PersistentFlags().StringVar(&kubeContext, "context", "", "Kubeconfig context to use (default the current context)")
+	rootCmd.PersistentFlags().StringVarP(&kubeNamespace, "namespace", "n", "", "Namespace of node command pods (default the namespace of the context)")
+	rootCmd.PersistentFlags().StringVar(&kubeClient, "client", kubectl.ClientKubectl,
+		"How kictl talks to the cluster: kubectl (shell out to the kubectl binary) or native (client-go using the kubeconfig)")
+
+	// Node command execution flags
+	rootCmd.PersistentFlags().StringVar(&nodeExecBackend, "node-exec-backend", kubectl.NodeExecBackendDebugPod,
+		"How commands run on nodes: debug-pod (kubectl debug node), ephemeral (ephemeral container in an existing host pod) or agent (exec into a kictl node agent DaemonSet pod, deployed on first use)")
+	rootCmd.PersistentFlags().StringVar(&hostPodNamespace, "host-pod-namespace", "kube-system", "Namespace of the per-node host-network pod used by the ephemeral backend")
+	rootCmd.PersistentFlags().StringVar(&hostPodSelector, "host-pod-selector", "k8s-app=kube-proxy", "Label selector of the per-node host-network pod used by the ephemeral backend")
+	rootCmd.PersistentFlags().StringVar(&hostEntry, "host-entry", "",
+		"How node commands enter the host: chroot, nsenter or none (default: chroot for debug-pod and agent, none for ephemeral)")
+	rootCmd.PersistentFlags().StringVar(&agentNamespace, "agent-namespace", "kube-system", "Namespace of the node agent DaemonSet used by the agent backend")
+	rootCmd.PersistentFlags().StringSliceVar(&sshNodes, "ssh-nodes", nil,
+		"Run node commands over SSH on these nodes or node patterns, \"*\" for all, falling back to the backend when SSH fails (overrides spec.ssh.nodes)")
+
+	// Restricted mode flags
+	rootCmd.PersistentFlags().BoolVar(&restricted, "restricted", false, "Refuse any node command whose executable is not in --allowed-commands")
+	rootCmd.PersistentFlags().StringSliceVar(&allowedCommands, "allowed-commands", kubectl.DefaultAllowedCommands, "Node command allowlist used with --restricted")
+
+	// Concurrency flags
+	rootCmd.PersistentFlags().IntVar(&maxParallelism, "max-parallelism", 1,
+		"Maximum nodes labeled or configured at once; reduced automatically while the API server is slow or returns 429")
+	rootCmd.PersistentFlags().BoolVar(&zoneAware, "zone-aware", false, "With --max-parallelism, never change two nodes of the same --zone-key failure domain at once")
+	rootCmd.PersistentFlags().StringVar(&zoneKey, "zone-key", defaultZoneKey, "Node label naming the failure domain of a node for --zone-aware")
+	rootCmd.PersistentFlags().StringVar(&rolloutOrder, "rollout-order", throttle.OrderAlphabetical,
+		"Order nodes are changed in: alphabetical, random (seeded by --rollout-seed) or zone (one node of each --zone-key zone in turn)")
+	rootCmd.PersistentFlags().Int64Var(&rolloutSeed, "rollout-seed", 0, "Seed of --rollout-order random; 0 picks one and logs it so the order can be repeated")
+	rootCmd.PersistentFlags().IntVar(&batchSize, "batch-size", 0, "Change nodes in batches of this many, each finished before the next starts; 0 changes them in one batch")
+	rootCmd.PersistentFlags().DurationVar(&soakDuration, "soak", 0, "After every batch, watch its nodes this long and abort the rollout when one is not Ready")
+	rootCmd.PersistentFlags().StringSliceVar(&soakTests, "soak-tests", nil, "NodeTestConf tests to run as smoke tests at the end of every soak; a failure aborts the rollout")
+	rootCmd.PersistentFlags().BoolVar(&soakRollback, "soak-rollback", false, "Tear down the VLAN interfaces created on the batch whose soak aborted the rollout")
+
+	// Offline mode flags
+	rootCmd.PersistentFlags().BoolVar(&offline, "offline", false, "Fail instead of reaching any host other than the cluster API server (no credential plugins, auth providers or remote paths)")
+
+	// Workspace flags
+	rootCmd.PersistentFlags().StringVar(&workspaceDir, "workspace", "", "Directory holding logs, plans and history, one subfolder per run (default $KICTL_HOME or ~/.kictl)")
+	rootCmd.PersistentFlags().DurationVar(&retentionMaxAge, "retention-max-age", 30*24*time.Hour, "Remove run folders older than this at startup (0 keeps them regardless of age)")
+	rootCmd.PersistentFlags().IntVar(&retentionMaxRuns, "retention-max-runs", 200, "Keep at most this many run folders (0 for no limit)")
+	rootCmd.PersistentFlags().StringVar(&retentionMaxSize, "retention-max-size", "1Gi", "Keep run folders below this total size, e.g. 500Mi or 2Gi (0 for no limit)")
+
+	// Artifact storage flags
+	rootCmd.PersistentFlags().StringVar(&artifactsURL, "artifacts", "", "Also upload each run folder and its reports to s3://bucket[/prefix]/<run>/ with the aws CLI")
+	rootCmd.PersistentFlags().StringVar(&artifactsEndpoint, "artifacts-endpoint", "", "S3-compatible endpoint for --artifacts, e.g. https://minio.lab:9000 (default AWS)")
+
+	// CMDB flags
+	rootCmd.PersistentFlags().StringVar(&cmdbURL, "cmdb-url", "", "POST the per-node changes of each successful apply to this CMDB endpoint (token from $KICTL_CMDB_TOKEN)")
+	rootCmd.PersistentFlags().IntVar(&cmdbRetries, "cmdb-retries", 3, "Retries of a failed CMDB post before the change is queued for the next apply")
+
+	// History flags
+	rootCmd.PersistentFlags().StringVar(&historyDir, "history-dir", "", "Directory where run history and node snapshots are stored (default <workspace>/history)")
+	rootCmd.PersistentFlags().StringVar(&runDBPath, "run-db", "", "Also record runs, per-node results and timings in this SQLite database for 'kictl runs' (default $"+envRunDB+")")
+
+	// Audit flags
+	rootCmd.PersistentFlags().StringVar(&auditLog, "audit-log", "",
+		"Where every label and VLAN change of apply and delete is recorded: a JSON lines file, or '"+audit.DestinationEvents+"' for Events on the nodes (default ~/.kictl/"+audit.FileName+")")
+
+	// State flags
+	rootCmd.PersistentFlags().StringVar(&stateLocation, "state", "",
+		"Where the applied state used by 'kictl drift' is recorded: a file path or configmap:<namespace>/<name> (default <workspace>/state.json)")
+
+	// Expose the shared flags on the root itself for the legacy flag form
+	rootCmd.Flags().AddFlagSet(rootCmd.PersistentFlags())
+
+	// Operation commands
+	rootCmd.AddCommand(createApplyCommand())
+	rootCmd.AddCommand(createDeleteCommand())
+	rootCmd.AddCommand(createVerifyCommand())
+	rootCmd.AddCommand(createTestCommand())
+	rootCmd.AddCommand(createValidateCommand())
+	rootCmd.AddCommand(createPlanCommand())
+	rootCmd.AddCommand(createApproveCommand())
+	rootCmd.AddCommand(createAddressesCommand())
+	rootCmd.AddCommand(createGenerateCommand())
+	rootCmd.AddCommand(createImportCommand())
+	rootCmd.AddCommand(createConvertCommand())
+	rootCmd.AddCommand(createRenderCommand())
+	rootCmd.AddCommand(createSchemaCommand())
+	rootCmd.AddCommand(createCapabilitiesCommand())
+	rootCmd.AddCommand(createExportCommand())
+
+	// History commands
+	rootCmd.AddCommand(createSnapshotCommand())
+	rootCmd.AddCommand(createTimelineCommand())
+	rootCmd.AddCommand(createRunsCommand())
+	rootCmd.AddCommand(createCMDBCommand())
+	rootCmd.AddCommand(createDriftCommand())
+	rootCmd.AddCommand(createRevisionsCommand())
+	rootCmd.AddCommand(createLabelsCommand())
+	rootCmd.AddCommand(createCleanCommand())
+	rootCmd.AddCommand(createOperatorCommand())
+
+	// Recovery commands
+	rootCmd.AddCommand(createBMCCommand())
+	rootCmd.AddCommand(createOrphansCommand())
+	rootCmd.AddCommand(createQuarantineCommand())
+
+	// Shell completion, replacing the default command of cobra with one documenting the kictl completions
+	rootCmd.CompletionOptions.DisableDefaultCmd = true
+	rootCmd.AddCommand(createCompletionCommand())
+	registerConfigCompletion(rootCmd)
+	registerFilterCompletions(rootCmd)
 
 	return rootCmd
 }
 
+// validateGlobalFlags applies the user settings and checks the flags shared by every command
+func validateGlobalFlags(cmd *cobra.Command) error {
+	if err := applyUserSettings(cmd); err != nil {
+		return err
+	}
+	if err := validateColorMode(colorMode); err != nil {
+		return err
+	}
+	if err := validateOutputFlags(); err != nil {
+		return err
+	}
+	if err := kubectl.ValidateClient(kubeClient); err != nil {
+		return err
+	}
+	if err := kubectl.ValidateNodeExecBackend(nodeExecBackend); err != nil {
+		return err
+	}
+	if err := kubectl.ValidateHostEntry(hostEntry); err != nil {
+		return err
+	}
+	if _, err := retentionPolicy(); err != nil {
+		return err
+	}
+	if _, err := newArtifactUploader(); err != nil {
+		return err
+	}
+	if _, err := newCMDBClient(); err != nil {
+		return err
+	}
+	if err := logging.ValidateFormat(logFormat); err != nil {
+		return err
+	}
+	if maxParallelism < 1 {
+		return fmt.Errorf("--max-parallelism must be at least 1, got %d", maxParallelism)
+	}
+	if zoneAware && strings.TrimSpace(zoneKey) == "" {
+		return fmt.Errorf("--zone-aware needs a --zone-key naming the node label of the failure domain")
+	}
+	if err := throttle.ValidateOrder(rolloutOrder); err != nil {
+		return fmt.Errorf("--rollout-order: %w", err)
+	}
+	if rolloutOrder == throttle.OrderZone && strings.TrimSpace(zoneKey) == "" {
+		return fmt.Errorf("--rollout-order zone needs a --zone-key naming the node label of the zone")
+	}
+	if batchSize < 0 {
+		return fmt.Errorf("--batch-size must not be negative, got %d", batchSize)
+	}
+	if soakDuration < 0 {
+		return fmt.Errorf("--soak must not be negative, got %s", soakDuration)
+	}
+	if soakRollback && !soaking() {
+		return fmt.Errorf("--soak-rollback needs --soak or --soak-tests")
+	}
+	if offline {
+		return checkOffline()
+	}
+	return nil
+}
+
 func runCommand(cmd *cobra.Command, args []string) error {
 	ctx := context.Background()
 
@@ -104,38 +431,159 @@ func runCommand(cmd *cobra.Command, args []string) error {
 
 	// Validate operation flags BEFORE other checks
 	if applyOp && deleteOp {
-		return fmt.Errorf("cannot specify both --apply and --delete operations")
+		return configError(fmt.Errorf("cannot specify both --apply and --delete operations"))
+	}
+
+	// Flag-driven label cleanup runs standalone, without a configuration file
+	unlabelPrefixes, _ := cmd.Flags().GetStringSlice("unlabel-prefix")
+	if len(unlabelPrefixes) > 0 {
+		if applyOp || deleteOp {
+			return configError(fmt.Errorf("--unlabel-prefix cannot be combined with --apply or --delete"))
+		}
+		return runFlagCleanup(ctx, cmd, unlabelPrefixes)
 	}
 
 	// Config-based mode - check after flag validation
 	if configFile == "" {
-		return fmt.Errorf("configuration file is required. Use --config to specify a YAML file, or --generate-config to create a sample")
+		return configError(fmt.Errorf("configuration file is required. Use --config to specify a YAML file, or --generate-config to create a sample"))
 	}
 
 	// Initialize logger early for tests that expect logger errors
-	logger, err := logging.NewFileLogger("logs", verbose)
+	operation := operationApply
+	if deleteOp {
+		operation = operationDelete
+	}
+
+	logger, run, err := newRunLogger(cmd, operation)
 	if err != nil {
 		return fmt.Errorf("failed to initialize logger: %w", err)
 	}
-	defer logger.Close()
+	defer closeRun(cmd, logger, run)
 
 	// Require explicit operation - no dangerous defaults!
 	if !applyOp && !deleteOp {
-		return fmt.Errorf("operation required: specify either --apply or --delete\n\nExamples:\n  kictl --config %s --apply    # Apply configuration\n  kictl --config %s --delete   # Remove configuration", configFile, configFile)
+		return configError(fmt.Errorf("operation required: specify either --apply or --delete\n\nExamples:\n  kictl apply --config %s    # Apply configuration\n  kictl delete --config %s   # Remove configuration", configFile, configFile))
 	}
 
+	return runRecordedOperation(ctx, cmd, logger, run, operation)
+}
+
+// runBundleOperation loads the configuration bundle and runs an operation across all of its services
+// The recorder, which may be nil, collects what the run database keeps about it; files of the run go to its folder
+func runBundleOperation(ctx context.Context, cmd *cobra.Command, logger *logging.FileLogger, run *workspace.Run, operation string, recorder *runRecorder) error {
+	deleteOp := operation == operationDelete
+	verifyOp := operation == operationVerify
+
 	// Load configuration bundle (supports both single and multi-CRD configs)
-	bundle, err := config.LoadMultipleConfigs(configFile)
+	bundle, err := loadBundle()
 	if err != nil {
-		return fmt.Errorf("failed to load configuration: %w", err)
+		return configError(fmt.Errorf("failed to load configuration: %w", err))
+	}
+
+	// Offer to correct misspelled node names before anything touches the cluster
+	if fixTypos && !verifyOp {
+		fixed, err := fixNodeNameTypos(ctx, cmd, logger, bundle)
+		if err != nil {
+			return fmt.Errorf("failed to fix node name typos: %w", err)
+		}
+		if fixed {
+			bundle, err = loadBundle()
+			if err != nil {
+				return fmt.Errorf("failed to reload corrected configuration: %w", err)
+			}
+		}
 	}
 
 	// Create global precedence resolver
-	resolver := precedence.NewGlobalResolver(cmd)
+	resolver := newPrecedenceResolver(cmd)
 
 	// Apply global CLI precedence to ALL configurations in the bundle
 	if err := resolver.ApplyGlobalOverrides(bundle); err != nil {
-		return fmt.Errorf("failed to apply CLI precedence: %w", err)
+		return configError(fmt.Errorf("failed to apply CLI precedence: %w", err))
+	}
+	printConfigDecisions(cmd, resolver)
+	warnUnsafeOverrides(logger, resolver)
+
+	// Enforce node naming policy with overrides applied
+	if err := bundle.ValidateNodeNamePolicy(); err != nil {
+		return configError(fmt.Errorf("node name policy violation: %w", err))
+	}
+
+	// Restrict the run to the items addressed by --target; tests still resolve networks from every VLAN
+	networks := bundle.VLANs
+	smoke, err := soakSmokeTests(bundle)
+	if err != nil {
+		return configError(err)
+	}
+	if bundle, err = selectKinds(logger, bundle); err != nil {
+		return configError(err)
+	}
+	if bundle, err = targetBundle(logger, bundle); err != nil {
+		return configError(err)
+	}
+	if bundle, err = restrictToNodes(logger, bundle); err != nil {
+		return configError(err)
+	}
+
+	// Site templates are loaded up front, so a broken one fails the run before any node is touched
+	var templates *vlan.Templates
+	if templatesDir != "" && bundle.HasVLANs() {
+		if templates, err = vlan.LoadTemplates(templatesDir); err != nil {
+			return configError(fmt.Errorf("failed to load --templates-dir: %w", err))
+		}
+	}
+	if bundle, err = skipQuarantined(ctx, logger, bundle, newBundleExecutor(logger, bundle.GetDefaults())); err != nil {
+		return err
+	}
+	recorder.setDryRun(isBundleDryRun(bundle))
+
+	// Fail before anything runs when kubectl or the cluster cannot run the node commands of the run
+	if !isBundleDryRun(bundle) {
+		if err := checkCompatibility(ctx, logger); err != nil {
+			return err
+		}
+		defer func() { legacyDebug = false }()
+	}
+
+	// Protected clusters only take plans a second person approved
+	if operation == operationApply {
+		if err := checkPlanApproval(logger, bundle); err != nil {
+			return err
+		}
+	}
+
+	// Production contexts get confirmation, locking, history and policy enforcement whatever the flags say
+	var protected *protectedRun
+	if !verifyOp && !isBundleDryRun(bundle) {
+		if protected, err = enforceProtection(ctx, cmd, logger, resolver, operation); err != nil {
+			return err
+		}
+		defer protected.release(ctx, logger)
+	}
+
+	// Devices holding data are only zapped once the operator confirmed how many, see confirmZap
+	allowZap := false
+	if operation == operationApply {
+		if allowZap, err = confirmZap(cmd, logger, bundle); err != nil {
+			return err
+		}
+	}
+
+	// Every change to a node is written to the audit trail, see --audit-log
+	if !verifyOp && !isBundleDryRun(bundle) {
+		stopAudit, err := startAudit(logger, bundle, operation)
+		if err != nil {
+			return fmt.Errorf("failed to open the audit log: %w", err)
+		}
+		defer stopAudit()
+	}
+
+	// Bundles naming an owner must not silently change what other owners manage on shared nodes
+	var owned *ownedRun
+	if !verifyOp {
+		if owned, err = checkOwnership(ctx, logger, bundle, newBundleExecutor(logger, bundle.GetDefaults())); err != nil {
+			return err
+		}
 	}
 
 	// Log applied overrides for transparency
@@ -143,17 +591,26 @@ func runCommand(cmd *cobra.Command, args []string) error {
 	if len(overrides) > 0 {
 		logger.Info("🔄 CLI flags overriding config settings:")
 		for flag, value := range overrides {
+			if envVar := resolver.EnvVar(flag); envVar != "" {
+				logger.Info(fmt.Sprintf("  --%s: %v (from %s)", flag, value, envVar))
+				continue
+			}
 			logger.Info(fmt.Sprintf("  --%s: %v", flag, value))
 		}
 	}
 
-	// Display startup info with bundle summary
-	fmt.Printf("📋 Using config file: %s\n", configFile)
-	fmt.Printf("📦 Configuration bundle: %s\n", bundle.GetSummary())
+	// Display startup info with bundle summary, which quiet output leaves out
+	out := consoleOptions().Chatter(cmd.OutOrStdout())
+	fmt.Fprintf(out, "📋 Using config file: %s\n", configFile)
+	fmt.Fprintf(out, "📦 Configuration bundle: %s\n", bundle.GetSummary())
 
 	if len(overrides) > 0 {
 		if _, isDryRun := overrides["dry-run"]; isDryRun {
-			fmt.Printf("🧪 DRY RUN MODE: No changes will be made\n")
+			if len(dryRunKinds) > 0 {
+				fmt.Fprintf(out, "🧪 DRY RUN MODE for %s: No changes will be made to these kinds\n", strings.Join(dryRunKinds, ", "))
+			} else {
+				fmt.Fprintf(out, "🧪 DRY RUN MODE: No changes will be made\n")
+			}
 		}
 	}
 
@@ -163,123 +620,365 @@ func runCommand(cmd *cobra.Command, args []string) error {
 	// Execute operations based on what configurations are present
 	// This is the beautiful extensible pattern you loved!
 	var totalErrors []error
+	failures := summary.New()
 
-	// Process NodeLabels if present
-	if bundle.HasNodeLabels() {
-		logger.Info("🏷️  Processing node labeling configuration...")
+	// What every service did per node, printed as the node table at the end of the run
+	runNodes = newNodeTable()
+	defer func() { runNodes = nil }()
 
-		// Initialize kubectl executor
-		kubectlExecutor := kubectl.NewExecutor(logger)
-		// Speed up polling for tests
-		if os.Getenv("KICTL_TEST_MODE") == "true" {
-			kubectlExecutor.SetPollingInterval(0)
+	// What the services changed for real, which history, ownership and the applied state record
+	applied := newAppliedChanges(bundle)
+
+	// Kind of the service whose failure stops the remaining ones, see failurePolicy
+	var abortedBy string
+
+	// Verify results per node, printed as the verification report and written for CI dashboards with --junit
+	var report *junit.Report
+	if verifyOp {
+		report = junit.NewReport("kictl verify")
+	}
+
+	// Process label cleanup first so legacy labels are gone before new ones are applied
+	if bundle.HasCleanup() {
+		if deleteOp || verifyOp {
+			logger.Warn(fmt.Sprintf("⚠️  CleanupConf has no %s operation, skipping label cleanup", operation))
+		} else {
+			logger.Info("🧹 Processing label cleanup configuration...")
+			errorsBefore := len(totalErrors)
+			started := time.Now()
+			serviceLog := serviceLogger(logger, "cleanup", operation)
+			tools := bundle.Cleanup.GetTools()
+			serviceCtx, cancel := serviceContext(ctx, tools.Nlabel)
+			err := runLabelCleanup(serviceCtx, serviceLog, bundle.Cleanup, tools.Nlabel.DryRun, failures)
+			if serviceTimedOut(ctx, serviceCtx) {
+				err = serviceTimeoutError(bundle.Cleanup.Kind, tools.Nlabel)
+				failures.Add(bundle.Cleanup.Kind, err)
+			}
+			cancel()
+			if err != nil {
+				totalErrors = append(totalErrors, err)
+				if tools.Nlabel.AbortsOnFailure() {
+					abortedBy = bundle.Cleanup.Kind
+				}
+			} else if !tools.Nlabel.DryRun {
+				applied.add(bundle.Cleanup, nil)
+			}
+			recorder.service(bundle.Cleanup.Kind, started, len(totalErrors)-errorsBefore)
 		}
+	}
+
+	// Process NodeLabels if present
+	if bundle.HasNodeLabels() && !skipAfterAbort(logger, abortedBy, bundle.NodeLabels.Kind) {
+		serviceLog := serviceLogger(logger, "nlabel", operation)
+		serviceLog.Info("🏷️  Processing node labeling configuration...")
+		errorsBefore := len(totalErrors)
+		started := time.Now()
 
 		// Get final tool configuration from the resolved config
 		tools := bundle.NodeLabels.GetTools()
+		serviceCtx, cancel := serviceContext(ctx, tools.Nlabel)
+
+		// Initialize kubectl executor
+		workers, kubectlExecutor := newNodeWorkers(serviceLog, newBundleExecutor(serviceLog, bundle.GetDefaults()),
+			rolloutSoak(serviceCtx, serviceLog, bundle, smoke, tools.Nlabel.DryRun))
 
 		// Initialize labeling service with resolved configuration
 		labelingService := labeler.NewService(kubectlExecutor, labeler.Options{
 			DryRun:        tools.Nlabel.DryRun,
 			Verbose:       verbose, // CLI verbose always applies
 			ValidateNodes: tools.Nlabel.ValidateNodes,
-			Logger:        logger,
+			BulkLabel:     tools.Nlabel.BulkLabel,
+			Workers:       workers,
+			Progress:      progressReporter(),
+			Logger:        serviceLog,
 		})
 
 		// Execute labeling operation
 		var results *labeler.OperationResults
-		if deleteOp {
-			results, err = labelingService.RemoveLabels(ctx, bundle.NodeLabels)
-		} else {
-			results, err = labelingService.ApplyLabels(ctx, bundle.NodeLabels)
+		switch operation {
+		case operationDelete:
+			results, err = labelingService.RemoveLabels(serviceCtx, bundle.NodeLabels)
+		case operationVerify:
+			results, err = labelingService.VerifyLabels(serviceCtx, bundle.NodeLabels)
+		default:
+			results, err = labelingService.ApplyLabels(serviceCtx, bundle.NodeLabels)
+		}
+		if serviceTimedOut(ctx, serviceCtx) {
+			err = serviceTimeoutError(bundle.NodeLabels.Kind, tools.Nlabel)
+		}
+		if report != nil {
+			addLabelVerifyCases(report.Suite(bundle.NodeLabels.Kind), results, err)
 		}
 
 		if err != nil {
 			totalErrors = append(totalErrors, fmt.Errorf("node labeling failed: %w", err))
+			failures.Add(bundle.NodeLabels.Kind, err)
 		} else {
 			// Verify labels if not in dry run mode and operation was apply
-			if !tools.Nlabel.DryRun && applyOp {
-				_, verifyErr := labelingService.VerifyLabels(ctx, bundle.NodeLabels)
+			if !tools.Nlabel.DryRun && operation == operationApply {
+				verifyResults, verifyErr := labelingService.VerifyLabels(serviceCtx, bundle.NodeLabels)
+				runNodes.labelVerifyResults(bundle.NodeLabels.Kind, verifyResults)
 				if verifyErr != nil {
-					logger.Warn(fmt.Sprintf("Label verification failed: %v", verifyErr))
+					serviceLog.Warn(fmt.Sprintf("Label verification failed: %v", verifyErr))
 				}
 			}
 
 			// Handle any operation errors
 			if len(results.Errors) > 0 {
-				logger.Error("Some labeling operations failed:")
+				serviceLog.Error("Some labeling operations failed:")
 				for _, opErr := range results.Errors {
-					logger.Error(fmt.Sprintf("  - %v", opErr))
+					serviceLog.Error(fmt.Sprintf("  - %v", opErr))
 				}
 				totalErrors = append(totalErrors, fmt.Errorf("node labeling completed with %d errors", len(results.Errors)))
+				failures.Add(bundle.NodeLabels.Kind, results.Errors...)
+			}
+
+			// History and applied state record the nodes patterns and selectors resolved to
+			bundle.NodeLabels = bundle.NodeLabels.WithResolvedNodes(results.ResolvedNodes)
+			if !tools.Nlabel.DryRun {
+				applied.add(bundle.NodeLabels, results.FailedNodes)
 			}
 		}
+		cancel()
+		recorder.labelResults(bundle.NodeLabels.Kind, results)
+		runNodes.labelResults(bundle.NodeLabels.Kind, results, verifyOp)
+		recorder.service(bundle.NodeLabels.Kind, started, len(totalErrors)-errorsBefore)
+
+		if tools.Nlabel.AbortsOnFailure() && len(totalErrors) > errorsBefore {
+			abortedBy = bundle.NodeLabels.Kind
+		}
 	}
 
 	// Process VLANs if present
-	if bundle.HasVLANs() {
-		logger.Info("🌐 Processing VLAN configuration...")
-
-		// Initialize kubectl executor (reuse from labeling or create new one)
-		kubectlExecutor := kubectl.NewExecutor(logger)
-		// Speed up polling for tests
-		if os.Getenv("KICTL_TEST_MODE") == "true" {
-			kubectlExecutor.SetPollingInterval(0)
-		}
+	if bundle.HasVLANs() && !skipAfterAbort(logger, abortedBy, bundle.VLANs.Kind) {
+		serviceLog := serviceLogger(logger, "nvlan", operation)
+		serviceLog.Info("🌐 Processing VLAN configuration...")
+		errorsBefore := len(totalErrors)
+		started := time.Now()
 
 		// Get final tool configuration from the resolved config
 		tools := bundle.VLANs.GetTools()
+		serviceCtx, cancel := serviceContext(ctx, tools.Nvlan)
+
+		// Initialize kubectl executor (reuse from labeling or create new one)
+		workers, kubectlExecutor := newNodeWorkers(serviceLog, newBundleExecutor(serviceLog, bundle.GetDefaults()),
+			rolloutSoak(serviceCtx, serviceLog, bundle, smoke, tools.Nvlan.DryRun))
 
 		// Initialize VLAN service with resolved configuration
 		vlanService := vlan.NewService(kubectlExecutor, vlan.Options{
 			DryRun:               tools.Nvlan.DryRun,
 			Verbose:              verbose, // CLI verbose always applies
-			ValidateConnectivity: true,    // Default to true for safety
-			PersistentConfig:     false,   // Default to false for safety
-			DefaultInterface:     "eth0",  // Default interface
-			Logger:               logger,
+			ValidateConnectivity: tools.Nvlan.ValidateConnectivity,
+			PersistentConfig:     tools.Nvlan.PersistentConfig,
+			PersistenceBackend:   tools.Nvlan.PersistenceBackend,
+			NetplanTry:           tools.Nvlan.NetplanTry,
+			Templates:            templates,
+			ArtifactDir:          run.Path(workspace.PersistentConfigDir),
+			RollbackOnFailure:    rollbackOnFailure,
+			RollbackAbortedBatch: soakRollback,
+			ReachabilityGuard:    tools.Nvlan.ReachabilityGuard,
+			Force:                force,
+			Recovery:             newBMCController(tools.Nvlan.BMC),
+			MembershipLabels:     tools.Nvlan.MembershipLabels,
+			DefaultInterface:     bundle.GetDefaults().Spec.Interface,
+			InterfaceDetection:   bundle.GetDefaults().Spec.InterfaceDetection,
+			InterfaceAliases:     bundle.GetDefaults().Spec.InterfaceAliases,
+			Workers:              workers,
+			Progress:             progressReporter(),
+			Logger:               serviceLog,
 		})
 
 		// Execute VLAN operation
 		var results *vlan.OperationResults
-		if deleteOp {
-			results, err = vlanService.RemoveVLANs(ctx, bundle.VLANs)
-		} else {
-			results, err = vlanService.ConfigureVLANs(ctx, bundle.VLANs)
+		switch operation {
+		case operationDelete:
+			results, err = vlanService.RemoveVLANs(serviceCtx, bundle.VLANs)
+		case operationVerify:
+			results, err = vlanService.VerifyVLANs(serviceCtx, bundle.VLANs)
+		default:
+			results, err = vlanService.ConfigureVLANs(serviceCtx, bundle.VLANs)
+		}
+		if serviceTimedOut(ctx, serviceCtx) {
+			// The service's own cleanup ran on the expired context, so repeat it on the parent
+			err = serviceTimeoutError(bundle.VLANs.Kind, tools.Nvlan)
+			vlanService.Cleanup(ctx)
+		}
+		cancel()
+		if report != nil {
+			addVLANVerifyCases(report.Suite(bundle.VLANs.Kind), results, err)
 		}
 
 		if err != nil {
 			totalErrors = append(totalErrors, fmt.Errorf("VLAN configuration failed: %w", err))
+			failures.Add(bundle.VLANs.Kind, err)
 		} else {
 			// Handle any operation errors
 			if len(results.Errors) > 0 {
-				logger.Error("Some VLAN operations failed:")
+				serviceLog.Error("Some VLAN operations failed:")
 				for _, opErr := range results.Errors {
-					logger.Error(fmt.Sprintf("  - %v", opErr))
+					serviceLog.Error(fmt.Sprintf("  - %v", opErr))
 				}
 				totalErrors = append(totalErrors, fmt.Errorf("VLAN configuration completed with %d errors", len(results.Errors)))
+				failures.Add(bundle.VLANs.Kind, results.Errors...)
+			}
+			if !tools.Nvlan.DryRun {
+				applied.add(bundle.VLANs, results.FailedNodes)
+			}
+		}
+		recorder.vlanResults(bundle.VLANs.Kind, results)
+		runNodes.vlanResults(bundle.VLANs.Kind, results, verifyOp)
+		recorder.service(bundle.VLANs.Kind, started, len(totalErrors)-errorsBefore)
+
+		if tools.Nvlan.AbortsOnFailure() && len(totalErrors) > errorsBefore {
+			abortedBy = bundle.VLANs.Kind
+		}
+	}
+
+	// Process kernel tuning if present, before the tests that may depend on forwarding
+	if bundle.HasSysctls() && !skipAfterAbort(logger, abortedBy, bundle.Sysctls.Kind) {
+		serviceLog := serviceLogger(logger, "nsysctl", operation)
+		serviceLog.Info("⚙️  Processing kernel tuning configuration...")
+		errorsBefore := len(totalErrors)
+		started := time.Now()
+
+		// Get final tool configuration from the resolved config
+		tools := bundle.Sysctls.GetTools()
+		serviceCtx, cancel := serviceContext(ctx, tools.Nsysctl)
+
+		workers, kubectlExecutor := newNodeWorkers(serviceLog, newBundleExecutor(serviceLog, bundle.GetDefaults()),
+			rolloutSoak(serviceCtx, serviceLog, bundle, smoke, tools.Nsysctl.DryRun))
+
+		sysctlService := sysctl.NewService(kubectlExecutor, sysctl.Options{
+			DryRun:   tools.Nsysctl.DryRun,
+			Verbose:  verbose, // CLI verbose always applies
+			Workers:  workers,
+			Progress: progressReporter(),
+			Logger:   serviceLog,
+		})
+
+		var results *sysctl.OperationResults
+		switch operation {
+		case operationDelete:
+			results, err = sysctlService.RemoveSysctls(serviceCtx, bundle.Sysctls)
+		case operationVerify:
+			results, err = sysctlService.VerifySysctls(serviceCtx, bundle.Sysctls)
+		default:
+			results, err = sysctlService.ApplySysctls(serviceCtx, bundle.Sysctls)
+		}
+		if serviceTimedOut(ctx, serviceCtx) {
+			// The service's own cleanup ran on the expired context, so repeat it on the parent
+			err = serviceTimeoutError(bundle.Sysctls.Kind, tools.Nsysctl)
+			sysctlService.Cleanup(ctx)
+		}
+		cancel()
+		if report != nil {
+			addSysctlVerifyCases(report.Suite(bundle.Sysctls.Kind), results, err)
+		}
+
+		if err != nil {
+			totalErrors = append(totalErrors, fmt.Errorf("kernel tuning failed: %w", err))
+			failures.Add(bundle.Sysctls.Kind, err)
+		} else if len(results.Errors) > 0 {
+			serviceLog.Error("Some kernel tuning operations failed:")
+			for _, opErr := range results.Errors {
+				serviceLog.Error(fmt.Sprintf("  - %v", opErr))
+			}
+			totalErrors = append(totalErrors, fmt.Errorf("kernel tuning completed with %d errors", len(results.Errors)))
+			failures.Add(bundle.Sysctls.Kind, results.Errors...)
+		}
+		if err == nil && !tools.Nsysctl.DryRun {
+			applied.add(bundle.Sysctls, results.FailedNodes)
+		}
+		runNodes.sysctlResults(bundle.Sysctls.Kind, results, verifyOp)
+		recorder.service(bundle.Sysctls.Kind, started, len(totalErrors)-errorsBefore)
+
+		if tools.Nsysctl.AbortsOnFailure() && len(totalErrors) > errorsBefore {
+			abortedBy = bundle.Sysctls.Kind
+		}
+	}
+
+	// Process storage devices if present, labeling nodes for Rook once their devices are ready
+	if bundle.HasStorage() && !skipAfterAbort(logger, abortedBy, bundle.Storage.Kind) {
+		serviceLog := serviceLogger(logger, "nstorage", operation)
+		serviceLog.Info("💽 Processing storage device configuration...")
+		errorsBefore := len(totalErrors)
+		started := time.Now()
+
+		// Get final tool configuration from the resolved config
+		tools := bundle.Storage.GetTools()
+		serviceCtx, cancel := serviceContext(ctx, tools.Nstorage)
+
+		workers, kubectlExecutor := newNodeWorkers(serviceLog, newBundleExecutor(serviceLog, bundle.GetDefaults()),
+			rolloutSoak(serviceCtx, serviceLog, bundle, smoke, tools.Nstorage.DryRun))
+
+		storageService := storage.NewService(kubectlExecutor, storage.Options{
+			DryRun:   tools.Nstorage.DryRun,
+			Verbose:  verbose, // CLI verbose always applies
+			AllowZap: allowZap,
+			Workers:  workers,
+			Progress: progressReporter(),
+			Logger:   serviceLog,
+		})
+
+		var results *storage.OperationResults
+		switch operation {
+		case operationDelete:
+			results, err = storageService.RemoveStorage(serviceCtx, bundle.Storage)
+		case operationVerify:
+			results, err = storageService.VerifyStorage(serviceCtx, bundle.Storage)
+		default:
+			results, err = storageService.PrepareStorage(serviceCtx, bundle.Storage)
+		}
+		if serviceTimedOut(ctx, serviceCtx) {
+			// The service's own cleanup ran on the expired context, so repeat it on the parent
+			err = serviceTimeoutError(bundle.Storage.Kind, tools.Nstorage)
+			storageService.Cleanup(ctx)
+		}
+		cancel()
+		if report != nil {
+			addStorageVerifyCases(report.Suite(bundle.Storage.Kind), results, err)
+		}
+
+		if err != nil {
+			totalErrors = append(totalErrors, fmt.Errorf("storage preparation failed: %w", err))
+			failures.Add(bundle.Storage.Kind, err)
+		} else if len(results.Errors) > 0 {
+			serviceLog.Error("Some storage operations failed:")
+			for _, opErr := range results.Errors {
+				serviceLog.Error(fmt.Sprintf("  - %v", opErr))
 			}
+			totalErrors = append(totalErrors, fmt.Errorf("storage preparation completed with %d errors", len(results.Errors)))
+			failures.Add(bundle.Storage.Kind, results.Errors...)
+		}
+		if err == nil && !tools.Nstorage.DryRun {
+			applied.add(bundle.Storage, results.FailedNodes)
+		}
+		runNodes.storageResults(bundle.Storage.Kind, results, verifyOp)
+		recorder.service(bundle.Storage.Kind, started, len(totalErrors)-errorsBefore)
+
+		if tools.Nstorage.AbortsOnFailure() && len(totalErrors) > errorsBefore {
+			abortedBy = bundle.Storage.Kind
 		}
 	}
 
 	// Process Tests if present
-	if bundle.HasTests() {
-		logger.Info("🧪 Processing network connectivity tests...")
+	if bundle.HasTests() && !skipAfterAbort(logger, abortedBy, bundle.Tests.Kind) {
+		serviceLog := serviceLogger(logger, "ntest", operation)
+		serviceLog.Info("🧪 Processing network connectivity tests...")
+		errorsBefore := len(totalErrors)
+		started := time.Now()
 
 		// Initialize kubectl executor
-		kubectlExecutor := kubectl.NewExecutor(logger)
-		// Speed up polling for tests
-		if os.Getenv("KICTL_TEST_MODE") == "true" {
-			kubectlExecutor.SetPollingInterval(0)
-		}
+		kubectlExecutor := newBundleExecutor(serviceLog, bundle.GetDefaults())
 
 		// Get final tool configuration from the resolved config
 		tools := bundle.Tests.GetTools()
+		serviceCtx, cancel := serviceContext(ctx, tools.Ntest)
 
 		// Initialize network health check service with resolved configuration
 		// Pass VLAN config if available for network-to-IP mapping
 		var testService nethealthcheck.Service
-		if bundle.HasVLANs() {
+		if networks != nil {
 			testService = nethealthcheck.NewServiceWithVLAN(kubectlExecutor, nethealthcheck.Options{
 				DryRun:            tools.Ntest.DryRun,
 				Verbose:           verbose, // CLI verbose always applies
@@ -290,8 +989,8 @@ func runCommand(cmd *cobra.Command, args []string) error {
 				CleanupAfterTests: true,    // Clean up test pods
 				OpenstackProfiles: []string{"control-plane", "compute", "storage"},
 				ExcludeNodes:      tools.Ntest.ExcludeNodes, // Use config exclusion list
-				Logger:            logger,
-			}, bundle.VLANs)
+				Logger:            serviceLog,
+			}, networks)
 		} else {
 			testService = nethealthcheck.NewService(kubectlExecutor, nethealthcheck.Options{
 				DryRun:            tools.Ntest.DryRun,
@@ -303,45 +1002,473 @@ func runCommand(cmd *cobra.Command, args []string) error {
 				CleanupAfterTests: true,    // Clean up test pods
 				OpenstackProfiles: []string{"control-plane", "compute", "storage"},
 				ExcludeNodes:      tools.Ntest.ExcludeNodes, // Use config exclusion list
-				Logger:            logger,
+				Logger:            serviceLog,
 			})
 		}
 
 		// Execute test operation (tests don't support delete, only run/verify)
 		var results *nethealthcheck.TestResults
-		if deleteOp {
+		switch operation {
+		case operationDelete:
 			// For delete operation, we might want to stop any running tests
-			results, err = testService.StopTests(ctx, bundle.Tests)
-		} else {
+			results, err = testService.StopTests(serviceCtx, bundle.Tests)
+		case operationVerify:
+			results, err = testService.VerifyTests(serviceCtx, bundle.Tests)
+		default:
 			// For apply operation, run the tests
-			results, err = testService.RunTests(ctx, bundle.Tests)
+			results, err = testService.RunTests(serviceCtx, bundle.Tests)
+		}
+		if serviceTimedOut(ctx, serviceCtx) {
+			// The service's own cleanup ran on the expired context, so repeat it on the parent
+			err = serviceTimeoutError(bundle.Tests.Kind, tools.Ntest)
+			testService.Cleanup(ctx)
+		}
+		cancel()
+		if report != nil {
+			addTestCases(report.Suite(bundle.Tests.Kind), results, err)
+		}
+		if path := testReports[reportFormatJUnit]; path != "" && operation == operationApply {
+			if reportErr := writeTestReport(path, bundle.Tests.Kind, results, err); reportErr != nil {
+				totalErrors = append(totalErrors, reportErr)
+			} else {
+				serviceLog.Info(fmt.Sprintf("📄 JUnit test report written to %s", path))
+			}
 		}
 
 		if err != nil {
 			totalErrors = append(totalErrors, fmt.Errorf("network testing failed: %w", err))
+			failures.Add(bundle.Tests.Kind, err)
 		} else {
 			// Handle any test errors
 			if len(results.Errors) > 0 {
-				logger.Error("Some network tests failed:")
+				serviceLog.Error("Some network tests failed:")
 				for _, testErr := range results.Errors {
-					logger.Error(fmt.Sprintf("  - %v", testErr))
+					serviceLog.Error(fmt.Sprintf("  - %v", testErr))
 				}
 				totalErrors = append(totalErrors, fmt.Errorf("network testing completed with %d errors", len(results.Errors)))
+				failures.Add(bundle.Tests.Kind, results.Errors...)
 			} else {
-				logger.Info(fmt.Sprintf("✅ All %d network tests completed successfully", results.SuccessfulTests))
+				serviceLog.Info(fmt.Sprintf("✅ All %d network tests completed successfully", results.SuccessfulTests))
+			}
+		}
+
+		// Compare the connectivity matrix with the recorded baseline, or record it as the new one
+		if err == nil && operation == operationApply && !tools.Ntest.DryRun {
+			store, storeErr := openHistoryStore()
+			var broken []history.Pair
+			if storeErr == nil {
+				broken, storeErr = compareConnectivityBaseline(serviceLog, store, results, recordBaseline)
+			}
+			if storeErr != nil {
+				serviceLog.Warn(fmt.Sprintf("⚠️  Failed to compare with the connectivity baseline: %v", storeErr))
 			}
+			if len(broken) > 0 {
+				regressions := make([]error, 0, len(broken))
+				for _, pair := range broken {
+					regressions = append(regressions, fmt.Errorf("connectivity regression: %s was reachable in the baseline", pair))
+				}
+				totalErrors = append(totalErrors, fmt.Errorf("network testing found %d connectivity regressions", len(broken)))
+				failures.Add(bundle.Tests.Kind, regressions...)
+			}
+		}
+		recorder.testResults(bundle.Tests.Kind, results)
+		runNodes.testResults(results)
+		recorder.service(bundle.Tests.Kind, started, len(totalErrors)-errorsBefore)
+
+		if tools.Ntest.AbortsOnFailure() && len(totalErrors) > errorsBefore {
+			abortedBy = bundle.Tests.Kind
+		}
+	}
+
+	// Nodes failing the same operation run after run are taken out of later runs, see spec.quarantine
+	quarantineFailingNodes(ctx, logger, bundle, newBundleExecutor(logger, bundle.GetDefaults()), operation, recorder)
+
+	// Record node snapshots so `kictl timeline node` can show what this run changed
+	if !verifyOp && !isBundleDryRun(bundle) {
+		store, err := openHistoryStore()
+		if err == nil {
+			err = recordRunHistory(ctx, logger, store, newBundleExecutor(logger, bundle.GetDefaults()), bundle, applied.bundle, operation)
+		}
+		if err != nil && protected.production() {
+			totalErrors = append(totalErrors, fmt.Errorf("failed to record run history of a production context: %w", err))
+		} else if err != nil {
+			logger.Warn(fmt.Sprintf("⚠️  Failed to record run history: %v", err))
+		}
+
+		owned.record(ctx, logger, applied.bundle, operation)
+
+		if err := runAudit.Err(); err != nil && protected.production() {
+			totalErrors = append(totalErrors, fmt.Errorf("failed to audit changes to a production context: %w", err))
+		}
+
+		// Record what was applied so `kictl drift` can detect manual changes later
+		stateStore, err := openStateStore()
+		if err == nil {
+			err = recordAppliedState(ctx, logger, stateStore, applied.bundle, operation)
+		}
+		if err != nil && protected.production() {
+			totalErrors = append(totalErrors, fmt.Errorf("failed to record applied state of a production context: %w", err))
+		} else if err != nil {
+			logger.Warn(fmt.Sprintf("⚠️  Failed to record applied state: %v", err))
+		}
+	}
+
+	// Pin the nodes of a complete run to the bundle revision so `kictl revisions` can report skew
+	if !verifyOp && len(totalErrors) == 0 && pinsRevision(bundle) {
+		pinRevision(ctx, logger, bundle, newBundleExecutor(logger, bundle.GetDefaults()), operation)
+	}
+
+	// Keep the CMDB in sync with what a successful apply changed
+	if operation == operationApply && len(totalErrors) == 0 && !isBundleDryRun(bundle) {
+		client, err := newCMDBClient()
+		if err != nil {
+			logger.Warn(fmt.Sprintf("⚠️  Failed to sync the CMDB: %v", err))
+		} else if client != nil {
+			syncCMDB(ctx, logger, client, bundle)
+		}
+	}
+
+	if report != nil && junitOutput != "" {
+		if err := report.WriteFile(junitOutput); err != nil {
+			totalErrors = append(totalErrors, err)
+		} else {
+			logger.Info(fmt.Sprintf("📄 JUnit report written to %s", junitOutput))
+		}
+	}
+
+	// Publish the result per node for controllers that keep workloads off unverified nodes
+	if report != nil && publishCondition {
+		if err := publishVerifyConditions(ctx, logger, report, bundle); err != nil {
+			totalErrors = append(totalErrors, err)
+		}
+	}
+
+	// What happened on each node, before the verification report and the failure summary
+	runNodes.print(cmd.OutOrStdout())
+
+	// Nodes deviating from the bundle exit with their own code, so verify can gate on cluster health
+	if report != nil {
+		printVerifyReport(cmd.OutOrStdout(), report)
+		if err := verifyDeviations(report); err != nil {
+			logger.Error(fmt.Sprintf("❌ Verification found %d deviations from the bundle", report.Failures()))
+			printFailureSummary(cmd, failures)
+			return err
 		}
 	}
 
 	// Summary
 	if len(totalErrors) > 0 {
 		logger.Error(fmt.Sprintf("❌ Operation completed with %d errors", len(totalErrors)))
-		for _, err := range totalErrors {
-			logger.Error(fmt.Sprintf("  - %v", err))
-		}
-		return fmt.Errorf("operation completed with %d errors", len(totalErrors))
+		printFailureSummary(cmd, failures)
+		return runFailedError(fmt.Errorf("operation completed with %d errors", len(totalErrors)), failures)
 	}
 
 	logger.Info("✅ All operations completed successfully")
 	return nil
 }
+
+// checkOffline fails the run if anything configured would reach beyond the cluster API server
+func checkOffline() error {
+	if err := kubectl.CheckOfflinePath("config", configFile); err != nil {
+		return err
+	}
+	for _, path := range valuesFiles {
+		if err := kubectl.CheckOfflinePath("values", path); err != nil {
+			return err
+		}
+	}
+	if err := kubectl.CheckOfflinePath("workspace", workspaceDir); err != nil {
+		return err
+	}
+	if err := kubectl.CheckOfflinePath("artifacts", artifactsURL); err != nil {
+		return err
+	}
+	if err := kubectl.CheckOfflinePath("cmdb-url", cmdbURL); err != nil {
+		return err
+	}
+	if err := kubectl.CheckOfflinePath("history-dir", historyDir); err != nil {
+		return err
+	}
+	if err := kubectl.CheckOfflinePath("kubeconfig", kubeconfigPath); err != nil {
+		return err
+	}
+	if err := kubectl.CheckOfflineSSH(sshNodes); err != nil {
+		return err
+	}
+	_, err := kubectl.CheckOfflineKubeconfig(clusterTarget())
+	return err
+}
+
+// clusterTarget returns the kubeconfig, context and namespace selected by the flags
+func clusterTarget() kubectl.ClusterTarget {
+	return kubectl.ClusterTarget{Kubeconfig: kubeconfigPath, Context: kubeContext, Namespace: kubeNamespace}
+}
+
+// newKubectlExecutor creates the executor selected by --client, honoring the node command backend flags
+func newKubectlExecutor(logger logging.Logger) kubectl.DryRunExecutor {
+	return newBundleExecutor(logger, config.BuiltinDefaults())
+}
+
+// kubectlRunner returns the kubectl of the executor, or the replacement of a test when it sets one
+func kubectlRunner(executor kubectl.Executor, replacement kubectl.Runner) kubectl.Runner {
+	if replacement != nil {
+		return replacement
+	}
+	return executor.RunKubectl
+}
+
+// newBundleExecutor creates the executor selected by --client, running node commands with the bundle's debug image
+func newBundleExecutor(logger logging.Logger, defaults *config.Defaults) kubectl.DryRunExecutor {
+	options := kubectl.ExecutorOptions{
+		NodeExecBackend:  nodeExecBackend,
+		HostPodNamespace: hostPodNamespace,
+		HostPodSelector:  hostPodSelector,
+		HostEntry:        hostEntry,
+		AgentNamespace:   agentNamespace,
+		Restricted:       restricted,
+		AllowedCommands:  allowedCommands,
+		StrictDryRun:     dryRunStrict,
+		DebugImage:       defaults.Spec.DebugImage,
+		LegacyDebug:      legacyDebug,
+		Target:           clusterTarget(),
+	}
+
+	var kubectlExecutor kubectl.DryRunExecutor
+	if kubeClient == kubectl.ClientNative {
+		kubectlExecutor = kubectl.NewNativeExecutor(logger, options)
+	} else {
+		kubectlExecutor = kubectl.NewExecutorWithOptions(logger, options)
+	}
+	// Speed up polling for tests
+	if os.Getenv("KICTL_TEST_MODE") == "true" {
+		kubectlExecutor.SetPollingInterval(0)
+	}
+	return newAuditedExecutor(logger, newSSHExecutor(logger, kubectlExecutor, options, defaults.Spec.SSH))
+}
+
+// newSSHExecutor wraps the executor to run node commands over SSH on the nodes of --ssh-nodes or spec.ssh
+func newSSHExecutor(logger logging.Logger, executor kubectl.DryRunExecutor, options kubectl.ExecutorOptions, transport *config.SSHTransport) kubectl.DryRunExecutor {
+	if transport == nil {
+		transport = &config.SSHTransport{}
+	}
+	nodes := transport.Nodes
+	if len(sshNodes) > 0 {
+		nodes = sshNodes
+	}
+	if len(nodes) == 0 {
+		return executor
+	}
+	if offline {
+		logger.Warn("⚠️  Offline mode: ignoring spec.ssh, node commands run through the cluster")
+		return executor
+	}
+
+	sshOptions := kubectl.SSHOptions{
+		Nodes:                 nodes,
+		User:                  transport.User,
+		Port:                  transport.Port,
+		IdentityFiles:         transport.IdentityFiles,
+		KnownHostsFile:        transport.KnownHostsFile,
+		InsecureIgnoreHostKey: transport.InsecureIgnoreHostKey,
+		Hosts:                 transport.Hosts,
+	}
+	if transport.Bastion != "" {
+		// Validated with the bundle, so the bastion always parses here
+		user, host, port, _ := config.ParseSSHBastion(transport.Bastion)
+		if port == 0 {
+			port = 22
+		}
+		sshOptions.Bastion = net.JoinHostPort(host, strconv.Itoa(port))
+		sshOptions.BastionUser = user
+	}
+	return kubectl.NewSSHExecutor(logger, executor, options, sshOptions)
+}
+
+// serviceLogger tags the entries of a service with its component and the operation in JSON logs
+// Text logs already name the service and operation in their messages, so their lines stay as they are.
+func serviceLogger(logger logging.Logger, component, operation string) logging.Logger {
+	if logFormat != logging.FormatJSON {
+		return logger
+	}
+	return logger.With(logging.FieldComponent, component, logging.FieldOperation, operation)
+}
+
+// newNodeWorkers returns the adaptive worker pool for --max-parallelism and the executor feeding it API latency
+// A single worker needs no feedback, so the executor is returned unchanged, with a nil pool unless
+// --rollout-order asks for another order than alphabetical, --batch-size for batches or soak is set.
+// With --zone-aware the pool runs one node per failure domain at a time.
+func newNodeWorkers(logger logging.Logger, executor kubectl.DryRunExecutor, soak func(batch []string) error) (*throttle.Controller, kubectl.DryRunExecutor) {
+	ordered := rolloutOrder != "" && rolloutOrder != throttle.OrderAlphabetical
+	if maxParallelism <= 1 && !ordered && batchSize == 0 && soak == nil {
+		return nil, executor
+	}
+	options := throttle.Options{MaxWorkers: maxParallelism, Logger: logger, Order: rolloutOrder, Seed: rolloutSeed, BatchSize: batchSize, Soak: soak}
+	if rolloutOrder == throttle.OrderRandom && options.Seed == 0 {
+		options.Seed = time.Now().UnixNano()
+		logger.Info(fmt.Sprintf("🎲 Rollout seed %d, repeat this order with --rollout-seed %d", options.Seed, options.Seed))
+	}
+	if zoneAware || rolloutOrder == throttle.OrderZone {
+		zones := nodeZones(logger, executor, zoneKey)
+		options.Zone = zones
+		if zoneAware {
+			logger.Info(fmt.Sprintf("🗺️  Changing at most one node per %s at a time", zoneKey))
+			options.Domain = zones
+		}
+	}
+	workers := throttle.New(options)
+	if maxParallelism <= 1 {
+		return workers, executor
+	}
+	return workers, kubectl.NewObservedExecutor(executor, workers.Observe)
+}
+
+// runFlagCleanup builds a CleanupConf from the --unlabel-prefix, --nodes and --selector flags and runs it
+func runFlagCleanup(ctx context.Context, cmd *cobra.Command, prefixes []string) error {
+	nodes, err := selectedNodes()
+	if err != nil {
+		return err
+	}
+	selector, _ := cmd.Flags().GetString("selector")
+
+	cleanup := &config.CleanupConf{
+		APIVersion: "openstack.kictl.icycloud.io/v1",
+		Kind:       "CleanupConf",
+		Metadata:   config.Metadata{Name: "cli-cleanup", Namespace: "default"},
+		Spec: config.CleanupSpec{
+			LabelPrefixes: prefixes,
+			Nodes:         nodes,
+			NodeSelector:  selector,
+		},
+	}
+	if err := config.ValidateCleanupSpec(cleanup.Spec); err != nil {
+		return fmt.Errorf("invalid cleanup flags: %w (use --nodes or --selector to choose nodes)", err)
+	}
+
+	logger, run, err := newRunLogger(cmd, "cleanup")
+	if err != nil {
+		return fmt.Errorf("failed to initialize logger: %w", err)
+	}
+	defer closeRun(cmd, logger, run)
+
+	cleanupDryRun := dryRunCovers("CleanupConf")
+	if cleanupDryRun {
+		fmt.Fprintf(consoleOptions().Chatter(cmd.OutOrStdout()), "🧪 DRY RUN MODE: No changes will be made\n")
+	} else {
+		protected, err := enforceProtection(ctx, cmd, logger, newPrecedenceResolver(cmd), "clean up labels")
+		if err != nil {
+			return err
+		}
+		defer protected.release(ctx, logger)
+
+		stopAudit, err := startAudit(logger, config.NewSingleConfigBundle(cleanup), "cleanup")
+		if err != nil {
+			return fmt.Errorf("failed to open the audit log: %w", err)
+		}
+		defer stopAudit()
+	}
+
+	failures := summary.New()
+	if err := runLabelCleanup(ctx, logger, cleanup, cleanupDryRun, failures); err != nil {
+		printFailureSummary(cmd, failures)
+		return err
+	}
+	return nil
+}
+
+// runLabelCleanup removes labels matching the cleanup prefixes and reports failures as a single error
+// Individual failures are recorded in the failure summary
+func runLabelCleanup(ctx context.Context, logger logging.Logger, cleanup *config.CleanupConf, dryRun bool, failures *summary.Summary) error {
+	kubectlExecutor := newKubectlExecutor(logger)
+
+	labelingService := labeler.NewService(kubectlExecutor, labeler.Options{
+		DryRun:  dryRun,
+		Verbose: verbose,
+		Logger:  logger,
+	})
+
+	results, err := labelingService.CleanupLabels(ctx, cleanup)
+	if err != nil {
+		failures.Add(cleanup.Kind, err)
+		return fmt.Errorf("label cleanup failed: %w", err)
+	}
+
+	if len(results.Errors) > 0 || len(results.FailedNodes) > 0 {
+		logger.Error("Some label cleanup operations failed:")
+		for _, opErr := range results.Errors {
+			logger.Error(fmt.Sprintf("  - %v", opErr))
+		}
+		failures.Add(cleanup.Kind, results.Errors...)
+		return fmt.Errorf("label cleanup completed with %d failed nodes", len(results.FailedNodes))
+	}
+
+	return nil
+}
+
+// fixNodeNameTypos compares bundle node names with the cluster node list and interactively
+// rewrites misspelled names in the config file. Returns true if the file was changed.
+func fixNodeNameTypos(ctx context.Context, cmd *cobra.Command, logger logging.Logger, bundle *config.ConfigBundle) (bool, error) {
+	kubectlExecutor := newKubectlExecutor(logger)
+
+	success, output, err := kubectlExecutor.GetAllNodes(ctx)
+	if err != nil || !success {
+		return false, fmt.Errorf("failed to list cluster nodes: %v", err)
+	}
+
+	clusterNodes := make(map[string]bool)
+	nodeList := kubectl.ParseNodeNames(output)
+	for _, node := range nodeList {
+		clusterNodes[node] = true
+	}
+
+	out := cmd.OutOrStdout()
+	reader := bufio.NewReader(cmd.InOrStdin())
+	fixed := false
+
+	for _, node := range bundle.GetAllNodeNames() {
+		if clusterNodes[node] {
+			continue
+		}
+
+		suggestions := kubectl.SuggestNodeNames(node, nodeList)
+		if len(suggestions) == 0 {
+			fmt.Fprintf(out, "⚠️  Node %s not found in cluster and no similar node names exist\n", node)
+			continue
+		}
+
+		// Dry runs must not rewrite the config file, so only report the suggestions
+		if dryRun {
+			fmt.Fprintf(out, "🧪 DRY RUN: Node %s not found, did you mean %s?\n", node, strings.Join(suggestions, " or "))
+			continue
+		}
+		// Remote bundles cannot be rewritten, and the names of rendered templates may come from the values
+		if config.IsRemoteSource(configFile) || len(valuesFiles) > 0 {
+			fmt.Fprintf(out, "⚠️  Node %s not found, did you mean %s? Correct it at %s\n", node, strings.Join(suggestions, " or "), configFile)
+			continue
+		}
+
+		for _, suggestion := range suggestions {
+			fmt.Fprintf(out, "❓ Node %s not found. Replace with %s in %s? [y/N]: ", node, suggestion, configFile)
+			answer, _ := reader.ReadString('\n')
+			answer = strings.ToLower(strings.TrimSpace(answer))
+			if answer != "y" && answer != "yes" {
+				continue
+			}
+
+			count, err := config.ReplaceNodeName(configFile, node, suggestion)
+			if err != nil {
+				return fixed, err
+			}
+			logger.Info(fmt.Sprintf("✏️  Replaced %d occurrence(s) of %s with %s in %s", count, node, suggestion, configFile))
+			fixed = fixed || count > 0
+			break
+		}
+	}
+
+	return fixed, nil
+}
+
+// printFailureSummary prints the failures of a run grouped by error class and configuration kind
+func printFailureSummary(cmd *cobra.Command, failures *summary.Summary) {
+	out := cmd.ErrOrStderr()
+	summary.Render(out, failures, summary.RenderOptions{Color: colorEnabled(out)})
+}
@@ -224,7 +224,7 @@ func TestRunCommand(t *testing.T) {
 				"config": "nonexistent-config.yaml",
 			},
 			expectError: true,
-			errorText:   "failed to initialize logger",
+			errorText:   "operation required",
 		},
 		{
 			name:        "valid_config_apply_dry_run",
@@ -530,15 +530,15 @@ func TestFlagValidation(t *testing.T) {
 			name:        "valid_apply_with_config",
 			description: "Apply with config should validate successfully",
 			args:        []string{"--apply", "--config", "valid.yaml"},
-			expectError: true, // Will fail due to logger initialization, not config loading
-			errorText:   "failed to initialize logger",
+			expectError: true, // Will fail loading the missing config file
+			errorText:   "failed to load configuration",
 		},
 		{
 			name:        "dry_run_with_verbose",
 			description: "Dry-run with verbose should be valid combination",
 			args:        []string{"--config", "test.yaml", "--dry-run", "--verbose"},
-			expectError: true, // Will fail due to logger initialization
-			errorText:   "failed to initialize logger",
+			expectError: true, // Will fail because no operation is given
+			errorText:   "operation required",
 		},
 	}
 
@@ -715,7 +715,7 @@ func TestErrorHandling(t *testing.T) {
 			{
 				name:      "nonexistent_config_error",
 				args:      []string{"--config", "does-not-exist.yaml"},
-				errorText: "failed to initialize logger",
+				errorText: "operation required",
 			},
 		}
 
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/logging"
+	"k8ostack-ictl/internal/nethealthcheck"
+
+	"github.com/spf13/cobra"
+)
+
+// testSuiteFunc runs the connectivity tests of a bundle once
+type testSuiteFunc func(ctx context.Context) (*nethealthcheck.TestResults, error)
+
+// testWatchOptions controls how often the tests run and what a regression does
+type testWatchOptions struct {
+	Watch     bool
+	Interval  time.Duration
+	KeepGoing bool // Only alert on regressions instead of exiting
+}
+
+// createTestCommand creates the command running the NodeTestConf tests once or on a schedule
+func createTestCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "test",
+		Short: "Run the NodeTestConf connectivity tests, once or continuously with --watch",
+		Long: `Run the connectivity tests of the NodeTestConf in --config without applying
+anything else of the bundle. Exits with an error when a test fails.
+
+With --watch the tests run again every --interval as a lightweight network
+canary, logging a summary per round. A test that passed in the previous round
+and now fails is a regression: kictl logs an alert and exits with an error,
+or with --keep-going keeps watching and alerts again on the next regression.
+Tests failing from the first round on are reported but are not regressions.
+
+Examples:
+  # Run the tests once
+  kictl test --config cluster-config.yaml
+
+  # Re-run them every 5 minutes until a passing test starts failing
+  kictl test --config cluster-config.yaml --watch --interval 5m
+
+  # Keep watching through regressions, e.g. as a long-running canary
+  kictl test --config cluster-config.yaml --watch --keep-going`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var options testWatchOptions
+			options.Watch, _ = cmd.Flags().GetBool("watch")
+			options.Interval, _ = cmd.Flags().GetDuration("interval")
+			options.KeepGoing, _ = cmd.Flags().GetBool("keep-going")
+			if options.Interval <= 0 {
+				return fmt.Errorf("--interval must be positive")
+			}
+			if options.KeepGoing && !options.Watch {
+				return fmt.Errorf("--keep-going only applies with --watch")
+			}
+			if configFile == "" {
+				return fmt.Errorf("configuration file is required. Use --config to specify a YAML file with a NodeTestConf")
+			}
+
+			logger, run, err := newRunLogger(cmd, "test")
+			if err != nil {
+				return fmt.Errorf("failed to initialize logger: %w", err)
+			}
+			defer closeRun(cmd, logger, run)
+
+			bundle, err := loadBundle()
+			if err != nil {
+				return fmt.Errorf("failed to load configuration: %w", err)
+			}
+			resolver := newPrecedenceResolver(cmd)
+			if err := resolver.ApplyGlobalOverrides(bundle); err != nil {
+				return fmt.Errorf("failed to apply CLI precedence: %w", err)
+			}
+			warnUnsafeOverrides(logger, resolver)
+			if !bundle.HasTests() {
+				return fmt.Errorf("%s has no NodeTestConf to run", configFile)
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			serviceLog := serviceLogger(logger, "ntest", "test")
+			return runTestWatch(ctx, serviceLog, newTestSuite(serviceLog, bundle), bundle.Tests, options)
+		},
+	}
+
+	cmd.Flags().Bool("watch", false, "Re-run the tests every --interval until a passing test starts failing")
+	cmd.Flags().Duration("interval", 5*time.Minute, "Time between two test rounds with --watch")
+	cmd.Flags().Bool("keep-going", false, "With --watch, alert on regressions and keep watching instead of exiting")
+	return cmd
+}
+
+// newTestSuite returns the tests of the bundle run the way apply runs them, within the serviceTimeout of NodeTestConf
+func newTestSuite(logger logging.Logger, bundle *config.ConfigBundle) testSuiteFunc {
+	tools := bundle.Tests.GetTools()
+	testService := nethealthcheck.NewServiceWithVLAN(newBundleExecutor(logger, bundle.GetDefaults()), nethealthcheck.Options{
+		DryRun:            tools.Ntest.DryRun,
+		Verbose:           verbose,
+		Parallel:          tools.Ntest.Parallel,
+		Retries:           tools.Ntest.Retries,
+		OutputFormat:      tools.Ntest.OutputFormat,
+		TimeoutDefault:    30,
+		CleanupAfterTests: true,
+		OpenstackProfiles: []string{"control-plane", "compute", "storage"},
+		ExcludeNodes:      tools.Ntest.ExcludeNodes,
+		Logger:            logger,
+	}, bundle.VLANs)
+
+	return func(ctx context.Context) (*nethealthcheck.TestResults, error) {
+		serviceCtx, cancel := serviceContext(ctx, tools.Ntest)
+		defer cancel()
+		results, err := testService.RunTests(serviceCtx, bundle.Tests)
+		if serviceTimedOut(ctx, serviceCtx) {
+			err = serviceTimeoutError(bundle.Tests.Kind, tools.Ntest)
+			testService.Cleanup(ctx)
+		}
+		return results, err
+	}
+}
+
+// runTestWatch runs the tests once, or with --watch every interval until a test regresses or ctx is cancelled
+func runTestWatch(ctx context.Context, logger logging.Logger, suite testSuiteFunc, tests *config.NodeTestConf, options testWatchOptions) error {
+	watcher := newTestWatcher()
+	for round := 1; ; round++ {
+		results, err := suite(ctx)
+		if options.Watch && ctx.Err() != nil {
+			logger.Info("👋 Test watch stopped")
+			return nil
+		}
+		if err != nil {
+			if !options.Watch {
+				return fmt.Errorf("network testing failed: %w", err)
+			}
+			// An incomplete round says nothing about the tests, so their last state is kept
+			logger.Error(fmt.Sprintf("❌ Test round %d failed: %v", round, err))
+		} else {
+			statuses := testStatuses(tests, results)
+			regressed, recovered := watcher.observe(statuses)
+			failing := failingTests(statuses)
+
+			for _, name := range recovered {
+				logger.Info(fmt.Sprintf("💚 Test %s passes again", name))
+			}
+			for _, name := range regressed {
+				logger.Error(fmt.Sprintf("🚨 Test %s passed in the previous round and now fails: %s", name, statuses[name]))
+			}
+			logger.Info(fmt.Sprintf("📈 Round %d: %d of %d tests passing", round, len(statuses)-len(failing), len(statuses)))
+
+			if !options.Watch {
+				if len(failing) > 0 {
+					return fmt.Errorf("%d of %d network tests failed: %s", len(failing), len(statuses), strings.Join(failing, ", "))
+				}
+				return nil
+			}
+			if len(regressed) > 0 && !options.KeepGoing {
+				return fmt.Errorf("%d network tests started failing: %s", len(regressed), strings.Join(regressed, ", "))
+			}
+		}
+
+		logger.Info(fmt.Sprintf("⏰ Next test round in %s", options.Interval))
+		select {
+		case <-ctx.Done():
+			logger.Info("👋 Test watch stopped")
+			return nil
+		case <-time.After(options.Interval):
+		}
+	}
+}
+
+// testStatuses maps every test of the suite to why it failed in a round, or to "" when it passed
+// A test the service could not execute at all has no execution and counts as failing.
+func testStatuses(tests *config.NodeTestConf, results *nethealthcheck.TestResults) map[string]string {
+	executions := make(map[string]nethealthcheck.TestExecution, len(results.TestExecutions))
+	for _, execution := range results.TestExecutions {
+		executions[execution.TestName] = execution
+	}
+
+	statuses := make(map[string]string, len(tests.Spec.Tests))
+	for _, test := range tests.Spec.Tests {
+		execution, ok := executions[test.Name]
+		switch {
+		case !ok:
+			statuses[test.Name] = "the test could not be executed"
+		case execution.ActualSuccess != execution.ExpectSuccess:
+			statuses[test.Name] = execution.ErrorMessage
+			if statuses[test.Name] == "" {
+				statuses[test.Name] = fmt.Sprintf("expected success %v, got %v", execution.ExpectSuccess, execution.ActualSuccess)
+			}
+		default:
+			statuses[test.Name] = ""
+		}
+	}
+	return statuses
+}
+
+// failingTests returns the sorted names of the failing tests of a round
+func failingTests(statuses map[string]string) []string {
+	var failing []string
+	for name, status := range statuses {
+		if status != "" {
+			failing = append(failing, name)
+		}
+	}
+	sort.Strings(failing)
+	return failing
+}
+
+// testWatcher remembers which tests passed in the last complete round
+type testWatcher struct {
+	passed map[string]bool
+}
+
+// newTestWatcher creates a watcher that has seen no round yet
+func newTestWatcher() *testWatcher {
+	return &testWatcher{passed: map[string]bool{}}
+}
+
+// observe records a round and returns the sorted tests that passed in the previous round and now fail, and the reverse
+func (w *testWatcher) observe(statuses map[string]string) (regressed, recovered []string) {
+	for name, status := range statuses {
+		passed, seen := w.passed[name]
+		switch {
+		case status != "" && passed:
+			regressed = append(regressed, name)
+		case status == "" && seen && !passed:
+			recovered = append(recovered, name)
+		}
+		w.passed[name] = status == ""
+	}
+	sort.Strings(regressed)
+	sort.Strings(recovered)
+	return regressed, recovered
+}
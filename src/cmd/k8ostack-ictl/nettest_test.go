@@ -0,0 +1,191 @@
+// Package main provides unit tests for the test command
+// WHY: A canary that misses a regression, or alerts on tests that never passed, is worse than none
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/logging"
+	"k8ostack-ictl/internal/nethealthcheck"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// watchedTests is a suite of two tests, one expecting success and one expecting isolation
+func watchedTests() *config.NodeTestConf {
+	return &config.NodeTestConf{Spec: config.NodeTestSpec{Tests: []config.ConnectivityTest{
+		{Name: "storage-reachable", ExpectSuccess: true},
+		{Name: "storage-isolated"},
+	}}}
+}
+
+// watchRound builds the results of a round in which the named tests fail
+func watchRound(failing ...string) *nethealthcheck.TestResults {
+	results := &nethealthcheck.TestResults{}
+	for _, test := range watchedTests().Spec.Tests {
+		execution := nethealthcheck.TestExecution{TestName: test.Name, ExpectSuccess: test.ExpectSuccess, ActualSuccess: test.ExpectSuccess}
+		for _, name := range failing {
+			if name == test.Name {
+				execution.ActualSuccess = !test.ExpectSuccess
+				execution.ErrorMessage = "100% packet loss"
+			}
+		}
+		results.TestExecutions = append(results.TestExecutions, execution)
+	}
+	return results
+}
+
+// TestTestWatcher_Unit tests detecting regressions and recoveries between rounds
+// WHY: Only a test that passed before and now fails is a regression; failing from the start is not
+func TestTestWatcher_Unit(t *testing.T) {
+	tests := []struct {
+		name              string
+		rounds            [][]string // failing tests per round
+		expectedRegressed []string
+		expectedRecovered []string
+	}{
+		{
+			name:   "first_round_never_regresses",
+			rounds: [][]string{{"storage-reachable"}},
+		},
+		{
+			name:              "passing_test_starts_failing",
+			rounds:            [][]string{{}, {"storage-isolated"}},
+			expectedRegressed: []string{"storage-isolated"},
+		},
+		{
+			name:   "still_failing_is_not_a_new_regression",
+			rounds: [][]string{{}, {"storage-isolated"}, {"storage-isolated"}},
+		},
+		{
+			name:              "failing_test_recovers",
+			rounds:            [][]string{{"storage-reachable"}, {}},
+			expectedRecovered: []string{"storage-reachable"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A watcher that saw every round but the last
+			watcher := newTestWatcher()
+			for _, failing := range tt.rounds[:len(tt.rounds)-1] {
+				watcher.observe(testStatuses(watchedTests(), watchRound(failing...)))
+			}
+
+			// When: Observe the last round
+			regressed, recovered := watcher.observe(testStatuses(watchedTests(), watchRound(tt.rounds[len(tt.rounds)-1]...)))
+
+			// Then: Only the changes since the previous round are reported
+			assert.Equal(t, tt.expectedRegressed, regressed)
+			assert.Equal(t, tt.expectedRecovered, recovered)
+		})
+	}
+}
+
+// TestTestStatuses_Unit tests the outcome of every test of a round
+// WHY: A test the service could not execute leaves no execution, and must not count as passing
+func TestTestStatuses_Unit(t *testing.T) {
+	// Given: A round in which only the isolation test ran, and passed
+	results := &nethealthcheck.TestResults{TestExecutions: []nethealthcheck.TestExecution{{TestName: "storage-isolated"}}}
+
+	// When: Derive the status of every test
+	statuses := testStatuses(watchedTests(), results)
+
+	// Then: The test without an execution fails
+	assert.Equal(t, map[string]string{
+		"storage-reachable": "the test could not be executed",
+		"storage-isolated":  "",
+	}, statuses)
+	assert.Equal(t, []string{"storage-reachable"}, failingTests(statuses))
+}
+
+// TestRunTestWatch_Unit tests running the tests once and on a schedule
+// WHY: The exit status is what alerts the canary's operator, so it must follow regressions and nothing else
+func TestRunTestWatch_Unit(t *testing.T) {
+	tests := []struct {
+		name           string
+		options        testWatchOptions
+		rounds         []*nethealthcheck.TestResults // nil for a round that fails as a whole
+		expectedRounds int
+		expectedError  string
+		expectAlert    bool
+	}{
+		{
+			name:           "single_run_passes",
+			rounds:         []*nethealthcheck.TestResults{watchRound()},
+			expectedRounds: 1,
+		},
+		{
+			name:           "single_run_fails_on_any_failing_test",
+			rounds:         []*nethealthcheck.TestResults{watchRound("storage-reachable")},
+			expectedRounds: 1,
+			expectedError:  "1 of 2 network tests failed: storage-reachable",
+		},
+		{
+			name:           "watch_exits_on_regression",
+			options:        testWatchOptions{Watch: true},
+			rounds:         []*nethealthcheck.TestResults{watchRound("storage-reachable"), watchRound(), watchRound("storage-isolated")},
+			expectedRounds: 3,
+			expectedError:  "1 network tests started failing: storage-isolated",
+			expectAlert:    true,
+		},
+		{
+			name:           "watch_keeps_state_through_incomplete_rounds",
+			options:        testWatchOptions{Watch: true},
+			rounds:         []*nethealthcheck.TestResults{watchRound(), nil, watchRound("storage-isolated")},
+			expectedRounds: 3,
+			expectedError:  "1 network tests started failing: storage-isolated",
+			expectAlert:    true,
+		},
+		{
+			name:           "keep_going_watches_until_stopped",
+			options:        testWatchOptions{Watch: true, KeepGoing: true},
+			rounds:         []*nethealthcheck.TestResults{watchRound(), watchRound("storage-isolated"), watchRound()},
+			expectedRounds: 3,
+			expectAlert:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A suite returning the rounds in turn, and stopped when asked for one more
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			rounds := 0
+			suite := func(ctx context.Context) (*nethealthcheck.TestResults, error) {
+				if rounds == len(tt.rounds) {
+					cancel()
+					return nil, ctx.Err()
+				}
+				results := tt.rounds[rounds]
+				rounds++
+				if results == nil {
+					return nil, errors.New("NodeTestConf did not finish within serviceTimeout 1m")
+				}
+				return results, nil
+			}
+			tt.options.Interval = time.Millisecond
+			logger := logging.NewRecordingLogger()
+
+			// When: Run the tests
+			err := runTestWatch(ctx, logger, suite, watchedTests(), tt.options)
+
+			// Then: The run stops after the expected round with the expected outcome
+			assert.Equal(t, tt.expectedRounds, rounds)
+			if tt.expectedError != "" {
+				require.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+			if tt.expectAlert {
+				assert.Contains(t, logger.Messages(logging.LevelError), "🚨 Test storage-isolated passed in the previous round and now fails: 100% packet loss")
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/logging"
+)
+
+// Help texts of the node filter flags on every command accepting them
+const (
+	nodesFlagUsage     = "Only act on these nodes, e.g. freshly added hosts (comma separated)"
+	nodesFileFlagUsage = "Only act on the nodes listed in this file, one per line ('#' starts a comment); adds to --nodes"
+)
+
+// selectedNodes returns the nodes of --nodes and --nodes-file, without duplicates, or nil when neither is set
+func selectedNodes() ([]string, error) {
+	var nodes []string
+	seen := make(map[string]bool)
+	add := func(node string) {
+		if node = strings.TrimSpace(node); node != "" && !seen[node] {
+			seen[node] = true
+			nodes = append(nodes, node)
+		}
+	}
+	for _, node := range nodeFilter {
+		add(node)
+	}
+
+	if nodesFile != "" {
+		file, err := os.Open(nodesFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read nodes file: %w", err)
+		}
+		defer file.Close()
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			line, _, _ := strings.Cut(scanner.Text(), "#")
+			add(line)
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read nodes file: %w", err)
+		}
+		if len(nodes) == 0 {
+			return nil, fmt.Errorf("nodes file %s lists no node", nodesFile)
+		}
+	}
+	return nodes, nil
+}
+
+// restrictToNodes restricts the bundle to the nodes of --nodes and --nodes-file, returning it unchanged without them
+func restrictToNodes(logger logging.Logger, bundle *config.ConfigBundle) (*config.ConfigBundle, error) {
+	nodes, err := selectedNodes()
+	if err != nil || len(nodes) == 0 {
+		return bundle, err
+	}
+	restricted, err := bundle.RestrictToNodes(nodes)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info(fmt.Sprintf("🎯 Limiting the run to %d nodes: %s", len(nodes), strings.Join(nodes, ", ")))
+	return restricted, nil
+}
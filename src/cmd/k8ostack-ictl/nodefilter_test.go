@@ -0,0 +1,78 @@
+// Package main provides unit tests for the node filter flags
+// WHY: Re-running a bundle against a few new hosts must never reach the others, and a typo must not run an empty bundle
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSelectedNodes tests merging --nodes and --nodes-file
+// WHY: Hosts given both ways must be acted on once, and an empty file must not silently mean every node
+func TestSelectedNodes(t *testing.T) {
+	tests := []struct {
+		name        string
+		nodes       []string
+		file        string
+		expected    []string
+		expectError string
+	}{
+		{name: "no_filter"},
+		{name: "flag_only", nodes: []string{"rsb17", "rsb18"}, expected: []string{"rsb17", "rsb18"}},
+		{
+			name:     "file_with_comments_adds_to_flag",
+			nodes:    []string{"rsb17"},
+			file:     "# new rack\nrsb18\n\nrsb17  # already given\n  rsb19\n",
+			expected: []string{"rsb17", "rsb18", "rsb19"},
+		},
+		{name: "empty_file", file: "# nothing yet\n", expectError: "lists no node"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: The node filter flags
+			originalNodes, originalFile := nodeFilter, nodesFile
+			defer func() { nodeFilter, nodesFile = originalNodes, originalFile }()
+			nodeFilter, nodesFile = tt.nodes, ""
+			if tt.file != "" {
+				nodesFile = filepath.Join(t.TempDir(), "nodes.txt")
+				require.NoError(t, os.WriteFile(nodesFile, []byte(tt.file), 0644))
+			}
+
+			// When: Resolve the selected nodes
+			nodes, err := selectedNodes()
+
+			// Then: Each node is listed once, in order
+			if tt.expectError != "" {
+				assert.ErrorContains(t, err, tt.expectError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, nodes)
+		})
+	}
+}
+
+// TestApplyNodes tests that apply rejects node filters leaving nothing to do before touching the cluster
+// WHY: A mistyped host name must fail the run instead of reporting success
+func TestApplyNodes(t *testing.T) {
+	// Given: A bundle file for rsb3 and a workspace
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "cluster-config.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte(exportTestConfig), 0644))
+	rootCmd := createRootCommand()
+	rootCmd.SetOut(new(bytes.Buffer))
+	rootCmd.SetErr(new(bytes.Buffer))
+	rootCmd.SetArgs([]string{"apply", "--config", configPath, "--dry-run", "--workspace", dir, "--nodes", "rbs3"})
+
+	// When: Apply to a mistyped node
+	err := rootCmd.Execute()
+
+	// Then: The run is refused
+	assert.ErrorContains(t, err, "no configuration of the bundle applies to nodes rbs3")
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"k8ostack-ictl/internal/kubectl"
+	"k8ostack-ictl/internal/labeler"
+	"k8ostack-ictl/internal/logging"
+	"k8ostack-ictl/internal/nethealthcheck"
+	"k8ostack-ictl/internal/progress"
+	"k8ostack-ictl/internal/storage"
+	"k8ostack-ictl/internal/summary"
+	"k8ostack-ictl/internal/sysctl"
+	"k8ostack-ictl/internal/vlan"
+
+	"golang.org/x/term"
+)
+
+// runNodes collects what the running bundle operation did per node, printed as the node table; nil outside one
+var runNodes *nodeTable
+
+// nodeTable turns the results of every service into one result per node, see summary.Nodes
+type nodeTable struct {
+	nodes *summary.Nodes
+}
+
+// newNodeTable creates an empty node table
+func newNodeTable() *nodeTable {
+	return &nodeTable{nodes: summary.NewNodes()}
+}
+
+// reporter returns the progress reporter timing the services per node
+func (t *nodeTable) reporter() progress.Reporter {
+	if t == nil {
+		return nil
+	}
+	return t.nodes
+}
+
+// labelResults adds the labels of a labeling service per node; verified marks the operation as a verification
+func (t *nodeTable) labelResults(kind string, results *labeler.OperationResults, verified bool) {
+	if t == nil || results == nil {
+		return
+	}
+	for node, labels := range results.AppliedLabels {
+		t.nodes.AddLabels(node, len(labels))
+	}
+	t.outcome(kind, labelNodes(results), results.FailedNodes, results.Errors, verified)
+}
+
+// labelVerifyResults adds the verification following a label apply, without counting the labels again
+func (t *nodeTable) labelVerifyResults(kind string, results *labeler.OperationResults) {
+	if t == nil || results == nil {
+		return
+	}
+	t.outcome(kind, labelNodes(results), results.FailedNodes, results.Errors, true)
+}
+
+// vlanResults adds the VLAN interfaces of a VLAN service per node
+func (t *nodeTable) vlanResults(kind string, results *vlan.OperationResults, verified bool) {
+	if t == nil || results == nil {
+		return
+	}
+	var nodes []string
+	for node, interfaces := range results.ConfiguredVLANs {
+		t.nodes.AddVLANs(node, len(interfaces))
+		nodes = append(nodes, node)
+	}
+	t.outcome(kind, nodes, results.FailedNodes, results.Errors, verified)
+}
+
+// sysctlResults adds the nodes of a kernel tuning service
+func (t *nodeTable) sysctlResults(kind string, results *sysctl.OperationResults, verified bool) {
+	if t == nil || results == nil {
+		return
+	}
+	t.outcome(kind, results.MatchingNodes, results.FailedNodes, results.Errors, verified)
+}
+
+// storageResults adds the nodes of a storage service
+func (t *nodeTable) storageResults(kind string, results *storage.OperationResults, verified bool) {
+	if t == nil || results == nil {
+		return
+	}
+	t.outcome(kind, results.PreparedNodes, results.FailedNodes, results.Errors, verified)
+}
+
+// testResults adds the connectivity tests as a verification of their source nodes, with their durations
+func (t *nodeTable) testResults(results *nethealthcheck.TestResults) {
+	if t == nil || results == nil {
+		return
+	}
+	for _, execution := range results.TestExecutions {
+		if execution.SourceNode == "" {
+			continue
+		}
+		passed := execution.ActualSuccess == execution.ExpectSuccess
+		t.nodes.AddDuration(execution.SourceNode, execution.Duration)
+		t.nodes.Verified(execution.SourceNode, passed)
+		if !passed {
+			t.nodes.Failed(execution.SourceNode, fmt.Sprintf("%s to %s failed", execution.TestName, execution.TargetNetwork))
+		}
+	}
+}
+
+// outcome lists the nodes a service handled and records its errors on the nodes they belong to
+// A verification also marks each node passed or failed. Failed nodes without an attributed error get a generic one.
+func (t *nodeTable) outcome(kind string, nodes, failedNodes []string, errs []error, verified bool) {
+	for _, node := range nodes {
+		t.nodes.Add(node)
+	}
+	failed := make(map[string]bool, len(failedNodes))
+	for _, node := range failedNodes {
+		failed[node] = false
+	}
+	for _, err := range errs {
+		if node := kubectl.NodeOf(err); node != "" {
+			failed[node] = true
+			t.nodes.Failed(node, err.Error())
+		}
+	}
+	for node, attributed := range failed {
+		if !attributed {
+			t.nodes.Failed(node, fmt.Sprintf("%s failed on the node", kind))
+		}
+	}
+	if !verified {
+		return
+	}
+	for _, node := range nodes {
+		if _, isFailed := failed[node]; !isFailed {
+			t.nodes.Verified(node, true)
+		}
+	}
+	for node := range failed {
+		t.nodes.Verified(node, false)
+	}
+}
+
+// print renders the node table on out, fitted to the terminal's width
+// JSON logs and --no-summary-table leave it out, as does a run that handled no node.
+func (t *nodeTable) print(out io.Writer) {
+	if t == nil || noSummaryTable || logFormat == logging.FormatJSON {
+		return
+	}
+	summary.RenderNodes(out, t.nodes.Results(), summary.TableOptions{Width: terminalWidth(out), Color: colorEnabled(out)})
+}
+
+// labelNodes returns the nodes a labeling service targeted or labeled
+func labelNodes(results *labeler.OperationResults) []string {
+	var nodes []string
+	for _, roleNodes := range results.ResolvedNodes {
+		nodes = append(nodes, roleNodes...)
+	}
+	for node := range results.AppliedLabels {
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+// terminalWidth returns the width of the terminal out writes to, or 0 when it is not a terminal
+func terminalWidth(out io.Writer) int {
+	file, ok := out.(*os.File)
+	if !ok || !progress.IsTerminal(out) {
+		return 0
+	}
+	width, _, err := term.GetSize(int(file.Fd()))
+	if err != nil {
+		return 0
+	}
+	return width
+}
@@ -0,0 +1,135 @@
+// Package main provides unit tests for the node table
+// WHY: Each service reports its nodes differently, and a node missing from the table hides what happened on it
+package main
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"k8ostack-ictl/internal/kubectl"
+	"k8ostack-ictl/internal/labeler"
+	"k8ostack-ictl/internal/logging"
+	"k8ostack-ictl/internal/nethealthcheck"
+	"k8ostack-ictl/internal/summary"
+	"k8ostack-ictl/internal/vlan"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNodeTable_Results tests merging the results of the labeling, VLAN and test services per node
+// WHY: Errors must land on the node they belong to, and only verifications may mark a node passed or failed
+func TestNodeTable_Results(t *testing.T) {
+	tests := []struct {
+		name     string
+		verified bool
+		runTests bool
+		expected []summary.NodeResult
+	}{
+		{
+			name: "apply_marks_no_verify_status",
+			expected: []summary.NodeResult{
+				{Node: "rsb2", Labels: 2, VLANs: 1},
+				{Node: "rsb3", Errors: []string{
+					"host command on node rsb3 failed (transport): connection refused",
+					"NodeVLANConf failed on the node",
+				}},
+			},
+		},
+		{
+			name:     "verify_marks_failed_nodes",
+			verified: true,
+			expected: []summary.NodeResult{
+				{Node: "rsb2", Labels: 2, VLANs: 1, Verify: summary.VerifyPassed},
+				{Node: "rsb3", Verify: summary.VerifyFailed, Errors: []string{
+					"host command on node rsb3 failed (transport): connection refused",
+					"NodeVLANConf failed on the node",
+				}},
+			},
+		},
+		{
+			name:     "tests_verify_their_source_nodes",
+			runTests: true,
+			expected: []summary.NodeResult{
+				{Node: "rsb2", Labels: 2, VLANs: 1, Verify: summary.VerifyPassed, Duration: time.Second},
+				{Node: "rsb3", Verify: summary.VerifyFailed, Duration: 2 * time.Second, Errors: []string{
+					"host command on node rsb3 failed (transport): connection refused",
+					"NodeVLANConf failed on the node",
+					"storage-reachable to storage failed",
+				}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: Services that handled rsb2 and failed on rsb3
+			labels := &labeler.OperationResults{
+				ResolvedNodes: map[string][]string{"control": {"rsb2", "rsb3"}},
+				AppliedLabels: map[string][]string{"rsb2": {"openstack-control-plane=enabled", "openstack-role=control"}},
+				Errors: []error{&kubectl.HostCommandError{
+					Node: "rsb3", Class: kubectl.HostErrorTransport, Cause: errors.New("connection refused"),
+				}},
+			}
+			vlans := &vlan.OperationResults{
+				FailedNodes:     []string{"rsb3"},
+				ConfiguredVLANs: map[string][]vlan.VLANInterfaceInfo{"rsb2": {{VLANName: "storage", VLANId: 300}}},
+			}
+			tests := &nethealthcheck.TestResults{TestExecutions: []nethealthcheck.TestExecution{
+				{TestName: "storage-reachable", SourceNode: "rsb2", TargetNetwork: "storage", ExpectSuccess: true, ActualSuccess: true, Duration: time.Second},
+				{TestName: "storage-reachable", SourceNode: "rsb3", TargetNetwork: "storage", ExpectSuccess: true, Duration: 2 * time.Second},
+			}}
+			table := newNodeTable()
+
+			// When: Add their results to the table
+			table.labelResults("NodeLabelConf", labels, tt.verified)
+			table.vlanResults("NodeVLANConf", vlans, tt.verified)
+			if tt.runTests {
+				table.testResults(tests)
+			}
+
+			// Then: Each node has one row with the errors of every service
+			assert.Equal(t, tt.expected, table.nodes.Results())
+		})
+	}
+}
+
+// TestNodeTable_Print tests the flags that leave the table out
+// WHY: JSON log consumers read one entry per line, and scripts may not want the table at all
+func TestNodeTable_Print(t *testing.T) {
+	tests := []struct {
+		name           string
+		logFormat      string
+		noSummaryTable bool
+		expectTable    bool
+	}{
+		{name: "text_logs", logFormat: logging.FormatText, expectTable: true},
+		{name: "json_logs", logFormat: logging.FormatJSON},
+		{name: "no_summary_table", logFormat: logging.FormatText, noSummaryTable: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A table with one node and the flags of the case
+			previousFormat, previousNoTable := logFormat, noSummaryTable
+			t.Cleanup(func() { logFormat, noSummaryTable = previousFormat, previousNoTable })
+			logFormat, noSummaryTable = tt.logFormat, tt.noSummaryTable
+			table := newNodeTable()
+			table.nodes.AddLabels("rsb2", 1)
+			var out bytes.Buffer
+
+			// When: Print it
+			table.print(&out)
+
+			// Then: The table is printed unless turned off
+			if tt.expectTable {
+				require.Contains(t, out.String(), "NODE")
+				assert.Contains(t, out.String(), "rsb2")
+			} else {
+				assert.Empty(t, out.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/config/precedence"
+	"k8ostack-ictl/internal/kubectl"
+	"k8ostack-ictl/internal/operator"
+
+	"github.com/spf13/cobra"
+)
+
+// createOperatorCommand creates the command that runs kictl as an in-cluster controller
+func createOperatorCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "operator",
+		Short: "Reconcile NodeLabelConf, NodeVLANConf and NodeTestConf custom resources continuously",
+		Long: `Register NodeLabelConf, NodeVLANConf and NodeTestConf as Kubernetes custom
+resources and reconcile them until stopped, using the same services as apply.
+
+Resources are validated like configuration files and reapplied every resync period
+to correct drift. Deleting a NodeLabelConf or NodeVLANConf removes its labels or
+VLANs from the nodes first. The outcome is reported in each resource's status.
+
+A resource failing --failure-budget times in a row is backed off: it is left
+alone for one resync period, doubled with every further failure up to
+--max-backoff. A node failing that often within a resource is left out of it
+the same way while the other nodes are reconciled. Both are logged as alerts
+and listed in the status; editing the resource resets its budget.
+
+Runs with the kubeconfig, or with the pod's service account inside the cluster.
+Unless --client is given, node commands use the native client so no kubectl binary
+is needed in the image.
+
+Examples:
+  # Run against the current kubeconfig context
+  kictl operator
+
+  # Watch one namespace and reapply every minute
+  kictl operator --namespace infra --resync-period 1m
+
+  # Then manage nodes declaratively
+  kubectl apply -f cluster-config.yaml`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			namespace, _ := cmd.Flags().GetString("namespace")
+			resyncPeriod, _ := cmd.Flags().GetDuration("resync-period")
+			registerCRDs, _ := cmd.Flags().GetBool("register-crds")
+			failureBudget, _ := cmd.Flags().GetInt("failure-budget")
+			maxBackoff, _ := cmd.Flags().GetDuration("max-backoff")
+			if resyncPeriod <= 0 {
+				return fmt.Errorf("--resync-period must be positive")
+			}
+			if failureBudget < 0 {
+				return fmt.Errorf("--failure-budget must not be negative")
+			}
+			if maxBackoff < resyncPeriod {
+				return fmt.Errorf("--max-backoff must be at least --resync-period")
+			}
+
+			logger, _, err := newRunLogger(cmd, "operator")
+			if err != nil {
+				return fmt.Errorf("failed to initialize logger: %w", err)
+			}
+			defer logger.Close()
+
+			client, err := operator.NewDynamicClient(clusterTarget())
+			if err != nil {
+				return err
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			if registerCRDs {
+				if err := operator.RegisterCRDs(ctx, client); err != nil {
+					return err
+				}
+				logger.Info(fmt.Sprintf("📜 Registered %d custom resource definitions", len(operator.Kinds)))
+			}
+
+			if !cmd.Flags().Changed("client") {
+				kubeClient = kubectl.ClientNative
+			}
+			resolver := precedence.NewGlobalResolver(cmd)
+			controller := operator.NewController(client, operator.NewServiceReconciler(newKubectlExecutor(logger), logger, verbose), operator.Options{
+				Namespace:    namespace,
+				ResyncPeriod: resyncPeriod,
+				Prepare: func(bundle *config.ConfigBundle) error {
+					if err := resolver.ApplyGlobalOverrides(bundle); err != nil {
+						return err
+					}
+					warnUnsafeOverrides(logger, resolver)
+					return bundle.ValidateNodeNamePolicy()
+				},
+				FailureBudget: failureBudget,
+				MaxBackoff:    maxBackoff,
+				Logger:        logger,
+			})
+
+			logger.Info("🤖 Operator started, press Ctrl+C to stop")
+			if err := controller.Run(ctx); err != nil {
+				return err
+			}
+			logger.Info("👋 Operator stopped")
+			return nil
+		},
+	}
+
+	cmd.Flags().String("namespace", "", "Only watch resources in this namespace (default all namespaces)")
+	cmd.Flags().Duration("resync-period", 5*time.Minute, "How often every resource is reapplied to correct drift")
+	cmd.Flags().Int("failure-budget", 5, "Consecutive failures of a resource or node before it is backed off; 0 retries forever")
+	cmd.Flags().Duration("max-backoff", time.Hour, "Longest a failing resource or node is left alone between attempts")
+	cmd.Flags().Bool("register-crds", true, "Create or update the custom resource definitions at startup (needs cluster-scoped CRD permissions)")
+	return cmd
+}
@@ -0,0 +1,83 @@
+// Package main provides unit tests for the operator command
+// WHY: The operator runs unattended, so bad flags must fail before it starts watching
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestOperatorCommand_Flags tests the operator command defaults
+// WHY: Registering CRDs and watching all namespaces is the zero-configuration setup
+func TestOperatorCommand_Flags(t *testing.T) {
+	cmd := createOperatorCommand()
+
+	tests := []struct {
+		flag     string
+		expected string
+	}{
+		{flag: "namespace", expected: ""},
+		{flag: "resync-period", expected: "5m0s"},
+		{flag: "register-crds", expected: "true"},
+		{flag: "failure-budget", expected: "5"},
+		{flag: "max-backoff", expected: "1h0m0s"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.flag, func(t *testing.T) {
+			flag := cmd.Flags().Lookup(tt.flag)
+			require.NotNil(t, flag)
+			assert.Equal(t, tt.expected, flag.DefValue)
+		})
+	}
+}
+
+// TestOperatorCommand_InvalidResync tests rejecting a non-positive resync period
+// WHY: A zero period would disable drift correction, which is the point of the operator
+func TestOperatorCommand_InvalidResync(t *testing.T) {
+	// Given: Operator with a zero resync period
+	rootCmd := createRootCommand()
+	rootCmd.SetOut(new(bytes.Buffer))
+	rootCmd.SetErr(new(bytes.Buffer))
+	rootCmd.SetArgs([]string{"operator", "--resync-period", "0s"})
+
+	// When: Execute
+	err := rootCmd.Execute()
+
+	// Then: Refused before contacting the cluster
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--resync-period must be positive")
+}
+
+// TestOperatorCommand_InvalidBudget tests rejecting error budget flags that cannot work
+// WHY: A backoff shorter than the resync period would never hold a failing resource back
+func TestOperatorCommand_InvalidBudget(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{name: "negative_budget", args: []string{"--failure-budget", "-1"}, expected: "--failure-budget must not be negative"},
+		{name: "backoff_below_resync", args: []string{"--max-backoff", "1m"}, expected: "--max-backoff must be at least --resync-period"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: Operator with the flags
+			rootCmd := createRootCommand()
+			rootCmd.SetOut(new(bytes.Buffer))
+			rootCmd.SetErr(new(bytes.Buffer))
+			rootCmd.SetArgs(append([]string{"operator"}, tt.args...))
+
+			// When: Execute
+			err := rootCmd.Execute()
+
+			// Then: Refused before contacting the cluster
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.expected)
+		})
+	}
+}
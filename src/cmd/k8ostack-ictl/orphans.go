@@ -0,0 +1,213 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/kubectl"
+	"k8ostack-ictl/internal/logging"
+	"k8ostack-ictl/internal/orphans"
+	"k8ostack-ictl/internal/ownership"
+
+	"github.com/spf13/cobra"
+)
+
+// orphanRunner replaces the kubectl of the executor for the pods, lock and ownership annotations of the orphan scan in tests
+var orphanRunner kubectl.Runner
+
+// createOrphansCommand creates the command finding and removing what kictl left behind on the cluster
+func createOrphansCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "orphans [bundle...]",
+		Short: "Find what kictl left on the cluster that no current bundle accounts for, and offer to remove it",
+		Long: `Scan the cluster and every node for artifacts kictl created that nothing uses any more:
+
+  - debug pods of interrupted runs, older than --min-pod-age
+  - a run lock whose holder stopped renewing it
+  - VLAN files (netplan, NetworkManager, networkd, ifcfg) with a kictl header naming
+    a VLAN that no bundle configures for the node
+  - ownership claims of the bundles' owners on label keys and VLANs no bundle sets
+
+The bundles given as arguments, or --config, are what the cluster is compared with;
+pass every bundle in use, since anything the others configure is reported. Claims of
+owners none of them names are left alone. Each orphan is removed only once confirmed,
+or all of them with --yes; --dry-run only lists them.
+
+Removing a VLAN file keeps the interface until the node reboots or the network is
+reloaded, after which it is gone.
+
+Examples:
+  # Compare the cluster with two bundles and pick what to remove
+  kictl orphans network.yaml storage.yaml
+
+  # List the orphans of the configured bundle without removing anything
+  kictl orphans --config cluster-config.yaml --dry-run`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			paths := args
+			if len(paths) == 0 {
+				if configFile == "" {
+					return fmt.Errorf("configuration file is required. Pass the bundles in use as arguments or use --config")
+				}
+				paths = []string{configFile}
+			}
+			minPodAge, _ := cmd.Flags().GetDuration("min-pod-age")
+			yes, _ := cmd.Flags().GetBool("yes")
+
+			logger, run, err := newRunLogger(cmd, "orphans")
+			if err != nil {
+				return fmt.Errorf("failed to initialize logger: %w", err)
+			}
+			defer closeRun(cmd, logger, run)
+
+			var bundles []*config.ConfigBundle
+			for _, path := range paths {
+				bundle, err := config.LoadMultipleConfigsWithOptions(path, loadOptions())
+				if err != nil {
+					return fmt.Errorf("failed to load configuration %s: %w", path, err)
+				}
+				bundles = append(bundles, bundle)
+			}
+
+			// Removals are audited; the executor must be created once the audit log is open
+			if !dryRun {
+				stopAudit, err := startAudit(logger, config.NewEmptyBundle(), "orphans")
+				if err != nil {
+					return fmt.Errorf("failed to open the audit log: %w", err)
+				}
+				defer stopAudit()
+			}
+			return runOrphans(context.Background(), cmd, logger, bundles, newKubectlExecutor(logger), minPodAge, yes)
+		},
+	}
+	cmd.Flags().Duration("min-pod-age", time.Hour, "Only report debug pods older than this, so pods of commands still running are left alone")
+	cmd.Flags().Bool("yes", false, "Remove every orphan without asking")
+	return cmd
+}
+
+// runOrphans scans the cluster for orphans of the bundles and removes those the user confirms
+func runOrphans(ctx context.Context, cmd *cobra.Command, logger logging.Logger, bundles []*config.ConfigBundle, executor kubectl.DryRunExecutor, minPodAge time.Duration, yes bool) error {
+	expected, err := expectedArtifacts(ctx, logger, bundles, executor)
+	if err != nil {
+		return err
+	}
+
+	workers, executor := newNodeWorkers(logger, executor, nil)
+	scanner := orphans.NewScanner(executor, orphans.Options{
+		MinPodAge: minPodAge,
+		Workers:   workers,
+		Run:       orphanRunner,
+	})
+	defer func() {
+		if _, err := executor.ReleaseDebugPods(context.Background()); err != nil {
+			logger.Warn(fmt.Sprintf("⚠️  Failed to remove the debug pods of the scan: %v", err))
+		}
+	}()
+
+	report, err := scanner.Scan(ctx, expected, time.Now())
+	if err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	if report.ActiveRun != "" {
+		fmt.Fprintf(out, "⚠️  %s holds the run lock; debug pods it started may still be in use\n", report.ActiveRun)
+	}
+	for _, scanErr := range report.Errors {
+		fmt.Fprintf(out, "⚠️  %v\n", scanErr)
+	}
+	if len(report.Findings) == 0 {
+		fmt.Fprintf(out, "✅ No orphans: everything kictl left on the cluster is accounted for by %d bundles\n", len(bundles))
+		return incompleteScan(report)
+	}
+
+	fmt.Fprintf(out, "🔎 Found %d orphans:\n", len(report.Findings))
+	for _, finding := range report.Findings {
+		fmt.Fprintf(out, "  - %s\n", finding)
+	}
+	if dryRun {
+		fmt.Fprintf(out, "🧪 DRY RUN: %d orphans would be offered for removal\n", len(report.Findings))
+		return incompleteScan(report)
+	}
+
+	// Production contexts confirm the removal and hold the cluster lock, which takes over an expired one
+	protected, err := enforceProtection(ctx, cmd, logger, newPrecedenceResolver(cmd), "remove orphans")
+	if err != nil {
+		return err
+	}
+	defer protected.release(ctx, logger)
+
+	reader := bufio.NewReader(cmd.InOrStdin())
+	removed, failed := 0, 0
+	for _, finding := range report.Findings {
+		if finding.Kind == orphans.KindLock && protected.production() {
+			fmt.Fprintf(out, "🔒 %s was taken over by this run\n", finding)
+			continue
+		}
+		if !yes {
+			fmt.Fprintf(out, "❓ Remove %s? [y/N]: ", finding)
+			answer, _ := reader.ReadString('\n')
+			answer = strings.ToLower(strings.TrimSpace(answer))
+			if answer != "y" && answer != "yes" {
+				continue
+			}
+		}
+		if err := scanner.Remove(ctx, finding); err != nil {
+			logger.Warn(fmt.Sprintf("⚠️  %v", err))
+			failed++
+			continue
+		}
+		logger.Info(fmt.Sprintf("🗑️  Removed %s %s", finding.Kind, finding.Name))
+		removed++
+	}
+
+	fmt.Fprintf(out, "🧹 Removed %d of %d orphans\n", removed, len(report.Findings))
+	if failed > 0 {
+		return fmt.Errorf("failed to remove %d orphans", failed)
+	}
+	return incompleteScan(report)
+}
+
+// incompleteScan fails a scan that could not read part of the cluster, since orphans there went unreported
+func incompleteScan(report *orphans.Report) error {
+	if len(report.Errors) > 0 {
+		return fmt.Errorf("orphan scan is incomplete: %d parts of the cluster could not be read", len(report.Errors))
+	}
+	return nil
+}
+
+// expectedArtifacts collects the VLANs each node gets and the claims of each owner across the bundles
+func expectedArtifacts(ctx context.Context, logger logging.Logger, bundles []*config.ConfigBundle, executor kubectl.DryRunExecutor) (orphans.Expected, error) {
+	expected := orphans.Expected{VLANs: map[string]map[string]bool{}, Claims: map[string]ownership.Claims{}}
+	for _, bundle := range bundles {
+		if bundle.HasVLANs() {
+			for vlanName, vlanConfig := range bundle.VLANs.Spec.VLANs {
+				for node := range vlanConfig.NodeMapping {
+					if expected.VLANs[node] == nil {
+						expected.VLANs[node] = map[string]bool{}
+					}
+					expected.VLANs[node][vlanName] = true
+				}
+			}
+		}
+
+		policy := bundle.GetDefaults().Spec.Ownership
+		if policy == nil {
+			continue
+		}
+		claims, err := bundleClaims(ctx, logger, bundle, executor)
+		if err != nil {
+			return expected, fmt.Errorf("failed to resolve the nodes of owner %s: %w", policy.Owner, err)
+		}
+		if expected.Claims[policy.Owner] == nil {
+			expected.Claims[policy.Owner] = ownership.Claims{}
+		}
+		for node, claim := range claims {
+			expected.Claims[policy.Owner].Add(node, claim)
+		}
+	}
+	return expected, nil
+}
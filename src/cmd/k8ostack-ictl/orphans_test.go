@@ -0,0 +1,128 @@
+// Package main provides unit tests for the orphans command
+// WHY: Orphans are only removed once confirmed, so a stray answer or a dry run must never delete anything
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/labeler"
+	"k8ostack-ictl/internal/logging"
+	"k8ostack-ictl/internal/settings"
+	"k8ostack-ictl/internal/workspace"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRunOrphans tests the guided cleanup of orphaned VLAN files
+// WHY: Each orphan is removed on its own answer, all of them with --yes and none in a dry run
+func TestRunOrphans(t *testing.T) {
+	tests := []struct {
+		name          string
+		answers       string
+		yes           bool
+		dryRun        bool
+		production    bool
+		expectRemoved []string
+		expectOutput  string
+	}{
+		{
+			name:          "confirm_first_only",
+			answers:       "y\nn\n",
+			expectRemoved: []string{"rm -f /etc/netplan/60-kictl-eth0.200.yaml"},
+			expectOutput:  "🧹 Removed 1 of 2 orphans",
+		},
+		{
+			name:          "yes",
+			yes:           true,
+			expectRemoved: []string{"rm -f /etc/netplan/60-kictl-eth0.200.yaml", "rm -f /etc/netplan/60-kictl-eth0.300.yaml"},
+			expectOutput:  "🧹 Removed 2 of 2 orphans",
+		},
+		{
+			name:          "production_confirmed",
+			answers:       "prod-east\n",
+			yes:           true,
+			production:    true,
+			expectRemoved: []string{"rm -f /etc/netplan/60-kictl-eth0.200.yaml", "rm -f /etc/netplan/60-kictl-eth0.300.yaml"},
+			expectOutput:  "Type the context name to continue",
+		},
+		{
+			name:         "dry_run",
+			yes:          true,
+			dryRun:       true,
+			expectOutput: "🧪 DRY RUN: 2 orphans would be offered for removal",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: rsb2 with files of the current VLAN storage and the removed VLANs backup and tenant
+			cmd := createRootCommand()
+			var out bytes.Buffer
+			cmd.SetOut(&out)
+			cmd.SetIn(strings.NewReader(tt.answers))
+
+			savedDryRun, savedRunner, savedLock, savedContext := dryRun, orphanRunner, lockRunner, kubeContext
+			t.Cleanup(func() {
+				dryRun, orphanRunner, lockRunner, kubeContext = savedDryRun, savedRunner, savedLock, savedContext
+			})
+			dryRun = tt.dryRun
+			home := t.TempDir()
+			t.Setenv(workspace.EnvHome, home)
+			var lockCalls []string
+			if tt.production {
+				require.NoError(t, os.WriteFile(settings.Path(home), []byte("clusters:\n  - context: prod-*\n    protection: production\n"), 0644))
+				kubeContext = "prod-east"
+				lockRunner = func(ctx context.Context, stdin []byte, args ...string) (string, error) {
+					lockCalls = append(lockCalls, args[0])
+					return "", nil
+				}
+			}
+			orphanRunner = func(ctx context.Context, stdin []byte, args ...string) (string, error) {
+				if strings.Contains(strings.Join(args, " "), "get pods") {
+					return `{"items": []}`, nil
+				}
+				return "", nil
+			}
+
+			executor := labeler.NewMockDryRunExecutor()
+			executor.On("GetAllNodes", mock.Anything).Return(true, "node/rsb2\n", nil)
+			executor.On("ExecNodeCommand", mock.Anything, "rsb2", mock.MatchedBy(func(cmd string) bool {
+				return strings.HasPrefix(cmd, "grep")
+			})).Return(true, "/etc/netplan/60-kictl-eth0.100.yaml:# Generated by kictl for VLAN storage\n"+
+				"/etc/netplan/60-kictl-eth0.200.yaml:# Generated by kictl for VLAN backup\n"+
+				"/etc/netplan/60-kictl-eth0.300.yaml:# Generated by kictl for VLAN tenant\n", nil)
+			executor.On("GetNodeAnnotations", mock.Anything, "rsb2").Return(true, "{}", nil)
+			executor.On("ReleaseDebugPods", mock.Anything).Return(1, nil)
+			var removed []string
+			executor.On("ExecNodeCommand", mock.Anything, "rsb2", mock.MatchedBy(func(cmd string) bool {
+				return strings.HasPrefix(cmd, "rm")
+			})).Run(func(args mock.Arguments) {
+				removed = append(removed, args.String(2))
+			}).Return(true, "", nil)
+
+			bundle := config.NewSingleConfigBundle(&config.NodeVLANConf{Spec: config.NodeVLANSpec{VLANs: map[string]config.VLANConfig{
+				"storage": {ID: 100, NodeMapping: config.NodeMapping{"rsb2": "10.0.100.12/24"}},
+			}}})
+
+			// When: Scan the cluster and clean up
+			err := runOrphans(context.Background(), cmd, logging.NewRecordingLogger(), []*config.ConfigBundle{bundle}, executor, time.Hour, tt.yes)
+
+			// Then: Only confirmed orphans are removed
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectRemoved, removed)
+			assert.Contains(t, out.String(), "VLAN file /etc/netplan/60-kictl-eth0.200.yaml on node rsb2: VLAN backup is not configured")
+			assert.Contains(t, out.String(), tt.expectOutput)
+			if tt.production {
+				assert.Equal(t, []string{"create", "delete"}, lockCalls, "the removal holds the cluster lock")
+			}
+		})
+	}
+}
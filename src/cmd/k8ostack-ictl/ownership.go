@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/kubectl"
+	"k8ostack-ictl/internal/labeler"
+	"k8ostack-ictl/internal/logging"
+	"k8ostack-ictl/internal/ownership"
+)
+
+// ownershipRunner replaces the kubectl of the executor for the ownership annotations of nodes in tests
+var ownershipRunner kubectl.Runner
+
+// ownedRun is the owner of a bundle with spec.ownership, and what it claimed on the bundle's nodes before the run
+type ownedRun struct {
+	owner    string
+	existing ownership.Claims
+	executor kubectl.DryRunExecutor
+}
+
+// checkOwnership compares the label keys and VLANs a run changes with the claims of other owners on its nodes
+// Conflicts are warnings unless spec.ownership.onConflict is fail. It returns nil when the bundle names no owner.
+func checkOwnership(ctx context.Context, logger logging.Logger, bundle *config.ConfigBundle, executor kubectl.DryRunExecutor) (*ownedRun, error) {
+	policy := bundle.GetDefaults().Spec.Ownership
+	if policy == nil {
+		return nil, nil
+	}
+	claims, err := bundleClaims(ctx, logger, bundle, executor)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve the nodes of owner %s: %w", policy.Owner, err)
+	}
+
+	run := &ownedRun{owner: policy.Owner, existing: ownership.Claims{}, executor: executor}
+	var conflicts []string
+	for _, node := range claims.Nodes() {
+		success, output, err := executor.GetNodeAnnotations(ctx, node)
+		if err != nil || !success {
+			logger.Warn(fmt.Sprintf("⚠️  Failed to read the ownership of node %s: %v", node, err))
+			continue
+		}
+		nodeClaims, err := ownership.Parse(output)
+		if err != nil {
+			return nil, fmt.Errorf("node %s: %w", node, err)
+		}
+		run.existing[node] = nodeClaims[policy.Owner]
+		for _, conflict := range ownership.Conflicts(node, policy.Owner, claims[node], nodeClaims) {
+			conflicts = append(conflicts, conflict.String())
+		}
+	}
+
+	if len(conflicts) == 0 {
+		logger.Info(fmt.Sprintf("🤝 Owner %s: no label keys or VLANs of other owners are touched on %d nodes", policy.Owner, len(claims)))
+		return run, nil
+	}
+	if policy.FailsOnConflict() {
+		return nil, fmt.Errorf("owner %s would change what other owners manage:\n  - %s", policy.Owner, strings.Join(conflicts, "\n  - "))
+	}
+	logger.Warn(fmt.Sprintf("⚠️  Owner %s changes what other owners manage:", policy.Owner))
+	for _, conflict := range conflicts {
+		logger.Warn(fmt.Sprintf("  - %s", conflict))
+	}
+	return run, nil
+}
+
+// bundleClaims returns the label keys and VLAN IDs each node gets from the bundle
+func bundleClaims(ctx context.Context, logger logging.Logger, bundle *config.ConfigBundle, executor kubectl.DryRunExecutor) (ownership.Claims, error) {
+	var resolved map[string][]string
+	if bundle.HasNodeLabels() {
+		labelingService := labeler.NewService(executor, labeler.Options{Verbose: verbose, Logger: logger})
+		var err error
+		if resolved, err = labelingService.ResolveNodes(ctx, bundle.NodeLabels); err != nil {
+			return nil, err
+		}
+	}
+	return claimsOf(bundle, resolved), nil
+}
+
+// appliedClaims returns the label keys and VLAN IDs a run applied to each node, see appliedChanges
+// Its label roles hold the node names they resolved to, so patterns left in them claim nothing.
+func appliedClaims(applied *config.ConfigBundle) ownership.Claims {
+	resolved := make(map[string][]string)
+	if applied.HasNodeLabels() {
+		for roleName, role := range applied.NodeLabels.Spec.NodeRoles {
+			for _, node := range role.Nodes {
+				if !config.IsNodePattern(node) {
+					resolved[roleName] = append(resolved[roleName], node)
+				}
+			}
+		}
+	}
+	return claimsOf(applied, resolved)
+}
+
+// claimsOf returns the label keys of the bundle's roles on the nodes they resolved to, and the VLAN IDs of its nodes
+func claimsOf(bundle *config.ConfigBundle, resolved map[string][]string) ownership.Claims {
+	claims := ownership.Claims{}
+	if bundle.HasNodeLabels() {
+		for roleName, role := range bundle.NodeLabels.Spec.NodeRoles {
+			var keys []string
+			for key := range role.Labels {
+				keys = append(keys, key)
+			}
+			for _, node := range resolved[roleName] {
+				claims.Add(node, ownership.Claim{Labels: keys})
+			}
+		}
+	}
+	if bundle.HasVLANs() {
+		for _, vlanConfig := range bundle.VLANs.Spec.VLANs {
+			for node := range vlanConfig.NodeMapping {
+				claims.Add(node, ownership.Claim{VLANs: []int{vlanConfig.ID}})
+			}
+		}
+	}
+	return claims
+}
+
+// record writes the owner's claims for what the run applied: apply adds to them and delete releases them
+// Kinds that ran dry and nodes that failed claim nothing, see appliedChanges. Nodes whose annotations could not be
+// read are skipped, so their earlier claims are not lost. It is a no-op on a nil run.
+func (r *ownedRun) record(ctx context.Context, logger logging.Logger, applied *config.ConfigBundle, operation string) {
+	if r == nil {
+		return
+	}
+	claims := appliedClaims(applied)
+	run := kubectlRunner(r.executor, ownershipRunner)
+	for _, node := range claims.Nodes() {
+		if _, read := r.existing[node]; !read {
+			continue
+		}
+		claim := r.existing[node].Union(claims[node])
+		if operation == operationDelete {
+			claim = r.existing[node].Without(claims[node])
+		}
+		if err := ownership.Record(ctx, node, r.owner, claim, run); err != nil {
+			logger.Warn(fmt.Sprintf("⚠️  %v", err))
+		}
+	}
+}
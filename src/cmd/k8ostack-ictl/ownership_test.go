@@ -0,0 +1,141 @@
+// Package main provides unit tests for node ownership across bundles
+// WHY: Teams sharing nodes must learn before a run that it would change labels or VLANs another team manages
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/labeler"
+	"k8ostack-ictl/internal/logging"
+	"k8ostack-ictl/internal/ownership"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// ownershipTestBundle returns a bundle of owner team-a labeling rsb2 and rsb3 and adding VLAN 100 to rsb2
+func ownershipTestBundle(onConflict string) *config.ConfigBundle {
+	return &config.ConfigBundle{
+		Defaults: &config.Defaults{Spec: config.DefaultsSpec{
+			Ownership: &config.OwnershipPolicy{Owner: "team-a", OnConflict: onConflict},
+		}},
+		NodeLabels: &config.NodeLabelConf{Spec: config.NodeLabelSpec{NodeRoles: map[string]config.NodeRole{
+			"compute": {Nodes: []string{"rsb2", "rsb3"}, Labels: map[string]string{"zone": "a"}},
+		}}},
+		VLANs: &config.NodeVLANConf{Spec: config.NodeVLANSpec{VLANs: map[string]config.VLANConfig{
+			"storage": {ID: 100, NodeMapping: config.NodeMapping{"rsb2": "10.0.100.2/24"}},
+		}}},
+	}
+}
+
+// TestCheckOwnership tests checking a run against the ownership annotations of its nodes
+// WHY: Conflicts warn or fail as the bundle asks, and the run's claims are recorded on top of its earlier ones
+func TestCheckOwnership(t *testing.T) {
+	tests := []struct {
+		name            string
+		onConflict      string
+		rsb3Annotations string
+		expectWarning   string
+		expectedRsb3    string
+		expectError     string
+	}{
+		{
+			name:            "no_conflict",
+			rsb3Annotations: `{"owner.kictl.icycloud.io/team-a":"{\"labels\":[\"rack\"]}"}`,
+			expectedRsb3:    `{"labels":["rack","zone"]}`,
+		},
+		{
+			name:            "conflict_warns",
+			rsb3Annotations: `{"owner.kictl.icycloud.io/team-b":"{\"labels\":[\"zone\"]}"}`,
+			expectWarning:   "label zone on node rsb3 is owned by team-b",
+			expectedRsb3:    `{"labels":["zone"]}`,
+		},
+		{
+			name:            "conflict_fails",
+			onConflict:      config.OwnershipConflictFail,
+			rsb3Annotations: `{"owner.kictl.icycloud.io/team-b":"{\"labels\":[\"zone\"]}"}`,
+			expectError:     "owner team-a would change what other owners manage:\n  - label zone on node rsb3 is owned by team-b",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: Nodes rsb2 without claims and rsb3 with the claims of the case
+			executor := labeler.NewMockDryRunExecutor()
+			executor.On("GetNodeAnnotations", mock.Anything, "rsb2").Return(true, `{"node.alpha.kubernetes.io/ttl":"0"}`, nil)
+			executor.On("GetNodeAnnotations", mock.Anything, "rsb3").Return(true, tt.rsb3Annotations, nil)
+			logger := logging.NewRecordingLogger()
+
+			// When: Check the run
+			owned, err := checkOwnership(context.Background(), logger, ownershipTestBundle(tt.onConflict), executor)
+
+			// Then: Conflicts are reported the way the bundle asks
+			if tt.expectError != "" {
+				assert.EqualError(t, err, tt.expectError)
+				return
+			}
+			require.NoError(t, err)
+			warnings := strings.Join(logger.Messages(logging.LevelWarn), "\n")
+			if tt.expectWarning != "" {
+				assert.Contains(t, warnings, tt.expectWarning)
+			} else {
+				assert.Empty(t, warnings)
+			}
+
+			// And: Recording the apply adds the run's claims to those of team-a
+			var annotations []string
+			savedRunner := ownershipRunner
+			t.Cleanup(func() { ownershipRunner = savedRunner })
+			ownershipRunner = func(ctx context.Context, stdin []byte, args ...string) (string, error) {
+				annotations = append(annotations, strings.Join(args[len(args)-4:], " "))
+				return "", nil
+			}
+			owned.record(context.Background(), logger, ownershipTestBundle(tt.onConflict), operationApply)
+			assert.Equal(t, []string{
+				`node rsb2 --overwrite owner.kictl.icycloud.io/team-a={"labels":["zone"],"vlans":[100]}`,
+				`node rsb3 --overwrite owner.kictl.icycloud.io/team-a=` + tt.expectedRsb3,
+			}, annotations)
+		})
+	}
+}
+
+// TestCheckOwnership_NoOwner tests bundles without spec.ownership
+// WHY: Bundles that name no owner keep running without extra cluster reads
+func TestCheckOwnership_NoOwner(t *testing.T) {
+	bundle := ownershipTestBundle("")
+	bundle.Defaults = nil
+
+	owned, err := checkOwnership(context.Background(), logging.NewRecordingLogger(), bundle, labeler.NewMockDryRunExecutor())
+
+	require.NoError(t, err)
+	assert.Nil(t, owned)
+	owned.record(context.Background(), logging.NewRecordingLogger(), bundle, operationDelete)
+}
+
+// TestOwnedRun_RecordApplied tests that only what a run applied is claimed
+// WHY: Claims for kinds that ran dry or nodes that failed would decide later conflicts on changes never made
+func TestOwnedRun_RecordApplied(t *testing.T) {
+	// Given: A run whose labels failed on rsb3 and whose VLANs ran dry
+	bundle := ownershipTestBundle("")
+	applied := newAppliedChanges(bundle)
+	applied.add(bundle.NodeLabels, []string{"rsb3"})
+	owned := &ownedRun{owner: "team-a", existing: ownership.Claims{"rsb2": {}, "rsb3": {}}}
+
+	var annotations []string
+	savedRunner := ownershipRunner
+	t.Cleanup(func() { ownershipRunner = savedRunner })
+	ownershipRunner = func(ctx context.Context, stdin []byte, args ...string) (string, error) {
+		annotations = append(annotations, strings.Join(args[len(args)-4:], " "))
+		return "", nil
+	}
+
+	// When: Record the apply
+	owned.record(context.Background(), logging.NewRecordingLogger(), applied.bundle, operationApply)
+
+	// Then: Only the labels of rsb2 are claimed
+	assert.Equal(t, []string{`node rsb2 --overwrite owner.kictl.icycloud.io/team-a={"labels":["zone"]}`}, annotations)
+}
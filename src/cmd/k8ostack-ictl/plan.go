@@ -0,0 +1,202 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"k8ostack-ictl/internal/approval"
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/labeler"
+	"k8ostack-ictl/internal/logging"
+	"k8ostack-ictl/internal/plan"
+	"k8ostack-ictl/internal/vlan"
+	"k8ostack-ictl/internal/workspace"
+
+	"github.com/spf13/cobra"
+)
+
+// createPlanCommand creates the command that shows what an apply would change
+func createPlanCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "plan",
+		Short: "Show the changes an apply would make, without touching anything",
+		Long: `Read the current node labels and VLAN interfaces, diff them against the
+bundle and print the pending additions, changes and removals per node.
+
+Label removals come from CleanupConf prefixes. VLAN interfaces that are not in the
+bundle are left alone by apply and therefore not listed. Connectivity tests do not
+change node state and are not planned.
+
+Examples:
+  kictl plan --config cluster-config.yaml
+  kictl plan --config cluster-config.yaml --target vlan.management
+
+With --out, the plan is also saved as an artifact that 'kictl approve' signs off
+and 'kictl apply --plan' checks before applying to a protected context.
+
+  kictl plan --config cluster-config.yaml --context prod --out prod.plan.json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if configFile == "" {
+				return fmt.Errorf("configuration file is required. Use --config to specify a YAML file, or 'kictl generate' to create a sample")
+			}
+
+			logger, run, err := newRunLogger(cmd, "plan")
+			if err != nil {
+				return fmt.Errorf("failed to initialize logger: %w", err)
+			}
+			defer closeRun(cmd, logger, run)
+
+			outPath, _ := cmd.Flags().GetString("out")
+			return runPlan(context.Background(), cmd, logger, run, outPath)
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&targets, "target", nil, targetFlagUsage)
+	cmd.Flags().StringSliceVar(&onlyKinds, "only", nil, onlyFlagUsage)
+	cmd.Flags().StringSliceVar(&skipKinds, "skip", nil, skipFlagUsage)
+	cmd.Flags().String("out", "", "Also save the plan as an artifact for 'kictl approve' and 'kictl apply --plan'")
+	registerFilterCompletions(cmd)
+	return cmd
+}
+
+// runPlan builds the plan for the configured bundle, renders it and saves a copy in the run folder
+// With outPath set, a complete plan is also written there as an approval artifact.
+func runPlan(ctx context.Context, cmd *cobra.Command, logger *logging.FileLogger, run *workspace.Run, outPath string) error {
+	bundle, err := loadBundle()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	resolver := newPrecedenceResolver(cmd)
+	if err := resolver.ApplyGlobalOverrides(bundle); err != nil {
+		return fmt.Errorf("failed to apply CLI precedence: %w", err)
+	}
+	printConfigDecisions(cmd, resolver)
+	warnUnsafeOverrides(logger, resolver)
+	if err := bundle.ValidateNodeNamePolicy(); err != nil {
+		return fmt.Errorf("node name policy violation: %w", err)
+	}
+	if bundle, err = selectKinds(logger, bundle); err != nil {
+		return err
+	}
+	if bundle, err = targetBundle(logger, bundle); err != nil {
+		return err
+	}
+
+	logger.Info(fmt.Sprintf("📋 Planning %s", bundle.GetSummary()))
+
+	// Plans only read state, so the executor is never put in dry-run mode
+	kubectlExecutor := newBundleExecutor(logger, bundle.GetDefaults())
+	result := &plan.Plan{}
+
+	if bundle.HasNodeLabels() || bundle.HasCleanup() {
+		var labelConfig config.Config
+		if bundle.HasNodeLabels() {
+			labelConfig = bundle.NodeLabels
+		}
+
+		labelingService := labeler.NewService(kubectlExecutor, labeler.Options{
+			Verbose: verbose,
+			Logger:  logger,
+		})
+		labelPlan, err := labelingService.PlanLabels(ctx, labelConfig, bundle.Cleanup)
+		if err != nil {
+			return fmt.Errorf("failed to plan labels: %w", err)
+		}
+		result.Merge(labelPlan)
+	}
+
+	if bundle.HasVLANs() {
+		vlanService := vlan.NewService(kubectlExecutor, vlan.Options{
+			Verbose:            verbose,
+			DefaultInterface:   bundle.GetDefaults().Spec.Interface,
+			InterfaceDetection: bundle.GetDefaults().Spec.InterfaceDetection,
+			InterfaceAliases:   bundle.GetDefaults().Spec.InterfaceAliases,
+			Logger:             logger,
+		})
+		vlanPlan, err := vlanService.PlanVLANs(ctx, bundle.VLANs)
+		if err != nil {
+			return fmt.Errorf("failed to plan VLANs: %w", err)
+		}
+		result.Merge(vlanPlan)
+	}
+
+	var rendered bytes.Buffer
+	renderPlan(&rendered, result)
+	fmt.Fprint(cmd.OutOrStdout(), rendered.String())
+
+	planPath := run.Path(workspace.PlanFile)
+	if err := os.WriteFile(planPath, rendered.Bytes(), 0644); err != nil {
+		logger.Warn(fmt.Sprintf("⚠️  Failed to save plan: %v", err))
+	} else {
+		logger.Info(fmt.Sprintf("💾 Plan saved to %s", planPath))
+	}
+
+	if len(result.Errors) > 0 {
+		return fmt.Errorf("plan is incomplete: %d nodes could not be read", len(result.Errors))
+	}
+	if outPath != "" {
+		return savePlanArtifact(cmd, bundle, result, outPath)
+	}
+	return nil
+}
+
+// savePlanArtifact writes the plan with the configuration, context and scope it covers, for approval
+func savePlanArtifact(cmd *cobra.Command, bundle *config.ConfigBundle, result *plan.Plan, outPath string) error {
+	currentContext, err := clusterTarget().CurrentContext()
+	if err != nil {
+		return fmt.Errorf("failed to determine the cluster context: %w", err)
+	}
+
+	artifact := &approval.Artifact{
+		Version:      approval.ArtifactVersion,
+		ConfigSource: bundle.Source,
+		ConfigDigest: bundle.Digest,
+		Context:      currentContext,
+		Scope:        planScope(),
+		Planner:      approval.Identity(),
+		CreatedAt:    time.Now().UTC().Truncate(time.Second),
+		Changes:      result.Changes,
+	}
+	if err := approval.Write(outPath, artifact); err != nil {
+		return err
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "💾 Plan %s saved to %s for approval\n", artifact.Digest(), outPath)
+	return nil
+}
+
+// renderPlan prints the pending changes grouped by node, followed by totals
+func renderPlan(out io.Writer, result *plan.Plan) {
+	for _, node := range result.Nodes() {
+		fmt.Fprintf(out, "\nNode %s:\n", node)
+		for _, change := range result.NodeChanges(node) {
+			switch change.Op {
+			case plan.OpAdd:
+				fmt.Fprintf(out, "  + %s %s=%s\n", change.Kind, change.Key, change.NewValue)
+			case plan.OpRemove:
+				fmt.Fprintf(out, "  - %s %s=%s\n", change.Kind, change.Key, change.OldValue)
+			case plan.OpChange:
+				fmt.Fprintf(out, "  ~ %s %s: %s -> %s\n", change.Kind, change.Key, change.OldValue, change.NewValue)
+			}
+		}
+	}
+
+	if len(result.Errors) > 0 {
+		fmt.Fprintln(out)
+		for _, err := range result.Errors {
+			fmt.Fprintf(out, "⚠️  %v\n", err)
+		}
+	}
+
+	add, change, remove := result.Counts()
+	if add+change+remove == 0 && len(result.Errors) == 0 {
+		fmt.Fprintln(out, "\n✅ No changes. The cluster matches the configuration.")
+		return
+	}
+	fmt.Fprintf(out, "\n📋 Plan: %d to add, %d to change, %d to remove.\n", add, change, remove)
+}
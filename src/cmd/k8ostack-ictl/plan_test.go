@@ -0,0 +1,75 @@
+// Package main provides unit tests for the plan command
+// WHY: The plan output is read by humans before apply, so its format must stay predictable
+package main
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"k8ostack-ictl/internal/plan"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRenderPlan tests rendering of pending changes
+// WHY: Each node lists its changes with +, ~ and - markers, followed by totals
+func TestRenderPlan(t *testing.T) {
+	tests := []struct {
+		name     string
+		plan     *plan.Plan
+		expected string
+	}{
+		{
+			name: "changes_grouped_by_node",
+			plan: &plan.Plan{Changes: []plan.Change{
+				{Node: "rsb3", Kind: "vlan", Key: "storage", Op: plan.OpAdd, NewValue: "eth0.100 10.100.0.13/24"},
+				{Node: "rsb2", Kind: "label", Key: "zone", Op: plan.OpChange, OldValue: "a", NewValue: "b"},
+				{Node: "rsb2", Kind: "label", Key: "legacy", Op: plan.OpRemove, OldValue: "x"},
+			}},
+			expected: "\nNode rsb2:\n" +
+				"  - label legacy=x\n" +
+				"  ~ label zone: a -> b\n" +
+				"\nNode rsb3:\n" +
+				"  + vlan storage=eth0.100 10.100.0.13/24\n" +
+				"\n📋 Plan: 1 to add, 1 to change, 1 to remove.\n",
+		},
+		{
+			name:     "no_changes",
+			plan:     &plan.Plan{},
+			expected: "\n✅ No changes. The cluster matches the configuration.\n",
+		},
+		{
+			name:     "unreadable_nodes_are_not_reported_as_clean",
+			plan:     &plan.Plan{Errors: []error{errors.New("node rsb9 not found")}},
+			expected: "\n⚠️  node rsb9 not found\n\n📋 Plan: 0 to add, 0 to change, 0 to remove.\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: Output buffer
+			var out bytes.Buffer
+
+			// When: Render
+			renderPlan(&out, tt.plan)
+
+			// Then: Output matches exactly
+			assert.Equal(t, tt.expected, out.String())
+		})
+	}
+}
+
+// TestPlanCommand_RequiresConfig tests the missing configuration error
+// WHY: Plan must fail before querying the cluster when no configuration is given
+func TestPlanCommand_RequiresConfig(t *testing.T) {
+	root := createRootCommand()
+	root.SetOut(new(bytes.Buffer))
+	root.SetErr(new(bytes.Buffer))
+	root.SetArgs([]string{"plan"})
+
+	err := root.Execute()
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "configuration file is required")
+}
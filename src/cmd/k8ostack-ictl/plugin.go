@@ -0,0 +1,50 @@
+package main
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// pluginBinary is the name kubectl looks up on $PATH to run kictl as `kubectl ictl`
+const pluginBinary = "kubectl-ictl"
+
+// pluginDisplayName is how help and usage name kictl when it runs as a kubectl plugin
+const pluginDisplayName = "kubectl ictl"
+
+// pluginMode is set when kictl runs as a kubectl plugin; it then selects the cluster the way kubectl does
+var pluginMode bool
+
+// isPluginInvocation reports whether the program name is the kubectl plugin binary, e.g. /usr/local/bin/kubectl-ictl
+func isPluginInvocation(argv0 string) bool {
+	return strings.TrimSuffix(filepath.Base(argv0), ".exe") == pluginBinary
+}
+
+// kictlCommandPattern matches kictl where help texts start a command line with it, but not in names like kictl.icycloud.io
+var kictlCommandPattern = regexp.MustCompile("(?m)(^|[\\s'\"`(])kictl(\\s)")
+
+// adaptToPlugin names the commands and the examples of their help `kubectl ictl` instead of kictl
+func adaptToPlugin(root *cobra.Command) {
+	if root.Annotations == nil {
+		root.Annotations = make(map[string]string)
+	}
+	root.Annotations[cobra.CommandDisplayNameAnnotation] = pluginDisplayName
+
+	var adapt func(cmd *cobra.Command)
+	adapt = func(cmd *cobra.Command) {
+		cmd.Short = pluginText(cmd.Short)
+		cmd.Long = pluginText(cmd.Long)
+		cmd.Example = pluginText(cmd.Example)
+		for _, child := range cmd.Commands() {
+			adapt(child)
+		}
+	}
+	adapt(root)
+}
+
+// pluginText replaces the kictl command lines of a help text by kubectl ictl
+func pluginText(text string) string {
+	return kictlCommandPattern.ReplaceAllString(text, "${1}"+pluginDisplayName+"${2}")
+}
@@ -0,0 +1,86 @@
+// Package main provides unit tests for running kictl as the kubectl plugin kubectl ictl
+// WHY: As a plugin, help must name the command users type and the cluster must be the one kubectl would pick
+package main
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"k8ostack-ictl/internal/settings"
+	"k8ostack-ictl/internal/workspace"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestIsPluginInvocation tests recognising the plugin binary name
+// WHY: Only kubectl-ictl is run by kubectl; other names, including kictl itself, keep the standalone behaviour
+func TestIsPluginInvocation(t *testing.T) {
+	tests := []struct {
+		argv0    string
+		expected bool
+	}{
+		{argv0: "/usr/local/bin/kubectl-ictl", expected: true},
+		{argv0: "kubectl-ictl", expected: true},
+		{argv0: "kubectl-ictl.exe", expected: true},
+		{argv0: "/usr/local/bin/kictl"},
+		{argv0: "./k8ostack-ictl"},
+		{argv0: "/usr/local/bin/kubectl-ictl-dev"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.argv0, func(t *testing.T) {
+			assert.Equal(t, tt.expected, isPluginInvocation(tt.argv0))
+		})
+	}
+}
+
+// TestAdaptToPlugin tests the help of kictl run as a kubectl plugin
+// WHY: Users copy the examples, so they must start with kubectl ictl while label keys keep their kictl domain
+func TestAdaptToPlugin(t *testing.T) {
+	// Given: The commands adapted to plugin mode
+	root := createRootCommand()
+	adaptToPlugin(root)
+
+	// When: Show the help of a subcommand
+	var out bytes.Buffer
+	root.SetOut(&out)
+	root.SetErr(&out)
+	root.SetArgs([]string{"quarantine", "--help"})
+	require.NoError(t, root.Execute())
+
+	// Then: Usage and examples name kubectl ictl
+	help := out.String()
+	assert.Contains(t, help, "kubectl ictl quarantine [command]")
+	assert.Contains(t, help, "  kubectl ictl quarantine list")
+	assert.NotContains(t, help, "  kictl quarantine")
+
+	// And: Names containing kictl are left alone
+	assert.Contains(t, help, "kictl.icycloud.io/quarantined=true")
+	assert.Equal(t, "see 'kubectl ictl runs' and (kubectl ictl plan)", pluginText("see 'kictl runs' and (kictl plan)"))
+}
+
+// TestUserSettings_PluginMode tests the settings file in plugin mode
+// WHY: kubectl users expect $KUBECONFIG and its current context, not a context the kictl settings file picked
+func TestUserSettings_PluginMode(t *testing.T) {
+	// Given: A settings file choosing a kubeconfig, a context and the parallelism
+	home := t.TempDir()
+	t.Setenv(workspace.EnvHome, home)
+	require.NoError(t, os.WriteFile(settings.Path(home), []byte("defaults:\n  kubeconfig: /etc/kictl/lab.yaml\n  context: lab\n  maxParallelism: 8\n"), 0644))
+	saved := pluginMode
+	t.Cleanup(func() { pluginMode = saved })
+	pluginMode = true
+
+	// When: Run a command that needs no cluster as kubectl ictl
+	root := createRootCommand()
+	root.SetOut(new(bytes.Buffer))
+	root.SetErr(new(bytes.Buffer))
+	root.SetArgs([]string{"schema", "export", "--kind", "Defaults"})
+	require.NoError(t, root.Execute())
+
+	// Then: Cluster selection is left to kubectl, other settings still apply
+	assert.Empty(t, kubeconfigPath)
+	assert.Empty(t, kubeContext)
+	assert.Equal(t, 8, maxParallelism)
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"io"
+
+	"k8ostack-ictl/internal/logging"
+	"k8ostack-ictl/internal/progress"
+)
+
+// liveProgress draws the progress of the running operation; nil when no progress line is drawn
+var liveProgress *progress.Terminal
+
+// progressConsole returns the console of a run logger, drawing a progress line on it when it is a terminal
+// --no-progress, --quiet and JSON logs, whose consumers expect one entry per line, turn the line off.
+func progressConsole(out io.Writer) io.Writer {
+	liveProgress = nil
+	if noProgress || !consoleOptions().Progress() || logFormat == logging.FormatJSON || !progress.IsTerminal(out) {
+		return out
+	}
+	liveProgress = progress.NewTerminal(out)
+	return liveProgress
+}
+
+// progressReporter returns the reporter the services feed: the progress line and the node table, or nil without either
+func progressReporter() progress.Reporter {
+	var live progress.Reporter
+	if liveProgress != nil {
+		live = liveProgress
+	}
+	return progress.Tee(live, runNodes.reporter())
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"k8ostack-ictl/internal/approval"
+	"k8ostack-ictl/internal/clusterlock"
+	"k8ostack-ictl/internal/config/precedence"
+	"k8ostack-ictl/internal/kubectl"
+	"k8ostack-ictl/internal/logging"
+	"k8ostack-ictl/internal/settings"
+
+	"github.com/spf13/cobra"
+)
+
+// lockRunner replaces the kubectl of the executor for the production cluster lock in tests
+var lockRunner kubectl.Runner
+
+// protectedRun is what a change to a production cluster holds on to until the run ends
+type protectedRun struct {
+	context string
+	lock    *clusterlock.Lock
+}
+
+// enforceProtection applies the protection level of the target context to a run changing it
+// Production contexts refuse unsafe overrides and --force, ask for the context name as confirmation and hold
+// the cluster lock, whatever the flags say. The result is nil for other contexts.
+func enforceProtection(ctx context.Context, cmd *cobra.Command, logger logging.Logger, resolver *precedence.GlobalResolver, operation string) (*protectedRun, error) {
+	userSettings, err := loadSettings()
+	if err != nil {
+		return nil, err
+	}
+	if len(userSettings.Clusters) == 0 {
+		return nil, nil
+	}
+	currentContext, err := clusterTarget().CurrentContext()
+	if err != nil {
+		return nil, fmt.Errorf("failed to determine the cluster context: %w", err)
+	}
+	if userSettings.Protection(currentContext) != settings.ProtectionProduction {
+		return nil, nil
+	}
+	logger.Info(fmt.Sprintf("🛡️  Context '%s' is classified production: confirmation, locking, history and policy enforcement are on", currentContext))
+
+	if unsafe := resolver.UnsafeOverrides(); len(unsafe) > 0 {
+		return nil, fmt.Errorf("production context '%s' does not accept unsafe overrides: %s", currentContext, strings.Join(unsafe, "; "))
+	}
+	if force {
+		return nil, fmt.Errorf("production context '%s' does not accept --force: the reachability guard stays on", currentContext)
+	}
+
+	out := cmd.OutOrStdout()
+	fmt.Fprintf(out, "⚠️  About to %s on production context '%s'. Type the context name to continue: ", operation, currentContext)
+	answer, _ := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+	if strings.TrimSpace(answer) != currentContext {
+		fmt.Fprintln(out)
+		return nil, fmt.Errorf("%s on production context '%s' not confirmed", operation, currentContext)
+	}
+
+	host, _ := os.Hostname()
+	holder := fmt.Sprintf("%s@%s %s (pid %d)", approval.Identity(), host, operation, os.Getpid())
+	lock, err := clusterlock.Acquire(ctx, holder, time.Now(), kubectlRunner(newKubectlExecutor(logger), lockRunner))
+	if err != nil {
+		return nil, err
+	}
+	logger.Info(fmt.Sprintf("🔒 Locked context '%s' for this run", currentContext))
+	return &protectedRun{context: currentContext, lock: lock}, nil
+}
+
+// release gives up the cluster lock of a production run; it is a no-op on a nil run
+func (p *protectedRun) release(ctx context.Context, logger logging.Logger) {
+	if p == nil {
+		return
+	}
+	if err := p.lock.Release(ctx); err != nil {
+		logger.Warn(fmt.Sprintf("⚠️  %v", err))
+		return
+	}
+	logger.Info(fmt.Sprintf("🔓 Released the lock of context '%s'", p.context))
+}
+
+// production reports whether the run changes a production context
+func (p *protectedRun) production() bool {
+	return p != nil
+}
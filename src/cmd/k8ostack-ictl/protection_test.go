@@ -0,0 +1,131 @@
+// Package main provides unit tests for cluster protection levels
+// WHY: Production safeguards must switch on from the settings file alone, whatever the flags say
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"k8ostack-ictl/internal/logging"
+	"k8ostack-ictl/internal/settings"
+	"k8ostack-ictl/internal/workspace"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestEnforceProtection tests the safeguards of production contexts
+// WHY: Production runs need the context name typed back and the cluster lock; other contexts run unhindered
+func TestEnforceProtection(t *testing.T) {
+	tests := []struct {
+		name          string
+		context       string
+		answer        string
+		force         bool
+		expectLock    bool
+		expectedError string
+	}{
+		{name: "sandbox_context", context: "kind-dev"},
+		{name: "staging_context", context: "stage"},
+		{name: "confirmed_production", context: "prod-east", answer: "prod-east\n", expectLock: true},
+		{name: "unconfirmed_production", context: "prod-east", answer: "yes\n", expectedError: "apply on production context 'prod-east' not confirmed"},
+		{name: "force_on_production", context: "prod-east", answer: "prod-east\n", force: true, expectedError: "does not accept --force"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: Settings classifying prod-* as production and stage as staging
+			home := t.TempDir()
+			t.Setenv(workspace.EnvHome, home)
+			require.NoError(t, os.WriteFile(settings.Path(home), []byte(
+				"clusters:\n  - context: prod-*\n    protection: production\n  - context: stage\n    protection: staging\n"), 0644))
+
+			cmd := createRootCommand()
+			var out bytes.Buffer
+			cmd.SetOut(&out)
+			cmd.SetIn(strings.NewReader(tt.answer))
+
+			var lockCalls []string
+			savedContext, savedForce, savedRunner := kubeContext, force, lockRunner
+			t.Cleanup(func() { kubeContext, force, lockRunner = savedContext, savedForce, savedRunner })
+			kubeContext, force = tt.context, tt.force
+			lockRunner = func(ctx context.Context, stdin []byte, args ...string) (string, error) {
+				lockCalls = append(lockCalls, strings.Join(args, " "))
+				return "", nil
+			}
+
+			// When: Enforce the protection of the context for an apply
+			logger := logging.NewRecordingLogger()
+			protected, err := enforceProtection(context.Background(), cmd, logger, newPrecedenceResolver(cmd), operationApply)
+
+			// Then: Only confirmed production runs hold the lock
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+				assert.Empty(t, lockCalls)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectLock, protected.production())
+			if !tt.expectLock {
+				assert.Empty(t, lockCalls)
+				assert.Empty(t, out.String())
+				return
+			}
+			assert.Contains(t, out.String(), "Type the context name to continue")
+			require.Equal(t, []string{"create -f -"}, lockCalls)
+
+			// And: Releasing deletes the lock lease
+			protected.release(context.Background(), logger)
+			assert.Equal(t, "delete lease kictl-run-lock --namespace kube-system --ignore-not-found", lockCalls[1])
+		})
+	}
+}
+
+// TestProtectedCommands tests that commands changing the cluster outside a bundle run are protected too
+// WHY: Production safeguards apply regardless of flags, so no command may reach a production cluster unconfirmed
+func TestProtectedCommands(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		operation string
+	}{
+		{name: "unlabel_prefix", args: []string{"--unlabel-prefix", "legacy.icycloud.io/", "--nodes", "rsb2"}, operation: "clean up labels"},
+		{name: "quarantine_release", args: []string{"quarantine", "release", "rsb2"}, operation: "release quarantined nodes"},
+		{name: "multus_apply", args: []string{"export", "multus", "--apply"}, operation: "apply NetworkAttachmentDefinitions"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A production context and a kubectl recording every call
+			home := t.TempDir()
+			t.Setenv(workspace.EnvHome, home)
+			require.NoError(t, os.WriteFile(settings.Path(home), []byte("clusters:\n  - context: prod-*\n    protection: production\n"), 0644))
+			calls := filepath.Join(t.TempDir(), "calls")
+			bin := t.TempDir()
+			require.NoError(t, os.WriteFile(filepath.Join(bin, "kubectl"), []byte("#!/bin/sh\necho \"$*\" >> "+calls+"\n"), 0755))
+			t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+			configPath := filepath.Join(t.TempDir(), "cluster-config.yaml")
+			require.NoError(t, os.WriteFile(configPath, []byte(exportTestConfig), 0644))
+
+			cmd := createRootCommand()
+			out := new(bytes.Buffer)
+			cmd.SetOut(out)
+			cmd.SetErr(new(bytes.Buffer))
+			cmd.SetIn(strings.NewReader("yes\n"))
+			cmd.SetArgs(append(tt.args, "--context", "prod-east", "--config", configPath))
+			t.Cleanup(func() { kubeContext, configFile = "", "" })
+
+			// When: Run the command without typing the context name
+			err := cmd.Execute()
+
+			// Then: It stops at the confirmation before kubectl runs
+			assert.ErrorContains(t, err, tt.operation+" on production context 'prod-east' not confirmed")
+			assert.Contains(t, out.String(), "Type the context name to continue")
+			assert.NoFileExists(t, calls)
+		})
+	}
+}
@@ -0,0 +1,310 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/kubectl"
+	"k8ostack-ictl/internal/logging"
+	"k8ostack-ictl/internal/quarantine"
+	"k8ostack-ictl/internal/rundb"
+	"k8ostack-ictl/internal/workspace"
+
+	"github.com/spf13/cobra"
+)
+
+// quarantineRunner replaces the kubectl of the executor for the quarantine label and reason of nodes in tests
+var quarantineRunner kubectl.Runner
+
+// quarantineClient posts quarantine notices to the webhook; nil uses a client with a short timeout
+var quarantineClient *http.Client
+
+// diagnosticsTimeout bounds collecting diagnostics from a node that is failing already
+const diagnosticsTimeout = time.Minute
+
+// createQuarantineCommand creates the command group listing and releasing quarantined nodes
+func createQuarantineCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "quarantine",
+		Short: "List and release nodes quarantined after repeated failures",
+		Long: `Bundles with a spec.quarantine policy in their Defaults document take a node out
+of their runs once a service failed on it in spec.quarantine.after runs of the same
+operation in a row. The node gets the label ` + quarantine.Label + `=true and the
+reason in the ` + quarantine.ReasonAnnotation + ` annotation; the collected
+diagnostics are written to the quarantine folder of the workspace and posted to
+spec.quarantine.webhook when it is set.
+
+Quarantined nodes stay out of the runs of every bundle with a quarantine policy
+until they are released, e.g. once the hardware is repaired.
+
+Examples:
+  kictl quarantine list
+
+  kictl quarantine release rsb3`,
+	}
+	cmd.AddCommand(createQuarantineListCommand())
+	cmd.AddCommand(createQuarantineReleaseCommand())
+	return cmd
+}
+
+// createQuarantineListCommand creates the command listing the quarantined nodes with their reasons
+func createQuarantineListCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List the quarantined nodes and why they were quarantined",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			logger, run, err := newRunLogger(cmd, "quarantine")
+			if err != nil {
+				return fmt.Errorf("failed to initialize logger: %w", err)
+			}
+			defer closeRun(cmd, logger, run)
+
+			return listQuarantined(context.Background(), cmd.OutOrStdout(), logger, newKubectlExecutor(logger))
+		},
+	}
+}
+
+// createQuarantineReleaseCommand creates the command returning quarantined nodes to the runs
+func createQuarantineReleaseCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "release NODE...",
+		Short: "Release quarantined nodes so runs include them again",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			logger, run, err := newRunLogger(cmd, "quarantine")
+			if err != nil {
+				return fmt.Errorf("failed to initialize logger: %w", err)
+			}
+			defer closeRun(cmd, logger, run)
+
+			ctx := context.Background()
+			protected, err := enforceProtection(ctx, cmd, logger, newPrecedenceResolver(cmd), "release quarantined nodes")
+			if err != nil {
+				return err
+			}
+			defer protected.release(ctx, logger)
+
+			stopAudit, err := startAudit(logger, config.NewEmptyBundle(), "quarantine release")
+			if err != nil {
+				return fmt.Errorf("failed to open the audit log: %w", err)
+			}
+			defer stopAudit()
+
+			release := kubectlRunner(newKubectlExecutor(logger), quarantineRunner)
+			var failed []string
+			for _, node := range args {
+				if err := quarantine.Release(ctx, node, release); err != nil {
+					logger.Error(fmt.Sprintf("❌ %v", err))
+					failed = append(failed, node)
+					continue
+				}
+				logger.Info(fmt.Sprintf("🔓 Released node %s from quarantine", node))
+			}
+			if len(failed) > 0 {
+				return fmt.Errorf("failed to release %d of %d nodes: %s", len(failed), len(args), strings.Join(failed, ", "))
+			}
+			return nil
+		},
+	}
+}
+
+// listQuarantined prints the quarantined nodes of the cluster with the reason recorded on each
+func listQuarantined(ctx context.Context, out io.Writer, logger logging.Logger, executor kubectl.DryRunExecutor) error {
+	nodes, err := quarantinedNodes(ctx, executor)
+	if err != nil {
+		return err
+	}
+	if len(nodes) == 0 {
+		fmt.Fprintln(out, "✅ No node is quarantined.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NODE\tREASON")
+	for _, node := range nodes {
+		reason := "-"
+		success, output, err := executor.GetNodeAnnotations(ctx, node)
+		if err != nil || !success {
+			logger.Warn(fmt.Sprintf("⚠️  Failed to read the quarantine reason of node %s: %v", node, err))
+		} else if recorded, err := quarantine.ReasonFromAnnotations(output); err != nil {
+			logger.Warn(fmt.Sprintf("⚠️  node %s: %v", node, err))
+		} else if recorded != "" {
+			reason = recorded
+		}
+		fmt.Fprintf(w, "%s\t%s\n", node, reason)
+	}
+	return w.Flush()
+}
+
+// quarantinedNodes returns the sorted nodes of the cluster carrying the quarantine label
+func quarantinedNodes(ctx context.Context, executor kubectl.DryRunExecutor) ([]string, error) {
+	success, output, err := executor.GetNodesByLabel(ctx, quarantine.Selector)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list quarantined nodes: %w", err)
+	}
+	if !success {
+		return nil, fmt.Errorf("failed to list quarantined nodes: %s", strings.TrimSpace(output))
+	}
+	nodes := kubectl.ParseNodeNames(output)
+	sort.Strings(nodes)
+	return nodes, nil
+}
+
+// skipQuarantined leaves quarantined nodes out of a bundle with a quarantine policy, returning other bundles unchanged
+func skipQuarantined(ctx context.Context, logger logging.Logger, bundle *config.ConfigBundle, executor kubectl.DryRunExecutor) (*config.ConfigBundle, error) {
+	if !bundle.GetDefaults().Spec.Quarantine.Enabled() {
+		return bundle, nil
+	}
+	nodes, err := quarantinedNodes(ctx, executor)
+	if err != nil || len(nodes) == 0 {
+		return bundle, err
+	}
+	remaining, err := bundle.ExcludeNodes(nodes)
+	if err != nil {
+		return nil, fmt.Errorf("quarantined nodes: %w", err)
+	}
+
+	logger.Warn(fmt.Sprintf("🚧 Skipping %d quarantined nodes: %s (see 'kictl quarantine list')", len(nodes), strings.Join(nodes, ", ")))
+	return remaining, nil
+}
+
+// quarantineFailingNodes quarantines the nodes on which a service failed in this run and the runs of the same
+// operation before it, as many as the bundle's quarantine policy allows. Failures to quarantine are only logged.
+func quarantineFailingNodes(ctx context.Context, logger logging.Logger, bundle *config.ConfigBundle, executor kubectl.DryRunExecutor, operation string, recorder *runRecorder) {
+	policy := bundle.GetDefaults().Spec.Quarantine
+	if !policy.Enabled() || operation == operationVerify || isBundleDryRun(bundle) {
+		return
+	}
+	if recorder == nil {
+		logger.Warn(fmt.Sprintf("⚠️  spec.quarantine needs a run database to count failures: use --run-db or set %s", envRunDB))
+		return
+	}
+
+	var failed []rundb.NodeResult
+	for _, result := range recorder.run.Nodes {
+		if result.Status == rundb.StatusFailed {
+			failed = append(failed, result)
+		}
+	}
+	if len(failed) == 0 {
+		return
+	}
+	sort.SliceStable(failed, func(i, j int) bool { return failed[i].Node < failed[j].Node })
+
+	db, err := rundb.Open(recorder.path)
+	if err != nil {
+		logger.Warn(fmt.Sprintf("⚠️  Failed to count the failures of nodes: %v", err))
+		return
+	}
+	defer db.Close()
+
+	quarantined := make(map[string]bool)
+	for _, result := range failed {
+		if quarantined[result.Node] {
+			continue
+		}
+		failures := []quarantine.Failure{{RunID: recorder.run.ID, StartedAt: recorder.run.StartedAt, Error: result.Error}}
+		history, err := db.NodeHistory(result.Node, result.Service, operation, policy.After-1)
+		if err != nil {
+			logger.Warn(fmt.Sprintf("⚠️  Failed to count the failures of node %s: %v", result.Node, err))
+			continue
+		}
+		for _, past := range history {
+			if past.Status != rundb.StatusFailed {
+				break
+			}
+			failures = append(failures, quarantine.Failure{RunID: past.RunID, StartedAt: past.StartedAt, Error: past.Error})
+		}
+		if len(failures) < policy.After {
+			continue
+		}
+		quarantined[result.Node] = true
+		quarantineNode(ctx, logger, policy, executor, quarantine.Notice{
+			Node:          result.Node,
+			Service:       result.Service,
+			Operation:     operation,
+			Reason:        quarantine.Reason(result.Service, operation, failures),
+			QuarantinedAt: time.Now().UTC(),
+			Diagnostics:   quarantine.Diagnostics{Failures: failures},
+		})
+	}
+}
+
+// quarantineNode labels a node as quarantined, then collects its diagnostics, writes them to the workspace and
+// posts the notice to the policy's webhook
+func quarantineNode(ctx context.Context, logger logging.Logger, policy *config.QuarantinePolicy, executor kubectl.DryRunExecutor, notice quarantine.Notice) {
+	if err := quarantine.Quarantine(ctx, notice.Node, notice.Reason, kubectlRunner(executor, quarantineRunner)); err != nil {
+		logger.Error(fmt.Sprintf("❌ %v", err))
+		return
+	}
+	logger.Error(fmt.Sprintf("🚨 Quarantined node %s: %s", notice.Node, notice.Reason))
+
+	collectDiagnostics(ctx, executor, &notice)
+	if path, err := writeQuarantineNotice(notice); err != nil {
+		logger.Warn(fmt.Sprintf("⚠️  Failed to write the diagnostics of node %s: %v", notice.Node, err))
+	} else {
+		logger.Info(fmt.Sprintf("📄 Diagnostics of node %s written to %s", notice.Node, path))
+	}
+
+	switch {
+	case policy.Webhook == "":
+	case offline:
+		logger.Warn(fmt.Sprintf("⚠️  Not notifying %s about node %s in offline mode", policy.Webhook, notice.Node))
+	default:
+		if err := quarantine.Notify(ctx, quarantineClient, policy.Webhook, notice); err != nil {
+			logger.Warn(fmt.Sprintf("⚠️  %v", err))
+		} else {
+			logger.Info(fmt.Sprintf("📣 Notified %s about node %s", policy.Webhook, notice.Node))
+		}
+	}
+}
+
+// collectDiagnostics adds the network and hardware state of the node to a notice, noting what could not be read
+func collectDiagnostics(ctx context.Context, executor kubectl.DryRunExecutor, notice *quarantine.Notice) {
+	ctx, cancel := context.WithTimeout(ctx, diagnosticsTimeout)
+	defer cancel()
+
+	collect := func(what string, read func(context.Context, string) (bool, string, error)) string {
+		success, output, err := read(ctx, notice.Node)
+		if err != nil || !success {
+			message := strings.TrimSpace(output)
+			if err != nil {
+				message = err.Error()
+			}
+			notice.Diagnostics.CollectErrors = append(notice.Diagnostics.CollectErrors, what+": "+message)
+			return ""
+		}
+		return output
+	}
+	notice.Diagnostics.Network = collect("network", executor.GetNodeNetworkInfo)
+	notice.Diagnostics.Hardware = collect("hardware", executor.GetNodeHardwareInfo)
+}
+
+// writeQuarantineNotice writes a notice to the quarantine folder of the workspace and returns its path
+func writeQuarantineNotice(notice quarantine.Notice) (string, error) {
+	ws, err := openWorkspace()
+	if err != nil {
+		return "", err
+	}
+	dir := ws.Path(workspace.QuarantineDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	data, err := json.MarshalIndent(notice, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, fmt.Sprintf("%s-%s.json", notice.Node, notice.QuarantinedAt.Format("20060102-150405")))
+	return path, os.WriteFile(path, append(data, '\n'), 0644)
+}
@@ -0,0 +1,186 @@
+// Package main provides unit tests for quarantining nodes after repeated failures
+// WHY: A node with broken hardware must leave the rollout after the agreed number of failures, not before or never
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/labeler"
+	"k8ostack-ictl/internal/logging"
+	"k8ostack-ictl/internal/quarantine"
+	"k8ostack-ictl/internal/rundb"
+	"k8ostack-ictl/internal/workspace"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// quarantineTestBundle returns a bundle adding VLAN 100 to rsb2 and rsb3, with a quarantine policy when after is set
+func quarantineTestBundle(after int, webhook string) *config.ConfigBundle {
+	bundle := &config.ConfigBundle{
+		VLANs: &config.NodeVLANConf{Kind: "NodeVLANConf", Spec: config.NodeVLANSpec{VLANs: map[string]config.VLANConfig{
+			"storage": {ID: 100, NodeMapping: config.NodeMapping{"rsb2": "10.0.100.2/24", "rsb3": "10.0.100.3/24"}},
+		}}},
+	}
+	if after > 0 {
+		bundle.Defaults = config.BuiltinDefaults()
+		bundle.Defaults.Spec.Quarantine = &config.QuarantinePolicy{After: after, Webhook: webhook}
+	}
+	return bundle
+}
+
+// TestQuarantineFailingNodes tests quarantining the nodes that failed in enough runs in a row
+// WHY: One failed run must not quarantine a node, and the notice must carry what is needed to repair it
+func TestQuarantineFailingNodes(t *testing.T) {
+	// Given: A run database in which rsb3 failed the previous apply and rsb2 passed it
+	dir := t.TempDir()
+	savedWorkspace, savedRunner, savedClient := workspaceDir, quarantineRunner, quarantineClient
+	t.Cleanup(func() { workspaceDir, quarantineRunner, quarantineClient = savedWorkspace, savedRunner, savedClient })
+	workspaceDir = filepath.Join(dir, "workspace")
+	db, err := rundb.Open(filepath.Join(dir, "runs.db"))
+	require.NoError(t, err)
+	started := time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC)
+	require.NoError(t, db.SaveRun(rundb.Run{ID: "run-1", Operation: operationApply, StartedAt: started, Status: rundb.StatusFailed, Nodes: []rundb.NodeResult{
+		{Service: "NodeVLANConf", Node: "rsb2", Status: rundb.StatusSucceeded},
+		{Service: "NodeVLANConf", Node: "rsb3", Status: rundb.StatusFailed, Error: "ip link add failed"},
+	}}))
+	db.Close()
+
+	// And: This apply failed on both nodes, with a webhook and a kubectl recording what they receive
+	var notices []quarantine.Notice
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var notice quarantine.Notice
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&notice))
+		notices = append(notices, notice)
+	}))
+	defer server.Close()
+	quarantineClient = server.Client()
+	var kubectlCalls []string
+	quarantineRunner = func(ctx context.Context, stdin []byte, args ...string) (string, error) {
+		kubectlCalls = append(kubectlCalls, strings.Join(args, " "))
+		return "", nil
+	}
+	recorder := &runRecorder{path: filepath.Join(dir, "runs.db"), run: rundb.Run{
+		ID: "run-2", Operation: operationApply, StartedAt: started.Add(time.Hour),
+		Nodes: []rundb.NodeResult{
+			{Service: "NodeVLANConf", Node: "rsb2", Status: rundb.StatusFailed, Error: "ip link add failed"},
+			{Service: "NodeVLANConf", Node: "rsb3", Status: rundb.StatusFailed, Error: "ip link add failed again"},
+		},
+	}}
+	executor := labeler.NewMockDryRunExecutor()
+	executor.On("GetNodeNetworkInfo", mock.Anything, "rsb3").Return(true, "2: eth0: <BROADCAST,UP>", nil)
+	executor.On("GetNodeHardwareInfo", mock.Anything, "rsb3").Return(false, "", errors.New("debug pod not scheduled"))
+	logger := logging.NewRecordingLogger()
+
+	// When: Quarantine the nodes failing twice in a row
+	quarantineFailingNodes(context.Background(), logger, quarantineTestBundle(2, server.URL), executor, operationApply, recorder)
+
+	// Then: Only rsb3 is quarantined, with its reason
+	reason := "NodeVLANConf apply failed in 2 runs in a row: ip link add failed again"
+	assert.Equal(t, []string{
+		"label node rsb3 --overwrite kictl.icycloud.io/quarantined=true",
+		"annotate node rsb3 --overwrite kictl.icycloud.io/quarantine-reason=" + reason,
+	}, kubectlCalls)
+	assert.Contains(t, logger.Messages(logging.LevelError), "🚨 Quarantined node rsb3: "+reason)
+
+	// And: The notice with both failures and the diagnostics is posted and written to the workspace
+	require.Len(t, notices, 1)
+	notice := notices[0]
+	assert.Equal(t, "rsb3", notice.Node)
+	assert.Equal(t, []string{"run-2", "run-1"}, []string{notice.Diagnostics.Failures[0].RunID, notice.Diagnostics.Failures[1].RunID})
+	assert.Equal(t, "2: eth0: <BROADCAST,UP>", notice.Diagnostics.Network)
+	assert.Equal(t, []string{"hardware: debug pod not scheduled"}, notice.Diagnostics.CollectErrors)
+	files, err := filepath.Glob(filepath.Join(workspaceDir, workspace.QuarantineDir, "rsb3-*.json"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	data, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"reason": "`+reason+`"`)
+}
+
+// TestQuarantineFailingNodes_Skipped tests the runs that never quarantine
+// WHY: Verifications and dry runs change nothing, and without a run database there is nothing to count
+func TestQuarantineFailingNodes_Skipped(t *testing.T) {
+	tests := []struct {
+		name          string
+		operation     string
+		recorder      *runRecorder
+		expectWarning bool
+	}{
+		{name: "verify", operation: operationVerify},
+		{name: "no_run_database", operation: operationApply, expectWarning: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A kubectl failing the test when called
+			savedRunner := quarantineRunner
+			t.Cleanup(func() { quarantineRunner = savedRunner })
+			quarantineRunner = func(ctx context.Context, stdin []byte, args ...string) (string, error) {
+				t.Errorf("unexpected kubectl %v", args)
+				return "", nil
+			}
+			logger := logging.NewRecordingLogger()
+
+			// When: Quarantine after a run
+			quarantineFailingNodes(context.Background(), logger, quarantineTestBundle(1, ""), labeler.NewMockDryRunExecutor(), tt.operation, tt.recorder)
+
+			// Then: No node is quarantined, and a missing run database is reported
+			if tt.expectWarning {
+				assert.Contains(t, logger.Messages(logging.LevelWarn), "⚠️  spec.quarantine needs a run database to count failures: use --run-db or set KICTL_RUN_DB")
+			} else {
+				assert.Empty(t, logger.Messages(logging.LevelWarn))
+			}
+		})
+	}
+}
+
+// TestSkipQuarantined tests leaving quarantined nodes out of a run
+// WHY: A quarantined node must stay out until released, but bundles without a policy must not even look
+func TestSkipQuarantined(t *testing.T) {
+	tests := []struct {
+		name          string
+		after         int
+		quarantined   string
+		expectedNodes []string
+		expectError   string
+	}{
+		{name: "no_policy", expectedNodes: []string{"rsb2", "rsb3"}},
+		{name: "none_quarantined", after: 3, expectedNodes: []string{"rsb2", "rsb3"}},
+		{name: "rsb3_quarantined", after: 3, quarantined: "node/rsb3\n", expectedNodes: []string{"rsb2"}},
+		{name: "all_quarantined", after: 3, quarantined: "node/rsb2\nnode/rsb3\n", expectError: "quarantined nodes: no configuration of the bundle applies to nodes other than rsb2, rsb3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A cluster with the quarantined nodes of the case
+			executor := labeler.NewMockDryRunExecutor()
+			if tt.after > 0 {
+				executor.On("GetNodesByLabel", mock.Anything, quarantine.Selector).Return(true, tt.quarantined, nil)
+			}
+
+			// When: Skip the quarantined nodes
+			bundle, err := skipQuarantined(context.Background(), logging.NewRecordingLogger(), quarantineTestBundle(tt.after, ""), executor)
+
+			// Then: Only the nodes not quarantined remain
+			executor.AssertExpectations(t)
+			if tt.expectError != "" {
+				assert.EqualError(t, err, tt.expectError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedNodes, bundle.GetAllNodeNames())
+		})
+	}
+}
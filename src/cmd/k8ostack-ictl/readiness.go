@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/junit"
+	"k8ostack-ictl/internal/kubectl"
+	"k8ostack-ictl/internal/logging"
+	"k8ostack-ictl/internal/readiness"
+	"k8ostack-ictl/internal/revision"
+)
+
+// readinessRunner replaces the kubectl of the executor setting the verification condition in tests
+var readinessRunner kubectl.Runner
+
+// nodeVerifyResults combines the node cases of the label and VLAN suites of a verify report into one result per node
+// It reports false when a service could not verify at all, since its nodes would otherwise look verified.
+func nodeVerifyResults(report *junit.Report, bundle *config.ConfigBundle) ([]readiness.Result, bool) {
+	kinds := make(map[string]bool)
+	if bundle.HasNodeLabels() {
+		kinds[bundle.NodeLabels.Kind] = true
+	}
+	if bundle.HasVLANs() {
+		kinds[bundle.VLANs.Kind] = true
+	}
+
+	failures := make(map[string][]string)
+	for _, suite := range report.Suites {
+		if !kinds[suite.Name] {
+			continue
+		}
+		for _, c := range suite.Cases {
+			switch {
+			case c.Name == serviceCase && c.Failure != "":
+				return nil, false
+			case c.Skipped != "":
+			case c.Failure != "":
+				failures[c.Name] = append(failures[c.Name], fmt.Sprintf("%s: %s", suite.Name, c.Failure))
+			default:
+				if _, ok := failures[c.Name]; !ok {
+					failures[c.Name] = nil
+				}
+			}
+		}
+	}
+
+	nodes := make([]string, 0, len(failures))
+	for nodeName := range failures {
+		nodes = append(nodes, nodeName)
+	}
+	sort.Strings(nodes)
+
+	verified := "Matches the bundle"
+	if bundle.Digest != "" {
+		verified = "Matches bundle revision " + revision.Short(bundle.Digest)
+	}
+	results := make([]readiness.Result, 0, len(nodes))
+	for _, nodeName := range nodes {
+		result := readiness.Result{Node: nodeName, Verified: len(failures[nodeName]) == 0, Message: verified}
+		if !result.Verified {
+			result.Message = strings.Join(failures[nodeName], "; ")
+		}
+		results = append(results, result)
+	}
+	return results, true
+}
+
+// publishVerifyConditions sets the verification condition on every verified node, failing with the nodes it could not set
+func publishVerifyConditions(ctx context.Context, logger logging.Logger, report *junit.Report, bundle *config.ConfigBundle) error {
+	results, complete := nodeVerifyResults(report, bundle)
+	if !complete {
+		logger.Warn(fmt.Sprintf("⚠️  Verification is incomplete, leaving the %s condition of the nodes unchanged", readiness.ConditionType))
+		return nil
+	}
+	if isBundleDryRun(bundle) {
+		logger.Info(fmt.Sprintf("🧪 DRY RUN: Would set the %s condition of %d nodes", readiness.ConditionType, len(results)))
+		return nil
+	}
+
+	run := kubectlRunner(newBundleExecutor(logger, bundle.GetDefaults()), readinessRunner)
+	now := time.Now()
+	var failed []string
+	for _, result := range results {
+		if err := readiness.Publish(ctx, result, now, run); err != nil {
+			logger.Error(fmt.Sprintf("❌ %v", err))
+			failed = append(failed, result.Node)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to set the %s condition of nodes %s", readiness.ConditionType, strings.Join(failed, ", "))
+	}
+	logger.Info(fmt.Sprintf("🚦 Set the %s condition of %d nodes", readiness.ConditionType, len(results)))
+	return nil
+}
@@ -0,0 +1,114 @@
+// Package main provides unit tests for publishing verify results as a node condition
+// WHY: Workloads are scheduled by this condition, so a node must never look verified after an incomplete check
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"k8ostack-ictl/internal/junit"
+	"k8ostack-ictl/internal/logging"
+	"k8ostack-ictl/internal/readiness"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readinessTestReport returns a verify report with rsb2 verified, rsb3 missing labels and rsb4 missing its VLAN
+func readinessTestReport() *junit.Report {
+	report := junit.NewReport("kictl verify")
+	labels := report.Suite("NodeLabelConf")
+	labels.Pass("rsb2")
+	labels.Fail("rsb3", "expected labels are missing")
+	report.Suite("NodeVLANConf").Fail("rsb4", "VLAN interfaces do not match the configuration")
+	report.Suite("NodeTestConf").Fail("ping rsb2 -> rsb3", "100% packet loss")
+	return report
+}
+
+// TestNodeVerifyResults tests combining the suites of a verify report into one result per node
+// WHY: A node passing its labels but failing its VLANs is not verified, and connectivity cases name no node
+func TestNodeVerifyResults(t *testing.T) {
+	tests := []struct {
+		name             string
+		serviceFailure   bool
+		expected         []readiness.Result
+		expectIncomplete bool
+	}{
+		{
+			name: "per_node",
+			expected: []readiness.Result{
+				{Node: "rsb2", Verified: true, Message: "Matches bundle revision sha256:2c26b46b68ff"},
+				{Node: "rsb3", Message: "NodeLabelConf: expected labels are missing"},
+				{Node: "rsb4", Message: "NodeVLANConf: VLAN interfaces do not match the configuration"},
+			},
+		},
+		{name: "service_failed", serviceFailure: true, expectIncomplete: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: The report of a bundle with labels, VLANs and tests
+			report := readinessTestReport()
+			if tt.serviceFailure {
+				report.Suite("NodeVLANConf").Fail(serviceCase, "failed to list nodes")
+			}
+			bundle := revisionTestBundle()
+			bundle.NodeLabels.Kind, bundle.VLANs.Kind = "NodeLabelConf", "NodeVLANConf"
+
+			// When: Combine the results
+			results, complete := nodeVerifyResults(report, bundle)
+
+			// Then: Each node has one result, unless a service could not verify
+			assert.Equal(t, !tt.expectIncomplete, complete)
+			assert.Equal(t, tt.expected, results)
+		})
+	}
+}
+
+// TestPublishVerifyConditions tests setting the condition of the verified nodes
+// WHY: A node whose condition could not be set must fail the run, as it may still claim to be verified
+func TestPublishVerifyConditions(t *testing.T) {
+	tests := []struct {
+		name        string
+		dryRun      bool
+		failNode    string
+		expectCalls int
+		expectError string
+	}{
+		{name: "publish", expectCalls: 6},
+		{name: "patch_fails", failNode: "rsb3", expectCalls: 6, expectError: "failed to set the kictl.icycloud.io/NetworkVerified condition of nodes rsb3"},
+		{name: "dry_run", dryRun: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A runner recording the kubectl calls
+			savedRunner := readinessRunner
+			t.Cleanup(func() { readinessRunner = savedRunner })
+			var calls []string
+			readinessRunner = func(ctx context.Context, stdin []byte, args ...string) (string, error) {
+				calls = append(calls, strings.Join(args, " "))
+				if args[0] == "patch" && args[2] == tt.failNode {
+					return "nodes \"rsb3\" is forbidden", assert.AnError
+				}
+				return "", nil
+			}
+			bundle := revisionTestBundle()
+			bundle.NodeLabels.Kind, bundle.VLANs.Kind = "NodeLabelConf", "NodeVLANConf"
+			bundle.NodeLabels.Tools.Nlabel.DryRun = tt.dryRun
+			bundle.VLANs.Tools.Nvlan.DryRun = tt.dryRun
+
+			// When: Publish the report
+			err := publishVerifyConditions(context.Background(), logging.NewRecordingLogger(), readinessTestReport(), bundle)
+
+			// Then: Every node is read and patched, and failures name the nodes
+			if tt.expectError != "" {
+				assert.EqualError(t, err, tt.expectError)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Len(t, calls, tt.expectCalls)
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"k8ostack-ictl/internal/config"
+
+	"github.com/spf13/cobra"
+)
+
+// createRenderCommand creates the command that prints a configuration template rendered with --values
+func createRenderCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "render",
+		Short: "Print the bundle a configuration template renders with --values",
+		Long: `Render the --config template with the --values files and print the resulting
+bundle, as every other command loads it. The bundle is validated before it is
+printed, so CI can stamp out and check one bundle per environment.
+
+Templates use Go template syntax with the values as .Values, e.g.
+{{ .Values.vlans.management.subnet }}, and a subset of the sprig functions.
+
+Examples:
+  # Print the bundle of the staging environment
+  kictl render --config cluster.tmpl.yaml --values staging.yaml
+
+  # Write the production bundle, with site overrides on top of shared values
+  kictl render --config cluster.tmpl.yaml --values common.yaml --values prod.yaml --output prod.yaml`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			output, _ := cmd.Flags().GetString("output")
+			return runRender(cmd, output)
+		},
+	}
+
+	cmd.Flags().StringP("output", "o", "", "Output file (default stdout)")
+	return cmd
+}
+
+// runRender renders --config with --values and writes the validated bundle to stdout or an output file
+func runRender(cmd *cobra.Command, output string) error {
+	if configFile == "" {
+		return configError(fmt.Errorf("configuration file is required. Use --config to specify the template to render"))
+	}
+	if len(valuesFiles) == 0 {
+		return configError(fmt.Errorf("no values to render with. Use --values to specify a values file"))
+	}
+
+	data, err := config.RenderConfig(configFile, valuesFiles)
+	if err != nil {
+		return configError(err)
+	}
+	if _, err := config.LoadConfigData(data, configFile); err != nil {
+		return configError(fmt.Errorf("rendered config is not valid: %w", err))
+	}
+
+	if output == "" {
+		_, err := cmd.OutOrStdout().Write(data)
+		return err
+	}
+	if err := os.WriteFile(output, data, 0644); err != nil {
+		return fmt.Errorf("failed to write rendered config: %w", err)
+	}
+	fmt.Fprintf(consoleOptions().Chatter(cmd.OutOrStdout()), "✅ Rendered %s with %d values files to %s\n", configFile, len(valuesFiles), output)
+	return nil
+}
@@ -0,0 +1,117 @@
+// Package main provides unit tests for rendering configuration templates with --values
+// WHY: CI stamps out one bundle per environment with render, and every command must load the same rendered bundle
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// renderTestTemplate is a labels bundle template taking its node from the values
+const renderTestTemplate = `apiVersion: openstack.kictl.icycloud.io/v2
+kind: NodeLabelConf
+metadata:
+  name: labels
+spec:
+  nodeRoles:
+    compute:
+      nodes: [{{ .Values.node }}]
+      labels:
+        openstack-role: compute
+`
+
+// TestRenderCommand tests printing and writing a rendered bundle
+// WHY: Only a bundle that loads may be written, and a template without values or with a missing one must fail as configuration
+func TestRenderCommand(t *testing.T) {
+	tests := []struct {
+		name           string
+		values         []string
+		output         bool
+		expected       string
+		expectError    string
+		expectExitCode int
+	}{
+		{name: "stdout", values: []string{"node: rsb2\n"}, expected: "      nodes: [rsb2]\n"},
+		{name: "later_values_win", values: []string{"node: rsb2\n", "node: rsb3\n"}, expected: "      nodes: [rsb3]\n"},
+		{name: "output_file", values: []string{"node: rsb2\n"}, output: true, expected: "      nodes: [rsb2]\n"},
+		{name: "missing_value", values: []string{"nodes: rsb2\n"}, expectError: "prints a key missing from the values", expectExitCode: 2},
+		{name: "invalid_result", values: []string{"node: '[rsb2'\n"}, expectError: "rendered config is not valid", expectExitCode: 2},
+		{name: "no_values", expectError: "no values to render with", expectExitCode: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A bundle template and values files
+			savedConfig, savedValues := configFile, valuesFiles
+			t.Cleanup(func() { configFile, valuesFiles = savedConfig, savedValues })
+			dir := t.TempDir()
+			configPath := filepath.Join(dir, "cluster.tmpl.yaml")
+			require.NoError(t, os.WriteFile(configPath, []byte(renderTestTemplate), 0644))
+			args := []string{"render", "--config", configPath}
+			for i, values := range tt.values {
+				path := filepath.Join(dir, "values"+string(rune('a'+i))+".yaml")
+				require.NoError(t, os.WriteFile(path, []byte(values), 0644))
+				args = append(args, "--values", path)
+			}
+			outputPath := filepath.Join(dir, "rendered.yaml")
+			if tt.output {
+				args = append(args, "--output", outputPath)
+			}
+
+			// When: Render it
+			var stdout bytes.Buffer
+			root := createRootCommand()
+			root.SetOut(&stdout)
+			root.SetErr(new(bytes.Buffer))
+			root.SetArgs(args)
+			err := root.Execute()
+
+			// Then: The rendered bundle is printed or written
+			if tt.expectError != "" {
+				assert.ErrorContains(t, err, tt.expectError)
+				assert.Equal(t, tt.expectExitCode, exitCode(err))
+				return
+			}
+			require.NoError(t, err)
+			if !tt.output {
+				assert.Contains(t, stdout.String(), tt.expected)
+				return
+			}
+			assert.Contains(t, stdout.String(), "✅ Rendered")
+			written, err := os.ReadFile(outputPath)
+			require.NoError(t, err)
+			assert.Contains(t, string(written), tt.expected)
+		})
+	}
+}
+
+// TestLoadBundle_Values tests that commands loading --config render it with --values
+// WHY: validate, plan and apply must see the bundle render prints, not the template
+func TestLoadBundle_Values(t *testing.T) {
+	// Given: A bundle template and its values
+	savedConfig, savedValues := configFile, valuesFiles
+	t.Cleanup(func() { configFile, valuesFiles = savedConfig, savedValues })
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "cluster.tmpl.yaml")
+	valuesPath := filepath.Join(dir, "staging.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte(renderTestTemplate), 0644))
+	require.NoError(t, os.WriteFile(valuesPath, []byte("node: rsb2\n"), 0644))
+
+	// When: Load the bundle the way commands do
+	configFile, valuesFiles = configPath, []string{valuesPath}
+	bundle, err := loadBundle()
+
+	// Then: The bundle is the rendered one
+	require.NoError(t, err)
+	assert.Equal(t, []string{"rsb2"}, bundle.NodeLabels.Spec.NodeRoles["compute"].Nodes)
+
+	// When/Then: Without the values the template does not load
+	valuesFiles = nil
+	_, err = loadBundle()
+	assert.Error(t, err)
+}
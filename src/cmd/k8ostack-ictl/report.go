@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8ostack-ictl/internal/junit"
+	"k8ostack-ictl/internal/nethealthcheck"
+)
+
+// reportFormatJUnit is the --report format writing connectivity test results as JUnit XML
+const reportFormatJUnit = "junit"
+
+// supportedReportFormats lists the formats --report accepts
+var supportedReportFormats = []string{reportFormatJUnit}
+
+// reportValue backs the repeatable --report <format>=<path> flag
+type reportValue struct {
+	reports *map[string]string
+}
+
+// newReportValue binds --report to reports (format -> path), resetting it like StringVar does
+func newReportValue(reports *map[string]string) *reportValue {
+	*reports = make(map[string]string)
+	return &reportValue{reports: reports}
+}
+
+// Set parses one <format>=<path> argument
+func (v *reportValue) Set(value string) error {
+	format, path, ok := strings.Cut(value, "=")
+	if !ok || path == "" {
+		return fmt.Errorf("must be <format>=<path>, e.g. %s=report.xml", reportFormatJUnit)
+	}
+	supported := false
+	for _, candidate := range supportedReportFormats {
+		supported = supported || candidate == format
+	}
+	if !supported {
+		return fmt.Errorf("unsupported report format '%s', supported: %s", format, strings.Join(supportedReportFormats, ", "))
+	}
+	(*v.reports)[format] = path
+	return nil
+}
+
+// String renders the reports as a comma separated list, sorted by format
+func (v *reportValue) String() string {
+	formats := make([]string, 0, len(*v.reports))
+	for format := range *v.reports {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+
+	reports := make([]string, len(formats))
+	for i, format := range formats {
+		reports[i] = format + "=" + (*v.reports)[format]
+	}
+	return strings.Join(reports, ",")
+}
+
+// Type names the flag argument in help output
+func (v *reportValue) Type() string {
+	return "format=path"
+}
+
+// writeTestReport writes the connectivity test results of a NodeTestConf run as JUnit XML
+func writeTestReport(path, kind string, results *nethealthcheck.TestResults, err error) error {
+	report := junit.NewReport("kictl tests")
+	addTestCases(report.Suite(kind), results, err)
+	return report.WriteFile(path)
+}
@@ -0,0 +1,87 @@
+// Package main provides unit tests for the --report flag
+// WHY: Report paths are given by CI scripts, so malformed arguments must fail before the run starts
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"k8ostack-ictl/internal/nethealthcheck"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestReportValue tests parsing of --report arguments
+// WHY: Only supported formats with a path are accepted, and a repeated format keeps the last path
+func TestReportValue(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		expected    map[string]string
+		expectError string
+	}{
+		{"junit", []string{"junit=report.xml"}, map[string]string{"junit": "report.xml"}, ""},
+		{"repeated_format_keeps_last", []string{"junit=a.xml", "junit=b.xml"}, map[string]string{"junit": "b.xml"}, ""},
+		{"missing_path", []string{"junit="}, nil, "must be <format>=<path>"},
+		{"missing_separator", []string{"report.xml"}, nil, "must be <format>=<path>"},
+		{"unsupported_format", []string{"html=report.html"}, nil, "unsupported report format 'html', supported: junit"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: An unset flag value
+			var reports map[string]string
+			value := newReportValue(&reports)
+
+			// When: Set every argument
+			var err error
+			for _, arg := range tt.args {
+				if err = value.Set(arg); err != nil {
+					break
+				}
+			}
+
+			// Then: Valid arguments are stored, invalid ones rejected
+			if tt.expectError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, reports)
+			assert.Equal(t, "junit="+tt.expected["junit"], value.String())
+		})
+	}
+}
+
+// TestWriteTestReport tests the JUnit report of a connectivity test run
+// WHY: Each test needs its duration, outcome and output, and execution errors must not be dropped
+func TestWriteTestReport(t *testing.T) {
+	// Given: Results with a passed test, a failed test and an execution error
+	results := &nethealthcheck.TestResults{
+		TestExecutions: []nethealthcheck.TestExecution{
+			{TestName: "ping", SourceNode: "rsb2", TargetNode: "rsb3", ExpectSuccess: true, ActualSuccess: true, Duration: 1500 * time.Millisecond, Output: "3 packets received"},
+			{TestName: "api", SourceNode: "rsb3", TargetNode: "rsb2", ExpectSuccess: true, Duration: 2 * time.Second, ErrorMessage: "connection refused"},
+		},
+		Errors: []error{errors.New("test storage: no source pod")},
+	}
+	path := filepath.Join(t.TempDir(), "report.xml")
+
+	// When: Write the report
+	err := writeTestReport(path, "NodeTestConf", results, nil)
+
+	// Then: Every test and the execution error appear as cases
+	require.NoError(t, err)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	report := string(data)
+	assert.Contains(t, report, `<testsuite name="NodeTestConf" tests="3" failures="2" skipped="0" time="3.500">`)
+	assert.Contains(t, report, `<testcase name="ping rsb2 -&gt; rsb3" classname="kictl tests.NodeTestConf" time="1.500">`)
+	assert.Contains(t, report, `<system-out>3 packets received</system-out>`)
+	assert.Contains(t, report, `<failure message="connection refused">`)
+	assert.Contains(t, report, `<failure message="test storage: no source pod">`)
+}
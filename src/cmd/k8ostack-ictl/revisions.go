@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/kubectl"
+	"k8ostack-ictl/internal/labeler"
+	"k8ostack-ictl/internal/logging"
+	"k8ostack-ictl/internal/revision"
+
+	"github.com/spf13/cobra"
+)
+
+// revisionRunner replaces the kubectl of the executor for the revision annotations of nodes in tests
+var revisionRunner kubectl.Runner
+
+// createRevisionsCommand creates the command reporting which bundle revision each node was last brought to
+func createRevisionsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "revisions",
+		Short: "Group the nodes of a bundle by the revision last applied to them and report skew",
+		Long: `Every apply of a whole bundle pins its nodes to the bundle revision, the digest
+of the configuration. This command reads the pins of the bundle's nodes and groups
+them: nodes on the current revision, nodes still on an older one and nodes the
+bundle was never applied to. Exits with an error when nodes are behind, so it can
+gate automation.
+
+Runs narrowed with --target, --only or --skip, or with a kind in dry run, do not
+pin nodes, since they leave part of the bundle unapplied. Runs narrowed with
+--nodes do, which is how stragglers are brought up to date.
+
+Examples:
+  kictl revisions --config cluster-config.yaml
+
+  # Print the apply bringing the stragglers to the current revision
+  kictl revisions --config cluster-config.yaml --print-filter`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if configFile == "" {
+				return fmt.Errorf("configuration file is required. Use --config to specify a YAML file")
+			}
+			printFilter, _ := cmd.Flags().GetBool("print-filter")
+
+			logger, run, err := newRunLogger(cmd, "revisions")
+			if err != nil {
+				return fmt.Errorf("failed to initialize logger: %w", err)
+			}
+			defer closeRun(cmd, logger, run)
+
+			bundle, err := loadBundle()
+			if err != nil {
+				return fmt.Errorf("failed to load configuration: %w", err)
+			}
+			return runRevisions(context.Background(), cmd.OutOrStdout(), logger, bundle, newKubectlExecutor(logger), printFilter)
+		},
+	}
+	cmd.Flags().Bool("print-filter", false, "Print the kictl apply --nodes command bringing the nodes behind to the current revision")
+	return cmd
+}
+
+// revisionKey returns the revision annotation of the bundle, one per owner when it sets spec.ownership
+func revisionKey(bundle *config.ConfigBundle) string {
+	if policy := bundle.GetDefaults().Spec.Ownership; policy != nil {
+		return revision.Key(policy.Owner)
+	}
+	return revision.Key("")
+}
+
+// runRevisions reads the revision pinned on every node of the bundle and renders the skew
+func runRevisions(ctx context.Context, out io.Writer, logger logging.Logger, bundle *config.ConfigBundle, executor kubectl.DryRunExecutor, printFilter bool) error {
+	nodes, err := bundleNodes(ctx, logger, bundle, executor)
+	if err != nil {
+		return fmt.Errorf("failed to resolve the nodes of the bundle: %w", err)
+	}
+
+	key := revisionKey(bundle)
+	revisions := make(map[string]string, len(nodes))
+	var unread []string
+	for _, node := range nodes {
+		success, output, err := executor.GetNodeAnnotations(ctx, node)
+		if err != nil || !success {
+			logger.Warn(fmt.Sprintf("⚠️  Failed to read the revision of node %s: %v", node, err))
+			unread = append(unread, node)
+			continue
+		}
+		if revisions[node], err = revision.FromAnnotations(output, key); err != nil {
+			return fmt.Errorf("node %s: %w", node, err)
+		}
+	}
+
+	skew := revision.NewSkew(bundle.Digest, revisions)
+	renderRevisions(out, skew)
+
+	stragglers := skew.Stragglers()
+	if printFilter && len(stragglers) > 0 {
+		fmt.Fprintf(out, "\nkictl apply --config %s --nodes %s\n", configFile, strings.Join(stragglers, ","))
+	}
+	if len(stragglers) > 0 {
+		return fmt.Errorf("revision skew: %d of %d nodes are not on revision %s", len(stragglers), len(nodes), revision.Short(bundle.Digest))
+	}
+	if len(unread) > 0 {
+		return fmt.Errorf("revision check is incomplete: %d nodes could not be read", len(unread))
+	}
+	return nil
+}
+
+// bundleNodes returns the nodes of the bundle with node patterns and selectors resolved against the cluster
+func bundleNodes(ctx context.Context, logger logging.Logger, bundle *config.ConfigBundle, executor kubectl.DryRunExecutor) ([]string, error) {
+	resolved := *bundle
+	if bundle.HasNodeLabels() {
+		labelingService := labeler.NewService(executor, labeler.Options{Verbose: verbose, Logger: logger})
+		roles, err := labelingService.ResolveNodes(ctx, bundle.NodeLabels)
+		if err != nil {
+			return nil, err
+		}
+		resolved.NodeLabels = bundle.NodeLabels.WithResolvedNodes(roles)
+	}
+	return resolved.GetAllNodeNames(), nil
+}
+
+// renderRevisions prints the nodes grouped by revision, the current revision first
+func renderRevisions(out io.Writer, skew *revision.Skew) {
+	fmt.Fprintf(out, "🔎 Bundle revision %s\n", revision.Short(skew.Current))
+	for _, group := range skew.Groups {
+		switch group.Revision {
+		case skew.Current:
+			fmt.Fprintf(out, "\n📌 Current %s (%d nodes):\n", revision.Short(group.Revision), len(group.Nodes))
+		case "":
+			fmt.Fprintf(out, "\n❔ Never applied (%d nodes):\n", len(group.Nodes))
+		default:
+			fmt.Fprintf(out, "\n⏳ Older %s (%d nodes):\n", revision.Short(group.Revision), len(group.Nodes))
+		}
+		fmt.Fprintf(out, "  %s\n", strings.Join(group.Nodes, ", "))
+	}
+
+	if stragglers := skew.Stragglers(); len(stragglers) > 0 {
+		fmt.Fprintf(out, "\n📋 Skew: %d nodes are not on the current revision.\n", len(stragglers))
+		return
+	}
+	fmt.Fprintln(out, "\n✅ No skew. Every node is on the current revision.")
+}
+
+// pinsRevision reports whether a run applies the whole bundle to its nodes, so they can be pinned to its revision
+// --nodes only chooses the nodes; --target, --only, --skip and kinds in dry run leave part of the bundle unapplied.
+func pinsRevision(bundle *config.ConfigBundle) bool {
+	if bundle.Digest == "" || len(targets) > 0 || len(onlyKinds) > 0 || len(skipKinds) > 0 {
+		return false
+	}
+	if bundle.HasNodeLabels() && bundle.NodeLabels.Tools.Nlabel.DryRun {
+		return false
+	}
+	if bundle.HasVLANs() && bundle.VLANs.Tools.Nvlan.DryRun {
+		return false
+	}
+	if bundle.HasCleanup() && bundle.Cleanup.Tools.Nlabel.DryRun {
+		return false
+	}
+	if bundle.HasSysctls() && bundle.Sysctls.Tools.Nsysctl.DryRun {
+		return false
+	}
+	if bundle.HasStorage() && bundle.Storage.Tools.Nstorage.DryRun {
+		return false
+	}
+	return true
+}
+
+// pinRevision pins the nodes of a run to the bundle revision after an apply, and removes the pin after a delete
+func pinRevision(ctx context.Context, logger logging.Logger, bundle *config.ConfigBundle, executor kubectl.DryRunExecutor, operation string) {
+	value := bundle.Digest
+	if operation == operationDelete {
+		value = ""
+	}
+	run := kubectlRunner(executor, revisionRunner)
+	nodes := bundle.GetAllNodeNames()
+	pinned := 0
+	for _, node := range nodes {
+		if err := revision.Pin(ctx, node, revisionKey(bundle), value, run); err != nil {
+			logger.Warn(fmt.Sprintf("⚠️  %v", err))
+			continue
+		}
+		pinned++
+	}
+	if operation == operationApply {
+		logger.Info(fmt.Sprintf("📌 Pinned %d of %d nodes to revision %s", pinned, len(nodes), revision.Short(value)))
+	}
+}
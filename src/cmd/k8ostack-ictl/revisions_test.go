@@ -0,0 +1,156 @@
+// Package main provides unit tests for the bundle revision report
+// WHY: Nodes left on older revisions must be found and brought up to date with a ready-to-run apply
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/labeler"
+	"k8ostack-ictl/internal/logging"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const testRevision = "sha256:2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae"
+
+// revisionTestBundle returns a bundle labeling rsb2 and rsb3 and adding VLAN 100 to rsb4
+func revisionTestBundle() *config.ConfigBundle {
+	return &config.ConfigBundle{
+		Digest: testRevision,
+		NodeLabels: &config.NodeLabelConf{Spec: config.NodeLabelSpec{NodeRoles: map[string]config.NodeRole{
+			"compute": {Nodes: []string{"rsb2", "rsb3"}, Labels: map[string]string{"zone": "a"}},
+		}}},
+		VLANs: &config.NodeVLANConf{Spec: config.NodeVLANSpec{VLANs: map[string]config.VLANConfig{
+			"storage": {ID: 100, NodeMapping: config.NodeMapping{"rsb4": "10.0.100.4/24"}},
+		}}},
+	}
+}
+
+// TestRunRevisions tests reporting the revision pinned on the nodes of a bundle
+// WHY: Skew fails the command like drift does, and --print-filter names exactly the nodes behind
+func TestRunRevisions(t *testing.T) {
+	tests := []struct {
+		name           string
+		rsb3           string
+		printFilter    bool
+		expectedOutput []string
+		expectError    string
+	}{
+		{
+			name:           "no_skew",
+			rsb3:           `{"kictl.icycloud.io/revision":"` + testRevision + `"}`,
+			expectedOutput: []string{"📌 Current sha256:2c26b46b68ff (3 nodes):\n  rsb2, rsb3, rsb4", "✅ No skew."},
+		},
+		{
+			name:        "skew_with_filter",
+			rsb3:        `{"kictl.icycloud.io/revision":"sha256:fcde2b2edba56bf408601fb721fe9b5c338d10ee429ea04fae5511b68fbf8fb9"}`,
+			printFilter: true,
+			expectedOutput: []string{
+				"⏳ Older sha256:fcde2b2edba5 (1 nodes):\n  rsb3",
+				"📋 Skew: 1 nodes are not on the current revision.",
+				"kictl apply --config cluster-config.yaml --nodes rsb3",
+			},
+			expectError: "revision skew: 1 of 3 nodes are not on revision sha256:2c26b46b68ff",
+		},
+		{
+			name:           "never_applied",
+			rsb3:           `{}`,
+			expectedOutput: []string{"❔ Never applied (1 nodes):\n  rsb3"},
+			expectError:    "revision skew: 1 of 3 nodes are not on revision sha256:2c26b46b68ff",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: rsb2 and rsb4 on the current revision and rsb3 as in the case
+			savedConfig := configFile
+			t.Cleanup(func() { configFile = savedConfig })
+			configFile = "cluster-config.yaml"
+			pinned := `{"kictl.icycloud.io/revision":"` + testRevision + `"}`
+			executor := labeler.NewMockDryRunExecutor()
+			executor.On("GetNodeAnnotations", mock.Anything, "rsb2").Return(true, pinned, nil)
+			executor.On("GetNodeAnnotations", mock.Anything, "rsb3").Return(true, tt.rsb3, nil)
+			executor.On("GetNodeAnnotations", mock.Anything, "rsb4").Return(true, pinned, nil)
+
+			// When: Report the revisions
+			var out bytes.Buffer
+			err := runRevisions(context.Background(), &out, logging.NewRecordingLogger(), revisionTestBundle(), executor, tt.printFilter)
+
+			// Then: The nodes are grouped by revision and skew is an error
+			if tt.expectError != "" {
+				assert.EqualError(t, err, tt.expectError)
+			} else {
+				require.NoError(t, err)
+			}
+			for _, expected := range tt.expectedOutput {
+				assert.Contains(t, out.String(), expected)
+			}
+			if !tt.printFilter {
+				assert.NotContains(t, out.String(), "kictl apply")
+			}
+		})
+	}
+}
+
+// TestPinRevision tests which runs pin their nodes and what they write
+// WHY: A run narrowed to part of the bundle must not mark its nodes as up to date
+func TestPinRevision(t *testing.T) {
+	tests := []struct {
+		name         string
+		only         []string
+		labelsDryRun bool
+		operation    string
+		expected     []string
+	}{
+		{
+			name:      "apply",
+			operation: operationApply,
+			expected: []string{
+				"rsb2 --overwrite kictl.icycloud.io/revision=" + testRevision,
+				"rsb3 --overwrite kictl.icycloud.io/revision=" + testRevision,
+				"rsb4 --overwrite kictl.icycloud.io/revision=" + testRevision,
+			},
+		},
+		{
+			name:      "delete",
+			operation: operationDelete,
+			expected: []string{
+				"rsb2 --overwrite kictl.icycloud.io/revision-",
+				"rsb3 --overwrite kictl.icycloud.io/revision-",
+				"rsb4 --overwrite kictl.icycloud.io/revision-",
+			},
+		},
+		{name: "only_one_kind", only: []string{"vlans"}, operation: operationApply},
+		{name: "labels_in_dry_run", labelsDryRun: true, operation: operationApply},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A bundle and the narrowing of the case
+			savedOnly, savedRunner := onlyKinds, revisionRunner
+			t.Cleanup(func() { onlyKinds, revisionRunner = savedOnly, savedRunner })
+			onlyKinds = tt.only
+			var annotations []string
+			revisionRunner = func(ctx context.Context, stdin []byte, args ...string) (string, error) {
+				annotations = append(annotations, strings.Join(args[len(args)-3:], " "))
+				return "", nil
+			}
+			bundle := revisionTestBundle()
+			bundle.NodeLabels.Tools.Nlabel.DryRun = tt.labelsDryRun
+
+			// When: The run ends without errors
+			if pinsRevision(bundle) {
+				pinRevision(context.Background(), logging.NewRecordingLogger(), bundle, newKubectlExecutor(logging.NewRecordingLogger()), tt.operation)
+			}
+
+			// Then: Only complete runs pin their nodes
+			assert.Equal(t, tt.expected, annotations)
+		})
+	}
+}
@@ -0,0 +1,351 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"k8ostack-ictl/internal/kubectl"
+	"k8ostack-ictl/internal/labeler"
+	"k8ostack-ictl/internal/logging"
+	"k8ostack-ictl/internal/nethealthcheck"
+	"k8ostack-ictl/internal/rundb"
+	"k8ostack-ictl/internal/vlan"
+	"k8ostack-ictl/internal/workspace"
+
+	"github.com/spf13/cobra"
+)
+
+// envRunDB sets the run database when --run-db is not given
+const envRunDB = "KICTL_RUN_DB"
+
+// runDatabasePath returns --run-db, or $KICTL_RUN_DB when it is unset; empty disables the run database
+func runDatabasePath() string {
+	if runDBPath != "" {
+		return runDBPath
+	}
+	return os.Getenv(envRunDB)
+}
+
+// runRecorder collects the timings and node results of a bundle operation for the run database
+// A nil recorder records nothing, so operations call it unconditionally.
+type runRecorder struct {
+	path string
+	run  rundb.Run
+}
+
+// newRunRecorder starts recording a run, or returns nil when no run database is configured
+func newRunRecorder(runID, operation string) *runRecorder {
+	path := runDatabasePath()
+	if path == "" {
+		return nil
+	}
+	return &runRecorder{path: path, run: rundb.Run{
+		ID:         runID,
+		Operation:  operation,
+		ConfigFile: configFile,
+		StartedAt:  time.Now().UTC(),
+	}}
+}
+
+// setDryRun records whether the run changed nothing
+func (r *runRecorder) setDryRun(dryRun bool) {
+	if r != nil {
+		r.run.DryRun = dryRun
+	}
+}
+
+// service records how long a service took and how many errors it added
+func (r *runRecorder) service(kind string, started time.Time, errs int) {
+	if r != nil {
+		r.run.Services = append(r.run.Services, rundb.ServiceTiming{Service: kind, Duration: time.Since(started), Errors: errs})
+	}
+}
+
+// labelResults records the outcome of a labeling service per node
+func (r *runRecorder) labelResults(kind string, results *labeler.OperationResults) {
+	if r == nil || results == nil {
+		return
+	}
+	var nodes []string
+	for _, roleNodes := range results.ResolvedNodes {
+		nodes = append(nodes, roleNodes...)
+	}
+	for node := range results.AppliedLabels {
+		nodes = append(nodes, node)
+	}
+	r.addNodes(kind, nodes, results.Order, results.FailedNodes, results.Errors)
+}
+
+// vlanResults records the outcome of a VLAN service per node
+func (r *runRecorder) vlanResults(kind string, results *vlan.OperationResults) {
+	if r == nil || results == nil {
+		return
+	}
+	var nodes []string
+	for node := range results.ConfiguredVLANs {
+		nodes = append(nodes, node)
+	}
+	r.addNodes(kind, nodes, results.Order, results.FailedNodes, results.Errors)
+}
+
+// testResults records the outcome of the connectivity tests per source node, with their summed durations
+func (r *runRecorder) testResults(kind string, results *nethealthcheck.TestResults) {
+	if r == nil || results == nil {
+		return
+	}
+	byNode := make(map[string]*rundb.NodeResult)
+	var nodes []string
+	for _, execution := range results.TestExecutions {
+		result, ok := byNode[execution.SourceNode]
+		if !ok {
+			result = &rundb.NodeResult{Service: kind, Node: execution.SourceNode, Status: rundb.StatusSucceeded}
+			byNode[execution.SourceNode] = result
+			nodes = append(nodes, execution.SourceNode)
+		}
+		result.Duration += execution.Duration
+		if execution.ActualSuccess != execution.ExpectSuccess {
+			result.Status = rundb.StatusFailed
+			result.Error = joinResultError(result.Error, fmt.Sprintf("%s to %s failed", execution.TestName, execution.TargetNetwork))
+		}
+	}
+	sort.Strings(nodes)
+	for _, node := range nodes {
+		r.run.Nodes = append(r.run.Nodes, *byNode[node])
+	}
+}
+
+// addNodes records one result per node, failed when the service listed it as failed or an error is attributed to it
+// Nodes are stored in the rollout order, so the run history shows which nodes --rollout-order changed first;
+// nodes the rollout did not order follow by name.
+func (r *runRecorder) addNodes(kind string, nodes, order, failedNodes []string, errs []error) {
+	failed := make(map[string]string)
+	for _, node := range failedNodes {
+		failed[node] = ""
+	}
+	for _, err := range errs {
+		if node := kubectl.NodeOf(err); node != "" {
+			failed[node] = joinResultError(failed[node], err.Error())
+		}
+	}
+	for node := range failed {
+		nodes = append(nodes, node)
+	}
+
+	position := make(map[string]int, len(order))
+	for i, node := range order {
+		if _, ok := position[node]; !ok {
+			position[node] = i
+		}
+	}
+	sort.Strings(nodes)
+	sort.SliceStable(nodes, func(i, j int) bool {
+		pi, iOrdered := position[nodes[i]]
+		pj, jOrdered := position[nodes[j]]
+		if iOrdered != jOrdered {
+			return iOrdered
+		}
+		return pi < pj
+	})
+	for i, node := range nodes {
+		if i > 0 && nodes[i-1] == node {
+			continue
+		}
+		result := rundb.NodeResult{Service: kind, Node: node, Status: rundb.StatusSucceeded}
+		if message, isFailed := failed[node]; isFailed {
+			result.Status, result.Error = rundb.StatusFailed, message
+		}
+		r.run.Nodes = append(r.run.Nodes, result)
+	}
+}
+
+// finish stores the run with its outcome; failures are only logged so the run database never fails an operation
+func (r *runRecorder) finish(logger logging.Logger, err error) {
+	if r == nil {
+		return
+	}
+	r.run.Duration = time.Since(r.run.StartedAt)
+	r.run.Status = rundb.StatusSucceeded
+	if err != nil {
+		r.run.Status, r.run.Error = rundb.StatusFailed, err.Error()
+	}
+
+	db, dbErr := rundb.Open(r.path)
+	if dbErr == nil {
+		dbErr = db.SaveRun(r.run)
+		db.Close()
+	}
+	if dbErr != nil {
+		logger.Warn(fmt.Sprintf("⚠️  Failed to record run %s in the run database: %v", r.run.ID, dbErr))
+		return
+	}
+	logger.Debug(fmt.Sprintf("Recorded run %s in %s", r.run.ID, r.path))
+}
+
+// joinResultError appends a message to the error of a node result
+func joinResultError(existing, message string) string {
+	if existing == "" {
+		return message
+	}
+	return existing + "; " + message
+}
+
+// runRecordedOperation runs a bundle operation and records it in the run database when one is configured
+func runRecordedOperation(ctx context.Context, cmd *cobra.Command, logger *logging.FileLogger, run *workspace.Run, operation string) error {
+	recorder := newRunRecorder(run.ID, operation)
+	err := runBundleOperation(ctx, cmd, logger, run, operation, recorder)
+	recorder.finish(logger, err)
+	return err
+}
+
+// openRunDatabase opens the run database for the runs commands
+func openRunDatabase() (*rundb.DB, error) {
+	path := runDatabasePath()
+	if path == "" {
+		return nil, fmt.Errorf("no run database configured: use --run-db or set %s", envRunDB)
+	}
+	return rundb.Open(path)
+}
+
+// createRunsCommand creates the command group for querying the run database
+func createRunsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "runs",
+		Short: "Query recorded runs, per-node results and timings",
+		Long: `Query the run database recorded with --run-db or $KICTL_RUN_DB.
+
+Every apply, delete and verify run stores its outcome, how long each service
+took and the result on each node, so trends are visible without external
+infrastructure.
+
+Examples:
+  kictl runs list --run-db ~/.kictl/runs.db
+  kictl runs list --operation apply --failed --limit 5
+  kictl runs list --node rsb2
+  kictl runs show 20261016-101500`,
+	}
+
+	list := &cobra.Command{
+		Use:   "list",
+		Short: "List recorded runs, newest first",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var filter rundb.ListFilter
+			filter.Operation, _ = cmd.Flags().GetString("operation")
+			filter.Node, _ = cmd.Flags().GetString("node")
+			filter.Failed, _ = cmd.Flags().GetBool("failed")
+			filter.Limit, _ = cmd.Flags().GetInt("limit")
+
+			db, err := openRunDatabase()
+			if err != nil {
+				return err
+			}
+			defer db.Close()
+
+			runs, err := db.ListRuns(filter)
+			if err != nil {
+				return err
+			}
+			printRunList(cmd.OutOrStdout(), runs)
+			return nil
+		},
+	}
+	list.Flags().String("operation", "", "Only list runs of this operation (apply, delete, verify)")
+	list.Flags().String("node", "", "Only list runs with a result on this node")
+	list.Flags().Bool("failed", false, "Only list failed runs")
+	list.Flags().Int("limit", 20, "List at most this many runs; 0 for all")
+
+	show := &cobra.Command{
+		Use:   "show <id>",
+		Short: "Show a run with its service timings and node results",
+		Long:  "Show a run with its service timings and node results. A unique prefix of the run ID is enough.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			db, err := openRunDatabase()
+			if err != nil {
+				return err
+			}
+			defer db.Close()
+
+			run, err := db.GetRun(args[0])
+			if err != nil {
+				return err
+			}
+			printRun(cmd.OutOrStdout(), run)
+			return nil
+		},
+	}
+
+	cmd.AddCommand(list, show)
+	return cmd
+}
+
+// printRunList renders one line per run
+func printRunList(out io.Writer, runs []rundb.Run) {
+	if len(runs) == 0 {
+		fmt.Fprintln(out, "No recorded runs match")
+		return
+	}
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "STARTED\tID\tOPERATION\tSTATUS\tDURATION\tCONFIG")
+	for _, run := range runs {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", run.StartedAt.Local().Format("2006-01-02 15:04:05"), run.ID,
+			runOperationLabel(run), run.Status, run.Duration.Round(time.Millisecond), run.ConfigFile)
+	}
+	w.Flush()
+}
+
+// printRun renders a run with its service timings and node results
+func printRun(out io.Writer, run *rundb.Run) {
+	icon := "✅"
+	if run.Status == rundb.StatusFailed {
+		icon = "❌"
+	}
+	fmt.Fprintf(out, "%s Run %s: %s %s\n", icon, run.ID, runOperationLabel(*run), run.Status)
+	fmt.Fprintf(out, "  Started:  %s\n", run.StartedAt.Local().Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(out, "  Duration: %s\n", run.Duration.Round(time.Millisecond))
+	if run.ConfigFile != "" {
+		fmt.Fprintf(out, "  Config:   %s\n", run.ConfigFile)
+	}
+	if run.Error != "" {
+		fmt.Fprintf(out, "  Error:    %s\n", run.Error)
+	}
+
+	if len(run.Services) > 0 {
+		fmt.Fprintln(out, "\n⏱️  Services:")
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		for _, service := range run.Services {
+			fmt.Fprintf(w, "  %s\t%s\t%d errors\n", service.Service, service.Duration.Round(time.Millisecond), service.Errors)
+		}
+		w.Flush()
+	}
+
+	if len(run.Nodes) > 0 {
+		fmt.Fprintln(out, "\n🖥️  Nodes:")
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		for _, node := range run.Nodes {
+			line := fmt.Sprintf("  %s\t%s\t%s", node.Node, node.Service, node.Status)
+			if node.Duration > 0 {
+				line += "\t" + node.Duration.Round(time.Millisecond).String()
+			}
+			if node.Error != "" {
+				line += "\t" + strings.ReplaceAll(node.Error, "\n", " ")
+			}
+			fmt.Fprintln(w, line)
+		}
+		w.Flush()
+	}
+}
+
+// runOperationLabel names the operation of a run, marking dry runs
+func runOperationLabel(run rundb.Run) string {
+	if run.DryRun {
+		return run.Operation + " (dry run)"
+	}
+	return run.Operation
+}
@@ -0,0 +1,202 @@
+// Package main provides unit tests for the run database recorder and the runs commands
+// WHY: Trend analysis is only as good as what each run records, per node and per service
+package main
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"k8ostack-ictl/internal/kubectl"
+	"k8ostack-ictl/internal/labeler"
+	"k8ostack-ictl/internal/logging"
+	"k8ostack-ictl/internal/nethealthcheck"
+	"k8ostack-ictl/internal/rundb"
+	"k8ostack-ictl/internal/vlan"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRunRecorder_NodeResults tests how service results become per-node results
+// WHY: A node must be recorded as failed whenever its service listed it or attributed an error to it
+func TestRunRecorder_NodeResults(t *testing.T) {
+	tests := []struct {
+		name     string
+		record   func(r *runRecorder)
+		expected []rundb.NodeResult
+	}{
+		{
+			name: "labels",
+			record: func(r *runRecorder) {
+				r.labelResults("NodeLabelConf", &labeler.OperationResults{
+					ResolvedNodes: map[string][]string{"compute": {"rsb3", "rsb2"}},
+					AppliedLabels: map[string][]string{"rsb2": {"role=compute"}},
+					Errors:        []error{kubectl.WrapNodeError("rsb3", errors.New("label failed")), errors.New("unattributed")},
+				})
+			},
+			expected: []rundb.NodeResult{
+				{Service: "NodeLabelConf", Node: "rsb2", Status: rundb.StatusSucceeded},
+				{Service: "NodeLabelConf", Node: "rsb3", Status: rundb.StatusFailed, Error: "label failed"},
+			},
+		},
+		{
+			name: "vlans",
+			record: func(r *runRecorder) {
+				r.vlanResults("NodeVLANConf", &vlan.OperationResults{
+					ConfiguredVLANs: map[string][]vlan.VLANInterfaceInfo{"rsb2": {}},
+					FailedNodes:     []string{"rsb4"},
+				})
+			},
+			expected: []rundb.NodeResult{
+				{Service: "NodeVLANConf", Node: "rsb2", Status: rundb.StatusSucceeded},
+				{Service: "NodeVLANConf", Node: "rsb4", Status: rundb.StatusFailed},
+			},
+		},
+		{
+			name: "rollout_order",
+			record: func(r *runRecorder) {
+				r.vlanResults("NodeVLANConf", &vlan.OperationResults{
+					ConfiguredVLANs: map[string][]vlan.VLANInterfaceInfo{"rsb2": {}, "rsb3": {}, "rsb5": {}},
+					FailedNodes:     []string{"rsb4"},
+					Order:           []string{"rsb3", "rsb5", "rsb2", "rsb3"},
+				})
+			},
+			expected: []rundb.NodeResult{
+				{Service: "NodeVLANConf", Node: "rsb3", Status: rundb.StatusSucceeded},
+				{Service: "NodeVLANConf", Node: "rsb5", Status: rundb.StatusSucceeded},
+				{Service: "NodeVLANConf", Node: "rsb2", Status: rundb.StatusSucceeded},
+				{Service: "NodeVLANConf", Node: "rsb4", Status: rundb.StatusFailed},
+			},
+		},
+		{
+			name: "tests",
+			record: func(r *runRecorder) {
+				r.testResults("NodeTestConf", &nethealthcheck.TestResults{TestExecutions: []nethealthcheck.TestExecution{
+					{TestName: "ping-api", SourceNode: "rsb2", TargetNetwork: "api", ExpectSuccess: true, ActualSuccess: true, Duration: time.Second},
+					{TestName: "ping-mgmt", SourceNode: "rsb2", TargetNetwork: "mgmt", ExpectSuccess: true, ActualSuccess: false, Duration: 2 * time.Second},
+					{TestName: "isolated", SourceNode: "rsb1", TargetNetwork: "storage", ExpectSuccess: false, ActualSuccess: false, Duration: time.Second},
+				}})
+			},
+			expected: []rundb.NodeResult{
+				{Service: "NodeTestConf", Node: "rsb1", Status: rundb.StatusSucceeded, Duration: time.Second},
+				{Service: "NodeTestConf", Node: "rsb2", Status: rundb.StatusFailed, Error: "ping-mgmt to mgmt failed", Duration: 3 * time.Second},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A recorder for an apply run
+			recorder := &runRecorder{run: rundb.Run{ID: "run-1", Operation: operationApply}}
+
+			// When: Record the results of a service
+			tt.record(recorder)
+
+			// Then: Each node appears once with its outcome
+			assert.Equal(t, tt.expected, recorder.run.Nodes)
+		})
+	}
+}
+
+// TestRunRecorder_Disabled tests that nothing is recorded without a run database
+// WHY: The run database is optional, so operations call the recorder unconditionally
+func TestRunRecorder_Disabled(t *testing.T) {
+	// Given: No --run-db and no $KICTL_RUN_DB
+	originalPath := runDBPath
+	defer func() { runDBPath = originalPath }()
+	runDBPath = ""
+	t.Setenv(envRunDB, "")
+
+	// When: Start and use a recorder
+	recorder := newRunRecorder("run-1", operationApply)
+	recorder.setDryRun(true)
+	recorder.service("NodeLabelConf", time.Now(), 0)
+	recorder.labelResults("NodeLabelConf", &labeler.OperationResults{})
+	recorder.finish(logging.NewRecordingLogger(), nil)
+
+	// Then: There is no recorder and nothing fails
+	assert.Nil(t, recorder)
+}
+
+// TestRunsCommands tests listing and showing runs recorded by a run
+// WHY: runs list and runs show are the only way to read the run database back
+func TestRunsCommands(t *testing.T) {
+	// Given: A failed apply recorded in the run database from the environment
+	originalPath, originalConfig := runDBPath, configFile
+	defer func() { runDBPath, configFile = originalPath, originalConfig }()
+	runDBPath, configFile = "", "cluster-config.yaml"
+	dbPath := filepath.Join(t.TempDir(), "runs.db")
+	t.Setenv(envRunDB, dbPath)
+
+	recorder := newRunRecorder("20261016-101500-apply", operationApply)
+	require.NotNil(t, recorder)
+	recorder.service("NodeVLANConf", time.Now(), 1)
+	recorder.vlanResults("NodeVLANConf", &vlan.OperationResults{FailedNodes: []string{"rsb4"}})
+	logger := logging.NewRecordingLogger()
+	recorder.finish(logger, errors.New("operation completed with 1 errors"))
+	require.Empty(t, logger.Messages(logging.LevelWarn))
+
+	tests := []struct {
+		name          string
+		args          []string
+		noDatabase    bool
+		expected      []string
+		expectedError string
+	}{
+		{
+			name:     "list",
+			args:     []string{"runs", "list"},
+			expected: []string{"20261016-101500-apply", "apply", "failed", "cluster-config.yaml"},
+		},
+		{
+			name:     "list_no_match",
+			args:     []string{"runs", "list", "--operation", "delete"},
+			expected: []string{"No recorded runs match"},
+		},
+		{
+			name:     "show_by_prefix",
+			args:     []string{"runs", "show", "20261016"},
+			expected: []string{"❌ Run 20261016-101500-apply: apply failed", "operation completed with 1 errors", "NodeVLANConf", "rsb4"},
+		},
+		{
+			name:          "show_unknown",
+			args:          []string{"runs", "show", "20250101"},
+			expectedError: "no run 20250101 in the run database",
+		},
+		{
+			name:          "no_database",
+			args:          []string{"runs", "list"},
+			noDatabase:    true,
+			expectedError: "no run database configured",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.noDatabase {
+				t.Setenv(envRunDB, "")
+			}
+			rootCmd := createRootCommand()
+			out := new(bytes.Buffer)
+			rootCmd.SetOut(out)
+			rootCmd.SetErr(new(bytes.Buffer))
+			rootCmd.SetArgs(tt.args)
+
+			// When: Run the runs command
+			err := rootCmd.Execute()
+
+			// Then: The recorded run is listed or shown
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			for _, expected := range tt.expected {
+				assert.Contains(t, out.String(), expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"k8ostack-ictl/internal/config"
+
+	"github.com/spf13/cobra"
+)
+
+// loadBundle loads --config, rendered with --values and validated against the JSON Schema of each kind first with --strict-schema
+func loadBundle() (*config.ConfigBundle, error) {
+	return config.LoadMultipleConfigsWithOptions(configFile, loadOptions())
+}
+
+// loadOptions are the options of the global flags for loading configuration bundles
+func loadOptions() config.LoadOptions {
+	return config.LoadOptions{StrictSchema: strictSchema, ValuesFiles: valuesFiles}
+}
+
+// createSchemaCommand creates the command group for the JSON Schemas of the configuration kinds
+func createSchemaCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "schema",
+		Short: "JSON Schemas of the configuration kinds for editors and CI",
+	}
+
+	export := &cobra.Command{
+		Use:   "export",
+		Short: "Print or write the JSON Schema of each configuration kind",
+		Long: `Print the JSON Schema accepting any document of a bundle, or with --kind the
+schema of one kind. With --output-dir, write one <kind>.schema.json per kind plus
+kictl.schema.json for whole bundles.
+
+The schemas reject unknown fields, like --strict-schema does. Point your editor at
+them, e.g. with a "# yaml-language-server: $schema=kictl.schema.json" comment.
+
+Examples:
+  kictl schema export > kictl.schema.json
+  kictl schema export --kind NodeVLANConf
+  kictl schema export --output-dir schemas/`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			kind, _ := cmd.Flags().GetString("kind")
+			outputDir, _ := cmd.Flags().GetString("output-dir")
+
+			if outputDir != "" {
+				return writeSchemas(cmd, outputDir)
+			}
+
+			var schema *config.Schema
+			if kind == "" {
+				schema = config.BundleSchema()
+			} else {
+				var err error
+				if schema, err = config.KindSchema(kind); err != nil {
+					return err
+				}
+			}
+			data, err := marshalSchema(schema)
+			if err != nil {
+				return err
+			}
+			_, err = cmd.OutOrStdout().Write(data)
+			return err
+		},
+	}
+	export.Flags().String("kind", "", "Only print the schema of this kind ("+strings.Join(config.SchemaKinds(), ", ")+")")
+	export.Flags().String("output-dir", "", "Write one schema file per kind and kictl.schema.json into this directory")
+
+	cmd.AddCommand(export)
+	return cmd
+}
+
+// writeSchemas writes the schema of every kind and of whole bundles into a directory
+func writeSchemas(cmd *cobra.Command, dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create schema directory: %w", err)
+	}
+
+	schemas := map[string]*config.Schema{"kictl.schema.json": config.BundleSchema()}
+	for _, kind := range config.SchemaKinds() {
+		schema, err := config.KindSchema(kind)
+		if err != nil {
+			return err
+		}
+		schemas[strings.ToLower(kind)+".schema.json"] = schema
+	}
+
+	for name, schema := range schemas {
+		data, err := marshalSchema(schema)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			return fmt.Errorf("failed to write schema %s: %w", name, err)
+		}
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "📝 Wrote %d schemas to %s\n", len(schemas), dir)
+	return nil
+}
+
+// marshalSchema renders a schema as indented JSON
+func marshalSchema(schema *config.Schema) ([]byte, error) {
+	data, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal schema: %w", err)
+	}
+	return append(data, '\n'), nil
+}
@@ -0,0 +1,88 @@
+// Package main provides unit tests for the schema command
+// WHY: Editors and CI consume the exported files, so their names and content must be stable
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSchemaExport tests printing and writing the JSON Schemas
+// WHY: Each kind needs its own file, and an unknown kind must fail instead of printing nothing
+func TestSchemaExport(t *testing.T) {
+	tests := []struct {
+		name          string
+		args          []string
+		expectedTitle string
+		expectedFiles []string
+		expectedError string
+	}{
+		{
+			name:          "bundle_schema",
+			args:          []string{"schema", "export"},
+			expectedTitle: "kictl configuration",
+		},
+		{
+			name:          "one_kind",
+			args:          []string{"schema", "export", "--kind", "NodeVLANConf"},
+			expectedTitle: "NodeVLANConf",
+		},
+		{
+			name: "output_dir",
+			args: []string{"schema", "export", "--output-dir"},
+			expectedFiles: []string{"cleanupconf.schema.json", "defaults.schema.json", "kictl.schema.json",
+				"nodelabelconf.schema.json", "nodestorageconf.schema.json", "nodesysctlconf.schema.json",
+				"nodetestconf.schema.json", "nodevlanconf.schema.json"},
+		},
+		{
+			name:          "unknown_kind",
+			args:          []string{"schema", "export", "--kind", "NodeRouteConf"},
+			expectedError: "unsupported config kind 'NodeRouteConf'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: The root command
+			dir := filepath.Join(t.TempDir(), "schemas")
+			args := tt.args
+			if tt.expectedFiles != nil {
+				args = append(args, dir)
+			}
+			rootCmd := createRootCommand()
+			out := new(bytes.Buffer)
+			rootCmd.SetOut(out)
+			rootCmd.SetErr(new(bytes.Buffer))
+			rootCmd.SetArgs(args)
+
+			// When: Export the schemas
+			err := rootCmd.Execute()
+
+			// Then: The schema is printed, or one file per kind is written
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			if tt.expectedFiles != nil {
+				entries, err := os.ReadDir(dir)
+				require.NoError(t, err)
+				var names []string
+				for _, entry := range entries {
+					names = append(names, entry.Name())
+				}
+				assert.Equal(t, tt.expectedFiles, names)
+				return
+			}
+			var schema map[string]interface{}
+			require.NoError(t, json.Unmarshal(out.Bytes(), &schema))
+			assert.Equal(t, tt.expectedTitle, schema["title"])
+		})
+	}
+}
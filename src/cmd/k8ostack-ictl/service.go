@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/logging"
+)
+
+// serviceContext bounds a whole service run by its tool's serviceTimeout, on top of per-command timeouts
+func serviceContext(ctx context.Context, tool config.ToolConfig) (context.Context, context.CancelFunc) {
+	if tool.ServiceTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, tool.ServiceTimeout)
+}
+
+// serviceTimedOut reports whether serviceCtx ended because of the service's own timeout rather than the parent's
+func serviceTimedOut(ctx, serviceCtx context.Context) bool {
+	return ctx.Err() == nil && errors.Is(serviceCtx.Err(), context.DeadlineExceeded)
+}
+
+// serviceTimeoutError describes a service the orchestrator aborted after its serviceTimeout
+func serviceTimeoutError(kind string, tool config.ToolConfig) error {
+	return fmt.Errorf("%s did not finish within serviceTimeout %s: %w", kind, tool.ServiceTimeout, context.DeadlineExceeded)
+}
+
+// skipAfterAbort reports whether a service is skipped because an earlier one failed under failurePolicy abort
+func skipAfterAbort(logger logging.Logger, abortedBy, kind string) bool {
+	if abortedBy == "" {
+		return false
+	}
+	logger.Warn(fmt.Sprintf("⏭️  Skipping %s: %s failed and its failurePolicy is %s", kind, abortedBy, config.FailurePolicyAbort))
+	return true
+}
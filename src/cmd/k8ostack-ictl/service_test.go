@@ -0,0 +1,96 @@
+// Package main provides unit tests for service-level timeouts and failure policies
+// WHY: A service timeout must be told apart from the command being cancelled, and aborts must skip later services
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/logging"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestServiceContext tests bounding a service run by its serviceTimeout
+// WHY: Only the service's own deadline counts as a service timeout, a cancelled parent does not
+func TestServiceContext(t *testing.T) {
+	tests := []struct {
+		name             string
+		timeout          time.Duration
+		cancelParent     bool
+		expectedTimedOut bool
+	}{
+		{"timeout_expires", time.Millisecond, false, true},
+		{"no_timeout", 0, false, false},
+		{"parent_cancelled", time.Millisecond, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A parent context and a service context with the timeout
+			ctx, cancelParent := context.WithCancel(context.Background())
+			defer cancelParent()
+			serviceCtx, cancel := serviceContext(ctx, config.ToolConfig{ServiceTimeout: tt.timeout})
+			defer cancel()
+
+			// When: The service runs past its timeout
+			if tt.cancelParent {
+				cancelParent()
+			}
+			time.Sleep(5 * time.Millisecond)
+
+			// Then: Only the service's own deadline reports a timeout
+			assert.Equal(t, tt.expectedTimedOut, serviceTimedOut(ctx, serviceCtx))
+		})
+	}
+}
+
+// TestServiceTimeoutError tests the error reported for a timed out service
+// WHY: The summary must name the service and its limit, and callers can still match the deadline
+func TestServiceTimeoutError(t *testing.T) {
+	// Given: A VLAN tool with a 20 minute service timeout
+	tool := config.ToolConfig{ServiceTimeout: 20 * time.Minute}
+
+	// When: Build the timeout error
+	err := serviceTimeoutError("NodeVLANConf", tool)
+
+	// Then: It names the service and wraps the deadline
+	require.Error(t, err)
+	assert.Equal(t, "NodeVLANConf did not finish within serviceTimeout 20m0s: context deadline exceeded", err.Error())
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+}
+
+// TestSkipAfterAbort tests skipping services after an aborting failure
+// WHY: Services run only while no earlier service failed under failurePolicy abort
+func TestSkipAfterAbort(t *testing.T) {
+	tests := []struct {
+		name      string
+		abortedBy string
+		expected  bool
+	}{
+		{"nothing_aborted", "", false},
+		{"vlans_aborted", "NodeVLANConf", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A logger and the aborting service, if any
+			logger := logging.NewRecordingLogger()
+
+			// When: Check whether the test service is skipped
+			skipped := skipAfterAbort(logger, tt.abortedBy, "NodeTestConf")
+
+			// Then: It is skipped, with a warning naming the aborting service, only after an abort
+			assert.Equal(t, tt.expected, skipped)
+			if tt.expected {
+				assert.Equal(t, []string{"⏭️  Skipping NodeTestConf: NodeVLANConf failed and its failurePolicy is abort"}, logger.Messages(logging.LevelWarn))
+			} else {
+				assert.Empty(t, logger.Messages(logging.LevelWarn))
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"k8ostack-ictl/internal/config/precedence"
+	"k8ostack-ictl/internal/settings"
+	"k8ostack-ictl/internal/ui"
+	"k8ostack-ictl/internal/workspace"
+
+	"github.com/spf13/cobra"
+)
+
+// settingsFlags are the global flags the user settings file set for this run, listed by --explain-config
+var settingsFlags []string
+
+// settingsPath returns the user settings file in the default workspace, which --workspace does not move
+func settingsPath() (string, error) {
+	root, err := workspace.DefaultRoot()
+	if err != nil {
+		return "", err
+	}
+	return settings.Path(root), nil
+}
+
+// loadSettings reads the user settings file
+func loadSettings() (*settings.Settings, error) {
+	path, err := settingsPath()
+	if err != nil {
+		return nil, err
+	}
+	return settings.Load(path)
+}
+
+// applyUserSettings fills in the global flags that neither the command line nor the environment set from the settings file
+func applyUserSettings(cmd *cobra.Command) error {
+	settingsFlags = nil
+	userSettings, err := loadSettings()
+	if err != nil {
+		return err
+	}
+
+	values := userSettings.Defaults.Flags()
+	// $KICTL_HOME already chose the workspace
+	if _, set := os.LookupEnv(workspace.EnvHome); set {
+		delete(values, "workspace")
+	}
+	// As a kubectl plugin, the cluster is the one kubectl would use: flags, then $KUBECONFIG and its current context
+	if pluginMode {
+		delete(values, "kubeconfig")
+		delete(values, "context")
+	}
+	settingsFlags, err = precedence.ApplySettings(cmd, values)
+	return err
+}
+
+// printSettingsFlags lists the flags taken from the settings file when --explain-config is set
+func printSettingsFlags(out io.Writer) {
+	if !explainConfig || len(settingsFlags) == 0 {
+		return
+	}
+	path, _ := settingsPath()
+	fmt.Fprintf(out, "⚙️  Flags from the %s %s:\n", precedence.SourceSettings, path)
+	for _, name := range settingsFlags {
+		fmt.Fprintf(out, "  --%s\n", name)
+	}
+}
+
+// validateColorMode checks --color
+func validateColorMode(mode string) error {
+	for _, valid := range settings.ColorModes {
+		if mode == valid {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid --color '%s'. Expected: %s", mode, strings.Join(settings.ColorModes, ", "))
+}
+
+// validateOutputFlags checks --quiet against --verbose and applies --no-color, which wins over --color and the settings file
+func validateOutputFlags() error {
+	if quiet && verbose {
+		return fmt.Errorf("--quiet and --verbose cannot be used together")
+	}
+	if noColor {
+		colorMode = settings.ColorNever
+	}
+	return nil
+}
+
+// consoleOptions returns the console output flags of the command: --quiet and --color
+func consoleOptions() ui.Options {
+	return ui.Options{Quiet: quiet, Color: colorMode}
+}
+
+// colorEnabled reports whether output to w is colored, following --color, --no-color and NO_COLOR
+func colorEnabled(w io.Writer) bool {
+	return consoleOptions().Colored(w)
+}
+
+// expandAlias replaces an alias of the settings file in the first argument by its arguments, printing what runs
+// A broken settings file leaves the arguments alone; the command reports it when it starts.
+func expandAlias(root *cobra.Command, args []string) ([]string, error) {
+	userSettings, err := loadSettings()
+	if err != nil || len(args) == 0 {
+		return args, nil
+	}
+	if _, isAlias := userSettings.Aliases[args[0]]; !isAlias {
+		return args, nil
+	}
+	for _, command := range root.Commands() {
+		if command.Name() == args[0] || command.HasAlias(args[0]) {
+			return nil, fmt.Errorf("alias %s in the %s shadows the kictl command of the same name; rename it", args[0], precedence.SourceSettings)
+		}
+	}
+
+	expanded, _, err := userSettings.Expand(args)
+	if err != nil {
+		return nil, err
+	}
+	shown := make([]string, len(expanded))
+	for i, word := range expanded {
+		shown[i] = word
+		if word == "" || strings.ContainsAny(word, " \t'\"") {
+			shown[i] = strconv.Quote(word)
+		}
+	}
+	fmt.Fprintf(root.ErrOrStderr(), "🔁 %s: kictl %s\n", args[0], strings.Join(shown, " "))
+	return expanded, nil
+}
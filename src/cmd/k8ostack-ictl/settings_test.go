@@ -0,0 +1,177 @@
+// Package main provides unit tests for the user settings file
+// WHY: Operators rely on the settings file instead of retyping flags, so every command must pick it up
+package main
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"k8ostack-ictl/internal/settings"
+	"k8ostack-ictl/internal/workspace"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestUserSettings tests flag defaults from the settings file at startup
+// WHY: The settings file fills in unset flags, flags typed on the command line win, and a broken file stops the run
+func TestUserSettings(t *testing.T) {
+	tests := []struct {
+		name            string
+		content         string
+		args            []string
+		expectedContext string
+		expectedWorkers int
+		expectedColor   string
+		expectError     string
+	}{
+		{
+			name:            "no_settings_file",
+			expectedWorkers: 1,
+			expectedColor:   settings.ColorAuto,
+		},
+		{
+			name:            "defaults_fill_in_flags",
+			content:         "defaults:\n  context: lab\n  maxParallelism: 8\n  color: never\n",
+			expectedContext: "lab",
+			expectedWorkers: 8,
+			expectedColor:   settings.ColorNever,
+		},
+		{
+			name:            "command_line_wins",
+			content:         "defaults:\n  context: lab\n  maxParallelism: 8\n",
+			args:            []string{"--context", "prod", "--max-parallelism", "2"},
+			expectedContext: "prod",
+			expectedWorkers: 2,
+			expectedColor:   settings.ColorAuto,
+		},
+		{
+			name:        "invalid_settings",
+			content:     "defaults:\n  logFormat: xml\n",
+			expectError: "xml",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A default workspace with or without a settings file
+			home := t.TempDir()
+			t.Setenv(workspace.EnvHome, home)
+			if tt.content != "" {
+				require.NoError(t, os.WriteFile(settings.Path(home), []byte(tt.content), 0644))
+			}
+
+			// When: Run a command that needs no cluster
+			root := createRootCommand()
+			root.SetOut(new(bytes.Buffer))
+			root.SetErr(new(bytes.Buffer))
+			root.SetArgs(append([]string{"schema", "export", "--kind", "Defaults"}, tt.args...))
+			err := root.Execute()
+
+			// Then: The flags hold the settings unless the command line set them
+			if tt.expectError != "" {
+				assert.ErrorContains(t, err, tt.expectError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedContext, kubeContext)
+			assert.Equal(t, tt.expectedWorkers, maxParallelism)
+			assert.Equal(t, tt.expectedColor, colorMode)
+		})
+	}
+}
+
+// TestExpandAlias tests expanding aliases of the settings file before the command line is parsed
+// WHY: Aliases save typing but must never silently replace a kictl command, and must show what they run
+func TestExpandAlias(t *testing.T) {
+	tests := []struct {
+		name           string
+		args           []string
+		expected       []string
+		expectedStderr string
+		expectError    string
+	}{
+		{
+			name:           "alias_with_extra_arguments",
+			args:           []string{"prod-verify", "--nodes", "rsb2"},
+			expected:       []string{"--config", "prod.yaml", "verify", "--selector", "zone in (a)", "--nodes", "rsb2"},
+			expectedStderr: "🔁 prod-verify: kictl --config prod.yaml verify --selector \"zone in (a)\" --nodes rsb2\n",
+		},
+		{
+			name:     "command",
+			args:     []string{"plan", "--config", "lab.yaml"},
+			expected: []string{"plan", "--config", "lab.yaml"},
+		},
+		{
+			name:        "alias_shadowing_a_command",
+			args:        []string{"apply"},
+			expectError: "alias apply in the settings file shadows the kictl command of the same name",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A settings file with aliases
+			home := t.TempDir()
+			t.Setenv(workspace.EnvHome, home)
+			require.NoError(t, os.WriteFile(settings.Path(home), []byte(
+				"aliases:\n  prod-verify: --config prod.yaml verify --selector 'zone in (a)'\n  apply: --config prod.yaml apply\n"), 0644))
+			root := createRootCommand()
+			var stderr bytes.Buffer
+			root.SetErr(&stderr)
+
+			// When: Expand the arguments
+			expanded, err := expandAlias(root, tt.args)
+
+			// Then: Aliases become their arguments and the expansion is shown
+			if tt.expectError != "" {
+				assert.ErrorContains(t, err, tt.expectError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, expanded)
+			assert.Equal(t, tt.expectedStderr, stderr.String())
+		})
+	}
+}
+
+// TestValidateOutputFlags tests --quiet and --no-color
+// WHY: --no-color must win over a color from the settings file, and quiet verbose output makes no sense
+func TestValidateOutputFlags(t *testing.T) {
+	tests := []struct {
+		name          string
+		quiet         bool
+		verbose       bool
+		noColor       bool
+		color         string
+		expectedColor string
+		expectError   string
+	}{
+		{name: "defaults", color: settings.ColorAuto, expectedColor: settings.ColorAuto},
+		{name: "no_color_wins", noColor: true, color: settings.ColorAlways, expectedColor: settings.ColorNever},
+		{name: "quiet", quiet: true, color: settings.ColorAuto, expectedColor: settings.ColorAuto},
+		{name: "quiet_and_verbose", quiet: true, verbose: true, color: settings.ColorAuto, expectError: "--quiet and --verbose cannot be used together"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: The output flags of the case
+			savedQuiet, savedVerbose, savedNoColor, savedColor := quiet, verbose, noColor, colorMode
+			t.Cleanup(func() { quiet, verbose, noColor, colorMode = savedQuiet, savedVerbose, savedNoColor, savedColor })
+			quiet, verbose, noColor, colorMode = tt.quiet, tt.verbose, tt.noColor, tt.color
+
+			// When: Validate them
+			err := validateOutputFlags()
+
+			// Then: Contradictions fail and --no-color turns color off
+			if tt.expectError != "" {
+				assert.EqualError(t, err, tt.expectError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedColor, colorMode)
+			assert.False(t, colorEnabled(&bytes.Buffer{}))
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/kubectl"
+	"k8ostack-ictl/internal/logging"
+	"k8ostack-ictl/internal/soak"
+)
+
+// soakRunner replaces the kubectl of the executor reading node conditions during a soak in tests
+var soakRunner kubectl.Runner
+
+// soaking reports whether --soak or --soak-tests asks for a soak after every batch
+func soaking() bool {
+	return soakDuration > 0 || len(soakTests) > 0
+}
+
+// soakSmokeTests returns a copy of the bundle holding only the --soak-tests tests, or nil without --soak-tests
+// It runs before --target and --kinds narrow the bundle, so a run changing only VLANs can still smoke test them.
+func soakSmokeTests(bundle *config.ConfigBundle) (*config.ConfigBundle, error) {
+	if len(soakTests) == 0 {
+		return nil, nil
+	}
+	if !bundle.HasTests() {
+		return nil, fmt.Errorf("--soak-tests needs a NodeTestConf in the configuration")
+	}
+
+	byName := make(map[string]config.ConnectivityTest, len(bundle.Tests.Spec.Tests))
+	for _, test := range bundle.Tests.Spec.Tests {
+		byName[test.Name] = test
+	}
+	tests := *bundle.Tests
+	tests.Spec.Tests = nil
+	var unknown []string
+	for _, name := range soakTests {
+		test, ok := byName[name]
+		if !ok {
+			unknown = append(unknown, name)
+			continue
+		}
+		tests.Spec.Tests = append(tests.Spec.Tests, test)
+	}
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("--soak-tests names tests missing from %s: %s", bundle.Tests.Metadata.Name, strings.Join(unknown, ", "))
+	}
+
+	smoke := *bundle
+	smoke.Tests = &tests
+	return &smoke, nil
+}
+
+// rolloutSoak returns the soak run after every batch of a service, or nil without --soak and --soak-tests
+// Dry runs change nothing worth watching, so they get no soak either.
+func rolloutSoak(ctx context.Context, logger logging.Logger, bundle, smoke *config.ConfigBundle, dryRun bool) func(batch []string) error {
+	if !soaking() || dryRun {
+		return nil
+	}
+
+	options := soak.Options{Duration: soakDuration, Logger: logger}
+	if smoke != nil {
+		suite := newTestSuite(logger, smoke)
+		options.Smoke = func(ctx context.Context, batch []string) error {
+			results, err := suite(ctx)
+			if err != nil {
+				return err
+			}
+			if len(results.Errors) > 0 {
+				messages := make([]string, 0, len(results.Errors))
+				for _, testErr := range results.Errors {
+					messages = append(messages, testErr.Error())
+				}
+				return errors.New(strings.Join(messages, "; "))
+			}
+			return nil
+		}
+	}
+
+	watcher := soak.New(kubectlRunner(newBundleExecutor(logger, bundle.GetDefaults()), soakRunner), options)
+	return func(batch []string) error {
+		return watcher.Soak(ctx, batch)
+	}
+}
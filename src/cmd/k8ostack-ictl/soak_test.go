@@ -0,0 +1,124 @@
+// Package main provides unit tests for the soak run between rollout batches
+// WHY: The smoke tests must be the ones the operator named, and a dry run must never wait out a soak
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/logging"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// soakTestBundle returns a bundle with two connectivity tests
+func soakTestBundle() *config.ConfigBundle {
+	return config.NewSingleConfigBundle(&config.NodeTestConf{
+		Kind:     "NodeTestConf",
+		Metadata: config.Metadata{Name: "smoke"},
+		Spec: config.NodeTestSpec{Tests: []config.ConnectivityTest{
+			{Name: "api-ping", Type: config.TestTypePing, Source: "api", Targets: []string{"api"}},
+			{Name: "storage-ping", Type: config.TestTypePing, Source: "storage", Targets: []string{"storage"}},
+		}},
+	})
+}
+
+// TestSoakSmokeTests_Unit tests selecting the --soak-tests tests of a bundle
+// WHY: A misspelled test name would otherwise soak without the smoke test the operator relies on
+func TestSoakSmokeTests_Unit(t *testing.T) {
+	saved := soakTests
+	defer func() { soakTests = saved }()
+
+	tests := []struct {
+		name          string
+		soakTests     []string
+		bundle        *config.ConfigBundle
+		expectedTests []string
+		expectedErr   string
+	}{
+		{name: "no_smoke_tests", bundle: soakTestBundle()},
+		{name: "named_tests", soakTests: []string{"storage-ping"}, bundle: soakTestBundle(), expectedTests: []string{"storage-ping"}},
+		{name: "unknown_test", soakTests: []string{"storage-png"}, bundle: soakTestBundle(), expectedErr: "--soak-tests names tests missing from smoke: storage-png"},
+		{name: "no_test_conf", soakTests: []string{"api-ping"}, bundle: config.NewEmptyBundle(), expectedErr: "--soak-tests needs a NodeTestConf in the configuration"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: The --soak-tests of the case
+			soakTests = tt.soakTests
+
+			// When: Select the smoke tests
+			smoke, err := soakSmokeTests(tt.bundle)
+
+			// Then: Only the named tests are kept, and the bundle itself is left alone
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			if tt.expectedTests == nil {
+				assert.Nil(t, smoke)
+				return
+			}
+			var names []string
+			for _, test := range smoke.Tests.Spec.Tests {
+				names = append(names, test.Name)
+			}
+			assert.Equal(t, tt.expectedTests, names)
+			assert.Len(t, tt.bundle.Tests.Spec.Tests, 2)
+		})
+	}
+}
+
+// TestRolloutSoak_Unit tests the soak handed to the worker pool of a service
+// WHY: A NotReady node must abort the rollout, while dry runs and runs without --soak skip the soak entirely
+func TestRolloutSoak_Unit(t *testing.T) {
+	savedDuration, savedTests, savedRunner := soakDuration, soakTests, soakRunner
+	defer func() { soakDuration, soakTests, soakRunner = savedDuration, savedTests, savedRunner }()
+
+	tests := []struct {
+		name        string
+		duration    time.Duration
+		dryRun      bool
+		ready       string
+		expectSoak  bool
+		expectedErr string
+	}{
+		{name: "no_soak", ready: "True"},
+		{name: "dry_run", duration: time.Millisecond, dryRun: true, ready: "True"},
+		{name: "ready_batch", duration: time.Millisecond, ready: "True", expectSoak: true},
+		{name: "not_ready_batch", duration: time.Millisecond, ready: "False", expectSoak: true, expectedErr: "node rsb3 is not Ready (False) during the soak"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: --soak and a kubectl reporting rsb3 with the Ready status of the case
+			soakDuration, soakTests = tt.duration, nil
+			soakRunner = func(ctx context.Context, stdin []byte, args ...string) (string, error) {
+				if args[2] == "rsb3" {
+					return tt.ready, nil
+				}
+				return "True", nil
+			}
+
+			// When: Create the soak and run it on a batch
+			soak := rolloutSoak(context.Background(), logging.NewRecordingLogger(), config.NewEmptyBundle(), nil, tt.dryRun)
+
+			// Then: Only real runs with --soak get one, failing on the NotReady node
+			if !tt.expectSoak {
+				assert.Nil(t, soak)
+				return
+			}
+			require.NotNil(t, soak)
+			err := soak([]string{"rsb2", "rsb3"})
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/logging"
+
+	"github.com/spf13/cobra"
+)
+
+// confirmZapFlagUsage is the help text of --confirm-zap on every command accepting it
+const confirmZapFlagUsage = "Confirm zapping this many storage devices without asking; must match the devices marked zap"
+
+// confirmZap asks before an apply may wipe the devices a NodeStorageConf marks zap
+// The operator types "zap <n> devices", or passes --confirm-zap <n> when nobody is at the terminal; a count that
+// does not match refuses the run, so a bundle gaining zap devices is never confirmed by an old pipeline flag.
+// It reports whether zapping is confirmed, false when the bundle zaps nothing.
+func confirmZap(cmd *cobra.Command, logger logging.Logger, bundle *config.ConfigBundle) (bool, error) {
+	if !bundle.HasStorage() || bundle.Storage.Tools.Nstorage.DryRun {
+		return false, nil
+	}
+	devices := bundle.Storage.Spec.ZapDevices()
+	if len(devices) == 0 {
+		return false, nil
+	}
+
+	out := cmd.OutOrStdout()
+	fmt.Fprintf(out, "💥 %s may wipe %d devices that hold data; everything on them is destroyed:\n", bundle.Storage.Metadata.Name, len(devices))
+	for _, device := range devices {
+		fmt.Fprintf(out, "  - %s on node %s\n", device.Path, device.Node)
+	}
+
+	if confirmZapCount != 0 {
+		if confirmZapCount != len(devices) {
+			return false, fmt.Errorf("--confirm-zap %d does not match the %d devices marked zap", confirmZapCount, len(devices))
+		}
+		logger.Warn(fmt.Sprintf("💥 Zapping %d devices confirmed by --confirm-zap", len(devices)))
+		return true, nil
+	}
+
+	phrase := fmt.Sprintf("zap %d devices", len(devices))
+	fmt.Fprintf(out, "⚠️  Type '%s' to continue: ", phrase)
+	answer, _ := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+	if strings.TrimSpace(answer) != phrase {
+		fmt.Fprintln(out)
+		return false, fmt.Errorf("zapping %d devices not confirmed; nothing was changed", len(devices))
+	}
+	logger.Warn(fmt.Sprintf("💥 Zapping %d devices confirmed", len(devices)))
+	return true, nil
+}
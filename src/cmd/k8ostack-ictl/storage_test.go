@@ -0,0 +1,69 @@
+// Package main provides unit tests for the zap confirmation of storage devices
+// WHY: Zapping destroys data, so an apply must never wipe a device on a stray answer or an outdated pipeline flag
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/logging"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestConfirmZap tests the confirmation asked before devices marked zap may be wiped
+// WHY: The count of devices must be confirmed exactly, by typing it or by --confirm-zap
+func TestConfirmZap(t *testing.T) {
+	tests := []struct {
+		name        string
+		answer      string
+		count       int
+		dryRun      bool
+		expectAllow bool
+		expectError string
+	}{
+		{name: "typed_phrase", answer: "zap 2 devices\n", expectAllow: true},
+		{name: "typed_yes", answer: "y\n", expectError: "zapping 2 devices not confirmed"},
+		{name: "flag_count", count: 2, expectAllow: true},
+		{name: "flag_outdated_count", count: 1, expectError: "--confirm-zap 1 does not match the 2 devices marked zap"},
+		{name: "dry_run", dryRun: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A bundle zapping a device on rsb2 and rsb3, and an empty device on rsb3
+			cmd := createRootCommand()
+			var out bytes.Buffer
+			cmd.SetOut(&out)
+			cmd.SetIn(strings.NewReader(tt.answer))
+			saved := confirmZapCount
+			t.Cleanup(func() { confirmZapCount = saved })
+			confirmZapCount = tt.count
+
+			storageConf := &config.NodeStorageConf{Metadata: config.Metadata{Name: "ceph-osds"}, Spec: config.NodeStorageSpec{Nodes: map[string]config.StorageNode{
+				"rsb2": {Devices: []config.StorageDevice{{Path: "/dev/sdb", Zap: true}}},
+				"rsb3": {Devices: []config.StorageDevice{{Path: "/dev/sdb", Zap: true}, {Path: "/dev/sdc"}}},
+			}}}
+			storageConf.Tools.Nstorage.DryRun = tt.dryRun
+			bundle := config.NewSingleConfigBundle(storageConf)
+
+			// When: Ask for confirmation
+			allow, err := confirmZap(cmd, logging.NewRecordingLogger(), bundle)
+
+			// Then: Zapping is only allowed for the exact count
+			assert.Equal(t, tt.expectAllow, allow)
+			if tt.expectError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectError)
+				return
+			}
+			require.NoError(t, err)
+			if !tt.dryRun {
+				assert.Contains(t, out.String(), "  - /dev/sdb on node rsb3\n")
+			}
+		})
+	}
+}
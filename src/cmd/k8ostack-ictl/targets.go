@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/logging"
+
+	"github.com/spf13/cobra"
+)
+
+// targetFlagUsage is the help text of --target on every command accepting it
+const targetFlagUsage = "Only act on the items at this resource address or below it, e.g. vlan.management.rsb2 (repeatable, see 'kictl addresses')"
+
+// targetBundle restricts the bundle to the items addressed by --target, returning it unchanged without targets
+func targetBundle(logger logging.Logger, bundle *config.ConfigBundle) (*config.ConfigBundle, error) {
+	if len(targets) == 0 {
+		return bundle, nil
+	}
+	addresses, err := config.ParseResourceAddresses(targets)
+	if err != nil {
+		return nil, err
+	}
+	targeted, err := bundle.Target(addresses)
+	if err != nil {
+		return nil, fmt.Errorf("%w; list the addresses with 'kictl addresses --config %s'", err, configFile)
+	}
+
+	logger.Info(fmt.Sprintf("🎯 Targeting %d of %d items: %s",
+		len(targeted.ResourceAddresses()), len(bundle.ResourceAddresses()), strings.Join(targets, ", ")))
+	return targeted, nil
+}
+
+// createAddressesCommand creates the command that lists the resource address of every item in the bundle
+func createAddressesCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "addresses [address...]",
+		Short: "List the resource addresses of the bundle for --target",
+		Long: `List the stable address of every item in the bundle, one per line:
+
+  cleanup.<label prefix>               label prefix removed by a CleanupConf
+  nodelabel.<role>.<node>/<label key>  label of a role on one node
+  nodelabel.<role>/<label key>         label of a role matching nodes by nodeSelector
+  vlan.<vlan>.<node>                   VLAN interface on one node
+  test.<name>                          connectivity test
+
+Pass an address to --target of apply, delete or plan to act on that item only.
+An address without its trailing segments takes in everything below it, and a
+node name takes in roles listing it by pattern. With arguments, only the items
+those addresses take in are listed, exactly as a run with --target would see them.
+
+Examples:
+  kictl addresses --config cluster-config.yaml
+  kictl addresses --config cluster-config.yaml vlan.management
+  kictl apply --config cluster-config.yaml --target vlan.management.rsb2`,
+		ValidArgsFunction: completeFromBundle(targetCompletions),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if configFile == "" {
+				return fmt.Errorf("configuration file is required. Use --config to specify a YAML file")
+			}
+
+			bundle, err := loadBundle()
+			if err != nil {
+				return fmt.Errorf("failed to load configuration: %w", err)
+			}
+			if len(args) > 0 {
+				addresses, err := config.ParseResourceAddresses(args)
+				if err != nil {
+					return err
+				}
+				if bundle, err = bundle.Target(addresses); err != nil {
+					return err
+				}
+			}
+
+			for _, address := range bundle.ResourceAddresses() {
+				fmt.Fprintln(cmd.OutOrStdout(), address)
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,103 @@
+// Package main provides unit tests for resource addresses on the command line
+// WHY: Users copy addresses from 'kictl addresses' into --target, so both must agree on every item
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAddressesCommand tests listing the resource addresses of a bundle
+// WHY: The listing must name every item, and with addresses only what a run with those targets would see
+func TestAddressesCommand(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		expected    string
+		expectError string
+	}{
+		{
+			name:     "all_items",
+			expected: "nodelabel.compute.rsb3/role\nvlan.management.rsb3\n",
+		},
+		{
+			name:     "filtered",
+			args:     []string{"vlan"},
+			expected: "vlan.management.rsb3\n",
+		},
+		{
+			name:        "unmatched",
+			args:        []string{"vlan.storage"},
+			expectError: "no item of the bundle matches target vlan.storage",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A bundle file
+			configPath := filepath.Join(t.TempDir(), "cluster-config.yaml")
+			require.NoError(t, os.WriteFile(configPath, []byte(exportTestConfig), 0644))
+			rootCmd := createRootCommand()
+			out := new(bytes.Buffer)
+			rootCmd.SetOut(out)
+			rootCmd.SetErr(new(bytes.Buffer))
+			rootCmd.SetArgs(append([]string{"addresses", "--config", configPath}, tt.args...))
+
+			// When: List the addresses
+			err := rootCmd.Execute()
+
+			// Then: One address per line
+			if tt.expectError != "" {
+				assert.ErrorContains(t, err, tt.expectError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, out.String())
+		})
+	}
+}
+
+// TestApplyTarget tests that apply rejects targets before touching the cluster
+// WHY: A mistyped target must fail the run instead of applying nothing, or everything
+func TestApplyTarget(t *testing.T) {
+	tests := []struct {
+		name        string
+		target      string
+		expectError string
+	}{
+		{
+			name:        "unmatched_target",
+			target:      "vlan.management.rsb9",
+			expectError: "no item of the bundle matches target vlan.management.rsb9; list the addresses with 'kictl addresses --config",
+		},
+		{
+			name:        "invalid_target",
+			target:      "vlans.management",
+			expectError: "invalid resource address 'vlans.management'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A bundle file and a workspace
+			dir := t.TempDir()
+			configPath := filepath.Join(dir, "cluster-config.yaml")
+			require.NoError(t, os.WriteFile(configPath, []byte(exportTestConfig), 0644))
+			rootCmd := createRootCommand()
+			rootCmd.SetOut(new(bytes.Buffer))
+			rootCmd.SetErr(new(bytes.Buffer))
+			rootCmd.SetArgs([]string{"apply", "--config", configPath, "--dry-run", "--workspace", dir, "--target", tt.target})
+
+			// When: Apply with the target
+			err := rootCmd.Execute()
+
+			// Then: The target is reported
+			assert.ErrorContains(t, err, tt.expectError)
+		})
+	}
+}
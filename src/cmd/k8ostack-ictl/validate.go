@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+
+	"k8ostack-ictl/internal/junit"
+
+	"github.com/spf13/cobra"
+)
+
+// createValidateCommand creates the command that checks a configuration bundle without contacting the cluster
+func createValidateCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "validate",
+		Short: "Check the configuration bundle without contacting the cluster",
+		Long: `Load the bundle and run every validation rule on it: schema and value checks,
+required document fields and the node name policy. Each rule is reported, not only the first failure.
+
+Examples:
+  kictl validate --config cluster-config.yaml
+
+  # Report each rule as a JUnit test case for CI dashboards
+  kictl validate --config cluster-config.yaml --junit validate-report.xml`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if configFile == "" {
+				return fmt.Errorf("configuration file is required. Use --config to specify a YAML file")
+			}
+			return runValidate(cmd)
+		},
+	}
+
+	cmd.Flags().StringVar(&junitOutput, "junit", "", "Also write the results as JUnit XML to this file, one test case per rule")
+	return cmd
+}
+
+// runValidate checks the bundle rule by rule, printing each outcome
+func runValidate(cmd *cobra.Command) error {
+	report := junit.NewReport("kictl validate")
+	suite := report.Suite("validation")
+
+	bundle, err := loadBundle()
+	if err != nil {
+		suite.Fail("load", err.Error())
+	} else {
+		suite.Pass("load")
+
+		// Rules read CLI overrides such as --node-name-pattern, like apply does
+		resolver := newPrecedenceResolver(cmd)
+		if err := resolver.ApplyGlobalOverrides(bundle); err != nil {
+			suite.Fail("cli overrides", err.Error())
+		}
+		printConfigDecisions(cmd, resolver)
+		for _, check := range bundle.Checks() {
+			if check.Err != nil {
+				suite.Fail(check.Rule, check.Err.Error())
+			} else {
+				suite.Pass(check.Rule)
+			}
+		}
+	}
+
+	out := cmd.OutOrStdout()
+	fmt.Fprintf(out, "📋 Validating config file: %s\n", configFile)
+	for _, c := range suite.Cases {
+		if c.Failure != "" {
+			fmt.Fprintf(out, "  ❌ %s: %s\n", c.Name, c.Failure)
+		} else {
+			fmt.Fprintf(out, "  ✅ %s\n", c.Name)
+		}
+	}
+
+	if junitOutput != "" {
+		if err := report.WriteFile(junitOutput); err != nil {
+			return err
+		}
+	}
+
+	if failures := report.Failures(); failures > 0 {
+		return fmt.Errorf("validation failed: %d of %d rules", failures, len(suite.Cases))
+	}
+	fmt.Fprintf(out, "✅ Configuration is valid\n")
+	return nil
+}
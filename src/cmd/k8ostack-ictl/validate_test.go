@@ -0,0 +1,127 @@
+// Package main provides unit tests for the validate command
+// WHY: Validate gates configuration changes in CI, so it must report every rule and fail on any
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestValidateCommand tests rule reporting and the JUnit report of validate
+// WHY: Each rule must appear as a test case, including rules that pass
+func TestValidateCommand(t *testing.T) {
+	tests := []struct {
+		name          string
+		content       string
+		extraArgs     []string
+		expectError   string
+		expectedOut   []string
+		expectedJUnit []string
+	}{
+		{
+			name: "valid_bundle",
+			content: `apiVersion: openstack.kictl.icycloud.io/v1
+kind: NodeLabelConf
+metadata:
+  name: labels
+spec:
+  nodeRoles:
+    control:
+      nodes: [rsb2]
+      labels:
+        role: control
+`,
+			expectedOut:   []string{"✅ load", "✅ document NodeLabelConf/labels", "✅ node name policy", "✅ Configuration is valid"},
+			expectedJUnit: []string{`tests="3" failures="0"`, `<testcase name="node name policy"`},
+		},
+		{
+			name: "node_name_policy_violation",
+			content: `apiVersion: openstack.kictl.icycloud.io/v1
+kind: NodeLabelConf
+metadata:
+  name: labels
+spec:
+  nodeRoles:
+    control:
+      nodes: [RSB2]
+      labels:
+        role: control
+`,
+			extraArgs:     []string{"--node-name-pattern", "rsb[0-9]+"},
+			expectError:   "validation failed: 1 of 3 rules",
+			expectedOut:   []string{"✅ load", "❌ node name policy"},
+			expectedJUnit: []string{`tests="3" failures="1"`, `<failure message=`},
+		},
+		{
+			name:          "unparsable_bundle",
+			content:       "kind: [",
+			expectError:   "validation failed: 1 of 1 rules",
+			expectedOut:   []string{"❌ load"},
+			expectedJUnit: []string{`<testcase name="load"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A bundle on disk
+			tempDir := t.TempDir()
+			bundlePath := filepath.Join(tempDir, "bundle.yaml")
+			reportPath := filepath.Join(tempDir, "report.xml")
+			require.NoError(t, os.WriteFile(bundlePath, []byte(tt.content), 0644))
+			defer func() { configFile, junitOutput = "", "" }()
+
+			// When: Execute validate with a JUnit report
+			var out bytes.Buffer
+			root := createRootCommand()
+			root.SetOut(&out)
+			root.SetErr(new(bytes.Buffer))
+			root.SetArgs(append([]string{"validate", "--config", bundlePath, "--junit", reportPath}, tt.extraArgs...))
+			err := root.Execute()
+
+			// Then: Output and report list every rule
+			if tt.expectError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectError)
+			} else {
+				require.NoError(t, err)
+			}
+			for _, expected := range tt.expectedOut {
+				assert.Contains(t, out.String(), expected)
+			}
+			report, err := os.ReadFile(reportPath)
+			require.NoError(t, err)
+			for _, expected := range tt.expectedJUnit {
+				assert.Contains(t, string(report), expected)
+			}
+		})
+	}
+}
+
+// TestValidateExplainConfig tests printing the precedence decisions with --explain-config
+// WHY: Users must see which layer turned a setting on without reading the resolver
+func TestValidateExplainConfig(t *testing.T) {
+	// Given: A bundle and dry run set through the environment
+	t.Setenv("KICTL_DRY_RUN", "true")
+	configPath := filepath.Join(t.TempDir(), "cluster-config.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte(exportTestConfig), 0644))
+	defer func() { configFile = "" }()
+	var out bytes.Buffer
+	root := createRootCommand()
+	root.SetOut(&out)
+	root.SetErr(new(bytes.Buffer))
+	root.SetArgs([]string{"validate", "--config", configPath, "--explain-config", "--log-level", "debug"})
+
+	// When: Validate with the explanation
+	require.NoError(t, root.Execute())
+
+	// Then: Every setting names the layer it came from
+	assert.Contains(t, out.String(), "🔍 Configuration decisions (default → config file → env → flag):")
+	assert.Contains(t, out.String(), "NodeVLANConf/test-vlans tools.nvlan.dryRun = true (default false → env KICTL_DRY_RUN true)")
+	assert.Contains(t, out.String(), `NodeLabelConf/test-labels tools.nlabel.logLevel = "debug" (default "info" → flag --log-level "debug")`)
+	assert.Contains(t, out.String(), `NodeLabelConf/test-labels tools.nlabel.nodeNamePattern = "" (default "")`)
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"k8ostack-ictl/internal/junit"
+)
+
+// printVerifyReport prints the verification result of every node, grouped by configuration kind
+func printVerifyReport(out io.Writer, report *junit.Report) {
+	fmt.Fprintf(out, "🩺 Verification report:\n")
+	for _, suite := range report.Suites {
+		fmt.Fprintf(out, "  %s\n", suite.Name)
+		for _, c := range suite.Cases {
+			switch {
+			case c.Failure != "":
+				fmt.Fprintf(out, "    ❌ %s: %s\n", c.Name, c.Failure)
+			case c.Skipped != "":
+				fmt.Fprintf(out, "    ⏭️  %s: %s\n", c.Name, c.Skipped)
+			default:
+				fmt.Fprintf(out, "    ✅ %s\n", c.Name)
+			}
+		}
+	}
+}
+
+// verifyDeviations returns an error exiting with exitDeviation when nodes deviate from the bundle
+// It returns nil when nothing deviates, or when a service failed to verify at all, which is not a deviation.
+func verifyDeviations(report *junit.Report) error {
+	if report.Failures() == 0 {
+		return nil
+	}
+	for _, suite := range report.Suites {
+		for _, c := range suite.Cases {
+			if c.Name == serviceCase && c.Failure != "" {
+				return nil
+			}
+		}
+	}
+	return &exitCodeError{code: exitDeviation, err: fmt.Errorf("verification found %d deviations from the bundle", report.Failures())}
+}
@@ -0,0 +1,82 @@
+// Package main provides unit tests for the verification report and exit codes of verify
+// WHY: verify gates on cluster health, so a deviating node must fail with its own exit code and be named in the report
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"k8ostack-ictl/internal/junit"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestVerifyDeviations tests telling deviating nodes apart from services that could not verify
+// WHY: A CI gate must know whether the cluster is unhealthy or the check itself broke
+func TestVerifyDeviations(t *testing.T) {
+	tests := []struct {
+		name         string
+		cases        []junit.Case
+		expectError  string
+		expectedCode int
+	}{
+		{
+			name:  "every_node_matches",
+			cases: []junit.Case{{Name: "rsb2"}, {Name: "rsb3"}},
+		},
+		{
+			name:         "node_deviates",
+			cases:        []junit.Case{{Name: "rsb2"}, {Name: "rsb3", Failure: "expected labels are missing"}},
+			expectError:  "verification found 1 deviations from the bundle",
+			expectedCode: exitDeviation,
+		},
+		{
+			name:  "service_failed",
+			cases: []junit.Case{{Name: "rsb3", Failure: "eth0.100 missing"}, {Name: serviceCase, Failure: "cluster unreachable"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A verification report of one kind
+			report := junit.NewReport("kictl verify")
+			for _, c := range tt.cases {
+				report.Suite("NodeLabelConf").Add(c)
+			}
+
+			// When: Check it for deviations
+			err := verifyDeviations(report)
+
+			// Then: Only deviating nodes end with the deviation exit code
+			if tt.expectError == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tt.expectError)
+			assert.Equal(t, tt.expectedCode, exitCode(fmt.Errorf("verify: %w", err)))
+		})
+	}
+}
+
+// TestPrintVerifyReport tests the per-node verification report
+// WHY: Operators read the report to find which nodes deviate and why
+func TestPrintVerifyReport(t *testing.T) {
+	// Given: A report with a matching, a deviating and a skipped node
+	report := junit.NewReport("kictl verify")
+	report.Suite("NodeLabelConf").Pass("rsb2")
+	report.Suite("NodeLabelConf").Fail("rsb3", "expected labels are missing")
+	report.Suite("NodeTestConf").Skip("mgmt-ping rsb4 -> rsb2", "excluded")
+
+	// When: Print it
+	var out bytes.Buffer
+	printVerifyReport(&out, report)
+
+	// Then: Every node is listed under its kind with its result
+	assert.Equal(t, "🩺 Verification report:\n"+
+		"  NodeLabelConf\n"+
+		"    ✅ rsb2\n"+
+		"    ❌ rsb3: expected labels are missing\n"+
+		"  NodeTestConf\n"+
+		"    ⏭️  mgmt-ping rsb4 -> rsb2: excluded\n", out.String())
+}
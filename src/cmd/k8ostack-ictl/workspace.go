@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"k8ostack-ictl/internal/artifacts"
+	"k8ostack-ictl/internal/history"
+	"k8ostack-ictl/internal/logging"
+	"k8ostack-ictl/internal/workspace"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// openWorkspace opens the --workspace directory, falling back to $KICTL_HOME or ~/.kictl
+func openWorkspace() (*workspace.Workspace, error) {
+	return workspace.Open(workspaceDir)
+}
+
+// openHistoryStore opens --history-dir, or the history folder of the workspace when it is unset
+func openHistoryStore() (*history.FileStore, error) {
+	dir := historyDir
+	if dir == "" {
+		ws, err := openWorkspace()
+		if err != nil {
+			return nil, err
+		}
+		dir = ws.Path(workspace.HistoryDir)
+	}
+	return history.NewFileStore(dir)
+}
+
+// retentionPolicy builds the workspace retention limits from the --retention-* flags
+func retentionPolicy() (workspace.Retention, error) {
+	if retentionMaxAge < 0 || retentionMaxRuns < 0 {
+		return workspace.Retention{}, fmt.Errorf("retention limits cannot be negative")
+	}
+	maxSize, err := resource.ParseQuantity(retentionMaxSize)
+	if err != nil {
+		return workspace.Retention{}, fmt.Errorf("invalid --retention-max-size %q: %w", retentionMaxSize, err)
+	}
+	if maxSize.Sign() < 0 {
+		return workspace.Retention{}, fmt.Errorf("retention limits cannot be negative")
+	}
+	return workspace.Retention{MaxAge: retentionMaxAge, MaxRuns: retentionMaxRuns, MaxSize: maxSize.Value()}, nil
+}
+
+// formatSize renders a byte count the way --retention-max-size accepts it
+func formatSize(size int64) string {
+	return resource.NewQuantity(size, resource.BinarySI).String()
+}
+
+// newRunLogger creates the run folder for an operation and a logger writing into it and to the command output
+// Older run folders beyond the retention limits are pruned first, keeping the new run
+func newRunLogger(cmd *cobra.Command, operation string) (*logging.FileLogger, *workspace.Run, error) {
+	return newRunLoggerTo(progressConsole(cmd.OutOrStdout()), operation)
+}
+
+// newRunLoggerTo is newRunLogger with the console output sent to a given writer, e.g. stderr when stdout carries data
+func newRunLoggerTo(console io.Writer, operation string) (*logging.FileLogger, *workspace.Run, error) {
+	ws, err := openWorkspace()
+	if err != nil {
+		return nil, nil, err
+	}
+	run, err := ws.NewRun(operation, time.Now())
+	if err != nil {
+		return nil, nil, err
+	}
+	logger, err := logging.NewFileLoggerWithOptions(run.Dir, logging.Options{
+		Verbose:      verbose,
+		Console:      console,
+		Format:       logFormat,
+		ConsoleLevel: consoleOptions().ConsoleLevel(),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pruneWorkspace(ws, run, logger)
+	return logger, run, nil
+}
+
+// pruneWorkspace applies the retention limits to older runs; failures are logged so housekeeping never fails an operation
+func pruneWorkspace(ws *workspace.Workspace, run *workspace.Run, logger *logging.FileLogger) {
+	retention, err := retentionPolicy()
+	if err == nil {
+		var result workspace.PruneResult
+		result, err = ws.Prune(retention, time.Now(), run.ID)
+		if len(result.Removed) > 0 {
+			logger.Info(fmt.Sprintf("🧹 Pruned %d old runs from %s (%s freed)", len(result.Removed), ws.Root, formatSize(result.Freed)))
+		}
+	}
+	if err != nil {
+		logger.Warn(fmt.Sprintf("⚠️  Failed to prune workspace: %v", err))
+	}
+}
+
+// artifactRunner runs the aws CLI for --artifacts uploads; nil runs the local binary
+var artifactRunner artifacts.Runner
+
+// newArtifactUploader returns the uploader for --artifacts, or nil when it is unset
+func newArtifactUploader() (*artifacts.Uploader, error) {
+	if artifactsURL == "" {
+		return nil, nil
+	}
+	return artifacts.NewUploader(artifactsURL, artifactsEndpoint, artifactRunner)
+}
+
+// runReports lists the report files a run may have written outside its run folder
+func runReports() []string {
+	var reports []string
+	if junitOutput != "" {
+		reports = append(reports, junitOutput)
+	}
+	for _, path := range testReports {
+		reports = append(reports, path)
+	}
+	sort.Strings(reports)
+	return reports
+}
+
+// closeRun closes the run logger and, with --artifacts, uploads the run folder and its reports
+// Upload failures are only reported, so a storage outage never fails an operation
+func closeRun(cmd *cobra.Command, logger *logging.FileLogger, run *workspace.Run) {
+	logger.Close()
+
+	uploader, err := newArtifactUploader()
+	if uploader == nil || err != nil {
+		return
+	}
+	url, err := uploader.UploadRun(context.Background(), run.ID, run.Dir, runReports())
+	if err != nil {
+		fmt.Fprintf(cmd.ErrOrStderr(), "⚠️  Failed to upload run artifacts: %v (they remain in %s)\n", err, run.Dir)
+		return
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "📦 Uploaded run artifacts to %s\n", url)
+}
@@ -0,0 +1,217 @@
+// Package main provides unit tests for workspace resolution in the CLI
+// WHY: Logs, plans and history must follow --workspace and never land in the current directory
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"k8ostack-ictl/internal/workspace"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// init keeps every test in this package out of the real ~/.kictl
+func init() {
+	home, err := os.MkdirTemp("", "kictl-home-")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv(workspace.EnvHome, home)
+}
+
+// TestWorkspaceFlag_Unit tests the --workspace flag defaults
+// WHY: An empty default is what lets $KICTL_HOME and ~/.kictl take effect
+func TestWorkspaceFlag_Unit(t *testing.T) {
+	rootCmd := createRootCommand()
+
+	workspaceFlag := rootCmd.PersistentFlags().Lookup("workspace")
+	require.NotNil(t, workspaceFlag)
+	assert.Equal(t, "", workspaceFlag.DefValue)
+
+	historyFlag := rootCmd.PersistentFlags().Lookup("history-dir")
+	require.NotNil(t, historyFlag)
+	assert.Equal(t, "", historyFlag.DefValue, "history should default to the workspace")
+}
+
+// TestNewRunLogger_Unit tests that run logs are written into a run folder of the workspace
+// WHY: Replaces the old ./logs directory, which depended on where kictl was started
+func TestNewRunLogger_Unit(t *testing.T) {
+	originalWorkspace := workspaceDir
+	defer func() { workspaceDir = originalWorkspace }()
+
+	// Given: Explicit workspace
+	workspaceDir = t.TempDir()
+
+	// When: Creating the logger for an apply
+	cmd := &cobra.Command{}
+	out := new(bytes.Buffer)
+	cmd.SetOut(out)
+	logger, run, err := newRunLogger(cmd, operationApply)
+	require.NoError(t, err)
+	defer logger.Close()
+
+	// Then: The run folder lives under runs/ and holds the log file
+	assert.Equal(t, filepath.Join(workspaceDir, workspace.RunsDir), filepath.Dir(run.Dir))
+	assert.Contains(t, run.ID, "-apply-")
+	logs, err := filepath.Glob(filepath.Join(run.Dir, "*.log"))
+	require.NoError(t, err)
+	assert.Len(t, logs, 1)
+
+	// Then: Console output goes to the command output
+	assert.Contains(t, out.String(), "📝 Logging to: "+logs[0])
+}
+
+// TestOpenHistoryStore_Unit tests where history is stored
+// WHY: --history-dir must still win so existing history locations keep working
+func TestOpenHistoryStore_Unit(t *testing.T) {
+	originalWorkspace, originalHistory := workspaceDir, historyDir
+	defer func() { workspaceDir, historyDir = originalWorkspace, originalHistory }()
+
+	tests := []struct {
+		name        string
+		historyDir  string
+		expectedDir func(root string) string
+	}{
+		{
+			name:        "defaults to the workspace history folder",
+			expectedDir: func(root string) string { return filepath.Join(root, workspace.HistoryDir) },
+		},
+		{
+			name:        "explicit history directory wins",
+			historyDir:  "custom-history",
+			expectedDir: func(root string) string { return filepath.Join(root, "custom-history") },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: Workspace and optional history directory
+			root := t.TempDir()
+			workspaceDir = root
+			historyDir = ""
+			if tt.historyDir != "" {
+				historyDir = filepath.Join(root, tt.historyDir)
+			}
+
+			// When: Opening the store
+			_, err := openHistoryStore()
+
+			// Then: The expected directory is created
+			require.NoError(t, err)
+			assert.DirExists(t, tt.expectedDir(root))
+		})
+	}
+}
+
+// TestNewRunLogger_Prunes_Unit tests that starting a run applies the retention limits
+// WHY: Pruning at startup is what keeps unattended hosts from accumulating logs
+func TestNewRunLogger_Prunes_Unit(t *testing.T) {
+	originalWorkspace, originalRuns, originalSize := workspaceDir, retentionMaxRuns, retentionMaxSize
+	defer func() {
+		workspaceDir, retentionMaxRuns, retentionMaxSize = originalWorkspace, originalRuns, originalSize
+	}()
+
+	// Given: Workspace with two earlier runs and a limit of one run
+	workspaceDir = t.TempDir()
+	retentionMaxRuns, retentionMaxSize = 1, "0"
+	for i := 0; i < 2; i++ {
+		previousLogger, previous, err := newRunLogger(&cobra.Command{}, operationVerify)
+		require.NoError(t, err)
+		previousLogger.Close()
+		modTime := time.Now().Add(time.Duration(i-2) * time.Hour)
+		require.NoError(t, os.Chtimes(previous.Dir, modTime, modTime))
+	}
+
+	// When: A new run starts
+	logger, run, err := newRunLogger(&cobra.Command{}, operationApply)
+	require.NoError(t, err)
+	defer logger.Close()
+
+	// Then: The new run and the newest earlier run remain
+	remaining, err := os.ReadDir(filepath.Join(workspaceDir, workspace.RunsDir))
+	require.NoError(t, err)
+	assert.Len(t, remaining, 2)
+	assert.DirExists(t, run.Dir)
+}
+
+// TestCloseRun_Artifacts_Unit tests uploading a run folder and its reports when the run ends
+// WHY: The bastion disk must not be the only copy of audit evidence, yet a storage outage must not fail the run
+func TestCloseRun_Artifacts_Unit(t *testing.T) {
+	tests := []struct {
+		name           string
+		artifacts      string
+		runErr         error
+		expectUpload   bool
+		expectedOutput string
+		expectedErrOut string
+	}{
+		{
+			name: "no_artifacts_destination",
+		},
+		{
+			name:           "uploaded",
+			artifacts:      "s3://audit/kictl",
+			expectUpload:   true,
+			expectedOutput: "📦 Uploaded run artifacts to s3://audit/kictl/",
+		},
+		{
+			name:           "failed_upload_is_only_reported",
+			artifacts:      "s3://audit/kictl",
+			runErr:         errors.New("exit status 1"),
+			expectUpload:   true,
+			expectedErrOut: "⚠️  Failed to upload run artifacts",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			originalWorkspace, originalArtifacts, originalReports := workspaceDir, artifactsURL, testReports
+			defer func() {
+				workspaceDir, artifactsURL, testReports, artifactRunner = originalWorkspace, originalArtifacts, originalReports, nil
+			}()
+
+			// Given: A run that wrote a JUnit report next to the workspace
+			workspaceDir = t.TempDir()
+			artifactsURL = tt.artifacts
+			report := filepath.Join(t.TempDir(), "report.xml")
+			require.NoError(t, os.WriteFile(report, []byte("<testsuites/>"), 0644))
+			testReports = map[string]string{reportFormatJUnit: report}
+			var calls [][]string
+			artifactRunner = func(ctx context.Context, args ...string) (string, error) {
+				calls = append(calls, args)
+				return "", tt.runErr
+			}
+			cmd := &cobra.Command{}
+			out, errOut := new(bytes.Buffer), new(bytes.Buffer)
+			cmd.SetOut(out)
+			cmd.SetErr(errOut)
+			logger, run, err := newRunLogger(cmd, operationApply)
+			require.NoError(t, err)
+
+			// When: The run ends
+			closeRun(cmd, logger, run)
+
+			// Then: The run folder and report are uploaded under the run's prefix, and failures are only reported
+			if !tt.expectUpload {
+				assert.Empty(t, calls)
+				return
+			}
+			require.NotEmpty(t, calls)
+			assert.Equal(t, []string{"s3", "cp", "--only-show-errors", "--recursive", run.Dir, "s3://audit/kictl/" + run.ID + "/"}, calls[0])
+			if tt.runErr == nil {
+				require.Len(t, calls, 2)
+				assert.Equal(t, "s3://audit/kictl/"+run.ID+"/reports/report.xml", calls[1][len(calls[1])-1])
+			}
+			assert.Contains(t, out.String(), tt.expectedOutput)
+			assert.Contains(t, errOut.String(), tt.expectedErrOut)
+		})
+	}
+}
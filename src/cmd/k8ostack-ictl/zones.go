@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"k8ostack-ictl/internal/kubectl"
+	"k8ostack-ictl/internal/logging"
+)
+
+// defaultZoneKey is the well-known node label of the zone a node runs in
+const defaultZoneKey = "topology.kubernetes.io/zone"
+
+// nodeZones returns a lookup of the failure domain of a node, the value of its key label, read once per node
+// Nodes without the label, or whose labels cannot be read, belong to no domain and are only limited by the workers.
+func nodeZones(logger logging.Logger, executor kubectl.DryRunExecutor, key string) func(nodeName string) string {
+	var mu sync.Mutex
+	zones := make(map[string]string)
+
+	return func(nodeName string) string {
+		mu.Lock()
+		defer mu.Unlock()
+		if zone, ok := zones[nodeName]; ok {
+			return zone
+		}
+
+		zone := ""
+		success, output, err := executor.GetNodeLabels(context.Background(), nodeName)
+		if err == nil && !success {
+			err = fmt.Errorf("%s", strings.TrimSpace(output))
+		}
+		if err != nil {
+			logger.Warn(fmt.Sprintf("⚠️  Could not read the labels of node %s, not limiting it by %s: %v", nodeName, key, err))
+		} else if zone = kubectl.ParseNodeLabels(output)[key]; zone == "" {
+			logger.Warn(fmt.Sprintf("⚠️  Node %s has no %s label, not limiting it by zone", nodeName, key))
+		}
+		zones[nodeName] = zone
+		return zone
+	}
+}
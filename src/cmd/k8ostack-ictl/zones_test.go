@@ -0,0 +1,57 @@
+// Package main provides unit tests for looking up the failure domains of nodes
+// WHY: --zone-aware is only as safe as the zones it reads, and nodes it cannot place must be reported
+package main
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"k8ostack-ictl/internal/labeler"
+	"k8ostack-ictl/internal/logging"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNodeZones_Unit tests reading the failure domain of nodes from their labels
+// WHY: Each node is read once, and a node without a zone is run without the domain limit and warned about
+func TestNodeZones_Unit(t *testing.T) {
+	// Given: rsb2 in zone-a, rsb3 without a zone and rsb4 whose labels cannot be read
+	executor := labeler.NewMockDryRunExecutor()
+	executor.On("GetNodeLabels", mock.Anything, "rsb2").Return(true, "NAME   LABELS\nrsb2   kubernetes.io/hostname=rsb2,topology.kubernetes.io/zone=zone-a", nil).Once()
+	executor.On("GetNodeLabels", mock.Anything, "rsb3").Return(true, "NAME   LABELS\nrsb3   kubernetes.io/hostname=rsb3", nil).Once()
+	executor.On("GetNodeLabels", mock.Anything, "rsb4").Return(false, "", errors.New("connection refused")).Once()
+	logger := logging.NewRecordingLogger()
+
+	// When: Look up the zones, rsb2 twice
+	zoneOf := nodeZones(logger, executor, defaultZoneKey)
+	zones := []string{zoneOf("rsb2"), zoneOf("rsb2"), zoneOf("rsb3"), zoneOf("rsb4")}
+
+	// Then: rsb2 is in zone-a and read once, the other nodes have no zone and are warned about
+	assert.Equal(t, []string{"zone-a", "zone-a", "", ""}, zones)
+	executor.AssertExpectations(t)
+	assert.Equal(t, []string{
+		"⚠️  Node rsb3 has no topology.kubernetes.io/zone label, not limiting it by zone",
+		"⚠️  Could not read the labels of node rsb4, not limiting it by topology.kubernetes.io/zone: connection refused",
+	}, logger.Messages(logging.LevelWarn))
+}
+
+// TestZoneAwareValidation_Unit tests rejecting --zone-aware without a label to read zones from
+// WHY: An empty key would put every node in no zone and silently drop the limit
+func TestZoneAwareValidation_Unit(t *testing.T) {
+	// Given: A command with --zone-aware and an empty --zone-key
+	cmd := createRootCommand()
+	t.Cleanup(func() { zoneAware, zoneKey = false, defaultZoneKey })
+	cmd.SetOut(new(bytes.Buffer))
+	cmd.SetErr(new(bytes.Buffer))
+	cmd.SetArgs([]string{"apply", "--config", "missing.yaml", "--max-parallelism", "4", "--zone-aware", "--zone-key", ""})
+
+	// When: Execute it
+	err := cmd.Execute()
+
+	// Then: The flags are rejected before the configuration is read
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--zone-aware needs a --zone-key")
+}
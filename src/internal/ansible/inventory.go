@@ -0,0 +1,226 @@
+// Package ansible exports a configuration bundle as an Ansible inventory with host_vars
+package ansible
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"k8ostack-ictl/internal/config"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Inventory file layout, as read by ansible-inventory from a directory
+const (
+	HostsFile   = "hosts.yml"
+	HostVarsDir = "host_vars"
+)
+
+// groupPrefix keeps kictl's groups apart from the ones playbooks define themselves
+const groupPrefix = "kictl_"
+
+// VLAN is the addressing of one VLAN on a host
+// Address and IP are the primary address, the first IPv4 one of a dual-stack host; Address6 and IP6 its first IPv6 one.
+type VLAN struct {
+	ID        int      `yaml:"id"`
+	Subnet    string   `yaml:"subnet,omitempty"`
+	Address   string   `yaml:"address"` // CIDR, e.g. 10.1.0.2/24
+	IP        string   `yaml:"ip"`
+	Address6  string   `yaml:"address6,omitempty"`
+	IP6       string   `yaml:"ip6,omitempty"`
+	Addresses []string `yaml:"addresses,omitempty"` // every address of a dual-stack host
+	Interface string   `yaml:"interface,omitempty"` // parent interface or OVS bridge; empty when detected on the node
+	Device    string   `yaml:"device,omitempty"`    // VLAN interface or OVS port kictl creates, e.g. eth1.100
+}
+
+// HostVars are the variables kictl exports for one host
+type HostVars struct {
+	Roles  []string          `yaml:"kictl_roles,omitempty"`
+	Labels map[string]string `yaml:"kictl_labels,omitempty"`
+	VLANs  map[string]VLAN   `yaml:"kictl_vlans,omitempty"`
+}
+
+// Inventory is the hosts, groups and host variables of a bundle
+type Inventory struct {
+	Hosts  map[string]*HostVars
+	Groups map[string][]string // group -> sorted hosts
+
+	// Warnings lists what could not be exported without the cluster, e.g. roles targeting nodes by pattern
+	Warnings []string
+}
+
+// FromBundle builds the inventory of a bundle: one group per role and per VLAN, and the labels and
+// VLAN addresses of every node as host variables
+func FromBundle(bundle *config.ConfigBundle) (*Inventory, error) {
+	inv := &Inventory{Hosts: make(map[string]*HostVars), Groups: make(map[string][]string)}
+
+	if bundle.HasNodeLabels() {
+		for _, roleName := range sortedKeys(bundle.NodeLabels.Spec.NodeRoles) {
+			role := bundle.NodeLabels.Spec.NodeRoles[roleName]
+			if role.IsDynamic() {
+				inv.Warnings = append(inv.Warnings, fmt.Sprintf("role %s targets nodes by pattern or nodeSelector; only its listed nodes are exported", roleName))
+			}
+			group := GroupName(roleName)
+			for _, node := range role.NodeNames() {
+				vars := inv.host(node)
+				vars.Roles = append(vars.Roles, roleName)
+				if vars.Labels == nil {
+					vars.Labels = make(map[string]string)
+				}
+				for key, value := range role.Labels {
+					vars.Labels[key] = value
+				}
+				inv.Groups[group] = append(inv.Groups[group], node)
+			}
+		}
+	}
+
+	if bundle.HasVLANs() {
+		aliases := bundle.GetDefaults().Spec.InterfaceAliases
+		for _, vlanName := range sortedKeys(bundle.VLANs.Spec.VLANs) {
+			vlanConfig := bundle.VLANs.Spec.VLANs[vlanName]
+			group := GroupName("vlan_" + vlanName)
+			for _, node := range sortedKeys(vlanConfig.NodeMapping) {
+				addresses := config.SplitAddresses(vlanConfig.NodeMapping[node])
+				parent, err := aliases.Resolve(node, vlanConfig.Interface)
+				if err != nil {
+					return nil, fmt.Errorf("VLAN %s: %w", vlanName, err)
+				}
+				if vlanConfig.OVS != nil {
+					parent = vlanConfig.OVS.Bridge
+				}
+
+				if len(addresses) == 0 {
+					return nil, fmt.Errorf("VLAN %s: node %s has no address", vlanName, node)
+				}
+
+				exported := VLAN{ID: vlanConfig.ID, Subnet: vlanConfig.Subnet, Interface: parent}
+				for _, address := range addresses {
+					ip, _, err := net.ParseCIDR(address)
+					if err != nil {
+						return nil, fmt.Errorf("VLAN %s: invalid address %s of node %s: %w", vlanName, address, node, err)
+					}
+					if ip.To4() == nil && exported.Address6 == "" {
+						exported.Address6, exported.IP6 = address, ip.String()
+					}
+				}
+				primary := config.PrimaryAddress(vlanConfig.NodeMapping[node])
+				ip, _, _ := net.ParseCIDR(primary)
+				exported.Address, exported.IP = primary, ip.String()
+				if len(addresses) > 1 {
+					exported.Addresses = addresses
+				}
+				if vlanConfig.OVS != nil {
+					exported.Device = vlanConfig.OVS.Port
+				} else if parent != "" {
+					exported.Device = fmt.Sprintf("%s.%d", parent, vlanConfig.ID)
+				}
+				vars := inv.host(node)
+				if vars.VLANs == nil {
+					vars.VLANs = make(map[string]VLAN)
+				}
+				vars.VLANs[vlanName] = exported
+				inv.Groups[group] = append(inv.Groups[group], node)
+			}
+		}
+	}
+
+	for group, hosts := range inv.Groups {
+		sort.Strings(hosts)
+		inv.Groups[group] = hosts
+	}
+	return inv, nil
+}
+
+// GroupName turns a role or VLAN name into an Ansible group name, e.g. control-plane -> kictl_control_plane
+func GroupName(name string) string {
+	return groupPrefix + strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// host returns the variables of a node, adding it to the inventory on first use
+func (inv *Inventory) host(node string) *HostVars {
+	if inv.Hosts[node] == nil {
+		inv.Hosts[node] = &HostVars{}
+	}
+	return inv.Hosts[node]
+}
+
+// inventoryGroup is a group of a YAML inventory
+type inventoryGroup struct {
+	Hosts    map[string]interface{}    `yaml:"hosts,omitempty"`
+	Children map[string]inventoryGroup `yaml:"children,omitempty"`
+}
+
+// MarshalHosts renders the YAML inventory; inlineVars puts the host variables into it instead of host_vars files
+func (inv *Inventory) MarshalHosts(inlineVars bool) ([]byte, error) {
+	all := inventoryGroup{Hosts: make(map[string]interface{}), Children: make(map[string]inventoryGroup)}
+	for node, vars := range inv.Hosts {
+		if inlineVars {
+			all.Hosts[node] = vars
+		} else {
+			all.Hosts[node] = map[string]string{}
+		}
+	}
+	for group, hosts := range inv.Groups {
+		members := make(map[string]interface{}, len(hosts))
+		for _, node := range hosts {
+			members[node] = map[string]string{}
+		}
+		all.Children[group] = inventoryGroup{Hosts: members}
+	}
+
+	data, err := yaml.Marshal(map[string]inventoryGroup{"all": all})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal inventory: %w", err)
+	}
+	return data, nil
+}
+
+// WriteDir writes hosts.yml and one host_vars/<node>.yml per host into dir, returning the files written
+func (inv *Inventory) WriteDir(dir string) ([]string, error) {
+	varsDir := filepath.Join(dir, HostVarsDir)
+	if err := os.MkdirAll(varsDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create inventory directory: %w", err)
+	}
+
+	hosts, err := inv.MarshalHosts(false)
+	if err != nil {
+		return nil, err
+	}
+	written := []string{filepath.Join(dir, HostsFile)}
+	if err := os.WriteFile(written[0], hosts, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write inventory: %w", err)
+	}
+
+	for _, node := range sortedKeys(inv.Hosts) {
+		data, err := yaml.Marshal(inv.Hosts[node])
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal host_vars of %s: %w", node, err)
+		}
+		path := filepath.Join(varsDir, node+".yml")
+		if err := os.WriteFile(path, append([]byte("---\n"), data...), 0644); err != nil {
+			return nil, fmt.Errorf("failed to write host_vars of %s: %w", node, err)
+		}
+		written = append(written, path)
+	}
+	return written, nil
+}
+
+// sortedKeys returns the keys of a map in order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,129 @@
+// Package ansible provides unit tests for the inventory export
+// WHY: Playbooks read the exported addressing instead of their own copy, so it must match the bundle exactly
+package ansible
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"k8ostack-ictl/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+// testBundle labels two nodes, targets more by pattern and gives one node two VLANs, one of them dual-stack
+func testBundle() *config.ConfigBundle {
+	return &config.ConfigBundle{
+		NodeLabels: &config.NodeLabelConf{Spec: config.NodeLabelSpec{NodeRoles: map[string]config.NodeRole{
+			"control-plane": {Nodes: []string{"rsb2"}, Labels: map[string]string{"openstack-control-plane": "enabled"}},
+			"compute":       {Nodes: []string{"rsb3", "compute-*"}, Labels: map[string]string{"openstack-compute-node": "enabled"}},
+		}}},
+		VLANs: &config.NodeVLANConf{Spec: config.NodeVLANSpec{VLANs: map[string]config.VLANConfig{
+			"management": {ID: 100, Subnet: "10.1.0.0/24", Interface: "uplink1", NodeMapping: map[string]string{"rsb2": "10.1.0.2/24"}},
+			"storage":    {ID: 200, Subnet: "10.2.0.0/24", NodeMapping: map[string]string{"rsb2": "10.2.0.2/24,fd00:2::2/64"}},
+		}}},
+		Defaults: &config.Defaults{Spec: config.DefaultsSpec{InterfaceAliases: config.InterfaceAliases{"*": {"uplink1": "eno1"}}}},
+	}
+}
+
+// TestFromBundle tests building the inventory of a bundle
+// WHY: Groups and host variables are what playbooks target and read
+func TestFromBundle(t *testing.T) {
+	// Given: A bundle with roles and VLANs
+	bundle := testBundle()
+
+	// When: Build its inventory
+	inv, err := FromBundle(bundle)
+
+	// Then: Roles and VLANs become groups, labels and addresses host variables
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]string{
+		"kictl_compute":         {"rsb3"},
+		"kictl_control_plane":   {"rsb2"},
+		"kictl_vlan_management": {"rsb2"},
+		"kictl_vlan_storage":    {"rsb2"},
+	}, inv.Groups)
+	assert.Equal(t, &HostVars{
+		Roles:  []string{"control-plane"},
+		Labels: map[string]string{"openstack-control-plane": "enabled"},
+		VLANs: map[string]VLAN{
+			"management": {ID: 100, Subnet: "10.1.0.0/24", Address: "10.1.0.2/24", IP: "10.1.0.2", Interface: "eno1", Device: "eno1.100"},
+			"storage": {ID: 200, Subnet: "10.2.0.0/24", Address: "10.2.0.2/24", IP: "10.2.0.2", Address6: "fd00:2::2/64", IP6: "fd00:2::2",
+				Addresses: []string{"10.2.0.2/24", "fd00:2::2/64"}},
+		},
+	}, inv.Hosts["rsb2"])
+	assert.Equal(t, []string{"role compute targets nodes by pattern or nodeSelector; only its listed nodes are exported"}, inv.Warnings)
+}
+
+// TestFromBundle_InvalidAddress tests a VLAN address that is no CIDR
+// WHY: A playbook must never receive an address kictl itself would refuse to configure
+func TestFromBundle_InvalidAddress(t *testing.T) {
+	// Given: A VLAN address without prefix length
+	bundle := &config.ConfigBundle{VLANs: &config.NodeVLANConf{Spec: config.NodeVLANSpec{VLANs: map[string]config.VLANConfig{
+		"management": {ID: 100, NodeMapping: map[string]string{"rsb2": "10.1.0.2"}},
+	}}}}
+
+	// When: Build its inventory
+	_, err := FromBundle(bundle)
+
+	// Then: The export fails naming the node
+	assert.ErrorContains(t, err, "VLAN management: invalid address 10.1.0.2 of node rsb2")
+}
+
+// TestGroupName tests turning names into Ansible group names
+// WHY: Ansible warns about and may reject group names with dashes or dots
+func TestGroupName(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "plain", input: "compute", expected: "kictl_compute"},
+		{name: "dashes", input: "control-plane", expected: "kictl_control_plane"},
+		{name: "dots", input: "vlan_ceph.public", expected: "kictl_vlan_ceph_public"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given/When: Convert the name
+			// Then: Only letters, digits and underscores remain
+			assert.Equal(t, tt.expected, GroupName(tt.input))
+		})
+	}
+}
+
+// TestInventory_WriteDir tests writing the inventory directory
+// WHY: ansible-inventory -i <dir> must find hosts.yml and the host_vars files
+func TestInventory_WriteDir(t *testing.T) {
+	// Given: The inventory of a bundle
+	inv, err := FromBundle(testBundle())
+	require.NoError(t, err)
+	dir := filepath.Join(t.TempDir(), "inventory")
+
+	// When: Write it
+	written, err := inv.WriteDir(dir)
+
+	// Then: hosts.yml lists every host and group, host_vars hold the variables
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(dir, HostsFile),
+		filepath.Join(dir, HostVarsDir, "rsb2.yml"),
+		filepath.Join(dir, HostVarsDir, "rsb3.yml"),
+	}, written)
+
+	var hosts map[string]inventoryGroup
+	data, err := os.ReadFile(filepath.Join(dir, HostsFile))
+	require.NoError(t, err)
+	require.NoError(t, yaml.Unmarshal(data, &hosts))
+	assert.Len(t, hosts["all"].Hosts, 2)
+	assert.Contains(t, hosts["all"].Children["kictl_vlan_storage"].Hosts, "rsb2")
+
+	var vars HostVars
+	data, err = os.ReadFile(filepath.Join(dir, HostVarsDir, "rsb3.yml"))
+	require.NoError(t, err)
+	require.NoError(t, yaml.Unmarshal(data, &vars))
+	assert.Equal(t, HostVars{Roles: []string{"compute"}, Labels: map[string]string{"openstack-compute-node": "enabled"}}, vars)
+}
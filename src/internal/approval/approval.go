@@ -0,0 +1,285 @@
+// Package approval records plans as artifacts that a second person approves before apply changes a protected cluster
+package approval
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"sort"
+	"strings"
+	"time"
+
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/plan"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// ArtifactVersion is the format version of plan artifacts
+const ArtifactVersion = 1
+
+// EnvIdentity overrides the login name kictl records as planner and approver
+const EnvIdentity = "KICTL_IDENTITY"
+
+// signedPrefix separates approval signatures from other uses of the same SSH key
+const signedPrefix = "kictl-plan-approval"
+
+// Scope is the part of the bundle a plan covers, as selected by --target, --only and --skip
+type Scope struct {
+	Targets []string `json:"targets,omitempty"`
+	Only    []string `json:"only,omitempty"`
+	Skip    []string `json:"skip,omitempty"`
+}
+
+// Artifact is a plan saved for approval; apply --plan only runs it against the configuration, context and scope it was made for
+type Artifact struct {
+	Version      int           `json:"version"`
+	ConfigSource string        `json:"configSource"`
+	ConfigDigest string        `json:"configDigest"`
+	Context      string        `json:"context"`
+	Scope        Scope         `json:"scope"`
+	Planner      string        `json:"planner"`
+	CreatedAt    time.Time     `json:"createdAt"`
+	Changes      []plan.Change `json:"changes"`
+}
+
+// Approval is the consent of one person to apply a plan
+type Approval struct {
+	Approver   string    `json:"approver"`
+	PlanDigest string    `json:"planDigest"`
+	ApprovedAt time.Time `json:"approvedAt"`
+
+	// Signature is the base64 SSH signature of the approver over the plan digest, see Sign
+	Signature string `json:"signature,omitempty"`
+}
+
+// Identity returns the person running kictl: $KICTL_IDENTITY, or else the login name
+func Identity() string {
+	if identity := os.Getenv(EnvIdentity); identity != "" {
+		return identity
+	}
+	if current, err := user.Current(); err == nil && current.Username != "" {
+		return current.Username
+	}
+	return "unknown"
+}
+
+// Digest returns the SHA-256 of the artifact, which approvals refer to
+func (a *Artifact) Digest() string {
+	data, _ := json.Marshal(a)
+	return fmt.Sprintf("sha256:%x", sha256.Sum256(data))
+}
+
+// Check returns an error when the artifact was made for another configuration, context or scope
+func (a *Artifact) Check(configDigest, kubeContext string, scope Scope) error {
+	if a.ConfigDigest != configDigest {
+		return fmt.Errorf("the configuration changed since the plan was made (%s, now %s)", a.ConfigDigest, configDigest)
+	}
+	if a.Context != kubeContext {
+		return fmt.Errorf("the plan was made for context '%s', not '%s'", a.Context, kubeContext)
+	}
+	for _, selection := range []struct {
+		flag          string
+		planned, used []string
+	}{
+		{"--target", a.Scope.Targets, scope.Targets},
+		{"--only", a.Scope.Only, scope.Only},
+		{"--skip", a.Scope.Skip, scope.Skip},
+	} {
+		if !sameItems(selection.planned, selection.used) {
+			return fmt.Errorf("the plan was made with %s [%s], not [%s]", selection.flag,
+				strings.Join(selection.planned, ","), strings.Join(selection.used, ","))
+		}
+	}
+	return nil
+}
+
+// Write saves the artifact as JSON
+func Write(path string, artifact *Artifact) error {
+	data, err := json.MarshalIndent(artifact, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode plan: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write plan %s: %w", path, err)
+	}
+	return nil
+}
+
+// Read loads an artifact written by Write
+func Read(path string) (*Artifact, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plan %s: %w", path, err)
+	}
+	var artifact Artifact
+	if err := json.Unmarshal(data, &artifact); err != nil {
+		return nil, fmt.Errorf("failed to parse plan %s: %w", path, err)
+	}
+	if artifact.Version != ArtifactVersion {
+		return nil, fmt.Errorf("plan %s has version %d, expected %d; create it again with 'kictl plan --out'", path, artifact.Version, ArtifactVersion)
+	}
+	return &artifact, nil
+}
+
+// ApprovalsPath returns the default approvals file of a plan, next to it
+func ApprovalsPath(planPath string) string {
+	return planPath + ".approvals.json"
+}
+
+// ReadApprovals loads an approvals file; a missing file holds no approvals
+func ReadApprovals(path string) ([]Approval, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read approvals %s: %w", path, err)
+	}
+	var approvals []Approval
+	if err := json.Unmarshal(data, &approvals); err != nil {
+		return nil, fmt.Errorf("failed to parse approvals %s: %w", path, err)
+	}
+	return approvals, nil
+}
+
+// AddApproval records an approval, replacing an earlier one of the same approver for the same plan
+func AddApproval(path string, approval Approval) error {
+	approvals, err := ReadApprovals(path)
+	if err != nil {
+		return err
+	}
+	kept := approvals[:0]
+	for _, existing := range approvals {
+		if existing.Approver != approval.Approver || existing.PlanDigest != approval.PlanDigest {
+			kept = append(kept, existing)
+		}
+	}
+	data, err := json.MarshalIndent(append(kept, approval), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode approvals: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write approvals %s: %w", path, err)
+	}
+	return nil
+}
+
+// LoadSigner reads an unencrypted SSH private key to sign approvals with
+func LoadSigner(keyPath string) (ssh.Signer, error) {
+	data, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key %s: %w", keyPath, err)
+	}
+	signer, err := ssh.ParsePrivateKey(data)
+	var passphraseErr *ssh.PassphraseMissingError
+	if errors.As(err, &passphraseErr) {
+		return nil, fmt.Errorf("key %s is protected by a passphrase, which kictl cannot prompt for; use a dedicated approval key", keyPath)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse key %s: %w", keyPath, err)
+	}
+	return signer, nil
+}
+
+// Sign signs the approval of a plan digest by an approver
+func Sign(signer ssh.Signer, approver, planDigest string) (string, error) {
+	signature, err := signer.Sign(nil, signedMessage(approver, planDigest))
+	if err != nil {
+		return "", fmt.Errorf("failed to sign approval: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(ssh.Marshal(signature)), nil
+}
+
+// Verify returns the first approval that lets a second person's consent cover the artifact
+// The approver must differ from the planner and, when the policy lists approvers, be one of them.
+// Approvers listed with a public key must have signed their approval with it.
+func Verify(policy *config.ApprovalPolicy, artifact *Artifact, approvals []Approval) (Approval, error) {
+	digest := artifact.Digest()
+	var rejected []string
+	for _, approval := range approvals {
+		if approval.PlanDigest != digest {
+			continue
+		}
+		if err := checkApproval(policy, artifact, approval); err != nil {
+			rejected = append(rejected, err.Error())
+			continue
+		}
+		return approval, nil
+	}
+
+	if len(rejected) == 0 {
+		return Approval{}, fmt.Errorf("plan %s has no approval; a second person must run 'kictl approve' on it", digest)
+	}
+	return Approval{}, fmt.Errorf("plan %s has no valid approval: %s", digest, strings.Join(rejected, "; "))
+}
+
+// checkApproval applies the two-person rule and the policy to one approval of the artifact
+func checkApproval(policy *config.ApprovalPolicy, artifact *Artifact, approval Approval) error {
+	if approval.Approver == artifact.Planner {
+		return fmt.Errorf("%s made the plan and cannot approve it", approval.Approver)
+	}
+	if policy == nil || len(policy.Approvers) == 0 {
+		return nil
+	}
+	approver, listed := policy.Approver(approval.Approver)
+	if !listed {
+		return fmt.Errorf("%s is not an approver", approval.Approver)
+	}
+	if approver.PublicKey == "" {
+		return nil
+	}
+	if approval.Signature == "" {
+		return fmt.Errorf("approval of %s is not signed", approval.Approver)
+	}
+	if err := verifySignature(approver.PublicKey, approval); err != nil {
+		return fmt.Errorf("approval of %s: %w", approval.Approver, err)
+	}
+	return nil
+}
+
+// verifySignature checks the signature of an approval against the approver's public key
+func verifySignature(publicKey string, approval Approval) error {
+	key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(publicKey))
+	if err != nil {
+		return fmt.Errorf("invalid public key: %w", err)
+	}
+	data, err := base64.StdEncoding.DecodeString(approval.Signature)
+	if err != nil {
+		return fmt.Errorf("invalid signature encoding: %w", err)
+	}
+	var signature ssh.Signature
+	if err := ssh.Unmarshal(data, &signature); err != nil {
+		return fmt.Errorf("invalid signature: %w", err)
+	}
+	if err := key.Verify(signedMessage(approval.Approver, approval.PlanDigest), &signature); err != nil {
+		return fmt.Errorf("signature does not match the key of the approver")
+	}
+	return nil
+}
+
+// signedMessage is what an approval signature covers: the approver and the plan digest
+func signedMessage(approver, planDigest string) []byte {
+	return []byte(signedPrefix + "\n" + approver + "\n" + planDigest)
+}
+
+// sameItems reports whether two lists hold the same items, in any order
+func sameItems(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	sortedA := append([]string(nil), a...)
+	sortedB := append([]string(nil), b...)
+	sort.Strings(sortedA)
+	sort.Strings(sortedB)
+	for i := range sortedA {
+		if sortedA[i] != sortedB[i] {
+			return false
+		}
+	}
+	return true
+}
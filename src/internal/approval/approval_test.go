@@ -0,0 +1,235 @@
+// Package approval provides unit tests for plan artifacts and their approvals
+// WHY: Protected clusters rely on these checks to enforce the two-person rule
+package approval
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/plan"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+// testArtifact returns a plan made by alice for the prod context
+func testArtifact() *Artifact {
+	return &Artifact{
+		Version:      ArtifactVersion,
+		ConfigSource: "prod.yaml",
+		ConfigDigest: "sha256:abc",
+		Context:      "prod",
+		Scope:        Scope{Targets: []string{"vlan.management"}},
+		Planner:      "alice",
+		CreatedAt:    time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC),
+		Changes: []plan.Change{
+			{Node: "rsb2", Kind: "vlan", Key: "management", Op: plan.OpAdd, NewValue: "eth0.100 192.168.100.12/24"},
+		},
+	}
+}
+
+// testSigner returns a fresh SSH key and its authorized_keys line
+func testSigner(t *testing.T) (ssh.Signer, string) {
+	t.Helper()
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	signer, err := ssh.NewSignerFromKey(private)
+	require.NoError(t, err)
+	return signer, string(ssh.MarshalAuthorizedKey(signer.PublicKey()))
+}
+
+// TestArtifact_WriteRead tests that a saved plan keeps its digest
+// WHY: Approvals refer to the digest, so reading the artifact back must not change it
+func TestArtifact_WriteRead(t *testing.T) {
+	// Given: A saved artifact
+	path := filepath.Join(t.TempDir(), "plan.json")
+	artifact := testArtifact()
+	require.NoError(t, Write(path, artifact))
+
+	// When: Read it back
+	loaded, err := Read(path)
+
+	// Then: Content and digest are unchanged
+	require.NoError(t, err)
+	assert.Equal(t, artifact, loaded)
+	assert.Equal(t, artifact.Digest(), loaded.Digest())
+}
+
+// TestArtifact_Check tests matching a plan against the apply about to run
+// WHY: An approved plan must not cover a different configuration, cluster or scope
+func TestArtifact_Check(t *testing.T) {
+	tests := []struct {
+		name          string
+		configDigest  string
+		context       string
+		scope         Scope
+		expectedError string
+	}{
+		{
+			name:         "matching_apply",
+			configDigest: "sha256:abc",
+			context:      "prod",
+			scope:        Scope{Targets: []string{"vlan.management"}},
+		},
+		{
+			name:          "changed_configuration",
+			configDigest:  "sha256:def",
+			context:       "prod",
+			scope:         Scope{Targets: []string{"vlan.management"}},
+			expectedError: "the configuration changed since the plan was made",
+		},
+		{
+			name:          "other_context",
+			configDigest:  "sha256:abc",
+			context:       "staging",
+			scope:         Scope{Targets: []string{"vlan.management"}},
+			expectedError: "the plan was made for context 'prod', not 'staging'",
+		},
+		{
+			name:          "wider_scope",
+			configDigest:  "sha256:abc",
+			context:       "prod",
+			expectedError: "the plan was made with --target [vlan.management], not []",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// When: Check the plan against the apply
+			err := testArtifact().Check(tt.configDigest, tt.context, tt.scope)
+
+			// Then: Only a matching apply passes
+			if tt.expectedError == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.expectedError)
+		})
+	}
+}
+
+// TestVerify tests the two-person rule and the approver policy
+// WHY: Apply to a protected context runs only with an approval that satisfies every rule
+func TestVerify(t *testing.T) {
+	signer, publicKey := testSigner(t)
+	otherSigner, _ := testSigner(t)
+	digest := testArtifact().Digest()
+
+	sign := func(s ssh.Signer, approver string) string {
+		signature, err := Sign(s, approver, digest)
+		require.NoError(t, err)
+		return signature
+	}
+
+	tests := []struct {
+		name             string
+		policy           *config.ApprovalPolicy
+		approvals        []Approval
+		expectedApprover string
+		expectedError    string
+	}{
+		{
+			name:             "second_person_without_approver_list",
+			policy:           &config.ApprovalPolicy{ProtectedContexts: []string{"prod"}},
+			approvals:        []Approval{{Approver: "bob", PlanDigest: digest}},
+			expectedApprover: "bob",
+		},
+		{
+			name:          "no_approvals",
+			policy:        &config.ApprovalPolicy{ProtectedContexts: []string{"prod"}},
+			expectedError: "has no approval",
+		},
+		{
+			name:          "planner_cannot_approve",
+			policy:        &config.ApprovalPolicy{ProtectedContexts: []string{"prod"}},
+			approvals:     []Approval{{Approver: "alice", PlanDigest: digest}},
+			expectedError: "alice made the plan and cannot approve it",
+		},
+		{
+			name:          "approval_of_another_plan_is_ignored",
+			policy:        &config.ApprovalPolicy{ProtectedContexts: []string{"prod"}},
+			approvals:     []Approval{{Approver: "bob", PlanDigest: "sha256:other"}},
+			expectedError: "has no approval",
+		},
+		{
+			name: "unlisted_approver",
+			policy: &config.ApprovalPolicy{ProtectedContexts: []string{"prod"},
+				Approvers: []config.Approver{{Name: "carol"}}},
+			approvals:     []Approval{{Approver: "bob", PlanDigest: digest}},
+			expectedError: "bob is not an approver",
+		},
+		{
+			name: "signed_by_approver_key",
+			policy: &config.ApprovalPolicy{ProtectedContexts: []string{"prod"},
+				Approvers: []config.Approver{{Name: "bob", PublicKey: publicKey}}},
+			approvals:        []Approval{{Approver: "bob", PlanDigest: digest, Signature: sign(signer, "bob")}},
+			expectedApprover: "bob",
+		},
+		{
+			name: "unsigned_approval_of_keyed_approver",
+			policy: &config.ApprovalPolicy{ProtectedContexts: []string{"prod"},
+				Approvers: []config.Approver{{Name: "bob", PublicKey: publicKey}}},
+			approvals:     []Approval{{Approver: "bob", PlanDigest: digest}},
+			expectedError: "approval of bob is not signed",
+		},
+		{
+			name: "signed_with_another_key",
+			policy: &config.ApprovalPolicy{ProtectedContexts: []string{"prod"},
+				Approvers: []config.Approver{{Name: "bob", PublicKey: publicKey}}},
+			approvals:     []Approval{{Approver: "bob", PlanDigest: digest, Signature: sign(otherSigner, "bob")}},
+			expectedError: "signature does not match the key of the approver",
+		},
+		{
+			name: "signature_of_another_approver_name",
+			policy: &config.ApprovalPolicy{ProtectedContexts: []string{"prod"},
+				Approvers: []config.Approver{{Name: "bob", PublicKey: publicKey}}},
+			approvals:     []Approval{{Approver: "bob", PlanDigest: digest, Signature: sign(signer, "mallory")}},
+			expectedError: "signature does not match the key of the approver",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// When: Verify the approvals of the plan
+			granted, err := Verify(tt.policy, testArtifact(), tt.approvals)
+
+			// Then: The valid approval is returned, or the reason none is
+			if tt.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedApprover, granted.Approver)
+		})
+	}
+}
+
+// TestAddApproval tests recording approvals in the approvals file
+// WHY: Approving a plan again must replace the earlier approval instead of piling up entries
+func TestAddApproval(t *testing.T) {
+	// Given: An approvals file that does not exist yet
+	path := filepath.Join(t.TempDir(), "plan.json.approvals.json")
+	approvals, err := ReadApprovals(path)
+	require.NoError(t, err)
+	assert.Empty(t, approvals)
+
+	// When: Two people approve, and the first one approves again
+	require.NoError(t, AddApproval(path, Approval{Approver: "bob", PlanDigest: "sha256:abc"}))
+	require.NoError(t, AddApproval(path, Approval{Approver: "carol", PlanDigest: "sha256:abc"}))
+	require.NoError(t, AddApproval(path, Approval{Approver: "bob", PlanDigest: "sha256:abc", Signature: "c2ln"}))
+
+	// Then: One approval per person remains, with the latest of bob
+	approvals, err = ReadApprovals(path)
+	require.NoError(t, err)
+	assert.Equal(t, []Approval{
+		{Approver: "carol", PlanDigest: "sha256:abc"},
+		{Approver: "bob", PlanDigest: "sha256:abc", Signature: "c2ln"},
+	}, approvals)
+}
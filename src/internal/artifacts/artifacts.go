@@ -0,0 +1,94 @@
+// Package artifacts copies run folders and reports to S3-compatible storage such as MinIO
+package artifacts
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Runner runs the aws CLI with the given arguments and returns its combined output
+type Runner func(ctx context.Context, args ...string) (string, error)
+
+// Uploader stores the artifacts of each run under <prefix>/<run ID>/ of a bucket
+type Uploader struct {
+	bucket   string
+	prefix   string
+	endpoint string
+	run      Runner
+}
+
+// NewUploader creates an uploader for an s3://bucket[/prefix] destination
+// endpoint selects an S3-compatible server such as MinIO; empty uses AWS. A nil runner runs the local aws CLI.
+func NewUploader(destination, endpoint string, run Runner) (*Uploader, error) {
+	rest, ok := strings.CutPrefix(destination, "s3://")
+	if !ok {
+		return nil, fmt.Errorf("artifacts destination %s must be an s3://bucket[/prefix] URL", destination)
+	}
+	bucket, prefix, _ := strings.Cut(rest, "/")
+	if bucket == "" {
+		return nil, fmt.Errorf("artifacts destination %s names no bucket", destination)
+	}
+	if endpoint != "" && !strings.HasPrefix(endpoint, "https://") && !strings.HasPrefix(endpoint, "http://") {
+		return nil, fmt.Errorf("artifacts endpoint %s must be an http(s):// URL", endpoint)
+	}
+	if run == nil {
+		run = runAWS
+	}
+	return &Uploader{bucket: bucket, prefix: strings.Trim(prefix, "/"), endpoint: endpoint, run: run}, nil
+}
+
+// RunURL returns where the artifacts of a run are stored
+func (u *Uploader) RunURL(runID string) string {
+	if u.prefix == "" {
+		return fmt.Sprintf("s3://%s/%s/", u.bucket, runID)
+	}
+	return fmt.Sprintf("s3://%s/%s/%s/", u.bucket, u.prefix, runID)
+}
+
+// UploadRun copies a run folder and the reports written outside it, which go to reports/ of the run
+// Reports that were not written, e.g. because the run failed early, are skipped.
+func (u *Uploader) UploadRun(ctx context.Context, runID, dir string, reports []string) (string, error) {
+	runURL := u.RunURL(runID)
+	if err := u.copy(ctx, dir, runURL, "--recursive"); err != nil {
+		return "", fmt.Errorf("failed to upload run folder %s: %w", dir, err)
+	}
+
+	for _, report := range reports {
+		if _, err := os.Stat(report); err != nil {
+			continue
+		}
+		if err := u.copy(ctx, report, runURL+"reports/"+filepath.Base(report)); err != nil {
+			return "", fmt.Errorf("failed to upload report %s: %w", report, err)
+		}
+	}
+	return runURL, nil
+}
+
+// copy runs aws s3 cp against the configured endpoint
+func (u *Uploader) copy(ctx context.Context, source, target string, extra ...string) error {
+	args := []string{"s3", "cp", "--only-show-errors"}
+	if u.endpoint != "" {
+		args = append(args, "--endpoint-url", u.endpoint)
+	}
+	args = append(args, extra...)
+	args = append(args, source, target)
+
+	output, err := u.run(ctx, args...)
+	if err != nil {
+		if output = strings.TrimSpace(output); output != "" {
+			return fmt.Errorf("%w: %s", err, output)
+		}
+		return err
+	}
+	return nil
+}
+
+// runAWS runs the local aws CLI, which takes its credentials from the usual AWS_* variables
+func runAWS(ctx context.Context, args ...string) (string, error) {
+	output, err := exec.CommandContext(ctx, "aws", args...).CombinedOutput()
+	return string(output), err
+}
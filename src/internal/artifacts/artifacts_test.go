@@ -0,0 +1,135 @@
+// Package artifacts provides unit tests for uploading run artifacts
+// WHY: Audit evidence must land under one prefix per run, on the configured S3-compatible endpoint
+package artifacts
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingRunner records aws CLI runs and answers them with a fixed output and error
+func recordingRunner(calls *[][]string, output string, err error) Runner {
+	return func(ctx context.Context, args ...string) (string, error) {
+		*calls = append(*calls, args)
+		return output, err
+	}
+}
+
+// TestNewUploader tests parsing of artifact destinations
+// WHY: A malformed destination must fail before the run, not when its evidence is uploaded
+func TestNewUploader(t *testing.T) {
+	tests := []struct {
+		name          string
+		destination   string
+		endpoint      string
+		expectedURL   string
+		expectedError string
+	}{
+		{
+			name:        "bucket_with_prefix",
+			destination: "s3://audit/kictl/prod/",
+			endpoint:    "https://minio.lab:9000",
+			expectedURL: "s3://audit/kictl/prod/20261016-101500-apply-1/",
+		},
+		{
+			name:        "bucket_only",
+			destination: "s3://audit",
+			expectedURL: "s3://audit/20261016-101500-apply-1/",
+		},
+		{
+			name:          "not_an_s3_url",
+			destination:   "https://minio.lab:9000/audit",
+			expectedError: "must be an s3://bucket[/prefix] URL",
+		},
+		{
+			name:          "no_bucket",
+			destination:   "s3:///kictl",
+			expectedError: "names no bucket",
+		},
+		{
+			name:          "endpoint_without_scheme",
+			destination:   "s3://audit",
+			endpoint:      "minio.lab:9000",
+			expectedError: "must be an http(s):// URL",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A destination and endpoint
+
+			// When: Create an uploader
+			uploader, err := NewUploader(tt.destination, tt.endpoint, nil)
+
+			// Then: Runs are stored under their own prefix, or the destination is refused
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedURL, uploader.RunURL("20261016-101500-apply-1"))
+		})
+	}
+}
+
+// TestUploader_UploadRun tests copying a run folder and its reports
+// WHY: Reports written outside the run folder are evidence too, and a failed copy must be reported
+func TestUploader_UploadRun(t *testing.T) {
+	dir := t.TempDir()
+	report := filepath.Join(dir, "report.xml")
+	require.NoError(t, os.WriteFile(report, []byte("<testsuites/>"), 0644))
+	runDir := filepath.Join(dir, "20261016-101500-apply-1")
+	endpoint := []string{"s3", "cp", "--only-show-errors", "--endpoint-url", "https://minio.lab:9000"}
+
+	tests := []struct {
+		name          string
+		runErr        error
+		output        string
+		expectedCalls [][]string
+		expectedError string
+	}{
+		{
+			name: "run_folder_and_written_reports",
+			expectedCalls: [][]string{
+				append(append([]string{}, endpoint...), "--recursive", runDir, "s3://audit/kictl/20261016-101500-apply-1/"),
+				append(append([]string{}, endpoint...), report, "s3://audit/kictl/20261016-101500-apply-1/reports/report.xml"),
+			},
+		},
+		{
+			name:   "failed_upload",
+			runErr: errors.New("exit status 1"),
+			output: "upload failed: Could not connect to the endpoint URL\n",
+			expectedCalls: [][]string{
+				append(append([]string{}, endpoint...), "--recursive", runDir, "s3://audit/kictl/20261016-101500-apply-1/"),
+			},
+			expectedError: "failed to upload run folder " + runDir + ": exit status 1: upload failed: Could not connect to the endpoint URL",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: An uploader for a MinIO bucket
+			var calls [][]string
+			uploader, err := NewUploader("s3://audit/kictl", "https://minio.lab:9000", recordingRunner(&calls, tt.output, tt.runErr))
+			require.NoError(t, err)
+
+			// When: Upload a run with one written and one missing report
+			url, err := uploader.UploadRun(context.Background(), "20261016-101500-apply-1", runDir, []string{report, filepath.Join(dir, "missing.xml")})
+
+			// Then: The run folder and written reports are copied under the run's prefix
+			assert.Equal(t, tt.expectedCalls, calls)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "s3://audit/kictl/20261016-101500-apply-1/", url)
+		})
+	}
+}
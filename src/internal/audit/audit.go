@@ -0,0 +1,228 @@
+// Package audit keeps an append-only record of every change kictl makes to nodes and the cluster
+// Unlike run logs, which are pruned with their runs, the audit trail answers who changed what, when and from which configuration.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"k8ostack-ictl/internal/kubectl"
+)
+
+// Verbs of audited calls
+const (
+	VerbLabel       = "label"
+	VerbUnlabel     = "unlabel"
+	VerbNodeCommand = "node-command"
+	VerbKubectl     = "kubectl"
+)
+
+// Results of audited calls
+const (
+	ResultSucceeded = "succeeded"
+	ResultFailed    = "failed"
+)
+
+// DestinationEvents writes the audit trail as Kubernetes Events on the nodes instead of a file
+const DestinationEvents = "events"
+
+// FileName is the audit file in the workspace
+const FileName = "audit.jsonl"
+
+// Record is one change made to a node or the cluster, or attempted
+type Record struct {
+	Time         time.Time `json:"time"`
+	User         string    `json:"user"`
+	Host         string    `json:"host,omitempty"`
+	Context      string    `json:"context,omitempty"`
+	Operation    string    `json:"operation,omitempty"` // apply or delete
+	Config       string    `json:"config,omitempty"`
+	ConfigDigest string    `json:"configDigest,omitempty"`
+	Verb         string    `json:"verb"`
+	Node         string    `json:"node"`    // empty for changes to other objects, e.g. NetworkAttachmentDefinitions
+	Command      string    `json:"command"` // kubectl arguments, or the node script
+	Result       string    `json:"result"`
+	Error        string    `json:"error,omitempty"`
+}
+
+// Sink stores audit records
+type Sink interface {
+	Write(ctx context.Context, record Record) error
+}
+
+// Log stamps records with the time and the run's user, context and configuration and writes them to a sink
+// Write failures do not stop the change being audited; the first one is kept for Err.
+type Log struct {
+	sink Sink
+	base Record
+	now  func() time.Time
+
+	mu  sync.Mutex
+	err error
+}
+
+// NewLog creates a log whose records start from base, which holds the fields shared by the run
+func NewLog(sink Sink, base Record) *Log {
+	return &Log{sink: sink, base: base, now: time.Now}
+}
+
+// Record writes the outcome of a call that changed, or tried to change, a node
+func (l *Log) Record(ctx context.Context, verb, node, command string, success bool, output string, callErr error) error {
+	record := l.base
+	record.Time = l.now().UTC()
+	record.Verb = verb
+	record.Node = node
+	record.Command = command
+	record.Result = ResultSucceeded
+	switch {
+	case callErr != nil:
+		record.Result, record.Error = ResultFailed, callErr.Error()
+	case !success:
+		record.Result, record.Error = ResultFailed, strings.TrimSpace(output)
+	}
+
+	err := l.sink.Write(ctx, record)
+	if err != nil {
+		l.mu.Lock()
+		if l.err == nil {
+			l.err = err
+		}
+		l.mu.Unlock()
+	}
+	return err
+}
+
+// Err returns the first record that could not be written, or nil
+func (l *Log) Err() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.err
+}
+
+// FileSink appends records to a file as JSON lines
+type FileSink struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// OpenFile opens an audit file for appending, creating it and its directory if needed
+func OpenFile(path string) (*FileSink, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create audit directory: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit file: %w", err)
+	}
+	return &FileSink{file: file}, nil
+}
+
+// Write appends a record as one line, so concurrent node workers never interleave records
+func (s *FileSink) Write(_ context.Context, record Record) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit record: %w", err)
+	}
+	return nil
+}
+
+// Close closes the audit file
+func (s *FileSink) Close() error {
+	return s.file.Close()
+}
+
+// EventNamespace holds the audit Events; node Events live in the default namespace like those of the kubelet
+const EventNamespace = "default"
+
+// EventSink writes records as Events involving the changed node, where kubectl describe node shows them
+// The full record is kept in the kictl.icycloud.io/audit annotation of the Event.
+type EventSink struct {
+	run kubectl.Runner
+}
+
+// NewEventSink creates a sink writing through the kubectl of an executor that is not itself audited
+func NewEventSink(run kubectl.Runner) *EventSink {
+	return &EventSink{run: run}
+}
+
+// Write creates the Event of a record
+func (s *EventSink) Write(ctx context.Context, record Record) error {
+	manifest, err := eventManifest(record)
+	if err != nil {
+		return err
+	}
+	if output, err := s.run(ctx, manifest, "create", "-f", "-"); err != nil {
+		return fmt.Errorf("failed to create audit event for %s: %s", subject(record.Node), strings.TrimSpace(output))
+	}
+	return nil
+}
+
+// subject names what a record changed: its node, or the cluster for records without one
+func subject(node string) string {
+	if node == "" {
+		return "the cluster"
+	}
+	return "node " + node
+}
+
+// eventManifest renders a record as a core/v1 Event on its node, or on the Event namespace for records without one
+func eventManifest(record Record) ([]byte, error) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+	eventType, reason := "Normal", "Kictl"+eventReason(record.Verb)
+	if record.Result == ResultFailed {
+		eventType = "Warning"
+	}
+	message := fmt.Sprintf("%s %s by %s: %s", record.Verb, record.Result, record.User, record.Command)
+	if record.Error != "" {
+		message += ": " + record.Error
+	}
+	stamp := record.Time.Format(time.RFC3339)
+	name, involved := record.Node+".kictl-", map[string]string{"apiVersion": "v1", "kind": "Node", "name": record.Node}
+	if record.Node == "" {
+		name, involved = "kictl-", map[string]string{"apiVersion": "v1", "kind": "Namespace", "name": EventNamespace}
+	}
+
+	return json.Marshal(map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Event",
+		"metadata": map[string]interface{}{
+			"generateName": name,
+			"namespace":    EventNamespace,
+			"labels":       map[string]string{"app.kubernetes.io/managed-by": "kictl"},
+			"annotations":  map[string]string{"kictl.icycloud.io/audit": string(data)},
+		},
+		"involvedObject": involved,
+		"reason":         reason,
+		"message":        message,
+		"type":           eventType,
+		"source":         map[string]string{"component": "kictl", "host": record.Host},
+		"firstTimestamp": stamp,
+		"lastTimestamp":  stamp,
+		"count":          1,
+	})
+}
+
+// eventReason turns a verb such as node-command into the CamelCase of Event reasons
+func eventReason(verb string) string {
+	var reason strings.Builder
+	for _, word := range strings.Split(verb, "-") {
+		if word != "" {
+			reason.WriteString(strings.ToUpper(word[:1]) + word[1:])
+		}
+	}
+	return reason.String()
+}
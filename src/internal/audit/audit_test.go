@@ -0,0 +1,137 @@
+// Package audit provides unit tests for the audit trail
+// WHY: The audit trail is what answers who changed a node, so records must be complete and never overwritten
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLog_FileSink tests appending records to the audit file
+// WHY: Each run adds to the same file, so earlier records must survive and failures must say why
+func TestLog_FileSink(t *testing.T) {
+	// Given: An audit file holding a record of an earlier run
+	path := filepath.Join(t.TempDir(), "audit", FileName)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte("{\"verb\":\"label\"}\n"), 0600))
+	sink, err := OpenFile(path)
+	require.NoError(t, err)
+	log := NewLog(sink, Record{User: "alice", Context: "prod-east", Operation: "apply", ConfigDigest: "sha256:abc"})
+	log.now = func() time.Time { return time.Date(2026, 10, 16, 10, 15, 0, 0, time.UTC) }
+
+	// When: Record a label change and a failed VLAN script
+	require.NoError(t, log.Record(context.Background(), VerbLabel, "rsb2", "label node rsb2 role=compute", true, "node/rsb2 labeled", nil))
+	require.NoError(t, log.Record(context.Background(), VerbNodeCommand, "rsb3", "ip link add link eth0 name eth0.100 type vlan id 100", false, "RTNETLINK answers: File exists\n", nil))
+	require.NoError(t, sink.Close())
+
+	// Then: Both records follow the earlier one with the run's fields
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 3)
+
+	var labeled, failed Record
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &labeled))
+	require.NoError(t, json.Unmarshal([]byte(lines[2]), &failed))
+	assert.Equal(t, Record{
+		Time: time.Date(2026, 10, 16, 10, 15, 0, 0, time.UTC), User: "alice", Context: "prod-east", Operation: "apply",
+		ConfigDigest: "sha256:abc", Verb: VerbLabel, Node: "rsb2", Command: "label node rsb2 role=compute", Result: ResultSucceeded,
+	}, labeled)
+	assert.Equal(t, ResultFailed, failed.Result)
+	assert.Equal(t, "RTNETLINK answers: File exists", failed.Error)
+	assert.NoError(t, log.Err())
+}
+
+// TestEventSink tests writing records as Events on the nodes
+// WHY: Clusters without a shared file system keep the audit trail in the API server, next to the node it concerns
+func TestEventSink(t *testing.T) {
+	tests := []struct {
+		name         string
+		record       Record
+		runErr       error
+		expectedType string
+		expectedObj  map[string]string
+		expectError  string
+	}{
+		{
+			name:         "succeeded_change",
+			record:       Record{User: "alice", Verb: VerbNodeCommand, Node: "rsb2", Command: "ip link set eth0.100 up", Result: ResultSucceeded},
+			expectedType: "Normal",
+			expectedObj:  map[string]string{"apiVersion": "v1", "kind": "Node", "name": "rsb2"},
+		},
+		{
+			name:         "failed_change",
+			record:       Record{User: "alice", Verb: VerbUnlabel, Node: "rsb2", Command: "label node rsb2 role-", Result: ResultFailed, Error: "forbidden"},
+			expectedType: "Warning",
+			expectedObj:  map[string]string{"apiVersion": "v1", "kind": "Node", "name": "rsb2"},
+		},
+		{
+			name:         "cluster_change",
+			record:       Record{User: "alice", Verb: VerbKubectl, Command: "apply -f -", Result: ResultSucceeded},
+			expectedType: "Normal",
+			expectedObj:  map[string]string{"apiVersion": "v1", "kind": "Namespace", "name": EventNamespace},
+		},
+		{
+			name:        "event_not_created",
+			record:      Record{User: "alice", Verb: VerbLabel, Node: "rsb2", Result: ResultSucceeded},
+			runErr:      errors.New("exit status 1"),
+			expectError: "failed to create audit event for node rsb2: events is forbidden",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A sink whose kubectl is recorded
+			var args []string
+			var manifest []byte
+			sink := NewEventSink(func(ctx context.Context, stdin []byte, kubectlArgs ...string) (string, error) {
+				args, manifest = kubectlArgs, stdin
+				if tt.runErr != nil {
+					return "events is forbidden\n", tt.runErr
+				}
+				return "event/rsb2.kictl-x7k2p created", nil
+			})
+
+			// When: Write the record
+			err := sink.Write(context.Background(), tt.record)
+
+			// Then: An Event involving the node, or the namespace for cluster changes, is created, carrying the full record
+			if tt.expectError != "" {
+				assert.EqualError(t, err, tt.expectError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, []string{"create", "-f", "-"}, args)
+
+			var event struct {
+				Metadata struct {
+					Namespace   string            `json:"namespace"`
+					Annotations map[string]string `json:"annotations"`
+				} `json:"metadata"`
+				InvolvedObject map[string]string `json:"involvedObject"`
+				Reason         string            `json:"reason"`
+				Type           string            `json:"type"`
+			}
+			require.NoError(t, json.Unmarshal(manifest, &event))
+			assert.Equal(t, EventNamespace, event.Metadata.Namespace)
+			assert.Equal(t, tt.expectedObj, event.InvolvedObject)
+			assert.Equal(t, "Kictl"+eventReason(tt.record.Verb), event.Reason)
+			assert.Equal(t, tt.expectedType, event.Type)
+
+			var record Record
+			require.NoError(t, json.Unmarshal([]byte(event.Metadata.Annotations["kictl.icycloud.io/audit"]), &record))
+			assert.Equal(t, tt.record.Command, record.Command)
+		})
+	}
+
+	assert.Equal(t, "NodeCommand", eventReason(VerbNodeCommand))
+}
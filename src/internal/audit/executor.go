@@ -0,0 +1,86 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"k8ostack-ictl/internal/kubectl"
+	"k8ostack-ictl/internal/logging"
+)
+
+// auditedExecutor records the label changes, node-changing scripts and kubectl writes of the wrapped executor
+// Dry runs change nothing, so their calls are not recorded.
+type auditedExecutor struct {
+	kubectl.DryRunExecutor
+	log    *Log
+	logger logging.Logger
+}
+
+// NewExecutor wraps an executor so that every change it makes to a node is written to the audit log
+func NewExecutor(executor kubectl.DryRunExecutor, log *Log, logger logging.Logger) kubectl.DryRunExecutor {
+	return &auditedExecutor{DryRunExecutor: executor, log: log, logger: logger}
+}
+
+// record writes the outcome of a call, warning when the audit log cannot take it
+func (e *auditedExecutor) record(ctx context.Context, verb, node, command string, success bool, output string, err error) {
+	if recordErr := e.log.Record(ctx, verb, node, command, success, output, err); recordErr != nil {
+		e.logger.Warn(fmt.Sprintf("⚠️  Failed to audit %s on %s: %v", verb, subject(node), recordErr))
+	}
+}
+
+// LabelNode applies a label to a node
+func (e *auditedExecutor) LabelNode(ctx context.Context, nodeName, label string, overwrite bool) (bool, string, error) {
+	success, output, err := e.DryRunExecutor.LabelNode(ctx, nodeName, label, overwrite)
+	if !e.IsDryRun() {
+		command := "label node " + nodeName + " " + label
+		if overwrite {
+			command += " --overwrite"
+		}
+		e.record(ctx, VerbLabel, nodeName, command, success, output, err)
+	}
+	return success, output, err
+}
+
+// LabelNodes applies the same labels to several nodes, recording one entry per node
+func (e *auditedExecutor) LabelNodes(ctx context.Context, nodeNames []string, labels map[string]string, overwrite bool) (bool, string, error) {
+	success, output, err := e.DryRunExecutor.LabelNodes(ctx, nodeNames, labels, overwrite)
+	if !e.IsDryRun() {
+		for _, nodeName := range nodeNames {
+			command := "label node " + nodeName + " " + strings.Join(kubectl.FormatLabels(labels), " ")
+			if overwrite {
+				command += " --overwrite"
+			}
+			e.record(ctx, VerbLabel, nodeName, command, success, output, err)
+		}
+	}
+	return success, output, err
+}
+
+// UnlabelNode removes a label from a node
+func (e *auditedExecutor) UnlabelNode(ctx context.Context, nodeName, labelKey string) (bool, string, error) {
+	success, output, err := e.DryRunExecutor.UnlabelNode(ctx, nodeName, labelKey)
+	if !e.IsDryRun() {
+		e.record(ctx, VerbUnlabel, nodeName, "label node "+nodeName+" "+labelKey+"-", success, output, err)
+	}
+	return success, output, err
+}
+
+// ExecNodeCommand executes a command on a node, recording scripts that may change it such as VLAN setup
+func (e *auditedExecutor) ExecNodeCommand(ctx context.Context, nodeName, command string) (bool, string, error) {
+	success, output, err := e.DryRunExecutor.ExecNodeCommand(ctx, nodeName, command)
+	if !e.IsDryRun() && kubectl.IsMutatingHostCommand(command) {
+		e.record(ctx, VerbNodeCommand, nodeName, command, success, output, err)
+	}
+	return success, output, err
+}
+
+// RunKubectl runs a kubectl command, recording its writes such as ownership annotations on a node or applied
+// NetworkAttachmentDefinitions; writes to objects other than nodes are recorded without a node
+func (e *auditedExecutor) RunKubectl(ctx context.Context, stdin []byte, args ...string) (string, error) {
+	output, err := e.DryRunExecutor.RunKubectl(ctx, stdin, args...)
+	if !e.IsDryRun() && kubectl.IsMutatingCommand(args) {
+		e.record(ctx, VerbKubectl, kubectl.CommandNode(args), strings.Join(args, " "), err == nil, output, err)
+	}
+	return output, err
+}
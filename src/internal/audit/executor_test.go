@@ -0,0 +1,104 @@
+// Package audit provides unit tests for the audited executor
+// WHY: Every label, VLAN and kubectl change must reach the audit trail, and nothing that leaves the cluster untouched
+package audit
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"k8ostack-ictl/internal/labeler"
+	"k8ostack-ictl/internal/logging"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingSink keeps the records written to it
+type recordingSink struct {
+	records []Record
+	err     error
+}
+
+func (s *recordingSink) Write(_ context.Context, record Record) error {
+	s.records = append(s.records, record)
+	return s.err
+}
+
+// TestAuditedExecutor tests which calls are recorded
+// WHY: Dry runs and inspection scripts change nothing, while failed changes matter as much as successful ones
+func TestAuditedExecutor(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("changes", func(t *testing.T) {
+		// Given: An executor that is not in dry run
+		inner := labeler.NewMockDryRunExecutor()
+		inner.On("IsDryRun").Return(false)
+		inner.On("LabelNodes", ctx, []string{"rsb2", "rsb3"}, map[string]string{"role": "compute"}, true).Return(true, "", nil)
+		inner.On("UnlabelNode", ctx, "rsb4", "role").Return(false, "", errors.New("nodes \"rsb4\" not found"))
+		inner.On("ExecNodeCommand", ctx, "rsb2", mock.Anything).Return(true, "", nil)
+		inner.On("RunKubectl", ctx, mock.Anything, mock.Anything).Return("", nil)
+		sink := &recordingSink{}
+		executor := NewExecutor(inner, NewLog(sink, Record{User: "alice"}), logging.NewRecordingLogger())
+
+		// When: Label, unlabel, run an inspecting and a changing script and annotate, read and create with kubectl
+		executor.LabelNodes(ctx, []string{"rsb2", "rsb3"}, map[string]string{"role": "compute"}, true)
+		executor.UnlabelNode(ctx, "rsb4", "role")
+		executor.ExecNodeCommand(ctx, "rsb2", "ip -o addr show")
+		executor.ExecNodeCommand(ctx, "rsb2", "ip link delete eth0.100")
+		executor.RunKubectl(ctx, nil, "annotate", "node", "rsb3", "--overwrite", "kictl.icycloud.io/owner=a")
+		executor.RunKubectl(ctx, nil, "get", "node", "rsb3")
+		executor.RunKubectl(ctx, []byte("{}"), "create", "-f", "-")
+
+		// Then: Each change has a record, the inspection and the read none
+		commands := make([]string, 0, len(sink.records))
+		for _, record := range sink.records {
+			assert.Equal(t, "alice", record.User)
+			commands = append(commands, record.Verb+" "+record.Node+" "+record.Result+": "+record.Command)
+		}
+		assert.Equal(t, []string{
+			"label rsb2 succeeded: label node rsb2 role=compute --overwrite",
+			"label rsb3 succeeded: label node rsb3 role=compute --overwrite",
+			"unlabel rsb4 failed: label node rsb4 role-",
+			"node-command rsb2 succeeded: ip link delete eth0.100",
+			"kubectl rsb3 succeeded: annotate node rsb3 --overwrite kictl.icycloud.io/owner=a",
+			"kubectl  succeeded: create -f -",
+		}, commands)
+	})
+
+	t.Run("dry_run", func(t *testing.T) {
+		// Given: An executor in dry run
+		inner := labeler.NewMockDryRunExecutor()
+		inner.On("IsDryRun").Return(true)
+		inner.On("LabelNode", ctx, "rsb2", "role=compute", false).Return(true, "node/rsb2 labeled", nil)
+		sink := &recordingSink{}
+		executor := NewExecutor(inner, NewLog(sink, Record{}), logging.NewRecordingLogger())
+
+		// When: Label a node
+		executor.LabelNode(ctx, "rsb2", "role=compute", false)
+
+		// Then: Nothing is recorded
+		assert.Empty(t, sink.records)
+	})
+
+	t.Run("unwritable_log", func(t *testing.T) {
+		// Given: An audit log that cannot be written
+		inner := labeler.NewMockDryRunExecutor()
+		inner.On("IsDryRun").Return(false)
+		inner.On("LabelNode", ctx, "rsb2", "role=compute", false).Return(true, "node/rsb2 labeled", nil)
+		log := NewLog(&recordingSink{err: errors.New("disk full")}, Record{})
+		logger := logging.NewRecordingLogger()
+		executor := NewExecutor(inner, log, logger)
+
+		// When: Label a node
+		success, _, err := executor.LabelNode(ctx, "rsb2", "role=compute", false)
+
+		// Then: The change stands, with a warning and the failure kept for the run
+		assert.True(t, success)
+		assert.NoError(t, err)
+		assert.EqualError(t, log.Err(), "disk full")
+		require.Len(t, logger.Messages(logging.LevelWarn), 1)
+		assert.Contains(t, logger.Messages(logging.LevelWarn)[0], "Failed to audit label on node rsb2: disk full")
+	})
+}
@@ -0,0 +1,156 @@
+// Package bmc reaches nodes through their baseboard management controller when the network no longer does
+package bmc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"k8ostack-ictl/internal/config"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// defaultConsoleDuration is how long console-log reads the serial console when consoleDuration is unset
+const defaultConsoleDuration = 30 * time.Second
+
+// Credentials log in to a BMC
+type Credentials struct {
+	Username string
+	Password string
+}
+
+// CredentialsFunc resolves the credentials a node's BMC entry refers to
+type CredentialsFunc func(ctx context.Context, ref config.SecretRef) (Credentials, error)
+
+// Runner runs ipmitool with the given environment and arguments and returns its combined output
+type Runner func(ctx context.Context, env []string, args ...string) (string, error)
+
+// Controller runs recovery actions on the BMCs of a config
+type Controller struct {
+	recovery    config.BMCRecovery
+	credentials CredentialsFunc
+	run         Runner
+}
+
+// NewController creates a controller for the BMCs of a config; a nil runner runs the local ipmitool
+func NewController(recovery config.BMCRecovery, credentials CredentialsFunc, run Runner) *Controller {
+	if run == nil {
+		run = runIPMITool
+	}
+	return &Controller{recovery: recovery, credentials: credentials, run: run}
+}
+
+// Has reports whether a node has a BMC to fall back to
+func (c *Controller) Has(nodeName string) bool {
+	_, ok := c.recovery.Nodes[nodeName]
+	return ok
+}
+
+// Action returns the configured recovery action
+func (c *Controller) Action() string {
+	if c.recovery.Action == "" {
+		return config.BMCActionConsoleLog
+	}
+	return c.recovery.Action
+}
+
+// Recover runs the configured recovery action on a node and returns what it did, e.g. the console output
+func (c *Controller) Recover(ctx context.Context, nodeName string) (string, error) {
+	if c.Action() == config.BMCActionPowerCycle {
+		return "", c.PowerCycle(ctx, nodeName)
+	}
+	return c.ConsoleLog(ctx, nodeName)
+}
+
+// PowerCycle power-cycles a node through its BMC
+func (c *Controller) PowerCycle(ctx context.Context, nodeName string) error {
+	output, err := c.ipmitool(ctx, nodeName, "chassis", "power", "cycle")
+	if err != nil {
+		return fmt.Errorf("power-cycle of node %s failed: %w%s", nodeName, err, outputSuffix(output))
+	}
+	return nil
+}
+
+// ConsoleLog reads the serial console of a node over IPMI serial-over-LAN for the configured duration
+func (c *Controller) ConsoleLog(ctx context.Context, nodeName string) (string, error) {
+	duration := c.recovery.ConsoleDuration
+	if duration == 0 {
+		duration = defaultConsoleDuration
+	}
+
+	// A stale session from an earlier run would refuse the new one
+	_, _ = c.ipmitool(ctx, nodeName, "sol", "deactivate")
+
+	solCtx, cancel := context.WithTimeout(ctx, duration)
+	defer cancel()
+	output, err := c.ipmitool(solCtx, nodeName, "sol", "activate")
+	// The session only ends when its time is up
+	if err != nil && !errors.Is(solCtx.Err(), context.DeadlineExceeded) {
+		return output, fmt.Errorf("console log of node %s failed: %w%s", nodeName, err, outputSuffix(output))
+	}
+	_, _ = c.ipmitool(ctx, nodeName, "sol", "deactivate")
+	return output, nil
+}
+
+// ipmitool runs an ipmitool command against a node's BMC, passing the password through the environment
+func (c *Controller) ipmitool(ctx context.Context, nodeName string, args ...string) (string, error) {
+	bmc, ok := c.recovery.Nodes[nodeName]
+	if !ok {
+		return "", fmt.Errorf("node %s has no BMC configured", nodeName)
+	}
+	if c.credentials == nil {
+		return "", fmt.Errorf("no credentials source for the BMC of node %s", nodeName)
+	}
+	credentials, err := c.credentials(ctx, bmc.Credentials)
+	if err != nil {
+		return "", err
+	}
+
+	iface := bmc.Interface
+	if iface == "" {
+		iface = "lanplus"
+	}
+	base := []string{"-I", iface, "-H", bmc.Address, "-U", credentials.Username, "-E"}
+	return c.run(ctx, []string{"IPMI_PASSWORD=" + credentials.Password}, append(base, args...)...)
+}
+
+// runIPMITool runs the local ipmitool binary
+func runIPMITool(ctx context.Context, env []string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "ipmitool", args...)
+	cmd.Env = append(os.Environ(), env...)
+	output, err := cmd.CombinedOutput()
+	return string(output), err
+}
+
+// SecretCredentials reads BMC credentials from the username and password keys of Kubernetes Secrets
+func SecretCredentials(client kubernetes.Interface) CredentialsFunc {
+	return func(ctx context.Context, ref config.SecretRef) (Credentials, error) {
+		namespace := ref.Namespace
+		if namespace == "" {
+			namespace = "default"
+		}
+		secret, err := client.CoreV1().Secrets(namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+		if err != nil {
+			return Credentials{}, fmt.Errorf("failed to read BMC credentials %s/%s: %w", namespace, ref.Name, err)
+		}
+		credentials := Credentials{Username: string(secret.Data["username"]), Password: string(secret.Data["password"])}
+		if credentials.Username == "" || credentials.Password == "" {
+			return Credentials{}, fmt.Errorf("BMC credentials %s/%s need username and password keys", namespace, ref.Name)
+		}
+		return credentials, nil
+	}
+}
+
+// outputSuffix appends trimmed command output to an error message
+func outputSuffix(output string) string {
+	if output = strings.TrimSpace(output); output != "" {
+		return ": " + output
+	}
+	return ""
+}
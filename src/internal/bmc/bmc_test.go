@@ -0,0 +1,178 @@
+// Package bmc provides unit tests for BMC recovery actions
+// WHY: These actions run when nothing else reaches a node, so they must log in and act exactly as configured
+package bmc
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"k8ostack-ictl/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// ipmiCall is one recorded ipmitool run
+type ipmiCall struct {
+	env  []string
+	args []string
+}
+
+// recordingRunner records ipmitool runs and answers them with a fixed output and error
+func recordingRunner(calls *[]ipmiCall, output string, err error) Runner {
+	return func(ctx context.Context, env []string, args ...string) (string, error) {
+		*calls = append(*calls, ipmiCall{env: env, args: args})
+		return output, err
+	}
+}
+
+// staticCredentials returns the same credentials for every secret
+func staticCredentials(ctx context.Context, ref config.SecretRef) (Credentials, error) {
+	return Credentials{Username: "admin", Password: "s3cret"}, nil
+}
+
+// testRecovery is a BMC recovery for node1
+func testRecovery(action string) config.BMCRecovery {
+	return config.BMCRecovery{
+		Action: action,
+		Nodes: map[string]config.NodeBMC{
+			"node1": {Address: "10.0.9.11", Credentials: config.SecretRef{Name: "node1-bmc"}},
+		},
+	}
+}
+
+// TestController_Recover tests the ipmitool commands of each recovery action
+// WHY: The password must stay out of the arguments, and the default action must only observe the node
+func TestController_Recover(t *testing.T) {
+	login := []string{"-I", "lanplus", "-H", "10.0.9.11", "-U", "admin", "-E"}
+
+	tests := []struct {
+		name          string
+		action        string
+		runErr        error
+		expectedArgs  [][]string
+		expectedError string
+	}{
+		{
+			name:   "console_log_by_default",
+			action: "",
+			expectedArgs: [][]string{
+				append(login, "sol", "deactivate"),
+				append(login, "sol", "activate"),
+				append(login, "sol", "deactivate"),
+			},
+		},
+		{
+			name:         "power_cycle",
+			action:       config.BMCActionPowerCycle,
+			expectedArgs: [][]string{append(login, "chassis", "power", "cycle")},
+		},
+		{
+			name:          "failed_power_cycle",
+			action:        config.BMCActionPowerCycle,
+			runErr:        errors.New("exit status 1"),
+			expectedArgs:  [][]string{append(login, "chassis", "power", "cycle")},
+			expectedError: "power-cycle of node node1 failed: exit status 1: Unable to establish IPMI v2 / RMCP+ session",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A BMC for node1
+			var calls []ipmiCall
+			recovery := testRecovery(tt.action)
+			recovery.ConsoleDuration = time.Millisecond
+			output := "Unable to establish IPMI v2 / RMCP+ session"
+			if tt.runErr == nil {
+				output = "[SOL Session operational]\nUbuntu 22.04 node1 ttyS0"
+			}
+			controller := NewController(recovery, staticCredentials, recordingRunner(&calls, output, tt.runErr))
+
+			// When: Recover node1
+			_, err := controller.Recover(context.Background(), "node1")
+
+			// Then: ipmitool logs in with the password in the environment and runs the action
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Len(t, calls, len(tt.expectedArgs))
+			for i, call := range calls {
+				assert.Equal(t, tt.expectedArgs[i], call.args)
+				assert.Equal(t, []string{"IPMI_PASSWORD=s3cret"}, call.env)
+			}
+		})
+	}
+}
+
+// TestController_ConsoleLog tests reading the serial console until its time is up
+// WHY: The SOL session never ends on its own, so running out of time is success and the output is kept
+func TestController_ConsoleLog(t *testing.T) {
+	// Given: A SOL session that runs until it is stopped
+	recovery := testRecovery(config.BMCActionConsoleLog)
+	recovery.ConsoleDuration = 10 * time.Millisecond
+	controller := NewController(recovery, staticCredentials, func(ctx context.Context, env []string, args ...string) (string, error) {
+		if args[len(args)-1] != "activate" {
+			return "", nil
+		}
+		<-ctx.Done()
+		return "kernel: eth0.100: link becomes ready", errors.New("signal: killed")
+	})
+
+	// When: Read the console
+	output, err := controller.ConsoleLog(context.Background(), "node1")
+
+	// Then: The output read until then is returned
+	require.NoError(t, err)
+	assert.Equal(t, "kernel: eth0.100: link becomes ready", output)
+
+	// When/Then: Nodes without a BMC are refused
+	_, err = controller.ConsoleLog(context.Background(), "node2")
+	assert.EqualError(t, err, "console log of node node2 failed: node node2 has no BMC configured")
+	assert.False(t, controller.Has("node2"))
+}
+
+// TestSecretCredentials tests reading BMC credentials from Secrets
+// WHY: A Secret missing a key must fail before ipmitool runs with an empty password
+func TestSecretCredentials(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "node1-bmc", Namespace: "kube-system"},
+			Data: map[string][]byte{"username": []byte("admin"), "password": []byte("s3cret")}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "node2-bmc", Namespace: "default"},
+			Data: map[string][]byte{"username": []byte("admin")}},
+	)
+
+	tests := []struct {
+		name          string
+		ref           config.SecretRef
+		expected      Credentials
+		expectedError string
+	}{
+		{"complete", config.SecretRef{Namespace: "kube-system", Name: "node1-bmc"}, Credentials{Username: "admin", Password: "s3cret"}, ""},
+		{"missing_password", config.SecretRef{Name: "node2-bmc"}, Credentials{}, "BMC credentials default/node2-bmc need username and password keys"},
+		{"missing_secret", config.SecretRef{Name: "node3-bmc"}, Credentials{}, "failed to read BMC credentials default/node3-bmc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: Secrets in the cluster
+
+			// When: Read the referenced credentials
+			credentials, err := SecretCredentials(client)(context.Background(), tt.ref)
+
+			// Then: Only complete credentials are returned
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, credentials)
+		})
+	}
+}
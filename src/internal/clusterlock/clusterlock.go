@@ -0,0 +1,145 @@
+// Package clusterlock keeps two kictl runs from changing one cluster at the same time
+// The lock is a coordination.k8s.io Lease, so it holds across operators and machines.
+package clusterlock
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8ostack-ictl/internal/kubectl"
+)
+
+// Lease location and lifetime
+const (
+	Name      = "kictl-run-lock"
+	Namespace = "kube-system"
+
+	// Duration after which a lock left behind by a crashed run may be taken over
+	Duration = 2 * time.Hour
+)
+
+// Lock is a held cluster lock
+type Lock struct {
+	run kubectl.Runner
+}
+
+// Acquire takes the lock for holder, e.g. "alice@ops1 apply (pid 4242)", taking over an expired lock
+// run is the kubectl of the executor selecting the cluster.
+func Acquire(ctx context.Context, holder string, now time.Time, run kubectl.Runner) (*Lock, error) {
+	lock := &Lock{run: run}
+
+	output, err := lock.create(ctx, holder, now)
+	if err == nil {
+		return lock, nil
+	}
+	if !strings.Contains(output, "AlreadyExists") && !strings.Contains(output, "already exists") {
+		return nil, fmt.Errorf("failed to create lock lease %s/%s: %s", Namespace, Name, strings.TrimSpace(output))
+	}
+
+	current, renewed, duration, err := lock.read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if now.Before(renewed.Add(duration)) {
+		return nil, fmt.Errorf("cluster is locked by %s since %s; wait for that run, or delete lease %s/%s if it crashed",
+			current, renewed.Format(time.RFC3339), Namespace, Name)
+	}
+
+	// The holder stopped without releasing the lock; delete the expired lease and retry once
+	if output, err := lock.run(ctx, nil, "delete", "lease", Name, "--namespace", Namespace, "--ignore-not-found"); err != nil {
+		return nil, fmt.Errorf("failed to remove expired lock of %s: %s", current, strings.TrimSpace(output))
+	}
+	if output, err := lock.create(ctx, holder, now); err != nil {
+		return nil, fmt.Errorf("failed to take over expired lock of %s: %s", current, strings.TrimSpace(output))
+	}
+	return lock, nil
+}
+
+// Release gives the lock up
+func (l *Lock) Release(ctx context.Context) error {
+	if output, err := l.run(ctx, nil, "delete", "lease", Name, "--namespace", Namespace, "--ignore-not-found"); err != nil {
+		return fmt.Errorf("failed to release lock lease %s/%s: %s", Namespace, Name, strings.TrimSpace(output))
+	}
+	return nil
+}
+
+// create creates the lease for holder; it fails when the lease exists
+func (l *Lock) create(ctx context.Context, holder string, now time.Time) (string, error) {
+	stamp := now.UTC().Format("2006-01-02T15:04:05.000000Z")
+	manifest := fmt.Sprintf(`apiVersion: coordination.k8s.io/v1
+kind: Lease
+metadata:
+  name: %s
+  namespace: %s
+  labels:
+    app.kubernetes.io/managed-by: kictl
+spec:
+  holderIdentity: %q
+  leaseDurationSeconds: %d
+  acquireTime: %s
+  renewTime: %s
+`, Name, Namespace, holder, int(Duration/time.Second), stamp, stamp)
+	return l.run(ctx, []byte(manifest), "create", "-f", "-")
+}
+
+// Holder describes who holds the cluster lock and until when
+type Holder struct {
+	Identity string
+	Renewed  time.Time
+	Duration time.Duration
+}
+
+// Expired reports whether the holder stopped renewing the lock, so it may be taken over
+func (h Holder) Expired(now time.Time) bool {
+	return !now.Before(h.Renewed.Add(h.Duration))
+}
+
+// Inspect returns the current holder of the lock, or nil when the cluster is not locked
+// run is the kubectl of the executor selecting the cluster.
+func Inspect(ctx context.Context, run kubectl.Runner) (*Holder, error) {
+	output, err := run(ctx, nil, "get", "lease", Name, "--namespace", Namespace, "--ignore-not-found",
+		"-o", "jsonpath="+leaseFields)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lock lease %s/%s: %s", Namespace, Name, strings.TrimSpace(output))
+	}
+	if strings.TrimSpace(output) == "" {
+		return nil, nil
+	}
+	identity, renewed, duration, err := parseLease(output)
+	if err != nil {
+		return nil, err
+	}
+	return &Holder{Identity: identity, Renewed: renewed, Duration: duration}, nil
+}
+
+// leaseFields are the lease fields read, as a kubectl jsonpath
+const leaseFields = "{.spec.holderIdentity}|{.spec.renewTime}|{.spec.leaseDurationSeconds}"
+
+// read returns the holder, renew time and duration of the existing lease
+func (l *Lock) read(ctx context.Context) (string, time.Time, time.Duration, error) {
+	output, err := l.run(ctx, nil, "get", "lease", Name, "--namespace", Namespace, "-o", "jsonpath="+leaseFields)
+	if err != nil {
+		return "", time.Time{}, 0, fmt.Errorf("failed to read lock lease %s/%s: %s", Namespace, Name, strings.TrimSpace(output))
+	}
+	return parseLease(output)
+}
+
+// parseLease parses the lease fields printed by kubectl
+func parseLease(output string) (string, time.Time, time.Duration, error) {
+	fields := strings.Split(strings.TrimSpace(output), "|")
+	if len(fields) != 3 {
+		return "", time.Time{}, 0, fmt.Errorf("unexpected lock lease %s/%s: %s", Namespace, Name, output)
+	}
+	renewed, err := time.Parse(time.RFC3339Nano, fields[1])
+	if err != nil {
+		return "", time.Time{}, 0, fmt.Errorf("invalid renew time of lock lease %s/%s: %w", Namespace, Name, err)
+	}
+	seconds, err := strconv.Atoi(fields[2])
+	if err != nil {
+		return "", time.Time{}, 0, fmt.Errorf("invalid duration of lock lease %s/%s: %w", Namespace, Name, err)
+	}
+	return fields[0], renewed, time.Duration(seconds) * time.Second, nil
+}
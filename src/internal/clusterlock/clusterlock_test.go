@@ -0,0 +1,171 @@
+// Package clusterlock provides unit tests for the cluster run lock
+// WHY: Two runs changing a production cluster at once must be impossible, yet a crashed run must not lock it forever
+package clusterlock
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeCluster answers kubectl lease commands from an in-memory lease
+type fakeCluster struct {
+	lease string // "holder|renewTime|seconds", or "" when there is none
+	calls []string
+}
+
+// run implements Runner
+func (f *fakeCluster) run(ctx context.Context, stdin []byte, args ...string) (string, error) {
+	f.calls = append(f.calls, strings.Join(args, " "))
+	switch {
+	case contains(args, "create"):
+		if f.lease != "" {
+			return `Error from server (AlreadyExists): leases.coordination.k8s.io "kictl-run-lock" already exists`, errors.New("exit status 1")
+		}
+		f.lease = "created"
+		return "lease.coordination.k8s.io/kictl-run-lock created", nil
+	case contains(args, "get"):
+		return f.lease, nil
+	case contains(args, "delete"):
+		f.lease = ""
+		return "", nil
+	}
+	return "", errors.New("unexpected command")
+}
+
+// contains reports whether args holds a value
+func contains(args []string, value string) bool {
+	for _, arg := range args {
+		if arg == value {
+			return true
+		}
+	}
+	return false
+}
+
+// TestAcquire tests taking the cluster lock
+// WHY: A held lock blocks other runs until it expires, when it may be taken over
+func TestAcquire(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		lease         string
+		expectedError string
+		expectedCalls []string
+	}{
+		{
+			name:          "free_cluster",
+			expectedCalls: []string{"create -f -"},
+		},
+		{
+			name:          "held_lock",
+			lease:         "bob@ops2 apply (pid 7)|2026-10-16T11:30:00.000000Z|7200",
+			expectedError: "cluster is locked by bob@ops2 apply (pid 7) since 2026-10-16T11:30:00Z",
+		},
+		{
+			name:  "expired_lock",
+			lease: "bob@ops2 apply (pid 7)|2026-10-16T09:00:00.000000Z|7200",
+			expectedCalls: []string{
+				"create -f -",
+				"get lease kictl-run-lock --namespace kube-system -o jsonpath={.spec.holderIdentity}|{.spec.renewTime}|{.spec.leaseDurationSeconds}",
+				"delete lease kictl-run-lock --namespace kube-system --ignore-not-found",
+				"create -f -",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A cluster with or without a lock lease
+			cluster := &fakeCluster{lease: tt.lease}
+
+			// When: Acquire the lock
+			lock, err := Acquire(context.Background(), "alice@ops1 apply (pid 42)", now, cluster.run)
+
+			// Then: The lock is held unless another run holds it
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+				assert.Nil(t, lock)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedCalls, cluster.calls)
+			assert.Equal(t, "created", cluster.lease)
+
+			// And: Releasing deletes the lease
+			require.NoError(t, lock.Release(context.Background()))
+			assert.Empty(t, cluster.lease)
+		})
+	}
+}
+
+// TestAcquire_LeaseManifest tests the lease kictl creates
+// WHY: Other operators read the holder and expiry from the lease when they are locked out
+func TestAcquire_LeaseManifest(t *testing.T) {
+	var manifest string
+	run := func(ctx context.Context, stdin []byte, args ...string) (string, error) {
+		manifest = string(stdin)
+		return "", nil
+	}
+
+	_, err := Acquire(context.Background(), "alice@ops1 apply (pid 42)", time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC), run)
+
+	require.NoError(t, err)
+	assert.Contains(t, manifest, "kind: Lease\n")
+	assert.Contains(t, manifest, "namespace: kube-system\n")
+	assert.Contains(t, manifest, `holderIdentity: "alice@ops1 apply (pid 42)"`)
+	assert.Contains(t, manifest, "leaseDurationSeconds: 7200\n")
+	assert.Contains(t, manifest, "renewTime: 2026-10-16T12:00:00.000000Z\n")
+}
+
+// TestInspect tests reading the holder of the cluster lock
+// WHY: A lock left behind by a crashed run is reported as an orphan, while a live one must be left alone
+func TestInspect(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		lease         string
+		expectHolder  string
+		expectExpired bool
+	}{
+		{name: "free_cluster"},
+		{
+			name:         "held_lock",
+			lease:        "bob@ops2 apply (pid 7)|2026-10-16T11:30:00.000000Z|7200",
+			expectHolder: "bob@ops2 apply (pid 7)",
+		},
+		{
+			name:          "expired_lock",
+			lease:         "bob@ops2 apply (pid 7)|2026-10-16T09:00:00.000000Z|7200",
+			expectHolder:  "bob@ops2 apply (pid 7)",
+			expectExpired: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A cluster with or without a lock lease
+			cluster := &fakeCluster{lease: tt.lease}
+
+			// When: Inspect the lock
+			holder, err := Inspect(context.Background(), cluster.run)
+
+			// Then: The holder is reported with whether its lock expired
+			require.NoError(t, err)
+			if tt.expectHolder == "" {
+				assert.Nil(t, holder)
+				return
+			}
+			require.NotNil(t, holder)
+			assert.Equal(t, tt.expectHolder, holder.Identity)
+			assert.Equal(t, tt.expectExpired, holder.Expired(now))
+		})
+	}
+}
@@ -0,0 +1,298 @@
+// Package cmdb keeps an external asset database in sync by posting the per-node changes of each apply
+package cmdb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"k8ostack-ictl/internal/config"
+)
+
+// TokenEnv holds the bearer token sent to the CMDB endpoint
+const TokenEnv = "KICTL_CMDB_TOKEN"
+
+// Delivery defaults
+const (
+	defaultBackoff = time.Second
+	requestTimeout = 30 * time.Second
+)
+
+// rejectedSuffix marks queued events the endpoint refused, so they are kept but no longer retried
+const rejectedSuffix = ".rejected"
+
+// VLANAssignment is one VLAN interface an apply configured on a node
+type VLANAssignment struct {
+	Name      string   `json:"name"`
+	ID        int      `json:"id"`
+	Interface string   `json:"interface,omitempty"`
+	Address   string   `json:"address"`             // primary address: the first IPv4 one of a dual-stack node
+	Addresses []string `json:"addresses,omitempty"` // every address of a dual-stack node
+}
+
+// NodeChange is what an apply set on one node
+type NodeChange struct {
+	Node   string            `json:"node"`
+	Labels map[string]string `json:"labels,omitempty"`
+	VLANs  []VLANAssignment  `json:"vlans,omitempty"`
+}
+
+// Event is the JSON document posted to the CMDB for one apply
+type Event struct {
+	RunID      string       `json:"runId"`
+	Operation  string       `json:"operation"`
+	ConfigFile string       `json:"configFile,omitempty"`
+	Timestamp  time.Time    `json:"timestamp"`
+	Nodes      []NodeChange `json:"nodes"`
+}
+
+// Changes collects the labels and VLAN assignments an apply of the bundle set, by node
+// Node patterns must already be resolved, see config.NodeLabelConf.WithResolvedNodes.
+func Changes(bundle *config.ConfigBundle) []NodeChange {
+	byNode := make(map[string]*NodeChange)
+	change := func(node string) *NodeChange {
+		if byNode[node] == nil {
+			byNode[node] = &NodeChange{Node: node}
+		}
+		return byNode[node]
+	}
+
+	if bundle.HasNodeLabels() {
+		for _, role := range bundle.NodeLabels.Spec.NodeRoles {
+			for _, node := range role.Nodes {
+				c := change(node)
+				if c.Labels == nil {
+					c.Labels = make(map[string]string)
+				}
+				for key, value := range role.Labels {
+					c.Labels[key] = value
+				}
+			}
+		}
+	}
+	if bundle.HasVLANs() {
+		for name, vlanConfig := range bundle.VLANs.Spec.VLANs {
+			for node, address := range vlanConfig.NodeMapping {
+				c := change(node)
+				assignment := VLANAssignment{Name: name, ID: vlanConfig.ID, Interface: vlanConfig.Interface, Address: config.PrimaryAddress(address)}
+				if addresses := config.SplitAddresses(address); len(addresses) > 1 {
+					assignment.Addresses = addresses
+				}
+				c.VLANs = append(c.VLANs, assignment)
+			}
+		}
+	}
+
+	changes := make([]NodeChange, 0, len(byNode))
+	for _, c := range byNode {
+		sort.Slice(c.VLANs, func(i, j int) bool {
+			if c.VLANs[i].ID != c.VLANs[j].ID {
+				return c.VLANs[i].ID < c.VLANs[j].ID
+			}
+			return c.VLANs[i].Name < c.VLANs[j].Name
+		})
+		changes = append(changes, *c)
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Node < changes[j].Node })
+	return changes
+}
+
+// Options configures a Client
+type Options struct {
+	Endpoint string        // http(s) URL events are POSTed to
+	Token    string        // bearer token; only sent over https
+	Retries  int           // further attempts after a failed post
+	Backoff  time.Duration // wait before the first retry, doubled for each further one (default 1s)
+	QueueDir string        // where undelivered events wait for the next Send; empty posts once without queueing
+	Client   *http.Client  // default has a 30s timeout
+}
+
+// Result counts what a Send did with the queue
+type Result struct {
+	Delivered int // events posted, including older queued ones
+	Queued    int // events still waiting for the next Send
+	Rejected  int // events the endpoint refused, set aside as *.rejected
+}
+
+// RejectedError is an answer that retrying cannot change, e.g. 400 Bad Request
+type RejectedError struct {
+	Status string
+}
+
+func (e *RejectedError) Error() string {
+	return fmt.Sprintf("CMDB rejected the change with %s", e.Status)
+}
+
+// Client posts change events to a CMDB with retries and an on-disk queue
+type Client struct {
+	opts Options
+}
+
+// NewClient validates the options and creates a client
+func NewClient(opts Options) (*Client, error) {
+	if !strings.HasPrefix(opts.Endpoint, "https://") && !strings.HasPrefix(opts.Endpoint, "http://") {
+		return nil, fmt.Errorf("CMDB endpoint %s must be an http(s):// URL", opts.Endpoint)
+	}
+	if opts.Token != "" && !strings.HasPrefix(opts.Endpoint, "https://") {
+		return nil, fmt.Errorf("refusing to send the CMDB token over plain http, use https")
+	}
+	if opts.Retries < 0 {
+		return nil, fmt.Errorf("CMDB retries cannot be negative, got %d", opts.Retries)
+	}
+	if opts.Backoff <= 0 {
+		opts.Backoff = defaultBackoff
+	}
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: requestTimeout}
+	}
+	return &Client{opts: opts}, nil
+}
+
+// Endpoint returns the URL events are posted to
+func (c *Client) Endpoint() string {
+	return c.opts.Endpoint
+}
+
+// Send queues an event and then delivers every queued event, oldest first
+// Delivery stops at the first event the endpoint cannot be reached for, leaving it and later ones queued
+// for the next Send. Events the endpoint rejects are set aside instead of blocking the queue.
+func (c *Client) Send(ctx context.Context, event Event) (Result, error) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to encode CMDB event: %w", err)
+	}
+	key := event.Timestamp.UTC().Format("20060102T150405.000000000") + "-" + event.RunID
+	if c.opts.QueueDir == "" {
+		if err := c.post(ctx, key, body); err != nil {
+			return Result{}, err
+		}
+		return Result{Delivered: 1}, nil
+	}
+
+	if err := os.MkdirAll(c.opts.QueueDir, 0755); err != nil {
+		return Result{}, fmt.Errorf("failed to create CMDB queue: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(c.opts.QueueDir, key+".json"), body, 0600); err != nil {
+		return Result{}, fmt.Errorf("failed to queue CMDB event: %w", err)
+	}
+	return c.Flush(ctx)
+}
+
+// Flush delivers the queued events, oldest first, without adding one
+func (c *Client) Flush(ctx context.Context) (Result, error) {
+	queued, err := c.queued()
+	if err != nil {
+		return Result{}, err
+	}
+
+	var result Result
+	var firstErr error
+	for i, path := range queued {
+		body, err := os.ReadFile(path)
+		if err != nil {
+			return result, fmt.Errorf("failed to read queued CMDB event: %w", err)
+		}
+		key := strings.TrimSuffix(filepath.Base(path), ".json")
+
+		err = c.post(ctx, key, body)
+		var rejected *RejectedError
+		switch {
+		case err == nil:
+			result.Delivered++
+			os.Remove(path)
+		case errors.As(err, &rejected):
+			result.Rejected++
+			if firstErr == nil {
+				firstErr = fmt.Errorf("%s: %w", filepath.Base(path), err)
+			}
+			os.Rename(path, path+rejectedSuffix)
+		default:
+			result.Queued = len(queued) - i
+			if firstErr == nil {
+				firstErr = err
+			}
+			return result, firstErr
+		}
+	}
+	return result, firstErr
+}
+
+// queued lists the events waiting for delivery, oldest first
+func (c *Client) queued() ([]string, error) {
+	entries, err := os.ReadDir(c.opts.QueueDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CMDB queue: %w", err)
+	}
+	var paths []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".json") {
+			paths = append(paths, filepath.Join(c.opts.QueueDir, entry.Name()))
+		}
+	}
+	return paths, nil
+}
+
+// post sends one event, retrying connection failures, 429 and 5xx answers with exponential backoff
+// The idempotency key lets the endpoint ignore a retry of a post that arrived but was not acknowledged.
+func (c *Client) post(ctx context.Context, key string, body []byte) error {
+	var err error
+	backoff := c.opts.Backoff
+	for attempt := 0; attempt <= c.opts.Retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+
+		err = c.postOnce(ctx, key, body)
+		var rejected *RejectedError
+		if err == nil || errors.As(err, &rejected) {
+			return err
+		}
+	}
+	return fmt.Errorf("failed to post to CMDB after %d attempts: %w", c.opts.Retries+1, err)
+}
+
+// postOnce makes a single POST of an event
+func (c *Client) postOnce(ctx context.Context, key string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.opts.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", key)
+	if c.opts.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.opts.Token)
+	}
+
+	resp, err := c.opts.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusTooManyRequests, resp.StatusCode >= 500:
+		return fmt.Errorf("CMDB answered %s", resp.Status)
+	default:
+		return &RejectedError{Status: resp.Status}
+	}
+}
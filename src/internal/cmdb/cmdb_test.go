@@ -0,0 +1,223 @@
+// Package cmdb provides unit tests for the CMDB change hook
+// WHY: The asset database only stays in sync if every change is delivered once, in order, despite outages
+package cmdb
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"k8ostack-ictl/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestChanges tests collecting the per-node changes of a bundle
+// WHY: The CMDB keys assets by node, so labels and VLANs of one node must arrive together
+func TestChanges(t *testing.T) {
+	// Given: A bundle labelling two nodes and giving one of them a VLAN and a dual-stack VLAN
+	bundle := &config.ConfigBundle{
+		NodeLabels: &config.NodeLabelConf{Spec: config.NodeLabelSpec{NodeRoles: map[string]config.NodeRole{
+			"compute": {Nodes: []string{"rsb3", "rsb2"}, Labels: map[string]string{"role": "compute"}},
+		}}},
+		VLANs: &config.NodeVLANConf{Spec: config.NodeVLANSpec{VLANs: map[string]config.VLANConfig{
+			"storage":    {ID: 200, NodeMapping: map[string]string{"rsb2": "10.2.0.2/24,fd00:2::2/64"}},
+			"management": {ID: 100, Interface: "eth1", NodeMapping: map[string]string{"rsb2": "10.1.0.2/24"}},
+		}}},
+	}
+
+	// When: Collect the changes
+	changes := Changes(bundle)
+
+	// Then: One change per node, sorted, with VLANs by ID
+	assert.Equal(t, []NodeChange{
+		{Node: "rsb2", Labels: map[string]string{"role": "compute"}, VLANs: []VLANAssignment{
+			{Name: "management", ID: 100, Interface: "eth1", Address: "10.1.0.2/24"},
+			{Name: "storage", ID: 200, Address: "10.2.0.2/24", Addresses: []string{"10.2.0.2/24", "fd00:2::2/64"}},
+		}},
+		{Node: "rsb3", Labels: map[string]string{"role": "compute"}},
+	}, changes)
+}
+
+// TestNewClient tests endpoint validation
+// WHY: A token must never leave over plain http
+func TestNewClient(t *testing.T) {
+	tests := []struct {
+		name          string
+		opts          Options
+		expectedError string
+	}{
+		{name: "https_with_token", opts: Options{Endpoint: "https://cmdb.lab/api/changes", Token: "secret"}},
+		{name: "http_without_token", opts: Options{Endpoint: "http://cmdb.lab/api/changes"}},
+		{name: "http_with_token", opts: Options{Endpoint: "http://cmdb.lab/api/changes", Token: "secret"}, expectedError: "refusing to send the CMDB token over plain http"},
+		{name: "not_a_url", opts: Options{Endpoint: "cmdb.lab"}, expectedError: "must be an http(s):// URL"},
+		{name: "negative_retries", opts: Options{Endpoint: "https://cmdb.lab", Retries: -1}, expectedError: "retries cannot be negative"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given/When: Create a client
+			client, err := NewClient(tt.opts)
+
+			// Then: Invalid options fail
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.opts.Endpoint, client.Endpoint())
+		})
+	}
+}
+
+// cmdbServer records posted events and answers with the queued status codes, then 201
+type cmdbServer struct {
+	mu       sync.Mutex
+	statuses []int
+	events   []Event
+	keys     []string
+	auth     []string
+}
+
+func (s *cmdbServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	status := http.StatusCreated
+	if len(s.statuses) > 0 {
+		status, s.statuses = s.statuses[0], s.statuses[1:]
+	}
+	if status < 300 {
+		body, _ := io.ReadAll(r.Body)
+		var event Event
+		json.Unmarshal(body, &event)
+		s.events = append(s.events, event)
+		s.keys = append(s.keys, r.Header.Get("Idempotency-Key"))
+		s.auth = append(s.auth, r.Header.Get("Authorization"))
+	}
+	w.WriteHeader(status)
+}
+
+// TestClient_Send tests retries, queueing and rejection
+// WHY: An outage must delay changes rather than lose them, and a bad event must not block later ones
+func TestClient_Send(t *testing.T) {
+	base := time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC)
+	event := func(runID string, offset time.Duration) Event {
+		return Event{RunID: runID, Operation: "apply", Timestamp: base.Add(offset), Nodes: []NodeChange{{Node: "rsb2"}}}
+	}
+
+	tests := []struct {
+		name              string
+		statuses          []int
+		retries           int
+		expectedResult    Result
+		expectedRuns      []string
+		expectedQueue     []string
+		expectedError     string
+		preQueuedOlderRun bool
+	}{
+		{
+			name:           "delivered",
+			expectedResult: Result{Delivered: 1},
+			expectedRuns:   []string{"run-2"},
+		},
+		{
+			name:           "retried_after_server_error",
+			statuses:       []int{http.StatusServiceUnavailable, http.StatusTooManyRequests},
+			retries:        2,
+			expectedResult: Result{Delivered: 1},
+			expectedRuns:   []string{"run-2"},
+		},
+		{
+			name:           "queued_while_down",
+			statuses:       []int{http.StatusBadGateway, http.StatusBadGateway},
+			retries:        1,
+			expectedResult: Result{Queued: 1},
+			expectedQueue:  []string{"20261016T100100.000000000-run-2.json"},
+			expectedError:  "failed to post to CMDB after 2 attempts: CMDB answered 502 Bad Gateway",
+		},
+		{
+			name:              "backlog_delivered_in_order",
+			preQueuedOlderRun: true,
+			expectedResult:    Result{Delivered: 2},
+			expectedRuns:      []string{"run-1", "run-2"},
+		},
+		{
+			name:              "rejected_set_aside",
+			statuses:          []int{http.StatusBadRequest},
+			preQueuedOlderRun: true,
+			expectedResult:    Result{Delivered: 1, Rejected: 1},
+			expectedRuns:      []string{"run-2"},
+			expectedQueue:     []string{"20261016T100000.000000000-run-1.json.rejected"},
+			expectedError:     "CMDB rejected the change with 400 Bad Request",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A CMDB answering with the given statuses and a queue directory
+			server := &cmdbServer{statuses: tt.statuses}
+			ts := httptest.NewServer(server)
+			defer ts.Close()
+			queueDir := filepath.Join(t.TempDir(), "cmdb-queue")
+			client, err := NewClient(Options{Endpoint: ts.URL, Retries: tt.retries, Backoff: time.Millisecond, QueueDir: queueDir})
+			require.NoError(t, err)
+			if tt.preQueuedOlderRun {
+				server.statuses = append([]int{http.StatusInternalServerError}, server.statuses...)
+				_, err := client.Send(context.Background(), event("run-1", 0))
+				require.Error(t, err)
+			}
+
+			// When: Send the change of a run
+			result, err := client.Send(context.Background(), event("run-2", time.Minute))
+
+			// Then: Events are delivered in order, or stay queued
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.expectedResult, result)
+			var runs []string
+			for _, delivered := range server.events {
+				runs = append(runs, delivered.RunID)
+			}
+			assert.Equal(t, tt.expectedRuns, runs)
+
+			entries, err := os.ReadDir(queueDir)
+			require.NoError(t, err)
+			var queue []string
+			for _, entry := range entries {
+				queue = append(queue, entry.Name())
+			}
+			assert.Equal(t, tt.expectedQueue, queue)
+		})
+	}
+}
+
+// TestClient_Send_Headers tests the headers of a post without a queue
+// WHY: The endpoint deduplicates retries by idempotency key and authenticates by token
+func TestClient_Send_Headers(t *testing.T) {
+	// Given: An https CMDB and a client with a token
+	server := &cmdbServer{}
+	ts := httptest.NewTLSServer(server)
+	defer ts.Close()
+	client, err := NewClient(Options{Endpoint: ts.URL, Token: "secret", Client: ts.Client()})
+	require.NoError(t, err)
+
+	// When: Send a change
+	result, err := client.Send(context.Background(), Event{RunID: "run-1", Timestamp: time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC)})
+
+	// Then: It carries the token and a key derived from the run
+	require.NoError(t, err)
+	assert.Equal(t, Result{Delivered: 1}, result)
+	assert.Equal(t, []string{"Bearer secret"}, server.auth)
+	assert.Equal(t, []string{"20261016T100000.000000000-run-1"}, server.keys)
+}
@@ -0,0 +1,185 @@
+// Package compat checks the local kubectl and the cluster against the versions kictl supports before a run
+// generates node commands one of them does not understand
+package compat
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"k8ostack-ictl/internal/kubectl"
+)
+
+// Version is a Kubernetes version as kubectl version reports it
+type Version struct {
+	Major      int
+	Minor      int
+	GitVersion string // e.g. v1.27.3-eks-a5565ad; empty for the versions kictl defines
+}
+
+// String returns the git version, or vMAJOR.MINOR when there is none
+func (v Version) String() string {
+	if v.GitVersion != "" {
+		return v.GitVersion
+	}
+	return fmt.Sprintf("v%d.%d", v.Major, v.Minor)
+}
+
+// AtLeast reports whether v is the same minor version as other or newer
+func (v Version) AtLeast(other Version) bool {
+	if v.Major != other.Major {
+		return v.Major > other.Major
+	}
+	return v.Minor >= other.Minor
+}
+
+// Versions are the versions of the local kubectl and of the cluster's API server
+type Versions struct {
+	Client Version
+	Server Version
+}
+
+// Supported cluster versions; clusters outside the range are warned about, not refused
+var (
+	MinServer = Version{Major: 1, Minor: 24}
+	MaxServer = Version{Major: 1, Minor: 31}
+)
+
+// maxSkew is the version skew between kubectl and the API server that kubectl supports
+const maxSkew = 1
+
+// sysadminProfileSince is the first kubectl with `kubectl debug --profile=sysadmin`
+var sysadminProfileSince = Version{Major: 1, Minor: 27}
+
+// ephemeralContainersSince is the first Kubernetes enabling ephemeral containers by default
+var ephemeralContainersSince = Version{Major: 1, Minor: 23}
+
+// Result is how a run adapts to the versions of kubectl and the cluster
+type Result struct {
+	Versions    Versions
+	LegacyDebug bool     // Create debug node pods from a manifest, see kubectl.ExecutorOptions
+	Adjustments []string // How the generated commands change
+	Warnings    []string // Versions outside what is supported, which may still work
+}
+
+// Check compares the versions with what a node command backend needs
+// It fails when the backend cannot work with them, adjusts the commands when it can, and warns about the rest.
+func Check(versions Versions, backend string) (Result, error) {
+	result := Result{Versions: versions}
+	client, server := versions.Client, versions.Server
+	hasSysadminProfile := client.AtLeast(sysadminProfileSince)
+
+	switch backend {
+	case kubectl.NodeExecBackendEphemeral:
+		if !hasSysadminProfile {
+			return result, fmt.Errorf("kubectl %s is not compatible with the %s exec backend: it needs kubectl debug --profile=sysadmin, added in kubectl %s; upgrade kubectl or use --node-exec-backend %s or %s",
+				client, backend, sysadminProfileSince, kubectl.NodeExecBackendDebugPod, kubectl.NodeExecBackendAgent)
+		}
+		if !server.AtLeast(ephemeralContainersSince) {
+			return result, fmt.Errorf("cluster %s is not compatible with the %s exec backend: ephemeral containers are enabled by default since Kubernetes %s; upgrade the cluster or use --node-exec-backend %s or %s",
+				server, backend, ephemeralContainersSince, kubectl.NodeExecBackendDebugPod, kubectl.NodeExecBackendAgent)
+		}
+	case kubectl.NodeExecBackendAgent:
+	default:
+		if !hasSysadminProfile {
+			result.LegacyDebug = true
+			result.Adjustments = append(result.Adjustments, fmt.Sprintf("kubectl %s has no kubectl debug --profile=sysadmin (added in %s): creating privileged debug pods from a manifest instead",
+				client, sysadminProfileSince))
+		}
+	}
+
+	if !server.AtLeast(MinServer) || !MaxServer.AtLeast(server) {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("cluster %s is outside the supported Kubernetes versions %s to %s", server, MinServer, MaxServer))
+	}
+	if skew := minorSkew(client, server); skew > maxSkew {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("kubectl %s is %d minor versions away from cluster %s; kubectl supports a skew of %d",
+			client, skew, server, maxSkew))
+	}
+	return result, nil
+}
+
+// minorSkew returns how many minor versions apart two versions of the same major version are
+func minorSkew(a, b Version) int {
+	if a.Major != b.Major {
+		return 100
+	}
+	if a.Minor > b.Minor {
+		return a.Minor - b.Minor
+	}
+	return b.Minor - a.Minor
+}
+
+// Read asks kubectl for its own version and the cluster's
+// run is the kubectl of the executor selecting the cluster.
+func Read(ctx context.Context, run kubectl.Runner) (Versions, error) {
+	args := []string{"version", "-o", "json"}
+	output, err := run(ctx, nil, args...)
+	if err != nil {
+		if message := strings.TrimSpace(output); message != "" {
+			return Versions{}, fmt.Errorf("kubectl version failed: %s", lastLine(message))
+		}
+		return Versions{}, fmt.Errorf("kubectl version failed: %w", err)
+	}
+	return ParseVersions(output)
+}
+
+// rawVersion is a version in the output of kubectl version -o json
+type rawVersion struct {
+	Major      string `json:"major"`
+	Minor      string `json:"minor"`
+	GitVersion string `json:"gitVersion"`
+}
+
+// ParseVersions parses the output of kubectl version -o json, which needs both versions
+func ParseVersions(output string) (Versions, error) {
+	var raw struct {
+		ClientVersion *rawVersion `json:"clientVersion"`
+		ServerVersion *rawVersion `json:"serverVersion"`
+	}
+	if err := json.Unmarshal([]byte(output), &raw); err != nil {
+		return Versions{}, fmt.Errorf("failed to parse kubectl version output: %w", err)
+	}
+	if raw.ClientVersion == nil {
+		return Versions{}, fmt.Errorf("kubectl version reported no client version")
+	}
+	if raw.ServerVersion == nil {
+		return Versions{}, fmt.Errorf("kubectl version reported no server version")
+	}
+	client, err := parseVersion(*raw.ClientVersion)
+	if err != nil {
+		return Versions{}, fmt.Errorf("kubectl: %w", err)
+	}
+	server, err := parseVersion(*raw.ServerVersion)
+	if err != nil {
+		return Versions{}, fmt.Errorf("cluster: %w", err)
+	}
+	return Versions{Client: client, Server: server}, nil
+}
+
+// gitVersionPattern matches the major and minor version of a git version like v1.27.3-gke.100
+var gitVersionPattern = regexp.MustCompile(`^v(\d+)\.(\d+)`)
+
+// parseVersion reads major and minor, which managed clusters suffix with +, falling back to the git version
+func parseVersion(raw rawVersion) (Version, error) {
+	major, majorErr := strconv.Atoi(strings.TrimSuffix(raw.Major, "+"))
+	minor, minorErr := strconv.Atoi(strings.TrimSuffix(raw.Minor, "+"))
+	if majorErr == nil && minorErr == nil {
+		return Version{Major: major, Minor: minor, GitVersion: raw.GitVersion}, nil
+	}
+	matches := gitVersionPattern.FindStringSubmatch(raw.GitVersion)
+	if matches == nil {
+		return Version{}, fmt.Errorf("unrecognised version '%s'", raw.GitVersion)
+	}
+	major, _ = strconv.Atoi(matches[1])
+	minor, _ = strconv.Atoi(matches[2])
+	return Version{Major: major, Minor: minor, GitVersion: raw.GitVersion}, nil
+}
+
+// lastLine returns the last line of kubectl output, where it prints why the server could not be reached
+func lastLine(output string) string {
+	lines := strings.Split(output, "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
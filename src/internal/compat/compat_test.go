@@ -0,0 +1,166 @@
+// Package compat provides unit tests for the kubectl and cluster version check
+// WHY: kubectl debug node flags changed across versions, so a run must adapt or stop before its first node command
+package compat
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"k8ostack-ictl/internal/kubectl"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestParseVersions tests reading the versions kubectl version -o json reports
+// WHY: Managed clusters suffix the minor version, and an unreachable server reports no version at all
+func TestParseVersions(t *testing.T) {
+	tests := []struct {
+		name           string
+		output         string
+		expectedClient Version
+		expectedServer Version
+		expectError    string
+	}{
+		{
+			name:           "plain",
+			output:         `{"clientVersion":{"major":"1","minor":"28","gitVersion":"v1.28.2"},"kustomizeVersion":"v5.0.4","serverVersion":{"major":"1","minor":"27","gitVersion":"v1.27.6"}}`,
+			expectedClient: Version{Major: 1, Minor: 28, GitVersion: "v1.28.2"},
+			expectedServer: Version{Major: 1, Minor: 27, GitVersion: "v1.27.6"},
+		},
+		{
+			name:           "managed_cluster",
+			output:         `{"clientVersion":{"major":"1","minor":"29","gitVersion":"v1.29.0"},"serverVersion":{"major":"1","minor":"27+","gitVersion":"v1.27.3-eks-a5565ad"}}`,
+			expectedClient: Version{Major: 1, Minor: 29, GitVersion: "v1.29.0"},
+			expectedServer: Version{Major: 1, Minor: 27, GitVersion: "v1.27.3-eks-a5565ad"},
+		},
+		{
+			name:           "git_version_only",
+			output:         `{"clientVersion":{"major":"","minor":"","gitVersion":"v1.26.1-dirty"},"serverVersion":{"major":"1","minor":"26","gitVersion":"v1.26.1"}}`,
+			expectedClient: Version{Major: 1, Minor: 26, GitVersion: "v1.26.1-dirty"},
+			expectedServer: Version{Major: 1, Minor: 26, GitVersion: "v1.26.1"},
+		},
+		{
+			name:        "no_server",
+			output:      `{"clientVersion":{"major":"1","minor":"28","gitVersion":"v1.28.2"}}`,
+			expectError: "kubectl version reported no server version",
+		},
+		{
+			name:        "unrecognised",
+			output:      `{"clientVersion":{"gitVersion":"devel"},"serverVersion":{"major":"1","minor":"28"}}`,
+			expectError: "kubectl: unrecognised version 'devel'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// When: Parse the output
+			versions, err := ParseVersions(tt.output)
+
+			// Then: Both versions are read, or the missing one is named
+			if tt.expectError != "" {
+				assert.EqualError(t, err, tt.expectError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedClient, versions.Client)
+			assert.Equal(t, tt.expectedServer, versions.Server)
+		})
+	}
+}
+
+// TestCheck tests checking versions against what each node command backend needs
+// WHY: Old kubectl must still work through a manifest where possible, and fail with the way out where not
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name              string
+		client            Version
+		server            Version
+		backend           string
+		expectLegacyDebug bool
+		expectWarnings    []string
+		expectError       string
+	}{
+		{
+			name:    "supported",
+			client:  Version{Major: 1, Minor: 28},
+			server:  Version{Major: 1, Minor: 28},
+			backend: kubectl.NodeExecBackendDebugPod,
+		},
+		{
+			name:              "debug_pod_without_sysadmin_profile",
+			client:            Version{Major: 1, Minor: 26},
+			server:            Version{Major: 1, Minor: 26},
+			backend:           kubectl.NodeExecBackendDebugPod,
+			expectLegacyDebug: true,
+		},
+		{
+			name:    "agent_without_sysadmin_profile",
+			client:  Version{Major: 1, Minor: 26},
+			server:  Version{Major: 1, Minor: 26},
+			backend: kubectl.NodeExecBackendAgent,
+		},
+		{
+			name:        "ephemeral_without_sysadmin_profile",
+			client:      Version{Major: 1, Minor: 26, GitVersion: "v1.26.3"},
+			server:      Version{Major: 1, Minor: 26},
+			backend:     kubectl.NodeExecBackendEphemeral,
+			expectError: "kubectl v1.26.3 is not compatible with the ephemeral exec backend: it needs kubectl debug --profile=sysadmin, added in kubectl v1.27; upgrade kubectl or use --node-exec-backend debug-pod or agent",
+		},
+		{
+			name:        "ephemeral_without_ephemeral_containers",
+			client:      Version{Major: 1, Minor: 27},
+			server:      Version{Major: 1, Minor: 22, GitVersion: "v1.22.17"},
+			backend:     kubectl.NodeExecBackendEphemeral,
+			expectError: "cluster v1.22.17 is not compatible with the ephemeral exec backend: ephemeral containers are enabled by default since Kubernetes v1.23; upgrade the cluster or use --node-exec-backend debug-pod or agent",
+		},
+		{
+			name:    "unsupported_cluster_and_skew",
+			client:  Version{Major: 1, Minor: 30},
+			server:  Version{Major: 1, Minor: 22},
+			backend: kubectl.NodeExecBackendDebugPod,
+			expectWarnings: []string{
+				"cluster v1.22 is outside the supported Kubernetes versions v1.24 to v1.31",
+				"kubectl v1.30 is 8 minor versions away from cluster v1.22; kubectl supports a skew of 1",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// When: Check the versions for the backend
+			result, err := Check(Versions{Client: tt.client, Server: tt.server}, tt.backend)
+
+			// Then: The run adapts, warns, or stops with the reason
+			if tt.expectError != "" {
+				assert.EqualError(t, err, tt.expectError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectLegacyDebug, result.LegacyDebug)
+			assert.Equal(t, tt.expectLegacyDebug, len(result.Adjustments) > 0)
+			assert.Equal(t, tt.expectWarnings, result.Warnings)
+		})
+	}
+}
+
+// TestRead tests asking kubectl for the versions
+// WHY: An unreachable server must surface kubectl's reason instead of a JSON error
+func TestRead(t *testing.T) {
+	var calls [][]string
+	run := func(ctx context.Context, stdin []byte, args ...string) (string, error) {
+		calls = append(calls, args)
+		return `{"clientVersion":{"major":"1","minor":"28"},"serverVersion":{"major":"1","minor":"28"}}`, nil
+	}
+	versions, err := Read(context.Background(), run)
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"version", "-o", "json"}}, calls)
+	assert.Equal(t, Version{Major: 1, Minor: 28}, versions.Server)
+
+	unreachable := func(ctx context.Context, stdin []byte, args ...string) (string, error) {
+		return "{\n  \"clientVersion\": {}\n}\nThe connection to the server 10.0.0.1:6443 was refused - did you specify the right host or port?\n", errors.New("exit status 1")
+	}
+	_, err = Read(context.Background(), unreachable)
+	assert.EqualError(t, err, "kubectl version failed: The connection to the server 10.0.0.1:6443 was refused - did you specify the right host or port?")
+}
@@ -0,0 +1,172 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// NodeMapping maps node names to their VLAN addresses in CIDR notation
+// A dual-stack node lists several addresses, kept comma separated, e.g. "10.1.0.12/24,fd00:1::12/64".
+type NodeMapping map[string]string
+
+// UnmarshalYAML accepts one address or a list of addresses per node
+func (m *NodeMapping) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: nodeMapping must map node names to addresses", value.Line)
+	}
+	mapping := make(NodeMapping, len(value.Content)/2)
+	for i := 0; i+1 < len(value.Content); i += 2 {
+		key, item := value.Content[i], value.Content[i+1]
+		if item.Kind == yaml.AliasNode {
+			item = item.Alias
+		}
+		switch item.Kind {
+		case yaml.ScalarNode:
+			mapping[key.Value] = item.Value
+		case yaml.SequenceNode:
+			var addresses []string
+			if err := item.Decode(&addresses); err != nil {
+				return fmt.Errorf("line %d: addresses of node %s: %w", item.Line, key.Value, err)
+			}
+			mapping[key.Value] = JoinAddresses(addresses)
+		default:
+			return fmt.Errorf("line %d: node %s needs an address or a list of addresses", item.Line, key.Value)
+		}
+	}
+	*m = mapping
+	return nil
+}
+
+// UnmarshalJSON accepts one address or a list of addresses per node
+func (m *NodeMapping) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	mapping := make(NodeMapping, len(raw))
+	for node, value := range raw {
+		var address string
+		if err := json.Unmarshal(value, &address); err == nil {
+			mapping[node] = address
+			continue
+		}
+		var addresses []string
+		if err := json.Unmarshal(value, &addresses); err != nil {
+			return fmt.Errorf("node %s needs an address or a list of addresses", node)
+		}
+		mapping[node] = JoinAddresses(addresses)
+	}
+	*m = mapping
+	return nil
+}
+
+// MarshalYAML writes dual-stack nodes as a list of addresses
+func (m NodeMapping) MarshalYAML() (interface{}, error) {
+	out := make(map[string]interface{}, len(m))
+	for node, value := range m {
+		if addresses := SplitAddresses(value); len(addresses) > 1 {
+			out[node] = addresses
+		} else {
+			out[node] = value
+		}
+	}
+	return out, nil
+}
+
+// SplitAddresses returns the addresses of a node mapping value, e.g. "10.1.0.12/24,fd00:1::12/64"
+func SplitAddresses(value string) []string {
+	var addresses []string
+	for _, address := range strings.Split(value, ",") {
+		if address = strings.TrimSpace(address); address != "" {
+			addresses = append(addresses, address)
+		}
+	}
+	return addresses
+}
+
+// JoinAddresses returns the node mapping value of a list of addresses
+func JoinAddresses(addresses []string) string {
+	return strings.Join(addresses, ",")
+}
+
+// PrimaryAddress returns the address kictl reaches a node by: its first IPv4 address, or its first address otherwise
+func PrimaryAddress(value string) string {
+	addresses := SplitAddresses(value)
+	for _, address := range addresses {
+		if !IsIPv6Address(address) {
+			return address
+		}
+	}
+	if len(addresses) == 0 {
+		return ""
+	}
+	return addresses[0]
+}
+
+// IsIPv6Address reports whether an address, with or without prefix length, is IPv6
+func IsIPv6Address(address string) bool {
+	host, _, _ := strings.Cut(address, "/")
+	ip := net.ParseIP(host)
+	return ip != nil && ip.To4() == nil
+}
+
+// CanonicalAddress writes an address the way `ip addr` prints it, e.g. fd00:0:0::2/64 -> fd00::2/64
+// An address without prefix length stays without one; applying it still fails on the node.
+func CanonicalAddress(address string) (string, error) {
+	address = strings.TrimSpace(address)
+	if !strings.Contains(address, "/") {
+		ip := net.ParseIP(address)
+		if ip == nil {
+			return "", fmt.Errorf("invalid IP address: %s", address)
+		}
+		return ip.String(), nil
+	}
+	ip, network, err := net.ParseCIDR(address)
+	if err != nil {
+		return "", err
+	}
+	ones, _ := network.Mask.Size()
+	return fmt.Sprintf("%s/%d", ip.String(), ones), nil
+}
+
+// validateNodeAddresses checks that every address of a node is an IP address, listed once
+func validateNodeAddresses(vlanName, node, value string) error {
+	addresses := SplitAddresses(value)
+	if len(addresses) == 0 {
+		return fmt.Errorf("VLAN %s: node %s has no address", vlanName, node)
+	}
+	seen := make(map[string]bool, len(addresses))
+	for _, address := range addresses {
+		canonical, err := CanonicalAddress(address)
+		if err != nil {
+			return fmt.Errorf("VLAN %s: invalid address '%s' of node %s: expected CIDR notation such as 10.0.0.1/24 or fd00::1/64", vlanName, address, node)
+		}
+		if seen[canonical] {
+			return fmt.Errorf("VLAN %s: node %s lists address %s twice", vlanName, node, address)
+		}
+		seen[canonical] = true
+	}
+	return nil
+}
+
+// canonicalNodeMapping rewrites every address of a mapping the way `ip addr` prints it
+func canonicalNodeMapping(mapping NodeMapping) NodeMapping {
+	if mapping == nil {
+		return nil
+	}
+	canonical := make(NodeMapping, len(mapping))
+	for node, value := range mapping {
+		addresses := SplitAddresses(value)
+		for i, address := range addresses {
+			if c, err := CanonicalAddress(address); err == nil {
+				addresses[i] = c
+			}
+		}
+		canonical[node] = JoinAddresses(addresses)
+	}
+	return canonical
+}
@@ -0,0 +1,125 @@
+// Package config provides unit tests for VLAN node addresses
+// WHY: Dual-stack nodes list an IPv4 and an IPv6 address, and both must reach the node exactly as written
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+// TestLoadNodeVLANConf_Addresses tests loading single, IPv6 and dual-stack node mappings
+// WHY: A list of addresses must load as one node mapping, and an address ip would print differently must not cause drift
+func TestLoadNodeVLANConf_Addresses(t *testing.T) {
+	tests := []struct {
+		name        string
+		nodeMapping string
+		expected    NodeMapping
+		expectError string
+	}{
+		{
+			name:        "ipv4",
+			nodeMapping: `rsb2: "10.1.0.2/24"`,
+			expected:    NodeMapping{"rsb2": "10.1.0.2/24"},
+		},
+		{
+			name:        "ipv6_is_canonicalized",
+			nodeMapping: `rsb2: "FD00:1:0:0::2/64"`,
+			expected:    NodeMapping{"rsb2": "fd00:1::2/64"},
+		},
+		{
+			name:        "dual_stack_list",
+			nodeMapping: `rsb2: ["10.1.0.2/24", "fd00:1::2/64"]`,
+			expected:    NodeMapping{"rsb2": "10.1.0.2/24,fd00:1::2/64"},
+		},
+		{
+			name:        "invalid_address",
+			nodeMapping: `rsb2: ["10.1.0.2/24", "fd00:1::zz/64"]`,
+			expectError: "VLAN management: invalid address 'fd00:1::zz/64' of node rsb2",
+		},
+		{
+			name:        "duplicate_address",
+			nodeMapping: `rsb2: ["fd00:1::2/64", "fd00:1:0::2/64"]`,
+			expectError: "VLAN management: node rsb2 lists address fd00:1:0::2/64 twice",
+		},
+		{
+			name:        "no_address",
+			nodeMapping: `rsb2: []`,
+			expectError: "VLAN management: node rsb2 has no address",
+		},
+		{
+			name:        "nested_mapping",
+			nodeMapping: `rsb2: {ipv4: "10.1.0.2/24"}`,
+			expectError: "node rsb2 needs an address or a list of addresses",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A VLAN config with the node mapping
+			data := `apiVersion: openstack.kictl.icycloud.io/v1
+kind: NodeVLANConf
+metadata:
+  name: test-vlans
+spec:
+  vlans:
+    management:
+      id: 100
+      nodeMapping:
+        ` + tt.nodeMapping + "\n"
+
+			// When: Load it
+			conf, err := loadNodeVLANConf([]byte(data), BuiltinDefaults())
+
+			// Then: Every address is kept, written the way ip prints it
+			if tt.expectError != "" {
+				assert.ErrorContains(t, err, tt.expectError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, conf.Spec.VLANs["management"].NodeMapping)
+		})
+	}
+}
+
+// TestNodeMapping_MarshalYAML tests writing node mappings back to YAML
+// WHY: Edited and imported bundles must keep dual-stack nodes as lists, which load back to the same mapping
+func TestNodeMapping_MarshalYAML(t *testing.T) {
+	// Given: A mapping with a single-stack and a dual-stack node
+	mapping := NodeMapping{"rsb2": "10.1.0.2/24", "rsb3": "10.1.0.3/24,fd00:1::3/64"}
+
+	// When: Marshal and load it again
+	data, err := yaml.Marshal(mapping)
+	require.NoError(t, err)
+	var loaded NodeMapping
+	require.NoError(t, yaml.Unmarshal(data, &loaded))
+
+	// Then: The dual-stack node is a list, and nothing is lost
+	assert.Equal(t, "rsb2: 10.1.0.2/24\nrsb3:\n    - 10.1.0.3/24\n    - fd00:1::3/64\n", string(data))
+	assert.Equal(t, mapping, loaded)
+}
+
+// TestPrimaryAddress tests choosing the address kictl reaches a node by
+// WHY: Connectivity tests and the reachability guard need one address, and IPv4 stays the default on dual-stack nodes
+func TestPrimaryAddress(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected string
+	}{
+		{name: "ipv4", value: "10.1.0.2/24", expected: "10.1.0.2/24"},
+		{name: "ipv6_only", value: "fd00:1::2/64", expected: "fd00:1::2/64"},
+		{name: "dual_stack_ipv6_first", value: "fd00:1::2/64,10.1.0.2/24", expected: "10.1.0.2/24"},
+		{name: "empty", value: "", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given/When: Pick the primary address
+			// Then: The first IPv4 address wins
+			assert.Equal(t, tt.expected, PrimaryAddress(tt.value))
+		})
+	}
+}
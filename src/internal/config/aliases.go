@@ -0,0 +1,56 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+)
+
+// AnyNode is the InterfaceAliases key whose aliases apply to every node without its own entry
+const AnyNode = "*"
+
+// InterfaceAliases maps logical interface names to the real interface on each node
+// node -> alias -> interface, e.g. {"*": {"uplink1": "eno1"}, "rsb2": {"uplink1": "ens1f0"}}
+type InterfaceAliases map[string]map[string]string
+
+// Resolve returns the interface an alias names on a node, or name itself when it is no alias
+// A name aliased for other nodes only is an error, since using it literally would target a missing interface.
+func (a InterfaceAliases) Resolve(nodeName, name string) (string, error) {
+	if iface, ok := a[nodeName][name]; ok {
+		return iface, nil
+	}
+	if iface, ok := a[AnyNode][name]; ok {
+		return iface, nil
+	}
+	if a.isAlias(name) {
+		return "", fmt.Errorf("interface alias %s is not defined for node %s", name, nodeName)
+	}
+	return name, nil
+}
+
+// isAlias reports whether any node defines name as an alias
+func (a InterfaceAliases) isAlias(name string) bool {
+	for _, aliases := range a {
+		if _, ok := aliases[name]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// validate checks that every alias names an interface
+func (a InterfaceAliases) validate() error {
+	nodes := make([]string, 0, len(a))
+	for nodeName := range a {
+		nodes = append(nodes, nodeName)
+	}
+	sort.Strings(nodes)
+
+	for _, nodeName := range nodes {
+		for alias, iface := range a[nodeName] {
+			if alias == "" || iface == "" {
+				return fmt.Errorf("spec.interfaceAliases.%s: alias '%s' must name an interface", nodeName, alias)
+			}
+		}
+	}
+	return nil
+}
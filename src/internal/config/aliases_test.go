@@ -0,0 +1,110 @@
+// Package config provides unit tests for interface aliases
+// WHY: Shared configurations name interfaces logically, so each node must resolve to its own NIC
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestInterfaceAliases_Resolve tests resolving logical interface names per node
+// WHY: A node-specific entry must win over the wildcard, and unknown aliases must never be used literally
+func TestInterfaceAliases_Resolve(t *testing.T) {
+	aliases := InterfaceAliases{
+		AnyNode: {"uplink1": "eno1"},
+		"rsb2":  {"uplink1": "ens1f0", "storage": "ens2f0"},
+	}
+
+	tests := []struct {
+		name        string
+		node        string
+		iface       string
+		expected    string
+		expectError string
+	}{
+		{name: "node_specific_alias", node: "rsb2", iface: "uplink1", expected: "ens1f0"},
+		{name: "wildcard_alias", node: "rsb3", iface: "uplink1", expected: "eno1"},
+		{name: "literal_interface", node: "rsb3", iface: "bond0", expected: "bond0"},
+		{name: "alias_missing_on_node", node: "rsb3", iface: "storage", expectError: "interface alias storage is not defined for node rsb3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// When: Resolving the name on the node
+			resolved, err := aliases.Resolve(tt.node, tt.iface)
+
+			// Then: The node's interface, the literal name, or an error
+			if tt.expectError != "" {
+				assert.EqualError(t, err, tt.expectError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, resolved)
+		})
+	}
+
+	// Without aliases every name is literal
+	resolved, err := InterfaceAliases(nil).Resolve("rsb2", "eth0")
+	require.NoError(t, err)
+	assert.Equal(t, "eth0", resolved)
+}
+
+// TestLoadMultipleConfigs_InterfaceAliases tests loading aliases from the Defaults document
+// WHY: VLANs keep the logical name after loading so it is resolved per node at apply time
+func TestLoadMultipleConfigs_InterfaceAliases(t *testing.T) {
+	tests := []struct {
+		name        string
+		aliases     string
+		expectError string
+	}{
+		{
+			name: "aliases_loaded",
+			aliases: `
+    "*":
+      uplink1: eno1
+    rsb2:
+      uplink1: ens1f0`,
+		},
+		{
+			name: "alias_without_interface",
+			aliases: `
+    rsb2:
+      uplink1: ""`,
+			expectError: "spec.interfaceAliases.rsb2: alias 'uplink1' must name an interface",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: Bundle whose Defaults define aliases and whose VLAN uses one
+			data := `apiVersion: openstack.kictl.icycloud.io/v1
+kind: Defaults
+metadata:
+  name: site
+spec:
+  interface: uplink1
+  interfaceAliases:` + tt.aliases + `
+---
+` + defaultsTestVLANs
+			configFile := filepath.Join(t.TempDir(), "bundle.yaml")
+			require.NoError(t, os.WriteFile(configFile, []byte(data), 0644))
+
+			// When: Load it
+			bundle, err := LoadMultipleConfigs(configFile)
+
+			// Then: Aliases are kept for the services or rejected
+			if tt.expectError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "uplink1", bundle.VLANs.Spec.VLANs["management"].Interface)
+			assert.Equal(t, "ens1f0", bundle.GetDefaults().Spec.InterfaceAliases["rsb2"]["uplink1"])
+		})
+	}
+}
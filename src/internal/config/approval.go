@@ -0,0 +1,82 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// ApprovalPolicy requires a plan approved by a second person before apply changes a protected cluster
+type ApprovalPolicy struct {
+	// ProtectedContexts are the kubeconfig contexts, or glob patterns such as "prod-*", that need an approved plan
+	ProtectedContexts []string `json:"protectedContexts" yaml:"protectedContexts"`
+
+	// Approvers may approve plans; when empty, anyone other than the planner may
+	Approvers []Approver `json:"approvers,omitempty" yaml:"approvers,omitempty"`
+}
+
+// Approver is a person allowed to approve plans
+type Approver struct {
+	Name string `json:"name" yaml:"name"`
+
+	// PublicKey, in authorized_keys format, must have signed the approvals of this approver when set
+	PublicKey string `json:"publicKey,omitempty" yaml:"publicKey,omitempty"`
+}
+
+// Protects reports whether applying to a kubeconfig context needs an approved plan
+func (p *ApprovalPolicy) Protects(kubeContext string) bool {
+	if p == nil {
+		return false
+	}
+	for _, pattern := range p.ProtectedContexts {
+		if matched, _ := path.Match(pattern, kubeContext); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// Approver returns the listed approver with the given name
+func (p *ApprovalPolicy) Approver(name string) (Approver, bool) {
+	for _, approver := range p.Approvers {
+		if approver.Name == name {
+			return approver, true
+		}
+	}
+	return Approver{}, false
+}
+
+// validate checks the context patterns, and that approvers are named once and their keys parse
+func (p *ApprovalPolicy) validate() error {
+	if p == nil {
+		return nil
+	}
+	if len(p.ProtectedContexts) == 0 {
+		return fmt.Errorf("spec.approval.protectedContexts must list the contexts needing an approved plan, or \"*\" for every context")
+	}
+	for _, pattern := range p.ProtectedContexts {
+		if _, err := path.Match(pattern, ""); err != nil || strings.TrimSpace(pattern) == "" {
+			return fmt.Errorf("spec.approval.protectedContexts: invalid context pattern '%s'", pattern)
+		}
+	}
+
+	seen := make(map[string]bool, len(p.Approvers))
+	for _, approver := range p.Approvers {
+		if strings.TrimSpace(approver.Name) == "" {
+			return fmt.Errorf("spec.approval.approvers: approver names cannot be empty")
+		}
+		if seen[approver.Name] {
+			return fmt.Errorf("spec.approval.approvers: approver '%s' is listed twice", approver.Name)
+		}
+		seen[approver.Name] = true
+		if approver.PublicKey == "" {
+			continue
+		}
+		if _, _, _, _, err := ssh.ParseAuthorizedKey([]byte(approver.PublicKey)); err != nil {
+			return fmt.Errorf("spec.approval.approvers: invalid public key of '%s': %w", approver.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+// Package config provides unit tests for the approval policy of the Defaults document
+// WHY: A mistyped policy must fail loading instead of silently leaving a production cluster unprotected
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// approvalTestDefaults returns a Defaults document with the given approval section
+func approvalTestDefaults(approval string) string {
+	return `apiVersion: openstack.kictl.icycloud.io/v1
+kind: Defaults
+metadata:
+  name: defaults
+spec:
+  approval:
+` + approval + `---
+` + nodeSetsTestLabels("[rsb1]")
+}
+
+// TestLoadMultipleConfigs_Approval tests loading the approval policy
+// WHY: Context patterns and approver keys are checked when the bundle loads, long before an apply needs them
+func TestLoadMultipleConfigs_Approval(t *testing.T) {
+	tests := []struct {
+		name        string
+		approval    string
+		protected   []string
+		unprotected []string
+		expectError string
+	}{
+		{
+			name:        "patterns_and_keyed_approver",
+			approval:    "    protectedContexts: [prod-*, dr]\n    approvers:\n      - name: bob\n        publicKey: ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIJkV7xVLl4KHG1RXo6ZC5YwSEqLvtTt3b1p0z2dMnPyq bob@lab\n",
+			protected:   []string{"prod-east", "dr"},
+			unprotected: []string{"staging", "dr-test"},
+		},
+		{
+			name:      "every_context",
+			approval:  "    protectedContexts: ['*']\n",
+			protected: []string{"prod", ""},
+		},
+		{
+			name:        "no_contexts",
+			approval:    "    approvers:\n      - name: bob\n",
+			expectError: "spec.approval.protectedContexts must list the contexts needing an approved plan",
+		},
+		{
+			name:        "invalid_pattern",
+			approval:    "    protectedContexts: ['prod-[']\n",
+			expectError: "invalid context pattern 'prod-['",
+		},
+		{
+			name:        "approver_listed_twice",
+			approval:    "    protectedContexts: [prod]\n    approvers:\n      - name: bob\n      - name: bob\n",
+			expectError: "approver 'bob' is listed twice",
+		},
+		{
+			name:        "invalid_public_key",
+			approval:    "    protectedContexts: [prod]\n    approvers:\n      - name: bob\n        publicKey: not-a-key\n",
+			expectError: "invalid public key of 'bob'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A bundle whose Defaults document has an approval policy
+			data := []byte(approvalTestDefaults(tt.approval))
+
+			// When: Load the bundle
+			bundle, err := LoadConfigData(data, "approval.yaml")
+
+			// Then: The policy protects exactly the matching contexts
+			if tt.expectError != "" {
+				assert.ErrorContains(t, err, tt.expectError)
+				return
+			}
+			require.NoError(t, err)
+			policy := bundle.GetDefaults().Spec.Approval
+			for _, kubeContext := range tt.protected {
+				assert.True(t, policy.Protects(kubeContext), kubeContext)
+			}
+			for _, kubeContext := range tt.unprotected {
+				assert.False(t, policy.Protects(kubeContext), kubeContext)
+			}
+		})
+	}
+}
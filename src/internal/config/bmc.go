@@ -0,0 +1,66 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// BMC recovery actions, taken when a network change cuts a node off
+const (
+	BMCActionConsoleLog = "console-log" // Capture the serial console for diagnosis; the default
+	BMCActionPowerCycle = "power-cycle" // Power-cycle the node, which drops interfaces that were not persisted
+)
+
+// BMCRecovery is the last resort when kictl loses a node during a network change
+// Nodes without a BMC entry are left as they are.
+type BMCRecovery struct {
+	Nodes           map[string]NodeBMC `json:"nodes" yaml:"nodes"`                                         // Node name -> its BMC
+	Action          string             `json:"action,omitempty" yaml:"action,omitempty"`                   // console-log (default) or power-cycle
+	ConsoleDuration time.Duration      `json:"consoleDuration,omitempty" yaml:"consoleDuration,omitempty"` // How long console-log reads the serial console, default 30s
+}
+
+// NodeBMC is the out-of-band management controller of a node, reached with IPMI over LAN
+type NodeBMC struct {
+	Address     string    `json:"address" yaml:"address"`                         // BMC host name or IP address
+	Credentials SecretRef `json:"credentials" yaml:"credentials"`                 // Secret with username and password keys
+	Interface   string    `json:"interface,omitempty" yaml:"interface,omitempty"` // ipmitool interface, default lanplus
+}
+
+// SecretRef names a Kubernetes Secret
+type SecretRef struct {
+	Namespace string `json:"namespace,omitempty" yaml:"namespace,omitempty"` // default "default"
+	Name      string `json:"name" yaml:"name"`
+}
+
+// validateBMCRecovery checks that every node's BMC can be reached and the action is known
+func validateBMCRecovery(toolName string, tool ToolConfig) error {
+	recovery := tool.BMC
+	if recovery == nil {
+		return nil
+	}
+	switch recovery.Action {
+	case "", BMCActionConsoleLog, BMCActionPowerCycle:
+	default:
+		return fmt.Errorf("tools.%s.bmc.action must be %s or %s, got '%s'", toolName, BMCActionConsoleLog, BMCActionPowerCycle, recovery.Action)
+	}
+	if recovery.ConsoleDuration < 0 {
+		return fmt.Errorf("tools.%s.bmc.consoleDuration must not be negative, got %s", toolName, recovery.ConsoleDuration)
+	}
+
+	nodes := make([]string, 0, len(recovery.Nodes))
+	for node := range recovery.Nodes {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+	for _, node := range nodes {
+		bmc := recovery.Nodes[node]
+		if bmc.Address == "" {
+			return fmt.Errorf("tools.%s.bmc.nodes.%s.address is required", toolName, node)
+		}
+		if bmc.Credentials.Name == "" {
+			return fmt.Errorf("tools.%s.bmc.nodes.%s.credentials.name is required", toolName, node)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+// Package config provides unit tests for the BMC recovery option
+// WHY: A BMC entry that cannot log in is only noticed when a node is already lost
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestValidateBMCRecovery tests the accepted BMC recovery sections
+// WHY: Every node's BMC needs an address and credentials, and the action must be one kictl can take
+func TestValidateBMCRecovery(t *testing.T) {
+	node1 := NodeBMC{Address: "10.0.9.11", Credentials: SecretRef{Namespace: "kube-system", Name: "node1-bmc"}}
+
+	tests := []struct {
+		name        string
+		recovery    *BMCRecovery
+		expectError string
+	}{
+		{"unset", nil, ""},
+		{"console_log_by_default", &BMCRecovery{Nodes: map[string]NodeBMC{"node1": node1}}, ""},
+		{"power_cycle", &BMCRecovery{Action: BMCActionPowerCycle, ConsoleDuration: time.Minute, Nodes: map[string]NodeBMC{"node1": node1}}, ""},
+		{"unknown_action", &BMCRecovery{Action: "reset"}, "tools.nvlan.bmc.action must be console-log or power-cycle, got 'reset'"},
+		{"negative_duration", &BMCRecovery{ConsoleDuration: -time.Second}, "tools.nvlan.bmc.consoleDuration must not be negative, got -1s"},
+		{"missing_address", &BMCRecovery{Nodes: map[string]NodeBMC{"node2": {Credentials: node1.Credentials}}}, "tools.nvlan.bmc.nodes.node2.address is required"},
+		{"missing_credentials", &BMCRecovery{Nodes: map[string]NodeBMC{"node2": {Address: "10.0.9.12"}}}, "tools.nvlan.bmc.nodes.node2.credentials.name is required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A tool configuration with a BMC recovery section
+			tool := ToolConfig{BMC: tt.recovery}
+
+			// When: Validate it
+			err := validateBMCRecovery("nvlan", tool)
+
+			// Then: Only usable BMC sections are accepted
+			if tt.expectError != "" {
+				assert.EqualError(t, err, tt.expectError)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
@@ -3,18 +3,24 @@ package config
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 )
 
 // ConfigBundle holds multiple related configurations that can be processed together
 // This enables single-manifest deployment of complex infrastructure setups
 type ConfigBundle struct {
-	NodeLabels *NodeLabelConf // Node labeling configuration
-	VLANs      *NodeVLANConf  // VLAN configuration
-	Tests      *NodeTestConf  // Connectivity testing configuration
+	NodeLabels *NodeLabelConf   // Node labeling configuration
+	VLANs      *NodeVLANConf    // VLAN configuration
+	Tests      *NodeTestConf    // Connectivity testing configuration
+	Cleanup    *CleanupConf     // Bulk label cleanup configuration
+	Sysctls    *NodeSysctlConf  // Kernel tuning configuration
+	Storage    *NodeStorageConf // Storage device preparation configuration
+	Defaults   *Defaults        // Defaults merged into every configuration; nil means built-in defaults
 
 	// Metadata about the bundle
 	Source string // Path to the source configuration file
+	Digest string // SHA-256 of the source configuration, e.g. "sha256:9f86..."; empty for in-memory bundles
 }
 
 // GetAllConfigs returns all non-nil configurations in the bundle
@@ -31,6 +37,15 @@ func (b *ConfigBundle) GetAllConfigs() []interface{} {
 	if b.Tests != nil {
 		configs = append(configs, b.Tests)
 	}
+	if b.Cleanup != nil {
+		configs = append(configs, b.Cleanup)
+	}
+	if b.Sysctls != nil {
+		configs = append(configs, b.Sysctls)
+	}
+	if b.Storage != nil {
+		configs = append(configs, b.Storage)
+	}
 
 	return configs
 }
@@ -49,10 +64,27 @@ func (b *ConfigBundle) GetAllConfigsTyped() []Config {
 	if b.Tests != nil {
 		configs = append(configs, b.Tests)
 	}
+	if b.Cleanup != nil {
+		configs = append(configs, b.Cleanup)
+	}
+	if b.Sysctls != nil {
+		configs = append(configs, b.Sysctls)
+	}
+	if b.Storage != nil {
+		configs = append(configs, b.Storage)
+	}
 
 	return configs
 }
 
+// GetDefaults returns the bundle defaults, falling back to the built-in defaults
+func (b *ConfigBundle) GetDefaults() *Defaults {
+	if b.Defaults == nil {
+		return BuiltinDefaults()
+	}
+	return b.Defaults
+}
+
 // GetConfigCount returns the number of configurations in the bundle
 func (b *ConfigBundle) GetConfigCount() int {
 	return len(b.GetAllConfigs())
@@ -73,6 +105,59 @@ func (b *ConfigBundle) HasTests() bool {
 	return b.Tests != nil
 }
 
+// HasCleanup returns true if the bundle contains label cleanup configuration
+func (b *ConfigBundle) HasCleanup() bool {
+	return b.Cleanup != nil
+}
+
+// HasSysctls returns true if the bundle contains kernel tuning configuration
+func (b *ConfigBundle) HasSysctls() bool {
+	return b.Sysctls != nil
+}
+
+// HasStorage returns true if the bundle contains storage device configuration
+func (b *ConfigBundle) HasStorage() bool {
+	return b.Storage != nil
+}
+
+// GetAllNodeNames returns the sorted, de-duplicated node names referenced by the bundle
+func (b *ConfigBundle) GetAllNodeNames() []string {
+	unique := make(map[string]bool)
+
+	if b.HasNodeLabels() {
+		for node := range nodeLabelConfNodes(*b.NodeLabels) {
+			unique[node] = true
+		}
+	}
+	if b.HasVLANs() {
+		for node := range nodeVLANConfNodes(*b.VLANs) {
+			unique[node] = true
+		}
+	}
+	if b.HasCleanup() {
+		for node := range cleanupConfNodes(*b.Cleanup) {
+			unique[node] = true
+		}
+	}
+	if b.HasSysctls() {
+		for node := range nodeSysctlConfNodes(*b.Sysctls) {
+			unique[node] = true
+		}
+	}
+	if b.HasStorage() {
+		for node := range nodeStorageConfNodes(*b.Storage) {
+			unique[node] = true
+		}
+	}
+
+	names := make([]string, 0, len(unique))
+	for node := range unique {
+		names = append(names, node)
+	}
+	sort.Strings(names)
+	return names
+}
+
 // GetSummary returns a human-readable summary of the bundle contents
 func (b *ConfigBundle) GetSummary() string {
 	var parts []string
@@ -94,6 +179,22 @@ func (b *ConfigBundle) GetSummary() string {
 		parts = append(parts, fmt.Sprintf("Tests(%d tests)", len(b.Tests.Spec.Tests)))
 	}
 
+	if b.HasCleanup() {
+		parts = append(parts, fmt.Sprintf("Cleanup(%d prefixes)", len(b.Cleanup.Spec.LabelPrefixes)))
+	}
+
+	if b.HasSysctls() {
+		parts = append(parts, fmt.Sprintf("Sysctls(%d roles)", len(b.Sysctls.Spec.NodeRoles)))
+	}
+
+	if b.HasStorage() {
+		deviceCount := 0
+		for _, node := range b.Storage.Spec.Nodes {
+			deviceCount += len(node.Devices)
+		}
+		parts = append(parts, fmt.Sprintf("Storage(%d nodes, %d devices)", len(b.Storage.Spec.Nodes), deviceCount))
+	}
+
 	if len(parts) == 0 {
 		return "Empty bundle"
 	}
@@ -120,6 +221,29 @@ func (b *ConfigBundle) Validate() error {
 	return nil
 }
 
+// Check is the outcome of one validation rule; Err is nil when the rule passed
+type Check struct {
+	Rule string
+	Err  error
+}
+
+// Checks runs every bundle validation rule and reports each outcome instead of stopping at the first failure
+// Documents are checked one by one, followed by the node name policy.
+func (b *ConfigBundle) Checks() []Check {
+	var checks []Check
+	if b.GetConfigCount() == 0 {
+		checks = append(checks, Check{Rule: "bundle", Err: fmt.Errorf("bundle contains no configurations")})
+	}
+
+	for _, cfg := range b.GetAllConfigsTyped() {
+		rule := fmt.Sprintf("document %s/%s", cfg.GetKind(), cfg.GetMetadata().Name)
+		checks = append(checks, Check{Rule: rule, Err: b.validateConfig(cfg)})
+	}
+
+	checks = append(checks, Check{Rule: "node name policy", Err: b.ValidateNodeNamePolicy()})
+	return checks
+}
+
 // validateConfig performs basic validation on a single configuration
 func (b *ConfigBundle) validateConfig(cfg Config) error {
 	if cfg.GetAPIVersion() == "" {
@@ -161,6 +285,18 @@ func NewSingleConfigBundle(cfg Config) *ConfigBundle {
 		bundle.Tests = c
 	case NodeTestConf:
 		bundle.Tests = &c
+	case *CleanupConf:
+		bundle.Cleanup = c
+	case CleanupConf:
+		bundle.Cleanup = &c
+	case *NodeSysctlConf:
+		bundle.Sysctls = c
+	case NodeSysctlConf:
+		bundle.Sysctls = &c
+	case *NodeStorageConf:
+		bundle.Storage = c
+	case NodeStorageConf:
+		bundle.Storage = &c
 	}
 
 	return bundle
@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // TestConfigBundle_GetAllConfigs tests configuration aggregation
@@ -416,6 +417,65 @@ func TestConfigBundle_Validate(t *testing.T) {
 	}
 }
 
+// TestConfigBundle_Checks tests rule-by-rule bundle validation
+// WHY: Validate reports stop at the first failure, while CI reports need every rule's outcome
+func TestConfigBundle_Checks(t *testing.T) {
+	tests := []struct {
+		name     string
+		bundle   *ConfigBundle
+		expected map[string]string // rule -> expected error text, empty when the rule passes
+	}{
+		{
+			name:     "empty_bundle",
+			bundle:   NewEmptyBundle(),
+			expected: map[string]string{"bundle": "bundle contains no configurations", "node name policy": ""},
+		},
+		{
+			name: "every_rule_reported",
+			bundle: &ConfigBundle{
+				NodeLabels: &NodeLabelConf{
+					APIVersion: "openstack.kictl.icycloud.io/v1",
+					Kind:       "NodeLabelConf",
+					Metadata:   Metadata{Name: "labels"},
+					Spec: NodeLabelSpec{NodeRoles: map[string]NodeRole{
+						"control": {Nodes: []string{"RSB2"}, Labels: map[string]string{"role": "control"}},
+					}},
+					Tools: Tools{Nlabel: ToolConfig{NodeNamePattern: "rsb[0-9]+"}},
+				},
+				VLANs: &NodeVLANConf{
+					Kind:     "NodeVLANConf",
+					Metadata: Metadata{Name: "vlans"},
+				},
+			},
+			expected: map[string]string{
+				"document NodeLabelConf/labels": "",
+				"document NodeVLANConf/vlans":   "apiVersion is required",
+				"node name policy":              "RSB2",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// When: Run the checks
+			checks := tt.bundle.Checks()
+
+			// Then: Each rule is reported with its own outcome
+			require.Len(t, checks, len(tt.expected))
+			for _, check := range checks {
+				expectedError, ok := tt.expected[check.Rule]
+				require.True(t, ok, "unexpected rule %s", check.Rule)
+				if expectedError == "" {
+					assert.NoError(t, check.Err, check.Rule)
+				} else {
+					require.Error(t, check.Err, check.Rule)
+					assert.Contains(t, check.Err.Error(), expectedError)
+				}
+			}
+		})
+	}
+}
+
 // TestNewSingleConfigBundle tests single config bundle creation
 // WHY: Validates compatibility bridge between single-config and multi-config workflows
 func TestNewSingleConfigBundle(t *testing.T) {
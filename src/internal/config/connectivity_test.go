@@ -0,0 +1,81 @@
+// Package config provides unit tests for the types of connectivity tests
+// WHY: Each type needs its own settings, and settings of another type must not be silently ignored
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestValidateConnectivityTest tests the accepted test types and their settings
+// WHY: A typo in the type must not silently fall back to a ping test
+func TestValidateConnectivityTest(t *testing.T) {
+	tests := []struct {
+		name        string
+		test        ConnectivityTest
+		expectError string
+	}{
+		{"ping_default", ConnectivityTest{Name: "mgmt"}, ""},
+		{"bandwidth", ConnectivityTest{Name: "storage", Type: TestTypeBandwidth, MinMbps: 9000}, ""},
+		{"bandwidth_without_minimum", ConnectivityTest{Name: "storage", Type: TestTypeBandwidth}, "test 'storage': bandwidth tests must set minMbps above 0"},
+		{"bandwidth_bad_port", ConnectivityTest{Name: "storage", Type: TestTypeBandwidth, MinMbps: 900, Port: 70000}, "test 'storage': port must be between 1 and 65535, got 70000"},
+		{"ping_with_minimum", ConnectivityTest{Name: "mgmt", Type: TestTypePing, MinMbps: 900}, "test 'mgmt': minMbps and duration only apply to bandwidth tests"},
+		{"ping_with_port", ConnectivityTest{Name: "mgmt", Port: 22}, "test 'mgmt': port only applies to tcp and bandwidth tests"},
+		{"tcp", ConnectivityTest{Name: "galera", Type: TestTypeTCP, Targets: []string{"management"}, Port: 3306}, ""},
+		{"tcp_without_port", ConnectivityTest{Name: "galera", Type: TestTypeTCP, Targets: []string{"management"}}, "test 'galera': tcp tests need a port between 1 and 65535, got 0"},
+		{"tcp_without_targets", ConnectivityTest{Name: "galera", Type: TestTypeTCP, Port: 3306}, "test 'galera': tcp tests need at least one target"},
+		{"http", ConnectivityTest{Name: "keystone", Type: TestTypeHTTP, URL: "https://keystone.mgmt:5000/v3", ExpectStatus: 300}, ""},
+		{"http_without_scheme", ConnectivityTest{Name: "keystone", Type: TestTypeHTTP, URL: "keystone.mgmt:5000"}, "test 'keystone': http tests need an http:// or https:// url, got 'keystone.mgmt:5000'"},
+		{"http_bad_status", ConnectivityTest{Name: "keystone", Type: TestTypeHTTP, URL: "http://keystone.mgmt", ExpectStatus: 20}, "test 'keystone': expectStatus must be an HTTP status code, got 20"},
+		{"http_with_targets", ConnectivityTest{Name: "keystone", Type: TestTypeHTTP, URL: "http://keystone.mgmt", Targets: []string{"api"}}, "test 'keystone': http tests request their url and take no targets or port"},
+		{"url_on_tcp", ConnectivityTest{Name: "galera", Type: TestTypeTCP, Targets: []string{"management"}, Port: 3306, URL: "http://db"}, "test 'galera': url, expectStatus and insecure only apply to http tests"},
+		{"negative_retries", ConnectivityTest{Name: "mgmt", Retries: -1}, "test 'mgmt': timeout, retries and retryDelay must not be negative"},
+		{"bandwidth_timeout_below_duration", ConnectivityTest{Name: "storage", Type: TestTypeBandwidth, MinMbps: 900, Duration: 30, Timeout: 30}, "test 'storage': timeout must exceed the bandwidth duration of 30s, got 30s"},
+		{"unknown_type", ConnectivityTest{Name: "mgmt", Type: "iperf"}, "test 'mgmt' has unknown type 'iperf'. Expected: ping, tcp, http or bandwidth"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A connectivity test of a NodeTestConf
+
+			// When: Validate it
+			err := validateConnectivityTest(tt.test)
+
+			// Then: Only known types with usable bandwidth settings are accepted
+			if tt.expectError != "" {
+				assert.EqualError(t, err, tt.expectError)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+// TestApplyNodeTestDefaults_Types tests the defaults of bandwidth and http tests
+// WHY: Bandwidth tests always expect traffic to flow, so segmentation must never take them for isolation tests
+func TestApplyNodeTestDefaults_Types(t *testing.T) {
+	// Given: A bandwidth test with only a minimum, a ping test and an http test without a status
+	conf := NodeTestConf{Spec: NodeTestSpec{Tests: []ConnectivityTest{
+		{Name: "storage", Type: TestTypeBandwidth, MinMbps: 9000, Duration: 60},
+		{Name: "isolation", Source: "tenant", Targets: []string{"management"}},
+		{Name: "keystone", Type: TestTypeHTTP, Source: "management", URL: "https://keystone.mgmt:5000/v3"},
+	}}}
+
+	// When: Apply the defaults
+	conf = applyNodeTestDefaults(conf)
+
+	// Then: The bandwidth test expects success on the iperf3 port with time beyond its duration, the http test a 200 and the ping test is unchanged
+	bandwidth := conf.Spec.Tests[0]
+	assert.True(t, bandwidth.ExpectSuccess)
+	assert.Equal(t, 60, bandwidth.Duration)
+	assert.Equal(t, 5201, bandwidth.Port)
+	assert.Equal(t, 80, bandwidth.Timeout)
+	ping := conf.Spec.Tests[1]
+	assert.False(t, ping.ExpectSuccess)
+	assert.Zero(t, ping.Duration)
+	assert.Zero(t, ping.Port)
+	assert.Equal(t, 200, conf.Spec.Tests[2].ExpectStatus)
+	assert.False(t, conf.Spec.Tests[2].ExpectSuccess)
+}
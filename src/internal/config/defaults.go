@@ -0,0 +1,237 @@
+package config
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultsKind is the kind of the optional bundle document holding defaults for every other document
+const DefaultsKind = "Defaults"
+
+// Built-in defaults used when neither the Defaults document nor a configuration sets a value
+const (
+	DefaultInterface     = "eth0"
+	DefaultDebugImage    = "busybox"
+	DefaultLabelLogLevel = "info" // Log level of the labeling service
+)
+
+// Interface detection strategies choosing the parent interface of VLANs that set none, per node
+const (
+	InterfaceDetectionInternalIP  = "internal-ip"  // Interface carrying the node's InternalIP
+	InterfaceDetectionFastestLink = "fastest-link" // Physical or bond interface with the highest link speed
+)
+
+// Defaults holds bundle-wide defaults merged into each configuration before its own values
+type Defaults struct {
+	APIVersion string       `json:"apiVersion" yaml:"apiVersion"`
+	Kind       string       `json:"kind" yaml:"kind"`
+	Metadata   Metadata     `json:"metadata" yaml:"metadata"`
+	Spec       DefaultsSpec `json:"spec" yaml:"spec"`
+
+	// tools keeps the raw tool settings so an explicit false still overrides a built-in true
+	tools map[string]interface{}
+}
+
+// DefaultsSpec contains the values applied to every configuration in the bundle
+type DefaultsSpec struct {
+	Interface          string            `json:"interface,omitempty" yaml:"interface,omitempty"`                   // Parent interface of VLANs that set none
+	InterfaceDetection string            `json:"interfaceDetection,omitempty" yaml:"interfaceDetection,omitempty"` // Detect the parent interface per node instead
+	InterfaceAliases   InterfaceAliases  `json:"interfaceAliases,omitempty" yaml:"interfaceAliases,omitempty"`     // Logical interface names resolved per node
+	DebugImage         string            `json:"debugImage,omitempty" yaml:"debugImage,omitempty"`                 // Image of the pods running node commands
+	SSH                *SSHTransport     `json:"ssh,omitempty" yaml:"ssh,omitempty"`                               // Run node commands over SSH on some nodes
+	NodeSets           NodeSets          `json:"nodeSets,omitempty" yaml:"nodeSets,omitempty"`                     // Named node lists for node set operations
+	Approval           *ApprovalPolicy   `json:"approval,omitempty" yaml:"approval,omitempty"`                     // Clusters whose applies need a plan approved by a second person
+	Ownership          *OwnershipPolicy  `json:"ownership,omitempty" yaml:"ownership,omitempty"`                   // Owner of the bundle's label keys and VLANs on shared nodes
+	Quarantine         *QuarantinePolicy `json:"quarantine,omitempty" yaml:"quarantine,omitempty"`                 // Take nodes out of runs after repeated failures
+	Tools              Tools             `json:"tools,omitempty" yaml:"tools,omitempty"`                           // Tool options of every configuration
+}
+
+// builtinTools are the tool options applied unless the bundle configures them
+func builtinTools() map[string]interface{} {
+	return map[string]interface{}{
+		"nvlan": map[string]interface{}{
+			"validateConnectivity": true,
+			"persistentConfig":     false,
+		},
+	}
+}
+
+// BuiltinDefaults returns the defaults of a bundle without a Defaults document
+func BuiltinDefaults() *Defaults {
+	return &Defaults{
+		APIVersion: "openstack.kictl.icycloud.io/v1",
+		Kind:       DefaultsKind,
+		Metadata:   Metadata{Name: "builtin"},
+		Spec: DefaultsSpec{
+			Interface:  DefaultInterface,
+			DebugImage: DefaultDebugImage,
+			Tools:      Tools{Nvlan: ToolConfig{ValidateConnectivity: true}},
+		},
+		tools: builtinTools(),
+	}
+}
+
+// BuiltinTools returns the tool options of a NodeLabelConf that neither the bundle nor the document sets
+func (c NodeLabelConf) BuiltinTools() Tools {
+	tools := BuiltinDefaults().Spec.Tools
+	tools.Nlabel.LogLevel = DefaultLabelLogLevel
+	tools.Nlabel.ValidateNodes = true
+	return tools
+}
+
+// BuiltinTools returns the tool options of a NodeVLANConf that neither the bundle nor the document sets
+func (c NodeVLANConf) BuiltinTools() Tools {
+	return BuiltinDefaults().Spec.Tools
+}
+
+// BuiltinTools returns the tool options of a NodeTestConf that neither the bundle nor the document sets
+func (c NodeTestConf) BuiltinTools() Tools {
+	return BuiltinDefaults().Spec.Tools
+}
+
+// BuiltinTools returns the tool options of a CleanupConf that neither the bundle nor the document sets
+func (c CleanupConf) BuiltinTools() Tools {
+	return BuiltinDefaults().Spec.Tools
+}
+
+// BuiltinTools returns the tool options of a NodeSysctlConf that neither the bundle nor the document sets
+func (c NodeSysctlConf) BuiltinTools() Tools {
+	return BuiltinDefaults().Spec.Tools
+}
+
+// BuiltinTools returns the tool options of a NodeStorageConf that neither the bundle nor the document sets
+func (c NodeStorageConf) BuiltinTools() Tools {
+	return BuiltinDefaults().Spec.Tools
+}
+
+// loadDefaults loads a Defaults document layered over the built-in defaults
+func loadDefaults(data []byte) (*Defaults, error) {
+	data, err := resolveDefaultsNodeSets(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var defaults Defaults
+	if err := yaml.Unmarshal(data, &defaults); err != nil {
+		return nil, fmt.Errorf("failed to parse Defaults: %w", err)
+	}
+
+	if err := validateDefaults(defaults); err != nil {
+		return nil, err
+	}
+
+	var raw struct {
+		Spec struct {
+			Tools map[string]interface{} `yaml:"tools"`
+		} `yaml:"spec"`
+	}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse Defaults tools: %w", err)
+	}
+
+	builtin := BuiltinDefaults()
+	if defaults.Spec.Interface == "" && defaults.Spec.InterfaceDetection == "" {
+		defaults.Spec.Interface = builtin.Spec.Interface
+	}
+	if defaults.Spec.DebugImage == "" {
+		defaults.Spec.DebugImage = builtin.Spec.DebugImage
+	}
+	defaults.tools = mergeMaps(builtin.tools, raw.Spec.Tools)
+	if err := decodeTools(defaults.tools, &defaults.Spec.Tools); err != nil {
+		return nil, fmt.Errorf("invalid Defaults tools: %w", err)
+	}
+
+	return &defaults, nil
+}
+
+// validateDefaults validates the Defaults document format
+func validateDefaults(defaults Defaults) error {
+	if defaults.Kind != DefaultsKind {
+		return fmt.Errorf("config kind must be '%s', got '%s'", DefaultsKind, defaults.Kind)
+	}
+
+	if err := validateAPIVersion(defaults.APIVersion); err != nil {
+		return err
+	}
+
+	if defaults.Metadata.Name == "" {
+		return fmt.Errorf("config metadata.name is required")
+	}
+
+	switch defaults.Spec.InterfaceDetection {
+	case "", InterfaceDetectionInternalIP, InterfaceDetectionFastestLink:
+	default:
+		return fmt.Errorf("spec.interfaceDetection must be '%s' or '%s', got '%s'",
+			InterfaceDetectionInternalIP, InterfaceDetectionFastestLink, defaults.Spec.InterfaceDetection)
+	}
+
+	if defaults.Spec.InterfaceDetection != "" && defaults.Spec.Interface != "" {
+		return fmt.Errorf("spec.interface and spec.interfaceDetection cannot both be set")
+	}
+
+	if err := defaults.Spec.InterfaceAliases.validate(); err != nil {
+		return err
+	}
+
+	if err := validateSSHTransport(defaults.Spec.SSH); err != nil {
+		return err
+	}
+
+	if err := defaults.Spec.NodeSets.validate(); err != nil {
+		return err
+	}
+
+	if err := defaults.Spec.Approval.validate(); err != nil {
+		return err
+	}
+
+	if err := defaults.Spec.Ownership.validate(); err != nil {
+		return err
+	}
+
+	if err := defaults.Spec.Quarantine.validate(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// applyTools sets tools to the default tool options overlaid with those the document sets itself
+func (d *Defaults) applyTools(data []byte, tools *Tools) error {
+	var raw struct {
+		Tools map[string]interface{} `yaml:"tools"`
+	}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	return decodeTools(mergeMaps(d.tools, raw.Tools), tools)
+}
+
+// decodeTools converts raw tool settings into typed tool options
+func decodeTools(raw map[string]interface{}, tools *Tools) error {
+	data, err := yaml.Marshal(raw)
+	if err != nil {
+		return err
+	}
+	*tools = Tools{}
+	return yaml.Unmarshal(data, tools)
+}
+
+// mergeMaps returns base overlaid with override, merging nested maps key by key
+func mergeMaps(base, override map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(base)+len(override))
+	for key, value := range base {
+		merged[key] = value
+	}
+	for key, value := range override {
+		baseMap, baseIsMap := merged[key].(map[string]interface{})
+		overrideMap, overrideIsMap := value.(map[string]interface{})
+		if baseIsMap && overrideIsMap {
+			merged[key] = mergeMaps(baseMap, overrideMap)
+			continue
+		}
+		merged[key] = value
+	}
+	return merged
+}
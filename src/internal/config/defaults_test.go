@@ -0,0 +1,307 @@
+// Package config provides unit tests for bundle defaults
+// WHY: Defaults change every configuration of a bundle, so their precedence must be exact
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// defaultsTestVLANs is a VLAN document with one VLAN relying on the default interface
+const defaultsTestVLANs = `apiVersion: openstack.kictl.icycloud.io/v1
+kind: NodeVLANConf
+metadata:
+  name: vlans
+spec:
+  vlans:
+    management:
+      id: 100
+      subnet: 192.168.100.0/24
+      nodeMapping:
+        rsb2: 192.168.100.12/24
+    storage:
+      id: 200
+      subnet: 192.168.200.0/24
+      interface: eth2
+      nodeMapping:
+        rsb2: 192.168.200.12/24
+`
+
+// TestLoadMultipleConfigs_Defaults tests merging the Defaults document into each configuration
+// WHY: Document values must win over bundle defaults, which must win over built-in defaults
+func TestLoadMultipleConfigs_Defaults(t *testing.T) {
+	tests := []struct {
+		name           string
+		configData     string
+		expectError    string
+		validateBundle func(*testing.T, *ConfigBundle)
+	}{
+		{
+			name: "builtin_defaults_without_document",
+			configData: defaultsTestVLANs + `---
+apiVersion: openstack.kictl.icycloud.io/v1
+kind: NodeTestConf
+metadata:
+  name: tests
+spec:
+  tests:
+    - name: ping
+      source: rsb2
+      targets: [rsb3]
+`,
+			validateBundle: func(t *testing.T, bundle *ConfigBundle) {
+				assert.Equal(t, DefaultInterface, bundle.VLANs.Spec.VLANs["management"].Interface)
+				assert.Equal(t, "eth2", bundle.VLANs.Spec.VLANs["storage"].Interface)
+				assert.True(t, bundle.VLANs.Tools.Nvlan.ValidateConnectivity)
+				assert.False(t, bundle.VLANs.Tools.Nvlan.PersistentConfig)
+				assert.Equal(t, DefaultDebugImage, bundle.GetDefaults().Spec.DebugImage)
+			},
+		},
+		{
+			name: "defaults_document_after_configurations",
+			configData: defaultsTestVLANs + `---
+apiVersion: openstack.kictl.icycloud.io/v1
+kind: Defaults
+metadata:
+  name: site
+spec:
+  interface: bond0
+  debugImage: registry.local/busybox:1.36
+  tools:
+    nvlan:
+      validateConnectivity: false
+      persistentConfig: true
+      logLevel: debug
+`,
+			validateBundle: func(t *testing.T, bundle *ConfigBundle) {
+				assert.Equal(t, "bond0", bundle.VLANs.Spec.VLANs["management"].Interface)
+				assert.Equal(t, "eth2", bundle.VLANs.Spec.VLANs["storage"].Interface)
+				assert.False(t, bundle.VLANs.Tools.Nvlan.ValidateConnectivity, "explicit false must override the built-in true")
+				assert.True(t, bundle.VLANs.Tools.Nvlan.PersistentConfig)
+				assert.Equal(t, "debug", bundle.VLANs.Tools.Nvlan.LogLevel)
+				assert.Equal(t, "registry.local/busybox:1.36", bundle.GetDefaults().Spec.DebugImage)
+				assert.Equal(t, 1, bundle.GetConfigCount(), "Defaults is not a configuration")
+			},
+		},
+		{
+			name: "document_values_override_defaults",
+			configData: `apiVersion: openstack.kictl.icycloud.io/v1
+kind: Defaults
+metadata:
+  name: site
+spec:
+  tools:
+    nvlan:
+      persistentConfig: true
+      logLevel: debug
+---
+` + defaultsTestVLANs + `tools:
+  nvlan:
+    persistentConfig: false
+    validateConnectivity: false
+`,
+			validateBundle: func(t *testing.T, bundle *ConfigBundle) {
+				assert.Equal(t, DefaultInterface, bundle.VLANs.Spec.VLANs["management"].Interface)
+				assert.False(t, bundle.VLANs.Tools.Nvlan.PersistentConfig)
+				assert.False(t, bundle.VLANs.Tools.Nvlan.ValidateConnectivity)
+				assert.Equal(t, "debug", bundle.VLANs.Tools.Nvlan.LogLevel, "unset document fields keep the default")
+			},
+		},
+		{
+			name: "defaults_node_name_pattern_is_enforced",
+			configData: `apiVersion: openstack.kictl.icycloud.io/v1
+kind: Defaults
+metadata:
+  name: site
+spec:
+  tools:
+    nvlan:
+      nodeNamePattern: "node-[0-9]+"
+---
+` + defaultsTestVLANs,
+			expectError: "rsb2",
+		},
+		{
+			name: "duplicate_defaults",
+			configData: `apiVersion: openstack.kictl.icycloud.io/v1
+kind: Defaults
+metadata:
+  name: first
+---
+apiVersion: openstack.kictl.icycloud.io/v1
+kind: Defaults
+metadata:
+  name: second
+---
+` + defaultsTestVLANs,
+			expectError: "duplicate Defaults in document 2",
+		},
+		{
+			name: "defaults_only",
+			configData: `apiVersion: openstack.kictl.icycloud.io/v1
+kind: Defaults
+metadata:
+  name: site
+---
+`,
+			expectError: "bundle contains no configurations",
+		},
+		{
+			name: "defaults_without_name",
+			configData: `apiVersion: openstack.kictl.icycloud.io/v1
+kind: Defaults
+metadata: {}
+---
+` + defaultsTestVLANs,
+			expectError: "metadata.name is required",
+		},
+		{
+			name: "interface_detection_leaves_interface_unset",
+			configData: `apiVersion: openstack.kictl.icycloud.io/v1
+kind: Defaults
+metadata:
+  name: site
+spec:
+  interfaceDetection: internal-ip
+---
+` + defaultsTestVLANs,
+			validateBundle: func(t *testing.T, bundle *ConfigBundle) {
+				assert.Equal(t, InterfaceDetectionInternalIP, bundle.GetDefaults().Spec.InterfaceDetection)
+				assert.Empty(t, bundle.GetDefaults().Spec.Interface)
+				assert.Empty(t, bundle.VLANs.Spec.VLANs["management"].Interface, "detected per node at apply time")
+				assert.Equal(t, "eth2", bundle.VLANs.Spec.VLANs["storage"].Interface)
+			},
+		},
+		{
+			name: "unknown_interface_detection",
+			configData: `apiVersion: openstack.kictl.icycloud.io/v1
+kind: Defaults
+metadata:
+  name: site
+spec:
+  interfaceDetection: widest-pipe
+---
+` + defaultsTestVLANs,
+			expectError: "spec.interfaceDetection must be 'internal-ip' or 'fastest-link'",
+		},
+		{
+			name: "interface_and_detection_together",
+			configData: `apiVersion: openstack.kictl.icycloud.io/v1
+kind: Defaults
+metadata:
+  name: site
+spec:
+  interface: bond0
+  interfaceDetection: fastest-link
+---
+` + defaultsTestVLANs,
+			expectError: "cannot both be set",
+		},
+		{
+			name: "ssh_transport",
+			configData: `apiVersion: openstack.kictl.icycloud.io/v1
+kind: Defaults
+metadata:
+  name: site
+spec:
+  ssh:
+    nodes: ["edge-*"]
+    user: ops
+    bastion: jump@bastion.lab:2222
+    hosts:
+      edge-1: 192.168.10.21
+---
+` + defaultsTestVLANs,
+			validateBundle: func(t *testing.T, bundle *ConfigBundle) {
+				transport := bundle.GetDefaults().Spec.SSH
+				require.NotNil(t, transport)
+				assert.Equal(t, []string{"edge-*"}, transport.Nodes)
+				assert.Equal(t, "ops", transport.User)
+				assert.Equal(t, map[string]string{"edge-1": "192.168.10.21"}, transport.Hosts)
+			},
+		},
+		{
+			name: "ssh_without_nodes",
+			configData: `apiVersion: openstack.kictl.icycloud.io/v1
+kind: Defaults
+metadata:
+  name: site
+spec:
+  ssh:
+    user: ops
+---
+` + defaultsTestVLANs,
+			expectError: "spec.ssh.nodes must list the nodes reached over SSH",
+		},
+		{
+			name: "ssh_invalid_bastion",
+			configData: `apiVersion: openstack.kictl.icycloud.io/v1
+kind: Defaults
+metadata:
+  name: site
+spec:
+  ssh:
+    nodes: ["*"]
+    bastion: bastion.lab:ssh
+---
+` + defaultsTestVLANs,
+			expectError: "invalid port in SSH bastion 'bastion.lab:ssh'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: Bundle file
+			configFile := filepath.Join(t.TempDir(), "bundle.yaml")
+			require.NoError(t, os.WriteFile(configFile, []byte(tt.configData), 0644))
+
+			// When: Load it
+			bundle, err := LoadMultipleConfigs(configFile)
+
+			// Then: Defaults are merged or the bundle is rejected
+			if tt.expectError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectError)
+				return
+			}
+			require.NoError(t, err)
+			tt.validateBundle(t, bundle)
+		})
+	}
+}
+
+// TestConfigBundle_GetDefaults tests the fallback for bundles built without the loader
+// WHY: Callers always need an interface and debug image, even for hand-built bundles
+func TestConfigBundle_GetDefaults(t *testing.T) {
+	bundle := NewEmptyBundle()
+
+	defaults := bundle.GetDefaults()
+
+	assert.Equal(t, DefaultInterface, defaults.Spec.Interface)
+	assert.Equal(t, DefaultDebugImage, defaults.Spec.DebugImage)
+	assert.True(t, defaults.Spec.Tools.Nvlan.ValidateConnectivity)
+}
+
+// TestMergeMaps tests nested overlaying of raw settings
+// WHY: A document setting one tool option must not drop the other defaults of that tool
+func TestMergeMaps(t *testing.T) {
+	base := map[string]interface{}{
+		"nvlan": map[string]interface{}{"validateConnectivity": true, "logLevel": "info"},
+		"ntest": map[string]interface{}{"retries": 3},
+	}
+	override := map[string]interface{}{
+		"nvlan": map[string]interface{}{"validateConnectivity": false},
+	}
+
+	merged := mergeMaps(base, override)
+
+	assert.Equal(t, map[string]interface{}{
+		"nvlan": map[string]interface{}{"validateConnectivity": false, "logLevel": "info"},
+		"ntest": map[string]interface{}{"retries": 3},
+	}, merged)
+	assert.Equal(t, true, base["nvlan"].(map[string]interface{})["validateConnectivity"], "base must not be modified")
+}
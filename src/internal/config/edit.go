@@ -0,0 +1,153 @@
+// Package config provides in-place editing helpers for configuration files
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ReplaceNodeName rewrites a node name where the config uses it as a node: entries of nodes lists
+// and keys of nodes and nodeMapping maps. Label values, VLAN and role names that happen to equal
+// the node name are left alone. Only the matched scalars are rewritten in the raw text, which keeps
+// comments and formatting intact; returns the number of replacements
+func ReplaceNodeName(configPath, oldName, newName string) (int, error) {
+	if oldName == "" {
+		return 0, fmt.Errorf("node name to replace must not be empty")
+	}
+
+	info, err := os.Stat(configPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to stat config file %s: %w", configPath, err)
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read config file %s: %w", configPath, err)
+	}
+
+	offsets, err := nodeNameOffsets(data, oldName)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse config file %s: %w", configPath, err)
+	}
+	if len(offsets) == 0 {
+		return 0, nil
+	}
+
+	var out bytes.Buffer
+	last := 0
+	for _, offset := range offsets {
+		out.Write(data[last:offset])
+		out.WriteString(newName)
+		last = offset + len(oldName)
+	}
+	out.Write(data[last:])
+
+	if err := os.WriteFile(configPath, out.Bytes(), info.Mode().Perm()); err != nil {
+		return 0, fmt.Errorf("failed to write config file %s: %w", configPath, err)
+	}
+
+	return len(offsets), nil
+}
+
+// nodeNameOffsets returns the sorted byte offsets of the node name scalars equal to name
+func nodeNameOffsets(data []byte, name string) ([]int, error) {
+	var scalars []*yaml.Node
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var document yaml.Node
+		if err := decoder.Decode(&document); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, err
+		}
+		scalars = append(scalars, nodeNameScalars(&document, name)...)
+	}
+
+	lineStarts := []int{0}
+	for i, c := range data {
+		if c == '\n' {
+			lineStarts = append(lineStarts, i+1)
+		}
+	}
+
+	var offsets []int
+	for _, scalar := range scalars {
+		if scalar.Line < 1 || scalar.Line > len(lineStarts) {
+			continue
+		}
+		// Columns count characters, not bytes
+		start, end := lineStarts[scalar.Line-1], len(data)
+		if scalar.Line < len(lineStarts) {
+			end = lineStarts[scalar.Line]
+		}
+		line := []rune(string(data[start:end]))
+		if scalar.Column < 1 || scalar.Column > len(line) {
+			continue
+		}
+		offset := start + len(string(line[:scalar.Column-1]))
+		if scalar.Style&(yaml.DoubleQuotedStyle|yaml.SingleQuotedStyle) != 0 {
+			offset++
+		}
+		// Escaped or folded scalars do not hold the name verbatim and are left alone
+		if bytes.HasPrefix(data[offset:], []byte(name)) {
+			offsets = append(offsets, offset)
+		}
+	}
+
+	sort.Ints(offsets)
+	return offsets, nil
+}
+
+// nodeNameScalars walks a document for node name scalars equal to name
+func nodeNameScalars(node *yaml.Node, name string) []*yaml.Node {
+	var found []*yaml.Node
+	switch node.Kind {
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for _, child := range node.Content {
+			found = append(found, nodeNameScalars(child, name)...)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if isNodeNameField(key.Value) {
+				found = append(found, matchingNodeNames(value, name)...)
+				continue
+			}
+			found = append(found, nodeNameScalars(value, name)...)
+		}
+	}
+	return found
+}
+
+// isNodeNameField reports whether a field lists node names, as entries or as map keys
+func isNodeNameField(field string) bool {
+	return field == "nodes" || field == "nodeMapping"
+}
+
+// matchingNodeNames returns the list entries or map keys of a node name field equal to name
+func matchingNodeNames(value *yaml.Node, name string) []*yaml.Node {
+	var found []*yaml.Node
+	switch value.Kind {
+	case yaml.SequenceNode:
+		for _, entry := range value.Content {
+			if entry.Kind == yaml.ScalarNode && entry.Value == name {
+				found = append(found, entry)
+			}
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(value.Content); i += 2 {
+			key := value.Content[i]
+			if key.Kind == yaml.ScalarNode && key.Value == name {
+				found = append(found, key)
+			}
+		}
+	}
+	return found
+}
@@ -0,0 +1,105 @@
+// Package config provides unit tests for configuration file editing
+// WHY: --fix-typos rewrites user files in place and must never corrupt unrelated content
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestReplaceNodeName tests node name replacement in node lists and node maps
+// WHY: Replacing a longer node name, a label value or a VLAN name would silently break the config
+func TestReplaceNodeName(t *testing.T) {
+	original := `# rbs2 is our first control node
+spec:
+  nodeRoles:
+    rbs2:
+      labels:
+        openstack-role: rbs2
+      nodes: ["rbs2", "rbs20", "xrbs2"]
+    compute:
+      nodes:
+        - rbs2
+        - 'rbs3'
+---
+spec:
+  vlans:
+    rbs2:
+      nodeMapping:
+        rbs2: "10.0.0.2/24"
+        rbs20: "10.0.0.20/24"
+`
+	expected := `# rbs2 is our first control node
+spec:
+  nodeRoles:
+    rbs2:
+      labels:
+        openstack-role: rbs2
+      nodes: ["rsb2", "rbs20", "xrbs2"]
+    compute:
+      nodes:
+        - rsb2
+        - 'rbs3'
+---
+spec:
+  vlans:
+    rbs2:
+      nodeMapping:
+        rsb2: "10.0.0.2/24"
+        rbs20: "10.0.0.20/24"
+`
+
+	// Given: Config file with a misspelled node that is also a role, label value and VLAN name
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte(original), 0600))
+
+	// When: Replace the node name
+	count, err := ReplaceNodeName(configPath, "rbs2", "rsb2")
+
+	// Then: Only node list entries and nodeMapping keys change, and permissions are kept
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+
+	data, err := os.ReadFile(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, expected, string(data))
+
+	info, err := os.Stat(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+// TestReplaceNodeName_Unchanged tests files without the node name in node positions
+// WHY: A file must not be rewritten when nothing refers to the node
+func TestReplaceNodeName_Unchanged(t *testing.T) {
+	original := "spec:\n  nodeRoles:\n    control:\n      labels:\n        owner: rbs2\n      nodes: [rsb3]\n"
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte(original), 0600))
+
+	count, err := ReplaceNodeName(configPath, "rbs2", "rsb2")
+
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+	data, err := os.ReadFile(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, original, string(data))
+}
+
+// TestConfigBundle_GetAllNodeNames tests node name collection across CRDs
+// WHY: Typo checks and filters need one de-duplicated node list per bundle
+func TestConfigBundle_GetAllNodeNames(t *testing.T) {
+	bundle := &ConfigBundle{
+		NodeLabels: &NodeLabelConf{Spec: NodeLabelSpec{NodeRoles: map[string]NodeRole{
+			"control": {Nodes: []string{"rsb3", "rsb2"}},
+		}}},
+		VLANs: &NodeVLANConf{Spec: NodeVLANSpec{VLANs: map[string]VLANConfig{
+			"management": {NodeMapping: map[string]string{"rsb2": "10.0.0.2/24", "rsb5": "10.0.0.5/24"}},
+		}}},
+	}
+
+	assert.Equal(t, []string{"rsb2", "rsb3", "rsb5"}, bundle.GetAllNodeNames())
+}
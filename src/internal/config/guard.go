@@ -0,0 +1,27 @@
+package config
+
+import "fmt"
+
+// ReachabilityGuard keeps kictl from cutting itself off from a node whose network it changes
+// A change to the interface carrying the node's InternalIP, or a VLAN on top of it, needs another way in first.
+type ReachabilityGuard struct {
+	ManagementVLAN string `json:"managementVLAN,omitempty" yaml:"managementVLAN,omitempty"` // VLAN of the config whose address on the node is another way in
+	Command        string `json:"command,omitempty" yaml:"command,omitempty"`               // Local command that succeeds when another way in works, e.g. a BMC check; {node} and {ip} are replaced
+}
+
+// validateReachabilityGuard checks that a guard names another way in, and that its management VLAN exists
+func validateReachabilityGuard(toolName string, tool ToolConfig, vlans map[string]VLANConfig) error {
+	guard := tool.ReachabilityGuard
+	if guard == nil {
+		return nil
+	}
+	if guard.ManagementVLAN == "" && guard.Command == "" {
+		return fmt.Errorf("tools.%s.reachabilityGuard needs a managementVLAN or a command", toolName)
+	}
+	if guard.ManagementVLAN != "" {
+		if _, ok := vlans[guard.ManagementVLAN]; !ok {
+			return fmt.Errorf("tools.%s.reachabilityGuard.managementVLAN '%s' is not a VLAN of this config", toolName, guard.ManagementVLAN)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+// Package config provides unit tests for the reachability guard option
+// WHY: A guard that names no way in, or a VLAN that does not exist, would refuse every change without saying why
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestValidateReachabilityGuard tests the accepted reachability guards
+// WHY: A guard must name at least one way in that kictl can check
+func TestValidateReachabilityGuard(t *testing.T) {
+	vlans := map[string]VLANConfig{"management": {ID: 100}, "storage": {ID: 200}}
+
+	tests := []struct {
+		name        string
+		guard       *ReachabilityGuard
+		expectError string
+	}{
+		{"unset", nil, ""},
+		{"management_vlan", &ReachabilityGuard{ManagementVLAN: "management"}, ""},
+		{"command", &ReachabilityGuard{Command: "ipmitool -H {node}-bmc chassis power status"}, ""},
+		{"empty", &ReachabilityGuard{}, "tools.nvlan.reachabilityGuard needs a managementVLAN or a command"},
+		{"unknown_vlan", &ReachabilityGuard{ManagementVLAN: "mgmt"}, "tools.nvlan.reachabilityGuard.managementVLAN 'mgmt' is not a VLAN of this config"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A tool configuration with a reachability guard
+			tool := ToolConfig{ReachabilityGuard: tt.guard}
+
+			// When: Validate it against the config's VLANs
+			err := validateReachabilityGuard("nvlan", tool, vlans)
+
+			// Then: Only guards naming a usable way in are accepted
+			if tt.expectError != "" {
+				assert.EqualError(t, err, tt.expectError)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
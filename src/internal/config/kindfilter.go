@@ -0,0 +1,46 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SelectKinds returns a copy of the bundle holding only the configurations of the given kinds, e.g. NodeVLANConf
+// With skip, the configurations of the given kinds are left out instead. A bundle left with nothing to do is an error.
+func (b *ConfigBundle) SelectKinds(kinds []string, skip bool) (*ConfigBundle, error) {
+	listed := make(map[string]bool, len(kinds))
+	for _, kind := range kinds {
+		listed[kind] = true
+	}
+	keep := func(kind string) bool {
+		return listed[kind] != skip
+	}
+
+	selected := &ConfigBundle{Defaults: b.Defaults, Source: b.Source, Digest: b.Digest}
+	if b.NodeLabels != nil && keep("NodeLabelConf") {
+		selected.NodeLabels = b.NodeLabels
+	}
+	if b.VLANs != nil && keep("NodeVLANConf") {
+		selected.VLANs = b.VLANs
+	}
+	if b.Tests != nil && keep("NodeTestConf") {
+		selected.Tests = b.Tests
+	}
+	if b.Cleanup != nil && keep("CleanupConf") {
+		selected.Cleanup = b.Cleanup
+	}
+	if b.Sysctls != nil && keep("NodeSysctlConf") {
+		selected.Sysctls = b.Sysctls
+	}
+	if b.Storage != nil && keep("NodeStorageConf") {
+		selected.Storage = b.Storage
+	}
+
+	if selected.GetConfigCount() == 0 {
+		if skip {
+			return nil, fmt.Errorf("no configuration of the bundle is left once %s are skipped", strings.Join(kinds, ", "))
+		}
+		return nil, fmt.Errorf("the bundle holds no configuration of kinds %s", strings.Join(kinds, ", "))
+	}
+	return selected, nil
+}
@@ -0,0 +1,78 @@
+// Package config provides unit tests for selecting the kinds of a bundle
+// WHY: --only and --skip must drop whole configurations without touching the others
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestConfigBundle_SelectKinds tests keeping or leaving out configuration kinds
+// WHY: Applying just the VLANs of a multi-kind bundle must not require editing the YAML
+func TestConfigBundle_SelectKinds(t *testing.T) {
+	tests := []struct {
+		name        string
+		kinds       []string
+		skip        bool
+		expected    []string
+		expectError string
+	}{
+		{name: "only_vlans", kinds: []string{"NodeVLANConf"}, expected: []string{"NodeVLANConf"}},
+		{name: "only_labels_and_tests", kinds: []string{"NodeLabelConf", "NodeTestConf"}, expected: []string{"NodeLabelConf", "NodeTestConf"}},
+		{name: "skip_cleanup", kinds: []string{"CleanupConf"}, skip: true, expected: []string{"NodeLabelConf", "NodeVLANConf", "NodeTestConf"}},
+		{
+			name:        "skip_everything",
+			kinds:       []string{"CleanupConf", "NodeLabelConf", "NodeVLANConf", "NodeTestConf"},
+			skip:        true,
+			expectError: "no configuration of the bundle is left once CleanupConf, NodeLabelConf, NodeVLANConf, NodeTestConf are skipped",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A bundle of every kind
+			bundle := newTargetTestBundle()
+			addresses := len(bundle.ResourceAddresses())
+
+			// When: Select the kinds
+			selected, err := bundle.SelectKinds(tt.kinds, tt.skip)
+
+			// Then: Only the selected configurations are left, and the bundle itself is unchanged
+			if tt.expectError != "" {
+				assert.ErrorContains(t, err, tt.expectError)
+				return
+			}
+			require.NoError(t, err)
+			var kinds []string
+			if selected.NodeLabels != nil {
+				kinds = append(kinds, "NodeLabelConf")
+			}
+			if selected.VLANs != nil {
+				kinds = append(kinds, "NodeVLANConf")
+			}
+			if selected.Tests != nil {
+				kinds = append(kinds, "NodeTestConf")
+			}
+			if selected.Cleanup != nil {
+				kinds = append(kinds, "CleanupConf")
+			}
+			assert.Equal(t, tt.expected, kinds)
+			assert.Len(t, bundle.ResourceAddresses(), addresses)
+		})
+	}
+}
+
+// TestConfigBundle_SelectKindsNotInBundle tests selecting a kind the bundle does not hold
+// WHY: --only on a kind missing from the file must fail instead of running nothing successfully
+func TestConfigBundle_SelectKindsNotInBundle(t *testing.T) {
+	// Given: A bundle holding VLANs only
+	bundle := &ConfigBundle{VLANs: &NodeVLANConf{Kind: "NodeVLANConf"}}
+
+	// When: Select its tests
+	_, err := bundle.SelectKinds([]string{"NodeTestConf"}, false)
+
+	// Then: The run is refused
+	assert.ErrorContains(t, err, "the bundle holds no configuration of kinds NodeTestConf")
+}
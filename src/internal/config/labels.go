@@ -0,0 +1,121 @@
+// Package config provides label key handling for NodeLabelConf
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Kubernetes label syntax limits
+const (
+	maxLabelNameLength   = 63
+	maxLabelPrefixLength = 253
+)
+
+var (
+	// labelNameRegex matches the name segment of a label key and label values
+	labelNameRegex = regexp.MustCompile(`^[A-Za-z0-9]([-A-Za-z0-9_.]*[A-Za-z0-9])?$`)
+
+	// labelPrefixRegex matches a DNS subdomain used as a label key prefix
+	labelPrefixRegex = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`)
+)
+
+// ValidateLabelKey checks that a label key is a well-formed Kubernetes label key
+func ValidateLabelKey(key string) error {
+	prefix, name := "", key
+	if idx := strings.LastIndex(key, "/"); idx >= 0 {
+		prefix, name = key[:idx], key[idx+1:]
+		if prefix == "" {
+			return fmt.Errorf("label key '%s' has an empty prefix", key)
+		}
+		if len(prefix) > maxLabelPrefixLength {
+			return fmt.Errorf("label key '%s' prefix must be at most %d characters", key, maxLabelPrefixLength)
+		}
+		if !labelPrefixRegex.MatchString(prefix) {
+			return fmt.Errorf("label key '%s' prefix must be a lowercase DNS subdomain", key)
+		}
+	}
+
+	if name == "" {
+		return fmt.Errorf("label key '%s' has an empty name", key)
+	}
+	if len(name) > maxLabelNameLength {
+		return fmt.Errorf("label key '%s' name must be at most %d characters", key, maxLabelNameLength)
+	}
+	if !labelNameRegex.MatchString(name) {
+		return fmt.Errorf("label key '%s' name must consist of alphanumerics, '-', '_' or '.', and start and end with an alphanumeric", key)
+	}
+
+	return nil
+}
+
+// ValidateLabelValue checks that a label value is a well-formed Kubernetes label value
+func ValidateLabelValue(value string) error {
+	if value == "" {
+		return nil
+	}
+	if len(value) > maxLabelNameLength {
+		return fmt.Errorf("label value '%s' must be at most %d characters", value, maxLabelNameLength)
+	}
+	if !labelNameRegex.MatchString(value) {
+		return fmt.Errorf("label value '%s' must consist of alphanumerics, '-', '_' or '.', and start and end with an alphanumeric", value)
+	}
+	return nil
+}
+
+// normalizeLabelPrefix ensures a configured prefix ends with exactly one '/'
+func normalizeLabelPrefix(prefix string) string {
+	prefix = strings.TrimSpace(prefix)
+	if prefix == "" {
+		return ""
+	}
+	return strings.TrimRight(prefix, "/") + "/"
+}
+
+// applyLabelPrefix prefixes bare label keys (keys without '/') with the spec label prefix
+func applyLabelPrefix(labels map[string]string, prefix string) map[string]string {
+	if prefix == "" || len(labels) == 0 {
+		return labels
+	}
+
+	prefixed := make(map[string]string, len(labels))
+	for key, value := range labels {
+		if !strings.Contains(key, "/") {
+			key = prefix + key
+		}
+		prefixed[key] = value
+	}
+	return prefixed
+}
+
+// validateNodeLabelKeys checks the final (prefixed) label keys and values of every role
+func validateNodeLabelKeys(config NodeLabelConf) error {
+	roleNames := make([]string, 0, len(config.Spec.NodeRoles))
+	for roleName := range config.Spec.NodeRoles {
+		roleNames = append(roleNames, roleName)
+	}
+	sort.Strings(roleNames)
+
+	for _, roleName := range roleNames {
+		role := config.Spec.NodeRoles[roleName]
+
+		keys := make([]string, 0, len(role.Labels))
+		for key := range role.Labels {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			if err := ValidateLabelKey(key); err != nil {
+				return fmt.Errorf("role '%s': %w", roleName, err)
+			}
+			if err := ValidateLabelValue(role.Labels[key]); err != nil {
+				return fmt.Errorf("role '%s': %w", roleName, err)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,143 @@
+// Package config provides unit tests for label key handling
+// WHY: Malformed label keys are rejected by the API server only after part of a rollout has run
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestValidateLabelKey tests Kubernetes label key syntax rules
+// WHY: Validation must mirror the API server so errors surface at load time
+func TestValidateLabelKey(t *testing.T) {
+	tests := []struct {
+		name        string
+		key         string
+		shouldError bool
+	}{
+		{"bare_key", "openstack-role", false},
+		{"prefixed_key", "openstack.icycloud.io/role", false},
+		{"dotted_name", "node.openstack.io/control-plane", false},
+		{"empty_key", "", true},
+		{"empty_prefix", "/role", true},
+		{"empty_name", "openstack.icycloud.io/", true},
+		{"uppercase_prefix", "OpenStack.io/role", true},
+		{"multiple_slashes", "key/with/slashes", true},
+		{"name_starts_with_dash", "-role", true},
+		{"name_too_long", strings.Repeat("a", 64), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateLabelKey(tt.key)
+			if tt.shouldError {
+				assert.Error(t, err, "key %q should be rejected", tt.key)
+			} else {
+				assert.NoError(t, err, "key %q should be accepted", tt.key)
+			}
+		})
+	}
+}
+
+// TestValidateLabelValue tests Kubernetes label value syntax rules
+// WHY: Values share the name segment rules and are equally rejected by the API server
+func TestValidateLabelValue(t *testing.T) {
+	assert.NoError(t, ValidateLabelValue(""), "empty value is allowed")
+	assert.NoError(t, ValidateLabelValue("control-plane"))
+	assert.Error(t, ValidateLabelValue("value:with:colons"))
+	assert.Error(t, ValidateLabelValue("value with spaces"))
+}
+
+// TestLoadConfig_LabelPrefix tests prefix application during loading
+// WHY: Bare keys must be namespaced consistently while explicit keys stay untouched
+func TestLoadConfig_LabelPrefix(t *testing.T) {
+	tests := []struct {
+		name           string
+		description    string
+		prefix         string
+		expectedLabels map[string]string
+	}{
+		{
+			name:        "prefix_with_trailing_slash",
+			description: "Bare keys get the prefix, prefixed keys are kept",
+			prefix:      "openstack.icycloud.io/",
+			expectedLabels: map[string]string{
+				"openstack.icycloud.io/role":  "control-plane",
+				"topology.kubernetes.io/zone": "zone-a",
+			},
+		},
+		{
+			name:        "prefix_without_trailing_slash",
+			description: "Missing trailing slash should be normalized",
+			prefix:      "openstack.icycloud.io",
+			expectedLabels: map[string]string{
+				"openstack.icycloud.io/role":  "control-plane",
+				"topology.kubernetes.io/zone": "zone-a",
+			},
+		},
+		{
+			name:        "no_prefix",
+			description: "Without a prefix keys are used verbatim",
+			prefix:      "",
+			expectedLabels: map[string]string{
+				"role":                        "control-plane",
+				"topology.kubernetes.io/zone": "zone-a",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: Config with mixed bare and prefixed keys
+			configData := `apiVersion: openstack.kictl.icycloud.io/v1
+kind: NodeLabelConf
+metadata:
+  name: prefix-test
+spec:
+  labelPrefix: "` + tt.prefix + `"
+  nodeRoles:
+    control:
+      nodes: ["rsb2"]
+      labels:
+        role: control-plane
+        topology.kubernetes.io/zone: zone-a`
+			configPath := filepath.Join(t.TempDir(), "config.yaml")
+			require.NoError(t, os.WriteFile(configPath, []byte(configData), 0644))
+
+			// When: Load configuration
+			cfg, err := LoadConfig(configPath)
+
+			// Then: Final keys should match expectation
+			require.NoError(t, err, tt.description)
+			assert.Equal(t, tt.expectedLabels, cfg.GetNodeRoles()["control"].Labels, tt.description)
+		})
+	}
+}
+
+// TestLoadConfig_InvalidLabelPrefix tests validation of the final prefixed keys
+// WHY: A bad prefix would otherwise break every label in the config
+func TestLoadConfig_InvalidLabelPrefix(t *testing.T) {
+	configData := `apiVersion: openstack.kictl.icycloud.io/v1
+kind: NodeLabelConf
+metadata:
+  name: bad-prefix
+spec:
+  labelPrefix: "OpenStack_IO/"
+  nodeRoles:
+    control:
+      nodes: ["rsb2"]
+      labels:
+        role: control-plane`
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte(configData), 0644))
+
+	_, err := LoadConfig(configPath)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "role 'control': label key 'OpenStack_IO/role' prefix must be a lowercase DNS subdomain")
+}
@@ -1,7 +1,9 @@
 package config
 
 import (
+	"crypto/sha256"
 	"fmt"
+	"net/url"
 	"os"
 	"strings"
 
@@ -15,9 +17,18 @@ func LoadConfig(configPath string) (Config, error) {
 		return nil, fmt.Errorf("configuration file is required")
 	}
 
-	data, err := os.ReadFile(configPath)
+	data, err := readConfigSource(configPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read config file %s: %w", configPath, err)
+		return nil, err
+	}
+	return loadConfigDocument(data)
+}
+
+// loadConfigDocument loads a single configuration document, detecting its kind
+func loadConfigDocument(data []byte) (Config, error) {
+	data, err := internalDocument(data)
+	if err != nil {
+		return nil, err
 	}
 
 	// Try to determine format by kind
@@ -28,40 +39,86 @@ func LoadConfig(configPath string) (Config, error) {
 		return nil, fmt.Errorf("failed to parse config: invalid YAML: %w", err)
 	}
 
+	// Without a Defaults document no set is named, so operations can only combine node lists
+	defaults := BuiltinDefaults()
+	data, err = resolveNodeSetOperations(data, defaults.Spec.NodeSets)
+	if err != nil {
+		return nil, err
+	}
 	switch kindDetector.Kind {
 	case "NodeLabelConf":
-		return loadNodeLabelConf(data)
+		return loadNodeLabelConf(data, defaults)
 	case "NodeVLANConf":
-		cfg, err := loadNodeVLANConf(data)
+		cfg, err := loadNodeVLANConf(data, defaults)
 		if err != nil {
 			return nil, err
 		}
 		return cfg, nil
 	case "NodeTestConf":
-		cfg, err := loadNodeTestConf(data)
+		cfg, err := loadNodeTestConf(data, defaults)
+		if err != nil {
+			return nil, err
+		}
+		return cfg, nil
+	case "CleanupConf":
+		cfg, err := loadCleanupConf(data, defaults)
+		if err != nil {
+			return nil, err
+		}
+		return cfg, nil
+	case "NodeSysctlConf":
+		cfg, err := loadNodeSysctlConf(data, defaults)
+		if err != nil {
+			return nil, err
+		}
+		return cfg, nil
+	case "NodeStorageConf":
+		cfg, err := loadNodeStorageConf(data, defaults)
 		if err != nil {
 			return nil, err
 		}
 		return cfg, nil
 	default:
-		return nil, fmt.Errorf("unsupported config kind '%s'. Expected: NodeLabelConf, NodeVLANConf, or NodeTestConf", kindDetector.Kind)
+		return nil, fmt.Errorf("unsupported config kind '%s'. Expected: NodeLabelConf, NodeVLANConf, NodeTestConf, CleanupConf, NodeSysctlConf, or NodeStorageConf", kindDetector.Kind)
 	}
 }
 
-// LoadMultipleConfigs loads configuration from file supporting both single and multi-document YAML
+// LoadOptions tunes how a configuration bundle is loaded
+type LoadOptions struct {
+	StrictSchema bool     // Validate every document against the JSON Schema of its kind first, rejecting unknown fields
+	ValuesFiles  []string // Render the configuration as a Go template with these values first, later files winning
+}
+
+// LoadMultipleConfigs loads configuration from a file or remote source supporting both single and multi-document YAML
 // This is the primary entry point for our unified architecture
 func LoadMultipleConfigs(configPath string) (*ConfigBundle, error) {
+	return LoadMultipleConfigsWithOptions(configPath, LoadOptions{})
+}
+
+// LoadMultipleConfigsWithOptions is LoadMultipleConfigs with load options
+func LoadMultipleConfigsWithOptions(configPath string, opts LoadOptions) (*ConfigBundle, error) {
 	if configPath == "" {
 		return nil, fmt.Errorf("configuration file is required")
 	}
 
-	data, err := os.ReadFile(configPath)
+	data, err := RenderConfig(configPath, opts.ValuesFiles)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read config file %s: %w", configPath, err)
+		return nil, err
+	}
+
+	if opts.StrictSchema {
+		if schemaErrs := ValidateSchema(data); len(schemaErrs) > 0 {
+			messages := make([]string, len(schemaErrs))
+			for i, schemaErr := range schemaErrs {
+				messages[i] = schemaErr.Error()
+			}
+			return nil, fmt.Errorf("schema validation failed:\n  %s", strings.Join(messages, "\n  "))
+		}
 	}
 
 	bundle := NewEmptyBundle()
 	bundle.Source = configPath
+	bundle.Digest = fmt.Sprintf("sha256:%x", sha256.Sum256(data))
 
 	// Check if this is a multi-document YAML
 	if isMultiDocumentYAML(data) {
@@ -69,12 +126,21 @@ func LoadMultipleConfigs(configPath string) (*ConfigBundle, error) {
 	}
 
 	// Single document - use existing logic but wrap in bundle
-	cfg, err := LoadConfig(configPath)
+	cfg, err := loadConfigDocument(data)
 	if err != nil {
 		return nil, err
 	}
 
-	return NewSingleConfigBundle(cfg), nil
+	single := NewSingleConfigBundle(cfg)
+	single.Digest = bundle.Digest
+	return single, nil
+}
+
+// LoadConfigData loads a configuration bundle from in-memory YAML or JSON, e.g. a custom resource read from the cluster
+func LoadConfigData(data []byte, source string) (*ConfigBundle, error) {
+	bundle := NewEmptyBundle()
+	bundle.Source = source
+	return loadMultiDocumentBundle(data, bundle)
 }
 
 // loadMultiDocumentBundle processes multiple YAML documents into a ConfigBundle
@@ -84,10 +150,15 @@ func loadMultiDocumentBundle(data []byte, bundle *ConfigBundle) (*ConfigBundle,
 		return nil, fmt.Errorf("failed to split YAML documents: %w", err)
 	}
 
+	kinds := make([]string, len(documents))
 	for i, doc := range documents {
 		if err := validateYAMLDocument(doc); err != nil {
 			return nil, fmt.Errorf("invalid YAML document %d: %w", i+1, err)
 		}
+		if doc, err = internalDocument(doc); err != nil {
+			return nil, fmt.Errorf("failed to convert document %d: %w", i+1, err)
+		}
+		documents[i] = doc
 
 		var kindDetector struct {
 			Kind string `yaml:"kind"`
@@ -96,10 +167,40 @@ func loadMultiDocumentBundle(data []byte, bundle *ConfigBundle) (*ConfigBundle,
 		if err := yaml.Unmarshal(doc, &kindDetector); err != nil {
 			return nil, fmt.Errorf("failed to detect kind in document %d: %w", i+1, err)
 		}
+		kinds[i] = kindDetector.Kind
+	}
+
+	// Defaults apply to every document, so load them first wherever they appear
+	bundle.Defaults = BuiltinDefaults()
+	foundDefaults := false
+	for i, doc := range documents {
+		if kinds[i] != DefaultsKind {
+			continue
+		}
+		if foundDefaults {
+			return nil, fmt.Errorf("duplicate Defaults in document %d: a bundle may contain only one", i+1)
+		}
+		defaults, err := loadDefaults(doc)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load Defaults in document %d: %w", i+1, err)
+		}
+		bundle.Defaults = defaults
+		foundDefaults = true
+	}
+
+	for i, doc := range documents {
+		if kinds[i] == DefaultsKind {
+			continue
+		}
+		doc, err := resolveNodeSetOperations(doc, bundle.Defaults.Spec.NodeSets)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve node sets in document %d: %w", i+1, err)
+		}
+
+		switch kinds[i] {
 
-		switch kindDetector.Kind {
 		case "NodeLabelConf":
-			cfg, err := loadNodeLabelConf(doc)
+			cfg, err := loadNodeLabelConf(doc, bundle.Defaults)
 			if err != nil {
 				return nil, fmt.Errorf("failed to load NodeLabelConf in document %d: %w", i+1, err)
 			}
@@ -108,21 +209,42 @@ func loadMultiDocumentBundle(data []byte, bundle *ConfigBundle) (*ConfigBundle,
 			}
 
 		case "NodeVLANConf":
-			cfg, err := loadNodeVLANConf(doc)
+			cfg, err := loadNodeVLANConf(doc, bundle.Defaults)
 			if err != nil {
 				return nil, fmt.Errorf("failed to load NodeVLANConf in document %d: %w", i+1, err)
 			}
 			bundle.VLANs = cfg
 
 		case "NodeTestConf":
-			cfg, err := loadNodeTestConf(doc)
+			cfg, err := loadNodeTestConf(doc, bundle.Defaults)
 			if err != nil {
 				return nil, fmt.Errorf("failed to load NodeTestConf in document %d: %w", i+1, err)
 			}
 			bundle.Tests = cfg
 
+		case "CleanupConf":
+			cfg, err := loadCleanupConf(doc, bundle.Defaults)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load CleanupConf in document %d: %w", i+1, err)
+			}
+			bundle.Cleanup = cfg
+
+		case "NodeSysctlConf":
+			cfg, err := loadNodeSysctlConf(doc, bundle.Defaults)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load NodeSysctlConf in document %d: %w", i+1, err)
+			}
+			bundle.Sysctls = cfg
+
+		case "NodeStorageConf":
+			cfg, err := loadNodeStorageConf(doc, bundle.Defaults)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load NodeStorageConf in document %d: %w", i+1, err)
+			}
+			bundle.Storage = cfg
+
 		default:
-			return nil, fmt.Errorf("unsupported config kind '%s' in document %d. Expected: NodeLabelConf, NodeVLANConf, NodeTestConf", kindDetector.Kind, i+1)
+			return nil, fmt.Errorf("unsupported config kind '%s' in document %d. Expected: NodeLabelConf, NodeVLANConf, NodeTestConf, CleanupConf, NodeSysctlConf, NodeStorageConf, Defaults", kinds[i], i+1)
 		}
 	}
 
@@ -134,26 +256,32 @@ func loadMultiDocumentBundle(data []byte, bundle *ConfigBundle) (*ConfigBundle,
 }
 
 // loadNodeVLANConf loads VLAN configuration
-func loadNodeVLANConf(data []byte) (*NodeVLANConf, error) {
+func loadNodeVLANConf(data []byte, defaults *Defaults) (*NodeVLANConf, error) {
 	var config NodeVLANConf
 	if err := yaml.Unmarshal(data, &config); err != nil {
 		return nil, fmt.Errorf("failed to parse NodeVLANConf: %w", err)
 	}
+	if err := defaults.applyTools(data, &config.Tools); err != nil {
+		return nil, fmt.Errorf("failed to apply defaults to NodeVLANConf: %w", err)
+	}
 
 	if err := validateNodeVLANConf(config); err != nil {
 		return nil, err
 	}
 
-	config = applyNodeVLANDefaults(config)
+	config = applyNodeVLANDefaults(config, defaults.Spec.Interface)
 	return &config, nil
 }
 
 // loadNodeTestConf loads test configuration
-func loadNodeTestConf(data []byte) (*NodeTestConf, error) {
+func loadNodeTestConf(data []byte, defaults *Defaults) (*NodeTestConf, error) {
 	var config NodeTestConf
 	if err := yaml.Unmarshal(data, &config); err != nil {
 		return nil, fmt.Errorf("failed to parse NodeTestConf: %w", err)
 	}
+	if err := defaults.applyTools(data, &config.Tools); err != nil {
+		return nil, fmt.Errorf("failed to apply defaults to NodeTestConf: %w", err)
+	}
 
 	if err := validateNodeTestConf(config); err != nil {
 		return nil, err
@@ -163,14 +291,68 @@ func loadNodeTestConf(data []byte) (*NodeTestConf, error) {
 	return &config, nil
 }
 
+// loadCleanupConf loads label cleanup configuration
+func loadCleanupConf(data []byte, defaults *Defaults) (*CleanupConf, error) {
+	var config CleanupConf
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse CleanupConf: %w", err)
+	}
+	if err := defaults.applyTools(data, &config.Tools); err != nil {
+		return nil, fmt.Errorf("failed to apply defaults to CleanupConf: %w", err)
+	}
+
+	if err := validateCleanupConf(config); err != nil {
+		return nil, err
+	}
+
+	config = applyCleanupDefaults(config)
+	return &config, nil
+}
+
+// loadNodeSysctlConf loads kernel tuning configuration
+func loadNodeSysctlConf(data []byte, defaults *Defaults) (*NodeSysctlConf, error) {
+	var config NodeSysctlConf
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse NodeSysctlConf: %w", err)
+	}
+	if err := defaults.applyTools(data, &config.Tools); err != nil {
+		return nil, fmt.Errorf("failed to apply defaults to NodeSysctlConf: %w", err)
+	}
+
+	if err := validateNodeSysctlConf(config); err != nil {
+		return nil, err
+	}
+
+	config = applyNodeSysctlDefaults(config)
+	return &config, nil
+}
+
+// loadNodeStorageConf loads storage device configuration
+func loadNodeStorageConf(data []byte, defaults *Defaults) (*NodeStorageConf, error) {
+	var config NodeStorageConf
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse NodeStorageConf: %w", err)
+	}
+	if err := defaults.applyTools(data, &config.Tools); err != nil {
+		return nil, fmt.Errorf("failed to apply defaults to NodeStorageConf: %w", err)
+	}
+
+	if err := validateNodeStorageConf(config); err != nil {
+		return nil, err
+	}
+
+	config = applyNodeStorageDefaults(config)
+	return &config, nil
+}
+
 // validateNodeVLANConf validates VLAN configuration
 func validateNodeVLANConf(config NodeVLANConf) error {
 	if config.Kind != "NodeVLANConf" {
 		return fmt.Errorf("config kind must be 'NodeVLANConf', got '%s'", config.Kind)
 	}
 
-	if !strings.HasSuffix(config.APIVersion, "/v1") {
-		return fmt.Errorf("config apiVersion must end with '/v1', got '%s'", config.APIVersion)
+	if err := validateAPIVersion(config.APIVersion); err != nil {
+		return err
 	}
 
 	if config.Metadata.Name == "" {
@@ -181,6 +363,46 @@ func validateNodeVLANConf(config NodeVLANConf) error {
 		return fmt.Errorf("config must contain at least one VLAN")
 	}
 
+	for vlanName, vlanConfig := range config.Spec.VLANs {
+		for node, addresses := range vlanConfig.NodeMapping {
+			if err := validateNodeAddresses(vlanName, node, addresses); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := validateVLANLayout(config.Spec.VLANs); err != nil {
+		return err
+	}
+
+	if err := checkNodeNames(config.Tools.Nvlan.NodeNamePattern, nodeVLANConfNodes(config)); err != nil {
+		return err
+	}
+
+	if err := validateServiceOptions("nvlan", config.Tools.Nvlan); err != nil {
+		return err
+	}
+
+	if err := validatePersistenceBackend("nvlan", config.Tools.Nvlan); err != nil {
+		return err
+	}
+
+	if err := validateNetplanTry("nvlan", config.Tools.Nvlan); err != nil {
+		return err
+	}
+
+	if err := validateReachabilityGuard("nvlan", config.Tools.Nvlan, config.Spec.VLANs); err != nil {
+		return err
+	}
+
+	if err := validateBMCRecovery("nvlan", config.Tools.Nvlan); err != nil {
+		return err
+	}
+
+	if err := validateMembershipLabels("nvlan", config.Tools.Nvlan, config.Spec.VLANs); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -190,8 +412,8 @@ func validateNodeTestConf(config NodeTestConf) error {
 		return fmt.Errorf("config kind must be 'NodeTestConf', got '%s'", config.Kind)
 	}
 
-	if !strings.HasSuffix(config.APIVersion, "/v1") {
-		return fmt.Errorf("config apiVersion must end with '/v1', got '%s'", config.APIVersion)
+	if err := validateAPIVersion(config.APIVersion); err != nil {
+		return err
 	}
 
 	if config.Metadata.Name == "" {
@@ -202,22 +424,147 @@ func validateNodeTestConf(config NodeTestConf) error {
 		return fmt.Errorf("config must contain at least one test")
 	}
 
+	for _, test := range config.Spec.Tests {
+		if err := validateConnectivityTest(test); err != nil {
+			return err
+		}
+	}
+
+	if err := validateServiceOptions("ntest", config.Tools.Ntest); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// validateConnectivityTest checks the type of a test and the settings that only apply to some types
+func validateConnectivityTest(test ConnectivityTest) error {
+	if test.Timeout < 0 || test.Retries < 0 || test.RetryDelay < 0 {
+		return fmt.Errorf("test '%s': timeout, retries and retryDelay must not be negative", test.Name)
+	}
+	if test.Type != TestTypeBandwidth && (test.MinMbps != 0 || test.Duration != 0) {
+		return fmt.Errorf("test '%s': minMbps and duration only apply to bandwidth tests", test.Name)
+	}
+	if test.Type != TestTypeHTTP && (test.URL != "" || test.ExpectStatus != 0 || test.Insecure) {
+		return fmt.Errorf("test '%s': url, expectStatus and insecure only apply to http tests", test.Name)
+	}
+
+	switch test.Type {
+	case "", TestTypePing:
+		if test.Port != 0 {
+			return fmt.Errorf("test '%s': port only applies to tcp and bandwidth tests", test.Name)
+		}
+	case TestTypeTCP:
+		if test.Port < 1 || test.Port > 65535 {
+			return fmt.Errorf("test '%s': tcp tests need a port between 1 and 65535, got %d", test.Name, test.Port)
+		}
+		if len(test.Targets) == 0 {
+			return fmt.Errorf("test '%s': tcp tests need at least one target", test.Name)
+		}
+	case TestTypeHTTP:
+		endpoint, err := url.Parse(test.URL)
+		if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+			return fmt.Errorf("test '%s': http tests need an http:// or https:// url, got '%s'", test.Name, test.URL)
+		}
+		if test.ExpectStatus != 0 && (test.ExpectStatus < 100 || test.ExpectStatus > 599) {
+			return fmt.Errorf("test '%s': expectStatus must be an HTTP status code, got %d", test.Name, test.ExpectStatus)
+		}
+		if len(test.Targets) > 0 || test.Port != 0 {
+			return fmt.Errorf("test '%s': http tests request their url and take no targets or port", test.Name)
+		}
+	case TestTypeBandwidth:
+		if test.MinMbps <= 0 {
+			return fmt.Errorf("test '%s': bandwidth tests must set minMbps above 0", test.Name)
+		}
+		if test.Duration < 0 {
+			return fmt.Errorf("test '%s': duration must not be negative, got %d", test.Name, test.Duration)
+		}
+		if test.Port < 0 || test.Port > 65535 {
+			return fmt.Errorf("test '%s': port must be between 1 and 65535, got %d", test.Name, test.Port)
+		}
+		// The timeout bounds the iperf3 client, which sends for the whole duration
+		duration := test.Duration
+		if duration == 0 {
+			duration = 10
+		}
+		if test.Timeout != 0 && test.Timeout <= duration {
+			return fmt.Errorf("test '%s': timeout must exceed the bandwidth duration of %ds, got %ds", test.Name, duration, test.Timeout)
+		}
+	default:
+		return fmt.Errorf("test '%s' has unknown type '%s'. Expected: %s, %s, %s or %s",
+			test.Name, test.Type, TestTypePing, TestTypeTCP, TestTypeHTTP, TestTypeBandwidth)
+	}
+	return nil
+}
+
+// validateCleanupConf validates label cleanup configuration
+func validateCleanupConf(config CleanupConf) error {
+	if config.Kind != "CleanupConf" {
+		return fmt.Errorf("config kind must be 'CleanupConf', got '%s'", config.Kind)
+	}
+
+	if err := validateAPIVersion(config.APIVersion); err != nil {
+		return err
+	}
+
+	if config.Metadata.Name == "" {
+		return fmt.Errorf("config metadata.name is required")
+	}
+
+	if err := validateServiceOptions("nlabel", config.Tools.Nlabel); err != nil {
+		return err
+	}
+
+	return ValidateCleanupSpec(config.Spec)
+}
+
+// ValidateCleanupSpec checks that a cleanup selects at least one prefix and a set of nodes
+// Shared with the --unlabel-prefix flag path, which builds the spec from CLI flags
+func ValidateCleanupSpec(spec CleanupSpec) error {
+	if len(spec.LabelPrefixes) == 0 {
+		return fmt.Errorf("cleanup must specify at least one label prefix")
+	}
+
+	for _, prefix := range spec.LabelPrefixes {
+		if strings.TrimSpace(prefix) == "" {
+			return fmt.Errorf("cleanup label prefixes must not be empty")
+		}
+	}
+
+	// Refuse to sweep the whole cluster implicitly
+	if len(spec.Nodes) == 0 && spec.NodeSelector == "" {
+		return fmt.Errorf("cleanup must select nodes with nodes or nodeSelector")
+	}
+
 	return nil
 }
 
-// applyNodeVLANDefaults applies default values to NodeVLANConf
-func applyNodeVLANDefaults(config NodeVLANConf) NodeVLANConf {
+// applyNodeVLANDefaults applies default values to NodeVLANConf, using defaultInterface for VLANs without one or an OVS bridge
+func applyNodeVLANDefaults(config NodeVLANConf, defaultInterface string) NodeVLANConf {
 	// Set default namespace if not specified
 	if config.Metadata.Namespace == "" {
 		config.Metadata.Namespace = "default"
 	}
 
-	// Apply VLAN-specific defaults
+	// Apply VLAN-specific defaults, writing addresses the way `ip addr` prints them
 	for vlanName, vlanConfig := range config.Spec.VLANs {
-		if vlanConfig.Interface == "" {
-			vlanConfig.Interface = "eth0" // Default interface
-			config.Spec.VLANs[vlanName] = vlanConfig
+		if vlanConfig.OVS != nil {
+			vlanConfig.OVS = withOVSDefaults(vlanConfig)
+		} else if vlanConfig.Interface == "" {
+			vlanConfig.Interface = defaultInterface
 		}
+		vlanConfig.NodeMapping = canonicalNodeMapping(vlanConfig.NodeMapping)
+		config.Spec.VLANs[vlanName] = vlanConfig
+	}
+
+	return config
+}
+
+// applyCleanupDefaults applies default values to CleanupConf
+func applyCleanupDefaults(config CleanupConf) CleanupConf {
+	// Set default namespace if not specified
+	if config.Metadata.Namespace == "" {
+		config.Metadata.Namespace = "default"
 	}
 
 	return config
@@ -235,6 +582,23 @@ func applyNodeTestDefaults(config NodeTestConf) NodeTestConf {
 		if test.Timeout == 0 {
 			config.Spec.Tests[i].Timeout = 30 // Default 30 seconds
 		}
+		if test.Type == TestTypeHTTP && test.ExpectStatus == 0 {
+			config.Spec.Tests[i].ExpectStatus = 200
+		}
+		if test.Type == TestTypeBandwidth {
+			// A bandwidth test always expects traffic to flow, so it is never taken for an isolation test
+			config.Spec.Tests[i].ExpectSuccess = true
+			if test.Duration == 0 {
+				config.Spec.Tests[i].Duration = 10
+			}
+			if test.Port == 0 {
+				config.Spec.Tests[i].Port = 5201
+			}
+			// The client sends for the whole duration, so the default timeout leaves time beyond it
+			if test.Timeout == 0 {
+				config.Spec.Tests[i].Timeout = config.Spec.Tests[i].Duration + 20
+			}
+		}
 		// Note: ExpectSuccess defaults to false (Go default), no need to override
 		// The original logic was backwards and would force false values to true
 	}
@@ -243,17 +607,26 @@ func applyNodeTestDefaults(config NodeTestConf) NodeTestConf {
 }
 
 // loadNodeLabelConf loads the CRD-based node label configuration
-func loadNodeLabelConf(data []byte) (Config, error) {
+func loadNodeLabelConf(data []byte, defaults *Defaults) (Config, error) {
 	var config NodeLabelConf
 	if err := yaml.Unmarshal(data, &config); err != nil {
 		return nil, fmt.Errorf("failed to parse NodeLabelConf: %w", err)
 	}
+	if err := defaults.applyTools(data, &config.Tools); err != nil {
+		return nil, fmt.Errorf("failed to apply defaults to NodeLabelConf: %w", err)
+	}
 
 	if err := validateNodeLabelConf(config); err != nil {
 		return nil, err
 	}
 
 	config = applyNodeLabelDefaults(config)
+
+	// Keys are validated after prefixing so the final label keys are checked
+	if err := validateNodeLabelKeys(config); err != nil {
+		return nil, err
+	}
+
 	return &config, nil
 }
 
@@ -263,8 +636,8 @@ func validateNodeLabelConf(config NodeLabelConf) error {
 		return fmt.Errorf("config kind must be 'NodeLabelConf', got '%s'", config.Kind)
 	}
 
-	if !strings.HasSuffix(config.APIVersion, "/v1") {
-		return fmt.Errorf("config apiVersion must end with '/v1', got '%s'", config.APIVersion)
+	if err := validateAPIVersion(config.APIVersion); err != nil {
+		return err
 	}
 
 	if config.Metadata.Name == "" {
@@ -275,6 +648,18 @@ func validateNodeLabelConf(config NodeLabelConf) error {
 		return fmt.Errorf("config must contain at least one node role")
 	}
 
+	if err := validateNodeRoles(config.Spec.NodeRoles); err != nil {
+		return err
+	}
+
+	if err := checkNodeNames(config.Tools.Nlabel.NodeNamePattern, nodeLabelConfNodes(config)); err != nil {
+		return err
+	}
+
+	if err := validateServiceOptions("nlabel", config.Tools.Nlabel); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -282,7 +667,7 @@ func validateNodeLabelConf(config NodeLabelConf) error {
 func applyNodeLabelDefaults(config NodeLabelConf) NodeLabelConf {
 	// Apply tool defaults if not specified
 	if config.Tools.Nlabel.LogLevel == "" {
-		config.Tools.Nlabel.LogLevel = "info"
+		config.Tools.Nlabel.LogLevel = DefaultLabelLogLevel
 	}
 	if !config.Tools.Nlabel.ValidateNodes {
 		config.Tools.Nlabel.ValidateNodes = true
@@ -293,6 +678,17 @@ func applyNodeLabelDefaults(config NodeLabelConf) NodeLabelConf {
 		config.Metadata.Namespace = "default"
 	}
 
+	// Namespace bare label keys with the configured prefix
+	config.Spec.LabelPrefix = normalizeLabelPrefix(config.Spec.LabelPrefix)
+	if config.Spec.LabelPrefix != "" {
+		roles := make(map[string]NodeRole, len(config.Spec.NodeRoles))
+		for roleName, role := range config.Spec.NodeRoles {
+			role.Labels = applyLabelPrefix(role.Labels, config.Spec.LabelPrefix)
+			roles[roleName] = role
+		}
+		config.Spec.NodeRoles = roles
+	}
+
 	return config
 }
 
@@ -450,19 +846,19 @@ func GenerateMultiCRDSampleConfig(filename string) error {
 	var documents [][]byte
 
 	// Marshal each configuration
-	labelData, err := yaml.Marshal(nodeLabels)
+	labelData, err := MarshalDocument(nodeLabels)
 	if err != nil {
 		return fmt.Errorf("failed to marshal node labels config: %w", err)
 	}
 	documents = append(documents, labelData)
 
-	vlanData, err := yaml.Marshal(vlans)
+	vlanData, err := MarshalDocument(vlans)
 	if err != nil {
 		return fmt.Errorf("failed to marshal VLAN config: %w", err)
 	}
 	documents = append(documents, vlanData)
 
-	testData, err := yaml.Marshal(tests)
+	testData, err := MarshalDocument(tests)
 	if err != nil {
 		return fmt.Errorf("failed to marshal test config: %w", err)
 	}
@@ -488,7 +884,7 @@ func GenerateMultiCRDSampleConfig(filename string) error {
 func GenerateSampleConfig(filename string) error {
 	config := GetDefaultNodeLabelConf()
 
-	data, err := yaml.Marshal(config)
+	data, err := MarshalDocument(config)
 	if err != nil {
 		return fmt.Errorf("failed to marshal sample config: %w", err)
 	}
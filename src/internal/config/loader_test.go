@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // TestLoadConfig tests single configuration loading
@@ -560,7 +561,7 @@ spec:
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			// When: Load VLAN configuration
-			config, err := loadNodeVLANConf([]byte(tt.configData))
+			config, err := loadNodeVLANConf([]byte(tt.configData), BuiltinDefaults())
 
 			// Then: Verify loading result
 			if tt.expectValid {
@@ -654,7 +655,7 @@ spec:
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			// When: Load test configuration
-			config, err := loadNodeTestConf([]byte(tt.configData))
+			config, err := loadNodeTestConf([]byte(tt.configData), BuiltinDefaults())
 
 			// Then: Verify loading result
 			if tt.expectValid {
@@ -722,12 +723,30 @@ func TestApplyDefaults(t *testing.T) {
 		}
 
 		// When: Apply defaults
-		result := applyNodeVLANDefaults(config)
+		result := applyNodeVLANDefaults(config, DefaultInterface)
 
 		// Then: Verify defaults are applied
 		assert.Equal(t, "default", result.Metadata.Namespace, "Should apply default namespace")
 	})
 
+	t.Run("ovs_vlan_defaults_applied", func(t *testing.T) {
+		// Given: A VLAN on an OVS bridge without port or tag
+		config := NodeVLANConf{
+			Spec: NodeVLANSpec{
+				VLANs: map[string]VLANConfig{
+					"provider": {ID: 300, OVS: &OVSConfig{Bridge: "br-ex"}},
+				},
+			},
+		}
+
+		// When: Apply defaults
+		result := applyNodeVLANDefaults(config, DefaultInterface)
+
+		// Then: The port is named after the VLAN ID, tagged with it, and no parent interface is set
+		assert.Equal(t, &OVSConfig{Bridge: "br-ex", Port: "vlan300", Tag: 300}, result.Spec.VLANs["provider"].OVS)
+		assert.Empty(t, result.Spec.VLANs["provider"].Interface)
+	})
+
 	t.Run("node_test_defaults_applied", func(t *testing.T) {
 		// Given: Minimal NodeTestConf
 		config := NodeTestConf{
@@ -922,3 +941,103 @@ func TestSampleConfigGeneration(t *testing.T) {
 		assert.Equal(t, 3, bundle.GetConfigCount(), "Multi-sample should have all three CRDs")
 	})
 }
+
+// TestLoadConfig_CleanupConf tests loading and validation of label cleanup documents
+// WHY: Cleanup removes labels in bulk, so an unscoped document must never load
+func TestLoadConfig_CleanupConf(t *testing.T) {
+	tests := []struct {
+		name        string
+		description string
+		spec        string
+		shouldError bool
+		errorText   string
+	}{
+		{
+			name:        "explicit_nodes",
+			description: "Prefixes with explicit nodes should load",
+			spec: `  labelPrefixes: ["openstack-role"]
+  nodes: ["rsb2", "rsb3"]`,
+		},
+		{
+			name:        "node_selector",
+			description: "Prefixes with a node selector should load",
+			spec: `  labelPrefixes: ["legacy.icycloud.io/"]
+  nodeSelector: "legacy.icycloud.io/managed=true"`,
+		},
+		{
+			name:        "missing_prefixes",
+			description: "A cleanup without prefixes should be rejected",
+			spec:        `  nodes: ["rsb2"]`,
+			shouldError: true,
+			errorText:   "at least one label prefix",
+		},
+		{
+			name:        "empty_prefix",
+			description: "An empty prefix would match every label and must be rejected",
+			spec: `  labelPrefixes: [""]
+  nodes: ["rsb2"]`,
+			shouldError: true,
+			errorText:   "must not be empty",
+		},
+		{
+			name:        "no_node_selection",
+			description: "A cleanup must never implicitly target every node",
+			spec:        `  labelPrefixes: ["openstack-role"]`,
+			shouldError: true,
+			errorText:   "nodes or nodeSelector",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: CleanupConf document
+			configData := `apiVersion: openstack.kictl.icycloud.io/v1
+kind: CleanupConf
+metadata:
+  name: legacy-cleanup
+spec:
+` + tt.spec
+			configPath := filepath.Join(t.TempDir(), "cleanup.yaml")
+			require.NoError(t, os.WriteFile(configPath, []byte(configData), 0644))
+
+			// When: Load as bundle
+			bundle, err := LoadMultipleConfigs(configPath)
+
+			// Then: Verify result
+			if tt.shouldError {
+				assert.Error(t, err, tt.description)
+				assert.Contains(t, err.Error(), tt.errorText, tt.description)
+				return
+			}
+			require.NoError(t, err, tt.description)
+			assert.True(t, bundle.HasCleanup(), tt.description)
+			assert.Equal(t, "default", bundle.Cleanup.Metadata.Namespace)
+			assert.Contains(t, bundle.GetSummary(), "Cleanup(1 prefixes)")
+		})
+	}
+}
+
+// TestLoadConfigData tests loading a bundle from in-memory data
+// WHY: Custom resources read from the API server arrive as JSON with server-populated fields
+func TestLoadConfigData(t *testing.T) {
+	// Given: NodeVLANConf as the API server returns it
+	data := []byte(`{"apiVersion":"openstack.kictl.icycloud.io/v1","kind":"NodeVLANConf",
+"metadata":{"name":"storage","namespace":"infra","uid":"1234","generation":2},
+"spec":{"vlans":{"storage":{"id":100,"subnet":"10.100.0.0/24","nodeMapping":{"rsb2":"10.100.0.12/24"}}}},
+"status":{"phase":"Ready"}}`)
+
+	// When: Loading it
+	bundle, err := LoadConfigData(data, "infra/storage")
+
+	// Then: Only the VLAN configuration is set and defaults are applied
+	require.NoError(t, err)
+	require.NotNil(t, bundle.VLANs)
+	assert.Nil(t, bundle.NodeLabels)
+	assert.Equal(t, "infra/storage", bundle.Source)
+	assert.Equal(t, "infra", bundle.VLANs.Metadata.Namespace)
+	assert.Equal(t, "10.100.0.12/24", bundle.VLANs.Spec.VLANs["storage"].NodeMapping["rsb2"])
+
+	// And: Invalid data is rejected
+	_, err = LoadConfigData([]byte(`{"apiVersion":"openstack.kictl.icycloud.io/v1","kind":"Unknown","metadata":{"name":"x"}}`), "infra/x")
+	assert.Error(t, err)
+}
@@ -0,0 +1,37 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// VLANMembershipLabelPrefix prefixes the node labels recording VLAN membership, e.g. network.kictl.io/vlan-management
+const VLANMembershipLabelPrefix = "network.kictl.io/vlan-"
+
+// VLANMembershipLabel returns the node label recording membership of a VLAN, valued with the node's primary address
+// The prefix length is dropped, and IPv6 colons are written as dashes since label values cannot hold colons.
+func VLANMembershipLabel(vlanName, addresses string) (key, value string) {
+	address, _, _ := strings.Cut(PrimaryAddress(addresses), "/")
+	return VLANMembershipLabelPrefix + vlanName, strings.Trim(strings.ReplaceAll(address, ":", "-"), "-")
+}
+
+// validateMembershipLabels checks that the membership label of every VLAN is a valid label key when they are enabled
+func validateMembershipLabels(toolName string, tool ToolConfig, vlans map[string]VLANConfig) error {
+	if !tool.MembershipLabels {
+		return nil
+	}
+	names := make([]string, 0, len(vlans))
+	for vlanName := range vlans {
+		names = append(names, vlanName)
+	}
+	sort.Strings(names)
+
+	for _, vlanName := range names {
+		key, _ := VLANMembershipLabel(vlanName, "")
+		if err := ValidateLabelKey(key); err != nil {
+			return fmt.Errorf("tools.%s.membershipLabels: VLAN %s cannot be a label: %w", toolName, vlanName, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+// Package config provides unit tests for VLAN membership labels
+// WHY: Label values cannot hold every address as written, and VLAN names must make valid label keys
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestVLANMembershipLabel tests the label recording a node's membership of a VLAN
+// WHY: The value must be the address kictl reaches the node by, in a form Kubernetes accepts
+func TestVLANMembershipLabel(t *testing.T) {
+	tests := []struct {
+		name          string
+		addresses     string
+		expectedValue string
+	}{
+		{name: "ipv4", addresses: "192.168.100.12/24", expectedValue: "192.168.100.12"},
+		{name: "dual_stack_prefers_ipv4", addresses: "fd00:100::12/64,192.168.100.12/24", expectedValue: "192.168.100.12"},
+		{name: "ipv6_only", addresses: "fd00:100::12/64", expectedValue: "fd00-100--12"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// When: Build the membership label of the management VLAN
+			key, value := VLANMembershipLabel("management", tt.addresses)
+
+			// Then: The key names the VLAN and the value is a valid label value
+			assert.Equal(t, "network.kictl.io/vlan-management", key)
+			assert.Equal(t, tt.expectedValue, value)
+			assert.NoError(t, ValidateLabelValue(value))
+		})
+	}
+}
+
+// TestValidateMembershipLabels tests rejecting VLAN names that cannot be label keys
+// WHY: An invalid key would fail every label call after the interfaces were already configured
+func TestValidateMembershipLabels(t *testing.T) {
+	vlans := map[string]VLANConfig{"storage net": {ID: 200}}
+
+	assert.NoError(t, validateMembershipLabels("nvlan", ToolConfig{}, vlans))
+	assert.ErrorContains(t, validateMembershipLabels("nvlan", ToolConfig{MembershipLabels: true}, vlans),
+		"tools.nvlan.membershipLabels: VLAN storage net cannot be a label")
+}
@@ -0,0 +1,260 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// HostnameLabel is the well-known label holding a node's hostname, used to narrow node selectors to node names
+const HostnameLabel = "kubernetes.io/hostname"
+
+// RestrictToNodes returns a copy of the bundle acting on the given nodes only
+// Role patterns are narrowed to the matching names, and node selectors to nodes whose hostname label is listed.
+// Tests are kept when they run from a listed node, storage nodes when listed. A bundle left with nothing to do is an error.
+func (b *ConfigBundle) RestrictToNodes(nodes []string) (*ConfigBundle, error) {
+	listed := make(map[string]bool, len(nodes))
+	for _, node := range nodes {
+		if IsNodePattern(node) {
+			return nil, fmt.Errorf("invalid node '%s': node filters take node names, not patterns", node)
+		}
+		listed[node] = true
+	}
+	names := sortedKeys(listed)
+	restricted := &ConfigBundle{Defaults: b.Defaults, Source: b.Source, Digest: b.Digest}
+
+	if b.Cleanup != nil {
+		cleanup := *b.Cleanup
+		cleanup.Spec.Nodes = filterNodeNames(b.Cleanup.Spec.Nodes, listed)
+		if cleanup.Spec.NodeSelector != "" {
+			cleanup.Spec.NodeSelector = restrictSelector(cleanup.Spec.NodeSelector, names)
+		}
+		if len(cleanup.Spec.Nodes) > 0 || cleanup.Spec.NodeSelector != "" {
+			restricted.Cleanup = &cleanup
+		}
+	}
+
+	if b.NodeLabels != nil {
+		roles := make(map[string]NodeRole)
+		for roleName, role := range b.NodeLabels.Spec.NodeRoles {
+			role.Nodes = filterNodeNames(role.Nodes, listed)
+			if role.NodeSelector != "" {
+				role.NodeSelector = restrictSelector(role.NodeSelector, names)
+			}
+			if len(role.Nodes) > 0 || role.NodeSelector != "" {
+				roles[roleName] = role
+			}
+		}
+		if len(roles) > 0 {
+			labels := *b.NodeLabels
+			labels.Spec.NodeRoles = roles
+			restricted.NodeLabels = &labels
+		}
+	}
+
+	if b.VLANs != nil {
+		vlans := make(map[string]VLANConfig)
+		for vlanName, vlanConfig := range b.VLANs.Spec.VLANs {
+			mapping := make(NodeMapping)
+			for node, address := range vlanConfig.NodeMapping {
+				if listed[node] {
+					mapping[node] = address
+				}
+			}
+			if len(mapping) > 0 {
+				vlanConfig.NodeMapping = mapping
+				vlans[vlanName] = vlanConfig
+			}
+		}
+		if len(vlans) > 0 {
+			vlanConf := *b.VLANs
+			vlanConf.Spec.VLANs = vlans
+			restricted.VLANs = &vlanConf
+		}
+	}
+
+	if b.Tests != nil {
+		var tests []ConnectivityTest
+		for _, test := range b.Tests.Spec.Tests {
+			if listed[test.Source] {
+				tests = append(tests, test)
+			}
+		}
+		if len(tests) > 0 {
+			testConf := *b.Tests
+			testConf.Spec.Tests = tests
+			restricted.Tests = &testConf
+		}
+	}
+
+	if b.Storage != nil {
+		nodes := make(map[string]StorageNode)
+		for node, storageNode := range b.Storage.Spec.Nodes {
+			if listed[node] {
+				nodes[node] = storageNode
+			}
+		}
+		if len(nodes) > 0 {
+			storage := *b.Storage
+			storage.Spec.Nodes = nodes
+			restricted.Storage = &storage
+		}
+	}
+
+	if restricted.Cleanup == nil && restricted.NodeLabels == nil && restricted.VLANs == nil && restricted.Tests == nil && restricted.Storage == nil {
+		return nil, fmt.Errorf("no configuration of the bundle applies to nodes %s", strings.Join(names, ", "))
+	}
+	return restricted, nil
+}
+
+// filterNodeNames returns the entries naming a listed node, and the listed nodes a pattern entry matches
+func filterNodeNames(entries []string, listed map[string]bool) []string {
+	kept := make(map[string]bool)
+	for _, entry := range entries {
+		if !IsNodePattern(entry) {
+			if listed[entry] {
+				kept[entry] = true
+			}
+			continue
+		}
+		for node := range listed {
+			if matched, _ := path.Match(entry, node); matched {
+				kept[node] = true
+			}
+		}
+	}
+	var nodes []string
+	for node := range kept {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+	return nodes
+}
+
+// restrictSelector adds a requirement on the hostname label to a node selector, keeping only the named nodes
+func restrictSelector(selector string, nodes []string) string {
+	return fmt.Sprintf("%s,%s in (%s)", selector, HostnameLabel, strings.Join(nodes, ","))
+}
+
+// ExcludeNodes returns a copy of the bundle leaving the given nodes out
+// Node names are dropped from every kind, node selectors gain a requirement against their hostname label and tests
+// add them to excludeNodes. Nodes a label role reaches by a name pattern alone cannot be told apart and stay in.
+// A bundle left with nothing to do is an error.
+func (b *ConfigBundle) ExcludeNodes(nodes []string) (*ConfigBundle, error) {
+	if len(nodes) == 0 {
+		return b, nil
+	}
+	excluded := make(map[string]bool, len(nodes))
+	for _, node := range nodes {
+		excluded[node] = true
+	}
+	names := sortedKeys(excluded)
+	kept := &ConfigBundle{Defaults: b.Defaults, Source: b.Source, Digest: b.Digest}
+
+	if b.Cleanup != nil {
+		cleanup := *b.Cleanup
+		cleanup.Spec.Nodes = withoutNodeNames(b.Cleanup.Spec.Nodes, excluded)
+		if cleanup.Spec.NodeSelector != "" {
+			cleanup.Spec.NodeSelector = excludeFromSelector(cleanup.Spec.NodeSelector, names)
+		}
+		if len(cleanup.Spec.Nodes) > 0 || cleanup.Spec.NodeSelector != "" {
+			kept.Cleanup = &cleanup
+		}
+	}
+
+	if b.NodeLabels != nil {
+		roles := make(map[string]NodeRole)
+		for roleName, role := range b.NodeLabels.Spec.NodeRoles {
+			role.Nodes = withoutNodeNames(role.Nodes, excluded)
+			if role.NodeSelector != "" {
+				role.NodeSelector = excludeFromSelector(role.NodeSelector, names)
+			}
+			if len(role.Nodes) > 0 || role.NodeSelector != "" {
+				roles[roleName] = role
+			}
+		}
+		if len(roles) > 0 {
+			labels := *b.NodeLabels
+			labels.Spec.NodeRoles = roles
+			kept.NodeLabels = &labels
+		}
+	}
+
+	if b.VLANs != nil {
+		vlans := make(map[string]VLANConfig)
+		for vlanName, vlanConfig := range b.VLANs.Spec.VLANs {
+			mapping := make(NodeMapping)
+			for node, address := range vlanConfig.NodeMapping {
+				if !excluded[node] {
+					mapping[node] = address
+				}
+			}
+			if len(mapping) > 0 {
+				vlanConfig.NodeMapping = mapping
+				vlans[vlanName] = vlanConfig
+			}
+		}
+		if len(vlans) > 0 {
+			vlanConf := *b.VLANs
+			vlanConf.Spec.VLANs = vlans
+			kept.VLANs = &vlanConf
+		}
+	}
+
+	if b.Tests != nil {
+		testConf := *b.Tests
+		testConf.Tools.Ntest.ExcludeNodes = append(append([]string{}, b.Tests.Tools.Ntest.ExcludeNodes...), names...)
+		kept.Tests = &testConf
+	}
+
+	if b.Sysctls != nil {
+		roles := make(map[string]SysctlRole)
+		for roleName, role := range b.Sysctls.Spec.NodeRoles {
+			role.Nodes = withoutNodeNames(role.Nodes, excluded)
+			if len(role.Nodes) > 0 {
+				roles[roleName] = role
+			}
+		}
+		if len(roles) > 0 {
+			sysctls := *b.Sysctls
+			sysctls.Spec.NodeRoles = roles
+			kept.Sysctls = &sysctls
+		}
+	}
+
+	if b.Storage != nil {
+		nodes := make(map[string]StorageNode)
+		for node, storageNode := range b.Storage.Spec.Nodes {
+			if !excluded[node] {
+				nodes[node] = storageNode
+			}
+		}
+		if len(nodes) > 0 {
+			storage := *b.Storage
+			storage.Spec.Nodes = nodes
+			kept.Storage = &storage
+		}
+	}
+
+	if kept.Cleanup == nil && kept.NodeLabels == nil && kept.VLANs == nil && kept.Tests == nil && kept.Sysctls == nil && kept.Storage == nil {
+		return nil, fmt.Errorf("no configuration of the bundle applies to nodes other than %s", strings.Join(names, ", "))
+	}
+	return kept, nil
+}
+
+// withoutNodeNames returns the entries not naming an excluded node, keeping patterns as they are
+func withoutNodeNames(entries []string, excluded map[string]bool) []string {
+	var nodes []string
+	for _, entry := range entries {
+		if !excluded[entry] {
+			nodes = append(nodes, entry)
+		}
+	}
+	return nodes
+}
+
+// excludeFromSelector adds a requirement on the hostname label to a node selector, leaving out the named nodes
+func excludeFromSelector(selector string, nodes []string) string {
+	return fmt.Sprintf("%s,%s notin (%s)", selector, HostnameLabel, strings.Join(nodes, ","))
+}
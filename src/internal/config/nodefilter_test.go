@@ -0,0 +1,134 @@
+// Package config provides unit tests for restricting a bundle to a set of nodes
+// WHY: Re-running a bundle against freshly added hosts must not touch any other node, even through patterns or selectors
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestConfigBundle_RestrictToNodes tests restricting every kind of the bundle to the listed nodes
+// WHY: Each kind names its nodes differently, so each must be narrowed its own way
+func TestConfigBundle_RestrictToNodes(t *testing.T) {
+	tests := []struct {
+		name        string
+		nodes       []string
+		expected    []string
+		expectRoles map[string]NodeRole
+		expectClean *CleanupSpec
+		expectError string
+	}{
+		{
+			name:     "one_node",
+			nodes:    []string{"rsb3"},
+			expected: []string{"cleanup.legacy.io/", "cleanup.old-", "nodelabel.control.rsb3/ceph-mon", "nodelabel.control.rsb3/openstack-role", "nodelabel.storage/ceph-osd", "vlan.management.rsb3", "vlan.storage.rsb3", "test.storage-ping"},
+			expectRoles: map[string]NodeRole{
+				"control": {Nodes: []string{"rsb3"}, Labels: map[string]string{"openstack-role": "control", "ceph-mon": "enabled"}},
+				"storage": {NodeSelector: "disk=ssd,kubernetes.io/hostname in (rsb3)", Labels: map[string]string{"ceph-osd": "enabled"}},
+			},
+			expectClean: &CleanupSpec{LabelPrefixes: []string{"legacy.io/", "old-"}, Nodes: []string{"rsb3"}},
+		},
+		{
+			name:     "pattern_role_narrowed_to_new_hosts",
+			nodes:    []string{"rsb17", "rsb18"},
+			expected: []string{"nodelabel.compute.rsb17/openstack-role", "nodelabel.compute.rsb18/openstack-role", "nodelabel.storage/ceph-osd"},
+			expectRoles: map[string]NodeRole{
+				"compute": {Nodes: []string{"rsb17", "rsb18"}, Labels: map[string]string{"openstack-role": "compute"}},
+				"storage": {NodeSelector: "disk=ssd,kubernetes.io/hostname in (rsb17,rsb18)", Labels: map[string]string{"ceph-osd": "enabled"}},
+			},
+		},
+		{
+			name:        "pattern_node",
+			nodes:       []string{"rsb*"},
+			expectError: "node filters take node names, not patterns",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A bundle of every kind whose cleanup lists nodes
+			bundle := newTargetTestBundle()
+			bundle.Cleanup.Spec.Nodes = []string{"rsb2", "rsb3"}
+
+			// When: Restrict it to the nodes
+			restricted, err := bundle.RestrictToNodes(tt.nodes)
+
+			// Then: Only the listed nodes are left, and the bundle itself is unchanged
+			if tt.expectError != "" {
+				assert.ErrorContains(t, err, tt.expectError)
+				return
+			}
+			require.NoError(t, err)
+			var addresses []string
+			for _, address := range restricted.ResourceAddresses() {
+				addresses = append(addresses, address.String())
+			}
+			assert.Equal(t, tt.expected, addresses)
+			assert.Equal(t, tt.expectRoles, restricted.NodeLabels.Spec.NodeRoles)
+			if tt.expectClean != nil {
+				require.NotNil(t, restricted.Cleanup)
+				assert.Equal(t, *tt.expectClean, restricted.Cleanup.Spec)
+			} else {
+				assert.Nil(t, restricted.Cleanup)
+			}
+			assert.Len(t, bundle.ResourceAddresses(), 13)
+		})
+	}
+}
+
+// TestConfigBundle_RestrictToNodesNothingLeft tests restricting a bundle to nodes it does not name
+// WHY: A mistyped node must fail the run instead of running nothing successfully
+func TestConfigBundle_RestrictToNodesNothingLeft(t *testing.T) {
+	// Given: A bundle listing its nodes by name only
+	bundle := &ConfigBundle{VLANs: &NodeVLANConf{Spec: NodeVLANSpec{VLANs: map[string]VLANConfig{
+		"management": {ID: 100, NodeMapping: NodeMapping{"rsb2": "10.1.0.2/24"}},
+	}}}}
+
+	// When: Restrict it to another node
+	_, err := bundle.RestrictToNodes([]string{"rsb9", "rbs2"})
+
+	// Then: The run is refused
+	assert.ErrorContains(t, err, "no configuration of the bundle applies to nodes rbs2, rsb9")
+}
+
+// TestConfigBundle_ExcludeNodes tests leaving nodes out of every kind of the bundle
+// WHY: The operator backs off failing nodes this way, so the other nodes must be reconciled exactly as before
+func TestConfigBundle_ExcludeNodes(t *testing.T) {
+	// Given: A bundle of every kind whose cleanup lists nodes
+	bundle := newTargetTestBundle()
+	bundle.Cleanup.Spec.Nodes = []string{"rsb2", "rsb3"}
+
+	// When: Leave rsb3 out
+	kept, err := bundle.ExcludeNodes([]string{"rsb3"})
+
+	// Then: rsb3 is gone from names and selectors, patterns stay, and the bundle itself is unchanged
+	require.NoError(t, err)
+	var addresses []string
+	for _, address := range kept.ResourceAddresses() {
+		addresses = append(addresses, address.String())
+	}
+	assert.Equal(t, []string{"cleanup.legacy.io/", "cleanup.old-", "nodelabel.compute.rsb1*/openstack-role", "nodelabel.control.rsb2/ceph-mon", "nodelabel.control.rsb2/openstack-role", "nodelabel.storage/ceph-osd", "vlan.management.rsb2", "test.mgmt-ping", "test.storage-ping"}, addresses)
+	assert.Equal(t, []string{"rsb2"}, kept.Cleanup.Spec.Nodes)
+	assert.Equal(t, []string{"rsb1*"}, kept.NodeLabels.Spec.NodeRoles["compute"].Nodes)
+	assert.Equal(t, "disk=ssd,kubernetes.io/hostname notin (rsb3)", kept.NodeLabels.Spec.NodeRoles["storage"].NodeSelector)
+	assert.Equal(t, []string{"rsb3"}, kept.Tests.Tools.Ntest.ExcludeNodes)
+	assert.Len(t, bundle.ResourceAddresses(), 13)
+	assert.Empty(t, bundle.Tests.Tools.Ntest.ExcludeNodes)
+}
+
+// TestConfigBundle_ExcludeNodesNothingLeft tests leaving out every node of a bundle
+// WHY: A bundle with nothing left must not be reconciled as if it succeeded
+func TestConfigBundle_ExcludeNodesNothingLeft(t *testing.T) {
+	// Given: A bundle listing its nodes by name only
+	bundle := &ConfigBundle{VLANs: &NodeVLANConf{Spec: NodeVLANSpec{VLANs: map[string]VLANConfig{
+		"management": {ID: 100, NodeMapping: NodeMapping{"rsb2": "10.1.0.2/24"}},
+	}}}}
+
+	// When: Leave its only node out
+	_, err := bundle.ExcludeNodes([]string{"rsb2"})
+
+	// Then: Nothing is left to do
+	assert.ErrorContains(t, err, "no configuration of the bundle applies to nodes other than rsb2")
+}
@@ -0,0 +1,264 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Node set operations, written as YAML tags on a list of operands, e.g. nodes: !difference [allNodes, controlNodes]
+const (
+	NodeSetUnion        = "!union"
+	NodeSetDifference   = "!difference"
+	NodeSetIntersection = "!intersection"
+)
+
+// NodeSets names lists of nodes in spec.nodeSets of the Defaults document, e.g. {"controlNodes": ["rsb2", "rsb3"]}
+type NodeSets map[string][]string
+
+// validate checks that every node set names its nodes
+func (s NodeSets) validate() error {
+	names := make([]string, 0, len(s))
+	for name := range s {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, node := range s[name] {
+			if strings.TrimSpace(node) == "" {
+				return fmt.Errorf("spec.nodeSets.%s: node names cannot be empty", name)
+			}
+		}
+	}
+	return nil
+}
+
+// hasNodeSetOperations reports whether a YAML document uses any node set operation
+func hasNodeSetOperations(data []byte) bool {
+	for _, tag := range []string{NodeSetUnion, NodeSetDifference, NodeSetIntersection} {
+		if bytes.Contains(data, []byte(tag)) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveNodeSetOperations replaces every node set operation of a YAML document by the node list it evaluates to
+// Operands are names of sets, lists of node names or nested operations. Documents without operations are returned as is.
+func resolveNodeSetOperations(data []byte, sets NodeSets) ([]byte, error) {
+	if !hasNodeSetOperations(data) {
+		return data, nil
+	}
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("failed to parse node set operations: %w", err)
+	}
+	resolver := &nodeSetResolver{resolved: sets}
+	if err := resolver.replace(&document); err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(&document)
+}
+
+// resolveDefaultsNodeSets evaluates the node sets of a Defaults document, which may be written with operations on each other
+func resolveDefaultsNodeSets(data []byte) ([]byte, error) {
+	if !hasNodeSetOperations(data) {
+		return data, nil
+	}
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("failed to parse node set operations: %w", err)
+	}
+
+	resolver := &nodeSetResolver{resolved: make(NodeSets), defined: make(map[string]*yaml.Node), resolving: make(map[string]bool)}
+	var setsNode *yaml.Node
+	if len(document.Content) > 0 {
+		if spec := mappingValue(document.Content[0], "spec"); spec != nil {
+			setsNode = mappingValue(spec, "nodeSets")
+		}
+	}
+	if setsNode != nil && setsNode.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(setsNode.Content); i += 2 {
+			resolver.defined[setsNode.Content[i].Value] = setsNode.Content[i+1]
+		}
+		for i := 0; i+1 < len(setsNode.Content); i += 2 {
+			if _, err := resolver.set(setsNode.Content[i].Value); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := resolver.replace(&document); err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(&document)
+}
+
+// nodeSetResolver evaluates node set operations against named sets
+type nodeSetResolver struct {
+	resolved  NodeSets              // Evaluated sets
+	defined   map[string]*yaml.Node // Sets of the Defaults document still to evaluate, by name
+	resolving map[string]bool       // Sets being evaluated, to detect sets defined through themselves
+}
+
+// replace rewrites every operation below a node into the plain list of nodes it evaluates to
+func (r *nodeSetResolver) replace(node *yaml.Node) error {
+	if isNodeSetOperation(node) {
+		nodes, err := r.evaluate(node)
+		if err != nil {
+			return err
+		}
+		*node = *nodeList(nodes)
+		return nil
+	}
+	for _, child := range node.Content {
+		if err := r.replace(child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// set returns the nodes of a named set, evaluating it on first use
+func (r *nodeSetResolver) set(name string) ([]string, error) {
+	if nodes, ok := r.resolved[name]; ok {
+		return nodes, nil
+	}
+	definition, ok := r.defined[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown node set '%s': define it in spec.nodeSets of the Defaults document", name)
+	}
+	if r.resolving[name] {
+		return nil, fmt.Errorf("node set '%s' is defined through itself", name)
+	}
+	r.resolving[name] = true
+	defer delete(r.resolving, name)
+
+	nodes, err := r.evaluate(definition)
+	if err != nil {
+		return nil, fmt.Errorf("spec.nodeSets.%s: %w", name, err)
+	}
+	r.resolved[name] = nodes
+	return nodes, nil
+}
+
+// evaluate returns the nodes of an operand: a set name, a list of node names or an operation
+func (r *nodeSetResolver) evaluate(node *yaml.Node) ([]string, error) {
+	switch {
+	case node.Kind == yaml.ScalarNode:
+		return r.set(node.Value)
+	case node.Kind != yaml.SequenceNode:
+		return nil, fmt.Errorf("node set operands must be set names or lists of nodes (line %d)", node.Line)
+	case !isNodeSetOperation(node):
+		nodes := make([]string, 0, len(node.Content))
+		for _, item := range node.Content {
+			if item.Kind != yaml.ScalarNode {
+				return nil, fmt.Errorf("node lists must hold node names (line %d)", item.Line)
+			}
+			nodes = append(nodes, item.Value)
+		}
+		return nodes, nil
+	}
+
+	operands := make([][]string, 0, len(node.Content))
+	for _, operand := range node.Content {
+		nodes, err := r.evaluate(operand)
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, nodes)
+	}
+	if len(operands) == 0 {
+		return nil, fmt.Errorf("%s needs at least one operand (line %d)", node.Tag, node.Line)
+	}
+
+	switch node.Tag {
+	case NodeSetUnion:
+		return unionNodes(operands), nil
+	case NodeSetDifference:
+		return differenceNodes(operands), nil
+	default:
+		return intersectionNodes(operands), nil
+	}
+}
+
+// isNodeSetOperation reports whether a YAML node is a list tagged with a node set operation
+func isNodeSetOperation(node *yaml.Node) bool {
+	switch node.Tag {
+	case NodeSetUnion, NodeSetDifference, NodeSetIntersection:
+		return node.Kind == yaml.SequenceNode
+	}
+	return false
+}
+
+// unionNodes returns the nodes of any operand, in order of first appearance
+func unionNodes(operands [][]string) []string {
+	seen := make(map[string]bool)
+	var nodes []string
+	for _, operand := range operands {
+		for _, node := range operand {
+			if !seen[node] {
+				seen[node] = true
+				nodes = append(nodes, node)
+			}
+		}
+	}
+	return nodes
+}
+
+// differenceNodes returns the nodes of the first operand that no other operand lists, in order
+func differenceNodes(operands [][]string) []string {
+	excluded := make(map[string]bool)
+	for _, operand := range operands[1:] {
+		for _, node := range operand {
+			excluded[node] = true
+		}
+	}
+	var nodes []string
+	for _, node := range unionNodes(operands[:1]) {
+		if !excluded[node] {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
+// intersectionNodes returns the nodes of the first operand that every other operand lists, in order
+func intersectionNodes(operands [][]string) []string {
+	var nodes []string
+	for _, node := range unionNodes(operands[:1]) {
+		inAll := true
+		for _, operand := range operands[1:] {
+			if !containsNode(operand, node) {
+				inAll = false
+				break
+			}
+		}
+		if inAll {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
+// containsNode reports whether a list of nodes holds a node
+func containsNode(nodes []string, node string) bool {
+	for _, candidate := range nodes {
+		if candidate == node {
+			return true
+		}
+	}
+	return false
+}
+
+// nodeList returns a YAML list of node names
+func nodeList(nodes []string) *yaml.Node {
+	list := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Style: yaml.FlowStyle}
+	for _, node := range nodes {
+		list.Content = append(list.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: node})
+	}
+	return list
+}
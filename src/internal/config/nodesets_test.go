@@ -0,0 +1,98 @@
+// Package config provides unit tests for node set operations
+// WHY: "every node except the control plane" must stay correct as nodes are added, without listing them twice
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// nodeSetsTestDefaults is a Defaults document naming node sets, one derived from the others
+const nodeSetsTestDefaults = `apiVersion: openstack.kictl.icycloud.io/v1
+kind: Defaults
+metadata:
+  name: defaults
+spec:
+  nodeSets:
+    workerNodes: !difference [allNodes, controlNodes]
+    allNodes: [rsb1, rsb2, rsb3, rsb4]
+    controlNodes: [rsb1, rsb2]
+    storageNodes: [rsb2, rsb4]
+---
+`
+
+// nodeSetsTestLabels returns a NodeLabelConf whose compute role lists the given nodes
+func nodeSetsTestLabels(nodes string) string {
+	return `apiVersion: openstack.kictl.icycloud.io/v1
+kind: NodeLabelConf
+metadata:
+  name: labels
+spec:
+  nodeRoles:
+    compute:
+      nodes: ` + nodes + `
+      labels:
+        role: compute
+`
+}
+
+// TestLoadMultipleConfigs_NodeSets tests resolving node set operations while loading a bundle
+// WHY: Each operation must give exactly the nodes it describes, and mistakes must fail loading
+func TestLoadMultipleConfigs_NodeSets(t *testing.T) {
+	tests := []struct {
+		name        string
+		defaults    string
+		nodes       string
+		expected    []string
+		expectError string
+	}{
+		{name: "set_defined_through_another", defaults: nodeSetsTestDefaults, nodes: "!union [workerNodes]", expected: []string{"rsb3", "rsb4"}},
+		{name: "difference", defaults: nodeSetsTestDefaults, nodes: "!difference [allNodes, controlNodes, [rsb4]]", expected: []string{"rsb3"}},
+		{name: "union_with_node_list", defaults: nodeSetsTestDefaults, nodes: "!union [controlNodes, storageNodes, [rsb9]]", expected: []string{"rsb1", "rsb2", "rsb4", "rsb9"}},
+		{name: "intersection", defaults: nodeSetsTestDefaults, nodes: "!intersection [controlNodes, storageNodes]", expected: []string{"rsb2"}},
+		{name: "nested", defaults: nodeSetsTestDefaults, nodes: "!union [!intersection [workerNodes, storageNodes], [rsb1]]", expected: []string{"rsb4", "rsb1"}},
+		{name: "node_lists_without_defaults", nodes: "!difference [[rsb1, rsb2], [rsb2]]", expected: []string{"rsb1"}},
+		{name: "plain_list", defaults: nodeSetsTestDefaults, nodes: "[rsb7]", expected: []string{"rsb7"}},
+		{
+			name:        "unknown_set",
+			defaults:    nodeSetsTestDefaults,
+			nodes:       "!difference [allNodes, ctrlNodes]",
+			expectError: "unknown node set 'ctrlNodes': define it in spec.nodeSets of the Defaults document",
+		},
+		{
+			name: "set_defined_through_itself",
+			defaults: `apiVersion: openstack.kictl.icycloud.io/v1
+kind: Defaults
+metadata:
+  name: defaults
+spec:
+  nodeSets:
+    a: !union [b, [rsb1]]
+    b: !union [a]
+---
+`,
+			nodes:       "[rsb1]",
+			expectError: "node set 'a' is defined through itself",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A bundle whose compute role lists nodes with a node set operation
+			data := []byte(tt.defaults + nodeSetsTestLabels(tt.nodes))
+
+			// When: Load the bundle
+			bundle, err := LoadConfigData(data, "nodesets.yaml")
+
+			// Then: The role lists the nodes the operation evaluates to
+			if tt.expectError != "" {
+				assert.ErrorContains(t, err, tt.expectError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, bundle.NodeLabels.Spec.NodeRoles["compute"].Nodes)
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxOVSPortNameLength is the longest name the kernel accepts for the interface of an internal port
+const maxOVSPortNameLength = 15
+
+// withOVSDefaults returns the OVS settings of a VLAN with the port named vlan<id> and tagged with the VLAN ID unless set
+func withOVSDefaults(vlan VLANConfig) *OVSConfig {
+	ovs := *vlan.OVS
+	if ovs.Port == "" {
+		ovs.Port = fmt.Sprintf("vlan%d", vlan.ID)
+	}
+	if ovs.Tag == 0 {
+		ovs.Tag = vlan.ID
+	}
+	return &ovs
+}
+
+// validateOVS checks the OVS settings of a VLAN, which replace its parent interface
+func validateOVS(vlanName string, vlan VLANConfig) error {
+	if vlan.OVS == nil {
+		return nil
+	}
+	if vlan.Interface != "" {
+		return fmt.Errorf("VLAN %s: interface and ovs are mutually exclusive, the port is attached to ovs.bridge", vlanName)
+	}
+	if vlan.OVS.Bridge == "" {
+		return fmt.Errorf("VLAN %s: ovs.bridge is required", vlanName)
+	}
+	ovs := withOVSDefaults(vlan)
+	if ovs.Tag < 1 || ovs.Tag > 4094 {
+		return fmt.Errorf("VLAN %s: ovs.tag %d is outside 1-4094", vlanName, ovs.Tag)
+	}
+	if len(ovs.Port) > maxOVSPortNameLength || strings.ContainsAny(ovs.Port, "/ \t") {
+		return fmt.Errorf("VLAN %s: ovs.port '%s' is not a valid interface name of at most %d characters", vlanName, ovs.Port, maxOVSPortNameLength)
+	}
+	return nil
+}
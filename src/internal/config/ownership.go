@@ -0,0 +1,46 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Conflict policies of an ownership policy
+const (
+	OwnershipConflictWarn = "warn"
+	OwnershipConflictFail = "fail"
+)
+
+// ownerPattern is a name usable in an annotation key: alphanumerics, '-', '_' and '.', at most 63 characters
+var ownerPattern = regexp.MustCompile(`^[A-Za-z0-9]([-A-Za-z0-9_.]{0,61}[A-Za-z0-9])?$`)
+
+// OwnershipPolicy names the team or application owning what the bundle manages on its nodes
+// Runs record the label keys and VLANs of the owner in a node annotation and check them against those of other owners.
+type OwnershipPolicy struct {
+	// Owner identifies the bundle, e.g. "team-network"
+	Owner string `json:"owner" yaml:"owner"`
+
+	// OnConflict is warn (default) or fail when a run would change label keys or VLANs another owner manages
+	OnConflict string `json:"onConflict,omitempty" yaml:"onConflict,omitempty"`
+}
+
+// FailsOnConflict reports whether a conflict with another owner stops the run
+func (p *OwnershipPolicy) FailsOnConflict() bool {
+	return p != nil && p.OnConflict == OwnershipConflictFail
+}
+
+// validate checks that the owner can be part of an annotation key and the conflict policy is known
+func (p *OwnershipPolicy) validate() error {
+	if p == nil {
+		return nil
+	}
+	if !ownerPattern.MatchString(p.Owner) {
+		return fmt.Errorf("spec.ownership.owner must be 1-63 letters, digits, '-', '_' or '.', starting and ending alphanumeric, got '%s'", p.Owner)
+	}
+	switch p.OnConflict {
+	case "", OwnershipConflictWarn, OwnershipConflictFail:
+		return nil
+	default:
+		return fmt.Errorf("spec.ownership.onConflict must be '%s' or '%s', got '%s'", OwnershipConflictWarn, OwnershipConflictFail, p.OnConflict)
+	}
+}
@@ -0,0 +1,57 @@
+// Package config provides unit tests for the ownership policy of the Defaults document
+// WHY: The owner ends up in node annotation keys, so names the API server would reject must fail loading
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLoadMultipleConfigs_Ownership tests loading the ownership policy
+// WHY: Conflicts only stop runs when the bundle asks for it
+func TestLoadMultipleConfigs_Ownership(t *testing.T) {
+	tests := []struct {
+		name         string
+		ownership    string
+		expectedFail bool
+		expectError  string
+	}{
+		{name: "warn_by_default", ownership: "    owner: team-network\n"},
+		{name: "fail_on_conflict", ownership: "    owner: team.storage_1\n    onConflict: fail\n", expectedFail: true},
+		{name: "owner_missing", ownership: "    onConflict: fail\n", expectError: "spec.ownership.owner must be 1-63 letters"},
+		{name: "owner_with_slash", ownership: "    owner: team/network\n", expectError: "got 'team/network'"},
+		{name: "unknown_policy", ownership: "    owner: team-network\n    onConflict: ignore\n", expectError: "spec.ownership.onConflict must be 'warn' or 'fail', got 'ignore'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A bundle whose Defaults document has an ownership policy
+			data := []byte(`apiVersion: openstack.kictl.icycloud.io/v1
+kind: Defaults
+metadata:
+  name: defaults
+spec:
+  ownership:
+` + tt.ownership + `---
+` + nodeSetsTestLabels("[rsb1]"))
+
+			// When: Load the bundle
+			bundle, err := LoadConfigData(data, "ownership.yaml")
+
+			// Then: The policy is loaded or rejected
+			if tt.expectError != "" {
+				assert.ErrorContains(t, err, tt.expectError)
+				return
+			}
+			require.NoError(t, err)
+			policy := bundle.GetDefaults().Spec.Ownership
+			require.NotNil(t, policy)
+			assert.Equal(t, tt.expectedFail, policy.FailsOnConflict())
+		})
+	}
+
+	var unset *OwnershipPolicy
+	assert.False(t, unset.FailsOnConflict())
+}
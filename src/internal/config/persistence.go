@@ -0,0 +1,57 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Persistence backends writing the configuration that recreates VLAN interfaces at boot
+const (
+	PersistenceBackendNetplan        = "netplan"        // Ubuntu; the default when none is set
+	PersistenceBackendNetworkManager = "networkmanager" // NetworkManager keyfiles, e.g. RHEL 9 and Fedora
+	PersistenceBackendNetworkd       = "networkd"       // systemd-networkd .netdev and .network files
+	PersistenceBackendIfcfg          = "ifcfg"          // Legacy network-scripts ifcfg files, e.g. RHEL 7
+	PersistenceBackendAuto           = "auto"           // Detect the backend on each node
+)
+
+// PersistenceBackends lists the supported persistence backends in the order auto-detection tries them
+var PersistenceBackends = []string{
+	PersistenceBackendNetplan,
+	PersistenceBackendNetworkManager,
+	PersistenceBackendNetworkd,
+	PersistenceBackendIfcfg,
+}
+
+// validatePersistenceBackend checks the persistence backend of a tool
+func validatePersistenceBackend(toolName string, tool ToolConfig) error {
+	if tool.PersistenceBackend == "" || tool.PersistenceBackend == PersistenceBackendAuto {
+		return nil
+	}
+	for _, backend := range PersistenceBackends {
+		if tool.PersistenceBackend == backend {
+			return nil
+		}
+	}
+	return fmt.Errorf("tools.%s.persistenceBackend must be %s or %s, got '%s'",
+		toolName, strings.Join(PersistenceBackends, ", "), PersistenceBackendAuto, tool.PersistenceBackend)
+}
+
+// validateNetplanTry checks that netplan try has a usable revert timeout and netplan files to apply
+func validateNetplanTry(toolName string, tool ToolConfig) error {
+	if tool.NetplanTry == 0 {
+		return nil
+	}
+	if tool.NetplanTry < time.Second {
+		return fmt.Errorf("tools.%s.netplanTry must be at least 1s, got %s", toolName, tool.NetplanTry)
+	}
+	if !tool.PersistentConfig {
+		return fmt.Errorf("tools.%s.netplanTry needs persistentConfig", toolName)
+	}
+	switch tool.PersistenceBackend {
+	case "", PersistenceBackendNetplan, PersistenceBackendAuto:
+		return nil
+	default:
+		return fmt.Errorf("tools.%s.netplanTry needs the %s persistence backend, got '%s'", toolName, PersistenceBackendNetplan, tool.PersistenceBackend)
+	}
+}
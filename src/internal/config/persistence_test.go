@@ -0,0 +1,79 @@
+// Package config provides unit tests for the VLAN persistence backend option
+// WHY: A backend typo must fail at load time instead of leaving nodes without boot configuration
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestValidatePersistenceBackend tests the accepted persistence backends
+// WHY: Only backends kictl can render, or auto-detection, are accepted
+func TestValidatePersistenceBackend(t *testing.T) {
+	tests := []struct {
+		name        string
+		backend     string
+		expectError string
+	}{
+		{"unset_means_netplan", "", ""},
+		{"auto", PersistenceBackendAuto, ""},
+		{"networkmanager", PersistenceBackendNetworkManager, ""},
+		{"ifcfg", PersistenceBackendIfcfg, ""},
+		{"unknown", "wicked", "tools.nvlan.persistenceBackend must be netplan, networkmanager, networkd, ifcfg or auto, got 'wicked'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A tool configuration with a persistence backend
+			tool := ToolConfig{PersistentConfig: true, PersistenceBackend: tt.backend}
+
+			// When: Validate it
+			err := validatePersistenceBackend("nvlan", tool)
+
+			// Then: Unknown backends are rejected with the choices listed
+			if tt.expectError != "" {
+				require.Error(t, err)
+				assert.Equal(t, tt.expectError, err.Error())
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+// TestValidateNetplanTry tests the netplan try option
+// WHY: netplan try only protects a node when there is a netplan file to apply and time to confirm it
+func TestValidateNetplanTry(t *testing.T) {
+	tests := []struct {
+		name        string
+		tool        ToolConfig
+		expectError string
+	}{
+		{"unset", ToolConfig{}, ""},
+		{"netplan_default", ToolConfig{PersistentConfig: true, NetplanTry: 2 * time.Minute}, ""},
+		{"auto", ToolConfig{PersistentConfig: true, PersistenceBackend: PersistenceBackendAuto, NetplanTry: 2 * time.Minute}, ""},
+		{"too_short", ToolConfig{PersistentConfig: true, NetplanTry: time.Millisecond}, "tools.nvlan.netplanTry must be at least 1s, got 1ms"},
+		{"without_persistent_config", ToolConfig{NetplanTry: 2 * time.Minute}, "tools.nvlan.netplanTry needs persistentConfig"},
+		{"other_backend", ToolConfig{PersistentConfig: true, PersistenceBackend: PersistenceBackendIfcfg, NetplanTry: 2 * time.Minute},
+			"tools.nvlan.netplanTry needs the netplan persistence backend, got 'ifcfg'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A tool configuration with netplan try
+
+			// When: Validate it
+			err := validateNetplanTry("nvlan", tt.tool)
+
+			// Then: Only a usable combination is accepted
+			if tt.expectError != "" {
+				assert.EqualError(t, err, tt.expectError)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,133 @@
+// Package config provides policy rules enforced during configuration validation
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+)
+
+// compileNodeNamePattern compiles a node name pattern so that it must match the whole name
+func compileNodeNamePattern(pattern string) (*regexp.Regexp, error) {
+	re, err := regexp.Compile("^(?:" + pattern + ")$")
+	if err != nil {
+		return nil, fmt.Errorf("invalid nodeNamePattern '%s': %w", pattern, err)
+	}
+	return re, nil
+}
+
+// checkNodeNames validates node names against the allowed hostname pattern
+// An empty pattern disables the check
+func checkNodeNames(pattern string, nodes map[string]string) error {
+	if pattern == "" {
+		return nil
+	}
+
+	re, err := compileNodeNamePattern(pattern)
+	if err != nil {
+		return err
+	}
+
+	// Sort for deterministic error messages
+	names := make([]string, 0, len(nodes))
+	for name := range nodes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if !re.MatchString(name) {
+			return fmt.Errorf("node '%s' in %s does not match allowed node name pattern '%s'", name, nodes[name], pattern)
+		}
+	}
+
+	return nil
+}
+
+// nodeLabelConfNodes returns node name -> referencing location for a NodeLabelConf
+// Name patterns are skipped, the nodes they match are only known on the cluster.
+func nodeLabelConfNodes(config NodeLabelConf) map[string]string {
+	nodes := make(map[string]string)
+	for roleName, role := range config.Spec.NodeRoles {
+		for _, node := range role.NodeNames() {
+			nodes[node] = fmt.Sprintf("role '%s'", roleName)
+		}
+	}
+	return nodes
+}
+
+// nodeVLANConfNodes returns node name -> referencing location for a NodeVLANConf
+func nodeVLANConfNodes(config NodeVLANConf) map[string]string {
+	nodes := make(map[string]string)
+	for vlanName, vlan := range config.Spec.VLANs {
+		for node := range vlan.NodeMapping {
+			nodes[node] = fmt.Sprintf("VLAN '%s'", vlanName)
+		}
+	}
+	return nodes
+}
+
+// cleanupConfNodes returns node name -> referencing location for a CleanupConf
+func cleanupConfNodes(config CleanupConf) map[string]string {
+	nodes := make(map[string]string)
+	for _, node := range config.Spec.Nodes {
+		nodes[node] = "cleanup nodes"
+	}
+	return nodes
+}
+
+// nodeSysctlConfNodes returns node name -> referencing location for a NodeSysctlConf
+func nodeSysctlConfNodes(config NodeSysctlConf) map[string]string {
+	nodes := make(map[string]string)
+	for roleName, role := range config.Spec.NodeRoles {
+		for _, node := range role.Nodes {
+			nodes[node] = fmt.Sprintf("sysctl role '%s'", roleName)
+		}
+	}
+	return nodes
+}
+
+// nodeStorageConfNodes returns node name -> referencing location for a NodeStorageConf
+func nodeStorageConfNodes(config NodeStorageConf) map[string]string {
+	nodes := make(map[string]string)
+	for node := range config.Spec.Nodes {
+		nodes[node] = "storage nodes"
+	}
+	return nodes
+}
+
+// ValidateNodeNamePolicy checks every node referenced in the bundle against its tool's nodeNamePattern
+// Called after CLI precedence so a --node-name-pattern override is enforced too
+func (b *ConfigBundle) ValidateNodeNamePolicy() error {
+	if b.NodeLabels != nil {
+		if err := checkNodeNames(b.NodeLabels.Tools.Nlabel.NodeNamePattern, nodeLabelConfNodes(*b.NodeLabels)); err != nil {
+			return fmt.Errorf("NodeLabelConf: %w", err)
+		}
+	}
+
+	if b.VLANs != nil {
+		if err := checkNodeNames(b.VLANs.Tools.Nvlan.NodeNamePattern, nodeVLANConfNodes(*b.VLANs)); err != nil {
+			return fmt.Errorf("NodeVLANConf: %w", err)
+		}
+	}
+
+	if b.Cleanup != nil {
+		if err := checkNodeNames(b.Cleanup.Tools.Nlabel.NodeNamePattern, cleanupConfNodes(*b.Cleanup)); err != nil {
+			return fmt.Errorf("CleanupConf: %w", err)
+		}
+	}
+
+	if b.Sysctls != nil {
+		if err := checkNodeNames(b.Sysctls.Tools.Nsysctl.NodeNamePattern, nodeSysctlConfNodes(*b.Sysctls)); err != nil {
+			return fmt.Errorf("NodeSysctlConf: %w", err)
+		}
+	}
+
+	if b.Storage != nil {
+		if err := checkNodeNames(b.Storage.Tools.Nstorage.NodeNamePattern, nodeStorageConfNodes(*b.Storage)); err != nil {
+			return fmt.Errorf("NodeStorageConf: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,144 @@
+// Package config provides unit tests for configuration policy rules
+// WHY: Naming policy must catch typos like rbs2 vs rsb2 before any cluster call is made
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCheckNodeNames tests node name matching against the allowed pattern
+// WHY: The pattern is the single guard against misspelled hostnames in configs
+func TestCheckNodeNames(t *testing.T) {
+	tests := []struct {
+		name        string
+		description string
+		pattern     string
+		nodes       map[string]string
+		shouldError bool
+		errorText   string
+	}{
+		{
+			name:        "empty_pattern_disables_check",
+			description: "No pattern configured should accept any node name",
+			pattern:     "",
+			nodes:       map[string]string{"anything-goes": "role 'control'"},
+			shouldError: false,
+		},
+		{
+			name:        "all_nodes_match",
+			description: "Node names following the convention should pass",
+			pattern:     "rsb[0-9]+",
+			nodes:       map[string]string{"rsb2": "role 'control'", "rsb10": "role 'compute'"},
+			shouldError: false,
+		},
+		{
+			name:        "typo_rejected",
+			description: "Transposed characters should be rejected with the referencing role",
+			pattern:     "rsb[0-9]+",
+			nodes:       map[string]string{"rsb2": "role 'control'", "rbs3": "role 'control'"},
+			shouldError: true,
+			errorText:   "node 'rbs3' in role 'control' does not match allowed node name pattern 'rsb[0-9]+'",
+		},
+		{
+			name:        "pattern_is_anchored",
+			description: "Partial matches must not satisfy the pattern",
+			pattern:     "rsb[0-9]+",
+			nodes:       map[string]string{"xrsb2-old": "VLAN 'management'"},
+			shouldError: true,
+			errorText:   "node 'xrsb2-old' in VLAN 'management'",
+		},
+		{
+			name:        "invalid_regex",
+			description: "Malformed patterns should be reported as configuration errors",
+			pattern:     "rsb[0-9",
+			nodes:       map[string]string{"rsb2": "role 'control'"},
+			shouldError: true,
+			errorText:   "invalid nodeNamePattern",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// When: Check node names
+			err := checkNodeNames(tt.pattern, tt.nodes)
+
+			// Then: Verify result
+			if tt.shouldError {
+				assert.Error(t, err, tt.description)
+				assert.Contains(t, err.Error(), tt.errorText, tt.description)
+			} else {
+				assert.NoError(t, err, tt.description)
+			}
+		})
+	}
+}
+
+// TestLoadConfig_NodeNamePattern tests that the loader enforces the naming policy
+// WHY: Violations must surface during validation rather than at GetNode time
+func TestLoadConfig_NodeNamePattern(t *testing.T) {
+	configData := `apiVersion: openstack.kictl.icycloud.io/v1
+kind: NodeLabelConf
+metadata:
+  name: policy-test
+spec:
+  nodeRoles:
+    control:
+      nodes: ["rsb2", "rbs3"]
+      labels:
+        openstack-role: control-plane
+tools:
+  nlabel:
+    nodeNamePattern: "rsb[0-9]+"`
+
+	// Given: Config with a misspelled node
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte(configData), 0644))
+
+	// When: Load configuration
+	_, err := LoadConfig(configPath)
+
+	// Then: Typo should be rejected
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "node 'rbs3' in role 'control'")
+}
+
+// TestConfigBundle_ValidateNodeNamePolicy tests bundle-wide policy enforcement
+// WHY: CLI overrides are applied after loading, so the bundle must be re-checkable
+func TestConfigBundle_ValidateNodeNamePolicy(t *testing.T) {
+	bundle := &ConfigBundle{
+		NodeLabels: &NodeLabelConf{
+			Spec: NodeLabelSpec{
+				NodeRoles: map[string]NodeRole{
+					"control": {Nodes: []string{"rsb2"}},
+				},
+			},
+			Tools: Tools{Nlabel: ToolConfig{NodeNamePattern: "rsb[0-9]+"}},
+		},
+		VLANs: &NodeVLANConf{
+			Spec: NodeVLANSpec{
+				VLANs: map[string]VLANConfig{
+					"management": {NodeMapping: map[string]string{"rbs2": "10.0.0.2/24"}},
+				},
+			},
+			Tools: Tools{Nvlan: ToolConfig{NodeNamePattern: "rsb[0-9]+"}},
+		},
+	}
+
+	// When: Validate policy
+	err := bundle.ValidateNodeNamePolicy()
+
+	// Then: VLAN typo should be reported with its kind
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "NodeVLANConf: node 'rbs2' in VLAN 'management'")
+
+	// When: Fix the typo
+	bundle.VLANs.Spec.VLANs["management"] = VLANConfig{NodeMapping: map[string]string{"rsb2": "10.0.0.2/24"}}
+
+	// Then: Bundle should pass
+	assert.NoError(t, bundle.ValidateNodeNamePolicy())
+}
@@ -3,14 +3,108 @@ package precedence
 
 import (
 	"fmt"
+	"os"
 	"reflect"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 )
 
+// Sources a setting can take its value from, lowest precedence first
+const (
+	SourceDefault = "default"
+	SourceConfig  = "config file"
+	SourceEnv     = "env"
+	SourceFlag    = "flag"
+)
+
+// EnvPrefix prefixes the environment variable of each global flag, e.g. KICTL_DRY_RUN for --dry-run
+const EnvPrefix = "KICTL_"
+
+// globalFlags are the CLI flags overriding tool settings of every configuration
+var globalFlags = []string{"dry-run", "log-level", "node-name-pattern"}
+
+// SetFlag is the repeatable flag overriding one tool setting, as <tool>.<setting>=<value>
+const SetFlag = "set"
+
+// AllowUnsafeFlag is the flag acknowledging overrides that turn off a safety setting of the config file
+const AllowUnsafeFlag = "allow-unsafe-override"
+
+// safetySettings are the tool settings making a run safer when true, e.g. dryRun
+var safetySettings = map[string]bool{"DryRun": true, "ValidateNodes": true, "ValidateConnectivity": true}
+
+// ScopedValue is a flag value that overrides the settings of some configuration kinds only
+type ScopedValue interface {
+	Scope() []string // Configuration kinds, e.g. NodeVLANConf; empty for every kind
+}
+
+// setOverride is one --set value
+type setOverride struct {
+	raw     string
+	tool    string
+	setting string
+	value   string
+	matched bool
+}
+
+// parseSetOverride splits a --set value written as <tool>.<setting>=<value>
+func parseSetOverride(raw string) (*setOverride, error) {
+	path, value, hasValue := strings.Cut(raw, "=")
+	tool, setting, hasSetting := strings.Cut(path, ".")
+	if !hasValue || !hasSetting || tool == "" || setting == "" {
+		return nil, fmt.Errorf("invalid --set %s: expected <tool>.<setting>=<value>, e.g. nlabel.validateNodes=false", raw)
+	}
+	switch tool {
+	case "nlabel", "nvlan", "ntest", "nsysctl", "nstorage":
+	default:
+		return nil, fmt.Errorf("invalid --set %s: unknown tool '%s'. Expected: nlabel, nvlan, ntest, nsysctl or nstorage", raw, tool)
+	}
+	return &setOverride{raw: raw, tool: tool, setting: setting, value: value}, nil
+}
+
+// Step is one source giving a setting a value
+type Step struct {
+	Source string // SourceDefault, SourceConfig, SourceEnv or SourceFlag
+	Name   string // Environment variable or flag, empty for the default and the config file
+	Value  string
+}
+
+// Decision records how one tool setting of one configuration got its value
+type Decision struct {
+	Config  string // Kind/name of the configuration
+	Setting string // Setting path, e.g. tools.nvlan.dryRun
+	Steps   []Step // Sources that set the value, lowest precedence first; the last one wins
+}
+
+// Value returns the value the setting ended up with
+func (d Decision) Value() string {
+	return d.Steps[len(d.Steps)-1].Value
+}
+
+// String returns the decision as "<config> <setting> = <value> (<source> <value> → ...)"
+func (d Decision) String() string {
+	steps := make([]string, len(d.Steps))
+	for i, step := range d.Steps {
+		steps[i] = step.Source
+		if step.Name != "" {
+			steps[i] += " " + step.Name
+		}
+		steps[i] += " " + step.Value
+	}
+	return fmt.Sprintf("%s %s = %s (%s)", d.Config, d.Setting, d.Value(), strings.Join(steps, " → "))
+}
+
 // GlobalResolver handles precedence resolution across multiple configuration types
 type GlobalResolver struct {
 	cmd *cobra.Command
+
+	trace     bool
+	decisions []Decision
+	fromEnv   map[string]string // Flags set from the environment, with their variable
+	sets      []*setOverride
+	unsafe    []string // Acknowledged overrides turning off a safety setting of the config file
 }
 
 // NewGlobalResolver creates a new global precedence resolver
@@ -20,8 +114,28 @@ func NewGlobalResolver(cmd *cobra.Command) *GlobalResolver {
 	}
 }
 
+// SetTrace enables recording a decision per tool setting, returned by Trace
+func (r *GlobalResolver) SetTrace(enabled bool) {
+	r.trace = enabled
+}
+
+// Trace returns the decisions recorded by ApplyGlobalOverrides while tracing is enabled
+func (r *GlobalResolver) Trace() []Decision {
+	return r.decisions
+}
+
+// UnsafeOverrides describes the overrides turning off a safety setting of the config file, allowed by --allow-unsafe-override
+func (r *GlobalResolver) UnsafeOverrides() []string {
+	return r.unsafe
+}
+
+// EnvVar returns the environment variable a global flag was set from, or "" when it was not
+func (r *GlobalResolver) EnvVar(flagName string) string {
+	return r.fromEnv[flagName]
+}
+
 // ApplyGlobalOverrides applies CLI flag overrides to all configurations in the bundle
-// This maintains the existing precedence pattern: CLI > Config > Defaults
+// This maintains the existing precedence pattern: CLI > Env > Config > Defaults
 func (r *GlobalResolver) ApplyGlobalOverrides(bundle interface{}) error {
 	// Use type assertion instead of reflection for better reliability
 	type ConfigBundle interface {
@@ -33,6 +147,13 @@ func (r *GlobalResolver) ApplyGlobalOverrides(bundle interface{}) error {
 		return fmt.Errorf("bundle does not implement ConfigBundle interface")
 	}
 
+	r.unsafe = nil
+	if err := r.applyEnv(); err != nil {
+		return err
+	}
+	if err := r.parseSets(); err != nil {
+		return err
+	}
 	configs := configBundle.GetAllConfigs()
 
 	// Apply precedence to each configuration
@@ -42,6 +163,73 @@ func (r *GlobalResolver) ApplyGlobalOverrides(bundle interface{}) error {
 		}
 	}
 
+	// Every document has every tool, so a --set matching nothing names an unknown setting
+	if len(configs) > 0 {
+		for _, set := range r.sets {
+			if !set.matched {
+				return fmt.Errorf("invalid --set %s: unknown %s setting '%s'", set.raw, set.tool, set.setting)
+			}
+		}
+	}
+
+	return nil
+}
+
+// parseSets parses the values of --set
+func (r *GlobalResolver) parseSets() error {
+	r.sets = nil
+	if r.cmd.Flags().Lookup(SetFlag) == nil {
+		return nil
+	}
+	values, err := r.cmd.Flags().GetStringArray(SetFlag)
+	if err != nil {
+		return err
+	}
+	for _, value := range values {
+		set, err := parseSetOverride(value)
+		if err != nil {
+			return err
+		}
+		r.sets = append(r.sets, set)
+	}
+	return nil
+}
+
+// inScope reports whether a flag overrides the settings of a configuration kind
+func (r *GlobalResolver) inScope(flagName, kind string) bool {
+	scoped, ok := r.cmd.Flags().Lookup(flagName).Value.(ScopedValue)
+	if !ok || len(scoped.Scope()) == 0 {
+		return true
+	}
+	for _, scopeKind := range scoped.Scope() {
+		if scopeKind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// applyEnv sets global flags not given on the command line from their environment variables
+// Setting the flag itself keeps everything reading it, like --dry-run=strict, consistent with the configurations.
+func (r *GlobalResolver) applyEnv() error {
+	if r.fromEnv != nil {
+		return nil
+	}
+	r.fromEnv = make(map[string]string)
+	for _, flagName := range globalFlags {
+		if r.cmd.Flags().Lookup(flagName) == nil || r.cmd.Flags().Changed(flagName) {
+			continue
+		}
+		envVar := EnvPrefix + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+		value, set := os.LookupEnv(envVar)
+		if !set {
+			continue
+		}
+		if err := r.cmd.Flags().Set(flagName, value); err != nil {
+			return fmt.Errorf("invalid %s=%s: %w", envVar, value, err)
+		}
+		r.fromEnv[flagName] = envVar
+	}
 	return nil
 }
 
@@ -53,6 +241,7 @@ func (r *GlobalResolver) applyToConfig(cfg interface{}) error {
 	if cfgValue.Kind() == reflect.Ptr {
 		cfgValue = cfgValue.Elem()
 	}
+	builtin := builtinTools(cfgValue)
 
 	// Look for the Tools field
 	toolsField := cfgValue.FieldByName("Tools")
@@ -62,12 +251,17 @@ func (r *GlobalResolver) applyToConfig(cfg interface{}) error {
 	}
 
 	// Apply CLI overrides to ALL tool configurations
-	toolNames := []string{"Nlabel", "Nvlan", "Ntest"}
+	toolNames := []string{"Nlabel", "Nvlan", "Ntest", "Nsysctl", "Nstorage"}
 
 	for _, toolName := range toolNames {
 		toolField := toolsField.FieldByName(toolName)
 		if toolField.IsValid() && toolField.CanSet() {
-			if err := r.applyToToolConfig(toolField); err != nil {
+			target := toolTarget{kind: configKind(cfgValue), config: configName(cfgValue), tool: strings.ToLower(toolName)}
+			var builtinTool reflect.Value
+			if builtin.IsValid() {
+				builtinTool = builtin.FieldByName(toolName)
+			}
+			if err := r.applyToTool(toolField, builtinTool, target); err != nil {
 				return fmt.Errorf("failed to apply overrides to %s: %w", toolName, err)
 			}
 		}
@@ -76,13 +270,74 @@ func (r *GlobalResolver) applyToConfig(cfg interface{}) error {
 	return nil
 }
 
+// builtinTools returns the Tools a configuration has before the bundle sets any, from its BuiltinTools method
+func builtinTools(cfgValue reflect.Value) reflect.Value {
+	method := cfgValue.MethodByName("BuiltinTools")
+	if !method.IsValid() || method.Type().NumIn() != 0 || method.Type().NumOut() != 1 {
+		return reflect.Value{}
+	}
+	tools := method.Call(nil)[0]
+	if tools.Kind() != reflect.Struct {
+		return reflect.Value{}
+	}
+	return tools
+}
+
+// toolTarget names the tool configuration overrides are applied to
+type toolTarget struct {
+	kind   string // Kind of the configuration, empty when it has none
+	config string // Kind/name of the configuration
+	tool   string // Tool key, e.g. nvlan; empty for a bare tool configuration
+}
+
+// configKind returns the Kind of a configuration, or "" when it has none
+func configKind(cfgValue reflect.Value) string {
+	if cfgValue.Kind() != reflect.Struct {
+		return ""
+	}
+	if kind := cfgValue.FieldByName("Kind"); kind.IsValid() && kind.Kind() == reflect.String {
+		return kind.String()
+	}
+	return ""
+}
+
+// configName returns Kind/name of a configuration, or its type name when it has neither
+func configName(cfgValue reflect.Value) string {
+	if cfgValue.Kind() != reflect.Struct {
+		return cfgValue.Type().String()
+	}
+	kind := cfgValue.FieldByName("Kind")
+	name := cfgValue.FieldByName("Metadata")
+	if name.IsValid() && name.Kind() == reflect.Struct {
+		name = name.FieldByName("Name")
+	}
+	if !kind.IsValid() || kind.Kind() != reflect.String || kind.String() == "" {
+		return cfgValue.Type().Name()
+	}
+	if !name.IsValid() || name.Kind() != reflect.String || name.String() == "" {
+		return kind.String()
+	}
+	return kind.String() + "/" + name.String()
+}
+
 // applyToToolConfig applies CLI overrides to tool-specific configuration
 func (r *GlobalResolver) applyToToolConfig(toolConfig reflect.Value) error {
+	return r.applyToTool(toolConfig, reflect.Value{}, toolTarget{config: toolConfig.Type().Name()})
+}
+
+// applyToTool applies CLI overrides to the tool configuration of a configuration, recording decisions when tracing
+// Global flags apply first, then --set values naming the tool, so the more specific override wins.
+// The built-in tool configuration gives the defaults of the trace; when invalid, settings default to their zero value.
+func (r *GlobalResolver) applyToTool(toolConfig, builtinTool reflect.Value, target toolTarget) error {
 	if !toolConfig.CanSet() {
 		return fmt.Errorf("tool config is not settable")
 	}
 
 	toolType := toolConfig.Type()
+	settingPrefix := "tools."
+	if target.tool != "" {
+		settingPrefix += target.tool + "."
+	}
 
 	// Iterate through tool config fields and check for CLI overrides
 	for i := 0; i < toolConfig.NumField(); i++ {
@@ -100,21 +355,149 @@ func (r *GlobalResolver) applyToToolConfig(toolConfig reflect.Value) error {
 			flagName = "dry-run"
 		case "LogLevel":
 			flagName = "log-level"
-		default:
-			continue // Skip unknown fields
+		case "NodeNamePattern":
+			flagName = "node-name-pattern"
 		}
 
-		// Check if CLI flag was explicitly set
-		if r.cmd.Flags().Changed(flagName) {
+		var sets []*setOverride
+		for _, set := range r.sets {
+			if set.tool == target.tool && set.setting == yamlName(fieldType) {
+				sets = append(sets, set)
+			}
+		}
+		if flagName == "" && len(sets) == 0 {
+			continue // Skip fields no override names
+		}
+
+		decision := Decision{Config: target.config, Setting: settingPrefix + yamlName(fieldType)}
+		defaultValue := reflect.Zero(field.Type())
+		if builtinTool.IsValid() {
+			defaultValue = builtinTool.Field(i)
+		}
+		if r.trace {
+			decision.Steps = configSteps(field, defaultValue)
+		}
+		// A safety setting the config file turns on, unlike its default, is a deliberate choice
+		safetyOn := safetySettings[fieldType.Name] && field.Bool() && !defaultValue.Bool()
+
+		// Check if CLI flag was explicitly set, and meant for this kind of configuration
+		if flagName != "" && r.cmd.Flags().Changed(flagName) && r.inScope(flagName, target.kind) {
 			if err := r.setFieldFromFlag(field, flagName); err != nil {
 				return fmt.Errorf("failed to set %s from flag: %w", fieldType.Name, err)
 			}
+			step := Step{Source: SourceFlag, Name: "--" + flagName, Value: formatValue(field)}
+			if envVar := r.fromEnv[flagName]; envVar != "" {
+				step = Step{Source: SourceEnv, Name: envVar, Value: formatValue(field)}
+			}
+			decision.Steps = append(decision.Steps, step)
+		}
+
+		for _, set := range sets {
+			set.matched = true
+			if err := setFieldFromString(field, set.value); err != nil {
+				return fmt.Errorf("invalid --set %s: %w", set.raw, err)
+			}
+			decision.Steps = append(decision.Steps, Step{Source: SourceFlag, Name: "--" + SetFlag, Value: formatValue(field)})
+		}
+
+		if safetyOn && !field.Bool() {
+			if err := r.checkUnsafe(decision); err != nil {
+				return err
+			}
+		}
+
+		if r.trace {
+			r.decisions = append(r.decisions, decision)
+		}
+	}
+
+	return nil
+}
+
+// checkUnsafe refuses an override turning off a safety setting of the config file unless --allow-unsafe-override is set
+func (r *GlobalResolver) checkUnsafe(decision Decision) error {
+	override := decision.Steps[len(decision.Steps)-1]
+	message := fmt.Sprintf("%s %s is true in the config file, %s %s turns it off",
+		decision.Config, decision.Setting, override.Source, override.Name)
+
+	allowed := false
+	if r.cmd.Flags().Lookup(AllowUnsafeFlag) != nil {
+		allowed, _ = r.cmd.Flags().GetBool(AllowUnsafeFlag)
+	}
+	if !allowed {
+		return fmt.Errorf("%s; pass --%s to confirm", message, AllowUnsafeFlag)
+	}
+	r.unsafe = append(r.unsafe, message)
+	return nil
+}
+
+// setFieldFromString sets a field from the text of a --set value
+func setFieldFromString(field reflect.Value, value string) error {
+	if field.Type() == reflect.TypeOf(time.Duration(0)) {
+		duration, err := time.ParseDuration(value)
+		if err != nil {
+			return err
 		}
+		field.SetInt(int64(duration))
+		return nil
 	}
 
+	switch field.Kind() {
+	case reflect.Bool:
+		val, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(val)
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Int:
+		val, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(val))
+	case reflect.Slice:
+		if field.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported field type: %s", field.Type())
+		}
+		var items []string
+		if value != "" {
+			items = strings.Split(value, ",")
+		}
+		field.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("the setting is a %s and cannot be set from the command line", field.Kind())
+	}
 	return nil
 }
 
+// configSteps returns the default of a setting, followed by the configuration's value when that differs
+// The Defaults document counts as part of the config file.
+func configSteps(field, defaultValue reflect.Value) []Step {
+	steps := []Step{{Source: SourceDefault, Value: formatValue(defaultValue)}}
+	if !field.Equal(defaultValue) {
+		steps = append(steps, Step{Source: SourceConfig, Value: formatValue(field)})
+	}
+	return steps
+}
+
+// formatValue renders a setting value, quoting strings so an empty one stays visible
+func formatValue(field reflect.Value) string {
+	if field.Kind() == reflect.String {
+		return strconv.Quote(field.String())
+	}
+	return fmt.Sprintf("%v", field.Interface())
+}
+
+// yamlName returns the configuration key of a struct field
+func yamlName(field reflect.StructField) string {
+	if name, _, _ := strings.Cut(field.Tag.Get("yaml"), ","); name != "" {
+		return name
+	}
+	return field.Name
+}
+
 // setFieldFromFlag sets a field value from the corresponding CLI flag
 func (r *GlobalResolver) setFieldFromFlag(field reflect.Value, flagName string) error {
 	switch field.Kind() {
@@ -150,10 +533,8 @@ func (r *GlobalResolver) setFieldFromFlag(field reflect.Value, flagName string)
 func (r *GlobalResolver) GetAppliedOverrides() map[string]interface{} {
 	overrides := make(map[string]interface{})
 
-	// Check which flags were explicitly set
-	flagNames := []string{"dry-run", "log-level"}
-
-	for _, flagName := range flagNames {
+	// Check which flags were explicitly set, on the command line or through the environment
+	for _, flagName := range append(globalFlags, SetFlag) {
 		if r.cmd.Flags().Changed(flagName) {
 			// Get the value based on flag type
 			if flag := r.cmd.Flags().Lookup(flagName); flag != nil {
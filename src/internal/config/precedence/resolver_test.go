@@ -3,13 +3,16 @@
 package precedence
 
 import (
+	"fmt"
 	"reflect"
 	"testing"
+	"time"
 
 	"k8ostack-ictl/internal/config"
 
 	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // TestGlobalResolver_ApplyGlobalOverrides tests CLI precedence resolution
@@ -395,3 +398,299 @@ func TestGlobalResolver_EdgeCases(t *testing.T) {
 		assert.NoError(t, err)
 	})
 }
+
+// TestGlobalResolver_Trace tests recording where each tool setting got its value
+// WHY: --explain-config must name the source that won, including environment variables nobody remembers setting
+func TestGlobalResolver_Trace(t *testing.T) {
+	tests := []struct {
+		name         string
+		env          map[string]string
+		flags        map[string]string
+		configDry    bool
+		expected     string
+		expectDryRun bool
+		expectError  string
+	}{
+		{
+			name:     "default_only",
+			expected: "NodeVLANConf/vlans tools.nvlan.dryRun = false (default false)",
+		},
+		{
+			name:         "config_file",
+			configDry:    true,
+			expected:     "NodeVLANConf/vlans tools.nvlan.dryRun = true (default false → config file true)",
+			expectDryRun: true,
+		},
+		{
+			name:         "env_over_config",
+			env:          map[string]string{"KICTL_DRY_RUN": "true"},
+			expected:     "NodeVLANConf/vlans tools.nvlan.dryRun = true (default false → env KICTL_DRY_RUN true)",
+			expectDryRun: true,
+		},
+		{
+			name:      "flag_over_env",
+			env:       map[string]string{"KICTL_DRY_RUN": "true"},
+			flags:     map[string]string{"dry-run": "false", AllowUnsafeFlag: "true"},
+			configDry: true,
+			expected:  "NodeVLANConf/vlans tools.nvlan.dryRun = false (default false → config file true → flag --dry-run false)",
+		},
+		{
+			name:        "invalid_env",
+			env:         map[string]string{"KICTL_DRY_RUN": "maybe"},
+			expectError: "invalid KICTL_DRY_RUN=maybe",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A VLAN configuration, the global flags and the environment
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			cmd := &cobra.Command{}
+			cmd.Flags().Bool("dry-run", false, "Enable dry-run mode")
+			cmd.Flags().String("log-level", "info", "Set log level")
+			cmd.Flags().Bool(AllowUnsafeFlag, false, "Allow turning off safety settings")
+			for name, value := range tt.flags {
+				require.NoError(t, cmd.Flags().Set(name, value))
+			}
+			bundle := &config.ConfigBundle{VLANs: &config.NodeVLANConf{
+				Kind:     "NodeVLANConf",
+				Metadata: config.Metadata{Name: "vlans"},
+				Tools:    config.Tools{Nvlan: config.ToolConfig{DryRun: tt.configDry, LogLevel: "debug"}},
+			}}
+			resolver := NewGlobalResolver(cmd)
+			resolver.SetTrace(true)
+
+			// When: Apply the overrides
+			err := resolver.ApplyGlobalOverrides(bundle)
+
+			// Then: The trace explains the dry-run setting of the tool
+			if tt.expectError != "" {
+				assert.ErrorContains(t, err, tt.expectError)
+				return
+			}
+			require.NoError(t, err)
+			var lines []string
+			for _, decision := range resolver.Trace() {
+				lines = append(lines, decision.String())
+			}
+			assert.Contains(t, lines, tt.expected)
+			assert.Contains(t, lines, `NodeVLANConf/vlans tools.nvlan.logLevel = "debug" (default "" → config file "debug")`)
+			assert.Equal(t, tt.expectDryRun, bundle.VLANs.Tools.Nvlan.DryRun)
+		})
+	}
+}
+
+// scopedBool is a bool flag value scoped to some configuration kinds, like --dry-run=vlans
+type scopedBool struct {
+	value bool
+	kinds []string
+}
+
+func (b *scopedBool) Set(value string) error { b.value = value == "true"; return nil }
+func (b *scopedBool) String() string         { return fmt.Sprint(b.value) }
+func (b *scopedBool) Type() string           { return "bool" }
+func (b *scopedBool) Scope() []string        { return b.kinds }
+
+// TestGlobalResolver_ScopedOverrides tests overrides limited to some kinds and tools
+// WHY: A run mixing risky VLAN changes with safe label changes must be able to simulate or relax one kind only
+func TestGlobalResolver_ScopedOverrides(t *testing.T) {
+	tests := []struct {
+		name             string
+		dryRunKinds      []string
+		sets             []string
+		expectLabelDry   bool
+		expectVLANDry    bool
+		expectSysctlDry  bool
+		expectStorageDry bool
+		expectValidate   bool
+		expectTimeout    time.Duration
+		expectError      string
+	}{
+		{
+			name:             "unscoped_dry_run",
+			expectLabelDry:   true,
+			expectVLANDry:    true,
+			expectSysctlDry:  true,
+			expectStorageDry: true,
+			expectValidate:   true,
+		},
+		{
+			name:           "dry_run_scoped_to_vlans",
+			dryRunKinds:    []string{"NodeVLANConf"},
+			expectVLANDry:  true,
+			expectValidate: true,
+		},
+		{
+			name:          "set_one_tool",
+			dryRunKinds:   []string{"NodeVLANConf"},
+			sets:          []string{"nlabel.validateNodes=false", "nvlan.serviceTimeout=90s"},
+			expectVLANDry: true,
+			expectTimeout: 90 * time.Second,
+		},
+		{
+			name:             "set_wins_over_global_flag",
+			sets:             []string{"nvlan.dryRun=false"},
+			expectLabelDry:   true,
+			expectSysctlDry:  true,
+			expectStorageDry: true,
+			expectValidate:   true,
+		},
+		{
+			name:             "set_sysctl_tool",
+			sets:             []string{"nsysctl.dryRun=false"},
+			expectLabelDry:   true,
+			expectVLANDry:    true,
+			expectStorageDry: true,
+			expectValidate:   true,
+		},
+		{
+			name:            "set_storage_tool",
+			sets:            []string{"nstorage.dryRun=false"},
+			expectLabelDry:  true,
+			expectVLANDry:   true,
+			expectSysctlDry: true,
+			expectValidate:  true,
+		},
+		{
+			name:        "unknown_tool",
+			sets:        []string{"nfoo.dryRun=true"},
+			expectError: "unknown tool 'nfoo'",
+		},
+		{
+			name:        "unknown_setting",
+			sets:        []string{"nlabel.validate=false"},
+			expectError: "unknown nlabel setting 'validate'",
+		},
+		{
+			name:        "missing_value",
+			sets:        []string{"nlabel.validateNodes"},
+			expectError: "expected <tool>.<setting>=<value>",
+		},
+		{
+			name:        "invalid_value",
+			sets:        []string{"nlabel.validateNodes=maybe"},
+			expectError: "invalid --set nlabel.validateNodes=maybe",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A label, a VLAN, a sysctl and a storage configuration, --dry-run scoped to some kinds and --set values
+			cmd := &cobra.Command{}
+			cmd.Flags().Var(&scopedBool{kinds: tt.dryRunKinds}, "dry-run", "Enable dry-run mode")
+			cmd.Flags().StringArray(SetFlag, nil, "Override one tool setting")
+			require.NoError(t, cmd.Flags().Set("dry-run", "true"))
+			for _, set := range tt.sets {
+				require.NoError(t, cmd.Flags().Set(SetFlag, set))
+			}
+			bundle := &config.ConfigBundle{
+				NodeLabels: &config.NodeLabelConf{Kind: "NodeLabelConf", Tools: config.Tools{Nlabel: config.ToolConfig{ValidateNodes: true}}},
+				VLANs:      &config.NodeVLANConf{Kind: "NodeVLANConf"},
+				Sysctls:    &config.NodeSysctlConf{Kind: "NodeSysctlConf"},
+				Storage:    &config.NodeStorageConf{Kind: "NodeStorageConf"},
+			}
+
+			// When: Apply the overrides
+			err := NewGlobalResolver(cmd).ApplyGlobalOverrides(bundle)
+
+			// Then: Only the configurations in scope and the named tools are overridden
+			if tt.expectError != "" {
+				assert.ErrorContains(t, err, tt.expectError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectLabelDry, bundle.NodeLabels.Tools.Nlabel.DryRun)
+			assert.Equal(t, tt.expectVLANDry, bundle.VLANs.Tools.Nvlan.DryRun)
+			assert.Equal(t, tt.expectSysctlDry, bundle.Sysctls.Tools.Nsysctl.DryRun)
+			assert.Equal(t, tt.expectStorageDry, bundle.Storage.Tools.Nstorage.DryRun)
+			assert.Equal(t, tt.expectValidate, bundle.NodeLabels.Tools.Nlabel.ValidateNodes)
+			assert.Equal(t, tt.expectTimeout, bundle.VLANs.Tools.Nvlan.ServiceTimeout)
+		})
+	}
+}
+
+// TestGlobalResolver_UnsafeOverride tests overrides turning off a safety setting the config file turns on
+// WHY: dryRun: true in a config file is a deliberate safety a stray flag or variable must not silently undo
+func TestGlobalResolver_UnsafeOverride(t *testing.T) {
+	tests := []struct {
+		name         string
+		env          map[string]string
+		flags        map[string]string
+		configDry    bool
+		expectDryRun bool
+		expectUnsafe []string
+		expectError  string
+	}{
+		{
+			name:        "flag_turns_off_config_dry_run",
+			flags:       map[string]string{"dry-run": "false"},
+			configDry:   true,
+			expectError: "NodeVLANConf/vlans tools.nvlan.dryRun is true in the config file, flag --dry-run turns it off; pass --allow-unsafe-override to confirm",
+		},
+		{
+			name:        "env_turns_off_config_dry_run",
+			env:         map[string]string{"KICTL_DRY_RUN": "false"},
+			configDry:   true,
+			expectError: "env KICTL_DRY_RUN turns it off",
+		},
+		{
+			name:        "set_turns_off_validation",
+			flags:       map[string]string{SetFlag: "ntest.validateConnectivity=false"},
+			expectError: "tools.ntest.validateConnectivity is true in the config file, flag --set turns it off",
+		},
+		{
+			name:  "set_turns_off_builtin_validation",
+			flags: map[string]string{SetFlag: "nvlan.validateConnectivity=false"},
+		},
+		{
+			name:         "acknowledged",
+			flags:        map[string]string{"dry-run": "false", AllowUnsafeFlag: "true"},
+			configDry:    true,
+			expectUnsafe: []string{"NodeVLANConf/vlans tools.nvlan.dryRun is true in the config file, flag --dry-run turns it off"},
+		},
+		{
+			name:         "turning_on_is_frictionless",
+			flags:        map[string]string{"dry-run": "true"},
+			expectDryRun: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A VLAN configuration validating connectivity of its tests, and overrides from flags and the environment
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			cmd := &cobra.Command{}
+			cmd.Flags().Bool("dry-run", false, "Enable dry-run mode")
+			cmd.Flags().StringArray(SetFlag, nil, "Override one tool setting")
+			cmd.Flags().Bool(AllowUnsafeFlag, false, "Allow turning off safety settings")
+			for name, value := range tt.flags {
+				require.NoError(t, cmd.Flags().Set(name, value))
+			}
+			bundle := &config.ConfigBundle{VLANs: &config.NodeVLANConf{
+				Kind:     "NodeVLANConf",
+				Metadata: config.Metadata{Name: "vlans"},
+				Tools: config.Tools{
+					Nvlan: config.ToolConfig{DryRun: tt.configDry, ValidateConnectivity: true},
+					Ntest: config.ToolConfig{ValidateConnectivity: true},
+				},
+			}}
+			resolver := NewGlobalResolver(cmd)
+
+			// When: Apply the overrides
+			err := resolver.ApplyGlobalOverrides(bundle)
+
+			// Then: Turning a safety off needs an acknowledgment, turning it on does not
+			if tt.expectError != "" {
+				assert.ErrorContains(t, err, tt.expectError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectDryRun, bundle.VLANs.Tools.Nvlan.DryRun)
+			assert.Equal(t, tt.expectUnsafe, resolver.UnsafeOverrides())
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package precedence
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// SourceSettings is the user settings file, which fills in global flags below the environment and the command line
+const SourceSettings = "settings file"
+
+// ApplySettings sets each flag that neither the command line nor its KICTL_* variable sets to its value from the user settings
+// It returns the names of the flags it set, sorted; a value naming an unknown flag or not parsing fails.
+func ApplySettings(cmd *cobra.Command, values map[string]string) ([]string, error) {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var applied []string
+	for _, name := range names {
+		flag := cmd.Flags().Lookup(name)
+		if flag == nil {
+			return applied, fmt.Errorf("%s sets unknown flag --%s", SourceSettings, name)
+		}
+		if flag.Changed {
+			continue
+		}
+		if _, set := os.LookupEnv(EnvPrefix + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))); set {
+			continue
+		}
+		if err := cmd.Flags().Set(name, values[name]); err != nil {
+			return applied, fmt.Errorf("invalid --%s %s in the %s: %w", name, values[name], SourceSettings, err)
+		}
+		// Set marks the flag as given on the command line; later precedence steps must still see it as a default
+		flag.Changed = false
+		applied = append(applied, name)
+	}
+	return applied, nil
+}
@@ -0,0 +1,91 @@
+// Package precedence provides unit tests for flag defaults from the user settings file
+// WHY: The settings file must fill in what operators leave out, never override what they typed or exported
+package precedence
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestApplySettings tests the precedence of the settings file below the environment and the command line
+// WHY: CLI > env > settings file > built-in default, like the tool settings of the configuration
+func TestApplySettings(t *testing.T) {
+	tests := []struct {
+		name            string
+		args            []string
+		env             map[string]string
+		values          map[string]string
+		expectedContext string
+		expectedWorkers int
+		expectedApplied []string
+		expectError     string
+	}{
+		{
+			name:            "fills_in_unset_flags",
+			values:          map[string]string{"context": "lab", "max-parallelism": "8"},
+			expectedContext: "lab",
+			expectedWorkers: 8,
+			expectedApplied: []string{"context", "max-parallelism"},
+		},
+		{
+			name:            "command_line_wins",
+			args:            []string{"--context", "prod"},
+			values:          map[string]string{"context": "lab", "max-parallelism": "8"},
+			expectedContext: "prod",
+			expectedWorkers: 8,
+			expectedApplied: []string{"max-parallelism"},
+		},
+		{
+			name:            "environment_wins",
+			env:             map[string]string{"KICTL_MAX_PARALLELISM": "2"},
+			values:          map[string]string{"context": "lab", "max-parallelism": "8"},
+			expectedContext: "lab",
+			expectedWorkers: 1,
+			expectedApplied: []string{"context"},
+		},
+		{
+			name:        "unknown_flag",
+			values:      map[string]string{"colour": "never"},
+			expectError: "settings file sets unknown flag --colour",
+		},
+		{
+			name:        "invalid_value",
+			values:      map[string]string{"max-parallelism": "many"},
+			expectError: "invalid --max-parallelism many in the settings file",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A command with global flags, parsed from the arguments
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			var kubeContext string
+			var workers int
+			cmd := &cobra.Command{}
+			cmd.Flags().StringVar(&kubeContext, "context", "", "")
+			cmd.Flags().IntVar(&workers, "max-parallelism", 1, "")
+			require.NoError(t, cmd.Flags().Parse(tt.args))
+
+			// When: Apply the settings
+			applied, err := ApplySettings(cmd, tt.values)
+
+			// Then: Only flags set by neither the command line nor the environment take the settings
+			if tt.expectError != "" {
+				assert.ErrorContains(t, err, tt.expectError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedContext, kubeContext)
+			assert.Equal(t, tt.expectedWorkers, workers)
+			assert.Equal(t, tt.expectedApplied, applied)
+			for _, name := range applied {
+				assert.False(t, cmd.Flags().Changed(name), "%s is not a command line flag", name)
+			}
+		})
+	}
+}
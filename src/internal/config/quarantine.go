@@ -0,0 +1,38 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// QuarantinePolicy takes nodes that keep failing the same operation out of the bundle's runs
+// Runs count the failures in the run database; a quarantined node stays out until 'kictl quarantine release'.
+type QuarantinePolicy struct {
+	// After is how many runs of an operation in a row a service must fail on a node before it is quarantined
+	After int `json:"after" yaml:"after"`
+
+	// Webhook receives a JSON notice with the collected diagnostics of every quarantined node
+	Webhook string `json:"webhook,omitempty" yaml:"webhook,omitempty"`
+}
+
+// Enabled reports whether runs quarantine failing nodes
+func (p *QuarantinePolicy) Enabled() bool {
+	return p != nil && p.After > 0
+}
+
+// validate checks the failure count and that the webhook is an HTTP URL
+func (p *QuarantinePolicy) validate() error {
+	if p == nil {
+		return nil
+	}
+	if p.After < 1 {
+		return fmt.Errorf("spec.quarantine.after must be at least 1, got %d", p.After)
+	}
+	if p.Webhook != "" {
+		webhook, err := url.Parse(p.Webhook)
+		if err != nil || (webhook.Scheme != "http" && webhook.Scheme != "https") || webhook.Host == "" {
+			return fmt.Errorf("spec.quarantine.webhook must be an http or https URL, got '%s'", p.Webhook)
+		}
+	}
+	return nil
+}
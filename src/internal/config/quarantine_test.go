@@ -0,0 +1,61 @@
+// Package config provides unit tests for the quarantine policy of the Defaults document
+// WHY: A typo in the policy must fail loading instead of quietly never quarantining a failing node
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLoadMultipleConfigs_Quarantine tests loading the quarantine policy
+// WHY: Runs only count failures and call the webhook when the policy asks for it
+func TestLoadMultipleConfigs_Quarantine(t *testing.T) {
+	tests := []struct {
+		name        string
+		quarantine  string
+		expected    QuarantinePolicy
+		expectError string
+	}{
+		{name: "after_failures", quarantine: "    after: 3\n", expected: QuarantinePolicy{After: 3}},
+		{
+			name:       "with_webhook",
+			quarantine: "    after: 2\n    webhook: https://alerts.lab/kictl\n",
+			expected:   QuarantinePolicy{After: 2, Webhook: "https://alerts.lab/kictl"},
+		},
+		{name: "after_missing", quarantine: "    webhook: https://alerts.lab/kictl\n", expectError: "spec.quarantine.after must be at least 1, got 0"},
+		{name: "webhook_not_http", quarantine: "    after: 3\n    webhook: alerts.lab/kictl\n", expectError: "spec.quarantine.webhook must be an http or https URL, got 'alerts.lab/kictl'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A bundle whose Defaults document has a quarantine policy
+			data := []byte(`apiVersion: openstack.kictl.icycloud.io/v1
+kind: Defaults
+metadata:
+  name: defaults
+spec:
+  quarantine:
+` + tt.quarantine + `---
+` + nodeSetsTestLabels("[rsb1]"))
+
+			// When: Load the bundle
+			bundle, err := LoadConfigData(data, "quarantine.yaml")
+
+			// Then: The policy is loaded or rejected
+			if tt.expectError != "" {
+				assert.ErrorContains(t, err, tt.expectError)
+				return
+			}
+			require.NoError(t, err)
+			policy := bundle.GetDefaults().Spec.Quarantine
+			require.NotNil(t, policy)
+			assert.Equal(t, tt.expected, *policy)
+			assert.True(t, policy.Enabled())
+		})
+	}
+
+	var unset *QuarantinePolicy
+	assert.False(t, unset.Enabled())
+}
@@ -0,0 +1,182 @@
+// Package config reads configuration bundles from remote sources
+package config
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// ConfigTokenEnv holds a bearer token sent when downloading configs over HTTPS or git
+	ConfigTokenEnv = "KICTL_CONFIG_TOKEN"
+	// ConfigUsernameEnv holds the basic auth user for HTTPS configs when no token is set
+	ConfigUsernameEnv = "KICTL_CONFIG_USERNAME"
+	// ConfigPasswordEnv holds the basic auth password for HTTPS configs
+	ConfigPasswordEnv = "KICTL_CONFIG_PASSWORD"
+
+	maxRemoteConfigSize = 10 << 20
+	remoteConfigTimeout = 60 * time.Second
+)
+
+// commandRunner runs a local command with extra environment and returns its standard output; tests replace it
+var commandRunner = func(ctx context.Context, env []string, name string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Env = append(os.Environ(), env...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return nil, fmt.Errorf("%s: %w: %s", name, err, message)
+		}
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return output, nil
+}
+
+// IsRemoteSource reports whether a config path is a URL or git source rather than a local file
+func IsRemoteSource(path string) bool {
+	return strings.HasPrefix(path, "git::") || strings.Contains(path, "://")
+}
+
+// readConfigSource reads a config from a local path, an http(s):// or s3:// URL, or a git:: source
+func readConfigSource(source string) ([]byte, error) {
+	if !IsRemoteSource(source) {
+		data, err := os.ReadFile(source)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config file %s: %w", source, err)
+		}
+		return data, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), remoteConfigTimeout)
+	defer cancel()
+
+	var data []byte
+	var err error
+	switch {
+	case strings.HasPrefix(source, "git::"):
+		data, err = readGitSource(ctx, source)
+	case strings.HasPrefix(source, "https://"), strings.HasPrefix(source, "http://"):
+		data, err = readHTTPSource(ctx, source)
+	case strings.HasPrefix(source, "s3://"):
+		data, err = readS3Source(ctx, source)
+	default:
+		return nil, fmt.Errorf("unsupported config source %s: expected a local path, https://, s3:// or git::", source)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to download config %s: %w", source, err)
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, fmt.Errorf("downloaded config %s is empty", source)
+	}
+	return data, nil
+}
+
+// readHTTPSource downloads a config over HTTP(S), authenticating with KICTL_CONFIG_TOKEN or KICTL_CONFIG_USERNAME/PASSWORD
+func readHTTPSource(ctx context.Context, source string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+	if err != nil {
+		return nil, err
+	}
+	if token := os.Getenv(ConfigTokenEnv); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	} else if username := os.Getenv(ConfigUsernameEnv); username != "" {
+		req.SetBasicAuth(username, os.Getenv(ConfigPasswordEnv))
+	}
+	if req.Header.Get("Authorization") != "" && req.URL.Scheme != "https" {
+		return nil, fmt.Errorf("refusing to send credentials over plain http, use https")
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("server answered %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxRemoteConfigSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxRemoteConfigSize {
+		return nil, fmt.Errorf("config is larger than %d bytes", maxRemoteConfigSize)
+	}
+	return data, nil
+}
+
+// readS3Source downloads a config from S3 with the aws CLI, which takes its credentials from the usual AWS_* variables
+func readS3Source(ctx context.Context, source string) ([]byte, error) {
+	return commandRunner(ctx, nil, "aws", "s3", "cp", "--only-show-errors", source, "-")
+}
+
+// readGitSource reads a file from a shallow clone of git::<repository>//<path>[?ref=<branch or tag>]
+// KICTL_CONFIG_TOKEN is sent as a bearer token through the environment, so it never shows in the process list.
+func readGitSource(ctx context.Context, source string) ([]byte, error) {
+	repository, path, ref, err := parseGitSource(source)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := os.MkdirTemp("", "kictl-config-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	env := []string{"GIT_TERMINAL_PROMPT=0"}
+	if token := os.Getenv(ConfigTokenEnv); token != "" {
+		env = append(env, "GIT_CONFIG_COUNT=1", "GIT_CONFIG_KEY_0=http.extraHeader", "GIT_CONFIG_VALUE_0=Authorization: Bearer "+token)
+	}
+	args := []string{"clone", "--quiet", "--depth", "1"}
+	if ref != "" {
+		args = append(args, "--branch", ref)
+	}
+	args = append(args, repository, dir)
+	if _, err := commandRunner(ctx, env, "git", args...); err != nil {
+		return nil, err
+	}
+
+	return os.ReadFile(filepath.Join(dir, path))
+}
+
+// parseGitSource splits git::<repository>//<path>[?ref=<ref>] into its repository, file path and ref
+func parseGitSource(source string) (string, string, string, error) {
+	rest := strings.TrimPrefix(source, "git::")
+
+	ref := ""
+	if i := strings.LastIndex(rest, "?"); i >= 0 {
+		query, err := url.ParseQuery(rest[i+1:])
+		if err != nil {
+			return "", "", "", fmt.Errorf("invalid query in %s: %w", source, err)
+		}
+		ref = query.Get("ref")
+		rest = rest[:i]
+	}
+
+	// The file path follows the first // after the repository's scheme
+	start := 0
+	if i := strings.Index(rest, "://"); i >= 0 {
+		start = i + len("://")
+	}
+	i := strings.Index(rest[start:], "//")
+	if i < 0 {
+		return "", "", "", fmt.Errorf("%s names no file: use git::<repository>//<path>[?ref=<branch or tag>]", source)
+	}
+	repository, path := rest[:start+i], rest[start+i+2:]
+	if repository == "" || !filepath.IsLocal(path) {
+		return "", "", "", fmt.Errorf("%s names no file inside its repository: use git::<repository>//<path>[?ref=<branch or tag>]", source)
+	}
+	return repository, path, ref, nil
+}
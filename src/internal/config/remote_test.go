@@ -0,0 +1,214 @@
+// Package config provides unit tests for loading configs from remote sources
+// WHY: Bundles kept in an artifact store must load exactly like local files, and credentials must only go where intended
+package config
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const remoteTestConfig = `apiVersion: openstack.kictl.icycloud.io/v1
+kind: NodeLabelConf
+metadata:
+  name: remote
+spec:
+  nodeRoles:
+    worker:
+      nodes: ["rsb7"]
+      labels:
+        role: "worker"
+`
+
+// stubCommandRunner replaces the command runner for the duration of a test
+func stubCommandRunner(t *testing.T, run func(ctx context.Context, env []string, name string, args ...string) ([]byte, error)) {
+	original := commandRunner
+	commandRunner = run
+	t.Cleanup(func() { commandRunner = original })
+}
+
+// TestLoadMultipleConfigs_HTTPSource tests downloading a bundle over HTTP
+// WHY: Tokens must reach the artifact store, and failed downloads must not be mistaken for configs
+func TestLoadMultipleConfigs_HTTPSource(t *testing.T) {
+	tests := []struct {
+		name          string
+		token         string
+		status        int
+		body          string
+		expectedError string
+	}{
+		{
+			name:   "downloads_bundle",
+			status: http.StatusOK,
+			body:   remoteTestConfig,
+		},
+		{
+			name:          "token_over_plain_http_is_refused",
+			token:         "s3cr3t",
+			status:        http.StatusOK,
+			body:          remoteTestConfig,
+			expectedError: "refusing to send credentials over plain http, use https",
+		},
+		{
+			name:          "server_error",
+			status:        http.StatusNotFound,
+			expectedError: "server answered 404 Not Found",
+		},
+		{
+			name:          "empty_download",
+			status:        http.StatusOK,
+			body:          "\n",
+			expectedError: "is empty",
+		},
+		{
+			name:          "invalid_bundle_is_rejected",
+			status:        http.StatusOK,
+			body:          "kind: NodeLabelConf\nspec: [",
+			expectedError: "failed to parse config",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A server holding the bundle
+			t.Setenv(ConfigTokenEnv, tt.token)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			// When: Load the bundle from its URL
+			bundle, err := LoadMultipleConfigs(server.URL + "/bundles/cluster.yaml")
+
+			// Then: The bundle loads, or the download fails before anything is processed
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			require.True(t, bundle.HasNodeLabels())
+			assert.Equal(t, "remote", bundle.NodeLabels.Metadata.Name)
+		})
+	}
+}
+
+// TestReadHTTPSource_Token tests that the token is sent over HTTPS
+// WHY: Private artifact stores reject anonymous downloads
+func TestReadHTTPSource_Token(t *testing.T) {
+	// Given: An HTTPS server expecting a bearer token
+	t.Setenv(ConfigTokenEnv, "s3cr3t")
+	var authorization string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		_, _ = w.Write([]byte(remoteTestConfig))
+	}))
+	defer server.Close()
+	original := http.DefaultClient
+	http.DefaultClient = server.Client()
+	defer func() { http.DefaultClient = original }()
+
+	// When: Download the config
+	data, err := readHTTPSource(context.Background(), server.URL+"/cluster.yaml")
+
+	// Then: The token was sent
+	require.NoError(t, err)
+	assert.Equal(t, remoteTestConfig, string(data))
+	assert.Equal(t, "Bearer s3cr3t", authorization)
+}
+
+// TestLoadMultipleConfigs_S3Source tests downloading a bundle with the aws CLI
+// WHY: The aws CLI owns S3 credentials, so kictl only has to stream the object
+func TestLoadMultipleConfigs_S3Source(t *testing.T) {
+	// Given: An aws CLI printing the object
+	var calls [][]string
+	stubCommandRunner(t, func(ctx context.Context, env []string, name string, args ...string) ([]byte, error) {
+		calls = append(calls, append([]string{name}, args...))
+		return []byte(remoteTestConfig), nil
+	})
+
+	// When: Load the bundle from S3
+	bundle, err := LoadMultipleConfigs("s3://kictl-bundles/prod/cluster.yaml")
+
+	// Then: The object was streamed to kictl
+	require.NoError(t, err)
+	assert.True(t, bundle.HasNodeLabels())
+	assert.Equal(t, [][]string{{"aws", "s3", "cp", "--only-show-errors", "s3://kictl-bundles/prod/cluster.yaml", "-"}}, calls)
+}
+
+// TestLoadMultipleConfigs_GitSource tests reading a bundle from a shallow clone
+// WHY: The ref and file path must be honoured, and the token must stay out of the command line
+func TestLoadMultipleConfigs_GitSource(t *testing.T) {
+	tests := []struct {
+		name          string
+		source        string
+		cloneErr      error
+		expectedArgs  []string
+		expectedError string
+	}{
+		{
+			name:         "file_at_ref",
+			source:       "git::https://git.example.com/infra/bundles.git//prod/cluster.yaml?ref=v1.2.0",
+			expectedArgs: []string{"clone", "--quiet", "--depth", "1", "--branch", "v1.2.0", "https://git.example.com/infra/bundles.git"},
+		},
+		{
+			name:         "default_branch_over_ssh",
+			source:       "git::git@git.example.com:infra/bundles.git//prod/cluster.yaml",
+			expectedArgs: []string{"clone", "--quiet", "--depth", "1", "git@git.example.com:infra/bundles.git"},
+		},
+		{
+			name:          "no_file_path",
+			source:        "git::https://git.example.com/infra/bundles.git",
+			expectedError: "names no file",
+		},
+		{
+			name:          "path_escaping_the_repository",
+			source:        "git::https://git.example.com/infra/bundles.git//../etc/passwd",
+			expectedError: "names no file inside its repository",
+		},
+		{
+			name:          "clone_fails",
+			source:        "git::https://git.example.com/infra/bundles.git//prod/cluster.yaml",
+			cloneErr:      errors.New("git: exit status 128: repository not found"),
+			expectedError: "repository not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A git that clones a repository holding prod/cluster.yaml
+			t.Setenv(ConfigTokenEnv, "s3cr3t")
+			var args, env []string
+			stubCommandRunner(t, func(ctx context.Context, cloneEnv []string, name string, cloneArgs ...string) ([]byte, error) {
+				args, env = cloneArgs, cloneEnv
+				if tt.cloneErr != nil {
+					return nil, tt.cloneErr
+				}
+				dir := cloneArgs[len(cloneArgs)-1]
+				require.NoError(t, os.MkdirAll(filepath.Join(dir, "prod"), 0755))
+				return nil, os.WriteFile(filepath.Join(dir, "prod", "cluster.yaml"), []byte(remoteTestConfig), 0644)
+			})
+
+			// When: Load the bundle from git
+			bundle, err := LoadMultipleConfigs(tt.source)
+
+			// Then: The file is read from a shallow clone of the ref
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, bundle.HasNodeLabels())
+			assert.Equal(t, tt.expectedArgs, args[:len(args)-1])
+			assert.NotContains(t, args, "s3cr3t")
+			assert.Contains(t, env, "GIT_CONFIG_VALUE_0=Authorization: Bearer s3cr3t")
+		})
+	}
+}
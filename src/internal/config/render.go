@@ -0,0 +1,242 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LoadValues reads values files, local or remote like --config, merging later files over earlier ones
+// Nested mappings are merged key by key; any other value of a later file replaces the earlier one.
+func LoadValues(paths []string) (map[string]interface{}, error) {
+	values := make(map[string]interface{})
+	for _, path := range paths {
+		data, err := readConfigSource(path)
+		if err != nil {
+			return nil, err
+		}
+		var file map[string]interface{}
+		if err := yaml.Unmarshal(data, &file); err != nil {
+			return nil, fmt.Errorf("failed to parse values file %s: %w", path, err)
+		}
+		mergeValues(values, file)
+	}
+	return values, nil
+}
+
+// mergeValues merges src into dst, recursing into mappings both have
+func mergeValues(dst, src map[string]interface{}) {
+	for key, value := range src {
+		if srcMap, ok := value.(map[string]interface{}); ok {
+			if dstMap, ok := dst[key].(map[string]interface{}); ok {
+				mergeValues(dstMap, srcMap)
+				continue
+			}
+		}
+		dst[key] = value
+	}
+}
+
+// missingValue is what a template prints for a key missing from the values
+const missingValue = "<no value>"
+
+// RenderTemplate renders a configuration written as a Go template, with the values as .Values
+// A missing key reaches functions as an empty value, so `.Values.vlan.mtu | default 1500` works like in sprig.
+// Printed as is, it fails the render instead, so a typo in a values file cannot render an empty subnet.
+func RenderTemplate(data []byte, source string, values map[string]interface{}) ([]byte, error) {
+	tmpl, err := template.New(source).Funcs(templateFuncs).Parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid config template: %w", err)
+	}
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, map[string]interface{}{"Values": values}); err != nil {
+		return nil, fmt.Errorf("failed to render config template: %w", err)
+	}
+	if i := bytes.Index(out.Bytes(), []byte(missingValue)); i >= 0 {
+		line := bytes.Count(out.Bytes()[:i], []byte("\n")) + 1
+		return nil, fmt.Errorf("failed to render config template: line %d of the rendered config prints a key missing from the values; use default or required for optional values", line)
+	}
+	return out.Bytes(), nil
+}
+
+// RenderConfig reads a configuration and, with values files, renders it as a template; without any it is returned as read
+func RenderConfig(configPath string, valuesPaths []string) ([]byte, error) {
+	data, err := readConfigSource(configPath)
+	if err != nil || len(valuesPaths) == 0 {
+		return data, err
+	}
+	values, err := LoadValues(valuesPaths)
+	if err != nil {
+		return nil, err
+	}
+	return RenderTemplate(data, configPath, values)
+}
+
+// templateFuncs are the functions of config templates, named and ordered like their sprig counterparts
+var templateFuncs = template.FuncMap{
+	"default":    func(def, value interface{}) interface{} { return ternaryValue(value, def, !isEmpty(value)) },
+	"empty":      isEmpty,
+	"required":   requiredValue,
+	"coalesce":   coalesce,
+	"ternary":    ternaryValue,
+	"quote":      func(value interface{}) string { return strconv.Quote(toString(value)) },
+	"squote":     func(value interface{}) string { return "'" + toString(value) + "'" },
+	"toString":   toString,
+	"toYaml":     toYAML,
+	"indent":     indent,
+	"nindent":    func(spaces int, text string) string { return "\n" + indent(spaces, text) },
+	"upper":      strings.ToUpper,
+	"lower":      strings.ToLower,
+	"trim":       strings.TrimSpace,
+	"trimPrefix": func(prefix, text string) string { return strings.TrimPrefix(text, prefix) },
+	"trimSuffix": func(suffix, text string) string { return strings.TrimSuffix(text, suffix) },
+	"replace":    func(old, new, text string) string { return strings.ReplaceAll(text, old, new) },
+	"contains":   func(substr, text string) bool { return strings.Contains(text, substr) },
+	"hasPrefix":  func(prefix, text string) bool { return strings.HasPrefix(text, prefix) },
+	"hasSuffix":  func(suffix, text string) bool { return strings.HasSuffix(text, suffix) },
+	"join":       join,
+	"splitList":  func(sep, text string) []string { return strings.Split(text, sep) },
+	"list":       func(items ...interface{}) []interface{} { return items },
+	"dict":       dict,
+	"until":      until,
+	"int":        toInt,
+	"add":        func(values ...interface{}) int { return foldInts(values, func(a, b int) int { return a + b }) },
+	"add1":       func(value interface{}) int { return toInt(value) + 1 },
+	"sub":        func(a, b interface{}) int { return toInt(a) - toInt(b) },
+	"mul":        func(values ...interface{}) int { return foldInts(values, func(a, b int) int { return a * b }) },
+	"div":        func(a, b interface{}) int { return toInt(a) / toInt(b) },
+	"mod":        func(a, b interface{}) int { return toInt(a) % toInt(b) },
+}
+
+// isEmpty reports whether a value is absent or the zero value of its type, like sprig's empty
+func isEmpty(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	}
+	return v.IsZero()
+}
+
+// requiredValue fails rendering with the message when the value is empty
+func requiredValue(message string, value interface{}) (interface{}, error) {
+	if isEmpty(value) {
+		return nil, fmt.Errorf("%s", message)
+	}
+	return value, nil
+}
+
+// coalesce returns the first non-empty value
+func coalesce(values ...interface{}) interface{} {
+	for _, value := range values {
+		if !isEmpty(value) {
+			return value
+		}
+	}
+	return nil
+}
+
+// ternaryValue returns yes when the condition holds and no otherwise
+func ternaryValue(yes, no interface{}, condition bool) interface{} {
+	if condition {
+		return yes
+	}
+	return no
+}
+
+// toString formats a value the way it appears in YAML, e.g. 100 for an integer read from a values file
+func toString(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	return fmt.Sprint(value)
+}
+
+// toYAML renders a value as YAML without the trailing newline, for use with nindent
+func toYAML(value interface{}) (string, error) {
+	data, err := yaml.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(string(data), "\n"), nil
+}
+
+// indent prefixes every line of a text with spaces
+func indent(spaces int, text string) string {
+	pad := strings.Repeat(" ", spaces)
+	return pad + strings.ReplaceAll(text, "\n", "\n"+pad)
+}
+
+// join joins the items of a list, formatting each with toString
+func join(sep string, list interface{}) string {
+	v := reflect.ValueOf(list)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return toString(list)
+	}
+	items := make([]string, v.Len())
+	for i := range items {
+		items[i] = toString(v.Index(i).Interface())
+	}
+	return strings.Join(items, sep)
+}
+
+// dict builds a mapping from alternating keys and values
+func dict(pairs ...interface{}) (map[string]interface{}, error) {
+	if len(pairs)%2 != 0 {
+		return nil, fmt.Errorf("dict needs a value for every key, got %d arguments", len(pairs))
+	}
+	result := make(map[string]interface{}, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		result[toString(pairs[i])] = pairs[i+1]
+	}
+	return result, nil
+}
+
+// until returns the integers from 0 up to but excluding n, e.g. to stamp out a number of nodes
+func until(n interface{}) []int {
+	count := toInt(n)
+	if count < 0 {
+		count = 0
+	}
+	result := make([]int, count)
+	for i := range result {
+		result[i] = i
+	}
+	return result
+}
+
+// toInt converts numbers and numeric strings to an int; anything else is 0, like sprig's int
+func toInt(value interface{}) int {
+	switch v := value.(type) {
+	case int:
+		return v
+	case int64:
+		return int(v)
+	case float64:
+		return int(v)
+	case string:
+		n, _ := strconv.Atoi(strings.TrimSpace(v))
+		return n
+	}
+	return 0
+}
+
+// foldInts combines numbers left to right
+func foldInts(values []interface{}, combine func(a, b int) int) int {
+	if len(values) == 0 {
+		return 0
+	}
+	result := toInt(values[0])
+	for _, value := range values[1:] {
+		result = combine(result, toInt(value))
+	}
+	return result
+}
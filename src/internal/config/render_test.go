@@ -0,0 +1,131 @@
+// Package config provides unit tests for rendering configuration templates with values
+// WHY: One bundle template is stamped out per environment, so values must land exactly where the template puts them
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRenderTemplate tests rendering templates with values and the template functions
+// WHY: A missing value must fail the render instead of producing an empty field kictl would apply
+func TestRenderTemplate(t *testing.T) {
+	values := map[string]interface{}{
+		"env":     "staging",
+		"vlan":    map[string]interface{}{"id": 100, "prefix": "192.168.100"},
+		"compute": 3,
+		"labels":  map[string]interface{}{"tier": "edge"},
+	}
+
+	tests := []struct {
+		name        string
+		template    string
+		expected    string
+		expectError string
+	}{
+		{name: "plain", template: "kind: NodeVLANConf\n", expected: "kind: NodeVLANConf\n"},
+		{name: "value", template: "id: {{ .Values.vlan.id }}", expected: "id: 100"},
+		{
+			name:     "stamped_nodes",
+			template: "{{ range $i := until .Values.compute }}\n  compute-{{ add1 $i }}: {{ $.Values.vlan.prefix }}.{{ add 10 $i }}/24{{ end }}",
+			expected: "\n  compute-1: 192.168.100.10/24\n  compute-2: 192.168.100.11/24\n  compute-3: 192.168.100.12/24",
+		},
+		{name: "default_of_missing_key", template: `{{ .Values.vlan.mtu | default 1500 }}`, expected: "1500"},
+		{name: "default_of_indexed_key", template: `{{ index .Values.vlan "mtu" | default 1500 }}`, expected: "1500"},
+		{name: "missing_key_in_condition", template: `{{ if .Values.vlan.mtu }}mtu{{ else }}none{{ end }}`, expected: "none"},
+		{name: "default_of_value", template: `{{ default "prod" .Values.env | upper | quote }}`, expected: `"STAGING"`},
+		{name: "to_yaml", template: "labels:{{ .Values.labels | toYaml | nindent 2 }}", expected: "labels:\n  tier: edge"},
+		{name: "join", template: `{{ list "a" 1 true | join "," }}`, expected: "a,1,true"},
+		{name: "ternary", template: `{{ ternary "strict" "relaxed" (eq .Values.env "prod") }}`, expected: "relaxed"},
+		{name: "required", template: `{{ required "env is required" "" }}`, expectError: "env is required"},
+		{name: "required_missing_key", template: `{{ required "vlan.mtu is required" .Values.vlan.mtu }}`, expectError: "vlan.mtu is required"},
+		{name: "missing_value", template: "id: {{ .Values.vlan.id }}\nsubnet: {{ .Values.vlan.subnet }}", expectError: "line 2 of the rendered config prints a key missing from the values"},
+		{name: "missing_parent", template: "subnet: {{ .Values.storage.subnet }}", expectError: "failed to render config template"},
+		{name: "syntax_error", template: "id: {{ .Values.vlan.id }", expectError: "invalid config template"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// When: Render the template
+			rendered, err := RenderTemplate([]byte(tt.template), "cluster.tmpl.yaml", values)
+
+			// Then: Values and functions fill the template, or the render fails with the reason
+			if tt.expectError != "" {
+				assert.ErrorContains(t, err, tt.expectError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, string(rendered))
+		})
+	}
+}
+
+// TestLoadValues tests merging values files
+// WHY: Environment files override shared ones key by key, so one changed subnet must not drop its siblings
+func TestLoadValues(t *testing.T) {
+	// Given: Shared values and an environment file overriding one nested key and a list
+	dir := t.TempDir()
+	common := filepath.Join(dir, "common.yaml")
+	prod := filepath.Join(dir, "prod.yaml")
+	require.NoError(t, os.WriteFile(common, []byte("vlan:\n  id: 100\n  subnet: 192.168.100.0/24\nnodes: [rsb2, rsb3]\n"), 0644))
+	require.NoError(t, os.WriteFile(prod, []byte("vlan:\n  subnet: 10.0.100.0/24\nnodes: [prod1]\n"), 0644))
+
+	// When: Load both, later winning
+	values, err := LoadValues([]string{common, prod})
+
+	// Then: Nested keys merge and other values are replaced
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"vlan":  map[string]interface{}{"id": 100, "subnet": "10.0.100.0/24"},
+		"nodes": []interface{}{"prod1"},
+	}, values)
+
+	// When/Then: A values file that is not a mapping is rejected
+	require.NoError(t, os.WriteFile(prod, []byte("- prod1\n"), 0644))
+	_, err = LoadValues([]string{common, prod})
+	assert.ErrorContains(t, err, "failed to parse values file "+prod)
+}
+
+// TestLoadMultipleConfigsWithOptions_Values tests loading a bundle template rendered with values
+// WHY: Every command loads the rendered bundle, and its digest must identify what was applied, not the template
+func TestLoadMultipleConfigsWithOptions_Values(t *testing.T) {
+	// Given: A bundle template and the values of two environments
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "cluster.tmpl.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte(`apiVersion: openstack.kictl.icycloud.io/v2
+kind: NodeLabelConf
+metadata:
+  name: {{ .Values.env }}-labels
+spec:
+  nodeRoles:
+    compute:
+      nodes:{{ range $i := until .Values.computeNodes }}
+        - {{ $.Values.env }}-compute-{{ add1 $i }}{{ end }}
+      labels:
+        openstack-role: compute
+`), 0644))
+	staging := filepath.Join(dir, "staging.yaml")
+	prod := filepath.Join(dir, "prod.yaml")
+	require.NoError(t, os.WriteFile(staging, []byte("env: staging\ncomputeNodes: 1\n"), 0644))
+	require.NoError(t, os.WriteFile(prod, []byte("env: prod\ncomputeNodes: 3\n"), 0644))
+
+	// When: Load the template with each environment's values
+	stagingBundle, err := LoadMultipleConfigsWithOptions(configPath, LoadOptions{ValuesFiles: []string{staging}})
+	require.NoError(t, err)
+	prodBundle, err := LoadMultipleConfigsWithOptions(configPath, LoadOptions{ValuesFiles: []string{prod}, StrictSchema: true})
+	require.NoError(t, err)
+
+	// Then: Each bundle has its environment's nodes and its own digest
+	assert.Equal(t, "staging-labels", stagingBundle.NodeLabels.Metadata.Name)
+	assert.Equal(t, []string{"staging-compute-1"}, stagingBundle.NodeLabels.Spec.NodeRoles["compute"].Nodes)
+	assert.Equal(t, []string{"prod-compute-1", "prod-compute-2", "prod-compute-3"}, prodBundle.NodeLabels.Spec.NodeRoles["compute"].Nodes)
+	assert.NotEqual(t, stagingBundle.Digest, prodBundle.Digest)
+
+	// When/Then: Without values the template is loaded as plain YAML and fails
+	_, err = LoadMultipleConfigsWithOptions(configPath, LoadOptions{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,365 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Kinds of resource addresses, the first segment of an address
+const (
+	AddressKindNodeLabel = "nodelabel"
+	AddressKindVLAN      = "vlan"
+	AddressKindTest      = "test"
+	AddressKindCleanup   = "cleanup"
+)
+
+// addressKindConfigKinds maps each address kind to the configuration kind holding its items
+var addressKindConfigKinds = map[string]string{
+	AddressKindNodeLabel: "NodeLabelConf",
+	AddressKindVLAN:      "NodeVLANConf",
+	AddressKindTest:      "NodeTestConf",
+	AddressKindCleanup:   "CleanupConf",
+}
+
+// configKindAliases are the other names of configuration kinds on the command line
+var configKindAliases = map[string]string{
+	"label":   "NodeLabelConf",
+	"sysctl":  "NodeSysctlConf",
+	"storage": "NodeStorageConf",
+}
+
+// ConfigKindOf returns the configuration kind an address kind stands for, e.g. NodeVLANConf for vlan
+// Plurals such as vlans, the alias labels and the configuration kind itself are accepted too.
+func ConfigKindOf(name string) (string, bool) {
+	if kind, ok := configKindAliases[strings.TrimSuffix(name, "s")]; ok {
+		return kind, true
+	}
+	for addressKind, kind := range addressKindConfigKinds {
+		if name == addressKind || name == addressKind+"s" || name == kind {
+			return kind, true
+		}
+	}
+	return "", false
+}
+
+// ResourceAddress identifies one item kictl manages, e.g. nodelabel.control.rsb2/openstack-role or vlan.management.rsb2
+// Node labels are nodelabel.<role>.<node>/<key>, without the node segment for roles matching nodes by nodeSelector.
+// VLANs are vlan.<vlan>.<node>, connectivity tests test.<name> and cleaned up label prefixes cleanup.<prefix>.
+type ResourceAddress struct {
+	Kind  string // nodelabel, vlan, test or cleanup
+	Group string // role, VLAN, test name or label prefix
+	Node  string // node name or pattern; empty for tests, cleanup and nodeSelector roles
+	Key   string // label key of a node label
+}
+
+// String returns the address as written on the command line
+func (a ResourceAddress) String() string {
+	s := a.Kind
+	if a.Group != "" {
+		s += "." + a.Group
+	}
+	if a.Node != "" {
+		s += "." + a.Node
+	}
+	if a.Key != "" {
+		s += "/" + a.Key
+	}
+	return s
+}
+
+// Includes reports whether the address is item itself or a prefix of it, e.g. vlan.management includes vlan.management.rsb2
+// A node pattern includes the node names it matches, and a node name the items whose node pattern matches it.
+func (a ResourceAddress) Includes(item ResourceAddress) bool {
+	if a.Kind != item.Kind || (a.Group != "" && a.Group != item.Group) || (a.Key != "" && a.Key != item.Key) {
+		return false
+	}
+	if a.Node == "" || a.Node == item.Node {
+		return true
+	}
+	if IsNodePattern(a.Node) {
+		matched, _ := path.Match(a.Node, item.Node)
+		return matched && !IsNodePattern(item.Node)
+	}
+	matched, _ := path.Match(item.Node, a.Node)
+	return matched && IsNodePattern(item.Node)
+}
+
+// ParseResourceAddress parses an address; leaving out trailing segments addresses everything below it
+func ParseResourceAddress(s string) (ResourceAddress, error) {
+	s = strings.TrimSpace(s)
+	kind, rest, _ := strings.Cut(s, ".")
+	address := ResourceAddress{Kind: kind}
+
+	switch kind {
+	case AddressKindNodeLabel:
+		head, key, _ := strings.Cut(rest, "/")
+		address.Group, address.Node, _ = strings.Cut(head, ".")
+		address.Key = key
+	case AddressKindVLAN:
+		address.Group, address.Node, _ = strings.Cut(rest, ".")
+	case AddressKindTest, AddressKindCleanup:
+		address.Group = rest
+	default:
+		return ResourceAddress{}, fmt.Errorf("invalid resource address '%s': unknown kind '%s'. Expected: %s, %s, %s or %s",
+			s, kind, AddressKindNodeLabel, AddressKindVLAN, AddressKindTest, AddressKindCleanup)
+	}
+
+	if address.String() != s || (address.Group == "" && (address.Node != "" || address.Key != "")) {
+		return ResourceAddress{}, fmt.Errorf("invalid resource address '%s': expected e.g. nodelabel.control.rsb2/openstack-role, vlan.management.rsb2 or test.<name>", s)
+	}
+	return address, nil
+}
+
+// ParseResourceAddresses parses every address of a list, e.g. the values of --target
+func ParseResourceAddresses(values []string) ([]ResourceAddress, error) {
+	addresses := make([]ResourceAddress, 0, len(values))
+	for _, value := range values {
+		address, err := ParseResourceAddress(value)
+		if err != nil {
+			return nil, err
+		}
+		addresses = append(addresses, address)
+	}
+	return addresses, nil
+}
+
+// ResourceAddresses returns the address of every item of the bundle, in the order the services process them
+func (b *ConfigBundle) ResourceAddresses() []ResourceAddress {
+	var addresses []ResourceAddress
+	if b.Cleanup != nil {
+		addresses = append(addresses, sortedAddresses(cleanupAddresses(b.Cleanup))...)
+	}
+	if b.NodeLabels != nil {
+		for _, roleName := range sortedKeys(b.NodeLabels.Spec.NodeRoles) {
+			addresses = append(addresses, sortedAddresses(roleAddresses(roleName, b.NodeLabels.Spec.NodeRoles[roleName]))...)
+		}
+	}
+	if b.VLANs != nil {
+		for _, vlanName := range sortedKeys(b.VLANs.Spec.VLANs) {
+			addresses = append(addresses, sortedAddresses(vlanAddresses(vlanName, b.VLANs.Spec.VLANs[vlanName]))...)
+		}
+	}
+	if b.Tests != nil {
+		for _, test := range b.Tests.Spec.Tests {
+			addresses = append(addresses, ResourceAddress{Kind: AddressKindTest, Group: test.Name})
+		}
+	}
+	return addresses
+}
+
+// Target returns a copy of the bundle holding only the items the targets include
+// A role whose nodes are targeted with different labels is split into one role per label set, named <role>-2 and so on.
+// A target that includes nothing is an error, so a typo never turns into a run that silently does nothing.
+func (b *ConfigBundle) Target(targets []ResourceAddress) (*ConfigBundle, error) {
+	targets = b.normalizeTargets(targets)
+	matched := make([]bool, len(targets))
+
+	// targetedNodes returns the node entries of the item to keep, narrowing a pattern to the targeted node names
+	targetedNodes := func(item ResourceAddress) []string {
+		var nodes []string
+		for i, target := range targets {
+			if !target.Includes(item) {
+				continue
+			}
+			matched[i] = true
+			if IsNodePattern(item.Node) && target.Node != "" && !IsNodePattern(target.Node) {
+				nodes = append(nodes, target.Node)
+			} else {
+				nodes = append(nodes, item.Node)
+			}
+		}
+		return nodes
+	}
+
+	targeted := &ConfigBundle{Defaults: b.Defaults, Source: b.Source, Digest: b.Digest}
+
+	if b.Cleanup != nil {
+		var prefixes []string
+		for _, item := range cleanupAddresses(b.Cleanup) {
+			if len(targetedNodes(item)) > 0 {
+				prefixes = append(prefixes, item.Group)
+			}
+		}
+		if len(prefixes) > 0 {
+			cleanup := *b.Cleanup
+			cleanup.Spec.LabelPrefixes = prefixes
+			targeted.Cleanup = &cleanup
+		}
+	}
+
+	if b.NodeLabels != nil {
+		roles := make(map[string]NodeRole)
+		for _, roleName := range sortedKeys(b.NodeLabels.Spec.NodeRoles) {
+			role := b.NodeLabels.Spec.NodeRoles[roleName]
+			keysByNode := make(map[string]map[string]bool)
+			for _, item := range roleAddresses(roleName, role) {
+				for _, node := range targetedNodes(item) {
+					if keysByNode[node] == nil {
+						keysByNode[node] = make(map[string]bool)
+					}
+					keysByNode[node][item.Key] = true
+				}
+			}
+			for name, targetedRole := range splitTargetedRole(roleName, role, keysByNode, b.NodeLabels.Spec.NodeRoles) {
+				roles[name] = targetedRole
+			}
+		}
+		if len(roles) > 0 {
+			labels := *b.NodeLabels
+			labels.Spec.NodeRoles = roles
+			targeted.NodeLabels = &labels
+		}
+	}
+
+	if b.VLANs != nil {
+		vlans := make(map[string]VLANConfig)
+		for _, vlanName := range sortedKeys(b.VLANs.Spec.VLANs) {
+			vlanConfig := b.VLANs.Spec.VLANs[vlanName]
+			mapping := make(NodeMapping)
+			for _, item := range vlanAddresses(vlanName, vlanConfig) {
+				if len(targetedNodes(item)) > 0 {
+					mapping[item.Node] = vlanConfig.NodeMapping[item.Node]
+				}
+			}
+			if len(mapping) > 0 {
+				vlanConfig.NodeMapping = mapping
+				vlans[vlanName] = vlanConfig
+			}
+		}
+		if len(vlans) > 0 {
+			vlanConf := *b.VLANs
+			vlanConf.Spec.VLANs = vlans
+			targeted.VLANs = &vlanConf
+		}
+	}
+
+	if b.Tests != nil {
+		var tests []ConnectivityTest
+		for _, test := range b.Tests.Spec.Tests {
+			if len(targetedNodes(ResourceAddress{Kind: AddressKindTest, Group: test.Name})) > 0 {
+				tests = append(tests, test)
+			}
+		}
+		if len(tests) > 0 {
+			testConf := *b.Tests
+			testConf.Spec.Tests = tests
+			targeted.Tests = &testConf
+		}
+	}
+
+	var unmatched []string
+	for i, target := range targets {
+		if !matched[i] {
+			unmatched = append(unmatched, target.String())
+		}
+	}
+	if len(unmatched) > 0 {
+		return nil, fmt.Errorf("no item of the bundle matches target %s", strings.Join(unmatched, ", "))
+	}
+	return targeted, nil
+}
+
+// normalizeTargets prefixes bare label keys of node label targets with the spec label prefix, like the loader does
+func (b *ConfigBundle) normalizeTargets(targets []ResourceAddress) []ResourceAddress {
+	if b.NodeLabels == nil {
+		return targets
+	}
+	prefix := normalizeLabelPrefix(b.NodeLabels.Spec.LabelPrefix)
+	normalized := make([]ResourceAddress, len(targets))
+	for i, target := range targets {
+		if target.Kind == AddressKindNodeLabel && target.Key != "" && prefix != "" && !strings.Contains(target.Key, "/") {
+			target.Key = prefix + target.Key
+		}
+		normalized[i] = target
+	}
+	return normalized
+}
+
+// splitTargetedRole returns the roles labeling each targeted node with its targeted keys, one role per set of keys
+// The node entry "" stands for the role's nodeSelector. Names of the split roles skip the names of existing roles.
+func splitTargetedRole(roleName string, role NodeRole, keysByNode map[string]map[string]bool, existing map[string]NodeRole) map[string]NodeRole {
+	nodes := sortedKeys(keysByNode)
+	var keySets []string
+	nodesByKeySet := make(map[string][]string)
+	for _, node := range nodes {
+		keySet := strings.Join(sortedKeys(keysByNode[node]), "\n")
+		if _, ok := nodesByKeySet[keySet]; !ok {
+			keySets = append(keySets, keySet)
+		}
+		nodesByKeySet[keySet] = append(nodesByKeySet[keySet], node)
+	}
+
+	roles := make(map[string]NodeRole, len(keySets))
+	suffix := 1
+	for i, keySet := range keySets {
+		targetedRole := NodeRole{Description: role.Description, Labels: make(map[string]string)}
+		for _, key := range strings.Split(keySet, "\n") {
+			targetedRole.Labels[key] = role.Labels[key]
+		}
+		for _, node := range nodesByKeySet[keySet] {
+			if node == "" {
+				targetedRole.NodeSelector = role.NodeSelector
+			} else {
+				targetedRole.Nodes = append(targetedRole.Nodes, node)
+			}
+		}
+		name := roleName
+		for taken := i > 0; taken; _, taken = existing[name] {
+			suffix++
+			name = fmt.Sprintf("%s-%d", roleName, suffix)
+		}
+		roles[name] = targetedRole
+	}
+	return roles
+}
+
+// roleAddresses returns the address of every label of a role on every node entry of it
+func roleAddresses(roleName string, role NodeRole) []ResourceAddress {
+	nodes := append([]string(nil), role.Nodes...)
+	if role.NodeSelector != "" {
+		nodes = append(nodes, "")
+	}
+	var addresses []ResourceAddress
+	for _, node := range nodes {
+		for key := range role.Labels {
+			addresses = append(addresses, ResourceAddress{Kind: AddressKindNodeLabel, Group: roleName, Node: node, Key: key})
+		}
+	}
+	return addresses
+}
+
+// vlanAddresses returns the address of a VLAN on every node of its mapping
+func vlanAddresses(vlanName string, vlanConfig VLANConfig) []ResourceAddress {
+	addresses := make([]ResourceAddress, 0, len(vlanConfig.NodeMapping))
+	for node := range vlanConfig.NodeMapping {
+		addresses = append(addresses, ResourceAddress{Kind: AddressKindVLAN, Group: vlanName, Node: node})
+	}
+	return addresses
+}
+
+// cleanupAddresses returns the address of every label prefix a cleanup removes
+func cleanupAddresses(cleanup *CleanupConf) []ResourceAddress {
+	addresses := make([]ResourceAddress, 0, len(cleanup.Spec.LabelPrefixes))
+	for _, prefix := range cleanup.Spec.LabelPrefixes {
+		addresses = append(addresses, ResourceAddress{Kind: AddressKindCleanup, Group: prefix})
+	}
+	return addresses
+}
+
+// sortedAddresses sorts addresses by their string form
+func sortedAddresses(addresses []ResourceAddress) []ResourceAddress {
+	sort.Slice(addresses, func(i, j int) bool { return addresses[i].String() < addresses[j].String() })
+	return addresses
+}
+
+// sortedKeys returns the keys of a map in sorted order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,196 @@
+// Package config provides unit tests for resource addresses
+// WHY: --target must act on exactly the addressed items, and an address must keep meaning the same item across runs
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTargetTestBundle returns a bundle with every kind of addressable item
+func newTargetTestBundle() *ConfigBundle {
+	return &ConfigBundle{
+		Cleanup: &CleanupConf{Kind: "CleanupConf", Spec: CleanupSpec{LabelPrefixes: []string{"legacy.io/", "old-"}}},
+		NodeLabels: &NodeLabelConf{Kind: "NodeLabelConf", Spec: NodeLabelSpec{NodeRoles: map[string]NodeRole{
+			"control": {Nodes: []string{"rsb2", "rsb3"}, Labels: map[string]string{"openstack-role": "control", "ceph-mon": "enabled"}},
+			"compute": {Nodes: []string{"rsb1*"}, Labels: map[string]string{"openstack-role": "compute"}},
+			"storage": {NodeSelector: "disk=ssd", Labels: map[string]string{"ceph-osd": "enabled"}},
+		}}},
+		VLANs: &NodeVLANConf{Kind: "NodeVLANConf", Spec: NodeVLANSpec{VLANs: map[string]VLANConfig{
+			"management": {ID: 100, NodeMapping: NodeMapping{"rsb2": "10.1.0.2/24", "rsb3": "10.1.0.3/24"}},
+			"storage":    {ID: 200, NodeMapping: NodeMapping{"rsb3": "10.2.0.3/24"}},
+		}}},
+		Tests: &NodeTestConf{Kind: "NodeTestConf", Spec: NodeTestSpec{Tests: []ConnectivityTest{
+			{Name: "mgmt-ping", Source: "rsb2"}, {Name: "storage-ping", Source: "rsb3"},
+		}}},
+	}
+}
+
+// TestParseResourceAddress tests parsing addresses given on the command line
+// WHY: Node names may contain dots and label keys slashes, so each kind must split its segments its own way
+func TestParseResourceAddress(t *testing.T) {
+	tests := []struct {
+		name        string
+		address     string
+		expected    ResourceAddress
+		expectError string
+	}{
+		{name: "node_label", address: "nodelabel.control.rsb2/openstack-role", expected: ResourceAddress{Kind: "nodelabel", Group: "control", Node: "rsb2", Key: "openstack-role"}},
+		{name: "prefixed_label_on_fqdn_node", address: "nodelabel.control.rsb2.lab.local/kictl.io/role", expected: ResourceAddress{Kind: "nodelabel", Group: "control", Node: "rsb2.lab.local", Key: "kictl.io/role"}},
+		{name: "selector_role_label", address: "nodelabel.storage/ceph-osd", expected: ResourceAddress{Kind: "nodelabel", Group: "storage", Key: "ceph-osd"}},
+		{name: "vlan_on_node", address: "vlan.management.rsb2", expected: ResourceAddress{Kind: "vlan", Group: "management", Node: "rsb2"}},
+		{name: "whole_vlan", address: "vlan.management", expected: ResourceAddress{Kind: "vlan", Group: "management"}},
+		{name: "whole_kind", address: "test", expected: ResourceAddress{Kind: "test"}},
+		{name: "cleanup_prefix", address: "cleanup.legacy.io/", expected: ResourceAddress{Kind: "cleanup", Group: "legacy.io/"}},
+		{name: "unknown_kind", address: "vlans.management", expectError: "unknown kind 'vlans'"},
+		{name: "trailing_dot", address: "vlan.management.", expectError: "invalid resource address 'vlan.management.'"},
+		{name: "missing_role", address: "nodelabel./openstack-role", expectError: "invalid resource address"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given/When: Parse the address
+			address, err := ParseResourceAddress(tt.address)
+
+			// Then: Its segments are split by kind and it prints back unchanged
+			if tt.expectError != "" {
+				assert.ErrorContains(t, err, tt.expectError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, address)
+			assert.Equal(t, tt.address, address.String())
+		})
+	}
+}
+
+// TestConfigBundle_ResourceAddresses tests listing the items of a bundle
+// WHY: The listing is what users copy into --target, so it must name every item in processing order
+func TestConfigBundle_ResourceAddresses(t *testing.T) {
+	// Given: A bundle of every kind
+	bundle := newTargetTestBundle()
+
+	// When: List its addresses
+	var addresses []string
+	for _, address := range bundle.ResourceAddresses() {
+		addresses = append(addresses, address.String())
+	}
+
+	// Then: Cleanup, labels, VLANs and tests, each sorted
+	assert.Equal(t, []string{
+		"cleanup.legacy.io/",
+		"cleanup.old-",
+		"nodelabel.compute.rsb1*/openstack-role",
+		"nodelabel.control.rsb2/ceph-mon",
+		"nodelabel.control.rsb2/openstack-role",
+		"nodelabel.control.rsb3/ceph-mon",
+		"nodelabel.control.rsb3/openstack-role",
+		"nodelabel.storage/ceph-osd",
+		"vlan.management.rsb2",
+		"vlan.management.rsb3",
+		"vlan.storage.rsb3",
+		"test.mgmt-ping",
+		"test.storage-ping",
+	}, addresses)
+}
+
+// TestConfigBundle_Target tests restricting a bundle to targeted items
+// WHY: A targeted apply or delete must touch nothing outside its targets, and a mistyped target must not run at all
+func TestConfigBundle_Target(t *testing.T) {
+	tests := []struct {
+		name        string
+		targets     []string
+		expected    []string
+		expectRoles map[string]NodeRole
+		expectError string
+	}{
+		{
+			name:     "vlan_on_one_node",
+			targets:  []string{"vlan.management.rsb2"},
+			expected: []string{"vlan.management.rsb2"},
+		},
+		{
+			name:     "whole_vlan_and_test",
+			targets:  []string{"vlan.management", "test.mgmt-ping"},
+			expected: []string{"vlan.management.rsb2", "vlan.management.rsb3", "test.mgmt-ping"},
+		},
+		{
+			name:     "node_pattern_target",
+			targets:  []string{"vlan.management.rsb[3-9]", "vlan.storage.rsb*"},
+			expected: []string{"vlan.management.rsb3", "vlan.storage.rsb3"},
+		},
+		{
+			name:     "node_name_narrows_role_pattern",
+			targets:  []string{"nodelabel.compute.rsb17"},
+			expected: []string{"nodelabel.compute.rsb17/openstack-role"},
+		},
+		{
+			name:     "selector_role_and_cleanup_prefix",
+			targets:  []string{"nodelabel.storage", "cleanup.old-"},
+			expected: []string{"cleanup.old-", "nodelabel.storage/ceph-osd"},
+		},
+		{
+			name:     "different_labels_per_node_split_the_role",
+			targets:  []string{"nodelabel.control.rsb2", "nodelabel.control.rsb3/ceph-mon"},
+			expected: []string{"nodelabel.control.rsb2/ceph-mon", "nodelabel.control.rsb2/openstack-role", "nodelabel.control-2.rsb3/ceph-mon"},
+			expectRoles: map[string]NodeRole{
+				"control":   {Nodes: []string{"rsb2"}, Labels: map[string]string{"openstack-role": "control", "ceph-mon": "enabled"}},
+				"control-2": {Nodes: []string{"rsb3"}, Labels: map[string]string{"ceph-mon": "enabled"}},
+			},
+		},
+		{
+			name:        "unmatched_target",
+			targets:     []string{"vlan.management.rsb9", "vlan.storage.rsb3"},
+			expectError: "no item of the bundle matches target vlan.management.rsb9",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A bundle of every kind and the targets
+			bundle := newTargetTestBundle()
+			targets, err := ParseResourceAddresses(tt.targets)
+			require.NoError(t, err)
+
+			// When: Target the bundle
+			targeted, err := bundle.Target(targets)
+
+			// Then: Only the targeted items are left, and the bundle itself is unchanged
+			if tt.expectError != "" {
+				assert.ErrorContains(t, err, tt.expectError)
+				return
+			}
+			require.NoError(t, err)
+			var addresses []string
+			for _, address := range targeted.ResourceAddresses() {
+				addresses = append(addresses, address.String())
+			}
+			assert.Equal(t, tt.expected, addresses)
+			if tt.expectRoles != nil {
+				assert.Equal(t, tt.expectRoles, targeted.NodeLabels.Spec.NodeRoles)
+			}
+			assert.Len(t, bundle.ResourceAddresses(), 13)
+		})
+	}
+}
+
+// TestConfigBundle_TargetLabelPrefix tests targeting labels by their key as written in the configuration
+// WHY: The loader prefixes bare keys with spec.labelPrefix, and users should not have to repeat the prefix
+func TestConfigBundle_TargetLabelPrefix(t *testing.T) {
+	// Given: A role whose keys were prefixed by the loader
+	bundle := &ConfigBundle{NodeLabels: &NodeLabelConf{Spec: NodeLabelSpec{
+		LabelPrefix: "kictl.io",
+		NodeRoles: map[string]NodeRole{
+			"control": {Nodes: []string{"rsb2"}, Labels: map[string]string{"kictl.io/openstack-role": "control", "kictl.io/zone": "a"}},
+		},
+	}}}
+
+	// When: Target a label by its bare key
+	targeted, err := bundle.Target([]ResourceAddress{{Kind: AddressKindNodeLabel, Group: "control", Node: "rsb2", Key: "openstack-role"}})
+
+	// Then: The prefixed label is targeted
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"kictl.io/openstack-role": "control"}, targeted.NodeLabels.Spec.NodeRoles["control"].Labels)
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// IsNodePattern reports whether a role node entry is a name glob pattern rather than a node name
+func IsNodePattern(name string) bool {
+	return strings.ContainsAny(name, "*?[")
+}
+
+// NodeNames returns the node entries of the role that are plain names
+func (r NodeRole) NodeNames() []string {
+	var names []string
+	for _, name := range r.Nodes {
+		if !IsNodePattern(name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// NodePatterns returns the node entries of the role that are name glob patterns
+func (r NodeRole) NodePatterns() []string {
+	var patterns []string
+	for _, name := range r.Nodes {
+		if IsNodePattern(name) {
+			patterns = append(patterns, name)
+		}
+	}
+	return patterns
+}
+
+// IsDynamic reports whether the role needs the cluster's node list to know which nodes it targets
+func (r NodeRole) IsDynamic() bool {
+	return r.NodeSelector != "" || len(r.NodePatterns()) > 0
+}
+
+// MatchesName reports whether a node entry of the role names nodeName, either literally or by pattern
+// The nodeSelector is not considered since matching it needs the node's labels.
+func (r NodeRole) MatchesName(nodeName string) bool {
+	for _, name := range r.Nodes {
+		if name == nodeName {
+			return true
+		}
+		if matched, _ := path.Match(name, nodeName); matched && IsNodePattern(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// WithResolvedNodes returns a copy of the configuration whose roles list the nodes they were resolved to
+// Roles missing from resolved are kept unchanged. Used to record what an apply actually targeted.
+func (c *NodeLabelConf) WithResolvedNodes(resolved map[string][]string) *NodeLabelConf {
+	resolvedConf := *c
+	resolvedConf.Spec.NodeRoles = make(map[string]NodeRole, len(c.Spec.NodeRoles))
+	for roleName, role := range c.Spec.NodeRoles {
+		if nodes, ok := resolved[roleName]; ok {
+			role.Nodes = nodes
+			role.NodeSelector = ""
+		}
+		resolvedConf.Spec.NodeRoles[roleName] = role
+	}
+	return &resolvedConf
+}
+
+// validateNodeRoles checks the node patterns of every role
+func validateNodeRoles(roles map[string]NodeRole) error {
+	roleNames := make([]string, 0, len(roles))
+	for roleName := range roles {
+		roleNames = append(roleNames, roleName)
+	}
+	sort.Strings(roleNames)
+
+	for _, roleName := range roleNames {
+		for _, pattern := range roles[roleName].NodePatterns() {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("role '%s': invalid node pattern '%s': %w", roleName, pattern, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+// Package config provides unit tests for role node targeting
+// WHY: Name patterns must be told apart from node names before anything queries the cluster
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNodeRole_Targeting tests splitting role nodes into names and patterns
+// WHY: Only plain names can be checked offline, patterns need the cluster's node list
+func TestNodeRole_Targeting(t *testing.T) {
+	tests := []struct {
+		name             string
+		role             NodeRole
+		expectedNames    []string
+		expectedPatterns []string
+		expectedDynamic  bool
+		matches          map[string]bool
+	}{
+		{
+			name:            "names_only",
+			role:            NodeRole{Nodes: []string{"rsb2", "rsb3"}},
+			expectedNames:   []string{"rsb2", "rsb3"},
+			expectedDynamic: false,
+			matches:         map[string]bool{"rsb2": true, "rsb4": false},
+		},
+		{
+			name:             "names_and_patterns",
+			role:             NodeRole{Nodes: []string{"rsb2", "compute-*", "storage-[0-9]"}},
+			expectedNames:    []string{"rsb2"},
+			expectedPatterns: []string{"compute-*", "storage-[0-9]"},
+			expectedDynamic:  true,
+			matches:          map[string]bool{"rsb2": true, "compute-12": true, "storage-1": true, "storage-12": false},
+		},
+		{
+			name:            "selector_only",
+			role:            NodeRole{NodeSelector: "node-role.kubernetes.io/worker"},
+			expectedDynamic: true,
+			matches:         map[string]bool{"rsb2": false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// When: Split the role's node entries
+			names := tt.role.NodeNames()
+			patterns := tt.role.NodePatterns()
+
+			// Then: Names, patterns and matches are as expected
+			assert.Equal(t, tt.expectedNames, names)
+			assert.Equal(t, tt.expectedPatterns, patterns)
+			assert.Equal(t, tt.expectedDynamic, tt.role.IsDynamic())
+			for nodeName, expected := range tt.matches {
+				assert.Equal(t, expected, tt.role.MatchesName(nodeName), nodeName)
+			}
+		})
+	}
+}
+
+// TestNodeLabelConf_WithResolvedNodes tests replacing role targets with resolved nodes
+// WHY: Recorded state must hold the nodes an apply reached, not the patterns that selected them
+func TestNodeLabelConf_WithResolvedNodes(t *testing.T) {
+	// Given: A configuration with a dynamic and a static role
+	original := &NodeLabelConf{Spec: NodeLabelSpec{NodeRoles: map[string]NodeRole{
+		"compute": {Nodes: []string{"compute-*"}, NodeSelector: "gpu=true", Labels: map[string]string{"role": "compute"}},
+		"control": {Nodes: []string{"rsb2"}, Labels: map[string]string{"role": "control"}},
+	}}}
+
+	// When: Resolve the dynamic role
+	resolved := original.WithResolvedNodes(map[string][]string{"compute": {"compute-1", "gpu-1"}})
+
+	// Then: Only the resolved role changes and the original is untouched
+	assert.Equal(t, NodeRole{Nodes: []string{"compute-1", "gpu-1"}, Labels: map[string]string{"role": "compute"}}, resolved.Spec.NodeRoles["compute"])
+	assert.Equal(t, original.Spec.NodeRoles["control"], resolved.Spec.NodeRoles["control"])
+	assert.Equal(t, []string{"compute-*"}, original.Spec.NodeRoles["compute"].Nodes)
+	assert.Equal(t, "gpu=true", original.Spec.NodeRoles["compute"].NodeSelector)
+}
+
+// TestValidateNodeRoles tests rejecting malformed node patterns
+// WHY: A broken pattern would silently match no node at apply time
+func TestValidateNodeRoles(t *testing.T) {
+	tests := []struct {
+		name        string
+		roles       map[string]NodeRole
+		expectError string
+	}{
+		{"valid_patterns", map[string]NodeRole{"compute": {Nodes: []string{"compute-[0-9]*", "rsb2"}}}, ""},
+		{"unclosed_bracket", map[string]NodeRole{"compute": {Nodes: []string{"compute-[0-9"}}}, "role 'compute': invalid node pattern 'compute-[0-9'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// When: Validate the roles
+			err := validateNodeRoles(tt.roles)
+
+			// Then: Only malformed patterns fail
+			if tt.expectError == "" {
+				assert.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectError)
+			}
+		})
+	}
+}
@@ -0,0 +1,336 @@
+// Package config generates JSON Schemas for the configuration kinds and validates documents against them
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SchemaDraft is the JSON Schema dialect of the generated schemas
+const SchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// schemaIDBase prefixes the $id of every generated schema
+const schemaIDBase = "https://kictl.icycloud.io/schemas/"
+
+// schemaTypes maps each kind to the type its documents decode into
+var schemaTypes = map[string]reflect.Type{
+	"NodeLabelConf":   reflect.TypeOf(NodeLabelConf{}),
+	"NodeVLANConf":    reflect.TypeOf(NodeVLANConf{}),
+	"NodeTestConf":    reflect.TypeOf(NodeTestConf{}),
+	"CleanupConf":     reflect.TypeOf(CleanupConf{}),
+	"NodeSysctlConf":  reflect.TypeOf(NodeSysctlConf{}),
+	"NodeStorageConf": reflect.TypeOf(NodeStorageConf{}),
+	DefaultsKind:      reflect.TypeOf(Defaults{}),
+}
+
+// Schema is the subset of JSON Schema kictl generates and validates against
+type Schema struct {
+	Schema      string             `json:"$schema,omitempty"`
+	ID          string             `json:"$id,omitempty"`
+	Title       string             `json:"title,omitempty"`
+	Description string             `json:"description,omitempty"`
+	Types       []string           `json:"-"`
+	Const       string             `json:"const,omitempty"`
+	Pattern     string             `json:"pattern,omitempty"`
+	Properties  map[string]*Schema `json:"properties,omitempty"`
+	Required    []string           `json:"required,omitempty"`
+	// Closed forbids properties not listed in Properties; AdditionalProperties types them otherwise
+	Closed               bool               `json:"-"`
+	AdditionalProperties *Schema            `json:"-"`
+	Items                *Schema            `json:"items,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+	Defs                 map[string]*Schema `json:"$defs,omitempty"`
+	Ref                  string             `json:"$ref,omitempty"`
+}
+
+// MarshalJSON renders a single type as a string and Closed as additionalProperties: false
+func (s *Schema) MarshalJSON() ([]byte, error) {
+	type plain Schema
+	out := struct {
+		*plain
+		Type                 interface{} `json:"type,omitempty"`
+		AdditionalProperties interface{} `json:"additionalProperties,omitempty"`
+	}{plain: (*plain)(s)}
+
+	switch len(s.Types) {
+	case 0:
+	case 1:
+		out.Type = s.Types[0]
+	default:
+		out.Type = s.Types
+	}
+	if s.AdditionalProperties != nil {
+		out.AdditionalProperties = s.AdditionalProperties
+	} else if s.Closed {
+		out.AdditionalProperties = false
+	}
+	return json.Marshal(out)
+}
+
+// SchemaKinds lists the kinds a schema can be generated for, sorted
+func SchemaKinds() []string {
+	kinds := make([]string, 0, len(schemaTypes))
+	for kind := range schemaTypes {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// KindSchema generates the JSON Schema of one configuration kind at the latest API version
+func KindSchema(kind string) (*Schema, error) {
+	return kindSchema(kind, versionOf(LatestAPIVersion))
+}
+
+// kindSchema generates the JSON Schema of one configuration kind at an API version from the type it decodes into
+func kindSchema(kind, version string) (*Schema, error) {
+	t, ok := schemaTypes[kind]
+	if !ok {
+		return nil, fmt.Errorf("unsupported config kind '%s'. Expected: %s", kind, strings.Join(SchemaKinds(), ", "))
+	}
+
+	schema := typeSchema(t)
+	schema.Schema = SchemaDraft
+	schema.ID = schemaIDBase + strings.ToLower(kind) + ".schema.json"
+	schema.Title = kind
+	schema.Properties["apiVersion"].Pattern = "/" + version + "$"
+	schema.Properties["kind"].Const = kind
+	schema.Properties["metadata"].Required = []string{"name"}
+	schema.Required = []string{"apiVersion", "kind", "metadata", "spec"}
+	if convert := documentVersions[version].schema; convert != nil {
+		convert(kind, schema)
+	}
+	return schema, nil
+}
+
+// BundleSchema generates a JSON Schema accepting a document of any configuration kind, for multi-document bundles
+func BundleSchema() *Schema {
+	bundle := &Schema{
+		Schema:      SchemaDraft,
+		ID:          schemaIDBase + "kictl.schema.json",
+		Title:       "kictl configuration",
+		Description: "One document of a kictl configuration bundle",
+		Defs:        make(map[string]*Schema),
+	}
+	for _, kind := range SchemaKinds() {
+		schema, _ := KindSchema(kind)
+		schema.Schema = ""
+		bundle.Defs[kind] = schema
+		bundle.OneOf = append(bundle.OneOf, &Schema{Ref: "#/$defs/" + kind})
+	}
+	return bundle
+}
+
+// typeSchema describes the YAML a Go type decodes from, following its yaml tags
+func typeSchema(t reflect.Type) *Schema {
+	if t == reflect.TypeOf(time.Duration(0)) {
+		return &Schema{Types: []string{"string", "integer"}, Description: "Duration such as 30s or 20m"}
+	}
+	if t == reflect.TypeOf(NodeMapping{}) {
+		address := &Schema{Types: []string{"string", "array"}, Items: &Schema{Types: []string{"string"}}, Description: "CIDR address, or a list of them for dual-stack nodes"}
+		return &Schema{Types: []string{"object"}, AdditionalProperties: address}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return typeSchema(t.Elem())
+	case reflect.String:
+		return &Schema{Types: []string{"string"}}
+	case reflect.Bool:
+		return &Schema{Types: []string{"boolean"}}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Types: []string{"integer"}}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Types: []string{"number"}}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Types: []string{"array"}, Items: typeSchema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Types: []string{"object"}, AdditionalProperties: typeSchema(t.Elem())}
+	case reflect.Struct:
+		schema := &Schema{Types: []string{"object"}, Properties: make(map[string]*Schema), Closed: true}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+			if !field.IsExported() || name == "-" {
+				continue
+			}
+			if name == "" {
+				name = strings.ToLower(field.Name)
+			}
+			schema.Properties[name] = typeSchema(field.Type)
+		}
+		return schema
+	default:
+		return &Schema{}
+	}
+}
+
+// SchemaError is one place a document breaks its schema
+type SchemaError struct {
+	Document int    // 1-based document index in the bundle
+	Line     int    // line in the file, 0 when unknown
+	Path     string // dotted path of the offending value, e.g. spec.nodeRoles.control.lables
+	Message  string
+}
+
+// Error renders the location and message
+func (e SchemaError) Error() string {
+	location := fmt.Sprintf("document %d", e.Document)
+	if e.Line > 0 {
+		location += fmt.Sprintf(", line %d", e.Line)
+	}
+	if e.Path != "" {
+		return fmt.Sprintf("%s: %s: %s", location, e.Path, e.Message)
+	}
+	return fmt.Sprintf("%s: %s", location, e.Message)
+}
+
+// ValidateSchema checks every document of a bundle against the schema of its kind, rejecting unknown fields
+func ValidateSchema(data []byte) []SchemaError {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	var errs []SchemaError
+	for document := 1; ; document++ {
+		var node yaml.Node
+		if err := decoder.Decode(&node); err != nil {
+			if !errors.Is(err, io.EOF) {
+				errs = append(errs, SchemaError{Document: document, Message: err.Error()})
+			}
+			return errs
+		}
+		if len(node.Content) == 0 || node.Content[0].Kind != yaml.MappingNode {
+			if len(node.Content) > 0 && node.Content[0].Tag != "!!null" {
+				errs = append(errs, SchemaError{Document: document, Line: node.Content[0].Line, Message: "document must be a mapping"})
+			}
+			continue
+		}
+
+		// Documents are checked against the schema of their own version, unknown versions against the latest
+		root := node.Content[0]
+		version := versionOf(scalarValue(root, "apiVersion"))
+		if _, ok := documentVersions[version]; !ok {
+			version = versionOf(LatestAPIVersion)
+		}
+		schema, err := kindSchema(scalarValue(root, "kind"), version)
+		if err != nil {
+			errs = append(errs, SchemaError{Document: document, Line: root.Line, Path: "kind", Message: err.Error()})
+			continue
+		}
+		for _, schemaErr := range validateNode(root, schema, "") {
+			schemaErr.Document = document
+			errs = append(errs, schemaErr)
+		}
+	}
+}
+
+// validateNode checks one YAML node against a schema
+func validateNode(node *yaml.Node, schema *Schema, path string) []SchemaError {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	fail := func(format string, args ...interface{}) []SchemaError {
+		return []SchemaError{{Line: node.Line, Path: path, Message: fmt.Sprintf(format, args...)}}
+	}
+
+	if len(schema.Types) > 0 && !typeMatches(node, schema.Types) {
+		return fail("expected %s, got %s", strings.Join(schema.Types, " or "), nodeType(node))
+	}
+	if schema.Const != "" && node.Value != schema.Const {
+		return fail("must be %q", schema.Const)
+	}
+	if schema.Pattern != "" && !regexp.MustCompile(schema.Pattern).MatchString(node.Value) {
+		return fail("must match %s", schema.Pattern)
+	}
+
+	var errs []SchemaError
+	switch node.Kind {
+	case yaml.MappingNode:
+		present := make(map[string]bool)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			present[key.Value] = true
+			childPath := joinSchemaPath(path, key.Value)
+			if property, ok := schema.Properties[key.Value]; ok {
+				errs = append(errs, validateNode(value, property, childPath)...)
+			} else if schema.AdditionalProperties != nil {
+				errs = append(errs, validateNode(value, schema.AdditionalProperties, childPath)...)
+			} else if schema.Closed {
+				errs = append(errs, SchemaError{Line: key.Line, Path: childPath, Message: "unknown field"})
+			}
+		}
+		for _, required := range schema.Required {
+			if !present[required] {
+				errs = append(errs, SchemaError{Line: node.Line, Path: joinSchemaPath(path, required), Message: "required field is missing"})
+			}
+		}
+	case yaml.SequenceNode:
+		// Operands of node set operations are set names and nested lists, checked when the loader resolves them
+		if schema.Items != nil && !isNodeSetOperation(node) {
+			for i, item := range node.Content {
+				errs = append(errs, validateNode(item, schema.Items, fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	}
+	return errs
+}
+
+// typeMatches reports whether a node has one of the JSON types
+func typeMatches(node *yaml.Node, types []string) bool {
+	actual := nodeType(node)
+	for _, expected := range types {
+		if expected == actual || (expected == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+// nodeType names the JSON type of a YAML node
+func nodeType(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return "object"
+	case yaml.SequenceNode:
+		return "array"
+	}
+	switch node.ShortTag() {
+	case "!!int":
+		return "integer"
+	case "!!float":
+		return "number"
+	case "!!bool":
+		return "boolean"
+	case "!!null":
+		return "null"
+	default:
+		return "string"
+	}
+}
+
+// mappingValue returns the value of a key in a mapping node, or nil
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// joinSchemaPath appends a key to a dotted path
+func joinSchemaPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
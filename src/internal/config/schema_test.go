@@ -0,0 +1,222 @@
+// Package config provides unit tests for JSON Schema generation and validation
+// WHY: Editors and CI validate configs with these schemas, so they must accept what kictl loads and reject typos it would ignore
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestValidateSchema tests validation of bundle documents against the schema of their kind
+// WHY: yaml decoding silently drops unknown fields, so a misspelled key would otherwise go unnoticed
+func TestValidateSchema(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		expected []string
+	}{
+		{
+			name: "valid_bundle",
+			data: `apiVersion: openstack.kictl.icycloud.io/v1
+kind: Defaults
+metadata:
+  name: site
+spec:
+  interface: eth1
+  tools:
+    nvlan:
+      netplanTry: 2m
+---
+apiVersion: openstack.kictl.icycloud.io/v1
+kind: NodeVLANConf
+metadata:
+  name: vlans
+spec:
+  vlans:
+    storage:
+      id: 200
+      subnet: 10.200.0.0/24
+      nodeMapping:
+        rsb2: 10.200.0.12/24
+        rsb3: [10.200.0.13/24, "fd00:200::13/64"]
+tools:
+  nvlan:
+    bmc:
+      consoleDuration: 1m
+      nodes:
+        rsb2:
+          address: 10.0.9.12
+          credentials:
+            name: rsb2-bmc
+`,
+		},
+		{
+			name: "node_set_operations",
+			data: `apiVersion: openstack.kictl.icycloud.io/v1
+kind: Defaults
+metadata:
+  name: site
+spec:
+  nodeSets:
+    all: [rsb1, rsb2, rsb3]
+    workers: !difference [all, [rsb1]]
+---
+apiVersion: openstack.kictl.icycloud.io/v1
+kind: NodeLabelConf
+metadata:
+  name: labels
+spec:
+  nodeRoles:
+    compute:
+      nodes: !union [workers, [rsb9]]
+      labels:
+        role: compute
+`,
+		},
+		{
+			name: "unknown_field",
+			data: `apiVersion: openstack.kictl.icycloud.io/v1
+kind: NodeLabelConf
+metadata:
+  name: labels
+spec:
+  nodeRoles:
+    control:
+      nodes: ["rsb2"]
+      lables:
+        role: control
+`,
+			expected: []string{"document 1, line 9: spec.nodeRoles.control.lables: unknown field"},
+		},
+		{
+			name: "wrong_types_and_missing_fields",
+			data: `apiVersion: openstack.kictl.icycloud.io/v3
+kind: NodeTestConf
+spec:
+  tests:
+    - name: ping
+      source: storage
+      targets: storage
+      timeout: soon
+`,
+			expected: []string{
+				"document 1, line 1: apiVersion: must match /v2$",
+				"document 1, line 7: spec.tests[0].targets: expected array, got string",
+				"document 1, line 8: spec.tests[0].timeout: expected integer, got string",
+				"document 1, line 1: metadata: required field is missing",
+			},
+		},
+		{
+			name: "error_in_second_document",
+			data: `apiVersion: openstack.kictl.icycloud.io/v1
+kind: CleanupConf
+metadata:
+  name: cleanup
+spec:
+  labelPrefixes: ["legacy-"]
+---
+apiVersion: openstack.kictl.icycloud.io/v1
+kind: NodeRouteConf
+metadata:
+  name: routes
+`,
+			expected: []string{"document 2, line 8: kind: unsupported config kind 'NodeRouteConf'. Expected: CleanupConf, Defaults, NodeLabelConf, NodeStorageConf, NodeSysctlConf, NodeTestConf, NodeVLANConf"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A bundle
+
+			// When: Validate it against the schemas
+			errs := ValidateSchema([]byte(tt.data))
+
+			// Then: Every violation is reported with its document, line and path
+			var messages []string
+			for _, err := range errs {
+				messages = append(messages, err.Error())
+			}
+			assert.Equal(t, tt.expected, messages)
+		})
+	}
+}
+
+// TestValidateSchema_GeneratedSample tests that the generated sample passes its own schema
+// WHY: A schema rejecting kictl's own output would be wrong, not the sample
+func TestValidateSchema_GeneratedSample(t *testing.T) {
+	// Given: The multi-CRD sample
+	path := filepath.Join(t.TempDir(), "sample.yaml")
+	require.NoError(t, GenerateMultiCRDSampleConfig(path))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	// When: Validate it
+	errs := ValidateSchema(data)
+
+	// Then: It is valid
+	assert.Empty(t, errs)
+}
+
+// TestLoadMultipleConfigsWithOptions_StrictSchema tests schema validation in the loader
+// WHY: Strict loading must refuse unknown fields that the default loader ignores
+func TestLoadMultipleConfigsWithOptions_StrictSchema(t *testing.T) {
+	// Given: A config with a misspelled field
+	path := filepath.Join(t.TempDir(), "labels.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`apiVersion: openstack.kictl.icycloud.io/v1
+kind: NodeLabelConf
+metadata:
+  name: labels
+spec:
+  nodeRoles:
+    worker:
+      nodes: ["rsb7"]
+      labels:
+        role: worker
+      descripton: typo
+`), 0644))
+
+	// When: Load it with and without strict schema validation
+	_, lenientErr := LoadMultipleConfigs(path)
+	_, strictErr := LoadMultipleConfigsWithOptions(path, LoadOptions{StrictSchema: true})
+
+	// Then: Only strict loading refuses it
+	assert.NoError(t, lenientErr)
+	assert.EqualError(t, strictErr, "schema validation failed:\n  document 1, line 11: spec.nodeRoles.worker.descripton: unknown field")
+}
+
+// TestKindSchema tests the JSON rendering of generated schemas
+// WHY: Editors read these files, so closed objects, maps and durations must use standard keywords
+func TestKindSchema(t *testing.T) {
+	// Given: The NodeVLANConf schema
+	schema, err := KindSchema("NodeVLANConf")
+	require.NoError(t, err)
+
+	// When: Render it as JSON
+	data, err := json.Marshal(schema)
+	require.NoError(t, err)
+	var rendered map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &rendered))
+
+	// Then: It uses standard keywords
+	assert.Equal(t, SchemaDraft, rendered["$schema"])
+	assert.Equal(t, "object", rendered["type"])
+	assert.Equal(t, false, rendered["additionalProperties"])
+	assert.Equal(t, []interface{}{"apiVersion", "kind", "metadata", "spec"}, rendered["required"])
+	properties := rendered["properties"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"const": "NodeVLANConf", "type": "string"}, properties["kind"])
+	vlans := properties["spec"].(map[string]interface{})["properties"].(map[string]interface{})["vlans"].(map[string]interface{})
+	assert.Equal(t, "object", vlans["additionalProperties"].(map[string]interface{})["type"])
+	assert.NotContains(t, properties, "tools")
+	netplanTry := properties["spec"].(map[string]interface{})["properties"].(map[string]interface{})["tools"].(map[string]interface{})["properties"].(map[string]interface{})["nvlan"].(map[string]interface{})["properties"].(map[string]interface{})["netplanTry"]
+	assert.Equal(t, []interface{}{"string", "integer"}, netplanTry.(map[string]interface{})["type"])
+
+	// When/Then: Unknown kinds have no schema, and the bundle schema offers every kind
+	_, err = KindSchema("NodeRouteConf")
+	assert.Error(t, err)
+	assert.Len(t, BundleSchema().OneOf, len(SchemaKinds()))
+}
@@ -0,0 +1,29 @@
+package config
+
+import "fmt"
+
+// Failure policies deciding what the orchestrator does after a service fails or times out
+const (
+	FailurePolicyContinue = "continue"
+	FailurePolicyAbort    = "abort"
+)
+
+// AbortsOnFailure reports whether a failure of this service stops the services after it
+func (t ToolConfig) AbortsOnFailure() bool {
+	return t.FailurePolicy == FailurePolicyAbort
+}
+
+// validateServiceOptions checks the service timeout and failure policy of a tool
+func validateServiceOptions(toolName string, tool ToolConfig) error {
+	if tool.ServiceTimeout < 0 {
+		return fmt.Errorf("tools.%s.serviceTimeout must not be negative, got %s", toolName, tool.ServiceTimeout)
+	}
+
+	switch tool.FailurePolicy {
+	case "", FailurePolicyContinue, FailurePolicyAbort:
+		return nil
+	default:
+		return fmt.Errorf("tools.%s.failurePolicy must be '%s' or '%s', got '%s'",
+			toolName, FailurePolicyContinue, FailurePolicyAbort, tool.FailurePolicy)
+	}
+}
@@ -0,0 +1,63 @@
+// Package config provides unit tests for service timeout and failure policy options
+// WHY: A service timeout or policy typo must fail at load time, not after half the services ran
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestValidateServiceOptions tests the service timeout and failure policy checks
+// WHY: Only non-negative timeouts and the two known policies are accepted
+func TestValidateServiceOptions(t *testing.T) {
+	tests := []struct {
+		name        string
+		tool        ToolConfig
+		expectError string
+	}{
+		{"unset", ToolConfig{}, ""},
+		{"timeout_and_abort", ToolConfig{ServiceTimeout: 20 * time.Minute, FailurePolicy: FailurePolicyAbort}, ""},
+		{"continue", ToolConfig{FailurePolicy: FailurePolicyContinue}, ""},
+		{"negative_timeout", ToolConfig{ServiceTimeout: -time.Second}, "tools.nvlan.serviceTimeout must not be negative, got -1s"},
+		{"unknown_policy", ToolConfig{FailurePolicy: "retry"}, "tools.nvlan.failurePolicy must be 'continue' or 'abort', got 'retry'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A tool configuration
+
+			// When: Validate its service options
+			err := validateServiceOptions("nvlan", tt.tool)
+
+			// Then: Invalid options are rejected with the tool named
+			if tt.expectError != "" {
+				require.Error(t, err)
+				assert.Equal(t, tt.expectError, err.Error())
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+// TestDecodeTools_ServiceOptions tests decoding service options from YAML
+// WHY: Timeouts are written as duration strings like 20m in the config files
+func TestDecodeTools_ServiceOptions(t *testing.T) {
+	// Given: Raw tool settings with a service timeout and failure policy
+	raw := map[string]interface{}{
+		"nvlan": map[string]interface{}{"serviceTimeout": "20m", "failurePolicy": "abort"},
+	}
+
+	// When: Decode them
+	var tools Tools
+	err := decodeTools(raw, &tools)
+
+	// Then: The timeout is a duration and only nvlan aborts on failure
+	require.NoError(t, err)
+	assert.Equal(t, 20*time.Minute, tools.Nvlan.ServiceTimeout)
+	assert.True(t, tools.Nvlan.AbortsOnFailure())
+	assert.False(t, tools.Ntest.AbortsOnFailure())
+}
@@ -0,0 +1,76 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SSHTransport runs node commands over SSH on nodes the cluster cannot schedule debug pods on
+// Commands on other nodes, and on these nodes when the SSH connection fails, run through the cluster.
+type SSHTransport struct {
+	Nodes                 []string          `json:"nodes" yaml:"nodes"`                                                     // Node names or glob patterns reached over SSH, "*" for every node
+	User                  string            `json:"user,omitempty" yaml:"user,omitempty"`                                   // Login user, default root; other users run commands with sudo -n
+	Port                  int               `json:"port,omitempty" yaml:"port,omitempty"`                                   // SSH port of the nodes, default 22
+	IdentityFiles         []string          `json:"identityFiles,omitempty" yaml:"identityFiles,omitempty"`                 // Private keys, default ~/.ssh/id_ed25519, id_ecdsa and id_rsa; the agent at $SSH_AUTH_SOCK is used too
+	Bastion               string            `json:"bastion,omitempty" yaml:"bastion,omitempty"`                             // Jump host as [user@]host[:port]
+	KnownHostsFile        string            `json:"knownHostsFile,omitempty" yaml:"knownHostsFile,omitempty"`               // Host keys of the nodes and the bastion, default ~/.ssh/known_hosts
+	InsecureIgnoreHostKey bool              `json:"insecureIgnoreHostKey,omitempty" yaml:"insecureIgnoreHostKey,omitempty"` // Skip host key checks; lab clusters only
+	Hosts                 map[string]string `json:"hosts,omitempty" yaml:"hosts,omitempty"`                                 // Address per node name, default the node's InternalIP
+}
+
+// ParseSSHBastion splits a jump host written as [user@]host[:port]; missing parts are returned empty or zero
+func ParseSSHBastion(bastion string) (user, host string, port int, err error) {
+	host = bastion
+	if at := strings.LastIndex(host, "@"); at >= 0 {
+		user, host = host[:at], host[at+1:]
+	}
+	if h, p, splitErr := net.SplitHostPort(host); splitErr == nil {
+		host = h
+		if port, err = strconv.Atoi(p); err != nil || port < 1 || port > 65535 {
+			return "", "", 0, fmt.Errorf("invalid port in SSH bastion '%s'", bastion)
+		}
+	}
+	if host == "" || strings.ContainsAny(host, "@/ ") {
+		return "", "", 0, fmt.Errorf("invalid SSH bastion '%s': expected [user@]host[:port]", bastion)
+	}
+	return user, host, port, nil
+}
+
+// validateSSHTransport checks the node patterns, port and bastion of the SSH transport
+func validateSSHTransport(transport *SSHTransport) error {
+	if transport == nil {
+		return nil
+	}
+	if len(transport.Nodes) == 0 {
+		return fmt.Errorf("spec.ssh.nodes must list the nodes reached over SSH, or \"*\" for every node")
+	}
+	for _, node := range transport.Nodes {
+		if _, err := path.Match(node, ""); err != nil {
+			return fmt.Errorf("spec.ssh.nodes: invalid node pattern '%s': %w", node, err)
+		}
+	}
+	if transport.Port < 0 || transport.Port > 65535 {
+		return fmt.Errorf("spec.ssh.port must be between 1 and 65535, got %d", transport.Port)
+	}
+	if transport.Bastion != "" {
+		if _, _, _, err := ParseSSHBastion(transport.Bastion); err != nil {
+			return fmt.Errorf("spec.ssh.bastion: %w", err)
+		}
+	}
+
+	nodes := make([]string, 0, len(transport.Hosts))
+	for node := range transport.Hosts {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+	for _, node := range nodes {
+		if transport.Hosts[node] == "" {
+			return fmt.Errorf("spec.ssh.hosts.%s needs an address", node)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// NodeLabels returns the labels of a node: spec.labels overridden by the node's own labels
+func (s NodeStorageSpec) NodeLabels(node string) map[string]string {
+	labels := make(map[string]string, len(s.Labels)+len(s.Nodes[node].Labels))
+	for key, value := range s.Labels {
+		labels[key] = value
+	}
+	for key, value := range s.Nodes[node].Labels {
+		labels[key] = value
+	}
+	return labels
+}
+
+// ZapDevices returns the devices marked zap per node, sorted by node
+func (s NodeStorageSpec) ZapDevices() []NodeDevice {
+	var devices []NodeDevice
+	for node, storageNode := range s.Nodes {
+		for _, device := range storageNode.Devices {
+			if device.Zap {
+				devices = append(devices, NodeDevice{Node: node, Path: device.Path})
+			}
+		}
+	}
+	sort.Slice(devices, func(i, j int) bool {
+		if devices[i].Node != devices[j].Node {
+			return devices[i].Node < devices[j].Node
+		}
+		return devices[i].Path < devices[j].Path
+	})
+	return devices
+}
+
+// NodeDevice is a device of a node
+type NodeDevice struct {
+	Node string
+	Path string
+}
+
+// validateNodeStorageConf validates storage device configuration
+func validateNodeStorageConf(config NodeStorageConf) error {
+	if config.Kind != "NodeStorageConf" {
+		return fmt.Errorf("config kind must be 'NodeStorageConf', got '%s'", config.Kind)
+	}
+
+	if err := validateAPIVersion(config.APIVersion); err != nil {
+		return err
+	}
+
+	if config.Metadata.Name == "" {
+		return fmt.Errorf("config metadata.name is required")
+	}
+
+	if len(config.Spec.Nodes) == 0 {
+		return fmt.Errorf("config must contain at least one node")
+	}
+
+	if err := validateStorageLabels("spec.labels", config.Spec.Labels); err != nil {
+		return err
+	}
+	for node, storageNode := range config.Spec.Nodes {
+		if err := validateStorageNode(node, storageNode); err != nil {
+			return err
+		}
+	}
+
+	if err := checkNodeNames(config.Tools.Nstorage.NodeNamePattern, nodeStorageConfNodes(config)); err != nil {
+		return err
+	}
+
+	return validateServiceOptions("nstorage", config.Tools.Nstorage)
+}
+
+// validateStorageNode checks that a node lists distinct devices below /dev and valid labels
+func validateStorageNode(node string, storageNode StorageNode) error {
+	if IsNodePattern(node) {
+		return fmt.Errorf("node pattern '%s' is not supported: devices are prepared on named nodes only", node)
+	}
+	if len(storageNode.Devices) == 0 {
+		return fmt.Errorf("node '%s' must list at least one device", node)
+	}
+
+	seen := make(map[string]bool, len(storageNode.Devices))
+	for _, device := range storageNode.Devices {
+		if !strings.HasPrefix(device.Path, "/dev/") || path.Clean(device.Path) != device.Path || strings.ContainsAny(device.Path, " \t\n'\"") {
+			return fmt.Errorf("node '%s' has invalid device path '%s': expected a block device below /dev/, e.g. /dev/disk/by-id/<id>", node, device.Path)
+		}
+		if seen[device.Path] {
+			return fmt.Errorf("node '%s' lists device '%s' twice", node, device.Path)
+		}
+		seen[device.Path] = true
+	}
+
+	return validateStorageLabels(fmt.Sprintf("node '%s'", node), storageNode.Labels)
+}
+
+// validateStorageLabels checks the keys and values of labels set on storage nodes
+func validateStorageLabels(location string, labels map[string]string) error {
+	for key, value := range labels {
+		if err := ValidateLabelKey(key); err != nil {
+			return fmt.Errorf("%s: %w", location, err)
+		}
+		if err := ValidateLabelValue(value); err != nil {
+			return fmt.Errorf("%s: label %s: %w", location, key, err)
+		}
+	}
+	return nil
+}
+
+// applyNodeStorageDefaults applies default values to NodeStorageConf
+func applyNodeStorageDefaults(config NodeStorageConf) NodeStorageConf {
+	// Set default namespace if not specified
+	if config.Metadata.Namespace == "" {
+		config.Metadata.Namespace = "default"
+	}
+
+	return config
+}
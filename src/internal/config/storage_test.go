@@ -0,0 +1,139 @@
+// Package config provides unit tests for the storage device configuration
+// WHY: Devices may be wiped on apply, so a document naming anything but distinct block devices of named nodes must never load
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLoadConfig_NodeStorageConf tests loading and validation of storage device documents
+// WHY: A typo in a device path must fail before any device is touched, not on the node
+func TestLoadConfig_NodeStorageConf(t *testing.T) {
+	tests := []struct {
+		name      string
+		spec      string
+		errorText string
+	}{
+		{
+			name: "devices_and_labels",
+			spec: `  labels:
+    ceph-osd: enabled
+  nodes:
+    rsb2:
+      devices:
+        - path: /dev/disk/by-id/nvme-SAMSUNG_S64HNE0R123456
+          zap: true
+        - path: /dev/sdc
+      labels:
+        topology.rook.io/rack: rack1
+    rsb3:
+      devices:
+        - path: /dev/sdb`,
+		},
+		{
+			name: "relative_device_path",
+			spec: `  nodes:
+    rsb2:
+      devices:
+        - path: sdb`,
+			errorText: "node 'rsb2' has invalid device path 'sdb'",
+		},
+		{
+			name: "device_path_escaping_dev",
+			spec: `  nodes:
+    rsb2:
+      devices:
+        - path: /dev/../etc/passwd`,
+			errorText: "node 'rsb2' has invalid device path '/dev/../etc/passwd'",
+		},
+		{
+			name: "duplicate_device",
+			spec: `  nodes:
+    rsb2:
+      devices:
+        - path: /dev/sdb
+        - path: /dev/sdb
+          zap: true`,
+			errorText: "node 'rsb2' lists device '/dev/sdb' twice",
+		},
+		{
+			name: "node_without_devices",
+			spec: `  nodes:
+    rsb2:
+      labels:
+        ceph-osd: enabled`,
+			errorText: "node 'rsb2' must list at least one device",
+		},
+		{
+			name: "node_pattern",
+			spec: `  nodes:
+    storage-*:
+      devices:
+        - path: /dev/sdb`,
+			errorText: "node pattern 'storage-*' is not supported",
+		},
+		{
+			name: "invalid_label",
+			spec: `  labels:
+    ceph-osd: "yes please"
+  nodes:
+    rsb2:
+      devices:
+        - path: /dev/sdb`,
+			errorText: "spec.labels: label ceph-osd: label value 'yes please'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: NodeStorageConf document
+			configData := `apiVersion: openstack.kictl.icycloud.io/v1
+kind: NodeStorageConf
+metadata:
+  name: ceph-osds
+spec:
+` + tt.spec
+			configPath := filepath.Join(t.TempDir(), "storage.yaml")
+			require.NoError(t, os.WriteFile(configPath, []byte(configData), 0644))
+
+			// When: Load as bundle
+			bundle, err := LoadMultipleConfigs(configPath)
+
+			// Then: Valid documents load with their nodes and devices, invalid ones name the problem
+			if tt.errorText != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errorText)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, bundle.HasStorage())
+			assert.Equal(t, []string{"rsb2", "rsb3"}, bundle.GetAllNodeNames())
+			assert.Contains(t, bundle.GetSummary(), "Storage(2 nodes, 3 devices)")
+			assert.Equal(t, []NodeDevice{{Node: "rsb2", Path: "/dev/disk/by-id/nvme-SAMSUNG_S64HNE0R123456"}}, bundle.Storage.Spec.ZapDevices())
+			assert.Equal(t, map[string]string{"ceph-osd": "enabled", "topology.rook.io/rack": "rack1"}, bundle.Storage.Spec.NodeLabels("rsb2"))
+		})
+	}
+}
+
+// TestConfigBundle_RestrictToNodesStorage tests restricting storage devices to the listed nodes
+// WHY: Preparing the disks of a new host with --nodes must never zap a device of another node
+func TestConfigBundle_RestrictToNodesStorage(t *testing.T) {
+	// Given: A storage bundle zapping a device on rsb2 and rsb3
+	bundle := NewSingleConfigBundle(&NodeStorageConf{Spec: NodeStorageSpec{Nodes: map[string]StorageNode{
+		"rsb2": {Devices: []StorageDevice{{Path: "/dev/sdb", Zap: true}}},
+		"rsb3": {Devices: []StorageDevice{{Path: "/dev/sdb", Zap: true}}},
+	}}})
+
+	// When: Restrict it to rsb3
+	restricted, err := bundle.RestrictToNodes([]string{"rsb3"})
+
+	// Then: Only the device of rsb3 is left to zap, and the bundle itself is unchanged
+	require.NoError(t, err)
+	assert.Equal(t, []NodeDevice{{Node: "rsb3", Path: "/dev/sdb"}}, restricted.Storage.Spec.ZapDevices())
+	assert.Len(t, bundle.Storage.Spec.Nodes, 2)
+}
@@ -0,0 +1,143 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// HugepageSizes maps the supported hugepage sizes to their size in kB, as named below /sys/kernel/mm/hugepages
+var HugepageSizes = map[string]int{
+	"2Mi": 2048,
+	"1Gi": 1048576,
+}
+
+// sysctlKeyPattern matches sysctl names, e.g. net.ipv4.conf.eth0/100.rp_filter; a / stands for a dot within a name
+var sysctlKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_]+(\.[A-Za-z0-9_/:@-]+)+$`)
+
+// KernelSettings are the sysctls and hugepage reservations of one node, merged from its roles
+type KernelSettings struct {
+	Sysctls   map[string]string
+	Hugepages map[string]int
+}
+
+// NodeSettings merges the settings of every role per node
+// A node in several roles must not get different values for the same setting from them.
+func (s NodeSysctlSpec) NodeSettings() (map[string]KernelSettings, error) {
+	roleNames := make([]string, 0, len(s.NodeRoles))
+	for roleName := range s.NodeRoles {
+		roleNames = append(roleNames, roleName)
+	}
+	sort.Strings(roleNames)
+
+	settings := make(map[string]KernelSettings)
+	sysctlRoles := make(map[string]string) // node/key -> role setting it, for conflict messages
+	for _, roleName := range roleNames {
+		role := s.NodeRoles[roleName]
+		for _, node := range role.Nodes {
+			nodeSettings, ok := settings[node]
+			if !ok {
+				nodeSettings = KernelSettings{Sysctls: make(map[string]string), Hugepages: make(map[string]int)}
+				settings[node] = nodeSettings
+			}
+			for key, value := range role.Sysctls {
+				if existing, ok := nodeSettings.Sysctls[key]; ok && existing != value {
+					return nil, fmt.Errorf("node '%s' gets sysctl %s = %s from role '%s' but %s from role '%s'",
+						node, key, existing, sysctlRoles[node+"/"+key], value, roleName)
+				}
+				nodeSettings.Sysctls[key] = value
+				sysctlRoles[node+"/"+key] = roleName
+			}
+			for size, count := range role.Hugepages {
+				if existing, ok := nodeSettings.Hugepages[size]; ok && existing != count {
+					return nil, fmt.Errorf("node '%s' gets %d %s hugepages from role '%s' but %d from role '%s'",
+						node, existing, size, sysctlRoles[node+"/hugepages-"+size], count, roleName)
+				}
+				nodeSettings.Hugepages[size] = count
+				sysctlRoles[node+"/hugepages-"+size] = roleName
+			}
+		}
+	}
+	return settings, nil
+}
+
+// validateNodeSysctlConf validates kernel tuning configuration
+func validateNodeSysctlConf(config NodeSysctlConf) error {
+	if config.Kind != "NodeSysctlConf" {
+		return fmt.Errorf("config kind must be 'NodeSysctlConf', got '%s'", config.Kind)
+	}
+
+	if err := validateAPIVersion(config.APIVersion); err != nil {
+		return err
+	}
+
+	if config.Metadata.Name == "" {
+		return fmt.Errorf("config metadata.name is required")
+	}
+
+	if len(config.Spec.NodeRoles) == 0 {
+		return fmt.Errorf("config must contain at least one node role")
+	}
+
+	for roleName, role := range config.Spec.NodeRoles {
+		if err := validateSysctlRole(roleName, role); err != nil {
+			return err
+		}
+	}
+
+	if _, err := config.Spec.NodeSettings(); err != nil {
+		return err
+	}
+
+	if err := checkNodeNames(config.Tools.Nsysctl.NodeNamePattern, nodeSysctlConfNodes(config)); err != nil {
+		return err
+	}
+
+	return validateServiceOptions("nsysctl", config.Tools.Nsysctl)
+}
+
+// validateSysctlRole checks that a role names its nodes and sets valid sysctls and hugepage reservations
+func validateSysctlRole(roleName string, role SysctlRole) error {
+	if len(role.Nodes) == 0 {
+		return fmt.Errorf("role '%s' must list at least one node", roleName)
+	}
+	for _, node := range role.Nodes {
+		if IsNodePattern(node) {
+			return fmt.Errorf("role '%s' lists node pattern '%s': kernel settings are applied to named nodes only", roleName, node)
+		}
+	}
+
+	if len(role.Sysctls) == 0 && len(role.Hugepages) == 0 {
+		return fmt.Errorf("role '%s' must set sysctls or hugepages", roleName)
+	}
+
+	for key, value := range role.Sysctls {
+		if !sysctlKeyPattern.MatchString(key) {
+			return fmt.Errorf("role '%s' has invalid sysctl name '%s'", roleName, key)
+		}
+		if strings.TrimSpace(value) == "" || strings.ContainsAny(value, "\n\r") {
+			return fmt.Errorf("role '%s' has invalid value '%s' for sysctl %s", roleName, value, key)
+		}
+	}
+
+	for size, count := range role.Hugepages {
+		if _, ok := HugepageSizes[size]; !ok {
+			return fmt.Errorf("role '%s' has unsupported hugepage size '%s'. Expected: 2Mi or 1Gi", roleName, size)
+		}
+		if count < 0 {
+			return fmt.Errorf("role '%s' reserves a negative number of %s hugepages: %d", roleName, size, count)
+		}
+	}
+	return nil
+}
+
+// applyNodeSysctlDefaults applies default values to NodeSysctlConf
+func applyNodeSysctlDefaults(config NodeSysctlConf) NodeSysctlConf {
+	// Set default namespace if not specified
+	if config.Metadata.Namespace == "" {
+		config.Metadata.Namespace = "default"
+	}
+
+	return config
+}
@@ -0,0 +1,136 @@
+// Package config provides unit tests for the kernel tuning configuration
+// WHY: Kernel settings are applied as root on every node of a role, so a malformed or conflicting document must never load
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLoadConfig_NodeSysctlConf tests loading and validation of kernel tuning documents
+// WHY: A node in two roles must get one value per setting, and only sizes the kernel offers can be reserved
+func TestLoadConfig_NodeSysctlConf(t *testing.T) {
+	tests := []struct {
+		name        string
+		spec        string
+		errorText   string
+		expectNodes []string
+	}{
+		{
+			name: "sysctls_and_hugepages",
+			spec: `  nodeRoles:
+    compute:
+      nodes: ["rsb2", "rsb3"]
+      sysctls:
+        net.ipv4.ip_forward: "1"
+        net.ipv4.conf.all.rp_filter: "0"
+      hugepages:
+        1Gi: 16
+    storage:
+      nodes: ["rsb3"]
+      sysctls:
+        net.ipv4.ip_forward: "1"`,
+			expectNodes: []string{"rsb2", "rsb3"},
+		},
+		{
+			name: "conflicting_roles",
+			spec: `  nodeRoles:
+    compute:
+      nodes: ["rsb2"]
+      sysctls:
+        net.ipv4.conf.all.rp_filter: "0"
+    storage:
+      nodes: ["rsb2"]
+      sysctls:
+        net.ipv4.conf.all.rp_filter: "1"`,
+			errorText: "node 'rsb2' gets sysctl net.ipv4.conf.all.rp_filter = 0 from role 'compute' but 1 from role 'storage'",
+		},
+		{
+			name: "unsupported_hugepage_size",
+			spec: `  nodeRoles:
+    compute:
+      nodes: ["rsb2"]
+      hugepages:
+        4Mi: 16`,
+			errorText: "role 'compute' has unsupported hugepage size '4Mi'",
+		},
+		{
+			name: "invalid_sysctl_name",
+			spec: `  nodeRoles:
+    compute:
+      nodes: ["rsb2"]
+      sysctls:
+        "net ipv4": "1"`,
+			errorText: "role 'compute' has invalid sysctl name 'net ipv4'",
+		},
+		{
+			name: "node_pattern",
+			spec: `  nodeRoles:
+    compute:
+      nodes: ["compute-*"]
+      sysctls:
+        net.ipv4.ip_forward: "1"`,
+			errorText: "role 'compute' lists node pattern 'compute-*'",
+		},
+		{
+			name: "nothing_to_set",
+			spec: `  nodeRoles:
+    compute:
+      nodes: ["rsb2"]`,
+			errorText: "role 'compute' must set sysctls or hugepages",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: NodeSysctlConf document
+			configData := `apiVersion: openstack.kictl.icycloud.io/v1
+kind: NodeSysctlConf
+metadata:
+  name: kernel-tuning
+spec:
+` + tt.spec
+			configPath := filepath.Join(t.TempDir(), "sysctl.yaml")
+			require.NoError(t, os.WriteFile(configPath, []byte(configData), 0644))
+
+			// When: Load as bundle
+			bundle, err := LoadMultipleConfigs(configPath)
+
+			// Then: Valid documents load with their nodes, invalid ones name the problem
+			if tt.errorText != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errorText)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, bundle.HasSysctls())
+			assert.Equal(t, "default", bundle.Sysctls.Metadata.Namespace)
+			assert.Equal(t, tt.expectNodes, bundle.GetAllNodeNames())
+			assert.Contains(t, bundle.GetSummary(), "Sysctls(2 roles)")
+		})
+	}
+}
+
+// TestNodeSysctlSpec_NodeSettings tests merging the settings of every role of a node
+// WHY: Apply writes one file per node, which must hold the settings of all its roles
+func TestNodeSysctlSpec_NodeSettings(t *testing.T) {
+	// Given: rsb3 in both roles
+	spec := NodeSysctlSpec{NodeRoles: map[string]SysctlRole{
+		"compute": {Nodes: []string{"rsb2", "rsb3"}, Sysctls: map[string]string{"net.ipv4.ip_forward": "1"}, Hugepages: map[string]int{"1Gi": 16}},
+		"storage": {Nodes: []string{"rsb3"}, Sysctls: map[string]string{"vm.swappiness": "10"}},
+	}}
+
+	// When: Merge the settings per node
+	settings, err := spec.NodeSettings()
+
+	// Then: Each node holds the settings of all its roles
+	require.NoError(t, err)
+	assert.Equal(t, map[string]KernelSettings{
+		"rsb2": {Sysctls: map[string]string{"net.ipv4.ip_forward": "1"}, Hugepages: map[string]int{"1Gi": 16}},
+		"rsb3": {Sysctls: map[string]string{"net.ipv4.ip_forward": "1", "vm.swappiness": "10"}, Hugepages: map[string]int{"1Gi": 16}},
+	}, settings)
+}
@@ -1,6 +1,8 @@
 // Package config defines configuration structures for k8ostack-ictl
 package config
 
+import "time"
+
 // Kubernetes-style metadata
 type Metadata struct {
 	Name      string            `json:"name" yaml:"name"`
@@ -10,9 +12,12 @@ type Metadata struct {
 
 // NodeRole represents a role configuration with multiple labels
 type NodeRole struct {
-	Nodes       []string          `json:"nodes" yaml:"nodes"`
-	Labels      map[string]string `json:"labels" yaml:"labels"`
-	Description string            `json:"description,omitempty" yaml:"description,omitempty"`
+	// Nodes lists node names or name glob patterns, e.g. "compute-*"
+	Nodes []string `json:"nodes" yaml:"nodes"`
+	// NodeSelector additionally targets every node matching this label selector, e.g. "node-role.kubernetes.io/worker"
+	NodeSelector string            `json:"nodeSelector,omitempty" yaml:"nodeSelector,omitempty"`
+	Labels       map[string]string `json:"labels" yaml:"labels"`
+	Description  string            `json:"description,omitempty" yaml:"description,omitempty"`
 }
 
 // ToolConfig represents tool-specific configuration
@@ -21,11 +26,26 @@ type ToolConfig struct {
 	DryRun        bool   `json:"dryRun,omitempty" yaml:"dryRun,omitempty"`
 	ValidateNodes bool   `json:"validateNodes,omitempty" yaml:"validateNodes,omitempty"`
 	LogLevel      string `json:"logLevel,omitempty" yaml:"logLevel,omitempty"`
-	
+
+	// Policy options
+	NodeNamePattern string `json:"nodeNamePattern,omitempty" yaml:"nodeNamePattern,omitempty"` // Regex every node name must fully match
+
+	// Service options, enforced around the whole service run rather than each command
+	ServiceTimeout time.Duration `json:"serviceTimeout,omitempty" yaml:"serviceTimeout,omitempty"` // e.g. 20m; 0 means no limit
+	FailurePolicy  string        `json:"failurePolicy,omitempty" yaml:"failurePolicy,omitempty"`   // continue (default) or abort the services after a failure
+
+	// NodeLabel-specific options
+	BulkLabel bool `json:"bulkLabel,omitempty" yaml:"bulkLabel,omitempty"` // Label the nodes of a role together instead of one by one
+
 	// VLAN-specific options
-	ValidateConnectivity bool `json:"validateConnectivity,omitempty" yaml:"validateConnectivity,omitempty"`
-	PersistentConfig     bool `json:"persistentConfig,omitempty" yaml:"persistentConfig,omitempty"`
-	
+	ValidateConnectivity bool               `json:"validateConnectivity,omitempty" yaml:"validateConnectivity,omitempty"`
+	PersistentConfig     bool               `json:"persistentConfig,omitempty" yaml:"persistentConfig,omitempty"`
+	PersistenceBackend   string             `json:"persistenceBackend,omitempty" yaml:"persistenceBackend,omitempty"` // netplan (default), networkmanager, networkd, ifcfg or auto
+	NetplanTry           time.Duration      `json:"netplanTry,omitempty" yaml:"netplanTry,omitempty"`                 // e.g. 2m; apply netplan files with netplan try, reverted unless confirmed in time
+	ReachabilityGuard    *ReachabilityGuard `json:"reachabilityGuard,omitempty" yaml:"reachabilityGuard,omitempty"`   // Refuse changes to the interface kictl reaches a node through without another way in
+	BMC                  *BMCRecovery       `json:"bmc,omitempty" yaml:"bmc,omitempty"`                               // Console log or power-cycle through the BMC of a node lost during a network change
+	MembershipLabels     bool               `json:"membershipLabels,omitempty" yaml:"membershipLabels,omitempty"`     // Label nodes with their VLANs, e.g. network.kictl.io/vlan-management=192.168.100.12
+
 	// NetHealthCheck-specific options
 	Parallel     bool     `json:"parallel,omitempty" yaml:"parallel,omitempty"`
 	Retries      int      `json:"retries,omitempty" yaml:"retries,omitempty"`
@@ -35,7 +55,9 @@ type ToolConfig struct {
 
 // NodeLabelSpec contains the specification for node labeling operations
 type NodeLabelSpec struct {
-	NodeRoles map[string]NodeRole `json:"nodeRoles" yaml:"nodeRoles"`
+	// LabelPrefix is prepended to bare label keys (keys without '/'), e.g. "openstack.icycloud.io/"
+	LabelPrefix string              `json:"labelPrefix,omitempty" yaml:"labelPrefix,omitempty"`
+	NodeRoles   map[string]NodeRole `json:"nodeRoles" yaml:"nodeRoles"`
 }
 
 // Tools contains tool-specific configurations for the infrastructure control platform
@@ -44,8 +66,10 @@ type Tools struct {
 	Nlabel ToolConfig `json:"nlabel,omitempty" yaml:"nlabel,omitempty"`
 
 	// Future service configurations
-	Nvlan ToolConfig `json:"nvlan,omitempty" yaml:"nvlan,omitempty"` // VLAN configuration service
-	Ntest ToolConfig `json:"ntest,omitempty" yaml:"ntest,omitempty"` // Network testing service
+	Nvlan    ToolConfig `json:"nvlan,omitempty" yaml:"nvlan,omitempty"`       // VLAN configuration service
+	Ntest    ToolConfig `json:"ntest,omitempty" yaml:"ntest,omitempty"`       // Network testing service
+	Nsysctl  ToolConfig `json:"nsysctl,omitempty" yaml:"nsysctl,omitempty"`   // Kernel tuning service
+	Nstorage ToolConfig `json:"nstorage,omitempty" yaml:"nstorage,omitempty"` // Storage device preparation service
 }
 
 // NodeLabelConf represents the CRD-based node labeling configuration
@@ -73,10 +97,18 @@ type NodeVLANSpec struct {
 
 // VLANConfig represents a single VLAN configuration
 type VLANConfig struct {
-	ID          int               `json:"id" yaml:"id"`
-	Subnet      string            `json:"subnet" yaml:"subnet"`
-	Interface   string            `json:"interface,omitempty" yaml:"interface,omitempty"`
-	NodeMapping map[string]string `json:"nodeMapping" yaml:"nodeMapping"`
+	ID          int         `json:"id" yaml:"id"`
+	Subnet      string      `json:"subnet" yaml:"subnet"`
+	Interface   string      `json:"interface,omitempty" yaml:"interface,omitempty"`
+	OVS         *OVSConfig  `json:"ovs,omitempty" yaml:"ovs,omitempty"`
+	NodeMapping NodeMapping `json:"nodeMapping" yaml:"nodeMapping"`
+}
+
+// OVSConfig attaches a VLAN to an Open vSwitch bridge as a tagged internal port instead of a VLAN sub-interface
+type OVSConfig struct {
+	Bridge string `json:"bridge" yaml:"bridge"`                 // e.g., "br-ex"
+	Port   string `json:"port,omitempty" yaml:"port,omitempty"` // internal port carrying the addresses, vlan<id> by default
+	Tag    int    `json:"tag,omitempty" yaml:"tag,omitempty"`   // access tag of the port, the VLAN ID by default
 }
 
 // NodeTestConf represents connectivity testing configuration
@@ -97,10 +129,102 @@ type NodeTestSpec struct {
 type ConnectivityTest struct {
 	Name          string   `json:"name" yaml:"name"`
 	Description   string   `json:"description,omitempty" yaml:"description,omitempty"`
+	Type          string   `json:"type,omitempty" yaml:"type,omitempty"` // ping (default), tcp, http or bandwidth
 	Source        string   `json:"source" yaml:"source"`
 	Targets       []string `json:"targets" yaml:"targets"`
-	Timeout       int      `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	Timeout       int      `json:"timeout,omitempty" yaml:"timeout,omitempty"` // Seconds each probe of the test may take, default 30
 	ExpectSuccess bool     `json:"expectSuccess,omitempty" yaml:"expectSuccess,omitempty"`
+	Retries       int      `json:"retries,omitempty" yaml:"retries,omitempty"`       // Attempts after a failure, default tools.ntest.retries
+	RetryDelay    int      `json:"retryDelay,omitempty" yaml:"retryDelay,omitempty"` // Seconds between attempts, default 5
+
+	// TCP and bandwidth tests only
+	Port int `json:"port,omitempty" yaml:"port,omitempty"` // Port connected to on every target; for bandwidth the iperf3 server, default 5201
+
+	// Bandwidth tests only
+	MinMbps  float64 `json:"minMbps,omitempty" yaml:"minMbps,omitempty"`   // Lowest acceptable throughput to every target
+	Duration int     `json:"duration,omitempty" yaml:"duration,omitempty"` // Seconds iperf3 sends for, default 10
+
+	// HTTP tests only
+	URL          string `json:"url,omitempty" yaml:"url,omitempty"`                   // Endpoint requested from the source, e.g. https://keystone.mgmt:5000/v3
+	ExpectStatus int    `json:"expectStatus,omitempty" yaml:"expectStatus,omitempty"` // Status code the endpoint must answer with, default 200
+	Insecure     bool   `json:"insecure,omitempty" yaml:"insecure,omitempty"`         // Skip TLS certificate verification
+}
+
+// Types of connectivity tests
+const (
+	TestTypePing      = "ping"      // ICMP reachability
+	TestTypeTCP       = "tcp"       // TCP connect to a port of every target
+	TestTypeHTTP      = "http"      // HTTP status of a URL
+	TestTypeBandwidth = "bandwidth" // TCP throughput measured with iperf3
+)
+
+// TestTypes lists the supported types of connectivity tests
+var TestTypes = []string{TestTypePing, TestTypeTCP, TestTypeHTTP, TestTypeBandwidth}
+
+// CleanupConf represents bulk removal of labels by key prefix
+type CleanupConf struct {
+	APIVersion string      `json:"apiVersion" yaml:"apiVersion"`
+	Kind       string      `json:"kind" yaml:"kind"`
+	Metadata   Metadata    `json:"metadata" yaml:"metadata"`
+	Spec       CleanupSpec `json:"spec" yaml:"spec"`
+	Tools      Tools       `json:"tools,omitempty" yaml:"tools,omitempty"`
+}
+
+// CleanupSpec contains the specification for label cleanup operations
+type CleanupSpec struct {
+	// LabelPrefixes selects label keys to remove, e.g. "openstack-role" or "legacy.icycloud.io/"
+	LabelPrefixes []string `json:"labelPrefixes" yaml:"labelPrefixes"`
+	Nodes         []string `json:"nodes,omitempty" yaml:"nodes,omitempty"`               // Explicit node names
+	NodeSelector  string   `json:"nodeSelector,omitempty" yaml:"nodeSelector,omitempty"` // Label selector for node discovery
+}
+
+// NodeSysctlConf represents kernel tuning of nodes: sysctls and hugepage reservations per role
+type NodeSysctlConf struct {
+	APIVersion string         `json:"apiVersion" yaml:"apiVersion"`
+	Kind       string         `json:"kind" yaml:"kind"`
+	Metadata   Metadata       `json:"metadata" yaml:"metadata"`
+	Spec       NodeSysctlSpec `json:"spec" yaml:"spec"`
+	Tools      Tools          `json:"tools,omitempty" yaml:"tools,omitempty"`
+}
+
+// NodeSysctlSpec contains the specification for kernel tuning operations
+type NodeSysctlSpec struct {
+	NodeRoles map[string]SysctlRole `json:"nodeRoles" yaml:"nodeRoles"`
+}
+
+// SysctlRole holds the kernel settings of a group of nodes
+type SysctlRole struct {
+	Nodes       []string          `json:"nodes" yaml:"nodes"`                             // Node names; a node in several roles gets the settings of all of them
+	Sysctls     map[string]string `json:"sysctls,omitempty" yaml:"sysctls,omitempty"`     // e.g. net.ipv4.ip_forward: "1"
+	Hugepages   map[string]int    `json:"hugepages,omitempty" yaml:"hugepages,omitempty"` // Pages reserved per page size, e.g. 1Gi: 16
+	Description string            `json:"description,omitempty" yaml:"description,omitempty"`
+}
+
+// NodeStorageConf represents the block devices of nodes prepared for Ceph OSDs and the labels Rook selects the nodes by
+type NodeStorageConf struct {
+	APIVersion string          `json:"apiVersion" yaml:"apiVersion"`
+	Kind       string          `json:"kind" yaml:"kind"`
+	Metadata   Metadata        `json:"metadata" yaml:"metadata"`
+	Spec       NodeStorageSpec `json:"spec" yaml:"spec"`
+	Tools      Tools           `json:"tools,omitempty" yaml:"tools,omitempty"`
+}
+
+// NodeStorageSpec contains the specification for storage device preparation
+type NodeStorageSpec struct {
+	Labels map[string]string      `json:"labels,omitempty" yaml:"labels,omitempty"` // Set on every node once its devices are ready, e.g. ceph-osd: "enabled"
+	Nodes  map[string]StorageNode `json:"nodes" yaml:"nodes"`                       // Node name -> its devices
+}
+
+// StorageNode holds the devices of one node handed to Ceph
+type StorageNode struct {
+	Devices []StorageDevice   `json:"devices" yaml:"devices"`
+	Labels  map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"` // Labels of this node only, overriding spec.labels
+}
+
+// StorageDevice is a block device prepared for a Ceph OSD
+type StorageDevice struct {
+	Path string `json:"path" yaml:"path"`                   // e.g. /dev/disk/by-id/nvme-SAMSUNG_MZQL23T8HCLS-00A07_S64HNE0R123456
+	Zap  bool   `json:"zap,omitempty" yaml:"zap,omitempty"` // Wipe partition tables and signatures when the device holds any; destroys its data
 }
 
 // Common interface for all config types
@@ -178,3 +302,69 @@ func (c NodeTestConf) GetNodeRoles() map[string]NodeRole {
 func (c NodeTestConf) GetTools() Tools {
 	return c.Tools
 }
+
+// Implement Config interface for CleanupConf
+func (c CleanupConf) GetAPIVersion() string {
+	return c.APIVersion
+}
+
+func (c CleanupConf) GetKind() string {
+	return c.Kind
+}
+
+func (c CleanupConf) GetMetadata() Metadata {
+	return c.Metadata
+}
+
+func (c CleanupConf) GetNodeRoles() map[string]NodeRole {
+	// Cleanup selects nodes directly rather than through roles
+	return make(map[string]NodeRole)
+}
+
+func (c CleanupConf) GetTools() Tools {
+	return c.Tools
+}
+
+// Implement Config interface for NodeSysctlConf
+func (c NodeSysctlConf) GetAPIVersion() string {
+	return c.APIVersion
+}
+
+func (c NodeSysctlConf) GetKind() string {
+	return c.Kind
+}
+
+func (c NodeSysctlConf) GetMetadata() Metadata {
+	return c.Metadata
+}
+
+func (c NodeSysctlConf) GetNodeRoles() map[string]NodeRole {
+	// Sysctl roles carry kernel settings rather than labels
+	return make(map[string]NodeRole)
+}
+
+func (c NodeSysctlConf) GetTools() Tools {
+	return c.Tools
+}
+
+// Implement Config interface for NodeStorageConf
+func (c NodeStorageConf) GetAPIVersion() string {
+	return c.APIVersion
+}
+
+func (c NodeStorageConf) GetKind() string {
+	return c.Kind
+}
+
+func (c NodeStorageConf) GetMetadata() Metadata {
+	return c.Metadata
+}
+
+func (c NodeStorageConf) GetNodeRoles() map[string]NodeRole {
+	// Storage nodes carry devices rather than roles
+	return make(map[string]NodeRole)
+}
+
+func (c NodeStorageConf) GetTools() Tools {
+	return c.Tools
+}
@@ -0,0 +1,235 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// APIGroup is the group of the apiVersion of every configuration kind
+const APIGroup = "openstack.kictl.icycloud.io"
+
+// API versions of configuration documents
+const (
+	APIVersionV1     = APIGroup + "/v1" // tools next to spec
+	APIVersionV2     = APIGroup + "/v2" // tools inside spec like in Defaults, so documents are valid custom resources
+	LatestAPIVersion = APIVersionV2
+)
+
+// documentVersion converts the documents of one API version to and from the internal layout the configuration types
+// decode from; nil conversions leave documents and schemas as they are
+type documentVersion struct {
+	toInternal   func(kind string, root *yaml.Node) error
+	fromInternal func(kind string, root *yaml.Node) error
+	schema       func(kind string, schema *Schema) // Adapts the schema generated from the types to the version
+}
+
+// documentVersions maps each supported version, the part of apiVersion after the last '/', to its conversions
+// The internal layout is v1, so v1 documents load unchanged.
+var documentVersions = map[string]documentVersion{
+	"v1": {},
+	"v2": {toInternal: toolsOutOfSpec, fromInternal: toolsIntoSpec, schema: toolsSchemaIntoSpec},
+}
+
+// APIVersions lists the supported versions, e.g. v1 and v2, sorted
+func APIVersions() []string {
+	versions := make([]string, 0, len(documentVersions))
+	for version := range documentVersions {
+		versions = append(versions, version)
+	}
+	sort.Strings(versions)
+	return versions
+}
+
+// versionOf returns the version of an apiVersion, e.g. v2 for openstack.kictl.icycloud.io/v2, or "" without a group
+func versionOf(apiVersion string) string {
+	i := strings.LastIndex(apiVersion, "/")
+	if i < 0 {
+		return ""
+	}
+	return apiVersion[i+1:]
+}
+
+// validateAPIVersion checks that the apiVersion of a configuration ends with a supported version
+func validateAPIVersion(apiVersion string) error {
+	if _, ok := documentVersions[versionOf(apiVersion)]; !ok {
+		return fmt.Errorf("config apiVersion must end with '/%s', got '%s'", strings.Join(APIVersions(), "' or '/"), apiVersion)
+	}
+	return nil
+}
+
+// internalDocument converts a document to the internal layout, returning documents of unknown versions unchanged
+// for validation to reject, and v1 documents unchanged byte for byte.
+func internalDocument(data []byte) ([]byte, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil || len(document.Content) == 0 || document.Content[0].Kind != yaml.MappingNode {
+		return data, nil
+	}
+	root := document.Content[0]
+	version, ok := documentVersions[versionOf(scalarValue(root, "apiVersion"))]
+	if !ok || version.toInternal == nil {
+		return data, nil
+	}
+
+	if err := version.toInternal(scalarValue(root, "kind"), root); err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(&document)
+}
+
+// ConvertDocuments rewrites every document of a bundle to an API version such as v2, keeping comments
+// It returns the bundle and how many documents were converted; a bundle already at that version is returned unchanged.
+func ConvertDocuments(data []byte, version string) ([]byte, int, error) {
+	target, ok := documentVersions[version]
+	if !ok {
+		return nil, 0, fmt.Errorf("unsupported API version '%s'. Expected: %s", version, strings.Join(APIVersions(), ", "))
+	}
+
+	var documents []*yaml.Node
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var document yaml.Node
+		if err := decoder.Decode(&document); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, 0, fmt.Errorf("failed to parse document %d: %w", len(documents)+1, err)
+		}
+		documents = append(documents, &document)
+	}
+
+	converted := 0
+	for i, document := range documents {
+		if len(document.Content) == 0 || document.Content[0].Kind != yaml.MappingNode {
+			continue
+		}
+		root := document.Content[0]
+		apiVersion := mappingValue(root, "apiVersion")
+		if apiVersion == nil {
+			return nil, 0, fmt.Errorf("document %d has no apiVersion", i+1)
+		}
+		current, ok := documentVersions[versionOf(apiVersion.Value)]
+		if !ok {
+			return nil, 0, fmt.Errorf("document %d: %w", i+1, validateAPIVersion(apiVersion.Value))
+		}
+		if versionOf(apiVersion.Value) == version {
+			continue
+		}
+
+		kind := scalarValue(root, "kind")
+		if current.toInternal != nil {
+			if err := current.toInternal(kind, root); err != nil {
+				return nil, 0, fmt.Errorf("document %d: %w", i+1, err)
+			}
+		}
+		if target.fromInternal != nil {
+			if err := target.fromInternal(kind, root); err != nil {
+				return nil, 0, fmt.Errorf("document %d: %w", i+1, err)
+			}
+		}
+		apiVersion.Value = strings.TrimSuffix(apiVersion.Value, versionOf(apiVersion.Value)) + version
+		converted++
+	}
+	if converted == 0 {
+		return data, 0, nil
+	}
+
+	var out bytes.Buffer
+	encoder := yaml.NewEncoder(&out)
+	encoder.SetIndent(2)
+	for _, document := range documents {
+		if err := encoder.Encode(document); err != nil {
+			return nil, 0, fmt.Errorf("failed to write converted bundle: %w", err)
+		}
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, 0, fmt.Errorf("failed to write converted bundle: %w", err)
+	}
+	return out.Bytes(), converted, nil
+}
+
+// MarshalDocument renders a configuration as a document of the latest API version
+func MarshalDocument(cfg interface{}) ([]byte, error) {
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	converted, _, err := ConvertDocuments(data, versionOf(LatestAPIVersion))
+	return converted, err
+}
+
+// toolsOutOfSpec moves spec.tools of a v2 document to the top level, where v1 and the configuration types keep it
+// Defaults keep their tools in spec in every version.
+func toolsOutOfSpec(kind string, root *yaml.Node) error {
+	if kind == DefaultsKind {
+		return nil
+	}
+	if mappingValue(root, "tools") != nil {
+		return fmt.Errorf("tools belongs in spec.tools in apiVersion %s", APIVersionV2)
+	}
+	spec := mappingValue(root, "spec")
+	if spec == nil || spec.Kind != yaml.MappingNode {
+		return nil
+	}
+	if key, value := removeMappingEntry(spec, "tools"); key != nil {
+		root.Content = append(root.Content, key, value)
+	}
+	return nil
+}
+
+// toolsIntoSpec moves the top-level tools of a document in the internal layout into spec for v2
+func toolsIntoSpec(kind string, root *yaml.Node) error {
+	if kind == DefaultsKind {
+		return nil
+	}
+	key, value := removeMappingEntry(root, "tools")
+	if key == nil {
+		return nil
+	}
+	spec := mappingValue(root, "spec")
+	if spec == nil {
+		spec = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "spec"}, spec)
+	}
+	if spec.Kind != yaml.MappingNode {
+		return fmt.Errorf("spec must be a mapping")
+	}
+	spec.Style &^= yaml.FlowStyle
+	spec.Content = append(spec.Content, key, value)
+	return nil
+}
+
+// toolsSchemaIntoSpec moves the tools property of a kind's schema into spec for v2
+func toolsSchemaIntoSpec(kind string, schema *Schema) {
+	tools, ok := schema.Properties["tools"]
+	if !ok || kind == DefaultsKind {
+		return
+	}
+	delete(schema.Properties, "tools")
+	schema.Properties["spec"].Properties["tools"] = tools
+}
+
+// removeMappingEntry removes a key from a mapping node, returning its key and value nodes or nil when absent
+func removeMappingEntry(node *yaml.Node, key string) (*yaml.Node, *yaml.Node) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			k, v := node.Content[i], node.Content[i+1]
+			node.Content = append(node.Content[:i], node.Content[i+2:]...)
+			return k, v
+		}
+	}
+	return nil, nil
+}
+
+// scalarValue returns the value of a key of a mapping node, or "" when it is absent
+func scalarValue(node *yaml.Node, key string) string {
+	if value := mappingValue(node, key); value != nil {
+		return value.Value
+	}
+	return ""
+}
@@ -0,0 +1,168 @@
+// Package config provides unit tests for API versions of configuration documents
+// WHY: v2 documents must load exactly like the v1 documents they were converted from, and v1 must keep loading
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// versionsTestV1 is a bundle at v1 with tools next to spec, a Defaults document and comments
+const versionsTestV1 = `# Lab bundle
+apiVersion: openstack.kictl.icycloud.io/v1
+kind: Defaults
+metadata:
+  name: lab
+spec:
+  interface: eth1
+  tools:
+    nvlan:
+      validateConnectivity: false
+---
+apiVersion: openstack.kictl.icycloud.io/v1
+kind: NodeVLANConf
+metadata:
+  name: vlans
+spec:
+  vlans:
+    management:
+      id: 100
+      subnet: 192.168.100.0/24
+      nodeMapping:
+        rsb2: 192.168.100.12/24 # control plane
+tools:
+  nvlan:
+    # Survive reboots
+    persistentConfig: true
+`
+
+// versionsTestV2 is versionsTestV1 at v2, as kictl convert writes it
+const versionsTestV2 = `# Lab bundle
+apiVersion: openstack.kictl.icycloud.io/v2
+kind: Defaults
+metadata:
+  name: lab
+spec:
+  interface: eth1
+  tools:
+    nvlan:
+      validateConnectivity: false
+---
+apiVersion: openstack.kictl.icycloud.io/v2
+kind: NodeVLANConf
+metadata:
+  name: vlans
+spec:
+  vlans:
+    management:
+      id: 100
+      subnet: 192.168.100.0/24
+      nodeMapping:
+        rsb2: 192.168.100.12/24 # control plane
+  tools:
+    nvlan:
+      # Survive reboots
+      persistentConfig: true
+`
+
+// TestLoadConfigData_APIVersions tests loading the same bundle at each API version
+// WHY: Converting a bundle must never change what a run does, and v2 must refuse tools left at the top level
+func TestLoadConfigData_APIVersions(t *testing.T) {
+	// Given: The bundle at v1 as the reference
+	reference, err := LoadConfigData([]byte(versionsTestV1), "v1")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		data        string
+		expectError string
+	}{
+		{name: "v2", data: versionsTestV2},
+		{name: "mixed", data: strings.Replace(versionsTestV1, "icycloud.io/v1\nkind: Defaults", "icycloud.io/v2\nkind: Defaults", 1)},
+		{
+			name:        "v2_with_top_level_tools",
+			data:        strings.Replace(versionsTestV1, "icycloud.io/v1\nkind: NodeVLANConf", "icycloud.io/v2\nkind: NodeVLANConf", 1),
+			expectError: "failed to convert document 2: tools belongs in spec.tools in apiVersion openstack.kictl.icycloud.io/v2",
+		},
+		{
+			name:        "unknown_version",
+			data:        strings.ReplaceAll(versionsTestV1, "icycloud.io/v1", "icycloud.io/v3"),
+			expectError: "config apiVersion must end with '/v1' or '/v2', got 'openstack.kictl.icycloud.io/v3'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// When: Load the bundle
+			bundle, err := LoadConfigData([]byte(tt.data), tt.name)
+
+			// Then: It loads into the same configuration as v1, or fails with the reason
+			if tt.expectError != "" {
+				assert.ErrorContains(t, err, tt.expectError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, reference.VLANs.Spec, bundle.VLANs.Spec)
+			assert.Equal(t, reference.VLANs.GetTools(), bundle.VLANs.GetTools())
+			assert.True(t, bundle.VLANs.GetTools().Nvlan.PersistentConfig)
+			assert.Equal(t, "eth1", bundle.GetDefaults().Spec.Interface)
+		})
+	}
+}
+
+// TestConvertDocuments tests rewriting bundles between API versions
+// WHY: kictl convert rewrites files people maintain, so comments must survive and converting back must round-trip
+func TestConvertDocuments(t *testing.T) {
+	tests := []struct {
+		name          string
+		data          string
+		version       string
+		expected      string
+		expectedCount int
+		expectError   string
+	}{
+		{name: "v1_to_v2", data: versionsTestV1, version: "v2", expected: versionsTestV2, expectedCount: 2},
+		{name: "v2_to_v1", data: versionsTestV2, version: "v1", expected: versionsTestV1, expectedCount: 2},
+		{name: "already_converted", data: versionsTestV2, version: "v2", expected: versionsTestV2},
+		{name: "unknown_target", data: versionsTestV1, version: "v3", expectError: "unsupported API version 'v3'. Expected: v1, v2"},
+		{
+			name:        "unknown_source",
+			data:        "apiVersion: openstack.kictl.icycloud.io/v0\nkind: NodeVLANConf\n",
+			version:     "v2",
+			expectError: "document 1: config apiVersion must end with '/v1' or '/v2'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// When: Convert the bundle
+			converted, count, err := ConvertDocuments([]byte(tt.data), tt.version)
+
+			// Then: Every document is at the version, with its comments
+			if tt.expectError != "" {
+				assert.ErrorContains(t, err, tt.expectError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, string(converted))
+			assert.Equal(t, tt.expectedCount, count)
+		})
+	}
+}
+
+// TestValidateSchema_APIVersions tests validating documents against the schema of their own version
+// WHY: --strict-schema must accept both versions, and reject tools where the version does not keep them
+func TestValidateSchema_APIVersions(t *testing.T) {
+	// When/Then: Both layouts are valid at their own version
+	assert.Empty(t, ValidateSchema([]byte(versionsTestV1)))
+	assert.Empty(t, ValidateSchema([]byte(versionsTestV2)))
+
+	// When/Then: Tools at the top level of a v2 document are an unknown field
+	mixed := strings.Replace(versionsTestV1, "icycloud.io/v1\nkind: NodeVLANConf", "icycloud.io/v2\nkind: NodeVLANConf", 1)
+	errs := ValidateSchema([]byte(mixed))
+	require.Len(t, errs, 1)
+	assert.Equal(t, "document 2, line 23: tools: unknown field", errs[0].Error())
+}
@@ -0,0 +1,126 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"sort"
+)
+
+// validateVLANLayout rejects VLANs that would collide on the nodes: the same ID on the same interface, the same OVS port,
+// overlapping subnets, node addresses outside the subnet of their VLAN, or one address on two nodes
+func validateVLANLayout(vlans map[string]VLANConfig) error {
+	names := make([]string, 0, len(vlans))
+	for vlanName := range vlans {
+		names = append(names, vlanName)
+	}
+	sort.Strings(names)
+
+	subnets := make(map[string]*net.IPNet, len(vlans))
+	for _, vlanName := range names {
+		if err := validateOVS(vlanName, vlans[vlanName]); err != nil {
+			return err
+		}
+		subnet, err := vlanSubnet(vlanName, vlans[vlanName])
+		if err != nil {
+			return err
+		}
+		subnets[vlanName] = subnet
+		if err := checkNodesInSubnet(vlanName, vlans[vlanName], subnet); err != nil {
+			return err
+		}
+		if err := checkAddressesUnique(vlanName, vlans[vlanName]); err != nil {
+			return err
+		}
+	}
+
+	for i, vlanName := range names {
+		for _, otherName := range names[i+1:] {
+			vlan, other := vlans[vlanName], vlans[otherName]
+			if vlan.OVS != nil && other.OVS != nil && withOVSDefaults(vlan).Port == withOVSDefaults(other).Port {
+				return fmt.Errorf("VLANs %s and %s both use OVS port %s", vlanName, otherName, withOVSDefaults(vlan).Port)
+			}
+			if vlan.OVS == nil && other.OVS == nil && vlan.ID == other.ID && vlan.Interface == other.Interface {
+				return fmt.Errorf("VLANs %s and %s both use ID %d on %s", vlanName, otherName, vlan.ID, interfaceDescription(vlan.Interface))
+			}
+			subnet, otherSubnet := subnets[vlanName], subnets[otherName]
+			if subnet != nil && otherSubnet != nil && (subnet.Contains(otherSubnet.IP) || otherSubnet.Contains(subnet.IP)) {
+				return fmt.Errorf("VLANs %s and %s have overlapping subnets %s and %s", vlanName, otherName, subnet, otherSubnet)
+			}
+		}
+	}
+	return nil
+}
+
+// vlanSubnet parses the subnet of a VLAN; a VLAN without subnet gets nil
+func vlanSubnet(vlanName string, vlan VLANConfig) (*net.IPNet, error) {
+	if vlan.Subnet == "" {
+		return nil, nil
+	}
+	ip, network, err := net.ParseCIDR(vlan.Subnet)
+	if err != nil {
+		return nil, fmt.Errorf("VLAN %s: invalid subnet '%s': expected CIDR notation such as 10.0.0.0/24 or fd00::/64", vlanName, vlan.Subnet)
+	}
+	if !ip.Equal(network.IP) {
+		return nil, fmt.Errorf("VLAN %s: subnet %s has host bits set, did you mean %s?", vlanName, vlan.Subnet, network)
+	}
+	return network, nil
+}
+
+// checkNodesInSubnet checks that every node address of the subnet's family lies in the subnet
+// The other addresses of a dual-stack node are not checked, since a VLAN declares one subnet.
+func checkNodesInSubnet(vlanName string, vlan VLANConfig, subnet *net.IPNet) error {
+	if subnet == nil {
+		return nil
+	}
+	nodes := make([]string, 0, len(vlan.NodeMapping))
+	for node := range vlan.NodeMapping {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+
+	for _, node := range nodes {
+		for _, address := range SplitAddresses(vlan.NodeMapping[node]) {
+			ip, _, err := net.ParseCIDR(address)
+			if err != nil || (ip.To4() == nil) != (subnet.IP.To4() == nil) {
+				continue
+			}
+			if !subnet.Contains(ip) {
+				return fmt.Errorf("VLAN %s: address %s of node %s is outside the VLAN subnet %s", vlanName, address, node, subnet)
+			}
+		}
+	}
+	return nil
+}
+
+// checkAddressesUnique checks that no address is given to two nodes of a VLAN, whatever its prefix length
+// Addresses moving between nodes, like keepalived VIPs, belong to keepalived and not to the mapping.
+func checkAddressesUnique(vlanName string, vlan VLANConfig) error {
+	nodes := make([]string, 0, len(vlan.NodeMapping))
+	for node := range vlan.NodeMapping {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+
+	owners := make(map[string]string)
+	for _, node := range nodes {
+		for _, address := range SplitAddresses(vlan.NodeMapping[node]) {
+			ip, _, err := net.ParseCIDR(address)
+			if err != nil {
+				continue
+			}
+			if owner, taken := owners[ip.String()]; taken && owner != node {
+				return fmt.Errorf("VLAN %s: address %s is given to both node %s and node %s", vlanName, ip, owner, node)
+			}
+			owners[ip.String()] = node
+		}
+	}
+	return nil
+}
+
+// interfaceDescription names the parent interface of a VLAN in messages
+func interfaceDescription(iface string) string {
+	if iface == "" {
+		return "the default interface"
+	}
+	return "interface " + iface
+}
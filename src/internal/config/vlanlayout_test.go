@@ -0,0 +1,151 @@
+// Package config provides unit tests for the VLAN layout validation
+// WHY: Colliding VLANs only fail on the nodes, half applied, so they must be rejected while loading
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestValidateVLANLayout tests rejecting VLANs that collide with each other or their own subnet
+// WHY: Errors must name both conflicting VLANs so the fix is obvious
+func TestValidateVLANLayout(t *testing.T) {
+	tests := []struct {
+		name          string
+		vlans         map[string]VLANConfig
+		expectedError string
+	}{
+		{
+			name: "valid_layout",
+			vlans: map[string]VLANConfig{
+				"management": {ID: 100, Subnet: "10.1.0.0/24", Interface: "eth0", NodeMapping: NodeMapping{"rsb2": "10.1.0.2/24"}},
+				"storage":    {ID: 100, Subnet: "10.2.0.0/24", Interface: "eth1", NodeMapping: NodeMapping{"rsb2": "10.2.0.2/24"}},
+			},
+		},
+		{
+			name: "dual_stack_checks_subnet_family",
+			vlans: map[string]VLANConfig{
+				"management": {ID: 100, Subnet: "fd00:1::/64", NodeMapping: NodeMapping{"rsb2": "10.1.0.2/24,fd00:1::2/64"}},
+				"storage":    {ID: 200, Subnet: "10.2.0.0/24", NodeMapping: NodeMapping{"rsb2": "10.2.0.2/24,fd00:2::2/64"}},
+				"tenant":     {ID: 300, NodeMapping: NodeMapping{"rsb2": "10.3.0.2/24"}},
+			},
+		},
+		{
+			name: "node_ip_and_service_vip",
+			vlans: map[string]VLANConfig{
+				"management": {ID: 100, Subnet: "10.1.0.0/24", NodeMapping: NodeMapping{"rsb2": "10.1.0.2/24,10.1.0.100/32", "rsb3": "10.1.0.3/24,10.1.0.101/32"}},
+			},
+		},
+		{
+			name: "address_on_two_nodes",
+			vlans: map[string]VLANConfig{
+				"management": {ID: 100, Subnet: "10.1.0.0/24", NodeMapping: NodeMapping{"rsb2": "10.1.0.2/24,10.1.0.100/32", "rsb3": "10.1.0.3/24,10.1.0.100/24"}},
+			},
+			expectedError: "VLAN management: address 10.1.0.100 is given to both node rsb2 and node rsb3",
+		},
+		{
+			name: "same_id_on_same_interface",
+			vlans: map[string]VLANConfig{
+				"management": {ID: 100, Subnet: "10.1.0.0/24", Interface: "eth0"},
+				"storage":    {ID: 100, Subnet: "10.2.0.0/24", Interface: "eth0"},
+			},
+			expectedError: "VLANs management and storage both use ID 100 on interface eth0",
+		},
+		{
+			name: "same_id_on_default_interface",
+			vlans: map[string]VLANConfig{
+				"management": {ID: 100, Subnet: "10.1.0.0/24"},
+				"storage":    {ID: 100, Subnet: "10.2.0.0/24"},
+			},
+			expectedError: "VLANs management and storage both use ID 100 on the default interface",
+		},
+		{
+			name: "overlapping_subnets",
+			vlans: map[string]VLANConfig{
+				"management": {ID: 100, Subnet: "10.1.0.0/16"},
+				"storage":    {ID: 200, Subnet: "10.1.20.0/24"},
+			},
+			expectedError: "VLANs management and storage have overlapping subnets 10.1.0.0/16 and 10.1.20.0/24",
+		},
+		{
+			name: "node_outside_subnet",
+			vlans: map[string]VLANConfig{
+				"management": {ID: 100, Subnet: "10.1.0.0/24", NodeMapping: NodeMapping{"rsb2": "10.1.0.2/24", "rsb3": "10.9.0.3/24"}},
+			},
+			expectedError: "VLAN management: address 10.9.0.3/24 of node rsb3 is outside the VLAN subnet 10.1.0.0/24",
+		},
+		{
+			name: "invalid_subnet",
+			vlans: map[string]VLANConfig{
+				"management": {ID: 100, Subnet: "10.1.0.0"},
+			},
+			expectedError: "VLAN management: invalid subnet '10.1.0.0': expected CIDR notation such as 10.0.0.0/24 or fd00::/64",
+		},
+		{
+			name: "subnet_with_host_bits",
+			vlans: map[string]VLANConfig{
+				"management": {ID: 100, Subnet: "10.1.0.1/24"},
+			},
+			expectedError: "VLAN management: subnet 10.1.0.1/24 has host bits set, did you mean 10.1.0.0/24?",
+		},
+		{
+			name: "ovs_port_beside_sub_interface",
+			vlans: map[string]VLANConfig{
+				"management": {ID: 100, Subnet: "10.1.0.0/24"},
+				"provider":   {ID: 100, Subnet: "10.2.0.0/24", OVS: &OVSConfig{Bridge: "br-ex", Port: "provider"}},
+				"tenant":     {ID: 200, Subnet: "10.3.0.0/24", OVS: &OVSConfig{Bridge: "br-ex", Tag: 100}},
+			},
+		},
+		{
+			name: "same_ovs_port",
+			vlans: map[string]VLANConfig{
+				"provider": {ID: 100, Subnet: "10.1.0.0/24", OVS: &OVSConfig{Bridge: "br-ex", Port: "vlan200"}},
+				"tenant":   {ID: 200, Subnet: "10.2.0.0/24", OVS: &OVSConfig{Bridge: "br-tenant"}},
+			},
+			expectedError: "VLANs provider and tenant both use OVS port vlan200",
+		},
+		{
+			name: "ovs_with_interface",
+			vlans: map[string]VLANConfig{
+				"provider": {ID: 100, Interface: "eth0", OVS: &OVSConfig{Bridge: "br-ex"}},
+			},
+			expectedError: "VLAN provider: interface and ovs are mutually exclusive, the port is attached to ovs.bridge",
+		},
+		{
+			name: "ovs_without_bridge",
+			vlans: map[string]VLANConfig{
+				"provider": {ID: 100, OVS: &OVSConfig{Port: "provider"}},
+			},
+			expectedError: "VLAN provider: ovs.bridge is required",
+		},
+		{
+			name: "ovs_tag_out_of_range",
+			vlans: map[string]VLANConfig{
+				"provider": {ID: 100, OVS: &OVSConfig{Bridge: "br-ex", Tag: 4095}},
+			},
+			expectedError: "VLAN provider: ovs.tag 4095 is outside 1-4094",
+		},
+		{
+			name: "ovs_port_name_too_long",
+			vlans: map[string]VLANConfig{
+				"provider": {ID: 100, OVS: &OVSConfig{Bridge: "br-ex", Port: "provider-network"}},
+			},
+			expectedError: "VLAN provider: ovs.port 'provider-network' is not a valid interface name of at most 15 characters",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// When: Validate the VLANs
+			err := validateVLANLayout(tt.vlans)
+
+			// Then: Collisions are rejected naming the VLANs involved
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
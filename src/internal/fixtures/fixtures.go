@@ -0,0 +1,247 @@
+// Package fixtures generates synthetic cluster snapshots so configurations can be checked without a cluster
+package fixtures
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"k8ostack-ictl/internal/config"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SnapshotKind is the kind of a fixtures file
+const SnapshotKind = "ClusterSnapshot"
+
+// Interface types in a snapshot
+const (
+	InterfacePhysical  = "physical"
+	InterfaceVLAN      = "vlan"
+	InterfaceOVSBridge = "ovs-bridge"
+	InterfaceOVSPort   = "ovs-port"
+)
+
+// defaultLinkSpeed is the link speed in Mb/s of every synthetic physical interface
+const defaultLinkSpeed = 10000
+
+// Snapshot is a synthetic view of the nodes, labels and interfaces of a cluster
+type Snapshot struct {
+	APIVersion string `yaml:"apiVersion"`
+	Kind       string `yaml:"kind"`
+	Source     string `yaml:"source,omitempty"` // Bundle the snapshot was generated from
+	Applied    bool   `yaml:"applied"`          // Whether the bundle is already applied in the snapshot
+	Nodes      []Node `yaml:"nodes"`
+}
+
+// Node is a synthetic cluster node
+type Node struct {
+	Name       string            `yaml:"name"`
+	InternalIP string            `yaml:"internalIP"`
+	Labels     map[string]string `yaml:"labels,omitempty"`
+	Interfaces []Interface       `yaml:"interfaces,omitempty"`
+}
+
+// Interface is a network interface of a synthetic node
+type Interface struct {
+	Name      string   `yaml:"name"`
+	Type      string   `yaml:"type"`
+	Parent    string   `yaml:"parent,omitempty"` // Parent of a VLAN interface, bridge of an OVS port
+	VLANID    int      `yaml:"vlanId,omitempty"` // VLAN ID, or the tag of an OVS port
+	Speed     int      `yaml:"speed,omitempty"`  // Link speed in Mb/s of a physical interface
+	Addresses []string `yaml:"addresses,omitempty"`
+}
+
+// Options controls what a generated snapshot contains
+type Options struct {
+	// Applied makes the snapshot look like the bundle was applied: role labels set and VLAN interfaces up
+	Applied bool
+}
+
+// Generate builds a snapshot with every node the bundle references
+// Nodes get a synthetic InternalIP on their primary interface and every parent interface their VLANs name.
+func Generate(bundle *config.ConfigBundle, options Options) (*Snapshot, error) {
+	defaults := bundle.GetDefaults()
+	snapshot := &Snapshot{
+		APIVersion: "openstack.kictl.icycloud.io/v1",
+		Kind:       SnapshotKind,
+		Source:     bundle.Source,
+		Applied:    options.Applied,
+	}
+
+	for i, nodeName := range bundle.GetAllNodeNames() {
+		node := Node{
+			Name:       nodeName,
+			InternalIP: syntheticInternalIP(i),
+			Labels:     map[string]string{"kubernetes.io/hostname": nodeName},
+		}
+
+		primary, err := primaryInterface(defaults, nodeName)
+		if err != nil {
+			return nil, err
+		}
+		node.Interfaces = append(node.Interfaces, Interface{
+			Name: primary, Type: InterfacePhysical, Speed: defaultLinkSpeed, Addresses: []string{node.InternalIP + "/24"},
+		})
+
+		vlanInterfaces, err := nodeVLANInterfaces(bundle, defaults, nodeName, primary)
+		if err != nil {
+			return nil, err
+		}
+		for _, vlanInterface := range vlanInterfaces {
+			switch {
+			case hasInterface(node.Interfaces, vlanInterface.Parent):
+			case vlanInterface.Type == InterfaceOVSPort:
+				node.Interfaces = append(node.Interfaces, Interface{Name: vlanInterface.Parent, Type: InterfaceOVSBridge})
+			default:
+				node.Interfaces = append(node.Interfaces, Interface{Name: vlanInterface.Parent, Type: InterfacePhysical, Speed: defaultLinkSpeed})
+			}
+		}
+
+		if options.Applied {
+			node.Interfaces = append(node.Interfaces, vlanInterfaces...)
+			applyLabels(bundle, &node)
+		}
+
+		snapshot.Nodes = append(snapshot.Nodes, node)
+	}
+
+	return snapshot, nil
+}
+
+// primaryInterface returns the interface carrying the InternalIP of a node
+// Detection strategies pick it on a real node, so fixtures use eth0 for them.
+func primaryInterface(defaults *config.Defaults, nodeName string) (string, error) {
+	if defaults.Spec.Interface == "" {
+		return config.DefaultInterface, nil
+	}
+	return defaults.Spec.InterfaceAliases.Resolve(nodeName, defaults.Spec.Interface)
+}
+
+// nodeVLANInterfaces returns the VLAN interfaces the bundle assigns to a node, sorted by name
+func nodeVLANInterfaces(bundle *config.ConfigBundle, defaults *config.Defaults, nodeName, primary string) ([]Interface, error) {
+	if !bundle.HasVLANs() {
+		return nil, nil
+	}
+
+	var interfaces []Interface
+	for vlanName, vlanConfig := range bundle.VLANs.Spec.VLANs {
+		address, ok := vlanConfig.NodeMapping[nodeName]
+		if !ok {
+			continue
+		}
+
+		if vlanConfig.OVS != nil {
+			interfaces = append(interfaces, Interface{
+				Name:      vlanConfig.OVS.Port,
+				Type:      InterfaceOVSPort,
+				Parent:    vlanConfig.OVS.Bridge,
+				VLANID:    vlanConfig.OVS.Tag,
+				Addresses: config.SplitAddresses(address),
+			})
+			continue
+		}
+
+		parent := primary
+		if vlanConfig.Interface != "" {
+			resolved, err := defaults.Spec.InterfaceAliases.Resolve(nodeName, vlanConfig.Interface)
+			if err != nil {
+				return nil, fmt.Errorf("VLAN %s: %w", vlanName, err)
+			}
+			parent = resolved
+		}
+
+		interfaces = append(interfaces, Interface{
+			Name:      fmt.Sprintf("%s.%d", parent, vlanConfig.ID),
+			Type:      InterfaceVLAN,
+			Parent:    parent,
+			VLANID:    vlanConfig.ID,
+			Addresses: config.SplitAddresses(address),
+		})
+	}
+
+	sort.Slice(interfaces, func(i, j int) bool { return interfaces[i].Name < interfaces[j].Name })
+	return interfaces, nil
+}
+
+// applyLabels sets the labels of every role naming the node, in role name order
+// Node selectors need real node labels, so roles only reach fixtures through names and name patterns.
+func applyLabels(bundle *config.ConfigBundle, node *Node) {
+	if !bundle.HasNodeLabels() {
+		return
+	}
+
+	roleNames := make([]string, 0, len(bundle.NodeLabels.Spec.NodeRoles))
+	for roleName := range bundle.NodeLabels.Spec.NodeRoles {
+		roleNames = append(roleNames, roleName)
+	}
+	sort.Strings(roleNames)
+
+	for _, roleName := range roleNames {
+		role := bundle.NodeLabels.Spec.NodeRoles[roleName]
+		if !role.MatchesName(node.Name) {
+			continue
+		}
+		for key, value := range role.Labels {
+			node.Labels[key] = value
+		}
+	}
+}
+
+// hasInterface reports whether an interface with the name exists
+func hasInterface(interfaces []Interface, name string) bool {
+	for _, iface := range interfaces {
+		if iface.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// syntheticInternalIP returns a unique InternalIP in 10.0.0.0/16 for the i-th node
+func syntheticInternalIP(i int) string {
+	return fmt.Sprintf("10.0.%d.%d", (i+10)/250, (i+10)%250+1)
+}
+
+// Save writes the snapshot as YAML
+func Save(snapshot *Snapshot, path string) error {
+	data, err := yaml.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("failed to encode fixtures: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write fixtures: %w", err)
+	}
+	return nil
+}
+
+// Load reads a snapshot written by Save
+func Load(path string) (*Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixtures: %w", err)
+	}
+
+	var snapshot Snapshot
+	if err := yaml.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to parse fixtures: %w", err)
+	}
+	if snapshot.Kind != SnapshotKind {
+		return nil, fmt.Errorf("fixtures kind must be '%s', got '%s'", SnapshotKind, snapshot.Kind)
+	}
+	if !strings.HasSuffix(snapshot.APIVersion, "/v1") {
+		return nil, fmt.Errorf("fixtures apiVersion must end with '/v1', got '%s'", snapshot.APIVersion)
+	}
+	return &snapshot, nil
+}
+
+// Node returns the node with the name
+func (s *Snapshot) Node(name string) (*Node, bool) {
+	for i := range s.Nodes {
+		if s.Nodes[i].Name == name {
+			return &s.Nodes[i], true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,197 @@
+// Package fixtures provides unit tests for synthetic cluster snapshots
+// WHY: Downstream CI validates configs against these snapshots, so their content must be deterministic
+package fixtures
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"k8ostack-ictl/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testBundle returns a bundle with one labeled role and two VLANs on different parents
+func testBundle() *config.ConfigBundle {
+	defaults := config.BuiltinDefaults()
+	defaults.Spec.Interface = "uplink"
+	defaults.Spec.InterfaceAliases = config.InterfaceAliases{
+		config.AnyNode: {"uplink": "eno1", "storage-link": "eno2"},
+		"rsb3":         {"uplink": "ens1f0"},
+	}
+
+	return &config.ConfigBundle{
+		Source:   "cluster.yaml",
+		Defaults: defaults,
+		NodeLabels: &config.NodeLabelConf{Spec: config.NodeLabelSpec{NodeRoles: map[string]config.NodeRole{
+			"control": {Nodes: []string{"rsb2"}, Labels: map[string]string{"role": "control"}},
+		}}},
+		VLANs: &config.NodeVLANConf{Spec: config.NodeVLANSpec{VLANs: map[string]config.VLANConfig{
+			"management": {ID: 10, NodeMapping: map[string]string{"rsb2": "10.10.0.12/24", "rsb3": "10.10.0.13/24"}},
+			"storage":    {ID: 100, Interface: "storage-link", NodeMapping: map[string]string{"rsb3": "10.100.0.13/24"}},
+		}}},
+	}
+}
+
+// TestGenerate tests snapshot generation from a bundle
+// WHY: Nodes need the parents their VLANs name, and only applied snapshots carry labels and VLANs
+func TestGenerate(t *testing.T) {
+	tests := []struct {
+		name     string
+		applied  bool
+		expected []Node
+	}{
+		{
+			name:    "unapplied_has_parents_only",
+			applied: false,
+			expected: []Node{
+				{Name: "rsb2", InternalIP: "10.0.0.11", Labels: map[string]string{"kubernetes.io/hostname": "rsb2"}, Interfaces: []Interface{
+					{Name: "eno1", Type: InterfacePhysical, Speed: 10000, Addresses: []string{"10.0.0.11/24"}},
+				}},
+				{Name: "rsb3", InternalIP: "10.0.0.12", Labels: map[string]string{"kubernetes.io/hostname": "rsb3"}, Interfaces: []Interface{
+					{Name: "ens1f0", Type: InterfacePhysical, Speed: 10000, Addresses: []string{"10.0.0.12/24"}},
+					{Name: "eno2", Type: InterfacePhysical, Speed: 10000},
+				}},
+			},
+		},
+		{
+			name:    "applied_has_labels_and_vlans",
+			applied: true,
+			expected: []Node{
+				{Name: "rsb2", InternalIP: "10.0.0.11", Labels: map[string]string{"kubernetes.io/hostname": "rsb2", "role": "control"}, Interfaces: []Interface{
+					{Name: "eno1", Type: InterfacePhysical, Speed: 10000, Addresses: []string{"10.0.0.11/24"}},
+					{Name: "eno1.10", Type: InterfaceVLAN, Parent: "eno1", VLANID: 10, Addresses: []string{"10.10.0.12/24"}},
+				}},
+				{Name: "rsb3", InternalIP: "10.0.0.12", Labels: map[string]string{"kubernetes.io/hostname": "rsb3"}, Interfaces: []Interface{
+					{Name: "ens1f0", Type: InterfacePhysical, Speed: 10000, Addresses: []string{"10.0.0.12/24"}},
+					{Name: "eno2", Type: InterfacePhysical, Speed: 10000},
+					{Name: "eno2.100", Type: InterfaceVLAN, Parent: "eno2", VLANID: 100, Addresses: []string{"10.100.0.13/24"}},
+					{Name: "ens1f0.10", Type: InterfaceVLAN, Parent: "ens1f0", VLANID: 10, Addresses: []string{"10.10.0.13/24"}},
+				}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A bundle with aliases, roles and VLANs
+			bundle := testBundle()
+
+			// When: Generate
+			snapshot, err := Generate(bundle, Options{Applied: tt.applied})
+
+			// Then: Nodes match exactly
+			require.NoError(t, err)
+			assert.Equal(t, SnapshotKind, snapshot.Kind)
+			assert.Equal(t, "cluster.yaml", snapshot.Source)
+			assert.Equal(t, tt.expected, snapshot.Nodes)
+		})
+	}
+}
+
+// TestGenerate_InterfaceDetection tests the primary interface when the bundle detects it per node
+// WHY: Detected interfaces are unknown without a cluster, so fixtures use the built-in default
+func TestGenerate_InterfaceDetection(t *testing.T) {
+	// Given: A bundle detecting interfaces by InternalIP
+	bundle := testBundle()
+	bundle.Defaults.Spec.Interface = ""
+	bundle.Defaults.Spec.InterfaceDetection = config.InterfaceDetectionInternalIP
+
+	// When: Generate
+	snapshot, err := Generate(bundle, Options{Applied: true})
+
+	// Then: VLANs without an interface use eth0
+	require.NoError(t, err)
+	node, ok := snapshot.Node("rsb2")
+	require.True(t, ok)
+	assert.Equal(t, "eth0", node.Interfaces[0].Name)
+	assert.Equal(t, "eth0.10", node.Interfaces[1].Name)
+}
+
+// TestGenerate_OVS tests the interfaces of a VLAN on an OVS bridge
+// WHY: An OVS port hangs off a bridge, which must not appear as a physical interface with a link speed
+func TestGenerate_OVS(t *testing.T) {
+	// Given: A VLAN as port provider on br-ex of rsb2
+	bundle := testBundle()
+	bundle.VLANs.Spec.VLANs["provider"] = config.VLANConfig{ID: 200, OVS: &config.OVSConfig{Bridge: "br-ex", Port: "provider", Tag: 200},
+		NodeMapping: map[string]string{"rsb2": "10.200.0.12/24"}}
+
+	// When: Generate
+	snapshot, err := Generate(bundle, Options{Applied: true})
+
+	// Then: rsb2 has the bridge and the tagged port on it
+	require.NoError(t, err)
+	node, ok := snapshot.Node("rsb2")
+	require.True(t, ok)
+	assert.Contains(t, node.Interfaces, Interface{Name: "br-ex", Type: InterfaceOVSBridge})
+	assert.Contains(t, node.Interfaces, Interface{Name: "provider", Type: InterfaceOVSPort, Parent: "br-ex", VLANID: 200, Addresses: []string{"10.200.0.12/24"}})
+}
+
+// TestGenerate_UndefinedAlias tests a VLAN naming an alias its node lacks
+// WHY: The snapshot must not invent an interface apply would fail to resolve
+func TestGenerate_UndefinedAlias(t *testing.T) {
+	// Given: An alias defined for rsb3 only
+	bundle := testBundle()
+	bundle.Defaults.Spec.InterfaceAliases = config.InterfaceAliases{
+		config.AnyNode: {"uplink": "eno1"},
+		"rsb3":         {"storage-link": "eno2"},
+	}
+	vlans := bundle.VLANs.Spec.VLANs
+	storage := vlans["storage"]
+	storage.NodeMapping["rsb2"] = "10.100.0.12/24"
+	vlans["storage"] = storage
+
+	// When: Generate
+	_, err := Generate(bundle, Options{})
+
+	// Then: The alias error names the VLAN
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "VLAN storage")
+	assert.Contains(t, err.Error(), "not defined for node rsb2")
+}
+
+// TestSaveLoad tests the snapshot file round trip
+// WHY: Load must reject files that are not snapshots instead of validating against empty fixtures
+func TestSaveLoad(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		expectError string
+	}{
+		{"wrong_kind", "apiVersion: openstack.kictl.icycloud.io/v1\nkind: NodeVLANConf\n", "fixtures kind must be 'ClusterSnapshot'"},
+		{"wrong_version", "apiVersion: openstack.kictl.icycloud.io/v2\nkind: ClusterSnapshot\n", "fixtures apiVersion must end with '/v1'"},
+		{"invalid_yaml", "nodes: [", "failed to parse fixtures"},
+	}
+
+	t.Run("round_trip", func(t *testing.T) {
+		// Given: A generated snapshot
+		snapshot, err := Generate(testBundle(), Options{Applied: true})
+		require.NoError(t, err)
+		path := filepath.Join(t.TempDir(), "fixtures.yaml")
+
+		// When: Save and load it
+		require.NoError(t, Save(snapshot, path))
+		loaded, err := Load(path)
+
+		// Then: The snapshot is unchanged
+		require.NoError(t, err)
+		assert.Equal(t, snapshot, loaded)
+	})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A file that is no valid snapshot
+			path := filepath.Join(t.TempDir(), "fixtures.yaml")
+			require.NoError(t, os.WriteFile(path, []byte(tt.content), 0644))
+
+			// When: Load it
+			_, err := Load(path)
+
+			// Then: Loading fails
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.expectError)
+		})
+	}
+}
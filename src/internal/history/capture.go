@@ -0,0 +1,43 @@
+package history
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8ostack-ictl/internal/kubectl"
+)
+
+// MetadataReader is the subset of kubectl.Executor needed to snapshot a node
+type MetadataReader interface {
+	GetNodeLabels(ctx context.Context, nodeName string) (bool, string, error)
+	GetNodeAnnotations(ctx context.Context, nodeName string) (bool, string, error)
+}
+
+// CaptureNode reads the live labels and annotations of a node into a snapshot
+// VLAN state is passed in by the caller since it is known from the applied configuration
+func CaptureNode(ctx context.Context, reader MetadataReader, runID, node string, vlans map[string]string) (NodeSnapshot, error) {
+	snapshot := NodeSnapshot{
+		Node:    node,
+		RunID:   runID,
+		TakenAt: time.Now().UTC(),
+		VLANs:   vlans,
+	}
+
+	success, output, err := reader.GetNodeLabels(ctx, node)
+	if err != nil || !success {
+		return snapshot, fmt.Errorf("failed to read labels of node %s: %v", node, err)
+	}
+	snapshot.Labels = kubectl.ParseNodeLabels(output)
+
+	success, output, err = reader.GetNodeAnnotations(ctx, node)
+	if err != nil || !success {
+		return snapshot, fmt.Errorf("failed to read annotations of node %s: %v", node, err)
+	}
+	snapshot.Annotations, err = kubectl.ParseNodeAnnotations(output)
+	if err != nil {
+		return snapshot, fmt.Errorf("failed to parse annotations of node %s: %w", node, err)
+	}
+
+	return snapshot, nil
+}
@@ -0,0 +1,74 @@
+// Package history provides unit tests for node snapshot capture
+// WHY: Capture is the only place live cluster data enters the history
+package history
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockMetadataReader mocks the MetadataReader interface
+type MockMetadataReader struct {
+	mock.Mock
+}
+
+func (m *MockMetadataReader) GetNodeLabels(ctx context.Context, nodeName string) (bool, string, error) {
+	args := m.Called(ctx, nodeName)
+	return args.Bool(0), args.String(1), args.Error(2)
+}
+
+func (m *MockMetadataReader) GetNodeAnnotations(ctx context.Context, nodeName string) (bool, string, error) {
+	args := m.Called(ctx, nodeName)
+	return args.Bool(0), args.String(1), args.Error(2)
+}
+
+// TestCaptureNode tests snapshot capture from kubectl output
+// WHY: Labels and annotations must be parsed, and read failures surfaced
+func TestCaptureNode(t *testing.T) {
+	labelsOutput := "NAME   STATUS   ROLES    AGE   VERSION   LABELS\nrsb2   Ready    <none>   10d   v1.28.2   openstack-role=compute"
+
+	t.Run("successful_capture", func(t *testing.T) {
+		// Given: Node with labels and annotations
+		reader := &MockMetadataReader{}
+		reader.On("GetNodeLabels", mock.Anything, "rsb2").Return(true, labelsOutput, nil)
+		reader.On("GetNodeAnnotations", mock.Anything, "rsb2").Return(true, `{"kictl.icycloud.io/revision":"3"}`, nil)
+
+		// When: Capture snapshot
+		snapshot, err := CaptureNode(context.Background(), reader, "run-1", "rsb2", map[string]string{"management": "10.0.0.2/24"})
+
+		// Then: Snapshot holds parsed metadata
+		require.NoError(t, err)
+		assert.Equal(t, "rsb2", snapshot.Node)
+		assert.Equal(t, "run-1", snapshot.RunID)
+		assert.Equal(t, map[string]string{"openstack-role": "compute"}, snapshot.Labels)
+		assert.Equal(t, map[string]string{"kictl.icycloud.io/revision": "3"}, snapshot.Annotations)
+		assert.Equal(t, map[string]string{"management": "10.0.0.2/24"}, snapshot.VLANs)
+		assert.False(t, snapshot.TakenAt.IsZero())
+	})
+
+	t.Run("label_read_failure", func(t *testing.T) {
+		reader := &MockMetadataReader{}
+		reader.On("GetNodeLabels", mock.Anything, "rsb2").Return(false, "", fmt.Errorf("connection refused"))
+
+		_, err := CaptureNode(context.Background(), reader, "run-1", "rsb2", nil)
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to read labels of node rsb2")
+	})
+
+	t.Run("invalid_annotations", func(t *testing.T) {
+		reader := &MockMetadataReader{}
+		reader.On("GetNodeLabels", mock.Anything, "rsb2").Return(true, labelsOutput, nil)
+		reader.On("GetNodeAnnotations", mock.Anything, "rsb2").Return(true, "map[broken", nil)
+
+		_, err := CaptureNode(context.Background(), reader, "run-1", "rsb2", nil)
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to parse annotations of node rsb2")
+	})
+}
@@ -0,0 +1,71 @@
+package history
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Pair is the observed connectivity from one endpoint to another for a single test
+type Pair struct {
+	Test          string `json:"test"`
+	SourceNode    string `json:"sourceNode,omitempty"`
+	SourceNetwork string `json:"sourceNetwork,omitempty"`
+	TargetNode    string `json:"targetNode,omitempty"`
+	TargetNetwork string `json:"targetNetwork,omitempty"`
+	Protocol      string `json:"protocol,omitempty"`
+	Port          int    `json:"port,omitempty"`
+	Reachable     bool   `json:"reachable"`
+}
+
+// Key identifies a pair across runs regardless of its outcome
+func (p Pair) Key() string {
+	return fmt.Sprintf("%s|%s|%s|%s|%s|%s|%d", p.Test, p.SourceNode, p.SourceNetwork, p.TargetNode, p.TargetNetwork, p.Protocol, p.Port)
+}
+
+// String describes a pair for reports, e.g. "storage-reachability: rsb7/storage -> storage (icmp)"
+func (p Pair) String() string {
+	endpoint := func(node, network string) string {
+		switch {
+		case node == "":
+			return network
+		case network == "":
+			return node
+		default:
+			return node + "/" + network
+		}
+	}
+	protocol := p.Protocol
+	if p.Port > 0 {
+		protocol = fmt.Sprintf("%s:%d", protocol, p.Port)
+	}
+	return fmt.Sprintf("%s: %s -> %s (%s)", p.Test, endpoint(p.SourceNode, p.SourceNetwork), endpoint(p.TargetNode, p.TargetNetwork), protocol)
+}
+
+// Matrix is the connectivity observed by one test run
+type Matrix struct {
+	TakenAt    time.Time `json:"takenAt"`
+	ConfigFile string    `json:"configFile,omitempty"`
+	Baseline   bool      `json:"baseline,omitempty"` // Later runs are compared against the latest baseline
+	Pairs      []Pair    `json:"pairs"`
+}
+
+// CompareMatrix returns the pairs reachable in the baseline that are no longer reachable, sorted by key
+// Pairs missing from either matrix are not compared, so added or removed tests are never regressions.
+func CompareMatrix(baseline, current Matrix) []Pair {
+	reachable := make(map[string]bool, len(baseline.Pairs))
+	for _, pair := range baseline.Pairs {
+		if pair.Reachable {
+			reachable[pair.Key()] = true
+		}
+	}
+
+	var broken []Pair
+	for _, pair := range current.Pairs {
+		if !pair.Reachable && reachable[pair.Key()] {
+			broken = append(broken, pair)
+		}
+	}
+	sort.Slice(broken, func(i, j int) bool { return broken[i].Key() < broken[j].Key() })
+	return broken
+}
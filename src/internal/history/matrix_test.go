@@ -0,0 +1,72 @@
+// Package history provides unit tests for connectivity matrix comparison
+// WHY: Regressions fail runs, so only pairs that were reachable in the baseline may be reported
+package history
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCompareMatrix tests detection of pairs broken since the baseline
+// WHY: Pairs that were already broken, recovered or are new must not be reported as regressions
+func TestCompareMatrix(t *testing.T) {
+	pair := func(test string, reachable bool) Pair {
+		return Pair{Test: test, SourceNode: "rsb7", SourceNetwork: "storage", TargetNetwork: "storage", Protocol: "icmp", Reachable: reachable}
+	}
+
+	tests := []struct {
+		name     string
+		baseline []Pair
+		current  []Pair
+		expected []Pair
+	}{
+		{
+			name:     "reachable_pair_breaks",
+			baseline: []Pair{pair("b", true), pair("a", true)},
+			current:  []Pair{pair("b", false), pair("a", false)},
+			expected: []Pair{pair("a", false), pair("b", false)},
+		},
+		{
+			name:     "still_reachable",
+			baseline: []Pair{pair("a", true)},
+			current:  []Pair{pair("a", true)},
+		},
+		{
+			name:     "already_broken_in_baseline",
+			baseline: []Pair{pair("a", false)},
+			current:  []Pair{pair("a", false)},
+		},
+		{
+			name:     "new_pair_is_not_compared",
+			baseline: []Pair{pair("a", true)},
+			current:  []Pair{pair("a", true), pair("c", false)},
+		},
+		{
+			name:     "same_test_on_another_port_is_another_pair",
+			baseline: []Pair{{Test: "api", Protocol: "tcp", Port: 5000, Reachable: true}},
+			current:  []Pair{{Test: "api", Protocol: "tcp", Port: 8774}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A baseline matrix and a later one
+
+			// When: Compare them
+			broken := CompareMatrix(Matrix{Baseline: true, Pairs: tt.baseline}, Matrix{Pairs: tt.current})
+
+			// Then: Only pairs reachable in the baseline and broken now are returned
+			assert.Equal(t, tt.expected, broken)
+		})
+	}
+}
+
+// TestPair_String tests how pairs are described in reports
+// WHY: Operators must recognise the test and both endpoints of a regression
+func TestPair_String(t *testing.T) {
+	assert.Equal(t, "storage-reachability: rsb7/storage -> storage (icmp)",
+		Pair{Test: "storage-reachability", SourceNode: "rsb7", SourceNetwork: "storage", TargetNetwork: "storage", Protocol: "icmp"}.String())
+	assert.Equal(t, "keystone: rsb7 -> rsb2/api (tcp:5000)",
+		Pair{Test: "keystone", SourceNode: "rsb7", TargetNode: "rsb2", TargetNetwork: "api", Protocol: "tcp", Port: 5000}.String())
+}
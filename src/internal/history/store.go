@@ -0,0 +1,146 @@
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// History file names inside the history directory
+const (
+	runsFile      = "runs.jsonl"
+	snapshotsFile = "snapshots.jsonl"
+	matricesFile  = "matrices.jsonl"
+)
+
+// FileStore implements Store with append-only JSON lines files
+type FileStore struct {
+	dir string
+}
+
+// NewFileStore creates a file-backed history store, creating the directory if needed
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create history directory: %w", err)
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+// RecordRun appends a run record
+func (s *FileStore) RecordRun(run Run) error {
+	return s.appendRecord(runsFile, run)
+}
+
+// SaveSnapshot appends a node snapshot record
+func (s *FileStore) SaveSnapshot(snapshot NodeSnapshot) error {
+	return s.appendRecord(snapshotsFile, snapshot)
+}
+
+// GetRuns returns all recorded runs keyed by run ID
+func (s *FileStore) GetRuns() (map[string]Run, error) {
+	runs := make(map[string]Run)
+	err := s.readRecords(runsFile, func(line []byte) error {
+		var run Run
+		if err := json.Unmarshal(line, &run); err != nil {
+			return err
+		}
+		runs[run.ID] = run
+		return nil
+	})
+	return runs, err
+}
+
+// GetNodeSnapshots returns the snapshots of a node in chronological order
+func (s *FileStore) GetNodeSnapshots(node string) ([]NodeSnapshot, error) {
+	var snapshots []NodeSnapshot
+	err := s.readRecords(snapshotsFile, func(line []byte) error {
+		var snapshot NodeSnapshot
+		if err := json.Unmarshal(line, &snapshot); err != nil {
+			return err
+		}
+		if snapshot.Node == node {
+			snapshots = append(snapshots, snapshot)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(snapshots, func(i, j int) bool {
+		return snapshots[i].TakenAt.Before(snapshots[j].TakenAt)
+	})
+	return snapshots, nil
+}
+
+// SaveMatrix appends a connectivity matrix record
+func (s *FileStore) SaveMatrix(matrix Matrix) error {
+	return s.appendRecord(matricesFile, matrix)
+}
+
+// GetBaseline returns the latest matrix recorded as baseline, or nil when there is none
+func (s *FileStore) GetBaseline() (*Matrix, error) {
+	var baseline *Matrix
+	err := s.readRecords(matricesFile, func(line []byte) error {
+		var matrix Matrix
+		if err := json.Unmarshal(line, &matrix); err != nil {
+			return err
+		}
+		if matrix.Baseline {
+			baseline = &matrix
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return baseline, nil
+}
+
+// appendRecord writes a single JSON line to a history file
+func (s *FileStore) appendRecord(name string, record interface{}) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s record: %w", name, err)
+	}
+
+	file, err := os.OpenFile(filepath.Join(s.dir, name), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", name, err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
+
+// readRecords calls fn for every non-empty line of a history file; a missing file has no records
+func (s *FileStore) readRecords(name string, fn func(line []byte) error) error {
+	file, err := os.Open(filepath.Join(s.dir, name))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", name, err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		if err := fn(scanner.Bytes()); err != nil {
+			return fmt.Errorf("corrupt record in %s line %d: %w", name, lineNumber, err)
+		}
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,98 @@
+// Package history provides unit tests for the file-backed history store
+// WHY: The timeline is only as trustworthy as the records it is built from
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFileStore_RoundTrip tests that runs and snapshots are persisted and read back
+// WHY: Snapshots from different runs and nodes must be attributed correctly
+func TestFileStore_RoundTrip(t *testing.T) {
+	// Given: Store with two runs and interleaved snapshots
+	store, err := NewFileStore(filepath.Join(t.TempDir(), "history"))
+	require.NoError(t, err)
+
+	base := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, store.RecordRun(Run{ID: "run-1", StartedAt: base, Operation: "apply", ConfigFile: "cluster.yaml"}))
+	require.NoError(t, store.RecordRun(Run{ID: "run-2", StartedAt: base.Add(time.Hour), Operation: "snapshot"}))
+
+	require.NoError(t, store.SaveSnapshot(NodeSnapshot{Node: "rsb2", RunID: "run-2", TakenAt: base.Add(time.Hour)}))
+	require.NoError(t, store.SaveSnapshot(NodeSnapshot{Node: "rsb3", RunID: "run-1", TakenAt: base}))
+	require.NoError(t, store.SaveSnapshot(NodeSnapshot{Node: "rsb2", RunID: "run-1", TakenAt: base, Labels: map[string]string{"role": "compute"}}))
+
+	// When: Read back
+	runs, err := store.GetRuns()
+	require.NoError(t, err)
+	snapshots, err := store.GetNodeSnapshots("rsb2")
+	require.NoError(t, err)
+
+	// Then: Runs are keyed by ID and snapshots are filtered and chronological
+	assert.Len(t, runs, 2)
+	assert.Equal(t, "cluster.yaml", runs["run-1"].ConfigFile)
+	require.Len(t, snapshots, 2)
+	assert.Equal(t, "run-1", snapshots[0].RunID)
+	assert.Equal(t, map[string]string{"role": "compute"}, snapshots[0].Labels)
+	assert.Equal(t, "run-2", snapshots[1].RunID)
+}
+
+// TestFileStore_EmptyAndCorrupt tests reading missing and damaged history files
+// WHY: A fresh history directory is normal, a damaged one must be reported not silently ignored
+func TestFileStore_EmptyAndCorrupt(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewFileStore(dir)
+	require.NoError(t, err)
+
+	snapshots, err := store.GetNodeSnapshots("rsb2")
+	assert.NoError(t, err, "missing file should mean no snapshots")
+	assert.Empty(t, snapshots)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, snapshotsFile), []byte("{\"node\":\"rsb2\"}\nnot-json\n"), 0644))
+	_, err = store.GetNodeSnapshots("rsb2")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "line 2")
+}
+
+// TestFileStore_Baseline tests that the latest baseline matrix is read back
+// WHY: Runs recorded after a baseline must not replace it, and a newer baseline must
+func TestFileStore_Baseline(t *testing.T) {
+	// Given: An empty store
+	store, err := NewFileStore(filepath.Join(t.TempDir(), "history"))
+	require.NoError(t, err)
+	base := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	// When/Then: No baseline yet
+	baseline, err := store.GetBaseline()
+	require.NoError(t, err)
+	assert.Nil(t, baseline)
+
+	// When: Two baselines and a later regular run are recorded
+	require.NoError(t, store.SaveMatrix(Matrix{TakenAt: base, Baseline: true}))
+	require.NoError(t, store.SaveMatrix(Matrix{TakenAt: base.Add(time.Hour), Baseline: true, Pairs: []Pair{{Test: "ping", Reachable: true}}}))
+	require.NoError(t, store.SaveMatrix(Matrix{TakenAt: base.Add(2 * time.Hour)}))
+	baseline, err = store.GetBaseline()
+
+	// Then: The latest baseline is returned
+	require.NoError(t, err)
+	require.NotNil(t, baseline)
+	assert.Equal(t, base.Add(time.Hour), baseline.TakenAt)
+	assert.Equal(t, []Pair{{Test: "ping", Reachable: true}}, baseline.Pairs)
+}
+
+// TestNewRunID tests the run identifier format
+// WHY: IDs are shown to operators and must sort by time
+func TestNewRunID(t *testing.T) {
+	now := time.Date(2026, 10, 16, 15, 4, 5, 0, time.UTC)
+
+	id := NewRunID(now)
+
+	assert.Regexp(t, regexp.MustCompile(`^20261016-150405-[0-9a-f]{6}$`), id)
+	assert.NotEqual(t, id, NewRunID(now), "IDs in the same second should differ")
+}
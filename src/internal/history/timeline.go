@@ -0,0 +1,87 @@
+package history
+
+import (
+	"sort"
+	"time"
+)
+
+// Change operations
+const (
+	ChangeAdded    = "added"
+	ChangeRemoved  = "removed"
+	ChangeModified = "modified"
+)
+
+// Change is a single metadata difference between two consecutive snapshots of a node
+type Change struct {
+	Kind     string // label, annotation or vlan
+	Key      string
+	Op       string // added, removed or modified
+	OldValue string
+	NewValue string
+}
+
+// TimelineEntry groups the changes first observed by one snapshot
+type TimelineEntry struct {
+	Time    time.Time
+	RunID   string
+	Changes []Change
+}
+
+// BuildTimeline diffs consecutive snapshots, skipping snapshots that observed no change
+// The first snapshot is reported in full as additions
+func BuildTimeline(snapshots []NodeSnapshot) []TimelineEntry {
+	var timeline []TimelineEntry
+	previous := NodeSnapshot{}
+
+	for _, snapshot := range snapshots {
+		var changes []Change
+		changes = append(changes, diffMaps("label", previous.Labels, snapshot.Labels)...)
+		changes = append(changes, diffMaps("annotation", previous.Annotations, snapshot.Annotations)...)
+		changes = append(changes, diffMaps("vlan", previous.VLANs, snapshot.VLANs)...)
+
+		if len(changes) > 0 {
+			timeline = append(timeline, TimelineEntry{
+				Time:    snapshot.TakenAt,
+				RunID:   snapshot.RunID,
+				Changes: changes,
+			})
+		}
+		previous = snapshot
+	}
+
+	return timeline
+}
+
+// diffMaps returns the key-sorted changes from before to after
+func diffMaps(kind string, before, after map[string]string) []Change {
+	keys := make(map[string]bool)
+	for key := range before {
+		keys[key] = true
+	}
+	for key := range after {
+		keys[key] = true
+	}
+
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+
+	var changes []Change
+	for _, key := range sorted {
+		oldValue, hadOld := before[key]
+		newValue, hasNew := after[key]
+
+		switch {
+		case !hadOld:
+			changes = append(changes, Change{Kind: kind, Key: key, Op: ChangeAdded, NewValue: newValue})
+		case !hasNew:
+			changes = append(changes, Change{Kind: kind, Key: key, Op: ChangeRemoved, OldValue: oldValue})
+		case oldValue != newValue:
+			changes = append(changes, Change{Kind: kind, Key: key, Op: ChangeModified, OldValue: oldValue, NewValue: newValue})
+		}
+	}
+	return changes
+}
@@ -0,0 +1,63 @@
+// Package history provides unit tests for timeline construction
+// WHY: Operators rely on the timeline to find which run changed a node
+package history
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestBuildTimeline tests diffing of consecutive snapshots
+// WHY: Every change must be attributed to the first run that observed it
+func TestBuildTimeline(t *testing.T) {
+	base := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	snapshots := []NodeSnapshot{
+		{
+			RunID:   "run-1",
+			TakenAt: base,
+			Labels:  map[string]string{"openstack-role": "compute"},
+			VLANs:   map[string]string{"management": "10.0.0.2/24"},
+		},
+		{
+			// No changes: must not produce an entry
+			RunID:   "run-2",
+			TakenAt: base.Add(time.Hour),
+			Labels:  map[string]string{"openstack-role": "compute"},
+			VLANs:   map[string]string{"management": "10.0.0.2/24"},
+		},
+		{
+			RunID:       "run-3",
+			TakenAt:     base.Add(2 * time.Hour),
+			Labels:      map[string]string{"openstack-role": "storage", "zone": "a"},
+			Annotations: map[string]string{"note": "moved"},
+		},
+	}
+
+	timeline := BuildTimeline(snapshots)
+
+	require.Len(t, timeline, 2)
+
+	// Then: First snapshot is reported in full
+	assert.Equal(t, "run-1", timeline[0].RunID)
+	assert.Equal(t, []Change{
+		{Kind: "label", Key: "openstack-role", Op: ChangeAdded, NewValue: "compute"},
+		{Kind: "vlan", Key: "management", Op: ChangeAdded, NewValue: "10.0.0.2/24"},
+	}, timeline[0].Changes)
+
+	// Then: Later changes are attributed to run-3, skipping the unchanged run-2
+	assert.Equal(t, "run-3", timeline[1].RunID)
+	assert.Equal(t, []Change{
+		{Kind: "label", Key: "openstack-role", Op: ChangeModified, OldValue: "compute", NewValue: "storage"},
+		{Kind: "label", Key: "zone", Op: ChangeAdded, NewValue: "a"},
+		{Kind: "annotation", Key: "note", Op: ChangeAdded, NewValue: "moved"},
+		{Kind: "vlan", Key: "management", Op: ChangeRemoved, OldValue: "10.0.0.2/24"},
+	}, timeline[1].Changes)
+}
+
+// TestBuildTimeline_Empty tests the no-snapshot case
+func TestBuildTimeline_Empty(t *testing.T) {
+	assert.Empty(t, BuildTimeline(nil))
+}
@@ -0,0 +1,56 @@
+// Package history records kictl runs and node metadata snapshots for later inspection
+package history
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+)
+
+// Run describes a single kictl invocation that produced snapshots
+type Run struct {
+	ID         string    `json:"id"`
+	StartedAt  time.Time `json:"startedAt"`
+	Operation  string    `json:"operation"` // apply, delete or snapshot
+	ConfigFile string    `json:"configFile,omitempty"`
+}
+
+// NodeSnapshot captures the kictl-relevant metadata of a node at a point in time
+type NodeSnapshot struct {
+	Node        string            `json:"node"`
+	RunID       string            `json:"runId"`
+	TakenAt     time.Time         `json:"takenAt"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	VLANs       map[string]string `json:"vlans,omitempty"` // VLAN name -> assigned address
+}
+
+// Store persists runs and snapshots
+type Store interface {
+	// RecordRun stores a run so snapshots can be attributed to it
+	RecordRun(run Run) error
+
+	// SaveSnapshot appends a node snapshot
+	SaveSnapshot(snapshot NodeSnapshot) error
+
+	// GetRuns returns all recorded runs keyed by run ID
+	GetRuns() (map[string]Run, error)
+
+	// GetNodeSnapshots returns the snapshots of a node in chronological order
+	GetNodeSnapshots(node string) ([]NodeSnapshot, error)
+
+	// SaveMatrix appends the connectivity matrix of a test run
+	SaveMatrix(matrix Matrix) error
+
+	// GetBaseline returns the latest matrix recorded as baseline, or nil when there is none
+	GetBaseline() (*Matrix, error)
+}
+
+// NewRunID returns a sortable, collision-resistant run identifier
+func NewRunID(now time.Time) string {
+	suffix := make([]byte, 3)
+	if _, err := rand.Read(suffix); err != nil {
+		return now.UTC().Format("20060102-150405.000000")
+	}
+	return now.UTC().Format("20060102-150405") + "-" + hex.EncodeToString(suffix)
+}
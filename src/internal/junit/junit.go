@@ -0,0 +1,185 @@
+// Package junit writes check results as JUnit XML for CI dashboards
+package junit
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// Report is a named collection of test suites, written as a <testsuites> document
+type Report struct {
+	Name   string
+	Suites []*Suite
+}
+
+// Suite groups the test cases of one check, e.g. one configuration kind
+type Suite struct {
+	Name  string
+	Cases []Case
+}
+
+// Case is a single check; it failed when Failure is set and was skipped when Skipped is set
+type Case struct {
+	Name     string
+	Failure  string
+	Skipped  string
+	Duration time.Duration // Omitted from the report when zero
+	Output   string        // Written as system-out, e.g. the command output of a connectivity test
+}
+
+// NewReport creates an empty report
+func NewReport(name string) *Report {
+	return &Report{Name: name}
+}
+
+// Suite returns the suite with the name, adding it on first use
+func (r *Report) Suite(name string) *Suite {
+	for _, suite := range r.Suites {
+		if suite.Name == name {
+			return suite
+		}
+	}
+	suite := &Suite{Name: name}
+	r.Suites = append(r.Suites, suite)
+	return suite
+}
+
+// Pass records a passed case
+func (s *Suite) Pass(name string) {
+	s.Cases = append(s.Cases, Case{Name: name})
+}
+
+// Fail records a failed case
+func (s *Suite) Fail(name, message string) {
+	s.Cases = append(s.Cases, Case{Name: name, Failure: message})
+}
+
+// Skip records a skipped case
+func (s *Suite) Skip(name, reason string) {
+	s.Cases = append(s.Cases, Case{Name: name, Skipped: reason})
+}
+
+// Add records a case with all of its details
+func (s *Suite) Add(c Case) {
+	s.Cases = append(s.Cases, c)
+}
+
+// Failures returns the number of failed cases in the report
+func (r *Report) Failures() int {
+	failures := 0
+	for _, suite := range r.Suites {
+		failures += suite.failures()
+	}
+	return failures
+}
+
+// failures returns the number of failed cases in the suite
+func (s *Suite) failures() int {
+	failures := 0
+	for _, c := range s.Cases {
+		if c.Failure != "" {
+			failures++
+		}
+	}
+	return failures
+}
+
+// skipped returns the number of skipped cases in the suite
+func (s *Suite) skipped() int {
+	skipped := 0
+	for _, c := range s.Cases {
+		if c.Skipped != "" && c.Failure == "" {
+			skipped++
+		}
+	}
+	return skipped
+}
+
+// XML document layout, following the schema Jenkins, GitLab and GitHub test reporters read
+type xmlTestSuites struct {
+	XMLName  xml.Name       `xml:"testsuites"`
+	Name     string         `xml:"name,attr"`
+	Tests    int            `xml:"tests,attr"`
+	Failures int            `xml:"failures,attr"`
+	Suites   []xmlTestSuite `xml:"testsuite"`
+}
+
+type xmlTestSuite struct {
+	Name     string        `xml:"name,attr"`
+	Tests    int           `xml:"tests,attr"`
+	Failures int           `xml:"failures,attr"`
+	Skipped  int           `xml:"skipped,attr"`
+	Time     string        `xml:"time,attr,omitempty"`
+	Cases    []xmlTestCase `xml:"testcase"`
+}
+
+type xmlTestCase struct {
+	Name      string      `xml:"name,attr"`
+	Classname string      `xml:"classname,attr"`
+	Time      string      `xml:"time,attr,omitempty"`
+	Failure   *xmlMessage `xml:"failure,omitempty"`
+	Skipped   *xmlMessage `xml:"skipped,omitempty"`
+	SystemOut string      `xml:"system-out,omitempty"`
+}
+
+type xmlMessage struct {
+	Message string `xml:"message,attr"`
+	Body    string `xml:",chardata"`
+}
+
+// Write encodes the report as JUnit XML
+func (r *Report) Write(w io.Writer) error {
+	doc := xmlTestSuites{Name: r.Name, Failures: r.Failures()}
+	for _, suite := range r.Suites {
+		xmlSuite := xmlTestSuite{Name: suite.Name, Tests: len(suite.Cases), Failures: suite.failures(), Skipped: suite.skipped()}
+		var suiteDuration time.Duration
+		for _, c := range suite.Cases {
+			suiteDuration += c.Duration
+			xmlCase := xmlTestCase{Name: c.Name, Classname: r.Name + "." + suite.Name, Time: seconds(c.Duration), SystemOut: c.Output}
+			if c.Failure != "" {
+				xmlCase.Failure = &xmlMessage{Message: c.Failure, Body: c.Failure}
+			} else if c.Skipped != "" {
+				xmlCase.Skipped = &xmlMessage{Message: c.Skipped}
+			}
+			xmlSuite.Cases = append(xmlSuite.Cases, xmlCase)
+		}
+		xmlSuite.Time = seconds(suiteDuration)
+		doc.Tests += xmlSuite.Tests
+		doc.Suites = append(doc.Suites, xmlSuite)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return fmt.Errorf("failed to encode JUnit report: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// seconds formats a duration as JUnit time in seconds, or an empty string for zero
+func seconds(d time.Duration) string {
+	if d == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+// WriteFile writes the report as JUnit XML to path
+func (r *Report) WriteFile(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create JUnit report: %w", err)
+	}
+	if err := r.Write(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,115 @@
+// Package junit provides unit tests for JUnit XML reports
+// WHY: CI dashboards parse these files, so counts and failure elements must follow the JUnit schema
+package junit
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestReport_Write tests the XML encoding of a report
+// WHY: Suite and report totals must match the cases, and failure messages must be escaped
+func TestReport_Write(t *testing.T) {
+	tests := []struct {
+		name     string
+		build    func(r *Report)
+		expected string
+	}{
+		{
+			name:  "empty_report",
+			build: func(r *Report) {},
+			expected: `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites name="kictl verify" tests="0" failures="0"></testsuites>
+`,
+		},
+		{
+			name: "passed_failed_and_skipped_cases",
+			build: func(r *Report) {
+				suite := r.Suite("NodeVLANConf")
+				suite.Pass("rsb2")
+				suite.Fail("rsb3", `interface "eth0.100" <missing>`)
+				r.Suite("NodeTestConf").Skip("ping", "dry run")
+				r.Suite("NodeVLANConf").Pass("rsb4")
+			},
+			expected: `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites name="kictl verify" tests="4" failures="1">
+  <testsuite name="NodeVLANConf" tests="3" failures="1" skipped="0">
+    <testcase name="rsb2" classname="kictl verify.NodeVLANConf"></testcase>
+    <testcase name="rsb3" classname="kictl verify.NodeVLANConf">
+      <failure message="interface &#34;eth0.100&#34; &lt;missing&gt;">interface &#34;eth0.100&#34; &lt;missing&gt;</failure>
+    </testcase>
+    <testcase name="rsb4" classname="kictl verify.NodeVLANConf"></testcase>
+  </testsuite>
+  <testsuite name="NodeTestConf" tests="1" failures="0" skipped="1">
+    <testcase name="ping" classname="kictl verify.NodeTestConf">
+      <skipped message="dry run"></skipped>
+    </testcase>
+  </testsuite>
+</testsuites>
+`,
+		},
+		{
+			name: "durations_and_output",
+			build: func(r *Report) {
+				r.Suite("NodeTestConf").Add(Case{Name: "ping", Duration: 1250 * time.Millisecond, Output: "3 packets received"})
+				r.Suite("NodeTestConf").Add(Case{Name: "api", Duration: 250 * time.Millisecond, Failure: "connection refused"})
+			},
+			expected: `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites name="kictl verify" tests="2" failures="1">
+  <testsuite name="NodeTestConf" tests="2" failures="1" skipped="0" time="1.500">
+    <testcase name="ping" classname="kictl verify.NodeTestConf" time="1.250">
+      <system-out>3 packets received</system-out>
+    </testcase>
+    <testcase name="api" classname="kictl verify.NodeTestConf" time="0.250">
+      <failure message="connection refused">connection refused</failure>
+    </testcase>
+  </testsuite>
+</testsuites>
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A report with cases
+			report := NewReport("kictl verify")
+			tt.build(report)
+
+			// When: Write it
+			var out bytes.Buffer
+			err := report.Write(&out)
+
+			// Then: The XML matches exactly
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, out.String())
+		})
+	}
+}
+
+// TestReport_WriteFile tests writing a report to disk
+// WHY: Unwritable paths must fail the run instead of leaving CI without results
+func TestReport_WriteFile(t *testing.T) {
+	// Given: A report with a failure
+	report := NewReport("kictl validate")
+	report.Suite("validation").Fail("load", "bad yaml")
+	path := filepath.Join(t.TempDir(), "report.xml")
+
+	// When: Write it to a file and to a missing directory
+	err := report.WriteFile(path)
+	missingErr := report.WriteFile(filepath.Join(t.TempDir(), "missing", "report.xml"))
+
+	// Then: Only the missing directory fails
+	require.NoError(t, err)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `failures="1"`)
+	assert.Equal(t, 1, report.Failures())
+	require.Error(t, missingErr)
+	assert.Contains(t, missingErr.Error(), "failed to create JUnit report")
+}
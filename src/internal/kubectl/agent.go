@@ -0,0 +1,255 @@
+package kubectl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+	utilexec "k8s.io/client-go/util/exec"
+)
+
+// AgentName names the node agent DaemonSet, its pods and its container
+const AgentName = "kictl-node-agent"
+
+// agentSelector selects the pods of the node agent DaemonSet
+const agentSelector = "app.kubernetes.io/name=" + AgentName
+
+// agentReadyTimeout bounds how long kictl waits for a new or updated agent to run on every node
+const agentReadyTimeout = 2 * time.Minute
+
+// agentIdleCommand keeps the agent container running until kictl execs into it, and stops it promptly on TERM
+var agentIdleCommand = []string{"/bin/sh", "-c", "trap 'exit 0' TERM; while true; do sleep 3600 & wait $!; done"}
+
+// AgentDaemonSet is the privileged DaemonSet whose per-node pods run node commands for the agent backend
+// Its pods share the host's network, PID and IPC namespaces and mount the host root at /host, like kubectl debug node pods.
+func AgentDaemonSet(namespace, image string) *appsv1.DaemonSet {
+	labels := map[string]string{"app.kubernetes.io/name": AgentName, "app.kubernetes.io/managed-by": "kictl"}
+	privileged := true
+	return &appsv1.DaemonSet{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "DaemonSet"},
+		ObjectMeta: metav1.ObjectMeta{Name: AgentName, Namespace: namespace, Labels: labels},
+		Spec: appsv1.DaemonSetSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app.kubernetes.io/name": AgentName}},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					HostNetwork:       true,
+					HostPID:           true,
+					HostIPC:           true,
+					PriorityClassName: "system-node-critical",
+					Tolerations:       []corev1.Toleration{{Operator: corev1.TolerationOpExists}},
+					Containers: []corev1.Container{{
+						Name:            AgentName,
+						Image:           image,
+						Command:         agentIdleCommand,
+						SecurityContext: &corev1.SecurityContext{Privileged: &privileged},
+						VolumeMounts:    []corev1.VolumeMount{{Name: "host-root", MountPath: "/host"}},
+					}},
+					Volumes: []corev1.Volume{{
+						Name:         "host-root",
+						VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: "/"}},
+					}},
+				},
+			},
+		},
+	}
+}
+
+// agentDeployment remembers that an executor deployed the agent, so it is deployed once per run
+type agentDeployment struct {
+	mu       sync.Mutex
+	deployed bool
+}
+
+// ensure deploys the agent unless it already was; a failed deployment is retried by the next node command
+func (d *agentDeployment) ensure(deploy func() error) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.deployed {
+		return nil
+	}
+	if err := deploy(); err != nil {
+		return err
+	}
+	d.deployed = true
+	return nil
+}
+
+// execViaAgent runs a command with kubectl exec in the node's agent pod, deploying the agent first
+func (e *RealExecutor) execViaAgent(ctx context.Context, nodeName, command string) (bool, string, error) {
+	namespace := e.options.AgentNamespace
+	if e.dryRun {
+		e.logger.Debug(fmt.Sprintf("DRY RUN: Would run on node %s via node agent %s/%s: %s", nodeName, namespace, AgentName, command))
+		return true, fmt.Sprintf("Command would be executed on node %s: %s", nodeName, command), nil
+	}
+
+	if err := e.agent.ensure(func() error { return e.deployAgent(ctx) }); err != nil {
+		return false, "", &HostCommandError{Node: nodeName, Class: HostErrorTransport, Cause: err}
+	}
+	podName, err := e.findNodePod(ctx, namespace, agentSelector, nodeName, "the agent exec backend")
+	if err != nil {
+		return false, "", &HostCommandError{Node: nodeName, Class: HostErrorTransport, Cause: err}
+	}
+
+	args := append([]string{"exec", "-n", namespace, podName, "-c", AgentName, "--"}, WrapHostCommand(e.options.HostEntry, command)...)
+	_, output, err := e.runCommand(ctx, args)
+	if err != nil {
+		// Packet loss is the expected result of isolation tests, not a failure
+		if strings.Contains(output, "0 received, 100% packet loss") {
+			return false, output, nil
+		}
+		return false, output, newHostCommandError(nodeName, output,
+			fmt.Errorf("node agent pod %s/%s failed: %w", namespace, podName, err))
+	}
+
+	return nodeCommandSucceeded(command, output), output, nil
+}
+
+// deployAgent applies the agent DaemonSet and waits until it runs on every node
+func (e *RealExecutor) deployAgent(ctx context.Context) error {
+	manifest, err := json.Marshal(AgentDaemonSet(e.options.AgentNamespace, e.options.DebugImage))
+	if err != nil {
+		return fmt.Errorf("failed to render the node agent: %w", err)
+	}
+	file, err := os.CreateTemp("", "kictl-node-agent-*.json")
+	if err != nil {
+		return fmt.Errorf("failed to write the node agent manifest: %w", err)
+	}
+	defer os.Remove(file.Name())
+	if _, err := file.Write(manifest); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write the node agent manifest: %w", err)
+	}
+	file.Close()
+
+	e.logger.Info(fmt.Sprintf("🛰️  Deploying node agent %s/%s", e.options.AgentNamespace, AgentName))
+	if _, output, err := e.runCommand(ctx, []string{"apply", "-f", file.Name()}); err != nil {
+		return fmt.Errorf("failed to deploy the node agent: %s: %w", output, err)
+	}
+	if _, output, err := e.runCommand(ctx, []string{"rollout", "status", "daemonset/" + AgentName,
+		"-n", e.options.AgentNamespace, "--timeout=" + agentReadyTimeout.String()}); err != nil {
+		return fmt.Errorf("node agent did not become ready: %s: %w", output, err)
+	}
+	return nil
+}
+
+// podExecFunc runs argv in a pod's container, returning its output and exit code
+type podExecFunc func(ctx context.Context, namespace, pod, container string, argv []string) (string, int32, error)
+
+// execInAgent runs a command in the node's agent pod; the agent must already be deployed, see deployAgent
+func (e *NativeExecutor) execInAgent(ctx context.Context, client kubernetes.Interface, nodeName, command string) (string, int32, error) {
+	if blocked, err := checkDryRunMutation(e.dryRun, e.options, e.logger, fmt.Sprintf("run node agent command on %s", nodeName)); blocked {
+		return "", 0, err
+	}
+	if e.podExec == nil {
+		return "", 0, fmt.Errorf("the agent exec backend needs a kubeconfig to exec into pods")
+	}
+
+	namespace := e.options.AgentNamespace
+
+	list, err := client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: agentSelector,
+		FieldSelector: "spec.nodeName=" + nodeName + ",status.phase=Running",
+	})
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to find node agent pod on node %s: %w", nodeName, err)
+	}
+	if len(list.Items) == 0 {
+		return "", 0, fmt.Errorf("no running pod matching %s in namespace %s on node %s for the agent exec backend", agentSelector, namespace, nodeName)
+	}
+
+	podName := list.Items[0].Name
+	e.logger.Debug(fmt.Sprintf("Running in node agent pod %s/%s: %s", namespace, podName, command))
+	return e.podExec(ctx, namespace, podName, AgentName, WrapHostCommand(e.options.HostEntry, command))
+}
+
+// deployAgent creates or updates the agent DaemonSet and waits until it runs on every node
+func (e *NativeExecutor) deployAgent(ctx context.Context, client kubernetes.Interface) error {
+	desired := AgentDaemonSet(e.options.AgentNamespace, e.options.DebugImage)
+	daemonSets := client.AppsV1().DaemonSets(e.options.AgentNamespace)
+
+	current, err := daemonSets.Get(ctx, AgentName, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		e.logger.Info(fmt.Sprintf("🛰️  Deploying node agent %s/%s", e.options.AgentNamespace, AgentName))
+		if _, err := daemonSets.Create(ctx, desired, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to deploy the node agent: %w", err)
+		}
+	case err != nil:
+		return fmt.Errorf("failed to read the node agent: %w", err)
+	case current.Spec.Template.Spec.Containers[0].Image != e.options.DebugImage:
+		e.logger.Info(fmt.Sprintf("🛰️  Updating node agent %s/%s to image %s", e.options.AgentNamespace, AgentName, e.options.DebugImage))
+		current.Spec.Template = desired.Spec.Template
+		if _, err := daemonSets.Update(ctx, current, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("failed to update the node agent: %w", err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, agentReadyTimeout)
+	defer cancel()
+	return e.waitFor(ctx, "node agent "+AgentName+" to become ready", func() (bool, error) {
+		ds, err := daemonSets.Get(ctx, AgentName, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		status := ds.Status
+		return status.ObservedGeneration >= ds.Generation && status.DesiredNumberScheduled > 0 &&
+			status.UpdatedNumberScheduled == status.DesiredNumberScheduled && status.NumberReady == status.DesiredNumberScheduled, nil
+	})
+}
+
+// remotePodExec returns a podExecFunc using the pods/exec subresource of the API server
+func remotePodExec(client kubernetes.Interface, config *rest.Config) podExecFunc {
+	return func(ctx context.Context, namespace, pod, container string, argv []string) (string, int32, error) {
+		req := client.CoreV1().RESTClient().Post().Resource("pods").Namespace(namespace).Name(pod).SubResource("exec").
+			VersionedParams(&corev1.PodExecOptions{Container: container, Command: argv, Stdout: true, Stderr: true}, scheme.ParameterCodec)
+		executor, err := remotecommand.NewSPDYExecutor(config, "POST", req.URL())
+		if err != nil {
+			return "", 0, fmt.Errorf("failed to exec into pod %s/%s: %w", namespace, pod, err)
+		}
+
+		var output lockedBuffer
+		err = executor.StreamWithContext(ctx, remotecommand.StreamOptions{Stdout: &output, Stderr: &output})
+		var exitErr utilexec.ExitError
+		if errors.As(err, &exitErr) {
+			return output.String(), int32(exitErr.ExitStatus()), nil
+		}
+		if err != nil {
+			return output.String(), 0, fmt.Errorf("exec into pod %s/%s failed: %w", namespace, pod, err)
+		}
+		return output.String(), 0, nil
+	}
+}
+
+// lockedBuffer collects stdout and stderr, which the exec stream writes concurrently
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+// String returns the collected output without surrounding whitespace
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return strings.TrimSpace(b.buf.String())
+}
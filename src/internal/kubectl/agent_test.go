@@ -0,0 +1,225 @@
+// Package kubectl provides unit tests for the node agent exec backend
+// WHY: The agent replaces one debug pod per command with one privileged pod per node, deployed once
+package kubectl
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"k8ostack-ictl/internal/logging"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// newReadyAgent returns an agent DaemonSet that runs on every node, and its pod on node rsb2
+func newReadyAgent(image string) (*appsv1.DaemonSet, *corev1.Pod) {
+	daemonSet := AgentDaemonSet("kube-system", image)
+	daemonSet.Status = appsv1.DaemonSetStatus{DesiredNumberScheduled: 1, UpdatedNumberScheduled: 1, NumberReady: 1}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: AgentName + "-x7k2p", Namespace: "kube-system", Labels: daemonSet.Spec.Template.Labels},
+		Spec:       corev1.PodSpec{NodeName: "rsb2"},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	return daemonSet, pod
+}
+
+// TestAgentDaemonSet tests the node agent manifest
+// WHY: The agent must reach the host like a kubectl debug node pod, on every node including tainted ones
+func TestAgentDaemonSet(t *testing.T) {
+	// Given/When: Render the agent
+	daemonSet := AgentDaemonSet("infra", "registry.local/busybox:1.36")
+
+	// Then: One privileged host pod per node with the host root mounted at /host
+	assert.Equal(t, "infra", daemonSet.Namespace)
+	assert.Equal(t, AgentName, daemonSet.Name)
+	spec := daemonSet.Spec.Template.Spec
+	assert.True(t, spec.HostNetwork)
+	assert.True(t, spec.HostPID)
+	assert.Equal(t, []corev1.Toleration{{Operator: corev1.TolerationOpExists}}, spec.Tolerations)
+	require.Len(t, spec.Containers, 1)
+	assert.Equal(t, "registry.local/busybox:1.36", spec.Containers[0].Image)
+	assert.True(t, *spec.Containers[0].SecurityContext.Privileged)
+	assert.Equal(t, "/host", spec.Containers[0].VolumeMounts[0].MountPath)
+	assert.Equal(t, "/", spec.Volumes[0].HostPath.Path)
+	assert.Subset(t, daemonSet.Spec.Template.Labels, daemonSet.Spec.Selector.MatchLabels)
+}
+
+// TestExecNodeCommand_AgentBackend tests command routing through the kubectl agent backend
+// WHY: The agent backend must exec into the agent pod rather than creating a node debug pod
+func TestExecNodeCommand_AgentBackend(t *testing.T) {
+	t.Run("dry_run", func(t *testing.T) {
+		// Given: Agent executor in dry-run mode
+		logger := logging.NewRecordingLogger()
+		executor := NewExecutorWithOptions(logger, ExecutorOptions{NodeExecBackend: NodeExecBackendAgent})
+		executor.SetDryRun(true)
+
+		// When: Execute command
+		success, output, err := executor.ExecNodeCommand(context.Background(), "rsb2", "ip link show")
+
+		// Then: Simulated without deploying the agent
+		assert.NoError(t, err)
+		assert.True(t, success)
+		assert.Contains(t, output, "Command would be executed on node rsb2")
+		debugMessage := strings.Join(logger.Messages(logging.LevelDebug), " ")
+		assert.Contains(t, debugMessage, "via node agent kube-system/"+AgentName)
+		assert.NotContains(t, debugMessage, "kubectl debug node")
+	})
+
+	t.Run("deploys_agent", func(t *testing.T) {
+		// Given: Agent executor without a reachable cluster
+		logger := logging.NewRecordingLogger()
+		executor := NewExecutorWithOptions(logger, ExecutorOptions{NodeExecBackend: NodeExecBackendAgent, AgentNamespace: "infra"})
+
+		// When: Execute command
+		success, _, err := executor.ExecNodeCommand(context.Background(), "rsb2", "ip link show")
+
+		// Then: Deploying the agent is attempted and its failure reported
+		assert.False(t, success)
+		var hostErr *HostCommandError
+		require.True(t, errors.As(err, &hostErr))
+		assert.Equal(t, HostErrorTransport, hostErr.Class)
+		infoMessage := strings.Join(logger.Messages(logging.LevelInfo), " ")
+		assert.Contains(t, infoMessage, "Deploying node agent infra/"+AgentName)
+		assert.NotContains(t, strings.Join(logger.Messages(logging.LevelDebug), " "), "kubectl debug node")
+	})
+
+	t.Run("deploy_failure_is_transport", func(t *testing.T) {
+		// Given: A kubectl that rejects the agent manifest with a generic error
+		bin := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(bin, "kubectl"), []byte("#!/bin/sh\necho 'error: manifest rejected'\nexit 1\n"), 0755))
+		t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+		executor := NewExecutorWithOptions(logging.NewRecordingLogger(), ExecutorOptions{NodeExecBackend: NodeExecBackendAgent})
+
+		// When: Execute command
+		success, _, err := executor.ExecNodeCommand(context.Background(), "rsb2", "ip link show")
+
+		// Then: The command never ran, so the failure is a transport failure whatever kubectl printed
+		assert.False(t, success)
+		var hostErr *HostCommandError
+		require.True(t, errors.As(err, &hostErr))
+		assert.Equal(t, HostErrorTransport, hostErr.Class)
+	})
+}
+
+// TestNativeExecutor_AgentBackend tests node commands through the node agent with client-go
+// WHY: Commands must exec into the agent pod on the node, and the agent must be deployed only once per run
+func TestNativeExecutor_AgentBackend(t *testing.T) {
+	tests := []struct {
+		name            string
+		exitCode        int32
+		expectSuccess   bool
+		expectErrorType string
+	}{
+		{name: "success", exitCode: 0, expectSuccess: true},
+		{name: "missing_binary", exitCode: 127, expectErrorType: HostErrorNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A ready agent and a pod exec that records its calls
+			daemonSet, pod := newReadyAgent("busybox")
+			client := fake.NewSimpleClientset(daemonSet, pod)
+			executor := NewNativeExecutorWithClient(logging.NewRecordingLogger(), client, "default",
+				ExecutorOptions{NodeExecBackend: NodeExecBackendAgent}).(*NativeExecutor)
+			executor.SetPollingInterval(0)
+			var calls [][]string
+			executor.podExec = func(ctx context.Context, namespace, podName, container string, argv []string) (string, int32, error) {
+				calls = append(calls, append([]string{namespace, podName, container}, argv...))
+				return "agent output", tt.exitCode, nil
+			}
+
+			// When: Execute two commands
+			success, output, err := executor.ExecNodeCommand(context.Background(), "rsb2", "ip link show")
+			_, _, _ = executor.ExecNodeCommand(context.Background(), "rsb2", "ip addr show")
+
+			// Then: Both ran in the agent pod, entering the host with chroot
+			assert.Equal(t, tt.expectSuccess, success)
+			if tt.expectErrorType != "" {
+				var hostErr *HostCommandError
+				require.True(t, errors.As(err, &hostErr))
+				assert.Equal(t, tt.expectErrorType, hostErr.Class)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, "agent output", output)
+			}
+			require.Len(t, calls, 2)
+			expected := append([]string{"kube-system", pod.Name, AgentName}, WrapHostCommand(HostEntryChroot, "ip link show")...)
+			assert.Equal(t, expected, calls[0])
+
+			// And: The agent was read once and no pod was created
+			gets := 0
+			for _, action := range client.Actions() {
+				assert.NotEqual(t, "create", action.GetVerb(), "agent backend must not create pods")
+				if action.GetVerb() == "get" && action.GetResource().Resource == "daemonsets" {
+					gets++
+				}
+			}
+			assert.Equal(t, 2, gets, "one lookup and one readiness check")
+		})
+	}
+}
+
+// TestNativeExecutor_DeployAgent tests creating and updating the agent DaemonSet
+// WHY: The first run must install the agent and a changed debug image must roll it out again
+func TestNativeExecutor_DeployAgent(t *testing.T) {
+	t.Run("creates_missing_agent", func(t *testing.T) {
+		// Given: A cluster without the agent, where it never becomes ready
+		client := fake.NewSimpleClientset()
+		executor := NewNativeExecutorWithClient(logging.NewRecordingLogger(), client, "default",
+			ExecutorOptions{AgentNamespace: "infra"}).(*NativeExecutor)
+		executor.SetPollingInterval(time.Millisecond)
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		// When: Deploy the agent
+		err := executor.deployAgent(ctx, client)
+
+		// Then: It is created, and waiting for it is bounded by the context
+		assert.Error(t, err)
+		created, getErr := client.AppsV1().DaemonSets("infra").Get(context.Background(), AgentName, metav1.GetOptions{})
+		require.NoError(t, getErr)
+		assert.Equal(t, "busybox", created.Spec.Template.Spec.Containers[0].Image)
+	})
+
+	t.Run("updates_image", func(t *testing.T) {
+		// Given: A ready agent running an older image
+		daemonSet, _ := newReadyAgent("busybox:1.35")
+		client := fake.NewSimpleClientset(daemonSet)
+		executor := NewNativeExecutorWithClient(logging.NewRecordingLogger(), client, "default",
+			ExecutorOptions{DebugImage: "busybox:1.36"}).(*NativeExecutor)
+		executor.SetPollingInterval(0)
+
+		// When: Deploy the agent
+		err := executor.deployAgent(context.Background(), client)
+
+		// Then: Its pod template uses the configured image
+		require.NoError(t, err)
+		updated, getErr := client.AppsV1().DaemonSets("kube-system").Get(context.Background(), AgentName, metav1.GetOptions{})
+		require.NoError(t, getErr)
+		assert.Equal(t, "busybox:1.36", updated.Spec.Template.Spec.Containers[0].Image)
+	})
+
+	t.Run("without_kubeconfig", func(t *testing.T) {
+		// Given: A ready agent but no way to exec into pods
+		daemonSet, pod := newReadyAgent("busybox")
+		executor, _ := newTestNativeExecutor(daemonSet, pod)
+		executor.options.NodeExecBackend = NodeExecBackendAgent
+
+		// When: Execute a command
+		success, _, err := executor.ExecNodeCommand(context.Background(), "rsb2", "ip link show")
+
+		// Then: The missing exec transport is reported
+		assert.False(t, success)
+		assert.ErrorContains(t, err, "needs a kubeconfig")
+	})
+}
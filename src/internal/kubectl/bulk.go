@@ -0,0 +1,36 @@
+package kubectl
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// FormatLabels returns labels as key=value arguments sorted by key
+func FormatLabels(labels map[string]string) []string {
+	keys := sortedKeys(labels)
+	formatted := make([]string, 0, len(keys))
+	for _, key := range keys {
+		formatted = append(formatted, key+"="+labels[key])
+	}
+	return formatted
+}
+
+// sortedKeys returns the keys of labels in sorted order
+func sortedKeys(labels map[string]string) []string {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// labeledOutput mimics the output kubectl label prints for each labeled node
+func labeledOutput(nodeNames []string) string {
+	lines := make([]string, 0, len(nodeNames))
+	for _, nodeName := range nodeNames {
+		lines = append(lines, fmt.Sprintf("node/%s labeled", nodeName))
+	}
+	return strings.Join(lines, "\n")
+}
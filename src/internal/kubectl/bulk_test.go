@@ -0,0 +1,69 @@
+// Package kubectl provides unit tests for labeling several nodes in one call
+// WHY: Large uniform roles are labeled with a shared label set, so the arguments must be complete and stable
+package kubectl
+
+import (
+	"context"
+	"testing"
+
+	"k8ostack-ictl/internal/logging"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFormatLabels tests the key=value arguments built from a label map
+// WHY: Sorted arguments keep commands and logs identical between runs
+func TestFormatLabels(t *testing.T) {
+	tests := []struct {
+		name     string
+		labels   map[string]string
+		expected []string
+	}{
+		{"empty", nil, []string{}},
+		{"sorted_by_key", map[string]string{"role": "compute", "ceph-node": "enabled"}, []string{"ceph-node=enabled", "role=compute"}},
+		{"empty_value", map[string]string{"openstack.icycloud.io/edge": ""}, []string{"openstack.icycloud.io/edge="}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given/When: Format the labels
+			formatted := FormatLabels(tt.labels)
+
+			// Then: Arguments are sorted key=value pairs
+			assert.Equal(t, tt.expected, formatted)
+		})
+	}
+}
+
+// TestRealExecutor_LabelNodes tests the single kubectl label command for several nodes
+// WHY: One command for all nodes is what makes bulk labeling faster than labeling node by node
+func TestRealExecutor_LabelNodes(t *testing.T) {
+	tests := []struct {
+		name            string
+		overwrite       bool
+		expectedCommand string
+	}{
+		{"overwrite", true, "kubectl label node rsb2 rsb3 ceph-node=enabled role=compute --overwrite"},
+		{"no_overwrite", false, "kubectl label node rsb2 rsb3 ceph-node=enabled role=compute"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A dry-run kubectl executor
+			logger := logging.NewRecordingLogger()
+			executor := NewExecutor(logger)
+			executor.SetDryRun(true)
+
+			// When: Label two nodes
+			success, output, err := executor.LabelNodes(context.Background(), []string{"rsb2", "rsb3"},
+				map[string]string{"role": "compute", "ceph-node": "enabled"}, tt.overwrite)
+
+			// Then: One command covers both nodes and the output lists each of them
+			require.NoError(t, err)
+			assert.True(t, success)
+			assert.Equal(t, "node/rsb2 labeled\nnode/rsb3 labeled", output)
+			assert.Equal(t, []string{"DRY RUN: Would run: " + tt.expectedCommand}, logger.Messages(logging.LevelDebug))
+		})
+	}
+}
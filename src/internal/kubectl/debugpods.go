@@ -0,0 +1,312 @@
+package kubectl
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/client-go/kubernetes"
+)
+
+// debugContainerName names the container of node debug pods, as kubectl debug node does
+const debugContainerName = "debugger"
+
+// debugPodPool keeps one idle debug pod per node for a run, so node commands exec into it instead of starting a pod each
+type debugPodPool struct {
+	mu      sync.Mutex
+	nodes   map[string]*nodeDebugPod
+	started []string // every pod the run started, including pods no longer reused
+}
+
+// nodeDebugPod is the debug pod of one node; its lock serialises starting it without blocking other nodes
+type nodeDebugPod struct {
+	mu   sync.Mutex
+	name string
+}
+
+// get returns the debug pod of the node, starting it on first use; a failed start is retried by the next command
+func (p *debugPodPool) get(nodeName string, start func() (string, error)) (string, error) {
+	p.mu.Lock()
+	if p.nodes == nil {
+		p.nodes = make(map[string]*nodeDebugPod)
+	}
+	pod, ok := p.nodes[nodeName]
+	if !ok {
+		pod = &nodeDebugPod{}
+		p.nodes[nodeName] = pod
+	}
+	p.mu.Unlock()
+
+	pod.mu.Lock()
+	defer pod.mu.Unlock()
+	if pod.name != "" {
+		return pod.name, nil
+	}
+	name, err := start()
+	if err != nil {
+		return "", err
+	}
+	pod.name = name
+	p.mu.Lock()
+	p.started = append(p.started, name)
+	p.mu.Unlock()
+	return name, nil
+}
+
+// discard stops reusing the debug pod of a node, e.g. after it was deleted under the run; it is still released
+func (p *debugPodPool) discard(nodeName, podName string) {
+	p.mu.Lock()
+	pod := p.nodes[nodeName]
+	p.mu.Unlock()
+	if pod == nil {
+		return
+	}
+	pod.mu.Lock()
+	if pod.name == podName {
+		pod.name = ""
+	}
+	pod.mu.Unlock()
+}
+
+// drain returns every pod the run started, sorted, and forgets them so the next command starts a new pod
+func (p *debugPodPool) drain() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	started := p.started
+	p.started = nil
+	p.nodes = nil
+	sort.Strings(started)
+	return started
+}
+
+// execViaDebugPod runs a command with kubectl exec in the node's debug pod, starting the pod on the first command
+func (e *RealExecutor) execViaDebugPod(ctx context.Context, nodeName, command string) (bool, string, error) {
+	e.logger.Debug(fmt.Sprintf("Running on node %s: %s", nodeName, command))
+	podName, err := e.debugPods.get(nodeName, func() (string, error) { return e.startDebugPod(ctx, nodeName) })
+	if err != nil {
+		return false, "", &HostCommandError{Node: nodeName, Class: HostErrorTransport, Cause: err}
+	}
+
+	args := append([]string{"exec", podName, "-c", debugContainerName, "--"}, WrapHostCommand(e.options.HostEntry, command)...)
+	_, output, err := e.runCommand(ctx, args)
+	if err != nil {
+		// Packet loss is the expected result of isolation tests, not a failure
+		if strings.Contains(output, "0 received, 100% packet loss") {
+			return false, output, nil
+		}
+		if strings.Contains(output, fmt.Sprintf("pods %q not found", podName)) {
+			e.debugPods.discard(nodeName, podName)
+		}
+		return false, output, newHostCommandError(nodeName, output, fmt.Errorf("debug pod %s failed: %w", podName, err))
+	}
+
+	return nodeCommandSucceeded(command, output), output, nil
+}
+
+// startDebugPod starts an idle kubectl debug node pod and waits until commands can exec into it
+func (e *RealExecutor) startDebugPod(ctx context.Context, nodeName string) (string, error) {
+	var podName string
+	if e.options.LegacyDebug {
+		name, err := e.createDebugPod(ctx, nodeName)
+		if err != nil {
+			return "", err
+		}
+		podName = name
+	} else {
+		args := append([]string{"debug", "node/" + nodeName, "--profile=sysadmin", "--image=" + e.options.DebugImage, "--"}, agentIdleCommand...)
+		_, output, err := e.runCommand(ctx, args)
+		if err != nil {
+			return "", fmt.Errorf("failed to start debug pod on node %s: %w", nodeName, err)
+		}
+		if podName = e.extractPodNameFromDebugOutput(output); podName == "" {
+			return "", fmt.Errorf("failed to extract pod name from debug output: %s", output)
+		}
+	}
+
+	wait := []string{"wait", "--for=condition=Ready", "pod/" + podName, "--timeout=" + nodeCommandTimeout.String()}
+	if _, output, err := e.runCommand(ctx, wait); err != nil {
+		e.deleteDebugPod(podName)
+		return "", fmt.Errorf("debug pod %s did not become ready: %s", podName, output)
+	}
+	e.logger.Debug(fmt.Sprintf("Started debug pod %s on node %s", podName, nodeName))
+	return podName, nil
+}
+
+// createDebugPod creates the pod kubectl debug node --profile=sysadmin would start, for kubectl versions without
+// that profile, and returns its name
+func (e *RealExecutor) createDebugPod(ctx context.Context, nodeName string) (string, error) {
+	pod := newDebugPod(e.options.Target.Namespace, nodeName, e.options.DebugImage, agentIdleCommand)
+	pod.TypeMeta = metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"}
+	manifest, err := json.Marshal(pod)
+	if err != nil {
+		return "", fmt.Errorf("failed to render debug pod for node %s: %w", nodeName, err)
+	}
+	file, err := os.CreateTemp("", "kictl-debug-pod-*.json")
+	if err != nil {
+		return "", fmt.Errorf("failed to write debug pod manifest: %w", err)
+	}
+	defer os.Remove(file.Name())
+	if _, err := file.Write(manifest); err != nil {
+		file.Close()
+		return "", fmt.Errorf("failed to write debug pod manifest: %w", err)
+	}
+	file.Close()
+
+	if _, output, err := e.runCommand(ctx, []string{"create", "-f", file.Name()}); err != nil {
+		return "", fmt.Errorf("failed to start debug pod on node %s: %s: %w", nodeName, output, err)
+	}
+	return pod.Name, nil
+}
+
+// deleteDebugPod deletes a debug pod with a fresh context, so it is removed even after a timeout
+func (e *RealExecutor) deleteDebugPod(podName string) error {
+	if _, output, err := e.runCommand(context.Background(), []string{"delete", "pod", podName, "--ignore-not-found", "--wait=false"}); err != nil {
+		return fmt.Errorf("failed to delete debug pod %s: %s", podName, output)
+	}
+	return nil
+}
+
+// ReleaseDebugPods deletes the debug pods the executor started for node commands
+func (e *RealExecutor) ReleaseDebugPods(ctx context.Context) (int, error) {
+	var errs []error
+	released := 0
+	for _, podName := range e.debugPods.drain() {
+		if err := e.deleteDebugPod(podName); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		released++
+	}
+	return released, errors.Join(errs...)
+}
+
+// newDebugPod builds a privileged pod pinned to the node, equivalent to `kubectl debug node --profile=sysadmin`
+func newDebugPod(namespace, nodeName, image string, command []string) *corev1.Pod {
+	privileged := true
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("node-debugger-%s-%s", nodeName, utilrand.String(5)),
+			Namespace: namespace,
+			Labels:    map[string]string{"app.kubernetes.io/managed-by": "kictl"},
+		},
+		Spec: corev1.PodSpec{
+			NodeName:      nodeName,
+			RestartPolicy: corev1.RestartPolicyNever,
+			HostNetwork:   true,
+			HostPID:       true,
+			HostIPC:       true,
+			Tolerations:   []corev1.Toleration{{Operator: corev1.TolerationOpExists}},
+			Containers: []corev1.Container{{
+				Name:            debugContainerName,
+				Image:           image,
+				Command:         command,
+				SecurityContext: &corev1.SecurityContext{Privileged: &privileged},
+				VolumeMounts:    []corev1.VolumeMount{{Name: "host-root", MountPath: "/host"}},
+			}},
+			Volumes: []corev1.Volume{{
+				Name:         "host-root",
+				VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: "/"}},
+			}},
+		},
+	}
+}
+
+// IsNodeCommandPod reports whether a pod is a debug pod kictl started to run node commands
+// Pods created through the API carry the managed-by label; kubectl debug node pods are recognised by their idle command.
+func IsNodeCommandPod(pod *corev1.Pod) bool {
+	if !strings.HasPrefix(pod.Name, "node-debugger-") {
+		return false
+	}
+	if pod.Labels["app.kubernetes.io/managed-by"] == "kictl" {
+		return true
+	}
+	idle := strings.Join(agentIdleCommand, " ")
+	for _, container := range pod.Spec.Containers {
+		if strings.Join(append(append([]string{}, container.Command...), container.Args...), " ") == idle {
+			return true
+		}
+	}
+	return false
+}
+
+// execInDebugPod runs a command in the node's debug pod, starting the pod on the first command; podExec must be set
+func (e *NativeExecutor) execInDebugPod(ctx context.Context, client kubernetes.Interface, namespace, nodeName, command string) (string, int32, error) {
+	if blocked, err := checkDryRunMutation(e.dryRun, e.options, e.logger, fmt.Sprintf("create node command pod on %s", nodeName)); blocked {
+		return "", 0, err
+	}
+
+	podName, err := e.debugPods.get(nodeName, func() (string, error) { return e.startDebugPod(ctx, client, namespace, nodeName) })
+	if err != nil {
+		return "", 0, err
+	}
+	e.logger.Debug(fmt.Sprintf("Running in debug pod %s/%s: %s", namespace, podName, command))
+	output, exitCode, err := e.podExec(ctx, namespace, podName, debugContainerName, WrapHostCommand(e.options.HostEntry, command))
+	if err != nil {
+		e.debugPods.discard(nodeName, podName)
+	}
+	return output, exitCode, err
+}
+
+// startDebugPod creates an idle debug pod on the node and waits until it runs
+func (e *NativeExecutor) startDebugPod(ctx context.Context, client kubernetes.Interface, namespace, nodeName string) (string, error) {
+	pod := newDebugPod(namespace, nodeName, e.options.DebugImage, agentIdleCommand)
+	pods := client.CoreV1().Pods(namespace)
+	e.logger.Debug(fmt.Sprintf("Starting debug pod %s/%s on node %s", namespace, pod.Name, nodeName))
+	if _, err := pods.Create(ctx, pod, metav1.CreateOptions{}); err != nil {
+		return "", fmt.Errorf("failed to create node command pod on %s: %w", nodeName, err)
+	}
+
+	err := e.waitFor(ctx, fmt.Sprintf("pod %s to run", pod.Name), func() (bool, error) {
+		current, err := pods.Get(ctx, pod.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		switch current.Status.Phase {
+		case corev1.PodRunning:
+			return true, nil
+		case corev1.PodSucceeded, corev1.PodFailed:
+			return false, fmt.Errorf("pod %s stopped with phase %s", pod.Name, current.Status.Phase)
+		}
+		return false, nil
+	})
+	if err != nil {
+		if deleteErr := pods.Delete(context.Background(), pod.Name, metav1.DeleteOptions{}); deleteErr != nil && !apierrors.IsNotFound(deleteErr) {
+			e.logger.Warn(fmt.Sprintf("Failed to delete node command pod %s: %v", pod.Name, deleteErr))
+		}
+		return "", err
+	}
+	return pod.Name, nil
+}
+
+// ReleaseDebugPods deletes the debug pods the executor started for node commands
+func (e *NativeExecutor) ReleaseDebugPods(ctx context.Context) (int, error) {
+	started := e.debugPods.drain()
+	if len(started) == 0 {
+		return 0, nil
+	}
+	client, namespace, err := e.clientset()
+	if err != nil {
+		return 0, err
+	}
+
+	var errs []error
+	released := 0
+	for _, podName := range started {
+		err := client.CoreV1().Pods(namespace).Delete(ctx, podName, metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			errs = append(errs, fmt.Errorf("failed to delete debug pod %s: %w", podName, err))
+			continue
+		}
+		released++
+	}
+	return released, errors.Join(errs...)
+}
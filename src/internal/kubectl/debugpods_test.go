@@ -0,0 +1,159 @@
+// Package kubectl provides unit tests for debug pod reuse
+// WHY: Starting one pod per node command made VLAN configuration slow, and cleanup must only remove the run's own pods
+package kubectl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"k8ostack-ictl/internal/logging"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// TestDebugPodPool tests starting, reusing and releasing the debug pods of a run
+// WHY: Concurrent commands on one node must share a single pod, and discarded pods must still be released
+func TestDebugPodPool(t *testing.T) {
+	// Given: A pool that names pods after the node and counts starts
+	var pool debugPodPool
+	var mu sync.Mutex
+	starts := map[string]int{}
+	start := func(nodeName string) func() (string, error) {
+		return func() (string, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			starts[nodeName]++
+			return fmt.Sprintf("node-debugger-%s-%d", nodeName, starts[nodeName]), nil
+		}
+	}
+
+	// When: Run commands on two nodes concurrently
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		for _, nodeName := range []string{"rsb2", "rsb3"} {
+			wg.Add(1)
+			go func(nodeName string) {
+				defer wg.Done()
+				_, err := pool.get(nodeName, start(nodeName))
+				assert.NoError(t, err)
+			}(nodeName)
+		}
+	}
+	wg.Wait()
+
+	// Then: Each node got one pod
+	assert.Equal(t, map[string]int{"rsb2": 1, "rsb3": 1}, starts)
+
+	// And: A discarded pod is replaced, and both are released
+	pool.discard("rsb2", "node-debugger-rsb2-1")
+	podName, err := pool.get("rsb2", start("rsb2"))
+	require.NoError(t, err)
+	assert.Equal(t, "node-debugger-rsb2-2", podName)
+	assert.Equal(t, []string{"node-debugger-rsb2-1", "node-debugger-rsb2-2", "node-debugger-rsb3-1"}, pool.drain())
+	assert.Empty(t, pool.drain())
+
+	// And: A failed start is retried by the next command
+	_, err = pool.get("rsb4", func() (string, error) { return "", errors.New("forbidden") })
+	assert.EqualError(t, err, "forbidden")
+	podName, err = pool.get("rsb4", start("rsb4"))
+	require.NoError(t, err)
+	assert.Equal(t, "node-debugger-rsb4-1", podName)
+}
+
+// TestNativeExecutor_DebugPodReuse tests node commands exec'ing into one debug pod per node
+// WHY: A run must create each node's pod once and delete exactly the pods it created
+func TestNativeExecutor_DebugPodReuse(t *testing.T) {
+	// Given: An API server that starts pods and a pod exec that records its calls
+	executor, client := newTestNativeExecutor(newTestNode("rsb2", nil))
+	client.PrependReactor("create", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		action.(k8stesting.CreateAction).GetObject().(*corev1.Pod).Status.Phase = corev1.PodRunning
+		return false, nil, nil
+	})
+	other := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "node-debugger-rsb2-other", Namespace: "default"}}
+	_, err := client.CoreV1().Pods("default").Create(context.Background(), other, metav1.CreateOptions{})
+	require.NoError(t, err)
+	var execs []string
+	executor.podExec = func(ctx context.Context, namespace, podName, container string, argv []string) (string, int32, error) {
+		execs = append(execs, podName+"/"+container)
+		return "exec output", 0, nil
+	}
+
+	// When: Run two commands on the node and release the run's pods
+	for _, command := range []string{"ip link show", "ip addr show"} {
+		success, output, err := executor.ExecNodeCommand(context.Background(), "rsb2", command)
+		require.NoError(t, err)
+		assert.True(t, success)
+		assert.Equal(t, "exec output", output)
+	}
+	released, err := executor.ReleaseDebugPods(context.Background())
+
+	// Then: Both commands ran in the one pod the run created
+	require.NoError(t, err)
+	assert.Equal(t, 1, released)
+	require.Len(t, execs, 2)
+	assert.Equal(t, execs[0], execs[1])
+
+	var created *corev1.Pod
+	for _, action := range client.Actions() {
+		if action.GetVerb() == "create" && action.GetResource().Resource == "pods" {
+			if pod := action.(k8stesting.CreateAction).GetObject().(*corev1.Pod); pod.Name != other.Name {
+				created = pod
+			}
+		}
+	}
+	require.NotNil(t, created)
+	assert.Equal(t, created.Name+"/"+debugContainerName, execs[0])
+	assert.Equal(t, agentIdleCommand, created.Spec.Containers[0].Command)
+
+	// And: Only the run's pod was deleted
+	pods, err := client.CoreV1().Pods("default").List(context.Background(), metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, pods.Items, 1)
+	assert.Equal(t, other.Name, pods.Items[0].Name)
+}
+
+// TestExecNodeCommand_DebugPod tests the kubectl commands of the debug pod backend
+// WHY: Starting the idle pod is logged with the node command, so failures without a cluster stay traceable
+func TestExecNodeCommand_DebugPod(t *testing.T) {
+	tests := []struct {
+		name          string
+		legacyDebug   bool
+		expectCommand string
+	}{
+		{name: "kubectl_debug", expectCommand: "kubectl --kubeconfig /nonexistent/kubeconfig debug node/rsb2 --profile=sysadmin"},
+		{name: "legacy_manifest", legacyDebug: true, expectCommand: "kubectl --kubeconfig /nonexistent/kubeconfig create -f "},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A kubectl executor without a reachable cluster
+			logger := logging.NewRecordingLogger()
+			executor := NewExecutorWithOptions(logger, ExecutorOptions{LegacyDebug: tt.legacyDebug}).(*RealExecutor)
+			executor.options.Target = ClusterTarget{Kubeconfig: "/nonexistent/kubeconfig"}
+
+			// When: Execute a command
+			success, _, err := executor.ExecNodeCommand(context.Background(), "rsb2", "ip link show")
+
+			// Then: Starting the debug pod fails as a transport error and no pod is left to release
+			assert.False(t, success)
+			var hostErr *HostCommandError
+			require.True(t, errors.As(err, &hostErr))
+			assert.Contains(t, hostErr.Error(), "failed to start debug pod on node rsb2")
+			released, err := executor.ReleaseDebugPods(context.Background())
+			assert.NoError(t, err)
+			assert.Zero(t, released)
+
+			// And: The pod was started the way the kubectl version allows
+			assert.Contains(t, strings.Join(logger.Messages(logging.LevelDebug), "\n"), tt.expectCommand)
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package kubectl
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"k8ostack-ictl/internal/logging"
+)
+
+// discoveryCommand describes a read-only node command and how it is reported in dry-run mode
+type discoveryCommand struct {
+	command string
+	action  string // dry-run log: "Would <action> on node X"
+	result  string // dry-run output: "DRY RUN: <result> for/on node X"
+}
+
+// Read-only node commands shared by all executor implementations
+var (
+	vlanDiscovery = discoveryCommand{
+		command: "ip link show type vlan",
+		action:  "discover VLANs",
+		result:  "VLAN discovery on",
+	}
+	networkInfoDiscovery = discoveryCommand{
+		command: "ip addr show && echo '---ROUTES---' && ip route show",
+		action:  "get network info",
+		result:  "Network info for",
+	}
+	hardwareInfoDiscovery = discoveryCommand{
+		command: "echo 'CPU:' && lscpu | grep -E '^CPU\\(s\\)|^Model name' && echo 'MEMORY:' && free -h && echo 'STORAGE:' && lsblk",
+		action:  "get hardware info",
+		result:  "Hardware info for",
+	}
+)
+
+// runDiscoveryCommand runs a read-only node command, simulating it in dry-run mode
+func runDiscoveryCommand(ctx context.Context, executor DryRunExecutor, logger logging.Logger, nodeName string, discovery discoveryCommand) (bool, string, error) {
+	if executor.IsDryRun() {
+		logger.Debug(fmt.Sprintf("DRY RUN: Would %s on node %s: %s", discovery.action, nodeName, discovery.command))
+		return true, fmt.Sprintf("DRY RUN: %s node %s", discovery.result, nodeName), nil
+	}
+
+	return executor.ExecNodeCommand(ctx, nodeName, discovery.command)
+}
+
+// getNodeRole retrieves node role based on labels and analysis
+func getNodeRole(ctx context.Context, executor Executor, nodeName string) (string, error) {
+	// Get node labels first
+	success, output, err := executor.GetNodeLabels(ctx, nodeName)
+	if err != nil || !success {
+		return "", fmt.Errorf("failed to get node labels for %s: %w", nodeName, err)
+	}
+
+	// Analyze labels to determine role
+	return analyzeNodeRole(output), nil
+}
+
+// analyzeNodeRole determines node role from label output
+func analyzeNodeRole(labelOutput string) string {
+	// Check for standard Kubernetes node roles
+	if strings.Contains(labelOutput, "node-role.kubernetes.io/control-plane") {
+		return "control-plane"
+	}
+	if strings.Contains(labelOutput, "node-role.kubernetes.io/master") {
+		return "control-plane"
+	}
+
+	// Check for OpenStack-specific roles
+	if strings.Contains(labelOutput, "openstack-role=storage") {
+		return "storage"
+	}
+	if strings.Contains(labelOutput, "openstack-role=compute") {
+		return "compute"
+	}
+	if strings.Contains(labelOutput, "openstack-role=control-plane") {
+		return "control-plane"
+	}
+
+	// Default to worker if no specific role found
+	return "worker"
+}
+
+// discoverClusterState returns comprehensive cluster overview
+func discoverClusterState(ctx context.Context, executor Executor) (map[string]interface{}, error) {
+	state := make(map[string]interface{})
+
+	// Get all nodes
+	success, nodesOutput, err := executor.GetAllNodes(ctx)
+	if err != nil || !success {
+		return nil, fmt.Errorf("failed to get cluster nodes: %w", err)
+	}
+
+	// Parse node list
+	nodeNames := strings.Split(strings.TrimSpace(nodesOutput), "\n")
+	nodeCount := len(nodeNames)
+	if nodeNames[0] == "" {
+		nodeCount = 0
+	}
+
+	// Count roles
+	roleCounts := make(map[string]int)
+	for _, nodeName := range nodeNames {
+		if nodeName == "" {
+			continue
+		}
+		// Strip "node/" prefix if present
+		cleanNodeName := strings.TrimPrefix(nodeName, "node/")
+		role, _ := executor.GetNodeRole(ctx, cleanNodeName)
+		roleCounts[role]++
+	}
+
+	state["total_nodes"] = nodeCount
+	state["node_roles"] = roleCounts
+	state["nodes"] = nodeNames
+
+	return state, nil
+}
+
+// discoverAllVLANs maps VLAN configurations across all nodes
+func discoverAllVLANs(ctx context.Context, executor Executor, logger logging.Logger) (map[string]string, error) {
+	vlanMap := make(map[string]string)
+
+	// Get all nodes first
+	success, nodesOutput, err := executor.GetAllNodes(ctx)
+	if err != nil || !success {
+		return nil, fmt.Errorf("failed to get cluster nodes: %w", err)
+	}
+
+	// Parse node list
+	nodeNames := strings.Split(strings.TrimSpace(nodesOutput), "\n")
+
+	// Discover VLANs on each node
+	for _, nodeName := range nodeNames {
+		if nodeName == "" {
+			continue
+		}
+
+		// Strip "node/" prefix if present
+		cleanNodeName := strings.TrimPrefix(nodeName, "node/")
+
+		success, vlanOutput, err := executor.DiscoverNodeVLANs(ctx, cleanNodeName)
+		if err != nil {
+			logger.Warn(fmt.Sprintf("Failed to discover VLANs on node %s: %v", cleanNodeName, err))
+			vlanMap[cleanNodeName] = "ERROR"
+			continue
+		}
+
+		if success {
+			vlanMap[cleanNodeName] = vlanOutput
+		} else {
+			vlanMap[cleanNodeName] = "NO_VLANS"
+		}
+	}
+
+	return vlanMap, nil
+}
@@ -0,0 +1,45 @@
+package kubectl
+
+import (
+	"errors"
+	"fmt"
+
+	"k8ostack-ictl/internal/logging"
+)
+
+// ErrDryRunMutation is returned when a strict dry run reaches a call that would change the cluster or a node
+var ErrDryRunMutation = errors.New("mutation attempted during dry run")
+
+// mutatingVerbs are the kubectl verbs that change cluster or node state
+// debug and exec count as mutations since they create pods or run commands on nodes
+var mutatingVerbs = map[string]bool{
+	"annotate": true, "apply": true, "attach": true, "autoscale": true, "cordon": true, "cp": true,
+	"create": true, "debug": true, "delete": true, "drain": true, "edit": true, "exec": true,
+	"expose": true, "label": true, "patch": true, "replace": true, "rollout": true, "run": true,
+	"scale": true, "set": true, "taint": true, "uncordon": true,
+}
+
+// IsMutatingCommand reports whether kubectl arguments change cluster or node state
+// Commands with --dry-run=server or --dry-run=client only validate the change, so they do not.
+func IsMutatingCommand(args []string) bool {
+	for _, arg := range args {
+		if arg == "--dry-run=server" || arg == "--dry-run=client" {
+			return false
+		}
+	}
+	return mutatingVerbs[commandVerb(args)]
+}
+
+// checkDryRunMutation is the last line of defence against mutations during a dry run
+// Executors simulate mutations before reaching the cluster, so getting here means a simulation is missing.
+// Plain dry runs block the mutation with a warning; strict dry runs fail so the gap cannot go unnoticed.
+func checkDryRunMutation(dryRun bool, options ExecutorOptions, logger logging.Logger, action string) (bool, error) {
+	if !dryRun {
+		return false, nil
+	}
+	if options.StrictDryRun {
+		return true, fmt.Errorf("%w: %s", ErrDryRunMutation, action)
+	}
+	logger.Warn(fmt.Sprintf("⚠️  DRY RUN: Blocked unexpected mutation: %s", action))
+	return true, nil
+}
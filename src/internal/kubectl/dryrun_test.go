@@ -0,0 +1,130 @@
+// Package kubectl provides unit tests for the dry-run mutation guard
+// WHY: A dry run must never change the cluster, even when a simulation is missing
+package kubectl
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"k8ostack-ictl/internal/logging"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestIsMutatingCommand tests kubectl verb classification
+// WHY: Read-only commands must still run during a dry run so verification keeps working
+func TestIsMutatingCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		expected bool
+	}{
+		{name: "get_node", args: []string{"get", "node", "rsb2"}, expected: false},
+		{name: "get_pods", args: []string{"get", "pods", "-o", "name"}, expected: false},
+		{name: "label_node", args: []string{"label", "node", "rsb2", "role=compute"}, expected: true},
+		{name: "delete_pod", args: []string{"delete", "pod", "node-debugger-rsb2"}, expected: true},
+		{name: "debug_node", args: []string{"debug", "node/rsb2", "-it"}, expected: true},
+		{name: "leading_flag", args: []string{"--request-timeout=5s", "annotate", "node", "rsb2"}, expected: true},
+		{name: "server_dry_run", args: []string{"apply", "-f", "-", "--dry-run=server"}, expected: false},
+		{name: "empty", args: nil, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, IsMutatingCommand(tt.args))
+		})
+	}
+}
+
+// TestRealExecutor_DryRunGuard tests that mutating kubectl commands never run during a dry run
+// WHY: The guard is the last line of defence when a method forgets to simulate its mutation
+func TestRealExecutor_DryRunGuard(t *testing.T) {
+	tests := []struct {
+		name          string
+		strict        bool
+		expectSuccess bool
+		expectErr     bool
+	}{
+		{name: "lenient_warns", strict: false, expectSuccess: true, expectErr: false},
+		{name: "strict_fails", strict: true, expectSuccess: false, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: Executor in dry-run mode
+			logger := logging.NewRecordingLogger()
+			executor := NewExecutorWithOptions(logger, ExecutorOptions{StrictDryRun: tt.strict}).(*RealExecutor)
+			executor.SetDryRun(true)
+
+			// When: A mutating command reaches the transport layer
+			success, output, err := executor.runCommand(context.Background(), []string{"label", "node", "rsb2", "role=compute"})
+
+			// Then: Blocked before kubectl runs
+			assert.Equal(t, tt.expectSuccess, success)
+			assert.Empty(t, output)
+			if tt.expectErr {
+				require.Error(t, err)
+				assert.True(t, errors.Is(err, ErrDryRunMutation))
+				assert.Contains(t, err.Error(), "kubectl label node rsb2 role=compute")
+			} else {
+				require.NoError(t, err)
+				assert.Len(t, logger.Messages(logging.LevelWarn), 1)
+				assert.Contains(t, logger.Messages(logging.LevelWarn)[0], "Blocked unexpected mutation")
+			}
+		})
+	}
+}
+
+// TestNativeExecutor_StrictDryRun tests that every mutating method only reads in a strict dry run
+// WHY: Strict mode turns any missing simulation into an error instead of a silent cluster change
+func TestNativeExecutor_StrictDryRun(t *testing.T) {
+	for _, backend := range []string{NodeExecBackendDebugPod, NodeExecBackendEphemeral, NodeExecBackendAgent} {
+		t.Run(backend, func(t *testing.T) {
+			ctx := context.Background()
+			executor, client := newTestNativeExecutor(newTestNode("rsb2", map[string]string{"openstack-role": "compute"}))
+			executor.options.StrictDryRun = true
+			executor.options.NodeExecBackend = backend
+			executor.SetDryRun(true)
+
+			// When: Every mutating operation runs
+			_, _, err := executor.LabelNode(ctx, "rsb2", "ceph-node=enabled", true)
+			require.NoError(t, err)
+			_, _, err = executor.UnlabelNode(ctx, "rsb2", "openstack-role")
+			require.NoError(t, err)
+			_, _, err = executor.DeletePod(ctx, "node-debugger-rsb2-abcde")
+			require.NoError(t, err)
+			_, _, err = executor.ExecNodeCommand(ctx, "rsb2", "ip link show")
+			require.NoError(t, err)
+
+			// Then: The simulations answered and the API server only saw reads
+			for _, action := range client.Actions() {
+				assert.Contains(t, []string{"get", "list"}, action.GetVerb(), "dry-run should only read, got %s %s", action.GetVerb(), action.GetResource().Resource)
+			}
+		})
+	}
+}
+
+// TestNativeExecutor_DryRunGuard tests the guard behind the native transport calls
+// WHY: A mutation reaching the API server in a strict dry run must fail loudly
+func TestNativeExecutor_DryRunGuard(t *testing.T) {
+	ctx := context.Background()
+	executor, client := newTestNativeExecutor(newTestNode("rsb2", nil))
+	executor.SetDryRun(true)
+
+	// Given: Lenient dry run, the patch is skipped with a warning
+	err := executor.patchNodeLabels(ctx, "rsb2", map[string]interface{}{"openstack-role": "compute"})
+	require.NoError(t, err)
+
+	// When: Strict dry run reaches the same call
+	executor.options.StrictDryRun = true
+	err = executor.patchNodeLabels(ctx, "rsb2", map[string]interface{}{"openstack-role": "compute"})
+
+	// Then: Fails with the dry-run mutation error and nothing was written
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrDryRunMutation))
+	_, _, err = executor.execInNodePod(ctx, client, "default", "rsb2", "ip link show")
+	assert.True(t, errors.Is(err, ErrDryRunMutation))
+	assert.Empty(t, client.Actions())
+}
@@ -0,0 +1,151 @@
+package kubectl
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Node command execution backends
+const (
+	// NodeExecBackendDebugPod runs node commands in a new `kubectl debug node/<name>` pod
+	NodeExecBackendDebugPod = "debug-pod"
+
+	// NodeExecBackendEphemeral attaches an ephemeral container to an existing host-network pod on the node
+	// Only needs the pods/ephemeralcontainers permission in one namespace instead of pod creation
+	NodeExecBackendEphemeral = "ephemeral"
+
+	// NodeExecBackendAgent execs into the node's pod of the kictl node agent DaemonSet, deployed on first use
+	// Avoids starting and cleaning up a pod per command
+	NodeExecBackendAgent = "agent"
+)
+
+// Defaults for the ephemeral backend host pod lookup and the agent backend DaemonSet
+const (
+	defaultHostPodNamespace = "kube-system"
+	defaultHostPodSelector  = "k8s-app=kube-proxy"
+	defaultAgentNamespace   = "kube-system"
+)
+
+// defaultDebugImage is the image of the pods and containers running node commands
+const defaultDebugImage = "busybox"
+
+// ExecutorOptions configures how a RealExecutor runs commands on nodes
+type ExecutorOptions struct {
+	NodeExecBackend  string        // debug-pod (default), ephemeral or agent
+	HostPodNamespace string        // Namespace of the per-node pod used by the ephemeral backend
+	HostPodSelector  string        // Label selector of the per-node pod used by the ephemeral backend
+	AgentNamespace   string        // Namespace of the node agent DaemonSet used by the agent backend
+	HostEntry        string        // chroot, nsenter or none; defaults to chroot for debug-pod and agent, none for ephemeral
+	Restricted       bool          // Refuse node commands that are not in AllowedCommands
+	AllowedCommands  []string      // Restricted mode allowlist; defaults to DefaultAllowedCommands
+	StrictDryRun     bool          // Fail instead of warn when a dry run reaches a cluster mutation
+	DebugImage       string        // Image of the pods and containers running node commands; defaults to busybox
+	LegacyDebug      bool          // Create debug node pods from a manifest, for kubectl without debug --profile=sysadmin
+	Target           ClusterTarget // Kubeconfig, context and namespace of every request
+}
+
+// withDefaults fills unset options with their defaults
+func (o ExecutorOptions) withDefaults() ExecutorOptions {
+	if o.NodeExecBackend == "" {
+		o.NodeExecBackend = NodeExecBackendDebugPod
+	}
+	if o.HostPodNamespace == "" {
+		o.HostPodNamespace = defaultHostPodNamespace
+	}
+	if o.HostPodSelector == "" {
+		o.HostPodSelector = defaultHostPodSelector
+	}
+	if o.AgentNamespace == "" {
+		o.AgentNamespace = defaultAgentNamespace
+	}
+	if o.HostEntry == "" {
+		// Debug node pods and agent pods mount the host root at /host; host pods only share the network namespace
+		o.HostEntry = HostEntryChroot
+		if o.NodeExecBackend == NodeExecBackendEphemeral {
+			o.HostEntry = HostEntryNone
+		}
+	}
+	if o.DebugImage == "" {
+		o.DebugImage = defaultDebugImage
+	}
+	if o.Restricted && len(o.AllowedCommands) == 0 {
+		o.AllowedCommands = DefaultAllowedCommands
+	}
+	return o
+}
+
+// ValidateNodeExecBackend checks that a node command backend name is supported
+func ValidateNodeExecBackend(backend string) error {
+	switch backend {
+	case "", NodeExecBackendDebugPod, NodeExecBackendEphemeral, NodeExecBackendAgent:
+		return nil
+	default:
+		return fmt.Errorf("unsupported node exec backend '%s'. Expected: %s, %s or %s", backend, NodeExecBackendDebugPod, NodeExecBackendEphemeral, NodeExecBackendAgent)
+	}
+}
+
+// execViaEphemeralContainer runs a command in an ephemeral container attached to the node's host pod
+// The host pod must use the host network namespace so network commands affect the node
+func (e *RealExecutor) execViaEphemeralContainer(ctx context.Context, nodeName, command string) (bool, string, error) {
+	if e.dryRun {
+		e.logger.Debug(fmt.Sprintf("DRY RUN: Would run on node %s via ephemeral container in %s pod (%s): %s",
+			nodeName, e.options.HostPodNamespace, e.options.HostPodSelector, command))
+		return true, fmt.Sprintf("Command would be executed on node %s: %s", nodeName, command), nil
+	}
+
+	podName, err := e.findHostPod(ctx, nodeName)
+	if err != nil {
+		return false, "", newHostCommandError(nodeName, "", err)
+	}
+
+	// Ephemeral containers cannot be removed, so use a recognisable unique name
+	containerName := fmt.Sprintf("kictl-%d", time.Now().UnixNano())
+	args := []string{
+		"debug", "-n", e.options.HostPodNamespace, "pod/" + podName,
+		"--container=" + containerName,
+		"--profile=sysadmin",
+		"--image=" + e.options.DebugImage,
+		"--attach", "--quiet",
+		"--",
+	}
+	args = append(args, WrapHostCommand(e.options.HostEntry, command)...)
+
+	_, output, err := e.runCommand(ctx, args)
+	if err != nil {
+		return false, output, newHostCommandError(nodeName, output,
+			fmt.Errorf("ephemeral container %s in pod %s/%s failed: %w", containerName, e.options.HostPodNamespace, podName, err))
+	}
+
+	return nodeCommandSucceeded(command, output), output, nil
+}
+
+// findHostPod returns the name of the running host pod scheduled on a node
+func (e *RealExecutor) findHostPod(ctx context.Context, nodeName string) (string, error) {
+	return e.findNodePod(ctx, e.options.HostPodNamespace, e.options.HostPodSelector, nodeName, "the ephemeral exec backend")
+}
+
+// findNodePod returns the name of a running pod matching a selector on a node, for the named use in errors
+func (e *RealExecutor) findNodePod(ctx context.Context, namespace, selector, nodeName, use string) (string, error) {
+	args := []string{
+		"get", "pods", "-n", namespace,
+		"-l", selector,
+		"--field-selector", "spec.nodeName=" + nodeName + ",status.phase=Running",
+		"-o", "name",
+	}
+
+	_, output, err := e.runCommand(ctx, args)
+	if err != nil {
+		return "", fmt.Errorf("failed to find host pod on node %s: %w", nodeName, err)
+	}
+
+	for _, line := range strings.Split(output, "\n") {
+		if name := strings.TrimPrefix(strings.TrimSpace(line), "pod/"); name != "" {
+			return name, nil
+		}
+	}
+
+	return "", fmt.Errorf("no running pod matching %s in namespace %s on node %s for %s",
+		selector, namespace, nodeName, use)
+}
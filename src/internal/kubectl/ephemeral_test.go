@@ -0,0 +1,83 @@
+// Package kubectl provides unit tests for the ephemeral container exec backend
+// WHY: Restricted clusters depend on this path, and it must never fall back to creating pods
+package kubectl
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"k8ostack-ictl/internal/logging"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestValidateNodeExecBackend tests backend name validation
+// WHY: A typo in --node-exec-backend must fail fast instead of silently using debug pods
+func TestValidateNodeExecBackend(t *testing.T) {
+	assert.NoError(t, ValidateNodeExecBackend(""))
+	assert.NoError(t, ValidateNodeExecBackend(NodeExecBackendDebugPod))
+	assert.NoError(t, ValidateNodeExecBackend(NodeExecBackendEphemeral))
+	assert.NoError(t, ValidateNodeExecBackend(NodeExecBackendAgent))
+
+	err := ValidateNodeExecBackend("ssh-please")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported node exec backend 'ssh-please'")
+}
+
+// TestExecutorOptions_Defaults tests default host pod lookup settings
+// WHY: Unset options must keep the historical debug-pod behavior
+func TestExecutorOptions_Defaults(t *testing.T) {
+	options := ExecutorOptions{}.withDefaults()
+	assert.Equal(t, NodeExecBackendDebugPod, options.NodeExecBackend)
+	assert.Equal(t, "kube-system", options.HostPodNamespace)
+	assert.Equal(t, "k8s-app=kube-proxy", options.HostPodSelector)
+	assert.Equal(t, "busybox", options.DebugImage)
+	assert.Equal(t, "kube-system", options.AgentNamespace)
+
+	custom := ExecutorOptions{NodeExecBackend: NodeExecBackendEphemeral, HostPodSelector: "app=node-agent"}.withDefaults()
+	assert.Equal(t, "app=node-agent", custom.HostPodSelector)
+	assert.Equal(t, "kube-system", custom.HostPodNamespace)
+}
+
+// TestExecNodeCommand_EphemeralBackend tests command routing through the ephemeral backend
+// WHY: The backend must look up a host pod rather than creating a node debug pod
+func TestExecNodeCommand_EphemeralBackend(t *testing.T) {
+	t.Run("dry_run", func(t *testing.T) {
+		// Given: Ephemeral executor in dry-run mode
+		logger := logging.NewRecordingLogger()
+		executor := NewExecutorWithOptions(logger, ExecutorOptions{NodeExecBackend: NodeExecBackendEphemeral})
+		executor.SetDryRun(true)
+
+		// When: Execute command
+		success, output, err := executor.ExecNodeCommand(context.Background(), "rsb2", "ip link show")
+
+		// Then: Simulated without cluster calls
+		assert.NoError(t, err)
+		assert.True(t, success)
+		assert.Contains(t, output, "Command would be executed on node rsb2")
+		debugMessage := strings.Join(logger.Messages(logging.LevelDebug), " ")
+		assert.Contains(t, debugMessage, "ephemeral container")
+		assert.NotContains(t, debugMessage, "kubectl debug node")
+	})
+
+	t.Run("host_pod_lookup", func(t *testing.T) {
+		// Given: Ephemeral executor without a reachable cluster
+		logger := logging.NewRecordingLogger()
+		executor := NewExecutorWithOptions(logger, ExecutorOptions{
+			NodeExecBackend:  NodeExecBackendEphemeral,
+			HostPodNamespace: "infra",
+			HostPodSelector:  "app=node-agent",
+		})
+
+		// When: Execute command
+		success, _, err := executor.ExecNodeCommand(context.Background(), "rsb2", "ip link show")
+
+		// Then: Lookup of the host pod is attempted and its failure reported
+		assert.False(t, success)
+		assert.Error(t, err)
+		debugMessage := strings.Join(logger.Messages(logging.LevelDebug), " ")
+		assert.Contains(t, debugMessage, "get pods -n infra -l app=node-agent --field-selector spec.nodeName=rsb2")
+		assert.NotContains(t, debugMessage, "kubectl debug node")
+	})
+}
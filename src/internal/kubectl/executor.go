@@ -3,25 +3,35 @@ package kubectl
 import (
 	"context"
 	"fmt"
-	"os/exec"
 	"regexp"
 	"strings"
 	"time"
+
+	"k8ostack-ictl/internal/logging"
 )
 
 // RealExecutor implements the Executor interface using actual kubectl commands
 type RealExecutor struct {
-	logger         Logger
+	logger         logging.Logger
 	dryRun         bool
 	pollingInterval time.Duration
+	options        ExecutorOptions
+	agent          agentDeployment
+	debugPods      debugPodPool
 }
 
 // NewExecutor creates a new kubectl executor
-func NewExecutor(logger Logger) DryRunExecutor {
+func NewExecutor(logger logging.Logger) DryRunExecutor {
+	return NewExecutorWithOptions(logger, ExecutorOptions{})
+}
+
+// NewExecutorWithOptions creates a new kubectl executor with a configurable node command backend
+func NewExecutorWithOptions(logger logging.Logger, options ExecutorOptions) DryRunExecutor {
 	return &RealExecutor{
 		logger:         logger,
 		dryRun:         false,
 		pollingInterval: 1 * time.Second, // Default polling interval
+		options:        options.withDefaults(),
 	}
 }
 
@@ -60,6 +70,22 @@ func (e *RealExecutor) LabelNode(ctx context.Context, nodeName, label string, ov
 	return e.runCommand(ctx, args)
 }
 
+// LabelNodes applies the same labels to several nodes with a single kubectl label call
+func (e *RealExecutor) LabelNodes(ctx context.Context, nodeNames []string, labels map[string]string, overwrite bool) (bool, string, error) {
+	args := append([]string{"label", "node"}, nodeNames...)
+	args = append(args, FormatLabels(labels)...)
+	if overwrite {
+		args = append(args, "--overwrite")
+	}
+
+	if e.dryRun {
+		e.logger.Debug(fmt.Sprintf("DRY RUN: Would run: kubectl %s", strings.Join(args, " ")))
+		return true, labeledOutput(nodeNames), nil
+	}
+
+	return e.runCommand(ctx, args)
+}
+
 // UnlabelNode removes a label from a node
 func (e *RealExecutor) UnlabelNode(ctx context.Context, nodeName, labelKey string) (bool, string, error) {
 	args := []string{"label", "node", nodeName, labelKey + "-"}
@@ -77,49 +103,66 @@ func (e *RealExecutor) GetNodeLabels(ctx context.Context, nodeName string) (bool
 	return e.runCommand(ctx, []string{"get", "node", nodeName, "--show-labels"})
 }
 
+// GetNodeAnnotations retrieves all annotations for a specific node as JSON
+func (e *RealExecutor) GetNodeAnnotations(ctx context.Context, nodeName string) (bool, string, error) {
+	return e.runCommand(ctx, []string{"get", "node", nodeName, "-o", "jsonpath={.metadata.annotations}"})
+}
+
+// GetNodeInternalIP retrieves the InternalIP address the node reports
+func (e *RealExecutor) GetNodeInternalIP(ctx context.Context, nodeName string) (bool, string, error) {
+	success, output, err := e.runCommand(ctx, []string{"get", "node", nodeName, "-o", `jsonpath={.status.addresses[?(@.type=="InternalIP")].address}`})
+	if err != nil || !success {
+		return success, output, err
+	}
+	if output == "" {
+		return false, "", fmt.Errorf("node %s reports no InternalIP address", nodeName)
+	}
+	// Dual-stack nodes report one InternalIP per family
+	return true, strings.Fields(output)[0], nil
+}
+
 // ExecNodeCommand executes a command on a specific node using kubectl debug
 func (e *RealExecutor) ExecNodeCommand(ctx context.Context, nodeName, command string) (bool, string, error) {
+	if err := checkCommandPolicy(e.options, e.logger, nodeName, command); err != nil {
+		return false, "", err
+	}
+
+	if e.options.NodeExecBackend == NodeExecBackendEphemeral {
+		return e.execViaEphemeralContainer(ctx, nodeName, command)
+	}
+	if e.options.NodeExecBackend == NodeExecBackendAgent {
+		return e.execViaAgent(ctx, nodeName, command)
+	}
+
 	// Use kubectl debug to execute commands on the node
 	args := []string{
 		"debug", "node/" + nodeName,
 		"--profile=sysadmin",
-		"--image=busybox",
-		"--", "chroot", "/host", "sh", "-c", command,
+		"--image=" + e.options.DebugImage,
+		"--",
 	}
+	args = append(args, WrapHostCommand(e.options.HostEntry, command)...)
 
 	if e.dryRun {
-		e.logger.Debug(fmt.Sprintf("DRY RUN: Would run: kubectl %s", strings.Join(args, " ")))
+		if e.options.LegacyDebug {
+			e.logger.Debug(fmt.Sprintf("DRY RUN: Would run on node %s in a debug pod created from a manifest: %s", nodeName, command))
+		} else {
+			e.logger.Debug(fmt.Sprintf("DRY RUN: Would run: kubectl %s", strings.Join(args, " ")))
+		}
 		return true, fmt.Sprintf("Command would be executed on node %s: %s", nodeName, command), nil
 	}
 
-	// Execute kubectl debug command
-	_, output, err := e.runCommand(ctx, args)
-	if err != nil {
-		return false, output, err
-	}
-
-	// kubectl debug is asynchronous and only returns pod creation message
-	// We need to extract the pod name and get its logs
-	podName := e.extractPodNameFromDebugOutput(output)
-	if podName == "" {
-		return false, output, fmt.Errorf("failed to extract pod name from debug output: %s", output)
-	}
-
-	// Wait for pod to complete and get logs
-	logOutput, err := e.waitForPodLogsWithTimeout(ctx, podName, 60*time.Second)
-	if err != nil {
-		return false, logOutput, err
-	}
+	// Commands exec into a debug pod kept per node until the run releases it
+	return e.execViaDebugPod(ctx, nodeName, command)
+}
 
-	// Determine success based on ping results
-	// For ping commands, success is determined by whether packets were received
+// nodeCommandSucceeded determines success from node command output
+// For ping commands, success is determined by whether packets were received
+func nodeCommandSucceeded(command, output string) bool {
 	if strings.Contains(command, "ping") {
-		// Check if ping was successful (packets received)
-		pingSuccess := !strings.Contains(logOutput, "0 received, 100% packet loss")
-		return pingSuccess, logOutput, nil
+		return !strings.Contains(output, "0 received, 100% packet loss")
 	}
-
-	return true, logOutput, nil
+	return true
 }
 
 // GetPods retrieves pods with optional filtering
@@ -169,163 +212,49 @@ func (e *RealExecutor) GetNodesByLabel(ctx context.Context, labelSelector string
 
 // GetNodeRole retrieves node role based on labels and analysis
 func (e *RealExecutor) GetNodeRole(ctx context.Context, nodeName string) (string, error) {
-	// Get node labels first
-	success, output, err := e.GetNodeLabels(ctx, nodeName)
-	if err != nil || !success {
-		return "", fmt.Errorf("failed to get node labels for %s: %w", nodeName, err)
-	}
-	
-	// Analyze labels to determine role
-	role := e.analyzeNodeRole(output)
-	return role, nil
-}
-
-// analyzeNodeRole determines node role from label output
-func (e *RealExecutor) analyzeNodeRole(labelOutput string) string {
-	// Check for standard Kubernetes node roles
-	if strings.Contains(labelOutput, "node-role.kubernetes.io/control-plane") {
-		return "control-plane"
-	}
-	if strings.Contains(labelOutput, "node-role.kubernetes.io/master") {
-		return "control-plane"
-	}
-	
-	// Check for OpenStack-specific roles
-	if strings.Contains(labelOutput, "openstack-role=storage") {
-		return "storage"
-	}
-	if strings.Contains(labelOutput, "openstack-role=compute") {
-		return "compute"
-	}
-	if strings.Contains(labelOutput, "openstack-role=control-plane") {
-		return "control-plane"
-	}
-	
-	// Default to worker if no specific role found
-	return "worker"
+	return getNodeRole(ctx, e, nodeName)
 }
 
 // DiscoverClusterState returns comprehensive cluster overview
 func (e *RealExecutor) DiscoverClusterState(ctx context.Context) (map[string]interface{}, error) {
-	state := make(map[string]interface{})
-	
-	// Get all nodes
-	success, nodesOutput, err := e.GetAllNodes(ctx)
-	if err != nil || !success {
-		return nil, fmt.Errorf("failed to get cluster nodes: %w", err)
-	}
-	
-	// Parse node list
-	nodeNames := strings.Split(strings.TrimSpace(nodesOutput), "\n")
-	nodeCount := len(nodeNames)
-	if nodeNames[0] == "" {
-		nodeCount = 0
-	}
-	
-	// Count roles
-	roleCounts := make(map[string]int)
-	for _, nodeName := range nodeNames {
-		if nodeName == "" {
-			continue
-		}
-		// Strip "node/" prefix if present
-		cleanNodeName := strings.TrimPrefix(nodeName, "node/")
-		role, _ := e.GetNodeRole(ctx, cleanNodeName)
-		roleCounts[role]++
-	}
-	
-	state["total_nodes"] = nodeCount
-	state["node_roles"] = roleCounts
-	state["nodes"] = nodeNames
-	
-	return state, nil
+	return discoverClusterState(ctx, e)
 }
 
 // DiscoverNodeVLANs detects VLAN configuration on a specific node
 func (e *RealExecutor) DiscoverNodeVLANs(ctx context.Context, nodeName string) (bool, string, error) {
-	// Use ExecNodeCommand to run VLAN discovery on the node
-	command := "ip link show type vlan"
-	
-	if e.dryRun {
-		e.logger.Debug(fmt.Sprintf("DRY RUN: Would discover VLANs on node %s: %s", nodeName, command))
-		return true, fmt.Sprintf("DRY RUN: VLAN discovery on node %s", nodeName), nil
-	}
-	
-	return e.ExecNodeCommand(ctx, nodeName, command)
+	return runDiscoveryCommand(ctx, e, e.logger, nodeName, vlanDiscovery)
 }
 
 // DiscoverAllVLANs maps VLAN configurations across all nodes
 func (e *RealExecutor) DiscoverAllVLANs(ctx context.Context) (map[string]string, error) {
-	vlanMap := make(map[string]string)
-	
-	// Get all nodes first
-	success, nodesOutput, err := e.GetAllNodes(ctx)
-	if err != nil || !success {
-		return nil, fmt.Errorf("failed to get cluster nodes: %w", err)
-	}
-	
-	// Parse node list
-	nodeNames := strings.Split(strings.TrimSpace(nodesOutput), "\n")
-	
-	// Discover VLANs on each node
-	for _, nodeName := range nodeNames {
-		if nodeName == "" {
-			continue
-		}
-		
-		// Strip "node/" prefix if present
-		cleanNodeName := strings.TrimPrefix(nodeName, "node/")
-		
-		success, vlanOutput, err := e.DiscoverNodeVLANs(ctx, cleanNodeName)
-		if err != nil {
-			e.logger.Warn(fmt.Sprintf("Failed to discover VLANs on node %s: %v", cleanNodeName, err))
-			vlanMap[cleanNodeName] = "ERROR"
-			continue
-		}
-		
-		if success {
-			vlanMap[cleanNodeName] = vlanOutput
-		} else {
-			vlanMap[cleanNodeName] = "NO_VLANS"
-		}
-	}
-	
-	return vlanMap, nil
+	return discoverAllVLANs(ctx, e, e.logger)
 }
 
 // GetNodeNetworkInfo retrieves network interface information from a node
 func (e *RealExecutor) GetNodeNetworkInfo(ctx context.Context, nodeName string) (bool, string, error) {
-	// Get comprehensive network information
-	command := "ip addr show && echo '---ROUTES---' && ip route show"
-	
-	if e.dryRun {
-		e.logger.Debug(fmt.Sprintf("DRY RUN: Would get network info on node %s: %s", nodeName, command))
-		return true, fmt.Sprintf("DRY RUN: Network info for node %s", nodeName), nil
-	}
-	
-	return e.ExecNodeCommand(ctx, nodeName, command)
+	return runDiscoveryCommand(ctx, e, e.logger, nodeName, networkInfoDiscovery)
 }
 
 // GetNodeHardwareInfo gets basic hardware specifications for node categorization
 func (e *RealExecutor) GetNodeHardwareInfo(ctx context.Context, nodeName string) (bool, string, error) {
-	// Get CPU, memory, and storage information
-	command := "echo 'CPU:' && lscpu | grep -E '^CPU\\(s\\)|^Model name' && echo 'MEMORY:' && free -h && echo 'STORAGE:' && lsblk"
-	
-	if e.dryRun {
-		e.logger.Debug(fmt.Sprintf("DRY RUN: Would get hardware info on node %s: %s", nodeName, command))
-		return true, fmt.Sprintf("DRY RUN: Hardware info for node %s", nodeName), nil
-	}
-	
-	return e.ExecNodeCommand(ctx, nodeName, command)
+	return runDiscoveryCommand(ctx, e, e.logger, nodeName, hardwareInfoDiscovery)
+}
+
+// RunKubectl runs a kubectl command for callers outside of the executor, like the cluster lock and ownership annotations
+func (e *RealExecutor) RunKubectl(ctx context.Context, stdin []byte, args ...string) (string, error) {
+	return runKubectl(ctx, e.logger, e.dryRun, e.options, stdin, args)
 }
 
 // runCommand executes a kubectl command
 func (e *RealExecutor) runCommand(ctx context.Context, args []string) (bool, string, error) {
-	e.logger.Debug(fmt.Sprintf("Running: kubectl %s", strings.Join(args, " ")))
+	if IsMutatingCommand(args) {
+		if blocked, err := checkDryRunMutation(e.dryRun, e.options, e.logger, "kubectl "+strings.Join(args, " ")); blocked {
+			return err == nil, "", err
+		}
+	}
 
-	cmd := exec.CommandContext(ctx, "kubectl", args...)
-	output, err := cmd.CombinedOutput()
-	outputStr := strings.TrimSpace(string(output))
+	output, err := execKubectl(ctx, e.logger, e.options.Target, nil, args)
+	outputStr := strings.TrimSpace(output)
 
 	if err != nil {
 		e.logger.Error(fmt.Sprintf("Command failed: %s", outputStr))
@@ -348,52 +277,3 @@ func (e *RealExecutor) extractPodNameFromDebugOutput(output string) string {
 	}
 	return ""
 }
-
-// waitForPodLogsWithTimeout waits for a pod to complete and returns its logs
-func (e *RealExecutor) waitForPodLogsWithTimeout(ctx context.Context, podName string, timeout time.Duration) (string, error) {
-	ctx, cancel := context.WithTimeout(ctx, timeout)
-	defer cancel()
-
-	// Wait for pod to reach a terminal state (Succeeded or Failed)
-	for {
-		select {
-		case <-ctx.Done():
-			return "", fmt.Errorf("timeout waiting for pod %s to complete", podName)
-		default:
-			// Check pod status
-			args := []string{"get", "pod", podName, "-o", "jsonpath={.status.phase}"}
-			success, phase, err := e.runCommand(ctx, args)
-			if err != nil {
-				// Pod might not exist yet, wait a bit
-				time.Sleep(e.pollingInterval)
-				continue
-			}
-
-			if success && (phase == "Succeeded" || phase == "Failed") {
-				// Pod completed, get logs
-				logArgs := []string{"logs", podName}
-				logSuccess, logs, logErr := e.runCommand(ctx, logArgs)
-				if logErr != nil {
-					return "", fmt.Errorf("failed to get logs from pod %s: %w", podName, logErr)
-				}
-
-				if !logSuccess {
-					return "", fmt.Errorf("failed to retrieve logs from pod %s", podName)
-				}
-
-				if phase == "Failed" {
-					// Check if it's an expected failure (e.g., no ping response for isolation tests)
-					if strings.Contains(logs, "0 received, 100% packet loss") {
-						return logs, nil // Considered a success for isolation
-					}
-					return logs, fmt.Errorf("unexpected pod %s failure: %s", podName, logs)
-				}
-
-				return logs, nil
-			}
-
-			// Wait before checking again
-			time.Sleep(e.pollingInterval)
-		}
-	}
-}
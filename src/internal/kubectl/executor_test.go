@@ -8,10 +8,11 @@ import (
 	"testing"
 	"time"
 
+	"k8ostack-ictl/internal/logging"
+
 	"github.com/stretchr/testify/assert"
 )
 
-// mockLogger implements the Logger interface for testing
 // TestExecNodeCommand tests command execution on node
 // WHY: Executing commands on nodes is crucial for node management and requires validation of implementation
 func TestExecNodeCommand(t *testing.T) {
@@ -43,7 +44,7 @@ func TestExecNodeCommand(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			logger := newMockLogger()
+			logger := logging.NewRecordingLogger()
 			executor := NewExecutor(logger)
 			executor.SetDryRun(tt.dryRun)
 			ctx := context.Background()
@@ -57,14 +58,14 @@ func TestExecNodeCommand(t *testing.T) {
 				// In production mode without cluster, we expect command to be attempted
 				// but may fail - that's OK for unit testing the interface
 				// The important thing is that the correct command was generated
-				debugMessage := strings.Join(logger.debugMessages, " ")
+				debugMessage := strings.Join(logger.Messages(logging.LevelDebug), " ")
 				assert.Contains(t, debugMessage, "kubectl debug node", "Should generate kubectl command")
 				assert.Contains(t, debugMessage, tt.nodeName, "Should include node name")
 				assert.Contains(t, debugMessage, tt.command, "Should include command")
 				// Don't assert on output - command may fail without cluster
 			}
 
-			debugMessage := strings.Join(logger.debugMessages, " ")
+			debugMessage := strings.Join(logger.Messages(logging.LevelDebug), " ")
 			assert.Contains(t, debugMessage, "kubectl debug node", "Should log kubectl command")
 			assert.Contains(t, debugMessage, tt.nodeName, "Should log node name")
 			assert.Contains(t, debugMessage, tt.command, "Should log command")
@@ -103,7 +104,7 @@ func TestGetPods(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			logger := newMockLogger()
+			logger := logging.NewRecordingLogger()
 			executor := NewExecutor(logger)
 			ctx := context.Background()
 
@@ -114,7 +115,7 @@ func TestGetPods(t *testing.T) {
 			// We verify the interface works regardless of success/failure
 			_ = output // Test interface behavior, not kubectl availability
 
-			debugMessage := strings.Join(logger.debugMessages, " ")
+			debugMessage := strings.Join(logger.Messages(logging.LevelDebug), " ")
 			assert.Contains(t, debugMessage, "kubectl get pods", "Should log kubectl command")
 			if tt.fieldSelector != "" {
 				assert.Contains(t, debugMessage, tt.fieldSelector, "Should include field selector")
@@ -154,7 +155,7 @@ func TestDeletePod(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			logger := newMockLogger()
+			logger := logging.NewRecordingLogger()
 			executor := NewExecutor(logger)
 			executor.SetDryRun(tt.dryRun)
 			ctx := context.Background()
@@ -167,74 +168,32 @@ func TestDeletePod(t *testing.T) {
 			} else {
 				// In production mode without cluster, command may fail - that's OK for unit testing
 				// The important thing is that the correct command was generated
-				debugMessage := strings.Join(logger.debugMessages, " ")
+				debugMessage := strings.Join(logger.Messages(logging.LevelDebug), " ")
 				assert.Contains(t, debugMessage, "kubectl delete pod", "Should generate kubectl command")
 				assert.Contains(t, debugMessage, tt.podName, "Should include pod name")
 				// Don't assert on output - command may fail without cluster
 			}
 
-			debugMessage := strings.Join(logger.debugMessages, " ")
+			debugMessage := strings.Join(logger.Messages(logging.LevelDebug), " ")
 			assert.Contains(t, debugMessage, "kubectl delete pod", "Should log kubectl command")
 			assert.Contains(t, debugMessage, tt.podName, "Should log pod name")
 		})
 	}
 }
 
-// mockLogger implements the Logger interface for testing
-type mockLogger struct {
-	debugMessages []string
-	infoMessages  []string
-	warnMessages  []string
-	errorMessages []string
-}
-
-func newMockLogger() *mockLogger {
-	return &mockLogger{
-		debugMessages: make([]string, 0),
-		infoMessages:  make([]string, 0),
-		warnMessages:  make([]string, 0),
-		errorMessages: make([]string, 0),
-	}
-}
-
-func (m *mockLogger) Debug(message string) {
-	m.debugMessages = append(m.debugMessages, message)
-}
-
-func (m *mockLogger) Info(message string) {
-	m.infoMessages = append(m.infoMessages, message)
-}
-
-func (m *mockLogger) Warn(message string) {
-	m.warnMessages = append(m.warnMessages, message)
-}
-
-func (m *mockLogger) Error(message string) {
-	m.errorMessages = append(m.errorMessages, message)
-}
-
-func (m *mockLogger) getAllMessages() []string {
-	var all []string
-	all = append(all, m.debugMessages...)
-	all = append(all, m.infoMessages...)
-	all = append(all, m.warnMessages...)
-	all = append(all, m.errorMessages...)
-	return all
-}
-
 // TestNewExecutor tests kubectl executor creation
 // WHY: Validates proper initialization of the critical cluster interface
 func TestNewExecutor(t *testing.T) {
 	tests := []struct {
 		name        string
 		description string
-		logger      Logger
+		logger      logging.Logger
 		expectValid bool
 	}{
 		{
 			name:        "valid_executor_creation",
 			description: "Valid logger should create functional kubectl executor",
-			logger:      newMockLogger(),
+			logger:      logging.NewRecordingLogger(),
 			expectValid: true,
 		},
 		{
@@ -284,7 +243,7 @@ func TestDryRunExecutor_StateMgmt(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			// Given: New executor
-			logger := newMockLogger()
+			logger := logging.NewRecordingLogger()
 			executor := NewExecutor(logger)
 
 			// When: Set dry-run state
@@ -336,7 +295,7 @@ func TestGetNode(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			// Given: Mock executor
-			logger := newMockLogger()
+			logger := logging.NewRecordingLogger()
 			executor := NewExecutor(logger)
 			ctx := context.Background()
 
@@ -352,8 +311,8 @@ func TestGetNode(t *testing.T) {
 
 				// Verify logging occurred (if logger is not nil)
 				if logger != nil {
-					assert.NotEmpty(t, logger.debugMessages, "Should log debug message")
-					debugMessage := strings.Join(logger.debugMessages, " ")
+					assert.NotEmpty(t, logger.Messages(logging.LevelDebug), "Should log debug message")
+					debugMessage := strings.Join(logger.Messages(logging.LevelDebug), " ")
 					assert.Contains(t, debugMessage, "kubectl get node", "Should log kubectl command")
 					assert.Contains(t, debugMessage, tt.nodeName, "Should log node name")
 				}
@@ -362,7 +321,7 @@ func TestGetNode(t *testing.T) {
 				assert.NotEmpty(t, output, "Should have output (success or error)")
 
 				// Verify command logging
-				debugMessage := strings.Join(logger.debugMessages, " ")
+				debugMessage := strings.Join(logger.Messages(logging.LevelDebug), " ")
 				assert.Contains(t, debugMessage, "kubectl label node", "Should log kubectl command")
 			}
 		})
@@ -422,7 +381,7 @@ func TestLabelNode(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			// Given: Mock executor with dry-run configuration
-			logger := newMockLogger()
+			logger := logging.NewRecordingLogger()
 			executor := NewExecutor(logger)
 			executor.SetDryRun(tt.dryRun)
 			ctx := context.Background()
@@ -438,7 +397,7 @@ func TestLabelNode(t *testing.T) {
 				assert.NoError(t, err, "Dry-run should not return error")
 
 				// Verify dry-run logging
-				debugMessage := strings.Join(logger.debugMessages, " ")
+				debugMessage := strings.Join(logger.Messages(logging.LevelDebug), " ")
 				assert.Contains(t, debugMessage, "DRY RUN", "Should log dry-run message")
 				assert.Contains(t, debugMessage, "kubectl label node", "Should log kubectl command")
 				assert.Contains(t, debugMessage, tt.nodeName, "Should log node name")
@@ -452,7 +411,7 @@ func TestLabelNode(t *testing.T) {
 				assert.NotEmpty(t, output, "Should have output (success or error)")
 
 				// Verify command logging
-				debugMessage := strings.Join(logger.debugMessages, " ")
+				debugMessage := strings.Join(logger.Messages(logging.LevelDebug), " ")
 				assert.Contains(t, debugMessage, "kubectl label node", "Should log kubectl command")
 			}
 		})
@@ -499,7 +458,7 @@ func TestUnlabelNode(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			// Given: Mock executor with dry-run configuration
-			logger := newMockLogger()
+			logger := logging.NewRecordingLogger()
 			executor := NewExecutor(logger)
 			executor.SetDryRun(tt.dryRun)
 			ctx := context.Background()
@@ -515,7 +474,7 @@ func TestUnlabelNode(t *testing.T) {
 				assert.NoError(t, err, "Dry-run should not return error")
 
 				// Verify dry-run logging
-				debugMessage := strings.Join(logger.debugMessages, " ")
+				debugMessage := strings.Join(logger.Messages(logging.LevelDebug), " ")
 				assert.Contains(t, debugMessage, "DRY RUN", "Should log dry-run message")
 				assert.Contains(t, debugMessage, "kubectl label node", "Should log kubectl command")
 				assert.Contains(t, debugMessage, tt.nodeName, "Should log node name")
@@ -525,7 +484,7 @@ func TestUnlabelNode(t *testing.T) {
 				assert.NotEmpty(t, output, "Should have output (success or error)")
 
 				// Verify command logging
-				debugMessage := strings.Join(logger.debugMessages, " ")
+				debugMessage := strings.Join(logger.Messages(logging.LevelDebug), " ")
 				assert.Contains(t, debugMessage, "kubectl label node", "Should log kubectl command")
 			}
 		})
@@ -558,7 +517,7 @@ func TestGetNodeLabels(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			// Given: Mock executor
-			logger := newMockLogger()
+			logger := logging.NewRecordingLogger()
 			executor := NewExecutor(logger)
 			ctx := context.Background()
 
@@ -571,7 +530,7 @@ func TestGetNodeLabels(t *testing.T) {
 				assert.NotEmpty(t, output, "Should have output (success or error)")
 
 				// Verify logging
-				debugMessage := strings.Join(logger.debugMessages, " ")
+				debugMessage := strings.Join(logger.Messages(logging.LevelDebug), " ")
 				assert.Contains(t, debugMessage, "kubectl get node", "Should log kubectl command")
 				assert.Contains(t, debugMessage, tt.nodeName, "Should log node name")
 				assert.Contains(t, debugMessage, "--show-labels", "Should include show-labels flag")
@@ -585,7 +544,7 @@ func TestGetNodeLabels(t *testing.T) {
 func TestExecutor_ContextHandling(t *testing.T) {
 	t.Run("context_timeout_handling", func(t *testing.T) {
 		// Given: Executor with timeout context
-		logger := newMockLogger()
+		logger := logging.NewRecordingLogger()
 		executor := NewExecutor(logger)
 
 		// Create context with very short timeout
@@ -601,8 +560,8 @@ func TestExecutor_ContextHandling(t *testing.T) {
 
 		// Verify logging occurred (if logger is not nil)
 		if logger != nil {
-			assert.NotEmpty(t, logger.debugMessages, "Should log debug message")
-			debugMessage := strings.Join(logger.debugMessages, " ")
+			assert.NotEmpty(t, logger.Messages(logging.LevelDebug), "Should log debug message")
+			debugMessage := strings.Join(logger.Messages(logging.LevelDebug), " ")
 			assert.Contains(t, debugMessage, "kubectl get node", "Should log kubectl command")
 			assert.Contains(t, debugMessage, "rsb2", "Should log node name")
 		}
@@ -610,7 +569,7 @@ func TestExecutor_ContextHandling(t *testing.T) {
 
 	t.Run("context_cancellation", func(t *testing.T) {
 		// Given: Executor with cancellable context
-		logger := newMockLogger()
+		logger := logging.NewRecordingLogger()
 		executor := NewExecutor(logger)
 
 		ctx, cancel := context.WithCancel(context.Background())
@@ -625,8 +584,8 @@ func TestExecutor_ContextHandling(t *testing.T) {
 
 		// Verify logging occurred (if logger is not nil)
 		if logger != nil {
-			assert.NotEmpty(t, logger.debugMessages, "Should log debug message")
-			debugMessage := strings.Join(logger.debugMessages, " ")
+			assert.NotEmpty(t, logger.Messages(logging.LevelDebug), "Should log debug message")
+			debugMessage := strings.Join(logger.Messages(logging.LevelDebug), " ")
 			assert.Contains(t, debugMessage, "kubectl label node", "Should log kubectl command")
 			assert.Contains(t, debugMessage, "rsb2", "Should log node name")
 		}
@@ -638,7 +597,7 @@ func TestExecutor_ContextHandling(t *testing.T) {
 func TestExecutor_EdgeCases(t *testing.T) {
 	t.Run("empty_node_names", func(t *testing.T) {
 		// Given: Executor
-		logger := newMockLogger()
+		logger := logging.NewRecordingLogger()
 		executor := NewExecutor(logger)
 		ctx := context.Background()
 
@@ -653,7 +612,7 @@ func TestExecutor_EdgeCases(t *testing.T) {
 
 	t.Run("special_characters_in_labels", func(t *testing.T) {
 		// Given: Executor in dry-run mode
-		logger := newMockLogger()
+		logger := logging.NewRecordingLogger()
 		executor := NewExecutor(logger)
 		executor.SetDryRun(true)
 		ctx := context.Background()
@@ -668,13 +627,13 @@ func TestExecutor_EdgeCases(t *testing.T) {
 		assert.Contains(t, output, "labeled", "Should simulate success")
 
 		// Verify logging includes special characters
-		debugMessage := strings.Join(logger.debugMessages, " ")
+		debugMessage := strings.Join(logger.Messages(logging.LevelDebug), " ")
 		assert.Contains(t, debugMessage, specialLabel, "Should log full label with special characters")
 	})
 
 	t.Run("very_long_node_names", func(t *testing.T) {
 		// Given: Executor in dry-run mode
-		logger := newMockLogger()
+		logger := logging.NewRecordingLogger()
 		executor := NewExecutor(logger)
 		executor.SetDryRun(true)
 		ctx := context.Background()
@@ -689,13 +648,13 @@ func TestExecutor_EdgeCases(t *testing.T) {
 		assert.Contains(t, output, "labeled", "Dry-run should simulate success")
 
 		// Verify logging includes full name
-		debugMessage := strings.Join(logger.debugMessages, " ")
+		debugMessage := strings.Join(logger.Messages(logging.LevelDebug), " ")
 		assert.Contains(t, debugMessage, longNodeName, "Should log full long node name")
 	})
 
 	t.Run("concurrent_operations", func(t *testing.T) {
 		// Given: Multiple executors
-		logger := newMockLogger()
+		logger := logging.NewRecordingLogger()
 		executor1 := NewExecutor(logger)
 		executor2 := NewExecutor(logger)
 		executor1.SetDryRun(true)
@@ -721,7 +680,7 @@ func TestExecutor_EdgeCases(t *testing.T) {
 		<-done2
 
 		// Verify both operations were logged
-		allMessages := logger.getAllMessages()
+		allMessages := logger.Messages("")
 		messageText := strings.Join(allMessages, " ")
 		assert.Contains(t, messageText, "rsb2", "Should log first operation")
 		assert.Contains(t, messageText, "rsb3", "Should log second operation")
@@ -752,7 +711,7 @@ func TestExecutor_DryRunConsistency(t *testing.T) {
 	for _, op := range operations {
 		t.Run(op.name+"_dry_run_consistency", func(t *testing.T) {
 			// Given: Executor in dry-run mode
-			logger := newMockLogger()
+			logger := logging.NewRecordingLogger()
 			executor := NewExecutor(logger)
 			executor.SetDryRun(true)
 			ctx := context.Background()
@@ -775,7 +734,7 @@ func TestExecutor_DryRunConsistency(t *testing.T) {
 			}
 
 			// Verify all operations were logged
-			assert.Len(t, logger.debugMessages, 3, "Should log all three operations")
+			assert.Len(t, logger.Messages(logging.LevelDebug), 3, "Should log all three operations")
 		})
 	}
 }
@@ -785,7 +744,7 @@ func TestExecutor_DryRunConsistency(t *testing.T) {
 func TestExecutor_LoggingBehavior(t *testing.T) {
 	t.Run("debug_logging_in_dry_run", func(t *testing.T) {
 		// Given: Executor in dry-run mode
-		logger := newMockLogger()
+		logger := logging.NewRecordingLogger()
 		executor := NewExecutor(logger)
 		executor.SetDryRun(true)
 		ctx := context.Background()
@@ -795,9 +754,9 @@ func TestExecutor_LoggingBehavior(t *testing.T) {
 		executor.UnlabelNode(ctx, "rsb3", "old-label")
 
 		// Then: Should log appropriate debug messages
-		assert.NotEmpty(t, logger.debugMessages, "Should have debug messages")
+		assert.NotEmpty(t, logger.Messages(logging.LevelDebug), "Should have debug messages")
 
-		debugText := strings.Join(logger.debugMessages, " ")
+		debugText := strings.Join(logger.Messages(logging.LevelDebug), " ")
 		assert.Contains(t, debugText, "DRY RUN", "Should log dry-run indicators")
 		assert.Contains(t, debugText, "kubectl", "Should log kubectl commands")
 		assert.Contains(t, debugText, "rsb2", "Should log first node")
@@ -806,7 +765,7 @@ func TestExecutor_LoggingBehavior(t *testing.T) {
 
 	t.Run("error_logging_in_production", func(t *testing.T) {
 		// Given: Executor in production mode (will fail in test env)
-		logger := newMockLogger()
+		logger := logging.NewRecordingLogger()
 		executor := NewExecutor(logger)
 		ctx := context.Background()
 
@@ -814,9 +773,9 @@ func TestExecutor_LoggingBehavior(t *testing.T) {
 		executor.LabelNode(ctx, "rsb2", "test=value", false)
 
 		// Then: Should log debug messages (error messages only on actual command failure)
-		assert.NotEmpty(t, logger.debugMessages, "Should have debug messages")
+		assert.NotEmpty(t, logger.Messages(logging.LevelDebug), "Should have debug messages")
 
-		debugText := strings.Join(logger.debugMessages, " ")
+		debugText := strings.Join(logger.Messages(logging.LevelDebug), " ")
 		assert.Contains(t, debugText, "kubectl", "Should log command attempt")
 		// Note: Error messages only appear if kubectl command actually fails
 	})
@@ -0,0 +1,277 @@
+package kubectl
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Host entry modes describe how a node command reaches the host from inside its container
+const (
+	// HostEntryChroot changes root into the host filesystem mounted at /host (kubectl debug node pods)
+	HostEntryChroot = "chroot"
+
+	// HostEntryNsenter enters the namespaces of host PID 1 (containers sharing the host PID namespace)
+	HostEntryNsenter = "nsenter"
+
+	// HostEntryNone runs the command in the container as-is (host network namespace only)
+	HostEntryNone = "none"
+)
+
+// hostPath is a PATH covering the usual sbin locations of ip, nmcli and netplan on host distributions
+const hostPath = "/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"
+
+// Host command failure classes
+const (
+	// HostErrorTransport means the command never ran: RBAC, API connectivity or pod scheduling failed
+	HostErrorTransport = "transport"
+
+	// HostErrorNotFound means a binary required by the command is missing on the host
+	HostErrorNotFound = "command-not-found"
+
+	// HostErrorPermission means the command ran without the privileges it needed
+	HostErrorPermission = "permission"
+
+	// HostErrorCommand means the command ran and failed
+	HostErrorCommand = "command"
+)
+
+// HostCommandError reports a failed node command together with its failure class
+type HostCommandError struct {
+	Node   string
+	Class  string
+	Output string
+	Cause  error
+}
+
+func (e *HostCommandError) Error() string {
+	return fmt.Sprintf("host command on node %s failed (%s): %v", e.Node, e.Class, e.Cause)
+}
+
+func (e *HostCommandError) Unwrap() error {
+	return e.Cause
+}
+
+// newHostCommandError classifies a node command failure from its output and error
+func newHostCommandError(nodeName, output string, cause error) *HostCommandError {
+	return &HostCommandError{
+		Node:   nodeName,
+		Class:  ClassifyHostCommandFailure(output, cause),
+		Output: output,
+		Cause:  cause,
+	}
+}
+
+// ClassifyHostCommandFailure determines the failure class from command output and error text
+// Transport failures are checked first since kubectl errors can mention "not found" too
+func ClassifyHostCommandFailure(output string, err error) string {
+	text := strings.ToLower(output)
+	if err != nil {
+		text += "\n" + strings.ToLower(err.Error())
+	}
+
+	var hostErr *HostCommandError
+	if errors.As(err, &hostErr) {
+		return hostErr.Class
+	}
+
+	switch {
+	case containsAny(text, "forbidden", "unauthorized", "unable to connect", "connection refused", "was refused",
+		"timeout waiting for pod", "failed to extract pod name", "failed to get logs", "no running pod matching", "error from server"):
+		return HostErrorTransport
+	case containsAny(text, "command not found", ": not found", "no such file or directory", "exit code 127"):
+		return HostErrorNotFound
+	case containsAny(text, "operation not permitted", "permission denied"):
+		return HostErrorPermission
+	default:
+		return HostErrorCommand
+	}
+}
+
+// containsAny reports whether text contains any of the substrings
+func containsAny(text string, substrings ...string) bool {
+	for _, substring := range substrings {
+		if strings.Contains(text, substring) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateHostEntry checks that a host entry mode is supported
+func ValidateHostEntry(entry string) error {
+	switch entry {
+	case "", HostEntryChroot, HostEntryNsenter, HostEntryNone:
+		return nil
+	default:
+		return fmt.Errorf("unsupported host entry '%s'. Expected: %s, %s or %s", entry, HostEntryChroot, HostEntryNsenter, HostEntryNone)
+	}
+}
+
+// WrapHostCommand returns the container argv running a host script with a host PATH
+func WrapHostCommand(entry, script string) []string {
+	shell := []string{"/bin/sh", "-c", fmt.Sprintf("export PATH=%s; %s", hostPath, script)}
+
+	switch entry {
+	case HostEntryChroot:
+		return append([]string{"chroot", "/host"}, shell...)
+	case HostEntryNsenter:
+		return append([]string{"nsenter", "--target", "1", "--mount", "--uts", "--ipc", "--net", "--pid", "--"}, shell...)
+	default:
+		return shell
+	}
+}
+
+// HostCommand builds a single host command line, quoting arguments that need it
+func HostCommand(name string, args ...string) string {
+	parts := make([]string, 0, len(args)+1)
+	parts = append(parts, QuoteShellArg(name))
+	for _, arg := range args {
+		parts = append(parts, QuoteShellArg(arg))
+	}
+	return strings.Join(parts, " ")
+}
+
+// JoinHostCommands chains host commands so the script stops at the first failure
+func JoinHostCommands(commands ...string) string {
+	return strings.Join(commands, " && ")
+}
+
+// QuoteShellArg single-quotes an argument unless it only contains shell-safe characters
+func QuoteShellArg(arg string) string {
+	if arg == "" {
+		return "''"
+	}
+	safe := true
+	for _, r := range arg {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./:=@%+,", r)) {
+			safe = false
+			break
+		}
+	}
+	if safe {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'"'"'`) + "'"
+}
+
+// readOnlyHostCommands are node executables that only inspect the node, whatever their arguments
+var readOnlyHostCommands = map[string]bool{
+	"cat": true, "command": true, "echo": true, "ethtool": true, "grep": true, "ls": true, "lsblk": true,
+	"ping": true, "readlink": true, "test": true, "true": true, "[": true,
+}
+
+// readOnlyHostVerbs are the subcommands that only list, for executables that can also change the node
+var readOnlyHostVerbs = map[string]map[string]bool{
+	"ip":         {"": true, "show": true, "list": true, "lst": true, "get": true},
+	"tc":         {"": true, "show": true, "list": true, "ls": true},
+	"netplan":    {"get": true, "info": true, "status": true},
+	"networkctl": {"": true, "status": true, "list": true},
+	"nmcli":      {"show": true, "status": true, "list": true},
+	"ovs-vsctl":  {"show": true, "list": true, "get": true, "find": true, "port-to-br": true, "iface-to-br": true, "list-br": true, "list-ports": true, "br-exists": true},
+	"systemctl":  {"status": true, "is-active": true, "is-enabled": true, "is-failed": true, "show": true, "list-units": true},
+}
+
+// shellKeywords may precede a command in a script without being one
+var shellKeywords = map[string]bool{"if": true, "then": true, "elif": true, "else": true, "fi": true, "!": true}
+
+// IsMutatingHostCommand reports whether a node script may change the node
+// Scripts count as mutating unless every command is known to only inspect the node and no output goes to a file,
+// so an unfamiliar command is treated as a change rather than missed.
+func IsMutatingHostCommand(script string) bool {
+	commands, writesFiles := splitHostScript(script)
+	if writesFiles {
+		return true
+	}
+	for _, command := range commands {
+		fields := strings.Fields(command)
+		for len(fields) > 0 && shellKeywords[fields[0]] {
+			fields = fields[1:]
+		}
+		if len(fields) == 0 {
+			continue
+		}
+		name := strings.Trim(fields[0], `'"`)
+		if readOnlyHostCommands[name] {
+			continue
+		}
+		if name == "sysctl" && !hostCommandSetsSysctl(fields[1:]) {
+			continue
+		}
+		verbs, known := readOnlyHostVerbs[name]
+		if !known || !verbs[hostCommandVerb(name, fields[1:])] {
+			return true
+		}
+	}
+	return false
+}
+
+// hostCommandVerb returns the subcommand of a command line: ip and tc name an object first, e.g. "ip link add"
+func hostCommandVerb(name string, args []string) string {
+	var words []string
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "-") {
+			words = append(words, strings.Trim(arg, `'"`))
+		}
+	}
+	if name == "ip" || name == "tc" || name == "nmcli" {
+		if len(words) < 2 {
+			return ""
+		}
+		return words[1]
+	}
+	if len(words) == 0 {
+		return ""
+	}
+	return words[0]
+}
+
+// hostCommandSetsSysctl reports whether sysctl arguments write a kernel parameter
+func hostCommandSetsSysctl(args []string) bool {
+	for _, arg := range args {
+		if arg == "-w" || arg == "-p" || strings.Contains(arg, "=") {
+			return true
+		}
+	}
+	return false
+}
+
+// splitHostScript splits a script into simple commands on unquoted ;, |, & and newlines
+// It also reports whether an unquoted redirection writes anywhere but /dev/null or another descriptor.
+func splitHostScript(script string) ([]string, bool) {
+	var commands []string
+	var current strings.Builder
+	inSingle, inDouble, writesFiles := false, false, false
+
+	runes := []rune(script)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case inSingle:
+			if r == '\'' {
+				inSingle = false
+			}
+		case r == '\'' && !inDouble:
+			inSingle = true
+		case r == '"':
+			inDouble = !inDouble
+		case inDouble:
+			// Other characters are literal inside double quotes
+		case r == '>':
+			target := strings.TrimLeft(string(runes[i+1:]), "> ")
+			if !strings.HasPrefix(target, "/dev/null") && !strings.HasPrefix(target, "&") {
+				writesFiles = true
+			}
+		case r == ';' || r == '|' || r == '&' || r == '\n':
+			// 2>&1 and >&2 are redirections, not background commands
+			if r == '&' && i > 0 && runes[i-1] == '>' {
+				break
+			}
+			commands = append(commands, strings.TrimSpace(current.String()))
+			current.Reset()
+			continue
+		}
+		current.WriteRune(r)
+	}
+	return append(commands, strings.TrimSpace(current.String())), writesFiles
+}
@@ -0,0 +1,164 @@
+// Package kubectl provides unit tests for host command wrapping and failure classification
+// WHY: Node commands that miss the host namespaces silently configure the debug container instead of the node
+package kubectl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"k8ostack-ictl/internal/logging"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestWrapHostCommand tests the container argv for each host entry mode
+// WHY: Each backend reaches the host differently and all of them need a usable PATH
+func TestWrapHostCommand(t *testing.T) {
+	script := "ip link show"
+	shell := []string{"/bin/sh", "-c", "export PATH=" + hostPath + "; ip link show"}
+
+	tests := []struct {
+		name     string
+		entry    string
+		expected []string
+	}{
+		{"chroot", HostEntryChroot, append([]string{"chroot", "/host"}, shell...)},
+		{"nsenter", HostEntryNsenter, append([]string{"nsenter", "--target", "1", "--mount", "--uts", "--ipc", "--net", "--pid", "--"}, shell...)},
+		{"none", HostEntryNone, shell},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, WrapHostCommand(tt.entry, script))
+		})
+	}
+}
+
+// TestHostCommand tests argument quoting of host command lines
+// WHY: Interface names and addresses come from user config and must not be interpreted by the shell
+func TestHostCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{"plain_arguments", []string{"ip", "addr", "add", "192.168.1.10/24", "dev", "ens160.100"}, "ip addr add 192.168.1.10/24 dev ens160.100"},
+		{"flag_arguments", []string{"ping", "-c", "3", "10.0.0.1"}, "ping -c 3 10.0.0.1"},
+		{"space_quoted", []string{"echo", "two words"}, "echo 'two words'"},
+		{"metacharacters_quoted", []string{"ip", "link", "show", "eth0; reboot"}, "ip link show 'eth0; reboot'"},
+		{"single_quote_escaped", []string{"echo", "it's"}, `echo 'it'"'"'s'`},
+		{"empty_argument", []string{"echo", ""}, "echo ''"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, HostCommand(tt.args[0], tt.args[1:]...))
+		})
+	}
+
+	assert.Equal(t, "ip link set eth0.50 down && ip link delete eth0.50",
+		JoinHostCommands(HostCommand("ip", "link", "set", "eth0.50", "down"), HostCommand("ip", "link", "delete", "eth0.50")))
+}
+
+// TestIsMutatingHostCommand tests telling node scripts that change the node from those that only inspect it
+// WHY: The audit trail records every change to a node, and inspection scripts would bury those changes
+func TestIsMutatingHostCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		script   string
+		expected bool
+	}{
+		{"ip_show", "ip -o addr show", false},
+		{"ip_show_chained", "ip addr show eth0.100 && ip -6 addr show dev eth0.100", false},
+		{"ip_link_add", "ip link add link eth0 name eth0.100 type vlan id 100 && ip link set eth0.100 up", true},
+		{"ip_delete_tolerated", "ip link set eth0.100 down && ip link delete eth0.100 || true", true},
+		{"ping", "ping -c 3 10.0.0.1", false},
+		{"detection_with_redirections", "if command -v netplan >/dev/null 2>&1; then echo netplan; elif systemctl is-active --quiet NetworkManager; then echo nm; fi", false},
+		{"listing_to_dev_null", "ls -d /sys/class/net/*/device 2>/dev/null; grep -s -H . /sys/class/net/*/speed", false},
+		{"write_to_file", "printf '%s' 'network: {}' > /etc/netplan/60-kictl.yaml", true},
+		{"quoted_operators", "echo 'a; rm -rf /' \"> x\"", false},
+		{"netplan_apply", "netplan apply", true},
+		{"sysctl_read", "sysctl net.ipv4.ip_forward", false},
+		{"sysctl_write", "sysctl -w net.ipv4.ip_forward=1", true},
+		{"ovs_check", "ovs-vsctl port-to-br vlan100 && ovs-vsctl get port vlan100 tag", false},
+		{"ovs_add_port", "ovs-vsctl add-port br-ex vlan100 tag=100 -- set interface vlan100 type=internal", true},
+		{"device_probe", "echo '== /dev/sdb'; lsblk -nP -o NAME,TYPE,FSTYPE,MOUNTPOINT /dev/sdb 2>&1 || true", false},
+		{"device_zap", "wipefs --all /dev/sdb && sgdisk --zap-all /dev/sdb", true},
+		{"unknown_command", "ifup eth0.100", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, IsMutatingHostCommand(tt.script))
+		})
+	}
+}
+
+// TestClassifyHostCommandFailure tests failure classes derived from output and errors
+// WHY: Callers must tell RBAC/connectivity problems apart from commands that failed on the host
+func TestClassifyHostCommandFailure(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   string
+		err      error
+		expected string
+	}{
+		{"forbidden", "", errors.New(`Error from server (Forbidden): pods "x" is forbidden`), HostErrorTransport},
+		{"connection_refused", "The connection to the server localhost:8080 was refused - did you specify the right host or port? connection refused", errors.New("exit status 1"), HostErrorTransport},
+		{"pod_timeout", "", errors.New("timeout waiting for pod node-debugger-rsb2 to complete"), HostErrorTransport},
+		{"missing_binary", "sh: nmcli: not found", errors.New("exit status 127"), HostErrorNotFound},
+		{"bash_missing_binary", "bash: netplan: command not found", errors.New("exit status 127"), HostErrorNotFound},
+		{"permission", "RTNETLINK answers: Operation not permitted", errors.New("exit status 2"), HostErrorPermission},
+		{"command_failure", "RTNETLINK answers: File exists", errors.New("exit status 2"), HostErrorCommand},
+		{"wrapped_host_error", "", fmt.Errorf("outer: %w", &HostCommandError{Class: HostErrorPermission, Cause: errors.New("inner")}), HostErrorPermission},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ClassifyHostCommandFailure(tt.output, tt.err))
+		})
+	}
+}
+
+// TestValidateHostEntry tests host entry mode validation
+// WHY: An unknown mode would fall back to running inside the container
+func TestValidateHostEntry(t *testing.T) {
+	assert.NoError(t, ValidateHostEntry(""))
+	assert.NoError(t, ValidateHostEntry(HostEntryChroot))
+	assert.NoError(t, ValidateHostEntry(HostEntryNsenter))
+	assert.NoError(t, ValidateHostEntry(HostEntryNone))
+
+	err := ValidateHostEntry("ssh")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported host entry 'ssh'")
+}
+
+// TestExecutorOptions_HostEntryDefaults tests the default host entry per backend
+// WHY: Debug node pods mount the host at /host while ephemeral containers do not
+func TestExecutorOptions_HostEntryDefaults(t *testing.T) {
+	assert.Equal(t, HostEntryChroot, ExecutorOptions{}.withDefaults().HostEntry)
+	assert.Equal(t, HostEntryNone, ExecutorOptions{NodeExecBackend: NodeExecBackendEphemeral}.withDefaults().HostEntry)
+	assert.Equal(t, HostEntryChroot, ExecutorOptions{NodeExecBackend: NodeExecBackendAgent}.withDefaults().HostEntry)
+	assert.Equal(t, HostEntryNsenter, ExecutorOptions{HostEntry: HostEntryNsenter}.withDefaults().HostEntry)
+}
+
+// TestExecNodeCommand_HostCommandError tests that node command failures carry a class
+// WHY: Services report failures per node and need to know whether the host was reached at all
+func TestExecNodeCommand_HostCommandError(t *testing.T) {
+	// Given: Executor without a reachable cluster
+	executor := NewExecutor(logging.NewRecordingLogger())
+
+	// When: Execute command on a node
+	success, _, err := executor.ExecNodeCommand(context.Background(), "rsb2", "ip link show")
+
+	// Then: Failure is a transport-classified host command error
+	assert.False(t, success)
+	var hostErr *HostCommandError
+	require.True(t, errors.As(err, &hostErr), "expected HostCommandError, got %v", err)
+	assert.Equal(t, "rsb2", hostErr.Node)
+	assert.Equal(t, HostErrorTransport, hostErr.Class)
+	assert.Contains(t, err.Error(), "host command on node rsb2 failed (transport)")
+}
@@ -14,12 +14,21 @@ type Executor interface {
 	// LabelNode applies a label to a node
 	LabelNode(ctx context.Context, nodeName, label string, overwrite bool) (bool, string, error)
 
+	// LabelNodes applies the same labels to several nodes with as few API calls as the client allows
+	LabelNodes(ctx context.Context, nodeNames []string, labels map[string]string, overwrite bool) (bool, string, error)
+
 	// UnlabelNode removes a label from a node
 	UnlabelNode(ctx context.Context, nodeName, labelKey string) (bool, string, error)
 
 	// GetNodeLabels retrieves all labels for a specific node
 	GetNodeLabels(ctx context.Context, nodeName string) (bool, string, error)
 
+	// GetNodeAnnotations retrieves all annotations for a specific node as JSON
+	GetNodeAnnotations(ctx context.Context, nodeName string) (bool, string, error)
+
+	// GetNodeInternalIP retrieves the InternalIP address the node reports
+	GetNodeInternalIP(ctx context.Context, nodeName string) (bool, string, error)
+
 	// ExecNodeCommand executes a command on a specific node
 	ExecNodeCommand(ctx context.Context, nodeName, command string) (bool, string, error)
 
@@ -29,6 +38,9 @@ type Executor interface {
 	// DeletePod deletes a specific pod
 	DeletePod(ctx context.Context, podName string) (bool, string, error)
 
+	// ReleaseDebugPods deletes the node command pods the executor started and returns how many it deleted
+	ReleaseDebugPods(ctx context.Context) (int, error)
+
 	// Node Discovery Methods
 	GetAllNodes(ctx context.Context) (bool, string, error)
 	GetNodesByLabel(ctx context.Context, labelSelector string) (bool, string, error)
@@ -40,6 +52,9 @@ type Executor interface {
 	DiscoverAllVLANs(ctx context.Context) (map[string]string, error)
 	GetNodeNetworkInfo(ctx context.Context, nodeName string) (bool, string, error)
 	GetNodeHardwareInfo(ctx context.Context, nodeName string) (bool, string, error)
+
+	// RunKubectl runs a kubectl command against the target, subject to the dry run and restricted mode
+	RunKubectl(ctx context.Context, stdin []byte, args ...string) (string, error)
 }
 
 // DryRunExecutor extends Executor with dry-run functionality
@@ -49,11 +64,3 @@ type DryRunExecutor interface {
 	IsDryRun() bool
 	SetPollingInterval(interval time.Duration)
 }
-
-// Logger defines the interface for logging kubectl operations
-type Logger interface {
-	Debug(message string)
-	Info(message string)
-	Warn(message string)
-	Error(message string)
-}
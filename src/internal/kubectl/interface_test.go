@@ -6,6 +6,8 @@ import (
 	"context"
 	"testing"
 
+	"k8ostack-ictl/internal/logging"
+
 	"github.com/stretchr/testify/assert"
 )
 
@@ -14,7 +16,7 @@ import (
 func TestExecutor_Interface(t *testing.T) {
 	t.Run("real_executor_implements_executor", func(t *testing.T) {
 		// Given: Real executor instance
-		logger := newMockLogger()
+		logger := logging.NewRecordingLogger()
 		executor := NewExecutor(logger)
 
 		// Then: Should implement Executor interface
@@ -23,7 +25,7 @@ func TestExecutor_Interface(t *testing.T) {
 
 	t.Run("real_executor_implements_dry_run_executor", func(t *testing.T) {
 		// Given: Real executor instance
-		logger := newMockLogger()
+		logger := logging.NewRecordingLogger()
 		executor := NewExecutor(logger)
 
 		// Then: Should implement DryRunExecutor interface
@@ -33,7 +35,7 @@ func TestExecutor_Interface(t *testing.T) {
 	t.Run("executor_interface_methods_exist", func(t *testing.T) {
 		// Given: Executor interface type
 		var executor Executor
-		logger := newMockLogger()
+		logger := logging.NewRecordingLogger()
 		executor = NewExecutor(logger)
 		ctx := context.Background()
 
@@ -58,7 +60,7 @@ func TestExecutor_Interface(t *testing.T) {
 	t.Run("dry_run_interface_methods_exist", func(t *testing.T) {
 		// Given: DryRunExecutor interface type
 		var executor DryRunExecutor
-		logger := newMockLogger()
+		logger := logging.NewRecordingLogger()
 		executor = NewExecutor(logger)
 
 		// When/Then: DryRunExecutor methods should be callable
@@ -80,7 +82,7 @@ func TestExecutor_Interface(t *testing.T) {
 
 	t.Run("dry_run_executor_embeds_executor", func(t *testing.T) {
 		// Given: DryRunExecutor instance
-		logger := newMockLogger()
+		logger := logging.NewRecordingLogger()
 		dryRunExecutor := NewExecutor(logger)
 		ctx := context.Background()
 
@@ -98,69 +100,12 @@ func TestExecutor_Interface(t *testing.T) {
 	})
 }
 
-// TestLogger_Interface tests the Logger interface compliance
-// WHY: Logger interface ensures consistent logging behavior across different implementations
-func TestLogger_Interface(t *testing.T) {
-	t.Run("mock_logger_implements_logger", func(t *testing.T) {
-		// Given: Mock logger instance
-		logger := newMockLogger()
-
-		// Then: Should implement Logger interface
-		assert.Implements(t, (*Logger)(nil), logger, "mockLogger should implement Logger interface")
-	})
-
-	t.Run("logger_interface_methods_exist", func(t *testing.T) {
-		// Given: Logger interface type
-		var logger Logger = newMockLogger()
-
-		// When/Then: Logger methods should be callable
-		assert.NotPanics(t, func() {
-			logger.Debug("test debug message")
-		}, "Debug method should be callable")
-
-		assert.NotPanics(t, func() {
-			logger.Info("test info message")
-		}, "Info method should be callable")
-
-		assert.NotPanics(t, func() {
-			logger.Warn("test warn message")
-		}, "Warn method should be callable")
-
-		assert.NotPanics(t, func() {
-			logger.Error("test error message")
-		}, "Error method should be callable")
-	})
-
-	t.Run("logger_message_storage", func(t *testing.T) {
-		// Given: Mock logger
-		mockLogger := newMockLogger()
-		var logger Logger = mockLogger
-
-		// When: Log messages at different levels
-		logger.Debug("debug message")
-		logger.Info("info message")
-		logger.Warn("warn message")
-		logger.Error("error message")
-
-		// Then: Messages should be stored correctly
-		assert.Len(t, mockLogger.debugMessages, 1, "Should store debug message")
-		assert.Len(t, mockLogger.infoMessages, 1, "Should store info message")
-		assert.Len(t, mockLogger.warnMessages, 1, "Should store warn message")
-		assert.Len(t, mockLogger.errorMessages, 1, "Should store error message")
-
-		assert.Equal(t, "debug message", mockLogger.debugMessages[0], "Debug message should match")
-		assert.Equal(t, "info message", mockLogger.infoMessages[0], "Info message should match")
-		assert.Equal(t, "warn message", mockLogger.warnMessages[0], "Warn message should match")
-		assert.Equal(t, "error message", mockLogger.errorMessages[0], "Error message should match")
-	})
-}
-
 // TestInterface_Composition tests interface composition and compatibility
 // WHY: Interface composition ensures clean architecture and proper inheritance relationships
 func TestInterface_Composition(t *testing.T) {
 	t.Run("dry_run_executor_composition", func(t *testing.T) {
 		// Given: DryRunExecutor instance
-		logger := newMockLogger()
+		logger := logging.NewRecordingLogger()
 		dryRunExecutor := NewExecutor(logger)
 
 		// When: Use as different interface types
@@ -191,7 +136,7 @@ func TestInterface_Composition(t *testing.T) {
 
 	t.Run("interface_type_assertions", func(t *testing.T) {
 		// Given: Various interface instances
-		logger := newMockLogger()
+		logger := logging.NewRecordingLogger()
 		realExecutor := NewExecutor(logger)
 
 		// When: Perform type assertions
@@ -207,14 +152,6 @@ func TestInterface_Composition(t *testing.T) {
 		} else {
 			t.Error("Should be able to assert to DryRunExecutor interface")
 		}
-
-		// Test Logger interface assertion with proper interface variable
-		var loggerInterface Logger = logger
-		if loggerAsInterface, ok := loggerInterface.(*mockLogger); ok {
-			assert.NotNil(t, loggerAsInterface, "Should assert to mockLogger from Logger interface")
-		} else {
-			t.Error("Should be able to assert mockLogger from Logger interface")
-		}
 	})
 }
 
@@ -223,7 +160,7 @@ func TestInterface_Composition(t *testing.T) {
 func TestInterface_ErrorHandling(t *testing.T) {
 	t.Run("executor_error_consistency", func(t *testing.T) {
 		// Given: Executor instances
-		logger := newMockLogger()
+		logger := logging.NewRecordingLogger()
 		executor := NewExecutor(logger)
 		ctx := context.Background()
 
@@ -246,7 +183,7 @@ func TestInterface_ErrorHandling(t *testing.T) {
 
 	t.Run("dry_run_error_consistency", func(t *testing.T) {
 		// Given: Executor in dry-run mode
-		logger := newMockLogger()
+		logger := logging.NewRecordingLogger()
 		executor := NewExecutor(logger)
 		executor.SetDryRun(true)
 		ctx := context.Background()
@@ -272,7 +209,7 @@ func TestInterface_ErrorHandling(t *testing.T) {
 func TestInterface_Documentation(t *testing.T) {
 	t.Run("interface_contract_compliance", func(t *testing.T) {
 		// Given: Documented interface contracts
-		logger := newMockLogger()
+		logger := logging.NewRecordingLogger()
 		executor := NewExecutor(logger)
 		ctx := context.Background()
 
@@ -0,0 +1,647 @@
+package kubectl
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"k8ostack-ictl/internal/logging"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// Kubernetes client implementations selectable with --client
+const (
+	// ClientKubectl shells out to the kubectl binary
+	ClientKubectl = "kubectl"
+
+	// ClientNative talks to the API server directly through client-go
+	ClientNative = "native"
+)
+
+// nodeCommandTimeout bounds how long a node command pod may run
+const nodeCommandTimeout = 60 * time.Second
+
+// ValidateClient checks that a client implementation name is supported
+func ValidateClient(client string) error {
+	switch client {
+	case "", ClientKubectl, ClientNative:
+		return nil
+	default:
+		return fmt.Errorf("unsupported client '%s'. Expected: %s or %s", client, ClientKubectl, ClientNative)
+	}
+}
+
+// NativeExecutor implements the Executor interface using client-go
+type NativeExecutor struct {
+	logger          logging.Logger
+	dryRun          bool
+	pollingInterval time.Duration
+	options         ExecutorOptions
+
+	// The client is created on first use so kubeconfig errors surface as operation errors
+	clientOnce sync.Once
+	client     kubernetes.Interface
+	namespace  string
+	clientErr  error
+
+	agent     agentDeployment
+	debugPods debugPodPool
+	podExec   podExecFunc // execs into agent and debug pods; nil without a kubeconfig
+}
+
+// NewNativeExecutor creates a client-go executor using the default kubeconfig loading rules
+func NewNativeExecutor(logger logging.Logger, options ExecutorOptions) DryRunExecutor {
+	return &NativeExecutor{
+		logger:          logger,
+		pollingInterval: 1 * time.Second,
+		options:         options.withDefaults(),
+	}
+}
+
+// NewNativeExecutorWithClient creates a client-go executor around an existing clientset
+func NewNativeExecutorWithClient(logger logging.Logger, client kubernetes.Interface, namespace string, options ExecutorOptions) DryRunExecutor {
+	e := &NativeExecutor{
+		logger:          logger,
+		pollingInterval: 1 * time.Second,
+		options:         options.withDefaults(),
+		client:          client,
+		namespace:       namespace,
+	}
+	e.clientOnce.Do(func() {})
+	return e
+}
+
+// SetDryRun enables or disables dry-run mode
+func (e *NativeExecutor) SetDryRun(enabled bool) {
+	e.dryRun = enabled
+}
+
+// IsDryRun returns whether dry-run mode is enabled
+func (e *NativeExecutor) IsDryRun() bool {
+	return e.dryRun
+}
+
+// SetPollingInterval sets the polling interval for waiting for pod completion
+func (e *NativeExecutor) SetPollingInterval(interval time.Duration) {
+	e.pollingInterval = interval
+}
+
+// clientset returns the Kubernetes client and the namespace used for node command pods
+func (e *NativeExecutor) clientset() (kubernetes.Interface, string, error) {
+	e.clientOnce.Do(func() {
+		var restConfig *rest.Config
+		e.client, restConfig, e.namespace, e.clientErr = newClientset(e.options.Target)
+		if e.clientErr == nil {
+			e.podExec = remotePodExec(e.client, restConfig)
+		}
+	})
+
+	return e.client, e.namespace, e.clientErr
+}
+
+// NewClientset creates a clientset for the target and returns it with the target namespace
+func NewClientset(target ClusterTarget) (kubernetes.Interface, string, error) {
+	client, _, namespace, err := newClientset(target)
+	return client, namespace, err
+}
+
+// newClientset creates a clientset for the target and returns it with its REST config and the target namespace
+func newClientset(target ClusterTarget) (kubernetes.Interface, *rest.Config, string, error) {
+	clientConfig := target.ClientConfig()
+
+	restConfig, err := clientConfig.ClientConfig()
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("failed to load kubeconfig: %w", err)
+	}
+
+	namespace, _, err := clientConfig.Namespace()
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("failed to determine namespace from kubeconfig: %w", err)
+	}
+
+	client, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	return client, restConfig, namespace, nil
+}
+
+// GetNode retrieves information about a specific node
+func (e *NativeExecutor) GetNode(ctx context.Context, nodeName string) (bool, string, error) {
+	node, err := e.getNode(ctx, nodeName)
+	if err != nil {
+		return false, "", err
+	}
+	return true, "node/" + node.Name, nil
+}
+
+// getNode fetches a node object
+func (e *NativeExecutor) getNode(ctx context.Context, nodeName string) (*corev1.Node, error) {
+	client, _, err := e.clientset()
+	if err != nil {
+		return nil, err
+	}
+
+	node, err := client.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node %s: %w", nodeName, err)
+	}
+	return node, nil
+}
+
+// LabelNode applies a label to a node
+func (e *NativeExecutor) LabelNode(ctx context.Context, nodeName, label string, overwrite bool) (bool, string, error) {
+	key, value, found := strings.Cut(label, "=")
+	if !found {
+		return false, "", fmt.Errorf("invalid label '%s': expected key=value", label)
+	}
+
+	if e.dryRun {
+		e.logger.Debug(fmt.Sprintf("DRY RUN: Would label node %s with %s", nodeName, label))
+		return true, fmt.Sprintf("node/%s labeled", nodeName), nil
+	}
+
+	node, err := e.getNode(ctx, nodeName)
+	if err != nil {
+		return false, "", err
+	}
+	if existing, exists := node.Labels[key]; exists && existing != value && !overwrite {
+		return false, "", fmt.Errorf("node %s: label '%s' already has a value (%s), and overwrite is false", nodeName, key, existing)
+	}
+
+	if err := e.patchNodeLabels(ctx, nodeName, map[string]interface{}{key: value}); err != nil {
+		return false, "", err
+	}
+	return true, fmt.Sprintf("node/%s labeled", nodeName), nil
+}
+
+// LabelNodes applies the same labels to several nodes, patching each with one shared merge patch
+// Nodes are patched in order and the first failure stops the call, wrapped with the node it hit.
+func (e *NativeExecutor) LabelNodes(ctx context.Context, nodeNames []string, labels map[string]string, overwrite bool) (bool, string, error) {
+	if e.dryRun {
+		e.logger.Debug(fmt.Sprintf("DRY RUN: Would label nodes %s with %s", strings.Join(nodeNames, ", "), strings.Join(FormatLabels(labels), ",")))
+		return true, labeledOutput(nodeNames), nil
+	}
+
+	patchLabels := make(map[string]interface{}, len(labels))
+	for key, value := range labels {
+		patchLabels[key] = value
+	}
+	patch, err := labelPatch(patchLabels)
+	if err != nil {
+		return false, "", err
+	}
+
+	for i, nodeName := range nodeNames {
+		if !overwrite {
+			if err := e.checkLabelConflicts(ctx, nodeName, labels); err != nil {
+				return false, labeledOutput(nodeNames[:i]), WrapNodeError(nodeName, err)
+			}
+		}
+		if err := e.patchNode(ctx, nodeName, patch); err != nil {
+			return false, labeledOutput(nodeNames[:i]), WrapNodeError(nodeName, err)
+		}
+	}
+	return true, labeledOutput(nodeNames), nil
+}
+
+// checkLabelConflicts fails when a node already has one of the labels with a different value
+func (e *NativeExecutor) checkLabelConflicts(ctx context.Context, nodeName string, labels map[string]string) error {
+	node, err := e.getNode(ctx, nodeName)
+	if err != nil {
+		return err
+	}
+	for _, key := range sortedKeys(labels) {
+		if existing, exists := node.Labels[key]; exists && existing != labels[key] {
+			return fmt.Errorf("node %s: label '%s' already has a value (%s), and overwrite is false", nodeName, key, existing)
+		}
+	}
+	return nil
+}
+
+// UnlabelNode removes a label from a node
+func (e *NativeExecutor) UnlabelNode(ctx context.Context, nodeName, labelKey string) (bool, string, error) {
+	if e.dryRun {
+		e.logger.Debug(fmt.Sprintf("DRY RUN: Would remove label %s from node %s", labelKey, nodeName))
+		return true, fmt.Sprintf("node/%s unlabeled", nodeName), nil
+	}
+
+	// A null value in a merge patch deletes the key
+	if err := e.patchNodeLabels(ctx, nodeName, map[string]interface{}{labelKey: nil}); err != nil {
+		return false, "", err
+	}
+	return true, fmt.Sprintf("node/%s unlabeled", nodeName), nil
+}
+
+// patchNodeLabels applies a JSON merge patch to a node's labels
+func (e *NativeExecutor) patchNodeLabels(ctx context.Context, nodeName string, labels map[string]interface{}) error {
+	patch, err := labelPatch(labels)
+	if err != nil {
+		return err
+	}
+	return e.patchNode(ctx, nodeName, patch)
+}
+
+// labelPatch builds the JSON merge patch setting labels; a nil value deletes the key
+func labelPatch(labels map[string]interface{}) ([]byte, error) {
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{"labels": labels},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build label patch: %w", err)
+	}
+	return patch, nil
+}
+
+// patchNode applies a label merge patch to a node
+func (e *NativeExecutor) patchNode(ctx context.Context, nodeName string, patch []byte) error {
+	if blocked, err := checkDryRunMutation(e.dryRun, e.options, e.logger, fmt.Sprintf("patch labels on node %s", nodeName)); blocked {
+		return err
+	}
+
+	client, _, err := e.clientset()
+	if err != nil {
+		return err
+	}
+
+	e.logger.Debug(fmt.Sprintf("Patching node %s: %s", nodeName, patch))
+	if _, err := client.CoreV1().Nodes().Patch(ctx, nodeName, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		return fmt.Errorf("failed to patch labels on node %s: %w", nodeName, err)
+	}
+	return nil
+}
+
+// GetNodeLabels retrieves all labels for a specific node
+// Output mirrors `kubectl get node --show-labels` so existing parsers keep working
+func (e *NativeExecutor) GetNodeLabels(ctx context.Context, nodeName string) (bool, string, error) {
+	node, err := e.getNode(ctx, nodeName)
+	if err != nil {
+		return false, "", err
+	}
+	return true, formatNodeLabelsTable(node), nil
+}
+
+// formatNodeLabelsTable renders a node's labels as a NAME/LABELS table with sorted key=value pairs
+func formatNodeLabelsTable(node *corev1.Node) string {
+	pairs := make([]string, 0, len(node.Labels))
+	for key, value := range node.Labels {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+
+	labels := strings.Join(pairs, ",")
+	if labels == "" {
+		labels = "<none>"
+	}
+	return fmt.Sprintf("NAME   LABELS\n%s   %s", node.Name, labels)
+}
+
+// GetNodeAnnotations retrieves all annotations for a specific node as JSON
+func (e *NativeExecutor) GetNodeAnnotations(ctx context.Context, nodeName string) (bool, string, error) {
+	node, err := e.getNode(ctx, nodeName)
+	if err != nil {
+		return false, "", err
+	}
+	if len(node.Annotations) == 0 {
+		return true, "", nil
+	}
+
+	data, err := json.Marshal(node.Annotations)
+	if err != nil {
+		return false, "", fmt.Errorf("failed to encode annotations of node %s: %w", nodeName, err)
+	}
+	return true, string(data), nil
+}
+
+// GetNodeInternalIP retrieves the InternalIP address the node reports
+func (e *NativeExecutor) GetNodeInternalIP(ctx context.Context, nodeName string) (bool, string, error) {
+	node, err := e.getNode(ctx, nodeName)
+	if err != nil {
+		return false, "", err
+	}
+	for _, address := range node.Status.Addresses {
+		if address.Type == corev1.NodeInternalIP {
+			return true, address.Address, nil
+		}
+	}
+	return false, "", fmt.Errorf("node %s reports no InternalIP address", nodeName)
+}
+
+// GetPods retrieves pods with optional filtering
+func (e *NativeExecutor) GetPods(ctx context.Context, fieldSelector, labelSelector string) (bool, string, error) {
+	client, namespace, err := e.clientset()
+	if err != nil {
+		return false, "", err
+	}
+
+	pods, err := client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+		FieldSelector: fieldSelector,
+		LabelSelector: labelSelector,
+	})
+	if err != nil {
+		return false, "", fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	names := make([]string, 0, len(pods.Items))
+	for _, pod := range pods.Items {
+		names = append(names, "pod/"+pod.Name)
+	}
+	return true, strings.Join(names, "\n"), nil
+}
+
+// DeletePod deletes a specific pod
+func (e *NativeExecutor) DeletePod(ctx context.Context, podName string) (bool, string, error) {
+	if e.dryRun {
+		e.logger.Debug(fmt.Sprintf("DRY RUN: Would delete pod %s", podName))
+		return true, fmt.Sprintf("pod/%s deleted", podName), nil
+	}
+
+	client, namespace, err := e.clientset()
+	if err != nil {
+		return false, "", err
+	}
+
+	if err := client.CoreV1().Pods(namespace).Delete(ctx, podName, metav1.DeleteOptions{}); err != nil {
+		return false, "", fmt.Errorf("failed to delete pod %s: %w", podName, err)
+	}
+	return true, fmt.Sprintf("pod/%s deleted", podName), nil
+}
+
+// GetAllNodes retrieves all nodes in the cluster
+func (e *NativeExecutor) GetAllNodes(ctx context.Context) (bool, string, error) {
+	return e.GetNodesByLabel(ctx, "")
+}
+
+// GetNodesByLabel retrieves nodes using a specific label selector
+func (e *NativeExecutor) GetNodesByLabel(ctx context.Context, labelSelector string) (bool, string, error) {
+	client, _, err := e.clientset()
+	if err != nil {
+		return false, "", err
+	}
+
+	nodes, err := client.CoreV1().Nodes().List(ctx, metav1.ListOptions{LabelSelector: labelSelector})
+	if err != nil {
+		return false, "", fmt.Errorf("failed to list nodes: %w", err)
+	}
+
+	names := make([]string, 0, len(nodes.Items))
+	for _, node := range nodes.Items {
+		names = append(names, "node/"+node.Name)
+	}
+	return true, strings.Join(names, "\n"), nil
+}
+
+// GetNodeRole retrieves node role based on labels and analysis
+func (e *NativeExecutor) GetNodeRole(ctx context.Context, nodeName string) (string, error) {
+	return getNodeRole(ctx, e, nodeName)
+}
+
+// DiscoverClusterState returns comprehensive cluster overview
+func (e *NativeExecutor) DiscoverClusterState(ctx context.Context) (map[string]interface{}, error) {
+	return discoverClusterState(ctx, e)
+}
+
+// DiscoverNodeVLANs detects VLAN configuration on a specific node
+func (e *NativeExecutor) DiscoverNodeVLANs(ctx context.Context, nodeName string) (bool, string, error) {
+	return runDiscoveryCommand(ctx, e, e.logger, nodeName, vlanDiscovery)
+}
+
+// DiscoverAllVLANs maps VLAN configurations across all nodes
+func (e *NativeExecutor) DiscoverAllVLANs(ctx context.Context) (map[string]string, error) {
+	return discoverAllVLANs(ctx, e, e.logger)
+}
+
+// GetNodeNetworkInfo retrieves network interface information from a node
+func (e *NativeExecutor) GetNodeNetworkInfo(ctx context.Context, nodeName string) (bool, string, error) {
+	return runDiscoveryCommand(ctx, e, e.logger, nodeName, networkInfoDiscovery)
+}
+
+// GetNodeHardwareInfo gets basic hardware specifications for node categorization
+func (e *NativeExecutor) GetNodeHardwareInfo(ctx context.Context, nodeName string) (bool, string, error) {
+	return runDiscoveryCommand(ctx, e, e.logger, nodeName, hardwareInfoDiscovery)
+}
+
+// RunKubectl runs a kubectl command for callers outside of the executor, like the cluster lock and ownership annotations
+// client-go has no equivalent of an arbitrary kubectl command line, so it runs the kubectl binary with the same target and checks.
+func (e *NativeExecutor) RunKubectl(ctx context.Context, stdin []byte, args ...string) (string, error) {
+	return runKubectl(ctx, e.logger, e.dryRun, e.options, stdin, args)
+}
+
+// ExecNodeCommand executes a command on a specific node in a privileged host pod, ephemeral container or node agent pod
+func (e *NativeExecutor) ExecNodeCommand(ctx context.Context, nodeName, command string) (bool, string, error) {
+	if err := checkCommandPolicy(e.options, e.logger, nodeName, command); err != nil {
+		return false, "", err
+	}
+
+	if e.dryRun {
+		e.logger.Debug(fmt.Sprintf("DRY RUN: Would run on node %s via %s: %s", nodeName, e.options.NodeExecBackend, command))
+		return true, fmt.Sprintf("Command would be executed on node %s: %s", nodeName, command), nil
+	}
+
+	client, namespace, err := e.clientset()
+	if err != nil {
+		return false, "", &HostCommandError{Node: nodeName, Class: HostErrorTransport, Cause: err}
+	}
+
+	// Deploying the agent waits for every node, so it is not bounded by the timeout of one command
+	if e.options.NodeExecBackend == NodeExecBackendAgent {
+		if err := e.agent.ensure(func() error { return e.deployAgent(ctx, client) }); err != nil {
+			return false, "", &HostCommandError{Node: nodeName, Class: HostErrorTransport, Cause: err}
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, nodeCommandTimeout)
+	defer cancel()
+
+	var output string
+	var exitCode int32
+	switch e.options.NodeExecBackend {
+	case NodeExecBackendEphemeral:
+		output, exitCode, err = e.execInEphemeralContainer(ctx, client, nodeName, command)
+	case NodeExecBackendAgent:
+		output, exitCode, err = e.execInAgent(ctx, client, nodeName, command)
+	default:
+		if e.podExec != nil {
+			output, exitCode, err = e.execInDebugPod(ctx, client, namespace, nodeName, command)
+		} else {
+			output, exitCode, err = e.execInNodePod(ctx, client, namespace, nodeName, command)
+		}
+	}
+	if err != nil {
+		return false, output, &HostCommandError{Node: nodeName, Class: HostErrorTransport, Output: output, Cause: err}
+	}
+
+	if exitCode != 0 {
+		// Packet loss is the expected result of isolation tests, not a failure
+		if strings.Contains(output, "0 received, 100% packet loss") {
+			return false, output, nil
+		}
+		return false, output, &HostCommandError{
+			Node:   nodeName,
+			Class:  exitCodeClass(exitCode, output),
+			Output: output,
+			Cause:  fmt.Errorf("command exited with code %d: %s", exitCode, output),
+		}
+	}
+
+	return nodeCommandSucceeded(command, output), output, nil
+}
+
+// exitCodeClass maps a shell exit code to a failure class
+func exitCodeClass(exitCode int32, output string) string {
+	switch exitCode {
+	case 126:
+		return HostErrorPermission
+	case 127:
+		return HostErrorNotFound
+	default:
+		return ClassifyHostCommandFailure(output, nil)
+	}
+}
+
+// execInNodePod runs a command in a privileged pod pinned to the node, equivalent to `kubectl debug node --profile=sysadmin`
+func (e *NativeExecutor) execInNodePod(ctx context.Context, client kubernetes.Interface, namespace, nodeName, command string) (string, int32, error) {
+	if blocked, err := checkDryRunMutation(e.dryRun, e.options, e.logger, fmt.Sprintf("create node command pod on %s", nodeName)); blocked {
+		return "", 0, err
+	}
+
+	pod := newDebugPod(namespace, nodeName, e.options.DebugImage, WrapHostCommand(e.options.HostEntry, command))
+	pods := client.CoreV1().Pods(namespace)
+	e.logger.Debug(fmt.Sprintf("Creating pod %s/%s on node %s: %s", namespace, pod.Name, nodeName, command))
+	if _, err := pods.Create(ctx, pod, metav1.CreateOptions{}); err != nil {
+		return "", 0, fmt.Errorf("failed to create node command pod on %s: %w", nodeName, err)
+	}
+	defer func() {
+		// Use a fresh context so the pod is removed even after a timeout
+		if err := pods.Delete(context.Background(), pod.Name, metav1.DeleteOptions{}); err != nil {
+			e.logger.Warn(fmt.Sprintf("Failed to delete node command pod %s: %v", pod.Name, err))
+		}
+	}()
+
+	var exitCode int32
+	err := e.waitFor(ctx, fmt.Sprintf("pod %s to complete", pod.Name), func() (bool, error) {
+		current, err := pods.Get(ctx, pod.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		if current.Status.Phase != corev1.PodSucceeded && current.Status.Phase != corev1.PodFailed {
+			return false, nil
+		}
+		exitCode = terminatedExitCode(current.Status.ContainerStatuses, debugContainerName, current.Status.Phase)
+		return true, nil
+	})
+	if err != nil {
+		return "", 0, err
+	}
+
+	logs, err := pods.GetLogs(pod.Name, &corev1.PodLogOptions{Container: debugContainerName}).DoRaw(ctx)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to get logs from pod %s: %w", pod.Name, err)
+	}
+	return strings.TrimSpace(string(logs)), exitCode, nil
+}
+
+// execInEphemeralContainer runs a command in an ephemeral container added to the node's host pod
+func (e *NativeExecutor) execInEphemeralContainer(ctx context.Context, client kubernetes.Interface, nodeName, command string) (string, int32, error) {
+	if blocked, err := checkDryRunMutation(e.dryRun, e.options, e.logger, fmt.Sprintf("add ephemeral container on node %s", nodeName)); blocked {
+		return "", 0, err
+	}
+
+	namespace := e.options.HostPodNamespace
+	pods := client.CoreV1().Pods(namespace)
+
+	list, err := pods.List(ctx, metav1.ListOptions{
+		LabelSelector: e.options.HostPodSelector,
+		FieldSelector: "spec.nodeName=" + nodeName + ",status.phase=Running",
+	})
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to find host pod on node %s: %w", nodeName, err)
+	}
+	if len(list.Items) == 0 {
+		return "", 0, fmt.Errorf("no running pod matching %s in namespace %s on node %s for the ephemeral exec backend",
+			e.options.HostPodSelector, namespace, nodeName)
+	}
+	pod := list.Items[0].DeepCopy()
+
+	// Ephemeral containers cannot be removed, so use a recognisable unique name
+	privileged := true
+	containerName := fmt.Sprintf("kictl-%d", time.Now().UnixNano())
+	pod.Spec.EphemeralContainers = append(pod.Spec.EphemeralContainers, corev1.EphemeralContainer{
+		EphemeralContainerCommon: corev1.EphemeralContainerCommon{
+			Name:            containerName,
+			Image:           e.options.DebugImage,
+			Command:         WrapHostCommand(e.options.HostEntry, command),
+			SecurityContext: &corev1.SecurityContext{Privileged: &privileged},
+		},
+	})
+
+	e.logger.Debug(fmt.Sprintf("Adding ephemeral container %s to pod %s/%s: %s", containerName, namespace, pod.Name, command))
+	if _, err := pods.UpdateEphemeralContainers(ctx, pod.Name, pod, metav1.UpdateOptions{}); err != nil {
+		return "", 0, fmt.Errorf("ephemeral container %s in pod %s/%s failed: %w", containerName, namespace, pod.Name, err)
+	}
+
+	var exitCode int32
+	err = e.waitFor(ctx, fmt.Sprintf("ephemeral container %s to complete", containerName), func() (bool, error) {
+		current, err := pods.Get(ctx, pod.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		for _, status := range current.Status.EphemeralContainerStatuses {
+			if status.Name == containerName && status.State.Terminated != nil {
+				exitCode = status.State.Terminated.ExitCode
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+	if err != nil {
+		return "", 0, err
+	}
+
+	logs, err := pods.GetLogs(pod.Name, &corev1.PodLogOptions{Container: containerName}).DoRaw(ctx)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to get logs from ephemeral container %s: %w", containerName, err)
+	}
+	return strings.TrimSpace(string(logs)), exitCode, nil
+}
+
+// terminatedExitCode returns the exit code of a terminated container, falling back to the pod phase
+func terminatedExitCode(statuses []corev1.ContainerStatus, containerName string, phase corev1.PodPhase) int32 {
+	for _, status := range statuses {
+		if status.Name == containerName && status.State.Terminated != nil {
+			return status.State.Terminated.ExitCode
+		}
+	}
+	if phase == corev1.PodFailed {
+		return 1
+	}
+	return 0
+}
+
+// waitFor polls a condition until it is met, fails or the context expires
+func (e *NativeExecutor) waitFor(ctx context.Context, what string, condition func() (bool, error)) error {
+	for {
+		done, err := condition()
+		if err != nil {
+			return fmt.Errorf("failed waiting for %s: %w", what, err)
+		}
+		if done {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timeout waiting for %s", what)
+		case <-time.After(e.pollingInterval):
+		}
+	}
+}
@@ -0,0 +1,370 @@
+// Package kubectl provides unit tests for the client-go executor
+// WHY: The native client must be a drop-in replacement for kubectl output consumed by the services
+package kubectl
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"k8ostack-ictl/internal/logging"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// newTestNode builds a node object with labels
+func newTestNode(name string, labels map[string]string) *corev1.Node {
+	return &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+}
+
+// newTestNativeExecutor creates a native executor around a fake clientset
+func newTestNativeExecutor(objects ...runtime.Object) (*NativeExecutor, *fake.Clientset) {
+	client := fake.NewSimpleClientset(objects...)
+	executor := NewNativeExecutorWithClient(logging.NewRecordingLogger(), client, "default", ExecutorOptions{}).(*NativeExecutor)
+	executor.SetPollingInterval(0)
+	return executor, client
+}
+
+// TestValidateClient tests client implementation name validation
+// WHY: A typo in --client must fail fast instead of silently using kubectl
+func TestValidateClient(t *testing.T) {
+	assert.NoError(t, ValidateClient(""))
+	assert.NoError(t, ValidateClient(ClientKubectl))
+	assert.NoError(t, ValidateClient(ClientNative))
+
+	err := ValidateClient("grpc")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported client 'grpc'")
+}
+
+// TestNativeExecutor_NodeLabels tests label get, apply and removal through the API
+// WHY: Output must stay parseable by ParseNodeLabels and labeler verification
+func TestNativeExecutor_NodeLabels(t *testing.T) {
+	ctx := context.Background()
+	executor, client := newTestNativeExecutor(newTestNode("rsb2", map[string]string{"openstack-role": "compute"}))
+
+	// Given: Node with an existing label
+	success, output, err := executor.GetNodeLabels(ctx, "rsb2")
+	require.NoError(t, err)
+	assert.True(t, success)
+	assert.Equal(t, map[string]string{"openstack-role": "compute"}, ParseNodeLabels(output))
+
+	// When: Label without overwrite conflicts with existing value
+	_, _, err = executor.LabelNode(ctx, "rsb2", "openstack-role=storage", false)
+
+	// Then: Refused like kubectl without --overwrite
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "already has a value (compute)")
+
+	// When: Overwrite and add labels
+	_, _, err = executor.LabelNode(ctx, "rsb2", "openstack-role=storage", true)
+	require.NoError(t, err)
+	success, output, err = executor.LabelNode(ctx, "rsb2", "ceph-node=enabled", false)
+	require.NoError(t, err)
+	assert.True(t, success)
+	assert.Equal(t, "node/rsb2 labeled", output)
+
+	node, err := client.CoreV1().Nodes().Get(ctx, "rsb2", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"openstack-role": "storage", "ceph-node": "enabled"}, node.Labels)
+
+	// When: Remove a label
+	_, _, err = executor.UnlabelNode(ctx, "rsb2", "ceph-node")
+	require.NoError(t, err)
+
+	// Then: Only the remaining label is listed
+	_, output, err = executor.GetNodeLabels(ctx, "rsb2")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"openstack-role": "storage"}, ParseNodeLabels(output))
+}
+
+// TestNativeExecutor_LabelNodes tests patching several nodes with one label set
+// WHY: A conflict must stop the call on the node it hit so callers can fall back to per-node labeling
+func TestNativeExecutor_LabelNodes(t *testing.T) {
+	tests := []struct {
+		name           string
+		overwrite      bool
+		expectedOutput string
+		expectedNode   string
+		expectedLabels map[string]string
+	}{
+		{
+			name:           "overwrite_labels_every_node",
+			overwrite:      true,
+			expectedOutput: "node/rsb2 labeled\nnode/rsb3 labeled\nnode/rsb4 labeled",
+			expectedLabels: map[string]string{"role": "compute", "ceph-node": "enabled"},
+		},
+		{
+			name:           "conflict_stops_at_node",
+			overwrite:      false,
+			expectedOutput: "node/rsb2 labeled",
+			expectedNode:   "rsb3",
+			expectedLabels: map[string]string{"role": "storage"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: Three nodes, one with a conflicting role label
+			ctx := context.Background()
+			executor, client := newTestNativeExecutor(
+				newTestNode("rsb2", nil),
+				newTestNode("rsb3", map[string]string{"role": "storage"}),
+				newTestNode("rsb4", nil),
+			)
+
+			// When: Label all of them
+			success, output, err := executor.LabelNodes(ctx, []string{"rsb2", "rsb3", "rsb4"},
+				map[string]string{"role": "compute", "ceph-node": "enabled"}, tt.overwrite)
+
+			// Then: Labeled nodes are listed and a failure names its node
+			assert.Equal(t, tt.expectedOutput, output)
+			if tt.expectedNode != "" {
+				require.Error(t, err)
+				assert.False(t, success)
+				assert.Equal(t, tt.expectedNode, NodeOf(err))
+				assert.Contains(t, err.Error(), "already has a value (storage)")
+			} else {
+				require.NoError(t, err)
+				assert.True(t, success)
+			}
+			node, err := client.CoreV1().Nodes().Get(ctx, "rsb3", metav1.GetOptions{})
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedLabels, node.Labels)
+		})
+	}
+}
+
+// TestNativeExecutor_DryRun tests that mutations are simulated in dry-run mode
+// WHY: Dry-run must never reach the API server with writes
+func TestNativeExecutor_DryRun(t *testing.T) {
+	ctx := context.Background()
+	executor, client := newTestNativeExecutor(newTestNode("rsb2", nil))
+	executor.SetDryRun(true)
+
+	_, _, err := executor.LabelNode(ctx, "rsb2", "openstack-role=compute", false)
+	require.NoError(t, err)
+	_, _, err = executor.DeletePod(ctx, "node-debugger-rsb2-abcde")
+	require.NoError(t, err)
+	success, output, err := executor.ExecNodeCommand(ctx, "rsb2", "ip link show")
+	require.NoError(t, err)
+	assert.True(t, success)
+	assert.Contains(t, output, "Command would be executed on node rsb2")
+
+	for _, action := range client.Actions() {
+		assert.Contains(t, []string{"get", "list"}, action.GetVerb(), "dry-run should only read, got %s %s", action.GetVerb(), action.GetResource().Resource)
+	}
+}
+
+// TestNativeExecutor_TypedErrors tests that API errors are wrapped, not flattened into strings
+// WHY: Callers can inspect the failure reason with apierrors helpers
+func TestNativeExecutor_TypedErrors(t *testing.T) {
+	executor, _ := newTestNativeExecutor()
+
+	success, _, err := executor.GetNode(context.Background(), "missing")
+
+	assert.False(t, success)
+	require.Error(t, err)
+	assert.True(t, apierrors.IsNotFound(err), "expected a NotFound API error, got %v", err)
+}
+
+// TestNativeExecutor_GetNodeInternalIP tests reading the InternalIP from the node status
+// WHY: VLAN interface detection looks for the interface carrying this address
+func TestNativeExecutor_GetNodeInternalIP(t *testing.T) {
+	withAddresses := newTestNode("rsb2", nil)
+	withAddresses.Status.Addresses = []corev1.NodeAddress{
+		{Type: corev1.NodeHostName, Address: "rsb2"},
+		{Type: corev1.NodeInternalIP, Address: "10.0.0.12"},
+	}
+	executor, _ := newTestNativeExecutor(withAddresses, newTestNode("rsb3", nil))
+
+	tests := []struct {
+		name      string
+		node      string
+		expectIP  string
+		expectErr string
+	}{
+		{name: "internal_ip_reported", node: "rsb2", expectIP: "10.0.0.12"},
+		{name: "no_internal_ip", node: "rsb3", expectErr: "reports no InternalIP"},
+		{name: "missing_node", node: "rsb9", expectErr: "failed to get node rsb9"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// When: Reading the InternalIP
+			success, address, err := executor.GetNodeInternalIP(context.Background(), tt.node)
+
+			// Then: The address or a descriptive error is returned
+			if tt.expectErr != "" {
+				assert.False(t, success)
+				assert.ErrorContains(t, err, tt.expectErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, success)
+			assert.Equal(t, tt.expectIP, address)
+		})
+	}
+}
+
+// TestNativeExecutor_ListNodesAndPods tests list output formatting
+// WHY: Node and pod listings are parsed as `-o name` output by the services
+func TestNativeExecutor_ListNodesAndPods(t *testing.T) {
+	ctx := context.Background()
+	executor, _ := newTestNativeExecutor(
+		newTestNode("rsb2", map[string]string{"openstack-role": "compute"}),
+		newTestNode("rsb3", map[string]string{"openstack-role": "storage"}),
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "node-debugger-rsb2-x1", Namespace: "default"}},
+	)
+
+	_, output, err := executor.GetAllNodes(ctx)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"rsb2", "rsb3"}, ParseNodeNames(output))
+
+	_, output, err = executor.GetNodesByLabel(ctx, "openstack-role=storage")
+	require.NoError(t, err)
+	assert.Equal(t, "node/rsb3", output)
+
+	_, output, err = executor.GetPods(ctx, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, "pod/node-debugger-rsb2-x1", output)
+
+	role, err := executor.GetNodeRole(ctx, "rsb3")
+	require.NoError(t, err)
+	assert.Equal(t, "storage", role)
+}
+
+// completePodsOnCreate makes the fake API server finish node command pods immediately with an exit code
+func completePodsOnCreate(client *fake.Clientset, exitCode int32) {
+	client.PrependReactor("create", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		pod := action.(k8stesting.CreateAction).GetObject().(*corev1.Pod)
+		pod.Status.Phase = corev1.PodSucceeded
+		if exitCode != 0 {
+			pod.Status.Phase = corev1.PodFailed
+		}
+		pod.Status.ContainerStatuses = []corev1.ContainerStatus{{
+			Name:  "debugger",
+			State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: exitCode}},
+		}}
+		return false, nil, nil
+	})
+}
+
+// TestNativeExecutor_ExecNodeCommand tests node command execution through a host pod
+// WHY: The pod must reach the host like `kubectl debug node` and be cleaned up afterwards
+func TestNativeExecutor_ExecNodeCommand(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		// Given: API server that completes pods successfully
+		ctx := context.Background()
+		executor, client := newTestNativeExecutor(newTestNode("rsb2", nil))
+		completePodsOnCreate(client, 0)
+
+		// When: Execute a command
+		success, output, err := executor.ExecNodeCommand(ctx, "rsb2", "ip link show")
+
+		// Then: Logs are returned and the pod is deleted
+		require.NoError(t, err)
+		assert.True(t, success)
+		assert.Equal(t, "fake logs", output)
+
+		var created *corev1.Pod
+		deleted := false
+		for _, action := range client.Actions() {
+			if action.GetVerb() == "create" && action.GetResource().Resource == "pods" {
+				created = action.(k8stesting.CreateAction).GetObject().(*corev1.Pod)
+			}
+			if action.GetVerb() == "delete" && action.GetResource().Resource == "pods" {
+				deleted = true
+			}
+		}
+		require.NotNil(t, created)
+		assert.Equal(t, "rsb2", created.Spec.NodeName)
+		assert.True(t, created.Spec.HostNetwork)
+		assert.True(t, strings.HasPrefix(created.Name, "node-debugger-rsb2-"))
+		assert.Equal(t, WrapHostCommand(HostEntryChroot, "ip link show"), created.Spec.Containers[0].Command)
+		assert.True(t, deleted, "node command pod should be deleted")
+	})
+
+	t.Run("missing_binary", func(t *testing.T) {
+		// Given: Command exits with 127
+		executor, client := newTestNativeExecutor(newTestNode("rsb2", nil))
+		completePodsOnCreate(client, 127)
+
+		// When: Execute
+		success, _, err := executor.ExecNodeCommand(context.Background(), "rsb2", "nmcli device")
+
+		// Then: Classified from the exit code
+		assert.False(t, success)
+		var hostErr *HostCommandError
+		require.True(t, errors.As(err, &hostErr))
+		assert.Equal(t, HostErrorNotFound, hostErr.Class)
+	})
+
+	t.Run("create_forbidden", func(t *testing.T) {
+		// Given: RBAC forbids pod creation
+		executor, client := newTestNativeExecutor(newTestNode("rsb2", nil))
+		client.PrependReactor("create", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			return true, nil, apierrors.NewForbidden(corev1.Resource("pods"), "", errors.New("no create permission"))
+		})
+
+		// When: Execute
+		_, _, err := executor.ExecNodeCommand(context.Background(), "rsb2", "ip link show")
+
+		// Then: Transport failure that keeps the API error
+		var hostErr *HostCommandError
+		require.True(t, errors.As(err, &hostErr))
+		assert.Equal(t, HostErrorTransport, hostErr.Class)
+		assert.True(t, apierrors.IsForbidden(err))
+	})
+}
+
+// TestNativeExecutor_EphemeralBackend tests node commands through an ephemeral container
+// WHY: Restricted clusters only grant ephemeral container updates on existing host pods
+func TestNativeExecutor_EphemeralBackend(t *testing.T) {
+	// Given: Host pod on the node and an API server that finishes ephemeral containers immediately
+	hostPod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "kube-proxy-abc", Namespace: "kube-system", Labels: map[string]string{"k8s-app": "kube-proxy"}},
+		Spec:       corev1.PodSpec{NodeName: "rsb2"},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	client := fake.NewSimpleClientset(hostPod)
+	client.PrependReactor("update", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "ephemeralcontainers" {
+			return false, nil, nil
+		}
+		pod := action.(k8stesting.UpdateAction).GetObject().(*corev1.Pod)
+		for _, container := range pod.Spec.EphemeralContainers {
+			pod.Status.EphemeralContainerStatuses = append(pod.Status.EphemeralContainerStatuses, corev1.ContainerStatus{
+				Name:  container.Name,
+				State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 0}},
+			})
+		}
+		return false, nil, nil
+	})
+	executor := NewNativeExecutorWithClient(logging.NewRecordingLogger(), client, "default", ExecutorOptions{NodeExecBackend: NodeExecBackendEphemeral})
+	executor.SetPollingInterval(0)
+
+	// When: Execute a command
+	success, output, err := executor.ExecNodeCommand(context.Background(), "rsb2", "ip link show")
+
+	// Then: Ephemeral container added to the host pod, no pod created
+	require.NoError(t, err)
+	assert.True(t, success)
+	assert.Equal(t, "fake logs", output)
+
+	updated, err := client.CoreV1().Pods("kube-system").Get(context.Background(), "kube-proxy-abc", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Len(t, updated.Spec.EphemeralContainers, 1)
+	assert.Equal(t, WrapHostCommand(HostEntryNone, "ip link show"), updated.Spec.EphemeralContainers[0].Command)
+	for _, action := range client.Actions() {
+		assert.NotEqual(t, "create", action.GetVerb(), "ephemeral backend must not create pods")
+	}
+}
@@ -0,0 +1,228 @@
+package kubectl
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// maxNodeSuggestions limits how many "did you mean" candidates are reported
+const maxNodeSuggestions = 3
+
+// NodeNotFoundError reports a node missing from the cluster with close matches from the node list
+type NodeNotFoundError struct {
+	NodeName    string
+	Suggestions []string
+	Cause       error
+}
+
+func (e *NodeNotFoundError) Error() string {
+	msg := fmt.Sprintf("node %s does not exist in the cluster", e.NodeName)
+	if len(e.Suggestions) > 0 {
+		msg += fmt.Sprintf(" (did you mean %s?)", strings.Join(e.Suggestions, " or "))
+	}
+	if e.Cause != nil {
+		msg += fmt.Sprintf(": %v", e.Cause)
+	}
+	return msg
+}
+
+func (e *NodeNotFoundError) Unwrap() error {
+	return e.Cause
+}
+
+// NodeError attributes an operation error to the node it happened on without changing its message
+type NodeError struct {
+	Node string
+	Err  error
+}
+
+func (e *NodeError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *NodeError) Unwrap() error {
+	return e.Err
+}
+
+// WrapNodeError attributes err to a node, leaving nil and already attributed errors unchanged
+func WrapNodeError(nodeName string, err error) error {
+	if err == nil || NodeOf(err) != "" {
+		return err
+	}
+	return &NodeError{Node: nodeName, Err: err}
+}
+
+// NodeOf returns the node an error is attributed to, or an empty string when it is unknown
+func NodeOf(err error) string {
+	var nodeErr *NodeError
+	if errors.As(err, &nodeErr) {
+		return nodeErr.Node
+	}
+	var notFound *NodeNotFoundError
+	if errors.As(err, &notFound) {
+		return notFound.NodeName
+	}
+	var hostErr *HostCommandError
+	if errors.As(err, &hostErr) {
+		return hostErr.Node
+	}
+	return ""
+}
+
+// NewNodeNotFoundError builds a NodeNotFoundError with suggestions from the cluster node names
+func NewNodeNotFoundError(nodeName string, clusterNodes []string, cause error) *NodeNotFoundError {
+	return &NodeNotFoundError{
+		NodeName:    nodeName,
+		Suggestions: SuggestNodeNames(nodeName, clusterNodes),
+		Cause:       cause,
+	}
+}
+
+// NodeNameCache lists the cluster node names once and shares them between suggestion lookups
+// Listing is best effort - a failing node listing simply yields no names, and so no suggestions
+type NodeNameCache struct {
+	executor Executor
+	once     sync.Once
+	names    []string
+}
+
+// NewNodeNameCache creates a node name cache listing nodes through executor on first use
+func NewNodeNameCache(executor Executor) *NodeNameCache {
+	return &NodeNameCache{executor: executor}
+}
+
+// Names returns the cluster node names, listing them on the first call only
+func (c *NodeNameCache) Names(ctx context.Context) []string {
+	c.once.Do(func() {
+		success, output, err := c.executor.GetAllNodes(ctx)
+		if err == nil && success {
+			c.names = ParseNodeNames(output)
+		}
+	})
+	return c.names
+}
+
+// ParseNodeNames parses `kubectl get nodes -o name` output into bare node names
+func ParseNodeNames(output string) []string {
+	var names []string
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		name := strings.TrimPrefix(strings.TrimSpace(line), "node/")
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// ParseNodeLabels parses `kubectl get node <name> --show-labels` output into a label map
+// The LABELS column is the last field of the node row, formatted as comma separated key=value pairs
+func ParseNodeLabels(output string) map[string]string {
+	labels := make(map[string]string)
+
+	// A header-only table means the node row is missing
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) < 2 {
+		return labels
+	}
+
+	fields := strings.Fields(lines[len(lines)-1])
+	if len(fields) == 0 || fields[len(fields)-1] == "<none>" {
+		return labels
+	}
+
+	for _, pair := range strings.Split(fields[len(fields)-1], ",") {
+		key, value, _ := strings.Cut(pair, "=")
+		if key != "" {
+			labels[key] = value
+		}
+	}
+	return labels
+}
+
+// ParseNodeAnnotations parses the JSON annotation map printed by GetNodeAnnotations
+func ParseNodeAnnotations(output string) (map[string]string, error) {
+	annotations := make(map[string]string)
+	output = strings.TrimSpace(output)
+	if output == "" {
+		return annotations, nil
+	}
+
+	if err := json.Unmarshal([]byte(output), &annotations); err != nil {
+		return nil, fmt.Errorf("invalid annotation output: %w", err)
+	}
+	return annotations, nil
+}
+
+// SuggestNodeNames returns the cluster node names closest to an unknown name
+// Candidates must be within an edit distance proportional to the name length
+func SuggestNodeNames(name string, candidates []string) []string {
+	maxDistance := len(name) / 3
+	if maxDistance < 2 {
+		maxDistance = 2
+	}
+
+	type match struct {
+		name     string
+		distance int
+	}
+
+	var matches []match
+	for _, candidate := range candidates {
+		if candidate == name {
+			continue
+		}
+		distance := editDistance(name, candidate)
+		if distance <= maxDistance {
+			matches = append(matches, match{candidate, distance})
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].distance != matches[j].distance {
+			return matches[i].distance < matches[j].distance
+		}
+		return matches[i].name < matches[j].name
+	})
+
+	var suggestions []string
+	for i := 0; i < len(matches) && i < maxNodeSuggestions; i++ {
+		suggestions = append(suggestions, matches[i].name)
+	}
+	return suggestions
+}
+
+// editDistance computes the Damerau-Levenshtein (optimal string alignment) distance
+// Transpositions count as a single edit so rbs2 -> rsb2 is distance 1
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	rows, cols := len(ra)+1, len(rb)+1
+
+	d := make([][]int, rows)
+	for i := range d {
+		d[i] = make([]int, cols)
+		d[i][0] = i
+	}
+	for j := 0; j < cols; j++ {
+		d[0][j] = j
+	}
+
+	for i := 1; i < rows; i++ {
+		for j := 1; j < cols; j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+
+	return d[rows-1][cols-1]
+}
@@ -0,0 +1,232 @@
+// Package kubectl provides unit tests for node name helpers
+// WHY: Typo suggestions turn an opaque "not found" into an actionable error message
+package kubectl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"k8ostack-ictl/internal/logging"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSuggestNodeNames tests nearest-match selection from the cluster node list
+// WHY: Suggestions must catch common typos without proposing unrelated nodes
+func TestSuggestNodeNames(t *testing.T) {
+	clusterNodes := []string{"rsb2", "rsb3", "rsb4", "rsb10", "storage-01"}
+
+	tests := []struct {
+		name        string
+		description string
+		nodeName    string
+		expected    []string
+	}{
+		{
+			name:        "transposition",
+			description: "Swapped characters should be a single edit",
+			nodeName:    "rbs2",
+			expected:    []string{"rsb2", "rsb3", "rsb4"},
+		},
+		{
+			name:        "missing_character",
+			description: "Dropped characters should match the intended node",
+			nodeName:    "storage-1",
+			expected:    []string{"storage-01"},
+		},
+		{
+			name:        "unrelated_name",
+			description: "Names far from every node should yield no suggestions",
+			nodeName:    "compute-node-99",
+			expected:    nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// When: Compute suggestions
+			suggestions := SuggestNodeNames(tt.nodeName, clusterNodes)
+
+			// Then: Verify ordering and content
+			assert.Equal(t, tt.expected, suggestions, tt.description)
+		})
+	}
+}
+
+// TestEditDistance tests the optimal string alignment distance
+// WHY: Suggestion ranking depends entirely on correct distances
+func TestEditDistance(t *testing.T) {
+	assert.Equal(t, 0, editDistance("rsb2", "rsb2"))
+	assert.Equal(t, 1, editDistance("rbs2", "rsb2"), "transposition should cost 1")
+	assert.Equal(t, 1, editDistance("rsb2", "rsb20"), "insertion should cost 1")
+	assert.Equal(t, 4, editDistance("", "rsb2"))
+}
+
+// TestParseNodeNames tests parsing of `kubectl get nodes -o name` output
+// WHY: Node lists come back prefixed and newline separated
+func TestParseNodeNames(t *testing.T) {
+	assert.Equal(t, []string{"rsb2", "rsb3"}, ParseNodeNames("node/rsb2\nnode/rsb3\n"))
+	assert.Nil(t, ParseNodeNames(""))
+}
+
+// TestParseNodeLabels tests parsing of the --show-labels LABELS column
+// WHY: Label cleanup decides what to remove from this parsed map
+func TestParseNodeLabels(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   string
+		expected map[string]string
+	}{
+		{
+			name: "labels_column",
+			output: `NAME   STATUS   ROLES    AGE   VERSION   LABELS
+rsb2   Ready    <none>   10d   v1.28.2   kubernetes.io/hostname=rsb2,openstack-role=compute,empty=`,
+			expected: map[string]string{
+				"kubernetes.io/hostname": "rsb2",
+				"openstack-role":         "compute",
+				"empty":                  "",
+			},
+		},
+		{
+			name: "no_labels",
+			output: `NAME   STATUS   ROLES    AGE   VERSION   LABELS
+rsb2   Ready    <none>   10d   v1.28.2   <none>`,
+			expected: map[string]string{},
+		},
+		{
+			name:     "header_only",
+			output:   "NAME   STATUS   ROLES    AGE   VERSION   LABELS",
+			expected: map[string]string{},
+		},
+		{
+			name:     "empty_output",
+			output:   "",
+			expected: map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ParseNodeLabels(tt.output))
+		})
+	}
+}
+
+// TestParseNodeAnnotations tests parsing of the jsonpath annotation map
+// WHY: Annotations feed node snapshots and must round-trip exactly
+func TestParseNodeAnnotations(t *testing.T) {
+	annotations, err := ParseNodeAnnotations(`{"node.alpha.kubernetes.io/ttl":"0","kictl.icycloud.io/revision":"3"}`)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"node.alpha.kubernetes.io/ttl": "0", "kictl.icycloud.io/revision": "3"}, annotations)
+
+	annotations, err = ParseNodeAnnotations("")
+	assert.NoError(t, err, "nodes without annotations print nothing")
+	assert.Empty(t, annotations)
+
+	_, err = ParseNodeAnnotations("map[ttl:0]")
+	assert.Error(t, err)
+}
+
+// TestNodeNotFoundError tests the error message and suggestion lookup
+// WHY: The message is what operators see when a config references a missing node
+func TestNodeNotFoundError(t *testing.T) {
+	t.Run("message_with_suggestions", func(t *testing.T) {
+		err := &NodeNotFoundError{NodeName: "rbs2", Suggestions: []string{"rsb2"}}
+		assert.Equal(t, "node rbs2 does not exist in the cluster (did you mean rsb2?)", err.Error())
+	})
+
+	t.Run("message_with_cause", func(t *testing.T) {
+		cause := fmt.Errorf("exit status 1")
+		err := &NodeNotFoundError{NodeName: "rbs2", Cause: cause}
+		assert.Equal(t, "node rbs2 does not exist in the cluster: exit status 1", err.Error())
+		assert.ErrorIs(t, err, cause)
+	})
+
+	t.Run("suggestions_from_cluster_nodes", func(t *testing.T) {
+		err := NewNodeNotFoundError("rbs2", []string{"ctl1", "rsb2"}, nil)
+		assert.Equal(t, []string{"rsb2"}, err.Suggestions)
+	})
+
+	t.Run("no_cluster_nodes_yields_no_suggestions", func(t *testing.T) {
+		err := NewNodeNotFoundError("rbs2", nil, nil)
+		assert.Empty(t, err.Suggestions)
+		assert.Contains(t, err.Error(), "node rbs2 does not exist")
+	})
+}
+
+// TestNodeNameCache tests that cluster node names are listed once per cache
+// WHY: A bundle full of misspelled nodes must not list the whole cluster once per missing node
+func TestNodeNameCache(t *testing.T) {
+	t.Run("lists_once", func(t *testing.T) {
+		// Given: A kubectl that counts its calls
+		bin := t.TempDir()
+		calls := filepath.Join(bin, "calls")
+		script := "#!/bin/sh\necho call >> " + calls + "\necho node/rsb2\necho node/rsb3\n"
+		require.NoError(t, os.WriteFile(filepath.Join(bin, "kubectl"), []byte(script), 0755))
+		t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+		cache := NewNodeNameCache(NewExecutor(logging.NewRecordingLogger()))
+
+		// When: Look names up twice
+		first := cache.Names(context.Background())
+		second := cache.Names(context.Background())
+
+		// Then: Both lookups share one listing
+		assert.Equal(t, []string{"rsb2", "rsb3"}, first)
+		assert.Equal(t, first, second)
+		data, err := os.ReadFile(calls)
+		require.NoError(t, err)
+		assert.Equal(t, "call\n", string(data))
+	})
+
+	t.Run("lookup_failure_yields_no_names", func(t *testing.T) {
+		// Given: A kubectl that cannot reach a cluster
+		bin := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(bin, "kubectl"), []byte("#!/bin/sh\nexit 1\n"), 0755))
+		t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+		cache := NewNodeNameCache(NewExecutor(logging.NewRecordingLogger()))
+
+		// When/Then: No names are returned
+		assert.Empty(t, cache.Names(context.Background()))
+	})
+}
+
+// TestNodeOf tests finding the node an error belongs to
+// WHY: The error summary groups failures by node, whatever error type the service returned
+func TestNodeOf(t *testing.T) {
+	cause := errors.New("exit status 1")
+
+	tests := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{name: "plain error", err: cause, expected: ""},
+		{name: "wrapped node error", err: fmt.Errorf("labeling: %w", WrapNodeError("rsb2", cause)), expected: "rsb2"},
+		{name: "node not found", err: &NodeNotFoundError{NodeName: "rsb9"}, expected: "rsb9"},
+		{name: "host command", err: &HostCommandError{Node: "rsb3", Class: HostErrorCommand, Cause: cause}, expected: "rsb3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, NodeOf(tt.err))
+		})
+	}
+}
+
+// TestWrapNodeError tests that attribution keeps the original error intact
+// WHY: Services wrap errors for the summary, and messages and errors.Is checks must not change
+func TestWrapNodeError(t *testing.T) {
+	cause := errors.New("exit status 1")
+	hostErr := &HostCommandError{Node: "rsb3", Class: HostErrorCommand, Cause: cause}
+
+	wrapped := WrapNodeError("rsb2", cause)
+	assert.Equal(t, cause.Error(), wrapped.Error())
+	assert.ErrorIs(t, wrapped, cause)
+	assert.Nil(t, WrapNodeError("rsb2", nil))
+	assert.Same(t, hostErr, WrapNodeError("rsb2", hostErr), "already attributed errors keep their node")
+}
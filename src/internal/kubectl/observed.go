@@ -0,0 +1,156 @@
+package kubectl
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// ObserveFunc receives the latency of an API call and whether the API server throttled it
+type ObserveFunc func(latency time.Duration, throttled bool)
+
+// observedExecutor reports every call of the wrapped executor to an ObserveFunc
+// Node commands run in pods whose runtime says nothing about the API server, so only their throttling is reported.
+type observedExecutor struct {
+	DryRunExecutor
+	observe ObserveFunc
+}
+
+// NewObservedExecutor wraps an executor so that observe sees the outcome of every call
+func NewObservedExecutor(executor DryRunExecutor, observe ObserveFunc) DryRunExecutor {
+	return &observedExecutor{DryRunExecutor: executor, observe: observe}
+}
+
+// IsThrottled reports whether a call failed because the API server rate limited it (HTTP 429)
+func IsThrottled(err error, output string) bool {
+	if err == nil {
+		return false
+	}
+	if apierrors.IsTooManyRequests(err) {
+		return true
+	}
+	message := err.Error() + "\n" + output
+	return strings.Contains(message, "TooManyRequests") || strings.Contains(message, "too many requests")
+}
+
+// apiCall times a call against the API server
+func (e *observedExecutor) apiCall(call func() (bool, string, error)) (bool, string, error) {
+	start := time.Now()
+	success, output, err := call()
+	e.observe(time.Since(start), IsThrottled(err, output))
+	return success, output, err
+}
+
+// nodeCall runs a node command, reporting only whether it was throttled
+func (e *observedExecutor) nodeCall(call func() (bool, string, error)) (bool, string, error) {
+	success, output, err := call()
+	e.observe(0, IsThrottled(err, output))
+	return success, output, err
+}
+
+// GetNode retrieves information about a specific node
+func (e *observedExecutor) GetNode(ctx context.Context, nodeName string) (bool, string, error) {
+	return e.apiCall(func() (bool, string, error) { return e.DryRunExecutor.GetNode(ctx, nodeName) })
+}
+
+// LabelNode applies a label to a node
+func (e *observedExecutor) LabelNode(ctx context.Context, nodeName, label string, overwrite bool) (bool, string, error) {
+	return e.apiCall(func() (bool, string, error) { return e.DryRunExecutor.LabelNode(ctx, nodeName, label, overwrite) })
+}
+
+// LabelNodes applies the same labels to several nodes
+func (e *observedExecutor) LabelNodes(ctx context.Context, nodeNames []string, labels map[string]string, overwrite bool) (bool, string, error) {
+	return e.apiCall(func() (bool, string, error) { return e.DryRunExecutor.LabelNodes(ctx, nodeNames, labels, overwrite) })
+}
+
+// UnlabelNode removes a label from a node
+func (e *observedExecutor) UnlabelNode(ctx context.Context, nodeName, labelKey string) (bool, string, error) {
+	return e.apiCall(func() (bool, string, error) { return e.DryRunExecutor.UnlabelNode(ctx, nodeName, labelKey) })
+}
+
+// GetNodeLabels retrieves all labels for a specific node
+func (e *observedExecutor) GetNodeLabels(ctx context.Context, nodeName string) (bool, string, error) {
+	return e.apiCall(func() (bool, string, error) { return e.DryRunExecutor.GetNodeLabels(ctx, nodeName) })
+}
+
+// GetNodeAnnotations retrieves all annotations for a specific node as JSON
+func (e *observedExecutor) GetNodeAnnotations(ctx context.Context, nodeName string) (bool, string, error) {
+	return e.apiCall(func() (bool, string, error) { return e.DryRunExecutor.GetNodeAnnotations(ctx, nodeName) })
+}
+
+// GetNodeInternalIP retrieves the InternalIP address the node reports
+func (e *observedExecutor) GetNodeInternalIP(ctx context.Context, nodeName string) (bool, string, error) {
+	return e.apiCall(func() (bool, string, error) { return e.DryRunExecutor.GetNodeInternalIP(ctx, nodeName) })
+}
+
+// ExecNodeCommand executes a command on a specific node
+func (e *observedExecutor) ExecNodeCommand(ctx context.Context, nodeName, command string) (bool, string, error) {
+	return e.nodeCall(func() (bool, string, error) { return e.DryRunExecutor.ExecNodeCommand(ctx, nodeName, command) })
+}
+
+// GetPods retrieves pods with optional filtering
+func (e *observedExecutor) GetPods(ctx context.Context, fieldSelector, labelSelector string) (bool, string, error) {
+	return e.apiCall(func() (bool, string, error) { return e.DryRunExecutor.GetPods(ctx, fieldSelector, labelSelector) })
+}
+
+// DeletePod deletes a specific pod
+func (e *observedExecutor) DeletePod(ctx context.Context, podName string) (bool, string, error) {
+	return e.apiCall(func() (bool, string, error) { return e.DryRunExecutor.DeletePod(ctx, podName) })
+}
+
+// GetAllNodes retrieves all nodes in the cluster
+func (e *observedExecutor) GetAllNodes(ctx context.Context) (bool, string, error) {
+	return e.apiCall(func() (bool, string, error) { return e.DryRunExecutor.GetAllNodes(ctx) })
+}
+
+// GetNodesByLabel retrieves the nodes matching a label selector
+func (e *observedExecutor) GetNodesByLabel(ctx context.Context, labelSelector string) (bool, string, error) {
+	return e.apiCall(func() (bool, string, error) { return e.DryRunExecutor.GetNodesByLabel(ctx, labelSelector) })
+}
+
+// GetNodeRole determines the role of a node
+func (e *observedExecutor) GetNodeRole(ctx context.Context, nodeName string) (string, error) {
+	start := time.Now()
+	role, err := e.DryRunExecutor.GetNodeRole(ctx, nodeName)
+	e.observe(time.Since(start), IsThrottled(err, ""))
+	return role, err
+}
+
+// DiscoverClusterState gathers the cluster state in several calls, reporting only whether one was throttled
+func (e *observedExecutor) DiscoverClusterState(ctx context.Context) (map[string]interface{}, error) {
+	state, err := e.DryRunExecutor.DiscoverClusterState(ctx)
+	e.observe(0, IsThrottled(err, ""))
+	return state, err
+}
+
+// DiscoverNodeVLANs discovers the VLAN interfaces of a node
+func (e *observedExecutor) DiscoverNodeVLANs(ctx context.Context, nodeName string) (bool, string, error) {
+	return e.nodeCall(func() (bool, string, error) { return e.DryRunExecutor.DiscoverNodeVLANs(ctx, nodeName) })
+}
+
+// DiscoverAllVLANs discovers the VLAN interfaces of every node
+func (e *observedExecutor) DiscoverAllVLANs(ctx context.Context) (map[string]string, error) {
+	vlans, err := e.DryRunExecutor.DiscoverAllVLANs(ctx)
+	e.observe(0, IsThrottled(err, ""))
+	return vlans, err
+}
+
+// GetNodeNetworkInfo retrieves the network configuration of a node
+func (e *observedExecutor) GetNodeNetworkInfo(ctx context.Context, nodeName string) (bool, string, error) {
+	return e.nodeCall(func() (bool, string, error) { return e.DryRunExecutor.GetNodeNetworkInfo(ctx, nodeName) })
+}
+
+// GetNodeHardwareInfo retrieves the hardware information of a node
+func (e *observedExecutor) GetNodeHardwareInfo(ctx context.Context, nodeName string) (bool, string, error) {
+	return e.nodeCall(func() (bool, string, error) { return e.DryRunExecutor.GetNodeHardwareInfo(ctx, nodeName) })
+}
+
+// RunKubectl runs a kubectl command, timing it like any other API call
+func (e *observedExecutor) RunKubectl(ctx context.Context, stdin []byte, args ...string) (string, error) {
+	start := time.Now()
+	output, err := e.DryRunExecutor.RunKubectl(ctx, stdin, args...)
+	e.observe(time.Since(start), IsThrottled(err, output))
+	return output, err
+}
@@ -0,0 +1,74 @@
+// Package kubectl provides unit tests for the observed executor
+// WHY: Adaptive concurrency only works if every API call reports its latency and 429s
+package kubectl
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// TestIsThrottled tests recognising rate limited calls from both clients
+// WHY: client-go returns typed errors while kubectl only prints the reason
+func TestIsThrottled(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		output   string
+		expected bool
+	}{
+		{"no_error", nil, "TooManyRequests", false},
+		{"client_go_429", apierrors.NewTooManyRequests("slow down", 1), "", true},
+		{"kubectl_output", errors.New("exit status 1"), "Error from server (TooManyRequests): the server has received too many requests and has asked us to try again later", true},
+		{"wrapped_message", errors.New("failed to get node rsb2: the server has received too many requests"), "", true},
+		{"other_error", errors.New("nodes \"rsb2\" not found"), "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A call outcome
+
+			// When: Check for throttling
+			throttled := IsThrottled(tt.err, tt.output)
+
+			// Then: Only rate limited calls count
+			assert.Equal(t, tt.expected, throttled)
+		})
+	}
+}
+
+// TestObservedExecutor tests reporting call outcomes to the observer
+// WHY: Throttled calls must reach the observer while results pass through unchanged
+func TestObservedExecutor(t *testing.T) {
+	// Given: A native executor whose API server throttles label patches
+	inner, client := newTestNativeExecutor(newTestNode("rsb2", nil))
+	client.PrependReactor("patch", "nodes", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewTooManyRequests("slow down", 1)
+	})
+	var throttled []bool
+	executor := NewObservedExecutor(inner, func(latency time.Duration, wasThrottled bool) {
+		assert.GreaterOrEqual(t, latency, time.Duration(0))
+		throttled = append(throttled, wasThrottled)
+	})
+
+	// When: Get the node, then label it alone and in bulk
+	success, output, err := executor.GetNode(context.Background(), "rsb2")
+	_, _, labelErr := executor.LabelNode(context.Background(), "rsb2", "role=compute", true)
+	_, _, bulkErr := executor.LabelNodes(context.Background(), []string{"rsb2"}, map[string]string{"role": "compute"}, true)
+
+	// Then: Every call is observed, only the patches as throttled
+	require.NoError(t, err)
+	assert.True(t, success)
+	assert.Equal(t, "node/rsb2", output)
+	require.Error(t, labelErr)
+	require.Error(t, bulkErr)
+	assert.Equal(t, []bool{false, true, true}, throttled)
+	assert.False(t, executor.IsDryRun())
+}
@@ -0,0 +1,69 @@
+package kubectl
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrOfflineViolation marks anything that would reach a host other than the cluster API in offline mode
+var ErrOfflineViolation = errors.New("offline mode violation")
+
+// offlineViolation wraps ErrOfflineViolation with the offending detail
+func offlineViolation(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrOfflineViolation, fmt.Sprintf(format, args...))
+}
+
+// CheckOfflineKubeconfig ensures the kubeconfig context of the target only talks to its API server
+// Credential plugins and auth providers contact identity services, so they are refused.
+// An empty target uses the default loading rules (KUBECONFIG, then ~/.kube/config) and their current context.
+// It returns the API server the run is limited to, or "" when no kubeconfig context is set (in-cluster).
+func CheckOfflineKubeconfig(target ClusterTarget) (string, error) {
+	rawConfig, err := target.ClientConfig().RawConfig()
+	if err != nil {
+		return "", fmt.Errorf("failed to load kubeconfig for offline check: %w", err)
+	}
+
+	currentContext := rawConfig.CurrentContext
+	if target.Context != "" {
+		currentContext = target.Context
+	}
+	if currentContext == "" {
+		return "", nil
+	}
+	kubeContext, exists := rawConfig.Contexts[currentContext]
+	if !exists {
+		return "", fmt.Errorf("kubeconfig context %q not found", currentContext)
+	}
+
+	if authInfo, exists := rawConfig.AuthInfos[kubeContext.AuthInfo]; exists {
+		if authInfo.Exec != nil {
+			return "", offlineViolation("user %q runs credential plugin %q", kubeContext.AuthInfo, authInfo.Exec.Command)
+		}
+		if authInfo.AuthProvider != nil {
+			return "", offlineViolation("user %q uses auth provider %q", kubeContext.AuthInfo, authInfo.AuthProvider.Name)
+		}
+	}
+
+	cluster, exists := rawConfig.Clusters[kubeContext.Cluster]
+	if !exists || cluster.Server == "" {
+		return "", fmt.Errorf("kubeconfig context %q has no API server", currentContext)
+	}
+	return cluster.Server, nil
+}
+
+// CheckOfflinePath refuses remote locations for files kictl would otherwise read or write
+func CheckOfflinePath(flagName, path string) error {
+	if strings.Contains(path, "://") || strings.HasPrefix(path, "git::") {
+		return offlineViolation("--%s %s is a remote location", flagName, path)
+	}
+	return nil
+}
+
+// CheckOfflineSSH refuses node commands over SSH, which connect to the nodes instead of the API server
+func CheckOfflineSSH(nodes []string) error {
+	if len(nodes) > 0 {
+		return offlineViolation("--ssh-nodes %s connects to the nodes over SSH", strings.Join(nodes, ","))
+	}
+	return nil
+}
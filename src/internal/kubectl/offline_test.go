@@ -0,0 +1,155 @@
+package kubectl
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCheckOfflineKubeconfig tests which kubeconfig users are allowed in offline mode
+// WHY: Credential plugins and auth providers talk to identity services outside the cluster
+func TestCheckOfflineKubeconfig(t *testing.T) {
+	const header = `apiVersion: v1
+kind: Config
+clusters:
+- name: lab
+  cluster:
+    server: https://10.0.0.10:6443
+contexts:
+- name: lab
+  context:
+    cluster: lab
+    user: admin
+current-context: lab
+users:
+- name: admin
+  user:
+`
+	tests := []struct {
+		name           string
+		kubeconfig     string
+		expectedServer string
+		violation      bool
+		errorContains  string
+	}{
+		{
+			name:           "token_user_allowed",
+			kubeconfig:     header + "    token: abc123\n",
+			expectedServer: "https://10.0.0.10:6443",
+		},
+		{
+			name: "exec_plugin_refused",
+			kubeconfig: header + `    exec:
+      apiVersion: client.authentication.k8s.io/v1beta1
+      command: aws
+      args: ["eks", "get-token"]
+`,
+			violation:     true,
+			errorContains: `credential plugin "aws"`,
+		},
+		{
+			name: "auth_provider_refused",
+			kubeconfig: header + `    auth-provider:
+      name: oidc
+      config:
+        idp-issuer-url: https://sso.example.com
+`,
+			violation:     true,
+			errorContains: `auth provider "oidc"`,
+		},
+		{
+			name:       "no_current_context",
+			kubeconfig: "apiVersion: v1\nkind: Config\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: Kubeconfig file
+			path := filepath.Join(t.TempDir(), "config")
+			require.NoError(t, os.WriteFile(path, []byte(tt.kubeconfig), 0600))
+
+			// When: Check it for offline use
+			server, err := CheckOfflineKubeconfig(ClusterTarget{Kubeconfig: path})
+
+			// Then: Only the API server is reachable
+			if tt.violation {
+				require.Error(t, err)
+				assert.ErrorIs(t, err, ErrOfflineViolation)
+				assert.Contains(t, err.Error(), tt.errorContains)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedServer, server)
+		})
+	}
+}
+
+// TestCheckOfflineKubeconfig_Context tests that the context selected with --context is the one checked
+// WHY: Checking the current context while the run uses another would let a credential plugin through
+func TestCheckOfflineKubeconfig_Context(t *testing.T) {
+	// Given: Kubeconfig whose non-current context runs a credential plugin
+	path := filepath.Join(t.TempDir(), "config")
+	require.NoError(t, os.WriteFile(path, []byte(`apiVersion: v1
+kind: Config
+clusters:
+- name: lab
+  cluster:
+    server: https://10.0.0.10:6443
+contexts:
+- name: lab
+  context:
+    cluster: lab
+    user: admin
+- name: cloud
+  context:
+    cluster: lab
+    user: sso
+current-context: lab
+users:
+- name: admin
+  user:
+    token: abc123
+- name: sso
+  user:
+    exec:
+      apiVersion: client.authentication.k8s.io/v1beta1
+      command: aws
+`), 0600))
+
+	// When/Then: The current context passes, the selected one is refused, an unknown one is reported
+	_, err := CheckOfflineKubeconfig(ClusterTarget{Kubeconfig: path})
+	assert.NoError(t, err)
+
+	_, err = CheckOfflineKubeconfig(ClusterTarget{Kubeconfig: path, Context: "cloud"})
+	assert.ErrorIs(t, err, ErrOfflineViolation)
+
+	_, err = CheckOfflineKubeconfig(ClusterTarget{Kubeconfig: path, Context: "missing"})
+	assert.ErrorContains(t, err, `kubeconfig context "missing" not found`)
+}
+
+// TestCheckOfflinePath tests rejection of remote file locations
+// WHY: A URL passed where kictl expects a file must never be fetched in offline mode
+func TestCheckOfflinePath(t *testing.T) {
+	assert.NoError(t, CheckOfflinePath("config", "configs/cluster.yaml"))
+	assert.NoError(t, CheckOfflinePath("config", ""))
+
+	err := CheckOfflinePath("config", "https://example.com/cluster.yaml")
+	assert.ErrorIs(t, err, ErrOfflineViolation)
+
+	err = CheckOfflinePath("config", "git::git@example.com:infra/bundles.git//cluster.yaml")
+	assert.ErrorIs(t, err, ErrOfflineViolation)
+}
+
+// TestCheckOfflineSSH tests refusing the SSH transport in offline mode
+// WHY: SSH connects to the nodes themselves, which offline mode promises never to do
+func TestCheckOfflineSSH(t *testing.T) {
+	assert.NoError(t, CheckOfflineSSH(nil))
+
+	err := CheckOfflineSSH([]string{"edge-*"})
+	assert.ErrorIs(t, err, ErrOfflineViolation)
+	assert.ErrorContains(t, err, "--ssh-nodes edge-*")
+}
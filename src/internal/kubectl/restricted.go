@@ -0,0 +1,136 @@
+package kubectl
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8ostack-ictl/internal/logging"
+)
+
+// DefaultAllowedCommands are the node commands permitted in restricted mode unless overridden
+var DefaultAllowedCommands = []string{"ip", "tc", "sysctl", "cat", "netplan"}
+
+// HostErrorRefused means restricted mode refused the command before it was sent to the node
+const HostErrorRefused = "refused"
+
+// alwaysAllowedCommands are shell builtins without side effects, used by services to tolerate failures
+var alwaysAllowedCommands = map[string]bool{"true": true}
+
+// CommandPolicy restricts node commands to an allowlist of executables
+type CommandPolicy struct {
+	allowed map[string]bool
+}
+
+// NewCommandPolicy creates a policy allowing only the given command names
+func NewCommandPolicy(commands []string) *CommandPolicy {
+	allowed := make(map[string]bool, len(commands))
+	for _, command := range commands {
+		if command = strings.TrimSpace(command); command != "" {
+			allowed[command] = true
+		}
+	}
+	return &CommandPolicy{allowed: allowed}
+}
+
+// AllowedCommands returns the sorted allowlist
+func (p *CommandPolicy) AllowedCommands() []string {
+	commands := make([]string, 0, len(p.allowed))
+	for command := range p.allowed {
+		commands = append(commands, command)
+	}
+	sort.Strings(commands)
+	return commands
+}
+
+// Check verifies that every command in a shell script is allowlisted
+// Scripts may only chain simple commands with &&, ||, ; or |; substitutions, redirections
+// and subshells are refused because they would run or write things the allowlist cannot see
+func (p *CommandPolicy) Check(script string) error {
+	commands, err := splitShellCommands(script)
+	if err != nil {
+		return err
+	}
+
+	for _, command := range commands {
+		fields := strings.Fields(command)
+		if len(fields) == 0 {
+			continue
+		}
+		name := strings.Trim(fields[0], `'"`)
+		if alwaysAllowedCommands[name] {
+			continue
+		}
+		// Absolute paths are not a way around the allowlist
+		if strings.Contains(name, "/") {
+			return fmt.Errorf("command '%s' must be given by name in restricted mode", name)
+		}
+		if !p.allowed[name] {
+			return fmt.Errorf("command '%s' is not allowed in restricted mode (allowed: %s)", name, strings.Join(p.AllowedCommands(), ", "))
+		}
+	}
+	return nil
+}
+
+// splitShellCommands splits a script into simple commands on unquoted &&, ||, ; and |
+func splitShellCommands(script string) ([]string, error) {
+	var commands []string
+	var current strings.Builder
+	inSingle, inDouble := false, false
+
+	flush := func() {
+		commands = append(commands, strings.TrimSpace(current.String()))
+		current.Reset()
+	}
+
+	runes := []rune(script)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case inSingle:
+			if r == '\'' {
+				inSingle = false
+			}
+		case r == '\'' && !inDouble:
+			inSingle = true
+		case r == '"':
+			inDouble = !inDouble
+		case r == '`' || (r == '$' && i+1 < len(runes) && runes[i+1] == '('):
+			return nil, fmt.Errorf("command substitution is not allowed in restricted mode")
+		case inDouble:
+			// Other characters are literal inside double quotes
+		case r == '>' || r == '<':
+			return nil, fmt.Errorf("redirection is not allowed in restricted mode")
+		case r == '(' || r == ')' || r == '{' || r == '}':
+			return nil, fmt.Errorf("subshells and command groups are not allowed in restricted mode")
+		case r == '&' && (i+1 >= len(runes) || runes[i+1] != '&'):
+			return nil, fmt.Errorf("background commands are not allowed in restricted mode")
+		case r == ';' || r == '|' || r == '&' || r == '\n':
+			flush()
+			if (r == '|' || r == '&') && i+1 < len(runes) && runes[i+1] == r {
+				i++
+			}
+			continue
+		}
+		current.WriteRune(r)
+	}
+
+	if inSingle || inDouble {
+		return nil, fmt.Errorf("unterminated quote in command")
+	}
+	flush()
+	return commands, nil
+}
+
+// checkCommandPolicy refuses a node command that restricted mode does not allow, even in dry-run
+func checkCommandPolicy(options ExecutorOptions, logger logging.Logger, nodeName, command string) error {
+	if !options.Restricted {
+		return nil
+	}
+
+	if err := NewCommandPolicy(options.AllowedCommands).Check(command); err != nil {
+		logger.Error(fmt.Sprintf("🔒 Refused command on node %s: %v", nodeName, err))
+		return &HostCommandError{Node: nodeName, Class: HostErrorRefused, Cause: err}
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+// Package kubectl provides unit tests for the restricted mode command allowlist
+// WHY: Least-privilege audits depend on kictl never sending a node command outside the allowlist
+package kubectl
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"k8ostack-ictl/internal/logging"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCommandPolicy_Check tests allowlist enforcement on generated scripts
+// WHY: Every command in a chained script must be checked, not just the first one
+func TestCommandPolicy_Check(t *testing.T) {
+	policy := NewCommandPolicy(DefaultAllowedCommands)
+
+	tests := []struct {
+		name          string
+		script        string
+		expectedError string
+	}{
+		{"single_command", "ip link show type vlan", ""},
+		{"vlan_setup_chain", "ip link add link eth0 name eth0.100 type vlan id 100 && ip addr add 192.168.1.10/24 dev eth0.100 && ip link set eth0.100 up", ""},
+		{"tolerated_failure", "ip link set eth0.100 down && ip link delete eth0.100 || true", ""},
+		{"pipeline", "cat /proc/net/vlan/config | ip -br link", ""},
+		{"quoted_metacharacters", "ip link show 'eth0; reboot'", ""},
+		{"sequence", "sysctl -w net.ipv4.ip_forward=1; tc qdisc show", ""},
+		{"not_allowlisted", "ping -c 3 10.0.0.1", "command 'ping' is not allowed"},
+		{"second_command_not_allowlisted", "ip link show && rm -rf /etc/netplan", "command 'rm' is not allowed"},
+		{"absolute_path", "/usr/bin/curl http://example.com", "must be given by name"},
+		{"command_substitution", "ip link show $(reboot)", "command substitution is not allowed"},
+		{"backtick_substitution", "ip link show `reboot`", "command substitution is not allowed"},
+		{"substitution_in_double_quotes", `ip link show "$(reboot)"`, "command substitution is not allowed"},
+		{"redirection", "cat /etc/shadow > /tmp/leak", "redirection is not allowed"},
+		{"subshell", "(reboot)", "subshells and command groups are not allowed"},
+		{"background", "ip monitor & reboot", "background commands are not allowed"},
+		{"unterminated_quote", "ip link show 'eth0", "unterminated quote"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Check(tt.script)
+			if tt.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+			}
+		})
+	}
+}
+
+// TestCommandPolicy_CustomAllowlist tests that the allowlist replaces the defaults
+// WHY: Teams extend the list for commands like ping only when they accept the extra privilege
+func TestCommandPolicy_CustomAllowlist(t *testing.T) {
+	policy := NewCommandPolicy([]string{"ping", " ip "})
+
+	assert.Equal(t, []string{"ip", "ping"}, policy.AllowedCommands())
+	assert.NoError(t, policy.Check("ping -c 3 10.0.0.1"))
+	assert.Error(t, policy.Check("sysctl -a"))
+}
+
+// TestExecNodeCommand_Restricted tests enforcement in the executor
+// WHY: Refusal must happen before dry-run simulation so previews show what would be blocked
+func TestExecNodeCommand_Restricted(t *testing.T) {
+	t.Run("refused_in_dry_run", func(t *testing.T) {
+		// Given: Restricted executor in dry-run mode
+		logger := logging.NewRecordingLogger()
+		executor := NewExecutorWithOptions(logger, ExecutorOptions{Restricted: true})
+		executor.SetDryRun(true)
+
+		// When: Execute a command outside the allowlist
+		success, _, err := executor.ExecNodeCommand(context.Background(), "rsb2", "echo hello")
+
+		// Then: Refused without simulating it
+		assert.False(t, success)
+		var hostErr *HostCommandError
+		require.True(t, errors.As(err, &hostErr))
+		assert.Equal(t, HostErrorRefused, hostErr.Class)
+		assert.Contains(t, err.Error(), "command 'echo' is not allowed")
+		assert.NotContains(t, strings.Join(logger.Messages(logging.LevelDebug), " "), "DRY RUN")
+	})
+
+	t.Run("allowed_in_dry_run", func(t *testing.T) {
+		// Given: Restricted executor in dry-run mode
+		executor := NewExecutorWithOptions(logging.NewRecordingLogger(), ExecutorOptions{Restricted: true})
+		executor.SetDryRun(true)
+
+		// When: Execute an allowlisted command
+		success, _, err := executor.ExecNodeCommand(context.Background(), "rsb2", "ip link show")
+
+		// Then: Simulated as usual
+		assert.NoError(t, err)
+		assert.True(t, success)
+	})
+
+	t.Run("unrestricted_by_default", func(t *testing.T) {
+		executor := NewExecutor(logging.NewRecordingLogger())
+		executor.SetDryRun(true)
+
+		success, _, err := executor.ExecNodeCommand(context.Background(), "rsb2", "echo hello")
+
+		assert.NoError(t, err)
+		assert.True(t, success)
+	})
+}
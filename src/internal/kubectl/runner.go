@@ -0,0 +1,77 @@
+package kubectl
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"k8ostack-ictl/internal/logging"
+)
+
+// Runner runs kubectl with the given arguments and standard input and returns its combined output
+// Executors provide one with RunKubectl, so the calls share the target, dry run and restricted mode of the run.
+type Runner func(ctx context.Context, stdin []byte, args ...string) (string, error)
+
+// podCommandVerbs are the kubectl verbs that run commands on nodes or in pods, out of sight of the restricted mode allowlist
+var podCommandVerbs = map[string]bool{"attach": true, "cp": true, "debug": true, "exec": true, "run": true}
+
+// commandVerb returns the kubectl verb of the arguments, the first one that is not a flag
+func commandVerb(args []string) string {
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "-") {
+			return arg
+		}
+	}
+	return ""
+}
+
+// CommandNode returns the node a kubectl command such as "annotate node rsb2 ..." works on, or "" for other objects
+func CommandNode(args []string) string {
+	var words []string
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "-") {
+			words = append(words, arg)
+		}
+	}
+	if len(words) < 3 {
+		return ""
+	}
+	switch words[1] {
+	case "node", "nodes", "no":
+		return words[2]
+	}
+	return ""
+}
+
+// runKubectl checks a kubectl command against restricted mode and the dry run, then runs it against the target
+// Restricted mode refuses the verbs running commands in pods; node commands go through ExecNodeCommand instead.
+// The target namespace is the one of the node command pods, so it is left out: callers name the namespace of their objects.
+func runKubectl(ctx context.Context, logger logging.Logger, dryRun bool, options ExecutorOptions, stdin []byte, args []string) (string, error) {
+	if options.Restricted && podCommandVerbs[commandVerb(args)] {
+		return "", fmt.Errorf("kubectl %s is not allowed in restricted mode", commandVerb(args))
+	}
+	if IsMutatingCommand(args) {
+		if blocked, err := checkDryRunMutation(dryRun, options, logger, "kubectl "+strings.Join(args, " ")); blocked {
+			return "", err
+		}
+	}
+	target := options.Target
+	target.Namespace = ""
+	return execKubectl(ctx, logger, target, stdin, args)
+}
+
+// execKubectl runs the local kubectl against the target and returns its combined output
+// Target flags go first so explicit flags of the command, like -n of the ephemeral backend, still win.
+func execKubectl(ctx context.Context, logger logging.Logger, target ClusterTarget, stdin []byte, args []string) (string, error) {
+	args = append(target.KubectlArgs(), args...)
+	logger.Debug(fmt.Sprintf("Running: kubectl %s", strings.Join(args, " ")))
+
+	cmd := exec.CommandContext(ctx, "kubectl", args...)
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	output, err := cmd.CombinedOutput()
+	return string(output), err
+}
@@ -0,0 +1,91 @@
+// Package kubectl provides unit tests for running kubectl commands through an executor
+// WHY: Cluster locks, annotations and audit events must honour the same target, dry run and restricted mode as the services
+package kubectl
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"k8ostack-ictl/internal/logging"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeKubectl puts a kubectl on PATH that prints its arguments and standard input
+func fakeKubectl(t *testing.T) {
+	bin := t.TempDir()
+	script := "#!/bin/sh\necho \"args: $*\"\ncat\n"
+	require.NoError(t, os.WriteFile(filepath.Join(bin, "kubectl"), []byte(script), 0755))
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+// TestRunKubectl tests the checks and target of kubectl commands run through an executor
+// WHY: A write that bypassed them would change a dry-run cluster or the wrong context
+func TestRunKubectl(t *testing.T) {
+	ctx := context.Background()
+	target := ClusterTarget{Kubeconfig: "/tmp/kubeconfig", Context: "prod", Namespace: "ops"}
+
+	for _, client := range []string{ClientKubectl, ClientNative} {
+		newExecutor := func(options ExecutorOptions) DryRunExecutor {
+			options.Target = target
+			if client == ClientNative {
+				return NewNativeExecutor(logging.NewRecordingLogger(), options)
+			}
+			return NewExecutorWithOptions(logging.NewRecordingLogger(), options)
+		}
+
+		t.Run(client+"/target_and_stdin", func(t *testing.T) {
+			// Given: A kubectl echoing what it gets
+			fakeKubectl(t)
+
+			// When: Create an object from standard input
+			output, err := newExecutor(ExecutorOptions{}).RunKubectl(ctx, []byte("manifest"), "create", "-f", "-")
+
+			// Then: The target flags, except the node command namespace, come first and the input reaches kubectl
+			require.NoError(t, err)
+			assert.Equal(t, "args: --kubeconfig /tmp/kubeconfig --context prod create -f -\nmanifest", output)
+		})
+
+		t.Run(client+"/strict_dry_run_blocks_writes", func(t *testing.T) {
+			fakeKubectl(t)
+			executor := newExecutor(ExecutorOptions{StrictDryRun: true})
+			executor.SetDryRun(true)
+
+			// When: Annotate a node during a strict dry run
+			_, err := executor.RunKubectl(ctx, nil, "annotate", "node", "rsb2", "--overwrite", "kictl.icycloud.io/owner=a")
+
+			// Then: It fails before kubectl runs
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, ErrDryRunMutation))
+
+			// And: Reads and server-side validation still run
+			output, err := executor.RunKubectl(ctx, nil, "apply", "-f", "-", "--dry-run=server")
+			require.NoError(t, err)
+			assert.Contains(t, output, "--dry-run=server")
+		})
+
+		t.Run(client+"/restricted_refuses_pod_commands", func(t *testing.T) {
+			fakeKubectl(t)
+
+			// When: Exec into a pod in restricted mode
+			_, err := newExecutor(ExecutorOptions{Restricted: true}).RunKubectl(ctx, nil, "exec", "-n", "kube-system", "pod", "--", "sh")
+
+			// Then: Refused, since the allowlist cannot see the command
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "not allowed in restricted mode")
+		})
+	}
+}
+
+// TestCommandNode tests finding the node a kubectl command works on
+// WHY: Audited kubectl writes are recorded against their node
+func TestCommandNode(t *testing.T) {
+	assert.Equal(t, "rsb2", CommandNode([]string{"annotate", "node", "rsb2", "--overwrite", "a=b"}))
+	assert.Equal(t, "rsb2", CommandNode([]string{"patch", "nodes", "rsb2", "--subresource=status", "-p", "{}"}))
+	assert.Equal(t, "", CommandNode([]string{"apply", "-f", "-"}))
+	assert.Equal(t, "", CommandNode([]string{"create", "lease", "kictl"}))
+}
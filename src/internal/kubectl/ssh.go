@@ -0,0 +1,360 @@
+package kubectl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"k8ostack-ictl/internal/logging"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// sshDialTimeout bounds connecting and authenticating to a node or the bastion
+const sshDialTimeout = 10 * time.Second
+
+// defaultSSHIdentityFiles are the keys tried in ~/.ssh when no identity file is configured
+var defaultSSHIdentityFiles = []string{"id_ed25519", "id_ecdsa", "id_rsa"}
+
+// SSHOptions configures the SSH transport of node commands
+type SSHOptions struct {
+	Nodes                 []string          // Node names or glob patterns reached over SSH
+	User                  string            // Login user, default root; other users run commands with sudo -n
+	Port                  int               // SSH port of the nodes, default 22
+	IdentityFiles         []string          // Private keys; the agent at $SSH_AUTH_SOCK is used too
+	Bastion               string            // Jump host as host:port, empty to connect directly
+	BastionUser           string            // Login user of the bastion, default User
+	KnownHostsFile        string            // Host keys of the nodes and the bastion, default ~/.ssh/known_hosts
+	InsecureIgnoreHostKey bool              // Skip host key checks
+	Hosts                 map[string]string // Address per node name, default the node's InternalIP
+}
+
+// Selects reports whether commands on a node go over SSH
+func (o SSHOptions) Selects(nodeName string) bool {
+	for _, pattern := range o.Nodes {
+		if matched, _ := path.Match(pattern, nodeName); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// sshExecutor runs node commands over SSH on the selected nodes and everything else through the wrapped executor
+// When connecting to a node fails, its command runs through the wrapped executor instead.
+type sshExecutor struct {
+	DryRunExecutor
+	options ExecutorOptions
+	ssh     SSHOptions
+	logger  logging.Logger
+
+	mu      sync.Mutex
+	config  *ssh.ClientConfig
+	bastion *ssh.Client
+	clients map[string]*ssh.Client
+}
+
+// NewSSHExecutor wraps an executor so that node commands on the nodes selected by sshOptions run over SSH
+func NewSSHExecutor(logger logging.Logger, executor DryRunExecutor, options ExecutorOptions, sshOptions SSHOptions) DryRunExecutor {
+	if sshOptions.User == "" {
+		sshOptions.User = "root"
+	}
+	if sshOptions.Port == 0 {
+		sshOptions.Port = 22
+	}
+	if sshOptions.BastionUser == "" {
+		sshOptions.BastionUser = sshOptions.User
+	}
+	return &sshExecutor{
+		DryRunExecutor: executor,
+		options:        options.withDefaults(),
+		ssh:            sshOptions,
+		logger:         logger,
+		clients:        make(map[string]*ssh.Client),
+	}
+}
+
+// ExecNodeCommand runs a command over SSH on selected nodes, falling back to the wrapped executor when SSH cannot connect
+func (e *sshExecutor) ExecNodeCommand(ctx context.Context, nodeName, command string) (bool, string, error) {
+	if !e.ssh.Selects(nodeName) {
+		return e.DryRunExecutor.ExecNodeCommand(ctx, nodeName, command)
+	}
+	if err := checkCommandPolicy(e.options, e.logger, nodeName, command); err != nil {
+		return false, "", err
+	}
+	if e.IsDryRun() {
+		e.logger.Debug(fmt.Sprintf("DRY RUN: Would run on node %s over SSH as %s: %s", nodeName, e.ssh.User, command))
+		return true, fmt.Sprintf("Command would be executed on node %s: %s", nodeName, command), nil
+	}
+
+	client, err := e.client(ctx, nodeName)
+	if err != nil {
+		e.logger.Warn(fmt.Sprintf("⚠️  SSH to node %s failed, running the command through the cluster: %v", nodeName, err))
+		return e.DryRunExecutor.ExecNodeCommand(ctx, nodeName, command)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, nodeCommandTimeout)
+	defer cancel()
+	e.logger.Debug(fmt.Sprintf("Running on node %s over SSH: %s", nodeName, command))
+	output, exitCode, err := runSSHCommand(ctx, client, e.commandLine(command))
+	if err != nil {
+		// The command may have started, so it is not repeated through the cluster
+		e.forget(nodeName, client)
+		return false, output, &HostCommandError{Node: nodeName, Class: HostErrorTransport, Output: output, Cause: err}
+	}
+
+	if exitCode != 0 {
+		// Packet loss is the expected result of isolation tests, not a failure
+		if strings.Contains(output, "0 received, 100% packet loss") {
+			return false, output, nil
+		}
+		return false, output, &HostCommandError{
+			Node:   nodeName,
+			Class:  exitCodeClass(int32(exitCode), output),
+			Output: output,
+			Cause:  fmt.Errorf("command exited with code %d: %s", exitCode, output),
+		}
+	}
+
+	return nodeCommandSucceeded(command, output), output, nil
+}
+
+// commandLine returns the SSH command line running a node command with a host PATH, through sudo unless logged in as root
+func (e *sshExecutor) commandLine(command string) string {
+	argv := WrapHostCommand(HostEntryNone, command)
+	if e.ssh.User != "root" {
+		argv = append([]string{"sudo", "-n"}, argv...)
+	}
+	return HostCommand(argv[0], argv[1:]...)
+}
+
+// client returns the SSH connection to a node, connecting on first use
+func (e *sshExecutor) client(ctx context.Context, nodeName string) (*ssh.Client, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if client, ok := e.clients[nodeName]; ok {
+		return client, nil
+	}
+
+	if e.config == nil {
+		config, err := e.clientConfig(e.ssh.User)
+		if err != nil {
+			return nil, err
+		}
+		e.config = config
+	}
+	address, err := e.nodeAddress(ctx, nodeName)
+	if err != nil {
+		return nil, err
+	}
+
+	var client *ssh.Client
+	if e.ssh.Bastion != "" {
+		client, err = e.dialViaBastion(ctx, address)
+	} else {
+		client, err = dialSSH(ctx, address, e.config)
+	}
+	if err != nil {
+		return nil, err
+	}
+	e.clients[nodeName] = client
+	return client, nil
+}
+
+// forget drops a broken connection so the next command on the node connects again
+func (e *sshExecutor) forget(nodeName string, client *ssh.Client) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.clients[nodeName] == client {
+		delete(e.clients, nodeName)
+	}
+	client.Close()
+}
+
+// nodeAddress returns host:port of a node, from the configured hosts or the node's InternalIP
+func (e *sshExecutor) nodeAddress(ctx context.Context, nodeName string) (string, error) {
+	host := e.ssh.Hosts[nodeName]
+	if host == "" {
+		success, output, err := e.DryRunExecutor.GetNodeInternalIP(ctx, nodeName)
+		if err != nil || !success {
+			return "", fmt.Errorf("failed to get the address of node %s: %v", nodeName, errorOrOutput(err, output))
+		}
+		host = output
+	}
+	if _, _, err := net.SplitHostPort(host); err == nil {
+		return host, nil
+	}
+	return net.JoinHostPort(host, strconv.Itoa(e.ssh.Port)), nil
+}
+
+// dialViaBastion connects to a node through the bastion, connecting to the bastion on first use
+func (e *sshExecutor) dialViaBastion(ctx context.Context, address string) (*ssh.Client, error) {
+	if e.bastion == nil {
+		config := e.config
+		if e.ssh.BastionUser != e.ssh.User {
+			var err error
+			if config, err = e.clientConfig(e.ssh.BastionUser); err != nil {
+				return nil, err
+			}
+		}
+		bastion, err := dialSSH(ctx, e.ssh.Bastion, config)
+		if err != nil {
+			return nil, fmt.Errorf("bastion %s: %w", e.ssh.Bastion, err)
+		}
+		e.bastion = bastion
+	}
+
+	conn, err := e.bastion.Dial("tcp", address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach %s through bastion %s: %w", address, e.ssh.Bastion, err)
+	}
+	clientConn, channels, requests, err := ssh.NewClientConn(conn, address, e.config)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to connect to %s through bastion %s: %w", address, e.ssh.Bastion, err)
+	}
+	return ssh.NewClient(clientConn, channels, requests), nil
+}
+
+// clientConfig returns the SSH client configuration of a login user, with key and agent authentication
+func (e *sshExecutor) clientConfig(user string) (*ssh.ClientConfig, error) {
+	hostKeyCallback := ssh.InsecureIgnoreHostKey()
+	if !e.ssh.InsecureIgnoreHostKey {
+		knownHostsFile := expandSSHPath(e.ssh.KnownHostsFile)
+		if knownHostsFile == "" {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return nil, fmt.Errorf("failed to find ~/.ssh/known_hosts: %w", err)
+			}
+			knownHostsFile = filepath.Join(home, ".ssh", "known_hosts")
+		}
+		callback, err := knownhosts.New(knownHostsFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read SSH known hosts: %w", err)
+		}
+		hostKeyCallback = callback
+	}
+
+	auth, err := sshAuthMethods(e.ssh.IdentityFiles)
+	if err != nil {
+		return nil, err
+	}
+	return &ssh.ClientConfig{User: user, Auth: auth, HostKeyCallback: hostKeyCallback, Timeout: sshDialTimeout}, nil
+}
+
+// sshAuthMethods returns key authentication with the identity files, or the default keys in ~/.ssh, and the SSH agent
+func sshAuthMethods(identityFiles []string) ([]ssh.AuthMethod, error) {
+	explicit := len(identityFiles) > 0
+	if !explicit {
+		if home, err := os.UserHomeDir(); err == nil {
+			for _, name := range defaultSSHIdentityFiles {
+				identityFiles = append(identityFiles, filepath.Join(home, ".ssh", name))
+			}
+		}
+	}
+
+	var signers []ssh.Signer
+	for _, file := range identityFiles {
+		data, err := os.ReadFile(expandSSHPath(file))
+		if err != nil {
+			if explicit {
+				return nil, fmt.Errorf("failed to read SSH identity file: %w", err)
+			}
+			continue
+		}
+		signer, err := ssh.ParsePrivateKey(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse SSH identity file %s: %w", file, err)
+		}
+		signers = append(signers, signer)
+	}
+
+	var methods []ssh.AuthMethod
+	if len(signers) > 0 {
+		methods = append(methods, ssh.PublicKeys(signers...))
+	}
+	if socket := os.Getenv("SSH_AUTH_SOCK"); socket != "" {
+		if conn, err := net.Dial("unix", socket); err == nil {
+			methods = append(methods, ssh.PublicKeysCallback(agent.NewClient(conn).Signers))
+		}
+	}
+	if len(methods) == 0 {
+		return nil, fmt.Errorf("no SSH key found: configure identityFiles or start an ssh-agent")
+	}
+	return methods, nil
+}
+
+// expandSSHPath replaces a leading ~/ with the home directory, as ssh does for its own files
+func expandSSHPath(file string) string {
+	if !strings.HasPrefix(file, "~/") {
+		return file
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return file
+	}
+	return filepath.Join(home, file[2:])
+}
+
+// dialSSH connects to an SSH server, giving up when the context ends
+func dialSSH(ctx context.Context, address string, config *ssh.ClientConfig) (*ssh.Client, error) {
+	dialer := net.Dialer{Timeout: sshDialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	clientConn, channels, requests, err := ssh.NewClientConn(conn, address, config)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return ssh.NewClient(clientConn, channels, requests), nil
+}
+
+// runSSHCommand runs a command line in a new session, returning its combined output and exit code
+func runSSHCommand(ctx context.Context, client *ssh.Client, commandLine string) (string, int, error) {
+	session, err := client.NewSession()
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to open SSH session: %w", err)
+	}
+	defer session.Close()
+
+	var output lockedBuffer
+	session.Stdout = &output
+	session.Stderr = &output
+
+	done := make(chan error, 1)
+	go func() { done <- session.Run(commandLine) }()
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		session.Close()
+		return output.String(), 0, fmt.Errorf("SSH command did not finish: %w", ctx.Err())
+	}
+
+	var exitErr *ssh.ExitError
+	if errors.As(err, &exitErr) {
+		return output.String(), exitErr.ExitStatus(), nil
+	}
+	if err != nil {
+		return output.String(), 0, fmt.Errorf("SSH command failed: %w", err)
+	}
+	return output.String(), 0, nil
+}
+
+// errorOrOutput returns the error, or the output of a call that failed without one
+func errorOrOutput(err error, output string) interface{} {
+	if err != nil {
+		return err
+	}
+	return output
+}
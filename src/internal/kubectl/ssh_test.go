@@ -0,0 +1,416 @@
+// Package kubectl provides unit tests for the SSH node command transport
+// WHY: Nodes that cannot schedule debug pods are only reachable over SSH, and a broken SSH setup must not fail the run
+package kubectl
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/pem"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"k8ostack-ictl/internal/logging"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// testSSHServer is an in-process SSH server answering exec requests and forwarding direct-tcpip channels
+type testSSHServer struct {
+	address  string
+	hostKey  ssh.PublicKey
+	mu       sync.Mutex
+	users    []string
+	commands []string
+}
+
+// sshTestCommandResults are the canned output and exit status per node command
+var sshTestCommandResults = map[string]struct {
+	output string
+	status uint32
+}{
+	"ip link show":         {"1: lo: <LOOPBACK,UP>", 0},
+	"ip link add eth0.100": {"RTNETLINK answers: Operation not permitted", 2},
+	"ping -c 3 10.1.0.9":   {"3 packets transmitted, 0 received, 100% packet loss", 1},
+}
+
+// startTestSSHServer starts an SSH server accepting the client key until the test ends
+func startTestSSHServer(t *testing.T, clientKey ssh.PublicKey) *testSSHServer {
+	_, hostPrivate, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	hostSigner, err := ssh.NewSignerFromKey(hostPrivate)
+	require.NoError(t, err)
+
+	server := &testSSHServer{hostKey: hostSigner.PublicKey()}
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if string(key.Marshal()) != string(clientKey.Marshal()) {
+				return nil, assert.AnError
+			}
+			server.mu.Lock()
+			server.users = append(server.users, conn.User())
+			server.mu.Unlock()
+			return nil, nil
+		},
+	}
+	config.AddHostKey(hostSigner)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+	server.address = listener.Addr().String()
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(conn, config)
+		}
+	}()
+	return server
+}
+
+// serve handles one client connection
+func (s *testSSHServer) serve(conn net.Conn, config *ssh.ServerConfig) {
+	_, channels, requests, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	go ssh.DiscardRequests(requests)
+	for newChannel := range channels {
+		switch newChannel.ChannelType() {
+		case "session":
+			go s.session(newChannel)
+		case "direct-tcpip":
+			go s.forward(newChannel)
+		default:
+			newChannel.Reject(ssh.UnknownChannelType, "unsupported")
+		}
+	}
+}
+
+// session answers the exec request of a session with the canned result of its command
+func (s *testSSHServer) session(newChannel ssh.NewChannel) {
+	channel, requests, err := newChannel.Accept()
+	if err != nil {
+		return
+	}
+	defer channel.Close()
+	for request := range requests {
+		if request.Type != "exec" {
+			request.Reply(false, nil)
+			continue
+		}
+		command := string(request.Payload[4:])
+		s.mu.Lock()
+		s.commands = append(s.commands, command)
+		s.mu.Unlock()
+		request.Reply(true, nil)
+
+		result := struct {
+			output string
+			status uint32
+		}{"sh: not found", 127}
+		for nodeCommand, canned := range sshTestCommandResults {
+			if strings.HasSuffix(command, "; "+nodeCommand+"'") {
+				result = canned
+			}
+		}
+		io.WriteString(channel, result.output)
+		status := make([]byte, 4)
+		binary.BigEndian.PutUint32(status, result.status)
+		channel.SendRequest("exit-status", false, status)
+		return
+	}
+}
+
+// forward connects a direct-tcpip channel to its destination, acting as a bastion
+func (s *testSSHServer) forward(newChannel ssh.NewChannel) {
+	var payload struct {
+		Host       string
+		Port       uint32
+		OriginHost string
+		OriginPort uint32
+	}
+	if err := ssh.Unmarshal(newChannel.ExtraData(), &payload); err != nil {
+		newChannel.Reject(ssh.ConnectionFailed, err.Error())
+		return
+	}
+	target, err := net.Dial("tcp", net.JoinHostPort(payload.Host, strconv.Itoa(int(payload.Port))))
+	if err != nil {
+		newChannel.Reject(ssh.ConnectionFailed, err.Error())
+		return
+	}
+	channel, requests, err := newChannel.Accept()
+	if err != nil {
+		target.Close()
+		return
+	}
+	go ssh.DiscardRequests(requests)
+	go func() {
+		io.Copy(channel, target)
+		channel.Close()
+	}()
+	io.Copy(target, channel)
+	target.Close()
+}
+
+// recorded returns the login users and command lines the server saw
+func (s *testSSHServer) recorded() ([]string, []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.users...), append([]string(nil), s.commands...)
+}
+
+// fallbackRecorder stands in for the cluster transport, recording the node commands it receives
+type fallbackRecorder struct {
+	DryRunExecutor
+	commands []string
+}
+
+// ExecNodeCommand records the command and succeeds
+func (r *fallbackRecorder) ExecNodeCommand(ctx context.Context, nodeName, command string) (bool, string, error) {
+	r.commands = append(r.commands, nodeName+": "+command)
+	return true, "from cluster", nil
+}
+
+// writeTestSSHKey writes a new client key to a file, returning the file and its public key
+func writeTestSSHKey(t *testing.T) (string, ssh.PublicKey) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	block, err := ssh.MarshalPrivateKey(private, "")
+	require.NoError(t, err)
+	keyFile := filepath.Join(t.TempDir(), "id_ed25519")
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(block), 0600))
+	signer, err := ssh.NewSignerFromKey(private)
+	require.NoError(t, err)
+	return keyFile, signer.PublicKey()
+}
+
+// TestSSHOptions_Selects tests choosing the nodes reached over SSH
+// WHY: Only the listed nodes may bypass the cluster, everything else keeps its usual backend
+func TestSSHOptions_Selects(t *testing.T) {
+	options := SSHOptions{Nodes: []string{"rsb2", "edge-*"}}
+
+	assert.True(t, options.Selects("rsb2"))
+	assert.True(t, options.Selects("edge-7"))
+	assert.False(t, options.Selects("rsb3"))
+	assert.True(t, SSHOptions{Nodes: []string{"*"}}.Selects("rsb3"))
+	assert.False(t, SSHOptions{}.Selects("rsb2"))
+}
+
+// TestSSHExecutor_ExecNodeCommand tests running node commands over SSH
+// WHY: Results over SSH must classify like results through the cluster, and only connection failures may fall back
+func TestSSHExecutor_ExecNodeCommand(t *testing.T) {
+	tests := []struct {
+		name          string
+		node          string
+		command       string
+		user          string
+		bastion       bool
+		unreachable   bool
+		expectSuccess bool
+		expectOutput  string
+		expectClass   string
+		expectSSH     bool
+		expectCluster []string
+	}{
+		{
+			name:          "command_succeeds",
+			node:          "rsb2",
+			command:       "ip link show",
+			expectSuccess: true,
+			expectOutput:  "1: lo: <LOOPBACK,UP>",
+			expectSSH:     true,
+		},
+		{
+			name:          "non_root_user_runs_sudo",
+			node:          "rsb2",
+			command:       "ip link show",
+			user:          "ops",
+			expectSuccess: true,
+			expectOutput:  "1: lo: <LOOPBACK,UP>",
+			expectSSH:     true,
+		},
+		{
+			name:          "through_bastion",
+			node:          "rsb2",
+			command:       "ip link show",
+			bastion:       true,
+			expectSuccess: true,
+			expectOutput:  "1: lo: <LOOPBACK,UP>",
+			expectSSH:     true,
+		},
+		{
+			name:         "permission_denied",
+			node:         "rsb2",
+			command:      "ip link add eth0.100",
+			expectOutput: "RTNETLINK answers: Operation not permitted",
+			expectClass:  HostErrorPermission,
+			expectSSH:    true,
+		},
+		{
+			name:         "packet_loss_is_not_an_error",
+			node:         "rsb2",
+			command:      "ping -c 3 10.1.0.9",
+			expectOutput: "3 packets transmitted, 0 received, 100% packet loss",
+			expectSSH:    true,
+		},
+		{
+			name:          "unselected_node_uses_cluster",
+			node:          "rsb3",
+			command:       "ip link show",
+			expectSuccess: true,
+			expectOutput:  "from cluster",
+			expectCluster: []string{"rsb3: ip link show"},
+		},
+		{
+			name:          "unreachable_node_falls_back",
+			node:          "rsb2",
+			command:       "ip link show",
+			unreachable:   true,
+			expectSuccess: true,
+			expectOutput:  "from cluster",
+			expectCluster: []string{"rsb2: ip link show"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: An SSH server trusted through known_hosts and an executor selecting rsb2
+			t.Setenv("SSH_AUTH_SOCK", "")
+			keyFile, clientKey := writeTestSSHKey(t)
+			server := startTestSSHServer(t, clientKey)
+			knownHostsFile := filepath.Join(t.TempDir(), "known_hosts")
+			require.NoError(t, os.WriteFile(knownHostsFile, []byte(knownhosts.Line([]string{server.address}, server.hostKey)+"\n"), 0600))
+
+			nodeAddress := server.address
+			if tt.unreachable {
+				listener, err := net.Listen("tcp", "127.0.0.1:0")
+				require.NoError(t, err)
+				nodeAddress = listener.Addr().String()
+				listener.Close()
+			}
+			sshOptions := SSHOptions{
+				Nodes:          []string{"rsb2"},
+				User:           tt.user,
+				IdentityFiles:  []string{keyFile},
+				KnownHostsFile: knownHostsFile,
+				Hosts:          map[string]string{"rsb2": nodeAddress},
+			}
+			if tt.bastion {
+				sshOptions.Bastion = server.address
+				sshOptions.BastionUser = "jump"
+			}
+			native, _ := newTestNativeExecutor()
+			cluster := &fallbackRecorder{DryRunExecutor: native}
+			logger := logging.NewRecordingLogger()
+			executor := NewSSHExecutor(logger, cluster, ExecutorOptions{}, sshOptions)
+
+			// When: Run the command on the node
+			success, output, err := executor.ExecNodeCommand(context.Background(), tt.node, tt.command)
+
+			// Then: The result is classified and the command ran where expected
+			assert.Equal(t, tt.expectSuccess, success)
+			assert.Equal(t, tt.expectOutput, output)
+			if tt.expectClass != "" {
+				var hostErr *HostCommandError
+				require.ErrorAs(t, err, &hostErr)
+				assert.Equal(t, tt.expectClass, hostErr.Class)
+				assert.Equal(t, "rsb2", hostErr.Node)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expectCluster, cluster.commands)
+
+			users, commands := server.recorded()
+			if !tt.expectSSH {
+				assert.Empty(t, commands)
+				return
+			}
+			argv := WrapHostCommand(HostEntryNone, tt.command)
+			expectedUser := "root"
+			if tt.user != "" {
+				expectedUser = tt.user
+				argv = append([]string{"sudo", "-n"}, argv...)
+			}
+			assert.Equal(t, []string{HostCommand(argv[0], argv[1:]...)}, commands)
+			if tt.bastion {
+				assert.Equal(t, []string{"jump", expectedUser}, users)
+			} else {
+				assert.Equal(t, []string{expectedUser}, users)
+			}
+		})
+	}
+}
+
+// TestSSHExecutor_UnknownHostKey tests refusing nodes missing from known_hosts
+// WHY: A node answering with an unknown key may be an impostor, so the command must go through the cluster instead
+func TestSSHExecutor_UnknownHostKey(t *testing.T) {
+	// Given: An SSH server absent from an empty known_hosts file
+	t.Setenv("SSH_AUTH_SOCK", "")
+	keyFile, clientKey := writeTestSSHKey(t)
+	server := startTestSSHServer(t, clientKey)
+	knownHostsFile := filepath.Join(t.TempDir(), "known_hosts")
+	require.NoError(t, os.WriteFile(knownHostsFile, nil, 0600))
+	native, _ := newTestNativeExecutor()
+	cluster := &fallbackRecorder{DryRunExecutor: native}
+	logger := logging.NewRecordingLogger()
+	executor := NewSSHExecutor(logger, cluster, ExecutorOptions{}, SSHOptions{
+		Nodes:          []string{"*"},
+		IdentityFiles:  []string{keyFile},
+		KnownHostsFile: knownHostsFile,
+		Hosts:          map[string]string{"rsb2": server.address},
+	})
+
+	// When: Run a command on the node
+	success, output, err := executor.ExecNodeCommand(context.Background(), "rsb2", "ip link show")
+
+	// Then: Nothing ran over SSH and the cluster ran the command
+	require.NoError(t, err)
+	assert.True(t, success)
+	assert.Equal(t, "from cluster", output)
+	_, commands := server.recorded()
+	assert.Empty(t, commands)
+	assert.Len(t, logger.Messages(logging.LevelWarn), 1)
+	assert.Contains(t, logger.Messages(logging.LevelWarn)[0], "SSH to node rsb2 failed")
+}
+
+// TestSSHExecutor_DryRunAndPolicy tests that dry run and restricted mode apply to SSH commands
+// WHY: SSH must not become a way around --dry-run or the restricted command allowlist
+func TestSSHExecutor_DryRunAndPolicy(t *testing.T) {
+	native, _ := newTestNativeExecutor()
+	cluster := &fallbackRecorder{DryRunExecutor: native}
+	sshOptions := SSHOptions{Nodes: []string{"rsb2"}, Hosts: map[string]string{"rsb2": "127.0.0.1:1"}}
+
+	// Given/When: A restricted executor runs a command outside the allowlist
+	restrictedExecutor := NewSSHExecutor(logging.NewRecordingLogger(), cluster, ExecutorOptions{Restricted: true, AllowedCommands: []string{"ip"}}, sshOptions)
+	_, _, err := restrictedExecutor.ExecNodeCommand(context.Background(), "rsb2", "rm -rf /etc")
+
+	// Then: The command is refused before connecting
+	assert.ErrorContains(t, err, "is not allowed in restricted mode")
+
+	// Given/When: A dry-run executor runs a command
+	cluster.SetDryRun(true)
+	dryRunExecutor := NewSSHExecutor(logging.NewRecordingLogger(), cluster, ExecutorOptions{}, sshOptions)
+	success, output, err := dryRunExecutor.ExecNodeCommand(context.Background(), "rsb2", "ip link add eth0.100")
+
+	// Then: Nothing runs anywhere
+	require.NoError(t, err)
+	assert.True(t, success)
+	assert.Contains(t, output, "would be executed on node rsb2")
+	assert.Empty(t, cluster.commands)
+}
@@ -0,0 +1,53 @@
+package kubectl
+
+import (
+	"fmt"
+
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// ClusterTarget selects the kubeconfig, context and namespace every request goes to
+// Empty fields keep what the kubeconfig (KUBECONFIG, then ~/.kube/config) selects.
+type ClusterTarget struct {
+	Kubeconfig string
+	Context    string
+	Namespace  string
+}
+
+// KubectlArgs returns the global kubectl flags selecting the target
+func (t ClusterTarget) KubectlArgs() []string {
+	var args []string
+	if t.Kubeconfig != "" {
+		args = append(args, "--kubeconfig", t.Kubeconfig)
+	}
+	if t.Context != "" {
+		args = append(args, "--context", t.Context)
+	}
+	if t.Namespace != "" {
+		args = append(args, "--namespace", t.Namespace)
+	}
+	return args
+}
+
+// CurrentContext returns the kubeconfig context the target selects, or "" when the kubeconfig sets none (in-cluster)
+func (t ClusterTarget) CurrentContext() (string, error) {
+	if t.Context != "" {
+		return t.Context, nil
+	}
+	rawConfig, err := t.ClientConfig().RawConfig()
+	if err != nil {
+		return "", fmt.Errorf("failed to load kubeconfig: %w", err)
+	}
+	return rawConfig.CurrentContext, nil
+}
+
+// ClientConfig returns the client-go configuration of the target
+func (t ClusterTarget) ClientConfig() clientcmd.ClientConfig {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = t.Kubeconfig
+
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: t.Context}
+	overrides.Context.Namespace = t.Namespace
+
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, overrides)
+}
@@ -0,0 +1,119 @@
+// Package kubectl provides unit tests for cluster targeting
+// WHY: Every request must reach the cluster, context and namespace the operator chose
+package kubectl
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"k8ostack-ictl/internal/logging"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// targetTestKubeconfig has two contexts so tests can tell which one was selected
+const targetTestKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: lab
+  cluster:
+    server: https://10.0.0.10:6443
+- name: prod
+  cluster:
+    server: https://10.1.0.10:6443
+contexts:
+- name: lab
+  context:
+    cluster: lab
+    user: admin
+    namespace: lab-tools
+- name: prod
+  context:
+    cluster: prod
+    user: admin
+current-context: lab
+users:
+- name: admin
+  user:
+    token: abc123
+`
+
+// TestClusterTarget_KubectlArgs tests the global flags passed to every kubectl command
+// WHY: Unset fields must not be passed, so kubectl keeps its own defaults for them
+func TestClusterTarget_KubectlArgs(t *testing.T) {
+	tests := []struct {
+		name     string
+		target   ClusterTarget
+		expected []string
+	}{
+		{name: "empty_target", target: ClusterTarget{}, expected: nil},
+		{
+			name:     "all_fields",
+			target:   ClusterTarget{Kubeconfig: "/etc/kictl/prod.yaml", Context: "prod", Namespace: "infra"},
+			expected: []string{"--kubeconfig", "/etc/kictl/prod.yaml", "--context", "prod", "--namespace", "infra"},
+		},
+		{name: "context_only", target: ClusterTarget{Context: "prod"}, expected: []string{"--context", "prod"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.target.KubectlArgs())
+		})
+	}
+}
+
+// TestClusterTarget_ClientConfig tests the client-go configuration of a target
+// WHY: The native client and the operator must select the same context and namespace as kubectl would
+func TestClusterTarget_ClientConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	require.NoError(t, os.WriteFile(path, []byte(targetTestKubeconfig), 0600))
+
+	tests := []struct {
+		name              string
+		target            ClusterTarget
+		expectedServer    string
+		expectedNamespace string
+	}{
+		{name: "current_context", target: ClusterTarget{Kubeconfig: path}, expectedServer: "https://10.0.0.10:6443", expectedNamespace: "lab-tools"},
+		{name: "context_override", target: ClusterTarget{Kubeconfig: path, Context: "prod"}, expectedServer: "https://10.1.0.10:6443", expectedNamespace: "default"},
+		{name: "namespace_override", target: ClusterTarget{Kubeconfig: path, Namespace: "infra"}, expectedServer: "https://10.0.0.10:6443", expectedNamespace: "infra"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// When: Resolving the client configuration
+			restConfig, err := tt.target.ClientConfig().ClientConfig()
+			require.NoError(t, err)
+			namespace, _, err := tt.target.ClientConfig().Namespace()
+			require.NoError(t, err)
+
+			// Then: The selected context decides server and namespace
+			assert.Equal(t, tt.expectedServer, restConfig.Host)
+			assert.Equal(t, tt.expectedNamespace, namespace)
+		})
+	}
+}
+
+// TestRealExecutor_ClusterTarget tests that kubectl commands carry the target flags
+// WHY: A command without them would silently act on whatever cluster the default kubeconfig points at
+func TestRealExecutor_ClusterTarget(t *testing.T) {
+	// Given: A kubectl that prints its arguments
+	bin := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(bin, "kubectl"), []byte("#!/bin/sh\necho \"$@\"\n"), 0755))
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	executor := NewExecutorWithOptions(logging.NewRecordingLogger(), ExecutorOptions{
+		Target: ClusterTarget{Kubeconfig: "/etc/kictl/prod.yaml", Context: "prod", Namespace: "infra"},
+	})
+
+	// When: Running a command
+	success, output, err := executor.GetNode(context.Background(), "rsb2")
+
+	// Then: The target flags precede the command
+	require.NoError(t, err)
+	assert.True(t, success)
+	assert.Equal(t, "--kubeconfig /etc/kictl/prod.yaml --context prod --namespace infra get node rsb2", output)
+}
@@ -0,0 +1,61 @@
+package labeler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"k8ostack-ictl/internal/kubectl"
+)
+
+// applyRoleBulk labels the nodes of a role with one LabelNodes call and returns the nodes left for the per-node path
+// Missing nodes and every node of a failed bulk call fall back, so their errors are still attributed per node.
+func (ls *LabelingService) applyRoleBulk(ctx context.Context, nodeNames []string, labels map[string]string, results *OperationResults) []string {
+	present, missing := nodeNames, []string(nil)
+	if ls.options.ValidateNodes {
+		success, output, err := ls.kubectl.GetAllNodes(ctx)
+		if err != nil || !success {
+			ls.options.Logger.Warn(fmt.Sprintf("⚠️  Could not list nodes for bulk labeling, labeling one by one: %v", err))
+			return nodeNames
+		}
+		present, missing = splitExistingNodes(nodeNames, kubectl.ParseNodeNames(output))
+	}
+
+	if len(present) < 2 {
+		return nodeNames
+	}
+
+	success, _, err := ls.kubectl.LabelNodes(ctx, present, labels, true)
+	if err != nil || !success {
+		ls.options.Logger.Warn(fmt.Sprintf("⚠️  Bulk labeling failed, labeling one by one: %v", err))
+		return nodeNames
+	}
+
+	applied := kubectl.FormatLabels(labels)
+	for _, nodeName := range present {
+		results.TotalNodes++
+		results.SuccessfulNodes++
+		results.AppliedLabels[nodeName] = append([]string(nil), applied...)
+		ls.progress().Done(nodeName, false)
+	}
+	ls.options.Logger.Info(fmt.Sprintf("✅ Applied labels %s to %d nodes in one call: %s",
+		strings.Join(applied, ", "), len(present), strings.Join(present, ", ")))
+
+	return missing
+}
+
+// splitExistingNodes separates the nodes found in the cluster from the missing ones, keeping their order
+func splitExistingNodes(nodeNames, clusterNodes []string) (present, missing []string) {
+	exists := make(map[string]bool, len(clusterNodes))
+	for _, name := range clusterNodes {
+		exists[name] = true
+	}
+	for _, name := range nodeNames {
+		if exists[name] {
+			present = append(present, name)
+		} else {
+			missing = append(missing, name)
+		}
+	}
+	return present, missing
+}
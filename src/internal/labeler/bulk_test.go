@@ -0,0 +1,146 @@
+// Package labeler provides unit tests for bulk node labeling
+// WHY: Labeling a uniform role in one call must report the same results as labeling node by node
+package labeler
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/kubectl"
+	"k8ostack-ictl/internal/logging"
+	"k8ostack-ictl/internal/progress"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLabelingService_ApplyLabels_Bulk tests applying a role's labels with one bulk call
+// WHY: Missing nodes and failed bulk calls must fall back to per-node labeling so errors keep their node
+func TestLabelingService_ApplyLabels_Bulk(t *testing.T) {
+	labels := map[string]string{"role": "compute"}
+	tests := []struct {
+		name                 string
+		nodes                []string
+		validateNodes        bool
+		mockSetupFunc        func(*MockDryRunExecutor)
+		expectedSuccessNodes int
+		expectedFailedNodes  []string
+		expectedApplied      map[string][]string
+	}{
+		{
+			name:  "one_call_for_all_nodes",
+			nodes: []string{"rsb2", "rsb3", "rsb4"},
+			mockSetupFunc: func(m *MockDryRunExecutor) {
+				m.On("LabelNodes", mock.Anything, []string{"rsb2", "rsb3", "rsb4"}, labels, true).Return(true, "labeled", nil).Once()
+			},
+			expectedSuccessNodes: 3,
+			expectedApplied: map[string][]string{
+				"rsb2": {"role=compute"}, "rsb3": {"role=compute"}, "rsb4": {"role=compute"},
+			},
+		},
+		{
+			name:          "missing_node_labeled_alone",
+			nodes:         []string{"rsb2", "rbs3", "rsb4"},
+			validateNodes: true,
+			mockSetupFunc: func(m *MockDryRunExecutor) {
+				m.On("GetAllNodes", mock.Anything).Return(true, "node/rsb2\nnode/rsb3\nnode/rsb4", nil)
+				m.On("LabelNodes", mock.Anything, []string{"rsb2", "rsb4"}, labels, true).Return(true, "labeled", nil).Once()
+				m.On("GetNode", mock.Anything, "rbs3").Return(false, "", fmt.Errorf("not found"))
+			},
+			expectedSuccessNodes: 2,
+			expectedFailedNodes:  []string{"rbs3"},
+			expectedApplied:      map[string][]string{"rsb2": {"role=compute"}, "rsb4": {"role=compute"}},
+		},
+		{
+			name:  "bulk_failure_falls_back_per_node",
+			nodes: []string{"rsb2", "rsb3"},
+			mockSetupFunc: func(m *MockDryRunExecutor) {
+				m.On("LabelNodes", mock.Anything, []string{"rsb2", "rsb3"}, labels, true).Return(false, "", kubectl.WrapNodeError("rsb3", fmt.Errorf("admission denied"))).Once()
+				m.On("LabelNode", mock.Anything, "rsb2", "role=compute", true).Return(true, "labeled", nil).Once()
+				m.On("LabelNode", mock.Anything, "rsb3", "role=compute", true).Return(false, "", fmt.Errorf("admission denied")).Once()
+			},
+			expectedSuccessNodes: 1,
+			expectedFailedNodes:  []string{"rsb3"},
+			expectedApplied:      map[string][]string{"rsb2": {"role=compute"}},
+		},
+		{
+			name:  "single_node_skips_bulk",
+			nodes: []string{"rsb2"},
+			mockSetupFunc: func(m *MockDryRunExecutor) {
+				m.On("LabelNode", mock.Anything, "rsb2", "role=compute", true).Return(true, "labeled", nil).Once()
+			},
+			expectedSuccessNodes: 1,
+			expectedApplied:      map[string][]string{"rsb2": {"role=compute"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A bulk labeling service and one role
+			mockKubectl := NewMockDryRunExecutor()
+			mockKubectl.On("SetDryRun", false).Return()
+			tt.mockSetupFunc(mockKubectl)
+			recorder := &progress.Recorder{}
+			service := NewService(mockKubectl, Options{
+				ValidateNodes: tt.validateNodes,
+				BulkLabel:     true,
+				Progress:      recorder,
+				Logger:        logging.NewRecordingLogger(),
+			})
+			cfg := &config.NodeLabelConf{
+				Kind:     "NodeLabelConf",
+				Metadata: config.Metadata{Name: "bulk"},
+				Spec: config.NodeLabelSpec{NodeRoles: map[string]config.NodeRole{
+					"compute": {Nodes: tt.nodes, Labels: labels},
+				}},
+			}
+
+			// When: Apply labels
+			results, err := service.ApplyLabels(context.Background(), cfg)
+
+			// Then: Every node is counted once with its own outcome
+			require.NoError(t, err)
+			assert.Equal(t, len(tt.nodes), results.TotalNodes)
+			assert.Equal(t, tt.expectedSuccessNodes, results.SuccessfulNodes)
+			assert.Equal(t, tt.expectedFailedNodes, results.FailedNodes)
+			assert.Equal(t, tt.expectedApplied, results.AppliedLabels)
+			for _, err := range results.Errors {
+				assert.Contains(t, tt.expectedFailedNodes, kubectl.NodeOf(err))
+			}
+			assert.Equal(t, []string{fmt.Sprintf("Apply labels/%d", len(tt.nodes))}, recorder.Tasks)
+			assert.Len(t, recorder.Succeeded, tt.expectedSuccessNodes)
+			assert.Equal(t, tt.expectedFailedNodes, recorder.Failed)
+			assert.Equal(t, 1, recorder.Ended)
+			mockKubectl.AssertExpectations(t)
+		})
+	}
+}
+
+// TestLabelingService_RemoveLabels_BulkIgnored tests that removal keeps labeling node by node
+// WHY: Bulk labeling only covers apply, so removal must not call LabelNodes
+func TestLabelingService_RemoveLabels_BulkIgnored(t *testing.T) {
+	// Given: A bulk labeling service
+	mockKubectl := NewMockDryRunExecutor()
+	mockKubectl.On("SetDryRun", false).Return()
+	mockKubectl.On("UnlabelNode", mock.Anything, mock.Anything, "role").Return(true, "unlabeled", nil).Twice()
+	service := NewService(mockKubectl, Options{BulkLabel: true, Logger: logging.NewRecordingLogger()})
+	cfg := &config.NodeLabelConf{
+		Kind:     "NodeLabelConf",
+		Metadata: config.Metadata{Name: "bulk"},
+		Spec: config.NodeLabelSpec{NodeRoles: map[string]config.NodeRole{
+			"compute": {Nodes: []string{"rsb2", "rsb3"}, Labels: map[string]string{"role": "compute"}},
+		}},
+	}
+
+	// When: Remove labels
+	results, err := service.RemoveLabels(context.Background(), cfg)
+
+	// Then: Each node is unlabeled on its own
+	require.NoError(t, err)
+	assert.Equal(t, 2, results.SuccessfulNodes)
+	mockKubectl.AssertExpectations(t)
+	mockKubectl.AssertNotCalled(t, "LabelNodes", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
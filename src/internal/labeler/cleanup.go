@@ -0,0 +1,147 @@
+package labeler
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/kubectl"
+)
+
+// CleanupLabels removes every label whose key matches one of the cleanup prefixes
+// Matching labels are logged as a diff before removal so dry runs show exactly what would change
+func (ls *LabelingService) CleanupLabels(ctx context.Context, cleanup *config.CleanupConf) (*OperationResults, error) {
+	ls.kubectl.SetDryRun(ls.options.DryRun)
+
+	results := &OperationResults{
+		AppliedLabels: make(map[string][]string),
+	}
+
+	prefixes := strings.Join(cleanup.Spec.LabelPrefixes, ", ")
+	ls.options.Logger.Info(strings.Repeat("=", 50))
+	if ls.options.DryRun {
+		ls.options.Logger.Info(fmt.Sprintf("🧪 DRY RUN: Simulating cleanup of labels with prefix %s...", prefixes))
+	} else {
+		ls.options.Logger.Info(fmt.Sprintf("🧹 Cleaning up labels with prefix %s...", prefixes))
+	}
+
+	nodes, err := ls.resolveCleanupNodes(ctx, cleanup.Spec)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, nodeName := range nodes {
+		results.TotalNodes++
+		if ls.cleanupNodeLabels(ctx, nodeName, cleanup.Spec.LabelPrefixes, results) {
+			results.SuccessfulNodes++
+		}
+	}
+
+	// Print summary
+	removed := 0
+	for _, labels := range results.AppliedLabels {
+		removed += len(labels)
+	}
+	ls.options.Logger.Info(strings.Repeat("=", 50))
+	ls.options.Logger.Info("📊 Cleanup Summary:")
+	ls.options.Logger.Info(fmt.Sprintf("  Nodes processed: %d", results.TotalNodes))
+	ls.options.Logger.Info(fmt.Sprintf("  Labels removed: %d", removed))
+	ls.options.Logger.Info(fmt.Sprintf("  Failed nodes: %d", len(results.FailedNodes)))
+
+	if len(results.FailedNodes) > 0 {
+		ls.options.Logger.Warn(fmt.Sprintf("  Failed nodes: %s", strings.Join(results.FailedNodes, ", ")))
+	}
+
+	return results, nil
+}
+
+// resolveCleanupNodes returns the sorted union of explicit nodes and nodes matching the selector
+func (ls *LabelingService) resolveCleanupNodes(ctx context.Context, spec config.CleanupSpec) ([]string, error) {
+	unique := make(map[string]bool)
+	for _, node := range spec.Nodes {
+		unique[node] = true
+	}
+
+	if spec.NodeSelector != "" {
+		success, output, err := ls.kubectl.GetNodesByLabel(ctx, spec.NodeSelector)
+		if err != nil || !success {
+			return nil, fmt.Errorf("failed to list nodes matching selector %s: %v", spec.NodeSelector, err)
+		}
+		for _, node := range kubectl.ParseNodeNames(output) {
+			unique[node] = true
+		}
+	}
+
+	nodes := make([]string, 0, len(unique))
+	for node := range unique {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+	return nodes, nil
+}
+
+// cleanupNodeLabels removes matching labels from a single node
+func (ls *LabelingService) cleanupNodeLabels(ctx context.Context, nodeName string, prefixes []string, results *OperationResults) bool {
+	success, output, err := ls.kubectl.GetNodeLabels(ctx, nodeName)
+	if err != nil || !success {
+		notFound := kubectl.NewNodeNotFoundError(nodeName, ls.nodes.Names(ctx), err)
+		ls.options.Logger.Error(notFound.Error())
+		results.FailedNodes = append(results.FailedNodes, nodeName)
+		results.Errors = append(results.Errors, notFound)
+		return false
+	}
+
+	labels := kubectl.ParseNodeLabels(output)
+	keys := matchLabelPrefixes(labels, prefixes)
+	if len(keys) == 0 {
+		ls.options.Logger.Info(fmt.Sprintf("  Node %s: no matching labels", nodeName))
+		return true
+	}
+
+	// Diff of labels to be removed
+	ls.options.Logger.Info(fmt.Sprintf("  Node %s:", nodeName))
+	for _, key := range keys {
+		ls.options.Logger.Info(fmt.Sprintf("    - %s=%s", key, labels[key]))
+	}
+
+	allSuccess := true
+	removedLabels := []string{}
+
+	for _, key := range keys {
+		success, output, err := ls.kubectl.UnlabelNode(ctx, nodeName, key)
+		if err != nil || !success {
+			ls.options.Logger.Error(fmt.Sprintf("Failed to remove label %s from node %s: %v", key, nodeName, err))
+			allSuccess = false
+			if err != nil {
+				results.Errors = append(results.Errors, kubectl.WrapNodeError(nodeName, err))
+			}
+			continue
+		}
+		ls.options.Logger.Debug(fmt.Sprintf("Removed label %s from node %s: %s", key, nodeName, output))
+		removedLabels = append(removedLabels, "-"+key)
+	}
+
+	results.AppliedLabels[nodeName] = removedLabels
+	if !allSuccess {
+		results.FailedNodes = append(results.FailedNodes, nodeName)
+	}
+
+	return allSuccess
+}
+
+// matchLabelPrefixes returns the sorted label keys starting with any of the prefixes
+func matchLabelPrefixes(labels map[string]string, prefixes []string) []string {
+	var keys []string
+	for key := range labels {
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(key, prefix) {
+				keys = append(keys, key)
+				break
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
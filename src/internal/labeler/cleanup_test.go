@@ -0,0 +1,172 @@
+// Package labeler provides unit tests for prefix-based label cleanup
+// WHY: Cleanup deletes labels it discovers rather than ones listed in config, so matching must be exact
+package labeler
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/logging"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// showLabels renders a kubectl --show-labels table for a single node
+func showLabels(node, labels string) string {
+	return fmt.Sprintf("NAME   STATUS   ROLES    AGE   VERSION   LABELS\n%s   Ready    <none>   10d   v1.28.2   %s", node, labels)
+}
+
+// TestLabelingService_CleanupLabels tests bulk removal of labels by key prefix
+// WHY: Legacy labels must be removed without touching unrelated labels on the same node
+func TestLabelingService_CleanupLabels(t *testing.T) {
+	tests := []struct {
+		name                 string
+		description          string
+		spec                 config.CleanupSpec
+		dryRun               bool
+		mockSetupFunc        func(*MockDryRunExecutor)
+		expectedTotalNodes   int
+		expectedSuccessNodes int
+		expectedFailedNodes  []string
+		expectedRemoved      map[string][]string
+		shouldError          bool
+	}{
+		{
+			name:        "removes_only_matching_labels",
+			description: "Only keys starting with the prefix should be removed",
+			spec: config.CleanupSpec{
+				LabelPrefixes: []string{"openstack-role"},
+				Nodes:         []string{"rsb2"},
+			},
+			mockSetupFunc: func(mockKubectl *MockDryRunExecutor) {
+				mockKubectl.On("SetDryRun", false).Return()
+				mockKubectl.On("GetNodeLabels", mock.Anything, "rsb2").
+					Return(true, showLabels("rsb2", "kubernetes.io/hostname=rsb2,openstack-role=compute,openstack-role-legacy=true"), nil)
+				mockKubectl.On("UnlabelNode", mock.Anything, "rsb2", "openstack-role").Return(true, "node/rsb2 unlabeled", nil)
+				mockKubectl.On("UnlabelNode", mock.Anything, "rsb2", "openstack-role-legacy").Return(true, "node/rsb2 unlabeled", nil)
+			},
+			expectedTotalNodes:   1,
+			expectedSuccessNodes: 1,
+			expectedRemoved:      map[string][]string{"rsb2": {"-openstack-role", "-openstack-role-legacy"}},
+		},
+		{
+			name:        "selector_and_explicit_nodes_merged",
+			description: "Selector results should be merged with explicit nodes without duplicates",
+			spec: config.CleanupSpec{
+				LabelPrefixes: []string{"legacy.icycloud.io/"},
+				Nodes:         []string{"rsb2"},
+				NodeSelector:  "legacy.icycloud.io/managed=true",
+			},
+			mockSetupFunc: func(mockKubectl *MockDryRunExecutor) {
+				mockKubectl.On("SetDryRun", false).Return()
+				mockKubectl.On("GetNodesByLabel", mock.Anything, "legacy.icycloud.io/managed=true").
+					Return(true, "node/rsb2\nnode/rsb3", nil)
+				mockKubectl.On("GetNodeLabels", mock.Anything, "rsb2").
+					Return(true, showLabels("rsb2", "legacy.icycloud.io/managed=true"), nil)
+				mockKubectl.On("GetNodeLabels", mock.Anything, "rsb3").
+					Return(true, showLabels("rsb3", "kubernetes.io/hostname=rsb3"), nil)
+				mockKubectl.On("UnlabelNode", mock.Anything, "rsb2", "legacy.icycloud.io/managed").Return(true, "node/rsb2 unlabeled", nil)
+			},
+			expectedTotalNodes:   2,
+			expectedSuccessNodes: 2,
+			expectedRemoved:      map[string][]string{"rsb2": {"-legacy.icycloud.io/managed"}},
+		},
+		{
+			name:        "dry_run_reports_diff",
+			description: "Dry run should enable executor dry-run and still report what would be removed",
+			spec: config.CleanupSpec{
+				LabelPrefixes: []string{"openstack-"},
+				Nodes:         []string{"rsb2"},
+			},
+			dryRun: true,
+			mockSetupFunc: func(mockKubectl *MockDryRunExecutor) {
+				mockKubectl.On("SetDryRun", true).Return()
+				mockKubectl.On("GetNodeLabels", mock.Anything, "rsb2").
+					Return(true, showLabels("rsb2", "openstack-role=compute"), nil)
+				mockKubectl.On("UnlabelNode", mock.Anything, "rsb2", "openstack-role").Return(true, "node/rsb2 unlabeled", nil)
+			},
+			expectedTotalNodes:   1,
+			expectedSuccessNodes: 1,
+			expectedRemoved:      map[string][]string{"rsb2": {"-openstack-role"}},
+		},
+		{
+			name:        "missing_node_fails",
+			description: "Nodes that cannot be read should be reported as failed",
+			spec: config.CleanupSpec{
+				LabelPrefixes: []string{"openstack-role"},
+				Nodes:         []string{"rbs2"},
+			},
+			mockSetupFunc: func(mockKubectl *MockDryRunExecutor) {
+				mockKubectl.On("SetDryRun", false).Return()
+				mockKubectl.On("GetNodeLabels", mock.Anything, "rbs2").Return(false, "", fmt.Errorf("not found"))
+				mockKubectl.On("GetAllNodes", mock.Anything).Return(true, "node/rsb2", nil)
+			},
+			expectedTotalNodes:   1,
+			expectedSuccessNodes: 0,
+			expectedFailedNodes:  []string{"rbs2"},
+			expectedRemoved:      map[string][]string{},
+		},
+		{
+			name:        "selector_failure",
+			description: "A failing selector lookup should abort the cleanup",
+			spec: config.CleanupSpec{
+				LabelPrefixes: []string{"openstack-role"},
+				NodeSelector:  "bad selector",
+			},
+			mockSetupFunc: func(mockKubectl *MockDryRunExecutor) {
+				mockKubectl.On("SetDryRun", false).Return()
+				mockKubectl.On("GetNodesByLabel", mock.Anything, "bad selector").Return(false, "", fmt.Errorf("invalid selector"))
+			},
+			shouldError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: Mocked cluster state
+			mockKubectl := NewMockDryRunExecutor()
+			mockLogger := logging.NewMockLogger()
+			mockLogger.On("Info", mock.AnythingOfType("string")).Return().Maybe()
+			mockLogger.On("Debug", mock.AnythingOfType("string")).Return().Maybe()
+			mockLogger.On("Warn", mock.AnythingOfType("string")).Return().Maybe()
+			mockLogger.On("Error", mock.AnythingOfType("string")).Return().Maybe()
+			tt.mockSetupFunc(mockKubectl)
+
+			service := NewService(mockKubectl, Options{DryRun: tt.dryRun, Logger: mockLogger})
+			cleanup := &config.CleanupConf{Spec: tt.spec}
+
+			// When: Run cleanup
+			results, err := service.CleanupLabels(context.Background(), cleanup)
+
+			// Then: Verify results
+			if tt.shouldError {
+				assert.Error(t, err, tt.description)
+				return
+			}
+			require.NoError(t, err, tt.description)
+			assert.Equal(t, tt.expectedTotalNodes, results.TotalNodes, tt.description)
+			assert.Equal(t, tt.expectedSuccessNodes, results.SuccessfulNodes, tt.description)
+			assert.Equal(t, tt.expectedFailedNodes, results.FailedNodes, tt.description)
+			assert.Equal(t, tt.expectedRemoved, results.AppliedLabels, tt.description)
+			mockKubectl.AssertExpectations(t)
+		})
+	}
+}
+
+// TestMatchLabelPrefixes tests prefix matching on label keys
+// WHY: Values must never be considered, only keys
+func TestMatchLabelPrefixes(t *testing.T) {
+	labels := map[string]string{
+		"openstack-role":         "compute",
+		"zone":                   "openstack-role",
+		"legacy.icycloud.io/foo": "bar",
+	}
+
+	assert.Equal(t, []string{"legacy.icycloud.io/foo", "openstack-role"},
+		matchLabelPrefixes(labels, []string{"openstack-role", "legacy.icycloud.io/"}))
+	assert.Empty(t, matchLabelPrefixes(labels, []string{"missing"}))
+}
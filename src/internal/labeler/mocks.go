@@ -27,6 +27,12 @@ func (m *MockDryRunExecutor) LabelNode(ctx context.Context, nodeName, label stri
 	return args.Bool(0), args.String(1), args.Error(2)
 }
 
+// LabelNodes mocks bulk node labeling operations
+func (m *MockDryRunExecutor) LabelNodes(ctx context.Context, nodeNames []string, labels map[string]string, overwrite bool) (bool, string, error) {
+	args := m.Called(ctx, nodeNames, labels, overwrite)
+	return args.Bool(0), args.String(1), args.Error(2)
+}
+
 // UnlabelNode mocks node label removal operations
 func (m *MockDryRunExecutor) UnlabelNode(ctx context.Context, nodeName, labelKey string) (bool, string, error) {
 	args := m.Called(ctx, nodeName, labelKey)
@@ -39,6 +45,18 @@ func (m *MockDryRunExecutor) GetNodeLabels(ctx context.Context, nodeName string)
 	return args.Bool(0), args.String(1), args.Error(2)
 }
 
+// GetNodeAnnotations mocks node annotation retrieval
+func (m *MockDryRunExecutor) GetNodeAnnotations(ctx context.Context, nodeName string) (bool, string, error) {
+	args := m.Called(ctx, nodeName)
+	return args.Bool(0), args.String(1), args.Error(2)
+}
+
+// GetNodeInternalIP mocks node InternalIP retrieval
+func (m *MockDryRunExecutor) GetNodeInternalIP(ctx context.Context, nodeName string) (bool, string, error) {
+	args := m.Called(ctx, nodeName)
+	return args.Bool(0), args.String(1), args.Error(2)
+}
+
 // ExecNodeCommand mocks node command execution
 func (m *MockDryRunExecutor) ExecNodeCommand(ctx context.Context, nodeName, command string) (bool, string, error) {
 	args := m.Called(ctx, nodeName, command)
@@ -57,78 +75,83 @@ func (m *MockDryRunExecutor) DeletePod(ctx context.Context, podName string) (boo
 	return args.Bool(0), args.String(1), args.Error(2)
 }
 
-// SetDryRun enables or disables dry-run mode
-func (m *MockDryRunExecutor) SetDryRun(enabled bool) {
-	m.dryRun = enabled
-	m.Called(enabled)
+// ReleaseDebugPods mocks releasing the node command pods of a run
+func (m *MockDryRunExecutor) ReleaseDebugPods(ctx context.Context) (int, error) {
+	args := m.Called(ctx)
+	return args.Int(0), args.Error(1)
 }
 
-// IsDryRun returns whether dry-run mode is enabled
-func (m *MockDryRunExecutor) IsDryRun() bool {
-	args := m.Called()
-	return args.Bool(0)
+// GetAllNodes mocks cluster node listing
+func (m *MockDryRunExecutor) GetAllNodes(ctx context.Context) (bool, string, error) {
+	args := m.Called(ctx)
+	return args.Bool(0), args.String(1), args.Error(2)
 }
 
-// SetPollingInterval mocks the polling interval configuration
-func (m *MockDryRunExecutor) SetPollingInterval(interval time.Duration) {
-	m.Called(interval)
+// GetNodesByLabel mocks node listing by label selector
+func (m *MockDryRunExecutor) GetNodesByLabel(ctx context.Context, labelSelector string) (bool, string, error) {
+	args := m.Called(ctx, labelSelector)
+	return args.Bool(0), args.String(1), args.Error(2)
 }
 
-// MockLogger mocks the kubectl.Logger interface for test output verification
-type MockLogger struct {
-	mock.Mock
-	Messages []LogMessage
+// GetNodeRole mocks node role discovery
+func (m *MockDryRunExecutor) GetNodeRole(ctx context.Context, nodeName string) (string, error) {
+	args := m.Called(ctx, nodeName)
+	return args.String(0), args.Error(1)
 }
 
-// LogMessage captures structured log data for assertions
-type LogMessage struct {
-	Level   string
-	Message string
+// DiscoverClusterState mocks cluster overview discovery
+func (m *MockDryRunExecutor) DiscoverClusterState(ctx context.Context) (map[string]interface{}, error) {
+	args := m.Called(ctx)
+	state, _ := args.Get(0).(map[string]interface{})
+	return state, args.Error(1)
 }
 
-// Debug captures debug messages
-func (m *MockLogger) Debug(message string) {
-	m.Messages = append(m.Messages, LogMessage{"DEBUG", message})
-	m.Called(message)
+// DiscoverNodeVLANs mocks VLAN discovery on a node
+func (m *MockDryRunExecutor) DiscoverNodeVLANs(ctx context.Context, nodeName string) (bool, string, error) {
+	args := m.Called(ctx, nodeName)
+	return args.Bool(0), args.String(1), args.Error(2)
 }
 
-// Info captures info messages
-func (m *MockLogger) Info(message string) {
-	m.Messages = append(m.Messages, LogMessage{"INFO", message})
-	m.Called(message)
+// DiscoverAllVLANs mocks VLAN discovery across the cluster
+func (m *MockDryRunExecutor) DiscoverAllVLANs(ctx context.Context) (map[string]string, error) {
+	args := m.Called(ctx)
+	vlans, _ := args.Get(0).(map[string]string)
+	return vlans, args.Error(1)
 }
 
-// Warn captures warning messages
-func (m *MockLogger) Warn(message string) {
-	m.Messages = append(m.Messages, LogMessage{"WARN", message})
-	m.Called(message)
+// GetNodeNetworkInfo mocks network information retrieval
+func (m *MockDryRunExecutor) GetNodeNetworkInfo(ctx context.Context, nodeName string) (bool, string, error) {
+	args := m.Called(ctx, nodeName)
+	return args.Bool(0), args.String(1), args.Error(2)
 }
 
-// Error captures error messages
-func (m *MockLogger) Error(message string) {
-	m.Messages = append(m.Messages, LogMessage{"ERROR", message})
-	m.Called(message)
+// GetNodeHardwareInfo mocks hardware information retrieval
+func (m *MockDryRunExecutor) GetNodeHardwareInfo(ctx context.Context, nodeName string) (bool, string, error) {
+	args := m.Called(ctx, nodeName)
+	return args.Bool(0), args.String(1), args.Error(2)
 }
 
-// GetMessages returns all captured messages for test assertions
-func (m *MockLogger) GetMessages() []LogMessage {
-	return m.Messages
+// RunKubectl mocks running a kubectl command
+func (m *MockDryRunExecutor) RunKubectl(ctx context.Context, stdin []byte, args ...string) (string, error) {
+	callArgs := m.Called(ctx, stdin, args)
+	return callArgs.String(0), callArgs.Error(1)
 }
 
-// GetMessagesByLevel returns messages filtered by log level
-func (m *MockLogger) GetMessagesByLevel(level string) []LogMessage {
-	var filtered []LogMessage
-	for _, msg := range m.Messages {
-		if msg.Level == level {
-			filtered = append(filtered, msg)
-		}
-	}
-	return filtered
+// SetDryRun enables or disables dry-run mode
+func (m *MockDryRunExecutor) SetDryRun(enabled bool) {
+	m.dryRun = enabled
+	m.Called(enabled)
 }
 
-// Clear resets captured messages for fresh test runs
-func (m *MockLogger) Clear() {
-	m.Messages = []LogMessage{}
+// IsDryRun returns whether dry-run mode is enabled
+func (m *MockDryRunExecutor) IsDryRun() bool {
+	args := m.Called()
+	return args.Bool(0)
+}
+
+// SetPollingInterval mocks the polling interval configuration
+func (m *MockDryRunExecutor) SetPollingInterval(interval time.Duration) {
+	m.Called(interval)
 }
 
 // NewMockDryRunExecutor creates a new mock executor for testing
@@ -136,11 +159,3 @@ func (m *MockLogger) Clear() {
 func NewMockDryRunExecutor() *MockDryRunExecutor {
 	return &MockDryRunExecutor{}
 }
-
-// NewMockLogger creates a new mock logger for testing
-// WHY: Enables verification of logging behavior and structured message capture
-func NewMockLogger() *MockLogger {
-	return &MockLogger{
-		Messages: make([]LogMessage, 0),
-	}
-}
@@ -0,0 +1,114 @@
+package labeler
+
+import (
+	"context"
+	"sort"
+
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/kubectl"
+	"k8ostack-ictl/internal/plan"
+)
+
+// PlanLabels diffs current node labels against the labels an apply would leave behind
+// Cleanup prefixes are removed first and configured labels applied on top, matching apply order.
+// Either argument may be nil. Nothing on the cluster is changed.
+func (ls *LabelingService) PlanLabels(ctx context.Context, cfg config.Config, cleanup *config.CleanupConf) (*plan.Plan, error) {
+	result := &plan.Plan{}
+
+	desired := make(map[string]map[string]string)
+	if cfg != nil {
+		roles, err := ls.resolveNodeRoles(ctx, cfg)
+		if err != nil {
+			return nil, err
+		}
+		for _, roleConfig := range roles {
+			for _, nodeName := range roleConfig.Nodes {
+				if desired[nodeName] == nil {
+					desired[nodeName] = make(map[string]string)
+				}
+				for key, value := range roleConfig.Labels {
+					desired[nodeName][key] = value
+				}
+			}
+		}
+	}
+
+	cleanupNodes := make(map[string]bool)
+	var prefixes []string
+	if cleanup != nil {
+		nodes, err := ls.resolveCleanupNodes(ctx, cleanup.Spec)
+		if err != nil {
+			return nil, err
+		}
+		for _, nodeName := range nodes {
+			cleanupNodes[nodeName] = true
+		}
+		prefixes = cleanup.Spec.LabelPrefixes
+	}
+
+	for _, nodeName := range planNodes(desired, cleanupNodes) {
+		success, output, err := ls.kubectl.GetNodeLabels(ctx, nodeName)
+		if err != nil || !success {
+			result.Errors = append(result.Errors, kubectl.NewNodeNotFoundError(nodeName, ls.nodes.Names(ctx), err))
+			continue
+		}
+
+		current := kubectl.ParseNodeLabels(output)
+		target := make(map[string]string, len(current))
+		for key, value := range current {
+			target[key] = value
+		}
+		if cleanupNodes[nodeName] {
+			for _, key := range matchLabelPrefixes(current, prefixes) {
+				delete(target, key)
+			}
+		}
+		for key, value := range desired[nodeName] {
+			target[key] = value
+		}
+
+		result.Changes = append(result.Changes, diffLabels(nodeName, current, target)...)
+	}
+
+	return result, nil
+}
+
+// planNodes returns the sorted union of configured and cleanup nodes
+func planNodes(desired map[string]map[string]string, cleanupNodes map[string]bool) []string {
+	unique := make(map[string]bool)
+	for nodeName := range desired {
+		unique[nodeName] = true
+	}
+	for nodeName := range cleanupNodes {
+		unique[nodeName] = true
+	}
+
+	nodes := make([]string, 0, len(unique))
+	for nodeName := range unique {
+		nodes = append(nodes, nodeName)
+	}
+	sort.Strings(nodes)
+	return nodes
+}
+
+// diffLabels returns the key-sorted label changes from current to target
+func diffLabels(nodeName string, current, target map[string]string) []plan.Change {
+	var changes []plan.Change
+	for key, newValue := range target {
+		oldValue, exists := current[key]
+		switch {
+		case !exists:
+			changes = append(changes, plan.Change{Node: nodeName, Kind: "label", Key: key, Op: plan.OpAdd, NewValue: newValue})
+		case oldValue != newValue:
+			changes = append(changes, plan.Change{Node: nodeName, Kind: "label", Key: key, Op: plan.OpChange, OldValue: oldValue, NewValue: newValue})
+		}
+	}
+	for key, oldValue := range current {
+		if _, kept := target[key]; !kept {
+			changes = append(changes, plan.Change{Node: nodeName, Kind: "label", Key: key, Op: plan.OpRemove, OldValue: oldValue})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Key < changes[j].Key })
+	return changes
+}
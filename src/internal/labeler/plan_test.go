@@ -0,0 +1,103 @@
+// Package labeler provides unit tests for label plans
+// WHY: Plans are shown before apply, so they must predict exactly what apply and cleanup will do
+package labeler
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/logging"
+	"k8ostack-ictl/internal/plan"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLabelingService_PlanLabels tests diffing current labels against the configuration
+// WHY: Additions, value changes and cleanup removals must each be reported once per key
+func TestLabelingService_PlanLabels(t *testing.T) {
+	labelConfig := config.NodeLabelConf{
+		Spec: config.NodeLabelSpec{
+			NodeRoles: map[string]config.NodeRole{
+				"compute": {
+					Nodes:  []string{"rsb2"},
+					Labels: map[string]string{"openstack-role": "compute", "zone": "b"},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name            string
+		description     string
+		cfg             config.Config
+		cleanup         *config.CleanupConf
+		mockSetupFunc   func(*MockDryRunExecutor)
+		expectedChanges []plan.Change
+		expectedErrors  int
+	}{
+		{
+			name:        "add_and_change",
+			description: "Missing keys are added and differing values changed",
+			cfg:         labelConfig,
+			mockSetupFunc: func(mockKubectl *MockDryRunExecutor) {
+				mockKubectl.On("GetNodeLabels", mock.Anything, "rsb2").Return(true, showLabels("rsb2", "kubernetes.io/hostname=rsb2,zone=a"), nil)
+			},
+			expectedChanges: []plan.Change{
+				{Node: "rsb2", Kind: "label", Key: "openstack-role", Op: plan.OpAdd, NewValue: "compute"},
+				{Node: "rsb2", Kind: "label", Key: "zone", Op: plan.OpChange, OldValue: "a", NewValue: "b"},
+			},
+		},
+		{
+			name:        "cleanup_then_reapply",
+			description: "Cleanup removals are reported unless the configuration sets the same label again",
+			cfg:         labelConfig,
+			cleanup: &config.CleanupConf{Spec: config.CleanupSpec{
+				LabelPrefixes: []string{"openstack-", "legacy/"},
+				Nodes:         []string{"rsb2", "rsb3"},
+			}},
+			mockSetupFunc: func(mockKubectl *MockDryRunExecutor) {
+				mockKubectl.On("GetNodeLabels", mock.Anything, "rsb2").Return(true, showLabels("rsb2", "openstack-role=compute,openstack-old=1,zone=b"), nil)
+				mockKubectl.On("GetNodeLabels", mock.Anything, "rsb3").Return(true, showLabels("rsb3", "legacy/flag=true"), nil)
+			},
+			expectedChanges: []plan.Change{
+				{Node: "rsb2", Kind: "label", Key: "openstack-old", Op: plan.OpRemove, OldValue: "1"},
+				{Node: "rsb3", Kind: "label", Key: "legacy/flag", Op: plan.OpRemove, OldValue: "true"},
+			},
+		},
+		{
+			name:        "unreadable_node",
+			description: "Nodes whose labels cannot be read are reported as errors, not changes",
+			cfg:         labelConfig,
+			mockSetupFunc: func(mockKubectl *MockDryRunExecutor) {
+				mockKubectl.On("GetNodeLabels", mock.Anything, "rsb2").Return(false, "", fmt.Errorf("not found"))
+				mockKubectl.On("GetAllNodes", mock.Anything).Return(true, "node/rsb3", nil)
+			},
+			expectedErrors: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: Mocked node labels
+			mockKubectl := NewMockDryRunExecutor()
+			mockLogger := logging.NewMockLogger()
+			tt.mockSetupFunc(mockKubectl)
+			service := NewService(mockKubectl, Options{Logger: mockLogger})
+
+			// When: Plan
+			result, err := service.PlanLabels(context.Background(), tt.cfg, tt.cleanup)
+
+			// Then: Only the expected changes are pending and nothing was modified
+			require.NoError(t, err, tt.description)
+			assert.Equal(t, tt.expectedChanges, result.Changes, tt.description)
+			assert.Len(t, result.Errors, tt.expectedErrors, tt.description)
+			mockKubectl.AssertExpectations(t)
+			mockKubectl.AssertNotCalled(t, "LabelNode", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			mockKubectl.AssertNotCalled(t, "UnlabelNode", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
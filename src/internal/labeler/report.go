@@ -0,0 +1,95 @@
+package labeler
+
+import (
+	"context"
+	"sort"
+
+	"k8ostack-ictl/internal/config"
+)
+
+// NodeLabelReport is what the configuration says one node should look like: its roles and their merged labels
+type NodeLabelReport struct {
+	Node      string
+	Roles     []string                     // roles targeting the node, sorted
+	Labels    map[string]string            // merged labels, without the keys in Conflicts
+	Sources   map[string][]string          // label key -> what sets it, e.g. its roles
+	Conflicts map[string]map[string]string // label key -> source -> value, for keys sources set to different values
+}
+
+// NewNodeLabelReport creates an empty report of a node
+func NewNodeLabelReport(nodeName string) *NodeLabelReport {
+	return &NodeLabelReport{
+		Node:      nodeName,
+		Labels:    make(map[string]string),
+		Sources:   make(map[string][]string),
+		Conflicts: make(map[string]map[string]string),
+	}
+}
+
+// Add records that source sets a label, turning the key into a conflict when another source set another value
+// Apply sets conflicting keys in no defined order, so the report names no winner.
+func (r *NodeLabelReport) Add(source, key, value string) {
+	if values, conflicting := r.Conflicts[key]; conflicting {
+		values[source] = value
+	} else if current, exists := r.Labels[key]; exists && current != value {
+		values := make(map[string]string, len(r.Sources[key])+1)
+		for _, other := range r.Sources[key] {
+			values[other] = current
+		}
+		values[source] = value
+		r.Conflicts[key] = values
+		delete(r.Labels, key)
+	} else {
+		r.Labels[key] = value
+	}
+	r.Sources[key] = append(r.Sources[key], source)
+}
+
+// InvertRoles returns one report per node the roles target, sorted by node name
+// The roles must list resolved node names, see ReportLabels.
+func InvertRoles(roles map[string]config.NodeRole) []*NodeLabelReport {
+	roleNames := make([]string, 0, len(roles))
+	for roleName := range roles {
+		roleNames = append(roleNames, roleName)
+	}
+	sort.Strings(roleNames)
+
+	reports := make(map[string]*NodeLabelReport)
+	for _, roleName := range roleNames {
+		role := roles[roleName]
+		keys := make([]string, 0, len(role.Labels))
+		for key := range role.Labels {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, nodeName := range role.Nodes {
+			report, ok := reports[nodeName]
+			if !ok {
+				report = NewNodeLabelReport(nodeName)
+				reports[nodeName] = report
+			}
+			report.Roles = append(report.Roles, roleName)
+			for _, key := range keys {
+				report.Add(roleName, key, role.Labels[key])
+			}
+		}
+	}
+
+	sorted := make([]*NodeLabelReport, 0, len(reports))
+	for _, report := range reports {
+		sorted = append(sorted, report)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Node < sorted[j].Node })
+	return sorted
+}
+
+// ReportLabels resolves the roles of the configuration against the cluster and inverts them into one report per node
+// Nothing on the cluster is changed.
+func (ls *LabelingService) ReportLabels(ctx context.Context, cfg config.Config) ([]*NodeLabelReport, error) {
+	roles, err := ls.resolveNodeRoles(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return InvertRoles(roles), nil
+}
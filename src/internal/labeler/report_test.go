@@ -0,0 +1,64 @@
+// Package labeler provides unit tests for the per-node label report
+// WHY: During incidents the report must say exactly which roles give a node which labels
+package labeler
+
+import (
+	"context"
+	"testing"
+
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/logging"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestInvertRoles tests turning roles into the roles and merged labels of each node
+// WHY: Keys two roles set to different values have no defined winner and must be reported as conflicts
+func TestInvertRoles(t *testing.T) {
+	// Given: rsb2 in two roles that agree on zone and disagree on tier
+	roles := map[string]config.NodeRole{
+		"compute": {Nodes: []string{"rsb2", "rsb3"}, Labels: map[string]string{"openstack-role": "compute", "zone": "a", "tier": "gold"}},
+		"storage": {Nodes: []string{"rsb2"}, Labels: map[string]string{"ceph-osd": "enabled", "zone": "a", "tier": "silver"}},
+	}
+
+	// When: Invert the roles
+	reports := InvertRoles(roles)
+
+	// Then: Each node lists its roles, merged labels, the roles setting them and the conflicts
+	require.Len(t, reports, 2)
+	rsb2 := reports[0]
+	assert.Equal(t, "rsb2", rsb2.Node)
+	assert.Equal(t, []string{"compute", "storage"}, rsb2.Roles)
+	assert.Equal(t, map[string]string{"openstack-role": "compute", "ceph-osd": "enabled", "zone": "a"}, rsb2.Labels)
+	assert.Equal(t, []string{"compute", "storage"}, rsb2.Sources["zone"])
+	assert.Equal(t, map[string]map[string]string{"tier": {"compute": "gold", "storage": "silver"}}, rsb2.Conflicts)
+
+	rsb3 := reports[1]
+	assert.Equal(t, []string{"compute"}, rsb3.Roles)
+	assert.Equal(t, map[string]string{"openstack-role": "compute", "zone": "a", "tier": "gold"}, rsb3.Labels)
+	assert.Empty(t, rsb3.Conflicts)
+}
+
+// TestLabelingService_ReportLabels tests resolving node patterns before inverting the roles
+// WHY: A node matched by pattern only must still appear with the labels of its role
+func TestLabelingService_ReportLabels(t *testing.T) {
+	// Given: A role matching rsb* in a cluster of rsb2 and ctl1
+	mockKubectl := NewMockDryRunExecutor()
+	mockKubectl.On("GetAllNodes", mock.Anything).Return(true, "node/rsb2\nnode/ctl1", nil)
+	service := NewService(mockKubectl, Options{Logger: logging.NewRecordingLogger()})
+	labelConfig := &config.NodeLabelConf{Spec: config.NodeLabelSpec{NodeRoles: map[string]config.NodeRole{
+		"compute": {Nodes: []string{"rsb*"}, Labels: map[string]string{"openstack-role": "compute"}},
+	}}}
+
+	// When: Report the labels
+	reports, err := service.ReportLabels(context.Background(), labelConfig)
+
+	// Then: Only the matched node is reported
+	require.NoError(t, err)
+	require.Len(t, reports, 1)
+	assert.Equal(t, "rsb2", reports[0].Node)
+	assert.Equal(t, map[string]string{"openstack-role": "compute"}, reports[0].Labels)
+	mockKubectl.AssertExpectations(t)
+}
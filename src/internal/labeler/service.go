@@ -4,8 +4,12 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"sync"
 
 	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/kubectl"
+	"k8ostack-ictl/internal/logging"
+	"k8ostack-ictl/internal/progress"
 
 	"golang.org/x/text/cases"
 	"golang.org/x/text/language"
@@ -29,7 +33,13 @@ func (ls *LabelingService) VerifyLabels(ctx context.Context, cfg config.Config)
 
 	ls.options.Logger.Info("🔍 Verifying applied labels...")
 
-	for _, roleConfig := range cfg.GetNodeRoles() {
+	roles, err := ls.resolveNodeRoles(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	results.ResolvedNodes = resolvedNodes(roles)
+
+	for _, roleConfig := range roles {
 		for _, nodeName := range roleConfig.Nodes {
 			results.TotalNodes++
 
@@ -37,7 +47,7 @@ func (ls *LabelingService) VerifyLabels(ctx context.Context, cfg config.Config)
 			if err != nil {
 				ls.options.Logger.Error(fmt.Sprintf("Failed to verify labels on node %s: %v", nodeName, err))
 				results.FailedNodes = append(results.FailedNodes, nodeName)
-				results.Errors = append(results.Errors, err)
+				results.Errors = append(results.Errors, kubectl.WrapNodeError(nodeName, err))
 				continue
 			}
 
@@ -70,16 +80,13 @@ func (ls *LabelingService) GetCurrentState(ctx context.Context, nodes []string)
 	state := make(map[string]map[string]string)
 
 	for _, nodeName := range nodes {
-		success, _, err := ls.kubectl.GetNodeLabels(ctx, nodeName)
+		success, output, err := ls.kubectl.GetNodeLabels(ctx, nodeName)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get labels for node %s: %w", nodeName, err)
 		}
 
 		if success {
-			// Parse labels from output - this is a simplified implementation
-			nodeLabels := make(map[string]string)
-			// TODO: Implement proper label parsing from kubectl output
-			state[nodeName] = nodeLabels
+			state[nodeName] = kubectl.ParseNodeLabels(output)
 		}
 	}
 
@@ -115,7 +122,19 @@ func (ls *LabelingService) processLabels(ctx context.Context, cfg config.Config,
 			operationName, configName, cfg.GetKind(), cfg.GetAPIVersion()))
 	}
 
-	for role, roleConfig := range cfg.GetNodeRoles() {
+	roles, err := ls.resolveNodeRoles(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	results.ResolvedNodes = resolvedNodes(roles)
+
+	total := 0
+	for _, roleConfig := range roles {
+		total += len(roleConfig.Nodes)
+	}
+	ls.progress().Begin(fmt.Sprintf("%s labels", caser.String(operationName)), total)
+
+	for role, roleConfig := range roles {
 		roleName := caser.String(strings.ReplaceAll(role, "_", " "))
 
 		ls.options.Logger.Info(fmt.Sprintf("Processing %s role with %d nodes...", roleName, len(roleConfig.Nodes)))
@@ -130,18 +149,42 @@ func (ls *LabelingService) processLabels(ctx context.Context, cfg config.Config,
 		}
 		ls.options.Logger.Info(fmt.Sprintf("  Labels: %s", strings.Join(labelList, ", ")))
 
-		for _, nodeName := range roleConfig.Nodes {
-			results.TotalNodes++
-			ls.options.Logger.Info(fmt.Sprintf("  Processing node: %s", nodeName))
+		nodes := roleConfig.Nodes
+		if operation == "apply" && ls.options.BulkLabel {
+			nodes = ls.applyRoleBulk(ctx, nodes, roleConfig.Labels, results)
+		}
 
-			if ls.processNodeLabels(ctx, nodeName, roleConfig.Labels, operation, results) {
+		results.Order = append(results.Order, ls.options.Workers.Order(nodes)...)
+		var mu sync.Mutex
+		err := ls.options.Workers.Run(nodes, func(nodeName string) {
+			node := ls.forNode(nodeName)
+			node.options.Logger.Info(fmt.Sprintf("  Processing node: %s", nodeName))
+			ls.progress().Started(nodeName)
+
+			nodeResults := &OperationResults{AppliedLabels: make(map[string][]string)}
+			success := node.processNodeLabels(ctx, nodeName, roleConfig.Labels, operation, nodeResults)
+			ls.progress().Done(nodeName, !success)
+
+			mu.Lock()
+			defer mu.Unlock()
+			results.TotalNodes++
+			if success {
 				results.SuccessfulNodes++
 			}
+			results.merge(nodeResults)
+		})
+		if err != nil {
+			// A batch failed its soak, so the later roles are left alone too
+			ls.options.Logger.Error(fmt.Sprintf("Stopped at %s role: %v", roleName, err))
+			results.Errors = append(results.Errors, err)
+			break
 		}
 
 		ls.options.Logger.Info(fmt.Sprintf("Completed %s role processing", roleName))
 	}
 
+	ls.progress().End()
+
 	// Print summary
 	ls.options.Logger.Info(strings.Repeat("=", 50))
 	ls.options.Logger.Info("📊 Operation Summary:")
@@ -156,17 +199,28 @@ func (ls *LabelingService) processLabels(ctx context.Context, cfg config.Config,
 	return results, nil
 }
 
+// progress returns the reporter of the service, which discards progress when none is set
+func (ls *LabelingService) progress() progress.Reporter {
+	return progress.Or(ls.options.Progress)
+}
+
+// forNode returns a copy of the service whose log entries carry the node name
+func (ls *LabelingService) forNode(nodeName string) *LabelingService {
+	node := *ls
+	node.options.Logger = ls.options.Logger.With(logging.FieldNode, nodeName)
+	return &node
+}
+
 // processNodeLabels processes labels for a single node
 func (ls *LabelingService) processNodeLabels(ctx context.Context, nodeName string, labels map[string]string, operation string, results *OperationResults) bool {
 	// Check if node exists
 	if ls.options.ValidateNodes {
 		success, _, err := ls.kubectl.GetNode(ctx, nodeName)
 		if err != nil || !success {
-			ls.options.Logger.Error(fmt.Sprintf("Node %s does not exist in the cluster", nodeName))
+			notFound := kubectl.NewNodeNotFoundError(nodeName, ls.nodes.Names(ctx), err)
+			ls.options.Logger.Error(notFound.Error())
 			results.FailedNodes = append(results.FailedNodes, nodeName)
-			if err != nil {
-				results.Errors = append(results.Errors, err)
-			}
+			results.Errors = append(results.Errors, notFound)
 			return false
 		}
 	}
@@ -197,7 +251,7 @@ func (ls *LabelingService) processNodeLabels(ctx context.Context, nodeName strin
 		if err != nil {
 			ls.options.Logger.Error(fmt.Sprintf("Failed to process label %s on node %s: %v", labelKey, nodeName, err))
 			allSuccess = false
-			results.Errors = append(results.Errors, err)
+			results.Errors = append(results.Errors, kubectl.WrapNodeError(nodeName, err))
 		}
 	}
 
@@ -8,9 +8,13 @@ import (
 	"testing"
 
 	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/kubectl"
+	"k8ostack-ictl/internal/logging"
+	"k8ostack-ictl/internal/throttle"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // TestLabelingService_ApplyLabels tests the core label application business logic
@@ -20,7 +24,7 @@ func TestLabelingService_ApplyLabels(t *testing.T) {
 		name                 string
 		description          string
 		nodeConfig           map[string]config.NodeRole
-		mockSetupFunc        func(*MockDryRunExecutor, *MockLogger)
+		mockSetupFunc        func(*MockDryRunExecutor, *logging.MockLogger)
 		expectedTotalNodes   int
 		expectedSuccessNodes int
 		expectedFailedNodes  []string
@@ -36,7 +40,7 @@ func TestLabelingService_ApplyLabels(t *testing.T) {
 					Description: "Control plane node",
 				},
 			},
-			mockSetupFunc: func(mockKubectl *MockDryRunExecutor, mockLogger *MockLogger) {
+			mockSetupFunc: func(mockKubectl *MockDryRunExecutor, mockLogger *logging.MockLogger) {
 				// Mock dry-run setting
 				mockKubectl.On("SetDryRun", false).Return()
 
@@ -68,7 +72,7 @@ func TestLabelingService_ApplyLabels(t *testing.T) {
 					Description: "Control plane nodes",
 				},
 			},
-			mockSetupFunc: func(mockKubectl *MockDryRunExecutor, mockLogger *MockLogger) {
+			mockSetupFunc: func(mockKubectl *MockDryRunExecutor, mockLogger *logging.MockLogger) {
 				mockKubectl.On("SetDryRun", false).Return()
 
 				// Mock successful operations for both nodes
@@ -99,11 +103,12 @@ func TestLabelingService_ApplyLabels(t *testing.T) {
 					Description: "Worker node that doesn't exist",
 				},
 			},
-			mockSetupFunc: func(mockKubectl *MockDryRunExecutor, mockLogger *MockLogger) {
+			mockSetupFunc: func(mockKubectl *MockDryRunExecutor, mockLogger *logging.MockLogger) {
 				mockKubectl.On("SetDryRun", false).Return()
 
 				// Mock node not found
 				mockKubectl.On("GetNode", mock.Anything, "nonexistent-node").Return(false, "", nil)
+				mockKubectl.On("GetAllNodes", mock.Anything).Return(true, "node/rsb2\nnode/rsb3", nil)
 
 				// Logger should capture error and warn messages
 				mockLogger.On("Info", mock.AnythingOfType("string")).Return().Maybe()
@@ -125,7 +130,7 @@ func TestLabelingService_ApplyLabels(t *testing.T) {
 					Description: "Mix of good and bad nodes",
 				},
 			},
-			mockSetupFunc: func(mockKubectl *MockDryRunExecutor, mockLogger *MockLogger) {
+			mockSetupFunc: func(mockKubectl *MockDryRunExecutor, mockLogger *logging.MockLogger) {
 				mockKubectl.On("SetDryRun", false).Return()
 
 				// Good node succeeds
@@ -135,6 +140,7 @@ func TestLabelingService_ApplyLabels(t *testing.T) {
 
 				// Bad node fails
 				mockKubectl.On("GetNode", mock.Anything, "bad-node").Return(false, "", nil)
+				mockKubectl.On("GetAllNodes", mock.Anything).Return(true, "node/good-node", nil)
 
 				mockLogger.On("Info", mock.AnythingOfType("string")).Return().Maybe()
 				mockLogger.On("Error", mock.AnythingOfType("string")).Return().Maybe()
@@ -151,7 +157,7 @@ func TestLabelingService_ApplyLabels(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			// Given: Setup mocks and service
 			mockKubectl := NewMockDryRunExecutor()
-			mockLogger := NewMockLogger()
+			mockLogger := logging.NewMockLogger()
 
 			tt.mockSetupFunc(mockKubectl, mockLogger)
 
@@ -209,7 +215,7 @@ func TestLabelingService_RemoveLabels(t *testing.T) {
 		name                 string
 		description          string
 		nodeConfig           map[string]config.NodeRole
-		mockSetupFunc        func(*MockDryRunExecutor, *MockLogger)
+		mockSetupFunc        func(*MockDryRunExecutor, *logging.MockLogger)
 		expectedTotalNodes   int
 		expectedSuccessNodes int
 		expectedFailedNodes  []string
@@ -225,7 +231,7 @@ func TestLabelingService_RemoveLabels(t *testing.T) {
 					Description: "Control plane node",
 				},
 			},
-			mockSetupFunc: func(mockKubectl *MockDryRunExecutor, mockLogger *MockLogger) {
+			mockSetupFunc: func(mockKubectl *MockDryRunExecutor, mockLogger *logging.MockLogger) {
 				mockKubectl.On("SetDryRun", false).Return()
 				mockKubectl.On("GetNode", mock.Anything, "rsb2").Return(true, "node/rsb2", nil)
 				mockKubectl.On("UnlabelNode", mock.Anything, "rsb2", "node.openstack.io/control-plane").
@@ -247,9 +253,10 @@ func TestLabelingService_RemoveLabels(t *testing.T) {
 					Description: "Worker node that doesn't exist",
 				},
 			},
-			mockSetupFunc: func(mockKubectl *MockDryRunExecutor, mockLogger *MockLogger) {
+			mockSetupFunc: func(mockKubectl *MockDryRunExecutor, mockLogger *logging.MockLogger) {
 				mockKubectl.On("SetDryRun", false).Return()
 				mockKubectl.On("GetNode", mock.Anything, "nonexistent-node").Return(false, "", nil)
+				mockKubectl.On("GetAllNodes", mock.Anything).Return(false, "", fmt.Errorf("connection refused"))
 				mockLogger.On("Info", mock.AnythingOfType("string")).Return().Maybe()
 				mockLogger.On("Error", mock.AnythingOfType("string")).Return().Maybe()
 				mockLogger.On("Warn", mock.AnythingOfType("string")).Return().Maybe()
@@ -265,7 +272,7 @@ func TestLabelingService_RemoveLabels(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			// Given: Setup mocks and service
 			mockKubectl := NewMockDryRunExecutor()
-			mockLogger := NewMockLogger()
+			mockLogger := logging.NewMockLogger()
 
 			tt.mockSetupFunc(mockKubectl, mockLogger)
 
@@ -323,7 +330,7 @@ func TestLabelingService_VerifyLabels(t *testing.T) {
 		name                 string
 		description          string
 		nodeConfig           map[string]config.NodeRole
-		mockSetupFunc        func(*MockDryRunExecutor, *MockLogger)
+		mockSetupFunc        func(*MockDryRunExecutor, *logging.MockLogger)
 		expectedTotalNodes   int
 		expectedSuccessNodes int
 		expectedFailedNodes  []string
@@ -339,7 +346,7 @@ func TestLabelingService_VerifyLabels(t *testing.T) {
 					Description: "Control plane node",
 				},
 			},
-			mockSetupFunc: func(mockKubectl *MockDryRunExecutor, mockLogger *MockLogger) {
+			mockSetupFunc: func(mockKubectl *MockDryRunExecutor, mockLogger *logging.MockLogger) {
 				mockKubectl.On("GetNodeLabels", mock.Anything, "rsb2").
 					Return(true, "node.openstack.io/control-plane=true", nil)
 				mockLogger.On("Info", mock.AnythingOfType("string")).Return().Maybe()
@@ -360,7 +367,7 @@ func TestLabelingService_VerifyLabels(t *testing.T) {
 					Description: "Worker node",
 				},
 			},
-			mockSetupFunc: func(mockKubectl *MockDryRunExecutor, mockLogger *MockLogger) {
+			mockSetupFunc: func(mockKubectl *MockDryRunExecutor, mockLogger *logging.MockLogger) {
 				mockKubectl.On("GetNodeLabels", mock.Anything, "rsb3").
 					Return(true, "other-label=value", nil) // Missing expected label
 				mockLogger.On("Info", mock.AnythingOfType("string")).Return().Maybe()
@@ -377,7 +384,7 @@ func TestLabelingService_VerifyLabels(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			// Given: Setup mocks and service
 			mockKubectl := NewMockDryRunExecutor()
-			mockLogger := NewMockLogger()
+			mockLogger := logging.NewMockLogger()
 
 			tt.mockSetupFunc(mockKubectl, mockLogger)
 
@@ -435,7 +442,7 @@ func TestLabelingService_GetCurrentState(t *testing.T) {
 		name          string
 		description   string
 		nodes         []string
-		mockSetupFunc func(*MockDryRunExecutor, *MockLogger)
+		mockSetupFunc func(*MockDryRunExecutor, *logging.MockLogger)
 		expectedState map[string]map[string]string
 		shouldError   bool
 	}{
@@ -443,7 +450,7 @@ func TestLabelingService_GetCurrentState(t *testing.T) {
 			name:        "successful_state_discovery",
 			description: "Successfully discovers current state",
 			nodes:       []string{"rsb2", "rsb3"},
-			mockSetupFunc: func(mockKubectl *MockDryRunExecutor, mockLogger *MockLogger) {
+			mockSetupFunc: func(mockKubectl *MockDryRunExecutor, mockLogger *logging.MockLogger) {
 				mockKubectl.On("GetNodeLabels", mock.Anything, "rsb2").Return(true, "labels", nil)
 				mockKubectl.On("GetNodeLabels", mock.Anything, "rsb3").Return(true, "labels", nil)
 			},
@@ -453,11 +460,25 @@ func TestLabelingService_GetCurrentState(t *testing.T) {
 			},
 			shouldError: false,
 		},
+		{
+			name:        "labels_parsed_from_show_labels",
+			description: "Parses the LABELS column of kubectl get node --show-labels",
+			nodes:       []string{"rsb2"},
+			mockSetupFunc: func(mockKubectl *MockDryRunExecutor, mockLogger *logging.MockLogger) {
+				mockKubectl.On("GetNodeLabels", mock.Anything, "rsb2").Return(true,
+					"NAME   STATUS   ROLES    AGE   VERSION   LABELS\n"+
+						"rsb2   Ready    <none>   12d   v1.29.2   kubernetes.io/hostname=rsb2,openstack-control-plane=enabled", nil)
+			},
+			expectedState: map[string]map[string]string{
+				"rsb2": {"kubernetes.io/hostname": "rsb2", "openstack-control-plane": "enabled"},
+			},
+			shouldError: false,
+		},
 		{
 			name:        "state_discovery_failure",
 			description: "Handles failure during state discovery",
 			nodes:       []string{"failing-node"},
-			mockSetupFunc: func(mockKubectl *MockDryRunExecutor, mockLogger *MockLogger) {
+			mockSetupFunc: func(mockKubectl *MockDryRunExecutor, mockLogger *logging.MockLogger) {
 				mockKubectl.On("GetNodeLabels", mock.Anything, "failing-node").Return(false, "", assert.AnError)
 			},
 			expectedState: nil,
@@ -469,7 +490,7 @@ func TestLabelingService_GetCurrentState(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			// Given: Setup mocks and service
 			mockKubectl := NewMockDryRunExecutor()
-			mockLogger := NewMockLogger()
+			mockLogger := logging.NewMockLogger()
 
 			tt.mockSetupFunc(mockKubectl, mockLogger)
 
@@ -535,7 +556,7 @@ func TestLabelingService_DryRunMode(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			// Given: Setup mocks for dry-run mode
 			mockKubectl := NewMockDryRunExecutor()
-			mockLogger := NewMockLogger()
+			mockLogger := logging.NewMockLogger()
 
 			// Mock dry-run setting
 			mockKubectl.On("SetDryRun", tt.expectDryRun).Return()
@@ -597,7 +618,7 @@ func TestLabelingService_DryRunMode(t *testing.T) {
 // WHY: Covers the ValidateNodes=false code path that may be missed
 func TestLabelingService_ValidationDisabled(t *testing.T) {
 	mockKubectl := NewMockDryRunExecutor()
-	mockLogger := NewMockLogger()
+	mockLogger := logging.NewMockLogger()
 
 	// Mock dry-run setting but NO node validation calls
 	mockKubectl.On("SetDryRun", false).Return()
@@ -680,7 +701,7 @@ func TestLabelingService_EmptyConfiguration(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockKubectl := NewMockDryRunExecutor()
-			mockLogger := NewMockLogger()
+			mockLogger := logging.NewMockLogger()
 
 			mockKubectl.On("SetDryRun", false).Return()
 			mockLogger.On("Info", mock.AnythingOfType("string")).Return().Maybe()
@@ -748,7 +769,7 @@ func TestLabelingService_RoleNameFormatting(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockKubectl := NewMockDryRunExecutor()
-			mockLogger := NewMockLogger()
+			mockLogger := logging.NewMockLogger()
 
 			mockKubectl.On("SetDryRun", false).Return()
 			mockKubectl.On("GetNode", mock.Anything, "test-node").Return(true, "node/test-node", nil)
@@ -797,7 +818,7 @@ func TestLabelingService_RoleNameFormatting(t *testing.T) {
 // WHY: Covers the GetNodeLabels error path in VerifyLabels that might not be fully tested
 func TestLabelingService_VerifyLabels_GetNodeLabelsFailure(t *testing.T) {
 	mockKubectl := NewMockDryRunExecutor()
-	mockLogger := NewMockLogger()
+	mockLogger := logging.NewMockLogger()
 
 	// Mock GetNodeLabels failure
 	mockKubectl.On("GetNodeLabels", mock.Anything, "failing-node").
@@ -842,7 +863,7 @@ func TestLabelingService_VerifyLabels_GetNodeLabelsFailure(t *testing.T) {
 // WHY: Covers the success=false path in GetCurrentState that might not be tested
 func TestLabelingService_GetCurrentState_MixedSuccess(t *testing.T) {
 	mockKubectl := NewMockDryRunExecutor()
-	mockLogger := NewMockLogger()
+	mockLogger := logging.NewMockLogger()
 
 	// Mock mixed success - one succeeds, one has success=false (but no error)
 	mockKubectl.On("GetNodeLabels", mock.Anything, "good-node").
@@ -865,3 +886,75 @@ func TestLabelingService_GetCurrentState_MixedSuccess(t *testing.T) {
 
 	mockKubectl.AssertExpectations(t)
 }
+
+// TestLabelingService_NodeNotFoundSuggestions tests "did you mean" hints for missing nodes
+// WHY: Typos like rbs2 vs rsb2 should be obvious from the error instead of a bare "not found"
+func TestLabelingService_NodeNotFoundSuggestions(t *testing.T) {
+	// Given: Cluster with rsb2 but config references rbs2
+	mockKubectl := NewMockDryRunExecutor()
+	mockLogger := logging.NewMockLogger()
+	mockKubectl.On("SetDryRun", false).Return()
+	mockKubectl.On("GetNode", mock.Anything, "rbs2").Return(false, "", nil)
+	mockKubectl.On("GetAllNodes", mock.Anything).Return(true, "node/rsb2\nnode/compute-01", nil)
+	mockLogger.On("Info", mock.AnythingOfType("string")).Return().Maybe()
+	mockLogger.On("Error", mock.AnythingOfType("string")).Return().Maybe()
+	mockLogger.On("Warn", mock.AnythingOfType("string")).Return().Maybe()
+
+	service := NewService(mockKubectl, Options{ValidateNodes: true, Logger: mockLogger})
+	testConfig := &config.NodeLabelConf{
+		Kind:     "NodeLabelConf",
+		Metadata: config.Metadata{Name: "typo-config"},
+		Spec: config.NodeLabelSpec{NodeRoles: map[string]config.NodeRole{
+			"control": {Nodes: []string{"rbs2"}, Labels: map[string]string{"role": "control"}},
+		}},
+	}
+
+	// When: Apply labels
+	result, err := service.ApplyLabels(context.Background(), testConfig)
+
+	// Then: Error should carry the suggestion
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"rbs2"}, result.FailedNodes)
+	assert.Len(t, result.Errors, 1)
+	assert.Contains(t, result.Errors[0].Error(), "did you mean rsb2?")
+
+	var notFound *kubectl.NodeNotFoundError
+	assert.ErrorAs(t, result.Errors[0], &notFound)
+	assert.Equal(t, []string{"rsb2"}, notFound.Suggestions)
+}
+
+// TestLabelingService_ApplyLabels_Workers tests labeling the nodes of a role concurrently
+// WHY: Results gathered by concurrent workers must match a sequential run
+func TestLabelingService_ApplyLabels_Workers(t *testing.T) {
+	// Given: Six nodes, one of which refuses the label, and three workers
+	nodes := []string{"rsb1", "rsb2", "rsb3", "rsb4", "rsb5", "rsb6"}
+	mockKubectl := NewMockDryRunExecutor()
+	mockKubectl.On("SetDryRun", false).Return()
+	mockKubectl.On("LabelNode", mock.Anything, "rsb4", "role=compute", true).Return(false, "", fmt.Errorf("admission denied"))
+	mockKubectl.On("LabelNode", mock.Anything, mock.Anything, "role=compute", true).Return(true, "labeled", nil)
+	logger := logging.NewRecordingLogger()
+
+	service := NewService(mockKubectl, Options{
+		Workers: throttle.New(throttle.Options{MaxWorkers: 3, Logger: logger}),
+		Logger:  logger,
+	})
+	cfg := &config.NodeLabelConf{
+		Kind:     "NodeLabelConf",
+		Metadata: config.Metadata{Name: "workers"},
+		Spec: config.NodeLabelSpec{NodeRoles: map[string]config.NodeRole{
+			"compute": {Nodes: nodes, Labels: map[string]string{"role": "compute"}},
+		}},
+	}
+
+	// When: Apply labels
+	result, err := service.ApplyLabels(context.Background(), cfg)
+
+	// Then: Every node is counted once and only rsb4 failed
+	require.NoError(t, err)
+	assert.Equal(t, 6, result.TotalNodes)
+	assert.Equal(t, 5, result.SuccessfulNodes)
+	assert.Equal(t, []string{"rsb4"}, result.FailedNodes)
+	assert.Len(t, result.AppliedLabels, 5)
+	require.Len(t, result.Errors, 1)
+	assert.Equal(t, "rsb4", kubectl.NodeOf(result.Errors[0]))
+}
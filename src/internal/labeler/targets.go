@@ -0,0 +1,102 @@
+package labeler
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/kubectl"
+)
+
+// resolveNodeRoles returns the roles of the configuration with name patterns and node selectors resolved to node names
+// Roles listing plain names only are returned unchanged and cost no cluster query.
+func (ls *LabelingService) resolveNodeRoles(ctx context.Context, cfg config.Config) (map[string]config.NodeRole, error) {
+	var clusterNodes []string
+	listed := false
+	allNodes := func() ([]string, error) {
+		if !listed {
+			success, output, err := ls.kubectl.GetAllNodes(ctx)
+			if err != nil || !success {
+				return nil, fmt.Errorf("failed to list nodes: %v", err)
+			}
+			clusterNodes = kubectl.ParseNodeNames(output)
+			listed = true
+		}
+		return clusterNodes, nil
+	}
+
+	roles := make(map[string]config.NodeRole, len(cfg.GetNodeRoles()))
+	for roleName, role := range cfg.GetNodeRoles() {
+		if role.IsDynamic() {
+			nodes, err := ls.resolveRoleNodes(ctx, roleName, role, allNodes)
+			if err != nil {
+				return nil, err
+			}
+			role.Nodes = nodes
+			role.NodeSelector = ""
+		}
+		roles[roleName] = role
+	}
+	return roles, nil
+}
+
+// ResolveNodes returns the nodes each role of the configuration targets, resolving patterns and selectors against the cluster
+func (ls *LabelingService) ResolveNodes(ctx context.Context, cfg config.Config) (map[string][]string, error) {
+	roles, err := ls.resolveNodeRoles(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return resolvedNodes(roles), nil
+}
+
+// resolveRoleNodes returns the listed names of a role followed by the sorted nodes its patterns and selector match
+func (ls *LabelingService) resolveRoleNodes(ctx context.Context, roleName string, role config.NodeRole, allNodes func() ([]string, error)) ([]string, error) {
+	var matched []string
+	if len(role.NodePatterns()) > 0 {
+		names, err := allNodes()
+		if err != nil {
+			return nil, fmt.Errorf("role '%s': %w", roleName, err)
+		}
+		for _, name := range names {
+			if role.MatchesName(name) {
+				matched = append(matched, name)
+			}
+		}
+	}
+
+	if role.NodeSelector != "" {
+		success, output, err := ls.kubectl.GetNodesByLabel(ctx, role.NodeSelector)
+		if err != nil || !success {
+			return nil, fmt.Errorf("role '%s': failed to list nodes matching selector %s: %v", roleName, role.NodeSelector, err)
+		}
+		matched = append(matched, kubectl.ParseNodeNames(output)...)
+	}
+	sort.Strings(matched)
+
+	if len(matched) == 0 {
+		ls.options.Logger.Warn(fmt.Sprintf("⚠️  Role %s: no cluster node matches its patterns or nodeSelector", roleName))
+	} else if ls.options.Verbose {
+		ls.options.Logger.Info(fmt.Sprintf("🎯 Role %s matched nodes: %s", roleName, strings.Join(matched, ", ")))
+	}
+
+	unique := make(map[string]bool)
+	var nodes []string
+	for _, name := range append(role.NodeNames(), matched...) {
+		if !unique[name] {
+			unique[name] = true
+			nodes = append(nodes, name)
+		}
+	}
+	return nodes, nil
+}
+
+// resolvedNodes returns role -> nodes of resolved roles, for OperationResults.ResolvedNodes
+func resolvedNodes(roles map[string]config.NodeRole) map[string][]string {
+	nodes := make(map[string][]string, len(roles))
+	for roleName, role := range roles {
+		nodes[roleName] = role.Nodes
+	}
+	return nodes
+}
@@ -0,0 +1,104 @@
+// Package labeler provides unit tests for role node targeting
+// WHY: Roles selecting nodes by pattern or selector must label exactly the matching cluster nodes
+package labeler
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/logging"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLabelingService_ApplyLabels_NodeTargeting tests roles using name patterns and node selectors
+// WHY: Matching nodes are resolved from the cluster at apply time and then labeled like listed nodes
+func TestLabelingService_ApplyLabels_NodeTargeting(t *testing.T) {
+	tests := []struct {
+		name          string
+		role          config.NodeRole
+		mockSetupFunc func(*MockDryRunExecutor)
+		expectedNodes []string
+		expectError   string
+	}{
+		{
+			name: "glob_pattern",
+			role: config.NodeRole{Nodes: []string{"compute-*"}, Labels: map[string]string{"role": "compute"}},
+			mockSetupFunc: func(m *MockDryRunExecutor) {
+				m.On("GetAllNodes", mock.Anything).Return(true, "node/compute-2\nnode/control-1\nnode/compute-1", nil).Once()
+			},
+			expectedNodes: []string{"compute-1", "compute-2"},
+		},
+		{
+			name: "names_patterns_and_selector_combined",
+			role: config.NodeRole{
+				Nodes:        []string{"rsb9", "compute-?", "compute-[12]"},
+				NodeSelector: "node-role.kubernetes.io/worker",
+				Labels:       map[string]string{"role": "compute"},
+			},
+			mockSetupFunc: func(m *MockDryRunExecutor) {
+				// The node list is fetched once for every pattern of the role
+				m.On("GetAllNodes", mock.Anything).Return(true, "node/compute-1\nnode/compute-2\nnode/control-1", nil).Once()
+				m.On("GetNodesByLabel", mock.Anything, "node-role.kubernetes.io/worker").Return(true, "node/worker-1\nnode/compute-1", nil)
+			},
+			expectedNodes: []string{"rsb9", "compute-1", "compute-2", "worker-1"},
+		},
+		{
+			name: "selector_matches_nothing",
+			role: config.NodeRole{NodeSelector: "gpu=true", Labels: map[string]string{"role": "gpu"}},
+			mockSetupFunc: func(m *MockDryRunExecutor) {
+				m.On("GetNodesByLabel", mock.Anything, "gpu=true").Return(true, "", nil)
+			},
+			expectedNodes: nil,
+		},
+		{
+			name: "selector_lookup_fails",
+			role: config.NodeRole{NodeSelector: "gpu=true", Labels: map[string]string{"role": "gpu"}},
+			mockSetupFunc: func(m *MockDryRunExecutor) {
+				m.On("GetNodesByLabel", mock.Anything, "gpu=true").Return(false, "", errors.New("forbidden"))
+			},
+			expectError: "role 'workers': failed to list nodes matching selector gpu=true: forbidden",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A role targeting nodes dynamically
+			mockKubectl := NewMockDryRunExecutor()
+			mockLogger := logging.NewMockLogger()
+			mockKubectl.On("SetDryRun", false).Return()
+			tt.mockSetupFunc(mockKubectl)
+			var labeled []string
+			mockKubectl.On("LabelNode", mock.Anything, mock.Anything, mock.Anything, true).
+				Run(func(args mock.Arguments) { labeled = append(labeled, args.String(1)) }).
+				Return(true, "labeled", nil).Maybe()
+			mockLogger.On("Info", mock.AnythingOfType("string")).Return().Maybe()
+			mockLogger.On("Warn", mock.AnythingOfType("string")).Return().Maybe()
+
+			service := NewService(mockKubectl, Options{Logger: mockLogger})
+			cfg := &config.NodeLabelConf{
+				Kind:     "NodeLabelConf",
+				Metadata: config.Metadata{Name: "targeting"},
+				Spec:     config.NodeLabelSpec{NodeRoles: map[string]config.NodeRole{"workers": tt.role}},
+			}
+
+			// When: Apply labels
+			result, err := service.ApplyLabels(context.Background(), cfg)
+
+			// Then: Exactly the matching nodes are labeled and reported
+			if tt.expectError != "" {
+				require.Error(t, err)
+				assert.Equal(t, tt.expectError, err.Error())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedNodes, labeled)
+			assert.Equal(t, tt.expectedNodes, result.ResolvedNodes["workers"])
+			mockKubectl.AssertExpectations(t)
+		})
+	}
+}
@@ -6,6 +6,10 @@ import (
 
 	"k8ostack-ictl/internal/config"
 	"k8ostack-ictl/internal/kubectl"
+	"k8ostack-ictl/internal/logging"
+	"k8ostack-ictl/internal/plan"
+	"k8ostack-ictl/internal/progress"
+	"k8ostack-ictl/internal/throttle"
 )
 
 // OperationResults tracks the results of labeling operations
@@ -14,9 +18,20 @@ type OperationResults struct {
 	SuccessfulNodes int
 	FailedNodes     []string
 	AppliedLabels   map[string][]string // node -> labels applied
+	ResolvedNodes   map[string][]string // role -> nodes it targeted, with patterns and nodeSelector resolved
+	Order           []string            // nodes in the order the rollout changed them, role by role
 	Errors          []error
 }
 
+// merge adds the failed nodes, applied labels and errors of one node's results
+func (r *OperationResults) merge(node *OperationResults) {
+	r.FailedNodes = append(r.FailedNodes, node.FailedNodes...)
+	for nodeName, labels := range node.AppliedLabels {
+		r.AppliedLabels[nodeName] = labels
+	}
+	r.Errors = append(r.Errors, node.Errors...)
+}
+
 // Service defines the interface for the labeling service
 type Service interface {
 	// ApplyLabels applies all labels defined in the configuration
@@ -28,8 +43,20 @@ type Service interface {
 	// VerifyLabels checks if labels are applied correctly
 	VerifyLabels(ctx context.Context, config config.Config) (*OperationResults, error)
 
+	// CleanupLabels removes every label whose key matches one of the cleanup prefixes
+	CleanupLabels(ctx context.Context, cleanup *config.CleanupConf) (*OperationResults, error)
+
+	// PlanLabels diffs current node labels against the labels an apply would leave behind
+	PlanLabels(ctx context.Context, config config.Config, cleanup *config.CleanupConf) (*plan.Plan, error)
+
+	// ReportLabels inverts the configuration into the roles and merged labels of each node
+	ReportLabels(ctx context.Context, config config.Config) ([]*NodeLabelReport, error)
+
 	// GetCurrentState discovers the current labeling state
 	GetCurrentState(ctx context.Context, nodes []string) (map[string]map[string]string, error)
+
+	// ResolveNodes returns the nodes each role targets, with name patterns and node selectors resolved
+	ResolveNodes(ctx context.Context, config config.Config) (map[string][]string, error)
 }
 
 // Options contains configuration options for the labeling service
@@ -37,19 +64,24 @@ type Options struct {
 	DryRun        bool
 	Verbose       bool
 	ValidateNodes bool
-	Logger        kubectl.Logger
+	BulkLabel     bool                 // Applies a role's labels to all its nodes in one call, falling back to one node at a time
+	Workers       *throttle.Controller // Processes the nodes of a role concurrently; nil processes them one by one
+	Progress      progress.Reporter    // Receives how many nodes are done; nil reports nothing
+	Logger        logging.Logger
 }
 
 // LabelingService implements the Service interface
 type LabelingService struct {
 	kubectl kubectl.DryRunExecutor
 	options Options
+	nodes   *kubectl.NodeNameCache // cluster node names for "did you mean" suggestions
 }
 
 // NewService creates a new labeling service
-func NewService(kubectl kubectl.DryRunExecutor, options Options) Service {
+func NewService(executor kubectl.DryRunExecutor, options Options) Service {
 	return &LabelingService{
-		kubectl: kubectl,
+		kubectl: executor,
 		options: options,
+		nodes:   kubectl.NewNodeNameCache(executor),
 	}
 }
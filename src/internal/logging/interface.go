@@ -0,0 +1,55 @@
+package logging
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Logger is the logging interface injected into every service and executor
+type Logger interface {
+	Debug(message string)
+	Info(message string)
+	Warn(message string)
+	Error(message string)
+
+	// With returns a logger that appends the key-value pairs to every message, e.g. With("node", "rsb2")
+	With(keysAndValues ...interface{}) Logger
+}
+
+// Log levels, as written to log files and recorded by the mock loggers
+const (
+	LevelDebug = "DEBUG"
+	LevelInfo  = "INFO"
+	LevelWarn  = "WARN"
+	LevelError = "ERROR"
+)
+
+// Field keys identifying where an entry comes from, emitted as their own JSON fields with --log-format json
+const (
+	FieldComponent = "component"
+	FieldNode      = "node"
+	FieldOperation = "operation"
+)
+
+// badKey stands in for the key of a trailing value without one
+const badKey = "!BADKEY"
+
+// FormatFields renders key-value pairs as "key=value" separated by spaces
+func FormatFields(keysAndValues []interface{}) string {
+	parts := make([]string, 0, (len(keysAndValues)+1)/2)
+	for i := 0; i < len(keysAndValues); i += 2 {
+		if i+1 == len(keysAndValues) {
+			parts = append(parts, fmt.Sprintf("%s=%v", badKey, keysAndValues[i]))
+			break
+		}
+		parts = append(parts, fmt.Sprintf("%v=%v", keysAndValues[i], keysAndValues[i+1]))
+	}
+	return strings.Join(parts, " ")
+}
+
+// appendFields returns a copy of fields with keysAndValues appended, so child loggers never share backing arrays
+func appendFields(fields []interface{}, keysAndValues []interface{}) []interface{} {
+	combined := make([]interface{}, 0, len(fields)+len(keysAndValues))
+	combined = append(combined, fields...)
+	return append(combined, keysAndValues...)
+}
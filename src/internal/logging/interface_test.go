@@ -0,0 +1,36 @@
+// Package logging provides tests for the shared logger interface
+package logging
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestFormatFields tests rendering key-value pairs
+// WHY: Fields are appended to plain-text log lines, so they must stay readable and never be dropped
+func TestFormatFields(t *testing.T) {
+	tests := []struct {
+		name          string
+		keysAndValues []interface{}
+		expected      string
+	}{
+		{name: "no fields", expected: ""},
+		{name: "pairs", keysAndValues: []interface{}{"node", "rsb2", "vlan", 100}, expected: "node=rsb2 vlan=100"},
+		{name: "value without key", keysAndValues: []interface{}{"node", "rsb2", "orphan"}, expected: "node=rsb2 !BADKEY=orphan"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, FormatFields(tt.keysAndValues))
+		})
+	}
+}
+
+// TestLoggers_Implement tests that every logger satisfies the interface
+// WHY: Services accept any Logger, so the file logger and both test loggers must stay interchangeable
+func TestLoggers_Implement(t *testing.T) {
+	assert.Implements(t, (*Logger)(nil), &FileLogger{})
+	assert.Implements(t, (*Logger)(nil), NewMockLogger())
+	assert.Implements(t, (*Logger)(nil), NewRecordingLogger())
+}
@@ -2,22 +2,86 @@
 package logging
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 )
 
-// FileLogger implements the kubectl.Logger interface with file and console output
+// Log formats selectable with --log-format
+const (
+	// FormatText writes "[LEVEL] message key=value" lines
+	FormatText = "text"
+
+	// FormatJSON writes one JSON object per entry for log shippers such as Loki or ELK
+	FormatJSON = "json"
+)
+
+// ValidateFormat checks that a log format name is supported
+func ValidateFormat(format string) error {
+	switch format {
+	case "", FormatText, FormatJSON:
+		return nil
+	default:
+		return fmt.Errorf("unsupported log format '%s'. Expected: %s or %s", format, FormatText, FormatJSON)
+	}
+}
+
+// FileLogger implements the Logger interface with file and console output
 type FileLogger struct {
 	fileLogger *log.Logger
 	logFile    *os.File
 	verbose    bool
+	console    io.Writer
+	format     string
+	minConsole int // Rank of the lowest level printed on the console, see Options.ConsoleLevel
+	fields     []interface{}
 }
 
-// NewFileLogger creates a new logger that writes to both file and console
+// Options configures the console output of a FileLogger; the file always gets every entry
+type Options struct {
+	Verbose      bool      // Print debug entries on the console
+	Console      io.Writer // Where entries are printed besides the file
+	Format       string    // FormatText or FormatJSON; defaults to text
+	ConsoleLevel string    // Lowest level printed on the console, e.g. LevelError for quiet output; defaults to all
+}
+
+// levelRanks orders the levels from least to most severe
+var levelRanks = map[string]int{LevelDebug: 0, LevelInfo: 1, LevelWarn: 2, LevelError: 3}
+
+// NewFileLogger creates a new logger that writes to both file and stdout
 func NewFileLogger(logDir string, verbose bool) (*FileLogger, error) {
+	return NewFileLoggerWithOutput(logDir, verbose, os.Stdout)
+}
+
+// NewFileLoggerWithOutput creates a new logger that writes to both file and the given console writer
+func NewFileLoggerWithOutput(logDir string, verbose bool, console io.Writer) (*FileLogger, error) {
+	return NewFileLoggerWithFormat(logDir, verbose, console, FormatText)
+}
+
+// NewFileLoggerWithFormat creates a new logger that writes entries in the given format to both file and console
+func NewFileLoggerWithFormat(logDir string, verbose bool, console io.Writer, format string) (*FileLogger, error) {
+	return NewFileLoggerWithOptions(logDir, Options{Verbose: verbose, Console: console, Format: format})
+}
+
+// NewFileLoggerWithOptions creates a new logger that writes every entry to a file and the selected ones to the console
+func NewFileLoggerWithOptions(logDir string, options Options) (*FileLogger, error) {
+	verbose, console, format := options.Verbose, options.Console, options.Format
+	if err := ValidateFormat(format); err != nil {
+		return nil, err
+	}
+	if format == "" {
+		format = FormatText
+	}
+	minConsole, known := levelRanks[options.ConsoleLevel]
+	if options.ConsoleLevel != "" && !known {
+		return nil, fmt.Errorf("unsupported console log level '%s'", options.ConsoleLevel)
+	}
+
 	// Create logs directory if it doesn't exist
 	if err := os.MkdirAll(logDir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create logs directory: %w", err)
@@ -32,21 +96,53 @@ func NewFileLogger(logDir string, verbose bool) (*FileLogger, error) {
 		return nil, fmt.Errorf("failed to create log file: %w", err)
 	}
 
-	fileLogger := log.New(logFile, "", log.LstdFlags)
+	// JSON entries carry their own timestamp
+	flags := log.LstdFlags
+	if format == FormatJSON {
+		flags = 0
+	}
+	fileLogger := log.New(logFile, "", flags)
 
 	logger := &FileLogger{
 		fileLogger: fileLogger,
 		logFile:    logFile,
 		verbose:    verbose,
+		console:    console,
+		format:     format,
+		minConsole: minConsole,
 	}
 
 	// Log initialization
-	fmt.Printf("📝 Logging to: %s\n", logPath)
+	if format == FormatText && logger.printed(LevelInfo) {
+		fmt.Fprintf(console, "📝 Logging to: %s\n", logPath)
+	}
 	logger.Info(fmt.Sprintf("Logging to: %s", logPath))
 
 	return logger, nil
 }
 
+// With returns a logger writing to the same file that appends the key-value pairs to every message
+// Only the logger returned by NewFileLogger should be closed.
+func (l *FileLogger) With(keysAndValues ...interface{}) Logger {
+	return &FileLogger{
+		fileLogger: l.fileLogger,
+		logFile:    l.logFile,
+		verbose:    l.verbose,
+		console:    l.console,
+		format:     l.format,
+		minConsole: l.minConsole,
+		fields:     appendFields(l.fields, keysAndValues),
+	}
+}
+
+// withFields appends the logger's fields to a message
+func (l *FileLogger) withFields(message string) string {
+	if len(l.fields) == 0 {
+		return message
+	}
+	return message + " " + FormatFields(l.fields)
+}
+
 // Close closes the log file
 func (l *FileLogger) Close() error {
 	if l.logFile != nil {
@@ -59,26 +155,84 @@ func (l *FileLogger) Close() error {
 
 // Debug logs debug messages (only in verbose mode)
 func (l *FileLogger) Debug(message string) {
-	l.fileLogger.Printf("[DEBUG] %s", message)
-	if l.verbose {
-		fmt.Printf("DEBUG: %s\n", message)
-	}
+	l.log(LevelDebug, message, l.verbose)
 }
 
 // Info logs informational messages
 func (l *FileLogger) Info(message string) {
-	l.fileLogger.Printf("[INFO] %s", message)
-	fmt.Printf("INFO: %s\n", message)
+	l.log(LevelInfo, message, true)
 }
 
 // Warn logs warning messages
 func (l *FileLogger) Warn(message string) {
-	l.fileLogger.Printf("[WARN] %s", message)
-	fmt.Printf("WARN: %s\n", message)
+	l.log(LevelWarn, message, true)
 }
 
 // Error logs error messages
 func (l *FileLogger) Error(message string) {
-	l.fileLogger.Printf("[ERROR] %s", message)
-	fmt.Printf("ERROR: %s\n", message)
+	l.log(LevelError, message, true)
+}
+
+// printed reports whether entries of a level reach the console
+func (l *FileLogger) printed(level string) bool {
+	return levelRanks[level] >= l.minConsole
+}
+
+// log writes an entry to the file and, when toConsole is set and the console takes the level, to the console
+func (l *FileLogger) log(level, message string, toConsole bool) {
+	toConsole = toConsole && l.printed(level)
+	if l.format == FormatJSON {
+		entry := formatJSONEntry(time.Now(), level, message, l.fields)
+		l.fileLogger.Print(entry)
+		if toConsole {
+			fmt.Fprintln(l.console, entry)
+		}
+		return
+	}
+
+	message = l.withFields(message)
+	l.fileLogger.Printf("[%s] %s", level, message)
+	if toConsole {
+		fmt.Fprintf(l.console, "%s: %s\n", level, message)
+	}
+}
+
+// formatJSONEntry renders an entry as a JSON object: time, level and msg first, then the fields in order
+// Errors and other values that do not encode as JSON are written as their string form.
+func formatJSONEntry(now time.Time, level, message string, fields []interface{}) string {
+	var entry strings.Builder
+	entry.WriteString(`{"time":`)
+	writeJSONValue(&entry, now.UTC().Format(time.RFC3339Nano))
+	entry.WriteString(`,"level":`)
+	writeJSONValue(&entry, strings.ToLower(level))
+	entry.WriteString(`,"msg":`)
+	writeJSONValue(&entry, message)
+
+	for i := 0; i < len(fields); i += 2 {
+		key, value := fmt.Sprint(fields[i]), interface{}(nil)
+		if i+1 == len(fields) {
+			key, value = badKey, fields[i]
+		} else {
+			value = fields[i+1]
+		}
+		entry.WriteString(",")
+		writeJSONValue(&entry, key)
+		entry.WriteString(":")
+		writeJSONValue(&entry, value)
+	}
+
+	entry.WriteString("}")
+	return entry.String()
+}
+
+// writeJSONValue appends the JSON encoding of value, falling back to its string form
+func writeJSONValue(entry *strings.Builder, value interface{}) {
+	if err, ok := value.(error); ok {
+		value = err.Error()
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		encoded, _ = json.Marshal(fmt.Sprint(value))
+	}
+	entry.Write(encoded)
 }
@@ -2,10 +2,14 @@
 package logging
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -281,3 +285,162 @@ func TestFileLogger_Integration(t *testing.T) {
 		assert.Contains(t, logStr, "[INFO] Operation completed successfully")
 	})
 }
+
+// TestFileLogger_With tests fields added through With
+// WHY: Child loggers share the run's log file, so their fields must reach it without affecting the parent
+func TestFileLogger_With(t *testing.T) {
+	// Given: File logger with a node-scoped child
+	logDir := filepath.Join(t.TempDir(), "logs")
+	logger, err := NewFileLogger(logDir, false)
+	require.NoError(t, err)
+	child := logger.With("node", "rsb2").With("vlan", 100)
+
+	// When: Logging through both
+	child.Info("VLAN configured")
+	logger.Info("Run finished")
+	require.NoError(t, logger.Close())
+
+	// Then: Only the child's message carries the fields
+	files, err := filepath.Glob(filepath.Join(logDir, "node_labeling_*.log"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	logContent, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	assert.Contains(t, string(logContent), "[INFO] VLAN configured node=rsb2 vlan=100")
+	assert.Contains(t, string(logContent), "[INFO] Run finished\n")
+}
+
+// TestNewFileLoggerWithOutput tests console output to a custom writer
+// WHY: Commands pass their own output so tests and output modes can capture or redirect it
+func TestNewFileLoggerWithOutput(t *testing.T) {
+	// Given: Non-verbose logger writing its console output to a buffer
+	var console bytes.Buffer
+	logger, err := NewFileLoggerWithOutput(t.TempDir(), false, &console)
+	require.NoError(t, err)
+	defer logger.Close()
+
+	// When: Logging at every level, including through a child
+	logger.Debug("hidden debug")
+	logger.Info("visible info")
+	logger.With("node", "rsb2").Warn("visible warning")
+
+	// Then: The buffer holds the console lines and debug stays hidden
+	output := console.String()
+	assert.Contains(t, output, "📝 Logging to: ")
+	assert.Contains(t, output, "INFO: visible info\n")
+	assert.Contains(t, output, "WARN: visible warning node=rsb2\n")
+	assert.NotContains(t, output, "hidden debug")
+}
+
+// TestFileLogger_ConsoleLevel tests quiet console output
+// WHY: Quiet runs print only errors, but the run log must still hold every entry for later troubleshooting
+func TestFileLogger_ConsoleLevel(t *testing.T) {
+	// Given: A logger printing only errors on the console
+	var console bytes.Buffer
+	logDir := t.TempDir()
+	logger, err := NewFileLoggerWithOptions(logDir, Options{Console: &console, ConsoleLevel: LevelError})
+	require.NoError(t, err)
+
+	// When: Logging at every level, including through a child
+	logger.Info("🏷️  Processing node labeling configuration...")
+	logger.With("node", "rsb2").Warn("⚠️  label drift")
+	logger.With("node", "rsb3").Error("❌ label failed")
+	require.NoError(t, logger.Close())
+
+	// Then: Only the error reaches the console, without the log file banner
+	assert.Equal(t, "ERROR: ❌ label failed node=rsb3\n", console.String())
+
+	// And: The file keeps every entry
+	files, err := filepath.Glob(filepath.Join(logDir, "node_labeling_*.log"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	logContent, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	assert.Contains(t, string(logContent), "[INFO] 🏷️  Processing node labeling configuration...")
+	assert.Contains(t, string(logContent), "[WARN] ⚠️  label drift node=rsb2")
+
+	// And: Unknown levels are rejected
+	_, err = NewFileLoggerWithOptions(t.TempDir(), Options{Console: &console, ConsoleLevel: "LOUD"})
+	assert.EqualError(t, err, "unsupported console log level 'LOUD'")
+}
+
+// TestFileLogger_JSONFormat tests structured JSON entries
+// WHY: Loki and ELK ingest one object per line, with the logger's fields as their own keys
+func TestFileLogger_JSONFormat(t *testing.T) {
+	// Given: Non-verbose JSON logger with a node-scoped child
+	var console bytes.Buffer
+	logDir := t.TempDir()
+	logger, err := NewFileLoggerWithFormat(logDir, false, &console, FormatJSON)
+	require.NoError(t, err)
+	child := logger.With(FieldComponent, "nvlan", FieldOperation, "apply").With(FieldNode, "rsb2", "vlan", 100)
+
+	// When: Logging through both
+	child.Warn(`interface "eth0.100" missing`)
+	logger.Debug("hidden debug")
+	require.NoError(t, logger.Close())
+
+	// Then: File and console hold JSON lines, and debug reaches only the file
+	files, err := filepath.Glob(filepath.Join(logDir, "node_labeling_*.log"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	content, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	require.Len(t, lines, 3)
+
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &entry))
+	assert.Equal(t, "warn", entry["level"])
+	assert.Equal(t, `interface "eth0.100" missing`, entry["msg"])
+	assert.Equal(t, "nvlan", entry["component"])
+	assert.Equal(t, "apply", entry["operation"])
+	assert.Equal(t, "rsb2", entry["node"])
+	assert.Equal(t, float64(100), entry["vlan"])
+	_, err = time.Parse(time.RFC3339Nano, entry["time"].(string))
+	assert.NoError(t, err)
+	assert.Contains(t, lines[2], `"level":"debug"`)
+
+	assert.NotContains(t, console.String(), "📝 Logging to")
+	assert.NotContains(t, console.String(), "hidden debug")
+	assert.Contains(t, console.String(), lines[1]+"\n")
+}
+
+// TestFormatJSONEntry tests the field order and value encoding of JSON entries
+// WHY: Errors and odd field lists must still produce valid JSON instead of dropping the entry
+func TestFormatJSONEntry(t *testing.T) {
+	tests := []struct {
+		name     string
+		fields   []interface{}
+		expected string
+	}{
+		{"no_fields", nil, `{"time":"2026-01-02T03:04:05Z","level":"info","msg":"done"}`},
+		{"error_value", []interface{}{"error", errors.New("boom")}, `{"time":"2026-01-02T03:04:05Z","level":"info","msg":"done","error":"boom"}`},
+		{"unencodable_value", []interface{}{"callback", func() {}}, ``},
+		{"missing_value", []interface{}{"node"}, `{"time":"2026-01-02T03:04:05Z","level":"info","msg":"done","!BADKEY":"node"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A fixed time and the fields
+			now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+			// When: Format the entry
+			entry := formatJSONEntry(now, LevelInfo, "done", tt.fields)
+
+			// Then: It is valid JSON in the expected order
+			assert.True(t, json.Valid([]byte(entry)), entry)
+			if tt.expected != "" {
+				assert.Equal(t, tt.expected, entry)
+			}
+		})
+	}
+}
+
+// TestValidateFormat tests log format name validation
+// WHY: A typo in --log-format must fail fast instead of silently writing text
+func TestValidateFormat(t *testing.T) {
+	assert.NoError(t, ValidateFormat(""))
+	assert.NoError(t, ValidateFormat(FormatText))
+	assert.NoError(t, ValidateFormat(FormatJSON))
+	assert.EqualError(t, ValidateFormat("logfmt"), "unsupported log format 'logfmt'. Expected: text or json")
+}
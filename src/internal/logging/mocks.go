@@ -0,0 +1,145 @@
+package logging
+
+import (
+	"sync"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// LogMessage captures structured log data for assertions
+type LogMessage struct {
+	Level   string
+	Message string
+	Fields  []interface{}
+}
+
+// MockLogger mocks the Logger interface for test output verification
+// Loggers returned by With record into and call expectations on the logger they came from.
+type MockLogger struct {
+	mock.Mock
+	Messages []LogMessage
+
+	root   *MockLogger
+	fields []interface{}
+}
+
+// NewMockLogger creates a new mock logger for testing
+// WHY: Enables verification of logging behavior and structured message capture
+func NewMockLogger() *MockLogger {
+	return &MockLogger{
+		Messages: make([]LogMessage, 0),
+	}
+}
+
+// record captures the message on the root logger and checks it against the expectations
+func (m *MockLogger) record(method, level, message string) {
+	root := m
+	if m.root != nil {
+		root = m.root
+	}
+	root.Messages = append(root.Messages, LogMessage{Level: level, Message: message, Fields: m.fields})
+	root.MethodCalled(method, message)
+}
+
+// Debug captures debug messages
+func (m *MockLogger) Debug(message string) {
+	m.record("Debug", LevelDebug, message)
+}
+
+// Info captures info messages
+func (m *MockLogger) Info(message string) {
+	m.record("Info", LevelInfo, message)
+}
+
+// Warn captures warning messages
+func (m *MockLogger) Warn(message string) {
+	m.record("Warn", LevelWarn, message)
+}
+
+// Error captures error messages
+func (m *MockLogger) Error(message string) {
+	m.record("Error", LevelError, message)
+}
+
+// With returns a child logger whose messages carry the fields; it needs no expectation of its own
+func (m *MockLogger) With(keysAndValues ...interface{}) Logger {
+	root := m
+	if m.root != nil {
+		root = m.root
+	}
+	return &MockLogger{root: root, fields: appendFields(m.fields, keysAndValues)}
+}
+
+// GetMessages returns all captured messages for test assertions
+func (m *MockLogger) GetMessages() []LogMessage {
+	return m.Messages
+}
+
+// GetMessagesByLevel returns messages filtered by log level
+func (m *MockLogger) GetMessagesByLevel(level string) []LogMessage {
+	var filtered []LogMessage
+	for _, msg := range m.Messages {
+		if msg.Level == level {
+			filtered = append(filtered, msg)
+		}
+	}
+	return filtered
+}
+
+// Clear resets captured messages for fresh test runs
+func (m *MockLogger) Clear() {
+	m.Messages = []LogMessage{}
+}
+
+// RecordingLogger records every message without expectations, for tests that only inspect output
+// Fields are appended to messages the way FileLogger writes them.
+type RecordingLogger struct {
+	mu       *sync.Mutex
+	messages *[]LogMessage
+	fields   []interface{}
+}
+
+// NewRecordingLogger creates an empty recording logger
+func NewRecordingLogger() *RecordingLogger {
+	return &RecordingLogger{mu: &sync.Mutex{}, messages: &[]LogMessage{}}
+}
+
+// record appends a message with the logger's fields
+func (r *RecordingLogger) record(level, message string) {
+	if len(r.fields) > 0 {
+		message += " " + FormatFields(r.fields)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	*r.messages = append(*r.messages, LogMessage{Level: level, Message: message, Fields: r.fields})
+}
+
+// Debug records a debug message
+func (r *RecordingLogger) Debug(message string) { r.record(LevelDebug, message) }
+
+// Info records an info message
+func (r *RecordingLogger) Info(message string) { r.record(LevelInfo, message) }
+
+// Warn records a warning message
+func (r *RecordingLogger) Warn(message string) { r.record(LevelWarn, message) }
+
+// Error records an error message
+func (r *RecordingLogger) Error(message string) { r.record(LevelError, message) }
+
+// With returns a logger recording into the same messages with the fields appended
+func (r *RecordingLogger) With(keysAndValues ...interface{}) Logger {
+	return &RecordingLogger{mu: r.mu, messages: r.messages, fields: appendFields(r.fields, keysAndValues)}
+}
+
+// Messages returns the recorded messages of one level, or of every level when level is empty
+func (r *RecordingLogger) Messages(level string) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var filtered []string
+	for _, msg := range *r.messages {
+		if level == "" || msg.Level == level {
+			filtered = append(filtered, msg.Message)
+		}
+	}
+	return filtered
+}
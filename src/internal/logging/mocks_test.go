@@ -0,0 +1,65 @@
+// Package logging provides tests for the shared test loggers
+package logging
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// TestMockLogger_With tests that child loggers report to their parent
+// WHY: Services log through With children, and tests only hold the logger they injected
+func TestMockLogger_With(t *testing.T) {
+	// Given: Mock expecting one info message
+	logger := NewMockLogger()
+	logger.On("Info", "VLAN configured").Once()
+
+	// When: Logging through a child
+	logger.With("node", "rsb2").With("vlan", 100).Info("VLAN configured")
+
+	// Then: The parent recorded the message with the child's fields
+	logger.AssertExpectations(t)
+	messages := logger.GetMessagesByLevel(LevelInfo)
+	if assert.Len(t, messages, 1) {
+		assert.Equal(t, []interface{}{"node", "rsb2", "vlan", 100}, messages[0].Fields)
+	}
+}
+
+// TestMockLogger_Levels tests message capture per level
+// WHY: Tests assert on warnings and errors separately from progress output
+func TestMockLogger_Levels(t *testing.T) {
+	logger := NewMockLogger()
+	for _, level := range []string{"Debug", "Info", "Warn", "Error"} {
+		logger.On(level, mock.Anything)
+	}
+
+	logger.Debug("debug message")
+	logger.Info("info message")
+	logger.Warn("warn message")
+	logger.Error("error message")
+
+	assert.Len(t, logger.GetMessages(), 4)
+	assert.Equal(t, "warn message", logger.GetMessagesByLevel(LevelWarn)[0].Message)
+	logger.Clear()
+	assert.Empty(t, logger.GetMessages())
+}
+
+// TestRecordingLogger tests recording without expectations
+// WHY: Executor tests inspect output only and must not need an expectation for every message
+func TestRecordingLogger(t *testing.T) {
+	// Given: Recording logger and a child
+	logger := NewRecordingLogger()
+	child := logger.With("node", "rsb2")
+
+	// When: Logging at several levels
+	logger.Debug("debug message")
+	child.Info("info message")
+	logger.Error("error message")
+
+	// Then: Messages are filtered by level and the child's fields are appended
+	assert.Equal(t, []string{"debug message"}, logger.Messages(LevelDebug))
+	assert.Equal(t, []string{"info message node=rsb2"}, logger.Messages(LevelInfo))
+	assert.Empty(t, logger.Messages(LevelWarn))
+	assert.Len(t, logger.Messages(""), 3)
+}
@@ -0,0 +1,345 @@
+// Package multus generates Multus NetworkAttachmentDefinitions for the VLANs of a bundle
+package multus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/kubectl"
+
+	"gopkg.in/yaml.v3"
+)
+
+// NetworkAttachmentDefinition API of Multus
+const (
+	APIVersion = "k8s.cni.cncf.io/v1"
+	Kind       = "NetworkAttachmentDefinition"
+)
+
+// CNI plugins attaching pods to a VLAN interface
+const (
+	PluginMacvlan = "macvlan"
+	PluginIPVLAN  = "ipvlan"
+)
+
+// IPAM plugins assigning pod addresses
+const (
+	IPAMWhereabouts = "whereabouts" // Cluster-wide pool of the VLAN subnet, excluding the node addresses
+	IPAMStatic      = "static"      // Addresses given per pod in the network selection annotation
+)
+
+// cniVersion is the CNI spec version of the generated plugin configurations
+const cniVersion = "0.3.1"
+
+// Labels set on every generated definition
+const (
+	ManagedByLabel = "app.kubernetes.io/managed-by"
+	ManagedBy      = "kictl"
+	VLANIDLabel    = "network.kictl.io/vlan-id"
+)
+
+// pluginModes are the modes of each plugin; the first one is the plugin's default
+var pluginModes = map[string][]string{
+	PluginMacvlan: {"bridge", "private", "vepa", "passthru"},
+	PluginIPVLAN:  {"l2", "l3", "l3s"},
+}
+
+// Options choose how pods attach to the VLANs
+type Options struct {
+	Namespace string // Namespace of the definitions; empty applies them to the namespace of the context
+	Plugin    string // PluginMacvlan (default) or PluginIPVLAN
+	Mode      string // Mode of the plugin; empty uses the plugin's default
+	IPAM      string // IPAMWhereabouts (default) or IPAMStatic
+}
+
+// NetworkAttachmentDefinition is a Multus network pods select with the k8s.v1.cni.cncf.io/networks annotation
+type NetworkAttachmentDefinition struct {
+	APIVersion string   `json:"apiVersion" yaml:"apiVersion"`
+	Kind       string   `json:"kind" yaml:"kind"`
+	Metadata   Metadata `json:"metadata" yaml:"metadata"`
+	Spec       Spec     `json:"spec" yaml:"spec"`
+}
+
+// Metadata identifies a definition
+type Metadata struct {
+	Name      string            `json:"name" yaml:"name"`
+	Namespace string            `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	Labels    map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"`
+}
+
+// Spec holds the CNI configuration of a definition as a JSON string
+type Spec struct {
+	Config string `json:"config" yaml:"config"`
+}
+
+// pluginConfig is the CNI configuration attaching pods to one VLAN interface
+type pluginConfig struct {
+	CNIVersion string     `json:"cniVersion"`
+	Name       string     `json:"name"`
+	Type       string     `json:"type"`
+	Master     string     `json:"master"`
+	Mode       string     `json:"mode"`
+	IPAM       ipamConfig `json:"ipam"`
+}
+
+// ipamConfig is the address assignment of a plugin configuration
+type ipamConfig struct {
+	Type    string   `json:"type"`
+	Range   string   `json:"range,omitempty"`
+	Exclude []string `json:"exclude,omitempty"`
+}
+
+// Attachments are the definitions generated for a bundle
+type Attachments struct {
+	Definitions []NetworkAttachmentDefinition
+
+	// Warnings lists the VLANs no definition could be generated for, e.g. because their parent interface differs per node
+	Warnings []string
+}
+
+// FromBundle generates one definition per VLAN, attaching pods to the VLAN interface kictl creates on the nodes
+func FromBundle(bundle *config.ConfigBundle, opts Options) (*Attachments, error) {
+	if err := opts.normalize(); err != nil {
+		return nil, err
+	}
+	attachments := &Attachments{}
+	if !bundle.HasVLANs() {
+		return attachments, nil
+	}
+
+	defaults := bundle.GetDefaults().Spec
+	names := make(map[string]string)
+	for _, vlanName := range sortedKeys(bundle.VLANs.Spec.VLANs) {
+		vlanConfig := bundle.VLANs.Spec.VLANs[vlanName]
+
+		name := ResourceName(vlanName)
+		if name == "" {
+			return nil, fmt.Errorf("VLAN %s cannot name a %s", vlanName, Kind)
+		}
+		if other, ok := names[name]; ok {
+			return nil, fmt.Errorf("VLANs %s and %s both name the %s %s", other, vlanName, Kind, name)
+		}
+		names[name] = vlanName
+
+		// The internal port of an OVS VLAN is the same interface on every node
+		if vlanConfig.OVS != nil {
+			definition, err := opts.definition(name, vlanConfig, vlanConfig.OVS.Port)
+			if err != nil {
+				return nil, fmt.Errorf("VLAN %s: %w", vlanName, err)
+			}
+			attachments.Definitions = append(attachments.Definitions, definition)
+			continue
+		}
+
+		parentName := vlanConfig.Interface
+		if parentName == "" {
+			if defaults.InterfaceDetection != "" {
+				attachments.Warnings = append(attachments.Warnings, fmt.Sprintf(
+					"VLAN %s: its parent interface is detected on each node (%s); set its interface to generate a %s", vlanName, defaults.InterfaceDetection, Kind))
+				continue
+			}
+			parentName = defaults.Interface
+		}
+
+		parents := make(map[string][]string)
+		for _, node := range sortedKeys(vlanConfig.NodeMapping) {
+			parent, err := defaults.InterfaceAliases.Resolve(node, parentName)
+			if err != nil {
+				return nil, fmt.Errorf("VLAN %s: %w", vlanName, err)
+			}
+			parents[parent] = append(parents[parent], node)
+		}
+		if len(parents) > 1 {
+			var differing []string
+			for _, parent := range sortedKeys(parents) {
+				differing = append(differing, fmt.Sprintf("%s on %s", parent, strings.Join(parents[parent], ", ")))
+			}
+			attachments.Warnings = append(attachments.Warnings, fmt.Sprintf(
+				"VLAN %s: its parent interface differs per node (%s), which one %s cannot express", vlanName, strings.Join(differing, "; "), Kind))
+			continue
+		}
+		parent := parentName
+		for resolved := range parents {
+			parent = resolved
+		}
+
+		definition, err := opts.definition(name, vlanConfig, fmt.Sprintf("%s.%d", parent, vlanConfig.ID))
+		if err != nil {
+			return nil, fmt.Errorf("VLAN %s: %w", vlanName, err)
+		}
+		attachments.Definitions = append(attachments.Definitions, definition)
+	}
+	return attachments, nil
+}
+
+// normalize fills in the default plugin, mode and IPAM and rejects unknown ones
+func (o *Options) normalize() error {
+	if o.Plugin == "" {
+		o.Plugin = PluginMacvlan
+	}
+	modes, ok := pluginModes[o.Plugin]
+	if !ok {
+		return fmt.Errorf("unknown plugin '%s'. Expected: %s or %s", o.Plugin, PluginMacvlan, PluginIPVLAN)
+	}
+	if o.Mode == "" {
+		o.Mode = modes[0]
+	}
+	if !containsString(modes, o.Mode) {
+		return fmt.Errorf("unknown %s mode '%s'. Expected: %s", o.Plugin, o.Mode, strings.Join(modes, ", "))
+	}
+	if o.IPAM == "" {
+		o.IPAM = IPAMWhereabouts
+	}
+	if o.IPAM != IPAMWhereabouts && o.IPAM != IPAMStatic {
+		return fmt.Errorf("unknown IPAM '%s'. Expected: %s or %s", o.IPAM, IPAMWhereabouts, IPAMStatic)
+	}
+	return nil
+}
+
+// definition builds the definition of one VLAN on its VLAN interface
+func (o Options) definition(name string, vlanConfig config.VLANConfig, master string) (NetworkAttachmentDefinition, error) {
+	plugin := pluginConfig{CNIVersion: cniVersion, Name: name, Type: o.Plugin, Master: master, Mode: o.Mode, IPAM: ipamConfig{Type: o.IPAM}}
+	if o.IPAM == IPAMWhereabouts {
+		_, subnet, err := net.ParseCIDR(vlanConfig.Subnet)
+		if err != nil {
+			return NetworkAttachmentDefinition{}, fmt.Errorf("invalid subnet %s: %w", vlanConfig.Subnet, err)
+		}
+		plugin.IPAM.Range = vlanConfig.Subnet
+		plugin.IPAM.Exclude = nodeAddresses(vlanConfig, subnet)
+	}
+
+	data, err := json.MarshalIndent(plugin, "", "  ")
+	if err != nil {
+		return NetworkAttachmentDefinition{}, fmt.Errorf("failed to render the CNI configuration: %w", err)
+	}
+	return NetworkAttachmentDefinition{
+		APIVersion: APIVersion,
+		Kind:       Kind,
+		Metadata: Metadata{Name: name, Namespace: o.Namespace, Labels: map[string]string{
+			ManagedByLabel: ManagedBy,
+			VLANIDLabel:    fmt.Sprint(vlanConfig.ID),
+		}},
+		Spec: Spec{Config: string(data)},
+	}, nil
+}
+
+// nodeAddresses returns the node addresses within a subnet as single-address ranges, so pods are never given one
+func nodeAddresses(vlanConfig config.VLANConfig, subnet *net.IPNet) []string {
+	var excluded []string
+	for _, node := range sortedKeys(vlanConfig.NodeMapping) {
+		for _, address := range config.SplitAddresses(vlanConfig.NodeMapping[node]) {
+			ip, _, err := net.ParseCIDR(address)
+			if err != nil || !subnet.Contains(ip) {
+				continue
+			}
+			bits := 32
+			if ip.To4() == nil {
+				bits = 128
+			}
+			excluded = append(excluded, fmt.Sprintf("%s/%d", ip, bits))
+		}
+	}
+	return excluded
+}
+
+// ResourceName turns a VLAN name into a definition name, e.g. Storage_Net -> storage-net
+func ResourceName(vlanName string) string {
+	name := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			return r
+		}
+		if r >= 'A' && r <= 'Z' {
+			return r - 'A' + 'a'
+		}
+		return '-'
+	}, vlanName)
+	if len(name) > 63 {
+		name = name[:63]
+	}
+	return strings.Trim(name, "-")
+}
+
+// Marshal renders the definitions as a multi-document YAML stream
+func (a *Attachments) Marshal() ([]byte, error) {
+	var documents []string
+	for _, definition := range a.Definitions {
+		data, err := yaml.Marshal(definition)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal %s %s: %w", Kind, definition.Metadata.Name, err)
+		}
+		documents = append(documents, string(data))
+	}
+	return []byte(strings.Join(documents, "---\n")), nil
+}
+
+// WriteDir writes one <name>.yaml file per definition into dir, returning the files written
+func (a *Attachments) WriteDir(dir string) ([]string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+	var written []string
+	for _, definition := range a.Definitions {
+		data, err := yaml.Marshal(definition)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal %s %s: %w", Kind, definition.Metadata.Name, err)
+		}
+		path := filepath.Join(dir, definition.Metadata.Name+".yaml")
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			return nil, fmt.Errorf("failed to write %s %s: %w", Kind, definition.Metadata.Name, err)
+		}
+		written = append(written, path)
+	}
+	return written, nil
+}
+
+// Apply applies the definitions with the kubectl of the executor selecting the cluster; dryRun only validates them on the server
+func (a *Attachments) Apply(ctx context.Context, dryRun bool, run kubectl.Runner) (string, error) {
+	if len(a.Definitions) == 0 {
+		return "", nil
+	}
+	manifests, err := a.Marshal()
+	if err != nil {
+		return "", err
+	}
+
+	args := []string{"apply", "-f", "-"}
+	if dryRun {
+		args = append(args, "--dry-run=server")
+	}
+	output, err := run(ctx, manifests, args...)
+	output = strings.TrimSpace(output)
+	if err != nil {
+		if strings.Contains(output, "no matches for kind") {
+			return output, fmt.Errorf("the cluster does not know %s; is Multus installed? %w", Kind, err)
+		}
+		return output, fmt.Errorf("failed to apply %s: %s: %w", Kind, output, err)
+	}
+	return output, nil
+}
+
+// containsString reports whether a list holds a value
+func containsString(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
+
+// sortedKeys returns the keys of a map in order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
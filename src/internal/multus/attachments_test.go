@@ -0,0 +1,140 @@
+// Package multus provides unit tests for the NetworkAttachmentDefinition generation
+// WHY: Pods reach the VLANs through these definitions, so they must match the interfaces and addressing of the bundle
+package multus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"k8ostack-ictl/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testBundle has a VLAN on an aliased interface, one on the default interface and one whose alias differs per node
+func testBundle() *config.ConfigBundle {
+	return &config.ConfigBundle{
+		VLANs: &config.NodeVLANConf{Spec: config.NodeVLANSpec{VLANs: map[string]config.VLANConfig{
+			"management":  {ID: 100, Subnet: "10.1.0.0/24", Interface: "uplink1", NodeMapping: map[string]string{"rsb2": "10.1.0.2/24", "rsb3": "10.1.0.3/24,fd00:1::3/64"}},
+			"storage_net": {ID: 200, Subnet: "10.2.0.0/24", NodeMapping: map[string]string{"rsb2": "10.2.0.2/24"}},
+			"tenant":      {ID: 300, Subnet: "10.3.0.0/24", Interface: "uplink2", NodeMapping: map[string]string{"rsb2": "10.3.0.2/24", "rsb3": "10.3.0.3/24"}},
+		}}},
+		Defaults: &config.Defaults{Spec: config.DefaultsSpec{
+			Interface: "eth0",
+			InterfaceAliases: config.InterfaceAliases{
+				"*":    {"uplink1": "eno1", "uplink2": "eno2"},
+				"rsb3": {"uplink2": "ens1f1"},
+			},
+		}},
+	}
+}
+
+// TestFromBundle tests generating the definitions of a bundle
+// WHY: The master must be the VLAN interface on every node, and whereabouts must never hand out a node address
+func TestFromBundle(t *testing.T) {
+	// Given: A bundle with three VLANs
+	bundle := testBundle()
+
+	// When: Generate its definitions in a namespace
+	attachments, err := FromBundle(bundle, Options{Namespace: "openstack"})
+
+	// Then: The VLANs with one parent interface get a macvlan definition, the other one a warning
+	require.NoError(t, err)
+	require.Len(t, attachments.Definitions, 2)
+	management := attachments.Definitions[0]
+	assert.Equal(t, Metadata{Name: "management", Namespace: "openstack", Labels: map[string]string{
+		ManagedByLabel: ManagedBy, VLANIDLabel: "100",
+	}}, management.Metadata)
+	var plugin pluginConfig
+	require.NoError(t, json.Unmarshal([]byte(management.Spec.Config), &plugin))
+	assert.Equal(t, pluginConfig{CNIVersion: cniVersion, Name: "management", Type: PluginMacvlan, Master: "eno1.100", Mode: "bridge",
+		IPAM: ipamConfig{Type: IPAMWhereabouts, Range: "10.1.0.0/24", Exclude: []string{"10.1.0.2/32", "10.1.0.3/32"}}}, plugin)
+	assert.Equal(t, "storage-net", attachments.Definitions[1].Metadata.Name)
+	assert.Contains(t, attachments.Definitions[1].Spec.Config, `"master": "eth0.200"`)
+	assert.Equal(t, []string{"VLAN tenant: its parent interface differs per node (eno2 on rsb2; ens1f1 on rsb3), which one NetworkAttachmentDefinition cannot express"},
+		attachments.Warnings)
+}
+
+// TestOptions tests choosing the plugin, mode and IPAM
+// WHY: A mode of the other plugin would only fail when a pod starts, so it must be rejected up front
+func TestOptions(t *testing.T) {
+	tests := []struct {
+		name          string
+		opts          Options
+		expectedMode  string
+		expectedError string
+	}{
+		{name: "macvlan_default", opts: Options{}, expectedMode: "bridge"},
+		{name: "ipvlan_default", opts: Options{Plugin: PluginIPVLAN}, expectedMode: "l2"},
+		{name: "ipvlan_l3", opts: Options{Plugin: PluginIPVLAN, Mode: "l3"}, expectedMode: "l3"},
+		{name: "mode_of_other_plugin", opts: Options{Mode: "l3"}, expectedError: "unknown macvlan mode 'l3'. Expected: bridge, private, vepa, passthru"},
+		{name: "unknown_plugin", opts: Options{Plugin: "bridge"}, expectedError: "unknown plugin 'bridge'"},
+		{name: "unknown_ipam", opts: Options{IPAM: "dhcp"}, expectedError: "unknown IPAM 'dhcp'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// When: Normalize the options
+			err := tt.opts.normalize()
+
+			// Then: Defaults are filled in or the options rejected
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedMode, tt.opts.Mode)
+		})
+	}
+}
+
+// TestApply tests applying the definitions with kubectl
+// WHY: A dry run must only validate, and a cluster without Multus must be named as the cause
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name          string
+		dryRun        bool
+		output        string
+		runErr        error
+		expectedArgs  []string
+		expectedError string
+	}{
+		{name: "apply", expectedArgs: []string{"apply", "-f", "-"}},
+		{name: "dry_run", dryRun: true, expectedArgs: []string{"apply", "-f", "-", "--dry-run=server"}},
+		{
+			name:          "multus_missing",
+			output:        `error: resource mapping not found: no matches for kind "NetworkAttachmentDefinition" in version "k8s.cni.cncf.io/v1"`,
+			runErr:        errors.New("exit status 1"),
+			expectedError: "the cluster does not know NetworkAttachmentDefinition; is Multus installed?",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: The definitions of a bundle and a recording kubectl
+			attachments, err := FromBundle(testBundle(), Options{})
+			require.NoError(t, err)
+			var args []string
+			var stdin []byte
+			run := func(ctx context.Context, input []byte, runArgs ...string) (string, error) {
+				args, stdin = runArgs, input
+				return tt.output, tt.runErr
+			}
+
+			// When: Apply them
+			_, err = attachments.Apply(context.Background(), tt.dryRun, run)
+
+			// Then: kubectl gets the manifests on its standard input
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedArgs, args)
+			assert.Contains(t, string(stdin), "name: storage-net")
+		})
+	}
+}
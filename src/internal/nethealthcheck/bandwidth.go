@@ -0,0 +1,167 @@
+package nethealthcheck
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/kubectl"
+)
+
+// executeBandwidthTest measures the TCP throughput from the first source node to every target node with iperf3
+// Each target runs a one-off iperf3 server and the source connects to the target's address on the target network,
+// so the traffic crosses that VLAN. The test succeeds when every target reaches the minimum throughput.
+func (nhs *NetHealthCheckService) executeBandwidthTest(ctx context.Context, testConfig config.ConnectivityTest) (*TestExecution, error) {
+	startTime := time.Now()
+
+	sourceNodes, err := nhs.getNodesForNetwork(testConfig.Source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get source nodes for network %s: %w", testConfig.Source, err)
+	}
+	if len(sourceNodes) == 0 {
+		return nil, fmt.Errorf("no nodes found for source network %s", testConfig.Source)
+	}
+	sourceNode := sourceNodes[0]
+
+	testExecution := &TestExecution{
+		TestName:      testConfig.Name,
+		TestType:      config.TestTypeBandwidth,
+		SourceNode:    sourceNode,
+		TargetNode:    fmt.Sprintf("%v", testConfig.Targets), // Multiple targets
+		SourceNetwork: testConfig.Source,
+		TargetNetwork: strings.Join(testConfig.Targets, ","),
+		Protocol:      "tcp",
+		Port:          testConfig.Port,
+		ExpectSuccess: true,
+		ActualSuccess: true,
+		MinMbps:       testConfig.MinMbps,
+	}
+
+	var outputs []string
+	measured := false
+	for _, targetNetwork := range testConfig.Targets {
+		targetNodes, err := nhs.getNodesForNetwork(targetNetwork)
+		if err != nil {
+			nhs.options.Logger.Warn(fmt.Sprintf("Failed to get target nodes for network %s: %v", targetNetwork, err))
+			continue
+		}
+
+		for _, targetNode := range targetNodes {
+			if targetNode == sourceNode {
+				continue
+			}
+			targetIP, err := nhs.getNodeIPForNetwork(targetNode, targetNetwork)
+			if err != nil {
+				nhs.options.Logger.Warn(fmt.Sprintf("Failed to get IP for node %s in network %s: %v", targetNode, targetNetwork, err))
+				continue
+			}
+
+			if nhs.options.DryRun {
+				nhs.options.Logger.Info(fmt.Sprintf("🧪 DRY RUN: Would measure bandwidth %s -> %s(%s), expecting at least %s Mbps",
+					sourceNode, targetNode, targetIP, formatMbps(testConfig.MinMbps)))
+				continue
+			}
+
+			mbps, err := nhs.measureBandwidth(ctx, sourceNode, targetNode, targetIP, testConfig)
+			if err != nil {
+				outputs = append(outputs, fmt.Sprintf("%s->%s(%s): %v", sourceNode, targetNode, targetIP, err))
+				testExecution.ActualSuccess = false
+				if testExecution.ErrorMessage == "" {
+					testExecution.ErrorMessage = err.Error()
+				}
+				continue
+			}
+
+			outputs = append(outputs, fmt.Sprintf("%s->%s(%s): %s Mbps", sourceNode, targetNode, targetIP, formatMbps(mbps)))
+			nhs.options.Logger.Info(fmt.Sprintf("📶 Bandwidth %s -> %s(%s): %s Mbps", sourceNode, targetNode, targetIP, formatMbps(mbps)))
+			if !measured || mbps < testExecution.ThroughputMbps {
+				testExecution.ThroughputMbps = mbps
+			}
+			measured = true
+			if mbps < testConfig.MinMbps {
+				testExecution.ActualSuccess = false
+				if testExecution.ErrorMessage == "" {
+					testExecution.ErrorMessage = fmt.Sprintf("bandwidth %s -> %s is %s Mbps, below %s Mbps",
+						sourceNode, targetNode, formatMbps(mbps), formatMbps(testConfig.MinMbps))
+				}
+			}
+		}
+	}
+
+	testExecution.Duration = time.Since(startTime)
+	testExecution.Output = strings.Join(outputs, "; ")
+	if nhs.options.DryRun {
+		testExecution.Output = "DRY RUN: Test would execute as expected"
+		return testExecution, nil
+	}
+	// A test reaching no target measured nothing, which must not pass for enough bandwidth
+	if !measured && testExecution.ActualSuccess {
+		testExecution.ActualSuccess = false
+		testExecution.ErrorMessage = fmt.Sprintf("no target of %s could be measured from %s", testExecution.TargetNetwork, sourceNode)
+	}
+	return testExecution, nil
+}
+
+// measureBandwidth starts a one-off iperf3 server on the target and runs the client on the source against it
+func (nhs *NetHealthCheckService) measureBandwidth(ctx context.Context, sourceNode, targetNode, targetIP string, testConfig config.ConnectivityTest) (float64, error) {
+	port := strconv.Itoa(testConfig.Port)
+	nhs.options.Logger.Info(fmt.Sprintf("📡 Executing bandwidth test: %s -> %s for %ds", sourceNode, targetIP, testConfig.Duration))
+
+	// -1 stops the server after one client; the short sleep lets the daemon bind before the client connects
+	server := kubectl.JoinHostCommands(kubectl.HostCommand("iperf3", "-s", "-1", "-D", "-p", port), kubectl.HostCommand("sleep", "1"))
+	success, output, err := nhs.kubectl.ExecNodeCommand(ctx, targetNode, server)
+	if err != nil {
+		return 0, fmt.Errorf("failed to start iperf3 server on node %s: %w", targetNode, err)
+	}
+	if !success {
+		return 0, fmt.Errorf("failed to start iperf3 server on node %s: %s", targetNode, strings.TrimSpace(output))
+	}
+	// A server the client never reached is still waiting; the anchored pattern spares the shell running pkill
+	defer nhs.kubectl.ExecNodeCommand(ctx, targetNode, kubectl.HostCommand("pkill", "-f", stopServerPattern(port))+" || true")
+
+	client := kubectl.HostCommand("iperf3", "-c", targetIP, "-p", port, "-t", strconv.Itoa(testConfig.Duration), "-J")
+	_, output, err = probe(ctx, testConfig.Timeout, func(ctx context.Context) (bool, string, error) {
+		return nhs.kubectl.ExecNodeCommand(ctx, sourceNode, client)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to run iperf3 client on node %s: %w", sourceNode, err)
+	}
+	return parseIperfThroughput(output)
+}
+
+// stopServerPattern matches the command line of the iperf3 server of a port, and nothing else
+func stopServerPattern(port string) string {
+	return fmt.Sprintf("^iperf3 -s -1 -D -p %s$", port)
+}
+
+// iperfReport is the part of iperf3 -J output holding the throughput or the failure
+type iperfReport struct {
+	End struct {
+		SumReceived struct {
+			BitsPerSecond float64 `json:"bits_per_second"`
+		} `json:"sum_received"`
+	} `json:"end"`
+	Error string `json:"error"`
+}
+
+// parseIperfThroughput returns the throughput the iperf3 server received, in Mbit/s
+func parseIperfThroughput(output string) (float64, error) {
+	var report iperfReport
+	if err := json.Unmarshal([]byte(output), &report); err != nil {
+		return 0, fmt.Errorf("unexpected iperf3 output: %s", strings.TrimSpace(output))
+	}
+	if report.Error != "" {
+		return 0, fmt.Errorf("iperf3: %s", report.Error)
+	}
+	return report.End.SumReceived.BitsPerSecond / 1e6, nil
+}
+
+// formatMbps formats a throughput in Mbit/s rounded to one decimal, e.g. 9412.3 or 9000
+func formatMbps(mbps float64) string {
+	return strconv.FormatFloat(math.Round(mbps*10)/10, 'f', -1, 64)
+}
@@ -0,0 +1,92 @@
+// Package nethealthcheck provides unit tests for iperf3 bandwidth tests
+// WHY: A link negotiated at a fraction of its speed still answers ping, so throughput must be measured per target
+package nethealthcheck
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/logging"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestExecuteBandwidthTest tests measuring the throughput from the source node to every target node
+// WHY: The slowest target decides the test, and a target that cannot be measured fails it
+func TestExecuteBandwidthTest(t *testing.T) {
+	tests := []struct {
+		name             string
+		clientOutputs    map[string]string // target IP -> iperf3 -J output
+		expectSuccess    bool
+		expectThroughput float64
+		expectError      string
+	}{
+		{
+			name: "above_minimum",
+			clientOutputs: map[string]string{
+				"10.0.50.16": `{"end": {"sum_received": {"bits_per_second": 9412345678}}}`,
+				"10.0.50.17": `{"end": {"sum_received": {"bits_per_second": 9100000000}}}`,
+			},
+			expectSuccess:    true,
+			expectThroughput: 9100,
+		},
+		{
+			name: "below_minimum",
+			clientOutputs: map[string]string{
+				"10.0.50.16": `{"end": {"sum_received": {"bits_per_second": 9412345678}}}`,
+				"10.0.50.17": `{"end": {"sum_received": {"bits_per_second": 940123456}}}`,
+			},
+			expectThroughput: 940.123456,
+			expectError:      "bandwidth rsb5 -> rsb7 is 940.1 Mbps, below 9000 Mbps",
+		},
+		{
+			name: "server_unreachable",
+			clientOutputs: map[string]string{
+				"10.0.50.16": `{"end": {"sum_received": {"bits_per_second": 9412345678}}}`,
+				"10.0.50.17": `{"start": {}, "end": {}, "error": "unable to connect to server: Connection refused"}`,
+			},
+			expectThroughput: 9412.345678,
+			expectError:      "iperf3: unable to connect to server: Connection refused",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: rsb5 on the replication VLAN and rsb6 and rsb7 on the backend VLAN, all with iperf3
+			executor := &MockDryRunExecutor{}
+			executor.On("ExecNodeCommand", mock.Anything, mock.Anything, mock.MatchedBy(func(cmd string) bool {
+				return strings.HasPrefix(cmd, "iperf3 -s -1 -D -p 5201")
+			})).Return(true, "", nil)
+			executor.On("ExecNodeCommand", mock.Anything, mock.Anything, "pkill -f '^iperf3 -s -1 -D -p 5201$' || true").Return(true, "", nil)
+			for ip, output := range tt.clientOutputs {
+				executor.On("ExecNodeCommand", mock.Anything, "rsb5", "iperf3 -c "+ip+" -p 5201 -t 10 -J").Return(true, output, nil).Once()
+			}
+			service := &NetHealthCheckService{
+				kubectl: executor,
+				options: Options{Logger: logging.NewRecordingLogger()},
+				vlanConfig: &config.NodeVLANConf{Spec: config.NodeVLANSpec{VLANs: map[string]config.VLANConfig{
+					"replication": {ID: 40, NodeMapping: config.NodeMapping{"rsb5": "10.0.40.15/24"}},
+					"backend":     {ID: 50, NodeMapping: config.NodeMapping{"rsb6": "10.0.50.16/24", "rsb7": "10.0.50.17/24"}},
+				}}},
+			}
+			test := config.ConnectivityTest{Name: "backend-throughput", Type: config.TestTypeBandwidth, Source: "replication",
+				Targets: []string{"backend"}, ExpectSuccess: true, MinMbps: 9000, Duration: 10, Port: 5201}
+
+			// When: Run the bandwidth test
+			execution, err := service.executeNetworkTest(context.Background(), test)
+
+			// Then: The lowest throughput is reported and decides the test
+			require.NoError(t, err)
+			executor.AssertExpectations(t)
+			assert.Equal(t, config.TestTypeBandwidth, execution.TestType)
+			assert.Equal(t, "rsb5", execution.SourceNode)
+			assert.Equal(t, tt.expectSuccess, execution.ActualSuccess)
+			assert.InDelta(t, tt.expectThroughput, execution.ThroughputMbps, 0.001)
+			assert.Equal(t, tt.expectError, execution.ErrorMessage)
+		})
+	}
+}
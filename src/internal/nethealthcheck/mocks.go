@@ -126,6 +126,9 @@ func (m *MockNetHealthCheckService) GetCurrentState(ctx context.Context, network
 	return state, nil
 }
 
+// Cleanup mock implementation
+func (m *MockNetHealthCheckService) Cleanup(ctx context.Context) {}
+
 // NetworkTestError represents a network testing error
 type NetworkTestError struct {
 	TestName    string
@@ -0,0 +1,120 @@
+// Package nethealthcheck provides unit tests for tcp and http connectivity tests
+// WHY: API endpoints, RabbitMQ and Galera answer on ports that a passing ping says nothing about
+package nethealthcheck
+
+import (
+	"context"
+	"testing"
+
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/logging"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// portsTestVLANs places rsb5 on the replication VLAN and rsb6 and rsb7 on the backend VLAN
+var portsTestVLANs = &config.NodeVLANConf{Spec: config.NodeVLANSpec{VLANs: map[string]config.VLANConfig{
+	"replication": {ID: 40, NodeMapping: config.NodeMapping{"rsb5": "10.0.40.15/24"}},
+	"backend":     {ID: 50, NodeMapping: config.NodeMapping{"rsb6": "10.0.50.16/24", "rsb7": "10.0.50.17/24"}},
+}}}
+
+// TestExecuteTCPTest tests connecting to a port of every target node
+// WHY: One closed port fails a test expecting it open, and a test expecting isolation passes only if none answers
+func TestExecuteTCPTest(t *testing.T) {
+	tests := []struct {
+		name          string
+		open          map[string]bool // target IP -> port answers
+		expectSuccess bool
+		expectPassed  bool
+	}{
+		{"all_open", map[string]bool{"10.0.50.16": true, "10.0.50.17": true}, true, true},
+		{"one_closed", map[string]bool{"10.0.50.16": true, "10.0.50.17": false}, true, false},
+		{"isolated", map[string]bool{"10.0.50.16": false, "10.0.50.17": false}, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: Galera on port 3306 of the backend nodes, open or closed
+			executor := &MockDryRunExecutor{}
+			for ip, open := range tt.open {
+				output := ""
+				if !open {
+					output = "nc: connect to " + ip + " port 3306 (tcp) failed: Connection refused"
+				}
+				executor.On("ExecNodeCommand", mock.Anything, "rsb5", "nc -z -w 5 "+ip+" 3306").Return(open, output, nil).Once()
+			}
+			service := &NetHealthCheckService{kubectl: executor, options: Options{Logger: logging.NewRecordingLogger()}, vlanConfig: portsTestVLANs}
+			test := config.ConnectivityTest{Name: "galera", Type: config.TestTypeTCP, Source: "replication",
+				Targets: []string{"backend"}, Port: 3306, Timeout: 5, ExpectSuccess: tt.expectSuccess}
+
+			// When: Run the tcp test
+			execution, err := service.executeNetworkTest(context.Background(), test)
+
+			// Then: Every target was connected to and the result matches the expectation or not
+			require.NoError(t, err)
+			executor.AssertExpectations(t)
+			assert.Equal(t, config.TestTypeTCP, execution.TestType)
+			assert.Equal(t, "tcp", execution.Protocol)
+			assert.Equal(t, 3306, execution.Port)
+			assert.Equal(t, tt.expectPassed, execution.ActualSuccess == execution.ExpectSuccess)
+		})
+	}
+}
+
+// TestExecuteHTTPTest tests requesting the URL of a test from the source node
+// WHY: An endpoint answering with the wrong status is as broken as one not answering, and both must say which
+func TestExecuteHTTPTest(t *testing.T) {
+	tests := []struct {
+		name         string
+		insecure     bool
+		command      string
+		status       string
+		expectPassed bool
+		expectError  string
+	}{
+		{
+			name:         "expected_status",
+			command:      "curl -s -o /dev/null -w '%{http_code}' --max-time 5 https://keystone.mgmt:5000/v3",
+			status:       "300",
+			expectPassed: true,
+		},
+		{
+			name:        "unexpected_status",
+			insecure:    true,
+			command:     "curl -s -o /dev/null -w '%{http_code}' --max-time 5 -k https://keystone.mgmt:5000/v3",
+			status:      "503",
+			expectError: "https://keystone.mgmt:5000/v3 answered 503 from rsb5, expected 300",
+		},
+		{
+			name:        "no_answer",
+			command:     "curl -s -o /dev/null -w '%{http_code}' --max-time 5 https://keystone.mgmt:5000/v3",
+			status:      "000",
+			expectError: "https://keystone.mgmt:5000/v3 did not answer from rsb5",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: Keystone answering the source node with a status
+			executor := &MockDryRunExecutor{}
+			executor.On("ExecNodeCommand", mock.Anything, "rsb5", tt.command).Return(tt.status != "000", tt.status, nil).Once()
+			service := &NetHealthCheckService{kubectl: executor, options: Options{Logger: logging.NewRecordingLogger()}, vlanConfig: portsTestVLANs}
+			test := config.ConnectivityTest{Name: "keystone", Type: config.TestTypeHTTP, Source: "replication",
+				URL: "https://keystone.mgmt:5000/v3", ExpectStatus: 300, Insecure: tt.insecure, Timeout: 5, ExpectSuccess: true}
+
+			// When: Run the http test
+			execution, err := service.executeNetworkTest(context.Background(), test)
+
+			// Then: Only the expected status passes, and failures name the URL and status
+			require.NoError(t, err)
+			executor.AssertExpectations(t)
+			assert.Equal(t, "https", execution.Protocol)
+			assert.Equal(t, 5000, execution.Port)
+			assert.Equal(t, tt.expectPassed, execution.ActualSuccess)
+			assert.Equal(t, tt.expectError, execution.ErrorMessage)
+			assert.Equal(t, "rsb5->https://keystone.mgmt:5000/v3: HTTP "+tt.status, execution.Output)
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package nethealthcheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"k8ostack-ictl/internal/config"
+)
+
+// defaultRetryDelay is the wait between attempts of a test that sets no retryDelay; a variable so tests need not wait
+var defaultRetryDelay = 5 * time.Second
+
+// runTest executes a test, retrying a test expecting success that fails up to its retry count
+// A test passing only after a retry is flaky; one failing every attempt is a hard failure. Tests expecting
+// isolation are not retried, since traffic getting through once already shows the isolation is broken.
+func (nhs *NetHealthCheckService) runTest(ctx context.Context, testConfig config.ConnectivityTest) (*TestExecution, error) {
+	retries := testConfig.Retries
+	if retries == 0 {
+		retries = nhs.options.Retries
+	}
+	if !testConfig.ExpectSuccess || nhs.options.DryRun {
+		retries = 0
+	}
+	delay := time.Duration(testConfig.RetryDelay) * time.Second
+	if delay == 0 {
+		delay = defaultRetryDelay
+	}
+
+	for attempt := 1; ; attempt++ {
+		execution, err := nhs.executeNetworkTest(ctx, testConfig)
+		passed := err == nil && execution.ActualSuccess == execution.ExpectSuccess
+		if execution != nil {
+			execution.Attempts = attempt
+			execution.Flaky = passed && attempt > 1
+		}
+		if passed || attempt > retries || ctx.Err() != nil {
+			return execution, err
+		}
+
+		var reason string
+		if err != nil {
+			reason = err.Error()
+		} else {
+			reason = execution.ErrorMessage
+		}
+		nhs.options.Logger.Warn(fmt.Sprintf("🔄 Test %s failed attempt %d of %d, retrying in %s: %s", testConfig.Name, attempt, retries+1, delay, reason))
+		select {
+		case <-ctx.Done():
+			return execution, err
+		case <-time.After(delay):
+		}
+	}
+}
+
+// probe runs one node command of a test within the test's timeout, reporting a timeout as an error
+func probe(ctx context.Context, timeout int, run func(ctx context.Context) (bool, string, error)) (bool, string, error) {
+	if timeout <= 0 {
+		return run(ctx)
+	}
+	probeCtx, cancel := context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
+	defer cancel()
+
+	success, output, err := run(probeCtx)
+	if errors.Is(probeCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+		return false, output, fmt.Errorf("timed out after %ds", timeout)
+	}
+	return success, output, err
+}
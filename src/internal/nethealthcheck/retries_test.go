@@ -0,0 +1,83 @@
+// Package nethealthcheck provides unit tests for test retries and probe timeouts
+// WHY: A lost packet must not fail a run, yet a test that only passes on retry must still be reported
+package nethealthcheck
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/logging"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRunTest tests retrying failed tests and classifying flaky and hard failures
+// WHY: Only tests expecting success are retried; traffic getting through an isolation test once is a leak
+func TestRunTest(t *testing.T) {
+	original := defaultRetryDelay
+	defaultRetryDelay = time.Millisecond
+	t.Cleanup(func() { defaultRetryDelay = original })
+
+	tests := []struct {
+		name           string
+		expectSuccess  bool
+		connects       []bool // Result of each attempt to connect
+		expectAttempts int
+		expectFlaky    bool
+		expectPassed   bool
+	}{
+		{"passes_first_attempt", true, []bool{true}, 1, false, true},
+		{"flaky", true, []bool{false, true}, 2, true, true},
+		{"hard_failure", true, []bool{false, false, false}, 3, false, false},
+		{"isolation_not_retried", false, []bool{true}, 1, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A tcp test with two retries against rsb6, connecting or not on each attempt
+			executor := &MockDryRunExecutor{}
+			for _, connects := range tt.connects {
+				executor.On("ExecNodeCommand", mock.Anything, "rsb5", "nc -z -w 5 10.0.50.16 5672").Return(connects, "", nil).Once()
+			}
+			service := &NetHealthCheckService{kubectl: executor, options: Options{Logger: logging.NewRecordingLogger()}, vlanConfig: &config.NodeVLANConf{
+				Spec: config.NodeVLANSpec{VLANs: map[string]config.VLANConfig{
+					"replication": {ID: 40, NodeMapping: config.NodeMapping{"rsb5": "10.0.40.15/24"}},
+					"backend":     {ID: 50, NodeMapping: config.NodeMapping{"rsb6": "10.0.50.16/24"}},
+				}},
+			}}
+			test := config.ConnectivityTest{Name: "rabbitmq", Type: config.TestTypeTCP, Source: "replication", Targets: []string{"backend"},
+				Port: 5672, Timeout: 5, Retries: 2, ExpectSuccess: tt.expectSuccess}
+
+			// When: Run the test
+			execution, err := service.runTest(context.Background(), test)
+
+			// Then: It ran until it passed or ran out of attempts
+			require.NoError(t, err)
+			executor.AssertExpectations(t)
+			assert.Equal(t, tt.expectAttempts, execution.Attempts)
+			assert.Equal(t, tt.expectFlaky, execution.Flaky)
+			assert.Equal(t, tt.expectPassed, execution.ActualSuccess == execution.ExpectSuccess)
+		})
+	}
+}
+
+// TestProbe tests bounding a probe by the timeout of its test
+// WHY: A node command that hangs must fail its probe after the timeout instead of stalling the run
+func TestProbe(t *testing.T) {
+	// Given: A probe that blocks until its context ends
+	hang := func(ctx context.Context) (bool, string, error) {
+		<-ctx.Done()
+		return true, "", ctx.Err()
+	}
+
+	// When: Run it with a timeout of one second
+	success, _, err := probe(context.Background(), 1, hang)
+
+	// Then: It fails with the timeout
+	assert.False(t, success)
+	assert.EqualError(t, err, "timed out after 1s")
+}
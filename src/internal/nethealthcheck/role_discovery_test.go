@@ -5,6 +5,9 @@ import (
 	"context"
 	"strings"
 	"testing"
+	"time"
+
+	"k8ostack-ictl/internal/logging"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -25,6 +28,11 @@ func (m *MockDryRunExecutor) LabelNode(ctx context.Context, nodeName, label stri
 	return args.Bool(0), args.String(1), args.Error(2)
 }
 
+func (m *MockDryRunExecutor) LabelNodes(ctx context.Context, nodeNames []string, labels map[string]string, overwrite bool) (bool, string, error) {
+	args := m.Called(ctx, nodeNames, labels, overwrite)
+	return args.Bool(0), args.String(1), args.Error(2)
+}
+
 func (m *MockDryRunExecutor) UnlabelNode(ctx context.Context, nodeName, labelKey string) (bool, string, error) {
 	args := m.Called(ctx, nodeName, labelKey)
 	return args.Bool(0), args.String(1), args.Error(2)
@@ -35,6 +43,16 @@ func (m *MockDryRunExecutor) GetNodeLabels(ctx context.Context, nodeName string)
 	return args.Bool(0), args.String(1), args.Error(2)
 }
 
+func (m *MockDryRunExecutor) GetNodeAnnotations(ctx context.Context, nodeName string) (bool, string, error) {
+	args := m.Called(ctx, nodeName)
+	return args.Bool(0), args.String(1), args.Error(2)
+}
+
+func (m *MockDryRunExecutor) GetNodeInternalIP(ctx context.Context, nodeName string) (bool, string, error) {
+	args := m.Called(ctx, nodeName)
+	return args.Bool(0), args.String(1), args.Error(2)
+}
+
 func (m *MockDryRunExecutor) ExecNodeCommand(ctx context.Context, nodeName, command string) (bool, string, error) {
 	args := m.Called(ctx, nodeName, command)
 	return args.Bool(0), args.String(1), args.Error(2)
@@ -50,6 +68,12 @@ func (m *MockDryRunExecutor) DeletePod(ctx context.Context, podName string) (boo
 	return args.Bool(0), args.String(1), args.Error(2)
 }
 
+// ReleaseDebugPods mocks releasing the node command pods of a run
+func (m *MockDryRunExecutor) ReleaseDebugPods(ctx context.Context) (int, error) {
+	args := m.Called(ctx)
+	return args.Int(0), args.Error(1)
+}
+
 func (m *MockDryRunExecutor) GetAllNodes(ctx context.Context) (bool, string, error) {
 	args := m.Called(ctx)
 	return args.Bool(0), args.String(1), args.Error(2)
@@ -90,6 +114,11 @@ func (m *MockDryRunExecutor) GetNodeHardwareInfo(ctx context.Context, nodeName s
 	return args.Bool(0), args.String(1), args.Error(2)
 }
 
+func (m *MockDryRunExecutor) RunKubectl(ctx context.Context, stdin []byte, args ...string) (string, error) {
+	callArgs := m.Called(ctx, stdin, args)
+	return callArgs.String(0), callArgs.Error(1)
+}
+
 func (m *MockDryRunExecutor) SetDryRun(enabled bool) {
 	m.Called(enabled)
 }
@@ -99,31 +128,10 @@ func (m *MockDryRunExecutor) IsDryRun() bool {
 	return args.Bool(0)
 }
 
-func (m *MockDryRunExecutor) SetPollingInterval(interval interface{}) {
+func (m *MockDryRunExecutor) SetPollingInterval(interval time.Duration) {
 	m.Called(interval)
 }
 
-// MockLogger for testing
-type MockLogger struct {
-	mock.Mock
-}
-
-func (m *MockLogger) Debug(message string) {
-	m.Called(message)
-}
-
-func (m *MockLogger) Info(message string) {
-	m.Called(message)
-}
-
-func (m *MockLogger) Warn(message string) {
-	m.Called(message)
-}
-
-func (m *MockLogger) Error(message string) {
-	m.Called(message)
-}
-
 // TestRoleBasedNodeDiscovery tests the new role-based node selection logic
 func TestRoleBasedNodeDiscovery(t *testing.T) {
 	tests := []struct {
@@ -187,7 +195,7 @@ func TestRoleBasedNodeDiscovery(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			// Setup mocks
 			mockKubectl := &MockDryRunExecutor{}
-			mockLogger := &MockLogger{}
+			mockLogger := logging.NewMockLogger()
 
 			// Mock GetAllNodes
 			mockKubectl.On("GetAllNodes", mock.Anything).Return(true, tt.nodeList, nil)
@@ -230,7 +238,7 @@ func TestRoleBasedNodeDiscovery(t *testing.T) {
 // TestNetworkRoleMapping tests the network to role mapping logic
 func TestNetworkRoleMapping(t *testing.T) {
 	mockKubectl := &MockDryRunExecutor{}
-	mockLogger := &MockLogger{}
+	mockLogger := logging.NewMockLogger()
 
 	service := &NetHealthCheckService{
 		kubectl: mockKubectl,
@@ -267,9 +275,10 @@ func TestNetworkRoleMapping(t *testing.T) {
 					mockKubectl.On("GetAllNodes", mock.Anything).Return(true, "node/rsb2\nnode/rsb5", nil)
 				} else {
 					// Other networks use role-based discovery
-					mockKubectl.On("GetAllNodes", mock.Anything).Return(true, "node/rsb2\nnode/rsb5", nil)
+					mockKubectl.On("GetAllNodes", mock.Anything).Return(true, "node/rsb2\nnode/rsb5\nnode/rsb7", nil)
 					mockKubectl.On("GetNodeRole", mock.Anything, "rsb2").Return("control-plane", nil)
 					mockKubectl.On("GetNodeRole", mock.Anything, "rsb5").Return("storage", nil)
+					mockKubectl.On("GetNodeRole", mock.Anything, "rsb7").Return("compute", nil)
 					mockLogger.On("Info", mock.AnythingOfType("string")).Return().Maybe()
 				}
 			}
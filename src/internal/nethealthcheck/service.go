@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/kubectl"
 )
 
 
@@ -52,8 +53,8 @@ func (nhs *NetHealthCheckService) processTests(ctx context.Context, cfg *config.
 			nhs.options.Logger.Info(fmt.Sprintf("  Description: %s", testConfig.Description))
 		}
 
-		// Execute the actual test
-		testExecution, err := nhs.executeNetworkTest(ctx, testConfig)
+		// Execute the actual test, retrying it when it fails
+		testExecution, err := nhs.runTest(ctx, testConfig)
 		if err != nil {
 			nhs.options.Logger.Error(fmt.Sprintf("Failed to execute test %s: %v", testConfig.Name, err))
 			results.Errors = append(results.Errors, err)
@@ -67,9 +68,19 @@ func (nhs *NetHealthCheckService) processTests(ctx context.Context, cfg *config.
 			
 			if testPassed {
 				nhs.options.Logger.Info(fmt.Sprintf("✅ Test %s completed successfully in %v", testConfig.Name, testExecution.Duration))
+				if testExecution.Flaky {
+					nhs.options.Logger.Warn(fmt.Sprintf("⚠️  Test %s is flaky: passed on attempt %d", testConfig.Name, testExecution.Attempts))
+					results.FlakyTests++
+				}
+				if testExecution.TestType == config.TestTypeBandwidth && !nhs.options.DryRun {
+					nhs.options.Logger.Info(fmt.Sprintf("📶 Test %s measured at least %s Mbps (minimum %s Mbps)", testConfig.Name, formatMbps(testExecution.ThroughputMbps), formatMbps(testExecution.MinMbps)))
+				}
 				results.SuccessfulTests++
 			} else {
 				nhs.options.Logger.Warn(fmt.Sprintf("❌ Test %s failed: %s", testConfig.Name, testExecution.ErrorMessage))
+				if testExecution.Attempts > 1 {
+					nhs.options.Logger.Warn(fmt.Sprintf("❌ Test %s failed all %d attempts", testConfig.Name, testExecution.Attempts))
+				}
 				results.FailedTests++
 			}
 			results.TestExecutions = append(results.TestExecutions, *testExecution)
@@ -84,13 +95,16 @@ func (nhs *NetHealthCheckService) processTests(ctx context.Context, cfg *config.
 	nhs.options.Logger.Info(fmt.Sprintf("  Total tests executed: %d", results.TotalTests))
 	nhs.options.Logger.Info(fmt.Sprintf("  Successful tests: %d", results.SuccessfulTests))
 	nhs.options.Logger.Info(fmt.Sprintf("  Failed tests: %d", results.FailedTests))
+	if results.FlakyTests > 0 {
+		nhs.options.Logger.Warn(fmt.Sprintf("  Flaky tests (passed after a retry): %d", results.FlakyTests))
+	}
 
 	if len(results.Errors) > 0 {
 		nhs.options.Logger.Warn(fmt.Sprintf("  Errors encountered: %d", len(results.Errors)))
 	}
 
-	// Cleanup test pods after operations
-	if nhs.options.CleanupAfterTests {
+	// Cleanup test pods after operations; dry runs start none
+	if nhs.options.CleanupAfterTests && !nhs.options.DryRun {
 		nhs.cleanupTestPods(ctx)
 	}
 
@@ -99,7 +113,7 @@ func (nhs *NetHealthCheckService) processTests(ctx context.Context, cfg *config.
 
 // StopTests stops any running tests
 func (nhs *NetHealthCheckService) StopTests(ctx context.Context, config *config.NodeTestConf) (*TestResults, error) {
-	fmt.Println("Stopping network health tests...")
+	nhs.options.Logger.Info("🛑 Stopping network health tests...")
 	return &TestResults{}, nil
 }
 
@@ -130,6 +144,12 @@ func (nhs *NetHealthCheckService) GetCurrentState(ctx context.Context, networks
 
 // executeNetworkTest performs an actual network connectivity test
 func (nhs *NetHealthCheckService) executeNetworkTest(ctx context.Context, testConfig config.ConnectivityTest) (*TestExecution, error) {
+	switch testConfig.Type {
+	case config.TestTypeBandwidth:
+		return nhs.executeBandwidthTest(ctx, testConfig)
+	case config.TestTypeHTTP:
+		return nhs.executeHTTPTest(ctx, testConfig)
+	}
 	startTime := time.Now()
 
 	// Get source and target node mappings from network names
@@ -145,6 +165,12 @@ func (nhs *NetHealthCheckService) executeNetworkTest(ctx context.Context, testCo
 	// Use first source node for simplicity (could be enhanced to test from multiple)
 	sourceNode := sourceNodes[0]
 
+	// Ping tests reach each target over ICMP, tcp tests connect to a port
+	testType, protocol := config.TestTypePing, "icmp"
+	if testConfig.Type == config.TestTypeTCP {
+		testType, protocol = config.TestTypeTCP, "tcp"
+	}
+
 	// Test against each target network
 	var allResults []string
 	overallSuccess := true
@@ -164,11 +190,16 @@ func (nhs *NetHealthCheckService) executeNetworkTest(ctx context.Context, testCo
 				continue
 			}
 
-			success, output, err := nhs.executePingTest(ctx, sourceNode, targetIP)
+			success, output, err := probe(ctx, testConfig.Timeout, func(ctx context.Context) (bool, string, error) {
+				if testConfig.Type == config.TestTypeTCP {
+					return nhs.executeTCPTest(ctx, sourceNode, targetIP, testConfig.Port, testConfig.Timeout)
+				}
+				return nhs.executePingTest(ctx, sourceNode, targetIP)
+			})
 			allResults = append(allResults, fmt.Sprintf("%s->%s(%s): %s", sourceNode, targetNode, targetIP, output))
 			
-			// Debug logging for ping test results
-			nhs.options.Logger.Debug(fmt.Sprintf("🔍 Ping result: %s->%s success=%v err=%v", sourceNode, targetIP, success, err))
+			// Debug logging for probe results
+			nhs.options.Logger.Debug(fmt.Sprintf("🔍 %s result: %s->%s success=%v err=%v", testType, sourceNode, targetIP, success, err))
 			
 			if err != nil && firstError == nil {
 				firstError = err
@@ -177,7 +208,7 @@ func (nhs *NetHealthCheckService) executeNetworkTest(ctx context.Context, testCo
 			
 			if !success {
 				overallSuccess = false
-				nhs.options.Logger.Debug(fmt.Sprintf("🔍 Overall success set to false due to ping failure"))
+				nhs.options.Logger.Debug(fmt.Sprintf("🔍 Overall success set to false due to %s failure", testType))
 			}
 		}
 	}
@@ -188,12 +219,13 @@ func (nhs *NetHealthCheckService) executeNetworkTest(ctx context.Context, testCo
 	// Create test execution result
 	testExecution := &TestExecution{
 		TestName:      testConfig.Name,
-		TestType:      "ping",
+		TestType:      testType,
 		SourceNode:    sourceNode,
 		TargetNode:    fmt.Sprintf("%v", testConfig.Targets), // Multiple targets
 		SourceNetwork: testConfig.Source,
 		TargetNetwork: strings.Join(testConfig.Targets, ","),
-		Protocol:      "icmp",
+		Protocol:      protocol,
+		Port:          testConfig.Port,
 		ExpectSuccess: testConfig.ExpectSuccess,
 		ActualSuccess: overallSuccess,
 		Duration:      time.Since(startTime),
@@ -206,7 +238,7 @@ func (nhs *NetHealthCheckService) executeNetworkTest(ctx context.Context, testCo
 
 	// Handle dry run mode
 	if nhs.options.DryRun {
-		nhs.options.Logger.Info(fmt.Sprintf("🧪 DRY RUN: Would execute ping test %s", testConfig.Name))
+		nhs.options.Logger.Info(fmt.Sprintf("🧪 DRY RUN: Would execute %s test %s", testType, testConfig.Name))
 		testExecution.ActualSuccess = testConfig.ExpectSuccess // Assume expected result in dry run
 		testExecution.Output = "DRY RUN: Test would execute as expected"
 		testExecution.ErrorMessage = ""
@@ -358,7 +390,8 @@ func (nhs *NetHealthCheckService) getNodeIPForNetwork(nodeName, networkName stri
 		return "", fmt.Errorf("node %s not found in network %s", nodeName, networkName)
 	}
 
-	// Extract just the IP address (remove /24 CIDR notation)
+	// Extract just the IP address of the primary address (remove /24 CIDR notation)
+	ipAddress = config.PrimaryAddress(ipAddress)
 	if strings.Contains(ipAddress, "/") {
 		parts := strings.Split(ipAddress, "/")
 		ipAddress = parts[0]
@@ -369,7 +402,7 @@ func (nhs *NetHealthCheckService) getNodeIPForNetwork(nodeName, networkName stri
 
 // executePingTest performs a ping test between two nodes
 func (nhs *NetHealthCheckService) executePingTest(ctx context.Context, sourceNode, targetIP string) (bool, string, error) {
-	command := fmt.Sprintf("ping -c 3 %s", targetIP)
+	command := kubectl.HostCommand("ping", "-c", "3", targetIP)
 	nhs.options.Logger.Info(fmt.Sprintf("📡 Executing ping test: %s -> %s", sourceNode, targetIP))
 
 	success, output, err := nhs.kubectl.ExecNodeCommand(ctx, sourceNode, command)
@@ -380,47 +413,27 @@ func (nhs *NetHealthCheckService) executePingTest(ctx context.Context, sourceNod
 	return success, output, nil
 }
 
-// cleanupTestPods automatically cleans up test pods after operations
-func (nhs *NetHealthCheckService) cleanupTestPods(ctx context.Context) {
-	nhs.options.Logger.Info("🧹 Cleaning up test pods...")
-
-	// Give pods a moment to transition to final status
-	cleanupDelay := nhs.options.TestDelay
-	if cleanupDelay == 0 {
-		cleanupDelay = 3 * time.Second // Default for production
-	}
-	time.Sleep(cleanupDelay)
-
-	// Get all pods
-	success, output, err := nhs.kubectl.GetPods(ctx, "", "")
-	if err != nil || !success {
-		nhs.options.Logger.Warn(fmt.Sprintf("Failed to get pods: %v", err))
+// Cleanup removes the test pods left behind by a run that was interrupted, e.g. by a service timeout
+func (nhs *NetHealthCheckService) Cleanup(ctx context.Context) {
+	if nhs.options.DryRun {
 		return
 	}
+	nhs.cleanupTestPods(ctx)
+}
 
-	// Filter for debug pods
-	podNames := strings.Split(output, "\n")
-	var debugPods []string
-	for _, podName := range podNames {
-		if strings.Contains(podName, "node-debugger") {
-			debugPods = append(debugPods, strings.TrimPrefix(podName, "pod/"))
-		}
-	}
+// cleanupTestPods deletes the debug pods the executor started for this service's test commands
+// Pods of other runs or users are left alone.
+func (nhs *NetHealthCheckService) cleanupTestPods(ctx context.Context) {
+	nhs.options.Logger.Info("🧹 Cleaning up test pods...")
 
-	// Delete each debug pod
-	deletedCount := 0
-	for _, podName := range debugPods {
-		success, _, err := nhs.kubectl.DeletePod(ctx, podName)
-		if err != nil {
-			nhs.options.Logger.Warn(fmt.Sprintf("Failed to delete pod %s: %v", podName, err))
-		} else if success {
-			deletedCount++
-		}
+	deletedCount, err := nhs.kubectl.ReleaseDebugPods(ctx)
+	if err != nil {
+		nhs.options.Logger.Warn(fmt.Sprintf("Failed to delete test pods: %v", err))
 	}
 
 	if deletedCount > 0 {
 		nhs.options.Logger.Info(fmt.Sprintf("✅ Cleaned up %d test pods", deletedCount))
-	} else {
+	} else if err == nil {
 		nhs.options.Logger.Info("✅ No test pods to clean up")
 	}
 }
@@ -7,6 +7,7 @@ import (
 
 	"k8ostack-ictl/internal/config"
 	"k8ostack-ictl/internal/kubectl"
+	"k8ostack-ictl/internal/logging"
 )
 
 // TestResults tracks the results of network connectivity testing operations
@@ -15,6 +16,7 @@ type TestResults struct {
 	SuccessfulTests   int
 	FailedTests       int
 	SkippedTests      int
+	FlakyTests        int // Tests that passed only after a retry, counted in SuccessfulTests too
 	TestExecutions    []TestExecution
 	NetworkValidation map[string]NetworkHealth
 	Errors            []error
@@ -24,7 +26,7 @@ type TestResults struct {
 // TestExecution represents information about a single test execution
 type TestExecution struct {
 	TestName       string        // e.g., "keystone-api-connectivity"
-	TestType       string        // e.g., "openstack-api", "ping", "tcp"
+	TestType       string        // e.g., "openstack-api", "ping", "bandwidth"
 	SourceNode     string        // e.g., "rsb7"
 	TargetNode     string        // e.g., "rsb2"
 	SourceNetwork  string        // e.g., "management"
@@ -37,6 +39,10 @@ type TestExecution struct {
 	Duration       time.Duration // How long the test took
 	Output         string        // Command output or response
 	ErrorMessage   string        // Error details if failed
+	MinMbps        float64       // Throughput a bandwidth test expects to every target
+	ThroughputMbps float64       // Lowest throughput a bandwidth test measured, in Mbit/s
+	Attempts       int           // Attempts made, 1 unless the test was retried
+	Flaky          bool          // Passed only after a retry; a failed test failed every attempt
 }
 
 // NetworkHealth represents the health status of a network segment
@@ -63,6 +69,9 @@ type Service interface {
 
 	// GetCurrentState discovers the current network health state
 	GetCurrentState(ctx context.Context, networks []string) (map[string]NetworkHealth, error)
+
+	// Cleanup removes the test pods left behind by an interrupted run
+	Cleanup(ctx context.Context)
 }
 
 // Options contains configuration options for the network health check service
@@ -76,8 +85,7 @@ type Options struct {
 	CleanupAfterTests    bool
 	OpenstackProfiles    []string      // e.g., ["control-plane", "compute", "storage"]
 	ExcludeNodes         []string      // List of nodes to exclude from testing
-	Logger               kubectl.Logger
-	TestDelay            time.Duration // For testing - can be set to 0 to skip sleep
+	Logger               logging.Logger
 }
 
 // NetHealthCheckService implements the Service interface
@@ -0,0 +1,249 @@
+package operator
+
+import (
+	"sort"
+	"time"
+)
+
+// NodeFailures is the error of a reconcile that failed on some nodes and reconciled the others
+// The controller counts it against the error budget of each failed node instead of the resource's.
+type NodeFailures struct {
+	Nodes []string
+	Err   error
+}
+
+// Error returns the message of the underlying error
+func (e *NodeFailures) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *NodeFailures) Unwrap() error {
+	return e.Err
+}
+
+// nodeFailures attributes err to the failed nodes, or returns it unchanged when no node is known to have failed
+func nodeFailures(nodes []string, err error) error {
+	if len(nodes) == 0 {
+		return err
+	}
+	return &NodeFailures{Nodes: nodes, Err: err}
+}
+
+// backoffError is returned by reconcile when a resource used up its error budget and waits until retryAfter
+type backoffError struct {
+	err        error
+	retryAfter time.Time
+}
+
+func (e *backoffError) Error() string {
+	return e.err.Error()
+}
+
+func (e *backoffError) Unwrap() error {
+	return e.err
+}
+
+// failureRecord counts the consecutive failures of a resource, or of a node within a resource
+type failureRecord struct {
+	failures   int
+	lastError  string
+	retryAfter time.Time // Zero until the budget is used up
+}
+
+// errorBudget tracks consecutive reconcile failures per resource and per node of each resource
+// A resource or node failing limit times in a row is backed off: left alone for the base delay, doubled with every
+// further failure up to the maximum, instead of being retried forever. Editing the spec of a resource resets its budget.
+type errorBudget struct {
+	limit       int // Zero never backs off
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+	generations map[request]int64
+	resources   map[request]*failureRecord
+	nodes       map[request]map[string]*failureRecord
+}
+
+// newErrorBudget creates a budget of limit consecutive failures, backing off from baseDelay up to maxDelay
+func newErrorBudget(limit int, baseDelay, maxDelay time.Duration) *errorBudget {
+	if baseDelay <= 0 {
+		baseDelay = time.Minute
+	}
+	if maxDelay < baseDelay {
+		maxDelay = baseDelay
+	}
+	return &errorBudget{
+		limit:       limit,
+		baseDelay:   baseDelay,
+		maxDelay:    maxDelay,
+		generations: make(map[request]int64),
+		resources:   make(map[request]*failureRecord),
+		nodes:       make(map[request]map[string]*failureRecord),
+	}
+}
+
+// observe resets the budget of a resource whose spec changed since its last reconcile, reporting whether it did
+func (b *errorBudget) observe(req request, generation int64) bool {
+	last, seen := b.generations[req]
+	b.generations[req] = generation
+	if !seen || last == generation {
+		return false
+	}
+	_, failing := b.resources[req]
+	reset := failing || len(b.nodes[req]) > 0
+	delete(b.resources, req)
+	delete(b.nodes, req)
+	return reset
+}
+
+// backedOff returns until when a resource is backed off, if it is at now
+func (b *errorBudget) backedOff(req request, now time.Time) (time.Time, bool) {
+	record, ok := b.resources[req]
+	if !ok || !record.retryAfter.After(now) {
+		return time.Time{}, false
+	}
+	return record.retryAfter, true
+}
+
+// excludedNodes returns the sorted nodes of a resource that are backed off at now
+func (b *errorBudget) excludedNodes(req request, now time.Time) []string {
+	var nodes []string
+	for node, record := range b.nodes[req] {
+		if record.retryAfter.After(now) {
+			nodes = append(nodes, node)
+		}
+	}
+	sort.Strings(nodes)
+	return nodes
+}
+
+// resourceFailed counts a failure of the whole resource and returns until when it is backed off, if it now is
+func (b *errorBudget) resourceFailed(req request, err error, now time.Time) (time.Time, bool) {
+	record, ok := b.resources[req]
+	if !ok {
+		record = &failureRecord{}
+		b.resources[req] = record
+	}
+	return b.fail(record, err, now)
+}
+
+// resourceSucceeded clears the consecutive failures of a resource
+func (b *errorBudget) resourceSucceeded(req request) {
+	delete(b.resources, req)
+}
+
+// recordNodes counts a failure for every failed node and clears the nodes that took part in the reconcile and passed
+// It returns the nodes backed off by this failure and the nodes that passed after failing before, both sorted.
+func (b *errorBudget) recordNodes(req request, failed, excluded []string, err error, now time.Time) (backedOff, recovered []string) {
+	skipped := make(map[string]bool, len(excluded))
+	for _, node := range excluded {
+		skipped[node] = true
+	}
+	failing := make(map[string]bool, len(failed))
+	for _, node := range failed {
+		failing[node] = true
+	}
+
+	for node := range b.nodes[req] {
+		if !failing[node] && !skipped[node] {
+			delete(b.nodes[req], node)
+			recovered = append(recovered, node)
+		}
+	}
+	for node := range failing {
+		if b.nodes[req] == nil {
+			b.nodes[req] = make(map[string]*failureRecord)
+		}
+		record, ok := b.nodes[req][node]
+		if !ok {
+			record = &failureRecord{}
+			b.nodes[req][node] = record
+		}
+		if _, ok := b.fail(record, err, now); ok {
+			backedOff = append(backedOff, node)
+		}
+	}
+	sort.Strings(backedOff)
+	sort.Strings(recovered)
+	return backedOff, recovered
+}
+
+// failures returns the consecutive failures of a resource, or of one of its nodes
+func (b *errorBudget) failures(req request, node string) int {
+	var record *failureRecord
+	if node == "" {
+		record = b.resources[req]
+	} else {
+		record = b.nodes[req][node]
+	}
+	if record == nil {
+		return 0
+	}
+	return record.failures
+}
+
+// fail counts a failure on a record and backs it off once the budget is used up
+func (b *errorBudget) fail(record *failureRecord, err error, now time.Time) (time.Time, bool) {
+	record.failures++
+	record.lastError = err.Error()
+	if b.limit <= 0 || record.failures < b.limit {
+		return time.Time{}, false
+	}
+	record.retryAfter = now.Add(b.delay(record.failures))
+	return record.retryAfter, true
+}
+
+// delay returns the backoff after a number of consecutive failures: the base delay once the budget is used up,
+// doubled with every further failure up to the maximum
+func (b *errorBudget) delay(failures int) time.Duration {
+	delay := b.baseDelay
+	for i := b.limit; i < failures && delay < b.maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > b.maxDelay {
+		delay = b.maxDelay
+	}
+	return delay
+}
+
+// status returns the budget fields of a resource's status: its consecutive failures and those of its nodes,
+// with the time each backed off one is retried. Fields without failures are left out.
+func (b *errorBudget) status(req request) map[string]interface{} {
+	status := make(map[string]interface{})
+	if record, ok := b.resources[req]; ok {
+		status["consecutiveFailures"] = int64(record.failures)
+		if !record.retryAfter.IsZero() {
+			status["retryAfter"] = record.retryAfter.UTC().Format(time.RFC3339)
+		}
+	}
+
+	var names []string
+	for node := range b.nodes[req] {
+		names = append(names, node)
+	}
+	sort.Strings(names)
+	var nodes []interface{}
+	for _, node := range names {
+		record := b.nodes[req][node]
+		entry := map[string]interface{}{
+			"name":                node,
+			"consecutiveFailures": int64(record.failures),
+			"lastError":           record.lastError,
+		}
+		if !record.retryAfter.IsZero() {
+			entry["retryAfter"] = record.retryAfter.UTC().Format(time.RFC3339)
+		}
+		nodes = append(nodes, entry)
+	}
+	if len(nodes) > 0 {
+		status["failingNodes"] = nodes
+	}
+	return status
+}
+
+// excludedUntil returns when a backed off node of a resource is retried
+func (b *errorBudget) excludedUntil(req request, node string) time.Time {
+	if record := b.nodes[req][node]; record != nil {
+		return record.retryAfter
+	}
+	return time.Time{}
+}
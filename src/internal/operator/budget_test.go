@@ -0,0 +1,156 @@
+// Package operator provides unit tests for the error budgets of resources and nodes
+// WHY: A node that never reconciles must stop being hammered, without holding back the nodes that do
+package operator
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"k8ostack-ictl/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// TestErrorBudget_Delay tests the backoff after each failure beyond the budget
+// WHY: Persistent failures must be retried less and less often, but still within the maximum backoff
+func TestErrorBudget_Delay(t *testing.T) {
+	tests := []struct {
+		failures int
+		expected time.Duration
+	}{
+		{failures: 3, expected: 5 * time.Minute},
+		{failures: 4, expected: 10 * time.Minute},
+		{failures: 5, expected: 20 * time.Minute},
+		{failures: 7, expected: time.Hour},
+		{failures: 70, expected: time.Hour},
+	}
+
+	budget := newErrorBudget(3, 5*time.Minute, time.Hour)
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, budget.delay(tt.failures), "after %d failures", tt.failures)
+	}
+}
+
+// TestErrorBudget_Nodes tests backing off and recovering single nodes of a resource
+// WHY: Only the failing node may be left out, and only until it passes again or the spec changes
+func TestErrorBudget_Nodes(t *testing.T) {
+	// Given: A budget of two failures
+	budget := newErrorBudget(2, time.Minute, time.Hour)
+	req := request{Kind: "NodeVLANConf", Namespace: "infra", Name: "storage"}
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	budget.observe(req, 1)
+	failure := errors.New("ip link failed")
+
+	// When/Then: rsb3 fails twice in a row and is left out for a minute
+	backedOff, _ := budget.recordNodes(req, []string{"rsb3"}, nil, failure, now)
+	assert.Empty(t, backedOff)
+	backedOff, _ = budget.recordNodes(req, []string{"rsb3"}, nil, failure, now)
+	assert.Equal(t, []string{"rsb3"}, backedOff)
+	assert.Equal(t, []string{"rsb3"}, budget.excludedNodes(req, now))
+	assert.Empty(t, budget.excludedNodes(req, now.Add(time.Minute)))
+
+	// When/Then: A reconcile without rsb3 keeps it backed off
+	_, recovered := budget.recordNodes(req, nil, []string{"rsb3"}, nil, now)
+	assert.Empty(t, recovered)
+	assert.Equal(t, 2, budget.failures(req, "rsb3"))
+
+	// When/Then: Failing again after the backoff doubles it
+	backedOff, _ = budget.recordNodes(req, []string{"rsb3"}, nil, failure, now.Add(time.Minute))
+	assert.Equal(t, []string{"rsb3"}, backedOff)
+	assert.Equal(t, now.Add(3*time.Minute), budget.excludedUntil(req, "rsb3"))
+
+	// When/Then: Passing clears it
+	_, recovered = budget.recordNodes(req, nil, nil, nil, now.Add(3*time.Minute))
+	assert.Equal(t, []string{"rsb3"}, recovered)
+	assert.Equal(t, 0, budget.failures(req, "rsb3"))
+
+	// When/Then: A spec change resets a backed off node
+	budget.recordNodes(req, []string{"rsb3"}, nil, failure, now)
+	budget.recordNodes(req, []string{"rsb3"}, nil, failure, now)
+	assert.True(t, budget.observe(req, 2))
+	assert.Empty(t, budget.excludedNodes(req, now))
+}
+
+// TestController_ReconcileBackoff tests backing off a resource that keeps failing as a whole
+// WHY: A resource retried forever floods the nodes and the logs; its status must say when it is retried
+func TestController_ReconcileBackoff(t *testing.T) {
+	// Given: A controller with a budget of two failures and a resource that always fails
+	resource := newResource("NodeLabelConf", "control", labelSpec)
+	reconciler := &MockReconciler{}
+	reconciler.On("Apply", mock.Anything, mock.Anything).Return("", errors.New("API server unreachable"))
+	controller, client := newTestController(reconciler, resource)
+	controller.budget = newErrorBudget(2, time.Minute, time.Hour)
+	req := request{Kind: "NodeLabelConf", Namespace: "infra", Name: "control"}
+
+	// When: Reconciling it three times
+	first := controller.reconcile(context.Background(), req)
+	second := controller.reconcile(context.Background(), req)
+	third := controller.reconcile(context.Background(), req)
+
+	// Then: The first failure is retried, the second backs it off and the third is skipped
+	var backoff *backoffError
+	assert.Error(t, first)
+	assert.False(t, errors.As(first, &backoff))
+	require.ErrorAs(t, second, &backoff)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), backoff.retryAfter, 5*time.Second)
+	assert.NoError(t, third)
+	reconciler.AssertNumberOfCalls(t, "Apply", 2)
+
+	obj := getResource(t, client, "NodeLabelConf", "control")
+	phase, _, _ := unstructured.NestedString(obj.Object, "status", "phase")
+	failures, _, _ := unstructured.NestedInt64(obj.Object, "status", "consecutiveFailures")
+	retryAfter, _, _ := unstructured.NestedString(obj.Object, "status", "retryAfter")
+	assert.Equal(t, PhaseBackedOff, phase)
+	assert.Equal(t, int64(2), failures)
+	assert.Equal(t, backoff.retryAfter.UTC().Format(time.RFC3339), retryAfter)
+}
+
+// TestController_ReconcileNodeBackoff tests leaving a persistently failing node out of a resource
+// WHY: One broken node must not keep the rest of the resource from being reconciled
+func TestController_ReconcileNodeBackoff(t *testing.T) {
+	// Given: A controller with a budget of one failure and a resource on two nodes, of which rsb3 fails
+	spec := map[string]interface{}{"nodeRoles": map[string]interface{}{"control": map[string]interface{}{
+		"nodes":  []interface{}{"rsb2", "rsb3"},
+		"labels": map[string]interface{}{"openstack-control-plane": "enabled"},
+	}}}
+	resource := newResource("NodeLabelConf", "control", spec)
+	withNodes := func(nodes ...string) interface{} {
+		return mock.MatchedBy(func(bundle *config.ConfigBundle) bool {
+			return assert.ObjectsAreEqual(nodes, bundle.NodeLabels.Spec.NodeRoles["control"].Nodes)
+		})
+	}
+	reconciler := &MockReconciler{}
+	reconciler.On("Apply", mock.Anything, withNodes("rsb2", "rsb3")).
+		Return("", &NodeFailures{Nodes: []string{"rsb3"}, Err: errors.New("labeling finished with 1 errors")}).Once()
+	reconciler.On("Apply", mock.Anything, withNodes("rsb2")).Return("labels applied to 1 nodes", nil).Once()
+	controller, client := newTestController(reconciler, resource)
+	controller.budget = newErrorBudget(1, time.Minute, time.Hour)
+	req := request{Kind: "NodeLabelConf", Namespace: "infra", Name: "control"}
+
+	// When: Reconciling it twice
+	first := controller.reconcile(context.Background(), req)
+	second := controller.reconcile(context.Background(), req)
+
+	// Then: rsb3 is left out of the second reconcile, which succeeds and reports the node
+	assert.Error(t, first)
+	assert.NoError(t, second)
+	reconciler.AssertExpectations(t)
+
+	obj := getResource(t, client, "NodeLabelConf", "control")
+	phase, _, _ := unstructured.NestedString(obj.Object, "status", "phase")
+	message, _, _ := unstructured.NestedString(obj.Object, "status", "message")
+	nodes, _, _ := unstructured.NestedSlice(obj.Object, "status", "failingNodes")
+	assert.Equal(t, PhaseReady, phase)
+	assert.Equal(t, "labels applied to 1 nodes, 1 nodes backed off", message)
+	require.Len(t, nodes, 1)
+	node := nodes[0].(map[string]interface{})
+	assert.Equal(t, "rsb3", node["name"])
+	assert.Equal(t, int64(1), node["consecutiveFailures"])
+	assert.Equal(t, "labeling finished with 1 errors", node["lastError"])
+	assert.NotEmpty(t, node["retryAfter"])
+}
@@ -0,0 +1,403 @@
+package operator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/kubectl"
+	"k8ostack-ictl/internal/logging"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+)
+
+// Phases reported in the status of reconciled resources
+const (
+	PhaseReady   = "Ready"
+	PhaseFailed  = "Failed"
+	PhaseInvalid = "Invalid"
+	// PhaseBackedOff is reported while a resource that used up its error budget waits for its next attempt
+	PhaseBackedOff = "BackedOff"
+)
+
+// Options configures the controller
+type Options struct {
+	// Namespace limits the watch to one namespace; empty watches all namespaces
+	Namespace string
+
+	// ResyncPeriod is how often every resource is reapplied to correct drift
+	ResyncPeriod time.Duration
+
+	// Prepare adjusts each loaded bundle before it is reconciled, e.g. to apply CLI precedence
+	Prepare func(bundle *config.ConfigBundle) error
+
+	// FailureBudget is how many times in a row a resource or one of its nodes may fail before it is backed off
+	// for ResyncPeriod, doubled with every further failure up to MaxBackoff; zero retries forever
+	FailureBudget int
+	MaxBackoff    time.Duration
+
+	Logger logging.Logger
+}
+
+// Controller watches the kictl custom resources and reconciles them one at a time
+// Resources are processed sequentially so two resources never run node commands concurrently.
+type Controller struct {
+	client     dynamic.Interface
+	reconciler Reconciler
+	options    Options
+	queue      workqueue.RateLimitingInterface
+	budget     *errorBudget
+}
+
+// request identifies a resource waiting to be reconciled
+type request struct {
+	Kind      string
+	Namespace string
+	Name      string
+}
+
+// NewDynamicClient creates a dynamic client for the target, or from the service account when running in a pod
+func NewDynamicClient(target kubectl.ClusterTarget) (dynamic.Interface, error) {
+	restConfig, err := target.ClientConfig().ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kubeconfig: %w", err)
+	}
+	client, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	return client, nil
+}
+
+// NewController creates a controller reconciling resources with the given reconciler
+func NewController(client dynamic.Interface, reconciler Reconciler, options Options) *Controller {
+	return &Controller{
+		client:     client,
+		reconciler: reconciler,
+		options:    options,
+		queue:      workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+		budget:     newErrorBudget(options.FailureBudget, options.ResyncPeriod, options.MaxBackoff),
+	}
+}
+
+// Run watches the custom resources and reconciles them until the context is cancelled
+func (c *Controller) Run(ctx context.Context) error {
+	defer c.queue.ShutDown()
+
+	factory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(c.client, c.options.ResyncPeriod, c.options.Namespace, nil)
+	for _, kind := range Kinds {
+		kind := kind
+		_, err := factory.ForResource(kind.Resource()).Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) { c.enqueue(kind, obj) },
+			UpdateFunc: func(oldObj, newObj interface{}) {
+				if needsReconcile(oldObj, newObj) {
+					c.enqueue(kind, newObj)
+				}
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to watch %s: %w", kind.Plural, err)
+		}
+	}
+
+	factory.Start(ctx.Done())
+	for resource, synced := range factory.WaitForCacheSync(ctx.Done()) {
+		if !synced {
+			return fmt.Errorf("failed to list %s, are the CRDs registered?", resource.Resource)
+		}
+	}
+	c.options.Logger.Info(fmt.Sprintf("👀 Watching %d resource kinds, resync every %s", len(Kinds), c.options.ResyncPeriod))
+
+	go func() {
+		<-ctx.Done()
+		c.queue.ShutDown()
+	}()
+	for c.processNextItem(ctx) {
+	}
+	return nil
+}
+
+// enqueue queues a watched object for reconciliation
+func (c *Controller) enqueue(kind Kind, obj interface{}) {
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		c.queue.Add(request{Kind: kind.Name, Namespace: u.GetNamespace(), Name: u.GetName()})
+	}
+}
+
+// needsReconcile skips updates that only touched status or metadata, which the controller makes itself
+// Periodic resyncs deliver the unchanged object and are always reconciled.
+func needsReconcile(oldObj, newObj interface{}) bool {
+	oldU, okOld := oldObj.(*unstructured.Unstructured)
+	newU, okNew := newObj.(*unstructured.Unstructured)
+	if !okOld || !okNew {
+		return false
+	}
+	return oldU.GetResourceVersion() == newU.GetResourceVersion() ||
+		oldU.GetGeneration() != newU.GetGeneration() ||
+		newU.GetDeletionTimestamp() != nil
+}
+
+// processNextItem reconciles the next queued resource, retrying it with backoff on failure
+// A resource that used up its error budget is not retried before its backoff ends.
+func (c *Controller) processNextItem(ctx context.Context) bool {
+	item, shutdown := c.queue.Get()
+	if shutdown {
+		return false
+	}
+	defer c.queue.Done(item)
+
+	req := item.(request)
+	err := c.reconcile(ctx, req)
+	var backoff *backoffError
+	if errors.As(err, &backoff) {
+		c.queue.Forget(item)
+		c.queue.AddAfter(item, time.Until(backoff.retryAfter))
+		return true
+	}
+	if err != nil {
+		c.options.Logger.Error(fmt.Sprintf("❌ %s %s/%s: %v (retrying)", req.Kind, req.Namespace, req.Name, err))
+		c.queue.AddRateLimited(item)
+		return true
+	}
+	c.queue.Forget(item)
+	return true
+}
+
+// reconcile applies a resource to the nodes, or removes it when the resource is being deleted
+func (c *Controller) reconcile(ctx context.Context, req request) error {
+	kind, ok := kindByName(req.Kind)
+	if !ok {
+		return fmt.Errorf("unknown kind %s", req.Kind)
+	}
+	resource := c.client.Resource(kind.Resource()).Namespace(req.Namespace)
+
+	obj, err := resource.Get(ctx, req.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read resource: %w", err)
+	}
+
+	bundle, loadErr := c.loadBundle(ctx, kind, obj)
+
+	if obj.GetDeletionTimestamp() != nil {
+		if !hasFinalizer(obj) {
+			return nil
+		}
+		if loadErr != nil {
+			c.options.Logger.Warn(fmt.Sprintf("⚠️  %s %s/%s is invalid, releasing it without removing its configuration: %v", req.Kind, req.Namespace, req.Name, loadErr))
+		} else {
+			c.options.Logger.Info(fmt.Sprintf("🗑️  Removing %s %s/%s from nodes", req.Kind, req.Namespace, req.Name))
+			if err := c.reconciler.Remove(ctx, bundle); err != nil {
+				return c.updateStatus(ctx, resource, obj, PhaseFailed, fmt.Sprintf("removal failed: %v", err), err)
+			}
+		}
+		obj.SetFinalizers(removeString(obj.GetFinalizers(), Finalizer))
+		if _, err := resource.Update(ctx, obj, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("failed to remove finalizer: %w", err)
+		}
+		return nil
+	}
+
+	if kind.Finalize && !hasFinalizer(obj) {
+		obj.SetFinalizers(append(obj.GetFinalizers(), Finalizer))
+		if obj, err = resource.Update(ctx, obj, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("failed to add finalizer: %w", err)
+		}
+	}
+
+	if c.budget.observe(req, obj.GetGeneration()) {
+		c.options.Logger.Info(fmt.Sprintf("📝 %s %s/%s changed, resetting its error budget", req.Kind, req.Namespace, req.Name))
+	}
+
+	// Invalid resources are not retried; editing the spec triggers the next attempt
+	if loadErr != nil {
+		return c.updateStatus(ctx, resource, obj, PhaseInvalid, loadErr.Error(), nil)
+	}
+
+	now := time.Now()
+	if retryAfter, backedOff := c.budget.backedOff(req, now); backedOff {
+		c.options.Logger.Debug(fmt.Sprintf("⏸️  %s %s/%s is backed off until %s", req.Kind, req.Namespace, req.Name, retryAfter.Format(time.RFC3339)))
+		return nil
+	}
+	excluded := c.budget.excludedNodes(req, now)
+	if len(excluded) > 0 {
+		kept, err := bundle.ExcludeNodes(excluded)
+		if err != nil {
+			return c.updateStatus(ctx, resource, obj, PhaseBackedOff, fmt.Sprintf("all nodes are backed off: %s", strings.Join(excluded, ", ")), nil)
+		}
+		c.options.Logger.Warn(fmt.Sprintf("⏸️  Reconciling %s %s/%s without backed off nodes %s", req.Kind, req.Namespace, req.Name, strings.Join(excluded, ", ")))
+		bundle = kept
+	}
+
+	c.options.Logger.Info(fmt.Sprintf("🔄 Reconciling %s %s/%s", req.Kind, req.Namespace, req.Name))
+	message, err := c.reconciler.Apply(ctx, bundle)
+	if err := c.recordBudget(req, excluded, err, now); err != nil {
+		if statusErr := c.updateStatus(ctx, resource, obj, PhaseBackedOff, err.Error(), nil); statusErr != nil {
+			return statusErr
+		}
+		return err
+	}
+	if err != nil {
+		return c.updateStatus(ctx, resource, obj, PhaseFailed, err.Error(), err)
+	}
+	if len(excluded) > 0 {
+		message = fmt.Sprintf("%s, %d nodes backed off", message, len(excluded))
+	}
+	c.options.Logger.Info(fmt.Sprintf("✅ %s %s/%s: %s", req.Kind, req.Namespace, req.Name, message))
+	return c.updateStatus(ctx, resource, obj, PhaseReady, message, nil)
+}
+
+// recordBudget counts the outcome of an apply against the error budgets of the resource and its nodes
+// Failures of single nodes count against those nodes only. It returns a backoffError once the resource is backed off.
+func (c *Controller) recordBudget(req request, excluded []string, applyErr error, now time.Time) error {
+	var failed *NodeFailures
+	var failedNodes []string
+	if errors.As(applyErr, &failed) {
+		failedNodes = failed.Nodes
+	}
+
+	backedOff, recovered := c.budget.recordNodes(req, failedNodes, excluded, applyErr, now)
+	for _, node := range recovered {
+		c.options.Logger.Info(fmt.Sprintf("💚 Node %s reconciles again for %s %s/%s", node, req.Kind, req.Namespace, req.Name))
+	}
+	for _, node := range backedOff {
+		c.options.Logger.Error(fmt.Sprintf("🚨 Node %s failed %s %s/%s %d times in a row, leaving it out until %s: %v",
+			node, req.Kind, req.Namespace, req.Name, c.budget.failures(req, node), c.budget.excludedUntil(req, node).Format(time.RFC3339), applyErr))
+	}
+
+	// A node that was to be left out but failed anyway, e.g. one a label role reaches by pattern, fails the resource
+	if applyErr == nil || (len(failedNodes) > 0 && !anyOf(failedNodes, excluded)) {
+		c.budget.resourceSucceeded(req)
+		return nil
+	}
+	retryAfter, backedOffResource := c.budget.resourceFailed(req, applyErr, now)
+	if !backedOffResource {
+		return nil
+	}
+	c.options.Logger.Error(fmt.Sprintf("🚨 %s %s/%s failed %d times in a row, backing off until %s: %v",
+		req.Kind, req.Namespace, req.Name, c.budget.failures(req, ""), retryAfter.Format(time.RFC3339), applyErr))
+	return &backoffError{err: applyErr, retryAfter: retryAfter}
+}
+
+// loadBundle validates a resource with the configuration file loader and returns it as a bundle
+// NodeTestConf bundles also carry the first NodeVLANConf of the namespace to resolve network names.
+func (c *Controller) loadBundle(ctx context.Context, kind Kind, obj *unstructured.Unstructured) (*config.ConfigBundle, error) {
+	bundle, err := loadResource(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	if bundle.HasTests() {
+		vlanKind, _ := kindByName("NodeVLANConf")
+		list, err := c.client.Resource(vlanKind.Resource()).Namespace(obj.GetNamespace()).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			c.options.Logger.Warn(fmt.Sprintf("⚠️  Failed to list NodeVLANConf in %s, network names will not be resolved: %v", obj.GetNamespace(), err))
+		} else if len(list.Items) > 0 {
+			sort.Slice(list.Items, func(i, j int) bool { return list.Items[i].GetName() < list.Items[j].GetName() })
+			if vlans, err := loadResource(&list.Items[0]); err == nil {
+				bundle.VLANs = vlans.VLANs
+			}
+		}
+	}
+
+	if c.options.Prepare != nil {
+		if err := c.options.Prepare(bundle); err != nil {
+			return nil, err
+		}
+	}
+	return bundle, nil
+}
+
+// loadResource converts a custom resource into a configuration bundle
+// The resource is read at the storage version, but keeps the layout of the version it was applied with, so
+// tools in spec mark it as v2 for the loader to move them back out.
+func loadResource(obj *unstructured.Unstructured) (*config.ConfigBundle, error) {
+	if _, inSpec, _ := unstructured.NestedFieldNoCopy(obj.Object, "spec", "tools"); inSpec {
+		obj = obj.DeepCopy()
+		obj.SetAPIVersion(config.APIVersionV2)
+	}
+	data, err := json.Marshal(obj.Object)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode resource: %w", err)
+	}
+	return config.LoadConfigData(data, obj.GetNamespace()+"/"+obj.GetName())
+}
+
+// updateStatus records the reconcile outcome on the resource and returns cause so callers can retry on it
+func (c *Controller) updateStatus(ctx context.Context, resource dynamic.ResourceInterface, obj *unstructured.Unstructured, phase, message string, cause error) error {
+	status := map[string]interface{}{
+		"phase":              phase,
+		"message":            message,
+		"observedGeneration": obj.GetGeneration(),
+		"lastReconcileTime":  time.Now().UTC().Format(time.RFC3339),
+	}
+	for key, value := range c.budget.status(request{Kind: obj.GetKind(), Namespace: obj.GetNamespace(), Name: obj.GetName()}) {
+		status[key] = value
+	}
+	if err := unstructured.SetNestedMap(obj.Object, status, "status"); err != nil {
+		return fmt.Errorf("failed to set status: %w", err)
+	}
+	if _, err := resource.UpdateStatus(ctx, obj, metav1.UpdateOptions{}); err != nil {
+		if cause != nil {
+			return fmt.Errorf("%w (status update failed: %v)", cause, err)
+		}
+		return fmt.Errorf("failed to update status: %w", err)
+	}
+	return cause
+}
+
+// kindByName returns the watched kind with the given name
+func kindByName(name string) (Kind, bool) {
+	for _, kind := range Kinds {
+		if kind.Name == name {
+			return kind, true
+		}
+	}
+	return Kind{}, false
+}
+
+// hasFinalizer reports whether the resource carries the kictl finalizer
+func hasFinalizer(obj *unstructured.Unstructured) bool {
+	for _, finalizer := range obj.GetFinalizers() {
+		if finalizer == Finalizer {
+			return true
+		}
+	}
+	return false
+}
+
+// anyOf reports whether any of values is in set
+func anyOf(values, set []string) bool {
+	for _, value := range values {
+		for _, member := range set {
+			if value == member {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// removeString returns values without s
+func removeString(values []string, s string) []string {
+	var result []string
+	for _, value := range values {
+		if value != s {
+			result = append(result, value)
+		}
+	}
+	return result
+}
@@ -0,0 +1,325 @@
+// Package operator provides unit tests for the custom resource controller
+// WHY: The controller changes nodes without a human in the loop, so every state transition must be right
+package operator
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/logging"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+// MockReconciler is a mock implementation of Reconciler
+type MockReconciler struct {
+	mock.Mock
+}
+
+func (m *MockReconciler) Apply(ctx context.Context, bundle *config.ConfigBundle) (string, error) {
+	args := m.Called(ctx, bundle)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockReconciler) Remove(ctx context.Context, bundle *config.ConfigBundle) error {
+	args := m.Called(ctx, bundle)
+	return args.Error(0)
+}
+
+// newResource creates a custom resource of the given kind in the infra namespace
+func newResource(kind, name string, spec map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": Group + "/" + Version,
+		"kind":       kind,
+		"metadata":   map[string]interface{}{"name": name, "namespace": "infra", "generation": int64(1)},
+		"spec":       spec,
+	}}
+}
+
+// labelSpec is a valid NodeLabelConf spec
+var labelSpec = map[string]interface{}{
+	"nodeRoles": map[string]interface{}{
+		"control": map[string]interface{}{
+			"nodes":  []interface{}{"rsb2"},
+			"labels": map[string]interface{}{"openstack-control-plane": "enabled"},
+		},
+	},
+}
+
+// newFakeClient creates a fake dynamic client serving the CRDs and the kictl resources
+func newFakeClient(objects ...runtime.Object) *dynamicfake.FakeDynamicClient {
+	listKinds := map[schema.GroupVersionResource]string{crdResource: "CustomResourceDefinitionList"}
+	for _, kind := range Kinds {
+		listKinds[kind.Resource()] = kind.Name + "List"
+	}
+	return dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds, objects...)
+}
+
+// newTestController creates a controller over a fake dynamic client holding objects
+func newTestController(reconciler Reconciler, objects ...runtime.Object) (*Controller, *dynamicfake.FakeDynamicClient) {
+	client := newFakeClient(objects...)
+	logger := logging.NewMockLogger()
+	for _, level := range []string{"Debug", "Info", "Warn", "Error"} {
+		logger.On(level, mock.Anything).Maybe()
+	}
+	return NewController(client, reconciler, Options{Logger: logger}), client
+}
+
+// getResource reads a resource back from the fake client
+func getResource(t *testing.T, client *dynamicfake.FakeDynamicClient, kind, name string) *unstructured.Unstructured {
+	k, ok := kindByName(kind)
+	require.True(t, ok)
+	obj, err := client.Resource(k.Resource()).Namespace("infra").Get(context.Background(), name, metav1.GetOptions{})
+	require.NoError(t, err)
+	return obj
+}
+
+// TestController_Reconcile tests reconciling resources that are not being deleted
+// WHY: The status phase is the only feedback users get after kubectl apply
+func TestController_Reconcile(t *testing.T) {
+	tests := []struct {
+		name            string
+		resource        *unstructured.Unstructured
+		applyMessage    string
+		applyErr        error
+		expectApply     bool
+		expectError     bool
+		expectPhase     string
+		expectMessage   string
+		expectFinalizer bool
+	}{
+		{
+			name:            "valid labels are applied",
+			resource:        newResource("NodeLabelConf", "control", labelSpec),
+			applyMessage:    "labels applied to 1 nodes",
+			expectApply:     true,
+			expectPhase:     PhaseReady,
+			expectMessage:   "labels applied to 1 nodes",
+			expectFinalizer: true,
+		},
+		{
+			name:            "failed apply is reported and retried",
+			resource:        newResource("NodeLabelConf", "control", labelSpec),
+			applyErr:        errors.New("node rsb2 not found"),
+			expectApply:     true,
+			expectError:     true,
+			expectPhase:     PhaseFailed,
+			expectMessage:   "node rsb2 not found",
+			expectFinalizer: true,
+		},
+		{
+			name:            "invalid spec is reported without retry",
+			resource:        newResource("NodeLabelConf", "empty", map[string]interface{}{}),
+			expectPhase:     PhaseInvalid,
+			expectMessage:   "at least one node role",
+			expectFinalizer: true,
+		},
+		{
+			name:          "tests get no finalizer",
+			resource:      newResource("NodeTestConf", "ping", map[string]interface{}{"tests": []interface{}{map[string]interface{}{"name": "ping", "source": "rsb2", "targets": []interface{}{"rsb3"}}}}),
+			applyMessage:  "1 of 1 tests passed",
+			expectApply:   true,
+			expectPhase:   PhaseReady,
+			expectMessage: "1 of 1 tests passed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: Controller over a cluster holding the resource
+			reconciler := &MockReconciler{}
+			if tt.expectApply {
+				reconciler.On("Apply", mock.Anything, mock.Anything).Return(tt.applyMessage, tt.applyErr)
+			}
+			controller, client := newTestController(reconciler, tt.resource)
+
+			// When: Reconciling it
+			err := controller.reconcile(context.Background(), request{Kind: tt.resource.GetKind(), Namespace: "infra", Name: tt.resource.GetName()})
+
+			// Then: Outcome is returned and recorded in the status
+			if tt.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			reconciler.AssertExpectations(t)
+			if !tt.expectApply {
+				reconciler.AssertNotCalled(t, "Apply", mock.Anything, mock.Anything)
+			}
+
+			obj := getResource(t, client, tt.resource.GetKind(), tt.resource.GetName())
+			phase, _, _ := unstructured.NestedString(obj.Object, "status", "phase")
+			message, _, _ := unstructured.NestedString(obj.Object, "status", "message")
+			generation, _, _ := unstructured.NestedInt64(obj.Object, "status", "observedGeneration")
+			assert.Equal(t, tt.expectPhase, phase)
+			assert.Contains(t, message, tt.expectMessage)
+			assert.Equal(t, int64(1), generation)
+			assert.Equal(t, tt.expectFinalizer, hasFinalizer(obj))
+		})
+	}
+}
+
+// TestController_ReconcileDeletion tests deleting a resource that carries the finalizer
+// WHY: Labels must come off the nodes before the resource disappears, and a failed removal must block deletion
+func TestController_ReconcileDeletion(t *testing.T) {
+	tests := []struct {
+		name            string
+		removeErr       error
+		expectError     bool
+		expectFinalizer bool
+	}{
+		{
+			name: "successful removal releases the resource",
+		},
+		{
+			name:            "failed removal keeps the finalizer",
+			removeErr:       errors.New("permission denied"),
+			expectError:     true,
+			expectFinalizer: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: Resource marked for deletion
+			resource := newResource("NodeLabelConf", "control", labelSpec)
+			resource.SetFinalizers([]string{Finalizer})
+			now := metav1.Now()
+			resource.SetDeletionTimestamp(&now)
+
+			reconciler := &MockReconciler{}
+			reconciler.On("Remove", mock.Anything, mock.MatchedBy(func(bundle *config.ConfigBundle) bool {
+				return bundle.HasNodeLabels() && bundle.NodeLabels.Metadata.Name == "control"
+			})).Return(tt.removeErr)
+			controller, client := newTestController(reconciler, resource)
+
+			// When: Reconciling it
+			err := controller.reconcile(context.Background(), request{Kind: "NodeLabelConf", Namespace: "infra", Name: "control"})
+
+			// Then: The configuration is removed before the finalizer is released
+			if tt.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			reconciler.AssertExpectations(t)
+			reconciler.AssertNotCalled(t, "Apply", mock.Anything, mock.Anything)
+			assert.Equal(t, tt.expectFinalizer, hasFinalizer(getResource(t, client, "NodeLabelConf", "control")))
+		})
+	}
+}
+
+// TestController_TestsUseNamespaceVLANs tests that connectivity tests see the VLANs of their namespace
+// WHY: Tests address networks by name, which only a NodeVLANConf can resolve
+func TestController_TestsUseNamespaceVLANs(t *testing.T) {
+	// Given: NodeTestConf next to a NodeVLANConf
+	tests := newResource("NodeTestConf", "ping", map[string]interface{}{"tests": []interface{}{map[string]interface{}{"name": "storage", "source": "storage", "targets": []interface{}{"storage"}}}})
+	vlans := newResource("NodeVLANConf", "storage", map[string]interface{}{"vlans": map[string]interface{}{"storage": map[string]interface{}{
+		"id": int64(100), "subnet": "10.100.0.0/24", "nodeMapping": map[string]interface{}{"rsb2": "10.100.0.12/24"},
+	}}})
+
+	reconciler := &MockReconciler{}
+	reconciler.On("Apply", mock.Anything, mock.MatchedBy(func(bundle *config.ConfigBundle) bool {
+		return bundle.HasTests() && bundle.HasVLANs() && bundle.VLANs.Metadata.Name == "storage"
+	})).Return("1 of 1 tests passed", nil)
+	controller, _ := newTestController(reconciler, tests, vlans)
+
+	// When: Reconciling the tests
+	err := controller.reconcile(context.Background(), request{Kind: "NodeTestConf", Namespace: "infra", Name: "ping"})
+
+	// Then: The VLAN configuration travels with the tests
+	require.NoError(t, err)
+	reconciler.AssertExpectations(t)
+}
+
+// TestController_ReconcileMissing tests reconciling a resource that no longer exists
+// WHY: Delete events race with the queue and must not produce retries
+func TestController_ReconcileMissing(t *testing.T) {
+	controller, _ := newTestController(&MockReconciler{})
+
+	err := controller.reconcile(context.Background(), request{Kind: "NodeLabelConf", Namespace: "infra", Name: "gone"})
+
+	assert.NoError(t, err)
+}
+
+// TestNeedsReconcile tests which watch updates trigger a reconcile
+// WHY: The controller's own status and finalizer writes must not cause endless reconcile loops
+func TestNeedsReconcile(t *testing.T) {
+	base := newResource("NodeLabelConf", "control", labelSpec)
+	base.SetResourceVersion("1")
+
+	statusOnly := base.DeepCopy()
+	statusOnly.SetResourceVersion("2")
+
+	specChange := statusOnly.DeepCopy()
+	specChange.SetGeneration(2)
+
+	deleting := statusOnly.DeepCopy()
+	now := metav1.Now()
+	deleting.SetDeletionTimestamp(&now)
+
+	tests := []struct {
+		name     string
+		newObj   *unstructured.Unstructured
+		expected bool
+	}{
+		{name: "periodic resync", newObj: base.DeepCopy(), expected: true},
+		{name: "status or finalizer update", newObj: statusOnly, expected: false},
+		{name: "spec change", newObj: specChange, expected: true},
+		{name: "deletion requested", newObj: deleting, expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, needsReconcile(base, tt.newObj))
+		})
+	}
+}
+
+// TestLoadResource tests loading resources applied at either served version
+// WHY: Without a conversion webhook a v2 resource read at v1 still has its tools in spec
+func TestLoadResource(t *testing.T) {
+	tests := []struct {
+		name  string
+		tools func(obj *unstructured.Unstructured)
+	}{
+		{
+			name: "v1_layout",
+			tools: func(obj *unstructured.Unstructured) {
+				obj.Object["tools"] = map[string]interface{}{"nlabel": map[string]interface{}{"logLevel": "debug"}}
+			},
+		},
+		{
+			name: "v2_layout_read_at_v1",
+			tools: func(obj *unstructured.Unstructured) {
+				obj.Object["spec"].(map[string]interface{})["tools"] = map[string]interface{}{"nlabel": map[string]interface{}{"logLevel": "debug"}}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A NodeLabelConf as the API server returns it at the storage version
+			obj := newResource("NodeLabelConf", "control", runtime.DeepCopyJSON(labelSpec))
+			tt.tools(obj)
+
+			// When: Load it
+			bundle, err := loadResource(obj)
+
+			// Then: The tools are read from wherever the layout keeps them
+			require.NoError(t, err)
+			require.NotNil(t, bundle.NodeLabels)
+			assert.Equal(t, "debug", bundle.NodeLabels.Tools.Nlabel.LogLevel)
+			assert.Equal(t, Group+"/"+Version, obj.GetAPIVersion(), "the resource itself is left as it is")
+		})
+	}
+}
@@ -0,0 +1,127 @@
+// Package operator runs kictl as an in-cluster controller reconciling its configuration custom resources
+package operator
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// API group and storage version of the kictl custom resources, matching the apiVersion of configuration files
+const (
+	Group   = "openstack.kictl.icycloud.io"
+	Version = "v1"
+)
+
+// ServedVersions are the versions the CRDs serve, so bundles written by generate, import or convert apply unchanged
+// Without a conversion webhook the API server keeps documents as written and only rewrites their apiVersion;
+// the operator tells the layouts apart by where the tools are when it loads a resource.
+var ServedVersions = []string{"v1", "v2"}
+
+// Finalizer holds back deletion of a resource until its labels, VLANs or kernel settings have been taken off the nodes
+const Finalizer = Group + "/cleanup"
+
+// Kind describes a configuration kind served as a custom resource
+type Kind struct {
+	Name      string
+	Plural    string
+	ShortName string
+	// Finalize removes the configuration from the nodes when the resource is deleted
+	Finalize bool
+}
+
+// Kinds lists the custom resources reconciled by the operator
+// CleanupConf is left out: continuously deleting labels by prefix is a one-off job, not desired state.
+var Kinds = []Kind{
+	{Name: "NodeLabelConf", Plural: "nodelabelconfs", ShortName: "nlc", Finalize: true},
+	{Name: "NodeVLANConf", Plural: "nodevlanconfs", ShortName: "nvc", Finalize: true},
+	{Name: "NodeTestConf", Plural: "nodetestconfs", ShortName: "ntc"},
+	{Name: "NodeSysctlConf", Plural: "nodesysctlconfs", ShortName: "nsc", Finalize: true},
+	{Name: "NodeStorageConf", Plural: "nodestorageconfs", ShortName: "nstc", Finalize: true},
+}
+
+// crdResource is the resource of CustomResourceDefinitions, used through the dynamic client
+var crdResource = schema.GroupVersionResource{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"}
+
+// Resource returns the group, version and resource of the kind
+func (k Kind) Resource() schema.GroupVersionResource {
+	return schema.GroupVersionResource{Group: Group, Version: Version, Resource: k.Plural}
+}
+
+// CRD returns the CustomResourceDefinition of the kind, serving every version of ServedVersions and storing Version
+// The spec is not restated as an OpenAPI schema; it is validated by the same loader as configuration files.
+func (k Kind) CRD() *unstructured.Unstructured {
+	preserve := map[string]interface{}{"type": "object", "x-kubernetes-preserve-unknown-fields": true}
+
+	versions := make([]interface{}, 0, len(ServedVersions))
+	for _, version := range ServedVersions {
+		versions = append(versions, map[string]interface{}{
+			"name":    version,
+			"served":  true,
+			"storage": version == Version,
+			"schema": map[string]interface{}{"openAPIV3Schema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"spec":   preserve,
+					"tools":  preserve,
+					"status": preserve,
+				},
+			}},
+			"subresources": map[string]interface{}{"status": map[string]interface{}{}},
+			"additionalPrinterColumns": []interface{}{
+				map[string]interface{}{"name": "Phase", "type": "string", "jsonPath": ".status.phase"},
+				map[string]interface{}{"name": "Message", "type": "string", "jsonPath": ".status.message"},
+				map[string]interface{}{"name": "Age", "type": "date", "jsonPath": ".metadata.creationTimestamp"},
+			},
+		})
+	}
+
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apiextensions.k8s.io/v1",
+		"kind":       "CustomResourceDefinition",
+		"metadata":   map[string]interface{}{"name": k.Plural + "." + Group},
+		"spec": map[string]interface{}{
+			"group": Group,
+			"scope": "Namespaced",
+			"names": map[string]interface{}{
+				"kind":       k.Name,
+				"listKind":   k.Name + "List",
+				"plural":     k.Plural,
+				"singular":   strings.ToLower(k.Name),
+				"shortNames": []interface{}{k.ShortName},
+				"categories": []interface{}{"kictl"},
+			},
+			"versions": versions,
+		},
+	}}
+}
+
+// RegisterCRDs creates the custom resource definitions, or updates them if they already exist
+func RegisterCRDs(ctx context.Context, client dynamic.Interface) error {
+	for _, kind := range Kinds {
+		crd := kind.CRD()
+
+		existing, err := client.Resource(crdResource).Get(ctx, crd.GetName(), metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			if _, err := client.Resource(crdResource).Create(ctx, crd, metav1.CreateOptions{}); err != nil {
+				return fmt.Errorf("failed to create CRD %s: %w", crd.GetName(), err)
+			}
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read CRD %s: %w", crd.GetName(), err)
+		}
+
+		crd.SetResourceVersion(existing.GetResourceVersion())
+		if _, err := client.Resource(crdResource).Update(ctx, crd, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("failed to update CRD %s: %w", crd.GetName(), err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+// Package operator provides unit tests for custom resource definition registration
+// WHY: The CRDs are the contract between kubectl apply and the configuration loader
+package operator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// TestKind_CRD tests the generated CustomResourceDefinitions
+// WHY: Resource names must match what configuration files already use so manifests work unchanged
+func TestKind_CRD(t *testing.T) {
+	for _, kind := range Kinds {
+		t.Run(kind.Name, func(t *testing.T) {
+			crd := kind.CRD()
+
+			assert.Equal(t, kind.Plural+"."+Group, crd.GetName())
+			group, _, _ := unstructured.NestedString(crd.Object, "spec", "group")
+			assert.Equal(t, Group, group)
+			crdKind, _, _ := unstructured.NestedString(crd.Object, "spec", "names", "kind")
+			assert.Equal(t, kind.Name, crdKind)
+
+			versions, _, _ := unstructured.NestedSlice(crd.Object, "spec", "versions")
+			require.Len(t, versions, len(ServedVersions))
+			for i, version := range versions {
+				served := version.(map[string]interface{})
+				assert.Equal(t, ServedVersions[i], served["name"])
+				assert.Equal(t, true, served["served"], "generated and converted bundles must apply at every version")
+				assert.Equal(t, ServedVersions[i] == Version, served["storage"])
+				_, hasStatus, _ := unstructured.NestedMap(served, "subresources", "status")
+				assert.True(t, hasStatus, "status must be a subresource so status writes do not bump the generation")
+			}
+		})
+	}
+}
+
+// TestRegisterCRDs tests creating and then updating the CRDs
+// WHY: Upgrading the operator must update existing CRDs instead of failing on them
+func TestRegisterCRDs(t *testing.T) {
+	// Given: Cluster without kictl CRDs
+	client := newFakeClient()
+	ctx := context.Background()
+
+	// When: Registering twice
+	require.NoError(t, RegisterCRDs(ctx, client))
+	require.NoError(t, RegisterCRDs(ctx, client))
+
+	// Then: One CRD per kind exists
+	list, err := client.Resource(crdResource).List(ctx, metav1.ListOptions{})
+	require.NoError(t, err)
+	assert.Len(t, list.Items, len(Kinds))
+}
@@ -0,0 +1,219 @@
+package operator
+
+import (
+	"context"
+	"fmt"
+
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/kubectl"
+	"k8ostack-ictl/internal/labeler"
+	"k8ostack-ictl/internal/logging"
+	"k8ostack-ictl/internal/nethealthcheck"
+	"k8ostack-ictl/internal/storage"
+	"k8ostack-ictl/internal/sysctl"
+	"k8ostack-ictl/internal/vlan"
+)
+
+// Reconciler brings the nodes in line with a single custom resource
+// The bundle holds the resource being reconciled; a NodeTestConf bundle may also carry the
+// namespace's NodeVLANConf, which is then only used to map network names to addresses.
+type Reconciler interface {
+	// Apply makes the nodes match the resource and returns a short status message
+	// An error that only some nodes caused is a *NodeFailures, so those nodes can be backed off alone.
+	Apply(ctx context.Context, bundle *config.ConfigBundle) (string, error)
+
+	// Remove takes what the resource configured off the nodes
+	Remove(ctx context.Context, bundle *config.ConfigBundle) error
+}
+
+// ServiceReconciler reconciles resources with the labeler, vlan, sysctl, storage and nethealthcheck services used by the CLI
+type ServiceReconciler struct {
+	kubectl kubectl.DryRunExecutor
+	logger  logging.Logger
+	verbose bool
+}
+
+// NewServiceReconciler creates a reconciler running the CLI services through the given executor
+func NewServiceReconciler(executor kubectl.DryRunExecutor, logger logging.Logger, verbose bool) Reconciler {
+	return &ServiceReconciler{kubectl: executor, logger: logger, verbose: verbose}
+}
+
+// Apply labels nodes, configures VLANs, tunes kernels, prepares storage devices or runs connectivity tests, depending on the resource kind
+func (r *ServiceReconciler) Apply(ctx context.Context, bundle *config.ConfigBundle) (string, error) {
+	switch {
+	case bundle.HasTests():
+		return r.runTests(ctx, bundle.Tests, bundle.VLANs)
+
+	case bundle.HasNodeLabels():
+		results, err := r.labelingService(bundle.NodeLabels).ApplyLabels(ctx, bundle.NodeLabels)
+		if err != nil {
+			return "", err
+		}
+		if len(results.Errors) > 0 {
+			return "", nodeFailures(results.FailedNodes, fmt.Errorf("labeling finished with %d errors, first: %v", len(results.Errors), results.Errors[0]))
+		}
+		return fmt.Sprintf("labels applied to %d nodes", results.SuccessfulNodes), nil
+
+	case bundle.HasVLANs():
+		results, err := r.vlanService(bundle.VLANs).ConfigureVLANs(ctx, bundle.VLANs)
+		if err != nil {
+			return "", err
+		}
+		if len(results.Errors) > 0 {
+			return "", nodeFailures(results.FailedNodes, fmt.Errorf("VLAN configuration finished with %d errors, first: %v", len(results.Errors), results.Errors[0]))
+		}
+		return fmt.Sprintf("VLANs configured on %d nodes", results.SuccessfulNodes), nil
+
+	case bundle.HasSysctls():
+		results, err := r.sysctlService(bundle.Sysctls).ApplySysctls(ctx, bundle.Sysctls)
+		if err != nil {
+			return "", err
+		}
+		if len(results.Errors) > 0 {
+			return "", nodeFailures(results.FailedNodes, fmt.Errorf("kernel tuning finished with %d errors, first: %v", len(results.Errors), results.Errors[0]))
+		}
+		return fmt.Sprintf("kernel settings applied to %d nodes", results.SuccessfulNodes), nil
+
+	case bundle.HasStorage():
+		results, err := r.storageService(bundle.Storage).PrepareStorage(ctx, bundle.Storage)
+		if err != nil {
+			return "", err
+		}
+		if len(results.Errors) > 0 {
+			return "", nodeFailures(results.FailedNodes, fmt.Errorf("storage preparation finished with %d errors, first: %v", len(results.Errors), results.Errors[0]))
+		}
+		return fmt.Sprintf("storage devices ready on %d nodes", results.SuccessfulNodes), nil
+	}
+
+	return "", fmt.Errorf("nothing to reconcile in %s", bundle.Source)
+}
+
+// Remove removes labels or VLANs or restores kernel settings; connectivity tests leave nothing behind to remove
+// Storage devices keep their data, only the labels handing the nodes to Rook are removed.
+func (r *ServiceReconciler) Remove(ctx context.Context, bundle *config.ConfigBundle) error {
+	switch {
+	case bundle.HasTests():
+		return nil
+
+	case bundle.HasNodeLabels():
+		results, err := r.labelingService(bundle.NodeLabels).RemoveLabels(ctx, bundle.NodeLabels)
+		if err != nil {
+			return err
+		}
+		if len(results.Errors) > 0 {
+			return fmt.Errorf("label removal finished with %d errors, first: %v", len(results.Errors), results.Errors[0])
+		}
+
+	case bundle.HasVLANs():
+		results, err := r.vlanService(bundle.VLANs).RemoveVLANs(ctx, bundle.VLANs)
+		if err != nil {
+			return err
+		}
+		if len(results.Errors) > 0 {
+			return fmt.Errorf("VLAN removal finished with %d errors, first: %v", len(results.Errors), results.Errors[0])
+		}
+
+	case bundle.HasSysctls():
+		results, err := r.sysctlService(bundle.Sysctls).RemoveSysctls(ctx, bundle.Sysctls)
+		if err != nil {
+			return err
+		}
+		if len(results.Errors) > 0 {
+			return fmt.Errorf("kernel settings restore finished with %d errors, first: %v", len(results.Errors), results.Errors[0])
+		}
+
+	case bundle.HasStorage():
+		results, err := r.storageService(bundle.Storage).RemoveStorage(ctx, bundle.Storage)
+		if err != nil {
+			return err
+		}
+		if len(results.Errors) > 0 {
+			return fmt.Errorf("storage label removal finished with %d errors, first: %v", len(results.Errors), results.Errors[0])
+		}
+	}
+	return nil
+}
+
+// labelingService creates a labeling service honoring the resource's tool settings
+func (r *ServiceReconciler) labelingService(cfg *config.NodeLabelConf) labeler.Service {
+	tools := cfg.GetTools()
+	return labeler.NewService(r.kubectl, labeler.Options{
+		DryRun:        tools.Nlabel.DryRun,
+		Verbose:       r.verbose,
+		ValidateNodes: tools.Nlabel.ValidateNodes,
+		BulkLabel:     tools.Nlabel.BulkLabel,
+		Logger:        r.logger,
+	})
+}
+
+// vlanService creates a VLAN service honoring the resource's tool settings
+func (r *ServiceReconciler) vlanService(cfg *config.NodeVLANConf) vlan.Service {
+	tools := cfg.GetTools()
+	return vlan.NewService(r.kubectl, vlan.Options{
+		DryRun:               tools.Nvlan.DryRun,
+		Verbose:              r.verbose,
+		ValidateConnectivity: tools.Nvlan.ValidateConnectivity,
+		PersistentConfig:     tools.Nvlan.PersistentConfig,
+		PersistenceBackend:   tools.Nvlan.PersistenceBackend,
+		NetplanTry:           tools.Nvlan.NetplanTry,
+		ReachabilityGuard:    tools.Nvlan.ReachabilityGuard,
+		MembershipLabels:     tools.Nvlan.MembershipLabels,
+		DefaultInterface:     config.DefaultInterface,
+		Logger:               r.logger,
+	})
+}
+
+// sysctlService creates a kernel tuning service honoring the resource's tool settings
+func (r *ServiceReconciler) sysctlService(cfg *config.NodeSysctlConf) sysctl.Service {
+	tools := cfg.GetTools()
+	return sysctl.NewService(r.kubectl, sysctl.Options{
+		DryRun:  tools.Nsysctl.DryRun,
+		Verbose: r.verbose,
+		Logger:  r.logger,
+	})
+}
+
+// storageService creates a storage service honoring the resource's tool settings
+// Nobody confirms a reconcile, so the operator never zaps: a device holding data fails its node until wiped by hand
+// or by a confirmed 'kictl apply'.
+func (r *ServiceReconciler) storageService(cfg *config.NodeStorageConf) storage.Service {
+	tools := cfg.GetTools()
+	return storage.NewService(r.kubectl, storage.Options{
+		DryRun:  tools.Nstorage.DryRun,
+		Verbose: r.verbose,
+		Logger:  r.logger,
+	})
+}
+
+// runTests runs connectivity tests, mapping network names through the VLAN configuration when there is one
+func (r *ServiceReconciler) runTests(ctx context.Context, tests *config.NodeTestConf, vlans *config.NodeVLANConf) (string, error) {
+	tools := tests.GetTools()
+	options := nethealthcheck.Options{
+		DryRun:            tools.Ntest.DryRun,
+		Verbose:           r.verbose,
+		Parallel:          tools.Ntest.Parallel,
+		Retries:           tools.Ntest.Retries,
+		OutputFormat:      tools.Ntest.OutputFormat,
+		TimeoutDefault:    30,
+		CleanupAfterTests: true,
+		OpenstackProfiles: []string{"control-plane", "compute", "storage"},
+		ExcludeNodes:      tools.Ntest.ExcludeNodes,
+		Logger:            r.logger,
+	}
+
+	var testService nethealthcheck.Service
+	if vlans != nil {
+		testService = nethealthcheck.NewServiceWithVLAN(r.kubectl, options, vlans)
+	} else {
+		testService = nethealthcheck.NewService(r.kubectl, options)
+	}
+
+	results, err := testService.RunTests(ctx, tests)
+	if err != nil {
+		return "", err
+	}
+	if results.FailedTests > 0 || len(results.Errors) > 0 {
+		return "", fmt.Errorf("%d of %d tests failed", results.FailedTests, results.TotalTests)
+	}
+	return fmt.Sprintf("%d of %d tests passed", results.SuccessfulTests, results.TotalTests), nil
+}
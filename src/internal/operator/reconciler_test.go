@@ -0,0 +1,139 @@
+// Package operator provides unit tests for the service-backed reconciler
+// WHY: The operator must do exactly what kictl apply and kictl delete do for the same configuration
+package operator
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/labeler"
+	"k8ostack-ictl/internal/logging"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// newLabelBundle loads the test NodeLabelConf resource into a bundle
+func newLabelBundle(t *testing.T) *config.ConfigBundle {
+	bundle, err := loadResource(newResource("NodeLabelConf", "control", labelSpec))
+	require.NoError(t, err)
+	return bundle
+}
+
+// TestServiceReconciler_Labels tests applying and removing labels through the labeling service
+// WHY: Label failures must surface as reconcile errors naming the failed nodes, so those nodes can be backed off
+func TestServiceReconciler_Labels(t *testing.T) {
+	tests := []struct {
+		name        string
+		remove      bool
+		nodeErr     error
+		expectError bool
+	}{
+		{name: "apply labels"},
+		{name: "apply to a broken node", nodeErr: errors.New("connection refused"), expectError: true},
+		{name: "remove labels", remove: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: Executor where rsb2 exists
+			executor := labeler.NewMockDryRunExecutor()
+			executor.On("SetDryRun", false).Maybe()
+			executor.On("IsDryRun").Return(false).Maybe()
+			executor.On("GetNode", mock.Anything, "rsb2").Return(true, "rsb2", nil).Maybe()
+			executor.On("LabelNode", mock.Anything, "rsb2", mock.Anything, true).Return(tt.nodeErr == nil, "", tt.nodeErr).Maybe()
+			executor.On("UnlabelNode", mock.Anything, "rsb2", mock.Anything).Return(true, "", nil).Maybe()
+			logger := logging.NewMockLogger()
+			for _, level := range []string{"Debug", "Info", "Warn", "Error"} {
+				logger.On(level, mock.Anything).Maybe()
+			}
+			reconciler := NewServiceReconciler(executor, logger, false)
+
+			// When: Reconciling
+			var err error
+			var message string
+			if tt.remove {
+				err = reconciler.Remove(context.Background(), newLabelBundle(t))
+			} else {
+				message, err = reconciler.Apply(context.Background(), newLabelBundle(t))
+			}
+
+			// Then: The matching node operation ran
+			if tt.expectError {
+				var failures *NodeFailures
+				require.ErrorAs(t, err, &failures)
+				assert.Equal(t, []string{"rsb2"}, failures.Nodes)
+				return
+			}
+			require.NoError(t, err)
+			if tt.remove {
+				executor.AssertCalled(t, "UnlabelNode", mock.Anything, "rsb2", mock.Anything)
+			} else {
+				executor.AssertCalled(t, "LabelNode", mock.Anything, "rsb2", mock.Anything, true)
+				assert.Equal(t, "labels applied to 1 nodes", message)
+			}
+		})
+	}
+}
+
+// TestServiceReconciler_RemoveTests tests deleting a NodeTestConf
+// WHY: Connectivity tests change nothing on the nodes, so there must be nothing to undo
+func TestServiceReconciler_RemoveTests(t *testing.T) {
+	bundle, err := loadResource(newResource("NodeTestConf", "ping", map[string]interface{}{"tests": []interface{}{map[string]interface{}{"name": "ping", "source": "rsb2", "targets": []interface{}{"rsb3"}}}}))
+	require.NoError(t, err)
+	executor := labeler.NewMockDryRunExecutor()
+
+	err = NewServiceReconciler(executor, logging.NewMockLogger(), false).Remove(context.Background(), bundle)
+
+	assert.NoError(t, err)
+	executor.AssertExpectations(t)
+}
+
+// TestServiceReconciler_RemoveSysctls tests deleting a NodeSysctlConf
+// WHY: The finalizer must restore the kernel settings of the nodes before the resource goes away
+func TestServiceReconciler_RemoveSysctls(t *testing.T) {
+	// Given: A resource tuning rsb2, whose recorded defaults disable forwarding
+	bundle, err := loadResource(newResource("NodeSysctlConf", "tuning", map[string]interface{}{"nodeRoles": map[string]interface{}{
+		"compute": map[string]interface{}{"nodes": []interface{}{"rsb2"}, "sysctls": map[string]interface{}{"net.ipv4.ip_forward": "1"}},
+	}}))
+	require.NoError(t, err)
+	executor := labeler.NewMockDryRunExecutor()
+	executor.On("SetDryRun", false).Return()
+	executor.On("ReleaseDebugPods", mock.Anything).Return(0, nil)
+	executor.On("ExecNodeCommand", mock.Anything, "rsb2", "cat /var/lib/kictl/sysctl-defaults 2>/dev/null || true").Return(true, "net.ipv4.ip_forward = 0\n", nil).Once()
+	executor.On("ExecNodeCommand", mock.Anything, "rsb2", "printf '%s\\n' 0 > /proc/sys/net/ipv4/ip_forward"+
+		" && rm -f /etc/sysctl.d/90-kictl.conf /etc/tmpfiles.d/kictl-hugepages.conf /var/lib/kictl/sysctl-defaults").Return(true, "", nil).Once()
+
+	// When: Removing the resource
+	err = NewServiceReconciler(executor, logging.NewRecordingLogger(), false).Remove(context.Background(), bundle)
+
+	// Then: The recorded value is restored and the files are removed
+	require.NoError(t, err)
+	executor.AssertExpectations(t)
+}
+
+// TestServiceReconciler_RemoveStorage tests deleting a NodeStorageConf
+// WHY: The finalizer must hand the nodes back from Rook without ever touching the devices and the data of their OSDs
+func TestServiceReconciler_RemoveStorage(t *testing.T) {
+	// Given: A resource preparing a device of rsb2 and labeling it for Rook
+	bundle, err := loadResource(newResource("NodeStorageConf", "ceph-osds", map[string]interface{}{
+		"labels": map[string]interface{}{"ceph-osd": "enabled"},
+		"nodes":  map[string]interface{}{"rsb2": map[string]interface{}{"devices": []interface{}{map[string]interface{}{"path": "/dev/sdb", "zap": true}}}},
+	}))
+	require.NoError(t, err)
+	executor := labeler.NewMockDryRunExecutor()
+	executor.On("SetDryRun", false).Return()
+	executor.On("ReleaseDebugPods", mock.Anything).Return(0, nil)
+	executor.On("UnlabelNode", mock.Anything, "rsb2", "ceph-osd").Return(true, "node/rsb2 unlabeled", nil).Once()
+
+	// When: Removing the resource
+	err = NewServiceReconciler(executor, logging.NewRecordingLogger(), false).Remove(context.Background(), bundle)
+
+	// Then: The label is removed and no node command runs
+	require.NoError(t, err)
+	executor.AssertExpectations(t)
+	executor.AssertNotCalled(t, "ExecNodeCommand", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,304 @@
+// Package orphans finds what kictl left behind on a cluster that no current bundle accounts for
+// Debug pods of interrupted runs, expired run locks, VLAN files of removed VLANs and ownership claims of removed roles
+// are reported one by one, so each can be removed on its own.
+package orphans
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"k8ostack-ictl/internal/clusterlock"
+	"k8ostack-ictl/internal/kubectl"
+	"k8ostack-ictl/internal/ownership"
+	"k8ostack-ictl/internal/throttle"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Kinds of orphaned artifacts
+const (
+	KindDebugPod  = "debug pod"
+	KindLock      = "run lock"
+	KindFile      = "VLAN file"
+	KindOwnership = "ownership claim"
+)
+
+// vlanFileHeader starts every file kictl writes to persist a VLAN, followed by the VLAN name
+const vlanFileHeader = "# Generated by kictl for VLAN "
+
+// vlanFilePaths are where the persistence backends write VLAN files, as shell globs
+var vlanFilePaths = []string{
+	"/etc/netplan/60-kictl-*.yaml",
+	"/etc/NetworkManager/system-connections/kictl-*.nmconnection",
+	"/etc/systemd/network/60-kictl-*",
+	"/etc/systemd/network/*.d/60-kictl-*.conf",
+	"/etc/sysconfig/network-scripts/ifcfg-*",
+}
+
+// Finding is an artifact kictl created that nothing accounts for any more
+type Finding struct {
+	Kind      string
+	Node      string          // node the artifact is on; empty for the run lock
+	Namespace string          // namespace of a debug pod or the lock lease
+	Name      string          // pod or lease name, file path or owner
+	Reason    string          // why nothing accounts for it, e.g. "VLAN storage is not configured for this node"
+	Keep      ownership.Claim // what an ownership claim keeps once the orphaned part is released
+}
+
+func (f Finding) String() string {
+	switch f.Kind {
+	case KindDebugPod, KindLock:
+		return fmt.Sprintf("%s %s/%s: %s", f.Kind, f.Namespace, f.Name, f.Reason)
+	case KindOwnership:
+		return fmt.Sprintf("%s of %s on node %s: %s", f.Kind, f.Name, f.Node, f.Reason)
+	default:
+		return fmt.Sprintf("%s %s on node %s: %s", f.Kind, f.Name, f.Node, f.Reason)
+	}
+}
+
+// Expected is what the current bundles account for on the cluster
+type Expected struct {
+	VLANs  map[string]map[string]bool  // node -> names of the VLANs the node gets
+	Claims map[string]ownership.Claims // owner -> what its bundle claims per node; owners of no current bundle are not judged
+}
+
+// Report is the outcome of a scan
+type Report struct {
+	Findings  []Finding
+	ActiveRun string  // holder of a live run lock, whose debug pods may still be in use
+	Errors    []error // parts of the cluster that could not be scanned
+}
+
+// Options contains configuration options for the scanner
+type Options struct {
+	MinPodAge time.Duration        // Debug pods younger than this may belong to a running command and are not reported
+	Workers   *throttle.Controller // Scans nodes concurrently; nil scans them one by one
+	Run       kubectl.Runner       // Runs kubectl for pods and leases; nil runs it through the executor
+}
+
+// Scanner finds and removes orphaned artifacts
+type Scanner struct {
+	executor kubectl.Executor
+	options  Options
+}
+
+// NewScanner creates a scanner reading nodes through the executor
+func NewScanner(executor kubectl.Executor, options Options) *Scanner {
+	if options.Run == nil {
+		options.Run = executor.RunKubectl
+	}
+	return &Scanner{executor: executor, options: options}
+}
+
+// Scan finds the orphaned artifacts of the cluster and of every node
+func (s *Scanner) Scan(ctx context.Context, expected Expected, now time.Time) (*Report, error) {
+	success, output, err := s.executor.GetAllNodes(ctx)
+	if err != nil || !success {
+		return nil, fmt.Errorf("failed to list cluster nodes: %v", err)
+	}
+	nodes := kubectl.ParseNodeNames(output)
+	sort.Strings(nodes)
+
+	report := &Report{}
+	if err := s.scanLock(ctx, report, now); err != nil {
+		report.Errors = append(report.Errors, err)
+	}
+	if err := s.scanDebugPods(ctx, report, now); err != nil {
+		report.Errors = append(report.Errors, err)
+	}
+
+	perNode := make(map[string][]Finding, len(nodes))
+	var mu sync.Mutex
+	err = s.options.Workers.Run(nodes, func(nodeName string) {
+		findings, err := s.scanNode(ctx, nodeName, expected)
+		mu.Lock()
+		defer mu.Unlock()
+		perNode[nodeName] = findings
+		if err != nil {
+			report.Errors = append(report.Errors, kubectl.WrapNodeError(nodeName, err))
+		}
+	})
+	if err != nil {
+		report.Errors = append(report.Errors, err)
+	}
+	for _, nodeName := range nodes {
+		report.Findings = append(report.Findings, perNode[nodeName]...)
+	}
+	sort.SliceStable(report.Errors, func(i, j int) bool { return report.Errors[i].Error() < report.Errors[j].Error() })
+	return report, nil
+}
+
+// scanLock reports a run lock whose holder stopped renewing it
+func (s *Scanner) scanLock(ctx context.Context, report *Report, now time.Time) error {
+	holder, err := clusterlock.Inspect(ctx, s.options.Run)
+	if err != nil || holder == nil {
+		return err
+	}
+	if !holder.Expired(now) {
+		report.ActiveRun = holder.Identity
+		return nil
+	}
+	report.Findings = append(report.Findings, Finding{
+		Kind:      KindLock,
+		Namespace: clusterlock.Namespace,
+		Name:      clusterlock.Name,
+		Reason:    fmt.Sprintf("held by %s, expired %s", holder.Identity, holder.Renewed.Add(holder.Duration).Format(time.RFC3339)),
+	})
+	return nil
+}
+
+// scanDebugPods reports the node command pods of every namespace older than the minimum age
+func (s *Scanner) scanDebugPods(ctx context.Context, report *Report, now time.Time) error {
+	output, err := s.kubectl(ctx, "get", "pods", "--all-namespaces", "-o", "json")
+	if err != nil {
+		return fmt.Errorf("failed to list pods: %s", strings.TrimSpace(output))
+	}
+	var pods corev1.PodList
+	if err := json.Unmarshal([]byte(output), &pods); err != nil {
+		return fmt.Errorf("failed to parse pods: %w", err)
+	}
+
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if !kubectl.IsNodeCommandPod(pod) {
+			continue
+		}
+		age := now.Sub(pod.CreationTimestamp.Time)
+		if age < s.options.MinPodAge {
+			continue
+		}
+		report.Findings = append(report.Findings, Finding{
+			Kind:      KindDebugPod,
+			Node:      pod.Spec.NodeName,
+			Namespace: pod.Namespace,
+			Name:      pod.Name,
+			Reason:    fmt.Sprintf("%s on node %s for %s", pod.Status.Phase, pod.Spec.NodeName, age.Truncate(time.Minute)),
+		})
+	}
+	return nil
+}
+
+// scanNode reports the VLAN files and ownership claims of a node that no current bundle accounts for
+func (s *Scanner) scanNode(ctx context.Context, nodeName string, expected Expected) ([]Finding, error) {
+	var findings []Finding
+
+	_, output, err := s.executor.ExecNodeCommand(ctx, nodeName, vlanFilesCommand())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list VLAN files: %w", err)
+	}
+	for _, file := range parseVLANFiles(output) {
+		if expected.VLANs[nodeName][file.vlan] {
+			continue
+		}
+		findings = append(findings, Finding{
+			Kind:   KindFile,
+			Node:   nodeName,
+			Name:   file.path,
+			Reason: fmt.Sprintf("VLAN %s is not configured for this node by any bundle", file.vlan),
+		})
+	}
+
+	success, output, err := s.executor.GetNodeAnnotations(ctx, nodeName)
+	if err != nil || !success {
+		return findings, fmt.Errorf("failed to read annotations: %v", err)
+	}
+	claims, err := ownership.Parse(output)
+	if err != nil {
+		return findings, err
+	}
+	owners := make([]string, 0, len(claims))
+	for owner := range claims {
+		if _, judged := expected.Claims[owner]; judged {
+			owners = append(owners, owner)
+		}
+	}
+	sort.Strings(owners)
+	for _, owner := range owners {
+		orphaned := claims[owner].Without(expected.Claims[owner][nodeName])
+		if orphaned.Empty() {
+			continue
+		}
+		findings = append(findings, Finding{
+			Kind:   KindOwnership,
+			Node:   nodeName,
+			Name:   owner,
+			Reason: fmt.Sprintf("claims %s, which no bundle of the owner sets", describeClaim(orphaned)),
+			Keep:   claims[owner].Without(orphaned),
+		})
+	}
+	return findings, nil
+}
+
+// Remove deletes an orphaned artifact; ownership claims keep what the owner's bundle still sets
+func (s *Scanner) Remove(ctx context.Context, finding Finding) error {
+	switch finding.Kind {
+	case KindDebugPod:
+		if output, err := s.kubectl(ctx, "delete", "pod", finding.Name, "--namespace", finding.Namespace, "--ignore-not-found", "--wait=false"); err != nil {
+			return fmt.Errorf("failed to delete debug pod %s/%s: %s", finding.Namespace, finding.Name, strings.TrimSpace(output))
+		}
+	case KindLock:
+		if output, err := s.kubectl(ctx, "delete", "lease", finding.Name, "--namespace", finding.Namespace, "--ignore-not-found"); err != nil {
+			return fmt.Errorf("failed to delete lock lease %s/%s: %s", finding.Namespace, finding.Name, strings.TrimSpace(output))
+		}
+	case KindFile:
+		success, output, err := s.executor.ExecNodeCommand(ctx, finding.Node, kubectl.HostCommand("rm", "-f", finding.Name))
+		if err != nil || !success {
+			return kubectl.WrapNodeError(finding.Node, fmt.Errorf("failed to remove %s: %v %s", finding.Name, err, strings.TrimSpace(output)))
+		}
+	case KindOwnership:
+		return ownership.Record(ctx, finding.Node, finding.Name, finding.Keep, s.options.Run)
+	default:
+		return fmt.Errorf("unknown orphan kind %q", finding.Kind)
+	}
+	return nil
+}
+
+// vlanFile is a file kictl wrote to persist a VLAN
+type vlanFile struct {
+	path string
+	vlan string
+}
+
+// vlanFilesCommand prints the header of every VLAN file kictl wrote, as path:header lines
+// The globs are left unquoted for the node's shell; paths that do not exist are skipped.
+func vlanFilesCommand() string {
+	return kubectl.HostCommand("grep", "-H", "-m1", "^"+vlanFileHeader) + " " + strings.Join(vlanFilePaths, " ") + " 2>/dev/null || true"
+}
+
+// parseVLANFiles parses the output of vlanFilesCommand
+func parseVLANFiles(output string) []vlanFile {
+	var files []vlanFile
+	for _, line := range strings.Split(output, "\n") {
+		path, header, found := strings.Cut(strings.TrimSpace(line), ":")
+		if !found {
+			continue
+		}
+		if name, ok := strings.CutPrefix(header, vlanFileHeader); ok {
+			files = append(files, vlanFile{path: path, vlan: strings.TrimSpace(name)})
+		}
+	}
+	return files
+}
+
+// describeClaim lists the label keys and VLANs of a claim, e.g. "label zone, VLAN 100"
+func describeClaim(claim ownership.Claim) string {
+	var items []string
+	for _, key := range claim.Labels {
+		items = append(items, "label "+key)
+	}
+	for _, id := range claim.VLANs {
+		items = append(items, "VLAN "+strconv.Itoa(id))
+	}
+	return strings.Join(items, ", ")
+}
+
+// kubectl runs kubectl against the target cluster
+func (s *Scanner) kubectl(ctx context.Context, args ...string) (string, error) {
+	return s.options.Run(ctx, nil, args...)
+}
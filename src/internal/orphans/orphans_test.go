@@ -0,0 +1,176 @@
+// Package orphans provides unit tests for the orphan scan
+// WHY: Cleanup deletes what the scan reports, so anything a current bundle or a running command still uses must never be reported
+package orphans
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"k8ostack-ictl/internal/labeler"
+	"k8ostack-ictl/internal/ownership"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// scanTestPods are two kictl debug pods, a fresh one of a running command and a kubectl debug pod of a person
+const scanTestPods = `{"items": [
+  {"metadata": {"name": "node-debugger-rsb2-ab12c", "namespace": "default", "creationTimestamp": "2026-10-16T09:00:00Z",
+    "labels": {"app.kubernetes.io/managed-by": "kictl"}}, "spec": {"nodeName": "rsb2"}, "status": {"phase": "Running"}},
+  {"metadata": {"name": "node-debugger-rsb3-de34f", "namespace": "ops", "creationTimestamp": "2026-10-16T10:00:00Z"},
+    "spec": {"nodeName": "rsb3", "containers": [{"name": "debugger",
+      "command": ["/bin/sh", "-c", "trap 'exit 0' TERM; while true; do sleep 3600 & wait $!; done"]}]}, "status": {"phase": "Running"}},
+  {"metadata": {"name": "node-debugger-rsb2-gh56i", "namespace": "default", "creationTimestamp": "2026-10-16T11:55:00Z",
+    "labels": {"app.kubernetes.io/managed-by": "kictl"}}, "spec": {"nodeName": "rsb2"}, "status": {"phase": "Running"}},
+  {"metadata": {"name": "node-debugger-rsb3-jk78l", "namespace": "default", "creationTimestamp": "2026-10-16T08:00:00Z"},
+    "spec": {"nodeName": "rsb3", "containers": [{"name": "debugger", "command": ["bash"]}]}, "status": {"phase": "Running"}}
+]}`
+
+// TestScanner_Scan tests finding what no current bundle accounts for
+// WHY: Files and claims of VLANs and roles still in a bundle, and other owners' claims, must be left alone
+func TestScanner_Scan(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		lease          string
+		expectFindings []string
+		expectActive   string
+	}{
+		{
+			name:  "expired_lock",
+			lease: "bob@ops2 apply (pid 7)|2026-10-16T09:00:00.000000Z|7200",
+			expectFindings: []string{
+				"run lock kube-system/kictl-run-lock: held by bob@ops2 apply (pid 7), expired 2026-10-16T11:00:00Z",
+				"debug pod default/node-debugger-rsb2-ab12c: Running on node rsb2 for 3h0m0s",
+				"debug pod ops/node-debugger-rsb3-de34f: Running on node rsb3 for 2h0m0s",
+				"VLAN file /etc/netplan/60-kictl-eth0.300.yaml on node rsb2: VLAN tenant is not configured for this node by any bundle",
+				"ownership claim of team-network on node rsb2: claims label legacy-role, VLAN 300, which no bundle of the owner sets",
+			},
+		},
+		{
+			name:         "live_lock",
+			lease:        "bob@ops2 apply (pid 7)|2026-10-16T11:30:00.000000Z|7200",
+			expectActive: "bob@ops2 apply (pid 7)",
+			expectFindings: []string{
+				"debug pod default/node-debugger-rsb2-ab12c: Running on node rsb2 for 3h0m0s",
+				"debug pod ops/node-debugger-rsb3-de34f: Running on node rsb3 for 2h0m0s",
+				"VLAN file /etc/netplan/60-kictl-eth0.300.yaml on node rsb2: VLAN tenant is not configured for this node by any bundle",
+				"ownership claim of team-network on node rsb2: claims label legacy-role, VLAN 300, which no bundle of the owner sets",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: rsb2 with a file and claim of a removed VLAN and role, rsb3 with only current ones
+			executor := labeler.NewMockDryRunExecutor()
+			executor.On("GetAllNodes", mock.Anything).Return(true, "node/rsb3\nnode/rsb2\n", nil)
+			executor.On("ExecNodeCommand", mock.Anything, "rsb2", vlanFilesCommand()).Return(true,
+				"/etc/netplan/60-kictl-eth0.100.yaml:# Generated by kictl for VLAN storage\n"+
+					"/etc/netplan/60-kictl-eth0.300.yaml:# Generated by kictl for VLAN tenant\n", nil)
+			executor.On("ExecNodeCommand", mock.Anything, "rsb3", vlanFilesCommand()).Return(true,
+				"/etc/netplan/60-kictl-eth0.100.yaml:# Generated by kictl for VLAN storage\n", nil)
+			executor.On("GetNodeAnnotations", mock.Anything, "rsb2").Return(true,
+				`{"owner.kictl.icycloud.io/team-network": "{\"labels\":[\"legacy-role\",\"zone\"],\"vlans\":[100,300]}",`+
+					` "owner.kictl.icycloud.io/team-storage": "{\"labels\":[\"disk\"]}"}`, nil)
+			executor.On("GetNodeAnnotations", mock.Anything, "rsb3").Return(true,
+				`{"owner.kictl.icycloud.io/team-network": "{\"vlans\":[100]}"}`, nil)
+			run := func(ctx context.Context, stdin []byte, args ...string) (string, error) {
+				switch args[0] {
+				case "get":
+					if args[1] == "lease" {
+						return tt.lease, nil
+					}
+					return scanTestPods, nil
+				}
+				return "", errors.New("unexpected command")
+			}
+			expected := Expected{
+				VLANs: map[string]map[string]bool{"rsb2": {"storage": true}, "rsb3": {"storage": true}},
+				Claims: map[string]ownership.Claims{"team-network": {
+					"rsb2": {Labels: []string{"zone"}, VLANs: []int{100}},
+					"rsb3": {VLANs: []int{100}},
+				}},
+			}
+			scanner := NewScanner(executor, Options{MinPodAge: time.Hour, Run: run})
+
+			// When: Scan the cluster
+			report, err := scanner.Scan(context.Background(), expected, now)
+
+			// Then: Only what nothing accounts for is reported, in a stable order
+			require.NoError(t, err)
+			assert.Empty(t, report.Errors)
+			assert.Equal(t, tt.expectActive, report.ActiveRun)
+			var findings []string
+			for _, finding := range report.Findings {
+				findings = append(findings, finding.String())
+			}
+			assert.Equal(t, tt.expectFindings, findings)
+		})
+	}
+}
+
+// TestScanner_Remove tests removing each kind of orphan
+// WHY: Releasing an orphaned claim must keep the rest of the owner's claim, or its current labels lose their protection
+func TestScanner_Remove(t *testing.T) {
+	tests := []struct {
+		name          string
+		finding       Finding
+		expectCommand string
+		expectNode    string
+	}{
+		{
+			name:          "debug_pod",
+			finding:       Finding{Kind: KindDebugPod, Node: "rsb2", Namespace: "ops", Name: "node-debugger-rsb2-ab12c"},
+			expectCommand: "delete pod node-debugger-rsb2-ab12c --namespace ops --ignore-not-found --wait=false",
+		},
+		{
+			name:          "run_lock",
+			finding:       Finding{Kind: KindLock, Namespace: "kube-system", Name: "kictl-run-lock"},
+			expectCommand: "delete lease kictl-run-lock --namespace kube-system --ignore-not-found",
+		},
+		{
+			name:       "vlan_file",
+			finding:    Finding{Kind: KindFile, Node: "rsb2", Name: "/etc/netplan/60-kictl-eth0.300.yaml"},
+			expectNode: "rm -f /etc/netplan/60-kictl-eth0.300.yaml",
+		},
+		{
+			name:          "ownership_claim",
+			finding:       Finding{Kind: KindOwnership, Node: "rsb2", Name: "team-network", Keep: ownership.Claim{VLANs: []int{100}}},
+			expectCommand: `annotate node rsb2 --overwrite owner.kictl.icycloud.io/team-network={"vlans":[100]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A scanner recording its kubectl and node commands
+			executor := labeler.NewMockDryRunExecutor()
+			if tt.expectNode != "" {
+				executor.On("ExecNodeCommand", mock.Anything, tt.finding.Node, tt.expectNode).Return(true, "", nil).Once()
+			}
+			var commands []string
+			run := func(ctx context.Context, stdin []byte, args ...string) (string, error) {
+				commands = append(commands, strings.Join(args, " "))
+				return "", nil
+			}
+			scanner := NewScanner(executor, Options{Run: run})
+
+			// When: Remove the orphan
+			err := scanner.Remove(context.Background(), tt.finding)
+
+			// Then: Exactly the orphan is removed
+			require.NoError(t, err)
+			executor.AssertExpectations(t)
+			if tt.expectCommand == "" {
+				assert.Empty(t, commands)
+				return
+			}
+			assert.Equal(t, []string{tt.expectCommand}, commands)
+		})
+	}
+}
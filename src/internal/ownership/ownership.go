@@ -0,0 +1,185 @@
+// Package ownership records on each node which bundle manages which label keys and VLANs
+// Every owner writes only its own annotation, so bundles sharing nodes can check each other's claims without overwriting them.
+package ownership
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"k8ostack-ictl/internal/kubectl"
+)
+
+// AnnotationPrefix prefixes the node annotation of each owner, e.g. owner.kictl.icycloud.io/team-network
+const AnnotationPrefix = "owner.kictl.icycloud.io/"
+
+// Claim is what one owner manages on a node
+type Claim struct {
+	Labels []string `json:"labels,omitempty"` // Label keys
+	VLANs  []int    `json:"vlans,omitempty"`  // VLAN IDs
+}
+
+// Empty reports whether the claim holds nothing
+func (c Claim) Empty() bool {
+	return len(c.Labels) == 0 && len(c.VLANs) == 0
+}
+
+// Union returns the label keys and VLANs of both claims, sorted
+func (c Claim) Union(other Claim) Claim {
+	labels := make(map[string]bool)
+	vlans := make(map[int]bool)
+	for _, claim := range []Claim{c, other} {
+		for _, key := range claim.Labels {
+			labels[key] = true
+		}
+		for _, id := range claim.VLANs {
+			vlans[id] = true
+		}
+	}
+	return newClaim(labels, vlans)
+}
+
+// Without returns the label keys and VLANs of the claim that other does not hold, sorted
+func (c Claim) Without(other Claim) Claim {
+	labels := make(map[string]bool)
+	vlans := make(map[int]bool)
+	for _, key := range c.Labels {
+		labels[key] = true
+	}
+	for _, id := range c.VLANs {
+		vlans[id] = true
+	}
+	for _, key := range other.Labels {
+		delete(labels, key)
+	}
+	for _, id := range other.VLANs {
+		delete(vlans, id)
+	}
+	return newClaim(labels, vlans)
+}
+
+// newClaim builds a sorted claim from sets of label keys and VLAN IDs
+func newClaim(labels map[string]bool, vlans map[int]bool) Claim {
+	var claim Claim
+	for key := range labels {
+		claim.Labels = append(claim.Labels, key)
+	}
+	for id := range vlans {
+		claim.VLANs = append(claim.VLANs, id)
+	}
+	sort.Strings(claim.Labels)
+	sort.Ints(claim.VLANs)
+	return claim
+}
+
+// Claims are the claims of a run per node
+type Claims map[string]Claim
+
+// Add adds label keys and VLAN IDs to the claim of a node
+func (c Claims) Add(node string, claim Claim) {
+	c[node] = c[node].Union(claim)
+}
+
+// Nodes returns the claimed nodes, sorted
+func (c Claims) Nodes() []string {
+	nodes := make([]string, 0, len(c))
+	for node := range c {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+	return nodes
+}
+
+// AnnotationKey returns the node annotation holding the claim of an owner
+func AnnotationKey(owner string) string {
+	return AnnotationPrefix + owner
+}
+
+// Annotation renders the claim of an owner as a node annotation value
+func Annotation(claim Claim) (string, error) {
+	data, err := json.Marshal(claim)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// Parse returns the claim of every owner in the annotations of a node, as kubectl prints them in JSON
+// An annotation that is not a claim is an error, since ignoring it would let a run overwrite what its owner manages.
+func Parse(annotations string) (map[string]Claim, error) {
+	claims := make(map[string]Claim)
+	if strings.TrimSpace(annotations) == "" {
+		return claims, nil
+	}
+	var values map[string]string
+	if err := json.Unmarshal([]byte(annotations), &values); err != nil {
+		return nil, fmt.Errorf("failed to parse node annotations: %w", err)
+	}
+	for key, value := range values {
+		owner, ok := strings.CutPrefix(key, AnnotationPrefix)
+		if !ok {
+			continue
+		}
+		var claim Claim
+		if err := json.Unmarshal([]byte(value), &claim); err != nil {
+			return nil, fmt.Errorf("invalid ownership annotation %s: %w", key, err)
+		}
+		claims[owner] = claim
+	}
+	return claims, nil
+}
+
+// Conflict is a label key or VLAN a run would change while another owner manages it on the node
+type Conflict struct {
+	Node  string
+	Owner string
+	Item  string // e.g. "label zone" or "VLAN 100"
+}
+
+func (c Conflict) String() string {
+	return fmt.Sprintf("%s on node %s is owned by %s", c.Item, c.Node, c.Owner)
+}
+
+// Conflicts returns the items of a claim that owners other than owner claim on the node, sorted by owner
+func Conflicts(node, owner string, claim Claim, claims map[string]Claim) []Conflict {
+	owners := make([]string, 0, len(claims))
+	for other := range claims {
+		if other != owner {
+			owners = append(owners, other)
+		}
+	}
+	sort.Strings(owners)
+
+	var conflicts []Conflict
+	for _, other := range owners {
+		shared := claim.Without(claim.Without(claims[other]))
+		for _, key := range shared.Labels {
+			conflicts = append(conflicts, Conflict{Node: node, Owner: other, Item: "label " + key})
+		}
+		for _, id := range shared.VLANs {
+			conflicts = append(conflicts, Conflict{Node: node, Owner: other, Item: "VLAN " + strconv.Itoa(id)})
+		}
+	}
+	return conflicts
+}
+
+// Record writes the claim of an owner to a node, removing the annotation when the claim is empty
+// run is the kubectl of the executor selecting the cluster.
+func Record(ctx context.Context, node, owner string, claim Claim, run kubectl.Runner) error {
+	annotation := AnnotationKey(owner) + "-"
+	if !claim.Empty() {
+		value, err := Annotation(claim)
+		if err != nil {
+			return err
+		}
+		annotation = AnnotationKey(owner) + "=" + value
+	}
+	args := []string{"annotate", "node", node, "--overwrite", annotation}
+	if output, err := run(ctx, nil, args...); err != nil {
+		return fmt.Errorf("failed to record ownership of %s on node %s: %s", owner, node, strings.TrimSpace(output))
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+// Package ownership provides unit tests for ownership annotations
+// WHY: Bundles sharing nodes rely on these annotations to avoid overwriting each other's labels and VLANs
+package ownership
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestParse tests reading the claims of every owner from node annotations
+// WHY: Other annotations are ignored, but a broken claim must not be mistaken for no claim
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations string
+		expected    map[string]Claim
+		expectError string
+	}{
+		{name: "no_annotations", annotations: "", expected: map[string]Claim{}},
+		{
+			name:        "two_owners",
+			annotations: `{"node.alpha.kubernetes.io/ttl":"0","owner.kictl.icycloud.io/team-a":"{\"labels\":[\"zone\"]}","owner.kictl.icycloud.io/team-b":"{\"vlans\":[100]}"}`,
+			expected: map[string]Claim{
+				"team-a": {Labels: []string{"zone"}},
+				"team-b": {VLANs: []int{100}},
+			},
+		},
+		{
+			name:        "broken_claim",
+			annotations: `{"owner.kictl.icycloud.io/team-a":"zone"}`,
+			expectError: "invalid ownership annotation owner.kictl.icycloud.io/team-a",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := Parse(tt.annotations)
+
+			if tt.expectError != "" {
+				assert.ErrorContains(t, err, tt.expectError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, claims)
+		})
+	}
+}
+
+// TestConflicts tests finding what a run shares with other owners
+// WHY: Only items another owner claims are conflicts; the run's own earlier claims are not
+func TestConflicts(t *testing.T) {
+	claims := map[string]Claim{
+		"team-a": {Labels: []string{"role", "zone"}, VLANs: []int{100}},
+		"team-b": {Labels: []string{"rack"}, VLANs: []int{200, 300}},
+		"team-c": {Labels: []string{"zone"}},
+	}
+	run := Claim{Labels: []string{"role", "zone"}, VLANs: []int{100, 300}}
+
+	conflicts := Conflicts("rsb2", "team-a", run, claims)
+
+	var described []string
+	for _, conflict := range conflicts {
+		described = append(described, conflict.String())
+	}
+	assert.Equal(t, []string{
+		"VLAN 300 on node rsb2 is owned by team-b",
+		"label zone on node rsb2 is owned by team-c",
+	}, described)
+}
+
+// TestClaim_UnionWithout tests combining claims
+// WHY: Apply adds to what an owner claims on a node and delete gives it back, without touching the rest
+func TestClaim_UnionWithout(t *testing.T) {
+	existing := Claim{Labels: []string{"zone"}, VLANs: []int{200}}
+	run := Claim{Labels: []string{"role", "zone"}, VLANs: []int{100}}
+
+	assert.Equal(t, Claim{Labels: []string{"role", "zone"}, VLANs: []int{100, 200}}, existing.Union(run))
+	assert.Equal(t, Claim{VLANs: []int{200}}, existing.Without(run))
+	assert.True(t, existing.Without(existing).Empty())
+}
+
+// TestRecord tests writing and removing the annotation of an owner
+// WHY: An owner without claims on a node must leave no annotation behind
+func TestRecord(t *testing.T) {
+	tests := []struct {
+		name         string
+		claim        Claim
+		runErr       error
+		expectedArgs []string
+		expectError  string
+	}{
+		{
+			name:         "claim",
+			claim:        Claim{Labels: []string{"zone"}, VLANs: []int{100}},
+			expectedArgs: []string{"annotate", "node", "rsb2", "--overwrite", `owner.kictl.icycloud.io/team-a={"labels":["zone"],"vlans":[100]}`},
+		},
+		{
+			name:         "empty_claim",
+			expectedArgs: []string{"annotate", "node", "rsb2", "--overwrite", "owner.kictl.icycloud.io/team-a-"},
+		},
+		{
+			name:        "annotate_fails",
+			claim:       Claim{Labels: []string{"zone"}},
+			runErr:      errors.New("exit status 1"),
+			expectError: "failed to record ownership of team-a on node rsb2: nodes \"rsb2\" is forbidden",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var args []string
+			err := Record(context.Background(), "rsb2", "team-a", tt.claim,
+				func(ctx context.Context, stdin []byte, kubectlArgs ...string) (string, error) {
+					args = kubectlArgs
+					if tt.runErr != nil {
+						return "nodes \"rsb2\" is forbidden\n", tt.runErr
+					}
+					return "node/rsb2 annotated", nil
+				})
+
+			if tt.expectError != "" {
+				assert.EqualError(t, err, tt.expectError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedArgs, args)
+		})
+	}
+}
@@ -0,0 +1,82 @@
+// Package plan describes pending changes that an apply would make to cluster nodes
+package plan
+
+import "sort"
+
+// Change operations
+const (
+	OpAdd    = "add"
+	OpChange = "change"
+	OpRemove = "remove"
+)
+
+// Change is a single pending difference between a node and the desired configuration
+type Change struct {
+	Node     string `json:"node"`
+	Kind     string `json:"kind"` // label or vlan
+	Key      string `json:"key"`
+	Op       string `json:"op"` // add, change or remove
+	OldValue string `json:"oldValue,omitempty"`
+	NewValue string `json:"newValue,omitempty"`
+}
+
+// Plan collects pending changes and the nodes whose state could not be read
+type Plan struct {
+	Changes []Change
+	Errors  []error
+}
+
+// Merge appends the changes and errors of another plan
+func (p *Plan) Merge(other *Plan) {
+	if other == nil {
+		return
+	}
+	p.Changes = append(p.Changes, other.Changes...)
+	p.Errors = append(p.Errors, other.Errors...)
+}
+
+// Counts returns the number of additions, changes and removals
+func (p *Plan) Counts() (add, change, remove int) {
+	for _, c := range p.Changes {
+		switch c.Op {
+		case OpAdd:
+			add++
+		case OpChange:
+			change++
+		case OpRemove:
+			remove++
+		}
+	}
+	return add, change, remove
+}
+
+// Nodes returns the sorted names of nodes with pending changes
+func (p *Plan) Nodes() []string {
+	seen := make(map[string]bool)
+	var nodes []string
+	for _, c := range p.Changes {
+		if !seen[c.Node] {
+			seen[c.Node] = true
+			nodes = append(nodes, c.Node)
+		}
+	}
+	sort.Strings(nodes)
+	return nodes
+}
+
+// NodeChanges returns the changes of a node ordered by kind, then key
+func (p *Plan) NodeChanges(node string) []Change {
+	var changes []Change
+	for _, c := range p.Changes {
+		if c.Node == node {
+			changes = append(changes, c)
+		}
+	}
+	sort.SliceStable(changes, func(i, j int) bool {
+		if changes[i].Kind != changes[j].Kind {
+			return changes[i].Kind < changes[j].Kind
+		}
+		return changes[i].Key < changes[j].Key
+	})
+	return changes
+}
@@ -0,0 +1,41 @@
+package plan
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestPlan_Summaries tests counting, node listing and per-node ordering
+// WHY: The CLI renders plans straight from these helpers, so ordering must be stable
+func TestPlan_Summaries(t *testing.T) {
+	// Given: Plans from two services merged together
+	labels := &Plan{Changes: []Change{
+		{Node: "rsb3", Kind: "label", Key: "zone", Op: OpChange, OldValue: "a", NewValue: "b"},
+		{Node: "rsb2", Kind: "label", Key: "openstack-role", Op: OpAdd, NewValue: "compute"},
+		{Node: "rsb2", Kind: "label", Key: "legacy", Op: OpRemove, OldValue: "x"},
+	}}
+	vlans := &Plan{
+		Changes: []Change{{Node: "rsb2", Kind: "vlan", Key: "storage", Op: OpAdd, NewValue: "eth0.100 10.0.0.2/24"}},
+		Errors:  []error{errors.New("node rsb9 not found")},
+	}
+
+	// When: Merge
+	result := &Plan{}
+	result.Merge(labels)
+	result.Merge(vlans)
+	result.Merge(nil)
+
+	// Then: Totals, nodes and ordering are derived from all changes
+	add, change, remove := result.Counts()
+	assert.Equal(t, []int{2, 1, 1}, []int{add, change, remove})
+	assert.Equal(t, []string{"rsb2", "rsb3"}, result.Nodes())
+	assert.Len(t, result.Errors, 1)
+
+	var keys []string
+	for _, c := range result.NodeChanges("rsb2") {
+		keys = append(keys, c.Kind+"/"+c.Key)
+	}
+	assert.Equal(t, []string{"label/legacy", "label/openstack-role", "vlan/storage"}, keys)
+}
@@ -0,0 +1,298 @@
+// Package progress shows how far operations spanning many nodes have come
+package progress
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Reporter receives the progress of a multi-node operation; services call it from concurrent workers
+type Reporter interface {
+	// Begin starts a task over total units of work, e.g. node-VLAN assignments
+	Begin(task string, total int)
+
+	// Started marks a node as being processed
+	Started(node string)
+
+	// Done marks one unit of work on a node as finished
+	Done(node string, failed bool)
+
+	// End finishes the task
+	End()
+}
+
+// Nop discards progress
+type Nop struct{}
+
+// Begin implements Reporter
+func (Nop) Begin(task string, total int) {}
+
+// Started implements Reporter
+func (Nop) Started(node string) {}
+
+// Done implements Reporter
+func (Nop) Done(node string, failed bool) {}
+
+// End implements Reporter
+func (Nop) End() {}
+
+// Or returns the reporter, or Nop when it is nil
+func Or(reporter Reporter) Reporter {
+	if reporter == nil {
+		return Nop{}
+	}
+	return reporter
+}
+
+// Tee passes progress on to every reporter, skipping nil ones; it returns nil when none is left
+func Tee(reporters ...Reporter) Reporter {
+	var tee tee
+	for _, reporter := range reporters {
+		if reporter != nil {
+			tee = append(tee, reporter)
+		}
+	}
+	switch len(tee) {
+	case 0:
+		return nil
+	case 1:
+		return tee[0]
+	}
+	return tee
+}
+
+// tee is the reporter returned by Tee
+type tee []Reporter
+
+// Begin implements Reporter
+func (t tee) Begin(task string, total int) {
+	for _, reporter := range t {
+		reporter.Begin(task, total)
+	}
+}
+
+// Started implements Reporter
+func (t tee) Started(node string) {
+	for _, reporter := range t {
+		reporter.Started(node)
+	}
+}
+
+// Done implements Reporter
+func (t tee) Done(node string, failed bool) {
+	for _, reporter := range t {
+		reporter.Done(node, failed)
+	}
+}
+
+// End implements Reporter
+func (t tee) End() {
+	for _, reporter := range t {
+		reporter.End()
+	}
+}
+
+// spinnerFrames animate the progress line while a task runs
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// refreshInterval is how often the spinner advances when no node finishes
+const refreshInterval = 100 * time.Millisecond
+
+// clearLine returns the cursor to the start of the line and erases it
+const clearLine = "\r\033[K"
+
+// Terminal draws the progress of the running task on one line of a terminal
+// Log output written through it is printed above that line, so both stay readable.
+type Terminal struct {
+	mu      sync.Mutex
+	out     io.Writer
+	task    string
+	total   int
+	done    int
+	failed  int
+	current string
+	frame   int
+	drawn   bool
+	stop    chan struct{}
+	stopped sync.WaitGroup
+}
+
+// NewTerminal creates a progress line on out, which should be a terminal, see IsTerminal
+func NewTerminal(out io.Writer) *Terminal {
+	return &Terminal{out: out}
+}
+
+// IsTerminal reports whether w is a terminal rather than a file or pipe
+func IsTerminal(w io.Writer) bool {
+	file, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// Write prints log output, erasing the progress line first and drawing it again after complete lines
+func (t *Terminal) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.erase()
+	n, err := t.out.Write(p)
+	if t.stop != nil && bytes.HasSuffix(p, []byte("\n")) {
+		t.draw()
+	}
+	return n, err
+}
+
+// Begin implements Reporter, starting the spinner
+func (t *Terminal) Begin(task string, total int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.task, t.total, t.done, t.failed, t.current = task, total, 0, 0, ""
+	if t.stop == nil {
+		t.stop = make(chan struct{})
+		t.stopped.Add(1)
+		go t.spin(t.stop)
+	}
+	t.draw()
+}
+
+// Started implements Reporter
+func (t *Terminal) Started(node string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.current = node
+	t.redraw()
+}
+
+// Done implements Reporter
+func (t *Terminal) Done(node string, failed bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if failed {
+		t.failed++
+	} else {
+		t.done++
+	}
+	t.redraw()
+}
+
+// End implements Reporter, erasing the progress line
+func (t *Terminal) End() {
+	t.mu.Lock()
+	stop := t.stop
+	t.stop = nil
+	t.erase()
+	t.mu.Unlock()
+
+	if stop != nil {
+		close(stop)
+		t.stopped.Wait()
+	}
+}
+
+// spin advances the spinner until stop is closed
+func (t *Terminal) spin(stop chan struct{}) {
+	defer t.stopped.Done()
+	ticker := time.NewTicker(refreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			t.mu.Lock()
+			t.frame = (t.frame + 1) % len(spinnerFrames)
+			t.redraw()
+			t.mu.Unlock()
+		}
+	}
+}
+
+// Line renders the progress line, e.g. "⠋ Configuring VLANs 42% (21/50) · ✅ 20 ❌ 1 · rsb23"
+func (t *Terminal) Line() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.line()
+}
+
+// line renders the progress line; the caller holds the lock
+func (t *Terminal) line() string {
+	finished := t.done + t.failed
+	percent := 100
+	if t.total > 0 {
+		percent = finished * 100 / t.total
+	}
+	line := fmt.Sprintf("%s %s %d%% (%d/%d) · ✅ %d ❌ %d", spinnerFrames[t.frame], t.task, percent, finished, t.total, t.done, t.failed)
+	if t.current != "" && finished < t.total {
+		line += " · " + t.current
+	}
+	return line
+}
+
+// redraw draws the progress line again while a task runs; the caller holds the lock
+func (t *Terminal) redraw() {
+	if t.stop != nil {
+		t.erase()
+		t.draw()
+	}
+}
+
+// draw prints the progress line without a newline; the caller holds the lock
+func (t *Terminal) draw() {
+	fmt.Fprint(t.out, t.line())
+	t.drawn = true
+}
+
+// erase removes a drawn progress line; the caller holds the lock
+func (t *Terminal) erase() {
+	if t.drawn {
+		fmt.Fprint(t.out, clearLine)
+		t.drawn = false
+	}
+}
+
+// Recorder keeps the progress it receives, for tests of the services feeding it
+type Recorder struct {
+	mu        sync.Mutex
+	Tasks     []string // Each task with its total, e.g. "Configure VLANs/3"
+	Succeeded []string // Nodes finished without failure, in order
+	Failed    []string // Nodes that failed, in order
+	Ended     int      // Tasks ended
+}
+
+// Begin implements Reporter
+func (r *Recorder) Begin(task string, total int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Tasks = append(r.Tasks, fmt.Sprintf("%s/%d", task, total))
+}
+
+// Started implements Reporter
+func (r *Recorder) Started(node string) {}
+
+// Done implements Reporter
+func (r *Recorder) Done(node string, failed bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if failed {
+		r.Failed = append(r.Failed, node)
+	} else {
+		r.Succeeded = append(r.Succeeded, node)
+	}
+}
+
+// End implements Reporter
+func (r *Recorder) End() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Ended++
+}
@@ -0,0 +1,103 @@
+// Package progress provides unit tests for the progress line
+// WHY: The line shares the terminal with the log, so neither may garble the other
+package progress
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestTerminal_Line tests the counts shown on the progress line
+// WHY: Done and failed nodes must add up to the percentage, and a finished task names no current node
+func TestTerminal_Line(t *testing.T) {
+	tests := []struct {
+		name     string
+		done     []string
+		failed   []string
+		expected string
+	}{
+		{name: "started", expected: "⠋ Configure VLANs 0% (0/4) · ✅ 0 ❌ 0 · rsb2"},
+		{name: "half_done", done: []string{"rsb2"}, failed: []string{"rsb3"}, expected: "⠋ Configure VLANs 50% (2/4) · ✅ 1 ❌ 1 · rsb2"},
+		{name: "finished", done: []string{"rsb2", "rsb3", "rsb4", "rsb5"}, expected: "⠋ Configure VLANs 100% (4/4) · ✅ 4 ❌ 0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A task over four nodes, with rsb2 being processed and the spinner standing still
+			terminal := &Terminal{out: new(bytes.Buffer), task: "Configure VLANs", total: 4}
+			terminal.Started("rsb2")
+
+			// When: Nodes finish
+			for _, node := range tt.done {
+				terminal.Done(node, false)
+			}
+			for _, node := range tt.failed {
+				terminal.Done(node, true)
+			}
+
+			// Then: The line shows the counts
+			assert.Equal(t, tt.expected, terminal.Line())
+		})
+	}
+}
+
+// TestTerminal_Write tests printing log output while the progress line is drawn
+// WHY: A log line written over the progress line would be appended to it on the same terminal line
+func TestTerminal_Write(t *testing.T) {
+	// Given: A running task
+	out := new(bytes.Buffer)
+	terminal := NewTerminal(out)
+	terminal.Begin("Apply labels", 2)
+
+	// When: A log line is written and the task ends
+	_, err := terminal.Write([]byte("✅ Applied labels to rsb2\n"))
+	terminal.End()
+
+	// Then: The line is erased before the log line, drawn again after it and erased at the end
+	assert.NoError(t, err)
+	_, after, found := strings.Cut(out.String(), clearLine+"✅ Applied labels to rsb2\n")
+	assert.True(t, found)
+	assert.Contains(t, after, "Apply labels 0% (0/2)")
+	assert.True(t, strings.HasSuffix(after, clearLine))
+
+	// And: Once ended, log output passes through untouched
+	out.Reset()
+	_, err = terminal.Write([]byte("📊 Summary\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, "📊 Summary\n", out.String())
+}
+
+// TestOr tests that services may leave the reporter unset
+// WHY: Tests and runs without a terminal pass no reporter, which must not panic
+func TestOr(t *testing.T) {
+	recorder := &Recorder{}
+
+	assert.Equal(t, Nop{}, Or(nil))
+	assert.Same(t, recorder, Or(recorder))
+}
+
+// TestTee tests passing progress on to several reporters
+// WHY: The progress line and the per-node summary table both follow the same services
+func TestTee(t *testing.T) {
+	// Given: Two recorders teed together with a missing reporter
+	first, second := &Recorder{}, &Recorder{}
+	reporter := Tee(first, nil, second)
+
+	// When: A task reports progress
+	reporter.Begin("Configure VLANs", 1)
+	reporter.Started("rsb2")
+	reporter.Done("rsb2", true)
+	reporter.End()
+
+	// Then: Both recorders receive it, and a single or no reporter is not wrapped
+	for _, recorder := range []*Recorder{first, second} {
+		assert.Equal(t, []string{"Configure VLANs/1"}, recorder.Tasks)
+		assert.Equal(t, []string{"rsb2"}, recorder.Failed)
+		assert.Equal(t, 1, recorder.Ended)
+	}
+	assert.Same(t, first, Tee(nil, first))
+	assert.Nil(t, Tee(nil))
+}
@@ -0,0 +1,143 @@
+// Package quarantine takes nodes that keep failing the same operation out of kictl runs until an operator releases them
+// A quarantined node carries Label, so runs can skip it, and ReasonAnnotation saying which failures put it there.
+package quarantine
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"k8ostack-ictl/internal/kubectl"
+)
+
+// Label marks a quarantined node; Selector selects the quarantined nodes
+const (
+	Label    = "kictl.icycloud.io/quarantined"
+	Selector = Label + "=true"
+)
+
+// ReasonAnnotation holds why a node was quarantined
+const ReasonAnnotation = "kictl.icycloud.io/quarantine-reason"
+
+// maxReasonLength keeps the reason annotation readable in kubectl describe
+const maxReasonLength = 512
+
+// webhookTimeout bounds a notification, which must not hold up the end of a run
+const webhookTimeout = 10 * time.Second
+
+// Failure is one of the failures of a node that led to its quarantine
+type Failure struct {
+	RunID     string    `json:"runId"`
+	StartedAt time.Time `json:"startedAt"`
+	Error     string    `json:"error"`
+}
+
+// Diagnostics is what was collected from a node when it was quarantined
+type Diagnostics struct {
+	Failures      []Failure `json:"failures"`                // The consecutive failures, newest first
+	Network       string    `json:"network,omitempty"`       // Interfaces and routes of the node
+	Hardware      string    `json:"hardware,omitempty"`      // CPU, memory and PCI devices of the node
+	CollectErrors []string  `json:"collectErrors,omitempty"` // Diagnostics that could not be collected
+}
+
+// Notice reports a quarantined node, written to the workspace and posted to the policy's webhook
+type Notice struct {
+	Node          string      `json:"node"`
+	Service       string      `json:"service"`   // Kind of the configuration failing, e.g. NodeVLANConf
+	Operation     string      `json:"operation"` // apply or delete
+	Reason        string      `json:"reason"`
+	QuarantinedAt time.Time   `json:"quarantinedAt"`
+	Diagnostics   Diagnostics `json:"diagnostics"`
+}
+
+// Reason describes consecutive failures of an operation of a service, ending with the latest error
+func Reason(service, operation string, failures []Failure) string {
+	reason := fmt.Sprintf("%s %s failed in %d runs in a row", service, operation, len(failures))
+	if len(failures) > 0 && failures[0].Error != "" {
+		reason += ": " + failures[0].Error
+	}
+	if runes := []rune(reason); len(runes) > maxReasonLength {
+		reason = string(runes[:maxReasonLength-1]) + "…"
+	}
+	return reason
+}
+
+// Quarantine labels a node as quarantined and records the reason on it
+// run is the kubectl of the executor selecting the cluster.
+func Quarantine(ctx context.Context, node, reason string, run kubectl.Runner) error {
+	if err := kubectlNode(ctx, run, "label", node, Label+"=true"); err != nil {
+		return fmt.Errorf("failed to quarantine node %s: %w", node, err)
+	}
+	if err := kubectlNode(ctx, run, "annotate", node, ReasonAnnotation+"="+reason); err != nil {
+		return fmt.Errorf("failed to record why node %s is quarantined: %w", node, err)
+	}
+	return nil
+}
+
+// Release removes the quarantine label and reason from a node
+func Release(ctx context.Context, node string, run kubectl.Runner) error {
+	if err := kubectlNode(ctx, run, "label", node, Label+"-"); err != nil {
+		return fmt.Errorf("failed to release node %s: %w", node, err)
+	}
+	if err := kubectlNode(ctx, run, "annotate", node, ReasonAnnotation+"-"); err != nil {
+		return fmt.Errorf("failed to remove the quarantine reason of node %s: %w", node, err)
+	}
+	return nil
+}
+
+// ReasonFromAnnotations returns the quarantine reason in the annotations of a node, as kubectl prints them in JSON
+func ReasonFromAnnotations(annotations string) (string, error) {
+	if strings.TrimSpace(annotations) == "" {
+		return "", nil
+	}
+	var values map[string]string
+	if err := json.Unmarshal([]byte(annotations), &values); err != nil {
+		return "", fmt.Errorf("failed to parse node annotations: %w", err)
+	}
+	return values[ReasonAnnotation], nil
+}
+
+// Notify posts a notice as JSON to a webhook; a nil client uses one with a short timeout
+func Notify(ctx context.Context, client *http.Client, webhook string, notice Notice) error {
+	if client == nil {
+		client = &http.Client{Timeout: webhookTimeout}
+	}
+	body, err := json.Marshal(notice)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to notify %s: %w", webhook, err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("quarantine webhook %s answered %s", webhook, resp.Status)
+	}
+	return nil
+}
+
+// kubectlNode runs kubectl label or annotate on a node, overwriting the existing value
+func kubectlNode(ctx context.Context, run kubectl.Runner, verb, node, change string) error {
+	args := []string{verb, "node", node, "--overwrite", change}
+	if output, err := run(ctx, nil, args...); err != nil {
+		if message := strings.TrimSpace(output); message != "" {
+			return errors.New(message)
+		}
+		return err
+	}
+	return nil
+}
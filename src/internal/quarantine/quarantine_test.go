@@ -0,0 +1,151 @@
+// Package quarantine provides unit tests for quarantining and releasing nodes
+// WHY: A node quarantined without its reason, or never released, is a node nobody knows how to get back
+package quarantine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestReason tests the reason recorded on a quarantined node
+// WHY: The annotation must name the failing service and its latest error, without growing without bound
+func TestReason(t *testing.T) {
+	failures := []Failure{{RunID: "run-3", Error: "ip link add failed"}, {RunID: "run-2"}, {RunID: "run-1"}}
+
+	assert.Equal(t, "NodeVLANConf apply failed in 3 runs in a row: ip link add failed", Reason("NodeVLANConf", "apply", failures))
+
+	long := Reason("NodeVLANConf", "apply", []Failure{{Error: strings.Repeat("x", 1000)}})
+	assert.Len(t, []rune(long), maxReasonLength)
+	assert.True(t, strings.HasSuffix(long, "…"))
+}
+
+// TestQuarantine tests labeling and releasing a node
+// WHY: Runs skip nodes by the label, and operators read the reason from the annotation
+func TestQuarantine(t *testing.T) {
+	tests := []struct {
+		name         string
+		release      bool
+		runErr       error
+		expectedArgs [][]string
+		expectError  string
+	}{
+		{
+			name: "quarantine",
+			expectedArgs: [][]string{
+				{"label", "node", "rsb3", "--overwrite", "kictl.icycloud.io/quarantined=true"},
+				{"annotate", "node", "rsb3", "--overwrite", "kictl.icycloud.io/quarantine-reason=NodeVLANConf apply failed in 3 runs in a row"},
+			},
+		},
+		{
+			name:    "release",
+			release: true,
+			expectedArgs: [][]string{
+				{"label", "node", "rsb3", "--overwrite", "kictl.icycloud.io/quarantined-"},
+				{"annotate", "node", "rsb3", "--overwrite", "kictl.icycloud.io/quarantine-reason-"},
+			},
+		},
+		{
+			name:        "label_fails",
+			runErr:      errors.New("exit status 1"),
+			expectError: "failed to quarantine node rsb3: nodes \"rsb3\" is forbidden",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A kubectl recording its arguments
+			var calls [][]string
+			run := func(ctx context.Context, stdin []byte, args ...string) (string, error) {
+				calls = append(calls, args)
+				if tt.runErr != nil {
+					return "nodes \"rsb3\" is forbidden\n", tt.runErr
+				}
+				return "", nil
+			}
+
+			// When: Quarantine or release rsb3
+			var err error
+			if tt.release {
+				err = Release(context.Background(), "rsb3", run)
+			} else {
+				err = Quarantine(context.Background(), "rsb3", "NodeVLANConf apply failed in 3 runs in a row", run)
+			}
+
+			// Then: The label and annotation change together, or the first failure is reported
+			if tt.expectError != "" {
+				assert.EqualError(t, err, tt.expectError)
+				assert.Len(t, calls, 1)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedArgs, calls)
+		})
+	}
+}
+
+// TestReasonFromAnnotations tests reading the reason of a quarantined node
+// WHY: Nodes quarantined by hand have a label but no reason, which must not be an error
+func TestReasonFromAnnotations(t *testing.T) {
+	reason, err := ReasonFromAnnotations(`{"kictl.icycloud.io/quarantine-reason":"NodeVLANConf apply failed in 3 runs in a row"}`)
+	require.NoError(t, err)
+	assert.Equal(t, "NodeVLANConf apply failed in 3 runs in a row", reason)
+
+	reason, err = ReasonFromAnnotations("")
+	require.NoError(t, err)
+	assert.Empty(t, reason)
+
+	_, err = ReasonFromAnnotations("not json")
+	assert.ErrorContains(t, err, "failed to parse node annotations")
+}
+
+// TestNotify tests posting a notice to the webhook
+// WHY: The notice is how someone learns a node left the rollout, so a rejected post must be reported
+func TestNotify(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		expectError string
+	}{
+		{name: "accepted", status: http.StatusAccepted},
+		{name: "rejected", status: http.StatusBadRequest, expectError: "answered 400 Bad Request"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A webhook answering with the status of the case
+			var received Notice
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+				assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+			notice := Notice{
+				Node: "rsb3", Service: "NodeVLANConf", Operation: "apply", Reason: "NodeVLANConf apply failed in 3 runs in a row",
+				QuarantinedAt: time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC),
+				Diagnostics:   Diagnostics{Failures: []Failure{{RunID: "run-3", Error: "ip link add failed"}}, Network: "2: eth0: <UP>"},
+			}
+
+			// When: Notify it
+			err := Notify(context.Background(), server.Client(), server.URL, notice)
+
+			// Then: The whole notice arrives, and a rejection is an error
+			if tt.expectError != "" {
+				assert.ErrorContains(t, err, tt.expectError)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, notice.Diagnostics.Failures[0].RunID, received.Diagnostics.Failures[0].RunID)
+			assert.Equal(t, notice.Reason, received.Reason)
+		})
+	}
+}
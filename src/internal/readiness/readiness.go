@@ -0,0 +1,90 @@
+// Package readiness publishes verification results as a node condition, so controllers can keep workloads
+// off nodes whose network configuration has not been verified
+package readiness
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"k8ostack-ictl/internal/kubectl"
+)
+
+// ConditionType is the node condition kictl verify sets
+const ConditionType = "kictl.icycloud.io/NetworkVerified"
+
+// Reasons of the condition
+const (
+	ReasonVerified = "Verified" // the node matches the bundle
+	ReasonDeviates = "Deviates" // the node deviates from the bundle
+)
+
+// Result is the verification result of one node
+type Result struct {
+	Node     string
+	Verified bool
+	Message  string
+}
+
+// Condition is a node condition as the API server stores it
+type Condition struct {
+	Type               string `json:"type"`
+	Status             string `json:"status"`
+	Reason             string `json:"reason,omitempty"`
+	Message            string `json:"message,omitempty"`
+	LastHeartbeatTime  string `json:"lastHeartbeatTime,omitempty"`
+	LastTransitionTime string `json:"lastTransitionTime,omitempty"`
+}
+
+// NewCondition builds the condition of a result at now, keeping the transition time of the previous condition
+// when its status is unchanged
+func NewCondition(result Result, previous *Condition, now time.Time) Condition {
+	condition := Condition{
+		Type:              ConditionType,
+		Status:            "False",
+		Reason:            ReasonDeviates,
+		Message:           result.Message,
+		LastHeartbeatTime: now.UTC().Format(time.RFC3339),
+	}
+	if result.Verified {
+		condition.Status, condition.Reason = "True", ReasonVerified
+	}
+	condition.LastTransitionTime = condition.LastHeartbeatTime
+	if previous != nil && previous.Status == condition.Status && previous.LastTransitionTime != "" {
+		condition.LastTransitionTime = previous.LastTransitionTime
+	}
+	return condition
+}
+
+// Publish sets the condition of a result on the status of its node
+// run is the kubectl of the executor selecting the cluster.
+func Publish(ctx context.Context, result Result, now time.Time, run kubectl.Runner) error {
+	jsonPath := fmt.Sprintf(`jsonpath={.status.conditions[?(@.type=="%s")]}`, ConditionType)
+	args := []string{"get", "node", result.Node, "-o", jsonPath}
+	output, err := run(ctx, nil, args...)
+	if err != nil {
+		return fmt.Errorf("failed to read condition %s of node %s: %s", ConditionType, result.Node, strings.TrimSpace(output))
+	}
+	var previous *Condition
+	if strings.TrimSpace(output) != "" {
+		previous = &Condition{}
+		if err := json.Unmarshal([]byte(output), previous); err != nil {
+			return fmt.Errorf("failed to parse condition %s of node %s: %w", ConditionType, result.Node, err)
+		}
+	}
+
+	// Node conditions merge by type, so the patch leaves the kubelet's conditions alone
+	patch, err := json.Marshal(map[string]interface{}{
+		"status": map[string]interface{}{"conditions": []Condition{NewCondition(result, previous, now)}},
+	})
+	if err != nil {
+		return err
+	}
+	args = []string{"patch", "node", result.Node, "--subresource=status", "--type=strategic", "-p", string(patch)}
+	if output, err := run(ctx, nil, args...); err != nil {
+		return fmt.Errorf("failed to set condition %s of node %s: %s", ConditionType, result.Node, strings.TrimSpace(output))
+	}
+	return nil
+}
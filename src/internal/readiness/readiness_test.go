@@ -0,0 +1,114 @@
+// Package readiness provides unit tests for the verification node condition
+// WHY: Controllers gate workloads on this condition, so its status and transition time must be exact
+package readiness
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNewCondition tests the condition built from a verification result
+// WHY: The transition time marks when the node last changed state, not when it was last verified
+func TestNewCondition(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	earlier := "2026-10-01T08:00:00Z"
+
+	tests := []struct {
+		name     string
+		result   Result
+		previous *Condition
+		expected Condition
+	}{
+		{
+			name:   "first_verification",
+			result: Result{Node: "rsb2", Verified: true, Message: "matches the bundle"},
+			expected: Condition{Type: ConditionType, Status: "True", Reason: ReasonVerified, Message: "matches the bundle",
+				LastHeartbeatTime: "2026-10-16T12:00:00Z", LastTransitionTime: "2026-10-16T12:00:00Z"},
+		},
+		{
+			name:     "still_verified",
+			result:   Result{Node: "rsb2", Verified: true},
+			previous: &Condition{Type: ConditionType, Status: "True", LastTransitionTime: earlier},
+			expected: Condition{Type: ConditionType, Status: "True", Reason: ReasonVerified,
+				LastHeartbeatTime: "2026-10-16T12:00:00Z", LastTransitionTime: earlier},
+		},
+		{
+			name:     "starts_deviating",
+			result:   Result{Node: "rsb2", Message: "VLAN interfaces do not match the configuration"},
+			previous: &Condition{Type: ConditionType, Status: "True", LastTransitionTime: earlier},
+			expected: Condition{Type: ConditionType, Status: "False", Reason: ReasonDeviates, Message: "VLAN interfaces do not match the configuration",
+				LastHeartbeatTime: "2026-10-16T12:00:00Z", LastTransitionTime: "2026-10-16T12:00:00Z"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, NewCondition(tt.result, tt.previous, now))
+		})
+	}
+}
+
+// TestPublish tests the kubectl calls setting the condition on a node
+// WHY: Only the status subresource may be patched, and a failed read must not overwrite the condition
+func TestPublish(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		current       string
+		getErr        error
+		expectedPatch string
+		expectError   string
+	}{
+		{
+			name:          "no_condition_yet",
+			expectedPatch: `{"status":{"conditions":[{"type":"kictl.icycloud.io/NetworkVerified","status":"True","reason":"Verified","lastHeartbeatTime":"2026-10-16T12:00:00Z","lastTransitionTime":"2026-10-16T12:00:00Z"}]}}`,
+		},
+		{
+			name:          "keeps_transition_time",
+			current:       `{"type":"kictl.icycloud.io/NetworkVerified","status":"True","lastTransitionTime":"2026-10-01T08:00:00Z"}`,
+			expectedPatch: `{"status":{"conditions":[{"type":"kictl.icycloud.io/NetworkVerified","status":"True","reason":"Verified","lastHeartbeatTime":"2026-10-16T12:00:00Z","lastTransitionTime":"2026-10-01T08:00:00Z"}]}}`,
+		},
+		{
+			name:        "read_fails",
+			getErr:      errors.New("exit status 1"),
+			expectError: "failed to read condition kictl.icycloud.io/NetworkVerified of node rsb2: nodes \"rsb2\" is forbidden",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A node with the current condition of the case
+			var calls [][]string
+			run := func(ctx context.Context, stdin []byte, args ...string) (string, error) {
+				calls = append(calls, args)
+				if args[0] == "get" {
+					if tt.getErr != nil {
+						return "nodes \"rsb2\" is forbidden\n", tt.getErr
+					}
+					return tt.current, nil
+				}
+				return "node/rsb2 patched", nil
+			}
+
+			// When: Publish a verified result
+			err := Publish(context.Background(), Result{Node: "rsb2", Verified: true}, now, run)
+
+			// Then: The status of the node is patched with the condition
+			if tt.expectError != "" {
+				assert.EqualError(t, err, tt.expectError)
+				assert.Len(t, calls, 1)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, calls, 2)
+			assert.Equal(t, []string{"get", "node", "rsb2", "-o", `jsonpath={.status.conditions[?(@.type=="kictl.icycloud.io/NetworkVerified")]}`}, calls[0])
+			assert.Equal(t, []string{"patch", "node", "rsb2", "--subresource=status", "--type=strategic", "-p", tt.expectedPatch}, calls[1])
+		})
+	}
+}
@@ -0,0 +1,121 @@
+// Package revision pins nodes to the revision of the bundle last applied to them and reports nodes left behind
+// The revision is the SHA-256 digest of the configuration, so every edit of the bundle is a new revision.
+package revision
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8ostack-ictl/internal/kubectl"
+)
+
+// AnnotationKey holds the bundle revision of nodes changed by bundles without an owner
+const AnnotationKey = "kictl.icycloud.io/revision"
+
+// OwnerAnnotationPrefix prefixes the revision annotation of each owner, so bundles sharing nodes pin them independently
+const OwnerAnnotationPrefix = "revision.kictl.icycloud.io/"
+
+// Key returns the revision annotation of a bundle owner, or AnnotationKey when the bundle has none
+func Key(owner string) string {
+	if owner == "" {
+		return AnnotationKey
+	}
+	return OwnerAnnotationPrefix + owner
+}
+
+// Short abbreviates a revision for display, e.g. sha256:9f86d081884c
+func Short(revision string) string {
+	if algorithm, digest, ok := strings.Cut(revision, ":"); ok && len(digest) > 12 {
+		return algorithm + ":" + digest[:12]
+	}
+	return revision
+}
+
+// FromAnnotations returns the revision under key in the annotations of a node, as kubectl prints them in JSON
+// A node never pinned has no revision.
+func FromAnnotations(annotations, key string) (string, error) {
+	if strings.TrimSpace(annotations) == "" {
+		return "", nil
+	}
+	var values map[string]string
+	if err := json.Unmarshal([]byte(annotations), &values); err != nil {
+		return "", fmt.Errorf("failed to parse node annotations: %w", err)
+	}
+	return values[key], nil
+}
+
+// Group is the nodes on one revision
+type Group struct {
+	Revision string // empty for nodes never pinned
+	Nodes    []string
+}
+
+// Skew groups the nodes of a bundle by their revision
+type Skew struct {
+	Current string
+	Groups  []Group // the current revision first, then other revisions by node count, then nodes never pinned
+}
+
+// NewSkew groups nodes by revision (node -> revision) against the current revision of the bundle
+func NewSkew(current string, revisions map[string]string) *Skew {
+	nodes := make(map[string][]string)
+	for node, revision := range revisions {
+		nodes[revision] = append(nodes[revision], node)
+	}
+
+	skew := &Skew{Current: current}
+	for revision, names := range nodes {
+		sort.Strings(names)
+		skew.Groups = append(skew.Groups, Group{Revision: revision, Nodes: names})
+	}
+	rank := func(group Group) int {
+		switch group.Revision {
+		case current:
+			return 0
+		case "":
+			return 2
+		default:
+			return 1
+		}
+	}
+	sort.Slice(skew.Groups, func(i, j int) bool {
+		a, b := skew.Groups[i], skew.Groups[j]
+		if rank(a) != rank(b) {
+			return rank(a) < rank(b)
+		}
+		if len(a.Nodes) != len(b.Nodes) {
+			return len(a.Nodes) > len(b.Nodes)
+		}
+		return a.Revision < b.Revision
+	})
+	return skew
+}
+
+// Stragglers returns the nodes not on the current revision, sorted
+func (s *Skew) Stragglers() []string {
+	var stragglers []string
+	for _, group := range s.Groups {
+		if group.Revision != s.Current {
+			stragglers = append(stragglers, group.Nodes...)
+		}
+	}
+	sort.Strings(stragglers)
+	return stragglers
+}
+
+// Pin records the revision under key on a node, removing the annotation when the revision is empty
+// run is the kubectl of the executor selecting the cluster.
+func Pin(ctx context.Context, node, key, revision string, run kubectl.Runner) error {
+	annotation := key + "-"
+	if revision != "" {
+		annotation = key + "=" + revision
+	}
+	args := []string{"annotate", "node", node, "--overwrite", annotation}
+	if output, err := run(ctx, nil, args...); err != nil {
+		return fmt.Errorf("failed to pin node %s to revision %s: %s", node, Short(revision), strings.TrimSpace(output))
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+// Package revision provides unit tests for bundle revision pins
+// WHY: Operators rely on the skew report to find nodes an interrupted or narrowed rollout left behind
+package revision
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	currentRevision = "sha256:2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae"
+	olderRevision   = "sha256:fcde2b2edba56bf408601fb721fe9b5c338d10ee429ea04fae5511b68fbf8fb9"
+)
+
+// TestFromAnnotations tests reading the revision of a bundle from node annotations
+// WHY: Each owner has its own pin, and a node without one was never brought to any revision
+func TestFromAnnotations(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations string
+		key         string
+		expected    string
+		expectError string
+	}{
+		{name: "no_annotations", annotations: "", key: Key(""), expected: ""},
+		{
+			name:        "unowned_bundle",
+			annotations: `{"kictl.icycloud.io/revision":"` + currentRevision + `","revision.kictl.icycloud.io/team-a":"` + olderRevision + `"}`,
+			key:         Key(""),
+			expected:    currentRevision,
+		},
+		{
+			name:        "owner",
+			annotations: `{"kictl.icycloud.io/revision":"` + currentRevision + `","revision.kictl.icycloud.io/team-a":"` + olderRevision + `"}`,
+			key:         Key("team-a"),
+			expected:    olderRevision,
+		},
+		{name: "not_pinned", annotations: `{"node.alpha.kubernetes.io/ttl":"0"}`, key: Key(""), expected: ""},
+		{name: "invalid_json", annotations: "node/rsb2", key: Key(""), expectError: "failed to parse node annotations"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FromAnnotations(tt.annotations, tt.key)
+
+			if tt.expectError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+// TestNewSkew tests grouping nodes by revision
+// WHY: The current revision leads the report and every other node is a straggler, including nodes never pinned
+func TestNewSkew(t *testing.T) {
+	// Given: Nodes on the current revision, an older one and none
+	revisions := map[string]string{
+		"rsb2": currentRevision,
+		"rsb3": olderRevision,
+		"rsb4": "",
+		"rsb5": currentRevision,
+	}
+
+	// When: Group them
+	skew := NewSkew(currentRevision, revisions)
+
+	// Then: Groups are ordered current, older, never applied
+	assert.Equal(t, []Group{
+		{Revision: currentRevision, Nodes: []string{"rsb2", "rsb5"}},
+		{Revision: olderRevision, Nodes: []string{"rsb3"}},
+		{Revision: "", Nodes: []string{"rsb4"}},
+	}, skew.Groups)
+	assert.Equal(t, []string{"rsb3", "rsb4"}, skew.Stragglers())
+	assert.Equal(t, "sha256:2c26b46b68ff", Short(currentRevision))
+}
+
+// TestPin tests writing and removing the revision pin of a node
+// WHY: A delete must leave no pin behind, or the node would look up to date with a bundle no longer on it
+func TestPin(t *testing.T) {
+	tests := []struct {
+		name         string
+		revision     string
+		runErr       error
+		expectedArgs []string
+		expectError  string
+	}{
+		{
+			name:         "pin",
+			revision:     currentRevision,
+			expectedArgs: []string{"annotate", "node", "rsb2", "--overwrite", "kictl.icycloud.io/revision=" + currentRevision},
+		},
+		{
+			name:         "unpin",
+			expectedArgs: []string{"annotate", "node", "rsb2", "--overwrite", "kictl.icycloud.io/revision-"},
+		},
+		{
+			name:        "annotate_fails",
+			revision:    currentRevision,
+			runErr:      errors.New("exit status 1"),
+			expectError: "failed to pin node rsb2 to revision sha256:2c26b46b68ff: nodes \"rsb2\" is forbidden",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var args []string
+			err := Pin(context.Background(), "rsb2", Key(""), tt.revision,
+				func(ctx context.Context, stdin []byte, kubectlArgs ...string) (string, error) {
+					args = kubectlArgs
+					if tt.runErr != nil {
+						return "nodes \"rsb2\" is forbidden\n", tt.runErr
+					}
+					return "node/rsb2 annotated", nil
+				})
+
+			if tt.expectError != "" {
+				assert.EqualError(t, err, tt.expectError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedArgs, args)
+		})
+	}
+}
@@ -0,0 +1,318 @@
+// Package rundb keeps a local SQLite database of runs, their per-node results and timings for trend analysis
+package rundb
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite" // registers the pure-Go "sqlite" driver
+)
+
+// Run outcomes
+const (
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+// schema creates the tables on first use; later versions must only add to it
+const schema = `
+CREATE TABLE IF NOT EXISTS runs (
+	id          TEXT PRIMARY KEY,
+	operation   TEXT NOT NULL,
+	config_file TEXT NOT NULL DEFAULT '',
+	started_at  TEXT NOT NULL,
+	duration_ms INTEGER NOT NULL,
+	dry_run     INTEGER NOT NULL DEFAULT 0,
+	status      TEXT NOT NULL,
+	error       TEXT NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS runs_started_at ON runs (started_at);
+CREATE TABLE IF NOT EXISTS service_timings (
+	run_id      TEXT NOT NULL REFERENCES runs (id) ON DELETE CASCADE,
+	service     TEXT NOT NULL,
+	duration_ms INTEGER NOT NULL,
+	errors      INTEGER NOT NULL DEFAULT 0
+);
+CREATE TABLE IF NOT EXISTS node_results (
+	run_id      TEXT NOT NULL REFERENCES runs (id) ON DELETE CASCADE,
+	service     TEXT NOT NULL,
+	node        TEXT NOT NULL,
+	status      TEXT NOT NULL,
+	error       TEXT NOT NULL DEFAULT '',
+	duration_ms INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS node_results_node ON node_results (node);
+`
+
+// Run is one recorded kictl operation
+type Run struct {
+	ID         string
+	Operation  string
+	ConfigFile string
+	StartedAt  time.Time
+	Duration   time.Duration
+	DryRun     bool
+	Status     string // StatusSucceeded or StatusFailed
+	Error      string
+	Services   []ServiceTiming
+	Nodes      []NodeResult
+}
+
+// ServiceTiming is how long one service of a run took and how many errors it reported
+type ServiceTiming struct {
+	Service  string // configuration kind, e.g. NodeVLANConf
+	Duration time.Duration
+	Errors   int
+}
+
+// NodeResult is the outcome of one service on one node
+type NodeResult struct {
+	Service  string
+	Node     string
+	Status   string // StatusSucceeded or StatusFailed
+	Error    string
+	Duration time.Duration // zero when the service does not time nodes
+}
+
+// NodeRun is the result of a service on a node in one run, as NodeHistory returns it
+type NodeRun struct {
+	RunID     string
+	StartedAt time.Time
+	Status    string
+	Error     string
+}
+
+// ListFilter narrows the runs ListRuns returns
+type ListFilter struct {
+	Operation string // only runs of this operation
+	Node      string // only runs with a result on this node
+	Failed    bool   // only failed runs
+	Limit     int    // at most this many, newest first; 0 for all
+}
+
+// DB is an open run database
+type DB struct {
+	db *sql.DB
+}
+
+// Open opens the run database at path, creating it and its tables if needed
+func Open(path string) (*DB, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create run database directory: %w", err)
+	}
+	db, err := sql.Open("sqlite", path+"?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open run database %s: %w", path, err)
+	}
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize run database %s: %w", path, err)
+	}
+	return &DB{db: db}, nil
+}
+
+// Close closes the database
+func (d *DB) Close() error {
+	return d.db.Close()
+}
+
+// SaveRun stores a run with its service timings and node results, replacing an earlier record with the same ID
+func (d *DB) SaveRun(run Run) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM runs WHERE id = ?`, run.ID); err != nil {
+		return fmt.Errorf("failed to replace run %s: %w", run.ID, err)
+	}
+	if _, err := tx.Exec(`INSERT INTO runs (id, operation, config_file, started_at, duration_ms, dry_run, status, error) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		run.ID, run.Operation, run.ConfigFile, run.StartedAt.UTC().Format(time.RFC3339Nano), run.Duration.Milliseconds(), run.DryRun, run.Status, run.Error); err != nil {
+		return fmt.Errorf("failed to save run %s: %w", run.ID, err)
+	}
+	for _, service := range run.Services {
+		if _, err := tx.Exec(`INSERT INTO service_timings (run_id, service, duration_ms, errors) VALUES (?, ?, ?, ?)`,
+			run.ID, service.Service, service.Duration.Milliseconds(), service.Errors); err != nil {
+			return fmt.Errorf("failed to save timing of %s: %w", service.Service, err)
+		}
+	}
+	for _, node := range run.Nodes {
+		if _, err := tx.Exec(`INSERT INTO node_results (run_id, service, node, status, error, duration_ms) VALUES (?, ?, ?, ?, ?, ?)`,
+			run.ID, node.Service, node.Node, node.Status, node.Error, node.Duration.Milliseconds()); err != nil {
+			return fmt.Errorf("failed to save result of node %s: %w", node.Node, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// ListRuns returns the runs matching a filter, newest first, without their timings and node results
+func (d *DB) ListRuns(filter ListFilter) ([]Run, error) {
+	query := `SELECT id, operation, config_file, started_at, duration_ms, dry_run, status, error FROM runs`
+	var conditions []string
+	var args []interface{}
+	if filter.Operation != "" {
+		conditions = append(conditions, "operation = ?")
+		args = append(args, filter.Operation)
+	}
+	if filter.Node != "" {
+		conditions = append(conditions, "id IN (SELECT run_id FROM node_results WHERE node = ?)")
+		args = append(args, filter.Node)
+	}
+	if filter.Failed {
+		conditions = append(conditions, "status = ?")
+		args = append(args, StatusFailed)
+	}
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY started_at DESC, id DESC"
+	if filter.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", filter.Limit)
+	}
+
+	rows, err := d.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list runs: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []Run
+	for rows.Next() {
+		run, err := scanRun(rows)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
+
+// GetRun returns a run with its timings and node results, by ID or unique ID prefix
+func (d *DB) GetRun(id string) (*Run, error) {
+	rows, err := d.db.Query(`SELECT id, operation, config_file, started_at, duration_ms, dry_run, status, error FROM runs WHERE id = ? OR id LIKE ? ESCAPE '\' ORDER BY id LIMIT 2`,
+		id, escapeLike(id)+"%")
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up run %s: %w", id, err)
+	}
+	var matches []Run
+	for rows.Next() {
+		run, err := scanRun(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		matches = append(matches, run)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case len(matches) == 0:
+		return nil, fmt.Errorf("no run %s in the run database", id)
+	case len(matches) > 1 && matches[0].ID != id:
+		return nil, fmt.Errorf("run ID prefix %s is ambiguous", id)
+	}
+	run := matches[0]
+
+	if err := d.loadServices(&run); err != nil {
+		return nil, err
+	}
+	if err := d.loadNodes(&run); err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// NodeHistory returns the latest results of a service on a node in runs of an operation that changed something,
+// newest first and at most limit of them
+func (d *DB) NodeHistory(node, service, operation string, limit int) ([]NodeRun, error) {
+	rows, err := d.db.Query(`SELECT r.id, r.started_at, n.status, n.error FROM node_results n JOIN runs r ON r.id = n.run_id
+		WHERE n.node = ? AND n.service = ? AND r.operation = ? AND r.dry_run = 0
+		ORDER BY r.started_at DESC, r.id DESC LIMIT ?`, node, service, operation, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the history of node %s: %w", node, err)
+	}
+	defer rows.Close()
+
+	var history []NodeRun
+	for rows.Next() {
+		var result NodeRun
+		var startedAt string
+		if err := rows.Scan(&result.RunID, &startedAt, &result.Status, &result.Error); err != nil {
+			return nil, err
+		}
+		if result.StartedAt, err = time.Parse(time.RFC3339Nano, startedAt); err != nil {
+			return nil, errors.New("run " + result.RunID + " has an invalid start time: " + startedAt)
+		}
+		history = append(history, result)
+	}
+	return history, rows.Err()
+}
+
+// loadServices reads the service timings of a run in the order they ran
+func (d *DB) loadServices(run *Run) error {
+	rows, err := d.db.Query(`SELECT service, duration_ms, errors FROM service_timings WHERE run_id = ? ORDER BY rowid`, run.ID)
+	if err != nil {
+		return fmt.Errorf("failed to read timings of run %s: %w", run.ID, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var service ServiceTiming
+		var durationMS int64
+		if err := rows.Scan(&service.Service, &durationMS, &service.Errors); err != nil {
+			return err
+		}
+		service.Duration = time.Duration(durationMS) * time.Millisecond
+		run.Services = append(run.Services, service)
+	}
+	return rows.Err()
+}
+
+// loadNodes reads the node results of a run, by service and node
+func (d *DB) loadNodes(run *Run) error {
+	rows, err := d.db.Query(`SELECT service, node, status, error, duration_ms FROM node_results WHERE run_id = ? ORDER BY rowid`, run.ID)
+	if err != nil {
+		return fmt.Errorf("failed to read node results of run %s: %w", run.ID, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var node NodeResult
+		var durationMS int64
+		if err := rows.Scan(&node.Service, &node.Node, &node.Status, &node.Error, &durationMS); err != nil {
+			return err
+		}
+		node.Duration = time.Duration(durationMS) * time.Millisecond
+		run.Nodes = append(run.Nodes, node)
+	}
+	return rows.Err()
+}
+
+// scanRun reads one row of the runs table
+func scanRun(rows *sql.Rows) (Run, error) {
+	var run Run
+	var startedAt string
+	var durationMS int64
+	if err := rows.Scan(&run.ID, &run.Operation, &run.ConfigFile, &startedAt, &durationMS, &run.DryRun, &run.Status, &run.Error); err != nil {
+		return Run{}, err
+	}
+	started, err := time.Parse(time.RFC3339Nano, startedAt)
+	if err != nil {
+		return Run{}, errors.New("run " + run.ID + " has an invalid start time: " + startedAt)
+	}
+	run.StartedAt = started
+	run.Duration = time.Duration(durationMS) * time.Millisecond
+	return run, nil
+}
+
+// escapeLike escapes the LIKE wildcards of a literal prefix
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
@@ -0,0 +1,148 @@
+// Package rundb provides unit tests for the run database
+// WHY: Trend analysis reads back what runs stored, so filters and lookups must return exactly the matching runs
+package rundb
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// openTestDB opens a fresh run database that is closed with the test
+func openTestDB(t *testing.T) *DB {
+	db, err := Open(filepath.Join(t.TempDir(), "kictl", "runs.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// TestDB_SaveAndGetRun tests the round trip of a run with its timings and node results
+// WHY: runs show must present a run exactly as it was recorded
+func TestDB_SaveAndGetRun(t *testing.T) {
+	// Given: A failed apply with two services
+	db := openTestDB(t)
+	run := Run{
+		ID:         "20261016-101500-apply-1",
+		Operation:  "apply",
+		ConfigFile: "cluster-config.yaml",
+		StartedAt:  time.Date(2026, 10, 16, 10, 15, 0, 0, time.UTC),
+		Duration:   93 * time.Second,
+		Status:     StatusFailed,
+		Error:      "operation completed with 1 errors",
+		Services: []ServiceTiming{
+			{Service: "NodeLabelConf", Duration: 4 * time.Second},
+			{Service: "NodeVLANConf", Duration: 89 * time.Second, Errors: 1},
+		},
+		Nodes: []NodeResult{
+			{Service: "NodeLabelConf", Node: "rsb2", Status: StatusSucceeded},
+			{Service: "NodeVLANConf", Node: "rsb2", Status: StatusFailed, Error: "node rsb2: ip link add failed"},
+		},
+	}
+
+	// When: Save it, save it again, and read it back by a prefix of its ID
+	require.NoError(t, db.SaveRun(run))
+	require.NoError(t, db.SaveRun(run))
+	got, err := db.GetRun("20261016-101500-app")
+
+	// Then: The run comes back once, with its timings and node results in order
+	require.NoError(t, err)
+	assert.Equal(t, run, *got)
+}
+
+// TestDB_GetRun_Lookup tests run lookups that cannot be answered
+// WHY: A prefix must never silently pick one of several runs
+func TestDB_GetRun_Lookup(t *testing.T) {
+	// Given: Two runs sharing a prefix
+	db := openTestDB(t)
+	started := time.Date(2026, 10, 16, 10, 15, 0, 0, time.UTC)
+	require.NoError(t, db.SaveRun(Run{ID: "20261016-101500-apply-1", Operation: "apply", StartedAt: started, Status: StatusSucceeded}))
+	require.NoError(t, db.SaveRun(Run{ID: "20261016-101500-apply-2", Operation: "apply", StartedAt: started, Status: StatusSucceeded}))
+
+	// When/Then: Ambiguous and unknown IDs fail, full IDs work
+	_, err := db.GetRun("20261016-101500")
+	assert.EqualError(t, err, "run ID prefix 20261016-101500 is ambiguous")
+	_, err = db.GetRun("20261017")
+	assert.EqualError(t, err, "no run 20261017 in the run database")
+	run, err := db.GetRun("20261016-101500-apply-1")
+	require.NoError(t, err)
+	assert.Equal(t, "20261016-101500-apply-1", run.ID)
+}
+
+// TestDB_ListRuns tests filtering and ordering of listed runs
+// WHY: Trends are read newest first, per operation, per node or for failures only
+func TestDB_ListRuns(t *testing.T) {
+	db := openTestDB(t)
+	base := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	runs := []Run{
+		{ID: "a", Operation: "apply", StartedAt: base, Status: StatusSucceeded,
+			Nodes: []NodeResult{{Service: "NodeLabelConf", Node: "rsb2", Status: StatusSucceeded}}},
+		{ID: "b", Operation: "delete", StartedAt: base.Add(time.Hour), Status: StatusFailed,
+			Nodes: []NodeResult{{Service: "NodeLabelConf", Node: "rsb3", Status: StatusFailed}}},
+		{ID: "c", Operation: "apply", StartedAt: base.Add(2 * time.Hour), Status: StatusFailed,
+			Nodes: []NodeResult{{Service: "NodeVLANConf", Node: "rsb2", Status: StatusFailed}}},
+	}
+	for _, run := range runs {
+		require.NoError(t, db.SaveRun(run))
+	}
+
+	tests := []struct {
+		name     string
+		filter   ListFilter
+		expected []string
+	}{
+		{name: "all_newest_first", expected: []string{"c", "b", "a"}},
+		{name: "by_operation", filter: ListFilter{Operation: "apply"}, expected: []string{"c", "a"}},
+		{name: "by_node", filter: ListFilter{Node: "rsb2"}, expected: []string{"c", "a"}},
+		{name: "failed_only", filter: ListFilter{Failed: true}, expected: []string{"c", "b"}},
+		{name: "limited", filter: ListFilter{Limit: 1}, expected: []string{"c"}},
+		{name: "no_match", filter: ListFilter{Node: "rsb9"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: Three recorded runs
+
+			// When: List them with a filter
+			listed, err := db.ListRuns(tt.filter)
+
+			// Then: Only matching runs are returned, without their details
+			require.NoError(t, err)
+			var ids []string
+			for _, run := range listed {
+				ids = append(ids, run.ID)
+				assert.Empty(t, run.Nodes)
+			}
+			assert.Equal(t, tt.expected, ids)
+		})
+	}
+}
+
+// TestDB_NodeHistory tests reading the latest results of a service on a node
+// WHY: Quarantining counts consecutive failures, so results of other services, operations and dry runs must not count
+func TestDB_NodeHistory(t *testing.T) {
+	// Given: Three applies, a dry run and a delete on rsb3, and a result of another service
+	db := openTestDB(t)
+	started := time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC)
+	save := func(id, operation string, offset time.Duration, dryRun bool, nodes ...NodeResult) {
+		require.NoError(t, db.SaveRun(Run{ID: id, Operation: operation, StartedAt: started.Add(offset), DryRun: dryRun, Status: StatusFailed, Nodes: nodes}))
+	}
+	failed := NodeResult{Service: "NodeVLANConf", Node: "rsb3", Status: StatusFailed, Error: "ip link add failed"}
+	save("apply-1", "apply", 0, false, NodeResult{Service: "NodeVLANConf", Node: "rsb3", Status: StatusSucceeded})
+	save("apply-2", "apply", time.Hour, false, failed, NodeResult{Service: "NodeLabelConf", Node: "rsb3", Status: StatusSucceeded})
+	save("dry-run", "apply", 2*time.Hour, true, failed)
+	save("delete", "delete", 3*time.Hour, false, failed)
+	save("apply-3", "apply", 4*time.Hour, false, failed)
+
+	// When: Read the last three VLAN applies of rsb3
+	history, err := db.NodeHistory("rsb3", "NodeVLANConf", "apply", 3)
+
+	// Then: Only the applies that changed something come back, newest first
+	require.NoError(t, err)
+	require.Len(t, history, 3)
+	assert.Equal(t, NodeRun{RunID: "apply-3", StartedAt: started.Add(4 * time.Hour), Status: StatusFailed, Error: "ip link add failed"}, history[0])
+	assert.Equal(t, "apply-2", history[1].RunID)
+	assert.Equal(t, StatusSucceeded, history[2].Status)
+}
@@ -0,0 +1,199 @@
+// Package segmentation derives the traffic the network must block from the isolation tests and VLANs of a bundle
+package segmentation
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strings"
+
+	"k8ostack-ictl/internal/config"
+)
+
+// Output formats of the expected-deny pairs
+const (
+	FormatReport   = "report"
+	FormatIPTables = "iptables"
+	FormatNFTables = "nftables"
+)
+
+// Formats lists the supported output formats
+var Formats = []string{FormatReport, FormatIPTables, FormatNFTables}
+
+// nftTable is the table holding the suggested nftables rules
+const nftTable = "kictl_segmentation"
+
+// Network is one side of an expected-deny pair
+type Network struct {
+	VLAN   string
+	ID     int
+	Subnet string
+}
+
+// Pair is traffic an isolation test expects the network to block, from the source VLAN to the target VLAN
+type Pair struct {
+	Test   string
+	Source Network
+	Target Network
+
+	// Port is the TCP port a tcp test expects blocked; 0 blocks all traffic
+	Port int
+
+	// SharedNodes are on both VLANs, so their traffic reaches the target without crossing any firewall
+	SharedNodes []string
+}
+
+// Plan is the expected-deny pairs of a bundle
+type Plan struct {
+	Pairs []Pair
+
+	// Warnings lists the isolation tests no pair could be derived for, e.g. because they name no VLAN of the bundle
+	Warnings []string
+}
+
+// FromBundle derives one pair per source and target of every test expecting no connectivity
+func FromBundle(bundle *config.ConfigBundle) *Plan {
+	plan := &Plan{}
+	if !bundle.HasTests() {
+		return plan
+	}
+
+	var vlans map[string]config.VLANConfig
+	if bundle.HasVLANs() {
+		vlans = bundle.VLANs.Spec.VLANs
+	}
+	for _, test := range bundle.Tests.Spec.Tests {
+		if test.ExpectSuccess {
+			continue
+		}
+		source, ok := vlans[test.Source]
+		if !ok {
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("test %s: source %s is no VLAN of the bundle; skipped", test.Name, test.Source))
+			continue
+		}
+		for _, targetName := range test.Targets {
+			target, ok := vlans[targetName]
+			if !ok {
+				plan.Warnings = append(plan.Warnings, fmt.Sprintf("test %s: target %s is no VLAN of the bundle; skipped", test.Name, targetName))
+				continue
+			}
+			if source.Subnet == "" || target.Subnet == "" {
+				plan.Warnings = append(plan.Warnings, fmt.Sprintf("test %s: %s or %s declares no subnet; skipped", test.Name, test.Source, targetName))
+				continue
+			}
+			if family(source.Subnet) != family(target.Subnet) {
+				plan.Warnings = append(plan.Warnings, fmt.Sprintf("test %s: %s and %s are of different address families; skipped", test.Name, test.Source, targetName))
+				continue
+			}
+			plan.Pairs = append(plan.Pairs, Pair{
+				Test:        test.Name,
+				Source:      Network{VLAN: test.Source, ID: source.ID, Subnet: source.Subnet},
+				Target:      Network{VLAN: targetName, ID: target.ID, Subnet: target.Subnet},
+				SharedNodes: sharedNodes(source.NodeMapping, target.NodeMapping),
+				Port:        tcpPort(test),
+			})
+		}
+	}
+	return plan
+}
+
+// Write renders the plan in one of Formats
+func (p *Plan) Write(out io.Writer, format string) error {
+	switch format {
+	case FormatReport:
+		p.writeReport(out)
+	case FormatIPTables:
+		p.writeIPTables(out)
+	case FormatNFTables:
+		p.writeNFTables(out)
+	default:
+		return fmt.Errorf("unknown format '%s'. Expected: %s", format, strings.Join(Formats, ", "))
+	}
+	return nil
+}
+
+// writeReport prints every pair with the nodes the network cannot isolate
+func (p *Plan) writeReport(out io.Writer) {
+	fmt.Fprintf(out, "🚫 Expected-deny pairs: %d\n", len(p.Pairs))
+	for _, pair := range p.Pairs {
+		port := ""
+		if pair.Port != 0 {
+			port = fmt.Sprintf(" tcp/%d", pair.Port)
+		}
+		fmt.Fprintf(out, "  %s: %s (VLAN %d, %s) -> %s (VLAN %d, %s)%s\n", pair.Test,
+			pair.Source.VLAN, pair.Source.ID, pair.Source.Subnet, pair.Target.VLAN, pair.Target.ID, pair.Target.Subnet, port)
+		if len(pair.SharedNodes) > 0 {
+			fmt.Fprintf(out, "    ⚠️  On both VLANs, so no firewall can isolate them: %s\n", strings.Join(pair.SharedNodes, ", "))
+		}
+	}
+}
+
+// writeIPTables prints FORWARD rules dropping each pair, with ip6tables for IPv6 subnets
+func (p *Plan) writeIPTables(out io.Writer) {
+	fmt.Fprintln(out, "# Suggested by kictl from the isolation tests; apply on the routers or firewalls between the VLANs")
+	for _, pair := range p.Pairs {
+		command := "iptables"
+		if family(pair.Source.Subnet) == "ip6" {
+			command = "ip6tables"
+		}
+		port := ""
+		if pair.Port != 0 {
+			port = fmt.Sprintf(" -p tcp --dport %d", pair.Port)
+		}
+		fmt.Fprintf(out, "%s -A FORWARD -s %s -d %s%s -m comment --comment %q -j DROP\n",
+			command, pair.Source.Subnet, pair.Target.Subnet, port, comment(pair))
+	}
+}
+
+// writeNFTables prints a table whose forward chain drops each pair
+func (p *Plan) writeNFTables(out io.Writer) {
+	fmt.Fprintln(out, "# Suggested by kictl from the isolation tests; apply on the routers or firewalls between the VLANs")
+	fmt.Fprintf(out, "table inet %s {\n", nftTable)
+	fmt.Fprintln(out, "  chain forward {")
+	fmt.Fprintln(out, "    type filter hook forward priority 0; policy accept;")
+	for _, pair := range p.Pairs {
+		match := family(pair.Source.Subnet)
+		port := ""
+		if pair.Port != 0 {
+			port = fmt.Sprintf(" tcp dport %d", pair.Port)
+		}
+		fmt.Fprintf(out, "    %s saddr %s %s daddr %s%s drop comment %q\n", match, pair.Source.Subnet, match, pair.Target.Subnet, port, comment(pair))
+	}
+	fmt.Fprintln(out, "  }")
+	fmt.Fprintln(out, "}")
+}
+
+// comment names the test and VLANs a rule comes from
+func comment(pair Pair) string {
+	return fmt.Sprintf("kictl %s: %s -> %s", pair.Test, pair.Source.VLAN, pair.Target.VLAN)
+}
+
+// tcpPort returns the port of a tcp test, which blocks that port only, or 0 for tests blocking all traffic
+func tcpPort(test config.ConnectivityTest) int {
+	if test.Type == config.TestTypeTCP {
+		return test.Port
+	}
+	return 0
+}
+
+// family returns the nftables family keyword of a subnet, ip or ip6
+func family(subnet string) string {
+	ip, _, err := net.ParseCIDR(subnet)
+	if err == nil && ip.To4() == nil {
+		return "ip6"
+	}
+	return "ip"
+}
+
+// sharedNodes returns the nodes mapped in both VLANs, in order
+func sharedNodes(source, target config.NodeMapping) []string {
+	var shared []string
+	for node := range source {
+		if _, ok := target[node]; ok {
+			shared = append(shared, node)
+		}
+	}
+	sort.Strings(shared)
+	return shared
+}
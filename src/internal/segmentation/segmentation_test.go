@@ -0,0 +1,105 @@
+// Package segmentation provides unit tests for the expected-deny pairs
+// WHY: Firewall rules derived from the wrong subnets would block the traffic the cluster needs
+package segmentation
+
+import (
+	"bytes"
+	"testing"
+
+	"k8ostack-ictl/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testBundle isolates storage from tenant and api and the Galera port of management from tenant, and expects management to reach storage
+func testBundle() *config.ConfigBundle {
+	return &config.ConfigBundle{
+		VLANs: &config.NodeVLANConf{Spec: config.NodeVLANSpec{VLANs: map[string]config.VLANConfig{
+			"management": {ID: 100, Subnet: "10.1.0.0/24", NodeMapping: config.NodeMapping{"rsb2": "10.1.0.2/24", "rsb5": "10.1.0.5/24"}},
+			"storage":    {ID: 200, Subnet: "10.2.0.0/24", NodeMapping: config.NodeMapping{"rsb5": "10.2.0.5/24", "rsb7": "10.2.0.7/24"}},
+			"tenant":     {ID: 300, Subnet: "10.3.0.0/24", NodeMapping: config.NodeMapping{"rsb7": "10.3.0.7/24"}},
+		}}},
+		Tests: &config.NodeTestConf{Spec: config.NodeTestSpec{Tests: []config.ConnectivityTest{
+			{Name: "management-reachability", Source: "management", Targets: []string{"storage"}, ExpectSuccess: true},
+			{Name: "storage-isolation", Source: "storage", Targets: []string{"tenant", "api"}},
+			{Name: "galera-isolation", Type: config.TestTypeTCP, Source: "tenant", Targets: []string{"management"}, Port: 3306},
+		}}},
+	}
+}
+
+// TestFromBundle tests deriving the expected-deny pairs of a bundle
+// WHY: Only tests expecting no connectivity describe segmentation, tcp tests only their port, and nodes on both VLANs defeat it
+func TestFromBundle(t *testing.T) {
+	// Given: A bundle with a reachability test, an isolation test and a tcp isolation test
+	bundle := testBundle()
+
+	// When: Derive its expected-deny pairs
+	plan := FromBundle(bundle)
+
+	// Then: Each isolation test gives one pair, the tcp test for its port, and the target that is no VLAN a warning
+	assert.Equal(t, []Pair{{
+		Test:        "storage-isolation",
+		Source:      Network{VLAN: "storage", ID: 200, Subnet: "10.2.0.0/24"},
+		Target:      Network{VLAN: "tenant", ID: 300, Subnet: "10.3.0.0/24"},
+		SharedNodes: []string{"rsb7"},
+	}, {
+		Test:   "galera-isolation",
+		Source: Network{VLAN: "tenant", ID: 300, Subnet: "10.3.0.0/24"},
+		Target: Network{VLAN: "management", ID: 100, Subnet: "10.1.0.0/24"},
+		Port:   3306,
+	}}, plan.Pairs)
+	assert.Equal(t, []string{"test storage-isolation: target api is no VLAN of the bundle; skipped"}, plan.Warnings)
+}
+
+// TestPlan_Write tests rendering the pairs in each format
+// WHY: The rules are pasted into firewalls as printed, so their syntax must hold
+func TestPlan_Write(t *testing.T) {
+	tests := []struct {
+		name          string
+		format        string
+		expected      []string
+		expectedError string
+	}{
+		{
+			name:   "report",
+			format: FormatReport,
+			expected: []string{"🚫 Expected-deny pairs: 2", "storage-isolation: storage (VLAN 200, 10.2.0.0/24) -> tenant (VLAN 300, 10.3.0.0/24)\n", "On both VLANs, so no firewall can isolate them: rsb7",
+				"galera-isolation: tenant (VLAN 300, 10.3.0.0/24) -> management (VLAN 100, 10.1.0.0/24) tcp/3306"},
+		},
+		{
+			name:   "iptables",
+			format: FormatIPTables,
+			expected: []string{`iptables -A FORWARD -s 10.2.0.0/24 -d 10.3.0.0/24 -m comment --comment "kictl storage-isolation: storage -> tenant" -j DROP`,
+				`iptables -A FORWARD -s 10.3.0.0/24 -d 10.1.0.0/24 -p tcp --dport 3306 -m comment --comment "kictl galera-isolation: tenant -> management" -j DROP`},
+		},
+		{
+			name:   "nftables",
+			format: FormatNFTables,
+			expected: []string{"table inet kictl_segmentation {", `ip saddr 10.2.0.0/24 ip daddr 10.3.0.0/24 drop comment "kictl storage-isolation: storage -> tenant"`,
+				`ip saddr 10.3.0.0/24 ip daddr 10.1.0.0/24 tcp dport 3306 drop comment "kictl galera-isolation: tenant -> management"`},
+		},
+		{name: "unknown", format: "pf", expectedError: "unknown format 'pf'. Expected: report, iptables, nftables"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: The pairs of a bundle
+			plan := FromBundle(testBundle())
+			out := new(bytes.Buffer)
+
+			// When: Render them
+			err := plan.Write(out, tt.format)
+
+			// Then: The format holds every pair
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			for _, expected := range tt.expected {
+				assert.Contains(t, out.String(), expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package settings
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Expand replaces an alias in the first argument by its argument list, keeping the arguments after it
+// Expansions are not expanded again. ok is false when the first argument is no alias.
+func (s *Settings) Expand(args []string) (expanded []string, ok bool, err error) {
+	if len(args) == 0 {
+		return args, false, nil
+	}
+	expansion, ok := s.Aliases[args[0]]
+	if !ok {
+		return args, false, nil
+	}
+	words, err := SplitArgs(expansion)
+	if err != nil {
+		return nil, false, fmt.Errorf("alias %s: %w", args[0], err)
+	}
+	return append(words, args[1:]...), true, nil
+}
+
+// validateAliases checks that alias names are single words not looking like flags, and that expansions split
+func validateAliases(aliases map[string]string) error {
+	names := make([]string, 0, len(aliases))
+	for name := range aliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if name == "" || strings.HasPrefix(name, "-") || strings.ContainsAny(name, " \t") {
+			return fmt.Errorf("aliases: invalid alias name '%s': use a single word not starting with '-'", name)
+		}
+		words, err := SplitArgs(aliases[name])
+		if err != nil {
+			return fmt.Errorf("aliases.%s: %w", name, err)
+		}
+		if len(words) == 0 {
+			return fmt.Errorf("aliases.%s: expansion is empty", name)
+		}
+	}
+	return nil
+}
+
+// SplitArgs splits a command line into words like a POSIX shell, honoring single and double quotes and backslashes
+// Variables, globs and other shell features are not expanded.
+func SplitArgs(line string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	var quote rune
+
+	runes := []rune(line)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case quote == '"':
+			switch {
+			case r == '"':
+				quote = 0
+			case r == '\\' && i+1 < len(runes) && strings.ContainsRune(`"\$`+"`", runes[i+1]):
+				i++
+				word.WriteRune(runes[i])
+			default:
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == '\\':
+			if i+1 == len(runes) {
+				return nil, fmt.Errorf("trailing backslash in '%s'", line)
+			}
+			i++
+			word.WriteRune(runes[i])
+			inWord = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote in '%s'", quote, line)
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
@@ -0,0 +1,88 @@
+// Package settings provides unit tests for command aliases
+// WHY: An alias must run exactly the arguments written in the settings file, quotes included
+package settings
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSplitArgs tests splitting alias expansions into arguments
+// WHY: Quoted values such as label selectors with spaces must stay one argument
+func TestSplitArgs(t *testing.T) {
+	tests := []struct {
+		name        string
+		line        string
+		expected    []string
+		expectError string
+	}{
+		{name: "plain_words", line: "--config prod.yaml  verify", expected: []string{"--config", "prod.yaml", "verify"}},
+		{name: "single_quotes", line: `--selector 'zone in (a, b)'`, expected: []string{"--selector", "zone in (a, b)"}},
+		{name: "double_quotes_with_escape", line: `--set "nvlan.note=say \"hi\""`, expected: []string{"--set", `nvlan.note=say "hi"`}},
+		{name: "backslash_space", line: `--config my\ cluster.yaml`, expected: []string{"--config", "my cluster.yaml"}},
+		{name: "empty_quoted_word", line: `--node-name-pattern ''`, expected: []string{"--node-name-pattern", ""}},
+		{name: "empty", line: "  ", expected: nil},
+		{name: "unterminated_quote", line: `--config 'prod.yaml`, expectError: "unterminated ' quote"},
+		{name: "trailing_backslash", line: `verify \`, expectError: "trailing backslash"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// When: Split the line
+			words, err := SplitArgs(tt.line)
+
+			// Then: Words match what a shell would pass
+			if tt.expectError != "" {
+				assert.ErrorContains(t, err, tt.expectError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, words)
+		})
+	}
+}
+
+// TestSettings_Expand tests expanding an alias in the first argument
+// WHY: Arguments after the alias are appended, and other first arguments are left alone
+func TestSettings_Expand(t *testing.T) {
+	settings := &Settings{Aliases: map[string]string{"prod-verify": "--config prod.yaml verify"}}
+
+	expanded, ok, err := settings.Expand([]string{"prod-verify", "--nodes", "rsb2"})
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"--config", "prod.yaml", "verify", "--nodes", "rsb2"}, expanded)
+
+	expanded, ok, err = settings.Expand([]string{"verify", "--config", "lab.yaml"})
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, []string{"verify", "--config", "lab.yaml"}, expanded)
+}
+
+// TestLoad_Aliases tests validating aliases when the settings file is read
+// WHY: A broken alias must fail loudly instead of running a mangled command
+func TestLoad_Aliases(t *testing.T) {
+	tests := []struct {
+		name        string
+		aliases     map[string]string
+		expectError string
+	}{
+		{name: "valid", aliases: map[string]string{"prod-verify": "--config prod.yaml verify"}},
+		{name: "flag_like_name", aliases: map[string]string{"--prod": "verify"}, expectError: "invalid alias name '--prod'"},
+		{name: "empty_expansion", aliases: map[string]string{"nothing": " "}, expectError: "aliases.nothing: expansion is empty"},
+		{name: "unterminated_quote", aliases: map[string]string{"prod": `--config "prod.yaml`}, expectError: "aliases.prod: unterminated"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := (&Settings{Aliases: tt.aliases}).validate()
+
+			if tt.expectError != "" {
+				assert.ErrorContains(t, err, tt.expectError)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,169 @@
+// Package settings reads the user's kictl settings file: flag defaults and how carefully each cluster must be changed
+package settings
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileName is the settings file in the default workspace ($KICTL_HOME or ~/.kictl)
+const FileName = "config"
+
+// Protection levels of a cluster, from least to most careful
+const (
+	ProtectionSandbox    = "sandbox"
+	ProtectionStaging    = "staging"
+	ProtectionProduction = "production"
+)
+
+// Protections lists the protection levels
+var Protections = []string{ProtectionSandbox, ProtectionStaging, ProtectionProduction}
+
+// Color modes of the output
+const (
+	ColorAuto   = "auto"
+	ColorAlways = "always"
+	ColorNever  = "never"
+)
+
+// ColorModes lists the color modes
+var ColorModes = []string{ColorAuto, ColorAlways, ColorNever}
+
+// Settings are the user's kictl settings
+type Settings struct {
+	// Defaults fill in global flags that neither the command line nor the environment set
+	Defaults Defaults `json:"defaults,omitempty" yaml:"defaults,omitempty"`
+
+	// Clusters classifies kubeconfig contexts; the first entry matching a context wins
+	Clusters []Cluster `json:"clusters,omitempty" yaml:"clusters,omitempty"`
+
+	// Aliases name argument lists, e.g. {"prod-verify": "--config prod.yaml verify"}, see Expand
+	Aliases map[string]string `json:"aliases,omitempty" yaml:"aliases,omitempty"`
+}
+
+// Defaults are the values of global flags an operator would otherwise type on every run
+type Defaults struct {
+	Kubeconfig     string `json:"kubeconfig,omitempty" yaml:"kubeconfig,omitempty"`         // --kubeconfig; ~ is expanded
+	Context        string `json:"context,omitempty" yaml:"context,omitempty"`               // --context
+	MaxParallelism int    `json:"maxParallelism,omitempty" yaml:"maxParallelism,omitempty"` // --max-parallelism
+	LogFormat      string `json:"logFormat,omitempty" yaml:"logFormat,omitempty"`           // --log-format
+	Color          string `json:"color,omitempty" yaml:"color,omitempty"`                   // --color
+	Workspace      string `json:"workspace,omitempty" yaml:"workspace,omitempty"`           // --workspace; ~ is expanded
+}
+
+// Flags returns the defaults that are set, keyed by flag name
+func (d Defaults) Flags() map[string]string {
+	flags := make(map[string]string)
+	for name, value := range map[string]string{
+		"kubeconfig": d.Kubeconfig,
+		"context":    d.Context,
+		"log-format": d.LogFormat,
+		"color":      d.Color,
+		"workspace":  d.Workspace,
+	} {
+		if value != "" {
+			flags[name] = value
+		}
+	}
+	if d.MaxParallelism != 0 {
+		flags["max-parallelism"] = strconv.Itoa(d.MaxParallelism)
+	}
+	return flags
+}
+
+// Cluster assigns a protection level to the kubeconfig contexts matching a name or glob pattern
+type Cluster struct {
+	Context    string `json:"context" yaml:"context"`
+	Protection string `json:"protection" yaml:"protection"`
+}
+
+// Path returns the settings file of a workspace root
+func Path(root string) string {
+	return filepath.Join(root, FileName)
+}
+
+// Load reads a settings file; a missing file gives empty settings, which classify every cluster as sandbox
+func Load(file string) (*Settings, error) {
+	data, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Settings{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read settings %s: %w", file, err)
+	}
+
+	var settings Settings
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&settings); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse settings %s: %w", file, err)
+	}
+	if err := settings.validate(); err != nil {
+		return nil, fmt.Errorf("invalid settings %s: %w", file, err)
+	}
+	if settings.Defaults.Kubeconfig, err = expandHome(settings.Defaults.Kubeconfig); err != nil {
+		return nil, err
+	}
+	if settings.Defaults.Workspace, err = expandHome(settings.Defaults.Workspace); err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+// Protection returns the protection level of a kubeconfig context, sandbox when no entry matches
+func (s *Settings) Protection(kubeContext string) string {
+	for _, cluster := range s.Clusters {
+		if matched, _ := path.Match(cluster.Context, kubeContext); matched {
+			return cluster.Protection
+		}
+	}
+	return ProtectionSandbox
+}
+
+// validate checks the defaults, context patterns and protection levels
+func (s *Settings) validate() error {
+	switch s.Defaults.Color {
+	case "", ColorAuto, ColorAlways, ColorNever:
+	default:
+		return fmt.Errorf("defaults.color must be one of %s, got '%s'", strings.Join(ColorModes, ", "), s.Defaults.Color)
+	}
+	if s.Defaults.MaxParallelism < 0 {
+		return fmt.Errorf("defaults.maxParallelism must be at least 1, got %d", s.Defaults.MaxParallelism)
+	}
+	if err := validateAliases(s.Aliases); err != nil {
+		return err
+	}
+	for i, cluster := range s.Clusters {
+		if _, err := path.Match(cluster.Context, ""); err != nil || strings.TrimSpace(cluster.Context) == "" {
+			return fmt.Errorf("clusters[%d]: invalid context pattern '%s'", i, cluster.Context)
+		}
+		switch cluster.Protection {
+		case ProtectionSandbox, ProtectionStaging, ProtectionProduction:
+		default:
+			return fmt.Errorf("clusters[%d]: protection of '%s' must be one of %s, got '%s'",
+				i, cluster.Context, strings.Join(Protections, ", "), cluster.Protection)
+		}
+	}
+	return nil
+}
+
+// expandHome replaces a leading ~/ by the home directory
+func expandHome(file string) (string, error) {
+	if !strings.HasPrefix(file, "~/") {
+		return file, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to expand %s: %w", file, err)
+	}
+	return filepath.Join(home, file[2:]), nil
+}
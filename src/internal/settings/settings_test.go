@@ -0,0 +1,144 @@
+// Package settings provides unit tests for the settings file
+// WHY: The protection level decides whether a run gets the production safeguards, so it must be read exactly
+package settings
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLoad tests reading the settings file and classifying contexts
+// WHY: The first matching entry wins, unknown contexts are sandbox and mistakes fail instead of lowering protection
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		expected    map[string]string
+		expectError string
+	}{
+		{
+			name:     "missing_file",
+			expected: map[string]string{"prod": ProtectionSandbox},
+		},
+		{
+			name:    "first_match_wins",
+			content: "clusters:\n  - context: prod-lab\n    protection: staging\n  - context: prod-*\n    protection: production\n  - context: stage\n    protection: staging\n",
+			expected: map[string]string{
+				"prod-lab":  ProtectionStaging,
+				"prod-east": ProtectionProduction,
+				"stage":     ProtectionStaging,
+				"kind-dev":  ProtectionSandbox,
+			},
+		},
+		{
+			name:        "unknown_protection",
+			content:     "clusters:\n  - context: prod\n    protection: critical\n",
+			expectError: "protection of 'prod' must be one of sandbox, staging, production, got 'critical'",
+		},
+		{
+			name:        "invalid_pattern",
+			content:     "clusters:\n  - context: 'prod-['\n    protection: production\n",
+			expectError: "invalid context pattern 'prod-['",
+		},
+		{
+			name:        "misspelled_field",
+			content:     "clusters:\n  - context: prod\n    protecton: production\n",
+			expectError: "field protecton not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A settings file, or none
+			path := Path(t.TempDir())
+			if tt.content != "" {
+				require.NoError(t, os.WriteFile(path, []byte(tt.content), 0644))
+			}
+
+			// When: Load it
+			settings, err := Load(path)
+
+			// Then: Contexts get the level of their first matching entry
+			if tt.expectError != "" {
+				assert.ErrorContains(t, err, tt.expectError)
+				return
+			}
+			require.NoError(t, err)
+			for kubeContext, protection := range tt.expected {
+				assert.Equal(t, protection, settings.Protection(kubeContext), kubeContext)
+			}
+		})
+	}
+}
+
+// TestPath tests the location of the settings file
+// WHY: The settings live next to the workspace folders, where users look for kictl files
+func TestPath(t *testing.T) {
+	assert.Equal(t, filepath.Join("/home/ops/.kictl", "config"), Path("/home/ops/.kictl"))
+}
+
+// TestLoad_Defaults tests reading the flag defaults
+// WHY: Each default must reach the flag it stands for, with ~ expanded in paths like the shell would
+func TestLoad_Defaults(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	tests := []struct {
+		name        string
+		content     string
+		expected    map[string]string
+		expectError string
+	}{
+		{
+			name:     "no_defaults",
+			content:  "clusters: []\n",
+			expected: map[string]string{},
+		},
+		{
+			name: "every_default",
+			content: "defaults:\n  kubeconfig: ~/.kube/lab.yaml\n  context: lab\n  maxParallelism: 8\n" +
+				"  logFormat: json\n  color: never\n  workspace: /data/kictl\n",
+			expected: map[string]string{
+				"kubeconfig":      filepath.Join(home, ".kube/lab.yaml"),
+				"context":         "lab",
+				"max-parallelism": "8",
+				"log-format":      "json",
+				"color":           "never",
+				"workspace":       "/data/kictl",
+			},
+		},
+		{
+			name:        "unknown_color",
+			content:     "defaults:\n  color: rainbow\n",
+			expectError: "defaults.color must be one of auto, always, never, got 'rainbow'",
+		},
+		{
+			name:        "negative_parallelism",
+			content:     "defaults:\n  maxParallelism: -1\n",
+			expectError: "defaults.maxParallelism must be at least 1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A settings file with flag defaults
+			path := Path(t.TempDir())
+			require.NoError(t, os.WriteFile(path, []byte(tt.content), 0644))
+
+			// When: Load it
+			settings, err := Load(path)
+
+			// Then: The defaults are keyed by the flags they set
+			if tt.expectError != "" {
+				assert.ErrorContains(t, err, tt.expectError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, settings.Defaults.Flags())
+		})
+	}
+}
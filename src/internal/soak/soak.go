@@ -0,0 +1,87 @@
+// Package soak watches a batch of changed nodes before a rollout moves on to the next batch
+// Nodes must stay Ready for the soak duration and then pass the smoke tests, or the rollout aborts.
+package soak
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"k8ostack-ictl/internal/kubectl"
+	"k8ostack-ictl/internal/logging"
+)
+
+// DefaultInterval is the time between two Ready checks of a batch
+const DefaultInterval = 10 * time.Second
+
+// Options configures a Watcher
+type Options struct {
+	Duration time.Duration // How long the batch must stay Ready; 0 checks once
+	Interval time.Duration // Time between Ready checks, default DefaultInterval
+	Logger   logging.Logger
+
+	// Smoke runs the smoke tests once the batch stayed Ready for Duration; nil runs none
+	Smoke func(ctx context.Context, batch []string) error
+}
+
+// Watcher soaks batches of nodes
+type Watcher struct {
+	run     kubectl.Runner
+	options Options
+}
+
+// New creates a watcher reading node conditions with run, the kubectl of the executor selecting the cluster
+func New(run kubectl.Runner, options Options) *Watcher {
+	if options.Interval <= 0 {
+		options.Interval = DefaultInterval
+	}
+	return &Watcher{run: run, options: options}
+}
+
+// Soak watches the nodes of a batch until Duration passes, failing as soon as one is not Ready, then runs the smoke tests
+func (w *Watcher) Soak(ctx context.Context, batch []string) error {
+	w.options.Logger.Info(fmt.Sprintf("⏳ Soaking %s for %s...", strings.Join(batch, ", "), w.options.Duration))
+	deadline := time.Now().Add(w.options.Duration)
+	for {
+		for _, nodeName := range batch {
+			if err := w.ready(ctx, nodeName); err != nil {
+				return err
+			}
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("soak interrupted: %w", ctx.Err())
+		case <-time.After(min(w.options.Interval, remaining)):
+		}
+	}
+
+	if w.options.Smoke != nil {
+		if err := w.options.Smoke(ctx, batch); err != nil {
+			return fmt.Errorf("smoke tests failed: %w", err)
+		}
+	}
+	w.options.Logger.Info(fmt.Sprintf("✅ %s passed the soak", strings.Join(batch, ", ")))
+	return nil
+}
+
+// ready fails unless the Ready condition of the node is True
+func (w *Watcher) ready(ctx context.Context, nodeName string) error {
+	output, err := w.run(ctx, nil, "get", "node", nodeName, "-o", `jsonpath={.status.conditions[?(@.type=="Ready")].status}`)
+	if err != nil {
+		return fmt.Errorf("failed to read the Ready condition of node %s: %s", nodeName, strings.TrimSpace(output))
+	}
+	status := strings.TrimSpace(output)
+	if status == "True" {
+		return nil
+	}
+	if status == "" {
+		status = "Unknown"
+	}
+	return fmt.Errorf("node %s is not Ready (%s) during the soak", nodeName, status)
+}
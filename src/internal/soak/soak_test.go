@@ -0,0 +1,116 @@
+// Package soak provides unit tests for watching a batch of nodes between rollout batches
+// WHY: A node that goes NotReady or fails the smoke tests must stop the rollout before the next batch
+package soak
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"k8ostack-ictl/internal/logging"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestWatcher_Soak tests the Ready checks and smoke tests of a soak
+// WHY: The soak is the only thing between a bad batch and the rest of the cluster
+func TestWatcher_Soak(t *testing.T) {
+	tests := []struct {
+		name          string
+		ready         map[string][]string // Ready status of each node, one per check; the last one repeats
+		readErr       bool
+		smokeErr      error
+		expectedErr   string
+		expectedSmoke bool
+	}{
+		{
+			name:          "stays_ready",
+			ready:         map[string][]string{"rsb2": {"True"}, "rsb3": {"True"}},
+			expectedSmoke: true,
+		},
+		{
+			name:        "goes_not_ready",
+			ready:       map[string][]string{"rsb2": {"True"}, "rsb3": {"True", "False"}},
+			expectedErr: "node rsb3 is not Ready (False) during the soak",
+		},
+		{
+			name:        "condition_missing",
+			ready:       map[string][]string{"rsb2": {""}, "rsb3": {"True"}},
+			expectedErr: "node rsb2 is not Ready (Unknown) during the soak",
+		},
+		{
+			name:        "read_fails",
+			readErr:     true,
+			expectedErr: "failed to read the Ready condition of node rsb2: connection refused",
+		},
+		{
+			name:          "smoke_fails",
+			ready:         map[string][]string{"rsb2": {"True"}, "rsb3": {"True"}},
+			smokeErr:      errors.New("storage-ping: 1 of 2 targets unreachable"),
+			expectedErr:   "smoke tests failed: storage-ping: 1 of 2 targets unreachable",
+			expectedSmoke: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A kubectl reporting the Ready status of the case, check by check
+			checks := make(map[string]int)
+			run := func(ctx context.Context, stdin []byte, args ...string) (string, error) {
+				if tt.readErr {
+					return "connection refused", errors.New("exit status 1")
+				}
+				assert.Equal(t, []string{"get", "node", args[2], "-o", `jsonpath={.status.conditions[?(@.type=="Ready")].status}`}, args)
+				statuses := tt.ready[args[2]]
+				status := statuses[min(checks[args[2]], len(statuses)-1)]
+				checks[args[2]]++
+				return status, nil
+			}
+			var smoked []string
+			watcher := New(run, Options{
+				Duration: 20 * time.Millisecond,
+				Interval: 5 * time.Millisecond,
+				Logger:   logging.NewRecordingLogger(),
+				Smoke: func(ctx context.Context, batch []string) error {
+					smoked = batch
+					return tt.smokeErr
+				},
+			})
+
+			// When: Soak the batch
+			err := watcher.Soak(context.Background(), []string{"rsb2", "rsb3"})
+
+			// Then: It fails on the first regression, and the smoke tests only run once the nodes stayed Ready
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Greater(t, checks["rsb2"], 1)
+			}
+			if tt.expectedSmoke {
+				assert.Equal(t, []string{"rsb2", "rsb3"}, smoked)
+			} else {
+				assert.Nil(t, smoked)
+			}
+		})
+	}
+}
+
+// TestWatcher_SoakCancelled tests stopping a soak when the run is interrupted
+// WHY: An interrupted run must not wait out the soak nor count the batch as healthy
+func TestWatcher_SoakCancelled(t *testing.T) {
+	// Given: A long soak of a Ready node and a cancelled context
+	run := func(ctx context.Context, stdin []byte, args ...string) (string, error) {
+		return "True", nil
+	}
+	watcher := New(run, Options{Duration: time.Hour, Logger: logging.NewRecordingLogger()})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// When: Soak the batch
+	err := watcher.Soak(ctx, []string{"rsb2"})
+
+	// Then: It stops at once with the cancellation
+	assert.ErrorIs(t, err, context.Canceled)
+}
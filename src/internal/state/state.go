@@ -0,0 +1,191 @@
+// Package state records the configuration kictl last applied so later runs can detect drift
+package state
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"k8ostack-ictl/internal/config"
+)
+
+// State is the cumulative labels and VLAN assignments kictl has applied to the cluster
+type State struct {
+	RunID      string                       `json:"runId,omitempty"`
+	UpdatedAt  time.Time                    `json:"updatedAt"`
+	ConfigFile string                       `json:"configFile,omitempty"`
+	Labels     map[string]map[string]string `json:"labels,omitempty"` // node -> label key -> value
+	VLANs      map[string]config.VLANConfig `json:"vlans,omitempty"`  // VLAN name -> configuration with the applied node mapping
+
+	// InterfaceDetection is the strategy that chose the parent of VLANs recorded without an interface
+	InterfaceDetection string `json:"interfaceDetection,omitempty"`
+
+	// InterfaceAliases resolves the logical interface names the recorded VLANs use
+	InterfaceAliases config.InterfaceAliases `json:"interfaceAliases,omitempty"`
+}
+
+// New creates an empty state
+func New() *State {
+	return &State{
+		Labels: make(map[string]map[string]string),
+		VLANs:  make(map[string]config.VLANConfig),
+	}
+}
+
+// Record updates the state with the effect of an apply or delete of the bundle
+// Label cleanup only forgets labels of explicitly listed nodes, or of every node when it uses a selector.
+func (s *State) Record(bundle *config.ConfigBundle, operation string) {
+	if s.Labels == nil {
+		s.Labels = make(map[string]map[string]string)
+	}
+	if s.VLANs == nil {
+		s.VLANs = make(map[string]config.VLANConfig)
+	}
+
+	switch operation {
+	case "apply":
+		if bundle.HasCleanup() {
+			s.forgetPrefixes(bundle.Cleanup.Spec)
+		}
+		if bundle.HasNodeLabels() {
+			for _, role := range bundle.NodeLabels.Spec.NodeRoles {
+				for _, node := range role.Nodes {
+					if s.Labels[node] == nil {
+						s.Labels[node] = make(map[string]string)
+					}
+					for key, value := range role.Labels {
+						s.Labels[node][key] = value
+					}
+				}
+			}
+		}
+		if bundle.HasVLANs() {
+			s.InterfaceDetection = bundle.GetDefaults().Spec.InterfaceDetection
+			s.InterfaceAliases = bundle.GetDefaults().Spec.InterfaceAliases
+			for name, vlanConfig := range bundle.VLANs.Spec.VLANs {
+				mapping := make(map[string]string)
+				for node, address := range s.VLANs[name].NodeMapping {
+					mapping[node] = address
+				}
+				for node, address := range vlanConfig.NodeMapping {
+					mapping[node] = address
+				}
+				vlanConfig.NodeMapping = mapping
+				s.VLANs[name] = vlanConfig
+			}
+		}
+
+	case "delete":
+		if bundle.HasNodeLabels() {
+			for _, role := range bundle.NodeLabels.Spec.NodeRoles {
+				for _, node := range role.Nodes {
+					for key := range role.Labels {
+						delete(s.Labels[node], key)
+					}
+				}
+			}
+		}
+		if bundle.HasVLANs() {
+			for name, vlanConfig := range bundle.VLANs.Spec.VLANs {
+				recorded, ok := s.VLANs[name]
+				if !ok {
+					continue
+				}
+				for node := range vlanConfig.NodeMapping {
+					delete(recorded.NodeMapping, node)
+				}
+				s.VLANs[name] = recorded
+			}
+		}
+	}
+
+	s.prune()
+}
+
+// forgetPrefixes drops recorded labels that a cleanup removes
+func (s *State) forgetPrefixes(spec config.CleanupSpec) {
+	nodes := spec.Nodes
+	if spec.NodeSelector != "" {
+		nodes = s.Nodes()
+	}
+	for _, node := range nodes {
+		for key := range s.Labels[node] {
+			for _, prefix := range spec.LabelPrefixes {
+				if strings.HasPrefix(key, prefix) {
+					delete(s.Labels[node], key)
+					break
+				}
+			}
+		}
+	}
+}
+
+// prune removes nodes without labels and VLANs without nodes
+func (s *State) prune() {
+	for node, labels := range s.Labels {
+		if len(labels) == 0 {
+			delete(s.Labels, node)
+		}
+	}
+	for name, vlanConfig := range s.VLANs {
+		if len(vlanConfig.NodeMapping) == 0 {
+			delete(s.VLANs, name)
+		}
+	}
+}
+
+// Empty reports whether nothing applied is recorded
+func (s *State) Empty() bool {
+	return len(s.Labels) == 0 && len(s.VLANs) == 0
+}
+
+// Nodes returns the sorted names of all nodes with recorded labels or VLANs
+func (s *State) Nodes() []string {
+	unique := make(map[string]bool)
+	for node := range s.Labels {
+		unique[node] = true
+	}
+	for _, vlanConfig := range s.VLANs {
+		for node := range vlanConfig.NodeMapping {
+			unique[node] = true
+		}
+	}
+
+	nodes := make([]string, 0, len(unique))
+	for node := range unique {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+	return nodes
+}
+
+// Bundle converts the state into a bundle describing what the cluster should look like
+// Every node becomes its own role so the labeling and VLAN planners can diff it against the cluster.
+func (s *State) Bundle() *config.ConfigBundle {
+	bundle := config.NewEmptyBundle()
+	bundle.Source = "applied state"
+
+	if len(s.Labels) > 0 {
+		roles := make(map[string]config.NodeRole, len(s.Labels))
+		for node, labels := range s.Labels {
+			roles[node] = config.NodeRole{Nodes: []string{node}, Labels: labels}
+		}
+		bundle.NodeLabels = &config.NodeLabelConf{
+			APIVersion: "openstack.kictl.icycloud.io/v1",
+			Kind:       "NodeLabelConf",
+			Metadata:   config.Metadata{Name: "applied-state"},
+			Spec:       config.NodeLabelSpec{NodeRoles: roles},
+		}
+	}
+
+	if len(s.VLANs) > 0 {
+		bundle.VLANs = &config.NodeVLANConf{
+			APIVersion: "openstack.kictl.icycloud.io/v1",
+			Kind:       "NodeVLANConf",
+			Metadata:   config.Metadata{Name: "applied-state"},
+			Spec:       config.NodeVLANSpec{VLANs: s.VLANs},
+		}
+	}
+
+	return bundle
+}
@@ -0,0 +1,134 @@
+// Package state provides unit tests for recording applied configuration
+// WHY: Drift is only as accurate as the state recorded after each run
+package state
+
+import (
+	"testing"
+
+	"k8ostack-ictl/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testBundle builds a bundle with labels on two nodes and one VLAN
+func testBundle() *config.ConfigBundle {
+	bundle := config.NewEmptyBundle()
+	bundle.NodeLabels = &config.NodeLabelConf{
+		Kind: "NodeLabelConf",
+		Spec: config.NodeLabelSpec{NodeRoles: map[string]config.NodeRole{
+			"compute": {Nodes: []string{"rsb2", "rsb3"}, Labels: map[string]string{"openstack-role": "compute"}},
+		}},
+	}
+	bundle.VLANs = &config.NodeVLANConf{
+		Kind: "NodeVLANConf",
+		Spec: config.NodeVLANSpec{VLANs: map[string]config.VLANConfig{
+			"storage": {ID: 100, Subnet: "10.100.0.0/24", Interface: "eth1", NodeMapping: map[string]string{"rsb2": "10.100.0.12/24"}},
+		}},
+	}
+	return bundle
+}
+
+// TestState_Record tests how apply, delete and cleanup change the recorded state
+// WHY: State is cumulative across runs, so each operation must only touch what it changed
+func TestState_Record(t *testing.T) {
+	tests := []struct {
+		name           string
+		record         func(*State)
+		expectedLabels map[string]map[string]string
+		expectedVLANs  map[string]map[string]string
+	}{
+		{
+			name: "apply_records_labels_and_vlans",
+			record: func(s *State) {
+				s.Record(testBundle(), "apply")
+			},
+			expectedLabels: map[string]map[string]string{
+				"rsb2": {"openstack-role": "compute"},
+				"rsb3": {"openstack-role": "compute"},
+			},
+			expectedVLANs: map[string]map[string]string{"storage": {"rsb2": "10.100.0.12/24"}},
+		},
+		{
+			name: "later_apply_merges_nodes",
+			record: func(s *State) {
+				s.Record(testBundle(), "apply")
+				other := config.NewEmptyBundle()
+				other.VLANs = &config.NodeVLANConf{Spec: config.NodeVLANSpec{VLANs: map[string]config.VLANConfig{
+					"storage": {ID: 100, Interface: "eth1", NodeMapping: map[string]string{"rsb3": "10.100.0.13/24"}},
+				}}}
+				s.Record(other, "apply")
+			},
+			expectedLabels: map[string]map[string]string{
+				"rsb2": {"openstack-role": "compute"},
+				"rsb3": {"openstack-role": "compute"},
+			},
+			expectedVLANs: map[string]map[string]string{"storage": {"rsb2": "10.100.0.12/24", "rsb3": "10.100.0.13/24"}},
+		},
+		{
+			name: "delete_forgets_everything_applied",
+			record: func(s *State) {
+				s.Record(testBundle(), "apply")
+				s.Record(testBundle(), "delete")
+			},
+			expectedLabels: map[string]map[string]string{},
+			expectedVLANs:  map[string]map[string]string{},
+		},
+		{
+			name: "cleanup_forgets_prefixed_labels_of_listed_nodes",
+			record: func(s *State) {
+				s.Record(testBundle(), "apply")
+				cleanup := config.NewEmptyBundle()
+				cleanup.Cleanup = &config.CleanupConf{Spec: config.CleanupSpec{LabelPrefixes: []string{"openstack-"}, Nodes: []string{"rsb3"}}}
+				s.Record(cleanup, "apply")
+			},
+			expectedLabels: map[string]map[string]string{"rsb2": {"openstack-role": "compute"}},
+			expectedVLANs:  map[string]map[string]string{"storage": {"rsb2": "10.100.0.12/24"}},
+		},
+		{
+			name: "verify_changes_nothing",
+			record: func(s *State) {
+				s.Record(testBundle(), "verify")
+			},
+			expectedLabels: map[string]map[string]string{},
+			expectedVLANs:  map[string]map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: Empty state
+			s := New()
+
+			// When: Record operations
+			tt.record(s)
+
+			// Then: Labels and VLAN node mappings match
+			assert.Equal(t, tt.expectedLabels, s.Labels)
+			vlans := make(map[string]map[string]string)
+			for name, vlanConfig := range s.VLANs {
+				vlans[name] = vlanConfig.NodeMapping
+			}
+			assert.Equal(t, tt.expectedVLANs, vlans)
+		})
+	}
+}
+
+// TestState_Bundle tests converting the state back into a configuration
+// WHY: Drift reuses the planners, which need one role per node with exactly its recorded labels
+func TestState_Bundle(t *testing.T) {
+	s := New()
+	assert.True(t, s.Empty())
+
+	s.Record(testBundle(), "apply")
+	bundle := s.Bundle()
+
+	assert.False(t, s.Empty())
+	assert.Equal(t, []string{"rsb2", "rsb3"}, s.Nodes())
+	require.NotNil(t, bundle.NodeLabels)
+	require.NotNil(t, bundle.VLANs)
+	assert.Equal(t, []string{"rsb2"}, bundle.NodeLabels.Spec.NodeRoles["rsb2"].Nodes)
+	assert.Equal(t, map[string]string{"openstack-role": "compute"}, bundle.NodeLabels.Spec.NodeRoles["rsb3"].Labels)
+	assert.Equal(t, "eth1", bundle.VLANs.Spec.VLANs["storage"].Interface)
+	assert.NoError(t, bundle.Validate())
+}
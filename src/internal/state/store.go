@@ -0,0 +1,176 @@
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ErrNotFound is returned by Load when no state has been recorded yet
+var ErrNotFound = errors.New("no applied state recorded")
+
+// ConfigMapPrefix selects a ConfigMap store in a --state location, e.g. configmap:kube-system/kictl-state
+const ConfigMapPrefix = "configmap:"
+
+// configMapKey is the ConfigMap data key holding the state
+const configMapKey = "state.json"
+
+// Store persists the applied state
+type Store interface {
+	// Load returns the recorded state, or ErrNotFound
+	Load(ctx context.Context) (*State, error)
+
+	// Save replaces the recorded state
+	Save(ctx context.Context, state *State) error
+
+	// String describes where the state is kept
+	String() string
+}
+
+// ParseConfigMapLocation splits a configmap:<namespace>/<name> location
+// ok is false when the location is a file path
+func ParseConfigMapLocation(location string) (namespace, name string, ok bool, err error) {
+	if !strings.HasPrefix(location, ConfigMapPrefix) {
+		return "", "", false, nil
+	}
+	namespace, name, found := strings.Cut(strings.TrimPrefix(location, ConfigMapPrefix), "/")
+	if !found || namespace == "" || name == "" {
+		return "", "", true, fmt.Errorf("invalid state location %q: expected %s<namespace>/<name>", location, ConfigMapPrefix)
+	}
+	return namespace, name, true, nil
+}
+
+// FileStore keeps the state in a JSON file
+type FileStore struct {
+	path string
+}
+
+// NewFileStore creates a store writing the state to path
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+// Load reads the state file
+func (s *FileStore) Load(ctx context.Context) (*State, error) {
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state file: %w", err)
+	}
+	return decode(data, s.path)
+}
+
+// Save writes the state file through a temporary file so a crash never leaves it half written
+func (s *FileStore) Save(ctx context.Context, state *State) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode state: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("failed to replace state file: %w", err)
+	}
+	return nil
+}
+
+// String returns the state file path
+func (s *FileStore) String() string {
+	return s.path
+}
+
+// ConfigMapStore keeps the state in a ConfigMap so every operator of the cluster shares it
+type ConfigMapStore struct {
+	client    kubernetes.Interface
+	namespace string
+	name      string
+}
+
+// NewConfigMapStore creates a store writing the state to the namespace/name ConfigMap
+func NewConfigMapStore(client kubernetes.Interface, namespace, name string) *ConfigMapStore {
+	return &ConfigMapStore{client: client, namespace: namespace, name: name}
+}
+
+// Load reads the state from the ConfigMap
+func (s *ConfigMapStore) Load(ctx context.Context) (*State, error) {
+	configMap, err := s.client.CoreV1().ConfigMaps(s.namespace).Get(ctx, s.name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state ConfigMap %s: %w", s, err)
+	}
+	data, ok := configMap.Data[configMapKey]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return decode([]byte(data), s.String())
+}
+
+// Save creates or updates the ConfigMap
+func (s *ConfigMapStore) Save(ctx context.Context, state *State) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode state: %w", err)
+	}
+
+	configMaps := s.client.CoreV1().ConfigMaps(s.namespace)
+	configMap, err := configMaps.Get(ctx, s.name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		configMap = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      s.name,
+				Namespace: s.namespace,
+				Labels:    map[string]string{"app.kubernetes.io/managed-by": "kictl"},
+			},
+			Data: map[string]string{configMapKey: string(data)},
+		}
+		if _, err := configMaps.Create(ctx, configMap, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create state ConfigMap %s: %w", s, err)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read state ConfigMap %s: %w", s, err)
+	}
+
+	if configMap.Data == nil {
+		configMap.Data = make(map[string]string)
+	}
+	configMap.Data[configMapKey] = string(data)
+	if _, err := configMaps.Update(ctx, configMap, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update state ConfigMap %s: %w", s, err)
+	}
+	return nil
+}
+
+// String returns the ConfigMap location
+func (s *ConfigMapStore) String() string {
+	return ConfigMapPrefix + s.namespace + "/" + s.name
+}
+
+// decode parses a recorded state
+func decode(data []byte, source string) (*State, error) {
+	state := New()
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("corrupt state in %s: %w", source, err)
+	}
+	return state, nil
+}
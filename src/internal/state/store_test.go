@@ -0,0 +1,100 @@
+// Package state provides unit tests for the state stores
+// WHY: A missing state must be distinguishable from a broken one, wherever it is kept
+package state
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// TestParseConfigMapLocation tests --state location parsing
+// WHY: A typo in a ConfigMap location must fail instead of silently writing a local file
+func TestParseConfigMapLocation(t *testing.T) {
+	tests := []struct {
+		location          string
+		expectedNamespace string
+		expectedName      string
+		expectedConfigMap bool
+		expectError       bool
+	}{
+		{location: "", expectedConfigMap: false},
+		{location: "/var/lib/kictl/state.json", expectedConfigMap: false},
+		{location: "configmap:kube-system/kictl-state", expectedNamespace: "kube-system", expectedName: "kictl-state", expectedConfigMap: true},
+		{location: "configmap:kictl-state", expectedConfigMap: true, expectError: true},
+		{location: "configmap:/kictl-state", expectedConfigMap: true, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.location, func(t *testing.T) {
+			namespace, name, isConfigMap, err := ParseConfigMapLocation(tt.location)
+
+			assert.Equal(t, tt.expectedConfigMap, isConfigMap)
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedNamespace, namespace)
+			assert.Equal(t, tt.expectedName, name)
+		})
+	}
+}
+
+// TestStores_RoundTrip tests saving, loading and replacing state in every store
+// WHY: Both stores must behave the same so drift works wherever the state lives
+func TestStores_RoundTrip(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	stores := map[string]Store{
+		"file":      NewFileStore(filepath.Join(t.TempDir(), "nested", "state.json")),
+		"configmap": NewConfigMapStore(client, "kube-system", "kictl-state"),
+	}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			// Given: Nothing recorded
+			_, err := store.Load(ctx)
+			assert.ErrorIs(t, err, ErrNotFound)
+
+			// When: Save twice
+			first := New()
+			first.Record(testBundle(), "apply")
+			require.NoError(t, store.Save(ctx, first))
+			second := New()
+			second.Labels["rsb4"] = map[string]string{"zone": "b"}
+			require.NoError(t, store.Save(ctx, second))
+
+			// Then: The last state is loaded
+			loaded, err := store.Load(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, map[string]map[string]string{"rsb4": {"zone": "b"}}, loaded.Labels)
+			assert.Empty(t, loaded.VLANs)
+		})
+	}
+
+	configMap, err := client.CoreV1().ConfigMaps("kube-system").Get(context.Background(), "kictl-state", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "kictl", configMap.Labels["app.kubernetes.io/managed-by"])
+	assert.Equal(t, "configmap:kube-system/kictl-state", stores["configmap"].String())
+}
+
+// TestFileStore_Corrupt tests that a damaged state file is reported, not treated as empty
+// WHY: Silently starting over would hide every earlier apply from drift
+func TestFileStore_Corrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	require.NoError(t, os.WriteFile(path, []byte("{not json"), 0644))
+
+	_, err := NewFileStore(path).Load(context.Background())
+
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, ErrNotFound)
+	assert.Contains(t, err.Error(), "corrupt state")
+}
@@ -0,0 +1,422 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/kubectl"
+	"k8ostack-ictl/internal/logging"
+	"k8ostack-ictl/internal/progress"
+)
+
+// Operations of the service
+const (
+	operationApply  = "apply"
+	operationRemove = "remove"
+	operationVerify = "verify"
+)
+
+// States of a device found by probing it
+const (
+	deviceMissing = "missing" // not a block device on the node
+	deviceMounted = "mounted" // the device or one of its partitions is mounted
+	deviceCeph    = "ceph"    // already holds a Ceph OSD, left untouched
+	deviceClean   = "clean"   // no partitions, filesystem or signature, ready for Rook
+	deviceDirty   = "dirty"   // holds partitions, a filesystem or a signature
+)
+
+// probeMarker starts the lsblk output of each device in the probe output
+const probeMarker = "== "
+
+// lsblkPair matches the KEY="value" pairs of lsblk -P output
+var lsblkPair = regexp.MustCompile(`([A-Z]+)="([^"]*)"`)
+
+// deviceState is what probing found on a device
+type deviceState struct {
+	Status string
+	Detail string // why the device is missing, mounted or dirty
+}
+
+// PrepareStorage checks the devices of every node, zaps those marked zap that hold data and labels the node
+func (ss *StorageService) PrepareStorage(ctx context.Context, cfg *config.NodeStorageConf) (*OperationResults, error) {
+	return ss.processNodes(ctx, cfg, operationApply)
+}
+
+// RemoveStorage removes the labels of every node; devices are never touched
+func (ss *StorageService) RemoveStorage(ctx context.Context, cfg *config.NodeStorageConf) (*OperationResults, error) {
+	return ss.processNodes(ctx, cfg, operationRemove)
+}
+
+// VerifyStorage checks that the devices of every node are ready for Ceph and its labels are set
+func (ss *StorageService) VerifyStorage(ctx context.Context, cfg *config.NodeStorageConf) (*OperationResults, error) {
+	return ss.processNodes(ctx, cfg, operationVerify)
+}
+
+// Cleanup removes the debug pods left behind by an operation that was interrupted, e.g. by a service timeout
+func (ss *StorageService) Cleanup(ctx context.Context) {
+	ss.cleanupDebugPods(ctx)
+}
+
+// processNodes runs an operation on every node of the configuration
+func (ss *StorageService) processNodes(ctx context.Context, cfg *config.NodeStorageConf, operation string) (*OperationResults, error) {
+	ss.kubectl.SetDryRun(ss.options.DryRun)
+
+	nodes := sortedKeys(cfg.Spec.Nodes)
+	results := &OperationResults{ZappedDevices: make(map[string][]string)}
+	if ss.options.DryRun {
+		ss.options.Logger.Info(fmt.Sprintf("🧪 DRY RUN: Simulating storage %s for %s on %d nodes...", operation, cfg.Metadata.Name, len(nodes)))
+	} else {
+		ss.options.Logger.Info(fmt.Sprintf("💽 Starting storage %s for %s on %d nodes...", operation, cfg.Metadata.Name, len(nodes)))
+	}
+
+	reporter := progress.Or(ss.options.Progress)
+	reporter.Begin(fmt.Sprintf("Storage %s", operation), len(nodes))
+
+	results.Order = ss.options.Workers.Order(nodes)
+	var mu sync.Mutex
+	err := ss.options.Workers.Run(nodes, func(nodeName string) {
+		node := ss.forNode(nodeName)
+		reporter.Started(nodeName)
+
+		labels := cfg.Spec.NodeLabels(nodeName)
+		var zapped []string
+		var err error
+		switch operation {
+		case operationRemove:
+			err = node.removeNode(ctx, nodeName, labels)
+		case operationVerify:
+			err = node.verifyNode(ctx, nodeName, cfg.Spec.Nodes[nodeName].Devices, labels)
+		default:
+			zapped, err = node.applyNode(ctx, nodeName, cfg.Spec.Nodes[nodeName].Devices, labels)
+		}
+		reporter.Done(nodeName, err != nil)
+
+		mu.Lock()
+		defer mu.Unlock()
+		results.TotalNodes++
+		if len(zapped) > 0 {
+			results.ZappedDevices[nodeName] = zapped
+		}
+		if err != nil {
+			node.options.Logger.Error(fmt.Sprintf("Failed to %s storage of node %s: %v", operation, nodeName, err))
+			results.FailedNodes = append(results.FailedNodes, nodeName)
+			results.Errors = append(results.Errors, kubectl.WrapNodeError(nodeName, err))
+			return
+		}
+		results.SuccessfulNodes++
+		if operation != operationRemove {
+			results.PreparedNodes = append(results.PreparedNodes, nodeName)
+		}
+	})
+	reporter.End()
+	if err != nil {
+		ss.options.Logger.Error(err.Error())
+		results.Errors = append(results.Errors, err)
+	}
+
+	sort.Strings(results.FailedNodes)
+	sort.Strings(results.PreparedNodes)
+	ss.options.Logger.Info(fmt.Sprintf("📊 Storage %s: %d of %d nodes succeeded", operation, results.SuccessfulNodes, results.TotalNodes))
+
+	ss.cleanupDebugPods(ctx)
+	return results, nil
+}
+
+// applyNode checks every device of a node before zapping any, then labels the node once all are ready
+// A device already holding a Ceph OSD is never zapped, even when marked zap, so a rerun cannot destroy an OSD.
+// It returns the devices it zapped.
+func (ss *StorageService) applyNode(ctx context.Context, nodeName string, devices []config.StorageDevice, labels map[string]string) ([]string, error) {
+	if ss.options.DryRun {
+		for _, device := range devices {
+			if device.Zap {
+				ss.options.Logger.Info(fmt.Sprintf("🧪 DRY RUN: Would check device %s on node %s and zap it if it holds data", device.Path, nodeName))
+			} else {
+				ss.options.Logger.Info(fmt.Sprintf("🧪 DRY RUN: Would check that device %s on node %s is empty", device.Path, nodeName))
+			}
+		}
+		for _, key := range sortedKeys(labels) {
+			ss.options.Logger.Info(fmt.Sprintf("🧪 DRY RUN: Would label node %s with %s=%s", nodeName, key, labels[key]))
+		}
+		return nil, nil
+	}
+
+	states, err := ss.probeDevices(ctx, nodeName, devices)
+	if err != nil {
+		return nil, err
+	}
+	var zap []string
+	for _, device := range devices {
+		state := states[device.Path]
+		switch state.Status {
+		case deviceMissing:
+			return nil, fmt.Errorf("device %s not found: %s", device.Path, state.Detail)
+		case deviceMounted:
+			return nil, fmt.Errorf("device %s is mounted at %s; it will not be zapped or handed to Ceph", device.Path, state.Detail)
+		case deviceCeph:
+			ss.options.Logger.Info(fmt.Sprintf("ℹ️  Device %s on node %s already holds a Ceph OSD, leaving it untouched", device.Path, nodeName))
+		case deviceDirty:
+			if !device.Zap {
+				return nil, fmt.Errorf("device %s holds data (%s); set zap: true to wipe it", device.Path, state.Detail)
+			}
+			if !ss.options.AllowZap {
+				return nil, fmt.Errorf("device %s holds data (%s) and zapping it was not confirmed", device.Path, state.Detail)
+			}
+			zap = append(zap, device.Path)
+		}
+	}
+
+	if len(zap) > 0 {
+		var commands []string
+		for _, path := range zap {
+			ss.options.Logger.Warn(fmt.Sprintf("💥 Zapping device %s on node %s", path, nodeName))
+			commands = append(commands, zapCommands(path)...)
+		}
+		if err := ss.exec(ctx, nodeName, kubectl.JoinHostCommands(commands...)); err != nil {
+			return nil, fmt.Errorf("failed to zap devices: %w", err)
+		}
+
+		// A partition table the kernel still holds open survives wipefs, so the result is checked
+		zapped := make([]config.StorageDevice, len(zap))
+		for i, path := range zap {
+			zapped[i] = config.StorageDevice{Path: path}
+		}
+		states, err := ss.probeDevices(ctx, nodeName, zapped)
+		if err != nil {
+			return zap, err
+		}
+		for _, path := range zap {
+			if state := states[path]; state.Status != deviceClean {
+				return zap, fmt.Errorf("device %s still holds data after zapping (%s)", path, state.Detail)
+			}
+		}
+		ss.options.Logger.Info(fmt.Sprintf("✅ Zapped %d devices on node %s", len(zap), nodeName))
+	}
+
+	for _, key := range sortedKeys(labels) {
+		label := key + "=" + labels[key]
+		success, output, err := ss.kubectl.LabelNode(ctx, nodeName, label, true)
+		if err != nil {
+			return zap, fmt.Errorf("failed to label node with %s: %w", label, err)
+		}
+		if !success {
+			return zap, fmt.Errorf("failed to label node with %s: %s", label, strings.TrimSpace(output))
+		}
+	}
+	ss.options.Logger.Info(fmt.Sprintf("✅ %d devices of node %s are ready for Ceph", len(devices), nodeName))
+	return zap, nil
+}
+
+// removeNode removes the labels of a node, so Rook stops placing OSDs on it; the devices keep their data
+func (ss *StorageService) removeNode(ctx context.Context, nodeName string, labels map[string]string) error {
+	if ss.options.DryRun {
+		for _, key := range sortedKeys(labels) {
+			ss.options.Logger.Info(fmt.Sprintf("🧪 DRY RUN: Would remove label %s from node %s", key, nodeName))
+		}
+		return nil
+	}
+
+	for _, key := range sortedKeys(labels) {
+		success, output, err := ss.kubectl.UnlabelNode(ctx, nodeName, key)
+		if err != nil {
+			return fmt.Errorf("failed to remove label %s: %w", key, err)
+		}
+		if !success {
+			return fmt.Errorf("failed to remove label %s: %s", key, strings.TrimSpace(output))
+		}
+	}
+	ss.options.Logger.Info(fmt.Sprintf("✅ Removed %d storage labels from node %s; its devices were left as they are", len(labels), nodeName))
+	return nil
+}
+
+// verifyNode checks that every device of a node is empty or holds a Ceph OSD and that its labels are set
+func (ss *StorageService) verifyNode(ctx context.Context, nodeName string, devices []config.StorageDevice, labels map[string]string) error {
+	if ss.options.DryRun {
+		ss.options.Logger.Info(fmt.Sprintf("🧪 DRY RUN: Would check %d devices and %d labels of node %s", len(devices), len(labels), nodeName))
+		return nil
+	}
+
+	states, err := ss.probeDevices(ctx, nodeName, devices)
+	if err != nil {
+		return err
+	}
+	var problems []string
+	for _, device := range devices {
+		switch state := states[device.Path]; state.Status {
+		case deviceMissing:
+			problems = append(problems, fmt.Sprintf("device %s not found", device.Path))
+		case deviceMounted:
+			problems = append(problems, fmt.Sprintf("device %s is mounted at %s", device.Path, state.Detail))
+		case deviceDirty:
+			problems = append(problems, fmt.Sprintf("device %s holds data (%s)", device.Path, state.Detail))
+		}
+	}
+
+	success, output, err := ss.kubectl.GetNodeLabels(ctx, nodeName)
+	if err != nil {
+		return fmt.Errorf("failed to read node labels: %w", err)
+	}
+	if !success {
+		return fmt.Errorf("failed to read node labels: %s", strings.TrimSpace(output))
+	}
+	current := kubectl.ParseNodeLabels(output)
+	for _, key := range sortedKeys(labels) {
+		if value, ok := current[key]; !ok {
+			problems = append(problems, fmt.Sprintf("label %s is missing", key))
+		} else if value != labels[key] {
+			problems = append(problems, fmt.Sprintf("label %s is %s, not %s", key, value, labels[key]))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%d storage problems: %s", len(problems), strings.Join(problems, ", "))
+	}
+	ss.options.Logger.Info(fmt.Sprintf("✅ Devices and labels of node %s are ready for Ceph", nodeName))
+	return nil
+}
+
+// probeDevices lists the partitions, filesystems and mount points of devices with a single node command
+func (ss *StorageService) probeDevices(ctx context.Context, nodeName string, devices []config.StorageDevice) (map[string]deviceState, error) {
+	commands := make([]string, len(devices))
+	for i, device := range devices {
+		// lsblk fails for a missing device; its message is kept in the output to report it
+		commands[i] = kubectl.HostCommand("echo", probeMarker+device.Path) + "; " +
+			kubectl.HostCommand("lsblk", "-nP", "-o", "NAME,TYPE,FSTYPE,MOUNTPOINT", device.Path) + " 2>&1 || true"
+	}
+	output, err := ss.run(ctx, nodeName, strings.Join(commands, "; "))
+	if err != nil {
+		return nil, fmt.Errorf("failed to probe devices: %w", err)
+	}
+	return parseProbe(output), nil
+}
+
+// run runs a node command and returns its output
+func (ss *StorageService) run(ctx context.Context, nodeName, command string) (string, error) {
+	success, output, err := ss.kubectl.ExecNodeCommand(ctx, nodeName, command)
+	if err != nil {
+		return output, err
+	}
+	if !success {
+		return output, fmt.Errorf("command failed: %s", strings.TrimSpace(output))
+	}
+	return output, nil
+}
+
+// exec runs a node command changing the node, logging it when verbose
+func (ss *StorageService) exec(ctx context.Context, nodeName, command string) error {
+	if ss.options.Verbose {
+		ss.options.Logger.Debug(fmt.Sprintf("Running on node %s: %s", nodeName, command))
+	}
+	_, err := ss.run(ctx, nodeName, command)
+	return err
+}
+
+// forNode returns a copy of the service whose log entries carry the node name
+func (ss *StorageService) forNode(nodeName string) *StorageService {
+	node := *ss
+	node.options.Logger = ss.options.Logger.With(logging.FieldNode, nodeName)
+	return &node
+}
+
+// cleanupDebugPods deletes the debug pods the executor started for this service's node commands
+func (ss *StorageService) cleanupDebugPods(ctx context.Context) {
+	if ss.options.DryRun {
+		return
+	}
+	deletedCount, err := ss.kubectl.ReleaseDebugPods(ctx)
+	if err != nil {
+		ss.options.Logger.Warn(fmt.Sprintf("Failed to delete debug pods: %v", err))
+	} else if deletedCount > 0 {
+		ss.options.Logger.Info(fmt.Sprintf("✅ Cleaned up %d debug pods", deletedCount))
+	}
+}
+
+// zapCommands wipe the signatures and partition tables of a device and zero its first 100MiB, as Rook documents for reusing a disk
+func zapCommands(path string) []string {
+	return []string{
+		kubectl.HostCommand("wipefs", "--all", path),
+		kubectl.HostCommand("sgdisk", "--zap-all", path),
+		kubectl.HostCommand("dd", "if=/dev/zero", "of="+path, "bs=1M", "count=100", "oflag=direct,dsync"),
+	}
+}
+
+// parseProbe classifies each device of the probe output by its lsblk lines
+func parseProbe(output string) map[string]deviceState {
+	rows := make(map[string][]map[string]string)
+	messages := make(map[string][]string)
+	var current string
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if path, ok := strings.CutPrefix(line, probeMarker); ok {
+			current = path
+			rows[current] = nil
+			continue
+		}
+		if current == "" || line == "" {
+			continue
+		}
+		pairs := lsblkPair.FindAllStringSubmatch(line, -1)
+		if len(pairs) == 0 {
+			messages[current] = append(messages[current], line)
+			continue
+		}
+		row := make(map[string]string, len(pairs))
+		for _, pair := range pairs {
+			row[pair[1]] = pair[2]
+		}
+		rows[current] = append(rows[current], row)
+	}
+
+	states := make(map[string]deviceState, len(rows))
+	for path, deviceRows := range rows {
+		states[path] = classifyDevice(deviceRows, strings.Join(messages[path], "; "))
+	}
+	return states
+}
+
+// classifyDevice decides the state of a device from the lsblk rows of the device and its children
+func classifyDevice(rows []map[string]string, message string) deviceState {
+	if len(rows) == 0 {
+		if message == "" {
+			message = "lsblk printed nothing"
+		}
+		return deviceState{Status: deviceMissing, Detail: message}
+	}
+	for _, row := range rows {
+		if row["MOUNTPOINT"] != "" {
+			return deviceState{Status: deviceMounted, Detail: row["MOUNTPOINT"]}
+		}
+	}
+	for _, row := range rows {
+		// Ceph volumes are LVM logical volumes named ceph--<vg>-osd--block--<id>, or raw bluestore devices
+		if row["FSTYPE"] == "ceph_bluestore" || strings.HasPrefix(row["NAME"], "ceph--") {
+			return deviceState{Status: deviceCeph}
+		}
+	}
+	if len(rows) == 1 && rows[0]["FSTYPE"] == "" {
+		return deviceState{Status: deviceClean}
+	}
+
+	var contents []string
+	for i, row := range rows {
+		if i == 0 && row["FSTYPE"] == "" {
+			continue
+		}
+		contents = append(contents, strings.TrimSpace(row["TYPE"]+" "+row["FSTYPE"]))
+	}
+	return deviceState{Status: deviceDirty, Detail: strings.Join(contents, ", ")}
+}
+
+// sortedKeys returns the keys of a map in order
+func sortedKeys[V any](values map[string]V) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
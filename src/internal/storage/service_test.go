@@ -0,0 +1,160 @@
+// Package storage provides unit tests for the storage service
+// WHY: Zapping destroys data, so only devices marked zap, confirmed and not holding an OSD or a mount may ever be wiped
+package storage
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/labeler"
+	"k8ostack-ictl/internal/logging"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// Probe outputs of the devices of rsb2
+const (
+	probeDirty = "== /dev/sdb\nNAME=\"sdb\" TYPE=\"disk\" FSTYPE=\"\" MOUNTPOINT=\"\"\nNAME=\"sdb1\" TYPE=\"part\" FSTYPE=\"ext4\" MOUNTPOINT=\"\"\n"
+	probeClean = "== /dev/sdb\nNAME=\"sdb\" TYPE=\"disk\" FSTYPE=\"\" MOUNTPOINT=\"\"\n"
+	probeRest  = "== /dev/sdc\nNAME=\"sdc\" TYPE=\"disk\" FSTYPE=\"\" MOUNTPOINT=\"\"\n" +
+		"== /dev/sdd\nNAME=\"sdd\" TYPE=\"disk\" FSTYPE=\"LVM2_member\" MOUNTPOINT=\"\"\n" +
+		"NAME=\"ceph--1a2b-osd--block--3c4d\" TYPE=\"lvm\" FSTYPE=\"ceph_bluestore\" MOUNTPOINT=\"\"\n"
+)
+
+// storageTestConfig returns a configuration with a device to zap, an empty one and one holding an OSD on rsb2
+func storageTestConfig() *config.NodeStorageConf {
+	return &config.NodeStorageConf{
+		APIVersion: "openstack.kictl.icycloud.io/v1",
+		Kind:       "NodeStorageConf",
+		Metadata:   config.Metadata{Name: "ceph-osds"},
+		Spec: config.NodeStorageSpec{
+			Labels: map[string]string{"ceph-osd": "enabled"},
+			Nodes: map[string]config.StorageNode{"rsb2": {
+				Devices: []config.StorageDevice{{Path: "/dev/sdb", Zap: true}, {Path: "/dev/sdc"}, {Path: "/dev/sdd", Zap: true}},
+				Labels:  map[string]string{"topology.rook.io/rack": "rack1"},
+			}},
+		},
+	}
+}
+
+// TestStorageService tests preparing, verifying and releasing the devices of a node
+// WHY: A device is only zapped once every device of the node checked out, and an OSD is never zapped even when marked zap
+func TestStorageService(t *testing.T) {
+	tests := []struct {
+		name         string
+		operation    string
+		allowZap     bool
+		probes       []string
+		nodeLabels   string
+		expectZaps   []string
+		expectLabels []string
+		expectError  string
+	}{
+		{
+			name:       "apply_zaps_confirmed_device",
+			operation:  operationApply,
+			allowZap:   true,
+			probes:     []string{probeDirty + probeRest, probeClean},
+			expectZaps: []string{"wipefs --all /dev/sdb && sgdisk --zap-all /dev/sdb && dd if=/dev/zero of=/dev/sdb bs=1M count=100 oflag=direct,dsync"},
+			expectLabels: []string{
+				"ceph-osd=enabled",
+				"topology.rook.io/rack=rack1",
+			},
+		},
+		{
+			name:        "apply_without_confirmation",
+			operation:   operationApply,
+			probes:      []string{probeDirty + probeRest},
+			expectError: "device /dev/sdb holds data (part ext4) and zapping it was not confirmed",
+		},
+		{
+			name:      "apply_mounted_device",
+			operation: operationApply,
+			allowZap:  true,
+			probes: []string{"== /dev/sdb\nNAME=\"sdb\" TYPE=\"disk\" FSTYPE=\"\" MOUNTPOINT=\"\"\n" +
+				"NAME=\"sdb1\" TYPE=\"part\" FSTYPE=\"xfs\" MOUNTPOINT=\"/var/lib/docker\"\n" + probeRest},
+			expectError: "device /dev/sdb is mounted at /var/lib/docker",
+		},
+		{
+			name:      "apply_missing_device",
+			operation: operationApply,
+			allowZap:  true,
+			probes: []string{"== /dev/sdb\nlsblk: /dev/sdb: not a block device\n" +
+				"== /dev/sdc\nNAME=\"sdc\" TYPE=\"disk\" FSTYPE=\"\" MOUNTPOINT=\"\"\n== /dev/sdd\n"},
+			expectError: "device /dev/sdb not found: lsblk: /dev/sdb: not a block device",
+		},
+		{
+			name:        "verify",
+			operation:   operationVerify,
+			probes:      []string{probeDirty + probeRest},
+			nodeLabels:  "NAME   STATUS   ROLES    AGE   VERSION   LABELS\nrsb2   Ready    <none>   1d    v1.30.0   ceph-osd=enabled,kubernetes.io/hostname=rsb2\n",
+			expectError: "2 storage problems: device /dev/sdb holds data (part ext4), label topology.rook.io/rack is missing",
+		},
+		{
+			name:         "remove_only_unlabels",
+			operation:    operationRemove,
+			expectLabels: []string{"ceph-osd-", "topology.rook.io/rack-"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: rsb2 answering the device probes of the case in order
+			executor := labeler.NewMockDryRunExecutor()
+			executor.On("SetDryRun", false).Return()
+			executor.On("ReleaseDebugPods", mock.Anything).Return(1, nil)
+			for _, probe := range tt.probes {
+				executor.On("ExecNodeCommand", mock.Anything, "rsb2", mock.MatchedBy(func(cmd string) bool {
+					return strings.HasPrefix(cmd, "echo")
+				})).Return(true, probe, nil).Once()
+			}
+			var zaps, labels []string
+			executor.On("ExecNodeCommand", mock.Anything, "rsb2", mock.MatchedBy(func(cmd string) bool {
+				return strings.HasPrefix(cmd, "wipefs")
+			})).Run(func(args mock.Arguments) {
+				zaps = append(zaps, args.String(2))
+			}).Return(true, "", nil).Maybe()
+			executor.On("LabelNode", mock.Anything, "rsb2", mock.Anything, true).Run(func(args mock.Arguments) {
+				labels = append(labels, args.String(2))
+			}).Return(true, "node/rsb2 labeled", nil).Maybe()
+			executor.On("UnlabelNode", mock.Anything, "rsb2", mock.Anything).Run(func(args mock.Arguments) {
+				labels = append(labels, args.String(2)+"-")
+			}).Return(true, "node/rsb2 unlabeled", nil).Maybe()
+			executor.On("GetNodeLabels", mock.Anything, "rsb2").Return(true, tt.nodeLabels, nil).Maybe()
+			service := NewService(executor, Options{AllowZap: tt.allowZap, Logger: logging.NewRecordingLogger()})
+
+			// When: Run the operation
+			var results *OperationResults
+			var err error
+			switch tt.operation {
+			case operationRemove:
+				results, err = service.RemoveStorage(context.Background(), storageTestConfig())
+			case operationVerify:
+				results, err = service.VerifyStorage(context.Background(), storageTestConfig())
+			default:
+				results, err = service.PrepareStorage(context.Background(), storageTestConfig())
+			}
+
+			// Then: Only the confirmed dirty device is zapped and the node is labeled once all devices are ready
+			require.NoError(t, err)
+			executor.AssertExpectations(t)
+			assert.Equal(t, tt.expectZaps, zaps)
+			assert.Equal(t, tt.expectLabels, labels)
+			if tt.expectError != "" {
+				assert.Equal(t, []string{"rsb2"}, results.FailedNodes)
+				require.Len(t, results.Errors, 1)
+				assert.Contains(t, results.Errors[0].Error(), tt.expectError)
+				return
+			}
+			assert.Empty(t, results.Errors)
+			assert.Equal(t, 1, results.SuccessfulNodes)
+			if len(tt.expectZaps) > 0 {
+				assert.Equal(t, map[string][]string{"rsb2": {"/dev/sdb"}}, results.ZappedDevices)
+			}
+		})
+	}
+}
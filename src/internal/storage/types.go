@@ -0,0 +1,62 @@
+// Package storage provides the core business logic for preparing block devices for Ceph OSDs and labeling their nodes for Rook
+package storage
+
+import (
+	"context"
+
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/kubectl"
+	"k8ostack-ictl/internal/logging"
+	"k8ostack-ictl/internal/progress"
+	"k8ostack-ictl/internal/throttle"
+)
+
+// OperationResults tracks the results of storage operations
+type OperationResults struct {
+	TotalNodes      int
+	SuccessfulNodes int
+	FailedNodes     []string
+	PreparedNodes   []string            // nodes whose devices are ready and whose labels are set after apply or verify
+	ZappedDevices   map[string][]string // node -> devices wiped by apply
+	Order           []string            // nodes in the order the rollout changed them
+	Errors          []error
+}
+
+// Service defines the interface for the storage service
+type Service interface {
+	// PrepareStorage checks the devices of every node, zaps those marked zap that hold data and labels the node
+	PrepareStorage(ctx context.Context, cfg *config.NodeStorageConf) (*OperationResults, error)
+
+	// RemoveStorage removes the labels of every node; devices are never touched
+	RemoveStorage(ctx context.Context, cfg *config.NodeStorageConf) (*OperationResults, error)
+
+	// VerifyStorage checks that the devices of every node are ready for Ceph and its labels are set
+	VerifyStorage(ctx context.Context, cfg *config.NodeStorageConf) (*OperationResults, error)
+
+	// Cleanup removes the debug pods left behind by an interrupted operation
+	Cleanup(ctx context.Context)
+}
+
+// Options contains configuration options for the storage service
+type Options struct {
+	DryRun   bool
+	Verbose  bool
+	AllowZap bool                 // Wipe devices marked zap; without it a device holding data fails its node
+	Workers  *throttle.Controller // Processes nodes concurrently; nil processes them one by one
+	Progress progress.Reporter    // Receives how many nodes are done; nil reports nothing
+	Logger   logging.Logger
+}
+
+// StorageService implements the Service interface
+type StorageService struct {
+	kubectl kubectl.DryRunExecutor
+	options Options
+}
+
+// NewService creates a new storage service
+func NewService(kubectl kubectl.DryRunExecutor, options Options) Service {
+	return &StorageService{
+		kubectl: kubectl,
+		options: options,
+	}
+}
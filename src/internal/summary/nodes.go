@@ -0,0 +1,264 @@
+package summary
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Verify outcomes of a node
+const (
+	VerifyPassed = "passed"
+	VerifyFailed = "failed"
+)
+
+// NodeResult is what a run did on one node, across every service
+type NodeResult struct {
+	Node     string
+	Labels   int           // labels applied, removed or verified
+	VLANs    int           // VLAN interfaces configured, removed or verified
+	Verify   string        // VerifyPassed, VerifyFailed, or empty when nothing was verified on the node
+	Duration time.Duration // time the services spent on the node
+	Errors   []string
+}
+
+// Failed reports whether anything failed on the node
+func (r NodeResult) Failed() bool {
+	return len(r.Errors) > 0 || r.Verify == VerifyFailed
+}
+
+// Nodes collects the results of a run per node; the services feed their timing to it as a progress.Reporter
+// It is safe for concurrent use, since services report progress from their workers.
+type Nodes struct {
+	mu      sync.Mutex
+	results map[string]*NodeResult
+	started map[string]time.Time
+	now     func() time.Time
+}
+
+// NewNodes creates an empty collection of node results
+func NewNodes() *Nodes {
+	return &Nodes{results: make(map[string]*NodeResult), started: make(map[string]time.Time), now: time.Now}
+}
+
+// node returns the result of a node, adding it first; the caller holds the lock
+func (n *Nodes) node(name string) *NodeResult {
+	result, ok := n.results[name]
+	if !ok {
+		result = &NodeResult{Node: name}
+		n.results[name] = result
+	}
+	return result
+}
+
+// Add lists a node in the results, also when nothing was counted on it
+func (n *Nodes) Add(node string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.node(node)
+}
+
+// AddLabels counts labels handled on a node
+func (n *Nodes) AddLabels(node string, count int) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.node(node).Labels += count
+}
+
+// AddVLANs counts VLAN interfaces handled on a node
+func (n *Nodes) AddVLANs(node string, count int) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.node(node).VLANs += count
+}
+
+// AddDuration adds time spent on a node by a service that does not report progress
+func (n *Nodes) AddDuration(node string, duration time.Duration) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.node(node).Duration += duration
+}
+
+// Verified records a verification of a node; once failed, the node stays failed
+func (n *Nodes) Verified(node string, passed bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	result := n.node(node)
+	if !passed {
+		result.Verify = VerifyFailed
+	} else if result.Verify == "" {
+		result.Verify = VerifyPassed
+	}
+}
+
+// Failed records an error on a node; the same message is kept once
+func (n *Nodes) Failed(node, message string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	result := n.node(node)
+	for _, existing := range result.Errors {
+		if existing == message {
+			return
+		}
+	}
+	result.Errors = append(result.Errors, message)
+}
+
+// Results returns the node results sorted by node name
+func (n *Nodes) Results() []NodeResult {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	results := make([]NodeResult, 0, len(n.results))
+	for _, result := range n.results {
+		results = append(results, *result)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Node < results[j].Node })
+	return results
+}
+
+// Begin implements progress.Reporter
+func (n *Nodes) Begin(task string, total int) {}
+
+// Started implements progress.Reporter, starting the clock of a node
+func (n *Nodes) Started(node string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.started[node] = n.now()
+}
+
+// Done implements progress.Reporter, adding the time since the node started or finished its last unit of work
+func (n *Nodes) Done(node string, failed bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	started, ok := n.started[node]
+	if !ok {
+		return
+	}
+	now := n.now()
+	n.node(node).Duration += now.Sub(started)
+	n.started[node] = now
+}
+
+// End implements progress.Reporter
+func (n *Nodes) End() {}
+
+// TableOptions controls how the node table is printed
+type TableOptions struct {
+	Width int // Terminal width to fit the table in; 0 never truncates
+	Color bool
+}
+
+// minErrorWidth is the narrowest the error column is squeezed to before the node column is truncated too
+const minErrorWidth = 20
+
+// RenderNodes prints one row per node: labels, VLANs, verify outcome, duration and errors
+// Columns are truncated with an ellipsis to fit the width, the error column first.
+func RenderNodes(w io.Writer, results []NodeResult, options TableOptions) {
+	if len(results) == 0 {
+		return
+	}
+	paint := func(color, text string) string {
+		if !options.Color || color == "" {
+			return text
+		}
+		return color + text + colorReset
+	}
+
+	header := []string{"NODE", "LABELS", "VLANS", "VERIFY", "DURATION", "ERROR"}
+	rows := make([][]string, 0, len(results))
+	for _, result := range results {
+		verify := result.Verify
+		if verify == "" {
+			verify = "-"
+		}
+		duration := "-"
+		if result.Duration > 0 {
+			duration = result.Duration.Round(time.Millisecond).String()
+		}
+		message := "-"
+		if len(result.Errors) > 0 {
+			message = strings.ReplaceAll(result.Errors[0], "\n", " ")
+			if len(result.Errors) > 1 {
+				message = fmt.Sprintf("%s (+%d more)", message, len(result.Errors)-1)
+			}
+		}
+		rows = append(rows, []string{result.Node, fmt.Sprint(result.Labels), fmt.Sprint(result.VLANs), verify, duration, message})
+	}
+
+	widths := make([]int, len(header))
+	for _, row := range append([][]string{header}, rows...) {
+		for i, cell := range row {
+			widths[i] = max(widths[i], utf8.RuneCountInString(cell))
+		}
+	}
+	fitWidths(widths, options.Width)
+
+	failed := 0
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, formatRow(header, widths, make([]string, len(header)), paint))
+	for i, row := range rows {
+		colors := make([]string, len(row))
+		if results[i].Failed() {
+			failed++
+			colors[0], colors[5] = colorRed, colorRed
+		}
+		switch row[3] {
+		case VerifyFailed:
+			colors[3] = colorRed
+		case "-":
+			colors[3] = colorDim
+		}
+		fmt.Fprintln(w, formatRow(row, widths, colors, paint))
+	}
+	fmt.Fprintf(w, "\n🖥️  %d nodes, %d failed\n", len(results), failed)
+}
+
+// fitWidths narrows the error column, then the node column, until a row fits width with its separators
+func fitWidths(widths []int, width int) {
+	if width <= 0 {
+		return
+	}
+	total := 2 * (len(widths) - 1)
+	for _, w := range widths {
+		total += w
+	}
+	last := len(widths) - 1
+	if excess := total - width; excess > 0 {
+		shrink := min(excess, max(widths[last]-minErrorWidth, 0))
+		widths[last] -= shrink
+		total -= shrink
+	}
+	if excess := total - width; excess > 0 {
+		widths[0] = max(widths[0]-excess, len("NODE"))
+	}
+}
+
+// formatRow truncates every cell to its column, colors it and pads it; the last cell is not padded
+func formatRow(cells []string, widths []int, colors []string, paint func(color, text string) string) string {
+	parts := make([]string, len(cells))
+	for i, cell := range cells {
+		cell = truncate(cell, widths[i])
+		padding := ""
+		if i < len(cells)-1 {
+			padding = strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell))
+		}
+		parts[i] = paint(colors[i], cell) + padding
+	}
+	return strings.Join(parts, "  ")
+}
+
+// truncate shortens text to width runes, ending it with an ellipsis
+func truncate(text string, width int) string {
+	runes := []rune(text)
+	if len(runes) <= width {
+		return text
+	}
+	if width <= 1 {
+		return string(runes[:width])
+	}
+	return string(runes[:width-1]) + "…"
+}
@@ -0,0 +1,114 @@
+// Package summary provides unit tests for the per-node results and their table
+// WHY: The table is the last thing an operator reads after a run, so every node must be on it and fit the terminal
+package summary
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNodes_Results tests collecting the results of several services per node
+// WHY: Labels, VLANs and tests of the same node must end up on one row, and one failed check fails the node
+func TestNodes_Results(t *testing.T) {
+	// Given: A collection fed by a labeling, a VLAN and a test service, with a fake clock
+	nodes := NewNodes()
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	nodes.now = func() time.Time { return now }
+
+	// When: rsb3 is labeled for 2s, gets a VLAN, passes one test and fails another, and rsb2 is only labeled
+	nodes.Started("rsb3")
+	now = now.Add(2 * time.Second)
+	nodes.Done("rsb3", false)
+	nodes.AddLabels("rsb3", 3)
+	nodes.AddVLANs("rsb3", 1)
+	nodes.AddDuration("rsb3", time.Second)
+	nodes.Verified("rsb3", false)
+	nodes.Verified("rsb3", true)
+	nodes.Failed("rsb3", "ping to storage failed")
+	nodes.Failed("rsb3", "ping to storage failed")
+	nodes.AddLabels("rsb2", 3)
+	nodes.Done("rsb4", false)
+
+	// Then: Each node has one row sorted by name, and rsb3 stays failed
+	results := nodes.Results()
+	require.Len(t, results, 2, "a node done without being started is not listed")
+	assert.Equal(t, NodeResult{Node: "rsb2", Labels: 3}, results[0])
+	assert.Equal(t, NodeResult{
+		Node: "rsb3", Labels: 3, VLANs: 1, Verify: VerifyFailed, Duration: 3 * time.Second,
+		Errors: []string{"ping to storage failed"},
+	}, results[1])
+	assert.True(t, results[1].Failed())
+}
+
+// TestRenderNodes tests the printed node table
+// WHY: Long error messages must not wrap the table on narrow terminals, nor be cut when the output is a file
+func TestRenderNodes(t *testing.T) {
+	results := []NodeResult{
+		{Node: "rsb2", Labels: 3, VLANs: 2, Verify: VerifyPassed, Duration: 1500 * time.Millisecond},
+		{Node: "rsb3-with-a-very-long-node-name", Labels: 3, Verify: VerifyFailed, Duration: time.Second,
+			Errors: []string{"host command on node rsb3 failed (transport): connection refused by the API server", "ping failed"}},
+	}
+	tests := []struct {
+		name     string
+		options  TableOptions
+		expected []string
+		absent   []string
+	}{
+		{
+			name:    "full width",
+			options: TableOptions{},
+			expected: []string{
+				"NODE                             LABELS  VLANS  VERIFY  DURATION  ERROR",
+				"rsb2                             3       2      passed  1.5s      -",
+				"failed (transport): connection refused by the API server (+1 more)",
+				"🖥️  2 nodes, 1 failed",
+			},
+			absent: []string{"…", "\033["},
+		},
+		{
+			name:     "truncated to the terminal",
+			options:  TableOptions{Width: 80},
+			expected: []string{"rsb3-with-a-very-long-no…", "host command on nod…"},
+		},
+		{
+			name:     "colors",
+			options:  TableOptions{Color: true},
+			expected: []string{colorRed + "failed" + colorReset, colorRed + "rsb3-with-a-very-long-node-name" + colorReset},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			RenderNodes(&out, results, tt.options)
+
+			for _, expected := range tt.expected {
+				assert.Contains(t, out.String(), expected)
+			}
+			for _, absent := range tt.absent {
+				assert.NotContains(t, out.String(), absent)
+			}
+			if tt.options.Width > 0 {
+				for _, line := range strings.Split(out.String(), "\n") {
+					assert.LessOrEqual(t, utf8.RuneCountInString(line), tt.options.Width, line)
+				}
+			}
+		})
+	}
+}
+
+// TestRenderNodes_Empty tests a run that handled no node
+// WHY: Runs of a CleanupConf only have no node results, and an empty table is noise
+func TestRenderNodes_Empty(t *testing.T) {
+	var out bytes.Buffer
+
+	RenderNodes(&out, nil, TableOptions{})
+
+	assert.Empty(t, out.String())
+}
@@ -0,0 +1,82 @@
+package summary
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"k8ostack-ictl/internal/ui"
+)
+
+// DefaultTopNodes is how many affected nodes are listed per error class
+const DefaultTopNodes = 5
+
+// ANSI colors used when the output is a terminal
+const (
+	colorReset  = "\033[0m"
+	colorRed    = "\033[31m"
+	colorYellow = "\033[33m"
+	colorDim    = "\033[2m"
+)
+
+// RenderOptions controls how a summary is printed
+type RenderOptions struct {
+	TopNodes int
+	Color    bool
+}
+
+// ColorEnabled reports whether w is a terminal and NO_COLOR is unset
+func ColorEnabled(w io.Writer) bool {
+	return ui.SupportsColor(w)
+}
+
+// Render prints the failures grouped by error class and by configuration kind
+// Each class shows its most affected nodes, a remediation hint and one example error
+func Render(w io.Writer, s *Summary, options RenderOptions) {
+	if s.Len() == 0 {
+		return
+	}
+	if options.TopNodes <= 0 {
+		options.TopNodes = DefaultTopNodes
+	}
+	paint := func(color, text string) string {
+		if !options.Color {
+			return text
+		}
+		return color + text + colorReset
+	}
+
+	byClass := s.ByClass()
+	fmt.Fprintf(w, "\n%s\n", paint(colorRed, fmt.Sprintf("❌ %d failures in %d error classes", s.Len(), len(byClass))))
+
+	fmt.Fprintln(w, "\nBy error class:")
+	for _, group := range byClass {
+		nodes := group.Nodes()
+		scope := fmt.Sprintf("(%d errors)", len(group.Failures))
+		if len(nodes) > 0 {
+			scope = fmt.Sprintf("on %d nodes", len(nodes))
+		}
+		fmt.Fprintf(w, "  %s %s — %s\n", paint(colorRed, "● "+group.Title), scope, paint(colorYellow, group.Hint))
+		if len(nodes) > 0 {
+			fmt.Fprintf(w, "      nodes: %s\n", topNodes(nodes, options.TopNodes))
+		}
+		fmt.Fprintf(w, "      %s\n", paint(colorDim, "e.g. "+group.Failures[0].Err.Error()))
+	}
+
+	fmt.Fprintln(w, "\nBy configuration:")
+	for _, group := range s.ByKind() {
+		line := fmt.Sprintf("  %s: %d failures", group.Title, len(group.Failures))
+		if nodes := group.Nodes(); len(nodes) > 0 {
+			line += fmt.Sprintf(" on %d nodes", len(nodes))
+		}
+		fmt.Fprintln(w, line)
+	}
+}
+
+// topNodes lists the first n nodes and counts the rest
+func topNodes(nodes []string, n int) string {
+	if len(nodes) <= n {
+		return strings.Join(nodes, ", ")
+	}
+	return fmt.Sprintf("%s and %d more", strings.Join(nodes[:n], ", "), len(nodes)-n)
+}
@@ -0,0 +1,96 @@
+// Package summary provides unit tests for summary rendering
+// WHY: The summary replaces the flat error list, so it must stay short and readable
+package summary
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"k8ostack-ictl/internal/kubectl"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newTransportSummary returns a summary with one transport failure on each of count nodes
+func newTransportSummary(count int) *Summary {
+	s := New()
+	for i := 1; i <= count; i++ {
+		s.Add("NodeVLANConf", &kubectl.HostCommandError{Node: fmt.Sprintf("rsb%d", i), Class: kubectl.HostErrorTransport, Cause: errors.New("connection refused")})
+	}
+	return s
+}
+
+// TestRender tests the printed summary
+// WHY: Large clusters must produce one line per problem, not one per node
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name     string
+		summary  *Summary
+		options  RenderOptions
+		expected []string
+		absent   []string
+	}{
+		{
+			name:    "top nodes and hint",
+			summary: newTransportSummary(7),
+			options: RenderOptions{TopNodes: 3},
+			expected: []string{
+				"❌ 7 failures in 1 error classes",
+				"● node unreachable on 7 nodes — check API access, RBAC and debug pod scheduling",
+				"nodes: rsb1, rsb2, rsb3 and 4 more",
+				"e.g. host command on node rsb1 failed (transport): connection refused",
+				"NodeVLANConf: 7 failures on 7 nodes",
+			},
+			absent: []string{"\033["},
+		},
+		{
+			name:     "colors",
+			summary:  newTransportSummary(1),
+			options:  RenderOptions{Color: true},
+			expected: []string{colorRed + "● node unreachable" + colorReset, colorYellow},
+		},
+		{
+			name: "errors without nodes",
+			summary: func() *Summary {
+				s := New()
+				s.Add("NodeTestConf", errors.New("no nodes found for network storage"))
+				return s
+			}(),
+			expected: []string{"● operation failed (1 errors) — see the run log for details", "NodeTestConf: 1 failures\n"},
+			absent:   []string{"nodes:"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			Render(&out, tt.summary, tt.options)
+
+			for _, expected := range tt.expected {
+				assert.Contains(t, out.String(), expected)
+			}
+			for _, absent := range tt.absent {
+				assert.NotContains(t, out.String(), absent)
+			}
+		})
+	}
+}
+
+// TestRender_Empty tests that a clean run prints nothing
+// WHY: Successful runs must not end with an empty failure section
+func TestRender_Empty(t *testing.T) {
+	var out bytes.Buffer
+	Render(&out, New(), RenderOptions{})
+	assert.Empty(t, out.String())
+}
+
+// TestColorEnabled tests terminal detection
+// WHY: Escape codes must never end up in redirected output or logs
+func TestColorEnabled(t *testing.T) {
+	assert.False(t, ColorEnabled(&bytes.Buffer{}))
+
+	t.Setenv("NO_COLOR", "1")
+	assert.False(t, ColorEnabled(&bytes.Buffer{}))
+}
@@ -0,0 +1,195 @@
+// Package summary groups the failures of a run into an actionable error summary
+package summary
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+
+	"k8ostack-ictl/internal/kubectl"
+)
+
+// Error classes beyond the host command failure classes of the kubectl package
+const (
+	ClassNodeNotFound = "node-not-found"
+	ClassImagePull    = "image-pull"
+	ClassTimeout      = "timeout"
+	ClassOther        = "other"
+)
+
+// classInfo describes an error class for humans
+type classInfo struct {
+	title string
+	hint  string
+}
+
+// classes maps every error class to its one-line title and remediation hint
+var classes = map[string]classInfo{
+	ClassNodeNotFound:           {"node not found", "check node names in the configuration"},
+	ClassImagePull:              {"debug image pull failed", "check registry access from the nodes"},
+	ClassTimeout:                {"operation timed out", "check cluster load or retry with fewer nodes"},
+	kubectl.HostErrorTransport:  {"node unreachable", "check API access, RBAC and debug pod scheduling"},
+	kubectl.HostErrorNotFound:   {"command missing on host", "install the required tools or change --host-entry"},
+	kubectl.HostErrorPermission: {"command not permitted", "check that debug pods run privileged"},
+	kubectl.HostErrorRefused:    {"command refused by restricted mode", "allow it with --allowed-commands"},
+	kubectl.HostErrorCommand:    {"command failed on host", "see the run log for the command output"},
+	ClassOther:                  {"operation failed", "see the run log for details"},
+}
+
+// Failure is one failed operation, attributed to the configuration kind and node it belongs to
+type Failure struct {
+	Kind  string
+	Node  string
+	Class string
+	Err   error
+}
+
+// Group collects failures sharing an error class or configuration kind
+type Group struct {
+	Name     string
+	Title    string
+	Hint     string
+	Failures []Failure
+}
+
+// Nodes returns the distinct nodes of the group, most affected first
+func (g Group) Nodes() []string {
+	counts := make(map[string]int)
+	for _, failure := range g.Failures {
+		if failure.Node != "" {
+			counts[failure.Node]++
+		}
+	}
+
+	nodes := make([]string, 0, len(counts))
+	for node := range counts {
+		nodes = append(nodes, node)
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		if counts[nodes[i]] != counts[nodes[j]] {
+			return counts[nodes[i]] > counts[nodes[j]]
+		}
+		return nodes[i] < nodes[j]
+	})
+	return nodes
+}
+
+// Summary accumulates the failures of a run
+type Summary struct {
+	failures []Failure
+}
+
+// New creates an empty summary
+func New() *Summary {
+	return &Summary{}
+}
+
+// Add records failures of a configuration kind, skipping nil errors
+func (s *Summary) Add(kind string, errs ...error) {
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+		s.failures = append(s.failures, Failure{
+			Kind:  kind,
+			Node:  kubectl.NodeOf(err),
+			Class: Classify(err),
+			Err:   err,
+		})
+	}
+}
+
+// Len returns the number of recorded failures
+func (s *Summary) Len() int {
+	return len(s.failures)
+}
+
+// Errors returns the recorded errors in the order they were added
+func (s *Summary) Errors() []error {
+	errs := make([]error, 0, len(s.failures))
+	for _, failure := range s.failures {
+		errs = append(errs, failure.Err)
+	}
+	return errs
+}
+
+// ByClass groups the failures by error class, largest group first
+func (s *Summary) ByClass() []Group {
+	return s.group(func(f Failure) string { return f.Class }, func(group *Group) {
+		info := classes[group.Name]
+		group.Title, group.Hint = info.title, info.hint
+	})
+}
+
+// ByKind groups the failures by configuration kind, largest group first
+func (s *Summary) ByKind() []Group {
+	return s.group(func(f Failure) string { return f.Kind }, func(group *Group) {
+		group.Title = group.Name
+	})
+}
+
+// group splits the failures by key and orders the groups by size, then name
+func (s *Summary) group(key func(Failure) string, describe func(*Group)) []Group {
+	index := make(map[string]int)
+	var groups []Group
+	for _, failure := range s.failures {
+		name := key(failure)
+		i, ok := index[name]
+		if !ok {
+			i = len(groups)
+			index[name] = i
+			groups = append(groups, Group{Name: name})
+			describe(&groups[i])
+		}
+		groups[i].Failures = append(groups[i].Failures, failure)
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		if len(groups[i].Failures) != len(groups[j].Failures) {
+			return len(groups[i].Failures) > len(groups[j].Failures)
+		}
+		return groups[i].Name < groups[j].Name
+	})
+	return groups
+}
+
+// Classify determines the error class of a failure
+// Image pulls are recognised from the message first since they surface as transport failures
+func Classify(err error) string {
+	var notFound *kubectl.NodeNotFoundError
+	if errors.As(err, &notFound) {
+		return ClassNodeNotFound
+	}
+
+	text := strings.ToLower(err.Error())
+	var hostErr *kubectl.HostCommandError
+	isHostErr := errors.As(err, &hostErr)
+	if isHostErr {
+		text += "\n" + strings.ToLower(hostErr.Output)
+	}
+
+	if containsAny(text, "errimagepull", "imagepullbackoff", "failed to pull image") {
+		return ClassImagePull
+	}
+	if isHostErr {
+		if _, known := classes[hostErr.Class]; known {
+			return hostErr.Class
+		}
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) || containsAny(text, "deadline exceeded", "timed out") {
+		return ClassTimeout
+	}
+	return ClassOther
+}
+
+// containsAny reports whether text contains any of the substrings
+func containsAny(text string, substrings ...string) bool {
+	for _, substring := range substrings {
+		if strings.Contains(text, substring) {
+			return true
+		}
+	}
+	return false
+}
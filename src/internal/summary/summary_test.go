@@ -0,0 +1,84 @@
+// Package summary provides unit tests for failure grouping
+// WHY: Operators act on the summary, so failures must land in the right class with the right nodes
+package summary
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"k8ostack-ictl/internal/kubectl"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestClassify tests error classification
+// WHY: The class decides which remediation hint the operator sees
+func TestClassify(t *testing.T) {
+	cause := errors.New("exit status 1")
+
+	tests := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{name: "node not found", err: &kubectl.NodeNotFoundError{NodeName: "rsb9"}, expected: ClassNodeNotFound},
+		{
+			name:     "image pull hidden in a transport failure",
+			err:      &kubectl.HostCommandError{Node: "rsb2", Class: kubectl.HostErrorTransport, Output: "Back-off pulling image: ErrImagePull", Cause: cause},
+			expected: ClassImagePull,
+		},
+		{name: "host command class", err: &kubectl.HostCommandError{Node: "rsb2", Class: kubectl.HostErrorPermission, Cause: cause}, expected: kubectl.HostErrorPermission},
+		{name: "refused by restricted mode", err: &kubectl.HostCommandError{Node: "rsb2", Class: kubectl.HostErrorRefused, Cause: cause}, expected: kubectl.HostErrorRefused},
+		{name: "deadline", err: fmt.Errorf("labeling: %w", context.DeadlineExceeded), expected: ClassTimeout},
+		{name: "anything else", err: cause, expected: ClassOther},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Classify(tt.err))
+		})
+	}
+}
+
+// TestSummary_ByClass tests grouping failures by error class
+// WHY: The largest problem must be listed first with its most affected nodes
+func TestSummary_ByClass(t *testing.T) {
+	// Given: Three unreachable nodes, one of them twice, and a missing node
+	s := New()
+	for _, node := range []string{"rsb3", "rsb2", "rsb4", "rsb3"} {
+		s.Add("NodeVLANConf", &kubectl.HostCommandError{Node: node, Class: kubectl.HostErrorTransport, Cause: errors.New("connection refused")})
+	}
+	s.Add("NodeLabelConf", &kubectl.NodeNotFoundError{NodeName: "rsb9"}, nil)
+
+	// When: Grouping by class
+	groups := s.ByClass()
+
+	// Then: Transport failures come first, their nodes ordered by failure count
+	require.Len(t, groups, 2)
+	assert.Equal(t, 5, s.Len(), "nil errors are skipped")
+	assert.Equal(t, kubectl.HostErrorTransport, groups[0].Name)
+	assert.Equal(t, "node unreachable", groups[0].Title)
+	assert.NotEmpty(t, groups[0].Hint)
+	assert.Equal(t, []string{"rsb3", "rsb2", "rsb4"}, groups[0].Nodes())
+	assert.Equal(t, ClassNodeNotFound, groups[1].Name)
+	assert.Equal(t, []string{"rsb9"}, groups[1].Nodes())
+}
+
+// TestSummary_ByKind tests grouping failures by configuration kind
+// WHY: Shows which configuration file section to look at
+func TestSummary_ByKind(t *testing.T) {
+	s := New()
+	s.Add("NodeLabelConf", kubectl.WrapNodeError("rsb2", errors.New("forbidden")))
+	s.Add("NodeVLANConf", errors.New("invalid IP format: 10.0.0.1"), errors.New("invalid IP format: 10.0.0.2"))
+
+	groups := s.ByKind()
+
+	require.Len(t, groups, 2)
+	assert.Equal(t, "NodeVLANConf", groups[0].Title)
+	assert.Len(t, groups[0].Failures, 2)
+	assert.Empty(t, groups[0].Nodes(), "errors without a node are counted but not listed")
+	assert.Equal(t, []string{"rsb2"}, groups[1].Nodes())
+}
@@ -0,0 +1,434 @@
+package sysctl
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/kubectl"
+	"k8ostack-ictl/internal/logging"
+	"k8ostack-ictl/internal/progress"
+)
+
+// hugepagesKeyPrefix names hugepage reservations among the settings, e.g. "hugepages.1Gi"
+const hugepagesKeyPrefix = "hugepages."
+
+// Operations of the service
+const (
+	operationApply  = "apply"
+	operationRemove = "remove"
+	operationVerify = "verify"
+)
+
+// ApplySysctls sets the sysctls and hugepage reservations of every node and persists them for the next boot
+func (ss *SysctlService) ApplySysctls(ctx context.Context, cfg *config.NodeSysctlConf) (*OperationResults, error) {
+	return ss.processNodes(ctx, cfg, operationApply)
+}
+
+// RemoveSysctls restores the values the settings had before kictl changed them and removes its files
+func (ss *SysctlService) RemoveSysctls(ctx context.Context, cfg *config.NodeSysctlConf) (*OperationResults, error) {
+	return ss.processNodes(ctx, cfg, operationRemove)
+}
+
+// VerifySysctls checks the running values of the settings of every node
+func (ss *SysctlService) VerifySysctls(ctx context.Context, cfg *config.NodeSysctlConf) (*OperationResults, error) {
+	return ss.processNodes(ctx, cfg, operationVerify)
+}
+
+// Cleanup removes the debug pods left behind by an operation that was interrupted, e.g. by a service timeout
+func (ss *SysctlService) Cleanup(ctx context.Context) {
+	ss.cleanupDebugPods(ctx)
+}
+
+// processNodes runs an operation on every node of the configuration
+func (ss *SysctlService) processNodes(ctx context.Context, cfg *config.NodeSysctlConf, operation string) (*OperationResults, error) {
+	ss.kubectl.SetDryRun(ss.options.DryRun)
+
+	settings, err := cfg.Spec.NodeSettings()
+	if err != nil {
+		return nil, err
+	}
+	nodes := make([]string, 0, len(settings))
+	for nodeName := range settings {
+		nodes = append(nodes, nodeName)
+	}
+	sort.Strings(nodes)
+
+	results := &OperationResults{Deviations: make(map[string][]Deviation)}
+	if ss.options.DryRun {
+		ss.options.Logger.Info(fmt.Sprintf("🧪 DRY RUN: Simulating kernel tuning %s for %s on %d nodes...", operation, cfg.Metadata.Name, len(nodes)))
+	} else {
+		ss.options.Logger.Info(fmt.Sprintf("⚙️  Starting kernel tuning %s for %s on %d nodes...", operation, cfg.Metadata.Name, len(nodes)))
+	}
+
+	reporter := progress.Or(ss.options.Progress)
+	reporter.Begin(fmt.Sprintf("Kernel tuning %s", operation), len(nodes))
+
+	results.Order = ss.options.Workers.Order(nodes)
+	var mu sync.Mutex
+	err = ss.options.Workers.Run(nodes, func(nodeName string) {
+		node := ss.forNode(nodeName)
+		reporter.Started(nodeName)
+
+		var deviations []Deviation
+		var err error
+		switch operation {
+		case operationRemove:
+			err = node.removeNode(ctx, nodeName)
+		case operationVerify:
+			deviations, err = node.verifyNode(ctx, nodeName, settings[nodeName])
+		default:
+			deviations, err = node.applyNode(ctx, nodeName, cfg.Metadata.Name, settings[nodeName])
+		}
+		if err == nil && len(deviations) > 0 {
+			err = deviationError(deviations)
+		}
+		reporter.Done(nodeName, err != nil)
+
+		mu.Lock()
+		defer mu.Unlock()
+		results.TotalNodes++
+		if len(deviations) > 0 {
+			results.Deviations[nodeName] = deviations
+		}
+		if err != nil {
+			node.options.Logger.Error(fmt.Sprintf("Failed to %s kernel settings of node %s: %v", operation, nodeName, err))
+			results.FailedNodes = append(results.FailedNodes, nodeName)
+			results.Errors = append(results.Errors, kubectl.WrapNodeError(nodeName, err))
+			return
+		}
+		results.SuccessfulNodes++
+		if operation != operationRemove {
+			results.MatchingNodes = append(results.MatchingNodes, nodeName)
+		}
+	})
+	reporter.End()
+	if err != nil {
+		ss.options.Logger.Error(err.Error())
+		results.Errors = append(results.Errors, err)
+	}
+
+	sort.Strings(results.FailedNodes)
+	sort.Strings(results.MatchingNodes)
+	ss.options.Logger.Info(fmt.Sprintf("📊 Kernel tuning %s: %d of %d nodes succeeded", operation, results.SuccessfulNodes, results.TotalNodes))
+
+	ss.cleanupDebugPods(ctx)
+	return results, nil
+}
+
+// applyNode records the current values of new settings, writes the files and sets the running values
+// Settings recorded by an earlier apply that the configuration no longer has are restored.
+// It returns the settings the kernel did not take, e.g. hugepages it could not reserve.
+func (ss *SysctlService) applyNode(ctx context.Context, nodeName, configName string, settings config.KernelSettings) ([]Deviation, error) {
+	desired := desiredValues(settings)
+	if ss.options.DryRun {
+		for _, key := range sortedKeys(desired) {
+			ss.options.Logger.Info(fmt.Sprintf("🧪 DRY RUN: Would set %s = %s on node %s", key, desired[key], nodeName))
+		}
+		return nil, nil
+	}
+
+	recorded, err := ss.readDefaults(ctx, nodeName)
+	if err != nil {
+		return nil, err
+	}
+	var unrecorded []string
+	for _, key := range sortedKeys(desired) {
+		if _, ok := recorded[key]; !ok {
+			unrecorded = append(unrecorded, key)
+		}
+	}
+	current, err := ss.readValues(ctx, nodeName, unrecorded)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range current {
+		recorded[key] = value
+	}
+
+	// Settings no longer configured go back to their recorded value and are forgotten
+	var restores []string
+	for _, key := range sortedKeys(recorded) {
+		if _, ok := desired[key]; !ok {
+			ss.options.Logger.Info(fmt.Sprintf("↩️  Restoring %s = %s on node %s, no longer configured", key, recorded[key], nodeName))
+			restores = append(restores, writeValueCommand(key, recorded[key]))
+			delete(recorded, key)
+		}
+	}
+
+	// The defaults are written first so that a failure part way can still be undone by delete
+	commands := []string{
+		kubectl.HostCommand("mkdir", "-p", "/var/lib/kictl"),
+		writeFileCommand(DefaultsFile, renderSettings(recorded)),
+		writeOrRemoveCommand(SysctlFile, renderSysctlFile(configName, settings)),
+		writeOrRemoveCommand(HugepagesFile, renderHugepagesFile(settings)),
+	}
+	commands = append(commands, restores...)
+	for _, key := range sortedKeys(desired) {
+		commands = append(commands, writeValueCommand(key, desired[key]))
+	}
+
+	if err := ss.exec(ctx, nodeName, kubectl.JoinHostCommands(commands...)); err != nil {
+		return nil, fmt.Errorf("failed to set kernel settings: %w", err)
+	}
+	ss.options.Logger.Info(fmt.Sprintf("✅ Set %d kernel settings on node %s", len(desired), nodeName))
+
+	// The kernel may reserve fewer hugepages than asked when memory is fragmented
+	return ss.verifyValues(ctx, nodeName, desired)
+}
+
+// removeNode restores the recorded values of a node and removes the files kictl wrote
+func (ss *SysctlService) removeNode(ctx context.Context, nodeName string) error {
+	if ss.options.DryRun {
+		ss.options.Logger.Info(fmt.Sprintf("🧪 DRY RUN: Would restore the kernel settings recorded on node %s and remove %s, %s and %s",
+			nodeName, SysctlFile, HugepagesFile, DefaultsFile))
+		return nil
+	}
+
+	recorded, err := ss.readDefaults(ctx, nodeName)
+	if err != nil {
+		return err
+	}
+	var commands []string
+	for _, key := range sortedKeys(recorded) {
+		commands = append(commands, writeValueCommand(key, recorded[key]))
+	}
+	commands = append(commands, kubectl.HostCommand("rm", "-f", SysctlFile, HugepagesFile, DefaultsFile))
+
+	if err := ss.exec(ctx, nodeName, kubectl.JoinHostCommands(commands...)); err != nil {
+		return fmt.Errorf("failed to restore kernel settings: %w", err)
+	}
+	ss.options.Logger.Info(fmt.Sprintf("✅ Restored %d kernel settings on node %s", len(recorded), nodeName))
+	return nil
+}
+
+// verifyNode compares the running values of a node with the configuration
+func (ss *SysctlService) verifyNode(ctx context.Context, nodeName string, settings config.KernelSettings) ([]Deviation, error) {
+	desired := desiredValues(settings)
+	if ss.options.DryRun {
+		ss.options.Logger.Info(fmt.Sprintf("🧪 DRY RUN: Would read %d kernel settings on node %s", len(desired), nodeName))
+		return nil, nil
+	}
+	deviations, err := ss.verifyValues(ctx, nodeName, desired)
+	if err == nil && len(deviations) == 0 {
+		ss.options.Logger.Info(fmt.Sprintf("✅ Kernel settings of node %s match the configuration", nodeName))
+	}
+	return deviations, err
+}
+
+// verifyValues reads the running values of settings and returns those that differ
+func (ss *SysctlService) verifyValues(ctx context.Context, nodeName string, desired map[string]string) ([]Deviation, error) {
+	actual, err := ss.readValues(ctx, nodeName, sortedKeys(desired))
+	if err != nil {
+		return nil, err
+	}
+	var deviations []Deviation
+	for _, key := range sortedKeys(desired) {
+		if normalizeValue(actual[key]) != normalizeValue(desired[key]) {
+			deviations = append(deviations, Deviation{Key: key, Expected: desired[key], Actual: actual[key]})
+		}
+	}
+	return deviations, nil
+}
+
+// readDefaults reads the values recorded before kictl first changed the settings of a node
+func (ss *SysctlService) readDefaults(ctx context.Context, nodeName string) (map[string]string, error) {
+	output, err := ss.run(ctx, nodeName, kubectl.HostCommand("cat", DefaultsFile)+" 2>/dev/null || true")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", DefaultsFile, err)
+	}
+	return parseSettings(output), nil
+}
+
+// readValues reads the running values of settings, failing for settings the kernel does not have
+func (ss *SysctlService) readValues(ctx context.Context, nodeName string, keys []string) (map[string]string, error) {
+	values := make(map[string]string, len(keys))
+	if len(keys) == 0 {
+		return values, nil
+	}
+
+	args := []string{"-H", ""}
+	for _, key := range keys {
+		args = append(args, settingPath(key))
+	}
+	output, err := ss.run(ctx, nodeName, kubectl.HostCommand("grep", args...))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read kernel settings: %w", err)
+	}
+
+	for _, line := range strings.Split(output, "\n") {
+		for _, key := range keys {
+			if value, ok := strings.CutPrefix(line, settingPath(key)+":"); ok {
+				values[key] = strings.TrimSpace(value)
+			}
+		}
+	}
+	for _, key := range keys {
+		if _, ok := values[key]; !ok {
+			return nil, fmt.Errorf("kernel setting %s not found in %s", key, settingPath(key))
+		}
+	}
+	return values, nil
+}
+
+// run runs a node command and returns its output
+func (ss *SysctlService) run(ctx context.Context, nodeName, command string) (string, error) {
+	success, output, err := ss.kubectl.ExecNodeCommand(ctx, nodeName, command)
+	if err != nil {
+		return output, err
+	}
+	if !success {
+		return output, fmt.Errorf("command failed: %s", strings.TrimSpace(output))
+	}
+	return output, nil
+}
+
+// exec runs a node command changing the node, logging it when verbose
+func (ss *SysctlService) exec(ctx context.Context, nodeName, command string) error {
+	if ss.options.Verbose {
+		ss.options.Logger.Debug(fmt.Sprintf("Running on node %s: %s", nodeName, command))
+	}
+	_, err := ss.run(ctx, nodeName, command)
+	return err
+}
+
+// forNode returns a copy of the service whose log entries carry the node name
+func (ss *SysctlService) forNode(nodeName string) *SysctlService {
+	node := *ss
+	node.options.Logger = ss.options.Logger.With(logging.FieldNode, nodeName)
+	return &node
+}
+
+// cleanupDebugPods deletes the debug pods the executor started for this service's node commands
+func (ss *SysctlService) cleanupDebugPods(ctx context.Context) {
+	if ss.options.DryRun {
+		return
+	}
+	deletedCount, err := ss.kubectl.ReleaseDebugPods(ctx)
+	if err != nil {
+		ss.options.Logger.Warn(fmt.Sprintf("Failed to delete debug pods: %v", err))
+	} else if deletedCount > 0 {
+		ss.options.Logger.Info(fmt.Sprintf("✅ Cleaned up %d debug pods", deletedCount))
+	}
+}
+
+// desiredValues returns the values of the settings of a node by key, hugepages as hugepages.<size>
+func desiredValues(settings config.KernelSettings) map[string]string {
+	values := make(map[string]string, len(settings.Sysctls)+len(settings.Hugepages))
+	for key, value := range settings.Sysctls {
+		values[key] = value
+	}
+	for size, count := range settings.Hugepages {
+		values[hugepagesKeyPrefix+size] = fmt.Sprintf("%d", count)
+	}
+	return values
+}
+
+// settingPath returns the file a setting is read and written through
+// A sysctl name maps dots to directories, while a / in the name stands for a dot, e.g. in an interface name.
+func settingPath(key string) string {
+	if size, ok := strings.CutPrefix(key, hugepagesKeyPrefix); ok {
+		return hugepagesPath(size)
+	}
+	path := strings.Map(func(r rune) rune {
+		switch r {
+		case '.':
+			return '/'
+		case '/':
+			return '.'
+		}
+		return r
+	}, key)
+	return "/proc/sys/" + path
+}
+
+// hugepagesPath returns the file holding the number of reserved hugepages of a page size
+func hugepagesPath(size string) string {
+	return fmt.Sprintf("/sys/kernel/mm/hugepages/hugepages-%dkB/nr_hugepages", config.HugepageSizes[size])
+}
+
+// renderSysctlFile renders the sysctl.d file of a node; empty when the node has no sysctls
+func renderSysctlFile(configName string, settings config.KernelSettings) string {
+	if len(settings.Sysctls) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("# Managed by kictl, NodeSysctlConf %s\n", configName) + renderSettings(settings.Sysctls)
+}
+
+// renderHugepagesFile renders the tmpfiles.d file reserving the hugepages of a node at boot; empty without hugepages
+func renderHugepagesFile(settings config.KernelSettings) string {
+	var b strings.Builder
+	for _, size := range sortedKeys(settings.Hugepages) {
+		fmt.Fprintf(&b, "w %s - - - - %d\n", hugepagesPath(size), settings.Hugepages[size])
+	}
+	return b.String()
+}
+
+// renderSettings renders settings as sorted "key = value" lines, the format of sysctl.d files
+func renderSettings(settings map[string]string) string {
+	var b strings.Builder
+	for _, key := range sortedKeys(settings) {
+		fmt.Fprintf(&b, "%s = %s\n", key, settings[key])
+	}
+	return b.String()
+}
+
+// parseSettings parses "key = value" lines, skipping comments
+func parseSettings(content string) map[string]string {
+	settings := make(map[string]string)
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if key, value, ok := strings.Cut(line, "="); ok {
+			settings[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+	return settings
+}
+
+// writeFileCommand writes content to a file on the node
+func writeFileCommand(path, content string) string {
+	return kubectl.HostCommand("printf", "%s", content) + " > " + kubectl.QuoteShellArg(path)
+}
+
+// writeOrRemoveCommand writes content to a file, or removes the file when there is no content
+func writeOrRemoveCommand(path, content string) string {
+	if content == "" {
+		return kubectl.HostCommand("rm", "-f", path)
+	}
+	return writeFileCommand(path, content)
+}
+
+// writeValueCommand sets the running value of a setting
+func writeValueCommand(key, value string) string {
+	return kubectl.HostCommand("printf", "%s\\n", value) + " > " + kubectl.QuoteShellArg(settingPath(key))
+}
+
+// normalizeValue collapses whitespace, as multi-value sysctls are read back tab separated
+func normalizeValue(value string) string {
+	return strings.Join(strings.Fields(value), " ")
+}
+
+// deviationError describes the settings of a node that differ from the configuration
+func deviationError(deviations []Deviation) error {
+	descriptions := make([]string, len(deviations))
+	for i, deviation := range deviations {
+		descriptions[i] = fmt.Sprintf("%s is %s, not %s", deviation.Key, deviation.Actual, deviation.Expected)
+	}
+	return fmt.Errorf("%d kernel settings differ from the configuration: %s", len(deviations), strings.Join(descriptions, ", "))
+}
+
+// sortedKeys returns the keys of a map in order
+func sortedKeys[V any](values map[string]V) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,186 @@
+// Package sysctl provides unit tests for the kernel tuning service
+// WHY: Delete must bring back the values a node had before kictl, so they are recorded before anything is changed
+package sysctl
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/labeler"
+	"k8ostack-ictl/internal/logging"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// Node commands of the tests, in the order the service runs them
+const (
+	readDefaultsCommand = "cat /var/lib/kictl/sysctl-defaults 2>/dev/null || true"
+	readValuesCommand   = "grep -H '' /sys/kernel/mm/hugepages/hugepages-1048576kB/nr_hugepages /proc/sys/net/ipv4/ip_forward"
+)
+
+// nodeCommand is a node command expected by a test, matched by prefix, with its output
+type nodeCommand struct {
+	prefix string
+	output string
+}
+
+// sysctlTestConfig returns a configuration forwarding IPv4 and reserving 16 1Gi hugepages on rsb2
+func sysctlTestConfig() *config.NodeSysctlConf {
+	return &config.NodeSysctlConf{
+		APIVersion: "openstack.kictl.icycloud.io/v1",
+		Kind:       "NodeSysctlConf",
+		Metadata:   config.Metadata{Name: "kernel-tuning"},
+		Spec: config.NodeSysctlSpec{NodeRoles: map[string]config.SysctlRole{
+			"compute": {Nodes: []string{"rsb2"}, Sysctls: map[string]string{"net.ipv4.ip_forward": "1"}, Hugepages: map[string]int{"1Gi": 16}},
+		}},
+	}
+}
+
+// TestSysctlService tests applying, verifying and restoring the kernel settings of a node
+// WHY: Settings the kernel does not take, like hugepages it cannot reserve, must fail the node rather than pass silently
+func TestSysctlService(t *testing.T) {
+	tests := []struct {
+		name             string
+		operation        string
+		commands         []nodeCommand
+		expectContains   []string
+		expectDeviations []Deviation
+		expectError      string
+	}{
+		{
+			name:      "first_apply_records_defaults",
+			operation: operationApply,
+			commands: []nodeCommand{
+				{prefix: readDefaultsCommand},
+				{prefix: readValuesCommand, output: "/proc/sys/net/ipv4/ip_forward:0\n/sys/kernel/mm/hugepages/hugepages-1048576kB/nr_hugepages:0\n"},
+				{prefix: "mkdir -p /var/lib/kictl"},
+				{prefix: readValuesCommand, output: "/proc/sys/net/ipv4/ip_forward:1\n/sys/kernel/mm/hugepages/hugepages-1048576kB/nr_hugepages:16\n"},
+			},
+			expectContains: []string{
+				"printf %s 'hugepages.1Gi = 0\nnet.ipv4.ip_forward = 0\n' > /var/lib/kictl/sysctl-defaults",
+				"printf %s '# Managed by kictl, NodeSysctlConf kernel-tuning\nnet.ipv4.ip_forward = 1\n' > /etc/sysctl.d/90-kictl.conf",
+				"printf %s 'w /sys/kernel/mm/hugepages/hugepages-1048576kB/nr_hugepages - - - - 16\n' > /etc/tmpfiles.d/kictl-hugepages.conf",
+				"printf '%s\\n' 16 > /sys/kernel/mm/hugepages/hugepages-1048576kB/nr_hugepages",
+				"printf '%s\\n' 1 > /proc/sys/net/ipv4/ip_forward",
+			},
+		},
+		{
+			name:      "apply_restores_dropped_setting",
+			operation: operationApply,
+			commands: []nodeCommand{
+				{prefix: readDefaultsCommand, output: "hugepages.1Gi = 0\nnet.ipv4.ip_forward = 0\nvm.swappiness = 60\n"},
+				{prefix: "mkdir -p /var/lib/kictl"},
+				{prefix: readValuesCommand, output: "/proc/sys/net/ipv4/ip_forward:1\n/sys/kernel/mm/hugepages/hugepages-1048576kB/nr_hugepages:16\n"},
+			},
+			expectContains: []string{
+				"printf %s 'hugepages.1Gi = 0\nnet.ipv4.ip_forward = 0\n' > /var/lib/kictl/sysctl-defaults",
+				"printf '%s\\n' 60 > /proc/sys/vm/swappiness",
+			},
+		},
+		{
+			name:      "apply_short_of_hugepages",
+			operation: operationApply,
+			commands: []nodeCommand{
+				{prefix: readDefaultsCommand, output: "hugepages.1Gi = 0\nnet.ipv4.ip_forward = 0\n"},
+				{prefix: "mkdir -p /var/lib/kictl"},
+				{prefix: readValuesCommand, output: "/proc/sys/net/ipv4/ip_forward:1\n/sys/kernel/mm/hugepages/hugepages-1048576kB/nr_hugepages:9\n"},
+			},
+			expectDeviations: []Deviation{{Key: "hugepages.1Gi", Expected: "16", Actual: "9"}},
+			expectError:      "1 kernel settings differ from the configuration: hugepages.1Gi is 9, not 16",
+		},
+		{
+			name:      "verify",
+			operation: operationVerify,
+			commands: []nodeCommand{
+				{prefix: readValuesCommand, output: "/proc/sys/net/ipv4/ip_forward:0\n/sys/kernel/mm/hugepages/hugepages-1048576kB/nr_hugepages:16\n"},
+			},
+			expectDeviations: []Deviation{{Key: "net.ipv4.ip_forward", Expected: "1", Actual: "0"}},
+			expectError:      "net.ipv4.ip_forward is 0, not 1",
+		},
+		{
+			name:      "remove_restores_defaults",
+			operation: operationRemove,
+			commands: []nodeCommand{
+				{prefix: readDefaultsCommand, output: "hugepages.1Gi = 0\nnet.ipv4.ip_forward = 0\n"},
+				{prefix: "printf"},
+			},
+			expectContains: []string{
+				"printf '%s\\n' 0 > /sys/kernel/mm/hugepages/hugepages-1048576kB/nr_hugepages && printf '%s\\n' 0 > /proc/sys/net/ipv4/ip_forward" +
+					" && rm -f /etc/sysctl.d/90-kictl.conf /etc/tmpfiles.d/kictl-hugepages.conf /var/lib/kictl/sysctl-defaults",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: rsb2 answering the node commands of the case in order
+			executor := labeler.NewMockDryRunExecutor()
+			executor.On("SetDryRun", false).Return()
+			executor.On("ReleaseDebugPods", mock.Anything).Return(1, nil)
+			var changes []string
+			for _, command := range tt.commands {
+				prefix := command.prefix
+				executor.On("ExecNodeCommand", mock.Anything, "rsb2", mock.MatchedBy(func(cmd string) bool {
+					return strings.HasPrefix(cmd, prefix)
+				})).Run(func(args mock.Arguments) {
+					if cmd := args.String(2); !strings.HasPrefix(cmd, "cat") && !strings.HasPrefix(cmd, "grep") {
+						changes = append(changes, cmd)
+					}
+				}).Return(true, command.output, nil).Once()
+			}
+			service := NewService(executor, Options{Logger: logging.NewRecordingLogger()})
+
+			// When: Run the operation
+			var results *OperationResults
+			var err error
+			switch tt.operation {
+			case operationRemove:
+				results, err = service.RemoveSysctls(context.Background(), sysctlTestConfig())
+			case operationVerify:
+				results, err = service.VerifySysctls(context.Background(), sysctlTestConfig())
+			default:
+				results, err = service.ApplySysctls(context.Background(), sysctlTestConfig())
+			}
+
+			// Then: The node is changed as expected and deviations fail it
+			require.NoError(t, err)
+			executor.AssertExpectations(t)
+			for _, expected := range tt.expectContains {
+				require.Len(t, changes, 1)
+				assert.Contains(t, changes[0], expected)
+			}
+			assert.Equal(t, tt.expectDeviations, results.Deviations["rsb2"])
+			if tt.expectError != "" {
+				assert.Equal(t, []string{"rsb2"}, results.FailedNodes)
+				require.Len(t, results.Errors, 1)
+				assert.Contains(t, results.Errors[0].Error(), tt.expectError)
+				return
+			}
+			assert.Empty(t, results.Errors)
+			assert.Equal(t, 1, results.SuccessfulNodes)
+		})
+	}
+}
+
+// TestSettingPath tests the files kernel settings are read and written through
+// WHY: A dot in an interface name is written as / in the sysctl name and must not become a directory
+func TestSettingPath(t *testing.T) {
+	tests := []struct {
+		key      string
+		expected string
+	}{
+		{key: "net.ipv4.ip_forward", expected: "/proc/sys/net/ipv4/ip_forward"},
+		{key: "net.ipv4.conf.eth0/100.rp_filter", expected: "/proc/sys/net/ipv4/conf/eth0.100/rp_filter"},
+		{key: "hugepages.2Mi", expected: "/sys/kernel/mm/hugepages/hugepages-2048kB/nr_hugepages"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			assert.Equal(t, tt.expected, settingPath(tt.key))
+		})
+	}
+}
@@ -0,0 +1,80 @@
+// Package sysctl provides the core business logic for kernel tuning operations: sysctls and hugepage reservations
+package sysctl
+
+import (
+	"context"
+
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/kubectl"
+	"k8ostack-ictl/internal/logging"
+	"k8ostack-ictl/internal/progress"
+	"k8ostack-ictl/internal/throttle"
+)
+
+// Files kictl writes on a node, all removed again by delete
+const (
+	// SysctlFile holds the sysctls of the configuration, loaded at boot by systemd-sysctl
+	SysctlFile = "/etc/sysctl.d/90-kictl.conf"
+
+	// HugepagesFile reserves the hugepages of the configuration at boot through systemd-tmpfiles
+	HugepagesFile = "/etc/tmpfiles.d/kictl-hugepages.conf"
+
+	// DefaultsFile records the value each setting had before kictl first changed it, restored by delete
+	DefaultsFile = "/var/lib/kictl/sysctl-defaults"
+)
+
+// OperationResults tracks the results of kernel tuning operations
+type OperationResults struct {
+	TotalNodes      int
+	SuccessfulNodes int
+	FailedNodes     []string
+	MatchingNodes   []string               // nodes whose settings match the configuration after apply or verify
+	Deviations      map[string][]Deviation // node -> settings whose value differs from the configuration
+	Order           []string               // nodes in the order the rollout changed them
+	Errors          []error
+}
+
+// Deviation is a kernel setting whose value on a node differs from the configuration
+type Deviation struct {
+	Key      string // e.g. "net.ipv4.ip_forward" or "hugepages.1Gi"
+	Expected string
+	Actual   string
+}
+
+// Service defines the interface for the kernel tuning service
+type Service interface {
+	// ApplySysctls sets the sysctls and hugepage reservations of every node and persists them for the next boot
+	ApplySysctls(ctx context.Context, cfg *config.NodeSysctlConf) (*OperationResults, error)
+
+	// RemoveSysctls restores the values the settings had before kictl changed them and removes its files
+	RemoveSysctls(ctx context.Context, cfg *config.NodeSysctlConf) (*OperationResults, error)
+
+	// VerifySysctls checks the running values of the settings of every node
+	VerifySysctls(ctx context.Context, cfg *config.NodeSysctlConf) (*OperationResults, error)
+
+	// Cleanup removes the debug pods left behind by an interrupted operation
+	Cleanup(ctx context.Context)
+}
+
+// Options contains configuration options for the kernel tuning service
+type Options struct {
+	DryRun   bool
+	Verbose  bool
+	Workers  *throttle.Controller // Processes nodes concurrently; nil processes them one by one
+	Progress progress.Reporter    // Receives how many nodes are done; nil reports nothing
+	Logger   logging.Logger
+}
+
+// SysctlService implements the Service interface
+type SysctlService struct {
+	kubectl kubectl.DryRunExecutor
+	options Options
+}
+
+// NewService creates a new kernel tuning service
+func NewService(kubectl kubectl.DryRunExecutor, options Options) Service {
+	return &SysctlService{
+		kubectl: kubectl,
+		options: options,
+	}
+}
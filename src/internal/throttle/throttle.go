@@ -0,0 +1,319 @@
+// Package throttle sizes node worker pools by how well the Kubernetes API server keeps up
+package throttle
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"k8ostack-ictl/internal/logging"
+)
+
+// DefaultSlowCall is the API call latency above which the control plane counts as struggling
+const DefaultSlowCall = 2 * time.Second
+
+// recoveryCalls is how many healthy API calls in a row add one worker back
+const recoveryCalls = 10
+
+// Orders in which Run starts its items
+const (
+	OrderAlphabetical = "alphabetical" // By name, the default
+	OrderRandom       = "random"       // Shuffled with Options.Seed, so a seed repeats the same order
+	OrderZone         = "zone"         // One item of each zone in turn, spreading the first changes over all zones
+)
+
+// Orders lists the supported orders for flag validation and help text
+var Orders = []string{OrderAlphabetical, OrderRandom, OrderZone}
+
+// ValidateOrder returns an error unless order is one of Orders or empty
+func ValidateOrder(order string) error {
+	switch order {
+	case "", OrderAlphabetical, OrderRandom, OrderZone:
+		return nil
+	}
+	return fmt.Errorf("invalid rollout order %q, expected one of: %s", order, strings.Join(Orders, ", "))
+}
+
+// Options configures a Controller
+type Options struct {
+	MaxWorkers int           // Starting and maximum number of concurrent workers; 1 runs sequentially
+	SlowCall   time.Duration // API calls slower than this reduce the workers, default DefaultSlowCall
+	Logger     logging.Logger
+
+	// Domain returns the failure domain of an item, e.g. the zone of a node; items of one domain never run at once
+	// Without it, or for items it returns no domain for, only the number of workers limits the items in flight.
+	Domain func(item string) string
+
+	// Order is the order items start in, one of Orders; empty is OrderAlphabetical
+	Order string
+	Seed  int64 // Seed of OrderRandom
+	// Zone returns the zone of an item for OrderZone, defaulting to Domain; items without one go last
+	Zone func(item string) string
+
+	// BatchSize splits the ordered items into batches of this many, each finished before the next starts; 0 is one batch
+	BatchSize int
+	// Soak watches a finished batch before the rollout moves on, including the last one; an error aborts the rollout
+	Soak func(batch []string) error
+}
+
+// AbortError reports a rollout stopped because a batch failed its soak
+type AbortError struct {
+	Batch   []string // Items of the batch that failed its soak
+	Skipped []string // Items of later batches, never started
+	Err     error
+}
+
+func (e *AbortError) Error() string {
+	message := fmt.Sprintf("rollout aborted after batch %s: %v", strings.Join(e.Batch, ", "), e.Err)
+	if len(e.Skipped) > 0 {
+		message += fmt.Sprintf("; not started: %s", strings.Join(e.Skipped, ", "))
+	}
+	return message
+}
+
+func (e *AbortError) Unwrap() error {
+	return e.Err
+}
+
+// Controller runs work with a number of workers that adapts to API server latency and throttling
+// Throttled (429) or slow calls halve the workers, and a streak of healthy calls adds one back.
+type Controller struct {
+	options Options
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	limit   int
+	active  int
+	healthy int
+	busy    map[string]bool // Failure domains with an item in flight
+}
+
+// New creates a controller starting at options.MaxWorkers workers
+func New(options Options) *Controller {
+	if options.MaxWorkers < 1 {
+		options.MaxWorkers = 1
+	}
+	if options.SlowCall <= 0 {
+		options.SlowCall = DefaultSlowCall
+	}
+
+	if options.Zone == nil {
+		options.Zone = options.Domain
+	}
+
+	c := &Controller{options: options, limit: options.MaxWorkers, busy: make(map[string]bool)}
+	c.cond = sync.NewCond(&c.mu)
+	return c
+}
+
+// Limit returns the current number of workers
+func (c *Controller) Limit() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.limit
+}
+
+// Observe feeds back the latency of one API call and whether the API server throttled it
+func (c *Controller) Observe(latency time.Duration, throttled bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if throttled || latency > c.options.SlowCall {
+		c.healthy = 0
+		if c.limit == 1 {
+			return
+		}
+
+		reason := "throttled with 429 Too Many Requests"
+		if !throttled {
+			reason = fmt.Sprintf("API call took %s", latency.Round(time.Millisecond))
+		}
+		previous := c.limit
+		c.limit /= 2
+		c.options.Logger.Warn(fmt.Sprintf("🐢 API server is struggling (%s), reducing workers %d → %d", reason, previous, c.limit))
+		return
+	}
+
+	if c.limit == c.options.MaxWorkers {
+		return
+	}
+	c.healthy++
+	if c.healthy < recoveryCalls {
+		return
+	}
+
+	c.healthy = 0
+	c.limit++
+	c.options.Logger.Info(fmt.Sprintf("⚡ API server recovered, increasing workers %d → %d", c.limit-1, c.limit))
+	c.cond.Broadcast()
+}
+
+// Order returns the items in the order Run starts them; a nil controller keeps them as given
+// The order depends only on the items and the options, so callers can record it before or after the run.
+func (c *Controller) Order(items []string) []string {
+	ordered := append([]string(nil), items...)
+	if c == nil {
+		return ordered
+	}
+
+	sort.Strings(ordered)
+	switch c.options.Order {
+	case OrderRandom:
+		random := rand.New(rand.NewSource(c.options.Seed))
+		random.Shuffle(len(ordered), func(i, j int) {
+			ordered[i], ordered[j] = ordered[j], ordered[i]
+		})
+	case OrderZone:
+		ordered = c.zoneOrder(ordered)
+	}
+	return ordered
+}
+
+// zoneOrder takes the first item of every zone, then the second and so on, with zones and items by name
+func (c *Controller) zoneOrder(items []string) []string {
+	if c.options.Zone == nil {
+		return items
+	}
+
+	byZone := make(map[string][]string)
+	var zones []string
+	for _, item := range items {
+		zone := c.options.Zone(item)
+		if _, ok := byZone[zone]; !ok && zone != "" {
+			zones = append(zones, zone)
+		}
+		byZone[zone] = append(byZone[zone], item)
+	}
+	sort.Strings(zones)
+
+	ordered := make([]string, 0, len(items))
+	for round := 0; len(ordered) < len(items)-len(byZone[""]); round++ {
+		for _, zone := range zones {
+			if round < len(byZone[zone]) {
+				ordered = append(ordered, byZone[zone][round])
+			}
+		}
+	}
+	return append(ordered, byZone[""]...)
+}
+
+// Run calls fn for every item, with at most Limit calls in flight, and waits for all of them
+// Items start in the configured Order; a nil controller or one limited to a single worker calls fn
+// sequentially. With a Domain, an item waiting for its domain lets later items of other domains go first.
+// With a BatchSize or Soak, every batch finishes and passes its soak before the next starts; a failed
+// soak stops the rollout with an *AbortError.
+func (c *Controller) Run(items []string, fn func(item string)) error {
+	// Zones and domains are looked up before any item runs, since the lookup may call the API server Observe locks against
+	pending := c.Order(items)
+	if c == nil {
+		for _, item := range pending {
+			fn(item)
+		}
+		return nil
+	}
+	if c.options.Order != "" && c.options.Order != OrderAlphabetical {
+		c.options.Logger.Info(fmt.Sprintf("🔀 Rollout order (%s): %s", c.options.Order, strings.Join(pending, ", ")))
+	}
+
+	domains := make(map[string]string, len(items))
+	if c.options.Domain != nil && c.options.MaxWorkers > 1 {
+		for _, item := range items {
+			domains[item] = c.options.Domain(item)
+		}
+	}
+
+	batches := c.batches(pending)
+	for i, batch := range batches {
+		if len(batches) > 1 {
+			c.options.Logger.Info(fmt.Sprintf("🌊 Batch %d/%d: %s", i+1, len(batches), strings.Join(batch, ", ")))
+		}
+		c.runBatch(batch, domains, fn)
+		if c.options.Soak == nil {
+			continue
+		}
+		if err := c.options.Soak(batch); err != nil {
+			abort := &AbortError{Batch: batch, Err: err}
+			for _, later := range batches[i+1:] {
+				abort.Skipped = append(abort.Skipped, later...)
+			}
+			return abort
+		}
+	}
+	return nil
+}
+
+// batches splits the ordered items into batches of BatchSize
+func (c *Controller) batches(items []string) [][]string {
+	size := c.options.BatchSize
+	if size <= 0 || size >= len(items) {
+		return [][]string{items}
+	}
+	var batches [][]string
+	for start := 0; start < len(items); start += size {
+		end := start + size
+		if end > len(items) {
+			end = len(items)
+		}
+		batches = append(batches, items[start:end])
+	}
+	return batches
+}
+
+// runBatch calls fn for the items of one batch and waits for all of them
+func (c *Controller) runBatch(items []string, domains map[string]string, fn func(item string)) {
+	if c.options.MaxWorkers <= 1 {
+		for _, item := range items {
+			fn(item)
+		}
+		return
+	}
+
+	pending := append([]string(nil), items...)
+	var wg sync.WaitGroup
+	for len(pending) > 0 {
+		i := c.acquire(pending, domains)
+		item := pending[i]
+		pending = append(pending[:i], pending[i+1:]...)
+		wg.Add(1)
+		go func(item string) {
+			defer wg.Done()
+			defer c.release(domains[item])
+			fn(item)
+		}(item)
+	}
+	wg.Wait()
+}
+
+// acquire waits for a free worker slot and a pending item whose domain has none in flight, returning its index
+func (c *Controller) acquire(pending []string, domains map[string]string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for {
+		if c.active < c.limit {
+			for i, item := range pending {
+				domain := domains[item]
+				if domain != "" && c.busy[domain] {
+					continue
+				}
+				c.active++
+				if domain != "" {
+					c.busy[domain] = true
+				}
+				return i
+			}
+		}
+		c.cond.Wait()
+	}
+}
+
+// release frees a worker slot and the domain of the item that held it
+func (c *Controller) release(domain string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.active--
+	delete(c.busy, domain)
+	c.cond.Broadcast()
+}
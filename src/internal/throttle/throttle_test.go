@@ -0,0 +1,364 @@
+// Package throttle provides unit tests for adaptive worker concurrency
+// WHY: Workers must back off while the API server struggles and come back once it recovers
+package throttle
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"k8ostack-ictl/internal/logging"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestController_Observe tests adjusting the workers from API call feedback
+// WHY: Throttled or slow calls halve the workers, a streak of healthy calls adds one back
+func TestController_Observe(t *testing.T) {
+	type call struct {
+		latency   time.Duration
+		throttled bool
+		repeat    int
+	}
+	tests := []struct {
+		name          string
+		maxWorkers    int
+		calls         []call
+		expectedLimit int
+		expectedWarns []string
+		expectedInfos []string
+	}{
+		{
+			name:          "healthy_calls_keep_the_maximum",
+			maxWorkers:    8,
+			calls:         []call{{latency: 100 * time.Millisecond, repeat: 20}},
+			expectedLimit: 8,
+		},
+		{
+			name:          "throttled_call_halves",
+			maxWorkers:    8,
+			calls:         []call{{throttled: true, repeat: 1}},
+			expectedLimit: 4,
+			expectedWarns: []string{"🐢 API server is struggling (throttled with 429 Too Many Requests), reducing workers 8 → 4"},
+		},
+		{
+			name:          "slow_calls_never_go_below_one",
+			maxWorkers:    4,
+			calls:         []call{{latency: 3 * time.Second, repeat: 5}},
+			expectedLimit: 1,
+			expectedWarns: []string{
+				"🐢 API server is struggling (API call took 3s), reducing workers 4 → 2",
+				"🐢 API server is struggling (API call took 3s), reducing workers 2 → 1",
+			},
+		},
+		{
+			name:       "recovers_one_worker_per_streak",
+			maxWorkers: 8,
+			calls: []call{
+				{throttled: true, repeat: 1},
+				{latency: time.Millisecond, repeat: 2 * recoveryCalls},
+			},
+			expectedLimit: 6,
+			expectedWarns: []string{"🐢 API server is struggling (throttled with 429 Too Many Requests), reducing workers 8 → 4"},
+			expectedInfos: []string{
+				"⚡ API server recovered, increasing workers 4 → 5",
+				"⚡ API server recovered, increasing workers 5 → 6",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A controller starting at its maximum
+			logger := logging.NewRecordingLogger()
+			controller := New(Options{MaxWorkers: tt.maxWorkers, Logger: logger})
+
+			// When: Observe the API calls
+			for _, c := range tt.calls {
+				for i := 0; i < c.repeat; i++ {
+					controller.Observe(c.latency, c.throttled)
+				}
+			}
+
+			// Then: The workers and the logged adjustments match
+			assert.Equal(t, tt.expectedLimit, controller.Limit())
+			assert.Equal(t, tt.expectedWarns, logger.Messages(logging.LevelWarn))
+			assert.Equal(t, tt.expectedInfos, logger.Messages(logging.LevelInfo))
+		})
+	}
+}
+
+// TestController_Run tests running items within the worker limit
+// WHY: Every item runs exactly once, never with more calls in flight than the limit allows
+func TestController_Run(t *testing.T) {
+	throttled := New(Options{MaxWorkers: 4, Logger: logging.NewRecordingLogger()})
+	throttled.Observe(0, true)
+	throttled.Observe(0, true)
+
+	tests := []struct {
+		name            string
+		controller      *Controller
+		expectedMaxBusy int
+	}{
+		{"nil_controller_is_sequential", nil, 1},
+		{"single_worker_is_sequential", New(Options{MaxWorkers: 1, Logger: logging.NewRecordingLogger()}), 1},
+		{"three_workers", New(Options{MaxWorkers: 3, Logger: logging.NewRecordingLogger()}), 3},
+		{"reduced_to_one_worker", throttled, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: Ten items
+			items := []string{"n0", "n1", "n2", "n3", "n4", "n5", "n6", "n7", "n8", "n9"}
+			var mu sync.Mutex
+			var seen []string
+			busy, maxBusy := 0, 0
+
+			// When: Run them, each holding its worker briefly
+			tt.controller.Run(items, func(item string) {
+				mu.Lock()
+				busy++
+				if busy > maxBusy {
+					maxBusy = busy
+				}
+				mu.Unlock()
+
+				time.Sleep(5 * time.Millisecond)
+
+				mu.Lock()
+				busy--
+				seen = append(seen, item)
+				mu.Unlock()
+			})
+
+			// Then: All items ran, at most the limit at a time
+			sort.Strings(seen)
+			assert.Equal(t, items, seen)
+			assert.LessOrEqual(t, maxBusy, tt.expectedMaxBusy)
+		})
+	}
+}
+
+// TestController_RunDomains tests running at most one item per failure domain at once
+// WHY: Changing two nodes of one zone together can cost quorum-based services like Ceph MONs their majority
+func TestController_RunDomains(t *testing.T) {
+	// Given: Four workers and six nodes over two zones, one node without a zone
+	zones := map[string]string{"n0": "zone-a", "n1": "zone-a", "n2": "zone-a", "n3": "zone-b", "n4": "zone-b", "n5": ""}
+	controller := New(Options{MaxWorkers: 4, Logger: logging.NewRecordingLogger(), Domain: func(item string) string {
+		return zones[item]
+	}})
+	items := []string{"n0", "n1", "n2", "n3", "n4", "n5"}
+	var mu sync.Mutex
+	var seen []string
+	inFlight := make(map[string]int)
+	maxInFlight := make(map[string]int)
+	busy, maxBusy, arrivals := 0, 0, 0
+	allStarted := make(chan struct{})
+
+	// When: Run them, the first three holding their workers until all three are in flight
+	controller.Run(items, func(item string) {
+		mu.Lock()
+		busy++
+		maxBusy = max(maxBusy, busy)
+		inFlight[zones[item]]++
+		maxInFlight[zones[item]] = max(maxInFlight[zones[item]], inFlight[zones[item]])
+		arrivals++
+		first := arrivals <= 3
+		if arrivals == 3 {
+			close(allStarted)
+		}
+		mu.Unlock()
+
+		if first {
+			select {
+			case <-allStarted:
+			case <-time.After(5 * time.Second):
+				t.Errorf("%s waited for three items in flight", item)
+			}
+		}
+
+		mu.Lock()
+		busy--
+		inFlight[zones[item]]--
+		seen = append(seen, item)
+		mu.Unlock()
+	})
+
+	// Then: All items ran, one per zone at a time, with the other zone and the unzoned node running alongside
+	sort.Strings(seen)
+	assert.Equal(t, items, seen)
+	assert.Equal(t, 1, maxInFlight["zone-a"])
+	assert.Equal(t, 1, maxInFlight["zone-b"])
+	assert.Equal(t, 3, maxBusy)
+}
+
+// TestController_Order tests the order Run starts its items in
+// WHY: A rollout must be repeatable from its seed, and zone order spreads the first changes over all zones
+func TestController_Order(t *testing.T) {
+	zones := map[string]string{"n0": "zone-a", "n1": "zone-a", "n2": "zone-a", "n3": "zone-b", "n4": "zone-b", "n5": "", "n6": "zone-c"}
+	zone := func(item string) string { return zones[item] }
+	items := []string{"n5", "n4", "n3", "n2", "n1", "n0", "n6"}
+
+	tests := []struct {
+		name     string
+		options  Options
+		expected []string
+	}{
+		{
+			name:     "alphabetical_by_default",
+			options:  Options{},
+			expected: []string{"n0", "n1", "n2", "n3", "n4", "n5", "n6"},
+		},
+		{
+			name:     "zone_round_robin",
+			options:  Options{Order: OrderZone, Zone: zone},
+			expected: []string{"n0", "n3", "n6", "n1", "n4", "n2", "n5"},
+		},
+		{
+			name:     "zone_defaults_to_domain",
+			options:  Options{Order: OrderZone, Domain: zone},
+			expected: []string{"n0", "n3", "n6", "n1", "n4", "n2", "n5"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A controller with the order of the case
+			tt.options.Logger = logging.NewRecordingLogger()
+			controller := New(tt.options)
+
+			// When: Order the items
+			ordered := controller.Order(items)
+
+			// Then: They come in the expected order, and the input is left as it was
+			assert.Equal(t, tt.expected, ordered)
+			assert.Equal(t, []string{"n5", "n4", "n3", "n2", "n1", "n0", "n6"}, items)
+		})
+	}
+
+	t.Run("random_repeats_with_its_seed", func(t *testing.T) {
+		// Given: Two controllers with the same seed and one with another
+		first := New(Options{Order: OrderRandom, Seed: 42, Logger: logging.NewRecordingLogger()})
+		second := New(Options{Order: OrderRandom, Seed: 42, Logger: logging.NewRecordingLogger()})
+		other := New(Options{Order: OrderRandom, Seed: 43, Logger: logging.NewRecordingLogger()})
+
+		// When: Order the items, given in another order to the second
+		ordered := first.Order(items)
+		reversed := []string{"n6", "n0", "n1", "n2", "n3", "n4", "n5"}
+
+		// Then: The same seed gives the same order whatever the input order, another seed a different one
+		assert.Equal(t, ordered, second.Order(reversed))
+		assert.NotEqual(t, ordered, other.Order(items))
+		assert.ElementsMatch(t, items, ordered)
+	})
+
+	t.Run("nil_keeps_the_items", func(t *testing.T) {
+		var controller *Controller
+		assert.Equal(t, items, controller.Order(items))
+	})
+}
+
+// TestController_RunOrder tests that a single worker runs the items in the configured order and logs it
+// WHY: The order must be the one recorded in the results, and operators need to see it in the log
+func TestController_RunOrder(t *testing.T) {
+	// Given: One worker in zone order
+	logger := logging.NewRecordingLogger()
+	zones := map[string]string{"n0": "zone-a", "n1": "zone-a", "n2": "zone-b"}
+	controller := New(Options{MaxWorkers: 1, Logger: logger, Order: OrderZone, Zone: func(item string) string { return zones[item] }})
+	var seen []string
+
+	// When: Run the nodes
+	controller.Run([]string{"n0", "n1", "n2"}, func(item string) {
+		seen = append(seen, item)
+	})
+
+	// Then: They ran round-robin over the zones, as logged
+	assert.Equal(t, []string{"n0", "n2", "n1"}, seen)
+	assert.Equal(t, []string{"🔀 Rollout order (zone): n0, n2, n1"}, logger.Messages(logging.LevelInfo))
+}
+
+// TestController_RunBatches tests running the items batch by batch with a soak after each batch
+// WHY: A batch that fails its soak must stop the rollout before the next batch changes more nodes
+func TestController_RunBatches(t *testing.T) {
+	items := []string{"n0", "n1", "n2", "n3", "n4"}
+	tests := []struct {
+		name            string
+		maxWorkers      int
+		failAfter       int // Soak of this batch fails, counting from 1; 0 never fails
+		expectedSeen    []string
+		expectedSoaked  [][]string
+		expectedBatch   []string
+		expectedSkipped []string
+	}{
+		{
+			name:           "every_batch_soaked",
+			maxWorkers:     1,
+			expectedSeen:   items,
+			expectedSoaked: [][]string{{"n0", "n1"}, {"n2", "n3"}, {"n4"}},
+		},
+		{
+			name:            "failed_soak_aborts",
+			maxWorkers:      1,
+			failAfter:       1,
+			expectedSeen:    []string{"n0", "n1"},
+			expectedSoaked:  [][]string{{"n0", "n1"}},
+			expectedBatch:   []string{"n0", "n1"},
+			expectedSkipped: []string{"n2", "n3", "n4"},
+		},
+		{
+			name:            "concurrent_batches",
+			maxWorkers:      4,
+			failAfter:       2,
+			expectedSeen:    []string{"n0", "n1", "n2", "n3"},
+			expectedSoaked:  [][]string{{"n0", "n1"}, {"n2", "n3"}},
+			expectedBatch:   []string{"n2", "n3"},
+			expectedSkipped: []string{"n4"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: Batches of two, with a soak that records the batch and fails where the case says
+			logger := logging.NewRecordingLogger()
+			var soaked [][]string
+			controller := New(Options{MaxWorkers: tt.maxWorkers, Logger: logger, BatchSize: 2, Soak: func(batch []string) error {
+				soaked = append(soaked, append([]string(nil), batch...))
+				if len(soaked) == tt.failAfter {
+					return errors.New("node n1 is NotReady")
+				}
+				return nil
+			}})
+			var mu sync.Mutex
+			var seen []string
+
+			// When: Run the items
+			err := controller.Run(items, func(item string) {
+				mu.Lock()
+				defer mu.Unlock()
+				seen = append(seen, item)
+			})
+
+			// Then: Only the batches up to the failed soak ran, and the error names the batch and what was skipped
+			sort.Strings(seen)
+			assert.Equal(t, tt.expectedSeen, seen)
+			for i, batch := range soaked {
+				sort.Strings(batch)
+				soaked[i] = batch
+			}
+			assert.Equal(t, tt.expectedSoaked, soaked)
+			assert.Contains(t, logger.Messages(logging.LevelInfo), "🌊 Batch 1/3: n0, n1")
+			if tt.failAfter == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			var abort *AbortError
+			if assert.ErrorAs(t, err, &abort) {
+				assert.Equal(t, tt.expectedBatch, abort.Batch)
+				assert.Equal(t, tt.expectedSkipped, abort.Skipped)
+				assert.Contains(t, err.Error(), "node n1 is NotReady")
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+// Package ui decides how much of the console output of kictl is printed and whether it is colored
+// Quiet mode keeps errors and the final summaries; color follows --color, --no-color and NO_COLOR.
+package ui
+
+import (
+	"io"
+	"os"
+
+	"k8ostack-ictl/internal/logging"
+	"k8ostack-ictl/internal/progress"
+	"k8ostack-ictl/internal/settings"
+)
+
+// Options are the console output flags of a command
+type Options struct {
+	Quiet bool   // Print only errors and the final summaries
+	Color string // settings.ColorAuto, ColorAlways or ColorNever; empty is auto
+}
+
+// Colored reports whether output to w is colored
+func (o Options) Colored(w io.Writer) bool {
+	switch o.Color {
+	case settings.ColorAlways:
+		return true
+	case settings.ColorNever:
+		return false
+	}
+	return SupportsColor(w)
+}
+
+// Chatter returns the writer for status lines such as the bundle summary: w, or io.Discard in quiet mode
+func (o Options) Chatter(w io.Writer) io.Writer {
+	if o.Quiet {
+		return io.Discard
+	}
+	return w
+}
+
+// Progress reports whether the live progress line may be drawn
+func (o Options) Progress() bool {
+	return !o.Quiet
+}
+
+// ConsoleLevel returns the lowest log level printed on the console: errors in quiet mode, every level otherwise
+func (o Options) ConsoleLevel() string {
+	if o.Quiet {
+		return logging.LevelError
+	}
+	return ""
+}
+
+// SupportsColor reports whether w is a terminal and NO_COLOR is unset
+func SupportsColor(w io.Writer) bool {
+	if _, disabled := os.LookupEnv("NO_COLOR"); disabled {
+		return false
+	}
+	return progress.IsTerminal(w)
+}
@@ -0,0 +1,62 @@
+// Package ui provides unit tests for the console output options
+// WHY: Scripts and CI logs need quiet, uncolored output without losing errors
+package ui
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"k8ostack-ictl/internal/logging"
+	"k8ostack-ictl/internal/settings"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestOptions tests what each output mode prints
+// WHY: Quiet mode drops status lines and warnings, but never errors
+func TestOptions(t *testing.T) {
+	var out bytes.Buffer
+
+	normal := Options{}
+	assert.Same(t, &out, normal.Chatter(&out))
+	assert.True(t, normal.Progress())
+	assert.Empty(t, normal.ConsoleLevel())
+
+	quiet := Options{Quiet: true}
+	assert.Equal(t, io.Discard, quiet.Chatter(&out))
+	assert.False(t, quiet.Progress())
+	assert.Equal(t, logging.LevelError, quiet.ConsoleLevel())
+}
+
+// TestOptions_Colored tests when output is colored
+// WHY: Escape codes must never end up in redirected output, and NO_COLOR must win over auto detection
+func TestOptions_Colored(t *testing.T) {
+	tests := []struct {
+		name     string
+		color    string
+		noColor  bool
+		expected bool
+	}{
+		{name: "auto_not_a_terminal", color: settings.ColorAuto},
+		{name: "empty_is_auto"},
+		{name: "always", color: settings.ColorAlways, expected: true},
+		{name: "always_ignores_no_color", color: settings.ColorAlways, noColor: true, expected: true},
+		{name: "never", color: settings.ColorNever},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: NO_COLOR as in the case
+			if tt.noColor {
+				t.Setenv("NO_COLOR", "1")
+			}
+
+			// When: Ask whether a buffer gets colors
+			colored := Options{Color: tt.color}.Colored(&bytes.Buffer{})
+
+			// Then: Only the explicit modes override detection
+			assert.Equal(t, tt.expected, colored)
+		})
+	}
+}
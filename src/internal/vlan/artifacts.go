@@ -0,0 +1,30 @@
+package vlan
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// saveArtifacts keeps a local copy of the persistent configuration files a transaction installed
+// The copies go to <ArtifactDir>/<node>/<path on the node>, so reviewers can read what landed without access to the node.
+// Failing to save only warns, as the files are installed on the node already.
+func (vs *VLANService) saveArtifacts(tx *vlanTransaction) {
+	if vs.options.ArtifactDir == "" || vs.options.DryRun || len(tx.files) == 0 {
+		return
+	}
+
+	nodeDir := filepath.Join(vs.options.ArtifactDir, tx.nodeName)
+	for _, file := range tx.files {
+		local := filepath.Join(nodeDir, filepath.FromSlash(file.Path))
+		err := os.MkdirAll(filepath.Dir(local), 0755)
+		if err == nil {
+			err = os.WriteFile(local, []byte(file.Content), 0644)
+		}
+		if err != nil {
+			vs.options.Logger.Warn(fmt.Sprintf("⚠️  Failed to save a copy of %s from node %s: %v", file.Path, tx.nodeName, err))
+			return
+		}
+	}
+	vs.options.Logger.Debug(fmt.Sprintf("Saved copies of the %s configuration of %s on node %s to %s", tx.backend.name(), tx.vlanInterface, tx.nodeName, nodeDir))
+}
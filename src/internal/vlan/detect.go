@@ -0,0 +1,165 @@
+package vlan
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/kubectl"
+)
+
+// linkSpeedCommand lists the physical and bond interfaces of a node, then the link speed of every interface
+// Missing bonding directories are expected, so only the exit status of grep counts.
+const linkSpeedCommand = "ls -d /sys/class/net/*/device /sys/class/net/*/bonding 2>/dev/null; grep -s -H . /sys/class/net/*/speed"
+
+// nodeInterface returns the parent interface of a VLAN on a node, detecting it once per node when the VLAN sets none
+// Configured names go through the interface aliases of the node, detected names are real interfaces already.
+func (vs *VLANService) nodeInterface(ctx context.Context, nodeName string, vlanConfig config.VLANConfig) (string, error) {
+	if vlanConfig.Interface != "" || vs.options.InterfaceDetection == "" {
+		name := vs.physicalInterface(vlanConfig)
+		resolved, err := vs.options.InterfaceAliases.Resolve(nodeName, name)
+		if err != nil {
+			return "", err
+		}
+		if resolved != name && vs.options.Verbose {
+			vs.options.Logger.Info(fmt.Sprintf("    🔗 Interface alias %s is %s on node %s", name, resolved, nodeName))
+		}
+		return resolved, nil
+	}
+
+	if detected, ok := vs.detected.get(nodeName); ok {
+		return detected, nil
+	}
+
+	var detected string
+	var err error
+	switch vs.options.InterfaceDetection {
+	case config.InterfaceDetectionInternalIP:
+		detected, err = vs.detectInternalIPInterface(ctx, nodeName)
+	case config.InterfaceDetectionFastestLink:
+		detected, err = vs.detectFastestLinkInterface(ctx, nodeName)
+	default:
+		err = fmt.Errorf("unknown interface detection strategy '%s'", vs.options.InterfaceDetection)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to detect the %s interface: %w", vs.options.InterfaceDetection, err)
+	}
+
+	if !vs.options.DryRun {
+		vs.options.Logger.Info(fmt.Sprintf("  🔎 Detected interface %s on node %s (%s)", detected, nodeName, vs.options.InterfaceDetection))
+	}
+	vs.detected.set(nodeName, detected)
+	return detected, nil
+}
+
+// detectInternalIPInterface finds the interface carrying the InternalIP the node reports to Kubernetes
+// Dry runs cannot run node commands, so they report the address the interface will be chosen by.
+func (vs *VLANService) detectInternalIPInterface(ctx context.Context, nodeName string) (string, error) {
+	success, internalIP, err := vs.kubectl.GetNodeInternalIP(ctx, nodeName)
+	if err != nil {
+		return "", err
+	}
+	if !success || internalIP == "" {
+		return "", fmt.Errorf("node %s reports no InternalIP address", nodeName)
+	}
+
+	if vs.options.DryRun {
+		placeholder := fmt.Sprintf("<interface of %s>", internalIP)
+		vs.options.Logger.Info(fmt.Sprintf("  🧪 DRY RUN: Node %s would use the interface carrying its InternalIP %s", nodeName, internalIP))
+		return placeholder, nil
+	}
+
+	success, output, err := vs.kubectl.ExecNodeCommand(ctx, nodeName, kubectl.HostCommand("ip", "-o", "addr", "show"))
+	if err != nil {
+		return "", err
+	}
+	if !success {
+		return "", fmt.Errorf("listing addresses failed: %s", output)
+	}
+
+	detected := parseAddressInterface(output, internalIP)
+	if detected == "" {
+		return "", fmt.Errorf("no interface on node %s carries InternalIP %s", nodeName, internalIP)
+	}
+	return detected, nil
+}
+
+// detectFastestLinkInterface finds the physical or bond interface with the highest link speed
+func (vs *VLANService) detectFastestLinkInterface(ctx context.Context, nodeName string) (string, error) {
+	if vs.options.DryRun {
+		vs.options.Logger.Info(fmt.Sprintf("  🧪 DRY RUN: Node %s would use its fastest physical link", nodeName))
+		return "<fastest link>", nil
+	}
+
+	success, output, err := vs.kubectl.ExecNodeCommand(ctx, nodeName, linkSpeedCommand)
+	if err != nil {
+		return "", err
+	}
+	if !success {
+		return "", fmt.Errorf("reading link speeds failed: %s", output)
+	}
+
+	detected := parseFastestLink(output)
+	if detected == "" {
+		return "", fmt.Errorf("no physical interface with a known link speed on node %s", nodeName)
+	}
+	return detected, nil
+}
+
+// parseAddressInterface returns the interface of `ip -o addr show` output that carries address
+// Example line: "2: eth0    inet 10.0.0.12/24 brd 10.0.0.255 scope global eth0\       valid_lft forever"
+func parseAddressInterface(output, address string) string {
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 || (fields[2] != "inet" && fields[2] != "inet6") {
+			continue
+		}
+		if ip, _, _ := strings.Cut(fields[3], "/"); ip == address {
+			name, _, _ := strings.Cut(fields[1], "@")
+			return name
+		}
+	}
+	return ""
+}
+
+// parseFastestLink returns the physical or bond interface with the highest speed in linkSpeedCommand output
+// Ties go to the first interface by name so repeated runs choose the same one.
+func parseFastestLink(output string) string {
+	candidates := make(map[string]bool)
+	speeds := make(map[string]int)
+
+	for _, line := range strings.Split(output, "\n") {
+		path, value, isSpeed := strings.Cut(strings.TrimSpace(line), ":")
+		parts := strings.Split(path, "/")
+		if len(parts) != 6 || parts[1] != "sys" || parts[3] != "net" {
+			continue
+		}
+		name := parts[4]
+		if !isSpeed {
+			candidates[name] = true
+			continue
+		}
+		if speed, err := strconv.Atoi(value); err == nil && speed > 0 {
+			speeds[name] = speed
+		}
+	}
+
+	names := make([]string, 0, len(speeds))
+	for name := range speeds {
+		if candidates[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	fastest := ""
+	for _, name := range names {
+		if fastest == "" || speeds[name] > speeds[fastest] {
+			fastest = name
+		}
+	}
+	return fastest
+}
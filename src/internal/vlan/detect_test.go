@@ -0,0 +1,279 @@
+// Package vlan provides tests for per-node interface detection
+// WHY: A wrongly detected parent interface puts VLANs on the wrong network
+package vlan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/logging"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// detectTestAddresses is `ip -o addr show` output of a node with an InternalIP on ens3
+const detectTestAddresses = `1: lo    inet 127.0.0.1/8 scope host lo\       valid_lft forever preferred_lft forever
+2: ens3    inet 10.0.0.12/24 brd 10.0.0.255 scope global ens3\       valid_lft forever preferred_lft forever
+2: ens3    inet6 fe80::1/64 scope link \       valid_lft forever preferred_lft forever
+4: ens3.100@ens3    inet 192.168.100.12/24 scope global ens3.100\       valid_lft forever preferred_lft forever`
+
+// TestParseAddressInterface tests finding the interface carrying an address
+// WHY: The InternalIP strategy must pick the interface itself, not a VLAN on top of it
+func TestParseAddressInterface(t *testing.T) {
+	tests := []struct {
+		name     string
+		address  string
+		expected string
+	}{
+		{name: "internal_ip_on_physical_interface", address: "10.0.0.12", expected: "ens3"},
+		{name: "ipv6_address", address: "fe80::1", expected: "ens3"},
+		{name: "vlan_interface_name_drops_parent", address: "192.168.100.12", expected: "ens3.100"},
+		{name: "prefix_of_another_address", address: "10.0.0.1", expected: ""},
+		{name: "unknown_address", address: "10.9.9.9", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, parseAddressInterface(detectTestAddresses, tt.address))
+		})
+	}
+}
+
+// TestParseFastestLink tests choosing the fastest physical or bond interface
+// WHY: Virtual interfaces report high speeds too and must never become VLAN parents
+func TestParseFastestLink(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   string
+		expected string
+	}{
+		{
+			name: "fastest_physical_interface",
+			output: `/sys/class/net/eno1/device
+/sys/class/net/ens5f0/device
+/sys/class/net/eno1/speed:1000
+/sys/class/net/ens5f0/speed:25000
+/sys/class/net/veth12ab/speed:100000`,
+			expected: "ens5f0",
+		},
+		{
+			name: "bond_counts_as_candidate",
+			output: `/sys/class/net/eno1/device
+/sys/class/net/eno2/device
+/sys/class/net/bond0/bonding
+/sys/class/net/bond0/speed:20000
+/sys/class/net/eno1/speed:10000
+/sys/class/net/eno2/speed:10000`,
+			expected: "bond0",
+		},
+		{
+			name: "tie_goes_to_first_name",
+			output: `/sys/class/net/eno2/device
+/sys/class/net/eno1/device
+/sys/class/net/eno2/speed:10000
+/sys/class/net/eno1/speed:10000`,
+			expected: "eno1",
+		},
+		{
+			name: "unknown_speed_is_ignored",
+			output: `/sys/class/net/eno1/device
+/sys/class/net/eno2/device
+/sys/class/net/eno1/speed:-1
+/sys/class/net/eno2/speed:1000`,
+			expected: "eno2",
+		},
+		{
+			name:     "no_physical_interface",
+			output:   `/sys/class/net/veth12ab/speed:10000`,
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, parseFastestLink(tt.output))
+		})
+	}
+}
+
+// TestVLANService_InterfaceDetection tests choosing the parent interface of VLANs without one per node
+// WHY: Nodes name their NICs differently, so one static fallback like eth0 is wrong on mixed hardware
+func TestVLANService_InterfaceDetection(t *testing.T) {
+	tests := []struct {
+		name            string
+		detection       string
+		vlanInterface   string
+		dryRun          bool
+		setupMocks      func(*MockDryRunExecutor)
+		expectInterface string
+		expectFailure   bool
+	}{
+		{
+			name:      "internal_ip_interface",
+			detection: config.InterfaceDetectionInternalIP,
+			setupMocks: func(mockKubectl *MockDryRunExecutor) {
+				mockKubectl.On("GetNodeInternalIP", mock.Anything, "node1").Return(true, "10.0.0.12", nil).Once()
+				mockKubectl.On("ExecNodeCommand", mock.Anything, "node1", "ip -o addr show").Return(true, detectTestAddresses, nil).Once()
+				mockKubectl.On("ExecNodeCommand", mock.Anything, "node1", mock.MatchedBy(func(cmd string) bool {
+					return cmd != "ip -o addr show"
+				})).Return(true, addrOutput("ens3.100=192.168.100.12/24", "ens3.200=192.168.200.12/24"), nil)
+			},
+			expectInterface: "ens3",
+		},
+		{
+			name:      "fastest_link_interface",
+			detection: config.InterfaceDetectionFastestLink,
+			setupMocks: func(mockKubectl *MockDryRunExecutor) {
+				mockKubectl.On("ExecNodeCommand", mock.Anything, "node1", linkSpeedCommand).
+					Return(true, "/sys/class/net/eno1/device\n/sys/class/net/eno2/device\n/sys/class/net/eno1/speed:1000\n/sys/class/net/eno2/speed:10000", nil).Once()
+				mockKubectl.On("ExecNodeCommand", mock.Anything, "node1", mock.MatchedBy(func(cmd string) bool {
+					return cmd != linkSpeedCommand
+				})).Return(true, addrOutput("eno2.100=192.168.100.12/24", "eno2.200=192.168.200.12/24"), nil)
+			},
+			expectInterface: "eno2",
+		},
+		{
+			name:          "explicit_interface_skips_detection",
+			detection:     config.InterfaceDetectionInternalIP,
+			vlanInterface: "bond0",
+			setupMocks: func(mockKubectl *MockDryRunExecutor) {
+				mockKubectl.On("ExecNodeCommand", mock.Anything, "node1", mock.Anything).
+					Return(true, addrOutput("bond0.100=192.168.100.12/24", "bond0.200=192.168.200.12/24"), nil)
+			},
+			expectInterface: "bond0",
+		},
+		{
+			name:      "dry_run_reports_internal_ip",
+			detection: config.InterfaceDetectionInternalIP,
+			dryRun:    true,
+			setupMocks: func(mockKubectl *MockDryRunExecutor) {
+				mockKubectl.On("GetNodeInternalIP", mock.Anything, "node1").Return(true, "10.0.0.12", nil).Once()
+				mockKubectl.On("ExecNodeCommand", mock.Anything, "node1", mock.MatchedBy(func(cmd string) bool {
+					return cmd != "ip -o addr show"
+				})).Return(true, "", nil)
+			},
+			expectInterface: "<interface of 10.0.0.12>",
+		},
+		{
+			name:      "missing_internal_ip_fails_node",
+			detection: config.InterfaceDetectionInternalIP,
+			setupMocks: func(mockKubectl *MockDryRunExecutor) {
+				mockKubectl.On("GetNodeInternalIP", mock.Anything, "node1").Return(false, "", errors.New("node node1 reports no InternalIP address"))
+			},
+			expectFailure: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: Two VLANs on one node sharing the interface under test
+			mockKubectl := NewMockDryRunExecutor()
+			mockKubectl.On("SetDryRun", tt.dryRun).Return()
+			mockKubectl.On("ReleaseDebugPods", mock.Anything).Return(0, nil).Maybe()
+			tt.setupMocks(mockKubectl)
+
+			mockLogger := logging.NewMockLogger()
+			mockLogger.On("Info", mock.AnythingOfType("string")).Return().Maybe()
+			mockLogger.On("Warn", mock.AnythingOfType("string")).Return().Maybe()
+			mockLogger.On("Error", mock.AnythingOfType("string")).Return().Maybe()
+			mockLogger.On("Debug", mock.AnythingOfType("string")).Return().Maybe()
+
+			service := NewService(mockKubectl, Options{
+				DryRun:             tt.dryRun,
+				InterfaceDetection: tt.detection,
+				Logger:             mockLogger,
+			})
+
+			vlanConfig := &config.NodeVLANConf{
+				APIVersion: "openstack.kictl.icycloud.io/v1",
+				Kind:       "NodeVLANConf",
+				Metadata:   config.Metadata{Name: "detect-test"},
+				Spec: config.NodeVLANSpec{
+					VLANs: map[string]config.VLANConfig{
+						"management": {ID: 100, Subnet: "192.168.100.0/24", Interface: tt.vlanInterface,
+							NodeMapping: map[string]string{"node1": "192.168.100.12/24"}},
+						"storage": {ID: 200, Subnet: "192.168.200.0/24", Interface: tt.vlanInterface,
+							NodeMapping: map[string]string{"node1": "192.168.200.12/24"}},
+					},
+				},
+			}
+
+			// When: Configure VLANs
+			results, err := service.ConfigureVLANs(context.Background(), vlanConfig)
+
+			// Then: Both VLANs use the interface detected once for the node
+			require.NoError(t, err)
+			if tt.expectFailure {
+				assert.Equal(t, []string{"node1", "node1"}, results.FailedNodes)
+				assert.ErrorContains(t, results.Errors[0], "failed to detect the internal-ip interface")
+				return
+			}
+			require.Len(t, results.ConfiguredVLANs["node1"], 2)
+			for _, info := range results.ConfiguredVLANs["node1"] {
+				assert.Equal(t, tt.expectInterface, info.PhysInterface)
+				assert.Equal(t, fmt.Sprintf("%s.%d", tt.expectInterface, info.VLANId), info.Interface)
+			}
+			mockKubectl.AssertExpectations(t)
+		})
+	}
+}
+
+// TestVLANService_InterfaceAliases tests resolving logical interface names on each node
+// WHY: One shared VLAN definition must land on ens1f0 on one node and eno1 on another
+func TestVLANService_InterfaceAliases(t *testing.T) {
+	// Given: A VLAN on the uplink1 alias of two nodes and one on an alias only rsb4 defines
+	mockKubectl := NewMockDryRunExecutor()
+	mockKubectl.On("SetDryRun", false).Return()
+	mockKubectl.On("ReleaseDebugPods", mock.Anything).Return(0, nil)
+	mockKubectl.On("ExecNodeCommand", mock.Anything, "rsb2", mock.MatchedBy(func(cmd string) bool {
+		return strings.Contains(cmd, "ip link add link ens1f0 name ens1f0.100")
+	})).Return(true, addrOutput("ens1f0.100=192.168.100.12/24"), nil)
+	mockKubectl.On("ExecNodeCommand", mock.Anything, "rsb3", mock.MatchedBy(func(cmd string) bool {
+		return strings.Contains(cmd, "ip link add link eno1 name eno1.100")
+	})).Return(true, addrOutput("eno1.100=192.168.100.13/24"), nil)
+
+	mockLogger := logging.NewMockLogger()
+	mockLogger.On("Info", mock.AnythingOfType("string")).Return().Maybe()
+	mockLogger.On("Warn", mock.AnythingOfType("string")).Return().Maybe()
+	mockLogger.On("Error", mock.AnythingOfType("string")).Return().Maybe()
+
+	service := NewService(mockKubectl, Options{
+		InterfaceAliases: config.InterfaceAliases{
+			config.AnyNode: {"uplink1": "eno1"},
+			"rsb2":         {"uplink1": "ens1f0"},
+			"rsb4":         {"storage": "ens2f0"},
+		},
+		Logger: mockLogger,
+	})
+	vlanConfig := &config.NodeVLANConf{
+		APIVersion: "openstack.kictl.icycloud.io/v1",
+		Kind:       "NodeVLANConf",
+		Metadata:   config.Metadata{Name: "alias-test"},
+		Spec: config.NodeVLANSpec{
+			VLANs: map[string]config.VLANConfig{
+				"management": {ID: 100, Subnet: "192.168.100.0/24", Interface: "uplink1",
+					NodeMapping: map[string]string{"rsb2": "192.168.100.12/24", "rsb3": "192.168.100.13/24"}},
+				"storage": {ID: 200, Subnet: "192.168.200.0/24", Interface: "storage",
+					NodeMapping: map[string]string{"rsb3": "192.168.200.13/24"}},
+			},
+		},
+	}
+
+	// When: Configure VLANs
+	results, err := service.ConfigureVLANs(context.Background(), vlanConfig)
+
+	// Then: Each node uses its own interface and an alias missing on a node fails that node
+	require.NoError(t, err)
+	assert.Equal(t, "ens1f0", results.ConfiguredVLANs["rsb2"][0].PhysInterface)
+	assert.Equal(t, "eno1", results.ConfiguredVLANs["rsb3"][0].PhysInterface)
+	assert.Equal(t, []string{"rsb3"}, results.FailedNodes)
+	require.Len(t, results.Errors, 1)
+	assert.ErrorContains(t, results.Errors[0], "interface alias storage is not defined for node rsb3")
+	mockKubectl.AssertExpectations(t)
+}
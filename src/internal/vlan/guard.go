@@ -0,0 +1,136 @@
+package vlan
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/kubectl"
+)
+
+// reachabilityGuard refuses network changes that could cut kictl off from a node with no other way in
+type reachabilityGuard struct {
+	config    config.ReachabilityGuard
+	addresses map[string]string // node -> address of the management VLAN
+}
+
+// newReachabilityGuard returns the guard of a config's changes, or nil when none is configured
+func (vs *VLANService) newReachabilityGuard(cfg *config.NodeVLANConf) *reachabilityGuard {
+	if vs.options.ReachabilityGuard == nil {
+		return nil
+	}
+	guard := &reachabilityGuard{config: *vs.options.ReachabilityGuard}
+	if management, ok := cfg.Spec.VLANs[guard.config.ManagementVLAN]; ok {
+		guard.addresses = management.NodeMapping
+	}
+	return guard
+}
+
+// checkReachability runs before a change to a node's VLAN interface is committed
+// It reports whether the change touches the interface kictl reaches the node through, so it is checked again afterwards.
+func (vs *VLANService) checkReachability(ctx context.Context, nodeName, vlanInterface, physInterface string) (bool, error) {
+	if vs.guard == nil {
+		return false, nil
+	}
+	if vs.options.DryRun {
+		vs.options.Logger.Info(fmt.Sprintf("  🧪 DRY RUN: Would check that node %s stays reachable while %s changes", nodeName, vlanInterface))
+		return false, nil
+	}
+
+	success, internalIP, err := vs.kubectl.GetNodeInternalIP(ctx, nodeName)
+	if err != nil {
+		return false, fmt.Errorf("reachability guard: %w", err)
+	}
+	if !success || internalIP == "" {
+		return false, fmt.Errorf("reachability guard: node %s reports no InternalIP address", nodeName)
+	}
+
+	success, output, err := vs.kubectl.ExecNodeCommand(ctx, nodeName, kubectl.HostCommand("ip", "-o", "addr", "show"))
+	if err != nil {
+		return false, fmt.Errorf("reachability guard: %w", err)
+	}
+	if !success {
+		return false, fmt.Errorf("reachability guard: listing addresses failed: %s", output)
+	}
+
+	managementInterface := parseAddressInterface(output, internalIP)
+	if managementInterface == "" {
+		return false, fmt.Errorf("reachability guard: no interface on node %s carries InternalIP %s", nodeName, internalIP)
+	}
+	if vlanInterface != managementInterface && physInterface != managementInterface {
+		return false, nil
+	}
+
+	path, err := vs.alternatePath(ctx, nodeName, internalIP, output, managementInterface, vlanInterface)
+	if err == nil {
+		vs.options.Logger.Info(fmt.Sprintf("  🛟 Node %s stays reachable through %s while %s changes", nodeName, path, vlanInterface))
+		return true, nil
+	}
+	if vs.options.Force {
+		vs.options.Logger.Warn(fmt.Sprintf("⚠️  Changing %s on node %s without another way in (forced): %v", vlanInterface, nodeName, err))
+		return true, nil
+	}
+	return false, fmt.Errorf("refusing to change %s: kictl reaches node %s only through %s (%v); use --force to change it anyway",
+		vlanInterface, nodeName, managementInterface, err)
+}
+
+// alternatePath finds a way into the node that does not depend on the management interface or the changed interface
+func (vs *VLANService) alternatePath(ctx context.Context, nodeName, internalIP, addrOutput, managementInterface, vlanInterface string) (string, error) {
+	var reasons []string
+
+	if vlanName := vs.guard.config.ManagementVLAN; vlanName != "" {
+		address, _, _ := strings.Cut(config.PrimaryAddress(vs.guard.addresses[nodeName]), "/")
+		carrier := ""
+		if address != "" {
+			carrier = parseAddressInterface(addrOutput, address)
+		}
+		switch {
+		case address == "":
+			reasons = append(reasons, fmt.Sprintf("the node has no %s VLAN address", vlanName))
+		case carrier == "":
+			reasons = append(reasons, fmt.Sprintf("%s VLAN address %s is not configured yet", vlanName, address))
+		case carrier == managementInterface || carrier == vlanInterface:
+			reasons = append(reasons, fmt.Sprintf("%s VLAN address %s is on %s itself", vlanName, address, carrier))
+		default:
+			return fmt.Sprintf("%s VLAN %s (%s)", vlanName, carrier, address), nil
+		}
+	}
+
+	if command := vs.guard.config.Command; command != "" {
+		command = strings.NewReplacer("{node}", nodeName, "{ip}", internalIP).Replace(command)
+		output, err := vs.runLocalCommand(ctx, command)
+		if err == nil {
+			return fmt.Sprintf("'%s'", command), nil
+		}
+		reason := fmt.Sprintf("'%s' failed: %v", command, err)
+		if output = strings.TrimSpace(output); output != "" {
+			reason += ": " + output
+		}
+		reasons = append(reasons, reason)
+	}
+
+	return "", fmt.Errorf("no alternate path: %s", strings.Join(reasons, "; "))
+}
+
+// runLocalCommand runs a guard command on the machine running kictl
+func (vs *VLANService) runLocalCommand(ctx context.Context, command string) (string, error) {
+	if vs.options.LocalCommand != nil {
+		return vs.options.LocalCommand(ctx, command)
+	}
+	output, err := exec.CommandContext(ctx, "sh", "-c", command).CombinedOutput()
+	return string(output), err
+}
+
+// confirmReachability checks, after a change to the management interface, that kictl still reaches the node through it
+func (vs *VLANService) confirmReachability(ctx context.Context, nodeName, vlanInterface string) error {
+	success, output, err := vs.kubectl.ExecNodeCommand(ctx, nodeName, "true")
+	if err == nil && !success {
+		err = fmt.Errorf("%s", output)
+	}
+	if err != nil {
+		return fmt.Errorf("node %s no longer answers after %s changed, recover it through its alternate path: %w", nodeName, vlanInterface, err)
+	}
+	return nil
+}
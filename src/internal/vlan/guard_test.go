@@ -0,0 +1,158 @@
+// Package vlan provides unit tests for the reachability guard
+// WHY: A VLAN change on the only interface kictl reaches a node through can strand the node
+package vlan
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/logging"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestVLANService_ConfigureVLANs_ReachabilityGuard tests which changes the guard lets through
+// WHY: Changes to the management interface need another way in, checked before and after, unless forced
+func TestVLANService_ConfigureVLANs_ReachabilityGuard(t *testing.T) {
+	isCommit := func(cmd string) bool { return strings.Contains(cmd, "ip link add") && strings.Contains(cmd, ".200") }
+	listCmd := "ip -o addr show"
+	withManagementVLAN := addrOutput("eth0=10.0.0.12/24", "eth1.100=192.168.100.10/24")
+	withoutManagementVLAN := addrOutput("eth0=10.0.0.12/24")
+
+	tests := []struct {
+		name          string
+		parent        string
+		guard         config.ReachabilityGuard
+		force         bool
+		addresses     string
+		localErr      error
+		afterErr      error
+		expectCommit  bool
+		expectAfter   bool
+		expectedError string
+	}{
+		{
+			name:         "other_interface_is_not_guarded",
+			parent:       "eth2",
+			guard:        config.ReachabilityGuard{ManagementVLAN: "management"},
+			addresses:    withoutManagementVLAN,
+			expectCommit: true,
+		},
+		{
+			name:         "management_vlan_is_another_way_in",
+			guard:        config.ReachabilityGuard{ManagementVLAN: "management"},
+			addresses:    withManagementVLAN,
+			expectCommit: true,
+			expectAfter:  true,
+		},
+		{
+			name:          "refused_without_another_way_in",
+			guard:         config.ReachabilityGuard{ManagementVLAN: "management"},
+			addresses:     withoutManagementVLAN,
+			expectedError: "refusing to change eth0.200: kictl reaches node node1 only through eth0 (no alternate path: management VLAN address 192.168.100.10 is not configured yet); use --force to change it anyway",
+		},
+		{
+			name:         "local_command_is_another_way_in",
+			guard:        config.ReachabilityGuard{Command: "ipmitool -H {node}-bmc chassis power status"},
+			addresses:    withoutManagementVLAN,
+			expectCommit: true,
+			expectAfter:  true,
+		},
+		{
+			name:          "failed_local_command_is_refused",
+			guard:         config.ReachabilityGuard{Command: "ipmitool -H {node}-bmc chassis power status"},
+			addresses:     withoutManagementVLAN,
+			localErr:      errors.New("exit status 1"),
+			expectedError: "no alternate path: 'ipmitool -H node1-bmc chassis power status' failed: exit status 1",
+		},
+		{
+			name:         "forced_without_another_way_in",
+			guard:        config.ReachabilityGuard{ManagementVLAN: "management"},
+			force:        true,
+			addresses:    withoutManagementVLAN,
+			expectCommit: true,
+			expectAfter:  true,
+		},
+		{
+			name:          "unreachable_after_the_change",
+			guard:         config.ReachabilityGuard{ManagementVLAN: "management"},
+			addresses:     withManagementVLAN,
+			afterErr:      errors.New("timed out waiting for the debug pod"),
+			expectCommit:  true,
+			expectAfter:   true,
+			expectedError: "node node1 no longer answers after eth0.200 changed, recover it through its alternate path: timed out waiting for the debug pod",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A storage VLAN on eth0, which carries the node's InternalIP, and a management VLAN on eth1
+			parent := tt.parent
+			if parent == "" {
+				parent = "eth0"
+			}
+			mockKubectl := NewMockDryRunExecutor()
+			mockKubectl.On("SetDryRun", false).Return()
+			mockKubectl.On("ReleaseDebugPods", mock.Anything).Return(0, nil)
+			mockKubectl.On("GetNodeInternalIP", mock.Anything, "node1").Return(true, "10.0.0.12", nil)
+			mockKubectl.On("ExecNodeCommand", mock.Anything, "node1", listCmd).Return(true, tt.addresses, nil)
+			mockKubectl.On("ExecNodeCommand", mock.Anything, "node1", "true").Return(tt.afterErr == nil, "", tt.afterErr)
+			mockKubectl.On("ExecNodeCommand", mock.Anything, "node1", mock.Anything).
+				Return(true, addrOutput("eth1.100=192.168.100.10/24", parent+".200=10.200.0.10/24"), nil)
+
+			var localCommands []string
+			guard := tt.guard
+			service := NewService(mockKubectl, Options{
+				ReachabilityGuard: &guard,
+				Force:             tt.force,
+				LocalCommand: func(ctx context.Context, command string) (string, error) {
+					localCommands = append(localCommands, command)
+					return "", tt.localErr
+				},
+				Logger: logging.NewRecordingLogger(),
+			})
+			vlanConfig := &config.NodeVLANConf{
+				Kind:     "NodeVLANConf",
+				Metadata: config.Metadata{Name: "guard"},
+				Spec: config.NodeVLANSpec{VLANs: map[string]config.VLANConfig{
+					"management": {ID: 100, Subnet: "192.168.100.0/24", Interface: "eth1",
+						NodeMapping: map[string]string{"node1": "192.168.100.10/24"}},
+					"storage": {ID: 200, Subnet: "10.200.0.0/24", Interface: parent,
+						NodeMapping: map[string]string{"node1": "10.200.0.10/24"}},
+				}},
+			}
+
+			// When: Configure VLANs
+			results, err := service.ConfigureVLANs(context.Background(), vlanConfig)
+
+			// Then: Guarded changes go through only with another way in, and are checked afterwards
+			require.NoError(t, err)
+			if tt.expectCommit {
+				mockKubectl.AssertCalled(t, "ExecNodeCommand", mock.Anything, "node1", mock.MatchedBy(isCommit))
+			} else {
+				mockKubectl.AssertNotCalled(t, "ExecNodeCommand", mock.Anything, "node1", mock.MatchedBy(isCommit))
+			}
+			if tt.expectAfter {
+				mockKubectl.AssertCalled(t, "ExecNodeCommand", mock.Anything, "node1", "true")
+			} else {
+				mockKubectl.AssertNotCalled(t, "ExecNodeCommand", mock.Anything, "node1", "true")
+			}
+			if tt.guard.Command != "" {
+				assert.Equal(t, []string{"ipmitool -H node1-bmc chassis power status"}, localCommands)
+			}
+			if tt.expectedError == "" {
+				assert.Empty(t, results.Errors)
+				assert.Empty(t, results.FailedNodes)
+			} else {
+				assert.Equal(t, []string{"node1"}, results.FailedNodes)
+				require.Len(t, results.Errors, 1)
+				assert.ErrorContains(t, results.Errors[0], tt.expectedError)
+			}
+		})
+	}
+}
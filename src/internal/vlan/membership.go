@@ -0,0 +1,31 @@
+package vlan
+
+import (
+	"context"
+	"fmt"
+
+	"k8ostack-ictl/internal/config"
+)
+
+// updateMembershipLabel labels a node with its membership of a VLAN after a configure, and removes the label after a remove
+// Labels only mirror the interfaces, so a failure is logged without failing the node.
+func (vs *VLANService) updateMembershipLabel(ctx context.Context, nodeName, vlanName, ipAddress, operation string) {
+	if !vs.options.MembershipLabels {
+		return
+	}
+	key, value := config.VLANMembershipLabel(vlanName, ipAddress)
+
+	var err error
+	if operation == "remove" {
+		_, _, err = vs.kubectl.UnlabelNode(ctx, nodeName, key)
+	} else {
+		_, _, err = vs.kubectl.LabelNode(ctx, nodeName, fmt.Sprintf("%s=%s", key, value), true)
+	}
+	if err != nil {
+		vs.options.Logger.Warn(fmt.Sprintf("⚠️  Failed to update VLAN membership label %s on node %s: %v", key, nodeName, err))
+		return
+	}
+	if vs.options.Verbose {
+		vs.options.Logger.Info(fmt.Sprintf("    🏷️  Updated VLAN membership label %s on node %s", key, nodeName))
+	}
+}
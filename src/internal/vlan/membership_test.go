@@ -0,0 +1,107 @@
+// Package vlan provides unit tests for VLAN membership labels
+// WHY: Schedulers and NetworkPolicies select nodes by these labels, so they must follow the interfaces exactly
+package vlan
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/logging"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestVLANService_MembershipLabels tests labeling nodes with the VLANs configured on them
+// WHY: A label must appear with the interface, disappear with it, and never fail a node whose interface is fine
+func TestVLANService_MembershipLabels(t *testing.T) {
+	isCreate := func(cmd string) bool { return strings.Contains(cmd, "ip link add") }
+	isTeardown := func(cmd string) bool { return strings.Contains(cmd, "ip link delete eth0.100") }
+	label := "network.kictl.io/vlan-management=192.168.100.10"
+
+	tests := []struct {
+		name          string
+		remove        bool
+		labels        bool
+		node2Fails    bool
+		labelFails    bool
+		expectLabel   bool
+		expectUnlabel bool
+		expectErrors  int
+	}{
+		{name: "configure_labels_node", labels: true, expectLabel: true},
+		{name: "disabled", labels: false},
+		{name: "remove_unlabels_node", remove: true, labels: true, expectUnlabel: true},
+		{name: "rollback_unlabels_node", labels: true, node2Fails: true, expectLabel: true, expectUnlabel: true, expectErrors: 1},
+		{name: "label_failure_keeps_node_configured", labels: true, labelFails: true, expectLabel: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A VLAN on node1, and on node2 when it fails to force a rollback
+			mockKubectl := NewMockDryRunExecutor()
+			mockLogger := logging.NewMockLogger()
+			mockLogger.On("Info", mock.AnythingOfType("string")).Return().Maybe()
+			mockLogger.On("Warn", mock.AnythingOfType("string")).Return().Maybe()
+			mockLogger.On("Error", mock.AnythingOfType("string")).Return().Maybe()
+			mockLogger.On("Debug", mock.AnythingOfType("string")).Return().Maybe()
+			mockKubectl.On("SetDryRun", false).Return()
+			mockKubectl.On("ReleaseDebugPods", mock.Anything).Return(0, nil)
+
+			nodeMapping := map[string]string{"node1": "192.168.100.10/24"}
+			if tt.node2Fails {
+				nodeMapping["node2"] = "192.168.100.11/24"
+				mockKubectl.On("ExecNodeCommand", mock.Anything, "node2", mock.MatchedBy(isCreate)).Return(false, "RTNETLINK answers: No such device", nil)
+			}
+			mockKubectl.On("ExecNodeCommand", mock.Anything, "node1", mock.MatchedBy(isCreate)).Return(true, addrOutput("eth0.100=192.168.100.10/24"), nil)
+			mockKubectl.On("ExecNodeCommand", mock.Anything, "node1", mock.MatchedBy(isTeardown)).Return(true, "", nil).Maybe()
+			var labelErr error
+			if tt.labelFails {
+				labelErr = errors.New("nodes \"node1\" is forbidden")
+			}
+			mockKubectl.On("LabelNode", mock.Anything, "node1", label, true).Return(labelErr == nil, "", labelErr).Maybe()
+			mockKubectl.On("UnlabelNode", mock.Anything, "node1", "network.kictl.io/vlan-management").Return(true, "", nil).Maybe()
+
+			service := NewService(mockKubectl, Options{
+				MembershipLabels:  tt.labels,
+				RollbackOnFailure: true,
+				DefaultInterface:  "eth0",
+				Logger:            mockLogger,
+			})
+			vlanConfig := &config.NodeVLANConf{
+				Kind:     "NodeVLANConf",
+				Metadata: config.Metadata{Name: "membership-test"},
+				Spec: config.NodeVLANSpec{VLANs: map[string]config.VLANConfig{
+					"management": {ID: 100, Subnet: "192.168.100.0/24", NodeMapping: nodeMapping},
+				}},
+			}
+
+			// When: Configure or remove the VLAN
+			var results *OperationResults
+			var err error
+			if tt.remove {
+				results, err = service.RemoveVLANs(context.Background(), vlanConfig)
+			} else {
+				results, err = service.ConfigureVLANs(context.Background(), vlanConfig)
+			}
+
+			// Then: node1 carries the label exactly while its interface exists
+			require.NoError(t, err)
+			assert.Len(t, results.Errors, tt.expectErrors)
+			if tt.expectLabel {
+				mockKubectl.AssertCalled(t, "LabelNode", mock.Anything, "node1", label, true)
+			} else {
+				mockKubectl.AssertNotCalled(t, "LabelNode", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			}
+			if tt.expectUnlabel {
+				mockKubectl.AssertCalled(t, "UnlabelNode", mock.Anything, "node1", "network.kictl.io/vlan-management")
+			} else {
+				mockKubectl.AssertNotCalled(t, "UnlabelNode", mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package vlan
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"k8ostack-ictl/internal/kubectl"
+)
+
+// netplanTryUnit names the transient systemd unit running netplan try for an interface
+// The unit runs outside the debug pod, so netplan try outlives the pod that started it.
+func (tx *vlanTransaction) netplanTryUnit() string {
+	return "kictl-netplan-try-" + tx.vlanInterface
+}
+
+// tryCommand installs the staged netplan file and starts netplan try, which applies it and reverts unless accepted in time
+// netplan try creates the interface itself, so the ip commands are not run.
+func (tx *vlanTransaction) tryCommand() string {
+	commands := make([]string, 0, len(tx.files)+2)
+	for _, file := range tx.files {
+		commands = append(commands, kubectl.HostCommand("install", "-m", file.Mode, tx.stagedPath(file), file.Path))
+	}
+	seconds := int((tx.try + time.Second - 1) / time.Second)
+	commands = append(commands,
+		kubectl.HostCommand("rm", "-rf", tx.stagingRoot()),
+		kubectl.HostCommand("systemd-run", "--unit", tx.netplanTryUnit(), "--collect", "netplan", "try", "--timeout", strconv.Itoa(seconds)),
+	)
+	return kubectl.JoinHostCommands(commands...)
+}
+
+// confirmNetplanTry accepts a netplan try once the node answers through a new debug pod and carries the address
+// A node the change cut off cannot answer, so nothing accepts the change and netplan reverts it on its own.
+func (vs *VLANService) confirmNetplanTry(ctx context.Context, tx *vlanTransaction) error {
+	deadline := time.Now().Add(tx.try / 2)
+	interval := tx.try / 10
+	listCmd := kubectl.HostCommand("ip", "-o", "addr", "show", "dev", tx.vlanInterface)
+
+	for {
+		success, output, err := vs.kubectl.ExecNodeCommand(ctx, tx.nodeName, listCmd)
+		if err != nil {
+			return &TransactionError{Phase: PhaseConfirm, Interface: tx.vlanInterface,
+				Err: fmt.Errorf("node did not answer after netplan try, which reverts the change within %s: %w", tx.try, err)}
+		}
+		if success && tx.confirmed(output) {
+			if err := vs.signalNetplanTry(ctx, tx, "SIGUSR1"); err != nil {
+				return &TransactionError{Phase: PhaseConfirm, Interface: tx.vlanInterface,
+					Err: fmt.Errorf("accepting netplan try failed, so it reverts within %s: %w", tx.try, err)}
+			}
+			return nil
+		}
+		if time.Now().After(deadline) {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return &TransactionError{Phase: PhaseConfirm, Interface: tx.vlanInterface, Err: ctx.Err()}
+		case <-time.After(interval):
+		}
+	}
+
+	confirmErr := fmt.Errorf("address %s not found on the interface", tx.ipAddress)
+	if err := vs.signalNetplanTry(ctx, tx, "SIGINT"); err != nil {
+		confirmErr = fmt.Errorf("%w, and rejecting netplan try failed, so it reverts within %s: %v", confirmErr, tx.try, err)
+	} else {
+		vs.options.Logger.Warn(fmt.Sprintf("↩️  Reverted netplan try of VLAN interface %s on node %s", tx.vlanInterface, tx.nodeName))
+	}
+	return &TransactionError{Phase: PhaseConfirm, Interface: tx.vlanInterface, Err: confirmErr}
+}
+
+// signalNetplanTry accepts (SIGUSR1) or rejects (SIGINT) a running netplan try
+func (vs *VLANService) signalNetplanTry(ctx context.Context, tx *vlanTransaction, signal string) error {
+	cmd := kubectl.HostCommand("systemctl", "kill", "--signal", signal, tx.netplanTryUnit())
+	success, output, err := vs.kubectl.ExecNodeCommand(ctx, tx.nodeName, cmd)
+	if err != nil {
+		return err
+	}
+	if !success {
+		return fmt.Errorf("%s", output)
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+// Package vlan provides unit tests for applying netplan files with netplan try
+// WHY: A VLAN change that cuts off a remote node must revert on its own instead of stranding the node
+package vlan
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/logging"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestVLANTransaction_NetplanTryCommand tests the commit of a transaction applied with netplan try
+// WHY: netplan try must run outside the debug pod and create the interface itself
+func TestVLANTransaction_NetplanTryCommand(t *testing.T) {
+	// Given: A prepared transaction with a 90s netplan try
+	service := NewService(NewMockDryRunExecutor(), Options{
+		PersistentConfig: true,
+		NetplanTry:       90 * time.Second,
+		Logger:           logging.NewRecordingLogger(),
+	}).(*VLANService)
+	tx, err := service.prepareVLANTransaction(context.Background(), "node1", "management", config.VLANConfig{ID: 100}, "eth0.100", "eth0", "192.168.100.10/24")
+	require.NoError(t, err)
+
+	// When/Then: The commit installs the file and starts netplan try as a transient unit, without ip commands
+	assert.True(t, tx.verifies())
+	assert.Equal(t, "install -m 600 /run/kictl/staged/eth0.100/etc/netplan/60-kictl-eth0.100.yaml /etc/netplan/60-kictl-eth0.100.yaml"+
+		" && rm -rf /run/kictl/staged/eth0.100"+
+		" && systemd-run --unit kictl-netplan-try-eth0.100 --collect netplan try --timeout 90", tx.commitCommand())
+}
+
+// TestVLANService_ConfigureVLANs_NetplanTry tests how netplan try is accepted or left to revert
+// WHY: Only a node that still answers through a new debug pod with the address in place may keep the change
+func TestVLANService_ConfigureVLANs_NetplanTry(t *testing.T) {
+	isVerify := func(cmd string) bool { return strings.Contains(cmd, "netplan generate") }
+	isTry := func(cmd string) bool { return strings.Contains(cmd, "netplan try") }
+	listCmd := "ip -o addr show dev eth0.100"
+	acceptCmd := "systemctl kill --signal SIGUSR1 kictl-netplan-try-eth0.100"
+	rejectCmd := "systemctl kill --signal SIGINT kictl-netplan-try-eth0.100"
+
+	tests := []struct {
+		name          string
+		setupMocks    func(*MockDryRunExecutor)
+		expectedError string
+		expectAccept  bool
+		expectReject  bool
+	}{
+		{
+			name: "accepted_once_address_appears",
+			setupMocks: func(m *MockDryRunExecutor) {
+				m.On("ExecNodeCommand", mock.Anything, "node1", listCmd).Return(false, "Device \"eth0.100\" does not exist.", nil).Once()
+				m.On("ExecNodeCommand", mock.Anything, "node1", listCmd).Return(true, addrOutput("eth0.100=192.168.100.10/24"), nil).Once()
+				m.On("ExecNodeCommand", mock.Anything, "node1", acceptCmd).Return(true, "", nil).Once()
+			},
+			expectAccept: true,
+		},
+		{
+			name: "unreachable_node_is_left_to_revert",
+			setupMocks: func(m *MockDryRunExecutor) {
+				m.On("ExecNodeCommand", mock.Anything, "node1", listCmd).Return(false, "", errors.New("timed out waiting for the debug pod")).Once()
+			},
+			expectedError: "node did not answer after netplan try, which reverts the change within 200ms: timed out waiting for the debug pod",
+		},
+		{
+			name: "missing_address_is_rejected",
+			setupMocks: func(m *MockDryRunExecutor) {
+				m.On("ExecNodeCommand", mock.Anything, "node1", listCmd).Return(true, addrOutput("eth0.100=192.168.100.99/24"), nil)
+				m.On("ExecNodeCommand", mock.Anything, "node1", rejectCmd).Return(true, "", nil).Once()
+			},
+			expectedError: "address 192.168.100.10/24 not found on the interface",
+			expectReject:  true,
+		},
+		{
+			name: "failed_accept_is_reported",
+			setupMocks: func(m *MockDryRunExecutor) {
+				m.On("ExecNodeCommand", mock.Anything, "node1", listCmd).Return(true, addrOutput("eth0.100=192.168.100.10/24"), nil).Once()
+				m.On("ExecNodeCommand", mock.Anything, "node1", acceptCmd).Return(false, "Failed to kill unit kictl-netplan-try-eth0.100.service: Unit not loaded.", nil).Once()
+			},
+			expectedError: "accepting netplan try failed, so it reverts within 200ms: Failed to kill unit",
+			expectAccept:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: One VLAN on one node applied with netplan try
+			mockKubectl := NewMockDryRunExecutor()
+			mockKubectl.On("SetDryRun", false).Return()
+			mockKubectl.On("ReleaseDebugPods", mock.Anything).Return(0, nil)
+			mockKubectl.On("ExecNodeCommand", mock.Anything, "node1", mock.MatchedBy(isVerify)).Return(true, "", nil).Once()
+			mockKubectl.On("ExecNodeCommand", mock.Anything, "node1", mock.MatchedBy(isTry)).Return(true, "Running as unit: kictl-netplan-try-eth0.100.service", nil).Once()
+			tt.setupMocks(mockKubectl)
+			service := NewService(mockKubectl, Options{
+				PersistentConfig: true,
+				NetplanTry:       200 * time.Millisecond,
+				Logger:           logging.NewRecordingLogger(),
+			})
+			vlanConfig := &config.NodeVLANConf{
+				Kind:     "NodeVLANConf",
+				Metadata: config.Metadata{Name: "netplan-try"},
+				Spec: config.NodeVLANSpec{VLANs: map[string]config.VLANConfig{
+					"management": {ID: 100, Subnet: "192.168.100.0/24", Interface: "eth0",
+						NodeMapping: map[string]string{"node1": "192.168.100.10/24"}},
+				}},
+			}
+
+			// When: Configure VLANs
+			results, err := service.ConfigureVLANs(context.Background(), vlanConfig)
+
+			// Then: The change is accepted only when confirmed, and never torn down with ip
+			require.NoError(t, err)
+			if tt.expectedError == "" {
+				require.Len(t, results.ConfiguredVLANs["node1"], 1)
+				assert.Equal(t, []string{"/etc/netplan/60-kictl-eth0.100.yaml"}, results.ConfiguredVLANs["node1"][0].PersistedFiles)
+				assert.Empty(t, results.Errors)
+			} else {
+				assert.Equal(t, []string{"node1"}, results.FailedNodes)
+				require.Len(t, results.Errors, 1)
+				var txErr *TransactionError
+				require.True(t, errors.As(results.Errors[0], &txErr))
+				assert.Equal(t, PhaseConfirm, txErr.Phase)
+				assert.ErrorContains(t, results.Errors[0], tt.expectedError)
+			}
+			if !tt.expectAccept {
+				mockKubectl.AssertNotCalled(t, "ExecNodeCommand", mock.Anything, "node1", acceptCmd)
+			}
+			if !tt.expectReject {
+				mockKubectl.AssertNotCalled(t, "ExecNodeCommand", mock.Anything, "node1", rejectCmd)
+			}
+			mockKubectl.AssertNotCalled(t, "ExecNodeCommand", mock.Anything, "node1", "ip link delete eth0.100")
+			mockKubectl.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package vlan
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/kubectl"
+)
+
+// vlanInterfaceNames returns the interface carrying a VLAN's addresses on a node and the interface it hangs off:
+// the internal port and its bridge for OVS VLANs, the sub-interface and its parent otherwise
+func (vs *VLANService) vlanInterfaceNames(ctx context.Context, nodeName string, vlanConfig config.VLANConfig) (string, string, error) {
+	if vlanConfig.OVS != nil {
+		return vlanConfig.OVS.Port, vlanConfig.OVS.Bridge, nil
+	}
+	physInterface, err := vs.nodeInterface(ctx, nodeName, vlanConfig)
+	if err != nil {
+		return "", "", err
+	}
+	return fmt.Sprintf("%s.%d", physInterface, vlanConfig.ID), physInterface, nil
+}
+
+// ovsAddPortCommand adds an internal port with an access tag to a bridge
+// Without --may-exist it fails on an existing port, so a port in ConfiguredVLANs was always created by the run.
+func ovsAddPortCommand(ovs *config.OVSConfig) string {
+	return kubectl.HostCommand("ovs-vsctl", "add-port", ovs.Bridge, ovs.Port, "tag="+strconv.Itoa(ovs.Tag),
+		"--", "set", "interface", ovs.Port, "type=internal")
+}
+
+// ovsDelPortCommand deletes a port from its bridge, tolerating a missing port when ifExists is set
+func ovsDelPortCommand(bridge, port string, ifExists bool) string {
+	if ifExists {
+		return kubectl.HostCommand("ovs-vsctl", "--if-exists", "del-port", bridge, port)
+	}
+	return kubectl.HostCommand("ovs-vsctl", "del-port", bridge, port)
+}
+
+// ovsCheckCommand prints the bridge of a port, then its tag, ahead of the address listing of verify
+func ovsCheckCommand(port string) string {
+	return kubectl.JoinHostCommands(
+		kubectl.HostCommand("ovs-vsctl", "port-to-br", port),
+		kubectl.HostCommand("ovs-vsctl", "get", "port", port, "tag"),
+	)
+}
+
+// ovsPortMismatch describes how the output of ovsCheckCommand differs from the configured bridge and tag, or returns ""
+func ovsPortMismatch(output string, ovs *config.OVSConfig) string {
+	lines := strings.SplitN(strings.TrimSpace(output), "\n", 3)
+	if len(lines) < 2 {
+		return fmt.Sprintf("port %s reports no bridge and tag", ovs.Port)
+	}
+	bridge, tag := strings.TrimSpace(lines[0]), strings.TrimSpace(lines[1])
+	if bridge != ovs.Bridge {
+		return fmt.Sprintf("port %s is on bridge %s, not %s", ovs.Port, bridge, ovs.Bridge)
+	}
+	if tag != strconv.Itoa(ovs.Tag) {
+		return fmt.Sprintf("port %s has tag %s, not %d", ovs.Port, tag, ovs.Tag)
+	}
+	return ""
+}
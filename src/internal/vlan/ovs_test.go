@@ -0,0 +1,158 @@
+// Package vlan provides unit tests for VLANs on Open vSwitch bridges
+// WHY: Network nodes carry provider VLANs as tagged OVS ports, which ip link cannot create or verify
+package vlan
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/logging"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// ovsTestConfig returns a VLAN configuration with VLAN 100 as port provider on br-ex of rsb2
+func ovsTestConfig() *config.NodeVLANConf {
+	return &config.NodeVLANConf{
+		APIVersion: "openstack.kictl.icycloud.io/v1",
+		Kind:       "NodeVLANConf",
+		Metadata:   config.Metadata{Name: "ovs-test"},
+		Spec: config.NodeVLANSpec{
+			VLANs: map[string]config.VLANConfig{
+				"provider": {ID: 100, Subnet: "10.0.100.0/24", OVS: &config.OVSConfig{Bridge: "br-ex", Port: "provider", Tag: 100},
+					NodeMapping: map[string]string{"rsb2": "10.0.100.2/24"}},
+			},
+		},
+	}
+}
+
+// TestVLANTransaction_OVSCommands tests the commit of a VLAN on an OVS bridge
+// WHY: The port must be created by ovs-vsctl with its tag, and the OVS database persists it instead of netplan
+func TestVLANTransaction_OVSCommands(t *testing.T) {
+	// Given: A prepared transaction for an OVS VLAN with persistent configuration enabled
+	service := NewService(NewMockDryRunExecutor(), Options{PersistentConfig: true, Logger: logging.NewRecordingLogger()}).(*VLANService)
+	vlanConfig := ovsTestConfig().Spec.VLANs["provider"]
+	tx, err := service.prepareVLANTransaction(context.Background(), "rsb2", "provider", vlanConfig, "provider", "br-ex", "10.0.100.2/24")
+	require.NoError(t, err)
+
+	// When/Then: The commit adds the internal port, addresses it and lists its addresses, without files to install
+	assert.Equal(t, "ovs-vsctl add-port br-ex provider tag=100 -- set interface provider type=internal"+
+		" && ip addr add 10.0.100.2/24 dev provider"+
+		" && ip link set provider up"+
+		" && ip -o addr show dev provider", tx.commitCommand())
+	assert.False(t, tx.verifies())
+	assert.Empty(t, tx.persistedPaths())
+	assert.Equal(t, "br-ex", tx.bridge)
+}
+
+// TestVLANService_OVS tests configuring, verifying and removing a VLAN on an OVS bridge
+// WHY: A port on the wrong bridge or tag carries no traffic even when its address is right
+func TestVLANService_OVS(t *testing.T) {
+	tests := []struct {
+		name           string
+		operation      string
+		command        string
+		output         string
+		expectVerified bool
+		expectWarning  string
+	}{
+		{
+			name:      "configure",
+			operation: "configure",
+			command:   "ovs-vsctl add-port br-ex provider tag=100",
+			output:    addrOutput("provider=10.0.100.2/24"),
+		},
+		{
+			name:      "remove",
+			operation: "remove",
+			command:   "ovs-vsctl --if-exists del-port br-ex provider || true",
+		},
+		{
+			name:           "verify",
+			operation:      "verify",
+			command:        "ovs-vsctl port-to-br provider && ovs-vsctl get port provider tag && ip addr show provider",
+			output:         "br-ex\n100\n" + addrOutput("provider=10.0.100.2/24"),
+			expectVerified: true,
+		},
+		{
+			name:          "verify_wrong_tag",
+			operation:     "verify",
+			command:       "ovs-vsctl port-to-br provider && ovs-vsctl get port provider tag && ip addr show provider",
+			output:        "br-ex\n200\n" + addrOutput("provider=10.0.100.2/24"),
+			expectWarning: "VLAN provider on node rsb2 has incorrect OVS configuration: port provider has tag 200, not 100",
+		},
+		{
+			name:          "verify_wrong_bridge",
+			operation:     "verify",
+			command:       "ovs-vsctl port-to-br provider && ovs-vsctl get port provider tag && ip addr show provider",
+			output:        "br-int\n100\n" + addrOutput("provider=10.0.100.2/24"),
+			expectWarning: "VLAN provider on node rsb2 has incorrect OVS configuration: port provider is on bridge br-int, not br-ex",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A node whose only command is the OVS command of the operation
+			mockKubectl := NewMockDryRunExecutor()
+			mockKubectl.On("SetDryRun", false).Return().Maybe()
+			mockKubectl.On("ReleaseDebugPods", mock.Anything).Return(0, nil).Maybe()
+			mockKubectl.On("ExecNodeCommand", mock.Anything, "rsb2", mock.MatchedBy(func(cmd string) bool {
+				return strings.HasPrefix(cmd, tt.command)
+			})).Return(true, tt.output, nil).Once()
+			logger := logging.NewRecordingLogger()
+			service := NewService(mockKubectl, Options{Logger: logger})
+
+			// When: Run the operation
+			var results *OperationResults
+			var err error
+			switch tt.operation {
+			case "configure":
+				results, err = service.ConfigureVLANs(context.Background(), ovsTestConfig())
+			case "remove":
+				results, err = service.RemoveVLANs(context.Background(), ovsTestConfig())
+			default:
+				results, err = service.VerifyVLANs(context.Background(), ovsTestConfig())
+			}
+
+			// Then: The port is handled on its bridge and verify checks bridge and tag
+			require.NoError(t, err)
+			assert.Empty(t, results.FailedNodes)
+			switch tt.operation {
+			case "configure":
+				require.Len(t, results.ConfiguredVLANs["rsb2"], 1)
+				assert.Equal(t, "provider", results.ConfiguredVLANs["rsb2"][0].Interface)
+				assert.Equal(t, "br-ex", results.ConfiguredVLANs["rsb2"][0].Bridge)
+			case "verify":
+				assert.Equal(t, tt.expectVerified, len(results.ConfiguredVLANs["rsb2"]) == 1)
+			}
+			if tt.expectWarning != "" {
+				assert.Contains(t, logger.Messages(logging.LevelWarn), tt.expectWarning)
+			}
+			mockKubectl.AssertExpectations(t)
+		})
+	}
+}
+
+// TestVLANService_OVSRollback tests rolling back a port the run added to a bridge
+// WHY: Deleting only the internal interface would leave the port in the OVS database, recreated at the next start
+func TestVLANService_OVSRollback(t *testing.T) {
+	// Given: A run that added a port to br-ex on rsb2
+	mockKubectl := NewMockDryRunExecutor()
+	mockKubectl.On("ExecNodeCommand", mock.Anything, "rsb2", "ovs-vsctl del-port br-ex provider").Return(true, "", nil).Once()
+	service := NewService(mockKubectl, Options{Logger: logging.NewRecordingLogger()}).(*VLANService)
+	results := &OperationResults{ConfiguredVLANs: map[string][]VLANInterfaceInfo{
+		"rsb2": {{VLANName: "provider", VLANId: 100, Interface: "provider", IPAddress: "10.0.100.2/24", PhysInterface: "br-ex", Bridge: "br-ex"}},
+	}}
+
+	// When: Roll back the run
+	service.rollbackVLANs(context.Background(), results)
+
+	// Then: The port is deleted from its bridge
+	assert.Empty(t, results.ConfiguredVLANs)
+	require.Len(t, results.RolledBackVLANs["rsb2"], 1)
+	mockKubectl.AssertExpectations(t)
+}
@@ -0,0 +1,308 @@
+package vlan
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/kubectl"
+)
+
+// persistedFile is a configuration file a persistence backend installs on a node
+type persistedFile struct {
+	Path    string
+	Content string
+	Mode    string // install -m mode
+}
+
+// persistenceBackend writes the configuration that recreates a VLAN interface at boot
+// The files take effect when the node's network service next starts; the running interface is created by ip.
+type persistenceBackend interface {
+	// name is the config.PersistenceBackend* name of the backend
+	name() string
+
+	// files renders the configuration files of an interface
+	files(ctx context.Context, vs *VLANService, nodeName string, iface VLANInterfaceInfo) ([]persistedFile, error)
+
+	// seedCommand copies the node's existing configuration below a staging root; empty when the backend has no check
+	seedCommand(root string) string
+
+	// checkCommand checks the files staged below a staging root; empty when the backend has no check
+	checkCommand(root string, files []persistedFile) string
+}
+
+// persistenceBackends holds the backends by name
+var persistenceBackends = map[string]persistenceBackend{
+	config.PersistenceBackendNetplan:        netplanBackend{},
+	config.PersistenceBackendNetworkManager: networkManagerBackend{},
+	config.PersistenceBackendNetworkd:       networkdBackend{},
+	config.PersistenceBackendIfcfg:          ifcfgBackend{},
+}
+
+// detectPersistenceBackendCommand prints the first backend managing the node's network, in config.PersistenceBackends order
+var detectPersistenceBackendCommand = "if command -v netplan >/dev/null 2>&1; then echo " + config.PersistenceBackendNetplan +
+	"; elif systemctl is-active --quiet NetworkManager; then echo " + config.PersistenceBackendNetworkManager +
+	"; elif systemctl is-active --quiet systemd-networkd; then echo " + config.PersistenceBackendNetworkd +
+	"; elif test -d /etc/sysconfig/network-scripts; then echo " + config.PersistenceBackendIfcfg +
+	"; fi"
+
+// nodePersistenceBackend returns the backend writing the persistent configuration of a node
+// Dry runs cannot run node commands, so auto-detection returns no backend and the files are not rendered.
+func (vs *VLANService) nodePersistenceBackend(ctx context.Context, nodeName string) (persistenceBackend, error) {
+	name := vs.options.PersistenceBackend
+	if name == "" {
+		name = config.PersistenceBackendNetplan
+	}
+
+	if name == config.PersistenceBackendAuto {
+		if vs.options.DryRun {
+			vs.options.Logger.Info(fmt.Sprintf("🧪 DRY RUN: Would detect the persistence backend of node %s", nodeName))
+			return nil, nil
+		}
+		detected, err := vs.detectPersistenceBackend(ctx, nodeName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to detect the persistence backend: %w", err)
+		}
+		name = detected
+	}
+
+	backend, ok := persistenceBackends[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown persistence backend '%s'", name)
+	}
+	return backend, nil
+}
+
+// detectPersistenceBackend finds the backend managing a node's network, once per node
+func (vs *VLANService) detectPersistenceBackend(ctx context.Context, nodeName string) (string, error) {
+	if detected, ok := vs.backends.get(nodeName); ok {
+		return detected, nil
+	}
+
+	success, output, err := vs.kubectl.ExecNodeCommand(ctx, nodeName, detectPersistenceBackendCommand)
+	if err != nil {
+		return "", err
+	}
+	detected := strings.TrimSpace(output)
+	if _, ok := persistenceBackends[detected]; !success || !ok {
+		return "", fmt.Errorf("none of %s manages the network of node %s", strings.Join(config.PersistenceBackends, ", "), nodeName)
+	}
+
+	vs.options.Logger.Info(fmt.Sprintf("  🔎 Detected persistence backend %s on node %s", detected, nodeName))
+	vs.backends.set(nodeName, detected)
+	return detected, nil
+}
+
+// netplanBackend writes netplan YAML, checked with netplan generate
+type netplanBackend struct{}
+
+func (netplanBackend) name() string { return config.PersistenceBackendNetplan }
+
+func (netplanBackend) files(_ context.Context, vs *VLANService, _ string, iface VLANInterfaceInfo) ([]persistedFile, error) {
+	content, err := vs.generateNetplanConfig(iface.VLANName, config.VLANConfig{ID: iface.VLANId}, iface.Interface, iface.PhysInterface, iface.IPAddress)
+	if err != nil {
+		return nil, err
+	}
+	return []persistedFile{{
+		Path:    netplanConfigPath(iface.Interface),
+		Content: content,
+		Mode:    "600",
+	}}, nil
+}
+
+// seedCommand copies the node's netplan files, so the check sees the staged file next to the ones it must agree with
+func (netplanBackend) seedCommand(root string) string {
+	return kubectl.HostCommand("cp", "-a", "/etc/netplan/.", root+"/etc/netplan")
+}
+
+// checkCommand runs netplan generate, which only reads and writes below the staging root
+func (netplanBackend) checkCommand(root string, _ []persistedFile) string {
+	return kubectl.HostCommand("netplan", "generate", "--root-dir", root)
+}
+
+// networkManagerBackend writes a NetworkManager keyfile connection
+type networkManagerBackend struct{}
+
+func (networkManagerBackend) name() string { return config.PersistenceBackendNetworkManager }
+
+func (networkManagerBackend) files(_ context.Context, _ *VLANService, _ string, iface VLANInterfaceInfo) ([]persistedFile, error) {
+	ipv4, ipv6 := splitAddressFamilies(iface.IPAddress)
+	return []persistedFile{{
+		Path: fmt.Sprintf("/etc/NetworkManager/system-connections/kictl-%s.nmconnection", iface.Interface),
+		Content: fmt.Sprintf(`# Generated by kictl for VLAN %s
+[connection]
+id=kictl-%s
+type=vlan
+interface-name=%s
+
+[vlan]
+id=%d
+parent=%s
+
+[ipv4]
+%s
+[ipv6]
+%s`, iface.VLANName, iface.Interface, iface.Interface, iface.VLANId, iface.PhysInterface, keyfileAddresses(ipv4), keyfileAddresses(ipv6)),
+		Mode: "600",
+	}}, nil
+}
+
+// keyfileAddresses renders the method and addresses of a keyfile ipv4 or ipv6 section
+func keyfileAddresses(addresses []string) string {
+	if len(addresses) == 0 {
+		return "method=disabled\n"
+	}
+	lines := "method=manual\n"
+	for i, address := range addresses {
+		lines += fmt.Sprintf("address%d=%s\n", i+1, address)
+	}
+	return lines
+}
+
+func (networkManagerBackend) seedCommand(string) string { return "" }
+
+// checkCommand parses each keyfile with nmcli --offline, which never contacts NetworkManager
+// nmcli before 1.44 has no offline mode, so the check is skipped on nodes with an older one.
+func (networkManagerBackend) checkCommand(root string, files []persistedFile) string {
+	commands := make([]string, 0, len(files))
+	for _, file := range files {
+		commands = append(commands, kubectl.HostCommand("nmcli", "--offline", "connection", "modify", "connection.autoconnect", "yes")+
+			" < "+kubectl.QuoteShellArg(root+file.Path)+" > /dev/null")
+	}
+	return "if " + kubectl.HostCommand("nmcli", "--offline", "--version") + " > /dev/null 2>&1; then " + kubectl.JoinHostCommands(commands...) + "; fi"
+}
+
+// networkdBackend writes a systemd-networkd netdev and network, and attaches the VLAN to its parent with a drop-in
+type networkdBackend struct{}
+
+func (networkdBackend) name() string { return config.PersistenceBackendNetworkd }
+
+func (networkdBackend) files(ctx context.Context, vs *VLANService, nodeName string, iface VLANInterfaceInfo) ([]persistedFile, error) {
+	parentFile, err := vs.networkdNetworkFile(ctx, nodeName, iface.PhysInterface)
+	if err != nil {
+		return nil, err
+	}
+
+	base := "/etc/systemd/network/60-kictl-" + iface.Interface
+	return []persistedFile{
+		{
+			Path: base + ".netdev",
+			Content: fmt.Sprintf(`# Generated by kictl for VLAN %s
+[NetDev]
+Name=%s
+Kind=vlan
+
+[VLAN]
+Id=%d
+`, iface.VLANName, iface.Interface, iface.VLANId),
+			Mode: "644",
+		},
+		{
+			Path: base + ".network",
+			Content: fmt.Sprintf(`# Generated by kictl for VLAN %s
+[Match]
+Name=%s
+
+[Network]
+%s`, iface.VLANName, iface.Interface, addressLines("Address=%s\n", config.SplitAddresses(iface.IPAddress))),
+			Mode: "644",
+		},
+		{
+			// Drop-ins only extend the file they are named after, so the parent's own network file is extended
+			Path: fmt.Sprintf("/etc/systemd/network/%s.d/60-kictl-%s.conf", path.Base(parentFile), iface.Interface),
+			Content: fmt.Sprintf(`# Generated by kictl for VLAN %s
+[Network]
+VLAN=%s
+`, iface.VLANName, iface.Interface),
+			Mode: "644",
+		},
+	}, nil
+}
+
+// systemd-networkd has no offline parser, so its files are staged and installed by the commit
+func (networkdBackend) seedCommand(string) string                   { return "" }
+func (networkdBackend) checkCommand(string, []persistedFile) string { return "" }
+
+// networkdNetworkFile finds the network file systemd-networkd configures an interface with
+func (vs *VLANService) networkdNetworkFile(ctx context.Context, nodeName, physInterface string) (string, error) {
+	if vs.options.DryRun {
+		return fmt.Sprintf("<network file of %s>", physInterface), nil
+	}
+
+	success, output, err := vs.kubectl.ExecNodeCommand(ctx, nodeName, kubectl.HostCommand("networkctl", "status", physInterface, "--no-pager"))
+	if err != nil {
+		return "", err
+	}
+	if success {
+		for _, line := range strings.Split(output, "\n") {
+			if key, value, ok := strings.Cut(strings.TrimSpace(line), ":"); ok && key == "Network File" {
+				if value = strings.TrimSpace(value); value != "" && value != "n/a" {
+					return value, nil
+				}
+			}
+		}
+	}
+	return "", fmt.Errorf("interface %s is not managed by systemd-networkd", physInterface)
+}
+
+// ifcfgBackend writes a legacy network-scripts ifcfg file
+type ifcfgBackend struct{}
+
+func (ifcfgBackend) name() string { return config.PersistenceBackendIfcfg }
+
+func (ifcfgBackend) files(_ context.Context, _ *VLANService, _ string, iface VLANInterfaceInfo) ([]persistedFile, error) {
+	ipv4, ipv6 := splitAddressFamilies(iface.IPAddress)
+	var addressing strings.Builder
+	for i, cidr := range ipv4 {
+		suffix := ""
+		if i > 0 {
+			suffix = strconv.Itoa(i)
+		}
+		address, prefix, _ := strings.Cut(cidr, "/")
+		fmt.Fprintf(&addressing, "IPADDR%s=%s\nPREFIX%s=%s\n", suffix, address, suffix, prefix)
+	}
+	if len(ipv6) > 0 {
+		fmt.Fprintf(&addressing, "IPV6INIT=yes\nIPV6ADDR=%s\n", ipv6[0])
+		if len(ipv6) > 1 {
+			fmt.Fprintf(&addressing, "IPV6ADDR_SECONDARIES=\"%s\"\n", strings.Join(ipv6[1:], " "))
+		}
+	}
+	return []persistedFile{{
+		Path: "/etc/sysconfig/network-scripts/ifcfg-" + iface.Interface,
+		Content: fmt.Sprintf(`# Generated by kictl for VLAN %s
+DEVICE=%s
+VLAN=yes
+PHYSDEV=%s
+VLAN_ID=%d
+BOOTPROTO=none
+%sONBOOT=yes
+`, iface.VLANName, iface.Interface, iface.PhysInterface, iface.VLANId, addressing.String()),
+		Mode: "644",
+	}}, nil
+}
+
+func (ifcfgBackend) seedCommand(string) string { return "" }
+
+// checkCommand parses each file with bash -n, as the network scripts source them with bash
+func (ifcfgBackend) checkCommand(root string, files []persistedFile) string {
+	commands := make([]string, 0, len(files))
+	for _, file := range files {
+		commands = append(commands, kubectl.HostCommand("bash", "-n", root+file.Path))
+	}
+	return kubectl.JoinHostCommands(commands...)
+}
+
+// splitAddressFamilies splits comma separated interface addresses into IPv4 and IPv6 ones
+func splitAddressFamilies(addresses string) (ipv4, ipv6 []string) {
+	for _, address := range config.SplitAddresses(addresses) {
+		if config.IsIPv6Address(address) {
+			ipv6 = append(ipv6, address)
+		} else {
+			ipv4 = append(ipv4, address)
+		}
+	}
+	return ipv4, ipv6
+}
@@ -0,0 +1,349 @@
+// Package vlan provides unit tests for the persistence backends
+// WHY: Mixed-distro clusters need each node's VLANs written in the format its network service reads at boot
+package vlan
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/logging"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPersistenceBackends_Files tests the files each backend renders
+// WHY: A file in the wrong place or format is silently ignored and the VLAN disappears on the next reboot
+func TestPersistenceBackends_Files(t *testing.T) {
+	iface := VLANInterfaceInfo{VLANName: "storage", VLANId: 200, Interface: "eth1.200", IPAddress: "10.200.0.5/24", PhysInterface: "eth1"}
+
+	tests := []struct {
+		name            string
+		backend         string
+		setupMocks      func(*MockDryRunExecutor)
+		expectedPaths   []string
+		expectedMode    string
+		expectedContent []string
+		expectedError   string
+	}{
+		{
+			name:            "netplan",
+			backend:         config.PersistenceBackendNetplan,
+			setupMocks:      func(m *MockDryRunExecutor) {},
+			expectedPaths:   []string{"/etc/netplan/60-kictl-eth1.200.yaml"},
+			expectedMode:    "600",
+			expectedContent: []string{"    eth1.200:\n      id: 200\n      link: eth1\n      addresses:\n        - 10.200.0.5/24\n"},
+		},
+		{
+			name:          "networkmanager",
+			backend:       config.PersistenceBackendNetworkManager,
+			setupMocks:    func(m *MockDryRunExecutor) {},
+			expectedPaths: []string{"/etc/NetworkManager/system-connections/kictl-eth1.200.nmconnection"},
+			expectedMode:  "600",
+			expectedContent: []string{
+				"[connection]\nid=kictl-eth1.200\ntype=vlan\ninterface-name=eth1.200\n",
+				"[vlan]\nid=200\nparent=eth1\n",
+				"[ipv4]\nmethod=manual\naddress1=10.200.0.5/24\n",
+			},
+		},
+		{
+			name:    "networkd",
+			backend: config.PersistenceBackendNetworkd,
+			setupMocks: func(m *MockDryRunExecutor) {
+				m.On("ExecNodeCommand", mock.Anything, "node1", "networkctl status eth1 --no-pager").
+					Return(true, "● 3: eth1\n                     Link File: /usr/lib/systemd/network/99-default.link\n                  Network File: /run/systemd/network/10-netplan-eth1.network\n                         State: routable (configured)", nil)
+			},
+			expectedPaths: []string{
+				"/etc/systemd/network/60-kictl-eth1.200.netdev",
+				"/etc/systemd/network/60-kictl-eth1.200.network",
+				"/etc/systemd/network/10-netplan-eth1.network.d/60-kictl-eth1.200.conf",
+			},
+			expectedMode: "644",
+			expectedContent: []string{
+				"[NetDev]\nName=eth1.200\nKind=vlan\n\n[VLAN]\nId=200\n",
+				"[Match]\nName=eth1.200\n\n[Network]\nAddress=10.200.0.5/24\n",
+				"[Network]\nVLAN=eth1.200\n",
+			},
+		},
+		{
+			name:    "networkd_unmanaged_parent",
+			backend: config.PersistenceBackendNetworkd,
+			setupMocks: func(m *MockDryRunExecutor) {
+				m.On("ExecNodeCommand", mock.Anything, "node1", "networkctl status eth1 --no-pager").
+					Return(true, "● 3: eth1\n                  Network File: n/a\n                         State: off (unmanaged)", nil)
+			},
+			expectedError: "interface eth1 is not managed by systemd-networkd",
+		},
+		{
+			name:          "ifcfg",
+			backend:       config.PersistenceBackendIfcfg,
+			setupMocks:    func(m *MockDryRunExecutor) {},
+			expectedPaths: []string{"/etc/sysconfig/network-scripts/ifcfg-eth1.200"},
+			expectedMode:  "644",
+			expectedContent: []string{
+				"DEVICE=eth1.200\nVLAN=yes\nPHYSDEV=eth1\nVLAN_ID=200\nBOOTPROTO=none\nIPADDR=10.200.0.5\nPREFIX=24\nONBOOT=yes\n",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A service and the backend
+			mockKubectl := NewMockDryRunExecutor()
+			tt.setupMocks(mockKubectl)
+			service := NewService(mockKubectl, Options{Logger: logging.NewRecordingLogger()}).(*VLANService)
+			backend := persistenceBackends[tt.backend]
+			require.NotNil(t, backend)
+
+			// When: Render the interface's files
+			files, err := backend.files(context.Background(), service, "node1", iface)
+
+			// Then: The files land where the network service reads them
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			paths := make([]string, 0, len(files))
+			for i, file := range files {
+				paths = append(paths, file.Path)
+				assert.Equal(t, tt.expectedMode, file.Mode)
+				assert.Contains(t, file.Content, "# Generated by kictl for VLAN storage\n")
+				if i < len(tt.expectedContent) {
+					assert.Contains(t, file.Content, tt.expectedContent[i])
+				}
+			}
+			assert.Equal(t, tt.expectedPaths, paths)
+			mockKubectl.AssertExpectations(t)
+		})
+	}
+}
+
+// TestPersistenceBackends_DualStackFiles tests the files each backend renders for a dual-stack interface
+// WHY: An IPv6 address missing from the boot configuration disappears on the next reboot, just like a missing file
+func TestPersistenceBackends_DualStackFiles(t *testing.T) {
+	iface := VLANInterfaceInfo{VLANName: "storage", VLANId: 200, Interface: "eth1.200", IPAddress: "10.200.0.5/24,fd00:200::5/64,fd00:201::5/64", PhysInterface: "eth1"}
+
+	tests := []struct {
+		name            string
+		backend         string
+		expectedContent string
+	}{
+		{
+			name:            "netplan",
+			backend:         config.PersistenceBackendNetplan,
+			expectedContent: "      addresses:\n        - 10.200.0.5/24\n        - fd00:200::5/64\n        - fd00:201::5/64\n",
+		},
+		{
+			name:            "networkmanager",
+			backend:         config.PersistenceBackendNetworkManager,
+			expectedContent: "[ipv4]\nmethod=manual\naddress1=10.200.0.5/24\n\n[ipv6]\nmethod=manual\naddress1=fd00:200::5/64\naddress2=fd00:201::5/64\n",
+		},
+		{
+			name:            "ifcfg",
+			backend:         config.PersistenceBackendIfcfg,
+			expectedContent: "IPADDR=10.200.0.5\nPREFIX=24\nIPV6INIT=yes\nIPV6ADDR=fd00:200::5/64\nIPV6ADDR_SECONDARIES=\"fd00:201::5/64\"\nONBOOT=yes\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A service and the backend
+			service := NewService(NewMockDryRunExecutor(), Options{Logger: logging.NewRecordingLogger()}).(*VLANService)
+
+			// When: Render the interface's files
+			files, err := persistenceBackends[tt.backend].files(context.Background(), service, "node1", iface)
+
+			// Then: Every address is configured at boot, IPv6 ones as IPv6
+			require.NoError(t, err)
+			require.Len(t, files, 1)
+			assert.Contains(t, files[0].Content, tt.expectedContent)
+		})
+	}
+}
+
+// TestPersistenceBackends_IPv6OnlyFiles tests networkd and NetworkManager files of an IPv6-only interface
+// WHY: An IPv6-only VLAN must not be configured with an empty IPv4 section
+func TestPersistenceBackends_IPv6OnlyFiles(t *testing.T) {
+	// Given: An IPv6-only interface whose parent networkd manages
+	iface := VLANInterfaceInfo{VLANName: "storage", VLANId: 200, Interface: "eth1.200", IPAddress: "fd00:200::5/64", PhysInterface: "eth1"}
+	mockKubectl := NewMockDryRunExecutor()
+	mockKubectl.On("ExecNodeCommand", mock.Anything, "node1", "networkctl status eth1 --no-pager").
+		Return(true, "                  Network File: /etc/systemd/network/10-eth1.network", nil)
+	service := NewService(mockKubectl, Options{Logger: logging.NewRecordingLogger()}).(*VLANService)
+
+	// When: Render the files
+	networkd, err := persistenceBackends[config.PersistenceBackendNetworkd].files(context.Background(), service, "node1", iface)
+	require.NoError(t, err)
+	keyfile, err := persistenceBackends[config.PersistenceBackendNetworkManager].files(context.Background(), service, "node1", iface)
+	require.NoError(t, err)
+
+	// Then: Only the IPv6 address is configured
+	assert.Contains(t, networkd[1].Content, "[Network]\nAddress=fd00:200::5/64\n")
+	assert.Contains(t, keyfile[0].Content, "[ipv4]\nmethod=disabled\n\n[ipv6]\nmethod=manual\naddress1=fd00:200::5/64\n")
+}
+
+// TestVLANService_NodePersistenceBackend tests how a node's backend is chosen
+// WHY: Existing configs must keep writing netplan, while auto must follow each node's distribution
+func TestVLANService_NodePersistenceBackend(t *testing.T) {
+	tests := []struct {
+		name            string
+		backend         string
+		dryRun          bool
+		setupMocks      func(*MockDryRunExecutor)
+		expectedBackend string
+		expectedError   string
+	}{
+		{
+			name:            "unset_is_netplan",
+			setupMocks:      func(m *MockDryRunExecutor) {},
+			expectedBackend: config.PersistenceBackendNetplan,
+		},
+		{
+			name:            "explicit_backend",
+			backend:         config.PersistenceBackendIfcfg,
+			setupMocks:      func(m *MockDryRunExecutor) {},
+			expectedBackend: config.PersistenceBackendIfcfg,
+		},
+		{
+			name:    "auto_detects_once_per_node",
+			backend: config.PersistenceBackendAuto,
+			setupMocks: func(m *MockDryRunExecutor) {
+				m.On("ExecNodeCommand", mock.Anything, "node1", detectPersistenceBackendCommand).Return(true, "networkmanager\n", nil).Once()
+			},
+			expectedBackend: config.PersistenceBackendNetworkManager,
+		},
+		{
+			name:    "auto_without_known_backend",
+			backend: config.PersistenceBackendAuto,
+			setupMocks: func(m *MockDryRunExecutor) {
+				m.On("ExecNodeCommand", mock.Anything, "node1", detectPersistenceBackendCommand).Return(true, "", nil).Once()
+			},
+			expectedError: "failed to detect the persistence backend: none of netplan, networkmanager, networkd, ifcfg manages the network of node node1",
+		},
+		{
+			name:    "auto_detection_failure",
+			backend: config.PersistenceBackendAuto,
+			setupMocks: func(m *MockDryRunExecutor) {
+				m.On("ExecNodeCommand", mock.Anything, "node1", detectPersistenceBackendCommand).Return(false, "", errors.New("debug pod timed out")).Once()
+			},
+			expectedError: "failed to detect the persistence backend: debug pod timed out",
+		},
+		{
+			name:       "auto_in_dry_run_renders_nothing",
+			backend:    config.PersistenceBackendAuto,
+			dryRun:     true,
+			setupMocks: func(m *MockDryRunExecutor) {},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A service with the configured backend
+			mockKubectl := NewMockDryRunExecutor()
+			tt.setupMocks(mockKubectl)
+			service := NewService(mockKubectl, Options{
+				DryRun:             tt.dryRun,
+				PersistenceBackend: tt.backend,
+				Logger:             logging.NewRecordingLogger(),
+			}).(*VLANService)
+
+			// When: Choose the backend of the node
+			first, err := service.nodePersistenceBackend(context.Background(), "node1")
+
+			// Then: The expected backend is chosen, and choosing it again does not detect again
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			second, err := service.nodePersistenceBackend(context.Background(), "node1")
+			require.NoError(t, err)
+			if tt.expectedBackend == "" {
+				assert.Nil(t, first)
+			} else {
+				require.NotNil(t, first)
+				assert.Equal(t, tt.expectedBackend, first.name())
+				assert.Equal(t, first, second)
+			}
+			mockKubectl.AssertExpectations(t)
+		})
+	}
+}
+
+// TestVLANTransaction_UncheckedBackendCommands tests a backend without a check
+// WHY: Files no tool can check beforehand are staged and installed by the commit, and removed again with the interface
+func TestVLANTransaction_UncheckedBackendCommands(t *testing.T) {
+	// Given: A prepared transaction writing systemd-networkd files
+	service := NewService(NewMockDryRunExecutor(), Options{
+		PersistentConfig:   true,
+		PersistenceBackend: config.PersistenceBackendNetworkd,
+		DryRun:             true,
+		Logger:             logging.NewRecordingLogger(),
+	}).(*VLANService)
+	tx, err := service.prepareVLANTransaction(context.Background(), "node1", "management", config.VLANConfig{ID: 100}, "eth0.100", "eth0", "192.168.100.10/24")
+	require.NoError(t, err)
+	require.Len(t, tx.files, 3)
+
+	// When/Then: There is no verify phase and the commit stages, installs and cleans up the files
+	assert.False(t, tx.verifies())
+	assert.Equal(t, "ip link add link eth0 name eth0.100 type vlan id 100"+
+		" && ip addr add 192.168.100.10/24 dev eth0.100"+
+		" && ip link set eth0.100 up"+
+		" && rm -rf /run/kictl/staged/eth0.100"+
+		" && mkdir -p /run/kictl/staged/eth0.100/etc/systemd/network '/run/kictl/staged/eth0.100/etc/systemd/network/<network file of eth0>.d'"+
+		" && printf %s '"+tx.files[0].Content+"' > /run/kictl/staged/eth0.100/etc/systemd/network/60-kictl-eth0.100.netdev"+
+		" && printf %s '"+tx.files[1].Content+"' > /run/kictl/staged/eth0.100/etc/systemd/network/60-kictl-eth0.100.network"+
+		" && printf %s '"+tx.files[2].Content+"' > '/run/kictl/staged/eth0.100/etc/systemd/network/<network file of eth0>.d/60-kictl-eth0.100.conf'"+
+		" && install -m 644 /run/kictl/staged/eth0.100/etc/systemd/network/60-kictl-eth0.100.netdev /etc/systemd/network/60-kictl-eth0.100.netdev"+
+		" && install -m 644 /run/kictl/staged/eth0.100/etc/systemd/network/60-kictl-eth0.100.network /etc/systemd/network/60-kictl-eth0.100.network"+
+		" && install -m 644 '/run/kictl/staged/eth0.100/etc/systemd/network/<network file of eth0>.d/60-kictl-eth0.100.conf' '/etc/systemd/network/<network file of eth0>.d/60-kictl-eth0.100.conf'"+
+		" && rm -rf /run/kictl/staged/eth0.100"+
+		" && ip -o addr show dev eth0.100", tx.commitCommand())
+}
+
+// TestVLANTransaction_BackendChecks tests the commands checking staged files of each backend
+// WHY: A file its backend cannot parse must fail the verify phase, before the commit touches the node's network
+func TestVLANTransaction_BackendChecks(t *testing.T) {
+	tests := []struct {
+		backend  string
+		expected string
+	}{
+		{
+			backend:  config.PersistenceBackendNetplan,
+			expected: "netplan generate --root-dir /run/kictl/staged/eth0.100",
+		},
+		{
+			backend: config.PersistenceBackendNetworkManager,
+			expected: "if nmcli --offline --version > /dev/null 2>&1; then" +
+				" nmcli --offline connection modify connection.autoconnect yes < /run/kictl/staged/eth0.100/etc/NetworkManager/system-connections/kictl-eth0.100.nmconnection > /dev/null; fi",
+		},
+		{
+			backend:  config.PersistenceBackendIfcfg,
+			expected: "bash -n /run/kictl/staged/eth0.100/etc/sysconfig/network-scripts/ifcfg-eth0.100",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.backend, func(t *testing.T) {
+			// Given: A prepared transaction writing files of the backend
+			service := NewService(NewMockDryRunExecutor(), Options{
+				PersistentConfig:   true,
+				PersistenceBackend: tt.backend,
+				Logger:             logging.NewRecordingLogger(),
+			}).(*VLANService)
+			tx, err := service.prepareVLANTransaction(context.Background(), "node1", "management", config.VLANConfig{ID: 100}, "eth0.100", "eth0", "192.168.100.10/24")
+			require.NoError(t, err)
+
+			// When/Then: The verify phase stages the files and ends with the check, and the commit installs the staged files
+			assert.True(t, tx.verifies())
+			assert.True(t, strings.HasSuffix(tx.verifyCommand(), " && "+tt.expected), tx.verifyCommand())
+			assert.NotContains(t, tx.commitCommand(), "printf")
+			assert.Contains(t, tx.commitCommand(), "install -m")
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package vlan
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/kubectl"
+	"k8ostack-ictl/internal/plan"
+)
+
+var (
+	// linkHeaderPattern matches "5: eth0.100@eth0: <BROADCAST,...>" lines of ip addr output
+	linkHeaderPattern = regexp.MustCompile(`^\d+:\s+([^:@\s]+)(?:@([^:\s]+))?:`)
+	// vlanIDPattern matches the "vlan protocol 802.1Q id 100" detail line of ip -d output
+	vlanIDPattern = regexp.MustCompile(`vlan protocol \S+ id (\d+)`)
+)
+
+// PlanVLANs diffs the VLAN interfaces on each node against the configuration
+// Interfaces are only added or readdressed by apply, so interfaces missing from the configuration are not reported.
+// Nothing on the cluster is changed.
+func (vs *VLANService) PlanVLANs(ctx context.Context, cfg *config.NodeVLANConf) (*plan.Plan, error) {
+	result := &plan.Plan{}
+
+	nodes := make([]string, 0)
+	for nodeName := range vs.getAllNodesFromConfig(cfg) {
+		nodes = append(nodes, nodeName)
+	}
+	sort.Strings(nodes)
+
+	// Internal ports of OVS bridges are openvswitch interfaces rather than vlan ones
+	listCmd := kubectl.HostCommand("ip", "-d", "addr", "show", "type", "vlan")
+	for _, vlanConfig := range cfg.Spec.VLANs {
+		if vlanConfig.OVS != nil {
+			listCmd = kubectl.JoinHostCommands(listCmd, kubectl.HostCommand("ip", "-d", "addr", "show", "type", "openvswitch"))
+			break
+		}
+	}
+
+	for _, nodeName := range nodes {
+		success, output, err := vs.kubectl.ExecNodeCommand(ctx, nodeName, listCmd)
+		if err != nil || !success {
+			result.Errors = append(result.Errors, fmt.Errorf("failed to read VLAN interfaces on node %s: %v", nodeName, err))
+			continue
+		}
+		current := make(map[string]VLANInterfaceInfo)
+		for _, info := range parseVLANAddresses(output) {
+			current[info.Interface] = info
+		}
+
+		for vlanName, vlanConfig := range cfg.Spec.VLANs {
+			ipAddress, exists := vlanConfig.NodeMapping[nodeName]
+			if !exists {
+				continue
+			}
+			vlanInterface, _, err := vs.vlanInterfaceNames(ctx, nodeName, vlanConfig)
+			if err != nil {
+				result.Errors = append(result.Errors, fmt.Errorf("failed to plan VLAN %s on node %s: %v", vlanName, nodeName, err))
+				continue
+			}
+			desired := fmt.Sprintf("%s %s", vlanInterface, ipAddress)
+
+			info, configured := current[vlanInterface]
+			switch {
+			case !configured:
+				result.Changes = append(result.Changes, plan.Change{Node: nodeName, Kind: "vlan", Key: vlanName, Op: plan.OpAdd, NewValue: desired})
+			case !hasAddress(info.IPAddress, ipAddress):
+				oldAddress := info.IPAddress
+				if oldAddress == "" {
+					oldAddress = "no address"
+				}
+				result.Changes = append(result.Changes, plan.Change{Node: nodeName, Kind: "vlan", Key: vlanName, Op: plan.OpChange,
+					OldValue: fmt.Sprintf("%s %s", vlanInterface, oldAddress), NewValue: desired})
+			}
+		}
+	}
+
+	// Reading interfaces starts debug pods just like verify does
+	if len(nodes) > 0 {
+		vs.cleanupDebugPods(ctx)
+	}
+
+	return result, nil
+}
+
+// physicalInterface returns the parent interface of a VLAN, falling back to the service default
+func (vs *VLANService) physicalInterface(vlanConfig config.VLANConfig) string {
+	if vlanConfig.Interface != "" {
+		return vlanConfig.Interface
+	}
+	if vs.options.DefaultInterface != "" {
+		return vs.options.DefaultInterface
+	}
+	return "eth0"
+}
+
+// parseVLANAddresses parses `ip -d addr show type vlan` output into one entry per interface
+// IPAddress holds all addresses of the interface, comma separated
+func parseVLANAddresses(output string) []VLANInterfaceInfo {
+	var interfaces []VLANInterfaceInfo
+	var addresses []string
+
+	flush := func() {
+		if len(interfaces) > 0 {
+			interfaces[len(interfaces)-1].IPAddress = strings.Join(addresses, ",")
+		}
+		addresses = nil
+	}
+
+	for _, line := range strings.Split(output, "\n") {
+		if match := linkHeaderPattern.FindStringSubmatch(line); match != nil {
+			flush()
+			interfaces = append(interfaces, VLANInterfaceInfo{Interface: match[1], PhysInterface: match[2]})
+			continue
+		}
+		if len(interfaces) == 0 {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && (fields[0] == "inet" || fields[0] == "inet6") {
+			addresses = append(addresses, fields[1])
+		} else if match := vlanIDPattern.FindStringSubmatch(line); match != nil {
+			interfaces[len(interfaces)-1].VLANId, _ = strconv.Atoi(match[1])
+		}
+	}
+	flush()
+
+	return interfaces
+}
+
+// hasAddress reports whether a comma separated address list contains every desired address, of a dual-stack node too
+// An address configured without a prefix length matches any prefix length
+func hasAddress(addresses, desired string) bool {
+	for _, address := range config.SplitAddresses(desired) {
+		if !containsAddress(addresses, address) {
+			return false
+		}
+	}
+	return true
+}
+
+// containsAddress reports whether a comma separated address list contains one address
+func containsAddress(addresses, address string) bool {
+	for _, candidate := range strings.Split(addresses, ",") {
+		if candidate == address || (!strings.Contains(address, "/") && strings.SplitN(candidate, "/", 2)[0] == address) {
+			return true
+		}
+	}
+	return false
+}
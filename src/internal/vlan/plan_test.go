@@ -0,0 +1,77 @@
+// Package vlan provides tests for VLAN plans
+package vlan
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/kubectl"
+	"k8ostack-ictl/internal/logging"
+	"k8ostack-ictl/internal/plan"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// ipAddrShowVLAN is sample `ip -d addr show type vlan` output with one configured VLAN
+const ipAddrShowVLAN = `5: eth0.100@eth0: <BROADCAST,MULTICAST,UP,LOWER_UP> mtu 1500 qdisc noqueue state UP group default qlen 1000
+    link/ether 52:54:00:12:34:56 brd ff:ff:ff:ff:ff:ff promiscuity 0 minmtu 0 maxmtu 65535
+    vlan protocol 802.1Q id 100 <REORDER_HDR> numtxqueues 1 numrxqueues 1
+    inet 10.100.0.12/24 scope global eth0.100
+       valid_lft forever preferred_lft forever
+6: eth0.200@eth0: <BROADCAST,MULTICAST> mtu 1500 qdisc noop state DOWN group default qlen 1000
+    link/ether 52:54:00:12:34:56 brd ff:ff:ff:ff:ff:ff promiscuity 0 minmtu 0 maxmtu 65535
+    vlan protocol 802.1Q id 200 <REORDER_HDR> numtxqueues 1 numrxqueues 1
+`
+
+// TestParseVLANAddresses tests parsing of ip -d addr output
+// WHY: Plans compare addresses per interface, so addresses must not leak between interfaces
+func TestParseVLANAddresses(t *testing.T) {
+	interfaces := parseVLANAddresses(ipAddrShowVLAN)
+
+	require.Len(t, interfaces, 2)
+	assert.Equal(t, VLANInterfaceInfo{Interface: "eth0.100", PhysInterface: "eth0", VLANId: 100, IPAddress: "10.100.0.12/24"}, interfaces[0])
+	assert.Equal(t, VLANInterfaceInfo{Interface: "eth0.200", PhysInterface: "eth0", VLANId: 200}, interfaces[1])
+	assert.Empty(t, parseVLANAddresses(""))
+}
+
+// TestVLANService_PlanVLANs tests diffing node VLAN interfaces against the configuration
+// WHY: Missing interfaces are additions, wrong or missing addresses are changes
+func TestVLANService_PlanVLANs(t *testing.T) {
+	cfg := &config.NodeVLANConf{
+		Spec: config.NodeVLANSpec{
+			VLANs: map[string]config.VLANConfig{
+				"storage":    {ID: 100, NodeMapping: map[string]string{"rsb2": "10.100.0.12/24", "rsb3": "10.100.0.13/24"}},
+				"tenant":     {ID: 200, NodeMapping: map[string]string{"rsb2": "10.200.0.12/24"}},
+				"management": {ID: 300, Interface: "eth1", NodeMapping: map[string]string{"rsb2": "10.30.0.12/24"}},
+			},
+		},
+	}
+	command := kubectl.HostCommand("ip", "-d", "addr", "show", "type", "vlan")
+
+	// Given: rsb2 has storage configured and tenant without address; rsb3 cannot be read
+	mockKubectl := NewMockDryRunExecutor()
+	mockLogger := logging.NewMockLogger()
+	mockLogger.On("Info", mock.Anything).Maybe()
+	mockLogger.On("Warn", mock.Anything).Maybe()
+	mockKubectl.On("ExecNodeCommand", mock.Anything, "rsb2", command).Return(true, ipAddrShowVLAN, nil)
+	mockKubectl.On("ExecNodeCommand", mock.Anything, "rsb3", command).Return(false, "", fmt.Errorf("timeout"))
+	mockKubectl.On("ReleaseDebugPods", mock.Anything).Return(0, nil)
+	service := NewService(mockKubectl, Options{DefaultInterface: "eth0", Logger: mockLogger})
+
+	// When: Plan
+	result, err := service.PlanVLANs(context.Background(), cfg)
+
+	// Then: Only rsb2's missing and misaddressed VLANs are pending
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []plan.Change{
+		{Node: "rsb2", Kind: "vlan", Key: "tenant", Op: plan.OpChange, OldValue: "eth0.200 no address", NewValue: "eth0.200 10.200.0.12/24"},
+		{Node: "rsb2", Kind: "vlan", Key: "management", Op: plan.OpAdd, NewValue: "eth1.300 10.30.0.12/24"},
+	}, result.Changes)
+	require.Len(t, result.Errors, 1)
+	assert.Contains(t, result.Errors[0].Error(), "rsb3")
+	mockKubectl.AssertNotCalled(t, "SetDryRun", mock.Anything)
+}
@@ -0,0 +1,32 @@
+package vlan
+
+import (
+	"context"
+	"fmt"
+
+	"k8ostack-ictl/internal/config"
+)
+
+// recoverLostNode runs the BMC recovery action on a node kictl lost during a change, as a last resort
+// The returned error is the cause extended with what the recovery did; nodes without a BMC are left as they are.
+func (vs *VLANService) recoverLostNode(ctx context.Context, nodeName string, cause error) error {
+	recovery := vs.options.Recovery
+	if recovery == nil || !recovery.Has(nodeName) {
+		return cause
+	}
+
+	action := recovery.Action()
+	vs.options.Logger.Warn(fmt.Sprintf("🆘 Lost node %s, running %s through its BMC as a last resort", nodeName, action))
+	output, err := recovery.Recover(ctx, nodeName)
+	if err != nil {
+		vs.options.Logger.Error(fmt.Sprintf("BMC %s of node %s failed: %v", action, nodeName, err))
+		return fmt.Errorf("%w; BMC %s failed too: %v", cause, action, err)
+	}
+
+	if action == config.BMCActionPowerCycle {
+		vs.options.Logger.Warn(fmt.Sprintf("🔌 Power-cycled node %s through its BMC", nodeName))
+		return fmt.Errorf("%w; node power-cycled through its BMC", cause)
+	}
+	vs.options.Logger.Warn(fmt.Sprintf("📟 Serial console of node %s:\n%s", nodeName, output))
+	return fmt.Errorf("%w; serial console captured through its BMC", cause)
+}
@@ -0,0 +1,147 @@
+// Package vlan provides unit tests for BMC recovery of lost nodes
+// WHY: A node cut off by a change can only be diagnosed or reset through its BMC, and only once
+package vlan
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"k8ostack-ictl/internal/bmc"
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/kubectl"
+	"k8ostack-ictl/internal/logging"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// testBMCController returns a BMC controller for one node that records the ipmitool arguments it runs
+func testBMCController(nodeName, action string, calls *[]string) *bmc.Controller {
+	recovery := config.BMCRecovery{
+		Action:          action,
+		ConsoleDuration: time.Millisecond,
+		Nodes:           map[string]config.NodeBMC{nodeName: {Address: "10.0.9.11", Credentials: config.SecretRef{Name: "bmc"}}},
+	}
+	credentials := func(ctx context.Context, ref config.SecretRef) (bmc.Credentials, error) {
+		return bmc.Credentials{Username: "admin", Password: "s3cret"}, nil
+	}
+	return bmc.NewController(recovery, credentials, func(ctx context.Context, env []string, args ...string) (string, error) {
+		*calls = append(*calls, strings.Join(args[len(args)-2:], " "))
+		return "ttyS0 login:", nil
+	})
+}
+
+// TestVLANService_RollbackOnFailure_BMCRecovery tests recovering a node that became unreachable before its rollback
+// WHY: Rollback is the last step of a failed run, so a lost node needs its BMC, run once, and not more teardown attempts
+func TestVLANService_RollbackOnFailure_BMCRecovery(t *testing.T) {
+	isCreate := func(cmd string) bool { return strings.Contains(cmd, "ip link add") }
+	isTeardown := func(cmd string) bool { return strings.Contains(cmd, "ip link delete") }
+
+	tests := []struct {
+		name          string
+		bmcNode       string
+		expectedCalls []string
+		expectedError string
+	}{
+		{
+			name:          "console_captured_through_bmc",
+			bmcNode:       "node1",
+			expectedCalls: []string{"sol deactivate", "sol activate", "sol deactivate"},
+			expectedError: "; serial console captured through its BMC",
+		},
+		{
+			name:          "node_without_bmc_is_left_as_it_is",
+			bmcNode:       "node2",
+			expectedError: "timeout waiting for pod",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: Two VLANs created on node1, which is lost before they are rolled back, and a failing node2
+			mockKubectl := NewMockDryRunExecutor()
+			mockKubectl.On("SetDryRun", false).Return()
+			mockKubectl.On("ReleaseDebugPods", mock.Anything).Return(0, nil)
+			mockKubectl.On("ExecNodeCommand", mock.Anything, "node1", mock.MatchedBy(isCreate)).
+				Return(true, addrOutput("eth0.100=192.168.100.10/24", "eth0.200=192.168.200.10/24"), nil)
+			mockKubectl.On("ExecNodeCommand", mock.Anything, "node2", mock.MatchedBy(isCreate)).Return(false, "RTNETLINK answers: No such device", nil)
+			mockKubectl.On("ExecNodeCommand", mock.Anything, "node1", mock.MatchedBy(isTeardown)).
+				Return(false, "", &kubectl.HostCommandError{Node: "node1", Class: kubectl.HostErrorTransport, Cause: errors.New("timeout waiting for pod")})
+
+			var calls []string
+			service := NewService(mockKubectl, Options{
+				RollbackOnFailure: true,
+				Recovery:          testBMCController(tt.bmcNode, "", &calls),
+				DefaultInterface:  "eth0",
+				Logger:            logging.NewRecordingLogger(),
+			})
+			vlanConfig := &config.NodeVLANConf{
+				Kind:     "NodeVLANConf",
+				Metadata: config.Metadata{Name: "bmc-recovery"},
+				Spec: config.NodeVLANSpec{VLANs: map[string]config.VLANConfig{
+					"management": {ID: 100, Subnet: "192.168.100.0/24",
+						NodeMapping: map[string]string{"node1": "192.168.100.10/24", "node2": "192.168.100.11/24"}},
+					"storage": {ID: 200, Subnet: "192.168.200.0/24",
+						NodeMapping: map[string]string{"node1": "192.168.200.10/24"}},
+				}},
+			}
+
+			// When: Configure VLANs
+			results, err := service.ConfigureVLANs(context.Background(), vlanConfig)
+
+			// Then: One teardown fails, the BMC action runs once, and both interfaces stay recorded
+			require.NoError(t, err)
+			mockKubectl.AssertNumberOfCalls(t, "ExecNodeCommand", 4)
+			assert.Equal(t, tt.expectedCalls, calls)
+			assert.Len(t, results.ConfiguredVLANs["node1"], 2)
+			require.Len(t, results.Errors, 2)
+			assert.ErrorContains(t, results.Errors[1], tt.expectedError)
+		})
+	}
+}
+
+// TestVLANService_ConfigureVLANs_ReachabilityGuard_BMCRecovery tests power-cycling a node lost after a guarded change
+// WHY: A node that no longer answers after its management interface changed is the case the BMC is configured for
+func TestVLANService_ConfigureVLANs_ReachabilityGuard_BMCRecovery(t *testing.T) {
+	// Given: A guarded change on eth0 after which node1 no longer answers, and power-cycle as the BMC action
+	mockKubectl := NewMockDryRunExecutor()
+	mockKubectl.On("SetDryRun", false).Return()
+	mockKubectl.On("ReleaseDebugPods", mock.Anything).Return(0, nil)
+	mockKubectl.On("GetNodeInternalIP", mock.Anything, "node1").Return(true, "10.0.0.12", nil)
+	mockKubectl.On("ExecNodeCommand", mock.Anything, "node1", "ip -o addr show").
+		Return(true, addrOutput("eth0=10.0.0.12/24", "eth1.100=192.168.100.10/24"), nil)
+	mockKubectl.On("ExecNodeCommand", mock.Anything, "node1", "true").Return(false, "", errors.New("timed out waiting for the debug pod"))
+	mockKubectl.On("ExecNodeCommand", mock.Anything, "node1", mock.Anything).
+		Return(true, addrOutput("eth1.100=192.168.100.10/24", "eth0.200=10.200.0.10/24"), nil)
+
+	var calls []string
+	service := NewService(mockKubectl, Options{
+		ReachabilityGuard: &config.ReachabilityGuard{ManagementVLAN: "management"},
+		Recovery:          testBMCController("node1", config.BMCActionPowerCycle, &calls),
+		Logger:            logging.NewRecordingLogger(),
+	})
+	vlanConfig := &config.NodeVLANConf{
+		Kind:     "NodeVLANConf",
+		Metadata: config.Metadata{Name: "guard"},
+		Spec: config.NodeVLANSpec{VLANs: map[string]config.VLANConfig{
+			"management": {ID: 100, Subnet: "192.168.100.0/24", Interface: "eth1",
+				NodeMapping: map[string]string{"node1": "192.168.100.10/24"}},
+			"storage": {ID: 200, Subnet: "10.200.0.0/24", Interface: "eth0",
+				NodeMapping: map[string]string{"node1": "10.200.0.10/24"}},
+		}},
+	}
+
+	// When: Configure VLANs
+	results, err := service.ConfigureVLANs(context.Background(), vlanConfig)
+
+	// Then: The node is power-cycled through its BMC and the failure says so
+	require.NoError(t, err)
+	assert.Equal(t, []string{"power cycle"}, calls)
+	require.Len(t, results.Errors, 1)
+	assert.ErrorContains(t, results.Errors[0], "no longer answers after eth0.200 changed")
+	assert.ErrorContains(t, results.Errors[0], "; node power-cycled through its BMC")
+}
@@ -2,12 +2,18 @@ package vlan
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
+	"sort"
 	"strings"
-	"time"
+	"sync"
 
 	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/kubectl"
+	"k8ostack-ictl/internal/logging"
+	"k8ostack-ictl/internal/progress"
+	"k8ostack-ictl/internal/throttle"
 
 	"golang.org/x/text/cases"
 	"golang.org/x/text/language"
@@ -44,11 +50,10 @@ func (vs *VLANService) VerifyVLANs(ctx context.Context, cfg *config.NodeVLANConf
 		if vs.options.ValidateConnectivity {
 			success, _, err := vs.kubectl.GetNode(ctx, nodeName)
 			if err != nil || !success {
-				vs.options.Logger.Error(fmt.Sprintf("Node %s not found in cluster: %v", nodeName, err))
+				notFound := kubectl.NewNodeNotFoundError(nodeName, vs.nodes.Names(ctx), err)
+				vs.options.Logger.Error(notFound.Error())
 				results.FailedNodes = append(results.FailedNodes, nodeName)
-				if err != nil {
-					results.Errors = append(results.Errors, err)
-				}
+				results.Errors = append(results.Errors, notFound)
 				continue
 			}
 		}
@@ -58,7 +63,7 @@ func (vs *VLANService) VerifyVLANs(ctx context.Context, cfg *config.NodeVLANConf
 		if err != nil {
 			vs.options.Logger.Error(fmt.Sprintf("Failed to verify VLANs on node %s: %v", nodeName, err))
 			results.FailedNodes = append(results.FailedNodes, nodeName)
-			results.Errors = append(results.Errors, err)
+			results.Errors = append(results.Errors, kubectl.WrapNodeError(nodeName, err))
 			continue
 		}
 
@@ -113,6 +118,14 @@ func (vs *VLANService) processVLANs(ctx context.Context, cfg *config.NodeVLANCon
 			operationName, configName, cfg.Kind, cfg.APIVersion))
 	}
 
+	vs.guard = vs.newReachabilityGuard(cfg)
+
+	total := 0
+	for _, vlanConfig := range cfg.Spec.VLANs {
+		total += len(vlanConfig.NodeMapping)
+	}
+	vs.progress().Begin(operationName, total)
+
 	// Process each VLAN
 	for vlanName, vlanConfig := range cfg.Spec.VLANs {
 		vs.options.Logger.Info(fmt.Sprintf("🔧 Processing VLAN: %s (ID: %d, Subnet: %s)",
@@ -124,16 +137,46 @@ func (vs *VLANService) processVLANs(ctx context.Context, cfg *config.NodeVLANCon
 		}
 
 		// Process each node in this VLAN
-		for nodeName, ipAddress := range vlanConfig.NodeMapping {
+		nodes := make([]string, 0, len(vlanConfig.NodeMapping))
+		for nodeName := range vlanConfig.NodeMapping {
+			nodes = append(nodes, nodeName)
+		}
+		sort.Strings(nodes)
+
+		results.Order = append(results.Order, vs.options.Workers.Order(nodes)...)
+		var mu sync.Mutex
+		err := vs.options.Workers.Run(nodes, func(nodeName string) {
+			ipAddress := vlanConfig.NodeMapping[nodeName]
+			node := vs.forNode(nodeName)
+			node.options.Logger.Info(fmt.Sprintf("  📍 Processing node: %s -> %s", nodeName, ipAddress))
+			vs.progress().Started(nodeName)
+
+			nodeResults := &OperationResults{ConfiguredVLANs: make(map[string][]VLANInterfaceInfo)}
+			success := node.processNodeVLAN(ctx, nodeName, vlanName, vlanConfig, ipAddress, operation, nodeResults)
+			vs.progress().Done(nodeName, !success)
+
+			mu.Lock()
+			defer mu.Unlock()
 			results.TotalNodes++
-			vs.options.Logger.Info(fmt.Sprintf("  📍 Processing node: %s -> %s", nodeName, ipAddress))
-
-			if vs.processNodeVLAN(ctx, nodeName, vlanName, vlanConfig, ipAddress, operation, results) {
+			if success {
 				results.SuccessfulNodes++
 			}
+			results.merge(nodeResults)
+		})
+		if err != nil {
+			// A batch failed its soak, so the later VLANs are left alone too
+			vs.options.Logger.Error(fmt.Sprintf("Stopped at VLAN %s: %v", vlanName, err))
+			results.Errors = append(results.Errors, err)
+			var abort *throttle.AbortError
+			if operation == "configure" && vs.options.RollbackAbortedBatch && errors.As(err, &abort) {
+				vs.rollbackBatch(ctx, results, vlanName, abort.Batch)
+			}
+			break
 		}
 	}
 
+	vs.progress().End()
+
 	// Print summary
 	vs.options.Logger.Info(strings.Repeat("=", 60))
 	vs.options.Logger.Info("📊 VLAN Operation Summary:")
@@ -143,6 +186,10 @@ func (vs *VLANService) processVLANs(ctx context.Context, cfg *config.NodeVLANCon
 
 	if len(results.FailedNodes) > 0 {
 		vs.options.Logger.Warn(fmt.Sprintf("  Failed nodes: %s", strings.Join(results.FailedNodes, ", ")))
+
+		if operation == "configure" && vs.options.RollbackOnFailure {
+			vs.rollbackVLANs(ctx, results)
+		}
 	}
 
 	// Automatically cleanup debug pods after operations
@@ -151,62 +198,88 @@ func (vs *VLANService) processVLANs(ctx context.Context, cfg *config.NodeVLANCon
 	return results, nil
 }
 
+// progress returns the reporter of the service, which discards progress when none is set
+func (vs *VLANService) progress() progress.Reporter {
+	return progress.Or(vs.options.Progress)
+}
+
+// forNode returns a copy of the service whose log entries carry the node name
+func (vs *VLANService) forNode(nodeName string) *VLANService {
+	node := *vs
+	node.options.Logger = vs.options.Logger.With(logging.FieldNode, nodeName)
+	return &node
+}
+
 // processNodeVLAN processes VLAN configuration for a single node
 func (vs *VLANService) processNodeVLAN(ctx context.Context, nodeName, vlanName string, vlanConfig config.VLANConfig, ipAddress, operation string, results *OperationResults) bool {
 	// Validate node exists if requested
 	if vs.options.ValidateConnectivity {
 		success, _, err := vs.kubectl.GetNode(ctx, nodeName)
 		if err != nil || !success {
-			vs.options.Logger.Error(fmt.Sprintf("Node %s does not exist in the cluster", nodeName))
+			notFound := kubectl.NewNodeNotFoundError(nodeName, vs.nodes.Names(ctx), err)
+			vs.options.Logger.Error(notFound.Error())
 			results.FailedNodes = append(results.FailedNodes, nodeName)
-			if err != nil {
-				results.Errors = append(results.Errors, err)
-			}
+			results.Errors = append(results.Errors, notFound)
 			return false
 		}
 	}
 
-	// Determine physical interface
-	physInterface := vlanConfig.Interface
-	if physInterface == "" {
-		physInterface = vs.options.DefaultInterface
-		if physInterface == "" {
-			physInterface = "eth0" // Default fallback
-		}
+	// Determine the VLAN interface and the physical interface or bridge it hangs off
+	vlanInterface, physInterface, err := vs.vlanInterfaceNames(ctx, nodeName, vlanConfig)
+	if err != nil {
+		vs.options.Logger.Error(fmt.Sprintf("Failed to choose the interface of VLAN %s on node %s: %v", vlanName, nodeName, err))
+		results.FailedNodes = append(results.FailedNodes, nodeName)
+		results.Errors = append(results.Errors, kubectl.WrapNodeError(nodeName, err))
+		return false
 	}
 
-	// Create VLAN interface name
-	vlanInterface := fmt.Sprintf("%s.%d", physInterface, vlanConfig.ID)
+	// Validate IP address format, of every address of a dual-stack node
+	addresses := config.SplitAddresses(ipAddress)
+	if len(addresses) == 0 {
+		addresses = []string{ipAddress}
+	}
+	for _, address := range addresses {
+		if _, _, err := net.ParseCIDR(address); err != nil {
+			vs.options.Logger.Error(fmt.Sprintf("Invalid IP address format for node %s: %s", nodeName, address))
+			results.FailedNodes = append(results.FailedNodes, nodeName)
+			results.Errors = append(results.Errors, kubectl.WrapNodeError(nodeName, fmt.Errorf("invalid IP format: %s", address)))
+			return false
+		}
+	}
 
-	// Validate IP address format
-	if _, _, err := net.ParseCIDR(ipAddress); err != nil {
-		vs.options.Logger.Error(fmt.Sprintf("Invalid IP address format for node %s: %s", nodeName, ipAddress))
+	guarded, err := vs.checkReachability(ctx, nodeName, vlanInterface, physInterface)
+	if err != nil {
+		vs.options.Logger.Error(fmt.Sprintf("Failed to %s VLAN %s on node %s: %v", operation, vlanName, nodeName, err))
 		results.FailedNodes = append(results.FailedNodes, nodeName)
-		results.Errors = append(results.Errors, fmt.Errorf("invalid IP format: %s", ipAddress))
+		results.Errors = append(results.Errors, kubectl.WrapNodeError(nodeName, err))
 		return false
 	}
 
 	var success bool
-	var err error
 
 	if operation == "remove" {
-		success, err = vs.removeVLANInterface(ctx, nodeName, vlanInterface)
+		success, err = vs.removeVLANInterface(ctx, nodeName, vlanInterface, vlanConfig.OVS)
 		if success {
 			vs.options.Logger.Info(fmt.Sprintf("✅ Removed VLAN interface %s from node %s", vlanInterface, nodeName))
 		}
 	} else {
-		success, err = vs.configureVLANInterface(ctx, nodeName, vlanName, vlanConfig, vlanInterface, physInterface, ipAddress)
-		if success {
+		var persisted []string
+		persisted, err = vs.configureVLANInterface(ctx, nodeName, vlanName, vlanConfig, vlanInterface, physInterface, ipAddress)
+		if success = err == nil; success {
 			vs.options.Logger.Info(fmt.Sprintf("✅ Configured VLAN %s (%s) on node %s: %s", vlanName, vlanInterface, nodeName, ipAddress))
 
 			// Add to results
 			vlanInfo := VLANInterfaceInfo{
-				VLANName:      vlanName,
-				VLANId:        vlanConfig.ID,
-				Interface:     vlanInterface,
-				IPAddress:     ipAddress,
-				PhysInterface: physInterface,
-				Subnet:        vlanConfig.Subnet,
+				VLANName:       vlanName,
+				VLANId:         vlanConfig.ID,
+				Interface:      vlanInterface,
+				IPAddress:      ipAddress,
+				PhysInterface:  physInterface,
+				Subnet:         vlanConfig.Subnet,
+				PersistedFiles: persisted,
+			}
+			if vlanConfig.OVS != nil {
+				vlanInfo.Bridge = vlanConfig.OVS.Bridge
 			}
 
 			if results.ConfiguredVLANs[nodeName] == nil {
@@ -216,68 +289,60 @@ func (vs *VLANService) processNodeVLAN(ctx context.Context, nodeName, vlanName s
 		}
 	}
 
+	if success && guarded {
+		if err = vs.confirmReachability(ctx, nodeName, vlanInterface); err != nil {
+			err = vs.recoverLostNode(ctx, nodeName, err)
+		}
+	}
+
 	if err != nil {
 		vs.options.Logger.Error(fmt.Sprintf("Failed to %s VLAN %s on node %s: %v", operation, vlanName, nodeName, err))
 		results.FailedNodes = append(results.FailedNodes, nodeName)
-		results.Errors = append(results.Errors, err)
+		results.Errors = append(results.Errors, kubectl.WrapNodeError(nodeName, err))
 		return false
 	}
 
+	if success {
+		vs.updateMembershipLabel(ctx, nodeName, vlanName, ipAddress, operation)
+	}
 	return success
 }
 
-// configureVLANInterface creates and configures a VLAN interface on a node
-func (vs *VLANService) configureVLANInterface(ctx context.Context, nodeName, vlanName string, vlanConfig config.VLANConfig, vlanInterface, physInterface, ipAddress string) (bool, error) {
-	// Combine all commands into a single execution to reduce pod creation
-	var commands []string
-	commands = append(commands,
-		// Create VLAN interface
-		fmt.Sprintf("ip link add link %s name %s type vlan id %d", physInterface, vlanInterface, vlanConfig.ID),
-		// Assign IP address
-		fmt.Sprintf("ip addr add %s dev %s", ipAddress, vlanInterface),
-		// Bring interface up
-		fmt.Sprintf("ip link set %s up", vlanInterface),
-	)
-
-	// Add persistent configuration if requested
-	if vs.options.PersistentConfig {
-		netplanCmd := vs.generateNetplanConfig(vlanName, vlanConfig, vlanInterface, physInterface, ipAddress)
-		if netplanCmd != "" {
-			commands = append(commands, netplanCmd)
-		}
-	}
-
-	// Combine all commands with && to ensure they run in sequence and fail fast
-	combinedCmd := strings.Join(commands, " && ")
-
-	// Execute combined command in a single pod
-	cmdSuccess, output, err := vs.kubectl.ExecNodeCommand(ctx, nodeName, combinedCmd)
+// configureVLANInterface creates and configures a VLAN interface on a node as a prepare, verify, commit and confirm transaction
+// It returns the persistent configuration files it installed.
+func (vs *VLANService) configureVLANInterface(ctx context.Context, nodeName, vlanName string, vlanConfig config.VLANConfig, vlanInterface, physInterface, ipAddress string) ([]string, error) {
+	tx, err := vs.prepareVLANTransaction(ctx, nodeName, vlanName, vlanConfig, vlanInterface, physInterface, ipAddress)
 	if err != nil {
-		return false, fmt.Errorf("failed to execute combined VLAN commands: %w", err)
+		return nil, &TransactionError{Phase: PhasePrepare, Interface: vlanInterface, Err: err}
 	}
-	if !cmdSuccess {
-		return false, fmt.Errorf("VLAN configuration failed: %s", output)
+
+	if err := vs.runVLANTransaction(ctx, tx); err != nil {
+		return nil, err
 	}
 
 	if vs.options.Verbose {
-		vs.options.Logger.Info(fmt.Sprintf("    💻 Executed combined VLAN setup for %s", vlanInterface))
+		vs.options.Logger.Info(fmt.Sprintf("    💻 Executed VLAN transaction for %s", vlanInterface))
 	}
 
-	return true, nil
+	return tx.persistedPaths(), nil
 }
 
-// removeVLANInterface removes a VLAN interface from a node
-func (vs *VLANService) removeVLANInterface(ctx context.Context, nodeName, vlanInterface string) (bool, error) {
+// removeVLANInterface removes a VLAN interface from a node, or the port of an OVS VLAN from its bridge
+func (vs *VLANService) removeVLANInterface(ctx context.Context, nodeName, vlanInterface string, ovs *config.OVSConfig) (bool, error) {
 	// Combine removal commands into a single execution
 	commands := []string{
 		// Bring interface down
-		fmt.Sprintf("ip link set %s down", vlanInterface),
+		kubectl.HostCommand("ip", "link", "set", vlanInterface, "down"),
 		// Remove VLAN interface
-		fmt.Sprintf("ip link delete %s", vlanInterface),
+		kubectl.HostCommand("ip", "link", "delete", vlanInterface),
+	}
+	if ovs != nil {
+		// Deleting the port removes its internal interface with it
+		commands = []string{ovsDelPortCommand(ovs.Bridge, vlanInterface, true)}
 	}
 
 	// Combine commands with && but use || true to make it non-failing if interface doesn't exist
-	combinedCmd := strings.Join(commands, " && ") + " || true"
+	combinedCmd := kubectl.JoinHostCommands(commands...) + " || true"
 
 	// Execute combined command in a single pod
 	cmdSuccess, output, err := vs.kubectl.ExecNodeCommand(ctx, nodeName, combinedCmd)
@@ -297,50 +362,182 @@ func (vs *VLANService) removeVLANInterface(ctx context.Context, nodeName, vlanIn
 	return true, nil
 }
 
+// rollbackVLANs tears down every VLAN interface the run created so a partial failure leaves no mixed state
+// Only interfaces in ConfiguredVLANs are touched: ip link add fails on existing interfaces, so those were all created by this run.
+func (vs *VLANService) rollbackVLANs(ctx context.Context, results *OperationResults) {
+	if len(results.ConfiguredVLANs) == 0 {
+		return
+	}
+
+	nodes := make([]string, 0, len(results.ConfiguredVLANs))
+	for nodeName := range results.ConfiguredVLANs {
+		nodes = append(nodes, nodeName)
+	}
+	sort.Strings(nodes)
+
+	if vs.options.DryRun {
+		vs.options.Logger.Info(fmt.Sprintf("🧪 DRY RUN: Would roll back VLAN interfaces created on %d nodes", len(nodes)))
+		return
+	}
+
+	vs.options.Logger.Warn(fmt.Sprintf("↩️  Rolling back VLAN interfaces created on %d nodes...", len(nodes)))
+	if results.RolledBackVLANs == nil {
+		results.RolledBackVLANs = make(map[string][]VLANInterfaceInfo)
+	}
+
+	for _, nodeName := range nodes {
+		var kept []VLANInterfaceInfo
+		lost := false
+		for _, vlanInfo := range results.ConfiguredVLANs[nodeName] {
+			// Once the node is unreachable, the rest of its interfaces cannot be rolled back either
+			if lost {
+				kept = append(kept, vlanInfo)
+				continue
+			}
+			if err := vs.rollbackVLANInterface(ctx, nodeName, vlanInfo.Interface, vlanInfo.Bridge, vlanInfo.PersistedFiles); err != nil {
+				if kubectl.ClassifyHostCommandFailure("", err) == kubectl.HostErrorTransport {
+					lost = true
+					err = vs.recoverLostNode(ctx, nodeName, err)
+				}
+				vs.options.Logger.Error(fmt.Sprintf("Failed to roll back VLAN interface %s on node %s: %v", vlanInfo.Interface, nodeName, err))
+				results.Errors = append(results.Errors, kubectl.WrapNodeError(nodeName, fmt.Errorf("rollback of %s failed: %w", vlanInfo.Interface, err)))
+				kept = append(kept, vlanInfo)
+				continue
+			}
+			vs.options.Logger.Info(fmt.Sprintf("↩️  Rolled back VLAN %s (%s) on node %s", vlanInfo.VLANName, vlanInfo.Interface, nodeName))
+			vs.updateMembershipLabel(ctx, nodeName, vlanInfo.VLANName, vlanInfo.IPAddress, "remove")
+			results.RolledBackVLANs[nodeName] = append(results.RolledBackVLANs[nodeName], vlanInfo)
+		}
+
+		if len(kept) == 0 {
+			delete(results.ConfiguredVLANs, nodeName)
+		} else {
+			results.ConfiguredVLANs[nodeName] = kept
+		}
+	}
+}
+
+// rollbackBatch tears down the interfaces of one VLAN that the run created on the nodes of a batch that failed its soak
+// The interfaces of earlier VLANs passed their soaks and stay.
+func (vs *VLANService) rollbackBatch(ctx context.Context, results *OperationResults, vlanName string, batch []string) {
+	aborted := &OperationResults{ConfiguredVLANs: make(map[string][]VLANInterfaceInfo)}
+	for _, nodeName := range batch {
+		var kept []VLANInterfaceInfo
+		for _, vlanInfo := range results.ConfiguredVLANs[nodeName] {
+			if vlanInfo.VLANName == vlanName {
+				aborted.ConfiguredVLANs[nodeName] = append(aborted.ConfiguredVLANs[nodeName], vlanInfo)
+			} else {
+				kept = append(kept, vlanInfo)
+			}
+		}
+		if _, ok := aborted.ConfiguredVLANs[nodeName]; !ok {
+			continue
+		}
+		if len(kept) == 0 {
+			delete(results.ConfiguredVLANs, nodeName)
+		} else {
+			results.ConfiguredVLANs[nodeName] = kept
+		}
+	}
+
+	vs.rollbackVLANs(ctx, aborted)
+
+	// Interfaces that could not be rolled back are still configured
+	results.merge(aborted)
+	if len(aborted.RolledBackVLANs) == 0 {
+		return
+	}
+	if results.RolledBackVLANs == nil {
+		results.RolledBackVLANs = make(map[string][]VLANInterfaceInfo)
+	}
+	for nodeName, interfaces := range aborted.RolledBackVLANs {
+		results.RolledBackVLANs[nodeName] = append(results.RolledBackVLANs[nodeName], interfaces...)
+	}
+}
+
+// rollbackVLANInterface deletes a VLAN interface, or its port when bridge names an OVS bridge, and the persistent configuration files installed with it
+// Unlike removeVLANInterface it fails when the interface is gone, as the run has just created it.
+func (vs *VLANService) rollbackVLANInterface(ctx context.Context, nodeName, vlanInterface, bridge string, persistedFiles []string) error {
+	commands := []string{kubectl.HostCommand("ip", "link", "delete", vlanInterface)}
+	if bridge != "" {
+		commands = []string{ovsDelPortCommand(bridge, vlanInterface, false)}
+	}
+	if len(persistedFiles) > 0 {
+		commands = append(commands, kubectl.HostCommand("rm", append([]string{"-f"}, persistedFiles...)...))
+	}
+
+	cmdSuccess, output, err := vs.kubectl.ExecNodeCommand(ctx, nodeName, kubectl.JoinHostCommands(commands...))
+	if err != nil {
+		return err
+	}
+	if !cmdSuccess {
+		return fmt.Errorf("teardown failed: %s", output)
+	}
+	return nil
+}
+
 // verifyNodeVLANs verifies VLAN configuration for a specific node
 func (vs *VLANService) verifyNodeVLANs(ctx context.Context, nodeName string, cfg *config.NodeVLANConf) ([]VLANInterfaceInfo, error) {
 	var vlans []VLANInterfaceInfo
 
 	for vlanName, vlanConfig := range cfg.Spec.VLANs {
 		if ipAddress, exists := vlanConfig.NodeMapping[nodeName]; exists {
-			physInterface := vlanConfig.Interface
-			if physInterface == "" {
-				physInterface = vs.options.DefaultInterface
-				if physInterface == "" {
-					physInterface = "eth0"
-				}
+			vlanInterface, physInterface, err := vs.vlanInterfaceNames(ctx, nodeName, vlanConfig)
+			if err != nil {
+				return nil, err
 			}
 
-			vlanInterface := fmt.Sprintf("%s.%d", physInterface, vlanConfig.ID)
-
-			// Check if interface exists and has correct IP
-			checkCmd := fmt.Sprintf("ip addr show %s", vlanInterface)
+			// Check if interface exists and has correct IP, listing IPv6 addresses with ip -6 when the node has any
+			// OVS ports print their bridge and tag first, as a port on the wrong bridge or tag carries no traffic.
+			addresses := config.SplitAddresses(ipAddress)
+			checkCmd := kubectl.HostCommand("ip", "addr", "show", vlanInterface)
+			if vlanConfig.OVS != nil {
+				checkCmd = kubectl.JoinHostCommands(ovsCheckCommand(vlanInterface), checkCmd)
+			}
+			for _, address := range addresses {
+				if config.IsIPv6Address(address) {
+					checkCmd = kubectl.JoinHostCommands(checkCmd, kubectl.HostCommand("ip", "-6", "addr", "show", "dev", vlanInterface))
+					break
+				}
+			}
 			success, output, err := vs.kubectl.ExecNodeCommand(ctx, nodeName, checkCmd)
 			if err != nil || !success {
 				vs.options.Logger.Warn(fmt.Sprintf("VLAN interface %s not found on node %s", vlanInterface, nodeName))
 				continue
 			}
+			if vlanConfig.OVS != nil {
+				if mismatch := ovsPortMismatch(output, vlanConfig.OVS); mismatch != "" {
+					vs.options.Logger.Warn(fmt.Sprintf("VLAN %s on node %s has incorrect OVS configuration: %s", vlanName, nodeName, mismatch))
+					continue
+				}
+			}
 
-			// Check for IP address in the inet line (e.g., "    inet 10.100.0.14/24 scope global eth0.100")
+			// Check for every address in its inet or inet6 line (e.g., "    inet 10.100.0.14/24 scope global eth0.100")
 			// The ip addr show command outputs the full CIDR notation in the inet line
-			inetLine := fmt.Sprintf("inet %s", ipAddress)
 			if vs.options.Verbose {
-				vs.options.Logger.Info(fmt.Sprintf("    🔍 Verifying VLAN %s on %s: looking for '%s' in output", vlanName, nodeName, inetLine))
+				for _, address := range addresses {
+					vs.options.Logger.Info(fmt.Sprintf("    🔍 Verifying VLAN %s on %s: looking for '%s %s' in output", vlanName, nodeName, addressFamily(address), address))
+				}
 				vs.options.Logger.Info(fmt.Sprintf("    📄 Output: %s", strings.ReplaceAll(output, "\n", "\\n")))
 			}
-			if strings.Contains(output, inetLine) {
+			missing := missingAddresses(output, ipAddress)
+			if len(addresses) > 0 && len(missing) == 0 {
 				vs.options.Logger.Info(fmt.Sprintf("✅ Verified VLAN %s (%s) on node %s", vlanName, vlanInterface, nodeName))
 
-				vlans = append(vlans, VLANInterfaceInfo{
+				vlanInfo := VLANInterfaceInfo{
 					VLANName:      vlanName,
 					VLANId:        vlanConfig.ID,
 					Interface:     vlanInterface,
 					IPAddress:     ipAddress,
 					PhysInterface: physInterface,
 					Subnet:        vlanConfig.Subnet,
-				})
+				}
+				if vlanConfig.OVS != nil {
+					vlanInfo.Bridge = vlanConfig.OVS.Bridge
+				}
+				vlans = append(vlans, vlanInfo)
 			} else {
-				vs.options.Logger.Warn(fmt.Sprintf("VLAN %s on node %s has incorrect IP configuration", vlanName, nodeName))
+				vs.options.Logger.Warn(fmt.Sprintf("VLAN %s on node %s has incorrect IP configuration: missing %s", vlanName, nodeName, strings.Join(missing, ", ")))
 			}
 		}
 	}
@@ -348,12 +545,9 @@ func (vs *VLANService) verifyNodeVLANs(ctx context.Context, nodeName string, cfg
 	return vlans, nil
 }
 
-// discoverNodeVLANs discovers existing VLAN interfaces on a node
+// discoverNodeVLANs discovers existing VLAN interfaces on a node, with their parent, VLAN ID and addresses
 func (vs *VLANService) discoverNodeVLANs(ctx context.Context, nodeName string) ([]VLANInterfaceInfo, error) {
-	var vlans []VLANInterfaceInfo
-
-	// List all VLAN interfaces
-	cmd := "ip link show type vlan"
+	cmd := kubectl.HostCommand("ip", "-d", "addr", "show", "type", "vlan")
 	success, output, err := vs.kubectl.ExecNodeCommand(ctx, nodeName, cmd)
 	if err != nil {
 		return nil, fmt.Errorf("failed to discover VLAN interfaces: %w", err)
@@ -361,43 +555,39 @@ func (vs *VLANService) discoverNodeVLANs(ctx context.Context, nodeName string) (
 
 	if !success {
 		// No VLAN interfaces found
-		return vlans, nil
-	}
-
-	// Parse output to extract VLAN information
-	// This is a simplified parser - in production, you'd want more robust parsing
-	lines := strings.Split(output, "\n")
-	for _, line := range lines {
-		if strings.Contains(line, "@") && strings.Contains(line, "vlan") {
-			// Extract interface name and VLAN ID
-			// Example: "eth0.100@eth0: <BROADCAST,MULTICAST,UP,LOWER_UP>"
-			parts := strings.Fields(line)
-			if len(parts) > 0 {
-				interfacePart := strings.Split(parts[1], "@")
-				if len(interfacePart) >= 2 {
-					vlanInterface := interfacePart[0]
-					_ = strings.TrimSuffix(interfacePart[1], ":") // physInterface not used in this context
-
-					// Extract VLAN ID from interface name
-					interfaceParts := strings.Split(vlanInterface, ".")
-					if len(interfaceParts) == 2 {
-						// TODO: Parse VLAN ID, get IP address, etc.
-						// For now, just record the interface exists
-						vs.options.Logger.Info(fmt.Sprintf("Discovered VLAN interface: %s", vlanInterface))
-					}
-				}
-			}
-		}
+		return []VLANInterfaceInfo{}, nil
 	}
 
-	return vlans, nil
+	return parseVLANAddresses(output), nil
+}
+
+// generateNetplanConfig renders the netplan file that recreates a VLAN interface at boot with the netplan template
+func (vs *VLANService) generateNetplanConfig(vlanName string, vlanConfig config.VLANConfig, vlanInterface, physInterface, ipAddress string) (string, error) {
+	templates := vs.options.Templates
+	if templates == nil {
+		templates = DefaultTemplates()
+	}
+	return templates.renderNetplan(NetplanData{
+		VLANName:  vlanName,
+		ID:        vlanConfig.ID,
+		Interface: vlanInterface,
+		Link:      physInterface,
+		Addresses: config.SplitAddresses(ipAddress),
+	})
+}
+
+// addressLines renders one line per address
+func addressLines(format string, addresses []string) string {
+	var lines strings.Builder
+	for _, address := range addresses {
+		fmt.Fprintf(&lines, format, address)
+	}
+	return lines.String()
 }
 
-// generateNetplanConfig generates persistent network configuration
-func (vs *VLANService) generateNetplanConfig(vlanName string, vlanConfig config.VLANConfig, vlanInterface, physInterface, ipAddress string) string {
-	// This would generate netplan YAML for Ubuntu/systemd persistence
-	// Simplified for now - in production, you'd generate proper netplan configs
-	return fmt.Sprintf("echo 'VLAN %s configured for persistence' # TODO: Implement netplan generation", vlanName)
+// netplanConfigPath is the netplan file holding the persistent configuration of a VLAN interface
+func netplanConfigPath(vlanInterface string) string {
+	return fmt.Sprintf("/etc/netplan/60-kictl-%s.yaml", vlanInterface)
 }
 
 // getAllNodesFromConfig extracts all unique node names from VLAN configuration
@@ -411,48 +601,28 @@ func (vs *VLANService) getAllNodesFromConfig(cfg *config.NodeVLANConf) map[strin
 	return nodes
 }
 
-// cleanupDebugPods automatically cleans up debug pods after VLAN operations
-func (vs *VLANService) cleanupDebugPods(ctx context.Context) {
-	vs.options.Logger.Info("🧹 Cleaning up debug pods...")
-
-	// Give pods a moment to transition to final status
-	// Use configurable delay - can be set to 0 for tests
-	cleanupDelay := vs.options.CleanupDelay
-	if cleanupDelay == 0 {
-		cleanupDelay = 3 * time.Second // Default for production
-	}
-	time.Sleep(cleanupDelay)
+// Cleanup removes the debug pods left behind by an operation that was interrupted, e.g. by a service timeout
+func (vs *VLANService) Cleanup(ctx context.Context) {
+	vs.cleanupDebugPods(ctx)
+}
 
-	// Step 1: Get ALL pods (no status filtering - match old behavior)
-	success, output, err := vs.kubectl.GetPods(ctx, "", "")
-	if err != nil || !success {
-		vs.options.Logger.Warn(fmt.Sprintf("Failed to get pods: %v", err))
+// cleanupDebugPods deletes the debug pods the executor started for this service's node commands
+// Dry runs start no debug pods, and pods of other runs or users are left alone.
+func (vs *VLANService) cleanupDebugPods(ctx context.Context) {
+	if vs.options.DryRun {
+		vs.options.Logger.Debug("DRY RUN: Skipping debug pod cleanup")
 		return
 	}
 
-	// Step 2: Filter ONLY by our specific name pattern (like old grep did)
-	podNames := strings.Split(output, "\n")
-	var debugPods []string
-	for _, podName := range podNames {
-		if strings.Contains(podName, "node-debugger") {
-			debugPods = append(debugPods, strings.TrimPrefix(podName, "pod/"))
-		}
-	}
-
-	// Step 3: Delete each debug pod (using generic building block!)
-	deletedCount := 0
-	for _, podName := range debugPods {
-		success, _, err := vs.kubectl.DeletePod(ctx, podName)
-		if err != nil {
-			vs.options.Logger.Warn(fmt.Sprintf("Failed to delete pod %s: %v", podName, err))
-		} else if success {
-			deletedCount++
-		}
+	vs.options.Logger.Info("🧹 Cleaning up debug pods...")
+	deletedCount, err := vs.kubectl.ReleaseDebugPods(ctx)
+	if err != nil {
+		vs.options.Logger.Warn(fmt.Sprintf("Failed to delete debug pods: %v", err))
 	}
 
 	if deletedCount > 0 {
 		vs.options.Logger.Info(fmt.Sprintf("✅ Cleaned up %d debug pods", deletedCount))
-	} else {
+	} else if err == nil {
 		vs.options.Logger.Info("✅ No debug pods to clean up")
 	}
 }
@@ -3,19 +3,25 @@ package vlan
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"testing"
 
 	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/logging"
+	"k8ostack-ictl/internal/progress"
+	"k8ostack-ictl/internal/throttle"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // TestNewService tests the creation of a new VLAN service
 func TestNewService(t *testing.T) {
 	mockKubectl := NewMockDryRunExecutor()
-	mockLogger := NewMockLogger()
+	mockLogger := logging.NewMockLogger()
 
 	options := Options{
 		DryRun:               true,
@@ -24,7 +30,6 @@ func TestNewService(t *testing.T) {
 		PersistentConfig:     false,
 		DefaultInterface:     "eth0",
 		Logger:               mockLogger,
-		CleanupDelay:         0, // Skip sleep in tests
 	}
 
 	service := NewService(mockKubectl, options)
@@ -45,7 +50,7 @@ func TestVLANService_ConfigureVLANs(t *testing.T) {
 		description          string
 		vlanConfig           *config.NodeVLANConf
 		options              Options
-		mockSetupFunc        func(*MockDryRunExecutor, *MockLogger)
+		mockSetupFunc        func(*MockDryRunExecutor, *logging.MockLogger)
 		expectedTotalNodes   int
 		expectedSuccessNodes int
 		expectedFailedNodes  []string
@@ -80,12 +85,12 @@ func TestVLANService_ConfigureVLANs(t *testing.T) {
 				DefaultInterface:     "eth0",
 				Logger:               nil, // Will be set in test
 			},
-			mockSetupFunc: func(mockKubectl *MockDryRunExecutor, mockLogger *MockLogger) {
+			mockSetupFunc: func(mockKubectl *MockDryRunExecutor, mockLogger *logging.MockLogger) {
 				mockKubectl.On("SetDryRun", false).Return()
 				mockKubectl.On("GetNode", mock.Anything, "node1").Return(true, "node/node1", nil)
 				mockKubectl.On("ExecNodeCommand", mock.Anything, "node1", mock.AnythingOfType("string")).
-					Return(true, "VLAN configured", nil)
-				mockKubectl.On("GetPods", mock.Anything, "", "").Return(true, "", nil)
+					Return(true, addrOutput("eth0.100=192.168.100.10/24"), nil)
+				mockKubectl.On("ReleaseDebugPods", mock.Anything).Return(0, nil)
 				mockLogger.On("Info", mock.AnythingOfType("string")).Return().Maybe()
 				mockLogger.On("Debug", mock.AnythingOfType("string")).Return().Maybe()
 				mockLogger.On("Warn", mock.AnythingOfType("string")).Return().Maybe()
@@ -144,15 +149,15 @@ func TestVLANService_ConfigureVLANs(t *testing.T) {
 				DefaultInterface:     "eth0",
 				Logger:               nil,
 			},
-			mockSetupFunc: func(mockKubectl *MockDryRunExecutor, mockLogger *MockLogger) {
+			mockSetupFunc: func(mockKubectl *MockDryRunExecutor, mockLogger *logging.MockLogger) {
 				mockKubectl.On("SetDryRun", false).Return()
 				// Node existence checks
 				mockKubectl.On("GetNode", mock.Anything, "node1").Return(true, "node/node1", nil)
 				mockKubectl.On("GetNode", mock.Anything, "node2").Return(true, "node/node2", nil)
 				// VLAN configuration commands
 				mockKubectl.On("ExecNodeCommand", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("string")).
-					Return(true, "VLAN configured", nil)
-				mockKubectl.On("GetPods", mock.Anything, "", "").Return(true, "", nil)
+					Return(true, addrOutput("eth0.100=192.168.100.10/24", "eth0.100=192.168.100.11/24", "eth1.200=10.10.200.10/24", "eth1.200=10.10.200.11/24"), nil)
+				mockKubectl.On("ReleaseDebugPods", mock.Anything).Return(0, nil)
 				mockLogger.On("Info", mock.AnythingOfType("string")).Return().Maybe()
 				mockLogger.On("Debug", mock.AnythingOfType("string")).Return().Maybe()
 				mockLogger.On("Warn", mock.AnythingOfType("string")).Return().Maybe()
@@ -197,14 +202,14 @@ func TestVLANService_ConfigureVLANs(t *testing.T) {
 				DefaultInterface:     "eth0",
 				Logger:               nil,
 			},
-			mockSetupFunc: func(mockKubectl *MockDryRunExecutor, mockLogger *MockLogger) {
+			mockSetupFunc: func(mockKubectl *MockDryRunExecutor, mockLogger *logging.MockLogger) {
 				mockKubectl.On("SetDryRun", false).Return()
 				mockKubectl.On("GetNode", mock.Anything, "node1").Return(true, "node/node1", nil)
 				// Should include netplan configuration in the command
 				mockKubectl.On("ExecNodeCommand", mock.Anything, "node1", mock.MatchedBy(func(cmd string) bool {
 					return len(cmd) > 200 // Persistent config commands are longer
-				})).Return(true, "VLAN configured with persistence", nil)
-				mockKubectl.On("GetPods", mock.Anything, "", "").Return(true, "", nil)
+				})).Return(true, addrOutput("eth0.100=192.168.100.10/24"), nil)
+				mockKubectl.On("ReleaseDebugPods", mock.Anything).Return(0, nil)
 				mockLogger.On("Info", mock.AnythingOfType("string")).Return().Maybe()
 				mockLogger.On("Debug", mock.AnythingOfType("string")).Return().Maybe()
 				mockLogger.On("Warn", mock.AnythingOfType("string")).Return().Maybe()
@@ -246,12 +251,12 @@ func TestVLANService_ConfigureVLANs(t *testing.T) {
 				DefaultInterface:     "eth0",
 				Logger:               nil,
 			},
-			mockSetupFunc: func(mockKubectl *MockDryRunExecutor, mockLogger *MockLogger) {
+			mockSetupFunc: func(mockKubectl *MockDryRunExecutor, mockLogger *logging.MockLogger) {
 				mockKubectl.On("SetDryRun", false).Return()
 				mockKubectl.On("GetNode", mock.Anything, "node1").Return(true, "node/node1", nil)
 				mockKubectl.On("ExecNodeCommand", mock.Anything, "node1", mock.AnythingOfType("string")).
-					Return(true, "VLAN configured", nil)
-				mockKubectl.On("GetPods", mock.Anything, "", "").Return(true, "", nil)
+					Return(true, addrOutput("eth0.100=192.168.100.10/24"), nil)
+				mockKubectl.On("ReleaseDebugPods", mock.Anything).Return(0, nil)
 				// Verbose mode should trigger additional Info calls
 				mockLogger.On("Info", mock.AnythingOfType("string")).Return().Maybe()
 				mockLogger.On("Debug", mock.AnythingOfType("string")).Return().Maybe()
@@ -293,13 +298,13 @@ func TestVLANService_ConfigureVLANs(t *testing.T) {
 				DefaultInterface:     "ens192", // Custom default interface
 				Logger:               nil,
 			},
-			mockSetupFunc: func(mockKubectl *MockDryRunExecutor, mockLogger *MockLogger) {
+			mockSetupFunc: func(mockKubectl *MockDryRunExecutor, mockLogger *logging.MockLogger) {
 				mockKubectl.On("SetDryRun", false).Return()
 				mockKubectl.On("GetNode", mock.Anything, "node1").Return(true, "node/node1", nil)
 				// Command should use ens192.100 as interface
 				mockKubectl.On("ExecNodeCommand", mock.Anything, "node1", mock.AnythingOfType("string")).
-					Return(true, "VLAN configured", nil)
-				mockKubectl.On("GetPods", mock.Anything, "", "").Return(true, "", nil)
+					Return(true, addrOutput("ens192.100=192.168.100.10/24"), nil)
+				mockKubectl.On("ReleaseDebugPods", mock.Anything).Return(0, nil)
 				mockLogger.On("Info", mock.AnythingOfType("string")).Return().Maybe()
 				mockLogger.On("Debug", mock.AnythingOfType("string")).Return().Maybe()
 				mockLogger.On("Warn", mock.AnythingOfType("string")).Return().Maybe()
@@ -343,12 +348,12 @@ func TestVLANService_ConfigureVLANs(t *testing.T) {
 				// DefaultInterface not specified - should fall back to eth0
 				Logger: nil,
 			},
-			mockSetupFunc: func(mockKubectl *MockDryRunExecutor, mockLogger *MockLogger) {
+			mockSetupFunc: func(mockKubectl *MockDryRunExecutor, mockLogger *logging.MockLogger) {
 				mockKubectl.On("SetDryRun", false).Return()
 				mockKubectl.On("GetNode", mock.Anything, "node1").Return(true, "node/node1", nil)
 				mockKubectl.On("ExecNodeCommand", mock.Anything, "node1", mock.AnythingOfType("string")).
-					Return(true, "VLAN configured", nil)
-				mockKubectl.On("GetPods", mock.Anything, "", "").Return(true, "", nil)
+					Return(true, addrOutput("eth0.100=192.168.100.10/24"), nil)
+				mockKubectl.On("ReleaseDebugPods", mock.Anything).Return(0, nil)
 				mockLogger.On("Info", mock.AnythingOfType("string")).Return().Maybe()
 				mockLogger.On("Debug", mock.AnythingOfType("string")).Return().Maybe()
 				mockLogger.On("Warn", mock.AnythingOfType("string")).Return().Maybe()
@@ -392,10 +397,11 @@ func TestVLANService_ConfigureVLANs(t *testing.T) {
 				DefaultInterface:     "eth0",
 				Logger:               nil,
 			},
-			mockSetupFunc: func(mockKubectl *MockDryRunExecutor, mockLogger *MockLogger) {
+			mockSetupFunc: func(mockKubectl *MockDryRunExecutor, mockLogger *logging.MockLogger) {
 				mockKubectl.On("SetDryRun", false).Return()
 				mockKubectl.On("GetNode", mock.Anything, "nonexistent-node").Return(false, "", nil)
-				mockKubectl.On("GetPods", mock.Anything, "", "").Return(true, "", nil)
+				mockKubectl.On("GetAllNodes", mock.Anything).Return(true, "node/node1", nil)
+				mockKubectl.On("ReleaseDebugPods", mock.Anything).Return(0, nil)
 				mockLogger.On("Info", mock.AnythingOfType("string")).Return().Maybe()
 				mockLogger.On("Debug", mock.AnythingOfType("string")).Return().Maybe()
 				mockLogger.On("Warn", mock.AnythingOfType("string")).Return().Maybe()
@@ -439,10 +445,10 @@ func TestVLANService_ConfigureVLANs(t *testing.T) {
 				DefaultInterface:     "eth0",
 				Logger:               nil,
 			},
-			mockSetupFunc: func(mockKubectl *MockDryRunExecutor, mockLogger *MockLogger) {
+			mockSetupFunc: func(mockKubectl *MockDryRunExecutor, mockLogger *logging.MockLogger) {
 				mockKubectl.On("SetDryRun", false).Return()
 				mockKubectl.On("GetNode", mock.Anything, mock.AnythingOfType("string")).Return(true, "node/found", nil)
-				mockKubectl.On("GetPods", mock.Anything, "", "").Return(true, "", nil)
+				mockKubectl.On("ReleaseDebugPods", mock.Anything).Return(0, nil)
 				mockLogger.On("Info", mock.AnythingOfType("string")).Return().Maybe()
 				mockLogger.On("Debug", mock.AnythingOfType("string")).Return().Maybe()
 				mockLogger.On("Warn", mock.AnythingOfType("string")).Return().Maybe()
@@ -485,12 +491,12 @@ func TestVLANService_ConfigureVLANs(t *testing.T) {
 				DefaultInterface:     "eth0",
 				Logger:               nil,
 			},
-			mockSetupFunc: func(mockKubectl *MockDryRunExecutor, mockLogger *MockLogger) {
+			mockSetupFunc: func(mockKubectl *MockDryRunExecutor, mockLogger *logging.MockLogger) {
 				mockKubectl.On("SetDryRun", false).Return()
 				mockKubectl.On("GetNode", mock.Anything, "node1").Return(true, "node/node1", nil)
 				mockKubectl.On("ExecNodeCommand", mock.Anything, "node1", mock.AnythingOfType("string")).
 					Return(false, "Failed to execute command", fmt.Errorf("command execution failed"))
-				mockKubectl.On("GetPods", mock.Anything, "", "").Return(true, "", nil)
+				mockKubectl.On("ReleaseDebugPods", mock.Anything).Return(0, nil)
 				mockLogger.On("Info", mock.AnythingOfType("string")).Return().Maybe()
 				mockLogger.On("Debug", mock.AnythingOfType("string")).Return().Maybe()
 				mockLogger.On("Warn", mock.AnythingOfType("string")).Return().Maybe()
@@ -539,12 +545,12 @@ func TestVLANService_ConfigureVLANs(t *testing.T) {
 				DefaultInterface:     "eth0",
 				Logger:               nil,
 			},
-			mockSetupFunc: func(mockKubectl *MockDryRunExecutor, mockLogger *MockLogger) {
+			mockSetupFunc: func(mockKubectl *MockDryRunExecutor, mockLogger *logging.MockLogger) {
 				mockKubectl.On("SetDryRun", false).Return()
 				mockKubectl.On("GetNode", mock.Anything, "node1").Return(true, "node/node1", nil)
 				mockKubectl.On("ExecNodeCommand", mock.Anything, "node1", mock.AnythingOfType("string")).
-					Return(true, "VLAN configured", nil)
-				mockKubectl.On("GetPods", mock.Anything, "", "").Return(true, "", nil)
+					Return(true, addrOutput("eth1.200=10.10.200.10/24"), nil)
+				mockKubectl.On("ReleaseDebugPods", mock.Anything).Return(0, nil)
 				mockLogger.On("Info", mock.AnythingOfType("string")).Return().Maybe()
 				mockLogger.On("Debug", mock.AnythingOfType("string")).Return().Maybe()
 				mockLogger.On("Warn", mock.AnythingOfType("string")).Return().Maybe()
@@ -587,12 +593,12 @@ func TestVLANService_ConfigureVLANs(t *testing.T) {
 				DefaultInterface:     "eth0",
 				Logger:               nil,
 			},
-			mockSetupFunc: func(mockKubectl *MockDryRunExecutor, mockLogger *MockLogger) {
+			mockSetupFunc: func(mockKubectl *MockDryRunExecutor, mockLogger *logging.MockLogger) {
 				mockKubectl.On("SetDryRun", false).Return()
 				// GetNode should NOT be called when validation is disabled
 				mockKubectl.On("ExecNodeCommand", mock.Anything, "node1", mock.AnythingOfType("string")).
-					Return(true, "VLAN configured", nil)
-				mockKubectl.On("GetPods", mock.Anything, "", "").Return(true, "", nil)
+					Return(true, addrOutput("eth0.100=192.168.100.10/24"), nil)
+				mockKubectl.On("ReleaseDebugPods", mock.Anything).Return(0, nil)
 				mockLogger.On("Info", mock.AnythingOfType("string")).Return().Maybe()
 				mockLogger.On("Debug", mock.AnythingOfType("string")).Return().Maybe()
 				mockLogger.On("Warn", mock.AnythingOfType("string")).Return().Maybe()
@@ -624,9 +630,9 @@ func TestVLANService_ConfigureVLANs(t *testing.T) {
 				DefaultInterface:     "eth0",
 				Logger:               nil,
 			},
-			mockSetupFunc: func(mockKubectl *MockDryRunExecutor, mockLogger *MockLogger) {
+			mockSetupFunc: func(mockKubectl *MockDryRunExecutor, mockLogger *logging.MockLogger) {
 				mockKubectl.On("SetDryRun", false).Return()
-				mockKubectl.On("GetPods", mock.Anything, "", "").Return(true, "", nil)
+				mockKubectl.On("ReleaseDebugPods", mock.Anything).Return(0, nil)
 				mockLogger.On("Info", mock.AnythingOfType("string")).Return().Maybe()
 				mockLogger.On("Debug", mock.AnythingOfType("string")).Return().Maybe()
 				mockLogger.On("Warn", mock.AnythingOfType("string")).Return().Maybe()
@@ -645,14 +651,12 @@ func TestVLANService_ConfigureVLANs(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			// Given: Setup mocks and service
 			mockKubectl := NewMockDryRunExecutor()
-			mockLogger := NewMockLogger()
+			mockLogger := logging.NewMockLogger()
 
 			tt.mockSetupFunc(mockKubectl, mockLogger)
 
 			// Set logger in options
 			tt.options.Logger = mockLogger
-			// Speed up tests by skipping cleanup sleep
-			tt.options.CleanupDelay = 0
 
 			service := NewService(mockKubectl, tt.options)
 
@@ -698,7 +702,7 @@ func TestVLANService_RemoveVLANs(t *testing.T) {
 		description string
 		vlanConfig  *config.NodeVLANConf
 		options     Options
-		setupMocks  func(*MockDryRunExecutor, *MockLogger)
+		setupMocks  func(*MockDryRunExecutor, *logging.MockLogger)
 		expectError bool
 	}{
 		{
@@ -728,13 +732,13 @@ func TestVLANService_RemoveVLANs(t *testing.T) {
 				ValidateConnectivity: true,
 				DefaultInterface:     "eth0",
 			},
-			setupMocks: func(mockKubectl *MockDryRunExecutor, mockLogger *MockLogger) {
+			setupMocks: func(mockKubectl *MockDryRunExecutor, mockLogger *logging.MockLogger) {
 				mockKubectl.On("SetDryRun", false).Return()
 				mockKubectl.On("GetNode", mock.Anything, "node1").Return(true, "node/node1", nil)
 				mockKubectl.On("ExecNodeCommand", mock.Anything, "node1", mock.MatchedBy(func(cmd string) bool {
 					return len(cmd) > 30 // Removal commands include "|| true" suffix
 				})).Return(true, "VLAN interface removed", nil)
-				mockKubectl.On("GetPods", mock.Anything, "", "").Return(true, "", nil)
+				mockKubectl.On("ReleaseDebugPods", mock.Anything).Return(0, nil)
 				mockLogger.On("Info", mock.AnythingOfType("string")).Return().Maybe()
 				mockLogger.On("Debug", mock.AnythingOfType("string")).Return().Maybe()
 				mockLogger.On("Warn", mock.AnythingOfType("string")).Return().Maybe()
@@ -768,13 +772,13 @@ func TestVLANService_RemoveVLANs(t *testing.T) {
 				ValidateConnectivity: true,
 				DefaultInterface:     "eth0",
 			},
-			setupMocks: func(mockKubectl *MockDryRunExecutor, mockLogger *MockLogger) {
+			setupMocks: func(mockKubectl *MockDryRunExecutor, mockLogger *logging.MockLogger) {
 				mockKubectl.On("SetDryRun", false).Return()
 				mockKubectl.On("GetNode", mock.Anything, "node1").Return(true, "node/node1", nil)
 				// Return false but we're lenient for removal
 				mockKubectl.On("ExecNodeCommand", mock.Anything, "node1", mock.AnythingOfType("string")).
 					Return(false, "Interface not found", nil)
-				mockKubectl.On("GetPods", mock.Anything, "", "").Return(true, "", nil)
+				mockKubectl.On("ReleaseDebugPods", mock.Anything).Return(0, nil)
 				mockLogger.On("Info", mock.AnythingOfType("string")).Return().Maybe()
 				mockLogger.On("Debug", mock.AnythingOfType("string")).Return().Maybe()
 				mockLogger.On("Warn", mock.AnythingOfType("string")).Return().Maybe()
@@ -786,12 +790,10 @@ func TestVLANService_RemoveVLANs(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockKubectl := NewMockDryRunExecutor()
-			mockLogger := NewMockLogger()
+			mockLogger := logging.NewMockLogger()
 			tt.setupMocks(mockKubectl, mockLogger)
 
 			tt.options.Logger = mockLogger
-			// Speed up tests by skipping cleanup sleep
-			tt.options.CleanupDelay = 0
 			service := NewService(mockKubectl, tt.options)
 
 			result, err := service.RemoveVLANs(context.Background(), tt.vlanConfig)
@@ -815,7 +817,7 @@ func TestVLANService_VerifyVLANs(t *testing.T) {
 		description string
 		vlanConfig  *config.NodeVLANConf
 		options     Options
-		setupMocks  func(*MockDryRunExecutor, *MockLogger)
+		setupMocks  func(*MockDryRunExecutor, *logging.MockLogger)
 		expectError bool
 		validateFn  func(*testing.T, *OperationResults)
 	}{
@@ -846,13 +848,12 @@ func TestVLANService_VerifyVLANs(t *testing.T) {
 				ValidateConnectivity: true,
 				DefaultInterface:     "eth0",
 			},
-			setupMocks: func(mockKubectl *MockDryRunExecutor, mockLogger *MockLogger) {
+			setupMocks: func(mockKubectl *MockDryRunExecutor, mockLogger *logging.MockLogger) {
 				mockKubectl.On("SetDryRun", true).Return()
 				mockKubectl.On("GetNode", mock.Anything, "node1").Return(true, "node/node1", nil)
 				// Return output that contains the expected IP
 				mockKubectl.On("ExecNodeCommand", mock.Anything, "node1", "ip addr show eth0.100").
 					Return(true, "eth0.100: interface exists\n    inet 192.168.100.10/24 brd", nil)
-				mockKubectl.On("GetPods", mock.Anything, "", "").Return(true, "", nil)
 				mockLogger.On("Info", mock.AnythingOfType("string")).Return().Maybe()
 				mockLogger.On("Debug", mock.AnythingOfType("string")).Return().Maybe()
 				mockLogger.On("Warn", mock.AnythingOfType("string")).Return().Maybe()
@@ -891,13 +892,12 @@ func TestVLANService_VerifyVLANs(t *testing.T) {
 				ValidateConnectivity: true,
 				DefaultInterface:     "eth0",
 			},
-			setupMocks: func(mockKubectl *MockDryRunExecutor, mockLogger *MockLogger) {
+			setupMocks: func(mockKubectl *MockDryRunExecutor, mockLogger *logging.MockLogger) {
 				mockKubectl.On("SetDryRun", true).Return()
 				mockKubectl.On("GetNode", mock.Anything, "node1").Return(true, "node/node1", nil)
 				// Interface not found
 				mockKubectl.On("ExecNodeCommand", mock.Anything, "node1", "ip addr show eth0.100").
 					Return(false, "Device not found", fmt.Errorf("interface not found"))
-				mockKubectl.On("GetPods", mock.Anything, "", "").Return(true, "", nil)
 				mockLogger.On("Info", mock.AnythingOfType("string")).Return().Maybe()
 				mockLogger.On("Debug", mock.AnythingOfType("string")).Return().Maybe()
 				mockLogger.On("Warn", mock.AnythingOfType("string")).Return().Maybe()
@@ -936,13 +936,12 @@ func TestVLANService_VerifyVLANs(t *testing.T) {
 				ValidateConnectivity: true,
 				DefaultInterface:     "eth0",
 			},
-			setupMocks: func(mockKubectl *MockDryRunExecutor, mockLogger *MockLogger) {
+			setupMocks: func(mockKubectl *MockDryRunExecutor, mockLogger *logging.MockLogger) {
 				mockKubectl.On("SetDryRun", true).Return()
 				mockKubectl.On("GetNode", mock.Anything, "node1").Return(true, "node/node1", nil)
 				// Return output with wrong IP
 				mockKubectl.On("ExecNodeCommand", mock.Anything, "node1", "ip addr show eth0.100").
 					Return(true, "eth0.100: interface exists\n    inet 192.168.100.99/24 brd", nil)
-				mockKubectl.On("GetPods", mock.Anything, "", "").Return(true, "", nil)
 				mockLogger.On("Info", mock.AnythingOfType("string")).Return().Maybe()
 				mockLogger.On("Debug", mock.AnythingOfType("string")).Return().Maybe()
 				mockLogger.On("Warn", mock.AnythingOfType("string")).Return().Maybe()
@@ -954,17 +953,144 @@ func TestVLANService_VerifyVLANs(t *testing.T) {
 				assert.Len(t, results.ConfiguredVLANs["node1"], 0) // IP mismatch, no VLAN recorded
 			},
 		},
+		{
+			name:        "dual_stack_verification",
+			description: "Verifies both addresses of a dual-stack node",
+			vlanConfig: &config.NodeVLANConf{
+				APIVersion: "openstack.kictl.icycloud.io/v1",
+				Kind:       "NodeVLANConf",
+				Metadata: config.Metadata{
+					Name: "verify-dual-stack-test",
+				},
+				Spec: config.NodeVLANSpec{
+					VLANs: map[string]config.VLANConfig{
+						"management": {
+							ID:        100,
+							Subnet:    "192.168.100.0/24",
+							Interface: "eth0",
+							NodeMapping: map[string]string{
+								"node1": "192.168.100.10/24,fd00:100::10/64",
+							},
+						},
+					},
+				},
+			},
+			options: Options{
+				DryRun:               true,
+				ValidateConnectivity: true,
+				DefaultInterface:     "eth0",
+			},
+			setupMocks: func(mockKubectl *MockDryRunExecutor, mockLogger *logging.MockLogger) {
+				mockKubectl.On("SetDryRun", true).Return()
+				mockKubectl.On("GetNode", mock.Anything, "node1").Return(true, "node/node1", nil)
+				// IPv6 addresses are listed with ip -6
+				mockKubectl.On("ExecNodeCommand", mock.Anything, "node1", "ip addr show eth0.100 && ip -6 addr show dev eth0.100").
+					Return(true, "eth0.100: interface exists\n    inet 192.168.100.10/24 brd\n    inet6 fd00:100::10/64 scope global", nil)
+				mockLogger.On("Info", mock.AnythingOfType("string")).Return().Maybe()
+				mockLogger.On("Debug", mock.AnythingOfType("string")).Return().Maybe()
+				mockLogger.On("Warn", mock.AnythingOfType("string")).Return().Maybe()
+			},
+			expectError: false,
+			validateFn: func(t *testing.T, results *OperationResults) {
+				assert.Equal(t, 1, results.SuccessfulNodes)
+				assert.Len(t, results.ConfiguredVLANs["node1"], 1)
+			},
+		},
+		{
+			name:        "dual_stack_missing_ipv6",
+			description: "Handles a dual-stack node whose IPv6 address is missing",
+			vlanConfig: &config.NodeVLANConf{
+				APIVersion: "openstack.kictl.icycloud.io/v1",
+				Kind:       "NodeVLANConf",
+				Metadata: config.Metadata{
+					Name: "verify-dual-stack-test",
+				},
+				Spec: config.NodeVLANSpec{
+					VLANs: map[string]config.VLANConfig{
+						"management": {
+							ID:        100,
+							Subnet:    "192.168.100.0/24",
+							Interface: "eth0",
+							NodeMapping: map[string]string{
+								"node1": "192.168.100.10/24,fd00:100::10/64",
+							},
+						},
+					},
+				},
+			},
+			options: Options{
+				DryRun:               true,
+				ValidateConnectivity: true,
+				DefaultInterface:     "eth0",
+			},
+			setupMocks: func(mockKubectl *MockDryRunExecutor, mockLogger *logging.MockLogger) {
+				mockKubectl.On("SetDryRun", true).Return()
+				mockKubectl.On("GetNode", mock.Anything, "node1").Return(true, "node/node1", nil)
+				// IPv6 addresses are listed with ip -6
+				mockKubectl.On("ExecNodeCommand", mock.Anything, "node1", "ip addr show eth0.100 && ip -6 addr show dev eth0.100").
+					Return(true, "eth0.100: interface exists\n    inet 192.168.100.10/24 brd\n    inet6 fe80::1/64 scope link", nil)
+				mockLogger.On("Info", mock.AnythingOfType("string")).Return().Maybe()
+				mockLogger.On("Debug", mock.AnythingOfType("string")).Return().Maybe()
+				mockLogger.On("Warn", mock.AnythingOfType("string")).Return().Maybe()
+			},
+			expectError: false,
+			validateFn: func(t *testing.T, results *OperationResults) {
+				assert.Equal(t, 1, results.SuccessfulNodes)
+				assert.Len(t, results.ConfiguredVLANs["node1"], 0) // IPv6 address missing, no VLAN recorded
+			},
+		},
+		{
+			name:        "node_ip_and_vip_missing_vip",
+			description: "Names the service VIP a node lacks next to its own address",
+			vlanConfig: &config.NodeVLANConf{
+				APIVersion: "openstack.kictl.icycloud.io/v1",
+				Kind:       "NodeVLANConf",
+				Metadata: config.Metadata{
+					Name: "verify-vip-test",
+				},
+				Spec: config.NodeVLANSpec{
+					VLANs: map[string]config.VLANConfig{
+						"management": {
+							ID:        100,
+							Subnet:    "192.168.100.0/24",
+							Interface: "eth0",
+							NodeMapping: map[string]string{
+								"node1": "192.168.100.10/24,192.168.100.100/32",
+							},
+						},
+					},
+				},
+			},
+			options: Options{
+				DryRun:               true,
+				ValidateConnectivity: true,
+				DefaultInterface:     "eth0",
+			},
+			setupMocks: func(mockKubectl *MockDryRunExecutor, mockLogger *logging.MockLogger) {
+				mockKubectl.On("SetDryRun", true).Return()
+				mockKubectl.On("GetNode", mock.Anything, "node1").Return(true, "node/node1", nil)
+				// keepalived left a /24 address on the interface, which is not the /32 VIP of the mapping
+				mockKubectl.On("ExecNodeCommand", mock.Anything, "node1", "ip addr show eth0.100").
+					Return(true, "eth0.100: interface exists\n    inet 192.168.100.10/24 brd 192.168.100.255 scope global eth0.100\n    inet 192.168.100.100/24 scope global secondary eth0.100", nil)
+				mockLogger.On("Info", mock.AnythingOfType("string")).Return().Maybe()
+				mockLogger.On("Debug", mock.AnythingOfType("string")).Return().Maybe()
+				mockLogger.On("Warn", "VLAN management on node node1 has incorrect IP configuration: missing 192.168.100.100/32").Return().Once()
+			},
+			expectError: false,
+			validateFn: func(t *testing.T, results *OperationResults) {
+				assert.Equal(t, 1, results.SuccessfulNodes)
+				assert.Len(t, results.ConfiguredVLANs["node1"], 0) // VIP missing, no VLAN recorded
+			},
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockKubectl := NewMockDryRunExecutor()
-			mockLogger := NewMockLogger()
+			mockLogger := logging.NewMockLogger()
 			tt.setupMocks(mockKubectl, mockLogger)
 
 			tt.options.Logger = mockLogger
-			// Speed up tests by skipping cleanup sleep
-			tt.options.CleanupDelay = 0
 			service := NewService(mockKubectl, tt.options)
 
 			result, err := service.VerifyVLANs(context.Background(), tt.vlanConfig)
@@ -990,7 +1116,7 @@ func TestVLANService_GetCurrentState(t *testing.T) {
 		name        string
 		description string
 		nodes       []string
-		setupMocks  func(*MockDryRunExecutor, *MockLogger)
+		setupMocks  func(*MockDryRunExecutor, *logging.MockLogger)
 		expectError bool
 		validateFn  func(*testing.T, map[string][]VLANInterfaceInfo)
 	}{
@@ -998,27 +1124,31 @@ func TestVLANService_GetCurrentState(t *testing.T) {
 			name:        "successful_state_discovery",
 			description: "Successfully discovers VLAN state on multiple nodes",
 			nodes:       []string{"node1", "node2"},
-			setupMocks: func(mockKubectl *MockDryRunExecutor, mockLogger *MockLogger) {
+			setupMocks: func(mockKubectl *MockDryRunExecutor, mockLogger *logging.MockLogger) {
 				// Mock discovery for node1
-				mockKubectl.On("ExecNodeCommand", mock.Anything, "node1", "ip link show type vlan").
-					Return(true, "3: eth0.100@eth0: <BROADCAST,MULTICAST,UP,LOWER_UP>\n4: eth1.200@eth1: <BROADCAST,MULTICAST,UP,LOWER_UP>", nil)
+				mockKubectl.On("ExecNodeCommand", mock.Anything, "node1", "ip -d addr show type vlan").
+					Return(true, ipAddrShowVLAN, nil)
 				// Mock discovery for node2
-				mockKubectl.On("ExecNodeCommand", mock.Anything, "node2", "ip link show type vlan").
-					Return(true, "3: eth0.300@eth0: <BROADCAST,MULTICAST,UP,LOWER_UP>", nil)
+				mockKubectl.On("ExecNodeCommand", mock.Anything, "node2", "ip -d addr show type vlan").
+					Return(true, "", nil)
 			},
 			expectError: false,
 			validateFn: func(t *testing.T, state map[string][]VLANInterfaceInfo) {
 				assert.Len(t, state, 2)
-				assert.Contains(t, state, "node1")
+				assert.Equal(t, []VLANInterfaceInfo{
+					{VLANId: 100, Interface: "eth0.100", IPAddress: "10.100.0.12/24", PhysInterface: "eth0"},
+					{VLANId: 200, Interface: "eth0.200", PhysInterface: "eth0"},
+				}, state["node1"])
 				assert.Contains(t, state, "node2")
+				assert.Empty(t, state["node2"])
 			},
 		},
 		{
 			name:        "discovery_failure",
 			description: "Handles failure during VLAN discovery",
 			nodes:       []string{"failing-node"},
-			setupMocks: func(mockKubectl *MockDryRunExecutor, mockLogger *MockLogger) {
-				mockKubectl.On("ExecNodeCommand", mock.Anything, "failing-node", "ip link show type vlan").
+			setupMocks: func(mockKubectl *MockDryRunExecutor, mockLogger *logging.MockLogger) {
+				mockKubectl.On("ExecNodeCommand", mock.Anything, "failing-node", "ip -d addr show type vlan").
 					Return(false, "", fmt.Errorf("command failed"))
 			},
 			expectError: true,
@@ -1028,8 +1158,8 @@ func TestVLANService_GetCurrentState(t *testing.T) {
 			name:        "no_vlans_found",
 			description: "Handles nodes with no VLAN interfaces",
 			nodes:       []string{"node-no-vlans"},
-			setupMocks: func(mockKubectl *MockDryRunExecutor, mockLogger *MockLogger) {
-				mockKubectl.On("ExecNodeCommand", mock.Anything, "node-no-vlans", "ip link show type vlan").
+			setupMocks: func(mockKubectl *MockDryRunExecutor, mockLogger *logging.MockLogger) {
+				mockKubectl.On("ExecNodeCommand", mock.Anything, "node-no-vlans", "ip -d addr show type vlan").
 					Return(false, "", nil) // No VLANs found
 			},
 			expectError: false,
@@ -1044,7 +1174,7 @@ func TestVLANService_GetCurrentState(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockKubectl := NewMockDryRunExecutor()
-			mockLogger := NewMockLogger()
+			mockLogger := logging.NewMockLogger()
 			tt.setupMocks(mockKubectl, mockLogger)
 
 			service := NewService(mockKubectl, Options{Logger: mockLogger})
@@ -1091,7 +1221,7 @@ func TestVLANService_HelperMethods(t *testing.T) {
 		}
 
 		mockKubectl := NewMockDryRunExecutor()
-		mockLogger := NewMockLogger()
+		mockLogger := logging.NewMockLogger()
 		service := NewService(mockKubectl, Options{Logger: mockLogger})
 
 		// Access internal method via type assertion
@@ -1116,7 +1246,7 @@ func TestVLANService_HelperMethods(t *testing.T) {
 		}
 
 		mockKubectl := NewMockDryRunExecutor()
-		mockLogger := NewMockLogger()
+		mockLogger := logging.NewMockLogger()
 		service := NewService(mockKubectl, Options{Logger: mockLogger})
 		vlanService := service.(*VLANService)
 
@@ -1130,7 +1260,7 @@ func TestVLANService_HelperMethods(t *testing.T) {
 	t.Run("generateNetplanConfig", func(t *testing.T) {
 		// Given: VLAN service and config
 		mockKubectl := NewMockDryRunExecutor()
-		mockLogger := NewMockLogger()
+		mockLogger := logging.NewMockLogger()
 		service := NewService(mockKubectl, Options{Logger: mockLogger})
 		vlanService := service.(*VLANService)
 
@@ -1141,12 +1271,20 @@ func TestVLANService_HelperMethods(t *testing.T) {
 		}
 
 		// When: Generate netplan config
-		netplanCmd := vlanService.generateNetplanConfig("management", vlanConfig, "eth0.100", "eth0", "192.168.100.10/24")
-
-		// Then: Should return expected command
-		assert.NotEmpty(t, netplanCmd)
-		assert.Contains(t, netplanCmd, "management")
-		assert.Contains(t, netplanCmd, "echo")
+		netplanCmd, err := vlanService.generateNetplanConfig("management", vlanConfig, "eth0.100", "eth0", "192.168.100.10/24")
+		require.NoError(t, err)
+
+		// Then: Should return a netplan file defining the VLAN on its parent
+		assert.Equal(t, `# Generated by kictl for VLAN management
+network:
+  version: 2
+  vlans:
+    eth0.100:
+      id: 100
+      link: eth0
+      addresses:
+        - 192.168.100.10/24
+`, netplanCmd)
 	})
 }
 
@@ -1155,19 +1293,24 @@ func TestVLANService_CleanupDebugPods(t *testing.T) {
 	tests := []struct {
 		name        string
 		description string
-		setupMocks  func(*MockDryRunExecutor, *MockLogger)
+		dryRun      bool
+		setupMocks  func(*MockDryRunExecutor, *logging.MockLogger)
 		expectLogs  []string
 	}{
+		{
+			name:        "dry_run_skips_cleanup",
+			description: "Dry runs never delete pods",
+			dryRun:      true,
+			setupMocks: func(mockKubectl *MockDryRunExecutor, mockLogger *logging.MockLogger) {
+				mockLogger.On("Debug", "DRY RUN: Skipping debug pod cleanup").Return()
+			},
+			expectLogs: []string{"Skipping debug pod cleanup"},
+		},
 		{
 			name:        "successful_cleanup_with_pods",
-			description: "Successfully cleans up debug pods",
-			setupMocks: func(mockKubectl *MockDryRunExecutor, mockLogger *MockLogger) {
-				// Mock pod listing with debug pods found
-				mockKubectl.On("GetPods", mock.Anything, "", "").
-					Return(true, "pod/node-debugger-abc123\npod/node-debugger-xyz789\npod/other-pod", nil)
-				// Mock pod deletion
-				mockKubectl.On("DeletePod", mock.Anything, "node-debugger-abc123").Return(true, "", nil)
-				mockKubectl.On("DeletePod", mock.Anything, "node-debugger-xyz789").Return(true, "", nil)
+			description: "Deletes the debug pods the run started",
+			setupMocks: func(mockKubectl *MockDryRunExecutor, mockLogger *logging.MockLogger) {
+				mockKubectl.On("ReleaseDebugPods", mock.Anything).Return(2, nil)
 
 				mockLogger.On("Info", "🧹 Cleaning up debug pods...").Return()
 				mockLogger.On("Info", "✅ Cleaned up 2 debug pods").Return()
@@ -1176,11 +1319,9 @@ func TestVLANService_CleanupDebugPods(t *testing.T) {
 		},
 		{
 			name:        "cleanup_no_pods_found",
-			description: "Handles cleanup when no debug pods exist",
-			setupMocks: func(mockKubectl *MockDryRunExecutor, mockLogger *MockLogger) {
-				// Mock pod listing with no debug pods
-				mockKubectl.On("GetPods", mock.Anything, "", "").
-					Return(true, "pod/other-pod-1\npod/other-pod-2", nil)
+			description: "Handles cleanup when the run started no debug pods",
+			setupMocks: func(mockKubectl *MockDryRunExecutor, mockLogger *logging.MockLogger) {
+				mockKubectl.On("ReleaseDebugPods", mock.Anything).Return(0, nil)
 
 				mockLogger.On("Info", "🧹 Cleaning up debug pods...").Return()
 				mockLogger.On("Info", "✅ No debug pods to clean up").Return()
@@ -1188,29 +1329,27 @@ func TestVLANService_CleanupDebugPods(t *testing.T) {
 			expectLogs: []string{"Cleaning up", "No debug pods"},
 		},
 		{
-			name:        "cleanup_pod_listing_failure",
-			description: "Handles failure when listing pods",
-			setupMocks: func(mockKubectl *MockDryRunExecutor, mockLogger *MockLogger) {
-				// Mock pod listing failure
-				mockKubectl.On("GetPods", mock.Anything, "", "").
-					Return(false, "", fmt.Errorf("failed to list pods"))
+			name:        "cleanup_pod_deletion_failure",
+			description: "Warns about pods that could not be deleted",
+			setupMocks: func(mockKubectl *MockDryRunExecutor, mockLogger *logging.MockLogger) {
+				mockKubectl.On("ReleaseDebugPods", mock.Anything).
+					Return(1, fmt.Errorf("failed to delete debug pod node-debugger-rsb3-x1y2z"))
 
 				mockLogger.On("Info", "🧹 Cleaning up debug pods...").Return()
-				mockLogger.On("Warn", mock.MatchedBy(func(msg string) bool {
-					return len(msg) > 10 // Check message has reasonable length
-				})).Return()
+				mockLogger.On("Warn", "Failed to delete debug pods: failed to delete debug pod node-debugger-rsb3-x1y2z").Return()
+				mockLogger.On("Info", "✅ Cleaned up 1 debug pods").Return()
 			},
-			expectLogs: []string{"Cleaning up", "Failed to get pods"},
+			expectLogs: []string{"Cleaning up", "Failed to delete debug pods"},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockKubectl := NewMockDryRunExecutor()
-			mockLogger := NewMockLogger()
+			mockLogger := logging.NewMockLogger()
 			tt.setupMocks(mockKubectl, mockLogger)
 
-			service := NewService(mockKubectl, Options{Logger: mockLogger})
+			service := NewService(mockKubectl, Options{Logger: mockLogger, DryRun: tt.dryRun})
 			vlanService := service.(*VLANService)
 
 			// When: Call cleanup method
@@ -1230,7 +1369,7 @@ func TestVLANService_DryRunMode(t *testing.T) {
 		description string
 		options     Options
 		operation   string
-		setupMocks  func(*MockDryRunExecutor, *MockLogger)
+		setupMocks  func(*MockDryRunExecutor, *logging.MockLogger)
 	}{
 		{
 			name:        "dry_run_configure",
@@ -1241,12 +1380,11 @@ func TestVLANService_DryRunMode(t *testing.T) {
 				DefaultInterface:     "eth0",
 			},
 			operation: "configure",
-			setupMocks: func(mockKubectl *MockDryRunExecutor, mockLogger *MockLogger) {
+			setupMocks: func(mockKubectl *MockDryRunExecutor, mockLogger *logging.MockLogger) {
 				mockKubectl.On("SetDryRun", true).Return()
 				mockKubectl.On("GetNode", mock.Anything, "node1").Return(true, "node/node1", nil)
 				mockKubectl.On("ExecNodeCommand", mock.Anything, "node1", mock.AnythingOfType("string")).
 					Return(true, "DRY RUN: Would configure VLAN", nil)
-				mockKubectl.On("GetPods", mock.Anything, "", "").Return(true, "", nil)
 				mockLogger.On("Info", mock.AnythingOfType("string")).Return().Maybe()
 				mockLogger.On("Debug", mock.AnythingOfType("string")).Return().Maybe()
 				mockLogger.On("Warn", mock.AnythingOfType("string")).Return().Maybe()
@@ -1261,12 +1399,11 @@ func TestVLANService_DryRunMode(t *testing.T) {
 				DefaultInterface:     "eth0",
 			},
 			operation: "verify",
-			setupMocks: func(mockKubectl *MockDryRunExecutor, mockLogger *MockLogger) {
+			setupMocks: func(mockKubectl *MockDryRunExecutor, mockLogger *logging.MockLogger) {
 				mockKubectl.On("SetDryRun", true).Return()
 				mockKubectl.On("GetNode", mock.Anything, "node1").Return(true, "node/node1", nil)
 				mockKubectl.On("ExecNodeCommand", mock.Anything, "node1", "ip addr show eth0.100").
 					Return(true, "eth0.100: interface exists", nil)
-				mockKubectl.On("GetPods", mock.Anything, "", "").Return(true, "", nil)
 				mockLogger.On("Info", mock.AnythingOfType("string")).Return().Maybe()
 				mockLogger.On("Debug", mock.AnythingOfType("string")).Return().Maybe()
 				mockLogger.On("Warn", mock.AnythingOfType("string")).Return().Maybe()
@@ -1277,7 +1414,7 @@ func TestVLANService_DryRunMode(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockKubectl := NewMockDryRunExecutor()
-			mockLogger := NewMockLogger()
+			mockLogger := logging.NewMockLogger()
 			tt.setupMocks(mockKubectl, mockLogger)
 
 			tt.options.Logger = mockLogger
@@ -1319,6 +1456,8 @@ func TestVLANService_DryRunMode(t *testing.T) {
 			assert.NoError(t, err)
 			assert.NotNil(t, result)
 			mockKubectl.AssertExpectations(t)
+			// WHY: Dry runs start no debug pods, so cleanup must not delete pods
+			mockKubectl.AssertNotCalled(t, "ReleaseDebugPods", mock.Anything)
 		})
 	}
 }
@@ -1333,7 +1472,7 @@ func TestVLANService_Options(t *testing.T) {
 		{
 			name: "minimal_options",
 			options: Options{
-				Logger: NewMockLogger(),
+				Logger: logging.NewMockLogger(),
 			},
 			description: "Service with minimal options",
 		},
@@ -1345,7 +1484,7 @@ func TestVLANService_Options(t *testing.T) {
 				ValidateConnectivity: true,
 				PersistentConfig:     true,
 				DefaultInterface:     "ens192",
-				Logger:               NewMockLogger(),
+				Logger:               logging.NewMockLogger(),
 			},
 			description: "Service with all options enabled",
 		},
@@ -1357,7 +1496,7 @@ func TestVLANService_Options(t *testing.T) {
 				ValidateConnectivity: true,
 				PersistentConfig:     true,
 				DefaultInterface:     "eth0",
-				Logger:               NewMockLogger(),
+				Logger:               logging.NewMockLogger(),
 			},
 			description: "Production-like configuration",
 		},
@@ -1531,3 +1670,261 @@ func TestOperationResults(t *testing.T) {
 		})
 	}
 }
+
+// TestVLANService_RollbackOnFailure tests tearing down the interfaces of a partially failed configure
+// WHY: A configure failing on some nodes must not leave the cluster with VLANs on only part of them
+func TestVLANService_RollbackOnFailure(t *testing.T) {
+	isCreate := func(cmd string) bool { return strings.Contains(cmd, "ip link add") }
+	isTeardown := func(cmd string) bool { return strings.Contains(cmd, "ip link delete eth0.100") }
+	isVerify := func(cmd string) bool { return strings.Contains(cmd, "netplan generate") }
+
+	tests := []struct {
+		name               string
+		description        string
+		options            Options
+		node2Fails         bool
+		teardownFails      bool
+		expectTeardown     bool
+		expectNetplan      bool
+		expectRolledBack   bool
+		expectConfigured   bool
+		expectedErrorCount int
+	}{
+		{
+			name:               "rollback_after_partial_failure",
+			description:        "Interfaces created on healthy nodes are removed when another node fails",
+			options:            Options{RollbackOnFailure: true},
+			node2Fails:         true,
+			expectTeardown:     true,
+			expectRolledBack:   true,
+			expectedErrorCount: 1,
+		},
+		{
+			name:               "rollback_removes_netplan_file",
+			description:        "Persistent configuration also removes the generated netplan file",
+			options:            Options{RollbackOnFailure: true, PersistentConfig: true},
+			node2Fails:         true,
+			expectTeardown:     true,
+			expectNetplan:      true,
+			expectRolledBack:   true,
+			expectedErrorCount: 1,
+		},
+		{
+			name:               "rollback_disabled_keeps_interfaces",
+			description:        "Without the option the partial state is left in place",
+			options:            Options{},
+			node2Fails:         true,
+			expectConfigured:   true,
+			expectedErrorCount: 1,
+		},
+		{
+			name:             "no_rollback_when_all_nodes_succeed",
+			description:      "A successful run keeps everything it created",
+			options:          Options{RollbackOnFailure: true},
+			expectConfigured: true,
+		},
+		{
+			name:               "failed_teardown_is_reported",
+			description:        "An interface that cannot be removed stays configured and adds an error",
+			options:            Options{RollbackOnFailure: true},
+			node2Fails:         true,
+			teardownFails:      true,
+			expectTeardown:     true,
+			expectConfigured:   true,
+			expectedErrorCount: 2,
+		},
+		{
+			name:               "dry_run_only_reports_rollback",
+			description:        "Dry runs never execute the teardown",
+			options:            Options{RollbackOnFailure: true, DryRun: true},
+			node2Fails:         true,
+			expectConfigured:   true,
+			expectedErrorCount: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A VLAN on two nodes where node2 may fail
+			mockKubectl := NewMockDryRunExecutor()
+			mockLogger := logging.NewMockLogger()
+			mockLogger.On("Info", mock.AnythingOfType("string")).Return().Maybe()
+			mockLogger.On("Warn", mock.AnythingOfType("string")).Return().Maybe()
+			mockLogger.On("Error", mock.AnythingOfType("string")).Return().Maybe()
+			mockLogger.On("Debug", mock.AnythingOfType("string")).Return().Maybe()
+
+			mockKubectl.On("SetDryRun", tt.options.DryRun).Return()
+			if tt.options.PersistentConfig {
+				mockKubectl.On("ExecNodeCommand", mock.Anything, mock.Anything, mock.MatchedBy(isVerify)).Return(true, "", nil)
+			}
+			mockKubectl.On("ExecNodeCommand", mock.Anything, "node1", mock.MatchedBy(isCreate)).Return(true, addrOutput("eth0.100=192.168.100.10/24"), nil)
+			if tt.node2Fails {
+				mockKubectl.On("ExecNodeCommand", mock.Anything, "node2", mock.MatchedBy(isCreate)).Return(false, "RTNETLINK answers: No such device", nil)
+			} else {
+				mockKubectl.On("ExecNodeCommand", mock.Anything, "node2", mock.MatchedBy(isCreate)).Return(true, addrOutput("eth0.100=192.168.100.11/24"), nil)
+			}
+			if tt.expectTeardown {
+				mockKubectl.On("ExecNodeCommand", mock.Anything, "node1", mock.MatchedBy(func(cmd string) bool {
+					return isTeardown(cmd) && tt.expectNetplan == strings.Contains(cmd, "rm -f /etc/netplan/60-kictl-eth0.100.yaml")
+				})).Return(!tt.teardownFails, "", nil)
+			}
+			if !tt.options.DryRun {
+				mockKubectl.On("ReleaseDebugPods", mock.Anything).Return(0, nil)
+			}
+
+			tt.options.Logger = mockLogger
+			tt.options.DefaultInterface = "eth0"
+			service := NewService(mockKubectl, tt.options)
+
+			vlanConfig := &config.NodeVLANConf{
+				APIVersion: "openstack.kictl.icycloud.io/v1",
+				Kind:       "NodeVLANConf",
+				Metadata:   config.Metadata{Name: "rollback-test"},
+				Spec: config.NodeVLANSpec{
+					VLANs: map[string]config.VLANConfig{
+						"management": {
+							ID:     100,
+							Subnet: "192.168.100.0/24",
+							NodeMapping: map[string]string{
+								"node1": "192.168.100.10/24",
+								"node2": "192.168.100.11/24",
+							},
+						},
+					},
+				},
+			}
+
+			// When: Configure VLANs
+			results, err := service.ConfigureVLANs(context.Background(), vlanConfig)
+
+			// Then: Interfaces of node1 are torn down only when expected
+			require.NoError(t, err)
+			assert.Len(t, results.Errors, tt.expectedErrorCount)
+			assert.Equal(t, tt.expectRolledBack, len(results.RolledBackVLANs["node1"]) == 1, "rolled back VLANs on node1")
+			assert.Equal(t, tt.expectConfigured, len(results.ConfiguredVLANs["node1"]) == 1, "configured VLANs on node1")
+			if !tt.expectTeardown {
+				mockKubectl.AssertNotCalled(t, "ExecNodeCommand", mock.Anything, "node1", mock.MatchedBy(isTeardown))
+			}
+			mockKubectl.AssertExpectations(t)
+		})
+	}
+}
+
+// TestVLANService_ConfigureVLANs_SoakAbort tests stopping a configure whose batch fails its soak
+// WHY: Nodes after the failed batch must stay untouched, and the batch itself is rolled back only on request
+func TestVLANService_ConfigureVLANs_SoakAbort(t *testing.T) {
+	isCreate := func(cmd string) bool { return strings.Contains(cmd, "ip link add") }
+	isTeardown := func(cmd string) bool { return strings.Contains(cmd, "ip link delete eth0.100") }
+
+	tests := []struct {
+		name               string
+		rollback           bool
+		expectedConfigured int
+		expectedRolledBack int
+	}{
+		{"abort_keeps_the_batch", false, 2, 0},
+		{"abort_rolls_back_the_batch", true, 0, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A VLAN on three nodes, rolled out in batches of two whose first soak fails
+			mockKubectl := NewMockDryRunExecutor()
+			mockKubectl.On("SetDryRun", false).Return()
+			mockKubectl.On("ExecNodeCommand", mock.Anything, mock.Anything, mock.MatchedBy(isCreate)).Return(true, addrOutput(
+				"eth0.100=192.168.100.11/24", "eth0.100=192.168.100.12/24",
+			), nil)
+			if tt.rollback {
+				mockKubectl.On("ExecNodeCommand", mock.Anything, mock.Anything, mock.MatchedBy(isTeardown)).Return(true, "", nil)
+			}
+			mockKubectl.On("ReleaseDebugPods", mock.Anything).Return(0, nil)
+			logger := logging.NewRecordingLogger()
+
+			service := NewService(mockKubectl, Options{
+				DefaultInterface:     "eth0",
+				RollbackAbortedBatch: tt.rollback,
+				Workers: throttle.New(throttle.Options{MaxWorkers: 1, Logger: logger, BatchSize: 2, Soak: func(batch []string) error {
+					return errors.New("node node2 is not Ready (False) during the soak")
+				}}),
+				Logger: logger,
+			})
+			vlanConfig := &config.NodeVLANConf{
+				Metadata: config.Metadata{Name: "soak-test"},
+				Spec: config.NodeVLANSpec{VLANs: map[string]config.VLANConfig{
+					"management": {ID: 100, Subnet: "192.168.100.0/24", NodeMapping: map[string]string{
+						"node1": "192.168.100.11/24", "node2": "192.168.100.12/24", "node3": "192.168.100.13/24",
+					}},
+				}},
+			}
+
+			// When: Configure VLANs
+			results, err := service.ConfigureVLANs(context.Background(), vlanConfig)
+
+			// Then: node3 was never changed, and the abort is reported with the batch kept or rolled back
+			require.NoError(t, err)
+			mockKubectl.AssertNotCalled(t, "ExecNodeCommand", mock.Anything, "node3", mock.Anything)
+			require.Len(t, results.Errors, 1)
+			assert.Contains(t, results.Errors[0].Error(), "rollout aborted after batch node1, node2")
+			assert.Contains(t, results.Errors[0].Error(), "not started: node3")
+			assert.Len(t, results.ConfiguredVLANs, tt.expectedConfigured)
+			assert.Len(t, results.RolledBackVLANs, tt.expectedRolledBack)
+			if !tt.rollback {
+				mockKubectl.AssertNotCalled(t, "ExecNodeCommand", mock.Anything, mock.Anything, mock.MatchedBy(isTeardown))
+			}
+		})
+	}
+}
+
+// TestVLANService_ConfigureVLANs_Workers tests configuring the nodes of two VLANs concurrently
+// WHY: Interfaces gathered by concurrent workers must add up per node like a sequential run
+func TestVLANService_ConfigureVLANs_Workers(t *testing.T) {
+	// Given: Two VLANs on four nodes, node3 failing every interface, and three workers
+	mockKubectl := NewMockDryRunExecutor()
+	mockKubectl.On("SetDryRun", false).Return()
+	mockKubectl.On("ExecNodeCommand", mock.Anything, "node3", mock.Anything).Return(false, "RTNETLINK answers: No such device", nil)
+	mockKubectl.On("ExecNodeCommand", mock.Anything, mock.Anything, mock.Anything).Return(true, addrOutput(
+		"eth0.100=192.168.100.11/24", "eth0.100=192.168.100.12/24", "eth0.100=192.168.100.14/24",
+		"eth0.200=192.168.200.11/24", "eth0.200=192.168.200.12/24", "eth0.200=192.168.200.14/24",
+	), nil)
+	mockKubectl.On("ReleaseDebugPods", mock.Anything).Return(0, nil)
+	logger := logging.NewRecordingLogger()
+	recorder := &progress.Recorder{}
+
+	service := NewService(mockKubectl, Options{
+		DefaultInterface: "eth0",
+		Workers:          throttle.New(throttle.Options{MaxWorkers: 3, Logger: logger}),
+		Progress:         recorder,
+		Logger:           logger,
+	})
+	nodeMapping := func(prefix string) map[string]string {
+		return map[string]string{"node1": prefix + ".11/24", "node2": prefix + ".12/24", "node3": prefix + ".13/24", "node4": prefix + ".14/24"}
+	}
+	vlanConfig := &config.NodeVLANConf{
+		Kind:     "NodeVLANConf",
+		Metadata: config.Metadata{Name: "workers"},
+		Spec: config.NodeVLANSpec{VLANs: map[string]config.VLANConfig{
+			"management": {ID: 100, Subnet: "192.168.100.0/24", NodeMapping: nodeMapping("192.168.100")},
+			"storage":    {ID: 200, Subnet: "192.168.200.0/24", NodeMapping: nodeMapping("192.168.200")},
+		}},
+	}
+
+	// When: Configure VLANs
+	results, err := service.ConfigureVLANs(context.Background(), vlanConfig)
+
+	// Then: Every healthy node has both interfaces and node3 failed twice
+	require.NoError(t, err)
+	assert.Equal(t, 8, results.TotalNodes)
+	assert.Equal(t, 6, results.SuccessfulNodes)
+	assert.Equal(t, []string{"node3", "node3"}, results.FailedNodes)
+	assert.Len(t, results.Errors, 2)
+	for _, nodeName := range []string{"node1", "node2", "node4"} {
+		assert.Len(t, results.ConfiguredVLANs[nodeName], 2, nodeName)
+	}
+	assert.NotContains(t, results.ConfiguredVLANs, "node3")
+
+	// And: The progress counts every assignment once, from concurrent workers
+	assert.Equal(t, []string{"Configure VLANs/8"}, recorder.Tasks)
+	assert.Len(t, recorder.Succeeded, 6)
+	assert.Equal(t, []string{"node3", "node3"}, recorder.Failed)
+	assert.Equal(t, 1, recorder.Ended)
+}
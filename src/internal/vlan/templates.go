@@ -0,0 +1,126 @@
+package vlan
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// NetplanTemplate is the file name of the template rendering netplan files, built in or in a --templates-dir
+const NetplanTemplate = "netplan.yaml.tmpl"
+
+// builtinTemplates are the templates used for every file a templates directory does not override
+//
+//go:embed templates/*.tmpl
+var builtinTemplates embed.FS
+
+// NetplanData is what the netplan template renders for one VLAN interface
+type NetplanData struct {
+	VLANName  string   // Name of the VLAN in the configuration, e.g. management
+	ID        int      // VLAN ID
+	Interface string   // VLAN interface, e.g. eth0.100
+	Link      string   // Parent interface, e.g. eth0
+	Addresses []string // Addresses in CIDR notation, IPv4 and IPv6
+}
+
+// sampleNetplanData is rendered when templates load, so a template failing on real data fails before any run
+var sampleNetplanData = NetplanData{VLANName: "management", ID: 100, Interface: "eth0.100", Link: "eth0",
+	Addresses: []string{"192.168.100.10/24", "fd00:100::10/64"}}
+
+// Templates render the persistent configuration files of VLAN interfaces
+type Templates struct {
+	netplan *template.Template
+}
+
+// DefaultTemplates returns the built-in templates, parsed on first use
+var DefaultTemplates = sync.OnceValue(func() *Templates {
+	templates, err := LoadTemplates("")
+	if err != nil {
+		panic(fmt.Sprintf("built-in templates: %v", err))
+	}
+	return templates
+})
+
+// LoadTemplates returns the built-in templates, with those dir holds replacing them; an empty dir loads none
+// Every template is rendered once with sample data, and unknown .tmpl files are rejected to catch misnamed overrides.
+func LoadTemplates(dir string) (*Templates, error) {
+	sources := make(map[string]string)
+	for _, name := range []string{NetplanTemplate} {
+		content, err := builtinTemplates.ReadFile("templates/" + name)
+		if err != nil {
+			return nil, err
+		}
+		sources[name] = string(content)
+	}
+
+	if dir != "" {
+		overrides, err := readTemplateOverrides(dir, sources)
+		if err != nil {
+			return nil, err
+		}
+		for name, content := range overrides {
+			sources[name] = content
+		}
+	}
+
+	netplan, err := template.New(NetplanTemplate).Option("missingkey=error").Parse(sources[NetplanTemplate])
+	if err != nil {
+		return nil, fmt.Errorf("invalid template %s: %w", NetplanTemplate, err)
+	}
+	templates := &Templates{netplan: netplan}
+	if _, err := templates.renderNetplan(sampleNetplanData); err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+// readTemplateOverrides reads the .tmpl files of a templates directory, which must each replace a known template
+func readTemplateOverrides(dir string, known map[string]string) (map[string]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read templates directory: %w", err)
+	}
+
+	overrides := make(map[string]string)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".tmpl") {
+			continue
+		}
+		if _, ok := known[entry.Name()]; !ok {
+			names := make([]string, 0, len(known))
+			for name := range known {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			return nil, fmt.Errorf("unknown template %s in %s. Expected: %s", entry.Name(), dir, strings.Join(names, ", "))
+		}
+		content, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read template: %w", err)
+		}
+		overrides[entry.Name()] = string(content)
+	}
+	return overrides, nil
+}
+
+// renderNetplan renders the netplan file of a VLAN interface
+// Output that is not YAML fails here, before netplan generate checks the rest on the node.
+func (t *Templates) renderNetplan(data NetplanData) (string, error) {
+	var out bytes.Buffer
+	if err := t.netplan.Execute(&out, data); err != nil {
+		return "", fmt.Errorf("failed to render template %s: %w", NetplanTemplate, err)
+	}
+	var document map[string]interface{}
+	if err := yaml.Unmarshal(out.Bytes(), &document); err != nil {
+		return "", fmt.Errorf("template %s rendered invalid YAML for %s: %w", NetplanTemplate, data.Interface, err)
+	}
+	return out.String(), nil
+}
@@ -0,0 +1,101 @@
+// Package vlan provides unit tests for rendering persistent configuration files from templates
+// WHY: Sites adjust the renderer output through their own templates, and a broken one must fail before any node is touched
+package vlan
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLoadTemplates tests the built-in netplan template and overriding it from a templates directory
+// WHY: An override replaces the built-in output exactly, and misnamed or failing templates are rejected at load
+func TestLoadTemplates(t *testing.T) {
+	tests := []struct {
+		name        string
+		files       map[string]string
+		noDir       bool
+		expected    string
+		expectError string
+	}{
+		{
+			name: "builtin",
+			expected: `# Generated by kictl for VLAN management
+network:
+  version: 2
+  vlans:
+    eth0.100:
+      id: 100
+      link: eth0
+      addresses:
+        - 192.168.100.10/24
+        - fd00:100::10/64
+`,
+		},
+		{
+			name: "override",
+			files: map[string]string{
+				NetplanTemplate: "network:\n  version: 2\n  vlans:\n    {{ .Interface }}:\n      id: {{ .ID }}\n      link: {{ .Link }}\n      accept-ra: false\n",
+				"README.md":     "site templates",
+			},
+			expected: "network:\n  version: 2\n  vlans:\n    eth0.100:\n      id: 100\n      link: eth0\n      accept-ra: false\n",
+		},
+		{
+			name:        "unknown_template",
+			files:       map[string]string{"netplan.yml.tmpl": "network: {}\n"},
+			expectError: "unknown template netplan.yml.tmpl",
+		},
+		{
+			name:        "syntax_error",
+			files:       map[string]string{NetplanTemplate: "id: {{ .ID }\n"},
+			expectError: "invalid template netplan.yaml.tmpl",
+		},
+		{
+			name:        "unknown_field",
+			files:       map[string]string{NetplanTemplate: "gateway4: {{ .Gateway }}\n"},
+			expectError: "failed to render template netplan.yaml.tmpl",
+		},
+		{
+			name:        "invalid_yaml",
+			files:       map[string]string{NetplanTemplate: "network:\n  vlans:\n    {{ .Interface }}:\n    id: {{ .ID }}\n      link: {{ .Link }}\n"},
+			expectError: "template netplan.yaml.tmpl rendered invalid YAML for eth0.100: yaml: line 5",
+		},
+		{
+			name:        "missing_directory",
+			noDir:       true,
+			expectError: "failed to read templates directory",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A templates directory with the files of the case, or none for the built-in templates
+			dir := ""
+			if len(tt.files) > 0 || tt.noDir {
+				dir = t.TempDir()
+				for name, content := range tt.files {
+					require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+				}
+				if tt.noDir {
+					dir = filepath.Join(dir, "missing")
+				}
+			}
+
+			// When: Load the templates and render the netplan file of an interface
+			templates, err := LoadTemplates(dir)
+
+			// Then: The file is rendered from the template in effect, or loading fails with the reason
+			if tt.expectError != "" {
+				assert.ErrorContains(t, err, tt.expectError)
+				return
+			}
+			require.NoError(t, err)
+			rendered, err := templates.renderNetplan(sampleNetplanData)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, rendered)
+		})
+	}
+}
@@ -0,0 +1,257 @@
+package vlan
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/kubectl"
+)
+
+// Phases of the transaction that configures one VLAN interface on a node
+const (
+	PhasePrepare = "prepare" // build the commands and persistent configuration files
+	PhaseVerify  = "verify"  // check the staged files on the node, for backends that can, e.g. with netplan generate
+	PhaseCommit  = "commit"  // create the interface and install the files
+	PhaseConfirm = "confirm" // check that the interface carries its address
+)
+
+// maxInterfaceNameLength is the longest interface name the kernel accepts (IFNAMSIZ minus the terminator)
+const maxInterfaceNameLength = 15
+
+// stagingDir holds the per-interface root directories persistent configuration files wait in before install
+const stagingDir = "/run/kictl/staged"
+
+// TransactionError reports the phase in which configuring a VLAN interface stopped
+type TransactionError struct {
+	Phase     string
+	Interface string
+	Err       error
+}
+
+func (e *TransactionError) Error() string {
+	return fmt.Sprintf("%s of %s failed: %v", e.Phase, e.Interface, e.Err)
+}
+
+func (e *TransactionError) Unwrap() error {
+	return e.Err
+}
+
+// vlanTransaction is the staged configuration of one VLAN interface on one node
+type vlanTransaction struct {
+	nodeName      string
+	vlanInterface string
+	bridge        string // OVS bridge of the port, empty for VLAN sub-interfaces
+	ipAddress     string
+	commit        []string           // host commands creating the interface, in order
+	backend       persistenceBackend // nil without persistent configuration
+	files         []persistedFile    // files installed by the commit
+	try           time.Duration      // revert timeout when netplan try applies the files instead of ip
+}
+
+// prepareVLANTransaction builds the commands and persistent configuration files of an interface without changing the node
+func (vs *VLANService) prepareVLANTransaction(ctx context.Context, nodeName, vlanName string, vlanConfig config.VLANConfig, vlanInterface, physInterface, ipAddress string) (*vlanTransaction, error) {
+	// Dry runs name detected interfaces with placeholders like <fastest link>, which are not checked
+	placeholder := vs.options.DryRun && strings.HasPrefix(physInterface, "<")
+	if len(vlanInterface) > maxInterfaceNameLength && !placeholder {
+		return nil, fmt.Errorf("interface name %s is longer than %d characters", vlanInterface, maxInterfaceNameLength)
+	}
+
+	tx := &vlanTransaction{
+		nodeName:      nodeName,
+		vlanInterface: vlanInterface,
+		ipAddress:     ipAddress,
+		commit:        []string{kubectl.HostCommand("ip", "link", "add", "link", physInterface, "name", vlanInterface, "type", "vlan", "id", strconv.Itoa(vlanConfig.ID))},
+	}
+	if vlanConfig.OVS != nil {
+		tx.bridge = vlanConfig.OVS.Bridge
+		tx.commit = []string{ovsAddPortCommand(vlanConfig.OVS)}
+	}
+	for _, address := range config.SplitAddresses(ipAddress) {
+		tx.commit = append(tx.commit, addAddressCommand(address, vlanInterface))
+	}
+	tx.commit = append(tx.commit, kubectl.HostCommand("ip", "link", "set", vlanInterface, "up"))
+
+	// The OVS database keeps ports across reboots, so only sub-interfaces get persistent configuration files
+	if !vs.options.PersistentConfig || tx.bridge != "" {
+		return tx, nil
+	}
+
+	backend, err := vs.nodePersistenceBackend(ctx, nodeName)
+	if err != nil || backend == nil {
+		return tx, err
+	}
+	iface := VLANInterfaceInfo{VLANName: vlanName, VLANId: vlanConfig.ID, Interface: vlanInterface, IPAddress: ipAddress, PhysInterface: physInterface}
+	files, err := backend.files(ctx, vs, nodeName, iface)
+	if err != nil {
+		return nil, fmt.Errorf("%s configuration: %w", backend.name(), err)
+	}
+	tx.backend, tx.files = backend, files
+	if backend.name() == config.PersistenceBackendNetplan {
+		tx.try = vs.options.NetplanTry
+	}
+	return tx, nil
+}
+
+// stagingRoot is the root directory the interface's files wait in before install
+func (tx *vlanTransaction) stagingRoot() string {
+	return path.Join(stagingDir, tx.vlanInterface)
+}
+
+// stagedPath is where a file waits for the commit
+func (tx *vlanTransaction) stagedPath(file persistedFile) string {
+	return tx.stagingRoot() + file.Path
+}
+
+// persistedPaths lists the files the commit installs
+func (tx *vlanTransaction) persistedPaths() []string {
+	paths := make([]string, 0, len(tx.files))
+	for _, file := range tx.files {
+		paths = append(paths, file.Path)
+	}
+	return paths
+}
+
+// verifies reports whether the backend checks the staged files before the commit
+func (tx *vlanTransaction) verifies() bool {
+	return tx.backend != nil && tx.backend.checkCommand(tx.stagingRoot(), tx.files) != ""
+}
+
+// stageCommands write the files below the staging root, next to a copy of the node's configuration when the backend checks them
+func (tx *vlanTransaction) stageCommands() []string {
+	commands := []string{kubectl.HostCommand("rm", "-rf", tx.stagingRoot())}
+
+	dirs := []string{"-p"}
+	seen := make(map[string]bool)
+	for _, file := range tx.files {
+		if dir := path.Dir(tx.stagedPath(file)); !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+	commands = append(commands, kubectl.HostCommand("mkdir", dirs...))
+
+	if seed := tx.backend.seedCommand(tx.stagingRoot()); seed != "" {
+		commands = append(commands, seed)
+	}
+	for _, file := range tx.files {
+		commands = append(commands, kubectl.HostCommand("printf", "%s", file.Content)+" > "+kubectl.QuoteShellArg(tx.stagedPath(file)))
+	}
+	return commands
+}
+
+// verifyCommand stages the files and checks them
+// Checks only read and write below the staging root, so a rejected file changes nothing.
+func (tx *vlanTransaction) verifyCommand() string {
+	commands := append(tx.stageCommands(), tx.backend.checkCommand(tx.stagingRoot(), tx.files))
+	return kubectl.JoinHostCommands(commands...)
+}
+
+// commitCommand applies the staged changes and ends by listing the interface's addresses for the confirm phase
+// Backends without a check stage their files here, as there is nothing to verify them with beforehand.
+func (tx *vlanTransaction) commitCommand() string {
+	if tx.try > 0 {
+		return tx.tryCommand()
+	}
+
+	commands := append([]string{}, tx.commit...)
+	if len(tx.files) > 0 {
+		if !tx.verifies() {
+			commands = append(commands, tx.stageCommands()...)
+		}
+		for _, file := range tx.files {
+			commands = append(commands, kubectl.HostCommand("install", "-m", file.Mode, tx.stagedPath(file), file.Path))
+		}
+		commands = append(commands, kubectl.HostCommand("rm", "-rf", tx.stagingRoot()))
+	}
+	commands = append(commands, kubectl.HostCommand("ip", "-o", "addr", "show", "dev", tx.vlanInterface))
+	return kubectl.JoinHostCommands(commands...)
+}
+
+// confirmed reports whether the address listing printed by the commit shows every address of the interface
+func (tx *vlanTransaction) confirmed(output string) bool {
+	return len(tx.missingAddresses(output)) == 0
+}
+
+// missingAddresses returns the addresses of the interface the address listing printed by the commit lacks
+func (tx *vlanTransaction) missingAddresses(output string) []string {
+	return missingAddresses(output, tx.ipAddress)
+}
+
+// missingAddresses returns the addresses of a node mapping value an ip addr listing lacks
+// Each address must be followed by a space, so 10.0.0.1/2 is not found in 10.0.0.1/24.
+func missingAddresses(output, addresses string) []string {
+	var missing []string
+	for _, address := range config.SplitAddresses(addresses) {
+		if !strings.Contains(output, addressFamily(address)+" "+address+" ") {
+			missing = append(missing, address)
+		}
+	}
+	return missing
+}
+
+// addAddressCommand adds one address to an interface, with ip -6 for IPv6 addresses
+func addAddressCommand(address, vlanInterface string) string {
+	if config.IsIPv6Address(address) {
+		return kubectl.HostCommand("ip", "-6", "addr", "add", address, "dev", vlanInterface)
+	}
+	return kubectl.HostCommand("ip", "addr", "add", address, "dev", vlanInterface)
+}
+
+// addressFamily is the keyword ip addr prints an address after: inet or inet6
+func addressFamily(address string) string {
+	if config.IsIPv6Address(address) {
+		return "inet6"
+	}
+	return "inet"
+}
+
+// runVLANTransaction verifies, commits and confirms a prepared interface
+// An interface that does not confirm is torn down again, since its commit has just created it.
+func (vs *VLANService) runVLANTransaction(ctx context.Context, tx *vlanTransaction) error {
+	if tx.verifies() {
+		success, output, err := vs.kubectl.ExecNodeCommand(ctx, tx.nodeName, tx.verifyCommand())
+		if err != nil {
+			return &TransactionError{Phase: PhaseVerify, Interface: tx.vlanInterface, Err: err}
+		}
+		if !success {
+			return &TransactionError{Phase: PhaseVerify, Interface: tx.vlanInterface, Err: fmt.Errorf("%s rejected the generated configuration: %s", tx.backend.name(), strings.TrimSpace(output))}
+		}
+	}
+
+	success, output, err := vs.kubectl.ExecNodeCommand(ctx, tx.nodeName, tx.commitCommand())
+	if err != nil {
+		return &TransactionError{Phase: PhaseCommit, Interface: tx.vlanInterface, Err: err}
+	}
+	if !success {
+		return &TransactionError{Phase: PhaseCommit, Interface: tx.vlanInterface, Err: fmt.Errorf("VLAN configuration failed: %s", output)}
+	}
+
+	if vs.options.DryRun {
+		return nil
+	}
+	if tx.try > 0 {
+		if err := vs.confirmNetplanTry(ctx, tx); err != nil {
+			return err
+		}
+		vs.saveArtifacts(tx)
+		return nil
+	}
+	missing := tx.missingAddresses(output)
+	if len(missing) == 0 {
+		vs.saveArtifacts(tx)
+		return nil
+	}
+
+	confirmErr := fmt.Errorf("address %s not found on the interface", strings.Join(missing, ", "))
+	if err := vs.rollbackVLANInterface(ctx, tx.nodeName, tx.vlanInterface, tx.bridge, tx.persistedPaths()); err != nil {
+		confirmErr = fmt.Errorf("%w, and removing the interface failed: %v", confirmErr, err)
+	} else {
+		vs.options.Logger.Warn(fmt.Sprintf("↩️  Removed unconfirmed VLAN interface %s from node %s", tx.vlanInterface, tx.nodeName))
+	}
+	return &TransactionError{Phase: PhaseConfirm, Interface: tx.vlanInterface, Err: confirmErr}
+}
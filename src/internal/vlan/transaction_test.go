@@ -0,0 +1,192 @@
+// Package vlan provides unit tests for the per-node VLAN transaction
+// WHY: A generated configuration the node rejects must never leave a half-applied interface behind
+package vlan
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"k8ostack-ictl/internal/config"
+	"k8ostack-ictl/internal/logging"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestVLANTransaction_Commands tests the node commands of each phase
+// WHY: The netplan file must be checked below a staging root and installed only by the commit
+func TestVLANTransaction_Commands(t *testing.T) {
+	// Given: A prepared transaction with persistent configuration
+	service := NewService(NewMockDryRunExecutor(), Options{PersistentConfig: true, Logger: logging.NewRecordingLogger()}).(*VLANService)
+	tx, err := service.prepareVLANTransaction(context.Background(), "node1", "management", config.VLANConfig{ID: 100}, "eth0.100", "eth0", "192.168.100.10/24")
+	require.NoError(t, err)
+
+	// When/Then: Verify stages and generates, commit creates, installs and lists the addresses
+	assert.Equal(t, "rm -rf /run/kictl/staged/eth0.100"+
+		" && mkdir -p /run/kictl/staged/eth0.100/etc/netplan"+
+		" && cp -a /etc/netplan/. /run/kictl/staged/eth0.100/etc/netplan"+
+		" && printf %s '"+tx.files[0].Content+"' > /run/kictl/staged/eth0.100/etc/netplan/60-kictl-eth0.100.yaml"+
+		" && netplan generate --root-dir /run/kictl/staged/eth0.100", tx.verifyCommand())
+	assert.Equal(t, "ip link add link eth0 name eth0.100 type vlan id 100"+
+		" && ip addr add 192.168.100.10/24 dev eth0.100"+
+		" && ip link set eth0.100 up"+
+		" && install -m 600 /run/kictl/staged/eth0.100/etc/netplan/60-kictl-eth0.100.yaml /etc/netplan/60-kictl-eth0.100.yaml"+
+		" && rm -rf /run/kictl/staged/eth0.100"+
+		" && ip -o addr show dev eth0.100", tx.commitCommand())
+	assert.True(t, tx.confirmed(addrOutput("eth0.100=192.168.100.10/24")))
+	assert.False(t, tx.confirmed(addrOutput("eth0.100=192.168.100.10/2")))
+}
+
+// TestVLANTransaction_DualStackCommands tests the commit and confirm of a dual-stack interface
+// WHY: IPv6 addresses need ip -6, and an interface missing either address is not configured
+func TestVLANTransaction_DualStackCommands(t *testing.T) {
+	// Given: A prepared transaction for an IPv4 and an IPv6 address
+	service := NewService(NewMockDryRunExecutor(), Options{Logger: logging.NewRecordingLogger()}).(*VLANService)
+	tx, err := service.prepareVLANTransaction(context.Background(), "node1", "management", config.VLANConfig{ID: 100}, "eth0.100", "eth0", "192.168.100.10/24,fd00:100::10/64")
+	require.NoError(t, err)
+
+	// When/Then: The commit adds each address with its family, and confirms only when both are listed
+	assert.Equal(t, "ip link add link eth0 name eth0.100 type vlan id 100"+
+		" && ip addr add 192.168.100.10/24 dev eth0.100"+
+		" && ip -6 addr add fd00:100::10/64 dev eth0.100"+
+		" && ip link set eth0.100 up"+
+		" && ip -o addr show dev eth0.100", tx.commitCommand())
+	assert.True(t, tx.confirmed(addrOutput("eth0.100=192.168.100.10/24", "eth0.100=fd00:100::10/64")))
+	assert.False(t, tx.confirmed(addrOutput("eth0.100=192.168.100.10/24")))
+	assert.Equal(t, []string{"fd00:100::10/64"}, tx.missingAddresses(addrOutput("eth0.100=192.168.100.10/24", "eth0.100=fe80::1/64")))
+}
+
+// TestVLANService_ConfigureVLANs_Transaction tests where each phase stops a failing node
+// WHY: Only a confirmed interface counts as configured and has its files copied, and a failure must name its phase
+func TestVLANService_ConfigureVLANs_Transaction(t *testing.T) {
+	isVerify := func(cmd string) bool { return strings.Contains(cmd, "netplan generate") }
+	isCommit := func(cmd string) bool { return strings.Contains(cmd, "ip link add") }
+	isTeardown := func(cmd string) bool { return strings.HasPrefix(cmd, "ip link delete") }
+
+	tests := []struct {
+		name           string
+		persistent     bool
+		parent         string
+		setupMocks     func(*MockDryRunExecutor)
+		expectedPhase  string
+		expectedError  string
+		expectCommit   bool
+		expectTeardown bool
+		expectArtifact bool
+	}{
+		{
+			name:       "confirmed_interface",
+			persistent: true,
+			setupMocks: func(m *MockDryRunExecutor) {
+				m.On("ExecNodeCommand", mock.Anything, "node1", mock.MatchedBy(isVerify)).Return(true, "", nil).Once()
+				m.On("ExecNodeCommand", mock.Anything, "node1", mock.MatchedBy(isCommit)).Return(true, addrOutput("eth0.100=192.168.100.10/24"), nil).Once()
+			},
+			expectCommit:   true,
+			expectArtifact: true,
+		},
+		{
+			name:       "rejected_netplan_never_commits",
+			persistent: true,
+			setupMocks: func(m *MockDryRunExecutor) {
+				m.On("ExecNodeCommand", mock.Anything, "node1", mock.MatchedBy(isVerify)).
+					Return(false, "Error in network definition: eth0.100: interface 'eth0' is not defined", nil).Once()
+			},
+			expectedPhase: PhaseVerify,
+			expectedError: "interface 'eth0' is not defined",
+		},
+		{
+			name: "commit_failure",
+			setupMocks: func(m *MockDryRunExecutor) {
+				m.On("ExecNodeCommand", mock.Anything, "node1", mock.MatchedBy(isCommit)).Return(false, "RTNETLINK answers: File exists", nil).Once()
+			},
+			expectedPhase: PhaseCommit,
+			expectedError: "RTNETLINK answers: File exists",
+			expectCommit:  true,
+		},
+		{
+			name: "unconfirmed_address_is_torn_down",
+			setupMocks: func(m *MockDryRunExecutor) {
+				m.On("ExecNodeCommand", mock.Anything, "node1", mock.MatchedBy(isCommit)).Return(true, addrOutput("eth0.100=192.168.100.99/24"), nil).Once()
+				m.On("ExecNodeCommand", mock.Anything, "node1", "ip link delete eth0.100").Return(true, "", nil).Once()
+			},
+			expectedPhase:  PhaseConfirm,
+			expectedError:  "address 192.168.100.10/24 not found on the interface",
+			expectCommit:   true,
+			expectTeardown: true,
+		},
+		{
+			name:          "long_interface_name_fails_prepare",
+			parent:        "enp129s0f0np0",
+			setupMocks:    func(m *MockDryRunExecutor) {},
+			expectedPhase: PhasePrepare,
+			expectedError: "interface name enp129s0f0np0.100 is longer than 15 characters",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: One VLAN on one node
+			mockKubectl := NewMockDryRunExecutor()
+			mockKubectl.On("SetDryRun", false).Return()
+			mockKubectl.On("ReleaseDebugPods", mock.Anything).Return(0, nil)
+			tt.setupMocks(mockKubectl)
+			parent := tt.parent
+			if parent == "" {
+				parent = "eth0"
+			}
+			artifactDir := t.TempDir()
+			service := NewService(mockKubectl, Options{
+				PersistentConfig: tt.persistent,
+				ArtifactDir:      artifactDir,
+				Logger:           logging.NewRecordingLogger(),
+			})
+			vlanConfig := &config.NodeVLANConf{
+				Kind:     "NodeVLANConf",
+				Metadata: config.Metadata{Name: "transaction"},
+				Spec: config.NodeVLANSpec{VLANs: map[string]config.VLANConfig{
+					"management": {ID: 100, Subnet: "192.168.100.0/24", Interface: parent,
+						NodeMapping: map[string]string{"node1": "192.168.100.10/24"}},
+				}},
+			}
+
+			// When: Configure VLANs
+			results, err := service.ConfigureVLANs(context.Background(), vlanConfig)
+
+			// Then: The node is configured only when confirmed, and failures name their phase
+			require.NoError(t, err)
+			if tt.expectedPhase == "" {
+				assert.Len(t, results.ConfiguredVLANs["node1"], 1)
+				assert.Empty(t, results.Errors)
+			} else {
+				assert.NotContains(t, results.ConfiguredVLANs, "node1")
+				assert.Equal(t, []string{"node1"}, results.FailedNodes)
+				require.Len(t, results.Errors, 1)
+				var txErr *TransactionError
+				require.True(t, errors.As(results.Errors[0], &txErr))
+				assert.Equal(t, tt.expectedPhase, txErr.Phase)
+				assert.ErrorContains(t, results.Errors[0], tt.expectedError)
+			}
+			if !tt.expectCommit {
+				mockKubectl.AssertNotCalled(t, "ExecNodeCommand", mock.Anything, "node1", mock.MatchedBy(isCommit))
+			}
+			if !tt.expectTeardown {
+				mockKubectl.AssertNotCalled(t, "ExecNodeCommand", mock.Anything, "node1", mock.MatchedBy(isTeardown))
+			}
+			mockKubectl.AssertExpectations(t)
+
+			// And: Only installed files are copied to the artifact folder of the node
+			saved, err := os.ReadFile(filepath.Join(artifactDir, "node1", "etc", "netplan", "60-kictl-eth0.100.yaml"))
+			if tt.expectArtifact {
+				require.NoError(t, err)
+				assert.Contains(t, string(saved), "eth0.100:")
+			} else {
+				assert.NoDirExists(t, filepath.Join(artifactDir, "node1"))
+			}
+		})
+	}
+}
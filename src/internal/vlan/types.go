@@ -3,10 +3,16 @@ package vlan
 
 import (
 	"context"
+	"sync"
 	"time"
 
+	"k8ostack-ictl/internal/bmc"
 	"k8ostack-ictl/internal/config"
 	"k8ostack-ictl/internal/kubectl"
+	"k8ostack-ictl/internal/logging"
+	"k8ostack-ictl/internal/plan"
+	"k8ostack-ictl/internal/progress"
+	"k8ostack-ictl/internal/throttle"
 )
 
 // OperationResults tracks the results of VLAN configuration operations
@@ -15,17 +21,33 @@ type OperationResults struct {
 	SuccessfulNodes int
 	FailedNodes     []string
 	ConfiguredVLANs map[string][]VLANInterfaceInfo // node -> VLAN interfaces configured
+	RolledBackVLANs map[string][]VLANInterfaceInfo // node -> VLAN interfaces torn down after a failed configure
+	Order           []string                       // nodes in the order the rollout changed them, VLAN by VLAN
 	Errors          []error
 }
 
 // VLANInterfaceInfo represents information about a configured VLAN interface
 type VLANInterfaceInfo struct {
-	VLANName      string // e.g., "management", "storage"
-	VLANId        int    // e.g., 100, 200
-	Interface     string // e.g., "eth0.100", "eth1.300"
-	IPAddress     string // e.g., "192.168.100.15/24"
-	PhysInterface string // e.g., "eth0", "eth1"
-	Subnet        string // e.g., "192.168.100.0/24"
+	VLANName       string   // e.g., "management", "storage"
+	VLANId         int      // e.g., 100, 200
+	Interface      string   // e.g., "eth0.100", "eth1.300"
+	IPAddress      string   // e.g., "192.168.100.15/24"
+	PhysInterface  string   // e.g., "eth0", "eth1", or the bridge of an OVS port
+	Bridge         string   // OVS bridge of the port, e.g., "br-ex"; empty for VLAN sub-interfaces
+	Subnet         string   // e.g., "192.168.100.0/24"
+	PersistedFiles []string // persistent configuration files installed with the interface
+}
+
+// merge adds the failed nodes, configured interfaces and errors of one node's results
+func (r *OperationResults) merge(node *OperationResults) {
+	r.FailedNodes = append(r.FailedNodes, node.FailedNodes...)
+	for nodeName, interfaces := range node.ConfiguredVLANs {
+		if existing, ok := r.ConfiguredVLANs[nodeName]; ok {
+			interfaces = append(existing, interfaces...)
+		}
+		r.ConfiguredVLANs[nodeName] = interfaces
+	}
+	r.Errors = append(r.Errors, node.Errors...)
 }
 
 // Service defines the interface for the VLAN configuration service
@@ -39,8 +61,14 @@ type Service interface {
 	// VerifyVLANs checks if VLANs are configured correctly
 	VerifyVLANs(ctx context.Context, config *config.NodeVLANConf) (*OperationResults, error)
 
+	// PlanVLANs diffs the VLAN interfaces on each node against the configuration
+	PlanVLANs(ctx context.Context, config *config.NodeVLANConf) (*plan.Plan, error)
+
 	// GetCurrentState discovers the current VLAN configuration state
 	GetCurrentState(ctx context.Context, nodes []string) (map[string][]VLANInterfaceInfo, error)
+
+	// Cleanup removes the debug pods left behind by an interrupted operation
+	Cleanup(ctx context.Context)
 }
 
 // Options contains configuration options for the VLAN service
@@ -49,22 +77,69 @@ type Options struct {
 	Verbose              bool
 	ValidateConnectivity bool
 	PersistentConfig     bool
+	PersistenceBackend   string                    // config.PersistenceBackend* writing the persistent configuration; empty is netplan
+	NetplanTry           time.Duration             // Revert timeout of netplan try applying netplan files; 0 applies with ip
+	Templates            *Templates                // Render the persistent configuration files; nil uses the built-in templates
+	ArtifactDir          string                    // Local copies of the installed persistent configuration files go here, per node; empty saves none
+	RollbackOnFailure    bool                      // Tear down the interfaces created by a configure run when any node fails
+	RollbackAbortedBatch bool                      // Tear down the interfaces created on the batch whose soak aborted the rollout
+	ReachabilityGuard    *config.ReachabilityGuard // Refuse changes to the interface kictl reaches a node through without another way in
+	Force                bool                      // Make changes the reachability guard refuses, with a warning
+	Recovery             *bmc.Controller           // Last resort for nodes lost during a change; nil leaves them as they are
+	MembershipLabels     bool                      // Label nodes with their VLANs, see config.VLANMembershipLabel
 	DefaultInterface     string
-	Logger               kubectl.Logger
-	CleanupDelay         time.Duration // For testing - can be set to 0 to skip sleep
+	InterfaceDetection   string                  // config.InterfaceDetection* strategy for VLANs without an interface, overriding DefaultInterface
+	InterfaceAliases     config.InterfaceAliases // Logical interface names resolved per node
+	Workers              *throttle.Controller    // Processes the nodes of a VLAN concurrently; nil processes them one by one
+	Progress             progress.Reporter       // Receives how many node-VLAN assignments are done; nil reports nothing
+	Logger               logging.Logger
+	LocalCommand         func(ctx context.Context, command string) (string, error) // Runs reachability guard commands; nil runs them with sh -c
 }
 
 // VLANService implements the Service interface
 type VLANService struct {
-	kubectl kubectl.DryRunExecutor
-	options Options
+	kubectl  kubectl.DryRunExecutor
+	options  Options
+	detected *nodeCache             // node -> detected parent interface, shared by the per-node copies of the service
+	backends *nodeCache             // node -> detected persistence backend
+	guard    *reachabilityGuard     // nil without a reachability guard
+	nodes    *kubectl.NodeNameCache // cluster node names for "did you mean" suggestions
+}
+
+// nodeCache caches a value detected per node while nodes are processed concurrently
+type nodeCache struct {
+	mu     sync.Mutex
+	byNode map[string]string
+}
+
+// newNodeCache creates an empty per-node cache
+func newNodeCache() *nodeCache {
+	return &nodeCache{byNode: make(map[string]string)}
+}
+
+// get returns the cached value of a node
+func (c *nodeCache) get(nodeName string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	value, ok := c.byNode[nodeName]
+	return value, ok
+}
+
+// set caches the value of a node
+func (c *nodeCache) set(nodeName, value string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.byNode[nodeName] = value
 }
 
 // NewService creates a new VLAN configuration service
-func NewService(kubectl kubectl.DryRunExecutor, options Options) Service {
+func NewService(executor kubectl.DryRunExecutor, options Options) Service {
 	return &VLANService{
-		kubectl: kubectl,
-		options: options,
+		kubectl:  executor,
+		options:  options,
+		detected: newNodeCache(),
+		backends: newNodeCache(),
+		nodes:    kubectl.NewNodeNameCache(executor),
 	}
 }
 
@@ -0,0 +1,142 @@
+package workspace
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Retention limits how many run folders are kept; a zero limit is not enforced
+type Retention struct {
+	MaxAge  time.Duration
+	MaxRuns int
+	MaxSize int64
+}
+
+// RunInfo describes a run folder found in the workspace
+type RunInfo struct {
+	ID      string
+	Dir     string
+	ModTime time.Time
+	Size    int64
+}
+
+// PruneResult lists the run folders removed by a prune
+type PruneResult struct {
+	Removed []RunInfo
+	Freed   int64
+}
+
+// Runs returns the run folders of the workspace, oldest first
+func (w *Workspace) Runs() ([]RunInfo, error) {
+	entries, err := os.ReadDir(w.Path(RunsDir))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read runs directory: %w", err)
+	}
+
+	var runs []RunInfo
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, fmt.Errorf("failed to inspect run %s: %w", entry.Name(), err)
+		}
+		dir := filepath.Join(w.Path(RunsDir), entry.Name())
+		size, err := dirSize(dir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to inspect run %s: %w", entry.Name(), err)
+		}
+		runs = append(runs, RunInfo{ID: entry.Name(), Dir: dir, ModTime: info.ModTime(), Size: size})
+	}
+
+	// Run IDs start with their start time, so the name breaks ties between equal modification times
+	sort.Slice(runs, func(i, j int) bool {
+		if !runs[i].ModTime.Equal(runs[j].ModTime) {
+			return runs[i].ModTime.Before(runs[j].ModTime)
+		}
+		return runs[i].ID < runs[j].ID
+	})
+	return runs, nil
+}
+
+// Expired returns the runs that exceed the retention limits, oldest first
+// Runs are given oldest first; runs whose ID is in keep are never returned and do not count against the limits.
+func Expired(runs []RunInfo, retention Retention, now time.Time, keep ...string) []RunInfo {
+	kept := make(map[string]bool, len(keep))
+	for _, id := range keep {
+		kept[id] = true
+	}
+
+	var candidates []RunInfo
+	var totalSize int64
+	for _, run := range runs {
+		if kept[run.ID] {
+			continue
+		}
+		candidates = append(candidates, run)
+		totalSize += run.Size
+	}
+
+	var expired []RunInfo
+	for i, run := range candidates {
+		remaining := len(candidates) - i
+		tooOld := retention.MaxAge > 0 && now.Sub(run.ModTime) > retention.MaxAge
+		tooMany := retention.MaxRuns > 0 && remaining > retention.MaxRuns
+		tooBig := retention.MaxSize > 0 && totalSize > retention.MaxSize
+		if !tooOld && !tooMany && !tooBig {
+			break
+		}
+		expired = append(expired, run)
+		totalSize -= run.Size
+	}
+	return expired
+}
+
+// Prune removes the run folders that exceed the retention limits, never touching the runs in keep
+func (w *Workspace) Prune(retention Retention, now time.Time, keep ...string) (PruneResult, error) {
+	runs, err := w.Runs()
+	if err != nil {
+		return PruneResult{}, err
+	}
+	return w.Remove(Expired(runs, retention, now, keep...))
+}
+
+// Remove deletes the given run folders
+func (w *Workspace) Remove(runs []RunInfo) (PruneResult, error) {
+	var result PruneResult
+	for _, run := range runs {
+		if err := os.RemoveAll(run.Dir); err != nil {
+			return result, fmt.Errorf("failed to remove run %s: %w", run.ID, err)
+		}
+		result.Removed = append(result.Removed, run)
+		result.Freed += run.Size
+	}
+	return result, nil
+}
+
+// dirSize returns the total size of the regular files below dir
+func dirSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.Type().IsRegular() {
+			info, err := entry.Info()
+			if err != nil {
+				return err
+			}
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
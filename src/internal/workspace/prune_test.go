@@ -0,0 +1,126 @@
+// Package workspace provides unit tests for run folder retention
+// WHY: Long-lived bastion hosts must not fill up with logs, but pruning must never eat the wrong runs
+package workspace
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestExpired tests which runs exceed the retention limits
+// WHY: Only the oldest runs may go, and only as many as needed to get back within every limit
+func TestExpired(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	runs := []RunInfo{
+		{ID: "run-1", ModTime: now.Add(-72 * time.Hour), Size: 300},
+		{ID: "run-2", ModTime: now.Add(-48 * time.Hour), Size: 300},
+		{ID: "run-3", ModTime: now.Add(-24 * time.Hour), Size: 300},
+		{ID: "run-4", ModTime: now.Add(-time.Hour), Size: 300},
+	}
+
+	tests := []struct {
+		name      string
+		retention Retention
+		keep      []string
+		expected  []string
+	}{
+		{
+			name:     "no limits keeps everything",
+			expected: nil,
+		},
+		{
+			name:      "max age removes old runs",
+			retention: Retention{MaxAge: 36 * time.Hour},
+			expected:  []string{"run-1", "run-2"},
+		},
+		{
+			name:      "max runs keeps the newest",
+			retention: Retention{MaxRuns: 3},
+			expected:  []string{"run-1"},
+		},
+		{
+			name:      "max size removes oldest until under the limit",
+			retention: Retention{MaxSize: 700},
+			expected:  []string{"run-1", "run-2"},
+		},
+		{
+			name:      "strictest limit wins",
+			retention: Retention{MaxAge: 60 * time.Hour, MaxRuns: 2, MaxSize: 10000},
+			expected:  []string{"run-1", "run-2"},
+		},
+		{
+			name:      "kept runs are neither removed nor counted",
+			retention: Retention{MaxRuns: 2},
+			keep:      []string{"run-1"},
+			expected:  []string{"run-2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// When: Selecting expired runs
+			expired := Expired(runs, tt.retention, now, tt.keep...)
+
+			// Then: Expected runs are selected, oldest first
+			var ids []string
+			for _, run := range expired {
+				ids = append(ids, run.ID)
+			}
+			assert.Equal(t, tt.expected, ids)
+		})
+	}
+}
+
+// TestPrune tests that pruning removes run folders from disk
+// WHY: The run started by the current command must survive even when it is over the limit itself
+func TestPrune(t *testing.T) {
+	// Given: Workspace with three runs of different ages
+	ws, err := Open(t.TempDir())
+	require.NoError(t, err)
+	now := time.Now()
+
+	var runs []*Run
+	for i := 0; i < 3; i++ {
+		run, err := ws.NewRun("apply", now)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(run.Path("run.log"), []byte(strings.Repeat("x", 100)), 0644))
+		modTime := now.Add(time.Duration(i-3) * time.Hour)
+		require.NoError(t, os.Chtimes(run.Dir, modTime, modTime))
+		runs = append(runs, run)
+	}
+
+	listed, err := ws.Runs()
+	require.NoError(t, err)
+	require.Len(t, listed, 3)
+	assert.Equal(t, runs[0].ID, listed[0].ID, "runs should be listed oldest first")
+	assert.Equal(t, int64(100), listed[0].Size)
+
+	// When: Pruning to one run while keeping the oldest
+	result, err := ws.Prune(Retention{MaxRuns: 1}, now, runs[0].ID)
+
+	// Then: Only the middle run is removed
+	require.NoError(t, err)
+	require.Len(t, result.Removed, 1)
+	assert.Equal(t, runs[1].ID, result.Removed[0].ID)
+	assert.Equal(t, int64(100), result.Freed)
+	assert.DirExists(t, runs[0].Dir)
+	assert.NoDirExists(t, runs[1].Dir)
+	assert.DirExists(t, runs[2].Dir)
+}
+
+// TestRuns_EmptyWorkspace tests listing a workspace that has never run anything
+// WHY: Startup pruning happens on fresh machines too
+func TestRuns_EmptyWorkspace(t *testing.T) {
+	ws, err := Open(filepath.Join(t.TempDir(), "fresh"))
+	require.NoError(t, err)
+
+	runs, err := ws.Runs()
+	assert.NoError(t, err)
+	assert.Empty(t, runs)
+}
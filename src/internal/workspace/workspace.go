@@ -0,0 +1,100 @@
+// Package workspace manages the directory that holds every on-disk kictl artifact
+package workspace
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// EnvHome overrides the default workspace location
+const EnvHome = "KICTL_HOME"
+
+// defaultDirName is the workspace directory created in the user's home directory
+const defaultDirName = ".kictl"
+
+// Workspace subdirectories shared by all runs
+const (
+	RunsDir       = "runs"
+	HistoryDir    = "history"
+	BackupsDir    = "backups"
+	CassettesDir  = "cassettes"
+	CMDBQueueDir  = "cmdb-queue"
+	QuarantineDir = "quarantine"
+)
+
+// Files written into a run directory
+const (
+	ResultsFile    = "results.json"
+	CheckpointFile = "checkpoint.json"
+	PlanFile       = "plan.txt"
+)
+
+// PersistentConfigDir is the run folder subdirectory holding a copy of the persistent network configuration
+// files installed on each node, e.g. persistent-config/rsb2/etc/netplan/60-kictl-eth0.100.yaml
+const PersistentConfigDir = "persistent-config"
+
+// Workspace is the root directory for logs, results, checkpoints, plans, backups and cassettes
+type Workspace struct {
+	Root string
+}
+
+// Run is the per-run subfolder of a workspace
+type Run struct {
+	ID  string
+	Dir string
+}
+
+// DefaultRoot returns $KICTL_HOME, or ~/.kictl when it is unset
+func DefaultRoot() (string, error) {
+	if home := os.Getenv(EnvHome); home != "" {
+		return home, nil
+	}
+	userHome, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to determine workspace: set %s or --workspace: %w", EnvHome, err)
+	}
+	return filepath.Join(userHome, defaultDirName), nil
+}
+
+// Open returns the workspace at root, or at DefaultRoot when root is empty, creating it if needed
+func Open(root string) (*Workspace, error) {
+	if root == "" {
+		var err error
+		if root, err = DefaultRoot(); err != nil {
+			return nil, err
+		}
+	}
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create workspace %s: %w", root, err)
+	}
+	return &Workspace{Root: root}, nil
+}
+
+// Path returns the location of a workspace subdirectory such as HistoryDir
+func (w *Workspace) Path(subdir string) string {
+	return filepath.Join(w.Root, subdir)
+}
+
+// NewRun creates a run folder named after the start time and operation, e.g. runs/20240501-101500-apply-123
+func (w *Workspace) NewRun(operation string, now time.Time) (*Run, error) {
+	runsDir := w.Path(RunsDir)
+	if err := os.MkdirAll(runsDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create runs directory: %w", err)
+	}
+
+	dir, err := os.MkdirTemp(runsDir, fmt.Sprintf("%s-%s-", now.Format("20060102-150405"), operation))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create run directory: %w", err)
+	}
+	if err := os.Chmod(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create run directory: %w", err)
+	}
+	return &Run{ID: filepath.Base(dir), Dir: dir}, nil
+}
+
+// Path returns the location of a file inside the run folder such as ResultsFile
+func (r *Run) Path(file string) string {
+	return filepath.Join(r.Dir, file)
+}
@@ -0,0 +1,99 @@
+// Package workspace provides unit tests for the on-disk workspace layout
+// WHY: Every artifact kictl writes must land under one predictable, configurable root
+package workspace
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDefaultRoot tests how the workspace location is chosen
+// WHY: $KICTL_HOME must win over the home directory so CI and shared hosts can relocate state
+func TestDefaultRoot(t *testing.T) {
+	tests := []struct {
+		name     string
+		kictlEnv string
+		home     string
+		expected string
+	}{
+		{
+			name:     "KICTL_HOME is used when set",
+			kictlEnv: "/srv/kictl",
+			home:     "/home/operator",
+			expected: "/srv/kictl",
+		},
+		{
+			name:     "falls back to .kictl in the home directory",
+			home:     "/home/operator",
+			expected: filepath.Join("/home/operator", ".kictl"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: Environment for the case
+			t.Setenv(EnvHome, tt.kictlEnv)
+			t.Setenv("HOME", tt.home)
+
+			// When: Resolving the default root
+			root, err := DefaultRoot()
+
+			// Then: Expected location is returned
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, root)
+		})
+	}
+}
+
+// TestOpen tests that opening a workspace creates its root
+// WHY: The first run on a machine must not fail because the workspace does not exist yet
+func TestOpen(t *testing.T) {
+	// Given: Explicit root that does not exist and a KICTL_HOME that does not either
+	root := filepath.Join(t.TempDir(), "explicit")
+	envRoot := filepath.Join(t.TempDir(), "env")
+	t.Setenv(EnvHome, envRoot)
+
+	// When: Opening with and without an explicit root
+	explicit, err := Open(root)
+	require.NoError(t, err)
+	fromEnv, err := Open("")
+	require.NoError(t, err)
+
+	// Then: Both roots are created and subdirectories resolve beneath them
+	assert.DirExists(t, root)
+	assert.DirExists(t, envRoot)
+	assert.Equal(t, envRoot, fromEnv.Root)
+	assert.Equal(t, filepath.Join(root, HistoryDir), explicit.Path(HistoryDir))
+}
+
+// TestNewRun tests per-run folder creation
+// WHY: Runs started in the same second must never share a folder or overwrite each other's logs
+func TestNewRun(t *testing.T) {
+	// Given: Workspace and a fixed start time
+	ws, err := Open(t.TempDir())
+	require.NoError(t, err)
+	now := time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC)
+
+	// When: Two runs start at the same time
+	first, err := ws.NewRun("apply", now)
+	require.NoError(t, err)
+	second, err := ws.NewRun("apply", now)
+	require.NoError(t, err)
+
+	// Then: Each gets its own sortable folder under runs/
+	assert.NotEqual(t, first.Dir, second.Dir)
+	assert.DirExists(t, first.Dir)
+	assert.Equal(t, ws.Path(RunsDir), filepath.Dir(first.Dir))
+	assert.True(t, strings.HasPrefix(first.ID, "20261016-093000-apply-"), "unexpected run ID %s", first.ID)
+	assert.Equal(t, filepath.Join(first.Dir, PlanFile), first.Path(PlanFile))
+
+	info, err := os.Stat(first.Dir)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0755), info.Mode().Perm())
+}